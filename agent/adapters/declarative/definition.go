@@ -25,6 +25,9 @@ type AgentDefinition struct {
 	Tools           []string         `yaml:"tools,omitempty" json:"tools,omitempty"`
 	ToolDefinitions []ToolDefinition `yaml:"tool_definitions,omitempty" json:"tool_definitions,omitempty"`
 
+	// Installable skills (prompt fragments, examples, memory snippets, required tools)
+	Skills []SkillDefinition `yaml:"skills,omitempty" json:"skills,omitempty"`
+
 	// Memory configuration
 	Memory *MemoryConfig `yaml:"memory,omitempty" json:"memory,omitempty"`
 
@@ -56,6 +59,30 @@ type ToolDefinition struct {
 	Description string `yaml:"description,omitempty" json:"description,omitempty"`
 }
 
+// SkillDefinition is the declarative form of an installable skill manifest.
+// It can be embedded in an AgentDefinition or loaded from its own file.
+type SkillDefinition struct {
+	ID                   string                   `yaml:"id" json:"id"`
+	Name                 string                   `yaml:"name" json:"name"`
+	Version              string                   `yaml:"version,omitempty" json:"version,omitempty"`
+	Description          string                   `yaml:"description,omitempty" json:"description,omitempty"`
+	Category             string                   `yaml:"category,omitempty" json:"category,omitempty"`
+	Tags                 []string                 `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Instructions         string                   `yaml:"instructions" json:"instructions"`
+	Tools                []string                 `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Examples             []SkillExampleDefinition `yaml:"examples,omitempty" json:"examples,omitempty"`
+	MemorySnippets       []string                 `yaml:"memory_snippets,omitempty" json:"memory_snippets,omitempty"`
+	RequiredCapabilities []string                 `yaml:"required_capabilities,omitempty" json:"required_capabilities,omitempty"`
+	Dependencies         []string                 `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+}
+
+// SkillExampleDefinition is a few-shot example attached to a skill.
+type SkillExampleDefinition struct {
+	Input       string `yaml:"input" json:"input"`
+	Output      string `yaml:"output" json:"output"`
+	Explanation string `yaml:"explanation,omitempty" json:"explanation,omitempty"`
+}
+
 // MemoryConfig configures the Agent's memory subsystem.
 type MemoryConfig struct {
 	Type     string `yaml:"type" json:"type"`         // "short_term", "long_term", "both"
@@ -3,6 +3,7 @@ package declarative
 import (
 	"fmt"
 
+	skills "github.com/BaSui01/agentflow/agent/capabilities/tools"
 	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
@@ -43,9 +44,63 @@ func (f *AgentFactory) Validate(def *AgentDefinition) error {
 	if def.Features.MaxReActIterations < 0 {
 		return fmt.Errorf("agent definition: max_react_iterations must be non-negative, got %d", def.Features.MaxReActIterations)
 	}
+	seen := make(map[string]struct{}, len(def.Skills))
+	for i := range def.Skills {
+		if _, err := f.ToSkill(&def.Skills[i]); err != nil {
+			return fmt.Errorf("agent definition: skills[%d]: %w", i, err)
+		}
+		if _, dup := seen[def.Skills[i].ID]; dup {
+			return fmt.Errorf("agent definition: skills[%d]: duplicate skill id %q", i, def.Skills[i].ID)
+		}
+		seen[def.Skills[i].ID] = struct{}{}
+	}
 	return nil
 }
 
+// ToSkill converts a SkillDefinition into a validated skill manifest that can
+// be packaged and installed on an agent.
+func (f *AgentFactory) ToSkill(def *SkillDefinition) (*skills.Skill, error) {
+	if def == nil {
+		return nil, fmt.Errorf("skill definition is nil")
+	}
+	builder := skills.NewSkillBuilder(def.ID, def.Name).
+		WithDescription(def.Description).
+		WithCategory(def.Category).
+		WithTags(def.Tags...).
+		WithInstructions(def.Instructions).
+		WithTools(def.Tools...).
+		WithDependencies(def.Dependencies...).
+		WithMemorySnippets(def.MemorySnippets...).
+		WithRequiredCapabilities(def.RequiredCapabilities...)
+	for _, ex := range def.Examples {
+		builder = builder.WithExample(ex.Input, ex.Output, ex.Explanation)
+	}
+	skill, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("skill %q: %w", def.ID, err)
+	}
+	if def.Version != "" {
+		skill.Version = def.Version
+	}
+	return skill, nil
+}
+
+// ToSkills converts every skill embedded in an AgentDefinition.
+func (f *AgentFactory) ToSkills(def *AgentDefinition) ([]*skills.Skill, error) {
+	if def == nil {
+		return nil, fmt.Errorf("agent definition is nil")
+	}
+	out := make([]*skills.Skill, 0, len(def.Skills))
+	for i := range def.Skills {
+		skill, err := f.ToSkill(&def.Skills[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, skill)
+	}
+	return out, nil
+}
+
 // ToAgentConfig converts an AgentDefinition into a strongly-typed runtime config.
 func (f *AgentFactory) ToAgentConfig(def *AgentDefinition) types.AgentConfig {
 	cfg := types.AgentConfig{
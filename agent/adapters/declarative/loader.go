@@ -47,21 +47,51 @@ func (l *YAMLLoader) LoadFile(path string) (*AgentDefinition, error) {
 // LoadBytes parses raw bytes in the given format ("yaml" or "json").
 func (l *YAMLLoader) LoadBytes(data []byte, format string) (*AgentDefinition, error) {
 	var def AgentDefinition
+	if err := decode(data, format, &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// LoadSkillFile reads a standalone skill definition file.
+// Format is auto-detected from the file extension (.yaml, .yml, .json).
+func (l *YAMLLoader) LoadSkillFile(path string) (*SkillDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read skill definition file: %w", err)
+	}
+
+	format := detectFormat(path)
+	if format == "" {
+		return nil, fmt.Errorf("unsupported file extension: %s", filepath.Ext(path))
+	}
+
+	return l.LoadSkillBytes(data, format)
+}
+
+// LoadSkillBytes parses a standalone skill definition in the given format.
+func (l *YAMLLoader) LoadSkillBytes(data []byte, format string) (*SkillDefinition, error) {
+	var def SkillDefinition
+	if err := decode(data, format, &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
 
+func decode(data []byte, format string, out any) error {
 	switch strings.ToLower(format) {
 	case "yaml", "yml":
-		if err := yaml.Unmarshal(data, &def); err != nil {
-			return nil, fmt.Errorf("parse YAML: %w", err)
+		if err := yaml.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("parse YAML: %w", err)
 		}
 	case "json":
-		if err := json.Unmarshal(data, &def); err != nil {
-			return nil, fmt.Errorf("parse JSON: %w", err)
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("parse JSON: %w", err)
 		}
 	default:
-		return nil, fmt.Errorf("unsupported format %q, use \"yaml\" or \"json\"", format)
+		return fmt.Errorf("unsupported format %q, use \"yaml\" or \"json\"", format)
 	}
-
-	return &def, nil
+	return nil
 }
 
 // detectFormat returns "yaml" or "json" based on file extension, or "" if unknown.
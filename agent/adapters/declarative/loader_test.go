@@ -662,6 +662,71 @@ metadata:
 	assert.Equal(t, "platform", cfg.Metadata["team"])
 }
 
+func TestYAMLLoader_LoadSkillFile(t *testing.T) {
+	content := `
+id: billing
+name: Billing
+version: "2.1.0"
+instructions: Answer billing questions using the invoice tools.
+tools: [invoice_lookup]
+examples:
+  - input: Where is my invoice?
+    output: It was emailed on the 1st.
+    explanation: invoices go out monthly
+memory_snippets:
+  - Customers are on the EU plan.
+required_capabilities: [tools]
+dependencies: [accounts]
+`
+	path := writeTemp(t, "billing.yaml", content)
+	def, err := NewYAMLLoader().LoadSkillFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"invoice_lookup"}, def.Tools)
+	require.Len(t, def.Examples, 1)
+	assert.Equal(t, "invoices go out monthly", def.Examples[0].Explanation)
+
+	skill, err := NewAgentFactory(nil).ToSkill(def)
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", skill.Version)
+	assert.Equal(t, []string{"Customers are on the EU plan."}, skill.MemorySnippets)
+	assert.Equal(t, []string{"tools"}, skill.RequiredCapabilities)
+	assert.Equal(t, []string{"accounts"}, skill.Dependencies)
+	assert.Contains(t, skill.PromptFragment(), "Output: It was emailed on the 1st.")
+
+	_, err = NewYAMLLoader().LoadSkillBytes([]byte(content), "toml")
+	assert.Error(t, err)
+}
+
+func TestAgentFactory_SkillsInAgentDefinition(t *testing.T) {
+	content := `
+name: support
+model: gpt-4
+skills:
+  - id: billing
+    name: Billing
+    instructions: Answer billing questions.
+  - id: refunds
+    name: Refunds
+    instructions: Handle refunds.
+    dependencies: [billing]
+`
+	def, err := NewYAMLLoader().LoadBytes([]byte(content), "yaml")
+	require.NoError(t, err)
+	factory := NewAgentFactory(nil)
+	require.NoError(t, factory.Validate(def))
+
+	skills, err := factory.ToSkills(def)
+	require.NoError(t, err)
+	require.Len(t, skills, 2)
+	assert.Equal(t, "1.0.0", skills[0].Version)
+	assert.Equal(t, []string{"billing"}, skills[1].Dependencies)
+
+	def.Skills[1].ID = "billing"
+	assert.ErrorContains(t, factory.Validate(def), `duplicate skill id "billing"`)
+	def.Skills[1].Instructions = ""
+	assert.ErrorContains(t, factory.Validate(def), "skills[1]")
+}
+
 // ============================================================
 // Helper
 // ============================================================
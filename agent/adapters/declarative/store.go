@@ -0,0 +1,148 @@
+package declarative
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDefinitionNotFound is returned when no stored definition matches a lookup.
+var ErrDefinitionNotFound = errors.New("agent definition not found")
+
+// AgentDefinitionRecord stores one version of a declarative agent definition.
+// Every create or update appends a new version; exactly one version per agent
+// is active and deployed at a time.
+type AgentDefinitionRecord struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	AgentID    string          `gorm:"size:120;not null;uniqueIndex:idx_agent_definition_version,priority:1" json:"agent_id"`
+	Version    int             `gorm:"not null;uniqueIndex:idx_agent_definition_version,priority:2" json:"version"`
+	Format     string          `gorm:"size:8;not null" json:"format"`
+	Source     string          `gorm:"type:text;not null" json:"source"`
+	Definition json.RawMessage `gorm:"type:json" json:"definition"`
+	Active     bool            `gorm:"not null;default:false;index" json:"active"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func (AgentDefinitionRecord) TableName() string {
+	return "sc_agent_definitions"
+}
+
+// Decode returns the parsed AgentDefinition stored in the record.
+func (r AgentDefinitionRecord) Decode() (*AgentDefinition, error) {
+	var def AgentDefinition
+	if err := json.Unmarshal(r.Definition, &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// DefinitionStore defines DB access for versioned agent definitions.
+type DefinitionStore interface {
+	ListActive() ([]AgentDefinitionRecord, error)
+	GetActive(agentID string) (AgentDefinitionRecord, error)
+	GetVersion(agentID string, version int) (AgentDefinitionRecord, error)
+	ListVersions(agentID string) ([]AgentDefinitionRecord, error)
+	LatestVersion(agentID string) (int, error)
+	Create(row *AgentDefinitionRecord) error
+	Deactivate(agentID string) error
+	Activate(agentID string, version int) error
+	DeleteVersion(agentID string, version int) error
+	Delete(agentID string) (int64, error)
+	WithTransaction(ctx context.Context, fn func(DefinitionStore) error) error
+}
+
+// GormDefinitionStore implements DefinitionStore on top of gorm.
+type GormDefinitionStore struct {
+	db *gorm.DB
+}
+
+// NewGormDefinitionStore creates a GORM-backed agent definition store.
+func NewGormDefinitionStore(db *gorm.DB) *GormDefinitionStore {
+	return &GormDefinitionStore{db: db}
+}
+
+func (s *GormDefinitionStore) ListActive() ([]AgentDefinitionRecord, error) {
+	var rows []AgentDefinitionRecord
+	err := s.db.Where("active = ?", true).Order("agent_id ASC").Limit(500).Find(&rows).Error
+	return rows, err
+}
+
+func (s *GormDefinitionStore) GetActive(agentID string) (AgentDefinitionRecord, error) {
+	var row AgentDefinitionRecord
+	err := s.db.Where("agent_id = ? AND active = ?", agentID, true).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, ErrDefinitionNotFound
+	}
+	return row, err
+}
+
+func (s *GormDefinitionStore) GetVersion(agentID string, version int) (AgentDefinitionRecord, error) {
+	var row AgentDefinitionRecord
+	err := s.db.Where("agent_id = ? AND version = ?", agentID, version).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, ErrDefinitionNotFound
+	}
+	return row, err
+}
+
+func (s *GormDefinitionStore) ListVersions(agentID string) ([]AgentDefinitionRecord, error) {
+	var rows []AgentDefinitionRecord
+	err := s.db.Where("agent_id = ?", agentID).Order("version DESC").Limit(500).Find(&rows).Error
+	return rows, err
+}
+
+// LatestVersion returns the highest stored version for agentID, or 0 when the
+// agent has no definitions.
+func (s *GormDefinitionStore) LatestVersion(agentID string) (int, error) {
+	var latest *int
+	err := s.db.Model(&AgentDefinitionRecord{}).
+		Where("agent_id = ?", agentID).
+		Select("MAX(version)").
+		Scan(&latest).Error
+	if err != nil || latest == nil {
+		return 0, err
+	}
+	return *latest, nil
+}
+
+func (s *GormDefinitionStore) Create(row *AgentDefinitionRecord) error {
+	return s.db.Create(row).Error
+}
+
+func (s *GormDefinitionStore) Deactivate(agentID string) error {
+	return s.db.Model(&AgentDefinitionRecord{}).
+		Where("agent_id = ? AND active = ?", agentID, true).
+		Update("active", false).Error
+}
+
+// Activate marks one stored version of agentID as active. Callers deactivate
+// the current version first to keep a single active version per agent.
+func (s *GormDefinitionStore) Activate(agentID string, version int) error {
+	result := s.db.Model(&AgentDefinitionRecord{}).
+		Where("agent_id = ? AND version = ?", agentID, version).
+		Update("active", true)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrDefinitionNotFound
+	}
+	return result.Error
+}
+
+// DeleteVersion removes a single version of agentID.
+func (s *GormDefinitionStore) DeleteVersion(agentID string, version int) error {
+	return s.db.Where("agent_id = ? AND version = ?", agentID, version).Delete(&AgentDefinitionRecord{}).Error
+}
+
+func (s *GormDefinitionStore) Delete(agentID string) (int64, error) {
+	result := s.db.Where("agent_id = ?", agentID).Delete(&AgentDefinitionRecord{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *GormDefinitionStore) WithTransaction(ctx context.Context, fn func(DefinitionStore) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormDefinitionStore(tx))
+	})
+}
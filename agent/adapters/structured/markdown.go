@@ -0,0 +1,241 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+)
+
+// MarkdownSection Markdown 文档中的一个章节（标题及其下的正文）。
+type MarkdownSection struct {
+	Heading string `json:"heading"`
+	Level   int    `json:"level"`
+	Content string `json:"content"`
+}
+
+// MarkdownDocument 解析后的 Markdown 文档。
+type MarkdownDocument struct {
+	// Title 文档首个一级标题
+	Title string `json:"title,omitempty"`
+	// Preamble 首个标题之前的正文
+	Preamble string            `json:"preamble,omitempty"`
+	Sections []MarkdownSection `json:"sections"`
+}
+
+// Section 按标题（忽略大小写）查找章节。
+func (d *MarkdownDocument) Section(heading string) (*MarkdownSection, bool) {
+	key := normalizeHeading(heading)
+	for i := range d.Sections {
+		if normalizeHeading(d.Sections[i].Heading) == key {
+			return &d.Sections[i], true
+		}
+	}
+	return nil, false
+}
+
+// MarkdownSectionSpec 章节约束。
+type MarkdownSectionSpec struct {
+	Heading string `json:"heading"`
+	// Level 标题级别（1-6），0 表示不限制
+	Level    int  `json:"level,omitempty"`
+	Required bool `json:"required,omitempty"`
+	// MinLength 正文最少字符数，0 表示不限制
+	MinLength int `json:"min_length,omitempty"`
+	// Description 写入提示词的章节说明
+	Description string `json:"description,omitempty"`
+}
+
+// MarkdownSchema 受约束 Markdown 输出的章节 schema。
+type MarkdownSchema struct {
+	Sections []MarkdownSectionSpec `json:"sections"`
+	// AllowExtraSections 允许出现 schema 之外的章节
+	AllowExtraSections bool `json:"allow_extra_sections,omitempty"`
+	// EnforceOrder 章节必须按 schema 声明顺序出现
+	EnforceOrder bool `json:"enforce_order,omitempty"`
+}
+
+// NewMarkdownOutput 创建受约束的 Markdown 结构化输出处理器。
+// 缺失的必需章节、级别不符、正文过短等错误的路径形如 "sections.Summary"。
+func NewMarkdownOutput(gateway llmcore.Gateway, schema *MarkdownSchema) (*StructuredOutput[MarkdownDocument], error) {
+	if schema == nil || len(schema.Sections) == 0 {
+		return nil, fmt.Errorf("markdown schema must define at least one section")
+	}
+	for i, spec := range schema.Sections {
+		if strings.TrimSpace(spec.Heading) == "" {
+			return nil, fmt.Errorf("markdown section %d has empty heading", i)
+		}
+		if spec.Level < 0 || spec.Level > 6 {
+			return nil, fmt.Errorf("markdown section %q has invalid level %d", spec.Heading, spec.Level)
+		}
+	}
+	so, err := NewStructuredOutput[MarkdownDocument](gateway)
+	if err != nil {
+		return nil, err
+	}
+	so.codec = &markdownCodec{schema: schema}
+	return so, nil
+}
+
+// ParseMarkdown 将 Markdown 文本按 ATX 标题（# 至 ######）切分为章节，忽略代码块中的标题。
+func ParseMarkdown(raw string) *MarkdownDocument {
+	doc := &MarkdownDocument{Sections: []MarkdownSection{}}
+	var current *MarkdownSection
+	var body []string
+	inFence := false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(body, "\n"))
+		if current == nil {
+			doc.Preamble = text
+		} else {
+			current.Content = text
+			doc.Sections = append(doc.Sections, *current)
+		}
+		body = body[:0]
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil {
+				flush()
+				current = &MarkdownSection{
+					Heading: strings.TrimSpace(strings.TrimRight(m[2], "# ")),
+					Level:   len(m[1]),
+				}
+				if current.Level == 1 && doc.Title == "" {
+					doc.Title = current.Heading
+				}
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return doc
+}
+
+var markdownHeadingPattern = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.+?)\s*$`)
+
+// normalizeHeading 归一化标题用于比较：忽略大小写、首尾空白与结尾冒号。
+func normalizeHeading(heading string) string {
+	heading = strings.TrimSpace(heading)
+	heading = strings.TrimRight(heading, ":：")
+	return strings.ToLower(strings.TrimSpace(heading))
+}
+
+// markdownCodec 将 Markdown 解码为 MarkdownDocument 并校验章节约束。
+type markdownCodec struct {
+	schema *MarkdownSchema
+}
+
+func (c *markdownCodec) mode() OutputMode {
+	return OutputModeMarkdown
+}
+
+func (c *markdownCodec) instruction() string {
+	var b strings.Builder
+	b.WriteString("Respond only with a Markdown document. Do not wrap it in a code block.")
+	b.WriteString(" Use these section headings")
+	if c.schema.EnforceOrder {
+		b.WriteString(" in this order")
+	}
+	b.WriteString(":")
+	for _, spec := range c.schema.Sections {
+		level := spec.Level
+		if level == 0 {
+			level = 2
+		}
+		fmt.Fprintf(&b, "\n%s %s", strings.Repeat("#", level), spec.Heading)
+		if spec.Required {
+			b.WriteString(" (required)")
+		}
+		if spec.Description != "" {
+			b.WriteString(": " + spec.Description)
+		}
+	}
+	if !c.schema.AllowExtraSections {
+		b.WriteString("\nDo not add any other sections.")
+	}
+	return b.String()
+}
+
+func (c *markdownCodec) decode(raw string) ([]byte, []ParseError) {
+	doc := ParseMarkdown(stripCodeFence(raw))
+	errors := c.validate(doc)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, append(errors, ParseError{Message: fmt.Sprintf("failed to encode markdown document: %v", err)})
+	}
+	return data, errors
+}
+
+func (c *markdownCodec) validate(doc *MarkdownDocument) []ParseError {
+	var errors []ParseError
+	known := make(map[string]bool, len(c.schema.Sections))
+	lastIndex := -1
+
+	for _, spec := range c.schema.Sections {
+		key := normalizeHeading(spec.Heading)
+		known[key] = true
+		path := "sections." + spec.Heading
+
+		index := -1
+		for i := range doc.Sections {
+			if normalizeHeading(doc.Sections[i].Heading) == key {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			if spec.Required {
+				errors = append(errors, ParseError{Path: path, Message: "required section is missing"})
+			}
+			continue
+		}
+
+		section := doc.Sections[index]
+		if spec.Level > 0 && section.Level != spec.Level {
+			errors = append(errors, ParseError{
+				Path:    path,
+				Message: fmt.Sprintf("section heading level is %d, expected %d", section.Level, spec.Level),
+			})
+		}
+		if spec.MinLength > 0 && len([]rune(section.Content)) < spec.MinLength {
+			errors = append(errors, ParseError{
+				Path:    path,
+				Message: fmt.Sprintf("section content has %d characters, minimum is %d", len([]rune(section.Content)), spec.MinLength),
+			})
+		}
+		if c.schema.EnforceOrder {
+			if index < lastIndex {
+				errors = append(errors, ParseError{Path: path, Message: "section is out of order"})
+			} else {
+				lastIndex = index
+			}
+		}
+	}
+
+	if !c.schema.AllowExtraSections {
+		for _, section := range doc.Sections {
+			// 文档标题不视为额外章节
+			if section.Level == 1 && section.Heading == doc.Title && !known[normalizeHeading(section.Heading)] {
+				continue
+			}
+			if !known[normalizeHeading(section.Heading)] {
+				errors = append(errors, ParseError{
+					Path:    "sections." + section.Heading,
+					Message: "section is not allowed by the schema",
+				})
+			}
+		}
+	}
+	return errors
+}
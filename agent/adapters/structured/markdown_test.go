@@ -0,0 +1,89 @@
+package structured
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reportSchema() *MarkdownSchema {
+	return &MarkdownSchema{
+		Sections: []MarkdownSectionSpec{
+			{Heading: "Summary", Level: 2, Required: true, MinLength: 10},
+			{Heading: "Findings", Level: 2, Required: true},
+			{Heading: "Appendix", Level: 2},
+		},
+		EnforceOrder: true,
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	doc := ParseMarkdown("intro text\n# Weekly Report\n## Summary\nAll good.\n```\n# not a heading\n```\n### Details ###\nmore")
+	assert.Equal(t, "Weekly Report", doc.Title)
+	assert.Equal(t, "intro text", doc.Preamble)
+	require.Len(t, doc.Sections, 3)
+	assert.Equal(t, "All good.\n```\n# not a heading\n```", doc.Sections[1].Content)
+	assert.Equal(t, "Details", doc.Sections[2].Heading)
+	assert.Equal(t, 3, doc.Sections[2].Level)
+
+	section, ok := doc.Section("summary:")
+	require.True(t, ok)
+	assert.Equal(t, 2, section.Level)
+	_, ok = doc.Section("missing")
+	assert.False(t, ok)
+}
+
+func TestMarkdownOutput_Generate(t *testing.T) {
+	provider := &mockProvider{response: "# Q3 Report\n## Summary\nRevenue grew 12% quarter over quarter.\n## Findings\n- churn down\n"}
+	so, err := NewMarkdownOutput(provider, reportSchema())
+	require.NoError(t, err)
+	assert.Equal(t, OutputModeMarkdown, so.Mode())
+
+	doc, err := so.Generate(context.Background(), "write the report")
+	require.NoError(t, err)
+	assert.Equal(t, "Q3 Report", doc.Title)
+	findings, ok := doc.Section("Findings")
+	require.True(t, ok)
+	assert.Equal(t, "- churn down", findings.Content)
+
+	require.NotNil(t, provider.lastReq)
+	assert.Nil(t, provider.lastReq.ResponseFormat)
+	assert.Contains(t, provider.lastReq.Messages[0].Content, "## Summary (required)")
+}
+
+func TestMarkdownOutput_SectionErrors(t *testing.T) {
+	so, err := NewMarkdownOutput(&mockProvider{}, reportSchema())
+	require.NoError(t, err)
+
+	result := so.ParseWithResult("## Findings\nnone\n### Summary\nshort\n## Notes\nextra")
+	assert.False(t, result.IsValid())
+	require.NotNil(t, result.Value, "the document is still returned alongside errors")
+
+	messages := make(map[string][]string)
+	for _, e := range result.Errors {
+		messages[e.Path] = append(messages[e.Path], e.Message)
+	}
+	assert.Contains(t, messages["sections.Summary"], "section heading level is 3, expected 2")
+	assert.Contains(t, messages["sections.Summary"], "section content has 5 characters, minimum is 10")
+	assert.Contains(t, messages["sections.Findings"], "section is out of order")
+	assert.Contains(t, messages["sections.Notes"], "section is not allowed by the schema")
+
+	_, err = so.Parse("## Findings\nnone")
+	var ve *ValidationErrors
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "sections.Summary", ve.Errors[0].Path)
+	assert.Equal(t, "required section is missing", ve.Errors[0].Message)
+}
+
+func TestNewMarkdownOutput_InvalidSchema(t *testing.T) {
+	_, err := NewMarkdownOutput(&mockProvider{}, nil)
+	require.Error(t, err)
+	_, err = NewMarkdownOutput(&mockProvider{}, &MarkdownSchema{Sections: []MarkdownSectionSpec{{Heading: " "}}})
+	require.Error(t, err)
+	_, err = NewMarkdownOutput(&mockProvider{}, &MarkdownSchema{Sections: []MarkdownSectionSpec{{Heading: "A", Level: 7}}})
+	require.Error(t, err)
+	_, err = NewMarkdownOutput(nil, reportSchema())
+	require.Error(t, err)
+}
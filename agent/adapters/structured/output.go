@@ -23,6 +23,28 @@ func (r *ParseResult[T]) IsValid() bool {
 	return r.Value != nil && len(r.Errors) == 0
 }
 
+// OutputMode 结构化输出的目标格式。
+type OutputMode string
+
+const (
+	// OutputModeJSON 原生 JSON 输出（默认）
+	OutputModeJSON OutputMode = "json"
+	// OutputModeTable CSV/Markdown 表格输出，按列 schema 逐行校验
+	OutputModeTable OutputMode = "table"
+	// OutputModeMarkdown 受约束的 Markdown 文档输出，按章节要求校验
+	OutputModeMarkdown OutputMode = "markdown"
+)
+
+// outputCodec 将非 JSON 目标格式的原始输出解码为 JSON，
+// 使表格与 Markdown 复用同一套 schema 校验与类型解析流程。
+type outputCodec interface {
+	mode() OutputMode
+	// instruction 返回下发给模型的格式约束提示
+	instruction() string
+	// decode 将原始输出转为 JSON；返回 nil 数据表示无法继续解析
+	decode(raw string) ([]byte, []ParseError)
+}
+
 // 结构化输出是一个通用结构化输出处理器，生成
 // 基于 llmcore.Gateway 的类型安全输出。
 type StructuredOutput[T any] struct {
@@ -30,6 +52,8 @@ type StructuredOutput[T any] struct {
 	gateway   llmcore.Gateway
 	validator SchemaValidator
 	generator *SchemaGenerator
+	// codec 为空时按 JSON 处理
+	codec outputCodec
 }
 
 // NewStructuredOutput为T型创建了新的结构化输出处理器.
@@ -76,6 +100,14 @@ func (s *StructuredOutput[T]) Schema() *JSONSchema {
 	return s.schema
 }
 
+// Mode 返回结构化输出的目标格式。
+func (s *StructuredOutput[T]) Mode() OutputMode {
+	if s.codec == nil {
+		return OutputModeJSON
+	}
+	return s.codec.mode()
+}
+
 // Generate 从 prompt 生成结构化输出。
 // 结构化 schema 约束统一通过 llmcore.Gateway 下发，
 // provider 差异由 llm 层处理。
@@ -135,20 +167,52 @@ func (s *StructuredOutput[T]) generateWithGatewayDetailed(ctx context.Context, r
 		return nil, "", nil, nil, fmt.Errorf("chat request cannot be nil")
 	}
 
+	reqCopy, err := s.prepareRequest(req)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	resp, err := s.invokeChat(ctx, reqCopy)
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("gateway invoke failed: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, "", nil, nil, fmt.Errorf("no response choices returned")
+	}
+
+	raw := resp.Choices[0].Message.Content
+	value, parseErrors := s.parseAndValidateDetailed(raw)
+	usage := resp.Usage
+
+	return value, raw, &usage, parseErrors, nil
+}
+
+// prepareRequest 按目标格式下发约束：JSON 走原生 ResponseFormat，
+// 表格与 Markdown 以系统提示约束格式。
+func (s *StructuredOutput[T]) prepareRequest(req *llmcore.ChatRequest) (*llmcore.ChatRequest, error) {
+	reqCopy := *req
+	if s.codec != nil {
+		reqCopy.ResponseFormat = nil
+		reqCopy.Messages = append([]types.Message{
+			{Role: llmcore.RoleSystem, Content: s.codec.instruction()},
+		}, req.Messages...)
+		return &reqCopy, nil
+	}
+
 	// 为请求构建 JSON Schema
 	schemaJSON, err := json.Marshal(s.schema)
 	if err != nil {
-		return nil, "", nil, nil, fmt.Errorf("failed to marshal schema: %w", err)
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
 	// 将 schema 转换为 map[string]any 用于 ResponseFormat
 	var schemaMap map[string]any
 	if err := json.Unmarshal(schemaJSON, &schemaMap); err != nil {
-		return nil, "", nil, nil, fmt.Errorf("failed to unmarshal schema to map: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal schema to map: %w", err)
 	}
 
 	strict := true
-	reqCopy := *req
 	reqCopy.ResponseFormat = &llmcore.ResponseFormat{
 		Type: llmcore.ResponseFormatJSONSchema,
 		JSONSchema: &llmcore.JSONSchemaParam{
@@ -157,21 +221,7 @@ func (s *StructuredOutput[T]) generateWithGatewayDetailed(ctx context.Context, r
 			Strict: &strict,
 		},
 	}
-
-	resp, err := s.invokeChat(ctx, &reqCopy)
-	if err != nil {
-		return nil, "", nil, nil, fmt.Errorf("gateway invoke failed: %w", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, "", nil, nil, fmt.Errorf("no response choices returned")
-	}
-
-	raw := resp.Choices[0].Message.Content
-	value, parseErrors := s.parseAndValidateDetailed(raw)
-	usage := resp.Usage
-
-	return value, raw, &usage, parseErrors, nil
+	return &reqCopy, nil
 }
 
 func (s *StructuredOutput[T]) invokeChat(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
@@ -207,6 +257,16 @@ func (s *StructuredOutput[T]) parseAndValidate(jsonStr string) (*T, error) {
 func (s *StructuredOutput[T]) parseAndValidateDetailed(jsonStr string) (*T, []ParseError) {
 	var errors []ParseError
 
+	// 非 JSON 格式先解码为 JSON，解码阶段的错误（如单元格类型、缺失章节）一并返回
+	if s.codec != nil {
+		data, decodeErrors := s.codec.decode(jsonStr)
+		errors = append(errors, decodeErrors...)
+		if data == nil {
+			return nil, errors
+		}
+		jsonStr = string(data)
+	}
+
 	// 先对计划进行验证
 	if err := s.validator.Validate([]byte(jsonStr), s.schema); err != nil {
 		if ve, ok := err.(*ValidationErrors); ok {
@@ -0,0 +1,371 @@
+package structured
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+)
+
+// TableColumn 表格列定义。
+type TableColumn struct {
+	// Name 列名，与表头及行类型的 json 字段名一致
+	Name string `json:"name"`
+	// Schema 单元格的校验 schema，为空时按字符串处理
+	Schema *JSONSchema `json:"schema,omitempty"`
+	// Required 单元格不能为空
+	Required bool `json:"required,omitempty"`
+}
+
+// TableSchema 表格输出的列 schema。
+type TableSchema struct {
+	Columns []TableColumn `json:"columns"`
+	// MinRows/MaxRows 数据行数限制，0 表示不限制
+	MinRows int `json:"min_rows,omitempty"`
+	MaxRows int `json:"max_rows,omitempty"`
+	// Delimiter CSV 分隔符，默认逗号
+	Delimiter rune `json:"delimiter,omitempty"`
+}
+
+// TableSchemaFromType 从行结构体类型生成表格 schema，列顺序与字段声明顺序一致。
+// 列约束沿用 jsonschema 标签。
+func TableSchemaFromType(t reflect.Type) (*TableSchema, error) {
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate table schema for nil type")
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("table row type must be a struct, got %s", t.Kind())
+	}
+
+	rowSchema, err := NewSchemaGenerator().GenerateSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	required := make(map[string]bool, len(rowSchema.Required))
+	for _, name := range rowSchema.Required {
+		required[name] = true
+	}
+
+	table := &TableSchema{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := getJSONFieldName(field)
+		if name == "-" {
+			continue
+		}
+		table.Columns = append(table.Columns, TableColumn{
+			Name:     name,
+			Schema:   rowSchema.Properties[name],
+			Required: required[name],
+		})
+	}
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table row type %s has no exported columns", t.Name())
+	}
+	return table, nil
+}
+
+// RowSchema 返回单行对应的对象 schema。
+func (t *TableSchema) RowSchema() *JSONSchema {
+	row := NewObjectSchema()
+	for _, col := range t.Columns {
+		colSchema := col.Schema
+		if colSchema == nil {
+			colSchema = NewStringSchema()
+		}
+		row.Properties[col.Name] = colSchema
+		if col.Required {
+			row.Required = append(row.Required, col.Name)
+		}
+	}
+	return row
+}
+
+// JSONSchema 返回整张表（行数组）对应的 schema。
+func (t *TableSchema) JSONSchema() *JSONSchema {
+	schema := NewArraySchema(t.RowSchema())
+	if t.MinRows > 0 {
+		minRows := t.MinRows
+		schema.MinItems = &minRows
+	}
+	if t.MaxRows > 0 {
+		maxRows := t.MaxRows
+		schema.MaxItems = &maxRows
+	}
+	return schema
+}
+
+// NewTableOutput 创建表格结构化输出处理器，列 schema 由行类型 R 推导。
+// 模型以 CSV 或 Markdown 表格作答，解析结果为 []R，错误路径形如 "[2].score"。
+func NewTableOutput[R any](gateway llmcore.Gateway) (*StructuredOutput[[]R], error) {
+	var zero R
+	table, err := TableSchemaFromType(reflect.TypeOf(zero))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate table schema for type %T: %w", zero, err)
+	}
+	return NewTableOutputWithSchema[R](gateway, table)
+}
+
+// NewTableOutputWithSchema 使用自定义列 schema 创建表格结构化输出处理器。
+func NewTableOutputWithSchema[R any](gateway llmcore.Gateway, table *TableSchema) (*StructuredOutput[[]R], error) {
+	if table == nil || len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table schema must define at least one column")
+	}
+	so, err := NewStructuredOutputWithSchema[[]R](gateway, table.JSONSchema())
+	if err != nil {
+		return nil, err
+	}
+	so.codec = &tableCodec{table: table}
+	return so, nil
+}
+
+// RowErrors 按数据行（从 0 开始）分组表格解析错误；不属于具体行的错误归入 -1。
+func RowErrors(errors []ParseError) map[int][]ParseError {
+	grouped := make(map[int][]ParseError)
+	for _, e := range errors {
+		row := -1
+		if m := rowPathPattern.FindStringSubmatch(e.Path); m != nil {
+			row, _ = strconv.Atoi(m[1])
+		}
+		grouped[row] = append(grouped[row], e)
+	}
+	return grouped
+}
+
+var (
+	rowPathPattern        = regexp.MustCompile(`^\[(\d+)\]`)
+	markdownSeparatorCell = regexp.MustCompile(`^:?-{3,}:?$`)
+)
+
+// tableCodec 将 CSV / Markdown 表格解码为行对象数组。
+type tableCodec struct {
+	table *TableSchema
+}
+
+func (c *tableCodec) mode() OutputMode {
+	return OutputModeTable
+}
+
+func (c *tableCodec) instruction() string {
+	names := make([]string, len(c.table.Columns))
+	var cols strings.Builder
+	for i, col := range c.table.Columns {
+		names[i] = col.Name
+		colType := TypeString
+		if col.Schema != nil && col.Schema.Type != "" {
+			colType = col.Schema.Type
+		}
+		fmt.Fprintf(&cols, "\n- %s (%s", col.Name, colType)
+		if col.Required {
+			cols.WriteString(", required")
+		}
+		if col.Schema != nil && len(col.Schema.Enum) > 0 {
+			fmt.Fprintf(&cols, ", one of %v", col.Schema.Enum)
+		}
+		cols.WriteString(")")
+		if col.Schema != nil && col.Schema.Description != "" {
+			cols.WriteString(": " + col.Schema.Description)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("Respond only with a CSV table. Do not wrap it in markdown or add any other text.")
+	fmt.Fprintf(&b, " The first line must be exactly this header: %s", strings.Join(names, string(c.delimiter())))
+	b.WriteString("\nColumns:")
+	b.WriteString(cols.String())
+	if c.table.MinRows > 0 {
+		fmt.Fprintf(&b, "\nInclude at least %d data rows.", c.table.MinRows)
+	}
+	if c.table.MaxRows > 0 {
+		fmt.Fprintf(&b, "\nInclude at most %d data rows.", c.table.MaxRows)
+	}
+	return b.String()
+}
+
+func (c *tableCodec) delimiter() rune {
+	if c.table.Delimiter == 0 {
+		return ','
+	}
+	return c.table.Delimiter
+}
+
+func (c *tableCodec) decode(raw string) ([]byte, []ParseError) {
+	records, err := c.readRecords(stripCodeFence(raw))
+	if err != nil {
+		return nil, []ParseError{{Message: fmt.Sprintf("table parse error: %v", err)}}
+	}
+	if len(records) == 0 {
+		return nil, []ParseError{{Message: "table is empty, header row is required"}}
+	}
+
+	var errors []ParseError
+	columns := make(map[string]TableColumn, len(c.table.Columns))
+	for _, col := range c.table.Columns {
+		columns[strings.ToLower(col.Name)] = col
+	}
+
+	// 按表头定位列，列顺序与 schema 不一致时仍可解析
+	header := make([]*TableColumn, len(records[0]))
+	seen := make(map[string]bool, len(header))
+	for i, name := range records[0] {
+		key := strings.ToLower(strings.TrimSpace(name))
+		col, ok := columns[key]
+		if !ok {
+			errors = append(errors, ParseError{Path: "header", Message: fmt.Sprintf("unknown column %q", strings.TrimSpace(name))})
+			continue
+		}
+		if seen[key] {
+			errors = append(errors, ParseError{Path: "header", Message: fmt.Sprintf("duplicate column %q", col.Name)})
+			continue
+		}
+		seen[key] = true
+		header[i] = &col
+	}
+	for _, col := range c.table.Columns {
+		if col.Required && !seen[strings.ToLower(col.Name)] {
+			errors = append(errors, ParseError{Path: "header", Message: fmt.Sprintf("missing required column %q", col.Name)})
+		}
+	}
+
+	rows := make([]map[string]any, 0, len(records)-1)
+	for i, record := range records[1:] {
+		rowPath := fmt.Sprintf("[%d]", i)
+		if len(record) != len(header) {
+			errors = append(errors, ParseError{
+				Path:    rowPath,
+				Message: fmt.Sprintf("row has %d cells, header has %d", len(record), len(header)),
+			})
+		}
+		row := make(map[string]any, len(header))
+		for j, cell := range record {
+			if j >= len(header) || header[j] == nil {
+				continue
+			}
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			value, err := convertCell(cell, header[j].Schema)
+			if err != nil {
+				errors = append(errors, ParseError{Path: rowPath + "." + header[j].Name, Message: err.Error()})
+				continue
+			}
+			row[header[j].Name] = value
+		}
+		rows = append(rows, row)
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, append(errors, ParseError{Message: fmt.Sprintf("failed to encode table rows: %v", err)})
+	}
+	return data, errors
+}
+
+// readRecords 读取 CSV 或 Markdown 管道表格。
+func (c *tableCodec) readRecords(raw string) ([][]string, error) {
+	if strings.HasPrefix(raw, "|") {
+		return readMarkdownTable(raw), nil
+	}
+	reader := csv.NewReader(strings.NewReader(raw))
+	reader.Comma = c.delimiter()
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// readMarkdownTable 解析 Markdown 管道表格，跳过分隔行。
+func readMarkdownTable(raw string) [][]string {
+	var records [][]string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+		cells := strings.Split(line, "|")
+		separator := true
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+			if !markdownSeparatorCell.MatchString(cells[i]) {
+				separator = false
+			}
+		}
+		if separator {
+			continue
+		}
+		records = append(records, cells)
+	}
+	return records
+}
+
+// convertCell 按列类型转换单元格文本。
+func convertCell(cell string, schema *JSONSchema) (any, error) {
+	if schema == nil {
+		return cell, nil
+	}
+	switch schema.Type {
+	case TypeInteger:
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as integer", cell)
+		}
+		return n, nil
+	case TypeNumber:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as number", cell)
+		}
+		return f, nil
+	case TypeBoolean:
+		b, err := strconv.ParseBool(strings.ToLower(cell))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as boolean", cell)
+		}
+		return b, nil
+	case TypeArray, TypeObject:
+		var v any
+		if err := json.Unmarshal([]byte(cell), &v); err != nil {
+			return nil, fmt.Errorf("cannot parse %q as %s", cell, schema.Type)
+		}
+		return v, nil
+	default:
+		return cell, nil
+	}
+}
+
+// stripCodeFence 去除包裹整段输出的 ``` 代码块。
+func stripCodeFence(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "```") {
+		return raw
+	}
+	lines := strings.Split(raw, "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[len(lines)-1]) != "```" {
+		return raw
+	}
+	return strings.TrimSpace(strings.Join(lines[1:len(lines)-1], "\n"))
+}
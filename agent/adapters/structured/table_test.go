@@ -0,0 +1,119 @@
+package structured
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSalesRow 是表格输出的测试行类型.
+type TestSalesRow struct {
+	Region string  `json:"region" jsonschema:"required,enum=north,south"`
+	Units  int     `json:"units" jsonschema:"required,minimum=0"`
+	Share  float64 `json:"share" jsonschema:"maximum=1"`
+	Active bool    `json:"active"`
+}
+
+func TestTableSchemaFromType(t *testing.T) {
+	table, err := TableSchemaFromType(nil)
+	require.Error(t, err)
+	assert.Nil(t, table)
+
+	_, err = TableSchemaFromType(reflect.TypeOf(""))
+	require.Error(t, err)
+
+	table, err = TableSchemaFromType(reflect.TypeOf(TestSalesRow{}))
+	require.NoError(t, err)
+	require.Len(t, table.Columns, 4)
+	assert.Equal(t, "region", table.Columns[0].Name)
+	assert.True(t, table.Columns[0].Required)
+	assert.Equal(t, TypeInteger, table.Columns[1].Schema.Type)
+	assert.False(t, table.Columns[3].Required)
+}
+
+func TestTableOutput_Generate(t *testing.T) {
+	provider := &mockProvider{response: "```csv\nregion,units,share,active\nnorth,12,0.4,true\nsouth,18,0.6,false\n```"}
+	so, err := NewTableOutput[TestSalesRow](provider)
+	require.NoError(t, err)
+	assert.Equal(t, OutputModeTable, so.Mode())
+
+	rows, err := so.Generate(context.Background(), "summarize sales")
+	require.NoError(t, err)
+	require.NotNil(t, rows)
+	assert.Equal(t, []TestSalesRow{
+		{Region: "north", Units: 12, Share: 0.4, Active: true},
+		{Region: "south", Units: 18, Share: 0.6},
+	}, *rows)
+
+	// 表格模式以系统提示约束格式，不下发 JSON ResponseFormat
+	require.NotNil(t, provider.lastReq)
+	assert.Nil(t, provider.lastReq.ResponseFormat)
+	require.Len(t, provider.lastReq.Messages, 2)
+	assert.Equal(t, llmcore.RoleSystem, provider.lastReq.Messages[0].Role)
+	assert.Contains(t, provider.lastReq.Messages[0].Content, "region,units,share,active")
+}
+
+func TestTableOutput_MarkdownTable(t *testing.T) {
+	so, err := NewTableOutput[TestSalesRow](&mockProvider{})
+	require.NoError(t, err)
+
+	result := so.ParseWithResult("| Units | Region |\n|---:|:---|\n| 3 | north |\n| 5 | south |")
+	require.True(t, result.IsValid(), "%v", result.Errors)
+	assert.Equal(t, 5, (*result.Value)[1].Units)
+	assert.Equal(t, "south", (*result.Value)[1].Region)
+}
+
+func TestTableOutput_RowLevelErrors(t *testing.T) {
+	so, err := NewTableOutput[TestSalesRow](&mockProvider{})
+	require.NoError(t, err)
+
+	raw := "region,units,share,extra\nnorth,abc,0.5,x\neast,4,0.1,y\nsouth,7,1.5,z\nsouth,8"
+	result := so.ParseWithResult(raw)
+	assert.False(t, result.IsValid())
+	assert.Equal(t, raw, result.Raw)
+
+	grouped := RowErrors(result.Errors)
+	require.Contains(t, grouped, -1)
+	assert.Equal(t, "header", grouped[-1][0].Path)
+	assert.Contains(t, grouped[-1][0].Message, `unknown column "extra"`)
+
+	require.Contains(t, grouped, 0)
+	assert.Equal(t, "[0].units", grouped[0][0].Path)
+	assert.Contains(t, grouped[0][0].Message, "as integer")
+	require.Contains(t, grouped, 1)
+	assert.Equal(t, "[1].region", grouped[1][0].Path)
+	require.Contains(t, grouped, 2)
+	assert.Equal(t, "[2].share", grouped[2][0].Path)
+	require.Contains(t, grouped, 3)
+	assert.Contains(t, grouped[3][0].Message, "row has 2 cells")
+}
+
+func TestTableOutput_WithSchema(t *testing.T) {
+	_, err := NewTableOutputWithSchema[map[string]any](&mockProvider{}, &TableSchema{})
+	require.Error(t, err)
+
+	so, err := NewTableOutputWithSchema[map[string]any](&mockProvider{}, &TableSchema{
+		Columns:   []TableColumn{{Name: "name", Required: true}, {Name: "age", Schema: NewIntegerSchema()}},
+		MinRows:   2,
+		Delimiter: '\t',
+	})
+	require.NoError(t, err)
+
+	result := so.ParseWithResult("name\tage\nalice\t30")
+	assert.False(t, result.IsValid())
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "minimum is 2")
+
+	result = so.ParseWithResult("age\n30\n31")
+	assert.False(t, result.IsValid())
+	assert.Equal(t, "header", result.Errors[0].Path)
+	assert.Contains(t, result.Errors[0].Message, `missing required column "name"`)
+
+	result = so.ParseWithResult("")
+	assert.Nil(t, result.Value)
+	require.Len(t, result.Errors, 1)
+}
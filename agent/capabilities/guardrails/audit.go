@@ -0,0 +1,434 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// AuditDirection 校验方向
+type AuditDirection string
+
+const (
+	// AuditDirectionInput 输入校验
+	AuditDirectionInput AuditDirection = "input"
+	// AuditDirectionOutput 输出校验
+	AuditDirectionOutput AuditDirection = "output"
+	// AuditDirectionToolCall 工具调用参数校验
+	AuditDirectionToolCall AuditDirection = "tool_call"
+)
+
+// AuditDecision 单个验证器的校验结论
+type AuditDecision string
+
+const (
+	// AuditDecisionAllow 通过
+	AuditDecisionAllow AuditDecision = "allow"
+	// AuditDecisionWarn 通过但产生警告
+	AuditDecisionWarn AuditDecision = "warn"
+	// AuditDecisionRedact 通过但内容被脱敏
+	AuditDecisionRedact AuditDecision = "redact"
+	// AuditDecisionEscalated 违规但经人工审批放行
+	AuditDecisionEscalated AuditDecision = "escalated"
+	// AuditDecisionBlock 拒绝
+	AuditDecisionBlock AuditDecision = "block"
+	// AuditDecisionError 验证器执行失败
+	AuditDecisionError AuditDecision = "error"
+)
+
+// RedactionSummary 脱敏摘要，仅记录类型与数量，不记录原文
+type RedactionSummary struct {
+	Redacted bool           `json:"redacted"`
+	Count    int            `json:"count"`
+	Types    map[string]int `json:"types,omitempty"`
+}
+
+// AuditDecisionEvent 一次验证器执行，由验证器链、输出验证器与工具调用验证器上报
+type AuditDecisionEvent struct {
+	Direction AuditDirection
+	Validator string
+	Content   string
+	ToolName  string
+	Result    *ValidationResult
+	Err       error
+}
+
+// AuditRecorder 接收每一次验证器执行结果（包括通过的结果）
+type AuditRecorder interface {
+	RecordDecision(ctx context.Context, event AuditDecisionEvent)
+}
+
+// AuditSink 审计记录输出目标
+type AuditSink interface {
+	Write(ctx context.Context, entry *AuditLogEntry) error
+}
+
+// AuditSinkFunc 函数形式的 AuditSink
+type AuditSinkFunc func(ctx context.Context, entry *AuditLogEntry) error
+
+// Write 实现 AuditSink
+func (f AuditSinkFunc) Write(ctx context.Context, entry *AuditLogEntry) error {
+	return f(ctx, entry)
+}
+
+// GuardrailAuditLoggerConfig 护栏审计日志配置
+type GuardrailAuditLoggerConfig struct {
+	// Store 可查询的存储，默认为容量 10000 的内存环形缓冲
+	Store AuditLogger
+	// Sinks 额外输出目标，如文件、OTLP 事件
+	Sinks  []AuditSink
+	Logger *zap.Logger
+	Now    func() time.Time
+}
+
+// GuardrailAuditLogger 记录所有护栏校验结论（验证器、结论、命中规则、脱敏摘要、租户、链路 ID），
+// 写入可查询存储并分发到可插拔的输出目标，供合规审计证明拦截了什么以及原因。
+// 记录中只保存内容哈希，不保存原文。
+type GuardrailAuditLogger struct {
+	store  AuditLogger
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	sinks []AuditSink
+}
+
+// NewGuardrailAuditLogger 创建护栏审计日志
+func NewGuardrailAuditLogger(cfg *GuardrailAuditLoggerConfig) *GuardrailAuditLogger {
+	if cfg == nil {
+		cfg = &GuardrailAuditLoggerConfig{}
+	}
+	store := cfg.Store
+	if store == nil {
+		store = NewMemoryAuditLogger(10000)
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &GuardrailAuditLogger{
+		store:  store,
+		logger: logger.With(zap.String("component", "guardrail_audit")),
+		now:    now,
+		sinks:  append([]AuditSink(nil), cfg.Sinks...),
+	}
+}
+
+// AddSink 追加输出目标
+func (l *GuardrailAuditLogger) AddSink(sink AuditSink) {
+	if sink == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// Log 写入存储并分发到所有输出目标，返回所有失败的合并错误
+func (l *GuardrailAuditLogger) Log(ctx context.Context, entry *AuditLogEntry) error {
+	var errs []error
+	if err := l.store.Log(ctx, entry); err != nil {
+		errs = append(errs, fmt.Errorf("audit store: %w", err))
+	}
+	l.mu.RLock()
+	sinks := append([]AuditSink(nil), l.sinks...)
+	l.mu.RUnlock()
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Query 查询审计记录
+func (l *GuardrailAuditLogger) Query(ctx context.Context, filter *AuditLogFilter) ([]*AuditLogEntry, error) {
+	return l.store.Query(ctx, filter)
+}
+
+// Count 统计审计记录
+func (l *GuardrailAuditLogger) Count(ctx context.Context, filter *AuditLogFilter) (int, error) {
+	return l.store.Count(ctx, filter)
+}
+
+// RecordDecision 将一次验证器执行转换为审计记录；审计失败不影响校验流程
+func (l *GuardrailAuditLogger) RecordDecision(ctx context.Context, event AuditDecisionEvent) {
+	entry := l.buildEntry(ctx, event)
+	if err := l.Log(ctx, entry); err != nil {
+		l.logger.Warn("failed to write guardrail audit entry",
+			zap.String("validator", entry.ValidatorName),
+			zap.String("decision", string(entry.Decision)),
+			zap.Error(err))
+	}
+}
+
+// Close 关闭实现了 io.Closer 的输出目标与存储
+func (l *GuardrailAuditLogger) Close() error {
+	l.mu.RLock()
+	sinks := append([]AuditSink(nil), l.sinks...)
+	l.mu.RUnlock()
+	var errs []error
+	for _, sink := range sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	if closer, ok := l.store.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (l *GuardrailAuditLogger) buildEntry(ctx context.Context, event AuditDecisionEvent) *AuditLogEntry {
+	entry := &AuditLogEntry{
+		ID:            uuid.NewString(),
+		Timestamp:     l.now(),
+		ValidatorName: event.Validator,
+		ContentHash:   hashContent(event.Content),
+		Direction:     event.Direction,
+		ToolName:      event.ToolName,
+	}
+	entry.TenantID, _ = types.TenantID(ctx)
+	entry.RunID, _ = types.RunID(ctx)
+	entry.TraceID, _ = types.TraceID(ctx)
+	if entry.TraceID == "" {
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			entry.TraceID = sc.TraceID().String()
+		}
+	}
+
+	if event.Err != nil {
+		entry.Decision = AuditDecisionError
+		entry.EventType = AuditEventValidationFailed
+		entry.Errors = []ValidationError{{
+			Code:     ErrCodeValidationFailed,
+			Message:  event.Err.Error(),
+			Severity: SeverityCritical,
+		}}
+		return entry
+	}
+	result := event.Result
+	if result == nil {
+		result = NewValidationResult()
+	}
+	entry.Errors = result.Errors
+	entry.Redaction = redactionSummary(result)
+	entry.MatchedRules = matchedRules(result)
+	entry.Metadata = auditMetadata(result.Metadata)
+	entry.Decision = auditDecision(result, entry.Redaction)
+	switch entry.Decision {
+	case AuditDecisionBlock:
+		entry.EventType = AuditEventValidationFailed
+	case AuditDecisionRedact:
+		entry.EventType = AuditEventContentFiltered
+	default:
+		entry.EventType = AuditEventValidationPassed
+	}
+	return entry
+}
+
+func auditDecision(result *ValidationResult, redaction *RedactionSummary) AuditDecision {
+	approved, _ := result.Metadata["policy_escalation_approved"].(bool)
+	toolApproved, _ := result.Metadata["tool_call_escalation_approved"].(bool)
+	switch {
+	case !result.Valid:
+		return AuditDecisionBlock
+	case approved || toolApproved:
+		return AuditDecisionEscalated
+	case redaction != nil:
+		return AuditDecisionRedact
+	case len(result.Warnings) > 0:
+		return AuditDecisionWarn
+	default:
+		return AuditDecisionAllow
+	}
+}
+
+// matchedRules 提取命中的策略名、工具调用规则与错误码
+func matchedRules(result *ValidationResult) []string {
+	seen := make(map[string]bool)
+	var rules []string
+	add := func(rule string) {
+		if rule != "" && !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+	if policy, ok := result.Metadata["policy"].(string); ok {
+		add(policy)
+	}
+	if names, ok := result.Metadata["tool_call_rules"].([]string); ok {
+		for _, name := range names {
+			add(name)
+		}
+	}
+	for _, e := range result.Errors {
+		add(e.Code)
+	}
+	return rules
+}
+
+// redactionSummary 根据验证器元数据统计脱敏类型与数量；未脱敏时返回 nil
+func redactionSummary(result *ValidationResult) *RedactionSummary {
+	if _, masked := result.Metadata["masked_content"]; !masked {
+		if _, filtered := result.Metadata["filtered_content"]; !filtered {
+			return nil
+		}
+	}
+	summary := &RedactionSummary{Redacted: true, Types: make(map[string]int)}
+	if matches, ok := result.Metadata["pii_matches"].([]PIIMatch); ok {
+		for _, m := range matches {
+			summary.Types[string(m.Type)]++
+		}
+	}
+	if counts, ok := result.Metadata["secret_types"].(map[SecretType]int); ok {
+		for t, n := range counts {
+			summary.Types[string(t)] += n
+		}
+	}
+	if policy, ok := result.Metadata["policy"].(string); ok && len(summary.Types) == 0 {
+		summary.Types["policy:"+policy] = 1
+	}
+	for _, n := range summary.Types {
+		summary.Count += n
+	}
+	if len(summary.Types) == 0 {
+		summary.Types = nil
+	}
+	return summary
+}
+
+// auditContentKeys 携带原文或原文片段的元数据键，不进入审计记录
+var auditContentKeys = map[string]bool{
+	"masked_content":    true,
+	"filtered_content":  true,
+	"truncated_content": true,
+}
+
+// auditMetadata 仅保留标量元数据，避免把匹配到的原始 PII 或凭据写入审计记录
+func auditMetadata(metadata map[string]any) map[string]any {
+	out := make(map[string]any)
+	for k, v := range metadata {
+		if auditContentKeys[k] {
+			continue
+		}
+		switch v.(type) {
+		case string, bool, int, int64, float64:
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// FileAuditSink 以 JSON Lines 追加写入审计记录
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileAuditSink 打开（必要时创建）审计文件
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("create audit directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	return &FileAuditSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Write 写入一行 JSON
+func (s *FileAuditSink) Write(_ context.Context, entry *AuditLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("audit file sink is closed")
+	}
+	if err := s.enc.Encode(entry); err != nil {
+		return fmt.Errorf("write audit file: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭文件
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// SpanEventAuditSink 将审计记录作为事件附加到当前 OpenTelemetry span，
+// 随 OTLP trace 导出到可观测性后端；无活动 span 时忽略。
+type SpanEventAuditSink struct{}
+
+// NewSpanEventAuditSink 创建 OTLP 事件输出目标
+func NewSpanEventAuditSink() *SpanEventAuditSink {
+	return &SpanEventAuditSink{}
+}
+
+// Write 添加 guardrail.decision span 事件
+func (s *SpanEventAuditSink) Write(ctx context.Context, entry *AuditLogEntry) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("guardrail.audit_id", entry.ID),
+		attribute.String("guardrail.validator", entry.ValidatorName),
+		attribute.String("guardrail.direction", string(entry.Direction)),
+		attribute.String("guardrail.decision", string(entry.Decision)),
+		attribute.String("guardrail.content_hash", entry.ContentHash),
+	}
+	if len(entry.MatchedRules) > 0 {
+		attrs = append(attrs, attribute.StringSlice("guardrail.matched_rules", entry.MatchedRules))
+	}
+	if entry.Redaction != nil {
+		attrs = append(attrs, attribute.Int("guardrail.redaction_count", entry.Redaction.Count))
+		if len(entry.Redaction.Types) > 0 {
+			redactedTypes := make([]string, 0, len(entry.Redaction.Types))
+			for t := range entry.Redaction.Types {
+				redactedTypes = append(redactedTypes, t)
+			}
+			sort.Strings(redactedTypes)
+			attrs = append(attrs, attribute.String("guardrail.redaction_types", strings.Join(redactedTypes, ",")))
+		}
+	}
+	if entry.TenantID != "" {
+		attrs = append(attrs, attribute.String("tenant.id", entry.TenantID))
+	}
+	if entry.ToolName != "" {
+		attrs = append(attrs, attribute.String("guardrail.tool_name", entry.ToolName))
+	}
+	span.AddEvent("guardrail.decision", trace.WithAttributes(attrs...), trace.WithTimestamp(entry.Timestamp))
+	return nil
+}
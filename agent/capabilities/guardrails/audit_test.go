@@ -0,0 +1,212 @@
+package guardrails
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGuardrailAuditLogger_RecordsEveryChainDecision(t *testing.T) {
+	auditLogger := NewGuardrailAuditLogger(nil)
+	chain := NewValidatorChain(&ValidatorChainConfig{Mode: ChainModeCollectAll})
+	chain.Add(
+		NewKeywordValidator(&KeywordValidatorConfig{BlockedKeywords: []string{"forbidden"}, Action: KeywordActionReject}),
+		NewPIIDetector(nil),
+		NewLengthValidator(&LengthValidatorConfig{MaxLength: 1000, Action: LengthActionReject}),
+	)
+	chain.SetAuditor(auditLogger, AuditDirectionInput)
+
+	ctx := types.WithTraceID(types.WithTenantID(context.Background(), "tenant-a"), "trace-1")
+	content := "forbidden request from alice@example.com"
+	_, err := chain.Validate(ctx, content)
+	require.NoError(t, err)
+
+	entries, err := auditLogger.Query(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	byValidator := make(map[string]*AuditLogEntry)
+	for _, e := range entries {
+		byValidator[e.ValidatorName] = e
+		assert.NotEmpty(t, e.ID)
+		assert.Equal(t, AuditDirectionInput, e.Direction)
+		assert.Equal(t, "tenant-a", e.TenantID)
+		assert.Equal(t, "trace-1", e.TraceID)
+		assert.Equal(t, hashContent(content), e.ContentHash)
+	}
+
+	blocked := byValidator["keyword_validator"]
+	require.NotNil(t, blocked)
+	assert.Equal(t, AuditDecisionBlock, blocked.Decision)
+	assert.Equal(t, AuditEventValidationFailed, blocked.EventType)
+	assert.Contains(t, blocked.MatchedRules, ErrCodeBlockedKeyword)
+
+	redacted := byValidator["pii_detector"]
+	require.NotNil(t, redacted)
+	assert.Equal(t, AuditDecisionRedact, redacted.Decision)
+	require.NotNil(t, redacted.Redaction)
+	assert.Equal(t, 1, redacted.Redaction.Count)
+	assert.Equal(t, 1, redacted.Redaction.Types[string(PIITypeEmail)])
+	assert.NotContains(t, redacted.Metadata, "masked_content")
+
+	passed := byValidator["length_validator"]
+	require.NotNil(t, passed)
+	assert.Equal(t, AuditDecisionAllow, passed.Decision)
+	assert.Equal(t, AuditEventValidationPassed, passed.EventType)
+
+	raw, err := json.Marshal(entries)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "alice@example.com", "audit records must not carry raw content")
+}
+
+func TestGuardrailAuditLogger_QueryFilters(t *testing.T) {
+	auditLogger := NewGuardrailAuditLogger(nil)
+	ctxA := types.WithTenantID(context.Background(), "tenant-a")
+	ctxB := types.WithTenantID(context.Background(), "tenant-b")
+
+	blocked := NewValidationResult()
+	blocked.AddError(ValidationError{Code: ErrCodeInjectionDetected, Message: "injection", Severity: SeverityCritical})
+	auditLogger.RecordDecision(ctxA, AuditDecisionEvent{Direction: AuditDirectionInput, Validator: "injection_detector", Result: blocked})
+	auditLogger.RecordDecision(ctxB, AuditDecisionEvent{Direction: AuditDirectionOutput, Validator: "secrets_validator", Result: NewValidationResult()})
+	auditLogger.RecordDecision(ctxB, AuditDecisionEvent{Direction: AuditDirectionOutput, Validator: "llm_judge", Err: errors.New("judge unavailable")})
+
+	rows, err := auditLogger.Query(context.Background(), &AuditLogFilter{Decisions: []AuditDecision{AuditDecisionBlock}})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "injection_detector", rows[0].ValidatorName)
+
+	rows, err = auditLogger.Query(context.Background(), &AuditLogFilter{TenantID: "tenant-b", Directions: []AuditDirection{AuditDirectionOutput}})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, AuditDecisionError, rows[1].Decision)
+
+	count, err := auditLogger.Count(context.Background(), &AuditLogFilter{Decisions: []AuditDecision{AuditDecisionAllow}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestGuardrailAuditLogger_ToolCallEscalation(t *testing.T) {
+	set, err := ParseToolCallRules([]byte(testToolCallRules))
+	require.NoError(t, err)
+	auditLogger := NewGuardrailAuditLogger(nil)
+	validator, err := NewToolCallValidator(&ToolCallValidatorConfig{
+		Rules:     set.Rules,
+		Escalator: &stubEscalator{approved: true},
+		Auditor:   auditLogger,
+	})
+	require.NoError(t, err)
+
+	result, err := validator.ValidateToolCall(context.Background(), types.ToolCall{
+		Name:      "fs_read",
+		Arguments: json.RawMessage(`{"path":"/etc/passwd"}`),
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+
+	rows, err := auditLogger.Query(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, AuditDirectionToolCall, rows[0].Direction)
+	assert.Equal(t, AuditDecisionEscalated, rows[0].Decision)
+	assert.Equal(t, "fs_read", rows[0].ToolName)
+	assert.Contains(t, rows[0].MatchedRules, "workspace-files")
+}
+
+func TestOutputValidator_RecordsPassingResultsWithGuardrailAuditLogger(t *testing.T) {
+	auditLogger := NewGuardrailAuditLogger(nil)
+	validator := NewOutputValidator(&OutputValidatorConfig{
+		Validators:     []Validator{NewLengthValidator(&LengthValidatorConfig{MaxLength: 100, Action: LengthActionReject})},
+		EnableAuditLog: true,
+		AuditLogger:    auditLogger,
+	})
+
+	_, err := validator.Validate(context.Background(), "fine")
+	require.NoError(t, err)
+
+	rows, err := auditLogger.Query(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, AuditDirectionOutput, rows[0].Direction)
+	assert.Equal(t, AuditDecisionAllow, rows[0].Decision)
+}
+
+func TestFileAuditSink_WritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "guardrails.jsonl")
+	sink, err := NewFileAuditSink(path)
+	require.NoError(t, err)
+	auditLogger := NewGuardrailAuditLogger(&GuardrailAuditLoggerConfig{Sinks: []AuditSink{sink}})
+
+	auditLogger.RecordDecision(context.Background(), AuditDecisionEvent{Direction: AuditDirectionInput, Validator: "a", Result: NewValidationResult()})
+	auditLogger.RecordDecision(context.Background(), AuditDecisionEvent{Direction: AuditDirectionInput, Validator: "b", Result: NewValidationResult()})
+	require.NoError(t, auditLogger.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		names = append(names, entry.ValidatorName)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	assert.Error(t, sink.Write(context.Background(), &AuditLogEntry{}))
+}
+
+func TestSpanEventAuditSink_AddsSpanEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "agent.execute")
+
+	auditLogger := NewGuardrailAuditLogger(&GuardrailAuditLoggerConfig{Sinks: []AuditSink{NewSpanEventAuditSink()}})
+	blocked := NewValidationResult()
+	blocked.AddError(ValidationError{Code: ErrCodeContentBlocked, Message: "blocked", Severity: SeverityHigh})
+	auditLogger.RecordDecision(ctx, AuditDecisionEvent{Direction: AuditDirectionOutput, Validator: "keyword_validator", Result: blocked})
+	span.End()
+
+	rows, err := auditLogger.Query(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, span.SpanContext().TraceID().String(), rows[0].TraceID, "trace id falls back to the active span")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "guardrail.decision", events[0].Name)
+	attrs := make(map[string]string)
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "block", attrs["guardrail.decision"])
+	assert.True(t, strings.Contains(attrs["guardrail.matched_rules"], ErrCodeContentBlocked))
+}
+
+func TestGuardrailAuditLogger_SinkErrorsDoNotBlockValidation(t *testing.T) {
+	failing := AuditSinkFunc(func(context.Context, *AuditLogEntry) error { return errors.New("sink down") })
+	auditLogger := NewGuardrailAuditLogger(&GuardrailAuditLoggerConfig{Sinks: []AuditSink{failing}})
+	chain := NewValidatorChain(nil)
+	chain.Add(NewLengthValidator(&LengthValidatorConfig{MaxLength: 10, Action: LengthActionReject}))
+	chain.SetAuditor(auditLogger, AuditDirectionInput)
+
+	result, err := chain.Validate(context.Background(), "ok")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+
+	count, err := auditLogger.Count(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "the queryable store still records the decision")
+}
@@ -40,6 +40,8 @@ func DefaultValidatorChainConfig() *ValidatorChainConfig {
 type ValidatorChain struct {
 	validators []Validator
 	mode       ChainMode
+	auditor    AuditRecorder
+	direction  AuditDirection
 	mu         sync.RWMutex
 }
 
@@ -132,6 +134,15 @@ func (c *ValidatorChain) GetMode() ChainMode {
 	return c.mode
 }
 
+// SetAuditor 设置审计记录器，链中每个验证器的执行结果（包括通过）都会被记录
+func (c *ValidatorChain) SetAuditor(recorder AuditRecorder, direction AuditDirection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.auditor = recorder
+	c.direction = direction
+}
+
 // Validate 执行验证器链
 // 按优先级顺序执行所有验证器，聚合验证结果
 // 实现 Validator 接口
@@ -140,11 +151,12 @@ func (c *ValidatorChain) Validate(ctx context.Context, content string) (*Validat
 	validators := make([]Validator, len(c.validators))
 	copy(validators, c.validators)
 	mode := c.mode
+	auditor, direction := c.auditor, c.direction
 	c.mu.RUnlock()
 
 	// 并行模式走独立路径
 	if mode == ChainModeParallel {
-		return c.validateParallel(ctx, validators, content)
+		return c.validateParallel(ctx, validators, content, auditor, direction)
 	}
 
 	// 按优先级排序（数字越小优先级越高）
@@ -175,6 +187,7 @@ func (c *ValidatorChain) Validate(ctx context.Context, content string) (*Validat
 
 		// 执行验证
 		vResult, err := v.Validate(ctx, content)
+		recordAudit(ctx, auditor, direction, v.Name(), content, vResult, err)
 		if err != nil {
 			result.AddError(ValidationError{
 				Code:     ErrCodeValidationFailed,
@@ -216,7 +229,7 @@ func (c *ValidatorChain) Validate(ctx context.Context, content string) (*Validat
 
 // validateParallel 并行执行所有验证器并收集结果。
 // 如果任何验证器返回 Tripwire，通过 context cancel 取消其他验证器。
-func (c *ValidatorChain) validateParallel(ctx context.Context, validators []Validator, content string) (*ValidationResult, error) {
+func (c *ValidatorChain) validateParallel(ctx context.Context, validators []Validator, content string, auditor AuditRecorder, direction AuditDirection) (*ValidationResult, error) {
 	if len(validators) == 0 {
 		result := NewValidationResult()
 		result.Metadata["validators_executed"] = make([]string, 0, 0)
@@ -244,6 +257,7 @@ func (c *ValidatorChain) validateParallel(ctx context.Context, validators []Vali
 		i, v := i, v
 		g.Go(func() error {
 			vResult, err := v.Validate(gctx, content)
+			recordAudit(ctx, auditor, direction, v.Name(), content, vResult, err)
 			results[i] = validatorResult{
 				name:   v.Name(),
 				result: vResult,
@@ -307,6 +321,7 @@ func (c *ValidatorChain) ValidateWithCallback(
 	c.mu.RLock()
 	validators := make([]Validator, len(c.validators))
 	copy(validators, c.validators)
+	auditor, direction := c.auditor, c.direction
 	c.mu.RUnlock()
 
 	// 按优先级排序
@@ -330,6 +345,7 @@ func (c *ValidatorChain) ValidateWithCallback(
 
 		// 执行验证
 		vResult, err := v.Validate(ctx, content)
+		recordAudit(ctx, auditor, direction, v.Name(), content, vResult, err)
 		if err != nil {
 			result.AddError(ValidationError{
 				Code:     ErrCodeValidationFailed,
@@ -355,6 +371,20 @@ func (c *ValidatorChain) ValidateWithCallback(
 	return result, nil
 }
 
+// recordAudit 向审计记录器上报单个验证器的执行结果
+func recordAudit(ctx context.Context, auditor AuditRecorder, direction AuditDirection, name, content string, result *ValidationResult, err error) {
+	if auditor == nil {
+		return
+	}
+	auditor.RecordDecision(ctx, AuditDecisionEvent{
+		Direction: direction,
+		Validator: name,
+		Content:   content,
+		Result:    result,
+		Err:       err,
+	})
+}
+
 // sortValidatorsByPriority 按优先级排序验证器（数字越小优先级越高）
 func sortValidatorsByPriority(validators []Validator) {
 	sort.Slice(validators, func(i, j int) bool {
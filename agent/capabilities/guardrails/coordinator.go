@@ -12,6 +12,7 @@ type Coordinator struct {
 	inputValidatorChain *ValidatorChain
 	outputValidator     *OutputValidator
 	config              *GuardrailsConfig
+	policyEngine        *PolicyEngine
 	enabled             bool
 	logger              *zap.Logger
 }
@@ -51,7 +52,7 @@ func (gc *Coordinator) initialize(cfg *GuardrailsConfig) {
 		gc.inputValidatorChain.Add(NewInjectionDetector(nil))
 	}
 	if cfg.PIIDetectionEnabled {
-		gc.inputValidatorChain.Add(NewPIIDetector(nil))
+		gc.inputValidatorChain.Add(NewPIIDetector(cfg.PIIDetectorConfig()))
 	}
 
 	outputConfig := &OutputValidatorConfig{
@@ -59,7 +60,27 @@ func (gc *Coordinator) initialize(cfg *GuardrailsConfig) {
 		Filters:        cfg.OutputFilters,
 		EnableAuditLog: true,
 	}
+	if cfg.AuditLogger != nil {
+		gc.inputValidatorChain.SetAuditor(cfg.AuditLogger, AuditDirectionInput)
+		outputConfig.AuditLogger = cfg.AuditLogger
+	}
 	gc.outputValidator = NewOutputValidator(outputConfig)
+	if cfg.SecretsDetection {
+		secrets := NewSecretsValidator(nil)
+		gc.outputValidator.AddValidator(secrets)
+		gc.outputValidator.AddFilter(secrets)
+	}
+	if cfg.PolicyFile != "" {
+		engine := NewPolicyEngine(&PolicyEngineConfig{Logger: gc.logger})
+		if err := engine.LoadFile(cfg.PolicyFile); err != nil {
+			gc.logger.Error("failed to load guardrail policies", zap.String("file", cfg.PolicyFile), zap.Error(err))
+		} else {
+			gc.policyEngine = engine
+			gc.inputValidatorChain.Add(engine)
+			gc.outputValidator.AddValidator(engine)
+			gc.outputValidator.AddFilter(engine)
+		}
+	}
 
 	gc.logger.Info("guardrails initialized",
 		zap.Int("input_validators", gc.inputValidatorChain.Len()),
@@ -125,6 +146,10 @@ func (gc *Coordinator) GetInputValidatorChain() *ValidatorChain {
 }
 func (gc *Coordinator) GetOutputValidator() *OutputValidator { return gc.outputValidator }
 
+// PolicyEngine 返回由 PolicyFile 加载的策略引擎，未配置时为 nil；
+// 可通过 BindHotReload 接入配置热重载。
+func (gc *Coordinator) PolicyEngine() *PolicyEngine { return gc.policyEngine }
+
 func (gc *Coordinator) InputValidatorCount() int {
 	if gc.inputValidatorChain == nil {
 		return 0
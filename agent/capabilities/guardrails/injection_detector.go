@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+
+	"github.com/BaSui01/agentflow/types"
 )
 
 var regexCache sync.Map
@@ -52,6 +54,18 @@ type InjectionDetectorConfig struct {
 	Priority int
 	// EnabledLanguages 启用的语言检测，为空则启用所有
 	EnabledLanguages []string
+	// Semantic 语义检测模式，与已知越狱语料做向量相似度比对；为空时仅使用正则模式
+	Semantic *SemanticInjectionConfig
+}
+
+// SemanticInjectionConfig 语义注入检测配置
+type SemanticInjectionConfig struct {
+	// Corpus 越狱语料库，可在运行时更新
+	Corpus *JailbreakCorpus
+	// Threshold 判定为命中的最低余弦相似度，默认 0.85
+	Threshold float64
+	// MaxMatches 单次检测最多报告的命中条目数，默认 3
+	MaxMatches int
 }
 
 // DefaultInjectionDetectorConfig 返回默认配置
@@ -72,6 +86,7 @@ type InjectionDetector struct {
 	caseSensitive bool
 	useDelimiters bool
 	priority      int
+	semantic      *SemanticInjectionConfig
 }
 
 // NewInjectionDetector 创建注入检测器
@@ -87,6 +102,17 @@ func NewInjectionDetector(config *InjectionDetectorConfig) *InjectionDetector {
 		priority:      config.Priority,
 	}
 
+	if config.Semantic != nil && config.Semantic.Corpus != nil {
+		semantic := *config.Semantic
+		if semantic.Threshold <= 0 {
+			semantic.Threshold = 0.85
+		}
+		if semantic.MaxMatches <= 0 {
+			semantic.MaxMatches = 3
+		}
+		detector.semantic = &semantic
+	}
+
 	// 加载默认注入模式
 	defaultPatterns := getDefaultInjectionPatterns(config.CaseSensitive)
 
@@ -302,6 +328,8 @@ type InjectionMatch struct {
 	Position    int    `json:"position"`
 	Length      int    `json:"length"`
 	MatchedText string `json:"matched_text"`
+	// Score 语义匹配的相似度，仅语义模式填充
+	Score float64 `json:"score,omitempty"`
 }
 
 // Validate 执行注入检测验证
@@ -311,6 +339,17 @@ func (d *InjectionDetector) Validate(ctx context.Context, content string) (*Vali
 
 	// 检测所有注入模式
 	matches := d.Detect(content)
+
+	// 语义检测失败时降级为仅正则检测
+	semanticMatches, version, err := d.DetectSemantic(ctx, content)
+	if err != nil {
+		result.AddWarning("semantic injection detection unavailable: " + err.Error())
+	}
+	if d.semantic != nil && err == nil {
+		result.Metadata["injection_corpus_version"] = version.String()
+	}
+	matches = append(matches, semanticMatches...)
+
 	if len(matches) == 0 {
 		return result, nil
 	}
@@ -373,6 +412,45 @@ func (d *InjectionDetector) Detect(content string) []InjectionMatch {
 	return matches
 }
 
+// DetectSemantic 将内容与越狱语料库做向量相似度比对，
+// 同时使用全局语料与 context 中租户的自定义语料；未启用语义模式时返回空结果。
+func (d *InjectionDetector) DetectSemantic(ctx context.Context, content string) ([]InjectionMatch, CorpusVersion, error) {
+	if d.semantic == nil {
+		return nil, CorpusVersion{}, nil
+	}
+	normalized := normalizeForDetection(content)
+	if strings.TrimSpace(normalized) == "" {
+		return nil, d.semantic.Corpus.Version(""), nil
+	}
+
+	tenantID, _ := types.TenantID(ctx)
+	corpusMatches, version, err := d.semantic.Corpus.Match(ctx, tenantID, normalized, d.semantic.Threshold)
+	if err != nil {
+		return nil, version, err
+	}
+	if len(corpusMatches) > d.semantic.MaxMatches {
+		corpusMatches = corpusMatches[:d.semantic.MaxMatches]
+	}
+
+	matches := make([]InjectionMatch, 0, len(corpusMatches))
+	for _, m := range corpusMatches {
+		description := "Semantic match to known jailbreak"
+		if m.Entry.Category != "" {
+			description += " (" + m.Entry.Category + ")"
+		}
+		matches = append(matches, InjectionMatch{
+			Pattern:     "corpus:" + m.Entry.ID,
+			Description: description,
+			Severity:    m.Entry.Severity,
+			Position:    0,
+			Length:      len(normalized),
+			MatchedText: m.Entry.Text,
+			Score:       m.Score,
+		})
+	}
+	return matches, version, nil
+}
+
 // normalizeForDetection 规范化输入文本用于注入检测。
 // 移除零宽字符、不可见 Unicode 控制字符，并将全角 ASCII 转为半角。
 func normalizeForDetection(s string) string {
@@ -0,0 +1,261 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// JailbreakEntry 语料库中的一条已知越狱提示
+type JailbreakEntry struct {
+	// ID 条目标识，为空时按文本哈希生成
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	// Category 攻击类别，如 "dan"、"role_play"、"prompt_leak"
+	Category string `json:"category,omitempty"`
+	// Severity 命中时的严重级别，默认 high
+	Severity string `json:"severity,omitempty"`
+}
+
+// CorpusVersion 语料库版本，全局语料与租户语料各自独立计数
+type CorpusVersion struct {
+	Global int64 `json:"global"`
+	Tenant int64 `json:"tenant,omitempty"`
+}
+
+// String 返回可写入审计日志的版本标识
+func (v CorpusVersion) String() string {
+	return fmt.Sprintf("global:%d,tenant:%d", v.Global, v.Tenant)
+}
+
+func (v CorpusVersion) forTenant(tenantID string) int64 {
+	if tenantID == "" {
+		return v.Global
+	}
+	return v.Tenant
+}
+
+// CorpusMatch 语料库相似度匹配结果
+type CorpusMatch struct {
+	Entry JailbreakEntry `json:"entry"`
+	Score float64        `json:"score"`
+	// TenantEntry 命中的是租户自定义语料
+	TenantEntry bool `json:"tenant_entry,omitempty"`
+}
+
+type embeddedJailbreak struct {
+	entry  JailbreakEntry
+	vector []float64
+}
+
+type corpusSet struct {
+	version int64
+	entries map[string]embeddedJailbreak
+}
+
+// JailbreakCorpus 已向量化的越狱提示语料库
+// 全局语料对所有租户生效，租户语料仅对对应租户生效（租户由 types.TenantID 从 context 读取）。
+// 语料可在运行时增删或整体替换，每次变更递增对应版本号。
+type JailbreakCorpus struct {
+	embedder Embedder
+
+	mu      sync.RWMutex
+	global  *corpusSet
+	tenants map[string]*corpusSet
+}
+
+// NewJailbreakCorpus 创建越狱语料库
+func NewJailbreakCorpus(embedder Embedder) *JailbreakCorpus {
+	return &JailbreakCorpus{
+		embedder: embedder,
+		global:   &corpusSet{entries: make(map[string]embeddedJailbreak)},
+		tenants:  make(map[string]*corpusSet),
+	}
+}
+
+// Upsert 新增或更新语料，tenantID 为空表示全局语料，返回变更后的版本号。
+// 向量化在锁外执行，失败时语料库保持不变。
+func (c *JailbreakCorpus) Upsert(ctx context.Context, tenantID string, entries ...JailbreakEntry) (int64, error) {
+	if len(entries) == 0 {
+		return c.Version(tenantID).forTenant(tenantID), nil
+	}
+	embedded, err := c.embed(ctx, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := c.setLocked(tenantID, true)
+	for _, e := range embedded {
+		set.entries[e.entry.ID] = e
+	}
+	return c.bumpLocked(set), nil
+}
+
+// Replace 用给定语料整体替换全局或租户语料，用于加载新发布的语料版本
+func (c *JailbreakCorpus) Replace(ctx context.Context, tenantID string, entries []JailbreakEntry) (int64, error) {
+	embedded, err := c.embed(ctx, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := c.setLocked(tenantID, true)
+	set.entries = make(map[string]embeddedJailbreak, len(embedded))
+	for _, e := range embedded {
+		set.entries[e.entry.ID] = e
+	}
+	return c.bumpLocked(set), nil
+}
+
+// Remove 删除指定条目，返回变更后的版本号；无条目被删除时版本不变
+func (c *JailbreakCorpus) Remove(tenantID string, ids ...string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := c.setLocked(tenantID, false)
+	if set == nil {
+		return 0
+	}
+	removed := false
+	for _, id := range ids {
+		if _, ok := set.entries[id]; ok {
+			delete(set.entries, id)
+			removed = true
+		}
+	}
+	if !removed {
+		return set.version
+	}
+	return c.bumpLocked(set)
+}
+
+// Version 返回对指定租户生效的语料版本
+func (c *JailbreakCorpus) Version(tenantID string) CorpusVersion {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.versionLocked(tenantID)
+}
+
+// Entries 返回全局或租户语料条目（按 ID 排序）
+func (c *JailbreakCorpus) Entries(tenantID string) []JailbreakEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	set := c.setLocked(tenantID, false)
+	if set == nil {
+		return nil
+	}
+	out := make([]JailbreakEntry, 0, len(set.entries))
+	for _, e := range set.entries {
+		out = append(out, e.entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Match 将内容与全局语料及租户语料比对，返回相似度不低于 threshold 的条目（按相似度降序）
+func (c *JailbreakCorpus) Match(ctx context.Context, tenantID, content string, threshold float64) ([]CorpusMatch, CorpusVersion, error) {
+	if c.embedder == nil {
+		return nil, CorpusVersion{}, errors.New("jailbreak corpus requires an embedder")
+	}
+	vector, err := c.embedder.EmbedQuery(ctx, content)
+	if err != nil {
+		return nil, CorpusVersion{}, fmt.Errorf("embed input: %w", err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var matches []CorpusMatch
+	collect := func(set *corpusSet, tenant bool) {
+		if set == nil {
+			return
+		}
+		for _, e := range set.entries {
+			if score := cosineSimilarity(vector, e.vector); score >= threshold {
+				matches = append(matches, CorpusMatch{Entry: e.entry, Score: score, TenantEntry: tenant})
+			}
+		}
+	}
+	collect(c.global, false)
+	if tenantID != "" {
+		collect(c.tenants[tenantID], true)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Entry.ID < matches[j].Entry.ID
+	})
+	return matches, c.versionLocked(tenantID), nil
+}
+
+func (c *JailbreakCorpus) embed(ctx context.Context, entries []JailbreakEntry) ([]embeddedJailbreak, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if c.embedder == nil {
+		return nil, errors.New("jailbreak corpus requires an embedder")
+	}
+	texts := make([]string, len(entries))
+	normalized := make([]JailbreakEntry, len(entries))
+	for i, entry := range entries {
+		entry.Text = strings.TrimSpace(entry.Text)
+		if entry.Text == "" {
+			return nil, fmt.Errorf("jailbreak entry %d has empty text", i)
+		}
+		if entry.ID == "" {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(entry.Text))
+			entry.ID = fmt.Sprintf("jb-%016x", h.Sum64())
+		}
+		if entry.Severity == "" {
+			entry.Severity = SeverityHigh
+		}
+		texts[i] = entry.Text
+		normalized[i] = entry
+	}
+
+	vectors, err := c.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed jailbreak corpus: %w", err)
+	}
+	if len(vectors) != len(entries) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d entries", len(vectors), len(entries))
+	}
+	out := make([]embeddedJailbreak, len(entries))
+	for i := range normalized {
+		out[i] = embeddedJailbreak{entry: normalized[i], vector: vectors[i]}
+	}
+	return out, nil
+}
+
+func (c *JailbreakCorpus) setLocked(tenantID string, create bool) *corpusSet {
+	if tenantID == "" {
+		return c.global
+	}
+	set, ok := c.tenants[tenantID]
+	if !ok && create {
+		set = &corpusSet{entries: make(map[string]embeddedJailbreak)}
+		c.tenants[tenantID] = set
+	}
+	return set
+}
+
+func (c *JailbreakCorpus) bumpLocked(set *corpusSet) int64 {
+	set.version++
+	return set.version
+}
+
+func (c *JailbreakCorpus) versionLocked(tenantID string) CorpusVersion {
+	v := CorpusVersion{Global: c.global.version}
+	if set, ok := c.tenants[tenantID]; ok && tenantID != "" {
+		v.Tenant = set.version
+	}
+	return v
+}
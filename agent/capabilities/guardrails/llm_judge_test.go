@@ -0,0 +1,129 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type judgeGatewayStub struct {
+	calls   atomic.Int32
+	reply   string
+	err     error
+	lastReq *llmcore.ChatRequest
+}
+
+func (g *judgeGatewayStub) Invoke(_ context.Context, req *llmcore.UnifiedRequest) (*llmcore.UnifiedResponse, error) {
+	g.calls.Add(1)
+	g.lastReq, _ = req.Payload.(*llmcore.ChatRequest)
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &llmcore.UnifiedResponse{Output: &llmcore.ChatResponse{
+		Choices: []llmcore.ChatChoice{{Message: types.Message{Role: types.RoleAssistant, Content: g.reply}}},
+	}}, nil
+}
+
+func (g *judgeGatewayStub) Stream(context.Context, *llmcore.UnifiedRequest) (<-chan llmcore.UnifiedChunk, error) {
+	return nil, nil
+}
+
+func TestLLMJudgeValidator_Violation(t *testing.T) {
+	gateway := &judgeGatewayStub{reply: "```json\n{\"allowed\": false, \"category\": \"self_harm\", \"severity\": \"HIGH\", \"rationale\": \"encourages self harm\"}\n```"}
+	v := NewLLMJudgeValidator(gateway, LLMJudgeConfig{
+		Model:            "judge-mini",
+		Rubric:           "No self-harm encouragement.",
+		Categories:       []string{"self_harm", "violence"},
+		TripwireSeverity: SeverityHigh,
+	}, nil)
+
+	result, err := v.Validate(context.Background(), "some risky text")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.True(t, result.Tripwire)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, ErrCodePolicyViolation, result.Errors[0].Code)
+	assert.Equal(t, SeverityHigh, result.Errors[0].Severity)
+	assert.Equal(t, "self_harm: encourages self harm", result.Errors[0].Message)
+
+	require.NotNil(t, gateway.lastReq)
+	assert.Equal(t, "judge-mini", gateway.lastReq.Model)
+	assert.Contains(t, gateway.lastReq.Messages[0].Content, "No self-harm encouragement.")
+	assert.Contains(t, gateway.lastReq.Messages[0].Content, "self_harm, violence")
+}
+
+func TestLLMJudgeValidator_CachesVerdicts(t *testing.T) {
+	gateway := &judgeGatewayStub{reply: `{"allowed": true}`}
+	v := NewLLMJudgeValidator(gateway, LLMJudgeConfig{}, nil)
+
+	for i := 0; i < 3; i++ {
+		result, err := v.Validate(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+	}
+	assert.Equal(t, int32(1), gateway.calls.Load())
+
+	result, err := v.Validate(context.Background(), "hello")
+	require.NoError(t, err)
+	verdict, ok := result.Metadata["llm_judge"].(JudgeVerdict)
+	require.True(t, ok)
+	assert.True(t, verdict.Cached)
+}
+
+func TestLLMJudgeValidator_Sampling(t *testing.T) {
+	gateway := &judgeGatewayStub{reply: `{"allowed": false, "severity": "low"}`}
+	v := NewLLMJudgeValidator(gateway, LLMJudgeConfig{SamplingRate: 0.25}, nil)
+	v.sample = func() float64 { return 0.5 }
+
+	result, err := v.Validate(context.Background(), "unsampled")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "sampled_out", result.Metadata["llm_judge_skipped"])
+	assert.Zero(t, gateway.calls.Load())
+
+	v.sample = func() float64 { return 0.1 }
+	result, err = v.Validate(context.Background(), "sampled")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.False(t, result.Tripwire, "tripwire disabled by default")
+}
+
+func TestLLMJudgeValidator_Fallback(t *testing.T) {
+	failure := errors.New("upstream down")
+	tests := []struct {
+		name      string
+		fallback  JudgeFallback
+		wantErr   bool
+		wantValid bool
+	}{
+		{name: "allow", fallback: JudgeFallbackAllow, wantValid: true},
+		{name: "block", fallback: JudgeFallbackBlock, wantValid: false},
+		{name: "error", fallback: JudgeFallbackError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewLLMJudgeValidator(&judgeGatewayStub{err: failure}, LLMJudgeConfig{Fallback: tt.fallback}, nil)
+			result, err := v.Validate(context.Background(), "content")
+			if tt.wantErr {
+				require.ErrorIs(t, err, failure)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, result.Valid)
+			assert.Contains(t, result.Metadata["llm_judge_error"], "upstream down")
+		})
+	}
+
+	v := NewLLMJudgeValidator(&judgeGatewayStub{reply: "I cannot answer"}, LLMJudgeConfig{}, nil)
+	result, err := v.Validate(context.Background(), "content")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.True(t, strings.Contains(result.Warnings[0], "llm judge unavailable"), "unparseable output uses the fallback")
+}
@@ -45,7 +45,7 @@ func (g *Manager) Init(cfg *GuardrailsConfig) {
 		g.inputValidatorChain.Add(NewInjectionDetector(nil))
 	}
 	if cfg.PIIDetectionEnabled {
-		g.inputValidatorChain.Add(NewPIIDetector(nil))
+		g.inputValidatorChain.Add(NewPIIDetector(cfg.PIIDetectorConfig()))
 	}
 
 	outputConfig := &OutputValidatorConfig{
@@ -53,6 +53,10 @@ func (g *Manager) Init(cfg *GuardrailsConfig) {
 		Filters:        cfg.OutputFilters,
 		EnableAuditLog: true,
 	}
+	if cfg.AuditLogger != nil {
+		g.inputValidatorChain.SetAuditor(cfg.AuditLogger, AuditDirectionInput)
+		outputConfig.AuditLogger = cfg.AuditLogger
+	}
 	g.outputValidator = NewOutputValidator(outputConfig)
 
 	g.logger.Info("guardrails initialized",
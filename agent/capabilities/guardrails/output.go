@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -118,6 +119,13 @@ func (v *OutputValidator) Validate(ctx context.Context, content string) (*Valida
 		}
 
 		vResult, err := validator.Validate(ctx, content)
+
+		// 审计记录器支持逐条决策时记录全部结果，否则仅记录验证失败事件
+		recorder, recordAll := auditLogger.(AuditRecorder)
+		if enableAuditLog && recordAll {
+			recordAudit(ctx, recorder, AuditDirectionOutput, validator.Name(), content, vResult, err)
+		}
+
 		if err != nil {
 			result.AddError(ValidationError{
 				Code:     ErrCodeValidationFailed,
@@ -130,7 +138,7 @@ func (v *OutputValidator) Validate(ctx context.Context, content string) (*Valida
 		result.Merge(vResult)
 
 		// 记录验证失败事件
-		if enableAuditLog && auditLogger != nil && !vResult.Valid {
+		if enableAuditLog && auditLogger != nil && !recordAll && !vResult.Valid {
 			v.logValidationFailure(ctx, auditLogger, validator.Name(), content, vResult)
 		}
 	}
@@ -243,6 +251,14 @@ func (v *OutputValidator) SetSafeReplacement(replacement string) {
 	v.safeReplacement = replacement
 }
 
+// SetAuditLogger 设置审计日志记录器并启用审计
+func (v *OutputValidator) SetAuditLogger(logger AuditLogger) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.auditLogger = logger
+	v.enableAuditLog = logger != nil
+}
+
 // GetAuditLogger 获取审计日志记录器
 func (v *OutputValidator) GetAuditLogger() AuditLogger {
 	v.mu.RLock()
@@ -492,6 +508,8 @@ const (
 	AuditEventPIIDetected AuditEventType = "pii_detected"
 	// AuditEventInjectionDetected 注入检测事件
 	AuditEventInjectionDetected AuditEventType = "injection_detected"
+	// AuditEventValidationPassed 验证通过事件（仅 GuardrailAuditLogger 记录）
+	AuditEventValidationPassed AuditEventType = "validation_passed"
 )
 
 // AuditLogEntry 审计日志条目
@@ -509,6 +527,27 @@ type AuditLogEntry struct {
 	Errors []ValidationError `json:"errors,omitempty"`
 	// Metadata 附加元数据
 	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// 以下字段由 GuardrailAuditLogger 填充
+
+	// ID 记录 ID
+	ID string `json:"id,omitempty"`
+	// Direction 校验方向：input、output、tool_call
+	Direction AuditDirection `json:"direction,omitempty"`
+	// Decision 校验结论
+	Decision AuditDecision `json:"decision,omitempty"`
+	// MatchedRules 命中的策略、规则或错误码
+	MatchedRules []string `json:"matched_rules,omitempty"`
+	// Redaction 脱敏摘要，不含原文
+	Redaction *RedactionSummary `json:"redaction,omitempty"`
+	// TenantID 租户 ID
+	TenantID string `json:"tenant_id,omitempty"`
+	// TraceID 链路追踪 ID
+	TraceID string `json:"trace_id,omitempty"`
+	// RunID 运行 ID
+	RunID string `json:"run_id,omitempty"`
+	// ToolName 工具调用校验时的工具名
+	ToolName string `json:"tool_name,omitempty"`
 }
 
 // AuditLogger 护栏层审计日志记录器接口。
@@ -538,6 +577,14 @@ type AuditLogFilter struct {
 	EventTypes []AuditEventType
 	// ValidatorNames 验证器名称过滤
 	ValidatorNames []string
+	// Decisions 校验结论过滤
+	Decisions []AuditDecision
+	// Directions 校验方向过滤
+	Directions []AuditDirection
+	// TenantID 租户过滤
+	TenantID string
+	// TraceID 链路追踪 ID 过滤
+	TraceID string
 	// Limit 返回数量限制
 	Limit int
 	// Offset 偏移量
@@ -680,6 +727,22 @@ func (l *MemoryAuditLogger) matchFilter(entry *AuditLogEntry, filter *AuditLogFi
 		}
 	}
 
+	// 结论与方向过滤
+	if len(filter.Decisions) > 0 && !slices.Contains(filter.Decisions, entry.Decision) {
+		return false
+	}
+	if len(filter.Directions) > 0 && !slices.Contains(filter.Directions, entry.Direction) {
+		return false
+	}
+
+	// 租户与链路过滤
+	if filter.TenantID != "" && entry.TenantID != filter.TenantID {
+		return false
+	}
+	if filter.TraceID != "" && entry.TraceID != filter.TraceID {
+		return false
+	}
+
 	return true
 }
 
@@ -53,6 +53,14 @@ type PIIDetectorConfig struct {
 	CustomPatterns map[PIIType]*regexp.Regexp
 	// Priority 验证器优先级
 	Priority int
+
+	// Locales 启用的地区规则包（如 zh-CN、eu、ja-JP、pt-BR、en-US），
+	// 包含 "auto" 时按内容语言自动选择；设置后改用 Registry 中的识别器检测
+	Locales []string
+	// Registry 识别器注册表，为空时使用 DefaultRecognizerRegistry
+	Registry *RecognizerRegistry
+	// MaskFormats 按实体类型覆盖脱敏格式
+	MaskFormats map[PIIType]PIIMaskFormat
 }
 
 // DefaultPIIDetectorConfig 返回默认配置
@@ -71,6 +79,13 @@ type PIIDetector struct {
 	patterns map[PIIType]*regexp.Regexp
 	action   PIIAction
 	priority int
+
+	// 地区规则包模式：registry 非空时生效
+	registry     *RecognizerRegistry
+	locales      []string
+	autoLocale   bool
+	enabledTypes map[PIIType]bool
+	maskFormats  map[PIIType]PIIMaskFormat
 }
 
 // NewPIIDetector 创建 PII 检测器
@@ -109,9 +124,83 @@ func NewPIIDetector(config *PIIDetectorConfig) *PIIDetector {
 		}
 	}
 
+	detector.maskFormats = config.MaskFormats
+	if len(config.Locales) > 0 || config.Registry != nil {
+		detector.initRecognizers(config)
+	}
+
 	return detector
 }
 
+// initRecognizers 启用地区规则包模式；自定义正则作为全局识别器加入
+func (d *PIIDetector) initRecognizers(config *PIIDetectorConfig) {
+	switch {
+	case config.Registry == nil:
+		d.registry = DefaultRecognizerRegistry()
+	case len(config.CustomPatterns) > 0:
+		d.registry = config.Registry.Clone()
+	default:
+		d.registry = config.Registry
+	}
+	for _, locale := range config.Locales {
+		if strings.EqualFold(strings.TrimSpace(locale), PIILocaleAuto) {
+			d.autoLocale = true
+			continue
+		}
+		d.locales = append(d.locales, strings.TrimSpace(locale))
+	}
+	if len(config.EnabledTypes) > 0 {
+		d.enabledTypes = make(map[PIIType]bool, len(config.EnabledTypes))
+		for _, piiType := range config.EnabledTypes {
+			d.enabledTypes[piiType] = true
+		}
+	}
+	for piiType, pattern := range config.CustomPatterns {
+		d.registry.Register(&PatternRecognizer{
+			RecognizerName: "custom_" + string(piiType),
+			Type:           piiType,
+			LocaleCodes:    []string{PIILocaleGlobal},
+			Pattern:        pattern,
+		})
+	}
+}
+
+// localesFor 返回检测 content 时使用的地区规则包，auto 模式下叠加语言识别结果
+func (d *PIIDetector) localesFor(content string) []string {
+	if !d.autoLocale {
+		return d.locales
+	}
+	locales := append([]string(nil), d.locales...)
+	return append(locales, LocalesForLanguage(DetectLanguage(content))...)
+}
+
+// detectWithRecognizers 运行识别器并去除重叠匹配
+func (d *PIIDetector) detectWithRecognizers(content string) []PIIMatch {
+	var matches []PIIMatch
+	for _, recognizer := range d.registry.Recognizers(d.localesFor(content)) {
+		if d.enabledTypes != nil && !d.enabledTypes[recognizer.Entity()] {
+			continue
+		}
+		matches = append(matches, recognizer.Recognize(content)...)
+	}
+	matches = resolveOverlaps(matches)
+	for i := range matches {
+		matches[i].Masked = d.maskMatch(matches[i].Type, matches[i].Value)
+	}
+	return matches
+}
+
+// maskMatch 优先使用配置的脱敏格式，其次为扩展实体的默认格式与内置 maskValue
+func (d *PIIDetector) maskMatch(piiType PIIType, value string) string {
+	if format, ok := d.maskFormats[piiType]; ok {
+		return format.Apply(piiType, value)
+	}
+	if format, ok := defaultLocaleMaskFormats[piiType]; ok {
+		return format.Apply(piiType, value)
+	}
+	return maskValue(piiType, value)
+}
+
 // getDefaultPatterns 返回默认的 PII 正则模式
 func getDefaultPatterns() map[PIIType]*regexp.Regexp {
 	return map[PIIType]*regexp.Regexp{
@@ -175,9 +264,12 @@ func (d *PIIDetector) Validate(ctx context.Context, content string) (*Validation
 		for piiType, count := range detectedTypes {
 			result.AddWarning(formatPIIWarningMessage(piiType, count))
 		}
-		result.Metadata["masked_content"] = d.Mask(content)
+		result.Metadata["masked_content"] = d.maskWithMatches(content, matches)
 		result.Metadata["pii_matches"] = matches
 	}
+	if d.autoLocale {
+		result.Metadata["pii_language"] = DetectLanguage(content)
+	}
 
 	// 记录检测到的 PII 信息到 metadata
 	result.Metadata["pii_detected"] = true
@@ -188,6 +280,10 @@ func (d *PIIDetector) Validate(ctx context.Context, content string) (*Validation
 
 // Detect 检测内容中的所有 PII
 func (d *PIIDetector) Detect(content string) []PIIMatch {
+	if d.registry != nil {
+		return d.detectWithRecognizers(content)
+	}
+
 	var matches []PIIMatch
 
 	for piiType, pattern := range d.patterns {
@@ -197,7 +293,7 @@ func (d *PIIDetector) Detect(content string) []PIIMatch {
 			matches = append(matches, PIIMatch{
 				Type:     piiType,
 				Value:    value,
-				Masked:   maskValue(piiType, value),
+				Masked:   d.maskMatch(piiType, value),
 				Position: loc[0],
 				Length:   loc[1] - loc[0],
 			})
@@ -209,17 +305,37 @@ func (d *PIIDetector) Detect(content string) []PIIMatch {
 
 // Mask 对内容中的 PII 进行脱敏处理
 func (d *PIIDetector) Mask(content string) string {
+	if d.registry != nil {
+		return d.maskWithMatches(content, d.detectWithRecognizers(content))
+	}
+
 	result := content
 
 	for piiType, pattern := range d.patterns {
 		result = pattern.ReplaceAllStringFunc(result, func(match string) string {
-			return maskValue(piiType, match)
+			return d.maskMatch(piiType, match)
 		})
 	}
 
 	return result
 }
 
+// maskWithMatches 按检测结果替换；传统模式沿用逐个正则替换
+func (d *PIIDetector) maskWithMatches(content string, matches []PIIMatch) string {
+	if d.registry == nil {
+		return d.Mask(content)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(content[last:m.Position])
+		b.WriteString(m.Masked)
+		last = m.Position + m.Length
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
 // Filter 实现 Filter 接口，对内容进行脱敏过滤
 func (d *PIIDetector) Filter(ctx context.Context, content string) (string, error) {
 	return d.Mask(content), nil
@@ -282,7 +398,7 @@ func formatPIIErrorMessage(piiType PIIType, count int) string {
 	}
 	typeName := typeNames[piiType]
 	if typeName == "" {
-		typeName = string(piiType)
+		typeName = piiTypeDisplayName(piiType)
 	}
 	return "检测到 " + typeName + " 信息，已拒绝处理"
 }
@@ -298,7 +414,25 @@ func formatPIIWarningMessage(piiType PIIType, count int) string {
 	}
 	typeName := typeNames[piiType]
 	if typeName == "" {
-		typeName = string(piiType)
+		typeName = piiTypeDisplayName(piiType)
 	}
 	return "检测到 " + typeName + " 信息"
 }
+
+// piiTypeDisplayName 返回地区规则包扩展实体的显示名称
+func piiTypeDisplayName(piiType PIIType) string {
+	switch piiType {
+	case PIITypeSSN:
+		return "社会安全号"
+	case PIITypeIBAN:
+		return "IBAN 账号"
+	case PIITypeVAT:
+		return "增值税号"
+	case PIITypeMyNumber:
+		return "个人编号"
+	case PIITypeCPF:
+		return "CPF 税号"
+	default:
+		return string(piiType)
+	}
+}
@@ -0,0 +1,570 @@
+package guardrails
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 地区规则包扩展的 PII 类型
+const (
+	// PIITypeSSN 美国社会安全号
+	PIITypeSSN PIIType = "ssn"
+	// PIITypeIBAN 国际银行账号
+	PIITypeIBAN PIIType = "iban"
+	// PIITypeVAT 欧盟增值税号
+	PIITypeVAT PIIType = "vat_id"
+	// PIITypeMyNumber 日本个人编号
+	PIITypeMyNumber PIIType = "my_number"
+	// PIITypeCPF 巴西自然人税号
+	PIITypeCPF PIIType = "cpf"
+)
+
+// 内置地区规则包
+const (
+	// PIILocaleGlobal 与地区无关的实体（邮箱、通过 Luhn 校验的银行卡号），始终启用
+	PIILocaleGlobal = "global"
+	PIILocaleUS     = "en-US"
+	PIILocaleEU     = "eu"
+	PIILocaleCN     = "zh-CN"
+	PIILocaleJP     = "ja-JP"
+	PIILocaleBR     = "pt-BR"
+	// PIILocaleAuto 按内容语言自动选择规则包
+	PIILocaleAuto = "auto"
+)
+
+// PIIRecognizer 实体识别器，可通过 RecognizerRegistry 注册自定义实现
+type PIIRecognizer interface {
+	// Name 识别器名称，同名注册会覆盖
+	Name() string
+	// Entity 识别的实体类型
+	Entity() PIIType
+	// Locales 适用的地区规则包，包含 PIILocaleGlobal 时始终启用
+	Locales() []string
+	// Recognize 返回内容中的匹配，只需填写 Type、Value、Position、Length
+	Recognize(content string) []PIIMatch
+}
+
+// PatternRecognizer 基于正则与可选校验函数的识别器
+type PatternRecognizer struct {
+	RecognizerName string
+	Type           PIIType
+	LocaleCodes    []string
+	Pattern        *regexp.Regexp
+	// Validate 过滤误报，如校验位检查；为空时正则命中即视为匹配
+	Validate func(value string) bool
+}
+
+// Name 实现 PIIRecognizer
+func (r *PatternRecognizer) Name() string { return r.RecognizerName }
+
+// Entity 实现 PIIRecognizer
+func (r *PatternRecognizer) Entity() PIIType { return r.Type }
+
+// Locales 实现 PIIRecognizer
+func (r *PatternRecognizer) Locales() []string { return r.LocaleCodes }
+
+// Recognize 实现 PIIRecognizer
+func (r *PatternRecognizer) Recognize(content string) []PIIMatch {
+	var matches []PIIMatch
+	for _, loc := range r.Pattern.FindAllStringIndex(content, -1) {
+		value := content[loc[0]:loc[1]]
+		if r.Validate != nil && !r.Validate(value) {
+			continue
+		}
+		matches = append(matches, PIIMatch{
+			Type:     r.Type,
+			Value:    value,
+			Position: loc[0],
+			Length:   loc[1] - loc[0],
+		})
+	}
+	return matches
+}
+
+// RecognizerRegistry 识别器注册表，并发安全
+type RecognizerRegistry struct {
+	mu          sync.RWMutex
+	recognizers map[string]PIIRecognizer
+	order       []string
+}
+
+// NewRecognizerRegistry 创建空注册表
+func NewRecognizerRegistry() *RecognizerRegistry {
+	return &RecognizerRegistry{recognizers: make(map[string]PIIRecognizer)}
+}
+
+// DefaultRecognizerRegistry 创建包含全部内置地区规则包的注册表
+func DefaultRecognizerRegistry() *RecognizerRegistry {
+	registry := NewRecognizerRegistry()
+	for _, r := range builtinRecognizers() {
+		registry.Register(r)
+	}
+	return registry
+}
+
+// Register 注册识别器，同名识别器会被替换
+func (r *RecognizerRegistry) Register(recognizer PIIRecognizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := recognizer.Name()
+	if _, exists := r.recognizers[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.recognizers[name] = recognizer
+}
+
+// Unregister 移除识别器
+func (r *RecognizerRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.recognizers[name]; !exists {
+		return
+	}
+	delete(r.recognizers, name)
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Clone 复制注册表，修改副本不影响原注册表
+func (r *RecognizerRegistry) Clone() *RecognizerRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clone := NewRecognizerRegistry()
+	for _, name := range r.order {
+		clone.recognizers[name] = r.recognizers[name]
+	}
+	clone.order = append(clone.order, r.order...)
+	return clone
+}
+
+// Recognizers 返回适用于给定地区的识别器；locales 为空时返回全部。
+// 地区按语言前缀匹配，如 "zh" 匹配 "zh-CN"。
+func (r *RecognizerRegistry) Recognizers(locales []string) []PIIRecognizer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]PIIRecognizer, 0, len(r.order))
+	for _, name := range r.order {
+		recognizer := r.recognizers[name]
+		if len(locales) == 0 || localesOverlap(recognizer.Locales(), locales) {
+			out = append(out, recognizer)
+		}
+	}
+	return out
+}
+
+func localesOverlap(recognizerLocales, wanted []string) bool {
+	for _, have := range recognizerLocales {
+		have = strings.ToLower(have)
+		if have == PIILocaleGlobal {
+			return true
+		}
+		for _, want := range wanted {
+			want = strings.ToLower(want)
+			if have == want || strings.HasPrefix(have, want+"-") || strings.HasPrefix(want, have+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PIIMaskStyle 脱敏样式
+type PIIMaskStyle string
+
+const (
+	// PIIMaskPartial 保留首尾字符，其余替换为掩码字符
+	PIIMaskPartial PIIMaskStyle = "partial"
+	// PIIMaskFull 全部替换为掩码字符
+	PIIMaskFull PIIMaskStyle = "full"
+	// PIIMaskLabel 替换为实体标签，如 [IBAN]
+	PIIMaskLabel PIIMaskStyle = "label"
+	// PIIMaskHash 替换为实体标签加短哈希，便于关联同一值而不泄露原文
+	PIIMaskHash PIIMaskStyle = "hash"
+)
+
+// PIIMaskFormat 单个实体类型的脱敏格式
+type PIIMaskFormat struct {
+	Style      PIIMaskStyle `json:"style" yaml:"style"`
+	KeepPrefix int          `json:"keep_prefix,omitempty" yaml:"keep_prefix,omitempty"`
+	KeepSuffix int          `json:"keep_suffix,omitempty" yaml:"keep_suffix,omitempty"`
+	// MaskChar 掩码字符，默认 "*"
+	MaskChar string `json:"mask_char,omitempty" yaml:"mask_char,omitempty"`
+	// Label label/hash 样式使用的标签，默认 "[<TYPE>]"
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+}
+
+// defaultLocaleMaskFormats 扩展实体的默认脱敏格式；原有实体沿用 maskValue
+var defaultLocaleMaskFormats = map[PIIType]PIIMaskFormat{
+	PIITypeSSN:      {Style: PIIMaskPartial, KeepSuffix: 4},
+	PIITypeIBAN:     {Style: PIIMaskPartial, KeepPrefix: 4, KeepSuffix: 4},
+	PIITypeVAT:      {Style: PIIMaskPartial, KeepPrefix: 2, KeepSuffix: 2},
+	PIITypeMyNumber: {Style: PIIMaskPartial, KeepSuffix: 4},
+	PIITypeCPF:      {Style: PIIMaskPartial, KeepSuffix: 2},
+}
+
+// Apply 按格式脱敏，非分隔字符才会被替换以保留原有排版
+func (f PIIMaskFormat) Apply(piiType PIIType, value string) string {
+	maskChar := f.MaskChar
+	if maskChar == "" {
+		maskChar = "*"
+	}
+	label := f.Label
+	if label == "" {
+		label = "[" + strings.ToUpper(string(piiType)) + "]"
+	}
+	switch f.Style {
+	case PIIMaskLabel:
+		return label
+	case PIIMaskHash:
+		sum := sha256.Sum256([]byte(value))
+		return strings.TrimSuffix(label, "]") + ":" + hex.EncodeToString(sum[:4]) + "]"
+	case PIIMaskFull:
+		return maskRunes([]rune(value), 0, 0, maskChar)
+	default:
+		return maskRunes([]rune(value), f.KeepPrefix, f.KeepSuffix, maskChar)
+	}
+}
+
+// maskRunes 按字母数字计数保留首尾，分隔符（空格、横线、点）原样保留
+func maskRunes(runes []rune, keepPrefix, keepSuffix int, maskChar string) string {
+	total := 0
+	for _, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			total++
+		}
+	}
+	if keepPrefix+keepSuffix >= total {
+		keepPrefix, keepSuffix = 0, 0
+	}
+	var b strings.Builder
+	seen := 0
+	for _, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if seen < keepPrefix || seen >= total-keepSuffix {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(maskChar)
+		}
+		seen++
+	}
+	return b.String()
+}
+
+// DetectLanguage 基于文字系统与特征字符的轻量语言识别，返回 ISO 639-1 代码；
+// 无法判断时返回空字符串。只用于选择 PII 规则包，不追求通用准确度。
+func DetectLanguage(content string) string {
+	var han, kana, hangul, latin int
+	for _, r := range content {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	switch {
+	case kana > 0:
+		return "ja"
+	case han > 0 && han >= hangul:
+		return "zh"
+	case hangul > 0:
+		return "ko"
+	case latin == 0:
+		return ""
+	}
+
+	lower := " " + strings.ToLower(content) + " "
+	best, bestScore := "en", 0
+	for _, lang := range latinLanguageOrder {
+		score := 0
+		for _, marker := range latinLanguageMarkers[lang] {
+			score += strings.Count(lower, marker)
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
+
+var latinLanguageOrder = []string{"pt", "es", "fr", "de", "it", "nl", "en"}
+
+// latinLanguageMarkers 各语言的特征字符与高频词
+var latinLanguageMarkers = map[string][]string{
+	"pt": {"ã", "õ", " não ", " você ", " meu ", " é ", " de ", " com "},
+	"es": {"ñ", "¿", "¡", " el ", " mi ", " es ", " con ", " número "},
+	"fr": {"è", "ê", " le ", " les ", " mon ", " est ", " numéro ", " avec "},
+	"de": {"ä", "ö", "ü", "ß", " der ", " die ", " und ", " ist ", " mein "},
+	"it": {"ò", " il ", " che ", " mio ", " è ", " numero "},
+	"nl": {"ij", " het ", " een ", " mijn ", " is ", " van "},
+	"en": {" the ", " my ", " is ", " and ", " number ", " please "},
+}
+
+// LocalesForLanguage 返回语言对应的地区规则包；未知语言返回全部内置规则包
+func LocalesForLanguage(lang string) []string {
+	switch lang {
+	case "zh":
+		return []string{PIILocaleCN}
+	case "ja":
+		return []string{PIILocaleJP}
+	case "pt":
+		return []string{PIILocaleBR, PIILocaleEU}
+	case "en":
+		return []string{PIILocaleUS, PIILocaleEU}
+	case "de", "fr", "es", "it", "nl":
+		return []string{PIILocaleEU}
+	default:
+		return []string{PIILocaleUS, PIILocaleEU, PIILocaleCN, PIILocaleJP, PIILocaleBR}
+	}
+}
+
+// builtinRecognizers 内置地区规则包
+func builtinRecognizers() []PIIRecognizer {
+	return []PIIRecognizer{
+		&PatternRecognizer{
+			RecognizerName: "global_email",
+			Type:           PIITypeEmail,
+			LocaleCodes:    []string{PIILocaleGlobal},
+			Pattern:        regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "global_payment_card",
+			Type:           PIITypeBankCard,
+			LocaleCodes:    []string{PIILocaleGlobal},
+			Pattern:        regexp.MustCompile(`\b\d{4}(?:[ -]?\d{4}){2}[ -]?\d{1,7}\b`),
+			Validate:       func(v string) bool { return luhnValid(digitsOnly(v)) },
+		},
+		&PatternRecognizer{
+			RecognizerName: "us_ssn",
+			Type:           PIITypeSSN,
+			LocaleCodes:    []string{PIILocaleUS},
+			Pattern:        regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+			Validate:       validSSN,
+		},
+		&PatternRecognizer{
+			RecognizerName: "us_phone",
+			Type:           PIITypePhone,
+			LocaleCodes:    []string{PIILocaleUS},
+			Pattern:        regexp.MustCompile(`(?:\+1[ .-]?)?(?:\([2-9]\d{2}\)|\b[2-9]\d{2})[ .-]?[2-9]\d{2}[ .-]\d{4}\b`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "eu_phone",
+			Type:           PIITypePhone,
+			LocaleCodes:    []string{PIILocaleEU},
+			Pattern:        regexp.MustCompile(`\+(?:3\d|4[0-9]|35\d|37\d|38\d)[ -]?\(?\d{1,4}\)?(?:[ -]?\d{2,4}){2,4}\b`),
+			Validate: func(v string) bool {
+				n := len(digitsOnly(v))
+				return n >= 9 && n <= 15
+			},
+		},
+		&PatternRecognizer{
+			RecognizerName: "eu_iban",
+			Type:           PIITypeIBAN,
+			LocaleCodes:    []string{PIILocaleEU},
+			Pattern:        regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+			Validate:       validIBAN,
+		},
+		&PatternRecognizer{
+			RecognizerName: "eu_vat",
+			Type:           PIITypeVAT,
+			LocaleCodes:    []string{PIILocaleEU},
+			Pattern:        regexp.MustCompile(`\b(?:ATU\d{8}|BE[01]\d{9}|DE\d{9}|DK\d{8}|ES[A-Z0-9]\d{7}[A-Z0-9]|FI\d{8}|FR[A-HJ-NP-Z0-9]{2}\d{9}|IE\d[A-Z0-9]\d{5}[A-Z]{1,2}|IT\d{11}|LU\d{8}|NL\d{9}B\d{2}|PL\d{10}|PT\d{9}|SE\d{12})\b`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "cn_phone",
+			Type:           PIITypePhone,
+			LocaleCodes:    []string{PIILocaleCN},
+			Pattern:        regexp.MustCompile(`(?:\+?86[ -]?)?\b1[3-9]\d{9}\b`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "cn_id_card",
+			Type:           PIITypeIDCard,
+			LocaleCodes:    []string{PIILocaleCN},
+			Pattern:        regexp.MustCompile(`\b[1-9]\d{5}(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`),
+			Validate:       validCNIDCard,
+		},
+		&PatternRecognizer{
+			RecognizerName: "cn_bank_card",
+			Type:           PIITypeBankCard,
+			LocaleCodes:    []string{PIILocaleCN},
+			Pattern:        regexp.MustCompile(`\b62\d{14,17}\b`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "jp_my_number",
+			Type:           PIITypeMyNumber,
+			LocaleCodes:    []string{PIILocaleJP},
+			Pattern:        regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}\b`),
+			Validate:       validMyNumber,
+		},
+		&PatternRecognizer{
+			RecognizerName: "jp_phone",
+			Type:           PIITypePhone,
+			LocaleCodes:    []string{PIILocaleJP},
+			Pattern:        regexp.MustCompile(`(?:\+81[ -]?|\b0)(?:[789]0[ -]?\d{4}[ -]?\d{4}|\d{1,4}-\d{1,4}-\d{4})\b`),
+		},
+		&PatternRecognizer{
+			RecognizerName: "br_cpf",
+			Type:           PIITypeCPF,
+			LocaleCodes:    []string{PIILocaleBR},
+			Pattern:        regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`),
+			Validate:       validCPF,
+		},
+		&PatternRecognizer{
+			RecognizerName: "br_phone",
+			Type:           PIITypePhone,
+			LocaleCodes:    []string{PIILocaleBR},
+			Pattern:        regexp.MustCompile(`\+55[ -]?\(?\d{2}\)?[ -]?9?\d{4}-?\d{4}\b`),
+		},
+	}
+}
+
+// resolveOverlaps 按位置排序并去除重叠匹配，重叠时保留更长的一项
+func resolveOverlaps(matches []PIIMatch) []PIIMatch {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Position != matches[j].Position {
+			return matches[i].Position < matches[j].Position
+		}
+		return matches[i].Length > matches[j].Length
+	})
+	out := matches[:0]
+	end := -1
+	for _, m := range matches {
+		if m.Position < end {
+			if last := &out[len(out)-1]; m.Length > last.Length {
+				*last = m
+				end = m.Position + m.Length
+			}
+			continue
+		}
+		out = append(out, m)
+		end = m.Position + m.Length
+	}
+	return out
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func luhnValid(digits string) bool {
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func validSSN(v string) bool {
+	d := digitsOnly(v)
+	area, group, serial := d[:3], d[3:5], d[5:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+func validIBAN(v string) bool {
+	iban := strings.ReplaceAll(v, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	var numeric strings.Builder
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			numeric.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			numeric.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(numeric.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func validCNIDCard(v string) bool {
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	const checkCodes = "10X98765432"
+	sum := 0
+	for i, w := range weights {
+		sum += int(v[i]-'0') * w
+	}
+	return strings.ToUpper(v[17:]) == string(checkCodes[sum%11])
+}
+
+func validMyNumber(v string) bool {
+	d := digitsOnly(v)
+	if len(d) != 12 {
+		return false
+	}
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		p := int(d[11-n] - '0')
+		q := n + 1
+		if n >= 7 {
+			q = n - 5
+		}
+		sum += p * q
+	}
+	check := 0
+	if r := sum % 11; r > 1 {
+		check = 11 - r
+	}
+	return int(d[11]-'0') == check
+}
+
+func validCPF(v string) bool {
+	d := digitsOnly(v)
+	if len(d) != 11 || strings.Count(d, d[:1]) == 11 {
+		return false
+	}
+	for _, length := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < length; i++ {
+			sum += int(d[i]-'0') * (length + 1 - i)
+		}
+		check := sum * 10 % 11
+		if check == 10 {
+			check = 0
+		}
+		if int(d[length]-'0') != check {
+			return false
+		}
+	}
+	return true
+}
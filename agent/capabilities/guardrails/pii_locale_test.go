@@ -0,0 +1,204 @@
+package guardrails
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIIChecksumValidators(t *testing.T) {
+	assert.True(t, luhnValid("4111111111111111"))
+	assert.False(t, luhnValid("4111111111111112"))
+
+	assert.True(t, validSSN("123-45-6789"))
+	assert.False(t, validSSN("000-45-6789"))
+	assert.False(t, validSSN("666-45-6789"))
+
+	assert.True(t, validIBAN("DE89 3704 0044 0532 0130 00"))
+	assert.False(t, validIBAN("DE88 3704 0044 0532 0130 00"))
+
+	assert.True(t, validCNIDCard("11010519491231002X"))
+	assert.False(t, validCNIDCard("110105194912310021"))
+
+	assert.True(t, validMyNumber("1234 5678 9018"))
+	assert.False(t, validMyNumber("1234 5678 9012"))
+
+	assert.True(t, validCPF("529.982.247-25"))
+	assert.False(t, validCPF("529.982.247-24"))
+	assert.False(t, validCPF("111.111.111-11"))
+}
+
+func TestPIIDetector_LocalePacks(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		content string
+		want    PIIType
+		value   string
+	}{
+		{"us ssn", PIILocaleUS, "SSN 123-45-6789 on file", PIITypeSSN, "123-45-6789"},
+		{"us phone", PIILocaleUS, "call (415) 555-2671 today", PIITypePhone, "(415) 555-2671"},
+		{"eu iban", PIILocaleEU, "IBAN DE89 3704 0044 0532 0130 00 bitte", PIITypeIBAN, "DE89 3704 0044 0532 0130 00"},
+		{"eu vat", PIILocaleEU, "USt-IdNr DE123456789", PIITypeVAT, "DE123456789"},
+		{"eu phone", PIILocaleEU, "Tel +49 30 1234 5678", PIITypePhone, "+49 30 1234 5678"},
+		{"cn id card", PIILocaleCN, "身份证 11010519491231002X 已登记", PIITypeIDCard, "11010519491231002X"},
+		{"cn phone", PIILocaleCN, "手机 13812345678", PIITypePhone, "13812345678"},
+		{"jp my number", PIILocaleJP, "マイナンバー 1234-5678-9018", PIITypeMyNumber, "1234-5678-9018"},
+		{"jp phone", PIILocaleJP, "電話 090-1234-5678", PIITypePhone, "090-1234-5678"},
+		{"br cpf", PIILocaleBR, "CPF 529.982.247-25", PIITypeCPF, "529.982.247-25"},
+		{"br phone", PIILocaleBR, "fone +55 11 91234-5678", PIITypePhone, "+55 11 91234-5678"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewPIIDetector(&PIIDetectorConfig{Locales: []string{tt.locale}})
+			matches := detector.Detect(tt.content)
+			require.Len(t, matches, 1)
+			assert.Equal(t, tt.want, matches[0].Type)
+			assert.Equal(t, tt.value, matches[0].Value)
+			assert.Equal(t, tt.value, tt.content[matches[0].Position:matches[0].Position+matches[0].Length])
+			assert.NotEqual(t, tt.value, matches[0].Masked)
+		})
+	}
+}
+
+func TestPIIDetector_LocaleChecksumRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		PIILocaleBR: "CPF 529.982.247-24",
+		PIILocaleEU: "IBAN DE88 3704 0044 0532 0130 00",
+		PIILocaleJP: "注文 1234 5678 9012",
+	}
+	for locale, content := range tests {
+		detector := NewPIIDetector(&PIIDetectorConfig{Locales: []string{locale}})
+		assert.Empty(t, detector.Detect(content), locale)
+	}
+}
+
+func TestPIIDetector_LocaleScopesRecognizers(t *testing.T) {
+	detector := NewPIIDetector(&PIIDetectorConfig{Locales: []string{PIILocaleCN}})
+
+	assert.Empty(t, detector.Detect("CPF 529.982.247-25"))
+	matches := detector.Detect("email a@example.com")
+	require.Len(t, matches, 1)
+	assert.Equal(t, PIITypeEmail, matches[0].Type, "global recognizers apply to every locale")
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"我的手机号是 13812345678", "zh"},
+		{"私のマイナンバーは 123456789018 です", "ja"},
+		{"제 전화번호는", "ko"},
+		{"Meu CPF é 529.982.247-25, não compartilhe", "pt"},
+		{"Meine Nummer ist die und der", "de"},
+		{"Please call my number", "en"},
+		{"12345", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectLanguage(tt.content), tt.content)
+	}
+}
+
+func TestPIIDetector_AutoLocale(t *testing.T) {
+	detector := NewPIIDetector(&PIIDetectorConfig{
+		Action:  PIIActionMask,
+		Locales: []string{PIILocaleAuto},
+	})
+
+	result, err := detector.Validate(context.Background(), "Meu CPF é 529.982.247-25, não compartilhe")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "pt", result.Metadata["pii_language"])
+	assert.Equal(t, "Meu CPF é ***.***.***-25, não compartilhe", result.Metadata["masked_content"])
+
+	matches := detector.Detect("我的身份证号 11010519491231002X")
+	require.Len(t, matches, 1)
+	assert.Equal(t, PIITypeIDCard, matches[0].Type)
+}
+
+func TestPIIDetector_CustomRecognizer(t *testing.T) {
+	const employeeID PIIType = "employee_id"
+	registry := DefaultRecognizerRegistry()
+	registry.Register(&PatternRecognizer{
+		RecognizerName: "acme_employee_id",
+		Type:           employeeID,
+		LocaleCodes:    []string{PIILocaleGlobal},
+		Pattern:        regexp.MustCompile(`\bEMP-\d{6}\b`),
+	})
+
+	detector := NewPIIDetector(&PIIDetectorConfig{
+		Action:      PIIActionReject,
+		Registry:    registry,
+		MaskFormats: map[PIIType]PIIMaskFormat{employeeID: {Style: PIIMaskLabel}},
+	})
+
+	matches := detector.Detect("owner EMP-004211")
+	require.Len(t, matches, 1)
+	assert.Equal(t, employeeID, matches[0].Type)
+	assert.Equal(t, "[EMPLOYEE_ID]", matches[0].Masked)
+
+	result, err := detector.Validate(context.Background(), "owner EMP-004211")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "employee_id")
+
+	registry.Unregister("acme_employee_id")
+	assert.Empty(t, detector.Detect("owner EMP-004211"))
+}
+
+func TestPIIDetector_CustomPatternsDoNotMutateRegistry(t *testing.T) {
+	registry := NewRecognizerRegistry()
+	detector := NewPIIDetector(&PIIDetectorConfig{
+		Registry:       registry,
+		CustomPatterns: map[PIIType]*regexp.Regexp{"ticket": regexp.MustCompile(`T-\d+`)},
+	})
+
+	assert.Len(t, detector.Detect("see T-42"), 1)
+	assert.Empty(t, registry.Recognizers(nil))
+}
+
+func TestPIIMaskFormats(t *testing.T) {
+	detector := NewPIIDetector(&PIIDetectorConfig{
+		Locales: []string{PIILocaleEU, PIILocaleUS},
+		MaskFormats: map[PIIType]PIIMaskFormat{
+			PIITypeSSN:  {Style: PIIMaskHash},
+			PIITypeIBAN: {Style: PIIMaskPartial, KeepPrefix: 2, KeepSuffix: 2, MaskChar: "#"},
+		},
+	})
+
+	masked := detector.Mask("IBAN DE89 3704 0044 0532 0130 00 SSN 123-45-6789")
+	assert.Contains(t, masked, "DE## #### #### #### #### 00")
+	assert.NotContains(t, masked, "123-45-6789")
+	assert.Regexp(t, `\[SSN:[0-9a-f]{8}\]`, masked)
+
+	// 同一值的哈希稳定，便于关联
+	assert.Equal(t, masked, detector.Mask("IBAN DE89 3704 0044 0532 0130 00 SSN 123-45-6789"))
+
+	assert.Equal(t, "****", PIIMaskFormat{Style: PIIMaskFull}.Apply(PIITypeCPF, "1234"))
+	assert.Equal(t, "<cpf>", PIIMaskFormat{Style: PIIMaskLabel, Label: "<cpf>"}.Apply(PIITypeCPF, "1234"))
+}
+
+func TestPIIDetector_LegacyModeUnchanged(t *testing.T) {
+	detector := NewPIIDetector(nil)
+	masked := detector.Mask("手机 13812345678")
+	assert.True(t, strings.HasPrefix(masked, "手机 138"))
+	assert.Nil(t, detector.registry)
+}
+
+func TestGuardrailsConfig_PIIDetectorConfig(t *testing.T) {
+	assert.Nil(t, DefaultConfig().PIIDetectorConfig())
+
+	cfg := DefaultConfig()
+	cfg.PIILocales = []string{"auto", PIILocaleCN}
+	detectorCfg := cfg.PIIDetectorConfig()
+	require.NotNil(t, detectorCfg)
+	assert.Equal(t, []string{"auto", PIILocaleCN}, detectorCfg.Locales)
+	assert.Equal(t, PIIActionMask, detectorCfg.Action)
+}
@@ -0,0 +1,686 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyAction 策略命中后的动作
+type PolicyAction string
+
+const (
+	// PolicyActionAllow 显式放行，跳过后续低优先级策略
+	PolicyActionAllow PolicyAction = "allow"
+	// PolicyActionDeny 拒绝
+	PolicyActionDeny PolicyAction = "deny"
+	// PolicyActionMask 按 content_patterns 脱敏后放行
+	PolicyActionMask PolicyAction = "mask"
+	// PolicyActionEscalate 升级至人工审批（HITL），审批通过才放行
+	PolicyActionEscalate PolicyAction = "escalate"
+)
+
+// ErrCodePolicyEscalation 策略要求人工审批但未获批准
+const ErrCodePolicyEscalation = "POLICY_ESCALATION"
+
+// PolicyTimeWindow 生效时间窗口，End 早于 Start 时表示跨越午夜
+type PolicyTimeWindow struct {
+	// Start/End 格式为 HH:MM
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+	// Timezone IANA 时区名，默认 UTC
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Days 生效的星期（mon..sun），为空表示每天
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+}
+
+// PolicyConditions 策略匹配条件，各条件之间为 AND，单个条件内的多个取值为 OR；
+// 未配置的条件视为匹配。
+type PolicyConditions struct {
+	// Roles 匹配 types.Roles 中任一角色
+	Roles []string `yaml:"roles,omitempty" json:"roles,omitempty"`
+	// Tools 匹配 types.ToolName，支持 path.Match 通配符
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Tenants 匹配 types.TenantID
+	Tenants []string `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+	// ContentPatterns 内容正则，任一命中即匹配；mask 动作按此脱敏
+	ContentPatterns []string `yaml:"content_patterns,omitempty" json:"content_patterns,omitempty"`
+	// TimeWindow 生效时间窗口
+	TimeWindow *PolicyTimeWindow `yaml:"time_window,omitempty" json:"time_window,omitempty"`
+}
+
+// PolicyRule 一条声明式护栏策略
+type PolicyRule struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Priority 数字越小越先评估，相同优先级按声明顺序
+	Priority int              `yaml:"priority,omitempty" json:"priority,omitempty"`
+	When     PolicyConditions `yaml:"when,omitempty" json:"when,omitempty"`
+	Action   PolicyAction     `yaml:"action" json:"action"`
+	// Message 拒绝或升级时返回的说明
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// Severity deny/escalate 的错误级别，默认 high
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+	// MaskWith mask 动作的替换文本，默认 [MASKED]
+	MaskWith string `yaml:"mask_with,omitempty" json:"mask_with,omitempty"`
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// PolicySet 策略文件的顶层结构
+type PolicySet struct {
+	Version  string       `yaml:"version,omitempty" json:"version,omitempty"`
+	Policies []PolicyRule `yaml:"policies" json:"policies"`
+}
+
+// ParsePolicySet 解析 YAML 策略
+func ParsePolicySet(data []byte) (*PolicySet, error) {
+	var set PolicySet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse guardrail policies: %w", err)
+	}
+	return &set, nil
+}
+
+// PolicyEscalation 提交给人工审批的策略命中信息
+type PolicyEscalation struct {
+	Policy   string   `json:"policy"`
+	Message  string   `json:"message,omitempty"`
+	Content  string   `json:"content"`
+	TenantID string   `json:"tenant_id,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	ToolName string   `json:"tool_name,omitempty"`
+}
+
+// PolicyEscalator 处理 escalate 动作，返回是否批准
+type PolicyEscalator interface {
+	Escalate(ctx context.Context, escalation PolicyEscalation) (bool, error)
+}
+
+// PolicyEngineConfig 策略引擎配置
+type PolicyEngineConfig struct {
+	// Escalator 人工审批处理器；为空时 escalate 按拒绝处理
+	Escalator PolicyEscalator
+	// Priority 引擎作为验证器的优先级
+	Priority int
+	// Now 时间源，默认 time.Now
+	Now    func() time.Time
+	Logger *zap.Logger
+}
+
+// PolicyEngine 策略即代码引擎
+// 从 YAML 加载声明式策略并编译为 ValidatorChain；同一时刻按优先级首个命中的策略生效。
+// 引擎本身实现 Validator 与 Filter，重新加载时原子替换编译结果，加载失败保留旧策略。
+type PolicyEngine struct {
+	escalator PolicyEscalator
+	priority  int
+	now       func() time.Time
+	logger    *zap.Logger
+
+	mu       sync.RWMutex
+	compiled *compiledPolicySet
+	source   string
+}
+
+type compiledPolicySet struct {
+	version  int64
+	rules    []*compiledPolicy
+	chain    *ValidatorChain
+	policies []PolicyRule
+}
+
+// NewPolicyEngine 创建策略引擎（初始无策略）
+func NewPolicyEngine(cfg *PolicyEngineConfig) *PolicyEngine {
+	if cfg == nil {
+		cfg = &PolicyEngineConfig{}
+	}
+	e := &PolicyEngine{
+		escalator: cfg.Escalator,
+		priority:  cfg.Priority,
+		now:       cfg.Now,
+		logger:    cfg.Logger,
+	}
+	if e.priority == 0 {
+		e.priority = 5
+	}
+	if e.now == nil {
+		e.now = time.Now
+	}
+	if e.logger == nil {
+		e.logger = zap.NewNop()
+	}
+	e.logger = e.logger.With(zap.String("component", "guardrail_policy_engine"))
+	e.compiled = &compiledPolicySet{chain: NewValidatorChain(nil)}
+	return e
+}
+
+// Load 解析并编译 YAML 策略，成功后原子替换当前策略
+func (e *PolicyEngine) Load(data []byte) error {
+	set, err := ParsePolicySet(data)
+	if err != nil {
+		return err
+	}
+	return e.Apply(set)
+}
+
+// LoadFile 从文件加载策略并记录来源，供 Reload 使用
+func (e *PolicyEngine) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read guardrail policy file: %w", err)
+	}
+	if err := e.Load(data); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.source = file
+	e.mu.Unlock()
+	return nil
+}
+
+// Reload 从上次加载的文件重新加载策略
+func (e *PolicyEngine) Reload() error {
+	e.mu.RLock()
+	source := e.source
+	e.mu.RUnlock()
+	if source == "" {
+		return errors.New("guardrail policy engine has no source file")
+	}
+	return e.LoadFile(source)
+}
+
+// Apply 编译策略集并原子替换当前策略
+func (e *PolicyEngine) Apply(set *PolicySet) error {
+	if set == nil {
+		set = &PolicySet{}
+	}
+	rules, err := compilePolicies(set.Policies)
+	if err != nil {
+		return err
+	}
+
+	chain := NewValidatorChain(&ValidatorChainConfig{Mode: ChainModeCollectAll})
+	for i, rule := range rules {
+		chain.Add(&policyRuleValidator{engine: e, policy: rule, rank: i})
+	}
+
+	e.mu.Lock()
+	version := e.compiled.version + 1
+	e.compiled = &compiledPolicySet{
+		version:  version,
+		rules:    rules,
+		chain:    chain,
+		policies: append([]PolicyRule(nil), set.Policies...),
+	}
+	e.mu.Unlock()
+
+	e.logger.Info("guardrail policies loaded",
+		zap.Int64("version", version),
+		zap.Int("policies", len(rules)),
+	)
+	return nil
+}
+
+// Version 返回策略版本，每次成功加载递增
+func (e *PolicyEngine) Version() int64 {
+	return e.current().version
+}
+
+// Policies 返回当前加载的策略定义
+func (e *PolicyEngine) Policies() []PolicyRule {
+	return append([]PolicyRule(nil), e.current().policies...)
+}
+
+// Chain 返回当前策略编译出的验证器链
+func (e *PolicyEngine) Chain() *ValidatorChain {
+	return e.current().chain
+}
+
+// Name 返回验证器名称
+func (e *PolicyEngine) Name() string {
+	return "policy_engine"
+}
+
+// Priority 返回优先级
+func (e *PolicyEngine) Priority() int {
+	return e.priority
+}
+
+// Validate 按优先级评估策略，首个命中的策略决定结果
+// 实现 Validator 接口
+func (e *PolicyEngine) Validate(ctx context.Context, content string) (*ValidationResult, error) {
+	compiled := e.current()
+	ctx = context.WithValue(ctx, policyDecisionKey{}, &policyDecision{})
+	result, err := compiled.chain.Validate(ctx, content)
+	if result != nil {
+		result.Metadata["policy_version"] = compiled.version
+	}
+	return result, err
+}
+
+// Filter 对命中 mask 策略的内容脱敏
+// 实现 Filter 接口
+func (e *PolicyEngine) Filter(ctx context.Context, content string) (string, error) {
+	now := e.now()
+	for _, rule := range e.current().rules {
+		if !rule.matches(ctx, content, now) {
+			continue
+		}
+		if rule.rule.Action == PolicyActionMask {
+			return rule.mask(content), nil
+		}
+		return content, nil
+	}
+	return content, nil
+}
+
+// BindHotReload 从配置的 agent.guardrails.policy_file 加载策略，并在配置热重载或回滚时重新加载。
+// 策略文件路径为空时清空策略；加载失败时保留旧策略并记录日志。
+func (e *PolicyEngine) BindHotReload(manager *config.HotReloadManager) error {
+	if manager == nil {
+		return errors.New("hot reload manager is nil")
+	}
+	if err := e.loadFromConfig(manager.GetConfig()); err != nil {
+		return err
+	}
+	manager.OnReload(func(_, newConfig *config.Config) {
+		if err := e.loadFromConfig(newConfig); err != nil {
+			e.logger.Warn("guardrail policy reload failed, keeping previous policies", zap.Error(err))
+		}
+	})
+	manager.OnRollback(func(event config.RollbackEvent) {
+		if err := e.loadFromConfig(event.RestoredConfig); err != nil {
+			e.logger.Warn("guardrail policy rollback failed, keeping previous policies", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+func (e *PolicyEngine) loadFromConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return nil
+	}
+	file := strings.TrimSpace(cfg.Agent.Guardrails.PolicyFile)
+	if file == "" {
+		e.mu.Lock()
+		e.source = ""
+		e.mu.Unlock()
+		return e.Apply(nil)
+	}
+	return e.LoadFile(file)
+}
+
+func (e *PolicyEngine) current() *compiledPolicySet {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.compiled
+}
+
+// policyDecision 记录一次评估中是否已有策略命中，实现首个命中生效
+type policyDecision struct {
+	mu      sync.Mutex
+	decided bool
+}
+
+type policyDecisionKey struct{}
+
+// policyRuleValidator 单条策略编译出的验证器
+type policyRuleValidator struct {
+	engine *PolicyEngine
+	policy *compiledPolicy
+	rank   int
+}
+
+func (v *policyRuleValidator) Name() string {
+	return "policy:" + v.policy.rule.Name
+}
+
+// Priority 返回策略在链中的排序位置，保证相同优先级按声明顺序评估
+func (v *policyRuleValidator) Priority() int {
+	return v.rank
+}
+
+func (v *policyRuleValidator) Validate(ctx context.Context, content string) (*ValidationResult, error) {
+	result := NewValidationResult()
+	if !v.policy.matches(ctx, content, v.engine.now()) {
+		return result, nil
+	}
+	if decision, ok := ctx.Value(policyDecisionKey{}).(*policyDecision); ok {
+		decision.mu.Lock()
+		decided := decision.decided
+		decision.decided = true
+		decision.mu.Unlock()
+		if decided {
+			return result, nil
+		}
+	}
+
+	rule := v.policy.rule
+	result.Metadata["policy"] = rule.Name
+	result.Metadata["policy_action"] = string(rule.Action)
+
+	switch rule.Action {
+	case PolicyActionDeny:
+		result.AddError(v.policy.violation(ErrCodePolicyViolation, v.policy.message("命中护栏策略")))
+	case PolicyActionMask:
+		result.AddWarning("护栏策略 " + rule.Name + " 已对内容脱敏")
+		result.Metadata["masked_content"] = v.policy.mask(content)
+	case PolicyActionEscalate:
+		v.escalate(ctx, content, result)
+	}
+	return result, nil
+}
+
+// escalate 提交人工审批；未配置审批处理器、审批出错或被拒绝时均按拒绝处理
+func (v *policyRuleValidator) escalate(ctx context.Context, content string, result *ValidationResult) {
+	rule := v.policy.rule
+	if v.engine.escalator == nil {
+		result.AddError(v.policy.violation(ErrCodePolicyEscalation, v.policy.message("需要人工审批")))
+		return
+	}
+
+	escalation := PolicyEscalation{Policy: rule.Name, Message: rule.Message, Content: content}
+	escalation.TenantID, _ = types.TenantID(ctx)
+	escalation.Roles, _ = types.Roles(ctx)
+	escalation.ToolName, _ = types.ToolName(ctx)
+
+	approved, err := v.engine.escalator.Escalate(ctx, escalation)
+	switch {
+	case err != nil:
+		result.AddError(v.policy.violation(ErrCodePolicyEscalation, "人工审批失败: "+err.Error()))
+	case !approved:
+		result.AddError(v.policy.violation(ErrCodePolicyViolation, v.policy.message("人工审批未通过")))
+	default:
+		result.AddWarning("护栏策略 " + rule.Name + " 已获人工审批放行")
+		result.Metadata["policy_escalation_approved"] = true
+	}
+}
+
+// compiledPolicy 预编译的策略
+type compiledPolicy struct {
+	rule     PolicyRule
+	patterns []*regexp.Regexp
+	roles    map[string]bool
+	tenants  map[string]bool
+	window   *compiledTimeWindow
+}
+
+type compiledTimeWindow struct {
+	location   *time.Location
+	start, end int
+	days       map[time.Weekday]bool
+}
+
+var policyWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// compilePolicies 校验并编译策略，按优先级稳定排序，跳过已禁用的策略
+func compilePolicies(rules []PolicyRule) ([]*compiledPolicy, error) {
+	seen := make(map[string]bool, len(rules))
+	compiled := make([]*compiledPolicy, 0, len(rules))
+	for i, rule := range rules {
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Name == "" {
+			return nil, fmt.Errorf("policy %d: name is required", i)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("policy %q: duplicate name", rule.Name)
+		}
+		seen[rule.Name] = true
+		if rule.Disabled {
+			continue
+		}
+		p, err := compilePolicy(rule)
+		if err != nil {
+			return nil, fmt.Errorf("policy %q: %w", rule.Name, err)
+		}
+		compiled = append(compiled, p)
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return compiled[i].rule.Priority < compiled[j].rule.Priority
+	})
+	return compiled, nil
+}
+
+func compilePolicy(rule PolicyRule) (*compiledPolicy, error) {
+	rule.Action = PolicyAction(strings.ToLower(strings.TrimSpace(string(rule.Action))))
+	switch rule.Action {
+	case PolicyActionAllow, PolicyActionDeny, PolicyActionMask, PolicyActionEscalate:
+	default:
+		return nil, fmt.Errorf("unknown action %q", rule.Action)
+	}
+	if rule.Action == PolicyActionMask && len(rule.When.ContentPatterns) == 0 {
+		return nil, errors.New("mask action requires content_patterns")
+	}
+	if rule.Severity == "" {
+		rule.Severity = SeverityHigh
+	}
+	if rule.MaskWith == "" {
+		rule.MaskWith = "[MASKED]"
+	}
+
+	p := &compiledPolicy{rule: rule, roles: toSet(rule.When.Roles), tenants: toSet(rule.When.Tenants)}
+	for _, tool := range rule.When.Tools {
+		if _, err := path.Match(tool, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", tool, err)
+		}
+	}
+	for _, pattern := range rule.When.ContentPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	if rule.When.TimeWindow != nil {
+		window, err := compileTimeWindow(rule.When.TimeWindow)
+		if err != nil {
+			return nil, err
+		}
+		p.window = window
+	}
+	return p, nil
+}
+
+func compileTimeWindow(w *PolicyTimeWindow) (*compiledTimeWindow, error) {
+	out := &compiledTimeWindow{location: time.UTC}
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time_window timezone %q: %w", w.Timezone, err)
+		}
+		out.location = loc
+	}
+	var err error
+	if out.start, err = parseClock(w.Start); err != nil {
+		return nil, fmt.Errorf("invalid time_window start: %w", err)
+	}
+	if out.end, err = parseClock(w.End); err != nil {
+		return nil, fmt.Errorf("invalid time_window end: %w", err)
+	}
+	if len(w.Days) > 0 {
+		out.days = make(map[time.Weekday]bool, len(w.Days))
+		for _, day := range w.Days {
+			key := strings.ToLower(strings.TrimSpace(day))
+			if len(key) > 3 {
+				key = key[:3]
+			}
+			weekday, ok := policyWeekdays[key]
+			if !ok {
+				return nil, fmt.Errorf("invalid time_window day %q", day)
+			}
+			out.days[weekday] = true
+		}
+	}
+	return out, nil
+}
+
+// parseClock 将 HH:MM 解析为当天分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *compiledTimeWindow) contains(now time.Time) bool {
+	local := now.In(w.location)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	var inWindow bool
+	switch {
+	case w.start == w.end:
+		inWindow = true
+	case w.start < w.end:
+		inWindow = minute >= w.start && minute < w.end
+	default:
+		// 跨越午夜的窗口，凌晨部分归属前一天
+		if minute < w.end {
+			inWindow = true
+			day = (day + 6) % 7
+		} else {
+			inWindow = minute >= w.start
+		}
+	}
+	if !inWindow {
+		return false
+	}
+	return len(w.days) == 0 || w.days[day]
+}
+
+func (p *compiledPolicy) matches(ctx context.Context, content string, now time.Time) bool {
+	if len(p.roles) > 0 {
+		roles, _ := types.Roles(ctx)
+		matched := false
+		for _, role := range roles {
+			if p.roles[role] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(p.tenants) > 0 {
+		tenantID, _ := types.TenantID(ctx)
+		if !p.tenants[tenantID] {
+			return false
+		}
+	}
+	if len(p.rule.When.Tools) > 0 {
+		toolName, ok := types.ToolName(ctx)
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, pattern := range p.rule.When.Tools {
+			if ok, _ := path.Match(pattern, toolName); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if p.window != nil && !p.window.contains(now) {
+		return false
+	}
+	if len(p.patterns) > 0 {
+		for _, re := range p.patterns {
+			if re.MatchString(content) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (p *compiledPolicy) mask(content string) string {
+	for _, re := range p.patterns {
+		content = re.ReplaceAllLiteralString(content, p.rule.MaskWith)
+	}
+	return content
+}
+
+func (p *compiledPolicy) message(fallback string) string {
+	if p.rule.Message != "" {
+		return p.rule.Message
+	}
+	return fallback + ": " + p.rule.Name
+}
+
+func (p *compiledPolicy) violation(code, message string) ValidationError {
+	return ValidationError{
+		Code:     code,
+		Message:  message,
+		Severity: p.rule.Severity,
+		Field:    p.rule.Name,
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.TrimSpace(v)] = true
+	}
+	return set
+}
+
+// HITLPolicyEscalator 基于 hitl.InterruptManager 的审批处理器，
+// 为每次升级创建审批中断并阻塞等待人工响应
+type HITLPolicyEscalator struct {
+	manager *hitl.InterruptManager
+	timeout time.Duration
+}
+
+// NewHITLPolicyEscalator 创建 HITL 审批处理器，timeout 为 0 时使用中断管理器默认超时
+func NewHITLPolicyEscalator(manager *hitl.InterruptManager, timeout time.Duration) *HITLPolicyEscalator {
+	return &HITLPolicyEscalator{manager: manager, timeout: timeout}
+}
+
+// Escalate 实现 PolicyEscalator 接口
+func (h *HITLPolicyEscalator) Escalate(ctx context.Context, escalation PolicyEscalation) (bool, error) {
+	if h.manager == nil {
+		return false, errors.New("hitl interrupt manager is nil")
+	}
+	runID, _ := types.RunID(ctx)
+	description := escalation.Message
+	if description == "" {
+		description = "内容命中需人工审批的护栏策略"
+	}
+	resp, err := h.manager.CreateInterrupt(ctx, hitl.InterruptOptions{
+		WorkflowID:  runID,
+		NodeID:      "guardrails",
+		Type:        hitl.InterruptTypeApproval,
+		Title:       "Guardrail policy escalation: " + escalation.Policy,
+		Description: description,
+		Data:        escalation,
+		Timeout:     h.timeout,
+		Metadata:    map[string]any{"policy": escalation.Policy},
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp != nil && resp.Approved, nil
+}
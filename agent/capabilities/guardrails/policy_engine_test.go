@@ -0,0 +1,248 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = `
+version: "1"
+policies:
+  - name: admins-bypass
+    priority: 1
+    when:
+      roles: [admin]
+    action: allow
+  - name: no-shell-for-guests
+    priority: 10
+    when:
+      roles: [guest]
+      tools: ["shell_*"]
+    action: deny
+    message: guests cannot run shell tools
+  - name: mask-card-numbers
+    priority: 20
+    when:
+      content_patterns: ['\b\d{4}-\d{4}-\d{4}-\d{4}\b']
+    action: mask
+    mask_with: "[CARD]"
+  - name: acme-refunds-need-approval
+    priority: 30
+    when:
+      tenants: [acme]
+      content_patterns: ['(?i)refund']
+    action: escalate
+  - name: disabled-rule
+    action: deny
+    disabled: true
+`
+
+type stubEscalator struct {
+	approved bool
+	err      error
+	calls    []PolicyEscalation
+}
+
+func (s *stubEscalator) Escalate(_ context.Context, escalation PolicyEscalation) (bool, error) {
+	s.calls = append(s.calls, escalation)
+	return s.approved, s.err
+}
+
+func newTestPolicyEngine(t *testing.T, escalator PolicyEscalator) *PolicyEngine {
+	t.Helper()
+	engine := NewPolicyEngine(&PolicyEngineConfig{Escalator: escalator})
+	require.NoError(t, engine.Load([]byte(testPolicies)))
+	return engine
+}
+
+func TestPolicyEngine_Load(t *testing.T) {
+	engine := newTestPolicyEngine(t, nil)
+	assert.Equal(t, int64(1), engine.Version())
+	assert.Len(t, engine.Policies(), 5)
+	assert.Equal(t, 4, engine.Chain().Len(), "disabled policies are not compiled")
+
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{"missing name", "policies:\n  - action: deny"},
+		{"duplicate name", "policies:\n  - {name: a, action: deny}\n  - {name: a, action: allow}"},
+		{"unknown action", "policies:\n  - {name: a, action: block}"},
+		{"mask without patterns", "policies:\n  - {name: a, action: mask}"},
+		{"bad regex", "policies:\n  - {name: a, action: deny, when: {content_patterns: ['(']}}"},
+		{"bad time", "policies:\n  - {name: a, action: deny, when: {time_window: {start: '25:00', end: '06:00'}}}"},
+		{"bad day", "policies:\n  - {name: a, action: deny, when: {time_window: {start: '22:00', end: '06:00', days: [someday]}}}"},
+		{"invalid yaml", "policies: ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, engine.Load([]byte(tt.policy)))
+			assert.Equal(t, int64(1), engine.Version(), "failed loads keep the previous policies")
+		})
+	}
+}
+
+func TestPolicyEngine_DenyAndAllow(t *testing.T) {
+	engine := newTestPolicyEngine(t, nil)
+	guestShell := types.WithToolName(types.WithRoles(context.Background(), []string{"guest"}), "shell_exec")
+
+	result, err := engine.Validate(guestShell, "ls -la")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, ErrCodePolicyViolation, result.Errors[0].Code)
+	assert.Equal(t, "guests cannot run shell tools", result.Errors[0].Message)
+	assert.Equal(t, "no-shell-for-guests", result.Metadata["policy"])
+	assert.Equal(t, int64(1), result.Metadata["policy_version"])
+
+	// 其他工具不受影响
+	result, err = engine.Validate(types.WithToolName(guestShell, "search"), "ls -la")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+
+	// 高优先级的 allow 策略跳过后续策略
+	adminShell := types.WithRoles(guestShell, []string{"admin", "guest"})
+	result, err = engine.Validate(adminShell, "ls -la")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "admins-bypass", result.Metadata["policy"])
+}
+
+func TestPolicyEngine_Mask(t *testing.T) {
+	engine := newTestPolicyEngine(t, nil)
+	ctx := context.Background()
+	content := "card 1234-5678-9012-3456 on file"
+
+	result, err := engine.Validate(ctx, content)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "card [CARD] on file", result.Metadata["masked_content"])
+
+	ov := NewOutputValidator(&OutputValidatorConfig{Validators: []Validator{engine}, Filters: []Filter{engine}})
+	output, _, err := ov.ValidateAndFilter(ctx, content)
+	require.NoError(t, err)
+	assert.Equal(t, "card [CARD] on file", output)
+
+	// 命中 allow 的请求不脱敏
+	filtered, err := engine.Filter(types.WithRoles(ctx, []string{"admin"}), content)
+	require.NoError(t, err)
+	assert.Equal(t, content, filtered)
+}
+
+func TestPolicyEngine_Escalate(t *testing.T) {
+	acme := types.WithTenantID(context.Background(), "acme")
+
+	result, err := newTestPolicyEngine(t, nil).Validate(acme, "please refund order 42")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ErrCodePolicyEscalation, result.Errors[0].Code)
+
+	escalator := &stubEscalator{approved: true}
+	engine := newTestPolicyEngine(t, escalator)
+	result, err = engine.Validate(acme, "please refund order 42")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	require.Len(t, escalator.calls, 1)
+	assert.Equal(t, "acme-refunds-need-approval", escalator.calls[0].Policy)
+	assert.Equal(t, "acme", escalator.calls[0].TenantID)
+
+	escalator.approved = false
+	result, err = engine.Validate(acme, "please refund order 42")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ErrCodePolicyViolation, result.Errors[0].Code)
+
+	escalator.err = errors.New("approval service down")
+	result, err = engine.Validate(acme, "please refund order 42")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ErrCodePolicyEscalation, result.Errors[0].Code)
+
+	// 其他租户不需要审批
+	result, err = engine.Validate(types.WithTenantID(context.Background(), "other"), "please refund order 42")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+func TestPolicyEngine_HITLEscalator(t *testing.T) {
+	manager := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), nil)
+	manager.RegisterHandler(hitl.InterruptTypeApproval, func(ctx context.Context, interrupt *hitl.Interrupt) error {
+		go func() {
+			_ = manager.ResolveInterrupt(context.Background(), interrupt.ID, &hitl.Response{Approved: true})
+		}()
+		return nil
+	})
+
+	engine := newTestPolicyEngine(t, NewHITLPolicyEscalator(manager, time.Second))
+	result, err := engine.Validate(types.WithTenantID(context.Background(), "acme"), "refund please")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, true, result.Metadata["policy_escalation_approved"])
+}
+
+func TestPolicyEngine_TimeWindow(t *testing.T) {
+	now := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC) // Saturday
+	engine := NewPolicyEngine(&PolicyEngineConfig{Now: func() time.Time { return now }})
+	require.NoError(t, engine.Load([]byte(`
+policies:
+  - name: no-deploys-overnight
+    when:
+      time_window: {start: "22:00", end: "06:00", timezone: UTC, days: [fri, sat]}
+    action: deny
+`)))
+
+	check := func(at time.Time) bool {
+		now = at
+		result, err := engine.Validate(context.Background(), "deploy")
+		require.NoError(t, err)
+		return result.Valid
+	}
+
+	assert.False(t, check(time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)), "saturday night")
+	assert.False(t, check(time.Date(2026, 10, 18, 5, 0, 0, 0, time.UTC)), "early sunday belongs to saturday's window")
+	assert.True(t, check(time.Date(2026, 10, 19, 5, 0, 0, 0, time.UTC)), "early monday belongs to sunday's window")
+	assert.True(t, check(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)), "saturday noon")
+}
+
+func TestPolicyEngine_BindHotReload(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.yaml")
+	second := filepath.Join(dir, "second.yaml")
+	require.NoError(t, os.WriteFile(first, []byte("policies:\n  - {name: deny-foo, action: deny, when: {content_patterns: [foo]}}"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("policies:\n  - {name: deny-bar, action: deny, when: {content_patterns: [bar]}}"), 0o600))
+
+	cfg := config.DefaultConfig()
+	cfg.Agent.Guardrails.PolicyFile = first
+	manager := config.NewHotReloadManager(cfg)
+
+	engine := NewPolicyEngine(nil)
+	require.NoError(t, engine.BindHotReload(manager))
+	assert.Equal(t, "deny-foo", engine.Policies()[0].Name)
+
+	require.NoError(t, manager.UpdateField("Agent.Guardrails.PolicyFile", second))
+	assert.Equal(t, "deny-bar", engine.Policies()[0].Name)
+	result, err := engine.Validate(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+
+	// 文件内容更新后随下一次配置重载生效；无效策略保留旧版本
+	require.NoError(t, os.WriteFile(second, []byte("policies: ["), 0o600))
+	require.NoError(t, manager.UpdateField("Agent.MaxIterations", 20))
+	assert.Equal(t, "deny-bar", engine.Policies()[0].Name)
+
+	require.NoError(t, manager.UpdateField("Agent.Guardrails.PolicyFile", ""))
+	assert.Empty(t, engine.Policies())
+
+	require.Error(t, NewPolicyEngine(nil).BindHotReload(nil))
+	require.Error(t, NewPolicyEngine(nil).Reload())
+}
@@ -0,0 +1,316 @@
+package guardrails
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretType 凭据类型
+type SecretType string
+
+const (
+	SecretTypeAWSAccessKey     SecretType = "aws_access_key"
+	SecretTypeAWSSecretKey     SecretType = "aws_secret_key"
+	SecretTypeGCPServiceKey    SecretType = "gcp_service_account_key"
+	SecretTypeAzureStorageKey  SecretType = "azure_storage_key"
+	SecretTypeGitHubToken      SecretType = "github_token"
+	SecretTypeSlackToken       SecretType = "slack_token"
+	SecretTypeStripeKey        SecretType = "stripe_key"
+	SecretTypeLLMAPIKey        SecretType = "llm_api_key"
+	SecretTypeJWT              SecretType = "jwt"
+	SecretTypePrivateKey       SecretType = "private_key"
+	SecretTypeConnectionString SecretType = "connection_string"
+	// SecretTypeGeneric 形如 api_key=... 的赋值，值需通过熵检测
+	SecretTypeGeneric SecretType = "generic_secret"
+)
+
+// SecretsAction 检测到凭据时的处理动作
+type SecretsAction string
+
+const (
+	// SecretsActionMask 脱敏后放行（默认）
+	SecretsActionMask SecretsAction = "mask"
+	// SecretsActionBlock 以严重错误拒绝，输出验证器将返回安全替代响应
+	SecretsActionBlock SecretsAction = "block"
+	// SecretsActionAlert 原样放行，仅告警
+	SecretsActionAlert SecretsAction = "alert"
+)
+
+// SecretMatch 凭据匹配结果
+// 出于安全考虑不保留原始值，仅保留脱敏后的预览
+type SecretMatch struct {
+	Type     SecretType `json:"type"`
+	Preview  string     `json:"preview"`
+	Position int        `json:"position"`
+	Length   int        `json:"length"`
+	Entropy  float64    `json:"entropy,omitempty"`
+}
+
+// SecretsValidatorConfig 凭据泄露检测配置
+type SecretsValidatorConfig struct {
+	// Action 处理动作，默认 mask
+	Action SecretsAction
+	// EnabledTypes 启用的凭据类型，为空则启用所有类型
+	EnabledTypes []SecretType
+	// MinEntropy 通用赋值类凭据的最低香农熵（bit/字符），默认 3.5
+	MinEntropy float64
+	// OnAlert 检测到凭据时的回调（所有动作均会触发，可选）
+	OnAlert func(ctx context.Context, matches []SecretMatch)
+	// Priority 验证器优先级
+	Priority int
+}
+
+// DefaultSecretsValidatorConfig 返回默认配置
+func DefaultSecretsValidatorConfig() *SecretsValidatorConfig {
+	return &SecretsValidatorConfig{
+		Action:     SecretsActionMask,
+		MinEntropy: 3.5,
+		Priority:   90,
+	}
+}
+
+type secretPattern struct {
+	secretType SecretType
+	pattern    *regexp.Regexp
+	// group 需要脱敏的捕获组，0 表示整个匹配
+	group int
+	// entropyCheck 为 true 时 group 对应的值还需通过熵检测
+	entropyCheck bool
+}
+
+// SecretsValidator 凭据泄露检测器
+// 实现 Validator 与 Filter 接口，基于正则与熵启发式检测输出中的 API Key、JWT、私钥、
+// 连接串与云凭据，按配置脱敏、拒绝或告警。
+type SecretsValidator struct {
+	patterns   []secretPattern
+	action     SecretsAction
+	minEntropy float64
+	onAlert    func(ctx context.Context, matches []SecretMatch)
+	priority   int
+}
+
+// NewSecretsValidator 创建凭据泄露检测器
+func NewSecretsValidator(config *SecretsValidatorConfig) *SecretsValidator {
+	if config == nil {
+		config = DefaultSecretsValidatorConfig()
+	}
+	defaults := DefaultSecretsValidatorConfig()
+
+	v := &SecretsValidator{
+		action:     config.Action,
+		minEntropy: config.MinEntropy,
+		onAlert:    config.OnAlert,
+		priority:   config.Priority,
+	}
+	if v.action == "" {
+		v.action = defaults.Action
+	}
+	if v.minEntropy <= 0 {
+		v.minEntropy = defaults.MinEntropy
+	}
+
+	enabled := make(map[SecretType]bool, len(config.EnabledTypes))
+	for _, t := range config.EnabledTypes {
+		enabled[t] = true
+	}
+	for _, p := range getDefaultSecretPatterns() {
+		if len(enabled) == 0 || enabled[p.secretType] {
+			v.patterns = append(v.patterns, p)
+		}
+	}
+	return v
+}
+
+// getDefaultSecretPatterns 返回默认的凭据正则模式，顺序即匹配优先级
+func getDefaultSecretPatterns() []secretPattern {
+	return []secretPattern{
+		{secretType: SecretTypePrivateKey, pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+		{secretType: SecretTypeGCPServiceKey, pattern: regexp.MustCompile(`"private_key_id"\s*:\s*"([a-f0-9]{40})"`), group: 1},
+		{secretType: SecretTypeJWT, pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+		{secretType: SecretTypeAWSAccessKey, pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+		{secretType: SecretTypeAWSSecretKey, pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`), group: 1},
+		{secretType: SecretTypeAzureStorageKey, pattern: regexp.MustCompile(`(?i)AccountKey=([A-Za-z0-9+/]{86}==)`), group: 1},
+		{secretType: SecretTypeGitHubToken, pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+		{secretType: SecretTypeSlackToken, pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+		{secretType: SecretTypeStripeKey, pattern: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+		{secretType: SecretTypeLLMAPIKey, pattern: regexp.MustCompile(`\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35})`)},
+		{secretType: SecretTypeConnectionString, pattern: regexp.MustCompile(`(?i)\b(?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|rediss|amqps?|mssql|sqlserver)://[^\s:/@]+:([^\s@/]+)@[^\s]+`), group: 1},
+		{secretType: SecretTypeGeneric, pattern: regexp.MustCompile(`(?i)\b[\w.-]*(?:api[_-]?key|secret|token|passw(?:or)?d|access[_-]?key|client[_-]?secret)["']?\s*[:=]\s*["']?([A-Za-z0-9/+_.=-]{16,})`), group: 1, entropyCheck: true},
+	}
+}
+
+// Name 返回验证器名称
+func (v *SecretsValidator) Name() string {
+	return "secrets_validator"
+}
+
+// Priority 返回优先级
+func (v *SecretsValidator) Priority() int {
+	return v.priority
+}
+
+// Validate 执行凭据泄露检测
+// 实现 Validator 接口
+func (v *SecretsValidator) Validate(ctx context.Context, content string) (*ValidationResult, error) {
+	result := NewValidationResult()
+
+	matches := v.Detect(content)
+	if len(matches) == 0 {
+		return result, nil
+	}
+
+	counts := make(map[SecretType]int)
+	for _, m := range matches {
+		counts[m.Type]++
+	}
+	typeNames := make([]string, 0, len(counts))
+	for t := range counts {
+		typeNames = append(typeNames, string(t))
+	}
+	sort.Strings(typeNames)
+
+	switch v.action {
+	case SecretsActionBlock:
+		for _, t := range typeNames {
+			result.AddError(ValidationError{
+				Code:     ErrCodeSecretDetected,
+				Message:  "检测到凭据泄露: " + t,
+				Severity: SeverityCritical,
+				Field:    t,
+			})
+		}
+	case SecretsActionAlert:
+		result.AddWarning("检测到凭据泄露: " + strings.Join(typeNames, ", "))
+	default:
+		result.AddWarning("检测到凭据泄露，已脱敏: " + strings.Join(typeNames, ", "))
+		result.Metadata["masked_content"] = v.Mask(content)
+	}
+
+	result.Metadata["secrets_detected"] = true
+	result.Metadata["secret_types"] = counts
+	result.Metadata["secret_matches"] = matches
+
+	if v.onAlert != nil {
+		v.onAlert(ctx, matches)
+	}
+	return result, nil
+}
+
+// Detect 检测内容中的所有凭据，重叠的匹配只保留优先级更高的一项
+func (v *SecretsValidator) Detect(content string) []SecretMatch {
+	spans := v.findSpans(content)
+	matches := make([]SecretMatch, 0, len(spans))
+	for _, s := range spans {
+		value := content[s.start:s.end]
+		m := SecretMatch{
+			Type:     s.secretType,
+			Preview:  secretPreview(value),
+			Position: s.start,
+			Length:   s.end - s.start,
+		}
+		if s.entropyCheck {
+			m.Entropy = shannonEntropy(value)
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// Mask 将内容中的凭据替换为 [REDACTED:<类型>]
+func (v *SecretsValidator) Mask(content string) string {
+	spans := v.findSpans(content)
+	if len(spans) == 0 {
+		return content
+	}
+	var b strings.Builder
+	b.Grow(len(content))
+	last := 0
+	for _, s := range spans {
+		b.WriteString(content[last:s.start])
+		b.WriteString("[REDACTED:" + string(s.secretType) + "]")
+		last = s.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// Filter 实现 Filter 接口；仅在 mask 动作下脱敏，其余动作原样返回
+func (v *SecretsValidator) Filter(ctx context.Context, content string) (string, error) {
+	if v.action != SecretsActionMask {
+		return content, nil
+	}
+	return v.Mask(content), nil
+}
+
+// GetAction 返回当前配置的处理动作
+func (v *SecretsValidator) GetAction() SecretsAction {
+	return v.action
+}
+
+type secretSpan struct {
+	secretType   SecretType
+	start, end   int
+	entropyCheck bool
+}
+
+// findSpans 返回按位置排序且互不重叠的凭据区间
+func (v *SecretsValidator) findSpans(content string) []secretSpan {
+	var spans []secretSpan
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range v.patterns {
+		for _, loc := range p.pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if p.group > 0 {
+				start, end = loc[2*p.group], loc[2*p.group+1]
+			}
+			if start < 0 || overlaps(start, end) {
+				continue
+			}
+			if p.entropyCheck && shannonEntropy(content[start:end]) < v.minEntropy {
+				continue
+			}
+			spans = append(spans, secretSpan{secretType: p.secretType, start: start, end: end, entropyCheck: p.entropyCheck})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// secretPreview 保留前 4 个字符，其余以 * 代替
+func secretPreview(value string) string {
+	const keep = 4
+	if len(value) <= keep*2 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:keep] + strings.Repeat("*", 8)
+}
+
+// shannonEntropy 计算字符串的香农熵（bit/字符）
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+	var entropy float64
+	for _, count := range freq {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,216 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// StreamingValidatorConfig 流式验证器链配置
+type StreamingValidatorConfig struct {
+	// WindowSize 每次检查的滑动窗口大小（rune 数），包含已输出的尾部与待输出内容，默认 1024
+	WindowSize int
+	// HoldBack 每次检查后保留不输出的尾部长度（rune 数），
+	// 用于捕获跨分片的匹配；越大越安全，首字延迟也越高，默认 32
+	HoldBack int
+	// Redaction 中止时替代被拦截内容输出的提示分片
+	Redaction string
+	// Validators 增量执行的验证器（PII、关键词、注入标记等）
+	Validators []Validator
+}
+
+// DefaultStreamingValidatorConfig 返回默认配置
+func DefaultStreamingValidatorConfig() *StreamingValidatorConfig {
+	return &StreamingValidatorConfig{
+		WindowSize: 1024,
+		HoldBack:   32,
+		Redaction:  "[内容已被安全策略拦截]",
+	}
+}
+
+// StreamingValidatorChain 流式验证器链
+// 输出验证器只在完整响应上运行，有害内容会在拦截前流到用户。
+// 流式链在每个分片到达时对滑动窗口执行增量检查，
+// 未通过时丢弃尚未输出的内容、输出脱敏分片并中止后续流。
+// 链本身无状态，可被多个流共享；每个流通过 NewSession 获取独立会话。
+type StreamingValidatorChain struct {
+	chain      *ValidatorChain
+	windowSize int
+	holdBack   int
+	redaction  string
+}
+
+// NewStreamingValidatorChain 创建流式验证器链
+func NewStreamingValidatorChain(config *StreamingValidatorConfig) *StreamingValidatorChain {
+	if config == nil {
+		config = DefaultStreamingValidatorConfig()
+	}
+	defaults := DefaultStreamingValidatorConfig()
+
+	windowSize := config.WindowSize
+	if windowSize <= 0 {
+		windowSize = defaults.WindowSize
+	}
+	holdBack := config.HoldBack
+	if holdBack <= 0 {
+		holdBack = defaults.HoldBack
+	}
+	if holdBack >= windowSize {
+		holdBack = windowSize / 2
+	}
+
+	redaction := config.Redaction
+	if redaction == "" {
+		redaction = defaults.Redaction
+	}
+
+	chain := NewValidatorChain(&ValidatorChainConfig{Mode: ChainModeFailFast})
+	chain.Add(config.Validators...)
+
+	return &StreamingValidatorChain{
+		chain:      chain,
+		windowSize: windowSize,
+		holdBack:   holdBack,
+		redaction:  redaction,
+	}
+}
+
+// Add 添加验证器
+func (c *StreamingValidatorChain) Add(validators ...Validator) {
+	c.chain.Add(validators...)
+}
+
+// NewSession 为一个输出流创建验证会话
+func (c *StreamingValidatorChain) NewSession() *StreamValidationSession {
+	return &StreamValidationSession{chain: c}
+}
+
+// StreamCheckResult 单次分片检查结果
+type StreamCheckResult struct {
+	// Emit 可以安全输出给用户的内容；中止时为脱敏分片
+	Emit string `json:"emit"`
+	// Aborted 流已被中止，调用方应停止转发后续分片
+	Aborted bool `json:"aborted"`
+	// Result 导致中止的验证结果
+	Result *ValidationResult `json:"result,omitempty"`
+}
+
+// StreamValidationSession 单个输出流的增量验证状态
+type StreamValidationSession struct {
+	chain *StreamingValidatorChain
+
+	mu      sync.Mutex
+	emitted []rune // 已输出内容的尾部，最多保留 windowSize 个 rune
+	pending []rune // 已接收但尚未输出的内容
+	aborted bool
+	result  *ValidationResult
+}
+
+// Write 接收一个分片，检查滑动窗口后返回可输出的内容。
+// 末尾 HoldBack 个 rune 暂不输出，待后续分片或 Flush 时再检查。
+func (s *StreamValidationSession) Write(ctx context.Context, chunk string) (*StreamCheckResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.aborted {
+		return &StreamCheckResult{Aborted: true, Result: s.result}, nil
+	}
+	s.pending = append(s.pending, []rune(chunk)...)
+	return s.checkLocked(ctx, s.chain.holdBack)
+}
+
+// Flush 在流结束时检查并输出剩余内容。
+func (s *StreamValidationSession) Flush(ctx context.Context) (*StreamCheckResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.aborted {
+		return &StreamCheckResult{Aborted: true, Result: s.result}, nil
+	}
+	return s.checkLocked(ctx, 0)
+}
+
+// Aborted 报告流是否已被中止
+func (s *StreamValidationSession) Aborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
+}
+
+// checkLocked 对 已输出尾部+待输出内容 组成的窗口执行验证，
+// 通过后输出除末尾 holdBack 个 rune 之外的待输出内容。
+func (s *StreamValidationSession) checkLocked(ctx context.Context, holdBack int) (*StreamCheckResult, error) {
+	if len(s.pending) <= holdBack {
+		return &StreamCheckResult{}, nil
+	}
+
+	windowSize := s.chain.windowSize
+	window := s.pending
+	if len(window) > windowSize {
+		// 待输出内容超过窗口时分段检查，保证每段都被完整扫描
+		window = window[len(window)-windowSize:]
+		if err := s.validateSegments(ctx); err != nil || s.aborted {
+			return s.abortResult(), err
+		}
+	} else if ctxLen := windowSize - len(window); ctxLen > 0 && len(s.emitted) > 0 {
+		tail := s.emitted
+		if len(tail) > ctxLen {
+			tail = tail[len(tail)-ctxLen:]
+		}
+		window = append(append(make([]rune, 0, len(tail)+len(window)), tail...), window...)
+	}
+
+	if err := s.validateLocked(ctx, string(window)); err != nil || s.aborted {
+		return s.abortResult(), err
+	}
+
+	cut := len(s.pending) - holdBack
+	out := s.pending[:cut]
+	s.emitted = append(s.emitted, out...)
+	if len(s.emitted) > windowSize {
+		s.emitted = append([]rune(nil), s.emitted[len(s.emitted)-windowSize:]...)
+	}
+	s.pending = append([]rune(nil), s.pending[cut:]...)
+	return &StreamCheckResult{Emit: string(out)}, nil
+}
+
+// validateSegments 按窗口大小、以半窗口步长扫描过长的待输出内容
+func (s *StreamValidationSession) validateSegments(ctx context.Context) error {
+	windowSize := s.chain.windowSize
+	step := windowSize / 2
+	if step <= 0 {
+		step = 1
+	}
+	for start := 0; start+windowSize < len(s.pending); start += step {
+		if err := s.validateLocked(ctx, string(s.pending[start:start+windowSize])); err != nil || s.aborted {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLocked 执行验证器链，未通过或触发 Tripwire 时将会话标记为中止。
+func (s *StreamValidationSession) validateLocked(ctx context.Context, content string) error {
+	result, err := s.chain.chain.Validate(ctx, content)
+	if err != nil {
+		var tripwireErr *TripwireError
+		if !errors.As(err, &tripwireErr) {
+			return err
+		}
+		result = tripwireErr.Result
+	}
+	if result == nil || result.Valid {
+		return nil
+	}
+	s.aborted = true
+	s.result = result
+	s.pending = nil
+	return nil
+}
+
+func (s *StreamValidationSession) abortResult() *StreamCheckResult {
+	if !s.aborted {
+		return &StreamCheckResult{}
+	}
+	return &StreamCheckResult{Emit: s.chain.redaction, Aborted: true, Result: s.result}
+}
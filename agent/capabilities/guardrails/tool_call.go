@@ -0,0 +1,541 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrCodeToolArgumentViolation 工具调用参数违反约束
+const ErrCodeToolArgumentViolation = "TOOL_ARGUMENT_VIOLATION"
+
+// ToolCallViolationAction 工具调用违反约束时的处理方式
+type ToolCallViolationAction string
+
+const (
+	// ToolCallViolationEscalate 提交人工审批，批准后放行（默认）
+	ToolCallViolationEscalate ToolCallViolationAction = "escalate"
+	// ToolCallViolationReject 直接拒绝
+	ToolCallViolationReject ToolCallViolationAction = "reject"
+)
+
+// ToolArgumentConstraint 单个参数的约束，字段命名参照 JSON Schema，
+// 另有路径、URL、SQL 三类面向工具安全的扩展约束。
+type ToolArgumentConstraint struct {
+	// Type string、number、integer、boolean、array、object
+	Type      string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Enum      []any    `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern   string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	MaxLength int      `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+	Minimum   *float64 `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum   *float64 `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	// Items 数组元素的约束
+	Items *ToolArgumentConstraint `yaml:"items,omitempty" json:"items,omitempty"`
+
+	// PathPrefixes 文件路径必须位于任一目录之下；相对路径基于第一个目录解析，不解析符号链接
+	PathPrefixes []string `yaml:"path_prefixes,omitempty" json:"path_prefixes,omitempty"`
+	// URLAllowlist 允许的主机名，"*.example.com" 匹配其子域名
+	URLAllowlist []string `yaml:"url_allowlist,omitempty" json:"url_allowlist,omitempty"`
+	// SQLReadOnly 只允许只读 SQL 语句
+	SQLReadOnly bool `yaml:"sql_read_only,omitempty" json:"sql_read_only,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ToolCallRule 一组工具的参数约束
+type ToolCallRule struct {
+	Name string `yaml:"name" json:"name"`
+	// Tools 工具名，支持 path.Match 通配符
+	Tools []string `yaml:"tools" json:"tools"`
+	// Required 必需参数
+	Required []string `yaml:"required,omitempty" json:"required,omitempty"`
+	// Properties 参数约束，键支持 "options.path" 形式的嵌套路径
+	Properties map[string]ToolArgumentConstraint `yaml:"properties,omitempty" json:"properties,omitempty"`
+	// AdditionalProperties 为 false 时拒绝未在 Properties 中声明的顶层参数
+	AdditionalProperties *bool                   `yaml:"additional_properties,omitempty" json:"additional_properties,omitempty"`
+	OnViolation          ToolCallViolationAction `yaml:"on_violation,omitempty" json:"on_violation,omitempty"`
+	// Message 违反约束时附加的说明
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// ToolCallRuleSet 工具调用约束文件的顶层结构
+type ToolCallRuleSet struct {
+	Rules []ToolCallRule `yaml:"rules" json:"rules"`
+}
+
+// ParseToolCallRules 解析 YAML 工具调用约束
+func ParseToolCallRules(data []byte) (*ToolCallRuleSet, error) {
+	var set ToolCallRuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse tool call rules: %w", err)
+	}
+	return &set, nil
+}
+
+// LoadToolCallRulesFile 从文件加载工具调用约束
+func LoadToolCallRulesFile(file string) (*ToolCallRuleSet, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read tool call rules: %w", err)
+	}
+	return ParseToolCallRules(data)
+}
+
+// ToolCallValidatorConfig 工具调用验证器配置
+type ToolCallValidatorConfig struct {
+	Rules []ToolCallRule
+	// Escalator 人工审批处理器；为空时 escalate 按拒绝处理
+	Escalator PolicyEscalator
+	// Auditor 审计记录器，为空时不记录
+	Auditor AuditRecorder
+	Logger  *zap.Logger
+}
+
+// ToolCallValidator 在工具执行前检查模型提出的工具调用（名称与参数）。
+// 所有匹配的规则均需满足；违反 escalate 规则时提交人工审批而不是直接拒绝。
+type ToolCallValidator struct {
+	rules     []ToolCallRule
+	escalator PolicyEscalator
+	auditor   AuditRecorder
+	logger    *zap.Logger
+}
+
+// NewToolCallValidator 创建工具调用验证器，规则非法时返回错误
+func NewToolCallValidator(cfg *ToolCallValidatorConfig) (*ToolCallValidator, error) {
+	if cfg == nil {
+		cfg = &ToolCallValidatorConfig{}
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	rules := make([]ToolCallRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		compiled, err := compileToolCallRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = compiled
+	}
+	return &ToolCallValidator{
+		rules:     rules,
+		escalator: cfg.Escalator,
+		auditor:   cfg.Auditor,
+		logger:    logger.With(zap.String("component", "tool_call_validator")),
+	}, nil
+}
+
+func compileToolCallRule(rule ToolCallRule) (ToolCallRule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return rule, fmt.Errorf("tool call rule name is required")
+	}
+	if len(rule.Tools) == 0 {
+		return rule, fmt.Errorf("tool call rule %q: tools is required", rule.Name)
+	}
+	for _, pattern := range rule.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return rule, fmt.Errorf("tool call rule %q: invalid tool pattern %q: %w", rule.Name, pattern, err)
+		}
+	}
+	switch rule.OnViolation {
+	case "":
+		rule.OnViolation = ToolCallViolationEscalate
+	case ToolCallViolationEscalate, ToolCallViolationReject:
+	default:
+		return rule, fmt.Errorf("tool call rule %q: unknown on_violation %q", rule.Name, rule.OnViolation)
+	}
+	properties := make(map[string]ToolArgumentConstraint, len(rule.Properties))
+	for name, constraint := range rule.Properties {
+		compiled, err := compileArgumentConstraint(constraint)
+		if err != nil {
+			return rule, fmt.Errorf("tool call rule %q: argument %q: %w", rule.Name, name, err)
+		}
+		properties[name] = compiled
+	}
+	rule.Properties = properties
+	return rule, nil
+}
+
+func compileArgumentConstraint(c ToolArgumentConstraint) (ToolArgumentConstraint, error) {
+	switch c.Type {
+	case "", "string", "number", "integer", "boolean", "array", "object":
+	default:
+		return c, fmt.Errorf("unknown type %q", c.Type)
+	}
+	if c.Pattern != "" {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return c, fmt.Errorf("invalid pattern: %w", err)
+		}
+		c.pattern = re
+	}
+	prefixes := make([]string, 0, len(c.PathPrefixes))
+	for _, prefix := range c.PathPrefixes {
+		if !path.IsAbs(prefix) {
+			return c, fmt.Errorf("path prefix %q must be absolute", prefix)
+		}
+		prefixes = append(prefixes, path.Clean(prefix))
+	}
+	c.PathPrefixes = prefixes
+	if c.Items != nil {
+		items, err := compileArgumentConstraint(*c.Items)
+		if err != nil {
+			return c, fmt.Errorf("items: %w", err)
+		}
+		c.Items = &items
+	}
+	return c, nil
+}
+
+// Rules 返回已编译的规则
+func (v *ToolCallValidator) Rules() []ToolCallRule {
+	return append([]ToolCallRule(nil), v.rules...)
+}
+
+// ValidateToolCall 校验一次工具调用。违反约束时：任一命中规则为 reject 则直接拒绝，
+// 否则提交审批，批准后结果有效并在 Metadata 中记录 tool_call_escalation_approved。
+func (v *ToolCallValidator) ValidateToolCall(ctx context.Context, call types.ToolCall) (*ValidationResult, error) {
+	result, err := v.validateToolCall(ctx, call)
+	if v.auditor != nil {
+		v.auditor.RecordDecision(ctx, AuditDecisionEvent{
+			Direction: AuditDirectionToolCall,
+			Validator: "tool_call_validator",
+			Content:   string(call.Arguments),
+			ToolName:  call.Name,
+			Result:    result,
+			Err:       err,
+		})
+	}
+	return result, err
+}
+
+func (v *ToolCallValidator) validateToolCall(ctx context.Context, call types.ToolCall) (*ValidationResult, error) {
+	result := NewValidationResult()
+	matched := v.matchingRules(call.Name)
+	if len(matched) == 0 {
+		return result, nil
+	}
+
+	args, parseErr := decodeToolArguments(call.Arguments)
+	action := ToolCallViolationEscalate
+	var messages []string
+	for _, rule := range matched {
+		var violations []ValidationError
+		if parseErr != nil {
+			violations = []ValidationError{{
+				Code:     ErrCodeToolArgumentViolation,
+				Message:  parseErr.Error(),
+				Severity: SeverityHigh,
+			}}
+		} else {
+			violations = checkToolCallRule(rule, args)
+		}
+		if len(violations) == 0 {
+			continue
+		}
+		if rule.OnViolation == ToolCallViolationReject {
+			action = ToolCallViolationReject
+		}
+		for _, violation := range violations {
+			if rule.Message != "" {
+				violation.Message = rule.Message + ": " + violation.Message
+			}
+			result.AddError(violation)
+			messages = append(messages, violation.Message)
+		}
+		result.Metadata["tool_call_rules"] = appendRuleName(result.Metadata["tool_call_rules"], rule.Name)
+		if parseErr != nil {
+			break
+		}
+	}
+	if result.Valid {
+		return result, nil
+	}
+	result.Metadata["tool_call_action"] = string(action)
+
+	if action == ToolCallViolationReject {
+		return result, nil
+	}
+	if v.escalator == nil {
+		result.Metadata["tool_call_escalation"] = "unavailable"
+		return result, nil
+	}
+
+	ruleNames, _ := result.Metadata["tool_call_rules"].([]string)
+	tenantID, _ := types.TenantID(ctx)
+	roles, _ := types.Roles(ctx)
+	approved, err := v.escalator.Escalate(ctx, PolicyEscalation{
+		Policy:   "tool_call:" + strings.Join(ruleNames, ","),
+		Message:  strings.Join(messages, "; "),
+		Content:  string(call.Arguments),
+		TenantID: tenantID,
+		Roles:    roles,
+		ToolName: call.Name,
+	})
+	if err != nil {
+		v.logger.Warn("tool call escalation failed", zap.String("tool", call.Name), zap.Error(err))
+		result.Metadata["tool_call_escalation"] = "error"
+		return result, nil
+	}
+	if !approved {
+		result.Metadata["tool_call_escalation"] = "rejected"
+		return result, nil
+	}
+
+	approvedResult := NewValidationResult()
+	approvedResult.Metadata = result.Metadata
+	approvedResult.Metadata["tool_call_escalation"] = "approved"
+	approvedResult.Metadata["tool_call_escalation_approved"] = true
+	for _, e := range result.Errors {
+		approvedResult.AddWarning(e.Message)
+	}
+	return approvedResult, nil
+}
+
+func (v *ToolCallValidator) matchingRules(toolName string) []ToolCallRule {
+	var matched []ToolCallRule
+	for _, rule := range v.rules {
+		for _, pattern := range rule.Tools {
+			if ok, _ := path.Match(pattern, toolName); ok {
+				matched = append(matched, rule)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func appendRuleName(existing any, name string) []string {
+	names, _ := existing.([]string)
+	return append(names, name)
+}
+
+func decodeToolArguments(raw json.RawMessage) (map[string]any, error) {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return map[string]any{}, nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("tool arguments are not a JSON object: %v", err)
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return args, nil
+}
+
+func checkToolCallRule(rule ToolCallRule, args map[string]any) []ValidationError {
+	var violations []ValidationError
+	violate := func(field, format string, a ...any) {
+		violations = append(violations, ValidationError{
+			Code:     ErrCodeToolArgumentViolation,
+			Message:  fmt.Sprintf("argument %q ", field) + fmt.Sprintf(format, a...),
+			Severity: SeverityHigh,
+			Field:    field,
+		})
+	}
+
+	for _, name := range rule.Required {
+		if _, ok := lookupArgument(args, name); !ok {
+			violate(name, "is required")
+		}
+	}
+	if rule.AdditionalProperties != nil && !*rule.AdditionalProperties {
+		for name := range args {
+			if _, ok := rule.Properties[name]; !ok {
+				violate(name, "is not allowed")
+			}
+		}
+	}
+	for name, constraint := range rule.Properties {
+		value, ok := lookupArgument(args, name)
+		if !ok {
+			continue
+		}
+		for _, msg := range checkArgumentConstraint(constraint, value) {
+			violate(name, "%s", msg)
+		}
+	}
+	return violations
+}
+
+func lookupArgument(args map[string]any, name string) (any, bool) {
+	if value, ok := args[name]; ok {
+		return value, true
+	}
+	var current any = args
+	for _, part := range strings.Split(name, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func checkArgumentConstraint(c ToolArgumentConstraint, value any) []string {
+	var problems []string
+	if c.Type != "" && !matchesArgumentType(c.Type, value) {
+		return []string{fmt.Sprintf("must be of type %s", c.Type)}
+	}
+	if len(c.Enum) > 0 && !enumContains(c.Enum, value) {
+		problems = append(problems, fmt.Sprintf("must be one of %v", c.Enum))
+	}
+	if n, ok := value.(float64); ok {
+		if c.Minimum != nil && n < *c.Minimum {
+			problems = append(problems, fmt.Sprintf("must be >= %v", *c.Minimum))
+		}
+		if c.Maximum != nil && n > *c.Maximum {
+			problems = append(problems, fmt.Sprintf("must be <= %v", *c.Maximum))
+		}
+	}
+	if s, ok := value.(string); ok {
+		if c.MaxLength > 0 && len([]rune(s)) > c.MaxLength {
+			problems = append(problems, fmt.Sprintf("exceeds max length %d", c.MaxLength))
+		}
+		if c.pattern != nil && !c.pattern.MatchString(s) {
+			problems = append(problems, fmt.Sprintf("does not match pattern %q", c.Pattern))
+		}
+		if len(c.PathPrefixes) > 0 && !pathWithinPrefixes(s, c.PathPrefixes) {
+			problems = append(problems, fmt.Sprintf("path must be under %s", strings.Join(c.PathPrefixes, ", ")))
+		}
+		if len(c.URLAllowlist) > 0 {
+			if msg := checkURLAllowlist(s, c.URLAllowlist); msg != "" {
+				problems = append(problems, msg)
+			}
+		}
+		if c.SQLReadOnly {
+			if msg := checkSQLReadOnly(s); msg != "" {
+				problems = append(problems, msg)
+			}
+		}
+	}
+	if items, ok := value.([]any); ok && c.Items != nil {
+		for i, item := range items {
+			for _, msg := range checkArgumentConstraint(*c.Items, item) {
+				problems = append(problems, fmt.Sprintf("item %d %s", i, msg))
+			}
+		}
+	}
+	return problems
+}
+
+func matchesArgumentType(typ string, value any) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, candidate := range enum {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathWithinPrefixes 清理 "." 与 ".." 后判断路径是否位于任一前缀目录之下
+func pathWithinPrefixes(p string, prefixes []string) bool {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if !path.IsAbs(p) {
+		p = path.Join(prefixes[0], p)
+	}
+	p = path.Clean(p)
+	for _, prefix := range prefixes {
+		if prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkURLAllowlist(raw string, allowlist []string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Hostname() == "" {
+		return "must be an absolute URL"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("scheme %q is not allowed", u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowlist {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if strings.HasPrefix(allowed, "*.") {
+			if strings.HasSuffix(host, allowed[1:]) {
+				return ""
+			}
+			continue
+		}
+		if host == allowed {
+			return ""
+		}
+	}
+	return fmt.Sprintf("host %q is not in the allowlist", host)
+}
+
+var (
+	sqlLineComment   = regexp.MustCompile(`--[^\n]*`)
+	sqlBlockComment  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"`)
+	sqlWriteKeyword  = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|upsert|replace|drop|alter|create|truncate|rename|grant|revoke|copy|call|exec|execute|into|lock|vacuum|attach|detach|pragma|set)\b`)
+	sqlReadStatement = regexp.MustCompile(`(?i)^(select|with|show|explain|describe|desc|values)\b`)
+)
+
+// checkSQLReadOnly 去除注释与字符串字面量后，要求每条语句均以只读关键字开头且不含写操作关键字
+func checkSQLReadOnly(query string) string {
+	stripped := sqlBlockComment.ReplaceAllString(query, " ")
+	stripped = sqlLineComment.ReplaceAllString(stripped, " ")
+	stripped = sqlStringLiteral.ReplaceAllString(stripped, "''")
+
+	statements := 0
+	for _, stmt := range strings.Split(stripped, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		statements++
+		if !sqlReadStatement.MatchString(stmt) {
+			return "must be a read-only SQL statement"
+		}
+		if kw := sqlWriteKeyword.FindString(stmt); kw != "" {
+			return fmt.Sprintf("must be read-only SQL, found %q", strings.ToUpper(kw))
+		}
+	}
+	if statements == 0 {
+		return "must contain a SQL statement"
+	}
+	return ""
+}
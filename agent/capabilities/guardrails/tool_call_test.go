@@ -0,0 +1,166 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToolCallRules = `
+rules:
+  - name: workspace-files
+    tools: ["fs_*"]
+    required: [path]
+    properties:
+      path:
+        type: string
+        path_prefixes: [/workspace]
+  - name: readonly-sql
+    tools: [sql_query]
+    on_violation: reject
+    message: database is read-only
+    additional_properties: false
+    properties:
+      query:
+        type: string
+        sql_read_only: true
+      limit:
+        type: integer
+        maximum: 1000
+  - name: web-fetch
+    tools: [http_get]
+    properties:
+      url:
+        url_allowlist: [api.github.com, "*.example.com"]
+      headers.method:
+        enum: [GET, HEAD]
+`
+
+func newTestToolCallValidator(t *testing.T, escalator PolicyEscalator) *ToolCallValidator {
+	t.Helper()
+	set, err := ParseToolCallRules([]byte(testToolCallRules))
+	require.NoError(t, err)
+	v, err := NewToolCallValidator(&ToolCallValidatorConfig{Rules: set.Rules, Escalator: escalator})
+	require.NoError(t, err)
+	return v
+}
+
+func toolCall(name, args string) types.ToolCall {
+	return types.ToolCall{ID: "call-1", Name: name, Arguments: json.RawMessage(args)}
+}
+
+func TestToolCallValidator_Constraints(t *testing.T) {
+	v := newTestToolCallValidator(t, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		call  types.ToolCall
+		valid bool
+	}{
+		{"unmatched tool", toolCall("calculator", `{"expr":"1+1"}`), true},
+		{"path under workspace", toolCall("fs_read", `{"path":"/workspace/src/main.go"}`), true},
+		{"relative path", toolCall("fs_read", `{"path":"src/main.go"}`), true},
+		{"path traversal", toolCall("fs_write", `{"path":"/workspace/../etc/passwd"}`), false},
+		{"relative traversal", toolCall("fs_read", `{"path":"../../etc/passwd"}`), false},
+		{"prefix lookalike", toolCall("fs_read", `{"path":"/workspace-other/x"}`), false},
+		{"missing required", toolCall("fs_read", `{}`), false},
+		{"wrong type", toolCall("fs_read", `{"path":42}`), false},
+		{"invalid json", toolCall("fs_read", `not json`), false},
+		{"select", toolCall("sql_query", `{"query":"SELECT * FROM users WHERE name = 'drop table'","limit":10}`), true},
+		{"cte", toolCall("sql_query", `{"query":"WITH t AS (SELECT 1) SELECT * FROM t"}`), true},
+		{"delete", toolCall("sql_query", `{"query":"DELETE FROM users"}`), false},
+		{"stacked write", toolCall("sql_query", `{"query":"SELECT 1; DROP TABLE users"}`), false},
+		{"select into", toolCall("sql_query", `{"query":"SELECT * INTO backup FROM users"}`), false},
+		{"comment hidden write", toolCall("sql_query", `{"query":"/* x */ UPDATE users SET a = 1"}`), false},
+		{"non integer limit", toolCall("sql_query", `{"query":"SELECT 1","limit":1.5}`), false},
+		{"limit above maximum", toolCall("sql_query", `{"query":"SELECT 1","limit":5000}`), false},
+		{"additional property", toolCall("sql_query", `{"query":"SELECT 1","db":"prod"}`), false},
+		{"allowed host", toolCall("http_get", `{"url":"https://api.github.com/repos"}`), true},
+		{"allowed subdomain", toolCall("http_get", `{"url":"https://docs.example.com/a"}`), true},
+		{"disallowed host", toolCall("http_get", `{"url":"https://evil.com/?q=api.github.com"}`), false},
+		{"suffix lookalike", toolCall("http_get", `{"url":"https://notexample.com"}`), false},
+		{"non http scheme", toolCall("http_get", `{"url":"file:///etc/passwd"}`), false},
+		{"nested enum", toolCall("http_get", `{"url":"https://api.github.com","headers":{"method":"POST"}}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := v.ValidateToolCall(ctx, tt.call)
+			require.NoError(t, err)
+			assert.Equal(t, tt.valid, result.Valid, "errors: %+v", result.Errors)
+			if !tt.valid {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, ErrCodeToolArgumentViolation, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestToolCallValidator_Escalation(t *testing.T) {
+	ctx := types.WithTenantID(context.Background(), "tenant-a")
+
+	t.Run("approved", func(t *testing.T) {
+		escalator := &stubEscalator{approved: true}
+		v := newTestToolCallValidator(t, escalator)
+		result, err := v.ValidateToolCall(ctx, toolCall("fs_write", `{"path":"/etc/hosts"}`))
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Equal(t, true, result.Metadata["tool_call_escalation_approved"])
+		assert.NotEmpty(t, result.Warnings)
+
+		require.Len(t, escalator.calls, 1)
+		assert.Equal(t, "tool_call:workspace-files", escalator.calls[0].Policy)
+		assert.Equal(t, "fs_write", escalator.calls[0].ToolName)
+		assert.Equal(t, "tenant-a", escalator.calls[0].TenantID)
+		assert.Contains(t, escalator.calls[0].Message, "/workspace")
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		escalator := &stubEscalator{}
+		v := newTestToolCallValidator(t, escalator)
+		result, err := v.ValidateToolCall(ctx, toolCall("fs_write", `{"path":"/etc/hosts"}`))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, "rejected", result.Metadata["tool_call_escalation"])
+	})
+
+	t.Run("escalator error fails closed", func(t *testing.T) {
+		v := newTestToolCallValidator(t, &stubEscalator{approved: true, err: errors.New("hitl down")})
+		result, err := v.ValidateToolCall(ctx, toolCall("fs_write", `{"path":"/etc/hosts"}`))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("reject rules skip escalation", func(t *testing.T) {
+		escalator := &stubEscalator{approved: true}
+		v := newTestToolCallValidator(t, escalator)
+		result, err := v.ValidateToolCall(ctx, toolCall("sql_query", `{"query":"TRUNCATE users"}`))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Empty(t, escalator.calls)
+		assert.Contains(t, result.Errors[0].Message, "database is read-only")
+	})
+}
+
+func TestNewToolCallValidator_InvalidRules(t *testing.T) {
+	cases := map[string]ToolCallRule{
+		"missing name":     {Tools: []string{"x"}},
+		"missing tools":    {Name: "r"},
+		"bad action":       {Name: "r", Tools: []string{"x"}, OnViolation: "ignore"},
+		"bad pattern":      {Name: "r", Tools: []string{"x"}, Properties: map[string]ToolArgumentConstraint{"a": {Pattern: "("}}},
+		"relative prefix":  {Name: "r", Tools: []string{"x"}, Properties: map[string]ToolArgumentConstraint{"a": {PathPrefixes: []string{"workspace"}}}},
+		"unknown type":     {Name: "r", Tools: []string{"x"}, Properties: map[string]ToolArgumentConstraint{"a": {Type: "date"}}},
+		"bad tool pattern": {Name: "r", Tools: []string{"["}},
+	}
+	for name, rule := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewToolCallValidator(&ToolCallValidatorConfig{Rules: []ToolCallRule{rule}})
+			assert.Error(t, err)
+		})
+	}
+}
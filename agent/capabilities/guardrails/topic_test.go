@@ -0,0 +1,142 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder 按关键词维度生成向量，便于构造确定的相似度
+type keywordEmbedder struct {
+	dims []string
+	err  error
+}
+
+func (e *keywordEmbedder) embed(text string) []float64 {
+	vec := make([]float64, len(e.dims))
+	lower := strings.ToLower(text)
+	for i, dim := range e.dims {
+		if strings.Contains(lower, dim) {
+			vec[i] = 1
+		}
+	}
+	return vec
+}
+
+func (e *keywordEmbedder) EmbedQuery(_ context.Context, query string) ([]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.embed(query), nil
+}
+
+func (e *keywordEmbedder) EmbedDocuments(_ context.Context, docs []string) ([][]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float64, len(docs))
+	for i, doc := range docs {
+		out[i] = e.embed(doc)
+	}
+	return out, nil
+}
+
+func bankingTopics() []Topic {
+	return []Topic{
+		{Name: "billing", Exemplars: []string{"invoice payment", "refund my invoice"}, Route: "billing-agent"},
+		{Name: "account", Exemplars: []string{"reset password login"}},
+		{Name: "politics", Exemplars: []string{"election vote"}},
+	}
+}
+
+func newBankingEmbedder() *keywordEmbedder {
+	return &keywordEmbedder{dims: []string{"invoice", "payment", "refund", "password", "login", "election", "vote", "weather"}}
+}
+
+func TestTopicValidator_AllowList(t *testing.T) {
+	v := NewTopicValidator(newBankingEmbedder(), nil, TopicValidatorConfig{
+		Topics:        bankingTopics(),
+		AllowedTopics: []string{"billing", "account"},
+	}, nil)
+
+	result, err := v.Validate(context.Background(), "I need a refund for this invoice")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "billing", result.Metadata["topic"])
+
+	result, err = v.Validate(context.Background(), "who should I vote for in the election")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, ErrCodeTopicNotAllowed, result.Errors[0].Code)
+	assert.Equal(t, "politics", result.Errors[0].Field)
+
+	result, err = v.Validate(context.Background(), "what is the weather today")
+	require.NoError(t, err)
+	assert.False(t, result.Valid, "unrecognized topics are outside an allow list")
+	assert.Equal(t, "unknown", result.Errors[0].Field)
+}
+
+func TestTopicValidator_DenyListAndRoute(t *testing.T) {
+	v := NewTopicValidator(newBankingEmbedder(), nil, TopicValidatorConfig{
+		Topics:        bankingTopics(),
+		DeniedTopics:  []string{"politics"},
+		Action:        TopicActionRoute,
+		FallbackRoute: "human",
+	}, nil)
+
+	result, err := v.Validate(context.Background(), "what is the weather today")
+	require.NoError(t, err)
+	assert.True(t, result.Valid, "deny-only mode allows unrecognized topics")
+
+	result, err = v.Validate(context.Background(), "election vote")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "human", result.Metadata["topic_route"])
+	assert.NotEmpty(t, result.Warnings)
+}
+
+func TestTopicValidator_LLMRefinement(t *testing.T) {
+	gateway := &judgeGatewayStub{reply: `{"topic": "account"}`}
+	v := NewTopicValidator(newBankingEmbedder(), gateway, TopicValidatorConfig{
+		Topics: []Topic{
+			{Name: "billing", Exemplars: []string{"invoice refund", "payment password"}},
+			{Name: "account", Exemplars: []string{"reset password login", "payment login"}},
+		},
+		AllowedTopics: []string{"account"},
+		RefineModel:   "classifier-mini",
+	}, nil)
+
+	// 与 billing、account 示例相似度相同，前两名难以区分
+	classification, err := v.Classify(context.Background(), "payment failed after password login")
+	require.NoError(t, err)
+	assert.True(t, classification.Refined)
+	assert.Equal(t, "account", classification.Topic)
+	assert.Equal(t, int32(1), gateway.calls.Load())
+	assert.Equal(t, "classifier-mini", gateway.lastReq.Model)
+
+	// 清晰命中时不调用 LLM
+	_, err = v.Classify(context.Background(), "reset password login")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), gateway.calls.Load())
+
+	// 复核失败时保留向量分类结果
+	gateway.err = errors.New("unavailable")
+	classification, err = v.Classify(context.Background(), "payment failed after password login")
+	require.NoError(t, err)
+	assert.False(t, classification.Refined)
+}
+
+func TestTopicValidator_EmbedderError(t *testing.T) {
+	v := NewTopicValidator(&keywordEmbedder{err: errors.New("down")}, nil, TopicValidatorConfig{Topics: bankingTopics()}, nil)
+	_, err := v.Validate(context.Background(), "hello")
+	require.Error(t, err)
+
+	v = NewTopicValidator(nil, nil, TopicValidatorConfig{}, nil)
+	_, err = v.Validate(context.Background(), "hello")
+	require.Error(t, err)
+}
@@ -16,6 +16,13 @@ type Validator interface {
 	Priority() int
 }
 
+// Embedder 语义类验证器（主题分类、越狱语义检测）所需的向量化能力
+// llm/capabilities/embedding.Provider 满足该接口
+type Embedder interface {
+	EmbedQuery(ctx context.Context, query string) ([]float64, error)
+	EmbedDocuments(ctx context.Context, documents []string) ([][]float64, error)
+}
+
 // ValidationResult 验证结果
 type ValidationResult struct {
 	Valid    bool              `json:"valid"`
@@ -88,6 +95,9 @@ const (
 	ErrCodeBlockedKeyword    = "BLOCKED_KEYWORD"
 	ErrCodeContentBlocked    = "CONTENT_BLOCKED"
 	ErrCodeValidationFailed  = "VALIDATION_FAILED"
+	ErrCodePolicyViolation   = "POLICY_VIOLATION"
+	ErrCodeTopicNotAllowed   = "TOPIC_NOT_ALLOWED"
+	ErrCodeSecretDetected    = "SECRET_DETECTED"
 )
 
 // TripwireError 表示 Tripwire 被触发的错误。
@@ -122,6 +132,16 @@ type GuardrailsConfig struct {
 	BlockedKeywords     []string `json:"blocked_keywords"`
 	PIIDetectionEnabled bool     `json:"pii_detection_enabled"`
 	InjectionDetection  bool     `json:"injection_detection"`
+	// PIILocales PII 地区规则包，包含 "auto" 时按内容语言自动选择；为空时使用默认规则
+	PIILocales []string `json:"pii_locales,omitempty"`
+	// SecretsDetection 在输出链中脱敏 API Key、私钥、连接串等凭据
+	SecretsDetection bool `json:"secrets_detection"`
+	// PolicyFile 声明式策略 YAML 文件，由 PolicyEngine 加载后同时作用于输入与输出
+	PolicyFile string `json:"policy_file,omitempty"`
+	// ToolCallValidator 在工具执行前校验工具调用参数
+	ToolCallValidator *ToolCallValidator `json:"-"`
+	// AuditLogger 记录输入与输出链中每个验证器的校验结论，为空时仅输出验证器在内存中记录失败事件
+	AuditLogger *GuardrailAuditLogger `json:"-"`
 
 	// 失败处理
 	OnInputFailure  FailureAction `json:"on_input_failure"`
@@ -129,6 +149,16 @@ type GuardrailsConfig struct {
 	MaxRetries      int           `json:"max_retries"`
 }
 
+// PIIDetectorConfig 根据护栏配置生成 PII 检测器配置，未配置地区规则包时返回 nil 使用默认配置
+func (c *GuardrailsConfig) PIIDetectorConfig() *PIIDetectorConfig {
+	if c == nil || len(c.PIILocales) == 0 {
+		return nil
+	}
+	cfg := DefaultPIIDetectorConfig()
+	cfg.Locales = append([]string(nil), c.PIILocales...)
+	return cfg
+}
+
 // FailureAction 失败处理动作
 type FailureAction string
 
@@ -151,6 +181,7 @@ func DefaultConfig() *GuardrailsConfig {
 		BlockedKeywords:     []string{},
 		PIIDetectionEnabled: true,
 		InjectionDetection:  true,
+		SecretsDetection:    true,
 		OnInputFailure:      FailureActionReject,
 		OnOutputFailure:     FailureActionReject,
 		MaxRetries:          0,
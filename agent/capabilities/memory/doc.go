@@ -2,10 +2,12 @@
 //
 // It provides:
 //   - Short-term and working memory stores (InMemoryMemoryStore)
+//   - Write-ahead log for in-process layers (WriteAheadLog, DurableMemoryStore, DurableVectorStore)
 //   - Episodic memory (EpisodicStore, InMemoryEpisodicStore)
 //   - Semantic memory / knowledge graph (KnowledgeGraph, InMemoryKnowledgeGraph)
 //   - Observation pipeline (in the observation/ subpackage)
 //   - Enhanced memory system (EnhancedMemorySystem) that unifies all layers
 //   - Memory coordinator (Coordinator) for caching and recent-message management
 //   - Memory runtime (MemoryRuntime) for policy-driven memory access
+//   - Recall quality harness (RecallEvaluator, ScoredMemoryManager) for tuning decay and importance
 package memory
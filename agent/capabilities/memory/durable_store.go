@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// WAL 中的记忆层名称
+const (
+	WALLayerShortTerm = "short_term"
+	WALLayerWorking   = "working"
+	WALLayerLongTerm  = "long_term"
+)
+
+// DurableMemoryStore 为进程内 MemoryStore 增加预写日志。
+// 写操作先追加到 WAL 再作用于内层存储，重启后通过 Recover 重放。
+// 值以 JSON 形式持久化，重放后得到的是 JSON 解码结果（如 map[string]any）。
+type DurableMemoryStore struct {
+	layer string
+	inner MemoryStore
+	wal   *WriteAheadLog
+}
+
+// NewDurableMemoryStore 创建带 WAL 的记忆存储
+func NewDurableMemoryStore(layer string, inner MemoryStore, wal *WriteAheadLog) *DurableMemoryStore {
+	return &DurableMemoryStore{layer: layer, inner: inner, wal: wal}
+}
+
+// Recover 从 WAL 重放该层的存活记录，剩余 TTL 按原过期时间计算
+func (s *DurableMemoryStore) Recover(ctx context.Context) (int, error) {
+	records, err := s.wal.LiveRecords(s.layer)
+	if err != nil {
+		return 0, err
+	}
+	now := s.wal.now()
+	restored := 0
+	for _, rec := range records {
+		var value any
+		if err := json.Unmarshal(rec.Value, &value); err != nil {
+			return restored, fmt.Errorf("decode wal value for %s: %w", rec.Key, err)
+		}
+		var ttl time.Duration
+		if rec.ExpiresAt > 0 {
+			if ttl = time.Unix(0, rec.ExpiresAt).Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		if err := s.inner.Save(ctx, rec.Key, value, ttl); err != nil {
+			return restored, fmt.Errorf("restore %s: %w", rec.Key, err)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+func (s *DurableMemoryStore) Save(ctx context.Context, key string, value any, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode memory value: %w", err)
+	}
+	rec := WALRecord{Layer: s.layer, Op: WALOpPut, Key: key, Value: raw}
+	if ttl > 0 {
+		rec.ExpiresAt = s.wal.now().Add(ttl).UnixNano()
+	}
+	if err := s.wal.Append(rec); err != nil {
+		return err
+	}
+	return s.inner.Save(ctx, key, value, ttl)
+}
+
+func (s *DurableMemoryStore) Load(ctx context.Context, key string) (any, error) {
+	return s.inner.Load(ctx, key)
+}
+
+func (s *DurableMemoryStore) Delete(ctx context.Context, key string) error {
+	if err := s.wal.Append(WALRecord{Layer: s.layer, Op: WALOpDelete, Key: key}); err != nil {
+		return err
+	}
+	return s.inner.Delete(ctx, key)
+}
+
+func (s *DurableMemoryStore) List(ctx context.Context, pattern string, limit int) ([]any, error) {
+	return s.inner.List(ctx, pattern, limit)
+}
+
+func (s *DurableMemoryStore) Clear(ctx context.Context) error {
+	if err := s.wal.Append(WALRecord{Layer: s.layer, Op: WALOpClear}); err != nil {
+		return err
+	}
+	return s.inner.Clear(ctx)
+}
+
+// durableVectorValue 向量记录在 WAL 中的值
+type durableVectorValue struct {
+	Vector   []float64      `json:"vector"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// DurableVectorStore 为进程内向量存储增加预写日志
+type DurableVectorStore struct {
+	layer string
+	inner types.VectorStore
+	wal   *WriteAheadLog
+}
+
+// NewDurableVectorStore 创建带 WAL 的向量存储
+func NewDurableVectorStore(layer string, inner types.VectorStore, wal *WriteAheadLog) *DurableVectorStore {
+	return &DurableVectorStore{layer: layer, inner: inner, wal: wal}
+}
+
+// Recover 从 WAL 重放该层的存活向量
+func (s *DurableVectorStore) Recover(ctx context.Context) (int, error) {
+	records, err := s.wal.LiveRecords(s.layer)
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, rec := range records {
+		var value durableVectorValue
+		if err := json.Unmarshal(rec.Value, &value); err != nil {
+			return restored, fmt.Errorf("decode wal vector for %s: %w", rec.Key, err)
+		}
+		if err := s.inner.Store(ctx, rec.Key, value.Vector, value.Metadata); err != nil {
+			return restored, fmt.Errorf("restore vector %s: %w", rec.Key, err)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+func (s *DurableVectorStore) Store(ctx context.Context, id string, vector []float64, metadata map[string]any) error {
+	if err := s.appendPut(id, vector, metadata); err != nil {
+		return err
+	}
+	return s.inner.Store(ctx, id, vector, metadata)
+}
+
+func (s *DurableVectorStore) Search(ctx context.Context, query []float64, topK int, filter map[string]any) ([]types.VectorSearchResult, error) {
+	return s.inner.Search(ctx, query, topK, filter)
+}
+
+func (s *DurableVectorStore) Delete(ctx context.Context, id string) error {
+	if err := s.wal.Append(WALRecord{Layer: s.layer, Op: WALOpDelete, Key: id}); err != nil {
+		return err
+	}
+	return s.inner.Delete(ctx, id)
+}
+
+// BatchStore 逐条写入 WAL 后批量作用于内层存储；内层不支持批量时逐条存储
+func (s *DurableVectorStore) BatchStore(ctx context.Context, items []VectorItem) error {
+	for _, item := range items {
+		if err := s.appendPut(item.ID, item.Vector, item.Metadata); err != nil {
+			return err
+		}
+	}
+	if batch, ok := s.inner.(BatchVectorStore); ok {
+		return batch.BatchStore(ctx, items)
+	}
+	for _, item := range items {
+		if err := s.inner.Store(ctx, item.ID, item.Vector, item.Metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DurableVectorStore) appendPut(id string, vector []float64, metadata map[string]any) error {
+	raw, err := json.Marshal(durableVectorValue{Vector: vector, Metadata: metadata})
+	if err != nil {
+		return fmt.Errorf("encode vector value: %w", err)
+	}
+	return s.wal.Append(WALRecord{Layer: s.layer, Op: WALOpPut, Key: id, Value: raw})
+}
+
+// recoverDurableLayers 用 WAL 包装默认的进程内记忆层并重放历史记录
+func recoverDurableLayers(
+	wal *WriteAheadLog,
+	shortTerm, working MemoryStore,
+	longTerm types.VectorStore,
+	logger *zap.Logger,
+) (MemoryStore, MemoryStore, types.VectorStore) {
+	ctx := context.Background()
+	durableShort := NewDurableMemoryStore(WALLayerShortTerm, shortTerm, wal)
+	durableWorking := NewDurableMemoryStore(WALLayerWorking, working, wal)
+	for _, store := range []*DurableMemoryStore{durableShort, durableWorking} {
+		restored, err := store.Recover(ctx)
+		if err != nil {
+			logger.Warn("memory wal replay incomplete", zap.String("layer", store.layer), zap.Error(err))
+		}
+		logger.Info("memory layer recovered from wal", zap.String("layer", store.layer), zap.Int("entries", restored))
+	}
+	if longTerm == nil {
+		return durableShort, durableWorking, nil
+	}
+	durableLong := NewDurableVectorStore(WALLayerLongTerm, longTerm, wal)
+	restored, err := durableLong.Recover(ctx)
+	if err != nil {
+		logger.Warn("memory wal replay incomplete", zap.String("layer", WALLayerLongTerm), zap.Error(err))
+	}
+	logger.Info("memory layer recovered from wal", zap.String("layer", WALLayerLongTerm), zap.Int("entries", restored))
+	return durableShort, durableWorking, durableLong
+}
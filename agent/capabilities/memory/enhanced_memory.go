@@ -52,6 +52,9 @@ type EnhancedMemorySystem struct {
 	consolidator     *MemoryConsolidator
 	consolidatorOnce sync.Once // 确保 consolidator 只初始化一次
 
+	// 进程内记忆层的预写日志（可选）
+	wal *WriteAheadLog
+
 	// 配置
 	config EnhancedMemoryConfig
 
@@ -88,6 +91,9 @@ type EnhancedMemoryConfig struct {
 	// 记忆整合配置
 	ConsolidationEnabled  bool          `json:"consolidation_enabled"`  // 是否启用记忆整合
 	ConsolidationInterval time.Duration `json:"consolidation_interval"` // 整合间隔
+
+	// 预写日志配置（仅对 NewDefaultEnhancedMemorySystem 创建的进程内短期、工作、长期记忆生效）
+	WAL WALConfig `json:"wal"`
 }
 
 // DefaultEnhancedMemoryConfig 默认配置
@@ -277,6 +283,18 @@ func NewDefaultEnhancedMemorySystem(config EnhancedMemoryConfig, logger *zap.Log
 		longTerm = NewInMemoryVectorStore(InMemoryVectorStoreConfig{Dimension: config.VectorDimension}, logger)
 	}
 
+	var wal *WriteAheadLog
+	var shortTermStore, workingStore MemoryStore = shortTerm, working
+	if strings.TrimSpace(config.WAL.Dir) != "" {
+		opened, err := OpenWriteAheadLog(config.WAL, logger)
+		if err != nil {
+			logger.Error("failed to open memory wal, falling back to volatile memory", zap.Error(err))
+		} else {
+			wal = opened
+			shortTermStore, workingStore, longTerm = recoverDurableLayers(wal, shortTerm, working, longTerm, logger)
+		}
+	}
+
 	var episodic EpisodicStore
 	if config.EpisodicEnabled {
 		episodic = NewInMemoryEpisodicStore(config.EpisodicMaxEntries, logger)
@@ -290,13 +308,14 @@ func NewDefaultEnhancedMemorySystem(config EnhancedMemoryConfig, logger *zap.Log
 	observationStore := obs.NewInMemoryObservationStore(config.ObservationMaxEntries)
 
 	system := NewEnhancedMemorySystem(EnhancedMemoryDeps{
-		ShortTerm:        shortTerm,
-		Working:          working,
+		ShortTerm:        shortTermStore,
+		Working:          workingStore,
 		LongTerm:         longTerm,
 		Episodic:         episodic,
 		Semantic:         semantic,
 		ObservationStore: observationStore,
 	}, config, logger)
+	system.wal = wal
 	if config.ConsolidationEnabled {
 		_ = system.AddDefaultConsolidationStrategies()
 	}
@@ -609,6 +628,14 @@ func (m *EnhancedMemorySystem) StopConsolidation() error {
 	return m.consolidator.Stop()
 }
 
+// Close 刷盘并关闭预写日志；未启用 WAL 时为空操作
+func (m *EnhancedMemorySystem) Close() error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.Close()
+}
+
 // 合并后启动一次合并运行(对手工运行和测试有用)。
 func (m *EnhancedMemorySystem) ConsolidateOnce(ctx context.Context) error {
 	if !m.config.ConsolidationEnabled || m.consolidator == nil {
@@ -0,0 +1,419 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ProfileStyle 描述对用户的表达风格校准。
+type ProfileStyle struct {
+	Tone      string `json:"tone,omitempty"`
+	Verbosity string `json:"verbosity,omitempty"`
+	Format    string `json:"format,omitempty"`
+}
+
+// AgentProfile 是按 用户+Agent 持久化的个性化画像。
+// 与原始记忆不同，画像是经过蒸馏的精简结论：偏好、风格校准和长期指令。
+type AgentProfile struct {
+	UserID               string            `json:"user_id"`
+	AgentID              string            `json:"agent_id"`
+	Preferences          map[string]string `json:"preferences,omitempty"`
+	Style                ProfileStyle      `json:"style"`
+	StandingInstructions []string          `json:"standing_instructions,omitempty"`
+	Version              int               `json:"version"`
+	UpdatedAt            time.Time         `json:"updated_at"`
+	LastDistilledAt      time.Time         `json:"last_distilled_at,omitempty"`
+}
+
+// NewAgentProfile 创建空画像
+func NewAgentProfile(userID, agentID string) *AgentProfile {
+	return &AgentProfile{UserID: userID, AgentID: agentID, Preferences: make(map[string]string)}
+}
+
+// Clone 返回深拷贝
+func (p *AgentProfile) Clone() *AgentProfile {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.Preferences = make(map[string]string, len(p.Preferences))
+	for k, v := range p.Preferences {
+		clone.Preferences[k] = v
+	}
+	clone.StandingInstructions = append([]string(nil), p.StandingInstructions...)
+	return &clone
+}
+
+// IsEmpty 判断画像是否没有任何可注入内容
+func (p *AgentProfile) IsEmpty() bool {
+	return p == nil || (len(p.Preferences) == 0 && p.Style == ProfileStyle{} && len(p.StandingInstructions) == 0)
+}
+
+// RenderPrompt 将画像渲染为可注入提示词的文本，空画像返回空字符串。
+func (p *AgentProfile) RenderPrompt() string {
+	if p.IsEmpty() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("<user_profile>\n")
+	if len(p.StandingInstructions) > 0 {
+		sb.WriteString("Standing instructions from this user:\n")
+		for _, inst := range p.StandingInstructions {
+			sb.WriteString("- " + inst + "\n")
+		}
+	}
+	if len(p.Preferences) > 0 {
+		sb.WriteString("Known preferences:\n")
+		keys := make([]string, 0, len(p.Preferences))
+		for k := range p.Preferences {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString("- " + k + ": " + p.Preferences[k] + "\n")
+		}
+	}
+	var style []string
+	if p.Style.Tone != "" {
+		style = append(style, "tone="+p.Style.Tone)
+	}
+	if p.Style.Verbosity != "" {
+		style = append(style, "verbosity="+p.Style.Verbosity)
+	}
+	if p.Style.Format != "" {
+		style = append(style, "format="+p.Style.Format)
+	}
+	if len(style) > 0 {
+		sb.WriteString("Preferred style: " + strings.Join(style, ", ") + "\n")
+	}
+	sb.WriteString("</user_profile>")
+	return sb.String()
+}
+
+// ProfileStore 持久化 Agent 画像。画像不存在时 LoadProfile 返回 nil, nil。
+type ProfileStore interface {
+	LoadProfile(ctx context.Context, userID, agentID string) (*AgentProfile, error)
+	SaveProfile(ctx context.Context, profile *AgentProfile) error
+}
+
+func validateProfileKey(userID, agentID string) error {
+	if strings.TrimSpace(userID) == "" {
+		return fmt.Errorf("profile user id is required")
+	}
+	if strings.TrimSpace(agentID) == "" {
+		return fmt.Errorf("profile agent id is required")
+	}
+	return nil
+}
+
+// InMemoryProfileStore 基于内存的画像存储，适用于测试和单进程部署。
+type InMemoryProfileStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*AgentProfile
+}
+
+// NewInMemoryProfileStore 创建内存画像存储
+func NewInMemoryProfileStore() *InMemoryProfileStore {
+	return &InMemoryProfileStore{profiles: make(map[string]*AgentProfile)}
+}
+
+func (s *InMemoryProfileStore) LoadProfile(ctx context.Context, userID, agentID string) (*AgentProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateProfileKey(userID, agentID); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profiles[agentID+"\x00"+userID].Clone(), nil
+}
+
+func (s *InMemoryProfileStore) SaveProfile(ctx context.Context, profile *AgentProfile) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("profile is nil")
+	}
+	if err := validateProfileKey(profile.UserID, profile.AgentID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[profile.AgentID+"\x00"+profile.UserID] = profile.Clone()
+	return nil
+}
+
+// FileProfileStore 以 JSON 文件持久化画像，每个 用户+Agent 一个文件。
+type FileProfileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileProfileStore 创建文件画像存储，目录不存在时自动创建。
+func NewFileProfileStore(dir string) (*FileProfileStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("profile directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create profile directory: %w", err)
+	}
+	return &FileProfileStore{dir: dir}, nil
+}
+
+func (s *FileProfileStore) path(userID, agentID string) string {
+	return filepath.Join(s.dir, url.PathEscape(agentID), url.PathEscape(userID)+".json")
+}
+
+func (s *FileProfileStore) LoadProfile(ctx context.Context, userID, agentID string) (*AgentProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateProfileKey(userID, agentID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, err := os.ReadFile(s.path(userID, agentID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profile: %w", err)
+	}
+	var profile AgentProfile
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return nil, fmt.Errorf("decode profile: %w", err)
+	}
+	return &profile, nil
+}
+
+func (s *FileProfileStore) SaveProfile(ctx context.Context, profile *AgentProfile) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("profile is nil")
+	}
+	if err := validateProfileKey(profile.UserID, profile.AgentID); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode profile: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(profile.UserID, profile.AgentID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create profile directory: %w", err)
+	}
+	// 先写临时文件再重命名，避免进程中断留下半截文件
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write profile: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write profile: %w", err)
+	}
+	return nil
+}
+
+// ProfileEpisodeSource 提供用于蒸馏的情节记忆，EpisodicStore 满足该接口。
+type ProfileEpisodeSource interface {
+	QueryEvents(ctx context.Context, query EpisodicQuery) ([]types.EpisodicEvent, error)
+}
+
+// ProfileDistillerConfig 画像蒸馏配置
+type ProfileDistillerConfig struct {
+	Model string
+	// Interval 两次蒸馏之间的最小间隔
+	Interval time.Duration
+	// MinEvents 新增情节少于该值时跳过蒸馏
+	MinEvents int
+	// MaxEvents 单次蒸馏最多读取的情节数
+	MaxEvents int
+}
+
+// DefaultProfileDistillerConfig 返回默认蒸馏配置
+func DefaultProfileDistillerConfig() ProfileDistillerConfig {
+	return ProfileDistillerConfig{
+		Interval:  time.Hour,
+		MinEvents: 3,
+		MaxEvents: 50,
+	}
+}
+
+// ProfileDistiller 周期性地调用 LLM，把情节记忆蒸馏进 Agent 画像。
+type ProfileDistiller struct {
+	gateway  llm.Gateway
+	episodes ProfileEpisodeSource
+	config   ProfileDistillerConfig
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// NewProfileDistiller 创建画像蒸馏器
+func NewProfileDistiller(gateway llm.Gateway, episodes ProfileEpisodeSource, config ProfileDistillerConfig, logger *zap.Logger) *ProfileDistiller {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	defaults := DefaultProfileDistillerConfig()
+	if config.MinEvents <= 0 {
+		config.MinEvents = defaults.MinEvents
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = defaults.MaxEvents
+	}
+	return &ProfileDistiller{
+		gateway:  gateway,
+		episodes: episodes,
+		config:   config,
+		logger:   logger.With(zap.String("component", "profile_distiller")),
+		now:      time.Now,
+	}
+}
+
+// Due 判断画像是否到了下一次蒸馏时间
+func (d *ProfileDistiller) Due(profile *AgentProfile) bool {
+	if profile == nil || profile.LastDistilledAt.IsZero() {
+		return true
+	}
+	return d.now().Sub(profile.LastDistilledAt) >= d.config.Interval
+}
+
+// profileUpdate 是 LLM 蒸馏输出的结构
+type profileUpdate struct {
+	Preferences          map[string]string `json:"preferences"`
+	Style                ProfileStyle      `json:"style"`
+	StandingInstructions []string          `json:"standing_instructions"`
+}
+
+// Distill 读取上次蒸馏以来该用户的情节，让 LLM 给出更新后的画像。
+// 返回值 updated 为 false 表示新增情节不足，画像未变化。
+func (d *ProfileDistiller) Distill(ctx context.Context, profile *AgentProfile) (_ *AgentProfile, updated bool, _ error) {
+	if profile == nil {
+		return nil, false, fmt.Errorf("profile is nil")
+	}
+	if d.gateway == nil || d.episodes == nil {
+		return profile, false, fmt.Errorf("profile distiller requires a gateway and an episode source")
+	}
+	events, err := d.episodes.QueryEvents(ctx, EpisodicQuery{
+		AgentID:   profile.AgentID,
+		StartTime: profile.LastDistilledAt,
+	})
+	if err != nil {
+		return profile, false, fmt.Errorf("query episodes: %w", err)
+	}
+	events = filterProfileEvents(events, profile)
+	if len(events) > d.config.MaxEvents {
+		events = events[len(events)-d.config.MaxEvents:]
+	}
+	if len(events) < d.config.MinEvents {
+		return profile, false, nil
+	}
+
+	current, err := json.Marshal(profileUpdate{
+		Preferences:          profile.Preferences,
+		Style:                profile.Style,
+		StandingInstructions: profile.StandingInstructions,
+	})
+	if err != nil {
+		return profile, false, fmt.Errorf("encode profile: %w", err)
+	}
+	var sb strings.Builder
+	for _, event := range events {
+		sb.WriteString(fmt.Sprintf("- [%s] %s: %s\n", event.Timestamp.Format(time.RFC3339), event.Type, event.Content))
+	}
+	prompt := "You maintain a concise personalization profile for one user of an AI agent.\n" +
+		"Current profile (JSON):\n" + string(current) + "\n\n" +
+		"Recent interactions:\n" + sb.String() + "\n" +
+		"Update the profile with durable preferences, style calibration and standing instructions the user has expressed. " +
+		"Drop anything contradicted by newer interactions and ignore one-off requests. " +
+		`Reply with only a JSON object: {"preferences":{"key":"value"},"style":{"tone":"","verbosity":"","format":""},"standing_instructions":["..."]}`
+
+	resp, err := d.gateway.Invoke(ctx, &llm.UnifiedRequest{
+		Capability: llm.CapabilityChat,
+		Payload: &llm.ChatRequest{
+			Model:       d.config.Model,
+			Messages:    []types.Message{{Role: llm.RoleUser, Content: prompt}},
+			Temperature: 0.2,
+			MaxTokens:   800,
+		},
+	})
+	if err != nil {
+		return profile, false, fmt.Errorf("distill profile: %w", err)
+	}
+	chatResp, ok := resp.Output.(*llm.ChatResponse)
+	if !ok || chatResp == nil || len(chatResp.Choices) == 0 {
+		return profile, false, fmt.Errorf("distill profile: unexpected gateway output %T", resp.Output)
+	}
+	var update profileUpdate
+	if err := json.Unmarshal([]byte(extractJSONObject(chatResp.Choices[0].Message.Content)), &update); err != nil {
+		return profile, false, fmt.Errorf("decode distilled profile: %w", err)
+	}
+
+	now := d.now()
+	next := profile.Clone()
+	next.Preferences = make(map[string]string, len(update.Preferences))
+	for k, v := range update.Preferences {
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); k != "" && v != "" {
+			next.Preferences[k] = v
+		}
+	}
+	next.Style = ProfileStyle{
+		Tone:      strings.TrimSpace(update.Style.Tone),
+		Verbosity: strings.TrimSpace(update.Style.Verbosity),
+		Format:    strings.TrimSpace(update.Style.Format),
+	}
+	next.StandingInstructions = normalizeStringSlice(update.StandingInstructions)
+	next.Version++
+	next.UpdatedAt = now
+	next.LastDistilledAt = now
+	d.logger.Debug("profile distilled",
+		zap.String("agent_id", next.AgentID),
+		zap.String("user_id", next.UserID),
+		zap.Int("events", len(events)),
+		zap.Int("version", next.Version),
+	)
+	return next, true, nil
+}
+
+// filterProfileEvents 只保留属于画像用户且晚于上次蒸馏的情节
+func filterProfileEvents(events []types.EpisodicEvent, profile *AgentProfile) []types.EpisodicEvent {
+	out := make([]types.EpisodicEvent, 0, len(events))
+	for _, event := range events {
+		if !profile.LastDistilledAt.IsZero() && !event.Timestamp.After(profile.LastDistilledAt) {
+			continue
+		}
+		if userID, _ := event.Context["user_id"].(string); userID != profile.UserID {
+			continue
+		}
+		out = append(out, event)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// extractJSONObject 截取 LLM 输出中的 JSON 对象，兼容 markdown 代码块包裹
+func extractJSONObject(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return strings.TrimSpace(content)
+	}
+	return content[start : end+1]
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type stubProfileGateway struct {
+	content  string
+	requests []*llm.ChatRequest
+}
+
+func (g *stubProfileGateway) Invoke(_ context.Context, req *llm.UnifiedRequest) (*llm.UnifiedResponse, error) {
+	g.requests = append(g.requests, req.Payload.(*llm.ChatRequest))
+	return &llm.UnifiedResponse{Output: &llm.ChatResponse{
+		Choices: []types.ChatChoice{{Message: types.Message{Role: llm.RoleAssistant, Content: g.content}}},
+	}}, nil
+}
+
+func (g *stubProfileGateway) Stream(context.Context, *llm.UnifiedRequest) (<-chan llm.UnifiedChunk, error) {
+	return nil, nil
+}
+
+func TestAgentProfile_RenderPrompt(t *testing.T) {
+	assert.Empty(t, NewAgentProfile("u1", "a1").RenderPrompt())
+
+	profile := &AgentProfile{
+		Preferences:          map[string]string{"timezone": "UTC+8", "language": "zh"},
+		Style:                ProfileStyle{Tone: "casual", Verbosity: "brief"},
+		StandingInstructions: []string{"Always cite sources"},
+	}
+	assert.Equal(t, "<user_profile>\n"+
+		"Standing instructions from this user:\n- Always cite sources\n"+
+		"Known preferences:\n- language: zh\n- timezone: UTC+8\n"+
+		"Preferred style: tone=casual, verbosity=brief\n"+
+		"</user_profile>", profile.RenderPrompt())
+}
+
+func TestProfileStores_RoundTrip(t *testing.T) {
+	fileStore, err := NewFileProfileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for name, store := range map[string]ProfileStore{"memory": NewInMemoryProfileStore(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			missing, err := store.LoadProfile(ctx, "u/1", "agent")
+			require.NoError(t, err)
+			assert.Nil(t, missing)
+
+			profile := NewAgentProfile("u/1", "agent")
+			profile.Preferences["units"] = "metric"
+			profile.Version = 2
+			require.NoError(t, store.SaveProfile(ctx, profile))
+			profile.Preferences["units"] = "imperial"
+
+			loaded, err := store.LoadProfile(ctx, "u/1", "agent")
+			require.NoError(t, err)
+			require.NotNil(t, loaded)
+			assert.Equal(t, "metric", loaded.Preferences["units"])
+			assert.Equal(t, 2, loaded.Version)
+
+			other, err := store.LoadProfile(ctx, "u/2", "agent")
+			require.NoError(t, err)
+			assert.Nil(t, other)
+			assert.Error(t, store.SaveProfile(ctx, NewAgentProfile("", "agent")))
+		})
+	}
+}
+
+func TestProfileDistiller_Distill(t *testing.T) {
+	ctx := context.Background()
+	episodes := NewInMemoryEpisodicStore(0, zap.NewNop())
+	base := time.Now().Add(-time.Hour)
+	for i, content := range []string{"please answer in bullet points", "keep it short", "I work in UTC+8"} {
+		require.NoError(t, episodes.RecordEvent(ctx, &types.EpisodicEvent{
+			AgentID: "agent", Type: "interaction", Content: content,
+			Context: map[string]any{"user_id": "u1"}, Timestamp: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	require.NoError(t, episodes.RecordEvent(ctx, &types.EpisodicEvent{
+		AgentID: "agent", Type: "interaction", Content: "another user's secret",
+		Context: map[string]any{"user_id": "u2"}, Timestamp: base,
+	}))
+
+	gateway := &stubProfileGateway{content: "```json\n" +
+		`{"preferences":{"timezone":"UTC+8"},"style":{"verbosity":"brief","format":"bullets"},"standing_instructions":["Keep answers short"," "]}` +
+		"\n```"}
+	distiller := NewProfileDistiller(gateway, episodes, ProfileDistillerConfig{Model: "gpt-4", Interval: time.Hour}, nil)
+
+	profile := NewAgentProfile("u1", "agent")
+	assert.True(t, distiller.Due(profile))
+	next, updated, err := distiller.Distill(ctx, profile)
+	require.NoError(t, err)
+	require.True(t, updated)
+	assert.Equal(t, map[string]string{"timezone": "UTC+8"}, next.Preferences)
+	assert.Equal(t, ProfileStyle{Verbosity: "brief", Format: "bullets"}, next.Style)
+	assert.Equal(t, []string{"Keep answers short"}, next.StandingInstructions)
+	assert.Equal(t, 1, next.Version)
+	assert.False(t, distiller.Due(next))
+	assert.Zero(t, profile.Version, "the input profile is not mutated")
+
+	require.Len(t, gateway.requests, 1)
+	prompt := gateway.requests[0].Messages[0].Content
+	assert.Contains(t, prompt, "keep it short")
+	assert.NotContains(t, prompt, "another user's secret")
+
+	// 上次蒸馏之后没有新情节时不会再次调用 LLM
+	again, updated, err := distiller.Distill(ctx, next)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Same(t, next, again)
+	assert.Len(t, gateway.requests, 1)
+}
@@ -0,0 +1,478 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ============================================================================
+// 记忆召回质量评测
+// ============================================================================
+//
+// 按时间线回放剧本化的对话历史，在指定时刻提出问题，统计期望记忆是否出现在
+// 召回结果中，以及过时/干扰记忆是否被错误召回。对多组召回策略（不同半衰期、
+// 重要度阈值等）运行同一批剧本并对比，让调参有据可依。
+// ============================================================================
+
+// MetadataEvalMemoryID 评测写入的记忆在元数据中携带的剧本记忆 ID，
+// 供自行分配记录 ID 的 MemoryManager 实现回溯匹配
+const MetadataEvalMemoryID = "eval_memory_id"
+
+// RecallEvalMemory 剧本中的一条记忆
+type RecallEvalMemory struct {
+	ID      string     `json:"id"`
+	Content string     `json:"content"`
+	Kind    MemoryKind `json:"kind,omitempty"`
+	// At 相对剧本起点的写入时刻
+	At time.Duration `json:"at"`
+	// Importance 重要度（0~1），写入元数据 MetadataImportance；为 0 时不写入
+	Importance float64        `json:"importance,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// RecallEvalQuery 剧本中的一次提问
+type RecallEvalQuery struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	// At 相对剧本起点的提问时刻，只有此前写入的记忆可被召回
+	At time.Duration `json:"at"`
+	// Expected 应被召回的记忆 ID
+	Expected []string `json:"expected"`
+	// Avoid 不应排在期望记忆之前的记忆 ID，如已被更正的旧事实
+	Avoid []string `json:"avoid,omitempty"`
+}
+
+// RecallEvalScenario 一段剧本化的历史及其提问
+type RecallEvalScenario struct {
+	Name     string             `json:"name"`
+	AgentID  string             `json:"agent_id,omitempty"`
+	Memories []RecallEvalMemory `json:"memories"`
+	Queries  []RecallEvalQuery  `json:"queries"`
+}
+
+// Validate 校验记忆与提问 ID 唯一，且期望/规避的记忆在提问前已写入
+func (s RecallEvalScenario) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("recall eval scenario: name is required")
+	}
+	written := make(map[string]time.Duration, len(s.Memories))
+	for i, mem := range s.Memories {
+		if mem.ID == "" {
+			return fmt.Errorf("recall eval scenario %q: memories[%d]: id is required", s.Name, i)
+		}
+		if _, dup := written[mem.ID]; dup {
+			return fmt.Errorf("recall eval scenario %q: duplicate memory id %q", s.Name, mem.ID)
+		}
+		written[mem.ID] = mem.At
+	}
+	queryIDs := make(map[string]struct{}, len(s.Queries))
+	for i, q := range s.Queries {
+		if q.ID == "" {
+			return fmt.Errorf("recall eval scenario %q: queries[%d]: id is required", s.Name, i)
+		}
+		if _, dup := queryIDs[q.ID]; dup {
+			return fmt.Errorf("recall eval scenario %q: duplicate query id %q", s.Name, q.ID)
+		}
+		queryIDs[q.ID] = struct{}{}
+		if strings.TrimSpace(q.Question) == "" {
+			return fmt.Errorf("recall eval scenario %q: query %q: question is required", s.Name, q.ID)
+		}
+		if len(q.Expected) == 0 {
+			return fmt.Errorf("recall eval scenario %q: query %q: expected is required", s.Name, q.ID)
+		}
+		for _, id := range q.Avoid {
+			if slices.Contains(q.Expected, id) {
+				return fmt.Errorf("recall eval scenario %q: query %q lists memory %q as both expected and avoid", s.Name, q.ID, id)
+			}
+		}
+		for _, id := range append(append([]string(nil), q.Expected...), q.Avoid...) {
+			at, ok := written[id]
+			if !ok {
+				return fmt.Errorf("recall eval scenario %q: query %q references unknown memory %q", s.Name, q.ID, id)
+			}
+			if at > q.At {
+				return fmt.Errorf("recall eval scenario %q: query %q references memory %q written after the query", s.Name, q.ID, id)
+			}
+		}
+	}
+	return nil
+}
+
+// RecallEvalStrategy 参与对比的一种召回策略
+type RecallEvalStrategy struct {
+	Name string
+	// NewManager 为每个剧本创建全新的记忆管理器；clock 返回剧本时间线上的当前时刻，
+	// 依赖时间的实现（如衰减打分）应以它为参考时间
+	NewManager func(clock func() time.Time) MemoryManager
+}
+
+// ScoredRecallStrategy 以给定打分配置创建 ScoredMemoryManager 的评测策略
+func ScoredRecallStrategy(name string, config RecallScoringConfig) RecallEvalStrategy {
+	if name == "" {
+		name = config.String()
+	}
+	return RecallEvalStrategy{
+		Name: name,
+		NewManager: func(clock func() time.Time) MemoryManager {
+			cfg := config
+			cfg.Now = clock
+			return NewScoredMemoryManager(cfg)
+		},
+	}
+}
+
+// SweepRecallScoring 在 base 配置上枚举半衰期与重要度阈值的组合，用于网格调参。
+// 任一列表为空时保留 base 中的对应值。
+func SweepRecallScoring(base RecallScoringConfig, halfLives []time.Duration, importanceThresholds []float64) []RecallEvalStrategy {
+	if len(halfLives) == 0 {
+		halfLives = []time.Duration{base.HalfLife}
+	}
+	if len(importanceThresholds) == 0 {
+		importanceThresholds = []float64{base.ImportanceThreshold}
+	}
+	strategies := make([]RecallEvalStrategy, 0, len(halfLives)*len(importanceThresholds))
+	for _, halfLife := range halfLives {
+		for _, threshold := range importanceThresholds {
+			cfg := base
+			cfg.HalfLife = halfLife
+			cfg.ImportanceThreshold = threshold
+			strategies = append(strategies, ScoredRecallStrategy("", cfg))
+		}
+	}
+	return strategies
+}
+
+// RecallEvalConfig 召回评测配置
+type RecallEvalConfig struct {
+	// TopK 每次提问召回的记忆条数
+	TopK int `json:"top_k"`
+	// Start 剧本时间线起点，为零值时使用固定时间，保证结果可复现
+	Start time.Time `json:"start"`
+}
+
+// DefaultRecallEvalConfig 返回默认配置
+func DefaultRecallEvalConfig() RecallEvalConfig {
+	return RecallEvalConfig{
+		TopK:  3,
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// RecallQueryResult 单次提问的评测结果
+type RecallQueryResult struct {
+	Scenario string   `json:"scenario"`
+	QueryID  string   `json:"query_id"`
+	Recalled []string `json:"recalled"`
+	Missed   []string `json:"missed,omitempty"`
+	// AvoidHits 排在所有期望记忆之前被召回的规避记忆
+	AvoidHits []string `json:"avoid_hits,omitempty"`
+	// Recall 期望记忆中被召回的比例
+	Recall float64 `json:"recall"`
+	// Precision 召回结果中属于期望记忆的比例；未召回任何记忆时为 0
+	Precision float64 `json:"precision"`
+	// ReciprocalRank 首条期望记忆排名的倒数，未命中为 0
+	ReciprocalRank float64 `json:"reciprocal_rank"`
+	Error          string  `json:"error,omitempty"`
+
+	hasAvoid bool
+}
+
+// RecallStrategyReport 单个策略在全部剧本上的汇总
+type RecallStrategyReport struct {
+	Strategy string              `json:"strategy"`
+	Queries  []RecallQueryResult `json:"queries"`
+	// HitRate 至少召回一条期望记忆的提问占比
+	HitRate       float64 `json:"hit_rate"`
+	MeanRecall    float64 `json:"mean_recall"`
+	MeanPrecision float64 `json:"mean_precision"`
+	MRR           float64 `json:"mrr"`
+	// AvoidRate 规避记忆排在期望记忆之前的提问占比（仅统计声明了 Avoid 的提问）
+	AvoidRate float64 `json:"avoid_rate"`
+	// Score 综合得分：MeanRecall 与 MRR 的均值，按 AvoidRate 折减
+	Score  float64 `json:"score"`
+	Errors int     `json:"errors"`
+}
+
+// RecallEvalReport 一次召回评测的报告，Strategies 按 Score 降序排列
+type RecallEvalReport struct {
+	TopK       int                     `json:"top_k"`
+	Scenarios  int                     `json:"scenarios"`
+	Queries    int                     `json:"queries"`
+	Strategies []*RecallStrategyReport `json:"strategies"`
+	Duration   time.Duration           `json:"duration"`
+}
+
+// Best 返回得分最高的策略，报告为空时返回 nil
+func (r *RecallEvalReport) Best() *RecallStrategyReport {
+	if r == nil || len(r.Strategies) == 0 {
+		return nil
+	}
+	return r.Strategies[0]
+}
+
+// Strategy 按名称查找策略报告
+func (r *RecallEvalReport) Strategy(name string) *RecallStrategyReport {
+	if r == nil {
+		return nil
+	}
+	for _, s := range r.Strategies {
+		if s.Strategy == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// RecallEvaluator 回放剧本并对召回策略打分
+type RecallEvaluator struct {
+	config RecallEvalConfig
+	logger *zap.Logger
+}
+
+// NewRecallEvaluator 创建召回评测器
+func NewRecallEvaluator(config RecallEvalConfig, logger *zap.Logger) *RecallEvaluator {
+	defaults := DefaultRecallEvalConfig()
+	if config.TopK <= 0 {
+		config.TopK = defaults.TopK
+	}
+	if config.Start.IsZero() {
+		config.Start = defaults.Start
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RecallEvaluator{config: config, logger: logger.With(zap.String("component", "memory_recall_evaluator"))}
+}
+
+// Run 对每个策略回放全部剧本。剧本或策略配置错误时返回错误；
+// 单次写入或检索失败记入结果，不中断评测。
+func (e *RecallEvaluator) Run(ctx context.Context, scenarios []RecallEvalScenario, strategies []RecallEvalStrategy) (*RecallEvalReport, error) {
+	if len(scenarios) == 0 {
+		return nil, errors.New("recall eval: no scenarios")
+	}
+	if len(strategies) == 0 {
+		return nil, errors.New("recall eval: no strategies")
+	}
+	queries := 0
+	for _, scenario := range scenarios {
+		if err := scenario.Validate(); err != nil {
+			return nil, err
+		}
+		queries += len(scenario.Queries)
+	}
+	names := make(map[string]struct{}, len(strategies))
+	for i, strategy := range strategies {
+		if strategy.Name == "" || strategy.NewManager == nil {
+			return nil, fmt.Errorf("recall eval: strategies[%d]: name and NewManager are required", i)
+		}
+		if _, dup := names[strategy.Name]; dup {
+			return nil, fmt.Errorf("recall eval: duplicate strategy %q", strategy.Name)
+		}
+		names[strategy.Name] = struct{}{}
+	}
+
+	start := time.Now()
+	report := &RecallEvalReport{
+		TopK:       e.config.TopK,
+		Scenarios:  len(scenarios),
+		Queries:    queries,
+		Strategies: make([]*RecallStrategyReport, 0, len(strategies)),
+	}
+	for _, strategy := range strategies {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sr := &RecallStrategyReport{Strategy: strategy.Name}
+		for _, scenario := range scenarios {
+			sr.Queries = append(sr.Queries, e.runScenario(ctx, scenario, strategy)...)
+		}
+		summarizeRecallStrategy(sr)
+		report.Strategies = append(report.Strategies, sr)
+		e.logger.Debug("recall strategy evaluated",
+			zap.String("strategy", sr.Strategy),
+			zap.Float64("score", sr.Score),
+			zap.Float64("mean_recall", sr.MeanRecall),
+			zap.Float64("avoid_rate", sr.AvoidRate))
+	}
+	sort.SliceStable(report.Strategies, func(i, j int) bool {
+		return report.Strategies[i].Score > report.Strategies[j].Score
+	})
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// runScenario 按时间线交替写入记忆与提问
+func (e *RecallEvaluator) runScenario(ctx context.Context, scenario RecallEvalScenario, strategy RecallEvalStrategy) []RecallQueryResult {
+	clock := &recallEvalClock{now: e.config.Start}
+	manager := strategy.NewManager(clock.Now)
+	agentID := scenario.AgentID
+	if agentID == "" {
+		agentID = "recall-eval"
+	}
+
+	memories := append([]RecallEvalMemory(nil), scenario.Memories...)
+	sort.SliceStable(memories, func(i, j int) bool { return memories[i].At < memories[j].At })
+	queries := append([]RecallEvalQuery(nil), scenario.Queries...)
+	sort.SliceStable(queries, func(i, j int) bool { return queries[i].At < queries[j].At })
+
+	results := make([]RecallQueryResult, 0, len(queries))
+	writeErrs := make(map[string]error)
+	next := 0
+	for _, query := range queries {
+		for ; next < len(memories) && memories[next].At <= query.At; next++ {
+			mem := memories[next]
+			clock.set(e.config.Start.Add(mem.At))
+			if err := manager.Save(ctx, e.recordFor(agentID, mem)); err != nil {
+				writeErrs[mem.ID] = err
+			}
+		}
+		clock.set(e.config.Start.Add(query.At))
+		results = append(results, e.runQuery(ctx, scenario.Name, agentID, query, manager, writeErrs))
+	}
+	return results
+}
+
+func (e *RecallEvaluator) recordFor(agentID string, mem RecallEvalMemory) MemoryRecord {
+	kind := mem.Kind
+	if kind == "" {
+		kind = MemoryEpisodic
+	}
+	metadata := make(map[string]any, len(mem.Metadata)+2)
+	for k, v := range mem.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataEvalMemoryID] = mem.ID
+	if mem.Importance > 0 {
+		metadata[MetadataImportance] = mem.Importance
+	}
+	return MemoryRecord{
+		ID:        mem.ID,
+		AgentID:   agentID,
+		Kind:      kind,
+		Content:   mem.Content,
+		Metadata:  metadata,
+		CreatedAt: e.config.Start.Add(mem.At),
+	}
+}
+
+func (e *RecallEvaluator) runQuery(ctx context.Context, scenario, agentID string, query RecallEvalQuery, manager MemoryManager, writeErrs map[string]error) RecallQueryResult {
+	result := RecallQueryResult{Scenario: scenario, QueryID: query.ID, hasAvoid: len(query.Avoid) > 0}
+	for _, id := range query.Expected {
+		if err, failed := writeErrs[id]; failed {
+			result.Error = fmt.Sprintf("save memory %q: %v", id, err)
+			break
+		}
+	}
+	records, err := manager.Search(ctx, agentID, query.Question, e.config.TopK)
+	if err != nil {
+		result.Error = err.Error()
+		result.Missed = append([]string(nil), query.Expected...)
+		return result
+	}
+
+	expected := make(map[string]struct{}, len(query.Expected))
+	for _, id := range query.Expected {
+		expected[id] = struct{}{}
+	}
+	avoid := make(map[string]struct{}, len(query.Avoid))
+	for _, id := range query.Avoid {
+		avoid[id] = struct{}{}
+	}
+
+	found := make(map[string]struct{}, len(expected))
+	relevant := 0
+	for rank, rec := range records {
+		id := evalMemoryID(rec)
+		result.Recalled = append(result.Recalled, id)
+		if _, ok := expected[id]; ok {
+			relevant++
+			if _, seen := found[id]; !seen {
+				found[id] = struct{}{}
+				if result.ReciprocalRank == 0 {
+					result.ReciprocalRank = 1 / float64(rank+1)
+				}
+			}
+		}
+		if _, ok := avoid[id]; ok && len(found) == 0 {
+			result.AvoidHits = append(result.AvoidHits, id)
+		}
+	}
+	for _, id := range query.Expected {
+		if _, ok := found[id]; !ok {
+			result.Missed = append(result.Missed, id)
+		}
+	}
+	result.Recall = float64(len(found)) / float64(len(expected))
+	if len(records) > 0 {
+		result.Precision = float64(relevant) / float64(len(records))
+	}
+	return result
+}
+
+// evalMemoryID 优先取元数据中的剧本记忆 ID，兼容重新分配记录 ID 的实现
+func evalMemoryID(rec MemoryRecord) string {
+	if id, ok := rec.Metadata[MetadataEvalMemoryID].(string); ok && id != "" {
+		return id
+	}
+	return rec.ID
+}
+
+func summarizeRecallStrategy(sr *RecallStrategyReport) {
+	if len(sr.Queries) == 0 {
+		return
+	}
+	var hits, withAvoid, avoided int
+	var recallSum, precisionSum, rrSum float64
+	for _, q := range sr.Queries {
+		if q.Error != "" {
+			sr.Errors++
+		}
+		if q.ReciprocalRank > 0 {
+			hits++
+		}
+		recallSum += q.Recall
+		precisionSum += q.Precision
+		rrSum += q.ReciprocalRank
+		if q.hasAvoid {
+			withAvoid++
+		}
+		if len(q.AvoidHits) > 0 {
+			avoided++
+		}
+	}
+	n := float64(len(sr.Queries))
+	sr.HitRate = float64(hits) / n
+	sr.MeanRecall = recallSum / n
+	sr.MeanPrecision = precisionSum / n
+	sr.MRR = rrSum / n
+	if withAvoid > 0 {
+		sr.AvoidRate = float64(avoided) / float64(withAvoid)
+	}
+	sr.Score = (sr.MeanRecall + sr.MRR) / 2 * (1 - sr.AvoidRate)
+}
+
+// recallEvalClock 剧本时间线上的可控时钟
+type recallEvalClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+func (c *recallEvalClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+func (c *recallEvalClock) set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
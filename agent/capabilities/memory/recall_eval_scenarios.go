@@ -0,0 +1,61 @@
+package memory
+
+import "time"
+
+const recallEvalDay = 24 * time.Hour
+
+// RecallBenchmarkScenarios 返回内置的召回基准剧本，覆盖偏好更正、
+// 久远但重要的事实、近期任务上下文与多事实聚合等典型场景。
+// 每次调用返回新的切片，调用方可按需追加自定义剧本。
+func RecallBenchmarkScenarios() []RecallEvalScenario {
+	return []RecallEvalScenario{
+		{
+			Name: "superseded-preference",
+			Memories: []RecallEvalMemory{
+				{ID: "pref-old", Content: "User prefers tea in the morning and dislikes coffee, always bring tea", At: 0, Importance: 0.6},
+				{ID: "trip", Content: "User booked a train trip to Kyoto for the spring holiday", At: 3 * recallEvalDay, Importance: 0.5},
+				{ID: "pref-new", Content: "User switched to coffee in the morning, no more tea", At: 40 * recallEvalDay, Importance: 0.6},
+			},
+			Queries: []RecallEvalQuery{
+				{ID: "morning-drink", Question: "Should I bring the user coffee or tea in the morning?", At: 41 * recallEvalDay, Expected: []string{"pref-new"}, Avoid: []string{"pref-old"}},
+			},
+		},
+		{
+			Name: "important-old-fact",
+			Memories: []RecallEvalMemory{
+				{ID: "allergy", Content: "User is severely allergic to peanuts", At: 0, Importance: 0.95},
+				{ID: "chatter-1", Content: "User said the peanuts at the bar were stale", At: 55 * recallEvalDay, Importance: 0.1},
+				{ID: "chatter-2", Content: "User joked that peanuts are not even nuts", At: 58 * recallEvalDay, Importance: 0.1},
+				{ID: "chatter-3", Content: "User asked for snack ideas without peanuts", At: 59 * recallEvalDay, Importance: 0.2},
+			},
+			Queries: []RecallEvalQuery{
+				{ID: "allergy-check", Question: "Is the user allergic to peanuts?", At: 60 * recallEvalDay, Expected: []string{"allergy"}},
+			},
+		},
+		{
+			Name: "recent-task-context",
+			Memories: []RecallEvalMemory{
+				{ID: "deadline-q1", Content: "Project Atlas report deadline is March 31", At: 0, Importance: 0.7},
+				{ID: "deadline-moved", Content: "Project Atlas report deadline moved to April 14", At: 20 * recallEvalDay, Importance: 0.7},
+				{ID: "atlas-owner", Content: "Maria owns the Project Atlas budget section", At: 21 * recallEvalDay, Importance: 0.5},
+			},
+			Queries: []RecallEvalQuery{
+				{ID: "atlas-deadline", Question: "When is the Project Atlas report deadline?", At: 22 * recallEvalDay, Expected: []string{"deadline-moved"}, Avoid: []string{"deadline-q1"}},
+				{ID: "atlas-budget", Question: "Who owns the Atlas budget section?", At: 22 * recallEvalDay, Expected: []string{"atlas-owner"}},
+			},
+		},
+		{
+			Name: "multi-fact-profile",
+			Memories: []RecallEvalMemory{
+				{ID: "home-city", Content: "User lives in Lisbon", At: 0, Importance: 0.8},
+				{ID: "timezone", Content: "User works on Lisbon time and avoids calls before 10am", At: 2 * recallEvalDay, Importance: 0.7},
+				{ID: "weather-chat", Content: "User complained about rain in Lisbon today", At: 29 * recallEvalDay, Importance: 0.1},
+				{ID: "language", Content: "User prefers replies in Portuguese", At: 5 * recallEvalDay, Importance: 0.8},
+			},
+			Queries: []RecallEvalQuery{
+				{ID: "schedule-call", Question: "What time can I schedule a call with the user in Lisbon?", At: 30 * recallEvalDay, Expected: []string{"timezone", "home-city"}},
+				{ID: "reply-language", Question: "Which language should replies use?", At: 30 * recallEvalDay, Expected: []string{"language"}},
+			},
+		},
+	}
+}
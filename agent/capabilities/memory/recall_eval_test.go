@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestScoredMemoryManager_DecayAndImportance(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	cfg := DefaultRecallScoringConfig()
+	cfg.ImportanceThreshold = 0.3
+	cfg.Now = func() time.Time { return now }
+	mgr := NewScoredMemoryManager(cfg)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.Save(ctx, MemoryRecord{ID: "old", AgentID: "a1", Content: "deploy window is Friday", CreatedAt: now.Add(-30 * recallEvalDay)}))
+	require.NoError(t, mgr.Save(ctx, MemoryRecord{ID: "new", AgentID: "a1", Content: "deploy window is Monday", CreatedAt: now.Add(-time.Hour)}))
+	require.NoError(t, mgr.Save(ctx, MemoryRecord{ID: "noise", AgentID: "a1", Content: "deploy window chat",
+		Metadata: map[string]any{MetadataImportance: 0.1}, CreatedAt: now}))
+	require.NoError(t, mgr.Save(ctx, MemoryRecord{ID: "other", AgentID: "a2", Content: "deploy window is Sunday", CreatedAt: now}))
+
+	got, err := mgr.Search(ctx, "a1", "When is the deploy window?", 5)
+	require.NoError(t, err)
+	require.Len(t, got, 2, "low-importance memory is filtered and other agents are isolated")
+	assert.Equal(t, "new", got[0].ID)
+	assert.Equal(t, "old", got[1].ID)
+	assert.Greater(t, got[0].Metadata["score"].(float64), got[1].Metadata["score"].(float64))
+
+	none, err := mgr.Search(ctx, "a1", "the and of", 5)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestRecallEvaluator_Metrics(t *testing.T) {
+	t.Parallel()
+
+	scenario := RecallEvalScenario{
+		Name: "fixed",
+		Memories: []RecallEvalMemory{
+			{ID: "m1", Content: "one"},
+			{ID: "m2", Content: "two"},
+			{ID: "m3", Content: "three", At: time.Hour},
+		},
+		Queries: []RecallEvalQuery{
+			{ID: "q1", Question: "q", At: time.Hour, Expected: []string{"m1", "m3"}, Avoid: []string{"m2"}},
+		},
+	}
+	// 固定返回 m2, m1：m2 排在唯一命中的期望记忆之前
+	fixed := RecallEvalStrategy{
+		Name: "fixed",
+		NewManager: func(func() time.Time) MemoryManager {
+			return &fixedSearchManager{testMemoryManager: newTestMM(), order: []string{"m2", "m1"}}
+		},
+	}
+
+	report, err := NewRecallEvaluator(RecallEvalConfig{TopK: 2}, zap.NewNop()).
+		Run(context.Background(), []RecallEvalScenario{scenario}, []RecallEvalStrategy{fixed})
+	require.NoError(t, err)
+	require.Len(t, report.Strategies, 1)
+
+	sr := report.Best()
+	require.Len(t, sr.Queries, 1)
+	q := sr.Queries[0]
+	assert.Equal(t, []string{"m2", "m1"}, q.Recalled)
+	assert.Equal(t, []string{"m3"}, q.Missed)
+	assert.Equal(t, []string{"m2"}, q.AvoidHits)
+	assert.InDelta(t, 0.5, q.Recall, 1e-9)
+	assert.InDelta(t, 0.5, q.Precision, 1e-9)
+	assert.InDelta(t, 0.5, q.ReciprocalRank, 1e-9)
+	assert.InDelta(t, 1.0, sr.AvoidRate, 1e-9)
+	assert.Zero(t, sr.Score)
+}
+
+func TestRecallEvaluator_TimelineHidesFutureMemories(t *testing.T) {
+	t.Parallel()
+
+	scenario := RecallEvalScenario{
+		Name: "timeline",
+		Memories: []RecallEvalMemory{
+			{ID: "early", Content: "server port is 8080"},
+			{ID: "late", Content: "server port is 9090", At: 2 * time.Hour},
+		},
+		Queries: []RecallEvalQuery{
+			{ID: "before", Question: "server port", At: time.Hour, Expected: []string{"early"}},
+			{ID: "after", Question: "server port", At: 3 * time.Hour, Expected: []string{"late"}},
+		},
+	}
+	report, err := NewRecallEvaluator(RecallEvalConfig{TopK: 1}, nil).Run(context.Background(),
+		[]RecallEvalScenario{scenario}, []RecallEvalStrategy{ScoredRecallStrategy("default", DefaultRecallScoringConfig())})
+	require.NoError(t, err)
+
+	sr := report.Strategy("default")
+	require.NotNil(t, sr)
+	assert.Equal(t, []string{"early"}, sr.Queries[0].Recalled)
+	assert.Equal(t, []string{"late"}, sr.Queries[1].Recalled)
+	assert.InDelta(t, 1.0, sr.HitRate, 1e-9)
+}
+
+func TestRecallEvaluator_BenchmarkScenariosRankDecay(t *testing.T) {
+	t.Parallel()
+
+	noDecay := DefaultRecallScoringConfig()
+	noDecay.RecencyWeight = 0
+	noDecay.ImportanceWeight = 0
+
+	aggressive := DefaultRecallScoringConfig()
+	aggressive.HalfLife = 12 * time.Hour
+	aggressive.RecencyWeight = 0.9
+
+	strategies := []RecallEvalStrategy{
+		ScoredRecallStrategy("no-decay", noDecay),
+		ScoredRecallStrategy("default", DefaultRecallScoringConfig()),
+		ScoredRecallStrategy("aggressive-decay", aggressive),
+	}
+	report, err := NewRecallEvaluator(DefaultRecallEvalConfig(), nil).
+		Run(context.Background(), RecallBenchmarkScenarios(), strategies)
+	require.NoError(t, err)
+	require.Len(t, report.Strategies, 3)
+
+	assert.Equal(t, "default", report.Best().Strategy)
+	assert.Greater(t, report.Strategy("no-decay").AvoidRate, report.Strategy("default").AvoidRate,
+		"without decay superseded facts outrank their corrections")
+	for _, sr := range report.Strategies {
+		assert.Zero(t, sr.Errors, sr.Strategy)
+	}
+}
+
+func TestRecallEvaluator_RecordsSearchErrors(t *testing.T) {
+	t.Parallel()
+
+	failing := RecallEvalStrategy{
+		Name: "failing",
+		NewManager: func(func() time.Time) MemoryManager {
+			mgr := newTestMM()
+			mgr.failOn = "search"
+			return mgr
+		},
+	}
+	report, err := NewRecallEvaluator(DefaultRecallEvalConfig(), nil).
+		Run(context.Background(), RecallBenchmarkScenarios()[:1], []RecallEvalStrategy{failing})
+	require.NoError(t, err)
+
+	sr := report.Best()
+	assert.Equal(t, len(sr.Queries), sr.Errors)
+	assert.Zero(t, sr.HitRate)
+	assert.Equal(t, []string{"pref-new"}, sr.Queries[0].Missed)
+}
+
+func TestRecallEvalScenario_Validate(t *testing.T) {
+	t.Parallel()
+
+	base := func() RecallEvalScenario {
+		return RecallEvalScenario{
+			Name:     "s",
+			Memories: []RecallEvalMemory{{ID: "m1", Content: "x"}, {ID: "m2", Content: "y", At: time.Hour}},
+			Queries:  []RecallEvalQuery{{ID: "q1", Question: "x", At: time.Hour, Expected: []string{"m1"}}},
+		}
+	}
+	require.NoError(t, base().Validate())
+
+	tests := map[string]func(*RecallEvalScenario){
+		"duplicate memory":   func(s *RecallEvalScenario) { s.Memories[1].ID = "m1" },
+		"unknown expected":   func(s *RecallEvalScenario) { s.Queries[0].Expected = []string{"nope"} },
+		"future memory":      func(s *RecallEvalScenario) { s.Queries[0].At = 0; s.Queries[0].Expected = []string{"m2"} },
+		"expected and avoid": func(s *RecallEvalScenario) { s.Queries[0].Avoid = []string{"m1"} },
+		"missing expected":   func(s *RecallEvalScenario) { s.Queries[0].Expected = nil },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := base()
+			mutate(&s)
+			assert.Error(t, s.Validate())
+		})
+	}
+
+	_, err := NewRecallEvaluator(DefaultRecallEvalConfig(), nil).Run(context.Background(), []RecallEvalScenario{base()}, nil)
+	assert.Error(t, err)
+}
+
+func TestSweepRecallScoring(t *testing.T) {
+	t.Parallel()
+
+	strategies := SweepRecallScoring(DefaultRecallScoringConfig(),
+		[]time.Duration{recallEvalDay, 7 * recallEvalDay}, []float64{0, 0.3, 0.5})
+	require.Len(t, strategies, 6)
+
+	names := make(map[string]struct{}, len(strategies))
+	for _, s := range strategies {
+		names[s.Name] = struct{}{}
+	}
+	assert.Len(t, names, 6, "sweep strategy names must be unique")
+}
+
+// BenchmarkRecallEvaluator_Sweep measures a half-life × threshold sweep over the built-in scenarios.
+func BenchmarkRecallEvaluator_Sweep(b *testing.B) {
+	scenarios := RecallBenchmarkScenarios()
+	strategies := SweepRecallScoring(DefaultRecallScoringConfig(),
+		[]time.Duration{recallEvalDay, 7 * recallEvalDay, 30 * recallEvalDay}, []float64{0, 0.3})
+	evaluator := NewRecallEvaluator(DefaultRecallEvalConfig(), zap.NewNop())
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	var best *RecallStrategyReport
+	for i := 0; i < b.N; i++ {
+		report, err := evaluator.Run(ctx, scenarios, strategies)
+		if err != nil {
+			b.Fatal(err)
+		}
+		best = report.Best()
+	}
+	b.ReportMetric(best.Score, "best_score")
+	b.ReportMetric(best.MeanRecall, "best_recall")
+}
+
+// fixedSearchManager returns saved records in a fixed order regardless of the query.
+type fixedSearchManager struct {
+	*testMemoryManager
+	order []string
+}
+
+func (m *fixedSearchManager) Search(ctx context.Context, _ string, _ string, topK int) ([]MemoryRecord, error) {
+	out := make([]MemoryRecord, 0, len(m.order))
+	for _, id := range m.order {
+		rec, err := m.Get(ctx, id)
+		if err != nil {
+			return nil, errors.New("fixed search: " + err.Error())
+		}
+		out = append(out, *rec)
+		if len(out) == topK {
+			break
+		}
+	}
+	return out, nil
+}
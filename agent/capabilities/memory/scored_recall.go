@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MetadataImportance 记忆元数据中的重要度字段，取值 0~1
+const MetadataImportance = "importance"
+
+// RecallScoringConfig 控制 ScoredMemoryManager 的召回打分。
+// 最终得分 = 相关度 × 新近度混合 × 重要度混合，混合方式与 rag TemporalRetriever 一致：
+// (1-w) + w×factor。
+type RecallScoringConfig struct {
+	// HalfLife 记忆年龄每增加一个 HalfLife，新近度因子减半；<=0 关闭衰减
+	HalfLife time.Duration `json:"half_life"`
+	// RecencyWeight 新近度在得分中的占比（0~1）
+	RecencyWeight float64 `json:"recency_weight"`
+	// ImportanceThreshold 重要度低于该值的记忆不参与召回
+	ImportanceThreshold float64 `json:"importance_threshold"`
+	// ImportanceWeight 重要度在得分中的占比（0~1）
+	ImportanceWeight float64 `json:"importance_weight"`
+	// DefaultImportance 未标注重要度的记忆所用的重要度
+	DefaultImportance float64 `json:"default_importance"`
+	// MinRelevance 与查询的词项覆盖率低于该值的记忆不召回
+	MinRelevance float64 `json:"min_relevance"`
+	// Now 评分参考时间，为 nil 时使用 time.Now
+	Now func() time.Time `json:"-"`
+}
+
+// DefaultRecallScoringConfig 返回默认召回打分配置
+func DefaultRecallScoringConfig() RecallScoringConfig {
+	return RecallScoringConfig{
+		HalfLife:          7 * 24 * time.Hour,
+		RecencyWeight:     0.3,
+		ImportanceWeight:  0.3,
+		DefaultImportance: 0.5,
+		MinRelevance:      0.01,
+	}
+}
+
+// String 返回便于在评测报告中区分配置的简短描述
+func (c RecallScoringConfig) String() string {
+	return fmt.Sprintf("half_life=%s recency=%.2f importance>=%.2f importance_w=%.2f",
+		c.HalfLife, c.RecencyWeight, c.ImportanceThreshold, c.ImportanceWeight)
+}
+
+// ScoredMemoryManager 基于内存的 MemoryManager，Search 按词项相关度、
+// 指数时间衰减与重要度综合排序。用于本地开发与召回参数调优。
+type ScoredMemoryManager struct {
+	mu      sync.RWMutex
+	records map[string]MemoryRecord
+	config  RecallScoringConfig
+	seq     int
+}
+
+// NewScoredMemoryManager 创建带衰减与重要度打分的内存记忆管理器
+func NewScoredMemoryManager(config RecallScoringConfig) *ScoredMemoryManager {
+	config.RecencyWeight = clampUnit(config.RecencyWeight)
+	config.ImportanceWeight = clampUnit(config.ImportanceWeight)
+	config.DefaultImportance = clampUnit(config.DefaultImportance)
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &ScoredMemoryManager{records: make(map[string]MemoryRecord), config: config}
+}
+
+// Config 返回生效的打分配置
+func (m *ScoredMemoryManager) Config() RecallScoringConfig { return m.config }
+
+func (m *ScoredMemoryManager) Save(_ context.Context, rec MemoryRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec.ID == "" {
+		m.seq++
+		rec.ID = "mem_" + strconv.Itoa(m.seq)
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = m.config.Now()
+	}
+	m.records[rec.ID] = rec
+	return nil
+}
+
+func (m *ScoredMemoryManager) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, id)
+	return nil
+}
+
+func (m *ScoredMemoryManager) Clear(_ context.Context, agentID string, kind MemoryKind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, rec := range m.records {
+		if rec.AgentID == agentID && (kind == "" || rec.Kind == kind) {
+			delete(m.records, id)
+		}
+	}
+	return nil
+}
+
+func (m *ScoredMemoryManager) LoadRecent(_ context.Context, agentID string, kind MemoryKind, limit int) ([]MemoryRecord, error) {
+	now := m.config.Now()
+	m.mu.RLock()
+	out := make([]MemoryRecord, 0)
+	for _, rec := range m.records {
+		if rec.AgentID == agentID && (kind == "" || rec.Kind == kind) && !recordExpired(rec, now) {
+			out = append(out, rec)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Search 返回得分最高的 topK 条记忆，元数据中的 "score" 为综合得分
+func (m *ScoredMemoryManager) Search(_ context.Context, agentID string, query string, topK int) ([]MemoryRecord, error) {
+	queryTerms := recallTerms(query)
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+	now := m.config.Now()
+
+	type scored struct {
+		rec   MemoryRecord
+		score float64
+	}
+	m.mu.RLock()
+	candidates := make([]scored, 0)
+	for _, rec := range m.records {
+		if rec.AgentID != agentID || recordExpired(rec, now) {
+			continue
+		}
+		if score, ok := m.score(rec, queryTerms, now); ok {
+			candidates = append(candidates, scored{rec: rec, score: score})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].rec.CreatedAt.After(candidates[j].rec.CreatedAt)
+	})
+	if topK > 0 && len(candidates) > topK {
+		candidates = candidates[:topK]
+	}
+	out := make([]MemoryRecord, len(candidates))
+	for i, c := range candidates {
+		rec := c.rec
+		metadata := make(map[string]any, len(rec.Metadata)+1)
+		for k, v := range rec.Metadata {
+			metadata[k] = v
+		}
+		metadata["score"] = c.score
+		rec.Metadata = metadata
+		out[i] = rec
+	}
+	return out, nil
+}
+
+func (m *ScoredMemoryManager) Get(_ context.Context, id string) (*MemoryRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rec, ok := m.records[id]
+	if !ok {
+		return nil, fmt.Errorf("memory record not found: %s", id)
+	}
+	return &rec, nil
+}
+
+// score 计算记忆得分；被重要度阈值或最低相关度过滤时返回 false
+func (m *ScoredMemoryManager) score(rec MemoryRecord, queryTerms map[string]struct{}, now time.Time) (float64, bool) {
+	importance := recordImportance(rec, m.config.DefaultImportance)
+	if importance < m.config.ImportanceThreshold {
+		return 0, false
+	}
+	relevance := termCoverage(queryTerms, recallTerms(rec.Content))
+	if relevance <= 0 || relevance < m.config.MinRelevance {
+		return 0, false
+	}
+	score := relevance
+	if m.config.RecencyWeight > 0 {
+		score *= (1 - m.config.RecencyWeight) + m.config.RecencyWeight*m.recencyFactor(rec.CreatedAt, now)
+	}
+	if m.config.ImportanceWeight > 0 {
+		score *= (1 - m.config.ImportanceWeight) + m.config.ImportanceWeight*importance
+	}
+	return score, true
+}
+
+// recencyFactor 在参考时间为 1，每经过一个 HalfLife 减半
+func (m *ScoredMemoryManager) recencyFactor(createdAt, now time.Time) float64 {
+	if m.config.HalfLife <= 0 {
+		return 1
+	}
+	age := now.Sub(createdAt)
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp(-math.Ln2 * float64(age) / float64(m.config.HalfLife))
+}
+
+func recordExpired(rec MemoryRecord, now time.Time) bool {
+	return rec.ExpiresAt != nil && !now.Before(*rec.ExpiresAt)
+}
+
+func recordImportance(rec MemoryRecord, fallback float64) float64 {
+	switch v := rec.Metadata[MetadataImportance].(type) {
+	case float64:
+		return clampUnit(v)
+	case float32:
+		return clampUnit(float64(v))
+	case int:
+		return clampUnit(float64(v))
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return clampUnit(f)
+		}
+	}
+	return fallback
+}
+
+// recallStopwords 不参与相关度计算的常见英文虚词
+var recallStopwords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "did": {}, "do": {},
+	"does": {}, "for": {}, "from": {}, "how": {}, "i": {}, "in": {}, "is": {}, "it": {}, "my": {},
+	"of": {}, "on": {}, "or": {}, "s": {}, "the": {}, "to": {}, "was": {}, "what": {}, "when": {},
+	"where": {}, "which": {}, "who": {}, "with": {},
+}
+
+// recallTerms 将文本切分为小写词项集合，忽略常见虚词；中日韩字符按单字切分
+func recallTerms(text string) map[string]struct{} {
+	terms := make(map[string]struct{})
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			if _, stop := recallStopwords[word.String()]; !stop {
+				terms[word.String()] = struct{}{}
+			}
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			terms[string(r)] = struct{}{}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+// termCoverage 返回查询词项在文档中出现的比例
+func termCoverage(query, doc map[string]struct{}) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	hits := 0
+	for term := range query {
+		if _, ok := doc[term]; ok {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(query))
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+var _ MemoryManager = (*ScoredMemoryManager)(nil)
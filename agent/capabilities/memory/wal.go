@@ -0,0 +1,563 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WALSyncPolicy 预写日志的刷盘策略
+type WALSyncPolicy string
+
+const (
+	// WALSyncAlways 每次写入后 fsync，崩溃不丢数据，吞吐最低
+	WALSyncAlways WALSyncPolicy = "always"
+	// WALSyncInterval 按 FlushInterval 周期刷盘，崩溃最多丢失一个周期内的写入
+	WALSyncInterval WALSyncPolicy = "interval"
+	// WALSyncNone 只在段切换、压缩与关闭时刷盘，依赖操作系统回写
+	WALSyncNone WALSyncPolicy = "none"
+)
+
+const (
+	defaultWALFlushInterval        = time.Second
+	defaultWALSegmentMaxBytes      = 64 << 20
+	defaultWALCompactAfterSegments = 4
+
+	walSegmentPrefix = "wal-"
+	walSegmentSuffix = ".log"
+	walHeaderSize    = 8
+	walMaxRecordSize = 64 << 20
+)
+
+// WALConfig 预写日志配置
+type WALConfig struct {
+	// Dir 段文件目录，为空表示不启用 WAL；多个记忆系统不能共享同一目录
+	Dir string `json:"dir,omitempty"`
+	// SyncPolicy 刷盘策略，默认 interval
+	SyncPolicy WALSyncPolicy `json:"sync_policy,omitempty"`
+	// FlushInterval interval 策略下的刷盘周期，默认 1s
+	FlushInterval time.Duration `json:"flush_interval,omitempty"`
+	// SegmentMaxBytes 单个段文件的最大字节数，超过后切换新段，默认 64MB
+	SegmentMaxBytes int64 `json:"segment_max_bytes,omitempty"`
+	// CompactAfterSegments 封存段数量达到该值时自动压缩，默认 4，负数表示关闭自动压缩
+	CompactAfterSegments int `json:"compact_after_segments,omitempty"`
+
+	// Now 用于测试，默认 time.Now
+	Now func() time.Time `json:"-"`
+}
+
+// WALOp 日志操作类型
+type WALOp string
+
+const (
+	WALOpPut    WALOp = "put"
+	WALOpDelete WALOp = "delete"
+	WALOpClear  WALOp = "clear"
+)
+
+// WALRecord 一条日志记录，Layer 区分记忆层（short_term、working、long_term 等）
+type WALRecord struct {
+	Seq       uint64          `json:"seq"`
+	Layer     string          `json:"layer"`
+	Op        WALOp           `json:"op"`
+	Key       string          `json:"key,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	ExpiresAt int64           `json:"expires_at,omitempty"` // UnixNano，0 表示不过期
+	Timestamp int64           `json:"ts"`
+}
+
+// Expired 判断 put 记录在给定时间是否已过期
+func (r *WALRecord) Expired(now time.Time) bool {
+	return r.ExpiresAt > 0 && now.UnixNano() >= r.ExpiresAt
+}
+
+// WriteAheadLog 进程内记忆层的追加式预写日志。
+// 记录按段文件存储，每条记录带长度与 CRC 校验；启动时截断末尾的半写记录，
+// 压缩时把所有段折叠为当前存活状态写入新段并删除旧段。
+type WriteAheadLog struct {
+	mu sync.Mutex
+
+	dir                  string
+	syncPolicy           WALSyncPolicy
+	segmentMaxBytes      int64
+	compactAfterSegments int
+	now                  func() time.Time
+	logger               *zap.Logger
+
+	segments []int // 封存段序号（升序），不含当前段
+	current  int
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	dirty    bool
+	nextSeq  uint64
+	closed   bool
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// OpenWriteAheadLog 打开（必要时创建）WAL 目录，校验已有段并定位写入位置
+func OpenWriteAheadLog(cfg WALConfig, logger *zap.Logger) (*WriteAheadLog, error) {
+	if strings.TrimSpace(cfg.Dir) == "" {
+		return nil, fmt.Errorf("wal dir is required")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	policy := cfg.SyncPolicy
+	switch policy {
+	case "":
+		policy = WALSyncInterval
+	case WALSyncAlways, WALSyncInterval, WALSyncNone:
+	default:
+		return nil, fmt.Errorf("unknown wal sync policy %q", policy)
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = defaultWALFlushInterval
+	}
+	segmentMax := cfg.SegmentMaxBytes
+	if segmentMax <= 0 {
+		segmentMax = defaultWALSegmentMaxBytes
+	}
+	compactAfter := cfg.CompactAfterSegments
+	if compactAfter == 0 {
+		compactAfter = defaultWALCompactAfterSegments
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create wal dir: %w", err)
+	}
+
+	w := &WriteAheadLog{
+		dir:                  cfg.Dir,
+		syncPolicy:           policy,
+		segmentMaxBytes:      segmentMax,
+		compactAfterSegments: compactAfter,
+		now:                  now,
+		logger:               logger.With(zap.String("component", "memory_wal")),
+	}
+	if err := w.recover(); err != nil {
+		return nil, err
+	}
+	if policy == WALSyncInterval {
+		w.stopCh = make(chan struct{})
+		w.doneCh = make(chan struct{})
+		go w.flushLoop(interval)
+	}
+	return w, nil
+}
+
+// recover 扫描已有段，截断最后一段末尾的损坏记录，并打开最后一段继续追加
+func (w *WriteAheadLog) recover() error {
+	segments, err := listWALSegments(w.dir)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return w.openSegment(1)
+	}
+	for i, idx := range segments {
+		last := i == len(segments)-1
+		validSize, err := scanWALSegment(w.segmentPath(idx), func(rec WALRecord) error {
+			if rec.Seq >= w.nextSeq {
+				w.nextSeq = rec.Seq + 1
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errWALCorrupt) {
+			return err
+		}
+		if errors.Is(err, errWALCorrupt) {
+			if !last {
+				w.logger.Warn("wal segment has corrupt records, ignoring the remainder", zap.Int("segment", idx))
+				continue
+			}
+			w.logger.Warn("truncating torn wal tail", zap.Int("segment", idx), zap.Int64("offset", validSize))
+			if err := os.Truncate(w.segmentPath(idx), validSize); err != nil {
+				return fmt.Errorf("truncate wal segment: %w", err)
+			}
+		}
+	}
+	w.segments = segments[:len(segments)-1]
+	return w.openSegment(segments[len(segments)-1])
+}
+
+func (w *WriteAheadLog) openSegment(idx int) error {
+	file, err := os.OpenFile(w.segmentPath(idx), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open wal segment: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat wal segment: %w", err)
+	}
+	w.current = idx
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.size = info.Size()
+	return nil
+}
+
+// Append 追加一条记录，Seq 与 Timestamp 由 WAL 分配
+func (w *WriteAheadLog) Append(rec WALRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return fmt.Errorf("wal is closed")
+	}
+
+	rec.Seq = w.nextSeq
+	rec.Timestamp = w.now().UnixNano()
+	n, err := writeWALRecord(w.writer, rec)
+	if err != nil {
+		return err
+	}
+	w.nextSeq++
+	w.size += int64(n)
+	w.dirty = true
+
+	if w.syncPolicy == WALSyncAlways {
+		if err := w.syncLocked(); err != nil {
+			return err
+		}
+	}
+	if w.size >= w.segmentMaxBytes {
+		return w.rotateLocked()
+	}
+	return nil
+}
+
+// Sync 立即刷盘
+func (w *WriteAheadLog) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	return w.syncLocked()
+}
+
+func (w *WriteAheadLog) syncLocked() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("flush wal: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("fsync wal: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// rotateLocked 封存当前段并切换到新段，封存段过多时触发压缩
+func (w *WriteAheadLog) rotateLocked() error {
+	if err := w.sealLocked(); err != nil {
+		return err
+	}
+	if w.compactAfterSegments > 0 && len(w.segments) >= w.compactAfterSegments {
+		if err := w.compactLocked(); err != nil {
+			w.logger.Warn("wal auto compaction failed", zap.Error(err))
+		} else {
+			return nil
+		}
+	}
+	return w.openSegment(w.current + 1)
+}
+
+func (w *WriteAheadLog) sealLocked() error {
+	if err := w.syncLocked(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("close wal segment: %w", err)
+	}
+	w.segments = append(w.segments, w.current)
+	return nil
+}
+
+// LiveRecords 返回指定层当前存活的 put 记录（已应用删除、清空与过期），按写入顺序排列
+func (w *WriteAheadLog) LiveRecords(layer string) ([]WALRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, fmt.Errorf("wal is closed")
+	}
+	if err := w.syncLocked(); err != nil {
+		return nil, err
+	}
+	segments := append(append([]int(nil), w.segments...), w.current)
+	live, err := w.foldSegmentsLocked(segments)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]WALRecord, 0, len(live))
+	for _, rec := range live {
+		if rec.Layer == layer {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// foldSegmentsLocked 按顺序重放给定段，得到每层每个 key 的最新存活记录
+func (w *WriteAheadLog) foldSegmentsLocked(segments []int) ([]WALRecord, error) {
+	type liveKey struct{ layer, key string }
+	state := make(map[liveKey]WALRecord)
+	apply := func(rec WALRecord) error {
+		switch rec.Op {
+		case WALOpPut:
+			state[liveKey{rec.Layer, rec.Key}] = rec
+		case WALOpDelete:
+			delete(state, liveKey{rec.Layer, rec.Key})
+		case WALOpClear:
+			for k := range state {
+				if k.layer == rec.Layer {
+					delete(state, k)
+				}
+			}
+		}
+		return nil
+	}
+	for _, idx := range segments {
+		if _, err := scanWALSegment(w.segmentPath(idx), apply); err != nil && !errors.Is(err, errWALCorrupt) {
+			return nil, err
+		}
+	}
+
+	now := w.now()
+	out := make([]WALRecord, 0, len(state))
+	for _, rec := range state {
+		if !rec.Expired(now) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Seq < out[j].Seq })
+	return out, nil
+}
+
+// Compact 将全部段折叠为存活记录写入新段，并删除旧段
+func (w *WriteAheadLog) Compact() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return fmt.Errorf("wal is closed")
+	}
+	if err := w.sealLocked(); err != nil {
+		return err
+	}
+	if err := w.compactLocked(); err != nil {
+		// 压缩失败时旧段仍然完整，打开新段继续写入
+		if openErr := w.openSegment(w.current + 1); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// compactLocked 要求当前段已封存；成功后打开压缩段之后的新段作为当前段
+func (w *WriteAheadLog) compactLocked() error {
+	live, err := w.foldSegmentsLocked(w.segments)
+	if err != nil {
+		return err
+	}
+
+	target := w.current + 1
+	tmpPath := w.segmentPath(target) + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("create compacted wal segment: %w", err)
+	}
+	bw := bufio.NewWriter(tmp)
+	for _, rec := range live {
+		if _, err := writeWALRecord(bw, rec); err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("flush compacted wal segment: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("fsync compacted wal segment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("close compacted wal segment: %w", err)
+	}
+	if err := os.Rename(tmpPath, w.segmentPath(target)); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("install compacted wal segment: %w", err)
+	}
+	syncWALDir(w.dir)
+
+	// 压缩段已落盘，旧段可以安全删除；删除中途崩溃时重放结果仍然一致
+	removed := len(w.segments)
+	for _, idx := range w.segments {
+		if err := os.Remove(w.segmentPath(idx)); err != nil && !os.IsNotExist(err) {
+			w.logger.Warn("failed to remove compacted wal segment", zap.Int("segment", idx), zap.Error(err))
+		}
+	}
+	w.segments = []int{target}
+	w.logger.Debug("wal compacted", zap.Int("segments_removed", removed), zap.Int("live_records", len(live)))
+	return w.openSegment(target + 1)
+}
+
+// Close 刷盘并关闭 WAL
+func (w *WriteAheadLog) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.syncLocked()
+	if closeErr := w.file.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("close wal segment: %w", closeErr))
+	}
+	w.mu.Unlock()
+
+	if w.stopCh != nil {
+		close(w.stopCh)
+		<-w.doneCh
+	}
+	return err
+}
+
+func (w *WriteAheadLog) flushLoop(interval time.Duration) {
+	defer close(w.doneCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			if err := w.Sync(); err != nil {
+				w.logger.Warn("periodic wal flush failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (w *WriteAheadLog) segmentPath(idx int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s%08d%s", walSegmentPrefix, idx, walSegmentSuffix))
+}
+
+var errWALCorrupt = errors.New("wal record corrupt")
+
+// writeWALRecord 以 [长度][CRC32][JSON] 帧格式写入一条记录，返回写入字节数
+func writeWALRecord(bw *bufio.Writer, rec WALRecord) (int, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return 0, fmt.Errorf("encode wal record: %w", err)
+	}
+	var header [walHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+	if _, err := bw.Write(header[:]); err != nil {
+		return 0, fmt.Errorf("write wal record: %w", err)
+	}
+	if _, err := bw.Write(payload); err != nil {
+		return 0, fmt.Errorf("write wal record: %w", err)
+	}
+	return walHeaderSize + len(payload), nil
+}
+
+// scanWALSegment 顺序读取段内记录，返回最后一条完整记录之后的偏移。
+// 遇到半写或校验失败的记录时返回 errWALCorrupt。
+func scanWALSegment(path string, fn func(WALRecord) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("open wal segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	var header [walHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return offset, nil
+			}
+			return offset, errWALCorrupt
+		}
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size == 0 || size > walMaxRecordSize {
+			return offset, errWALCorrupt
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return offset, errWALCorrupt
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return offset, errWALCorrupt
+		}
+		var rec WALRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return offset, errWALCorrupt
+		}
+		if err := fn(rec); err != nil {
+			return offset, err
+		}
+		offset += int64(walHeaderSize) + int64(size)
+	}
+}
+
+func listWALSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read wal dir: %w", err)
+	}
+	var segments []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, walSegmentPrefix) || !strings.HasSuffix(name, walSegmentSuffix) {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, walSegmentPrefix), walSegmentSuffix))
+		if err != nil || idx <= 0 {
+			continue
+		}
+		segments = append(segments, idx)
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+// syncWALDir 刷新目录项，保证重命名在崩溃后可见；不支持目录 fsync 的平台忽略错误
+func syncWALDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func openTestWAL(t *testing.T, cfg WALConfig) *WriteAheadLog {
+	t.Helper()
+	if cfg.SyncPolicy == "" {
+		cfg.SyncPolicy = WALSyncAlways
+	}
+	wal, err := OpenWriteAheadLog(cfg, zap.NewNop())
+	require.NoError(t, err)
+	return wal
+}
+
+func TestWriteAheadLog_ReplaysLiveRecordsAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	wal := openTestWAL(t, WALConfig{Dir: dir})
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "k1", Value: json.RawMessage(`"v1"`)}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "k2", Value: json.RawMessage(`"v2"`)}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpDelete, Key: "k1"}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "b", Op: WALOpPut, Key: "k1", Value: json.RawMessage(`"b1"`)}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "b", Op: WALOpClear}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "b", Op: WALOpPut, Key: "k3", Value: json.RawMessage(`"b3"`)}))
+	require.NoError(t, wal.Close())
+
+	reopened := openTestWAL(t, WALConfig{Dir: dir})
+	defer reopened.Close()
+
+	a, err := reopened.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, a, 1)
+	assert.Equal(t, "k2", a[0].Key)
+
+	b, err := reopened.LiveRecords("b")
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	assert.Equal(t, "k3", b[0].Key)
+
+	// 序号在重启后继续递增
+	require.NoError(t, reopened.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "k4", Value: json.RawMessage(`1`)}))
+	a, err = reopened.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, a, 2)
+	assert.Greater(t, a[1].Seq, b[0].Seq)
+}
+
+func TestWriteAheadLog_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	wal := openTestWAL(t, WALConfig{Dir: dir})
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "k1", Value: json.RawMessage(`"v1"`)}))
+	require.NoError(t, wal.Close())
+
+	segments, err := listWALSegments(dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	path := wal.segmentPath(segments[0])
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// 模拟崩溃时只写了一半的记录
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 40, 1, 2, 3, 4, '{', '"'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened := openTestWAL(t, WALConfig{Dir: dir})
+	defer reopened.Close()
+	truncated, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+
+	require.NoError(t, reopened.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "k2", Value: json.RawMessage(`"v2"`)}))
+	records, err := reopened.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"k1", "k2"}, []string{records[0].Key, records[1].Key})
+}
+
+func TestWriteAheadLog_RotatesAndCompacts(t *testing.T) {
+	dir := t.TempDir()
+	wal := openTestWAL(t, WALConfig{Dir: dir, SegmentMaxBytes: 128, CompactAfterSegments: -1})
+	for i := 0; i < 20; i++ {
+		require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "hot", Value: json.RawMessage(`"value"`)}))
+	}
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "gone", Value: json.RawMessage(`1`)}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpDelete, Key: "gone"}))
+
+	before, err := listWALSegments(dir)
+	require.NoError(t, err)
+	require.Greater(t, len(before), 2)
+
+	require.NoError(t, wal.Compact())
+	after, err := listWALSegments(dir)
+	require.NoError(t, err)
+	assert.Len(t, after, 2, "compacted segment plus a fresh active segment")
+
+	records, err := wal.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "hot", records[0].Key)
+	require.NoError(t, wal.Close())
+
+	reopened := openTestWAL(t, WALConfig{Dir: dir})
+	defer reopened.Close()
+	records, err = reopened.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "hot", records[0].Key)
+}
+
+func TestWriteAheadLog_AutoCompactionBoundsSegments(t *testing.T) {
+	dir := t.TempDir()
+	wal := openTestWAL(t, WALConfig{Dir: dir, SegmentMaxBytes: 128, CompactAfterSegments: 3})
+	defer wal.Close()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "same", Value: json.RawMessage(`"value"`)}))
+	}
+	segments, err := listWALSegments(dir)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(segments), 4)
+
+	records, err := wal.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+}
+
+func TestWriteAheadLog_DropsExpiredRecords(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+	wal := openTestWAL(t, WALConfig{Dir: t.TempDir(), Now: clock})
+	defer wal.Close()
+
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "short", Value: json.RawMessage(`1`), ExpiresAt: now.Add(time.Minute).UnixNano()}))
+	require.NoError(t, wal.Append(WALRecord{Layer: "a", Op: WALOpPut, Key: "long", Value: json.RawMessage(`2`), ExpiresAt: now.Add(time.Hour).UnixNano()}))
+
+	now = now.Add(10 * time.Minute)
+	records, err := wal.LiveRecords("a")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "long", records[0].Key)
+}
+
+func TestOpenWriteAheadLog_Validation(t *testing.T) {
+	_, err := OpenWriteAheadLog(WALConfig{}, nil)
+	assert.Error(t, err)
+	_, err = OpenWriteAheadLog(WALConfig{Dir: t.TempDir(), SyncPolicy: "sometimes"}, nil)
+	assert.Error(t, err)
+}
+
+func TestDurableMemoryStore_RecoversAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	wal := openTestWAL(t, WALConfig{Dir: dir})
+	store := NewDurableMemoryStore(WALLayerWorking, NewInMemoryMemoryStore(InMemoryMemoryStoreConfig{}, nil), wal)
+	require.NoError(t, store.Save(ctx, "task:1", map[string]any{"content": "draft plan"}, 0))
+	require.NoError(t, store.Save(ctx, "task:2", map[string]any{"content": "obsolete"}, time.Hour))
+	require.NoError(t, store.Delete(ctx, "task:2"))
+	require.NoError(t, store.Save(ctx, "task:3", "ttl entry", time.Hour))
+	// 不调用 Close，模拟进程崩溃；always 策略下每次写入已落盘
+
+	reopened := openTestWAL(t, WALConfig{Dir: dir})
+	defer reopened.Close()
+	recovered := NewDurableMemoryStore(WALLayerWorking, NewInMemoryMemoryStore(InMemoryMemoryStoreConfig{}, nil), reopened)
+	restored, err := recovered.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+
+	value, err := recovered.Load(ctx, "task:1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"content": "draft plan"}, value)
+	_, err = recovered.Load(ctx, "task:2")
+	assert.Error(t, err)
+	value, err = recovered.Load(ctx, "task:3")
+	require.NoError(t, err)
+	assert.Equal(t, "ttl entry", value)
+
+	require.NoError(t, recovered.Clear(ctx))
+	records, err := reopened.LiveRecords(WALLayerWorking)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestDurableVectorStore_RecoversVectors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	wal := openTestWAL(t, WALConfig{Dir: dir})
+	store := NewDurableVectorStore(WALLayerLongTerm, NewInMemoryVectorStore(InMemoryVectorStoreConfig{}, nil), wal)
+	require.NoError(t, store.BatchStore(ctx, []VectorItem{
+		{ID: "a", Vector: []float64{1, 0}, Metadata: map[string]any{"agent_id": "x"}},
+		{ID: "b", Vector: []float64{0, 1}},
+	}))
+	require.NoError(t, store.Delete(ctx, "b"))
+	require.NoError(t, wal.Close())
+
+	reopened := openTestWAL(t, WALConfig{Dir: dir})
+	defer reopened.Close()
+	recovered := NewDurableVectorStore(WALLayerLongTerm, NewInMemoryVectorStore(InMemoryVectorStoreConfig{}, nil), reopened)
+	restored, err := recovered.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+
+	results, err := recovered.Search(ctx, []float64{1, 0}, 5, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a", results[0].ID)
+}
+
+func TestNewDefaultEnhancedMemorySystem_WALRecovery(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultEnhancedMemoryConfig()
+	cfg.ConsolidationEnabled = false
+	cfg.LongTermEnabled = false
+	cfg.WAL = WALConfig{Dir: filepath.Join(t.TempDir(), "wal"), SyncPolicy: WALSyncInterval, FlushInterval: time.Hour}
+
+	system := NewDefaultEnhancedMemorySystem(cfg, nil)
+	require.NoError(t, system.SaveShortTerm(ctx, "agent-1", "remember the deploy window", nil))
+	require.NoError(t, system.Close())
+
+	restarted := NewDefaultEnhancedMemorySystem(cfg, nil)
+	defer restarted.Close()
+	entries, err := restarted.LoadShortTerm(ctx, "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "remember the deploy window", entries[0].Content)
+}
@@ -690,6 +690,31 @@ func TestReflexionExecutor_Execute_SuccessOnFirstTrial(t *testing.T) {
 	assert.Equal(t, "solution", result.FinalAnswer)
 }
 
+func TestReflexionExecutor_ReflectOnFailure(t *testing.T) {
+	t.Parallel()
+
+	var prompt string
+	provider := &testProvider{
+		completionFn: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			prompt = req.Messages[0].Content
+			return &llm.ChatResponse{
+				Choices: []llm.ChatChoice{{Message: types.Message{
+					Content: `{"analysis":"tool args were wrong","mistakes":["missing id"],"next_strategy":"look up the id first"}`,
+				}}},
+				Usage: llm.ChatUsage{TotalTokens: 12},
+			}, nil
+		},
+	}
+
+	r := NewReflexionExecutor(testGateway(provider), nil, nil, DefaultReflexionConfig(), zap.NewNop())
+	reflection, tokens, err := r.ReflectOnFailure(context.Background(), "refund order", "tool refund failed: id required")
+	require.NoError(t, err)
+	assert.Equal(t, "look up the id first", reflection.NextStrategy)
+	assert.Equal(t, []string{"missing id"}, reflection.Mistakes)
+	assert.Equal(t, 12, tokens)
+	assert.Contains(t, prompt, "Failure: tool refund failed: id required")
+}
+
 func TestReflexionExecutor_Execute_MultipleTrials(t *testing.T) {
 	t.Parallel()
 
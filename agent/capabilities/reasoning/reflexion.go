@@ -240,3 +240,21 @@ func (r *ReflexionExecutor) generateReflection(ctx context.Context, task string,
 	}
 	return parseResult.Value, structuredTokens(parseResult), nil
 }
+
+// ReflectOnFailure 分析一次失败的执行并给出修正后的策略，供调用方带着反思重试。
+func (r *ReflexionExecutor) ReflectOnFailure(ctx context.Context, task, failure string) (*Reflection, int, error) {
+	prompt := fmt.Sprintf("An attempt to complete this task failed.\nTask: %s\nFailure: %s\n"+
+		"Explain what went wrong, list the mistakes, and give an amended strategy for the next attempt.", task, failure)
+	parseResult, err := generateStructured[Reflection](ctx, r.gateway, newGatewayChatRequest(
+		defaultModel(r.config.Model),
+		[]types.Message{{Role: llmcore.RoleUser, Content: prompt}},
+		func(req *llmcore.ChatRequest) {
+			req.Temperature = 0.3
+			req.MaxTokens = 500
+		},
+	))
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseResult.Value, structuredTokens(parseResult), nil
+}
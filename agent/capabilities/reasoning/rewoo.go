@@ -25,6 +25,16 @@ type ReWOOConfig struct {
 	Timeout         time.Duration // Overall timeout
 	ParallelWorkers int           // Number of parallel workers for independent steps
 	Model           string        // LLM model to use for reasoning steps
+
+	// 成本估算：规划时按工具标注每步预估成本，并在提示词中引导选择更便宜的计划
+	ToolCosts       map[string]float64 // Estimated cost per call, keyed by tool name
+	DefaultToolCost float64            // Estimated cost for tools missing from ToolCosts
+	TokenCost       float64            // Estimated cost per LLM token (plan, re-plan, solve)
+
+	// BatchableTools 列出可合并为一次并行批量执行的工具，为空表示所有工具均可批量
+	BatchableTools []string
+	// MaxReplans 步骤失败时允许重新规划受影响后缀的最大次数，0 表示不重规划
+	MaxReplans int
 }
 
 // 默认 ReWOOConfig 返回合理的默认值 。
@@ -34,6 +44,7 @@ func DefaultReWOOConfig() ReWOOConfig {
 		Timeout:         120 * time.Second,
 		ParallelWorkers: 5,
 		Model:           "gpt-4o",
+		MaxReplans:      2,
 	}
 }
 
@@ -71,6 +82,8 @@ type PlanStep struct {
 	Arguments    string   `json:"arguments"`    // Arguments (may reference previous steps like #E1)
 	Dependencies []string `json:"dependencies"` // IDs of steps this depends on
 	Reasoning    string   `json:"reasoning"`    // Why this step is needed
+	// EstimatedCost 规划阶段按 ReWOOConfig 成本表估算的单步成本
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
 }
 
 // 执行运行了ReWOO推理模式.
@@ -99,14 +112,20 @@ func (r *ReWOO) Execute(ctx context.Context, task string) (*ReasoningResult, err
 		TokensUsed: planTokens,
 	})
 
-	// 第2阶段:工人-执行所有步骤
+	// 第2阶段:工人-按依赖分批执行，失败时只重规划受影响的后缀
 	r.logger.Info("ReWOO Phase 2: Executing", zap.Int("steps", len(plan)))
-	observations, execTokens := r.executeSteps(ctx, plan)
-	result.TotalTokens += execTokens
+	run := r.runPlan(ctx, task, plan, true)
+	plan = run.plan
+	observations := run.observations
+	result.TotalTokens += run.tokens
 
-	for id, obs := range observations {
+	for _, step := range plan {
+		obs, ok := observations[step.ID]
+		if !ok {
+			continue
+		}
 		result.Steps = append(result.Steps, ReasoningStep{
-			StepID:  id,
+			StepID:  step.ID,
 			Type:    "observation",
 			Content: obs,
 		})
@@ -122,19 +141,22 @@ func (r *ReWOO) Execute(ctx context.Context, task string) (*ReasoningResult, err
 	result.FinalAnswer = answer
 	result.TotalLatency = time.Since(start)
 
+	toolCost := r.estimatePlanCost(plan)
+	llmCost := float64(result.TotalTokens) * r.config.TokenCost
 	result.Metadata["plan_steps"] = len(plan)
 	result.Metadata["observations"] = len(observations)
+	result.Metadata["tool_batches"] = run.batches
+	result.Metadata["replans"] = run.replans
+	result.Metadata["failed_steps"] = len(run.failed)
+	result.Metadata["estimated_tool_cost"] = toolCost
+	result.Metadata["estimated_llm_cost"] = llmCost
+	result.Metadata["estimated_cost"] = toolCost + llmCost
 
 	return result, nil
 }
 
 func (r *ReWOO) generatePlan(ctx context.Context, task string) ([]PlanStep, int, error) {
 	// 构建工具描述
-	var toolDescs []string
-	for _, t := range r.toolSchemas {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", t.Name, t.Description))
-	}
-
 	prompt := fmt.Sprintf(`Plan a tool-first execution path for this task.
 
 Available tools:
@@ -146,9 +168,9 @@ Use the %s tool to return the plan.
 
 Rules:
 - Use at most %d steps
-- Prefer the fewest useful tool calls
+- Prefer the fewest useful tool calls%s
 - Reference prior results with #E<n>
-- Do not answer with prose outside the tool call`, strings.Join(toolDescs, "\n"), task, submitToolPlanTool, r.config.MaxPlanSteps)
+- Do not answer with prose outside the tool call`, r.describeTools(), task, submitToolPlanTool, r.config.MaxPlanSteps, r.costRule())
 
 	resp, err := invokeChatGateway(ctx, r.gateway, newGatewayChatRequest(
 		defaultModel(r.config.Model),
@@ -176,12 +198,54 @@ Rules:
 		return nil, tokens, fmt.Errorf("plan generation did not return native tool call: %w", parseErr)
 	}
 
-	// 构建依赖图
+	r.annotatePlan(plan)
+	return plan, tokens, nil
+}
+
+// annotatePlan 构建依赖图并标注每步预估成本
+func (r *ReWOO) annotatePlan(plan []PlanStep) {
 	for i := range plan {
 		plan[i].Dependencies = r.extractDependencies(plan[i].Arguments)
+		plan[i].EstimatedCost = r.estimateStepCost(plan[i].Tool)
 	}
+}
 
-	return plan, tokens, nil
+func (r *ReWOO) describeTools() string {
+	var toolDescs []string
+	for _, t := range r.toolSchemas {
+		desc := fmt.Sprintf("- %s: %s", t.Name, t.Description)
+		if r.hasCostModel() {
+			desc += fmt.Sprintf(" (est. cost %.4f/call)", r.estimateStepCost(t.Name))
+		}
+		toolDescs = append(toolDescs, desc)
+	}
+	return strings.Join(toolDescs, "\n")
+}
+
+func (r *ReWOO) costRule() string {
+	if !r.hasCostModel() {
+		return ""
+	}
+	return "\n- Prefer the cheapest plan that still answers the task; independent calls to the same tool run as one parallel batch"
+}
+
+func (r *ReWOO) hasCostModel() bool {
+	return len(r.config.ToolCosts) > 0 || r.config.DefaultToolCost > 0
+}
+
+func (r *ReWOO) estimateStepCost(tool string) float64 {
+	if cost, ok := r.config.ToolCosts[tool]; ok {
+		return cost
+	}
+	return r.config.DefaultToolCost
+}
+
+func (r *ReWOO) estimatePlanCost(plan []PlanStep) float64 {
+	total := 0.0
+	for _, step := range plan {
+		total += step.EstimatedCost
+	}
+	return total
 }
 
 func (r *ReWOO) extractDependencies(args string) []string {
@@ -199,16 +263,34 @@ func (r *ReWOO) extractDependencies(args string) []string {
 }
 
 func (r *ReWOO) executeSteps(ctx context.Context, plan []PlanStep) (map[string]string, int) {
-	observations := make(map[string]string)
-	totalTokens := 0
+	run := r.runPlan(ctx, "", plan, false)
+	return run.observations, run.tokens
+}
 
-	// 根据依赖关系构建执行命令
+// rewooRun 记录一次计划执行的状态
+type rewooRun struct {
+	plan         []PlanStep
+	observations map[string]string
+	failed       map[string]string
+	tokens       int
+	batches      int
+	replans      int
+}
+
+// runPlan 按依赖关系逐波执行步骤；每一波内兼容的工具调用合并为并行批次。
+// 步骤失败且允许重规划时，只替换失败步骤及其传递依赖者，已完成的观测保持不变。
+func (r *ReWOO) runPlan(ctx context.Context, task string, plan []PlanStep, allowReplan bool) *rewooRun {
+	run := &rewooRun{
+		plan:         plan,
+		observations: make(map[string]string),
+		failed:       make(map[string]string),
+	}
 	executed := make(map[string]bool)
 
-	for len(executed) < len(plan) {
+	for len(executed) < len(run.plan) {
 		// 查找可以执行的步骤( 所有已满足的道克)
 		var ready []PlanStep
-		for _, step := range plan {
+		for _, step := range run.plan {
 			if executed[step.ID] {
 				continue
 			}
@@ -229,39 +311,130 @@ func (r *ReWOO) executeSteps(ctx context.Context, plan []PlanStep) (map[string]s
 			break
 		}
 
-		// 执行已准备好的步骤(可并行)
-		for _, step := range ready {
-			// 参数中的替代依赖性
-			args := step.Arguments
-			for dep, obs := range observations {
-				args = strings.ReplaceAll(args, dep, obs)
+		var waveFailures []PlanStep
+		for _, batch := range r.batchSteps(ready) {
+			run.batches++
+			for i, output := range r.executeBatch(ctx, batch, run.observations) {
+				step := batch[i]
+				executed[step.ID] = true
+				run.observations[step.ID] = output.content
+				if output.err != "" {
+					run.failed[step.ID] = output.err
+					waveFailures = append(waveFailures, step)
+				}
+				r.logger.Debug("executed step",
+					zap.String("id", step.ID),
+					zap.String("tool", step.Tool),
+					zap.String("result_preview", truncate(output.content, 100)))
 			}
+		}
 
-			// 执行工具
-			result := r.executeTool(ctx, step.Tool, args)
-			observations[step.ID] = result
-			executed[step.ID] = true
+		for _, failedStep := range waveFailures {
+			if !allowReplan || run.replans >= r.config.MaxReplans || ctx.Err() != nil {
+				break
+			}
+			if _, stillFailed := run.failed[failedStep.ID]; !stillFailed {
+				continue
+			}
+			if r.replanSuffix(ctx, task, run, executed, failedStep) {
+				run.replans++
+			}
+		}
+	}
 
-			r.logger.Debug("executed step",
-				zap.String("id", step.ID),
-				zap.String("tool", step.Tool),
-				zap.String("result_preview", truncate(result, 100)))
+	return run
+}
+
+// batchSteps 将同一波就绪步骤按工具分组；可批量的工具按 ParallelWorkers 切分为并行批次
+func (r *ReWOO) batchSteps(ready []PlanStep) [][]PlanStep {
+	workers := r.config.ParallelWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	var batches [][]PlanStep
+	groups := make(map[string]int)
+	for _, step := range ready {
+		if !r.isBatchable(step.Tool) {
+			batches = append(batches, []PlanStep{step})
+			continue
 		}
+		idx, ok := groups[step.Tool]
+		if !ok || len(batches[idx]) >= workers {
+			batches = append(batches, nil)
+			idx = len(batches) - 1
+			groups[step.Tool] = idx
+		}
+		batches[idx] = append(batches[idx], step)
 	}
+	return batches
+}
 
-	return observations, totalTokens
+func (r *ReWOO) isBatchable(tool string) bool {
+	if len(r.config.BatchableTools) == 0 {
+		return true
+	}
+	for _, name := range r.config.BatchableTools {
+		if name == tool {
+			return true
+		}
+	}
+	return false
 }
 
-func (r *ReWOO) executeTool(ctx context.Context, toolName, args string) string {
-	// 构建工具调用
+type stepOutput struct {
+	content string
+	err     string
+}
+
+// executeBatch 以一次 Execute 并行执行批次内的调用；参数完全相同的调用只执行一次
+func (r *ReWOO) executeBatch(ctx context.Context, batch []PlanStep, observations map[string]string) []stepOutput {
+	outputs := make([]stepOutput, len(batch))
+	var calls []types.ToolCall
+	callIndex := make(map[string]int)
+	stepCall := make([]int, len(batch))
+	for i, step := range batch {
+		// 参数中的替代依赖性
+		args := step.Arguments
+		for dep, obs := range observations {
+			args = strings.ReplaceAll(args, dep, obs)
+		}
+		key := step.Tool + "\x00" + args
+		idx, ok := callIndex[key]
+		if !ok {
+			idx = len(calls)
+			callIndex[key] = idx
+			calls = append(calls, newReWOOToolCall(step.Tool, args, idx))
+		}
+		stepCall[i] = idx
+	}
+
+	results := r.toolExecutor.Execute(ctx, calls)
+	for i := range batch {
+		idx := stepCall[i]
+		if idx >= len(results) {
+			outputs[i] = stepOutput{content: "No result", err: "no result"}
+			continue
+		}
+		if results[idx].Error != "" {
+			outputs[i] = stepOutput{content: fmt.Sprintf("Error: %s", results[idx].Error), err: results[idx].Error}
+			continue
+		}
+		outputs[i] = stepOutput{content: string(results[idx].Result)}
+	}
+	return outputs
+}
+
+func newReWOOToolCall(toolName, args string, idx int) types.ToolCall {
 	argsJSON, _ := json.Marshal(map[string]string{"input": args})
-	call := types.ToolCall{
-		ID:        fmt.Sprintf("rewoo_%d", time.Now().UnixNano()),
+	return types.ToolCall{
+		ID:        fmt.Sprintf("rewoo_%d_%d", time.Now().UnixNano(), idx),
 		Name:      toolName,
 		Arguments: argsJSON,
 	}
+}
 
-	results := r.toolExecutor.Execute(ctx, []types.ToolCall{call})
+func (r *ReWOO) executeTool(ctx context.Context, toolName, args string) string {
+	results := r.toolExecutor.Execute(ctx, []types.ToolCall{newReWOOToolCall(toolName, args, 0)})
 	if len(results) > 0 {
 		if results[0].Error != "" {
 			return fmt.Sprintf("Error: %s", results[0].Error)
@@ -271,6 +444,117 @@ func (r *ReWOO) executeTool(ctx context.Context, toolName, args string) string {
 	return "No result"
 }
 
+// affectedSuffix 返回失败步骤及所有传递依赖它的步骤 ID
+func affectedSuffix(plan []PlanStep, failedID string) map[string]bool {
+	affected := map[string]bool{failedID: true}
+	for changed := true; changed; {
+		changed = false
+		for _, step := range plan {
+			if affected[step.ID] {
+				continue
+			}
+			for _, dep := range step.Dependencies {
+				if affected[dep] {
+					affected[step.ID] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return affected
+}
+
+// replanSuffix 请求规划器替换受影响的后缀，成功时原地更新计划与执行状态
+func (r *ReWOO) replanSuffix(ctx context.Context, task string, run *rewooRun, executed map[string]bool, failedStep PlanStep) bool {
+	affected := affectedSuffix(run.plan, failedStep.ID)
+
+	var completed, replaced []string
+	kept := make([]PlanStep, 0, len(run.plan))
+	existingIDs := make(map[string]bool)
+	for _, step := range run.plan {
+		if affected[step.ID] {
+			replaced = append(replaced, fmt.Sprintf("%s = %s[%s]", step.ID, step.Tool, step.Arguments))
+			continue
+		}
+		kept = append(kept, step)
+		existingIDs[step.ID] = true
+		if executed[step.ID] {
+			completed = append(completed, fmt.Sprintf("%s = %s[%s] -> %s", step.ID, step.Tool, step.Arguments, truncate(run.observations[step.ID], 200)))
+		}
+	}
+
+	prompt := fmt.Sprintf(`A step in the tool plan failed. Re-plan only the affected remaining steps.
+
+Available tools:
+%s
+
+Task: %s
+
+Completed steps (reuse their results via their IDs, do not repeat them):
+%s
+
+Failed step: %s = %s[%s] -> %s
+
+Steps to replace:
+%s
+
+Use the %s tool to return replacement steps only.
+
+Rules:
+- Use at most %d steps
+- Do not reuse any ID listed above; number new steps after the highest existing #E<n>
+- Reference prior results with #E<n>%s`,
+		r.describeTools(), task, strings.Join(completed, "\n"), failedStep.ID, failedStep.Tool, failedStep.Arguments,
+		run.failed[failedStep.ID], strings.Join(replaced, "\n"), submitToolPlanTool, r.config.MaxPlanSteps, r.costRule())
+
+	resp, err := invokeChatGateway(ctx, r.gateway, newGatewayChatRequest(
+		defaultModel(r.config.Model),
+		[]types.Message{{Role: llmcore.RoleUser, Content: prompt}},
+		func(req *llmcore.ChatRequest) {
+			req.Tools = []types.ToolSchema{toolPlanToolSchema()}
+			req.ToolChoice = &types.ToolChoice{Mode: types.ToolChoiceModeRequired}
+			req.ToolCallMode = llmcore.ToolCallModeNative
+			req.Temperature = 0.2
+			req.MaxTokens = 2000
+		},
+	))
+	if err != nil {
+		r.logger.Warn("ReWOO re-plan failed", zap.String("failed_step", failedStep.ID), zap.Error(err))
+		return false
+	}
+	run.tokens += resp.Usage.TotalTokens
+	choice, err := llmcore.FirstChoice(resp)
+	if err != nil {
+		return false
+	}
+	suffix, err := parseToolPlanToolCall(choice.Message)
+	if err != nil {
+		r.logger.Warn("ReWOO re-plan returned no tool plan", zap.Error(err))
+		return false
+	}
+	for _, step := range suffix {
+		if existingIDs[step.ID] {
+			r.logger.Warn("ReWOO re-plan reused an existing step ID", zap.String("id", step.ID))
+			return false
+		}
+		existingIDs[step.ID] = true
+	}
+	r.annotatePlan(suffix)
+
+	for id := range affected {
+		delete(executed, id)
+		delete(run.observations, id)
+		delete(run.failed, id)
+	}
+	run.plan = append(kept, suffix...)
+	r.logger.Info("ReWOO re-planned failed suffix",
+		zap.String("failed_step", failedStep.ID),
+		zap.Int("replaced", len(affected)),
+		zap.Int("new_steps", len(suffix)))
+	return true
+}
+
 func (r *ReWOO) synthesize(ctx context.Context, task string, plan []PlanStep, observations map[string]string) (string, int, error) {
 	// 从计划和观察中构建环境
 	var planSummary []string
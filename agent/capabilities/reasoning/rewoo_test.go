@@ -0,0 +1,153 @@
+package reasoning
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/BaSui01/agentflow/llm/capabilities/tools"
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func toolPlanResponse(steps string, tokens int) *llm.ChatResponse {
+	return &llm.ChatResponse{
+		Choices: []llm.ChatChoice{{Message: types.Message{
+			ToolCalls: []types.ToolCall{{ID: "call_plan", Name: submitToolPlanTool, Arguments: json.RawMessage(`{"steps":` + steps + `}`)}},
+		}}},
+		Usage: llm.ChatUsage{TotalTokens: tokens},
+	}
+}
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+	fail    map[string]bool
+}
+
+func (b *batchRecorder) executor() *testToolExecutor {
+	return &testToolExecutor{executeFn: func(_ context.Context, calls []types.ToolCall) []tools.ToolResult {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		names := make([]string, 0, len(calls))
+		results := make([]tools.ToolResult, 0, len(calls))
+		for _, call := range calls {
+			var args map[string]string
+			_ = json.Unmarshal(call.Arguments, &args)
+			names = append(names, call.Name+":"+args["input"])
+			if b.fail[call.Name] {
+				results = append(results, tools.ToolResult{ToolCallID: call.ID, Error: "upstream 503"})
+				continue
+			}
+			results = append(results, tools.ToolResult{ToolCallID: call.ID, Result: json.RawMessage(`"` + call.Name + `-ok"`)})
+		}
+		b.batches = append(b.batches, names)
+		return results
+	}}
+}
+
+func TestReWOO_BatchesCompatibleCallsAndDedupes(t *testing.T) {
+	t.Parallel()
+	recorder := &batchRecorder{}
+	cfg := DefaultReWOOConfig()
+	cfg.BatchableTools = []string{"search"}
+	r := NewReWOO(nil, recorder.executor(), nil, cfg, zap.NewNop())
+
+	plan := []PlanStep{
+		{ID: "#E1", Tool: "search", Arguments: "go"},
+		{ID: "#E2", Tool: "search", Arguments: "rust"},
+		{ID: "#E3", Tool: "search", Arguments: "go"},
+		{ID: "#E4", Tool: "fetch", Arguments: "a"},
+		{ID: "#E5", Tool: "fetch", Arguments: "b"},
+	}
+	observations, _ := r.executeSteps(context.Background(), plan)
+	require.Len(t, observations, 5)
+	assert.Equal(t, observations["#E1"], observations["#E3"])
+	assert.Equal(t, [][]string{{"search:go", "search:rust"}, {"fetch:a"}, {"fetch:b"}}, recorder.batches)
+}
+
+func TestReWOO_ReplansOnlyAffectedSuffix(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	var replanPrompt string
+	provider := &testProvider{
+		supportsNative: true,
+		completionFn: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			calls++
+			switch calls {
+			case 1:
+				return toolPlanResponse(`[
+					{"id":"#E1","tool":"search","arguments":"go"},
+					{"id":"#E2","tool":"fetch","arguments":"#E1"},
+					{"id":"#E3","tool":"summarize","arguments":"#E2"},
+					{"id":"#E4","tool":"search","arguments":"rust"}
+				]`, 40), nil
+			case 2:
+				replanPrompt = req.Messages[0].Content
+				return toolPlanResponse(`[
+					{"id":"#E5","tool":"mirror","arguments":"#E1"},
+					{"id":"#E6","tool":"summarize","arguments":"#E5"}
+				]`, 20), nil
+			default:
+				return &llm.ChatResponse{
+					Choices: []llm.ChatChoice{{Message: types.Message{Content: "answer"}}},
+					Usage:   llm.ChatUsage{TotalTokens: 10},
+				}, nil
+			}
+		},
+	}
+	recorder := &batchRecorder{fail: map[string]bool{"fetch": true}}
+	cfg := DefaultReWOOConfig()
+	cfg.ToolCosts = map[string]float64{"search": 0.01, "mirror": 0.02}
+	cfg.TokenCost = 0.001
+
+	r := NewReWOO(testGateway(provider), recorder.executor(), []types.ToolSchema{{Name: "search", Description: "web search"}}, cfg, zap.NewNop())
+	result, err := r.Execute(context.Background(), "compare languages")
+	require.NoError(t, err)
+	assert.Equal(t, "answer", result.FinalAnswer)
+	assert.Equal(t, 1, result.Metadata["replans"])
+	assert.Equal(t, 0, result.Metadata["failed_steps"])
+	assert.Contains(t, replanPrompt, "#E1 = search[go]")
+	assert.Contains(t, replanPrompt, "#E3 = summarize[#E2]")
+
+	searches := 0
+	for _, batch := range recorder.batches {
+		for _, call := range batch {
+			if call == "search:go" {
+				searches++
+			}
+		}
+	}
+	assert.Equal(t, 1, searches, "completed steps are not re-executed")
+	assert.Equal(t, []string{"summarize:\"mirror-ok\""}, recorder.batches[len(recorder.batches)-1])
+	assert.InDelta(t, 0.04, result.Metadata["estimated_tool_cost"], 1e-9)
+	assert.InDelta(t, 0.07, result.Metadata["estimated_llm_cost"], 1e-9)
+}
+
+func TestReWOO_GeneratePlanIncludesToolCosts(t *testing.T) {
+	t.Parallel()
+	var prompt string
+	provider := &testProvider{
+		supportsNative: true,
+		completionFn: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			prompt = req.Messages[0].Content
+			return toolPlanResponse(`[{"id":"#E1","tool":"search","arguments":"go"},{"id":"#E2","tool":"fetch","arguments":"#E1"}]`, 5), nil
+		},
+	}
+	cfg := DefaultReWOOConfig()
+	cfg.ToolCosts = map[string]float64{"search": 0.5}
+	cfg.DefaultToolCost = 0.1
+	r := NewReWOO(testGateway(provider), nil, []types.ToolSchema{{Name: "search", Description: "web"}}, cfg, zap.NewNop())
+
+	plan, _, err := r.generatePlan(context.Background(), "task")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "est. cost 0.5000/call")
+	assert.Contains(t, prompt, "cheapest plan")
+	assert.Equal(t, 0.5, plan[0].EstimatedCost)
+	assert.Equal(t, 0.1, plan[1].EstimatedCost)
+	assert.Equal(t, []string{"#E1"}, plan[1].Dependencies)
+}
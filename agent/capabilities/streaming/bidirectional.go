@@ -2,8 +2,10 @@ package streaming
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -63,22 +65,30 @@ type StreamConfig struct {
 	HeartbeatTimeout  time.Duration `json:"heartbeat_timeout"`  // 心跳超时，默认 10s
 	MaxReconnects     int           `json:"max_reconnects"`     // 最大重连次数，默认 5
 	EnableHeartbeat   bool          `json:"enable_heartbeat"`   // 是否启用心跳
+	// 重连退避：ReconnectDelay * ReconnectMultiplier^(n-1)，±ReconnectJitter 比例抖动，上限 ReconnectMaxDelay
+	ReconnectMultiplier float64       `json:"reconnect_multiplier"` // 退避倍数，<=0 时为 2
+	ReconnectMaxDelay   time.Duration `json:"reconnect_max_delay"`  // 单次退避上限，<=0 时为 30s
+	ReconnectJitter     float64       `json:"reconnect_jitter"`     // 抖动比例（0~1），0 表示不抖动
+	ReconnectBudget     int           `json:"reconnect_budget"`     // 流生命周期内累计重连预算（重连成功也不重置），0 表示不限制
 }
 
 // 默认 StreamConfig 返回默认流化配置 。
 func DefaultStreamConfig() StreamConfig {
 	return StreamConfig{
-		BufferSize:        1024,
-		MaxLatencyMS:      200,
-		SampleRate:        16000,
-		Channels:          1,
-		EnableVAD:         true,
-		ChunkDuration:     100 * time.Millisecond,
-		ReconnectDelay:    time.Second,
-		HeartbeatInterval: 30 * time.Second,
-		HeartbeatTimeout:  10 * time.Second,
-		MaxReconnects:     5,
-		EnableHeartbeat:   true,
+		BufferSize:          1024,
+		MaxLatencyMS:        200,
+		SampleRate:          16000,
+		Channels:            1,
+		EnableVAD:           true,
+		ChunkDuration:       100 * time.Millisecond,
+		ReconnectDelay:      time.Second,
+		HeartbeatInterval:   30 * time.Second,
+		HeartbeatTimeout:    10 * time.Second,
+		MaxReconnects:       5,
+		EnableHeartbeat:     true,
+		ReconnectMultiplier: 2,
+		ReconnectMaxDelay:   30 * time.Second,
+		ReconnectJitter:     0.2,
 	}
 }
 
@@ -99,7 +109,9 @@ type BidirectionalStream struct {
 	// 新增字段
 	connFactory    func() (StreamConnection, error) // 连接工厂，用于重连
 	reconnectCount int
-	lastHeartbeat  time.Time
+	lastHeartbeat  time.Time  // 最近一次收到对端数据的时间
+	lastWriteOK    time.Time  // 最近一次成功写入连接的时间，用于识别半开连接
+	totalReconnect int        // 累计重连尝试次数，受 ReconnectBudget 约束
 	errChan        chan error // 内部错误通道
 }
 
@@ -157,14 +169,15 @@ func (s *BidirectionalStream) Start(ctx context.Context) error {
 	if s.conn == nil && s.connFactory != nil {
 		conn, err := s.connFactory()
 		if err != nil {
-			s.setState(StateError)
+			s.emitState(StreamStateEvent{State: StateError, Cause: CauseNetworkError, Err: err, Terminal: true})
 			return fmt.Errorf("failed to establish connection: %w", err)
 		}
 		s.conn = conn
 	}
 	if s.conn == nil {
-		s.setState(StateError)
-		return fmt.Errorf("no connection available")
+		err := fmt.Errorf("no connection available")
+		s.emitState(StreamStateEvent{State: StateError, Err: err, Terminal: true})
+		return err
 	}
 
 	s.setState(StateConnected)
@@ -254,12 +267,39 @@ func (s *BidirectionalStream) Close() error {
 }
 
 func (s *BidirectionalStream) setState(state StreamState) {
+	s.emitState(StreamStateEvent{State: state})
+}
+
+// emitState 更新状态并通知 handler；实现 StreamLivenessHandler 的 handler 额外收到带原因的事件
+func (s *BidirectionalStream) emitState(event StreamStateEvent) {
 	s.mu.Lock()
-	s.State = state
+	event.Previous = s.State
+	s.State = event.State
 	s.mu.Unlock()
-	if s.handler != nil {
-		s.handler.OnStateChange(state)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if s.handler == nil {
+		return
 	}
+	s.handler.OnStateChange(event.State)
+	if lh, ok := s.handler.(StreamLivenessHandler); ok {
+		lh.OnStateEvent(event)
+	}
+}
+
+// connection 返回当前底层连接（重连时会被替换）
+func (s *BidirectionalStream) connection() StreamConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conn
+}
+
+// markWriteOK 记录一次成功写入
+func (s *BidirectionalStream) markWriteOK() {
+	s.mu.Lock()
+	s.lastWriteOK = time.Now()
+	s.mu.Unlock()
 }
 
 func (s *BidirectionalStream) processInbound(ctx context.Context) {
@@ -274,7 +314,7 @@ func (s *BidirectionalStream) processInbound(ctx context.Context) {
 		}
 
 		// 从底层连接读取数据
-		chunk, err := s.conn.ReadChunk(ctx)
+		chunk, err := s.connection().ReadChunk(ctx)
 		if err != nil {
 			// 检查是否是正常关闭
 			select {
@@ -286,10 +326,11 @@ func (s *BidirectionalStream) processInbound(ctx context.Context) {
 			}
 
 			s.logger.Error("connection read error", zap.Error(err))
-			s.errChan <- fmt.Errorf("inbound read error: %w", err)
+			readErr := fmt.Errorf("inbound read error: %w", err)
+			s.errChan <- readErr
 
 			// 尝试重连
-			if s.tryReconnect(ctx) {
+			if s.tryReconnect(ctx, CauseNetworkError, readErr) {
 				continue
 			}
 			return
@@ -360,20 +401,24 @@ func (s *BidirectionalStream) processOutbound(ctx context.Context) {
 			}
 
 			// 写入底层连接
-			if err := s.conn.WriteChunk(ctx, chunk); err != nil {
+			if err := s.connection().WriteChunk(ctx, chunk); err != nil {
 				s.logger.Error("connection write error", zap.Error(err))
-				s.errChan <- fmt.Errorf("outbound write error: %w", err)
+				writeErr := fmt.Errorf("outbound write error: %w", err)
+				s.errChan <- writeErr
 
 				// 尝试重连后重发
-				if s.tryReconnect(ctx) {
+				if s.tryReconnect(ctx, CauseNetworkError, writeErr) {
 					// 重连成功，重新发送当前 chunk
-					if retryErr := s.conn.WriteChunk(ctx, chunk); retryErr != nil {
+					if retryErr := s.connection().WriteChunk(ctx, chunk); retryErr != nil {
 						s.logger.Error("retry write failed after reconnect", zap.Error(retryErr))
+					} else {
+						s.markWriteOK()
 					}
 					continue
 				}
 				return
 			}
+			s.markWriteOK()
 		}
 	}
 }
@@ -399,26 +444,31 @@ func (s *BidirectionalStream) processHeartbeat(ctx context.Context) {
 			heartbeat.Type = "heartbeat"
 			heartbeat.Timestamp = time.Now()
 			heartbeat.Metadata = map[string]any{"ping": true}
-			err := s.conn.WriteChunk(ctx, *heartbeat)
+			err := s.connection().WriteChunk(ctx, *heartbeat)
 			streamChunkPool.Put(heartbeat)
 			if err != nil {
 				s.logger.Warn("heartbeat send failed", zap.Error(err))
 				s.errChan <- fmt.Errorf("heartbeat failed: %w", err)
+			} else {
+				s.markWriteOK()
 			}
 
-			// 检查对端心跳超时
+			// 检查对端心跳超时：写入成功但读取停滞为半开连接，写入同样失败则为对端超时
 			s.mu.RLock()
 			lastBeat := s.lastHeartbeat
+			lastWriteOK := s.lastWriteOK
 			s.mu.RUnlock()
 
 			if !lastBeat.IsZero() && time.Since(lastBeat) > s.Config.HeartbeatTimeout+s.Config.HeartbeatInterval {
+				cause := classifySilence(lastBeat, lastWriteOK)
 				s.logger.Warn("heartbeat timeout detected",
+					zap.String("cause", string(cause)),
 					zap.Duration("since_last", time.Since(lastBeat)))
-				s.errChan <- fmt.Errorf("heartbeat timeout: last=%v", lastBeat)
+				timeoutErr := fmt.Errorf("heartbeat timeout (%s): last=%v", cause, lastBeat)
+				s.errChan <- timeoutErr
 
-				// 尝试重连
-				if !s.tryReconnect(ctx) {
-					s.setState(StateError)
+				// 尝试重连；失败时 tryReconnect 已发出终止事件
+				if !s.tryReconnect(ctx, cause, timeoutErr) {
 					return
 				}
 			}
@@ -427,69 +477,79 @@ func (s *BidirectionalStream) processHeartbeat(ctx context.Context) {
 }
 
 // tryReconnect 尝试重新建立连接
-func (s *BidirectionalStream) tryReconnect(ctx context.Context) bool {
+// 单次断线内最多尝试 MaxReconnects 次，整个流生命周期内累计不超过 ReconnectBudget 次；
+// 放弃时发出 Terminal=true 的 StateError 事件。
+func (s *BidirectionalStream) tryReconnect(ctx context.Context, cause DisconnectCause, causeErr error) bool {
 	if s.connFactory == nil {
 		s.logger.Error("no connection factory, cannot reconnect")
+		s.emitState(StreamStateEvent{State: StateError, Cause: cause, Err: errors.Join(ErrReconnectUnavailable, causeErr), Terminal: true})
 		return false
 	}
 
-	s.mu.Lock()
-	if s.reconnectCount >= s.Config.MaxReconnects {
+	lastErr := causeErr
+	for {
+		s.mu.Lock()
+		if s.reconnectCount >= s.Config.MaxReconnects ||
+			(s.Config.ReconnectBudget > 0 && s.totalReconnect >= s.Config.ReconnectBudget) {
+			attempts, total := s.reconnectCount, s.totalReconnect
+			s.mu.Unlock()
+			s.logger.Error("max reconnect attempts reached",
+				zap.Int("attempts", attempts),
+				zap.Int("total_attempts", total),
+				zap.String("cause", string(cause)))
+			s.emitState(StreamStateEvent{
+				State:    StateError,
+				Cause:    cause,
+				Err:      errors.Join(ErrReconnectExhausted, lastErr),
+				Attempt:  attempts,
+				Terminal: true,
+			})
+			return false
+		}
+		s.reconnectCount++
+		s.totalReconnect++
+		attempt := s.reconnectCount
 		s.mu.Unlock()
-		s.logger.Error("max reconnect attempts reached",
-			zap.Int("attempts", s.reconnectCount))
-		s.setState(StateError)
-		return false
-	}
-	s.reconnectCount++
-	attempt := s.reconnectCount
-	s.mu.Unlock()
 
-	s.setState(StateConnecting)
-	s.logger.Info("attempting reconnect",
-		zap.Int("attempt", attempt),
-		zap.Int("max", s.Config.MaxReconnects))
-
-	// 指数退避
-	delay := s.Config.ReconnectDelay * time.Duration(1<<uint(attempt-1))
-	if delay > 30*time.Second {
-		delay = 30 * time.Second
-	}
+		s.emitState(StreamStateEvent{State: StateConnecting, Cause: cause, Err: lastErr, Attempt: attempt})
+		s.logger.Info("attempting reconnect",
+			zap.Int("attempt", attempt),
+			zap.Int("max", s.Config.MaxReconnects),
+			zap.String("cause", string(cause)))
 
-	select {
-	case <-ctx.Done():
-		return false
-	case <-s.done:
-		return false
-	case <-time.After(delay):
-	}
-
-	// 关闭旧连接
-	if s.conn != nil {
-		_ = s.conn.Close()
-	}
-
-	// 创建新连接
-	newConn, err := s.connFactory()
-	if err != nil {
-		s.logger.Error("reconnect failed", zap.Error(err), zap.Int("attempt", attempt))
-		return s.tryReconnect(ctx) // 递归重试
-	}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.done:
+			return false
+		case <-time.After(s.Config.reconnectBackoff(attempt, rand.Float64)):
+		}
 
-	s.mu.Lock()
-	s.conn = newConn
-	s.lastHeartbeat = time.Now()
-	s.mu.Unlock()
+		// 关闭旧连接
+		if old := s.connection(); old != nil {
+			_ = old.Close()
+		}
 
-	s.setState(StateConnected)
-	s.logger.Info("reconnected successfully", zap.Int("attempt", attempt))
+		// 创建新连接
+		newConn, err := s.connFactory()
+		if err != nil {
+			s.logger.Error("reconnect failed", zap.Error(err), zap.Int("attempt", attempt))
+			lastErr = err
+			cause = CauseNetworkError
+			continue
+		}
 
-	// 重置重连计数
-	s.mu.Lock()
-	s.reconnectCount = 0
-	s.mu.Unlock()
+		s.mu.Lock()
+		s.conn = newConn
+		s.lastHeartbeat = time.Now()
+		s.lastWriteOK = time.Time{}
+		s.reconnectCount = 0
+		s.mu.Unlock()
 
-	return true
+		s.emitState(StreamStateEvent{State: StateConnected, Cause: cause, Attempt: attempt})
+		s.logger.Info("reconnected successfully", zap.Int("attempt", attempt))
+		return true
+	}
 }
 
 // GetState 返回当前流状态 。
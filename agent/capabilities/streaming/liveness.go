@@ -0,0 +1,91 @@
+package streaming
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// 重连默认参数
+const (
+	defaultReconnectMultiplier = 2.0
+	defaultReconnectMaxDelay   = 30 * time.Second
+)
+
+var (
+	// ErrReconnectUnavailable 未配置连接工厂，无法重连
+	ErrReconnectUnavailable = errors.New("stream: no connection factory for reconnect")
+	// ErrReconnectExhausted 重连次数或累计重连预算已耗尽
+	ErrReconnectExhausted = errors.New("stream: reconnect attempts exhausted")
+)
+
+// DisconnectCause 描述连接状态变化的原因.
+type DisconnectCause string
+
+const (
+	CauseNone DisconnectCause = ""
+	// CausePeerTimeout 对端在心跳窗口内无任何数据，且本端心跳也发送失败
+	CausePeerTimeout DisconnectCause = "peer_timeout"
+	// CauseHalfOpen 半开连接：本端写入持续成功，但对端在心跳窗口内无任何数据
+	CauseHalfOpen DisconnectCause = "half_open"
+	// CauseNetworkError 底层连接读写返回错误
+	CauseNetworkError DisconnectCause = "network_error"
+)
+
+// StreamStateEvent 带原因的状态变更事件.
+type StreamStateEvent struct {
+	State     StreamState     `json:"state"`
+	Previous  StreamState     `json:"previous"`
+	Cause     DisconnectCause `json:"cause,omitempty"`
+	Err       error           `json:"-"`
+	Attempt   int             `json:"attempt,omitempty"`  // 当前重连尝试序号（仅重连相关事件）
+	Terminal  bool            `json:"terminal,omitempty"` // 为 true 表示流已放弃重连，不会再自动恢复
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// StreamLivenessHandler 可选接口：StreamHandler 同时实现该接口时，
+// 每次状态变更都会额外收到携带原因的 StreamStateEvent.
+type StreamLivenessHandler interface {
+	OnStateEvent(event StreamStateEvent)
+}
+
+// reconnectBackoff 计算第 attempt 次重连前的等待时间：
+// ReconnectDelay * ReconnectMultiplier^(attempt-1)，按 ReconnectJitter 做 ±比例抖动，并限制在 ReconnectMaxDelay 内.
+func (c StreamConfig) reconnectBackoff(attempt int, randFloat func() float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := c.ReconnectMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultReconnectMultiplier
+	}
+	maxDelay := c.ReconnectMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultReconnectMaxDelay
+	}
+
+	delay := float64(c.ReconnectDelay) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+
+	jitter := math.Min(math.Max(c.ReconnectJitter, 0), 1)
+	if jitter > 0 && randFloat != nil {
+		delay *= 1 - jitter + 2*jitter*randFloat()
+	}
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// classifySilence 判断心跳窗口内对端静默的原因：本端写入仍成功视为半开连接，否则视为对端超时.
+func classifySilence(lastInbound, lastWriteOK time.Time) DisconnectCause {
+	if lastWriteOK.After(lastInbound) {
+		return CauseHalfOpen
+	}
+	return CausePeerTimeout
+}
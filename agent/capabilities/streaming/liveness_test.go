@@ -0,0 +1,216 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- mock StreamLivenessHandler ---
+
+type livenessHandler struct {
+	mockHandler
+	evMu   sync.Mutex
+	events []StreamStateEvent
+	final  chan StreamStateEvent
+}
+
+func newLivenessHandler() *livenessHandler {
+	return &livenessHandler{final: make(chan StreamStateEvent, 1)}
+}
+
+func (h *livenessHandler) OnStateEvent(event StreamStateEvent) {
+	h.evMu.Lock()
+	h.events = append(h.events, event)
+	h.evMu.Unlock()
+	if event.Terminal {
+		select {
+		case h.final <- event:
+		default:
+		}
+	}
+}
+
+func (h *livenessHandler) getEvents() []StreamStateEvent {
+	h.evMu.Lock()
+	defer h.evMu.Unlock()
+	cp := make([]StreamStateEvent, len(h.events))
+	copy(cp, h.events)
+	return cp
+}
+
+func (h *livenessHandler) waitTerminal(t *testing.T) StreamStateEvent {
+	t.Helper()
+	select {
+	case ev := <-h.final:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for terminal state event")
+		return StreamStateEvent{}
+	}
+}
+
+func blockingRead(ctx context.Context) (*StreamChunk, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStreamConfig_ReconnectBackoff(t *testing.T) {
+	cfg := StreamConfig{ReconnectDelay: 100 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, cfg.reconnectBackoff(1, nil))
+	assert.Equal(t, 400*time.Millisecond, cfg.reconnectBackoff(3, nil))
+	assert.Equal(t, 30*time.Second, cfg.reconnectBackoff(20, nil))
+
+	cfg.ReconnectMultiplier = 3
+	cfg.ReconnectMaxDelay = time.Second
+	assert.Equal(t, 300*time.Millisecond, cfg.reconnectBackoff(2, nil))
+	assert.Equal(t, time.Second, cfg.reconnectBackoff(4, nil))
+
+	cfg.ReconnectMultiplier = 1
+	assert.Equal(t, 100*time.Millisecond, cfg.reconnectBackoff(5, nil))
+}
+
+func TestStreamConfig_ReconnectBackoff_Jitter(t *testing.T) {
+	cfg := StreamConfig{ReconnectDelay: time.Second, ReconnectJitter: 0.5, ReconnectMaxDelay: time.Minute}
+	assert.Equal(t, 500*time.Millisecond, cfg.reconnectBackoff(1, func() float64 { return 0 }))
+	assert.Equal(t, time.Second, cfg.reconnectBackoff(1, func() float64 { return 0.5 }))
+	assert.Equal(t, 1500*time.Millisecond, cfg.reconnectBackoff(1, func() float64 { return 1 }))
+
+	// 抖动后的结果仍受上限约束
+	cfg.ReconnectMaxDelay = 1200 * time.Millisecond
+	assert.Equal(t, 1200*time.Millisecond, cfg.reconnectBackoff(1, func() float64 { return 1 }))
+}
+
+func TestClassifySilence(t *testing.T) {
+	lastRead := time.Now().Add(-time.Minute)
+	assert.Equal(t, CauseHalfOpen, classifySilence(lastRead, time.Now()))
+	assert.Equal(t, CausePeerTimeout, classifySilence(lastRead, lastRead.Add(-time.Second)))
+	assert.Equal(t, CausePeerTimeout, classifySilence(lastRead, time.Time{}))
+}
+
+func TestBidirectionalStream_HalfOpenDetected(t *testing.T) {
+	conn := &mockConn{readFn: blockingRead}
+	handler := newLivenessHandler()
+	config := DefaultStreamConfig()
+	config.HeartbeatInterval = 20 * time.Millisecond
+	config.HeartbeatTimeout = 20 * time.Millisecond
+
+	stream := NewBidirectionalStream(config, handler, conn, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, stream.Start(ctx))
+	defer stream.Close()
+
+	ev := handler.waitTerminal(t)
+	assert.Equal(t, StateError, ev.State)
+	assert.Equal(t, CauseHalfOpen, ev.Cause)
+	assert.ErrorIs(t, ev.Err, ErrReconnectUnavailable)
+	assert.Equal(t, StateError, stream.GetState())
+	assert.Contains(t, handler.getStateChanges(), StateError)
+}
+
+func TestBidirectionalStream_PeerTimeoutWhenWritesFail(t *testing.T) {
+	conn := &mockConn{
+		readFn: blockingRead,
+		writeFn: func(ctx context.Context, chunk StreamChunk) error {
+			return errors.New("broken pipe")
+		},
+	}
+	handler := newLivenessHandler()
+	config := DefaultStreamConfig()
+	config.HeartbeatInterval = 20 * time.Millisecond
+	config.HeartbeatTimeout = 20 * time.Millisecond
+
+	stream := NewBidirectionalStream(config, handler, conn, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, stream.Start(ctx))
+	defer stream.Close()
+
+	ev := handler.waitTerminal(t)
+	assert.Equal(t, CausePeerTimeout, ev.Cause)
+}
+
+func TestBidirectionalStream_ReadErrorReportsNetworkCause(t *testing.T) {
+	readErr := errors.New("connection reset")
+	conn := &mockConn{readFn: func(ctx context.Context) (*StreamChunk, error) {
+		return nil, readErr
+	}}
+	handler := newLivenessHandler()
+	config := DefaultStreamConfig()
+	config.EnableHeartbeat = false
+
+	stream := NewBidirectionalStream(config, handler, conn, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, stream.Start(ctx))
+	defer stream.Close()
+
+	ev := handler.waitTerminal(t)
+	assert.Equal(t, CauseNetworkError, ev.Cause)
+	assert.ErrorIs(t, ev.Err, readErr)
+}
+
+func TestBidirectionalStream_ReconnectBudgetExhausted(t *testing.T) {
+	config := DefaultStreamConfig()
+	config.MaxReconnects = 5
+	config.ReconnectBudget = 2
+	config.ReconnectDelay = time.Millisecond
+	config.ReconnectJitter = 0
+
+	dialErr := errors.New("dial refused")
+	var dials int
+	factory := func() (StreamConnection, error) {
+		dials++
+		return nil, dialErr
+	}
+
+	handler := newLivenessHandler()
+	stream := NewBidirectionalStream(config, handler, nil, factory, nil)
+	assert.False(t, stream.tryReconnect(context.Background(), CauseHalfOpen, nil))
+	assert.Equal(t, 2, dials)
+
+	ev := handler.waitTerminal(t)
+	assert.Equal(t, StateError, ev.State)
+	assert.ErrorIs(t, ev.Err, ErrReconnectExhausted)
+	assert.ErrorIs(t, ev.Err, dialErr)
+	assert.Equal(t, CauseNetworkError, ev.Cause)
+
+	var attempts []int
+	for _, e := range handler.getEvents() {
+		if e.State == StateConnecting {
+			attempts = append(attempts, e.Attempt)
+		}
+	}
+	assert.Equal(t, []int{1, 2}, attempts)
+}
+
+func TestBidirectionalStream_ReconnectBudgetSpansRecoveries(t *testing.T) {
+	config := DefaultStreamConfig()
+	config.ReconnectBudget = 1
+	config.ReconnectDelay = time.Millisecond
+
+	factory := func() (StreamConnection, error) {
+		return &mockConn{}, nil
+	}
+
+	handler := newLivenessHandler()
+	stream := NewBidirectionalStream(config, handler, nil, factory, nil)
+	require.True(t, stream.tryReconnect(context.Background(), CausePeerTimeout, nil))
+
+	events := handler.getEvents()
+	require.NotEmpty(t, events)
+	last := events[len(events)-1]
+	assert.Equal(t, StateConnected, last.State)
+	assert.Equal(t, CausePeerTimeout, last.Cause)
+
+	// 单次断线计数已重置，但累计预算已用完
+	assert.False(t, stream.tryReconnect(context.Background(), CausePeerTimeout, nil))
+	ev := handler.waitTerminal(t)
+	assert.ErrorIs(t, ev.Err, ErrReconnectExhausted)
+}
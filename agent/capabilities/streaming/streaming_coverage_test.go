@@ -315,7 +315,7 @@ var _ io.Writer = (*StreamWriter)(nil)
 
 func TestBidirectionalStream_TryReconnect_NoFactory(t *testing.T) {
 	stream := NewBidirectionalStream(DefaultStreamConfig(), &mockHandler{}, nil, nil, nil)
-	result := stream.tryReconnect(context.Background())
+	result := stream.tryReconnect(context.Background(), CauseNetworkError, nil)
 	assert.False(t, result)
 }
 
@@ -331,7 +331,7 @@ func TestBidirectionalStream_TryReconnect_MaxAttempts(t *testing.T) {
 	}
 
 	stream := NewBidirectionalStream(config, &mockHandler{}, nil, factory, nil)
-	result := stream.tryReconnect(context.Background())
+	result := stream.tryReconnect(context.Background(), CauseNetworkError, nil)
 	assert.False(t, result)
 	assert.Equal(t, StateError, stream.GetState())
 }
@@ -347,7 +347,7 @@ func TestBidirectionalStream_TryReconnect_Success(t *testing.T) {
 	}
 
 	stream := NewBidirectionalStream(config, &mockHandler{}, nil, factory, nil)
-	result := stream.tryReconnect(context.Background())
+	result := stream.tryReconnect(context.Background(), CauseNetworkError, nil)
 	assert.True(t, result)
 	assert.Equal(t, StateConnected, stream.GetState())
 }
@@ -0,0 +1,469 @@
+package discovery
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ConstraintOrder ranks candidates that satisfy a constraint query.
+type ConstraintOrder string
+
+const (
+	ConstraintOrderNone        ConstraintOrder = ""
+	ConstraintOrderCheapest    ConstraintOrder = "cheapest"
+	ConstraintOrderFastest     ConstraintOrder = "fastest"
+	ConstraintOrderBest        ConstraintOrder = "best"
+	ConstraintOrderLeastLoaded ConstraintOrder = "least_loaded"
+)
+
+// Latency class names shared with the tools facade, ordered from fastest to slowest.
+const (
+	LatencyClassRealtime    = "realtime"
+	LatencyClassInteractive = "interactive"
+	LatencyClassStandard    = "standard"
+	LatencyClassBatch       = "batch"
+)
+
+var latencyClassOrder = []string{LatencyClassRealtime, LatencyClassInteractive, LatencyClassStandard, LatencyClassBatch}
+
+// latencyClassBounds are the p95 upper bounds of each class; batch is unbounded.
+var latencyClassBounds = map[string]time.Duration{
+	LatencyClassRealtime:    500 * time.Millisecond,
+	LatencyClassInteractive: 2 * time.Second,
+	LatencyClassStandard:    10 * time.Second,
+}
+
+// LatencyClassFor returns the narrowest latency class whose bound covers p95.
+func LatencyClassFor(p95 time.Duration) string {
+	if p95 <= 0 {
+		return ""
+	}
+	for _, class := range latencyClassOrder {
+		if bound, ok := latencyClassBounds[class]; ok && p95 <= bound {
+			return class
+		}
+	}
+	return LatencyClassBatch
+}
+
+// LatencyClassUpperBound returns the p95 bound of a latency class.
+func LatencyClassUpperBound(class string) (time.Duration, bool) {
+	bound, ok := latencyClassBounds[strings.ToLower(class)]
+	return bound, ok
+}
+
+func latencyClassRank(class string) int {
+	for i, name := range latencyClassOrder {
+		if strings.EqualFold(name, class) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ConstraintCandidate is the minimal capability shape a constraint query is evaluated against.
+type ConstraintCandidate struct {
+	Capability    string
+	Tags          []string
+	Cost          float64
+	HasCost       bool
+	Currency      string
+	P95Latency    time.Duration
+	LatencyClass  string
+	InputSchemas  []string
+	OutputSchemas []string
+	Score         float64
+	Load          float64
+}
+
+// EffectiveP95 returns the declared p95, falling back to the latency class bound.
+func (c ConstraintCandidate) EffectiveP95() (time.Duration, bool) {
+	if c.P95Latency > 0 {
+		return c.P95Latency, true
+	}
+	return LatencyClassUpperBound(c.LatencyClass)
+}
+
+func (c ConstraintCandidate) effectiveLatencyClass() string {
+	if c.LatencyClass != "" {
+		return c.LatencyClass
+	}
+	return LatencyClassFor(c.P95Latency)
+}
+
+// ConstraintCondition is a single "field op value" clause.
+type ConstraintCondition struct {
+	Field string
+	Op    string
+	Value string
+
+	number   float64
+	duration time.Duration
+}
+
+// ConstraintQuery is a parsed constraint expression such as
+// "cheapest agent with p95 < 2s supporting schema invoice.v1".
+type ConstraintQuery struct {
+	Order      ConstraintOrder
+	Conditions []ConstraintCondition
+}
+
+const (
+	fieldCost          = "cost"
+	fieldP95           = "p95"
+	fieldLatencyClass  = "latency_class"
+	fieldSchema        = "schema"
+	fieldInputSchema   = "input_schema"
+	fieldOutputSchema  = "output_schema"
+	fieldCapability    = "capability"
+	fieldTag           = "tag"
+	fieldCurrency      = "currency"
+	fieldScore         = "score"
+	fieldLoad          = "load"
+	constraintOpEquals = "="
+)
+
+var constraintFieldAliases = map[string]string{
+	"cost":          fieldCost,
+	"price":         fieldCost,
+	"p95":           fieldP95,
+	"latency":       fieldP95,
+	"p95_latency":   fieldP95,
+	"latency_class": fieldLatencyClass,
+	"class":         fieldLatencyClass,
+	"schema":        fieldSchema,
+	"input_schema":  fieldInputSchema,
+	"input":         fieldInputSchema,
+	"output_schema": fieldOutputSchema,
+	"output":        fieldOutputSchema,
+	"capability":    fieldCapability,
+	"cap":           fieldCapability,
+	"tag":           fieldTag,
+	"currency":      fieldCurrency,
+	"score":         fieldScore,
+	"load":          fieldLoad,
+}
+
+var constraintOrders = map[string]ConstraintOrder{
+	"cheapest":     ConstraintOrderCheapest,
+	"fastest":      ConstraintOrderFastest,
+	"best":         ConstraintOrderBest,
+	"least_loaded": ConstraintOrderLeastLoaded,
+}
+
+// constraintFillers are words that read naturally but carry no meaning.
+var constraintFillers = map[string]bool{
+	"agent": true, "agents": true, "with": true, "where": true, "that": true,
+	"having": true, "and": true,
+}
+
+// ParseConstraint parses a constraint expression. The grammar is an optional
+// order keyword (cheapest, fastest, best, least_loaded) followed by clauses
+// joined by "and" (or simply juxtaposed):
+//
+//	field op value            e.g. p95 < 2s, cost <= 0.01, tag = pii-safe
+//	supporting [input|output] schema <id>
+//
+// Supported fields are cost/price, p95/latency, latency_class, schema,
+// input_schema, output_schema, capability, tag, currency, score and load.
+func ParseConstraint(expr string) (*ConstraintQuery, error) {
+	tokens, err := tokenizeConstraint(expr)
+	if err != nil {
+		return nil, err
+	}
+	query := &ConstraintQuery{}
+	i := 0
+	if len(tokens) > 0 && !tokens[0].quoted {
+		if order, ok := constraintOrders[strings.ToLower(tokens[0].text)]; ok {
+			query.Order = order
+			i++
+		}
+	}
+
+	for i < len(tokens) {
+		tok := tokens[i]
+		word := strings.ToLower(tok.text)
+		if !tok.quoted && constraintFillers[word] {
+			i++
+			continue
+		}
+		if !tok.quoted && (word == "supporting" || word == "supports" || word == "support") {
+			cond, next, err := parseSupportsClause(tokens, i+1)
+			if err != nil {
+				return nil, err
+			}
+			query.Conditions = append(query.Conditions, cond)
+			i = next
+			continue
+		}
+
+		field, ok := constraintFieldAliases[word]
+		if !ok || tok.quoted {
+			return nil, fmt.Errorf("unknown constraint field %q", tok.text)
+		}
+		if i+2 >= len(tokens) {
+			return nil, fmt.Errorf("incomplete clause for %q", tok.text)
+		}
+		op := tokens[i+1]
+		if !op.operator || tokens[i+2].operator {
+			return nil, fmt.Errorf("expected \"%s <op> <value>\", got %q %q", tok.text, op.text, tokens[i+2].text)
+		}
+		cond, err := newConstraintCondition(field, op.text, tokens[i+2].text)
+		if err != nil {
+			return nil, err
+		}
+		query.Conditions = append(query.Conditions, cond)
+		i += 3
+	}
+	if query.Order == ConstraintOrderNone && len(query.Conditions) == 0 {
+		return nil, fmt.Errorf("empty constraint")
+	}
+	return query, nil
+}
+
+func parseSupportsClause(tokens []constraintToken, i int) (ConstraintCondition, int, error) {
+	field := fieldSchema
+	if i < len(tokens) && !tokens[i].quoted {
+		switch strings.ToLower(tokens[i].text) {
+		case "input", "input_schema":
+			field = fieldInputSchema
+			i++
+		case "output", "output_schema":
+			field = fieldOutputSchema
+			i++
+		}
+	}
+	if i < len(tokens) && !tokens[i].quoted && strings.EqualFold(tokens[i].text, "schema") {
+		i++
+	}
+	if i >= len(tokens) || tokens[i].operator {
+		return ConstraintCondition{}, i, fmt.Errorf("supporting clause requires a schema id")
+	}
+	cond, err := newConstraintCondition(field, constraintOpEquals, tokens[i].text)
+	return cond, i + 1, err
+}
+
+func newConstraintCondition(field, op, value string) (ConstraintCondition, error) {
+	if op == "==" {
+		op = constraintOpEquals
+	}
+	cond := ConstraintCondition{Field: field, Op: op, Value: value}
+	switch field {
+	case fieldCost, fieldScore, fieldLoad:
+		n, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil {
+			return cond, fmt.Errorf("%s requires a number, got %q", field, value)
+		}
+		cond.number = n
+	case fieldP95:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return cond, fmt.Errorf("%s requires a duration such as 2s, got %q", field, value)
+		}
+		cond.duration = d
+	case fieldLatencyClass:
+		if latencyClassRank(value) < 0 {
+			return cond, fmt.Errorf("unknown latency class %q", value)
+		}
+	default:
+		if op != constraintOpEquals && op != "!=" {
+			return cond, fmt.Errorf("%s only supports = and !=", field)
+		}
+	}
+	switch op {
+	case "<", "<=", ">", ">=", constraintOpEquals, "!=":
+	default:
+		return cond, fmt.Errorf("unknown operator %q", op)
+	}
+	return cond, nil
+}
+
+// String renders the query in canonical form.
+func (q *ConstraintQuery) String() string {
+	if q == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(q.Conditions)+1)
+	if q.Order != ConstraintOrderNone {
+		parts = append(parts, string(q.Order))
+	}
+	for i, cond := range q.Conditions {
+		if i > 0 {
+			parts = append(parts, "and")
+		}
+		parts = append(parts, cond.Field, cond.Op, strconv.Quote(cond.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Matches reports whether a candidate satisfies every condition. Unknown
+// cost or latency never satisfies a bound on that dimension.
+func (q *ConstraintQuery) Matches(c ConstraintCandidate) bool {
+	if q == nil {
+		return true
+	}
+	for _, cond := range q.Conditions {
+		if !cond.matches(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond ConstraintCondition) matches(c ConstraintCandidate) bool {
+	switch cond.Field {
+	case fieldCost:
+		return c.HasCost && compareFloat(c.Cost, cond.Op, cond.number)
+	case fieldScore:
+		return compareFloat(c.Score, cond.Op, cond.number)
+	case fieldLoad:
+		return compareFloat(c.Load, cond.Op, cond.number)
+	case fieldP95:
+		p95, ok := c.EffectiveP95()
+		return ok && compareFloat(float64(p95), cond.Op, float64(cond.duration))
+	case fieldLatencyClass:
+		rank := latencyClassRank(c.effectiveLatencyClass())
+		return rank >= 0 && compareFloat(float64(rank), cond.Op, float64(latencyClassRank(cond.Value)))
+	case fieldSchema:
+		return cond.membership(containsFold(c.InputSchemas, cond.Value) || containsFold(c.OutputSchemas, cond.Value))
+	case fieldInputSchema:
+		return cond.membership(containsFold(c.InputSchemas, cond.Value))
+	case fieldOutputSchema:
+		return cond.membership(containsFold(c.OutputSchemas, cond.Value))
+	case fieldCapability:
+		return cond.membership(c.Capability != "" && CapabilityMatches(c.Capability, cond.Value))
+	case fieldTag:
+		return cond.membership(containsFold(c.Tags, cond.Value))
+	case fieldCurrency:
+		return cond.membership(strings.EqualFold(c.Currency, cond.Value))
+	default:
+		return false
+	}
+}
+
+func (cond ConstraintCondition) membership(found bool) bool {
+	if cond.Op == "!=" {
+		return !found
+	}
+	return found
+}
+
+// Less orders two candidates by the query's order keyword. Candidates with
+// unknown cost or latency sort after known ones.
+func (q *ConstraintQuery) Less(a, b ConstraintCandidate) bool {
+	if q == nil {
+		return false
+	}
+	switch q.Order {
+	case ConstraintOrderCheapest:
+		if a.HasCost != b.HasCost {
+			return a.HasCost
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		return lessP95(a, b)
+	case ConstraintOrderFastest:
+		if lessP95(a, b) || lessP95(b, a) {
+			return lessP95(a, b)
+		}
+		if a.HasCost != b.HasCost {
+			return a.HasCost
+		}
+		return a.Cost < b.Cost
+	case ConstraintOrderBest:
+		return a.Score > b.Score
+	case ConstraintOrderLeastLoaded:
+		return a.Load < b.Load
+	default:
+		return false
+	}
+}
+
+func lessP95(a, b ConstraintCandidate) bool {
+	pa, okA := a.EffectiveP95()
+	pb, okB := b.EffectiveP95()
+	if okA != okB {
+		return okA
+	}
+	return pa < pb
+}
+
+func compareFloat(have float64, op string, want float64) bool {
+	switch op {
+	case "<":
+		return have < want
+	case "<=":
+		return have <= want
+	case ">":
+		return have > want
+	case ">=":
+		return have >= want
+	case constraintOpEquals:
+		return math.Abs(have-want) < 1e-9
+	case "!=":
+		return math.Abs(have-want) >= 1e-9
+	default:
+		return false
+	}
+}
+
+func containsFold(values []string, want string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+type constraintToken struct {
+	text     string
+	quoted   bool
+	operator bool
+}
+
+func isConstraintOperatorRune(r rune) bool {
+	return r == '<' || r == '>' || r == '=' || r == '!'
+}
+
+func tokenizeConstraint(expr string) ([]constraintToken, error) {
+	var tokens []constraintToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote in constraint")
+			}
+			tokens = append(tokens, constraintToken{text: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		case isConstraintOperatorRune(r):
+			end := i + 1
+			for end < len(runes) && isConstraintOperatorRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, constraintToken{text: string(runes[i:end]), operator: true})
+			i = end
+		default:
+			end := i + 1
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != ',' && !isConstraintOperatorRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, constraintToken{text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
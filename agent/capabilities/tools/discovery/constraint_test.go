@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstraintNaturalForm(t *testing.T) {
+	query, err := ParseConstraint("cheapest agent with p95<2s supporting schema invoice.v1")
+	require.NoError(t, err)
+
+	assert.Equal(t, ConstraintOrderCheapest, query.Order)
+	require.Len(t, query.Conditions, 2)
+	assert.Equal(t, "p95", query.Conditions[0].Field)
+	assert.Equal(t, "<", query.Conditions[0].Op)
+	assert.Equal(t, "schema", query.Conditions[1].Field)
+	assert.Equal(t, "invoice.v1", query.Conditions[1].Value)
+	assert.Equal(t, `cheapest p95 < "2s" and schema = "invoice.v1"`, query.String())
+}
+
+func TestParseConstraintAliasesAndQuotes(t *testing.T) {
+	query, err := ParseConstraint(`price <= $0.02 and latency_class <= interactive, supports output schema "https://schemas.example.com/report.json" and tag != beta`)
+	require.NoError(t, err)
+
+	assert.Equal(t, ConstraintOrderNone, query.Order)
+	require.Len(t, query.Conditions, 4)
+	assert.Equal(t, "cost", query.Conditions[0].Field)
+	assert.Equal(t, "output_schema", query.Conditions[2].Field)
+	assert.Equal(t, "https://schemas.example.com/report.json", query.Conditions[2].Value)
+	assert.Equal(t, "!=", query.Conditions[3].Op)
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"colour = red",
+		"p95 < fast",
+		"cost < cheap",
+		"latency_class = warp",
+		"schema < invoice",
+		"p95 <",
+		"supporting schema",
+		`tag = "unterminated`,
+	} {
+		_, err := ParseConstraint(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestConstraintQueryMatches(t *testing.T) {
+	query, err := ParseConstraint("p95 < 2s and schema = invoice.v1 and cost <= 0.05 and currency = usd")
+	require.NoError(t, err)
+
+	candidate := ConstraintCandidate{
+		Cost:         0.03,
+		HasCost:      true,
+		Currency:     "USD",
+		P95Latency:   1500 * time.Millisecond,
+		InputSchemas: []string{"invoice.v1"},
+	}
+	assert.True(t, query.Matches(candidate))
+
+	slow := candidate
+	slow.P95Latency = 3 * time.Second
+	assert.False(t, query.Matches(slow))
+
+	unpriced := candidate
+	unpriced.HasCost = false
+	assert.False(t, query.Matches(unpriced), "unknown cost never satisfies a cost bound")
+
+	classOnly := candidate
+	classOnly.P95Latency = 0
+	classOnly.LatencyClass = LatencyClassRealtime
+	assert.True(t, query.Matches(classOnly), "latency class bound stands in for p95")
+}
+
+func TestConstraintQueryLatencyClassComparison(t *testing.T) {
+	query, err := ParseConstraint("latency_class <= interactive")
+	require.NoError(t, err)
+
+	assert.True(t, query.Matches(ConstraintCandidate{LatencyClass: LatencyClassRealtime}))
+	assert.True(t, query.Matches(ConstraintCandidate{P95Latency: time.Second}))
+	assert.False(t, query.Matches(ConstraintCandidate{LatencyClass: LatencyClassBatch}))
+	assert.False(t, query.Matches(ConstraintCandidate{}))
+}
+
+func TestConstraintQueryLess(t *testing.T) {
+	cheap := ConstraintCandidate{Cost: 0.01, HasCost: true, P95Latency: 3 * time.Second}
+	fast := ConstraintCandidate{Cost: 0.05, HasCost: true, P95Latency: 200 * time.Millisecond}
+	unknown := ConstraintCandidate{}
+
+	cheapest := &ConstraintQuery{Order: ConstraintOrderCheapest}
+	assert.True(t, cheapest.Less(cheap, fast))
+	assert.True(t, cheapest.Less(fast, unknown))
+
+	fastest := &ConstraintQuery{Order: ConstraintOrderFastest}
+	assert.True(t, fastest.Less(fast, cheap))
+	assert.True(t, fastest.Less(cheap, unknown))
+}
+
+func TestLatencyClassFor(t *testing.T) {
+	assert.Equal(t, "", LatencyClassFor(0))
+	assert.Equal(t, LatencyClassRealtime, LatencyClassFor(300*time.Millisecond))
+	assert.Equal(t, LatencyClassInteractive, LatencyClassFor(2*time.Second))
+	assert.Equal(t, LatencyClassStandard, LatencyClassFor(5*time.Second))
+	assert.Equal(t, LatencyClassBatch, LatencyClassFor(time.Minute))
+}
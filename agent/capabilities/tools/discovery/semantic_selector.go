@@ -0,0 +1,332 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ToolEmbedder 语义工具选择所需的向量化能力
+// llm/capabilities/embedding.Provider 满足该接口
+type ToolEmbedder interface {
+	EmbedQuery(ctx context.Context, query string) ([]float64, error)
+	EmbedDocuments(ctx context.Context, documents []string) ([][]float64, error)
+}
+
+// SemanticToolSelectorConfig 语义工具选择配置
+type SemanticToolSelectorConfig struct {
+	// TopK 每次查询最多选出的工具数（不含固定工具），默认 8
+	TopK int `json:"top_k"`
+	// MinSimilarity 低于该相似度的工具不会被选中，默认 0.2
+	MinSimilarity float64 `json:"min_similarity"`
+	// PinnedTools 始终下发的工具
+	PinnedTools []string `json:"pinned_tools,omitempty"`
+	// Categories 工具名到分类路径（如 "dev/github"）的映射，
+	// 未声明的工具按名称前缀归类
+	Categories map[string]string `json:"categories,omitempty"`
+}
+
+// DefaultSemanticToolSelectorConfig 返回默认语义工具选择配置
+func DefaultSemanticToolSelectorConfig() SemanticToolSelectorConfig {
+	return SemanticToolSelectorConfig{
+		TopK:          8,
+		MinSimilarity: 0.2,
+	}
+}
+
+// SemanticToolScore 单个工具与查询的相似度
+type SemanticToolScore struct {
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"`
+}
+
+// SemanticToolSelection 语义选择结果
+type SemanticToolSelection struct {
+	// Tools 为固定工具加上 Top-K 相关工具
+	Tools  []types.ToolSchema  `json:"tools"`
+	Scores []SemanticToolScore `json:"scores,omitempty"`
+	// Catalog 非 nil 表示语义选择不可用（无向量化能力、向量化失败或没有足够相关的工具），
+	// 调用方应让 LLM 通过分类目录自行查找工具
+	Catalog *ToolCategoryTree `json:"-"`
+}
+
+type toolEmbedding struct {
+	text   string
+	vector []float64
+}
+
+// SemanticToolSelector 对工具描述做向量化，按查询相似度选择 Top-K 工具。
+// 工具向量按名称缓存，描述变化时重新计算。
+type SemanticToolSelector struct {
+	embedder ToolEmbedder
+	config   SemanticToolSelectorConfig
+
+	mu    sync.Mutex
+	cache map[string]toolEmbedding
+}
+
+// NewSemanticToolSelector 创建语义工具选择器，embedder 为 nil 时总是退化为分类目录
+func NewSemanticToolSelector(embedder ToolEmbedder, config SemanticToolSelectorConfig) *SemanticToolSelector {
+	defaults := DefaultSemanticToolSelectorConfig()
+	if config.TopK <= 0 {
+		config.TopK = defaults.TopK
+	}
+	if config.MinSimilarity <= 0 {
+		config.MinSimilarity = defaults.MinSimilarity
+	}
+	return &SemanticToolSelector{
+		embedder: embedder,
+		config:   config,
+		cache:    make(map[string]toolEmbedding),
+	}
+}
+
+// Config 返回生效的配置
+func (s *SemanticToolSelector) Config() SemanticToolSelectorConfig {
+	return s.config
+}
+
+// Select 为查询选择工具。可用工具不超过 TopK 加固定工具数时直接全部返回。
+func (s *SemanticToolSelector) Select(ctx context.Context, query string, tools []types.ToolSchema) (*SemanticToolSelection, error) {
+	pinned := make(map[string]struct{}, len(s.config.PinnedTools))
+	for _, name := range s.config.PinnedTools {
+		pinned[name] = struct{}{}
+	}
+	selection := &SemanticToolSelection{}
+	candidates := make([]types.ToolSchema, 0, len(tools))
+	for _, tool := range tools {
+		if _, ok := pinned[tool.Name]; ok {
+			selection.Tools = append(selection.Tools, tool)
+			continue
+		}
+		candidates = append(candidates, tool)
+	}
+	if len(candidates) <= s.config.TopK {
+		selection.Tools = append(selection.Tools, candidates...)
+		return selection, nil
+	}
+
+	fallback := func() (*SemanticToolSelection, error) {
+		selection.Catalog = BuildToolCategoryTree(candidates, s.config.Categories)
+		return selection, nil
+	}
+	if s.embedder == nil || strings.TrimSpace(query) == "" {
+		return fallback()
+	}
+	vectors, err := s.embedTools(ctx, candidates)
+	if err != nil {
+		return fallback()
+	}
+	queryVector, err := s.embedder.EmbedQuery(ctx, query)
+	if err != nil || len(queryVector) == 0 {
+		return fallback()
+	}
+
+	scores := make([]SemanticToolScore, 0, len(candidates))
+	byName := make(map[string]types.ToolSchema, len(candidates))
+	for i, tool := range candidates {
+		byName[tool.Name] = tool
+		scores = append(scores, SemanticToolScore{Name: tool.Name, Similarity: cosineSimilarity(queryVector, vectors[i])})
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Similarity > scores[j].Similarity })
+	selection.Scores = scores
+
+	selected := 0
+	for _, score := range scores {
+		if selected >= s.config.TopK || score.Similarity < s.config.MinSimilarity {
+			break
+		}
+		selection.Tools = append(selection.Tools, byName[score.Name])
+		selected++
+	}
+	if selected == 0 {
+		return fallback()
+	}
+	return selection, nil
+}
+
+// embedTools 返回与 tools 一一对应的向量，只对新增或描述变化的工具调用向量化
+func (s *SemanticToolSelector) embedTools(ctx context.Context, tools []types.ToolSchema) ([][]float64, error) {
+	texts := make([]string, len(tools))
+	vectors := make([][]float64, len(tools))
+	var missing []int
+
+	s.mu.Lock()
+	for i, tool := range tools {
+		texts[i] = toolEmbeddingText(tool)
+		if cached, ok := s.cache[tool.Name]; ok && cached.text == texts[i] {
+			vectors[i] = cached.vector
+			continue
+		}
+		missing = append(missing, i)
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	documents := make([]string, len(missing))
+	for j, i := range missing {
+		documents[j] = texts[i]
+	}
+	embedded, err := s.embedder.EmbedDocuments(ctx, documents)
+	if err != nil {
+		return nil, fmt.Errorf("embed tool descriptions: %w", err)
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embed tool descriptions: expected %d vectors, got %d", len(missing), len(embedded))
+	}
+
+	s.mu.Lock()
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+		s.cache[tools[i].Name] = toolEmbedding{text: texts[i], vector: embedded[j]}
+	}
+	s.mu.Unlock()
+	return vectors, nil
+}
+
+func toolEmbeddingText(tool types.ToolSchema) string {
+	if tool.Description == "" {
+		return tool.Name
+	}
+	return tool.Name + ": " + tool.Description
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// defaultToolCategory 无法从名称推断分类时使用的分类
+const defaultToolCategory = "general"
+
+// ToolCategoryOf 返回工具的分类路径：优先使用显式映射，否则取名称中第一个分隔符前的前缀
+func ToolCategoryOf(name string, categories map[string]string) string {
+	if category := strings.Trim(categories[name], "/ "); category != "" {
+		return category
+	}
+	if idx := strings.IndexAny(name, "._-/:"); idx > 0 {
+		return strings.ToLower(name[:idx])
+	}
+	return defaultToolCategory
+}
+
+// ToolCategoryTree 是按分类路径组织的工具层级目录，供 LLM 逐级浏览
+type ToolCategoryTree struct {
+	root  *toolCategoryNode
+	tools map[string]types.ToolSchema
+}
+
+type toolCategoryNode struct {
+	path     string
+	children map[string]*toolCategoryNode
+	tools    []string
+	total    int
+}
+
+// ToolCategorySummary 子分类摘要
+type ToolCategorySummary struct {
+	Path      string `json:"path"`
+	ToolCount int    `json:"tool_count"`
+}
+
+// ToolSummary 工具摘要
+type ToolSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ToolCategoryView 某一分类下的子分类与直属工具
+type ToolCategoryView struct {
+	Path       string                `json:"path"`
+	Categories []ToolCategorySummary `json:"categories,omitempty"`
+	Tools      []ToolSummary         `json:"tools,omitempty"`
+}
+
+// BuildToolCategoryTree 按分类路径构建工具目录
+func BuildToolCategoryTree(tools []types.ToolSchema, categories map[string]string) *ToolCategoryTree {
+	tree := &ToolCategoryTree{
+		root:  &toolCategoryNode{children: make(map[string]*toolCategoryNode)},
+		tools: make(map[string]types.ToolSchema, len(tools)),
+	}
+	for _, tool := range tools {
+		if tool.Name == "" {
+			continue
+		}
+		tree.tools[tool.Name] = tool
+		node := tree.root
+		node.total++
+		for _, segment := range strings.Split(ToolCategoryOf(tool.Name, categories), "/") {
+			if segment = strings.TrimSpace(segment); segment == "" {
+				continue
+			}
+			child, ok := node.children[segment]
+			if !ok {
+				path := segment
+				if node.path != "" {
+					path = node.path + "/" + segment
+				}
+				child = &toolCategoryNode{path: path, children: make(map[string]*toolCategoryNode)}
+				node.children[segment] = child
+			}
+			node = child
+			node.total++
+		}
+		node.tools = append(node.tools, tool.Name)
+	}
+	return tree
+}
+
+// Browse 返回分类路径下的子分类与直属工具，空路径表示根目录
+func (t *ToolCategoryTree) Browse(path string) (ToolCategoryView, error) {
+	node := t.root
+	for _, segment := range strings.Split(strings.Trim(path, "/ "), "/") {
+		if segment = strings.TrimSpace(segment); segment == "" {
+			continue
+		}
+		child, ok := node.children[segment]
+		if !ok {
+			return ToolCategoryView{}, fmt.Errorf("unknown tool category %q", path)
+		}
+		node = child
+	}
+
+	view := ToolCategoryView{Path: node.path}
+	for _, child := range node.children {
+		view.Categories = append(view.Categories, ToolCategorySummary{Path: child.path, ToolCount: child.total})
+	}
+	sort.Slice(view.Categories, func(i, j int) bool { return view.Categories[i].Path < view.Categories[j].Path })
+	for _, name := range node.tools {
+		view.Tools = append(view.Tools, ToolSummary{Name: name, Description: t.tools[name].Description})
+	}
+	sort.Slice(view.Tools, func(i, j int) bool { return view.Tools[i].Name < view.Tools[j].Name })
+	return view, nil
+}
+
+// Tool 按名称查找目录中的工具
+func (t *ToolCategoryTree) Tool(name string) (types.ToolSchema, bool) {
+	tool, ok := t.tools[name]
+	return tool, ok
+}
+
+// Len 返回目录中的工具数量
+func (t *ToolCategoryTree) Len() int {
+	return len(t.tools)
+}
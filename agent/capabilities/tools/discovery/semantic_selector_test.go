@@ -0,0 +1,173 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder 按固定关键词维度生成向量，便于断言相似度排序
+type keywordEmbedder struct {
+	dims      []string
+	docCalls  int
+	docsTotal int
+	err       error
+}
+
+func (e *keywordEmbedder) vector(text string) []float64 {
+	text = strings.ToLower(text)
+	vec := make([]float64, len(e.dims))
+	for i, dim := range e.dims {
+		if strings.Contains(text, dim) {
+			vec[i] = 1
+		}
+	}
+	return vec
+}
+
+func (e *keywordEmbedder) EmbedQuery(_ context.Context, query string) ([]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.vector(query), nil
+}
+
+func (e *keywordEmbedder) EmbedDocuments(_ context.Context, documents []string) ([][]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.docCalls++
+	e.docsTotal += len(documents)
+	out := make([][]float64, len(documents))
+	for i, doc := range documents {
+		out[i] = e.vector(doc)
+	}
+	return out, nil
+}
+
+func semanticTestTools() []types.ToolSchema {
+	return []types.ToolSchema{
+		{Name: "github_create_issue", Description: "Create an issue in a repository"},
+		{Name: "github_list_pulls", Description: "List pull requests of a repository"},
+		{Name: "weather_forecast", Description: "Get the weather forecast for a city"},
+		{Name: "calendar_create_event", Description: "Create a calendar event"},
+		{Name: "fs_read", Description: "Read a file from disk"},
+		{Name: "fs_write", Description: "Write a file to disk"},
+	}
+}
+
+func toolNames(tools []types.ToolSchema) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestSemanticToolSelector_SelectsTopKWithPins(t *testing.T) {
+	embedder := &keywordEmbedder{dims: []string{"weather", "issue", "file", "calendar"}}
+	selector := NewSemanticToolSelector(embedder, SemanticToolSelectorConfig{
+		TopK:        1,
+		PinnedTools: []string{"fs_read"},
+	})
+
+	selection, err := selector.Select(context.Background(), "what is the weather in Paris", semanticTestTools())
+	require.NoError(t, err)
+	assert.Nil(t, selection.Catalog)
+	assert.Equal(t, []string{"fs_read", "weather_forecast"}, toolNames(selection.Tools))
+	require.NotEmpty(t, selection.Scores)
+	assert.Equal(t, "weather_forecast", selection.Scores[0].Name)
+}
+
+func TestSemanticToolSelector_CachesToolEmbeddings(t *testing.T) {
+	embedder := &keywordEmbedder{dims: []string{"weather", "issue"}}
+	selector := NewSemanticToolSelector(embedder, SemanticToolSelectorConfig{TopK: 2})
+	tools := semanticTestTools()
+
+	_, err := selector.Select(context.Background(), "weather", tools)
+	require.NoError(t, err)
+	_, err = selector.Select(context.Background(), "issue", tools)
+	require.NoError(t, err)
+	assert.Equal(t, 1, embedder.docCalls)
+
+	tools[0].Description = "Open a new issue"
+	_, err = selector.Select(context.Background(), "issue", tools)
+	require.NoError(t, err)
+	assert.Equal(t, 2, embedder.docCalls)
+	assert.Equal(t, len(tools)+1, embedder.docsTotal)
+}
+
+func TestSemanticToolSelector_SmallToolSetReturnsAll(t *testing.T) {
+	selector := NewSemanticToolSelector(nil, SemanticToolSelectorConfig{TopK: 10})
+	selection, err := selector.Select(context.Background(), "anything", semanticTestTools())
+	require.NoError(t, err)
+	assert.Nil(t, selection.Catalog)
+	assert.Len(t, selection.Tools, len(semanticTestTools()))
+}
+
+func TestSemanticToolSelector_FallsBackToCatalog(t *testing.T) {
+	cases := map[string]ToolEmbedder{
+		"no embedder":  nil,
+		"embed error":  &keywordEmbedder{dims: []string{"x"}, err: errors.New("boom")},
+		"no relevance": &keywordEmbedder{dims: []string{"stock"}},
+	}
+	for name, embedder := range cases {
+		t.Run(name, func(t *testing.T) {
+			selector := NewSemanticToolSelector(embedder, SemanticToolSelectorConfig{
+				TopK:        2,
+				PinnedTools: []string{"fs_read"},
+			})
+			selection, err := selector.Select(context.Background(), "weather today", semanticTestTools())
+			require.NoError(t, err)
+			require.NotNil(t, selection.Catalog)
+			assert.Equal(t, []string{"fs_read"}, toolNames(selection.Tools))
+			assert.Equal(t, 5, selection.Catalog.Len())
+		})
+	}
+}
+
+func TestToolCategoryTree_Browse(t *testing.T) {
+	tree := BuildToolCategoryTree(semanticTestTools(), map[string]string{
+		"github_create_issue": "dev/github",
+		"github_list_pulls":   "dev/github",
+	})
+
+	root, err := tree.Browse("")
+	require.NoError(t, err)
+	assert.Equal(t, []ToolCategorySummary{
+		{Path: "calendar", ToolCount: 1},
+		{Path: "dev", ToolCount: 2},
+		{Path: "fs", ToolCount: 2},
+		{Path: "weather", ToolCount: 1},
+	}, root.Categories)
+	assert.Empty(t, root.Tools)
+
+	dev, err := tree.Browse("dev")
+	require.NoError(t, err)
+	assert.Equal(t, []ToolCategorySummary{{Path: "dev/github", ToolCount: 2}}, dev.Categories)
+
+	github, err := tree.Browse("/dev/github/")
+	require.NoError(t, err)
+	assert.Equal(t, []ToolSummary{
+		{Name: "github_create_issue", Description: "Create an issue in a repository"},
+		{Name: "github_list_pulls", Description: "List pull requests of a repository"},
+	}, github.Tools)
+
+	_, err = tree.Browse("missing")
+	assert.Error(t, err)
+
+	tool, ok := tree.Tool("fs_write")
+	assert.True(t, ok)
+	assert.Equal(t, "Write a file to disk", tool.Description)
+}
+
+func TestToolCategoryOf(t *testing.T) {
+	assert.Equal(t, "dev/github", ToolCategoryOf("gh", map[string]string{"gh": "/dev/github/"}))
+	assert.Equal(t, "github", ToolCategoryOf("GitHub.create", nil))
+	assert.Equal(t, "general", ToolCategoryOf("calculator", nil))
+}
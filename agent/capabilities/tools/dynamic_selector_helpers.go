@@ -10,11 +10,26 @@ import (
 type DynamicToolScore = tooldiscovery.DynamicToolScore
 type DynamicToolSelectionConfig = tooldiscovery.DynamicToolSelectionConfig
 type DynamicToolStats = tooldiscovery.DynamicToolStats
+type ToolEmbedder = tooldiscovery.ToolEmbedder
+type SemanticToolSelector = tooldiscovery.SemanticToolSelector
+type SemanticToolSelectorConfig = tooldiscovery.SemanticToolSelectorConfig
+type SemanticToolSelection = tooldiscovery.SemanticToolSelection
+type SemanticToolScore = tooldiscovery.SemanticToolScore
+type ToolCategoryTree = tooldiscovery.ToolCategoryTree
+type ToolCategoryView = tooldiscovery.ToolCategoryView
 
 func DefaultDynamicToolSelectionConfig() DynamicToolSelectionConfig {
 	return tooldiscovery.DefaultDynamicToolSelectionConfig()
 }
 
+func DefaultSemanticToolSelectorConfig() SemanticToolSelectorConfig {
+	return tooldiscovery.DefaultSemanticToolSelectorConfig()
+}
+
+func NewSemanticToolSelector(embedder ToolEmbedder, config SemanticToolSelectorConfig) *SemanticToolSelector {
+	return tooldiscovery.NewSemanticToolSelector(embedder, config)
+}
+
 func DynamicToolSemanticSimilarity(task string, tool types.ToolSchema) float64 {
 	return tooldiscovery.DynamicToolSemanticSimilarity(task, tool)
 }
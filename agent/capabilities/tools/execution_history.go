@@ -0,0 +1,351 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExecutionHistoryConfig 配置执行历史的衰减与延迟采样。
+type ExecutionHistoryConfig struct {
+	// HalfLife 是成功率衰减的半衰期，越久远的执行权重越低。
+	HalfLife time.Duration `json:"half_life"`
+
+	// MaxLatencySamples 是每个 (agent, capability) 保留的最近延迟样本数，用于计算分位数。
+	MaxLatencySamples int `json:"max_latency_samples"`
+
+	// LatencyTarget 是期望的 p95 延迟，超过时按比例降低质量分。
+	LatencyTarget time.Duration `json:"latency_target"`
+
+	// MinSamples 是质量分可信所需的最少有效样本数，不足时向 PriorQuality 收缩。
+	MinSamples float64 `json:"min_samples"`
+
+	// PriorQuality 是没有历史时的先验质量 (0-1)。
+	PriorQuality float64 `json:"prior_quality"`
+}
+
+// DefaultExecutionHistoryConfig 返回带有合理默认值的执行历史配置。
+func DefaultExecutionHistoryConfig() *ExecutionHistoryConfig {
+	return &ExecutionHistoryConfig{
+		HalfLife:          time.Hour,
+		MaxLatencySamples: 256,
+		LatencyTarget:     time.Second,
+		MinSamples:        5,
+		PriorQuality:      0.5,
+	}
+}
+
+// ExecutionStats 是单个 (agent, capability) 的可持久化执行统计。
+// 衰减量以 UpdatedAt 为基准，读取时再按经过的时间继续衰减。
+type ExecutionStats struct {
+	AgentID        string          `json:"agent_id"`
+	Capability     string          `json:"capability"`
+	SuccessCount   int64           `json:"success_count"`
+	FailureCount   int64           `json:"failure_count"`
+	DecayedSuccess float64         `json:"decayed_success"`
+	DecayedTotal   float64         `json:"decayed_total"`
+	LatencySamples []time.Duration `json:"latency_samples,omitempty"`
+	LastExecutedAt time.Time       `json:"last_executed_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	LastSuccess    bool            `json:"last_success"`
+}
+
+// ExecutionHistoryStore 持久化执行统计，使重启后评分不丢失。
+type ExecutionHistoryStore interface {
+	SaveStats(ctx context.Context, stats *ExecutionStats) error
+	LoadStats(ctx context.Context) ([]*ExecutionStats, error)
+	DeleteAgent(ctx context.Context, agentID string) error
+}
+
+// InMemoryExecutionHistoryStore 是基于内存 map 的 ExecutionHistoryStore。
+type InMemoryExecutionHistoryStore struct {
+	mu    sync.RWMutex
+	stats map[string]*ExecutionStats
+}
+
+// NewInMemoryExecutionHistoryStore 创建内存执行历史存储。
+func NewInMemoryExecutionHistoryStore() *InMemoryExecutionHistoryStore {
+	return &InMemoryExecutionHistoryStore{stats: make(map[string]*ExecutionStats)}
+}
+
+func (s *InMemoryExecutionHistoryStore) SaveStats(_ context.Context, stats *ExecutionStats) error {
+	if stats == nil || stats.AgentID == "" || stats.Capability == "" {
+		return fmt.Errorf("invalid execution stats")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats[historyKey(stats.AgentID, stats.Capability)] = stats.clone()
+	return nil
+}
+
+func (s *InMemoryExecutionHistoryStore) LoadStats(_ context.Context) ([]*ExecutionStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*ExecutionStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		out = append(out, st.clone())
+	}
+	return out, nil
+}
+
+func (s *InMemoryExecutionHistoryStore) DeleteAgent(_ context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, st := range s.stats {
+		if st.AgentID == agentID {
+			delete(s.stats, key)
+		}
+	}
+	return nil
+}
+
+var _ ExecutionHistoryStore = (*InMemoryExecutionHistoryStore)(nil)
+
+// CapabilityScorecard 是某个能力基于执行历史的评分明细。
+type CapabilityScorecard struct {
+	Capability         string        `json:"capability"`
+	SuccessCount       int64         `json:"success_count"`
+	FailureCount       int64         `json:"failure_count"`
+	DecayedSuccessRate float64       `json:"decayed_success_rate"`
+	EffectiveSamples   float64       `json:"effective_samples"`
+	LatencyP50         time.Duration `json:"latency_p50"`
+	LatencyP95         time.Duration `json:"latency_p95"`
+	LatencyP99         time.Duration `json:"latency_p99"`
+	// Quality 综合衰减成功率与 p95 延迟 (0-1)，样本不足时向先验收缩。
+	Quality        float64   `json:"quality"`
+	LastExecutedAt time.Time `json:"last_executed_at"`
+}
+
+// AgentScorecard 汇总代理各能力的历史评分。
+type AgentScorecard struct {
+	AgentID      string                `json:"agent_id"`
+	Capabilities []CapabilityScorecard `json:"capabilities"`
+	// Quality 是按有效样本数加权的能力质量均值。
+	Quality     float64   `json:"quality"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Capability 返回指定能力的评分，不存在时返回 nil。
+func (s *AgentScorecard) Capability(name string) *CapabilityScorecard {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Capabilities {
+		if s.Capabilities[i].Capability == name {
+			return &s.Capabilities[i]
+		}
+	}
+	return nil
+}
+
+// ScorecardProvider 由能提供执行历史评分的注册表实现，匹配器据此使用 MatchStrategyBestHistorical。
+type ScorecardProvider interface {
+	Scorecard(ctx context.Context, agentID string) (*AgentScorecard, error)
+}
+
+// ExecutionHistory 按 (agent, capability) 记录执行结果，维护时间衰减的成功率与延迟分位数。
+type ExecutionHistory struct {
+	mu     sync.RWMutex
+	config *ExecutionHistoryConfig
+	store  ExecutionHistoryStore
+	stats  map[string]*ExecutionStats
+	now    func() time.Time
+}
+
+// NewExecutionHistory 创建执行历史；store 为空时仅保存在内存中。
+func NewExecutionHistory(config *ExecutionHistoryConfig, store ExecutionHistoryStore) *ExecutionHistory {
+	if config == nil {
+		config = DefaultExecutionHistoryConfig()
+	}
+	return &ExecutionHistory{
+		config: config,
+		store:  store,
+		stats:  make(map[string]*ExecutionStats),
+		now:    time.Now,
+	}
+}
+
+// Restore 从存储加载已持久化的统计。
+func (h *ExecutionHistory) Restore(ctx context.Context) error {
+	if h.store == nil {
+		return nil
+	}
+	loaded, err := h.store.LoadStats(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load execution history: %w", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, st := range loaded {
+		if st == nil || st.AgentID == "" || st.Capability == "" {
+			continue
+		}
+		h.stats[historyKey(st.AgentID, st.Capability)] = st.clone()
+	}
+	return nil
+}
+
+// Record 记录一次执行结果并写入存储。
+func (h *ExecutionHistory) Record(ctx context.Context, agentID, capability string, success bool, latency time.Duration) error {
+	now := h.now()
+
+	h.mu.Lock()
+	key := historyKey(agentID, capability)
+	st, ok := h.stats[key]
+	if !ok {
+		st = &ExecutionStats{AgentID: agentID, Capability: capability, UpdatedAt: now}
+		h.stats[key] = st
+	}
+	factor := h.decayFactor(st.UpdatedAt, now)
+	st.DecayedSuccess *= factor
+	st.DecayedTotal *= factor
+	st.DecayedTotal++
+	if success {
+		st.SuccessCount++
+		st.DecayedSuccess++
+	} else {
+		st.FailureCount++
+	}
+	st.LastSuccess = success
+	st.LastExecutedAt = now
+	st.UpdatedAt = now
+
+	if limit := h.config.MaxLatencySamples; limit > 0 {
+		st.LatencySamples = append(st.LatencySamples, latency)
+		if len(st.LatencySamples) > limit {
+			st.LatencySamples = append(st.LatencySamples[:0:0], st.LatencySamples[len(st.LatencySamples)-limit:]...)
+		}
+	}
+	snapshot := st.clone()
+	h.mu.Unlock()
+
+	if h.store != nil {
+		if err := h.store.SaveStats(ctx, snapshot); err != nil {
+			return fmt.Errorf("failed to persist execution history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Forget 删除代理的全部历史。
+func (h *ExecutionHistory) Forget(ctx context.Context, agentID string) error {
+	h.mu.Lock()
+	for key, st := range h.stats {
+		if st.AgentID == agentID {
+			delete(h.stats, key)
+		}
+	}
+	h.mu.Unlock()
+
+	if h.store != nil {
+		return h.store.DeleteAgent(ctx, agentID)
+	}
+	return nil
+}
+
+// CapabilityScorecard 返回单个能力的评分；没有历史时返回 false。
+func (h *ExecutionHistory) CapabilityScorecard(agentID, capability string) (CapabilityScorecard, bool) {
+	now := h.now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	st, ok := h.stats[historyKey(agentID, capability)]
+	if !ok {
+		return CapabilityScorecard{}, false
+	}
+	return h.scorecardFor(st, now), true
+}
+
+// Scorecard 返回代理全部能力的评分，能力按名称排序。
+func (h *ExecutionHistory) Scorecard(agentID string) *AgentScorecard {
+	now := h.now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	card := &AgentScorecard{AgentID: agentID, GeneratedAt: now}
+	var weighted, weights float64
+	for _, st := range h.stats {
+		if st.AgentID != agentID {
+			continue
+		}
+		cs := h.scorecardFor(st, now)
+		card.Capabilities = append(card.Capabilities, cs)
+		w := math.Max(cs.EffectiveSamples, 1e-9)
+		weighted += cs.Quality * w
+		weights += w
+	}
+	sort.Slice(card.Capabilities, func(i, j int) bool {
+		return card.Capabilities[i].Capability < card.Capabilities[j].Capability
+	})
+	if weights > 0 {
+		card.Quality = weighted / weights
+	} else {
+		card.Quality = h.config.PriorQuality
+	}
+	return card
+}
+
+func (h *ExecutionHistory) scorecardFor(st *ExecutionStats, now time.Time) CapabilityScorecard {
+	cs := CapabilityScorecard{
+		Capability:       st.Capability,
+		SuccessCount:     st.SuccessCount,
+		FailureCount:     st.FailureCount,
+		EffectiveSamples: st.DecayedTotal * h.decayFactor(st.UpdatedAt, now),
+		LastExecutedAt:   st.LastExecutedAt,
+	}
+	// 成功率是比值，读取时的整体衰减不改变它，只影响有效样本数
+	if st.DecayedTotal > 0 {
+		cs.DecayedSuccessRate = st.DecayedSuccess / st.DecayedTotal
+	}
+	if len(st.LatencySamples) > 0 {
+		sorted := append([]time.Duration(nil), st.LatencySamples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		cs.LatencyP50 = latencyPercentile(sorted, 0.50)
+		cs.LatencyP95 = latencyPercentile(sorted, 0.95)
+		cs.LatencyP99 = latencyPercentile(sorted, 0.99)
+	}
+
+	quality := cs.DecayedSuccessRate
+	if target := h.config.LatencyTarget; target > 0 && cs.LatencyP95 > target {
+		quality *= float64(target) / float64(cs.LatencyP95)
+	}
+	// 有效样本不足时向先验收缩，避免一两次成功就排到最前
+	if h.config.MinSamples > 0 && cs.EffectiveSamples < h.config.MinSamples {
+		confidence := cs.EffectiveSamples / h.config.MinSamples
+		quality = quality*confidence + h.config.PriorQuality*(1-confidence)
+	}
+	cs.Quality = quality
+	return cs
+}
+
+// decayFactor 返回从 since 到 now 的指数衰减系数。
+func (h *ExecutionHistory) decayFactor(since, now time.Time) float64 {
+	if h.config.HalfLife <= 0 || !now.After(since) {
+		return 1
+	}
+	return math.Pow(0.5, float64(now.Sub(since))/float64(h.config.HalfLife))
+}
+
+// latencyPercentile 在已排序样本上按最近秩法取分位数。
+func latencyPercentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func historyKey(agentID, capability string) string {
+	return agentID + "\x00" + capability
+}
+
+func (s *ExecutionStats) clone() *ExecutionStats {
+	out := *s
+	out.LatencySamples = append([]time.Duration(nil), s.LatencySamples...)
+	return &out
+}
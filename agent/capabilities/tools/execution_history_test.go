@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestHistory(store ExecutionHistoryStore) (*ExecutionHistory, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := NewExecutionHistory(&ExecutionHistoryConfig{
+		HalfLife:          time.Hour,
+		MaxLatencySamples: 100,
+		LatencyTarget:     time.Second,
+	}, store)
+	h.now = clock.now
+	return h, clock
+}
+
+func TestExecutionHistory_DecayFavorsRecentOutcomes(t *testing.T) {
+	h, clock := newTestHistory(nil)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, h.Record(ctx, "a", "search", false, 100*time.Millisecond))
+	}
+	// 十个半衰期后旧失败几乎不再计权
+	clock.t = clock.t.Add(10 * time.Hour)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, h.Record(ctx, "a", "search", true, 100*time.Millisecond))
+	}
+
+	card, ok := h.CapabilityScorecard("a", "search")
+	require.True(t, ok)
+	assert.Equal(t, int64(10), card.SuccessCount)
+	assert.Equal(t, int64(10), card.FailureCount)
+	assert.Greater(t, card.DecayedSuccessRate, 0.99)
+
+	// 读取时继续衰减有效样本数，但不改变成功率
+	clock.t = clock.t.Add(time.Hour)
+	later, _ := h.CapabilityScorecard("a", "search")
+	assert.InDelta(t, card.EffectiveSamples/2, later.EffectiveSamples, 1e-6)
+	assert.InDelta(t, card.DecayedSuccessRate, later.DecayedSuccessRate, 1e-9)
+}
+
+func TestExecutionHistory_LatencyPercentilesAndQuality(t *testing.T) {
+	h, _ := newTestHistory(nil)
+	ctx := context.Background()
+	for i := 1; i <= 100; i++ {
+		require.NoError(t, h.Record(ctx, "a", "search", true, time.Duration(i)*20*time.Millisecond))
+	}
+
+	card, ok := h.CapabilityScorecard("a", "search")
+	require.True(t, ok)
+	assert.Equal(t, 1000*time.Millisecond, card.LatencyP50)
+	assert.Equal(t, 1900*time.Millisecond, card.LatencyP95)
+	assert.Equal(t, 1980*time.Millisecond, card.LatencyP99)
+	// p95 超过目标时按比例降低质量
+	assert.InDelta(t, 1.0/1.9, card.Quality, 1e-6)
+}
+
+func TestExecutionHistory_ShrinksTowardPriorWithFewSamples(t *testing.T) {
+	h := NewExecutionHistory(nil, nil)
+	require.NoError(t, h.Record(context.Background(), "a", "search", true, time.Millisecond))
+
+	card, _ := h.CapabilityScorecard("a", "search")
+	assert.InDelta(t, 1*0.2+0.5*0.8, card.Quality, 1e-6)
+
+	empty := h.Scorecard("missing")
+	assert.Empty(t, empty.Capabilities)
+	assert.Equal(t, 0.5, empty.Quality)
+}
+
+func TestExecutionHistory_PersistsAndRestores(t *testing.T) {
+	store := NewInMemoryExecutionHistoryStore()
+	h, _ := newTestHistory(store)
+	ctx := context.Background()
+	require.NoError(t, h.Record(ctx, "a", "search", true, 50*time.Millisecond))
+	require.NoError(t, h.Record(ctx, "a", "write", false, 70*time.Millisecond))
+
+	restored, _ := newTestHistory(store)
+	require.NoError(t, restored.Restore(ctx))
+	card := restored.Scorecard("a")
+	require.Len(t, card.Capabilities, 2)
+	assert.Equal(t, "search", card.Capabilities[0].Capability)
+	assert.Equal(t, int64(1), card.Capability("write").FailureCount)
+
+	require.NoError(t, restored.Forget(ctx, "a"))
+	assert.Empty(t, restored.Scorecard("a").Capabilities)
+	loaded, err := store.LoadStats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+}
+
+func TestCapabilityRegistry_Scorecard(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerMarketplaceAgent(t, reg, "agent-a", 0, nil, nil)
+	ctx := context.Background()
+
+	card, err := reg.Scorecard(ctx, "agent-a")
+	require.NoError(t, err)
+	assert.Empty(t, card.Capabilities)
+
+	require.NoError(t, reg.RecordExecution(ctx, "agent-a", "extract", true, 10*time.Millisecond))
+	card, err = reg.Scorecard(ctx, "agent-a")
+	require.NoError(t, err)
+	require.NotNil(t, card.Capability("extract"))
+	assert.Equal(t, int64(1), card.Capability("extract").SuccessCount)
+
+	// 注销后历史保留
+	require.NoError(t, reg.UnregisterAgent(ctx, "agent-a"))
+	_, err = reg.Scorecard(ctx, "agent-a")
+	assert.NoError(t, err)
+
+	_, err = reg.Scorecard(ctx, "ghost")
+	assert.Error(t, err)
+}
+
+func TestCapabilityMatcher_BestHistorical(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerMarketplaceAgent(t, reg, "flaky", 0, nil, &MarketplaceInfo{Pricing: &CapabilityPricing{PerCall: 0.01}})
+	registerMarketplaceAgent(t, reg, "reliable", 0, nil, &MarketplaceInfo{Pricing: &CapabilityPricing{PerCall: 0.02}})
+	registerMarketplaceAgent(t, reg, "busy-reliable", 0.9, nil, &MarketplaceInfo{Pricing: &CapabilityPricing{PerCall: 0.02}})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, reg.RecordExecution(ctx, "flaky", "extract", i%3 == 0, 100*time.Millisecond))
+		require.NoError(t, reg.RecordExecution(ctx, "reliable", "extract", true, 100*time.Millisecond))
+		require.NoError(t, reg.RecordExecution(ctx, "busy-reliable", "extract", true, 100*time.Millisecond))
+	}
+
+	matcher := NewCapabilityMatcher(reg, nil, zap.NewNop())
+	results, err := matcher.Match(ctx, &MatchRequest{
+		RequiredCapabilities: []string{"extract"},
+		Strategy:             MatchStrategyBestHistorical,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "reliable", results[0].Agent.Card.Name)
+	assert.Equal(t, "busy-reliable", results[1].Agent.Card.Name)
+	assert.Equal(t, "flaky", results[2].Agent.Card.Name)
+	assert.InDelta(t, 1.0, results[0].HistoricalQuality, 1e-6)
+	assert.InDelta(t, 0.02, results[0].EstimatedCost, 1e-9)
+	assert.Contains(t, results[0].Reason, "historical quality")
+}
@@ -0,0 +1,692 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	"go.uber.org/zap"
+)
+
+const (
+	// DiscoverySourceFederation 表示 Agent 信息来自对端注册中心的联邦同步
+	DiscoverySourceFederation = "federation"
+
+	// MetadataOriginRegistry 记录 Agent 最初注册所在的注册中心 ID
+	MetadataOriginRegistry = "origin_registry"
+	// MetadataOriginRegion 记录 Agent 所在注册中心的区域
+	MetadataOriginRegion = "origin_region"
+	// MetadataFederationHops 记录摘要从源注册中心到本地经过的跳数
+	MetadataFederationHops = "federation_hops"
+	// MetadataFederationPeer 记录本地从哪个对端学到该 Agent
+	MetadataFederationPeer = "federation_peer"
+
+	federationSnapshotPath = "/federation/snapshot"
+	federationChangesPath  = "/federation/changes"
+	maxFederationBodyBytes = 8 << 20 // 8 MiB
+
+	// federationChangeBuffer 是待推送变更的缓冲上限，溢出的变更由周期同步补齐
+	federationChangeBuffer = 256
+)
+
+// RegistryPeer 描述一个对等的注册中心。
+type RegistryPeer struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region,omitempty"`
+}
+
+// FederationConfig 配置注册中心联邦。
+type FederationConfig struct {
+	// RegistryID 是本注册中心在联邦中的唯一标识，用于来源标记与环路检测。
+	RegistryID string `json:"registry_id"`
+
+	// Region 是本注册中心所在区域，用于就近匹配。
+	Region string `json:"region,omitempty"`
+
+	// Peers 是周期拉取快照并推送变更的对端列表。
+	Peers []RegistryPeer `json:"peers,omitempty"`
+
+	// SyncInterval 是全量快照同步间隔。
+	SyncInterval time.Duration `json:"sync_interval"`
+
+	// MaxHops 是 Agent 摘要最多被转发的跳数，1 表示只接收对端自身的 Agent。
+	MaxHops int `json:"max_hops"`
+
+	// StaleAfter 是对端持续不可达多久后将其 Agent 标记为离线。
+	StaleAfter time.Duration `json:"stale_after"`
+
+	// RequestTimeout 是单次对端请求的超时。
+	RequestTimeout time.Duration `json:"request_timeout"`
+}
+
+// DefaultFederationConfig 返回默认的联邦配置。
+func DefaultFederationConfig() FederationConfig {
+	return FederationConfig{
+		SyncInterval:   30 * time.Second,
+		MaxHops:        2,
+		StaleAfter:     90 * time.Second,
+		RequestTimeout: 10 * time.Second,
+	}
+}
+
+// FederationSnapshot 是注册中心导出给对端的 Agent 摘要全集。
+type FederationSnapshot struct {
+	RegistryID  string       `json:"registry_id"`
+	Region      string       `json:"region,omitempty"`
+	Agents      []*AgentInfo `json:"agents"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// FederationChange 是两次快照之间的单个 Agent 变更。
+type FederationChange struct {
+	Type      DiscoveryEventType `json:"type"`
+	AgentID   string             `json:"agent_id"`
+	Agent     *AgentInfo         `json:"agent,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// FederationChangeBatch 是一次推送给对端的变更集合。
+type FederationChangeBatch struct {
+	RegistryID string             `json:"registry_id"`
+	Region     string             `json:"region,omitempty"`
+	Changes    []FederationChange `json:"changes"`
+}
+
+// PeerTransport 是注册中心之间的对等协议传输层。
+type PeerTransport interface {
+	// FetchSnapshot 拉取对端快照，requester 让对端排除从请求方学到的 Agent。
+	FetchSnapshot(ctx context.Context, peer RegistryPeer, requester string) (*FederationSnapshot, error)
+
+	// PushChanges 向对端推送本地 Agent 的变更。
+	PushChanges(ctx context.Context, peer RegistryPeer, batch *FederationChangeBatch) error
+}
+
+// RegistryFederation 在多个注册中心之间交换 Agent 摘要：周期拉取对端快照，
+// 并把本地 Agent 的变更即时推送给对端。导入的 Agent 带有来源标记，
+// 通过源注册中心检测、跳数上限与水平分割避免环路。
+type RegistryFederation struct {
+	config    FederationConfig
+	registry  Registry
+	transport PeerTransport
+	logger    *zap.Logger
+
+	mu       sync.Mutex
+	imported map[string]map[string]struct{} // peerID -> 从该对端导入的 agentID
+	exported map[string]struct{}            // 已作为本地 Agent 通告给对端的 agentID
+	lastSync map[string]time.Time
+
+	subscriptionID string
+	changes        chan FederationChange
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+// NewRegistryFederation 创建注册中心联邦，transport 为 nil 时使用 HTTP 传输。
+func NewRegistryFederation(registry Registry, config FederationConfig, transport PeerTransport, logger *zap.Logger) (*RegistryFederation, error) {
+	if registry == nil {
+		return nil, fmt.Errorf("federation requires a registry")
+	}
+	if strings.TrimSpace(config.RegistryID) == "" {
+		return nil, fmt.Errorf("federation requires a registry ID")
+	}
+	defaults := DefaultFederationConfig()
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = defaults.SyncInterval
+	}
+	if config.MaxHops <= 0 {
+		config.MaxHops = defaults.MaxHops
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = 3 * config.SyncInterval
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = defaults.RequestTimeout
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if transport == nil {
+		transport = NewHTTPPeerTransport(config.RequestTimeout)
+	}
+	return &RegistryFederation{
+		config:    config,
+		registry:  registry,
+		transport: transport,
+		logger:    logger.With(zap.String("component", "registry_federation"), zap.String("registry_id", config.RegistryID)),
+		imported:  make(map[string]map[string]struct{}),
+		exported:  make(map[string]struct{}),
+		lastSync:  make(map[string]time.Time),
+	}, nil
+}
+
+// Start 订阅本地注册中心的变更并启动周期同步。
+func (f *RegistryFederation) Start(ctx context.Context) error {
+	f.mu.Lock()
+	if f.cancel != nil {
+		f.mu.Unlock()
+		return fmt.Errorf("federation already started")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	f.cancel = cancel
+	f.changes = make(chan FederationChange, federationChangeBuffer)
+	changes := f.changes
+	f.mu.Unlock()
+
+	f.subscriptionID = f.registry.Subscribe(func(event *DiscoveryEvent) {
+		f.onLocalEvent(runCtx, event, changes)
+	})
+
+	f.wg.Add(2)
+	go f.syncLoop(runCtx)
+	go f.pushLoop(runCtx, changes)
+	return nil
+}
+
+// Stop 停止同步与推送，已导入的 Agent 保留在注册中心中。
+func (f *RegistryFederation) Stop() {
+	f.mu.Lock()
+	cancel := f.cancel
+	f.cancel = nil
+	f.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	f.registry.Unsubscribe(f.subscriptionID)
+	cancel()
+	f.wg.Wait()
+}
+
+func (f *RegistryFederation) syncLoop(ctx context.Context) {
+	defer f.wg.Done()
+	if err := f.SyncPeers(ctx); err != nil {
+		f.logger.Debug("initial federation sync incomplete", zap.Error(err))
+	}
+	ticker := time.NewTicker(f.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.SyncPeers(ctx); err != nil {
+				f.logger.Debug("federation sync incomplete", zap.Error(err))
+			}
+		}
+	}
+}
+
+// pushLoop 把积攒的本地变更批量推送给所有对端。
+func (f *RegistryFederation) pushLoop(ctx context.Context, changes <-chan FederationChange) {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-changes:
+			batch := []FederationChange{change}
+		drain:
+			for {
+				select {
+				case next := <-changes:
+					batch = append(batch, next)
+				default:
+					break drain
+				}
+			}
+			f.pushChanges(ctx, batch)
+		}
+	}
+}
+
+func (f *RegistryFederation) pushChanges(ctx context.Context, changes []FederationChange) {
+	batch := &FederationChangeBatch{RegistryID: f.config.RegistryID, Region: f.config.Region, Changes: changes}
+	for _, peer := range f.config.Peers {
+		pushCtx, cancel := context.WithTimeout(ctx, f.config.RequestTimeout)
+		if err := f.transport.PushChanges(pushCtx, peer, batch); err != nil {
+			f.logger.Debug("failed to push federation changes", zap.String("peer", peer.ID), zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// onLocalEvent 只转发本地 Agent 的变更；联邦导入的 Agent 由各自的源注册中心负责传播。
+func (f *RegistryFederation) onLocalEvent(ctx context.Context, event *DiscoveryEvent, changes chan<- FederationChange) {
+	if event == nil || event.AgentID == "" || ctx.Err() != nil {
+		return
+	}
+	change := FederationChange{Type: event.Type, AgentID: event.AgentID, Timestamp: event.Timestamp}
+	switch event.Type {
+	case DiscoveryEventAgentUnregistered:
+		if !f.forgetExported(event.AgentID) {
+			return
+		}
+	case DiscoveryEventHealthCheckFailed, DiscoveryEventHealthCheckRecovered:
+		change.Type = DiscoveryEventAgentUpdated
+		fallthrough
+	default:
+		agent, err := f.registry.GetAgent(ctx, event.AgentID)
+		if err != nil || agent == nil || isFederatedAgent(agent) {
+			return
+		}
+		change.Agent = f.exportLocal(agent)
+		if change.Type != DiscoveryEventAgentRegistered {
+			change.Type = DiscoveryEventAgentUpdated
+		}
+	}
+	select {
+	case changes <- change:
+	default:
+		f.logger.Debug("federation change buffer full, relying on periodic sync", zap.String("agent_id", event.AgentID))
+	}
+}
+
+// SyncPeers 拉取所有对端的快照并合并；对端长时间不可达时其 Agent 被标记为离线。
+func (f *RegistryFederation) SyncPeers(ctx context.Context) error {
+	var errs []error
+	for _, peer := range f.config.Peers {
+		fetchCtx, cancel := context.WithTimeout(ctx, f.config.RequestTimeout)
+		snapshot, err := f.transport.FetchSnapshot(fetchCtx, peer, f.config.RegistryID)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: %w", peer.ID, err))
+			f.markStale(ctx, peer)
+			continue
+		}
+		if err := f.ApplySnapshot(ctx, peer, snapshot); err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: %w", peer.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Snapshot 导出给 requester 的 Agent 摘要：本地 Agent 全部导出，
+// 联邦导入的 Agent 在未达跳数上限且不是从 requester 学到时转发（水平分割）。
+func (f *RegistryFederation) Snapshot(ctx context.Context, requester string) (*FederationSnapshot, error) {
+	agents, err := f.registry.ListAgents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	snapshot := &FederationSnapshot{
+		RegistryID:  f.config.RegistryID,
+		Region:      f.config.Region,
+		Agents:      make([]*AgentInfo, 0, len(agents)),
+		GeneratedAt: time.Now(),
+	}
+	for _, agent := range agents {
+		if agent == nil || agent.Card == nil {
+			continue
+		}
+		if !isFederatedAgent(agent) {
+			snapshot.Agents = append(snapshot.Agents, f.exportLocal(agent))
+			continue
+		}
+		if requester != "" && (agent.Metadata[MetadataFederationPeer] == requester || agent.Metadata[MetadataOriginRegistry] == requester) {
+			continue
+		}
+		if federationHops(agent) >= f.config.MaxHops {
+			continue
+		}
+		snapshot.Agents = append(snapshot.Agents, agent)
+	}
+	return snapshot, nil
+}
+
+// ApplySnapshot 合并对端快照，并移除该对端不再提供的已导入 Agent。
+func (f *RegistryFederation) ApplySnapshot(ctx context.Context, peer RegistryPeer, snapshot *FederationSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("nil federation snapshot")
+	}
+	peer = f.resolvePeer(peer, snapshot.RegistryID, snapshot.Region)
+
+	seen := make(map[string]struct{}, len(snapshot.Agents))
+	var errs []error
+	for _, agent := range snapshot.Agents {
+		imported, err := f.importAgent(ctx, peer, agent)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if imported {
+			seen[agent.Card.Name] = struct{}{}
+		}
+	}
+
+	f.mu.Lock()
+	previous := f.imported[peer.ID]
+	f.imported[peer.ID] = seen
+	f.lastSync[peer.ID] = time.Now()
+	f.mu.Unlock()
+
+	for agentID := range previous {
+		if _, ok := seen[agentID]; ok {
+			continue
+		}
+		if err := f.removeImported(ctx, peer.ID, agentID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyChanges 合并对端推送的变更。
+func (f *RegistryFederation) ApplyChanges(ctx context.Context, peer RegistryPeer, batch *FederationChangeBatch) error {
+	if batch == nil {
+		return fmt.Errorf("nil federation change batch")
+	}
+	peer = f.resolvePeer(peer, batch.RegistryID, batch.Region)
+
+	var errs []error
+	for _, change := range batch.Changes {
+		if change.Type == DiscoveryEventAgentUnregistered {
+			f.mu.Lock()
+			_, ok := f.imported[peer.ID][change.AgentID]
+			delete(f.imported[peer.ID], change.AgentID)
+			f.mu.Unlock()
+			if ok {
+				if err := f.removeImported(ctx, peer.ID, change.AgentID); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		if change.Agent == nil {
+			continue
+		}
+		imported, err := f.importAgent(ctx, peer, change.Agent)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if imported {
+			f.mu.Lock()
+			if f.imported[peer.ID] == nil {
+				f.imported[peer.ID] = make(map[string]struct{})
+			}
+			f.imported[peer.ID][change.Agent.Card.Name] = struct{}{}
+			f.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// importAgent 以联邦来源标记写入一个对端 Agent。
+// 源自本注册中心、超过跳数上限或与本地注册的 Agent 重名时跳过。
+func (f *RegistryFederation) importAgent(ctx context.Context, peer RegistryPeer, agent *AgentInfo) (bool, error) {
+	if agent == nil || agent.Card == nil || agent.Card.Name == "" {
+		return false, nil
+	}
+	origin := agent.Metadata[MetadataOriginRegistry]
+	if origin == "" {
+		origin = peer.ID
+	}
+	if origin == f.config.RegistryID {
+		return false, nil
+	}
+	hops := federationHops(agent) + 1
+	if hops > f.config.MaxHops {
+		return false, nil
+	}
+
+	agentID := agent.Card.Name
+	existing, err := f.registry.GetAgent(ctx, agentID)
+	exists := err == nil && existing != nil
+	if exists {
+		if !isFederatedAgent(existing) {
+			return false, nil
+		}
+		// 同一 Agent 经多条路径到达时保留跳数更少的路径
+		if existing.Metadata[MetadataFederationPeer] != peer.ID && federationHops(existing) < hops {
+			return false, nil
+		}
+	}
+
+	info := cloneAgentWithMetadata(agent)
+	info.IsLocal = false
+	info.LastHeartbeat = time.Now()
+	info.Metadata[MetadataDiscoverySource] = DiscoverySourceFederation
+	info.Metadata[MetadataOriginRegistry] = origin
+	if info.Metadata[MetadataOriginRegion] == "" {
+		info.Metadata[MetadataOriginRegion] = peer.Region
+	}
+	info.Metadata[MetadataFederationHops] = strconv.Itoa(hops)
+	info.Metadata[MetadataFederationPeer] = peer.ID
+
+	if exists {
+		if previousPeer := existing.Metadata[MetadataFederationPeer]; previousPeer != peer.ID {
+			f.forgetImported(previousPeer, agentID)
+		}
+		err = f.registry.UpdateAgent(ctx, info)
+	} else {
+		err = f.registry.RegisterAgent(ctx, info)
+	}
+	if err != nil {
+		return false, fmt.Errorf("import agent %s from %s: %w", agentID, peer.ID, err)
+	}
+	return true, nil
+}
+
+// removeImported 注销从 peerID 导入的 Agent；Agent 已被其他来源接管时不处理。
+func (f *RegistryFederation) removeImported(ctx context.Context, peerID, agentID string) error {
+	existing, err := f.registry.GetAgent(ctx, agentID)
+	if err != nil || existing == nil {
+		return nil
+	}
+	if !isFederatedAgent(existing) || existing.Metadata[MetadataFederationPeer] != peerID {
+		return nil
+	}
+	if err := f.registry.UnregisterAgent(ctx, agentID); err != nil {
+		return fmt.Errorf("remove federated agent %s: %w", agentID, err)
+	}
+	return nil
+}
+
+// markStale 在对端超过 StaleAfter 未成功同步时把其 Agent 标记为离线，恢复同步后随快照重新上线。
+func (f *RegistryFederation) markStale(ctx context.Context, peer RegistryPeer) {
+	f.mu.Lock()
+	last, synced := f.lastSync[peer.ID]
+	var agentIDs []string
+	if synced && time.Since(last) > f.config.StaleAfter {
+		for agentID := range f.imported[peer.ID] {
+			agentIDs = append(agentIDs, agentID)
+		}
+	}
+	f.mu.Unlock()
+
+	for _, agentID := range agentIDs {
+		if err := f.registry.UpdateAgentStatus(ctx, agentID, AgentStatusOffline); err != nil {
+			f.logger.Debug("failed to mark federated agent offline", zap.String("agent_id", agentID), zap.Error(err))
+		}
+	}
+}
+
+func (f *RegistryFederation) resolvePeer(peer RegistryPeer, registryID, region string) RegistryPeer {
+	if peer.ID == "" {
+		peer.ID = registryID
+	}
+	if peer.Region == "" {
+		peer.Region = region
+	}
+	return peer
+}
+
+// forgetExported 移除通告记录，返回该 Agent 此前是否作为本地 Agent 通告过。
+func (f *RegistryFederation) forgetExported(agentID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.exported[agentID]
+	delete(f.exported, agentID)
+	return ok
+}
+
+func (f *RegistryFederation) forgetImported(peerID, agentID string) {
+	f.mu.Lock()
+	delete(f.imported[peerID], agentID)
+	f.mu.Unlock()
+}
+
+// exportLocal 为本地 Agent 附加来源标记并记录已通告。
+func (f *RegistryFederation) exportLocal(agent *AgentInfo) *AgentInfo {
+	f.mu.Lock()
+	f.exported[agent.Card.Name] = struct{}{}
+	f.mu.Unlock()
+
+	info := cloneAgentWithMetadata(agent)
+	info.Metadata[MetadataOriginRegistry] = f.config.RegistryID
+	if f.config.Region != "" {
+		info.Metadata[MetadataOriginRegion] = f.config.Region
+	}
+	info.Metadata[MetadataFederationHops] = "0"
+	return info
+}
+
+// Handler 返回对端访问的 HTTP 处理器：GET /federation/snapshot 与 POST /federation/changes。
+func (f *RegistryFederation) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(federationSnapshotPath, f.handleSnapshot)
+	mux.HandleFunc(federationChangesPath, f.handleChanges)
+	return mux
+}
+
+func (f *RegistryFederation) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		f.writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	snapshot, err := f.Snapshot(r.Context(), r.URL.Query().Get("requester"))
+	if err != nil {
+		f.logger.Error("failed to build federation snapshot", zap.Error(err))
+		f.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to build snapshot"})
+		return
+	}
+	f.writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (f *RegistryFederation) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		f.writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxFederationBodyBytes)
+	var batch FederationChangeBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		f.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if batch.RegistryID == "" {
+		f.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "registry_id required"})
+		return
+	}
+	if err := f.ApplyChanges(r.Context(), f.peerByID(batch.RegistryID), &batch); err != nil {
+		f.logger.Warn("failed to apply some federation changes", zap.String("peer", batch.RegistryID), zap.Error(err))
+	}
+	f.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (f *RegistryFederation) peerByID(id string) RegistryPeer {
+	for _, peer := range f.config.Peers {
+		if peer.ID == id {
+			return peer
+		}
+	}
+	return RegistryPeer{ID: id}
+}
+
+func (f *RegistryFederation) writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		f.logger.Warn("failed to write federation response", zap.Error(err))
+	}
+}
+
+// HTTPPeerTransport 通过 RegistryFederation.Handler 暴露的 HTTP 接口与对端通信。
+type HTTPPeerTransport struct {
+	client *http.Client
+}
+
+// NewHTTPPeerTransport 创建 HTTP 对等传输。
+func NewHTTPPeerTransport(timeout time.Duration) *HTTPPeerTransport {
+	return &HTTPPeerTransport{client: tlsutil.SecureHTTPClient(timeout)}
+}
+
+// FetchSnapshot 拉取对端快照。
+func (t *HTTPPeerTransport) FetchSnapshot(ctx context.Context, peer RegistryPeer, requester string) (*FederationSnapshot, error) {
+	endpoint := strings.TrimRight(peer.Endpoint, "/") + federationSnapshotPath + "?requester=" + url.QueryEscape(requester)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned status %d", resp.StatusCode)
+	}
+
+	var snapshot FederationSnapshot
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFederationBodyBytes)).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// PushChanges 向对端推送变更。
+func (t *HTTPPeerTransport) PushChanges(ctx context.Context, peer RegistryPeer, batch *FederationChangeBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal changes: %w", err)
+	}
+	endpoint := strings.TrimRight(peer.Endpoint, "/") + federationChangesPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func isFederatedAgent(agent *AgentInfo) bool {
+	return agent != nil && agent.Metadata[MetadataDiscoverySource] == DiscoverySourceFederation
+}
+
+func federationHops(agent *AgentInfo) int {
+	hops, err := strconv.Atoi(agent.Metadata[MetadataFederationHops])
+	if err != nil || hops < 0 {
+		return 0
+	}
+	return hops
+}
+
+// cloneAgentWithMetadata 复制 Agent 并独立出 Metadata，便于改写来源标记。
+func cloneAgentWithMetadata(agent *AgentInfo) *AgentInfo {
+	info := *agent
+	info.Metadata = make(map[string]string, len(agent.Metadata)+4)
+	for k, v := range agent.Metadata {
+		info.Metadata[k] = v
+	}
+	if len(agent.Capabilities) > 0 {
+		info.Capabilities = append([]CapabilityInfo(nil), agent.Capabilities...)
+	}
+	return &info
+}
+
+var _ PeerTransport = (*HTTPPeerTransport)(nil)
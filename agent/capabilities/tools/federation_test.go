@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/execution/protocol/a2a"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// loopbackPeerTransport 在进程内直连各注册中心的联邦实例。
+type loopbackPeerTransport struct {
+	mu    sync.Mutex
+	peers map[string]*RegistryFederation
+	down  map[string]bool
+}
+
+func newLoopbackPeerTransport() *loopbackPeerTransport {
+	return &loopbackPeerTransport{peers: make(map[string]*RegistryFederation), down: make(map[string]bool)}
+}
+
+func (t *loopbackPeerTransport) peer(id string) (*RegistryFederation, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.down[id] {
+		return nil, errors.New("peer unreachable")
+	}
+	return t.peers[id], nil
+}
+
+func (t *loopbackPeerTransport) FetchSnapshot(ctx context.Context, peer RegistryPeer, requester string) (*FederationSnapshot, error) {
+	f, err := t.peer(peer.ID)
+	if err != nil {
+		return nil, err
+	}
+	return f.Snapshot(ctx, requester)
+}
+
+func (t *loopbackPeerTransport) PushChanges(ctx context.Context, peer RegistryPeer, batch *FederationChangeBatch) error {
+	f, err := t.peer(peer.ID)
+	if err != nil {
+		return err
+	}
+	return f.ApplyChanges(ctx, RegistryPeer{ID: batch.RegistryID, Region: batch.Region}, batch)
+}
+
+func registerFederationAgent(t *testing.T, reg Registry, name string, load float64) {
+	t.Helper()
+	require.NoError(t, reg.RegisterAgent(context.Background(), &AgentInfo{
+		Card:     a2a.NewAgentCard(name, name, "http://"+name, "1.0"),
+		Status:   AgentStatusOnline,
+		Load:     load,
+		Endpoint: "http://" + name,
+		Capabilities: []CapabilityInfo{{
+			Capability: a2a.Capability{Name: "translate", Type: a2a.CapabilityTypeTask},
+			Status:     CapabilityStatusActive,
+			Score:      80,
+		}},
+	}))
+}
+
+func newTestFederation(t *testing.T, transport PeerTransport, id, region string, peers ...RegistryPeer) (*RegistryFederation, *CapabilityRegistry) {
+	t.Helper()
+	reg := newCovTestRegistry(t)
+	f, err := NewRegistryFederation(reg, FederationConfig{RegistryID: id, Region: region, Peers: peers, MaxHops: 2}, transport, zap.NewNop())
+	require.NoError(t, err)
+	return f, reg
+}
+
+func TestRegistryFederation_SyncTagsOrigin(t *testing.T) {
+	ctx := context.Background()
+	transport := newLoopbackPeerTransport()
+	east, eastReg := newTestFederation(t, transport, "east", "us-east", RegistryPeer{ID: "west", Region: "us-west"})
+	west, westReg := newTestFederation(t, transport, "west", "us-west", RegistryPeer{ID: "east", Region: "us-east"})
+	transport.peers["east"], transport.peers["west"] = east, west
+
+	registerFederationAgent(t, westReg, "translator", 0.2)
+	registerFederationAgent(t, eastReg, "local-translator", 0.1)
+	require.NoError(t, east.SyncPeers(ctx))
+
+	imported, err := eastReg.GetAgent(ctx, "translator")
+	require.NoError(t, err)
+	assert.False(t, imported.IsLocal)
+	assert.Equal(t, "http://translator", imported.Endpoint)
+	assert.Equal(t, DiscoverySourceFederation, imported.Metadata[MetadataDiscoverySource])
+	assert.Equal(t, "west", imported.Metadata[MetadataOriginRegistry])
+	assert.Equal(t, "us-west", imported.Metadata[MetadataOriginRegion])
+	assert.Equal(t, "1", imported.Metadata[MetadataFederationHops])
+
+	// 对端移除后随下一次快照删除
+	require.NoError(t, westReg.UnregisterAgent(ctx, "translator"))
+	require.NoError(t, east.SyncPeers(ctx))
+	_, err = eastReg.GetAgent(ctx, "translator")
+	assert.Error(t, err)
+
+	// 本地 Agent 不被同名的远端摘要覆盖
+	registerFederationAgent(t, westReg, "local-translator", 0.9)
+	require.NoError(t, east.SyncPeers(ctx))
+	local, err := eastReg.GetAgent(ctx, "local-translator")
+	require.NoError(t, err)
+	assert.False(t, isFederatedAgent(local))
+	assert.InDelta(t, 0.1, local.Load, 1e-9)
+}
+
+func TestRegistryFederation_LoopPrevention(t *testing.T) {
+	ctx := context.Background()
+	transport := newLoopbackPeerTransport()
+	// a -> b -> c -> a 环形对等
+	a, aReg := newTestFederation(t, transport, "a", "r1", RegistryPeer{ID: "b"})
+	b, bReg := newTestFederation(t, transport, "b", "r2", RegistryPeer{ID: "c"})
+	c, cReg := newTestFederation(t, transport, "c", "r3", RegistryPeer{ID: "a"})
+	transport.peers["a"], transport.peers["b"], transport.peers["c"] = a, b, c
+
+	registerFederationAgent(t, cReg, "worker", 0.1)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.SyncPeers(ctx))
+		require.NoError(t, a.SyncPeers(ctx))
+		require.NoError(t, c.SyncPeers(ctx))
+	}
+
+	viaB, err := aReg.GetAgent(ctx, "worker")
+	require.NoError(t, err)
+	assert.Equal(t, "c", viaB.Metadata[MetadataOriginRegistry])
+	assert.Equal(t, "2", viaB.Metadata[MetadataFederationHops])
+	assert.Equal(t, "b", viaB.Metadata[MetadataFederationPeer])
+
+	atB, err := bReg.GetAgent(ctx, "worker")
+	require.NoError(t, err)
+	assert.Equal(t, "1", atB.Metadata[MetadataFederationHops])
+
+	// 源注册中心不会导入自己的 Agent
+	origin, err := cReg.GetAgent(ctx, "worker")
+	require.NoError(t, err)
+	assert.False(t, isFederatedAgent(origin))
+
+	// 达到跳数上限的摘要不再转发，水平分割排除请求方自身学到的 Agent
+	snapshot, err := a.Snapshot(ctx, "x")
+	require.NoError(t, err)
+	assert.Empty(t, snapshot.Agents)
+	snapshot, err = b.Snapshot(ctx, "c")
+	require.NoError(t, err)
+	assert.Empty(t, snapshot.Agents)
+}
+
+func TestRegistryFederation_StalePeerMarkedOffline(t *testing.T) {
+	ctx := context.Background()
+	transport := newLoopbackPeerTransport()
+	east, eastReg := newTestFederation(t, transport, "east", "us-east", RegistryPeer{ID: "west"})
+	west, westReg := newTestFederation(t, transport, "west", "us-west")
+	transport.peers["east"], transport.peers["west"] = east, west
+	east.config.StaleAfter = time.Millisecond
+
+	registerFederationAgent(t, westReg, "translator", 0.2)
+	require.NoError(t, east.SyncPeers(ctx))
+
+	transport.down["west"] = true
+	time.Sleep(5 * time.Millisecond)
+	assert.Error(t, east.SyncPeers(ctx))
+	agent, err := eastReg.GetAgent(ctx, "translator")
+	require.NoError(t, err)
+	assert.Equal(t, AgentStatusOffline, agent.Status)
+
+	transport.down["west"] = false
+	require.NoError(t, east.SyncPeers(ctx))
+	agent, err = eastReg.GetAgent(ctx, "translator")
+	require.NoError(t, err)
+	assert.Equal(t, AgentStatusOnline, agent.Status)
+}
+
+func TestRegistryFederation_PushesChangesOverHTTP(t *testing.T) {
+	ctx := context.Background()
+	eastReg := newCovTestRegistry(t)
+	east, err := NewRegistryFederation(eastReg, FederationConfig{RegistryID: "east", Region: "us-east"}, nil, zap.NewNop())
+	require.NoError(t, err)
+	server := httptest.NewServer(east.Handler())
+	defer server.Close()
+
+	westReg := newCovTestRegistry(t)
+	west, err := NewRegistryFederation(westReg, FederationConfig{
+		RegistryID:   "west",
+		Region:       "us-west",
+		Peers:        []RegistryPeer{{ID: "east", Endpoint: server.URL}},
+		SyncInterval: time.Hour,
+	}, nil, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, west.Start(ctx))
+	defer west.Stop()
+
+	registerFederationAgent(t, westReg, "translator", 0.2)
+	require.Eventually(t, func() bool {
+		agent, err := eastReg.GetAgent(ctx, "translator")
+		return err == nil && agent.Metadata[MetadataOriginRegistry] == "west" && agent.Metadata[MetadataOriginRegion] == "us-west"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, westReg.UnregisterAgent(ctx, "translator"))
+	require.Eventually(t, func() bool {
+		_, err := eastReg.GetAgent(ctx, "translator")
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 快照接口
+	snapshot, err := NewHTTPPeerTransport(time.Second).FetchSnapshot(ctx, RegistryPeer{ID: "east", Endpoint: server.URL}, "west")
+	require.NoError(t, err)
+	assert.Equal(t, "east", snapshot.RegistryID)
+}
+
+func TestCapabilityMatcher_LocalityPreference(t *testing.T) {
+	ctx := context.Background()
+	reg := newCovTestRegistry(t)
+	registerFederationAgent(t, reg, "local", 0.95)
+	for _, remote := range []struct{ name, region string }{{"near", "us-east"}, {"far", "eu-west"}} {
+		info := &AgentInfo{
+			Card:   a2a.NewAgentCard(remote.name, remote.name, "http://"+remote.name, "1.0"),
+			Status: AgentStatusOnline,
+			Load:   0.1,
+			Capabilities: []CapabilityInfo{{
+				Capability: a2a.Capability{Name: "translate", Type: a2a.CapabilityTypeTask},
+				Status:     CapabilityStatusActive,
+				Score:      80,
+			}},
+			Metadata: map[string]string{
+				MetadataDiscoverySource: DiscoverySourceFederation,
+				MetadataOriginRegistry:  remote.name + "-registry",
+				MetadataOriginRegion:    remote.region,
+			},
+		}
+		require.NoError(t, reg.RegisterAgent(ctx, info))
+	}
+	matcher := NewCapabilityMatcher(reg, nil, zap.NewNop())
+
+	// 本地容量耗尽时委派给同区域的远端代理
+	results, err := matcher.Match(ctx, &MatchRequest{RequiredCapabilities: []string{"translate"}, Locality: LocalityPreferLocal, Region: "us-east"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "near", results[0].Agent.Card.Name)
+	assert.Equal(t, "near-registry", results[0].OriginRegistry)
+	assert.Equal(t, "local", results[1].Agent.Card.Name)
+
+	// 本地仍有容量时不返回远端代理
+	require.NoError(t, reg.UpdateAgentLoad(ctx, "local", 0.3))
+	results, err = matcher.Match(ctx, &MatchRequest{RequiredCapabilities: []string{"translate"}, Locality: LocalityPreferLocal, Region: "us-east"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "local", results[0].Agent.Card.Name)
+	assert.Empty(t, results[0].OriginRegistry)
+
+	results, err = matcher.Match(ctx, &MatchRequest{RequiredCapabilities: []string{"translate"}, Locality: LocalityLocalOnly})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	results, err = matcher.Match(ctx, &MatchRequest{RequiredCapabilities: []string{"translate"}})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+}
@@ -241,6 +241,11 @@ func (i *AgentDiscoveryIntegration) RecordExecution(ctx context.Context, agentID
 	return i.service.RecordExecution(ctx, agentID, capabilityName, success, latency)
 }
 
+// Scorecard 返回代理基于执行历史的评分卡.
+func (i *AgentDiscoveryIntegration) Scorecard(ctx context.Context, agentID string) (*AgentScorecard, error) {
+	return i.service.Scorecard(ctx, agentID)
+}
+
 // Find AgentForTask 找到任务的最佳代理 。
 func (i *AgentDiscoveryIntegration) FindAgentForTask(ctx context.Context, taskDescription string, requiredCapabilities []string) (*AgentInfo, error) {
 	return i.service.FindAgent(ctx, taskDescription, requiredCapabilities)
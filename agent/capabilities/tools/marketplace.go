@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/adapters/structured"
+	tooldiscovery "github.com/BaSui01/agentflow/agent/capabilities/tools/discovery"
+)
+
+// LatencyClass 能力的延迟等级，按 p95 延迟划分。
+type LatencyClass string
+
+const (
+	// LatencyClassRealtime p95 不超过 500ms。
+	LatencyClassRealtime LatencyClass = tooldiscovery.LatencyClassRealtime
+	// LatencyClassInteractive p95 不超过 2s。
+	LatencyClassInteractive LatencyClass = tooldiscovery.LatencyClassInteractive
+	// LatencyClassStandard p95 不超过 10s。
+	LatencyClassStandard LatencyClass = tooldiscovery.LatencyClassStandard
+	// LatencyClassBatch 离线批处理，无延迟上限。
+	LatencyClassBatch LatencyClass = tooldiscovery.LatencyClassBatch
+)
+
+// LatencyClassForP95 返回覆盖给定 p95 延迟的最小延迟等级。
+func LatencyClassForP95(p95 time.Duration) LatencyClass {
+	return LatencyClass(tooldiscovery.LatencyClassFor(p95))
+}
+
+// CapabilityPricing 能力的计价方式，各项费用可叠加。
+type CapabilityPricing struct {
+	// Currency 为 ISO 4217 货币代码，默认 USD。
+	Currency string `json:"currency,omitempty"`
+
+	// PerCall 是每次调用的固定费用。
+	PerCall float64 `json:"per_call,omitempty"`
+
+	// PerThousandInputTokens 是每千输入 token 的费用。
+	PerThousandInputTokens float64 `json:"per_1k_input_tokens,omitempty"`
+
+	// PerThousandOutputTokens 是每千输出 token 的费用。
+	PerThousandOutputTokens float64 `json:"per_1k_output_tokens,omitempty"`
+}
+
+// EstimateCost 按预估 token 数计算单次调用费用。
+func (p *CapabilityPricing) EstimateCost(inputTokens, outputTokens int) float64 {
+	if p == nil {
+		return 0
+	}
+	return p.PerCall +
+		float64(inputTokens)/1000*p.PerThousandInputTokens +
+		float64(outputTokens)/1000*p.PerThousandOutputTokens
+}
+
+// CurrencyOrDefault 返回计价货币，未设置时为 USD。
+func (p *CapabilityPricing) CurrencyOrDefault() string {
+	if p == nil || p.Currency == "" {
+		return "USD"
+	}
+	return p.Currency
+}
+
+// MarketplaceInfo 是能力市场的机器可读元数据，用于按成本与兼容性治理跨团队 Agent 复用。
+// 可同时声明在 AgentInfo（默认值）与 CapabilityInfo（覆盖）上。
+type MarketplaceInfo struct {
+	// Pricing 是计价方式。
+	Pricing *CapabilityPricing `json:"pricing,omitempty"`
+
+	// LatencyClass 是声明的延迟等级。
+	LatencyClass LatencyClass `json:"latency_class,omitempty"`
+
+	// P95Latency 是声明或观测的 p95 延迟，未设置时按 LatencyClass 的上限估计。
+	P95Latency time.Duration `json:"p95_latency,omitempty"`
+
+	// InputSchemas 是能力接受的输入 schema，以 $id（缺省为 title）标识。
+	InputSchemas []*structured.JSONSchema `json:"input_schemas,omitempty"`
+
+	// OutputSchemas 是能力产出的输出 schema，以 $id（缺省为 title）标识。
+	OutputSchemas []*structured.JSONSchema `json:"output_schemas,omitempty"`
+}
+
+// Clone 复制元数据；schema 定义按只读共享。
+func (m *MarketplaceInfo) Clone() *MarketplaceInfo {
+	if m == nil {
+		return nil
+	}
+	out := *m
+	if m.Pricing != nil {
+		pricing := *m.Pricing
+		out.Pricing = &pricing
+	}
+	out.InputSchemas = append([]*structured.JSONSchema(nil), m.InputSchemas...)
+	out.OutputSchemas = append([]*structured.JSONSchema(nil), m.OutputSchemas...)
+	return &out
+}
+
+// EffectiveMarketplace 合并 Agent 级默认值与能力级声明，能力级非空字段优先。
+func EffectiveMarketplace(agent *AgentInfo, capability *CapabilityInfo) *MarketplaceInfo {
+	var base, override *MarketplaceInfo
+	if agent != nil {
+		base = agent.Marketplace
+	}
+	if capability != nil {
+		override = capability.Marketplace
+	}
+	if base == nil && override == nil {
+		return nil
+	}
+	out := &MarketplaceInfo{}
+	for _, info := range []*MarketplaceInfo{base, override} {
+		if info == nil {
+			continue
+		}
+		if info.Pricing != nil {
+			out.Pricing = info.Pricing
+		}
+		if info.LatencyClass != "" {
+			out.LatencyClass = info.LatencyClass
+		}
+		if info.P95Latency > 0 {
+			out.P95Latency = info.P95Latency
+		}
+		if len(info.InputSchemas) > 0 {
+			out.InputSchemas = info.InputSchemas
+		}
+		if len(info.OutputSchemas) > 0 {
+			out.OutputSchemas = info.OutputSchemas
+		}
+	}
+	return out
+}
+
+func schemaIDs(schemas []*structured.JSONSchema) []string {
+	ids := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		if schema == nil {
+			continue
+		}
+		if schema.ID != "" {
+			ids = append(ids, schema.ID)
+		} else if schema.Title != "" {
+			ids = append(ids, schema.Title)
+		}
+	}
+	return ids
+}
+
+// constraintCandidate 将能力转换为约束求值所需的最小结构。
+func constraintCandidate(agent *AgentInfo, capability *CapabilityInfo, req *MatchRequest) (tooldiscovery.ConstraintCandidate, *MarketplaceInfo) {
+	candidate := tooldiscovery.ConstraintCandidate{Load: agent.Load}
+	if capability != nil {
+		candidate.Capability = capability.Capability.Name
+		candidate.Tags = capability.Tags
+		candidate.Score = capability.Score
+	}
+	info := EffectiveMarketplace(agent, capability)
+	if info == nil {
+		return candidate, nil
+	}
+	if info.Pricing != nil {
+		candidate.HasCost = true
+		candidate.Cost = info.Pricing.EstimateCost(req.EstimatedInputTokens, req.EstimatedOutputTokens)
+		candidate.Currency = info.Pricing.CurrencyOrDefault()
+	}
+	candidate.P95Latency = info.P95Latency
+	candidate.LatencyClass = string(info.LatencyClass)
+	candidate.InputSchemas = schemaIDs(info.InputSchemas)
+	candidate.OutputSchemas = schemaIDs(info.OutputSchemas)
+	return candidate, info
+}
+
+// selectByConstraint 在 Agent 的候选能力中选出满足约束的最优一项。
+// 有匹配到的能力时只在其中选择，否则考虑 Agent 的全部能力。
+func selectByConstraint(agent *AgentInfo, matchedCaps []CapabilityInfo, req *MatchRequest, query *tooldiscovery.ConstraintQuery) (tooldiscovery.ConstraintCandidate, *MarketplaceInfo, bool) {
+	pool := matchedCaps
+	if len(pool) == 0 {
+		pool = agent.Capabilities
+	}
+
+	var (
+		best     tooldiscovery.ConstraintCandidate
+		bestInfo *MarketplaceInfo
+		found    bool
+	)
+	consider := func(capability *CapabilityInfo) {
+		candidate, info := constraintCandidate(agent, capability, req)
+		if !query.Matches(candidate) {
+			return
+		}
+		if !found || query.Less(candidate, best) {
+			best, bestInfo, found = candidate, info, true
+		}
+	}
+	if len(pool) == 0 {
+		consider(nil)
+	}
+	for i := range pool {
+		consider(&pool[i])
+	}
+	return best, bestInfo, found
+}
+
+// SelectByConstraint 按约束表达式选出单个 Agent，例如
+// "cheapest agent with p95 < 2s supporting schema invoice.v1"。
+func (m *CapabilityMatcher) SelectByConstraint(ctx context.Context, constraint string, requiredCapabilities ...string) (*MatchResult, error) {
+	result, err := m.MatchOne(ctx, &MatchRequest{
+		RequiredCapabilities: requiredCapabilities,
+		Constraint:           constraint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select by constraint %q: %w", constraint, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/adapters/structured"
+	"github.com/BaSui01/agentflow/agent/execution/protocol/a2a"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func registerMarketplaceAgent(t *testing.T, reg Registry, name string, load float64, agentMarket *MarketplaceInfo, capMarket *MarketplaceInfo) {
+	t.Helper()
+	info := &AgentInfo{
+		Card:   a2a.NewAgentCard(name, name, "http://localhost", "1.0"),
+		Status: AgentStatusOnline,
+		Load:   load,
+		Capabilities: []CapabilityInfo{
+			{
+				Capability:  a2a.Capability{Name: "extract", Description: "extract fields", Type: a2a.CapabilityTypeTask},
+				AgentID:     name,
+				Status:      CapabilityStatusActive,
+				Score:       80,
+				Marketplace: capMarket,
+			},
+		},
+		Marketplace: agentMarket,
+	}
+	require.NoError(t, reg.RegisterAgent(context.Background(), info))
+}
+
+func invoiceSchema() *structured.JSONSchema {
+	return &structured.JSONSchema{ID: "invoice.v1", Type: structured.TypeObject}
+}
+
+func TestCapabilityPricing_EstimateCost(t *testing.T) {
+	pricing := &CapabilityPricing{PerCall: 0.01, PerThousandInputTokens: 0.002, PerThousandOutputTokens: 0.004}
+	assert.InDelta(t, 0.01+0.004+0.002, pricing.EstimateCost(2000, 500), 1e-9)
+	assert.Equal(t, "USD", pricing.CurrencyOrDefault())
+
+	var nilPricing *CapabilityPricing
+	assert.Zero(t, nilPricing.EstimateCost(1000, 1000))
+}
+
+func TestEffectiveMarketplace_CapabilityOverridesAgent(t *testing.T) {
+	agent := &AgentInfo{Marketplace: &MarketplaceInfo{
+		Pricing:      &CapabilityPricing{PerCall: 0.1},
+		LatencyClass: LatencyClassStandard,
+		InputSchemas: []*structured.JSONSchema{invoiceSchema()},
+	}}
+	capability := &CapabilityInfo{Marketplace: &MarketplaceInfo{LatencyClass: LatencyClassRealtime}}
+
+	info := EffectiveMarketplace(agent, capability)
+	require.NotNil(t, info)
+	assert.Equal(t, LatencyClassRealtime, info.LatencyClass)
+	assert.Equal(t, 0.1, info.Pricing.PerCall)
+	assert.Len(t, info.InputSchemas, 1)
+
+	assert.Nil(t, EffectiveMarketplace(&AgentInfo{}, &CapabilityInfo{}))
+}
+
+func TestCapabilityMatcher_Constraint(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	schemas := []*structured.JSONSchema{invoiceSchema()}
+	registerMarketplaceAgent(t, reg, "premium", 0.1, nil, &MarketplaceInfo{
+		Pricing:      &CapabilityPricing{PerCall: 0.05},
+		P95Latency:   800 * time.Millisecond,
+		InputSchemas: schemas,
+	})
+	registerMarketplaceAgent(t, reg, "budget", 0.1, nil, &MarketplaceInfo{
+		Pricing:      &CapabilityPricing{PerCall: 0.01},
+		P95Latency:   1500 * time.Millisecond,
+		InputSchemas: schemas,
+	})
+	registerMarketplaceAgent(t, reg, "slow-cheap", 0.1, nil, &MarketplaceInfo{
+		Pricing:      &CapabilityPricing{PerCall: 0.001},
+		LatencyClass: LatencyClassBatch,
+		InputSchemas: schemas,
+	})
+	registerMarketplaceAgent(t, reg, "other-schema", 0.1, &MarketplaceInfo{
+		Pricing:      &CapabilityPricing{PerCall: 0.0001},
+		P95Latency:   100 * time.Millisecond,
+		InputSchemas: []*structured.JSONSchema{{Title: "receipt.v2"}},
+	}, nil)
+	registerMarketplaceAgent(t, reg, "unpriced", 0, nil, nil)
+
+	matcher := NewCapabilityMatcher(reg, nil, zap.NewNop())
+	ctx := context.Background()
+
+	t.Run("cheapest within latency and schema", func(t *testing.T) {
+		result, err := matcher.SelectByConstraint(ctx, "cheapest agent with p95<2s supporting schema invoice.v1", "extract")
+		require.NoError(t, err)
+		assert.Equal(t, "budget", result.Agent.Card.Name)
+		assert.InDelta(t, 0.01, result.EstimatedCost, 1e-9)
+		require.NotNil(t, result.Marketplace)
+		assert.Equal(t, 1500*time.Millisecond, result.Marketplace.P95Latency)
+	})
+
+	t.Run("fastest ordering", func(t *testing.T) {
+		results, err := matcher.Match(ctx, &MatchRequest{
+			RequiredCapabilities: []string{"extract"},
+			Constraint:           "fastest supporting input schema invoice.v1",
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "premium", results[0].Agent.Card.Name)
+		assert.Equal(t, "budget", results[1].Agent.Card.Name)
+		assert.Equal(t, "slow-cheap", results[2].Agent.Card.Name)
+	})
+
+	t.Run("agent level metadata and title schema ids", func(t *testing.T) {
+		result, err := matcher.SelectByConstraint(ctx, "schema = receipt.v2")
+		require.NoError(t, err)
+		assert.Equal(t, "other-schema", result.Agent.Card.Name)
+	})
+
+	t.Run("no agent satisfies constraint", func(t *testing.T) {
+		_, err := matcher.SelectByConstraint(ctx, "cost < 0.00001", "extract")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid constraint", func(t *testing.T) {
+		_, err := matcher.Match(ctx, &MatchRequest{Constraint: "colour = red"})
+		assert.ErrorContains(t, err, "invalid constraint")
+	})
+}
@@ -27,6 +27,12 @@ type CapabilityMatcher struct {
 
 	// 随机选择源。
 	rng *rand.Rand
+
+	// embeddings 非 nil 时启用基于向量的能力匹配。
+	embeddings *embeddingIndex
+
+	// reservations 非 nil 时，租约负载计入代理的有效负载。
+	reservations *ReservationManager
 }
 
 // MatcherConfig持有能力匹配器的配置.
@@ -57,20 +63,37 @@ type MatcherConfig struct {
 
 	// 语义相似 阈值是语义相似性的阈值.
 	SemanticSimilarityThreshold float64 `json:"semantic_similarity_threshold"`
+
+	// HistoryQualityWeight、HistoryLoadWeight 与 HistoryCostWeight 是
+	// MatchStrategyBestHistorical 中历史质量、空闲度与费用的权重.
+	HistoryQualityWeight float64 `json:"history_quality_weight"`
+	HistoryLoadWeight    float64 `json:"history_load_weight"`
+	HistoryCostWeight    float64 `json:"history_cost_weight"`
+
+	// EmbeddingSimilarityThreshold 是配置 EmbeddingProvider 后向量匹配的最低余弦相似度.
+	EmbeddingSimilarityThreshold float64 `json:"embedding_similarity_threshold"`
+
+	// LocalCapacityThreshold 是 LocalityPreferLocal 下视为容量耗尽的负载 (0-1).
+	LocalCapacityThreshold float64 `json:"local_capacity_threshold"`
 }
 
 // 默认 MatcherConfig 返回带有合理默认的 MatcherConfig 。
 func DefaultMatcherConfig() *MatcherConfig {
 	return &MatcherConfig{
-		DefaultStrategy:             MatchStrategyBestMatch,
-		DefaultLimit:                10,
-		DefaultTimeout:              5 * time.Second,
-		MinScoreThreshold:           0.0,
-		LoadWeight:                  0.3,
-		ScoreWeight:                 0.5,
-		LatencyWeight:               0.2,
-		EnableSemanticMatching:      true,
-		SemanticSimilarityThreshold: 0.5,
+		DefaultStrategy:              MatchStrategyBestMatch,
+		DefaultLimit:                 10,
+		DefaultTimeout:               5 * time.Second,
+		MinScoreThreshold:            0.0,
+		LoadWeight:                   0.3,
+		ScoreWeight:                  0.5,
+		LatencyWeight:                0.2,
+		EnableSemanticMatching:       true,
+		SemanticSimilarityThreshold:  0.5,
+		HistoryQualityWeight:         0.6,
+		HistoryLoadWeight:            0.25,
+		HistoryCostWeight:            0.15,
+		EmbeddingSimilarityThreshold: 0.5,
+		LocalCapacityThreshold:       0.9,
 	}
 }
 
@@ -92,6 +115,12 @@ func NewCapabilityMatcher(registry Registry, config *MatcherConfig, logger *zap.
 	}
 }
 
+// WithReservations 使匹配器按有效负载 (上报负载 + 租约负载) 过滤、计分和排序。
+func (m *CapabilityMatcher) WithReservations(reservations *ReservationManager) *CapabilityMatcher {
+	m.reservations = reservations
+	return m
+}
+
 // Match 找到匹配给定请求的代理 。
 func (m *CapabilityMatcher) Match(ctx context.Context, req *MatchRequest) ([]*MatchResult, error) {
 	if req == nil {
@@ -109,6 +138,15 @@ func (m *CapabilityMatcher) Match(ctx context.Context, req *MatchRequest) ([]*Ma
 		req.Timeout = m.config.DefaultTimeout
 	}
 
+	var constraint *tooldiscovery.ConstraintQuery
+	if strings.TrimSpace(req.Constraint) != "" {
+		parsed, err := tooldiscovery.ParseConstraint(req.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint: %w", err)
+		}
+		constraint = parsed
+	}
+
 	// 以超时创建上下文
 	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
 	defer cancel()
@@ -118,9 +156,13 @@ func (m *CapabilityMatcher) Match(ctx context.Context, req *MatchRequest) ([]*Ma
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agents: %w", err)
 	}
+	if m.reservations != nil {
+		m.reservations.applyReservations(agents)
+	}
 
 	// 过滤和计分代理
 	results := make([]*MatchResult, 0)
+	candidates := make(map[*MatchResult]tooldiscovery.ConstraintCandidate)
 	for _, agent := range agents {
 		// 跳过排除的代理
 		if m.isExcluded(agent.Card.Name, req.ExcludedAgents) {
@@ -137,8 +179,13 @@ func (m *CapabilityMatcher) Match(ctx context.Context, req *MatchRequest) ([]*Ma
 			continue
 		}
 
+		// 只匹配本地代理时跳过联邦导入的代理
+		if req.Locality == LocalityLocalOnly && isFederatedAgent(agent) {
+			continue
+		}
+
 		// 计算匹配分数
-		score, matchedCaps, confidence, reason := m.calculateMatchScore(ctx, agent, req)
+		score, matchedCaps, confidence, reason, semantic := m.calculateMatchScore(ctx, agent, req)
 
 		// 低于阈值时跳过
 		if score < req.MinScore && score < m.config.MinScoreThreshold {
@@ -150,17 +197,55 @@ func (m *CapabilityMatcher) Match(ctx context.Context, req *MatchRequest) ([]*Ma
 			continue
 		}
 
-		results = append(results, &MatchResult{
+		// 启用向量匹配且只给出任务描述时，没有能力达到相似度阈值的代理不参与匹配
+		if semantic != nil && !semantic.matched() && len(req.RequiredCapabilities) == 0 {
+			continue
+		}
+
+		result := &MatchResult{
 			Agent:               agent,
 			MatchedCapabilities: matchedCaps,
 			Score:               score,
 			Confidence:          confidence,
 			Reason:              reason,
-		})
+		}
+		if isFederatedAgent(agent) {
+			result.OriginRegistry = agent.Metadata[MetadataOriginRegistry]
+		}
+		if semantic != nil {
+			result.SemanticMatches = semantic.matches
+		}
+
+		// 检查市场约束
+		if constraint != nil {
+			candidate, info, ok := selectByConstraint(agent, matchedCaps, req, constraint)
+			if !ok {
+				continue
+			}
+			result.Marketplace = info
+			if candidate.HasCost {
+				result.EstimatedCost = candidate.Cost
+			}
+			candidates[result] = candidate
+		}
+
+		results = append(results, result)
 	}
 
-	// 根据战略排序结果
+	if req.Strategy == MatchStrategyBestHistorical {
+		m.applyHistoricalScores(ctx, results, req)
+	}
+
+	// 根据战略排序结果；约束带排序关键字时按约束排序
 	m.sortResults(results, req.Strategy)
+	if constraint != nil && constraint.Order != tooldiscovery.ConstraintOrderNone {
+		sort.SliceStable(results, func(i, j int) bool {
+			return constraint.Less(candidates[results[i]], candidates[results[j]])
+		})
+	}
+	if req.Locality == LocalityPreferLocal {
+		results = m.applyLocality(results, req)
+	}
 
 	// 应用限制
 	if len(results) > req.Limit {
@@ -196,33 +281,48 @@ func (m *CapabilityMatcher) Score(ctx context.Context, agent *AgentInfo, req *Ma
 		return 0, fmt.Errorf("agent or request is nil")
 	}
 
-	score, _, _, _ := m.calculateMatchScore(ctx, agent, req)
+	score, _, _, _, _ := m.calculateMatchScore(ctx, agent, req)
 	return score, nil
 }
 
-// 计算 MatchScore 为代理计算匹配分数。
-func (m *CapabilityMatcher) calculateMatchScore(ctx context.Context, agent *AgentInfo, req *MatchRequest) (float64, []CapabilityInfo, float64, string) {
+// 计算 MatchScore 为代理计算匹配分数。启用向量匹配时额外返回向量匹配明细。
+func (m *CapabilityMatcher) calculateMatchScore(ctx context.Context, agent *AgentInfo, req *MatchRequest) (float64, []CapabilityInfo, float64, string, *semanticMatchOutcome) {
 	var matchedCaps []CapabilityInfo
 	var reasons []string
 	var totalScore float64
 	var confidence float64 = 1.0
+	var semantic *semanticMatchOutcome
+	if m.embeddings != nil {
+		semantic = &semanticMatchOutcome{}
+	}
 
-	// 1. 检查所需能力
+	// 1. 检查所需能力，名称未命中时尝试向量匹配
 	requiredMatched := 0
 	for _, reqCap := range req.RequiredCapabilities {
+		found := false
 		for _, agentCap := range agent.Capabilities {
 			if m.capabilityMatches(agentCap.Capability.Name, reqCap) {
 				matchedCaps = append(matchedCaps, agentCap)
 				requiredMatched++
+				found = true
 				break
 			}
 		}
+		if found || semantic == nil {
+			continue
+		}
+		if match, ok := m.embeddingRequiredMatch(ctx, agent, reqCap); ok {
+			matchedCaps = appendCapabilityOnce(matchedCaps, agent, match.Capability)
+			semantic.matches = append(semantic.matches, *match)
+			confidence *= match.Similarity
+			requiredMatched++
+		}
 	}
 
 	if len(req.RequiredCapabilities) > 0 {
 		if requiredMatched < len(req.RequiredCapabilities) {
 			// 并非所有所需能力匹配
-			return 0, nil, 0, "missing required capabilities"
+			return 0, nil, 0, "missing required capabilities", semantic
 		}
 		totalScore += 40.0 // Base score for matching all required capabilities
 		reasons = append(reasons, fmt.Sprintf("matched %d required capabilities", requiredMatched))
@@ -270,14 +370,34 @@ func (m *CapabilityMatcher) calculateMatchScore(ctx context.Context, agent *Agen
 			}
 		}
 		if tagMatched < len(req.RequiredTags) {
-			return 0, nil, 0, "missing required tags"
+			return 0, nil, 0, "missing required tags", semantic
 		}
 		totalScore += 10.0
 		reasons = append(reasons, fmt.Sprintf("matched %d required tags", tagMatched))
 	}
 
-	// 4. 任务描述的语义匹配
-	if m.config.EnableSemanticMatching && req.TaskDescription != "" {
+	// 4. 任务描述的语义匹配：优先使用向量匹配，失败时回退到词法匹配
+	embedded := false
+	if semantic != nil && req.TaskDescription != "" {
+		matches, err := m.semanticCapabilityMatches(ctx, agent, req.TaskDescription)
+		if err != nil {
+			m.logger.Debug("embedding task match failed, falling back to lexical matching", zap.Error(err))
+		} else {
+			embedded = true
+			semantic.taskEmbedded = true
+			semantic.matches = append(semantic.matches, matches...)
+			if len(matches) > 0 {
+				for _, match := range matches {
+					matchedCaps = appendCapabilityOnce(matchedCaps, agent, match.Capability)
+				}
+				best := matches[0]
+				totalScore += best.Similarity * 20.0
+				confidence *= best.Similarity
+				reasons = append(reasons, fmt.Sprintf("embedding match %s: %.2f", best.Capability, best.Similarity))
+			}
+		}
+	}
+	if !embedded && m.config.EnableSemanticMatching && req.TaskDescription != "" {
 		semanticScore, semanticConfidence := m.calculateSemanticScore(agent, req.TaskDescription)
 		if semanticScore > m.config.SemanticSimilarityThreshold {
 			totalScore += semanticScore * 20.0
@@ -316,7 +436,33 @@ func (m *CapabilityMatcher) calculateMatchScore(ctx context.Context, agent *Agen
 	totalScore = math.Max(0, math.Min(100, totalScore))
 
 	reason := strings.Join(reasons, "; ")
-	return totalScore, matchedCaps, confidence, reason
+	return totalScore, matchedCaps, confidence, reason, semantic
+}
+
+// semanticMatchOutcome 记录向量匹配的明细；taskEmbedded 表示任务描述已成功完成向量匹配。
+type semanticMatchOutcome struct {
+	matches      []SemanticCapabilityMatch
+	taskEmbedded bool
+}
+
+// matched 报告任务描述是否匹配到了能力；任务描述未做向量匹配时视为匹配。
+func (o *semanticMatchOutcome) matched() bool {
+	return !o.taskEmbedded || len(o.matches) > 0
+}
+
+// appendCapabilityOnce 将代理的指定能力追加到 caps 中，已存在时跳过。
+func appendCapabilityOnce(caps []CapabilityInfo, agent *AgentInfo, name string) []CapabilityInfo {
+	for _, c := range caps {
+		if c.Capability.Name == name {
+			return caps
+		}
+	}
+	for _, c := range agent.Capabilities {
+		if c.Capability.Name == name {
+			return append(caps, c)
+		}
+	}
+	return caps
 }
 
 // 能力 匹配一个匹配所需能力的能力名称 。
@@ -380,6 +526,15 @@ func (m *CapabilityMatcher) sortResults(results []*MatchResult, strategy MatchSt
 		m.rng.Shuffle(len(results), func(i, j int) {
 			results[i], results[j] = results[j], results[i]
 		})
+
+	case MatchStrategyBestHistorical:
+		// 按历史综合分递减排序,再由匹配分数递减排序
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].HistoricalScore != results[j].HistoricalScore {
+				return results[i].HistoricalScore > results[j].HistoricalScore
+			}
+			return results[i].Score > results[j].Score
+		})
 	}
 }
 
@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// EmbeddingProvider 是基于向量的能力匹配所需的向量化能力，
+// llm/capabilities/embedding.Provider 满足该接口。
+type EmbeddingProvider = ToolEmbedder
+
+// maxQueryEmbeddings 是查询向量缓存的上限，超出后整体清空。
+const maxQueryEmbeddings = 256
+
+// SemanticCapabilityMatch 解释一次向量匹配：哪段文本以多大相似度匹配到了哪个能力。
+type SemanticCapabilityMatch struct {
+	// Query 是用于匹配的任务描述或未精确命中的能力名。
+	Query      string  `json:"query"`
+	Capability string  `json:"capability"`
+	Similarity float64 `json:"similarity"`
+}
+
+type capabilityEmbedding struct {
+	text   string
+	vector []float64
+}
+
+// embeddingIndex 缓存能力与查询的向量，能力描述变化时重新计算。
+type embeddingIndex struct {
+	provider EmbeddingProvider
+
+	mu           sync.Mutex
+	capabilities map[string]capabilityEmbedding
+	queries      map[string][]float64
+}
+
+func newEmbeddingIndex(provider EmbeddingProvider) *embeddingIndex {
+	return &embeddingIndex{
+		provider:     provider,
+		capabilities: make(map[string]capabilityEmbedding),
+		queries:      make(map[string][]float64),
+	}
+}
+
+// WithEmbeddingProvider 启用基于向量的能力匹配：任务描述与未精确命中的所需能力
+// 会按与能力描述的余弦相似度匹配，阈值为 MatcherConfig.EmbeddingSimilarityThreshold。
+func (m *CapabilityMatcher) WithEmbeddingProvider(provider EmbeddingProvider) *CapabilityMatcher {
+	if provider == nil {
+		m.embeddings = nil
+		return m
+	}
+	m.embeddings = newEmbeddingIndex(provider)
+	return m
+}
+
+// semanticCapabilityMatches 返回相似度不低于阈值的能力，按相似度递减排序。
+func (m *CapabilityMatcher) semanticCapabilityMatches(ctx context.Context, agent *AgentInfo, query string) ([]SemanticCapabilityMatch, error) {
+	if len(agent.Capabilities) == 0 {
+		return nil, nil
+	}
+	queryVector, err := m.embeddings.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := m.embeddings.capabilityVectors(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []SemanticCapabilityMatch
+	for i, cap := range agent.Capabilities {
+		similarity := cosineSimilarity(queryVector, vectors[i])
+		if similarity >= m.config.EmbeddingSimilarityThreshold {
+			matches = append(matches, SemanticCapabilityMatch{
+				Query:      query,
+				Capability: cap.Capability.Name,
+				Similarity: similarity,
+			})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	return matches, nil
+}
+
+// embeddingRequiredMatch 为未按名称命中的所需能力寻找最相近的能力。
+func (m *CapabilityMatcher) embeddingRequiredMatch(ctx context.Context, agent *AgentInfo, required string) (*SemanticCapabilityMatch, bool) {
+	matches, err := m.semanticCapabilityMatches(ctx, agent, required)
+	if err != nil {
+		m.logger.Debug("embedding capability match failed", zap.String("capability", required), zap.Error(err))
+		return nil, false
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return &matches[0], true
+}
+
+func (idx *embeddingIndex) query(ctx context.Context, text string) ([]float64, error) {
+	idx.mu.Lock()
+	if vector, ok := idx.queries[text]; ok {
+		idx.mu.Unlock()
+		return vector, nil
+	}
+	idx.mu.Unlock()
+
+	vector, err := idx.provider.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("embed query: empty vector")
+	}
+
+	idx.mu.Lock()
+	if len(idx.queries) >= maxQueryEmbeddings {
+		idx.queries = make(map[string][]float64)
+	}
+	idx.queries[text] = vector
+	idx.mu.Unlock()
+	return vector, nil
+}
+
+// capabilityVectors 返回与 agent.Capabilities 一一对应的向量，只对新增或描述变化的能力调用向量化。
+func (idx *embeddingIndex) capabilityVectors(ctx context.Context, agent *AgentInfo) ([][]float64, error) {
+	texts := make([]string, len(agent.Capabilities))
+	vectors := make([][]float64, len(agent.Capabilities))
+	var missing []int
+
+	idx.mu.Lock()
+	for i, cap := range agent.Capabilities {
+		texts[i] = capabilityEmbeddingText(cap)
+		if cached, ok := idx.capabilities[capabilityEmbeddingKey(agent, cap)]; ok && cached.text == texts[i] {
+			vectors[i] = cached.vector
+			continue
+		}
+		missing = append(missing, i)
+	}
+	idx.mu.Unlock()
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	documents := make([]string, len(missing))
+	for j, i := range missing {
+		documents[j] = texts[i]
+	}
+	embedded, err := idx.provider.EmbedDocuments(ctx, documents)
+	if err != nil {
+		return nil, fmt.Errorf("embed capability descriptions: %w", err)
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embed capability descriptions: expected %d vectors, got %d", len(missing), len(embedded))
+	}
+
+	idx.mu.Lock()
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+		idx.capabilities[capabilityEmbeddingKey(agent, agent.Capabilities[i])] = capabilityEmbedding{text: texts[i], vector: embedded[j]}
+	}
+	idx.mu.Unlock()
+	return vectors, nil
+}
+
+func capabilityEmbeddingKey(agent *AgentInfo, cap CapabilityInfo) string {
+	agentID := cap.AgentID
+	if agentID == "" && agent.Card != nil {
+		agentID = agent.Card.Name
+	}
+	return agentID + "\x00" + cap.Capability.Name
+}
+
+func capabilityEmbeddingText(cap CapabilityInfo) string {
+	if cap.Capability.Description == "" {
+		return cap.Capability.Name
+	}
+	return cap.Capability.Name + ": " + cap.Capability.Description
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
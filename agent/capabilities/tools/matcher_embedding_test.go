@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/execution/protocol/a2a"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// conceptEmbedder 按概念关键词生成向量，模拟能跨越字面差异的语义向量。
+type conceptEmbedder struct {
+	docCalls atomic.Int32
+	fail     bool
+}
+
+var embeddingConcepts = [][]string{
+	{"race", "review", "static", "lint", "bug", "analysis"},
+	{"search", "web", "lookup"},
+	{"translate", "language"},
+}
+
+func (e *conceptEmbedder) vector(text string) []float64 {
+	text = strings.ToLower(text)
+	v := make([]float64, len(embeddingConcepts))
+	for i, words := range embeddingConcepts {
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				v[i]++
+			}
+		}
+	}
+	return v
+}
+
+func (e *conceptEmbedder) EmbedQuery(_ context.Context, query string) ([]float64, error) {
+	if e.fail {
+		return nil, errors.New("embedding unavailable")
+	}
+	return e.vector(query), nil
+}
+
+func (e *conceptEmbedder) EmbedDocuments(_ context.Context, documents []string) ([][]float64, error) {
+	e.docCalls.Add(1)
+	if e.fail {
+		return nil, errors.New("embedding unavailable")
+	}
+	out := make([][]float64, len(documents))
+	for i, d := range documents {
+		out[i] = e.vector(d)
+	}
+	return out, nil
+}
+
+func registerEmbeddingAgent(t *testing.T, reg Registry, name, capName, capDescription string) {
+	t.Helper()
+	require.NoError(t, reg.RegisterAgent(context.Background(), &AgentInfo{
+		Card:   a2a.NewAgentCard(name, name, "http://localhost", "1.0"),
+		Status: AgentStatusOnline,
+		Capabilities: []CapabilityInfo{{
+			Capability: a2a.Capability{Name: capName, Description: capDescription, Type: a2a.CapabilityTypeTask},
+			AgentID:    name,
+			Status:     CapabilityStatusActive,
+			Score:      80,
+		}},
+	}))
+}
+
+func newEmbeddingTestMatcher(t *testing.T, embedder EmbeddingProvider) *CapabilityMatcher {
+	t.Helper()
+	reg := newCovTestRegistry(t)
+	registerEmbeddingAgent(t, reg, "analyzer", "static_analysis", "finds bugs with static analysis")
+	registerEmbeddingAgent(t, reg, "searcher", "web_search", "search the web")
+	config := DefaultMatcherConfig()
+	config.EnableSemanticMatching = false
+	return NewCapabilityMatcher(reg, config, zap.NewNop()).WithEmbeddingProvider(embedder)
+}
+
+func TestCapabilityMatcher_EmbeddingTaskMatch(t *testing.T) {
+	embedder := &conceptEmbedder{}
+	matcher := newEmbeddingTestMatcher(t, embedder)
+	ctx := context.Background()
+
+	results, err := matcher.Match(ctx, &MatchRequest{TaskDescription: "review my Go code for data races"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "analyzer", result.Agent.Card.Name)
+	require.Len(t, result.MatchedCapabilities, 1)
+	assert.Equal(t, "static_analysis", result.MatchedCapabilities[0].Capability.Name)
+	require.Len(t, result.SemanticMatches, 1)
+	assert.Equal(t, "static_analysis", result.SemanticMatches[0].Capability)
+	assert.InDelta(t, 1.0, result.SemanticMatches[0].Similarity, 1e-9)
+	assert.Contains(t, result.Reason, "embedding match static_analysis")
+
+	// 能力向量被缓存
+	_, err = matcher.Match(ctx, &MatchRequest{TaskDescription: "lookup something on the web"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), embedder.docCalls.Load())
+}
+
+func TestCapabilityMatcher_EmbeddingRequiredCapability(t *testing.T) {
+	matcher := newEmbeddingTestMatcher(t, &conceptEmbedder{})
+
+	result, err := matcher.MatchOne(context.Background(), &MatchRequest{RequiredCapabilities: []string{"code_review"}})
+	require.NoError(t, err)
+	assert.Equal(t, "analyzer", result.Agent.Card.Name)
+	require.Len(t, result.SemanticMatches, 1)
+	assert.Equal(t, "code_review", result.SemanticMatches[0].Query)
+
+	_, err = matcher.MatchOne(context.Background(), &MatchRequest{RequiredCapabilities: []string{"translate"}})
+	assert.Error(t, err)
+}
+
+func TestCapabilityMatcher_EmbeddingThresholdAndFallback(t *testing.T) {
+	matcher := newEmbeddingTestMatcher(t, &conceptEmbedder{})
+	results, err := matcher.Match(context.Background(), &MatchRequest{TaskDescription: "translate this language"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// 向量化失败时回退到原有匹配，不过滤代理
+	failing := newEmbeddingTestMatcher(t, &conceptEmbedder{fail: true})
+	results, err = failing.Match(context.Background(), &MatchRequest{TaskDescription: "review my code"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	for _, r := range results {
+		assert.Empty(t, r.SemanticMatches)
+	}
+}
+
+func TestDiscoveryService_SetEmbeddingProvider(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.Registry.EnableHealthCheck = false
+	config.Matcher.EnableSemanticMatching = false
+	service := NewDiscoveryService(config, zap.NewNop())
+	require.NoError(t, service.SetEmbeddingProvider(&conceptEmbedder{}))
+	registerEmbeddingAgent(t, service.registry, "analyzer", "static_analysis", "static analysis for bugs")
+	registerEmbeddingAgent(t, service.registry, "searcher", "web_search", "search the web")
+
+	agent, err := service.FindAgent(context.Background(), "review my Go code for data races", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "analyzer", agent.Card.Name)
+}
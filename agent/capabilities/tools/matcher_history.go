@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"go.uber.org/zap"
+)
+
+// neutralCostFactor 是存在定价候选时，未定价候选的费用因子。
+const neutralCostFactor = 0.5
+
+// applyHistoricalScores 为 MatchStrategyBestHistorical 计算综合分:
+// 历史质量来自注册表评分卡，空闲度为 1-Load，费用因子为最低费用与自身费用之比。
+func (m *CapabilityMatcher) applyHistoricalScores(ctx context.Context, results []*MatchResult, req *MatchRequest) {
+	provider, _ := m.registry.(ScorecardProvider)
+
+	costs := make([]float64, len(results))
+	priced := make([]bool, len(results))
+	minCost := math.Inf(1)
+	for i, result := range results {
+		cost, ok := historicalCost(result, req)
+		costs[i], priced[i] = cost, ok
+		if ok && cost < minCost {
+			minCost = cost
+		}
+	}
+
+	wq, wl, wc := m.config.HistoryQualityWeight, m.config.HistoryLoadWeight, m.config.HistoryCostWeight
+	total := wq + wl + wc
+	if total <= 0 {
+		wq, wl, wc, total = 1, 0, 0, 1
+	}
+
+	for i, result := range results {
+		quality := m.historicalQuality(ctx, provider, result)
+		idle := 1 - math.Max(0, math.Min(1, result.Agent.Load))
+
+		costFactor := 1.0
+		switch {
+		case priced[i] && costs[i] > 0:
+			costFactor = minCost / costs[i]
+		case !priced[i] && !math.IsInf(minCost, 1):
+			costFactor = neutralCostFactor
+		}
+
+		result.HistoricalQuality = quality
+		result.HistoricalScore = (wq*quality + wl*idle + wc*costFactor) / total * 100
+		if priced[i] && result.EstimatedCost == 0 {
+			result.EstimatedCost = costs[i]
+		}
+		historyReason := fmt.Sprintf("historical quality %.2f, idle %.2f, cost factor %.2f", quality, idle, costFactor)
+		if result.Reason == "" {
+			result.Reason = historyReason
+		} else {
+			result.Reason += "; " + historyReason
+		}
+	}
+}
+
+// historicalQuality 返回匹配能力的历史质量均值；没有评分卡时以能力分数代替。
+func (m *CapabilityMatcher) historicalQuality(ctx context.Context, provider ScorecardProvider, result *MatchResult) float64 {
+	caps := result.MatchedCapabilities
+	if len(caps) == 0 {
+		caps = result.Agent.Capabilities
+	}
+
+	var card *AgentScorecard
+	if provider != nil {
+		var err error
+		card, err = provider.Scorecard(ctx, result.Agent.Card.Name)
+		if err != nil {
+			m.logger.Debug("scorecard unavailable", zap.String("agent_id", result.Agent.Card.Name), zap.Error(err))
+			card = nil
+		}
+	}
+
+	if len(caps) == 0 {
+		if card != nil {
+			return card.Quality
+		}
+		return 0
+	}
+
+	var sum float64
+	for _, cap := range caps {
+		switch cs := card.Capability(cap.Capability.Name); {
+		case cs != nil:
+			sum += cs.Quality
+		case card != nil:
+			// 该能力尚无历史，使用代理整体质量
+			sum += card.Quality
+		default:
+			sum += cap.Score / 100
+		}
+	}
+	return sum / float64(len(caps))
+}
+
+// historicalCost 返回匹配能力中最低的预估费用。
+func historicalCost(result *MatchResult, req *MatchRequest) (float64, bool) {
+	caps := result.MatchedCapabilities
+	if len(caps) == 0 {
+		caps = result.Agent.Capabilities
+	}
+
+	best, found := 0.0, false
+	consider := func(capability *CapabilityInfo) {
+		candidate, _ := constraintCandidate(result.Agent, capability, req)
+		if candidate.HasCost && (!found || candidate.Cost < best) {
+			best, found = candidate.Cost, true
+		}
+	}
+	if len(caps) == 0 {
+		consider(nil)
+	}
+	for i := range caps {
+		consider(&caps[i])
+	}
+	return best, found
+}
@@ -0,0 +1,54 @@
+package tools
+
+import "sort"
+
+// 就近层级：本地注册、同区域远端、其他区域远端
+const (
+	localityTierLocal = iota
+	localityTierSameRegion
+	localityTierRemote
+)
+
+// applyLocality 实现 LocalityPreferLocal：先选有剩余容量的最近层级，
+// 更远的层级只在更近的层级全部达到 LocalCapacityThreshold 时参与；
+// 同一层级内保持策略排序，容量耗尽的代理排在有容量的代理之后。
+func (m *CapabilityMatcher) applyLocality(results []*MatchResult, req *MatchRequest) []*MatchResult {
+	tiers := make(map[*MatchResult]int, len(results))
+	best := -1
+	for _, r := range results {
+		tier := agentLocalityTier(r.Agent, req.Region)
+		tiers[r] = tier
+		if !m.capacityExhausted(r.Agent) && (best < 0 || tier < best) {
+			best = tier
+		}
+	}
+
+	kept := results[:0]
+	for _, r := range results {
+		if best < 0 || tiers[r] <= best {
+			kept = append(kept, r)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		ei, ej := m.capacityExhausted(kept[i].Agent), m.capacityExhausted(kept[j].Agent)
+		if ei != ej {
+			return !ei
+		}
+		return tiers[kept[i]] < tiers[kept[j]]
+	})
+	return kept
+}
+
+func (m *CapabilityMatcher) capacityExhausted(agent *AgentInfo) bool {
+	return m.config.LocalCapacityThreshold > 0 && agent.Load >= m.config.LocalCapacityThreshold
+}
+
+func agentLocalityTier(agent *AgentInfo, region string) int {
+	if !isFederatedAgent(agent) {
+		return localityTierLocal
+	}
+	if region != "" && agent.Metadata[MetadataOriginRegion] == region {
+		return localityTierSameRegion
+	}
+	return localityTierRemote
+}
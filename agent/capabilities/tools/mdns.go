@@ -0,0 +1,605 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toolremote "github.com/BaSui01/agentflow/agent/capabilities/tools/remote"
+	a2ashared "github.com/BaSui01/agentflow/agent/execution/protocol/a2a/shared"
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultMDNSService 是 DNS-SD 服务类型，同一局域网内的 Agent 以该类型互相发现
+	DefaultMDNSService = "_agentflow._tcp"
+
+	// MetadataDiscoverySource 标记 Agent 信息来源的元数据键
+	MetadataDiscoverySource = "discovery_source"
+	// DiscoverySourceMDNS 表示 Agent 信息来自 mDNS 能力摘要
+	DiscoverySourceMDNS = "mdns"
+
+	mdnsIPv4Group = "224.0.0.251"
+	mdnsPort      = 5353
+	mdnsDomain    = "local."
+	mdnsTTL       = 120
+
+	// TXT 记录单个字符串最长 255 字节
+	mdnsTXTMaxLen  = 255
+	mdnsTXTVersion = "1"
+)
+
+// EncodeAgentTXT 将 Agent 的能力摘要编码为 DNS-SD TXT 记录。
+// 能力与标签列表过长时拆分为 caps.0、caps.1 …… 多个键。
+func EncodeAgentTXT(info *AgentInfo) []string {
+	if info == nil || info.Card == nil {
+		return nil
+	}
+	txt := []string{"v=" + mdnsTXTVersion}
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		entry := key + "=" + value
+		if len(entry) > mdnsTXTMaxLen {
+			entry = entry[:mdnsTXTMaxLen]
+		}
+		txt = append(txt, entry)
+	}
+
+	add("name", info.Card.Name)
+	add("desc", info.Card.Description)
+	add("ver", info.Card.Version)
+	add("ep", agentEndpoint(info))
+	add("status", string(info.Status))
+	if info.Load > 0 {
+		add("load", strconv.FormatFloat(info.Load, 'f', 2, 64))
+	}
+	if info.Priority != 0 {
+		add("prio", strconv.Itoa(info.Priority))
+	}
+
+	capNames, tags := agentCapabilitySummary(info)
+	txt = append(txt, chunkTXTList("caps", capNames)...)
+	txt = append(txt, chunkTXTList("tags", tags)...)
+	return txt
+}
+
+// DecodeAgentTXT 从 DNS-SD TXT 记录还原 Agent 能力摘要。
+// 还原出的 AgentInfo 只包含摘要字段，并以 MetadataDiscoverySource 标记来源。
+func DecodeAgentTXT(txt []string) (*AgentInfo, error) {
+	values := make(map[string]string, len(txt))
+	lists := make(map[string]map[int]string)
+	for _, entry := range txt {
+		key, value, _ := strings.Cut(entry, "=")
+		if base, idx, ok := strings.Cut(key, "."); ok {
+			n, err := strconv.Atoi(idx)
+			if err != nil {
+				continue
+			}
+			if lists[base] == nil {
+				lists[base] = make(map[int]string)
+			}
+			lists[base][n] = value
+			continue
+		}
+		values[key] = value
+	}
+	if values["v"] != mdnsTXTVersion {
+		return nil, fmt.Errorf("unsupported agent TXT version %q", values["v"])
+	}
+	name := values["name"]
+	if name == "" {
+		return nil, errors.New("agent TXT record has no name")
+	}
+
+	card := a2ashared.NewAgentCard(name, values["desc"], values["ep"], values["ver"])
+	info := &AgentInfo{
+		Card:     card,
+		Status:   AgentStatus(values["status"]),
+		Endpoint: values["ep"],
+		Metadata: map[string]string{MetadataDiscoverySource: DiscoverySourceMDNS},
+	}
+	if info.Status == "" {
+		info.Status = AgentStatusOnline
+	}
+	if load, err := strconv.ParseFloat(values["load"], 64); err == nil {
+		info.Load = load
+	}
+	if prio, err := strconv.Atoi(values["prio"]); err == nil {
+		info.Priority = prio
+	}
+
+	tags := joinTXTList(lists["tags"])
+	for _, capName := range joinTXTList(lists["caps"]) {
+		card.AddCapability(capName, "", a2ashared.CapabilityTypeTask)
+		info.Capabilities = append(info.Capabilities, CapabilityInfo{
+			Capability: a2ashared.Capability{Name: capName, Type: a2ashared.CapabilityTypeTask},
+			AgentID:    name,
+			AgentName:  name,
+			Status:     CapabilityStatusActive,
+			Tags:       tags,
+		})
+	}
+	return info, nil
+}
+
+func agentEndpoint(info *AgentInfo) string {
+	if info.Endpoint != "" {
+		return info.Endpoint
+	}
+	return info.Card.URL
+}
+
+// agentCapabilitySummary 返回去重后的能力名称与标签
+func agentCapabilitySummary(info *AgentInfo) (capNames, tags []string) {
+	seenCap := make(map[string]bool)
+	seenTag := make(map[string]bool)
+	addCap := func(name string) {
+		if name != "" && !seenCap[name] {
+			seenCap[name] = true
+			capNames = append(capNames, name)
+		}
+	}
+	for _, c := range info.Capabilities {
+		addCap(c.Capability.Name)
+		for _, tag := range c.Tags {
+			if tag != "" && !seenTag[tag] {
+				seenTag[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	for _, c := range info.Card.Capabilities {
+		addCap(c.Name)
+	}
+	return capNames, tags
+}
+
+// chunkTXTList 将列表按逗号拼接并拆分到多个不超过 255 字节的 TXT 字符串
+func chunkTXTList(key string, values []string) []string {
+	var out []string
+	var current []string
+	size := 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		out = append(out, fmt.Sprintf("%s.%d=%s", key, len(out), strings.Join(current, ",")))
+		current, size = nil, 0
+	}
+	for _, v := range values {
+		prefix := len(key) + len(strconv.Itoa(len(out))) + 2
+		if prefix+len(v) > mdnsTXTMaxLen {
+			continue
+		}
+		if len(current) > 0 && prefix+size+1+len(v) > mdnsTXTMaxLen {
+			flush()
+		}
+		if len(current) > 0 {
+			size++
+		}
+		current = append(current, v)
+		size += len(v)
+	}
+	flush()
+	return out
+}
+
+func joinTXTList(chunks map[int]string) []string {
+	if len(chunks) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(chunks))
+	for i := range chunks {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var out []string
+	for _, i := range indexes {
+		out = append(out, toolremote.SplitAndTrimCSV(chunks[i])...)
+	}
+	return out
+}
+
+// mdnsServiceName 返回完整服务域名，如 _agentflow._tcp.local.
+func mdnsServiceName(service string) string {
+	if service == "" {
+		service = DefaultMDNSService
+	}
+	return strings.TrimSuffix(service, ".") + "." + mdnsDomain
+}
+
+// mdnsInstanceName 返回 Agent 的服务实例域名，实例标签不能含点且不超过 63 字节
+func mdnsInstanceName(service, agentName string) string {
+	label := strings.ReplaceAll(agentName, ".", "-")
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return label + "." + mdnsServiceName(service)
+}
+
+// buildMDNSQuery 构造查询服务实例的 PTR 请求
+func buildMDNSQuery(service string) ([]byte, error) {
+	name, err := dnsmessage.NewName(mdnsServiceName(service))
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// buildMDNSAnnouncement 构造 Agent 的 PTR/SRV/TXT 响应；ttl 为 0 时即 goodbye 报文
+func buildMDNSAnnouncement(service string, agents []*AgentInfo, ttl uint32) ([]byte, error) {
+	serviceName, err := dnsmessage.NewName(mdnsServiceName(service))
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	b.EnableCompression()
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	for _, info := range agents {
+		if info == nil || info.Card == nil || info.Card.Name == "" {
+			continue
+		}
+		instance, err := dnsmessage.NewName(mdnsInstanceName(service, info.Card.Name))
+		if err != nil {
+			return nil, err
+		}
+		hdr := dnsmessage.ResourceHeader{Name: serviceName, Class: dnsmessage.ClassINET, TTL: ttl}
+		if err := b.PTRResource(hdr, dnsmessage.PTRResource{PTR: instance}); err != nil {
+			return nil, err
+		}
+		hdr.Name = instance
+		if host, port, ok := endpointHostPort(agentEndpoint(info)); ok {
+			target, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+			if err == nil {
+				if err := b.SRVResource(hdr, dnsmessage.SRVResource{Target: target, Port: port}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := b.TXTResource(hdr, dnsmessage.TXTResource{TXT: EncodeAgentTXT(info)}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+func endpointHostPort(endpoint string) (string, uint16, bool) {
+	if endpoint == "" {
+		return "", 0, false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", 0, false
+	}
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	return u.Hostname(), uint16(n), true
+}
+
+// mdnsMessage 是解析后的 mDNS 报文
+type mdnsMessage struct {
+	// query 表示报文查询了本服务类型
+	query bool
+	// agents 为响应中携带的 Agent 摘要；goodbye 报文中的 Agent 状态为 offline
+	agents []*AgentInfo
+}
+
+// parseMDNSMessage 解析 mDNS 报文，只保留与本服务类型相关的问题与记录
+func parseMDNSMessage(service string, msg []byte) (*mdnsMessage, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := strings.ToLower(mdnsServiceName(service))
+	out := &mdnsMessage{}
+
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil, err
+	}
+	if !header.Response {
+		for _, q := range questions {
+			if (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) && strings.ToLower(q.Name.String()) == serviceName {
+				out.query = true
+			}
+		}
+		return out, nil
+	}
+
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SkipAllAuthorities(); err != nil {
+		return nil, err
+	}
+	additionals, err := p.AllAdditionals()
+	if err != nil {
+		return nil, err
+	}
+
+	srv := make(map[string]*dnsmessage.SRVResource)
+	type txtRecord struct {
+		txt []string
+		ttl uint32
+	}
+	var order []string
+	txts := make(map[string]txtRecord)
+	for _, r := range append(answers, additionals...) {
+		name := strings.ToLower(r.Header.Name.String())
+		if !strings.HasSuffix(name, "."+serviceName) {
+			continue
+		}
+		switch body := r.Body.(type) {
+		case *dnsmessage.SRVResource:
+			srv[name] = body
+		case *dnsmessage.TXTResource:
+			if _, ok := txts[name]; !ok {
+				order = append(order, name)
+			}
+			txts[name] = txtRecord{txt: body.TXT, ttl: r.Header.TTL}
+		}
+	}
+	for _, name := range order {
+		record := txts[name]
+		info, err := DecodeAgentTXT(record.txt)
+		if err != nil {
+			continue
+		}
+		if info.Endpoint == "" {
+			if s := srv[name]; s != nil {
+				info.Endpoint = fmt.Sprintf("http://%s:%d", strings.TrimSuffix(s.Target.String(), "."), s.Port)
+				info.Card.URL = info.Endpoint
+			}
+		}
+		if record.ttl == 0 {
+			info.Status = AgentStatusOffline
+		}
+		out.agents = append(out.agents, info)
+	}
+	return out, nil
+}
+
+// startMDNS 加入 mDNS 组播组并开始应答查询、接收其他 Agent 的通告
+func (p *DiscoveryProtocol) startMDNS(ctx context.Context) error {
+	addr := &net.UDPAddr{IP: net.ParseIP(mdnsIPv4Group), Port: mdnsPort}
+	conn, err := net.ListenMulticastUDP("udp4", nil, addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on mDNS group: %w", err)
+	}
+	if err := conn.SetReadBuffer(65536); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set mDNS read buffer: %w", err)
+	}
+	p.mdnsConn = conn
+	p.mdnsAddr = addr
+
+	p.wg.Add(2)
+	go p.mdnsListener(ctx)
+	go p.mdnsAnnouncer()
+
+	if err := p.queryMDNS(); err != nil {
+		p.logger.Debug("initial mDNS query failed", zap.Error(err))
+	}
+
+	p.logger.Info("mDNS discovery started", zap.String("service", mdnsServiceName(p.config.MDNSService)))
+	return nil
+}
+
+func (p *DiscoveryProtocol) mdnsListener(ctx context.Context) {
+	defer p.wg.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		select {
+		case <-p.done:
+			return
+		default:
+			if err := p.mdnsConn.SetReadDeadline(time.Now().Add(1 * time.Second)); err != nil {
+				p.logger.Debug("failed to set mDNS read deadline", zap.Error(err))
+				continue
+			}
+			n, _, err := p.mdnsConn.ReadFromUDP(buf)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					continue
+				}
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				p.logger.Debug("mDNS read error", zap.Error(err))
+				continue
+			}
+			p.handleMDNSPacket(ctx, buf[:n])
+		}
+	}
+}
+
+// mdnsAnnouncer 按 AnnounceInterval 周期性重发本地 Agent 的通告，保持对端缓存新鲜
+func (p *DiscoveryProtocol) mdnsAnnouncer() {
+	defer p.wg.Done()
+
+	interval := p.config.AnnounceInterval
+	if interval <= 0 {
+		interval = DefaultProtocolConfig().AnnounceInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			if err := p.announceMDNS(p.localMDNSAgents(), mdnsTTL); err != nil {
+				p.logger.Debug("periodic mDNS announcement failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (p *DiscoveryProtocol) handleMDNSPacket(ctx context.Context, packet []byte) {
+	msg, err := parseMDNSMessage(p.config.MDNSService, packet)
+	if err != nil {
+		p.logger.Debug("failed to parse mDNS packet", zap.Error(err))
+		return
+	}
+	if msg.query {
+		if err := p.announceMDNS(p.localMDNSAgents(), mdnsTTL); err != nil {
+			p.logger.Debug("failed to answer mDNS query", zap.Error(err))
+		}
+	}
+	for _, info := range msg.agents {
+		p.processMDNSAnnouncement(ctx, info)
+	}
+}
+
+// processMDNSAnnouncement 合并 mDNS 发现的 Agent 摘要。
+// 本地 Agent 优先；已通过本地或 HTTP 注册了完整信息的 Agent 只刷新状态与负载，不被摘要覆盖。
+func (p *DiscoveryProtocol) processMDNSAnnouncement(ctx context.Context, info *AgentInfo) {
+	if info == nil || info.Card == nil {
+		return
+	}
+	agentID := info.Card.Name
+
+	p.localMu.Lock()
+	if existing, ok := p.localAgents[agentID]; ok && existing.IsLocal {
+		p.localMu.Unlock()
+		return
+	}
+	if info.Status == AgentStatusOffline {
+		delete(p.localAgents, agentID)
+	} else {
+		info.IsLocal = false
+		info.LastHeartbeat = time.Now()
+		p.localAgents[agentID] = info
+	}
+	p.localMu.Unlock()
+
+	if p.registry != nil {
+		p.mergeMDNSIntoRegistry(ctx, info)
+	}
+
+	p.notifyHandlers(info)
+	p.logger.Debug("received mDNS announcement",
+		zap.String("agent_id", agentID),
+		zap.String("status", string(info.Status)),
+	)
+}
+
+func (p *DiscoveryProtocol) mergeMDNSIntoRegistry(ctx context.Context, info *AgentInfo) {
+	agentID := info.Card.Name
+	existing, err := p.registry.GetAgent(ctx, agentID)
+	if err != nil || existing == nil {
+		if info.Status == AgentStatusOffline {
+			return
+		}
+		if err := p.registry.RegisterAgent(ctx, info); err != nil {
+			p.logger.Debug("failed to register agent from mDNS", zap.String("agent_id", agentID), zap.Error(err))
+		}
+		return
+	}
+
+	if existing.Metadata[MetadataDiscoverySource] == DiscoverySourceMDNS && info.Status != AgentStatusOffline {
+		if err := p.registry.UpdateAgent(ctx, info); err != nil {
+			p.logger.Debug("failed to update agent from mDNS", zap.String("agent_id", agentID), zap.Error(err))
+		}
+		return
+	}
+
+	// 完整信息来自其他来源，或摘要收到 goodbye：只同步存活状态与负载
+	if err := p.registry.UpdateAgentStatus(ctx, agentID, info.Status); err != nil {
+		p.logger.Debug("failed to update agent status from mDNS", zap.String("agent_id", agentID), zap.Error(err))
+	}
+	if info.Status != AgentStatusOffline {
+		if err := p.registry.UpdateAgentLoad(ctx, agentID, info.Load); err != nil {
+			p.logger.Debug("failed to update agent load from mDNS", zap.String("agent_id", agentID), zap.Error(err))
+		}
+		if hb, ok := p.registry.(interface {
+			Heartbeat(ctx context.Context, agentID string) error
+		}); ok {
+			_ = hb.Heartbeat(ctx, agentID)
+		}
+	}
+}
+
+func (p *DiscoveryProtocol) localMDNSAgents() []*AgentInfo {
+	p.localMu.RLock()
+	defer p.localMu.RUnlock()
+
+	agents := make([]*AgentInfo, 0, len(p.localAgents))
+	for _, agent := range p.localAgents {
+		if agent.IsLocal {
+			agents = append(agents, agent)
+		}
+	}
+	return agents
+}
+
+// announceMDNS 向 mDNS 组播组发送 Agent 通告
+func (p *DiscoveryProtocol) announceMDNS(agents []*AgentInfo, ttl uint32) error {
+	if p.mdnsConn == nil || p.mdnsAddr == nil {
+		return fmt.Errorf("mDNS not initialized")
+	}
+	if len(agents) == 0 {
+		return nil
+	}
+	data, err := buildMDNSAnnouncement(p.config.MDNSService, agents, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to build mDNS announcement: %w", err)
+	}
+	_, err = p.mdnsConn.WriteToUDP(data, p.mdnsAddr)
+	return err
+}
+
+// queryMDNS 发送服务查询，对端的应答由 mdnsListener 异步处理
+func (p *DiscoveryProtocol) queryMDNS() error {
+	if p.mdnsConn == nil || p.mdnsAddr == nil {
+		return fmt.Errorf("mDNS not initialized")
+	}
+	data, err := buildMDNSQuery(p.config.MDNSService)
+	if err != nil {
+		return err
+	}
+	_, err = p.mdnsConn.WriteToUDP(data, p.mdnsAddr)
+	return err
+}
+
+// stopMDNS 发送 goodbye 报文并关闭连接
+func (p *DiscoveryProtocol) stopMDNS() {
+	if p.mdnsConn == nil {
+		return
+	}
+	if err := p.announceMDNS(p.localMDNSAgents(), 0); err != nil {
+		p.logger.Debug("failed to send mDNS goodbye", zap.Error(err))
+	}
+	p.mdnsConn.Close()
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/execution/protocol/a2a"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func mdnsTestAgent(name string, caps ...string) *AgentInfo {
+	card := a2a.NewAgentCard(name, "Research agent", "http://10.0.0.5:8080", "1.2.0")
+	info := &AgentInfo{Card: card, Status: AgentStatusOnline, Load: 0.25, Priority: 3, IsLocal: true}
+	for _, c := range caps {
+		info.Capabilities = append(info.Capabilities, CapabilityInfo{
+			Capability: a2a.Capability{Name: c, Type: a2a.CapabilityTypeTask},
+			Tags:       []string{"research"},
+		})
+	}
+	return info
+}
+
+func TestAgentTXTRoundTrip(t *testing.T) {
+	info := mdnsTestAgent("researcher", "web_search", "summarize")
+
+	decoded, err := DecodeAgentTXT(EncodeAgentTXT(info))
+	require.NoError(t, err)
+	assert.Equal(t, "researcher", decoded.Card.Name)
+	assert.Equal(t, "Research agent", decoded.Card.Description)
+	assert.Equal(t, "1.2.0", decoded.Card.Version)
+	assert.Equal(t, "http://10.0.0.5:8080", decoded.Endpoint)
+	assert.Equal(t, AgentStatusOnline, decoded.Status)
+	assert.InDelta(t, 0.25, decoded.Load, 1e-9)
+	assert.Equal(t, 3, decoded.Priority)
+	assert.False(t, decoded.IsLocal)
+	assert.Equal(t, DiscoverySourceMDNS, decoded.Metadata[MetadataDiscoverySource])
+	require.Len(t, decoded.Capabilities, 2)
+	assert.Equal(t, "web_search", decoded.Capabilities[0].Capability.Name)
+	assert.Equal(t, []string{"research"}, decoded.Capabilities[1].Tags)
+}
+
+func TestAgentTXTSplitsLongCapabilityLists(t *testing.T) {
+	caps := make([]string, 40)
+	for i := range caps {
+		caps[i] = fmt.Sprintf("capability_number_%02d", i)
+	}
+	txt := EncodeAgentTXT(mdnsTestAgent("wide", caps...))
+	chunks := 0
+	for _, entry := range txt {
+		assert.LessOrEqual(t, len(entry), mdnsTXTMaxLen)
+		if strings.HasPrefix(entry, "caps.") {
+			chunks++
+		}
+	}
+	assert.Greater(t, chunks, 1)
+
+	decoded, err := DecodeAgentTXT(txt)
+	require.NoError(t, err)
+	require.Len(t, decoded.Capabilities, len(caps))
+	assert.Equal(t, caps[39], decoded.Capabilities[39].Capability.Name)
+}
+
+func TestDecodeAgentTXTRejectsForeignRecords(t *testing.T) {
+	_, err := DecodeAgentTXT([]string{"path=/printer"})
+	assert.Error(t, err)
+	_, err = DecodeAgentTXT([]string{"v=1"})
+	assert.Error(t, err)
+}
+
+func TestMDNSMessageRoundTrip(t *testing.T) {
+	query, err := buildMDNSQuery(DefaultMDNSService)
+	require.NoError(t, err)
+	msg, err := parseMDNSMessage(DefaultMDNSService, query)
+	require.NoError(t, err)
+	assert.True(t, msg.query)
+
+	other, err := parseMDNSMessage("_other._tcp", query)
+	require.NoError(t, err)
+	assert.False(t, other.query)
+
+	packet, err := buildMDNSAnnouncement(DefaultMDNSService, []*AgentInfo{
+		mdnsTestAgent("agent.one", "translate"),
+		mdnsTestAgent("agent-two", "code_review"),
+	}, mdnsTTL)
+	require.NoError(t, err)
+	msg, err = parseMDNSMessage(DefaultMDNSService, packet)
+	require.NoError(t, err)
+	assert.False(t, msg.query)
+	require.Len(t, msg.agents, 2)
+	assert.Equal(t, "agent.one", msg.agents[0].Card.Name)
+	assert.Equal(t, AgentStatusOnline, msg.agents[0].Status)
+
+	goodbye, err := buildMDNSAnnouncement(DefaultMDNSService, []*AgentInfo{mdnsTestAgent("agent-two")}, 0)
+	require.NoError(t, err)
+	msg, err = parseMDNSMessage(DefaultMDNSService, goodbye)
+	require.NoError(t, err)
+	require.Len(t, msg.agents, 1)
+	assert.Equal(t, AgentStatusOffline, msg.agents[0].Status)
+}
+
+func TestProcessMDNSAnnouncementMergeSemantics(t *testing.T) {
+	ctx := context.Background()
+	reg := newCovTestRegistry(t)
+	proto := NewDiscoveryProtocol(&ProtocolConfig{EnableLocal: true, EnableMDNS: true}, reg, zap.NewNop())
+
+	// 本地 Agent 不会被同名 mDNS 摘要覆盖
+	local := mdnsTestAgent("self", "plan")
+	require.NoError(t, proto.Announce(ctx, local))
+	echo := mdnsTestAgent("self")
+	echo.IsLocal = false
+	proto.processMDNSAnnouncement(ctx, echo)
+	agents, err := proto.Discover(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.True(t, agents[0].IsLocal)
+
+	// 已通过 HTTP 注册完整信息的 Agent 只刷新状态与负载
+	full := mdnsTestAgent("remote-full", "plan", "execute")
+	full.IsLocal = false
+	full.Card.Description = "Full card from HTTP"
+	require.NoError(t, reg.RegisterAgent(ctx, full))
+	summary, err := DecodeAgentTXT(EncodeAgentTXT(mdnsTestAgent("remote-full")))
+	require.NoError(t, err)
+	summary.Status = AgentStatusBusy
+	proto.processMDNSAnnouncement(ctx, summary)
+	stored, err := reg.GetAgent(ctx, "remote-full")
+	require.NoError(t, err)
+	assert.Equal(t, "Full card from HTTP", stored.Card.Description)
+	assert.Len(t, stored.Capabilities, 2)
+	assert.Equal(t, AgentStatusBusy, stored.Status)
+
+	// 新 Agent 以摘要注册，goodbye 后从缓存移除并标记离线
+	peer, err := DecodeAgentTXT(EncodeAgentTXT(mdnsTestAgent("peer", "translate")))
+	require.NoError(t, err)
+	proto.processMDNSAnnouncement(ctx, peer)
+	stored, err = reg.GetAgent(ctx, "peer")
+	require.NoError(t, err)
+	assert.Equal(t, DiscoverySourceMDNS, stored.Metadata[MetadataDiscoverySource])
+	remote := true
+	agents, err = proto.Discover(ctx, &DiscoveryFilter{Remote: &remote, Capabilities: []string{"translate"}})
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.Equal(t, "peer", agents[0].Card.Name)
+
+	bye, err := DecodeAgentTXT(EncodeAgentTXT(mdnsTestAgent("peer")))
+	require.NoError(t, err)
+	bye.Status = AgentStatusOffline
+	proto.processMDNSAnnouncement(ctx, bye)
+	stored, err = reg.GetAgent(ctx, "peer")
+	require.NoError(t, err)
+	assert.Equal(t, AgentStatusOffline, stored.Status)
+	proto.localMu.RLock()
+	_, cached := proto.localAgents["peer"]
+	proto.localMu.RUnlock()
+	assert.False(t, cached)
+}
@@ -0,0 +1,468 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	"go.uber.org/zap"
+)
+
+const (
+	// WebhookSignatureHeader 携带 HMAC-SHA256 签名，格式为 "sha256=<hex>"，签名内容为 "<timestamp>.<body>"
+	WebhookSignatureHeader = "X-AgentFlow-Signature"
+	// WebhookTimestampHeader 携带签名时的 Unix 秒级时间戳，接收方可据此拒绝重放
+	WebhookTimestampHeader = "X-AgentFlow-Timestamp"
+	// WebhookEventHeader 携带事件类型
+	WebhookEventHeader = "X-AgentFlow-Event"
+
+	// DefaultNotificationSubjectPrefix 是事件总线主题前缀，完整主题为 "<prefix>.<event_type>"
+	DefaultNotificationSubjectPrefix = "agentflow.discovery"
+)
+
+// NotificationSink 是注册中心变更事件的外部投递目标。
+type NotificationSink interface {
+	// Name 返回目标名称，用于日志与死信记录。
+	Name() string
+	// Deliver 投递单个事件，返回错误时由通知器重试。
+	Deliver(ctx context.Context, event *DiscoveryEvent) error
+}
+
+// WebhookSink 以 HTTP POST 投递 JSON 事件，配置 Secret 时附带 HMAC-SHA256 签名。
+type WebhookSink struct {
+	name    string
+	url     string
+	secret  []byte
+	headers map[string]string
+	client  *http.Client
+	now     func() time.Time
+}
+
+// WebhookSinkConfig 配置 WebhookSink。
+type WebhookSinkConfig struct {
+	Name    string            `json:"name,omitempty"`
+	URL     string            `json:"url"`
+	Secret  string            `json:"-"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout time.Duration     `json:"timeout,omitempty"`
+}
+
+// NewWebhookSink 创建 Webhook 投递目标。
+func NewWebhookSink(config WebhookSinkConfig) (*WebhookSink, error) {
+	if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return nil, fmt.Errorf("webhook url must be http or https: %q", config.URL)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	name := config.Name
+	if name == "" {
+		name = "webhook:" + config.URL
+	}
+	return &WebhookSink{
+		name:    name,
+		url:     config.URL,
+		secret:  []byte(config.Secret),
+		headers: config.Headers,
+		client:  tlsutil.SecureHTTPClient(config.Timeout),
+		now:     time.Now,
+	}, nil
+}
+
+// Name 返回目标名称。
+func (s *WebhookSink) Name() string { return s.name }
+
+// Deliver 发送事件，非 2xx 响应视为失败。
+func (s *WebhookSink) Deliver(ctx context.Context, event *DiscoveryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(event.Type))
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(s.now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookPayload 计算 Webhook 签名头的值，接收方可用相同方法校验。
+func SignWebhookPayload(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 以常量时间比较校验 Webhook 签名。
+func VerifyWebhookSignature(secret []byte, timestamp string, body []byte, signature string) bool {
+	expected := SignWebhookPayload(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// BusPublisher 是消息总线客户端的最小抽象，NATS、Kafka 等客户端通过适配实现。
+type BusPublisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// BusPublisherFunc 将函数适配为 BusPublisher。
+type BusPublisherFunc func(ctx context.Context, subject string, data []byte) error
+
+// Publish 调用函数本身。
+func (f BusPublisherFunc) Publish(ctx context.Context, subject string, data []byte) error {
+	return f(ctx, subject, data)
+}
+
+// BusSink 将事件以 JSON 发布到 "<prefix>.<event_type>" 主题（Kafka 下即 topic）。
+type BusSink struct {
+	name      string
+	prefix    string
+	publisher BusPublisher
+}
+
+// NewBusSink 创建消息总线投递目标，prefix 为空时使用 DefaultNotificationSubjectPrefix。
+func NewBusSink(name, prefix string, publisher BusPublisher) (*BusSink, error) {
+	if publisher == nil {
+		return nil, fmt.Errorf("bus publisher is nil")
+	}
+	if prefix == "" {
+		prefix = DefaultNotificationSubjectPrefix
+	}
+	if name == "" {
+		name = "bus:" + prefix
+	}
+	return &BusSink{name: name, prefix: prefix, publisher: publisher}, nil
+}
+
+// Name 返回目标名称。
+func (s *BusSink) Name() string { return s.name }
+
+// Deliver 发布事件。
+func (s *BusSink) Deliver(ctx context.Context, event *DiscoveryEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return s.publisher.Publish(ctx, s.prefix+"."+string(event.Type), data)
+}
+
+// DeadLetter 记录重试耗尽或无法入队的事件。
+type DeadLetter struct {
+	Sink     string          `json:"sink"`
+	Event    *DiscoveryEvent `json:"event"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	FailedAt time.Time       `json:"failed_at"`
+}
+
+// DeadLetterQueue 保存投递失败的事件，便于告警与人工重放。
+type DeadLetterQueue interface {
+	Put(ctx context.Context, letter *DeadLetter) error
+}
+
+// MemoryDeadLetterQueue 是有容量上限的内存死信队列，满时丢弃最旧的记录。
+type MemoryDeadLetterQueue struct {
+	mu       sync.Mutex
+	letters  []*DeadLetter
+	capacity int
+}
+
+// NewMemoryDeadLetterQueue 创建内存死信队列，capacity <= 0 时为 1000。
+func NewMemoryDeadLetterQueue(capacity int) *MemoryDeadLetterQueue {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &MemoryDeadLetterQueue{capacity: capacity}
+}
+
+// Put 追加死信。
+func (q *MemoryDeadLetterQueue) Put(_ context.Context, letter *DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.letters) >= q.capacity {
+		q.letters = q.letters[1:]
+	}
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+// List 返回当前死信的副本。
+func (q *MemoryDeadLetterQueue) List() []*DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*DeadLetter(nil), q.letters...)
+}
+
+// Drain 取出并清空全部死信。
+func (q *MemoryDeadLetterQueue) Drain() []*DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	letters := q.letters
+	q.letters = nil
+	return letters
+}
+
+// NotifierConfig 配置注册中心变更通知。
+type NotifierConfig struct {
+	// Events 是需要外发的事件类型，为空时为注册、注销与健康检查失败。
+	Events []DiscoveryEventType `json:"events,omitempty"`
+
+	// MaxRetries 是首次投递失败后的最大重试次数。
+	MaxRetries int `json:"max_retries"`
+
+	// InitialBackoff 与 MaxBackoff 控制指数退避。
+	InitialBackoff time.Duration `json:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff"`
+
+	// DeliveryTimeout 是单次投递的超时。
+	DeliveryTimeout time.Duration `json:"delivery_timeout"`
+
+	// QueueSize 是待投递事件的缓冲上限，溢出的事件直接进入死信队列。
+	QueueSize int `json:"queue_size"`
+}
+
+// DefaultNotifierConfig 返回默认的通知配置。
+func DefaultNotifierConfig() NotifierConfig {
+	return NotifierConfig{
+		Events: []DiscoveryEventType{
+			DiscoveryEventAgentRegistered,
+			DiscoveryEventAgentUnregistered,
+			DiscoveryEventHealthCheckFailed,
+		},
+		MaxRetries:      3,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      10 * time.Second,
+		DeliveryTimeout: 10 * time.Second,
+		QueueSize:       256,
+	}
+}
+
+// RegistryNotifier 订阅注册中心事件并投递到外部目标，每个目标独立重试，重试耗尽后写入死信队列。
+type RegistryNotifier struct {
+	registry Registry
+	sinks    []NotificationSink
+	dlq      DeadLetterQueue
+	config   NotifierConfig
+	events   map[DiscoveryEventType]bool
+	logger   *zap.Logger
+
+	mu             sync.Mutex
+	cancel         context.CancelFunc
+	queue          chan *DiscoveryEvent
+	subscriptionID string
+	wg             sync.WaitGroup
+}
+
+// NewRegistryNotifier 创建注册中心变更通知器，dlq 为 nil 时使用内存死信队列。
+func NewRegistryNotifier(registry Registry, config NotifierConfig, dlq DeadLetterQueue, logger *zap.Logger, sinks ...NotificationSink) (*RegistryNotifier, error) {
+	if registry == nil {
+		return nil, fmt.Errorf("notifier requires a registry")
+	}
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("notifier requires at least one sink")
+	}
+	defaults := DefaultNotifierConfig()
+	if len(config.Events) == 0 {
+		config.Events = defaults.Events
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = max(defaults.MaxBackoff, config.InitialBackoff)
+	}
+	if config.DeliveryTimeout <= 0 {
+		config.DeliveryTimeout = defaults.DeliveryTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if dlq == nil {
+		dlq = NewMemoryDeadLetterQueue(0)
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	events := make(map[DiscoveryEventType]bool, len(config.Events))
+	for _, t := range config.Events {
+		events[t] = true
+	}
+	return &RegistryNotifier{
+		registry: registry,
+		sinks:    sinks,
+		dlq:      dlq,
+		config:   config,
+		events:   events,
+		logger:   logger.With(zap.String("component", "registry_notifier")),
+	}, nil
+}
+
+// DeadLetters 返回通知器使用的死信队列。
+func (n *RegistryNotifier) DeadLetters() DeadLetterQueue {
+	return n.dlq
+}
+
+// Start 订阅注册中心事件并启动投递。
+func (n *RegistryNotifier) Start(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.cancel != nil {
+		return fmt.Errorf("notifier already started")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	n.cancel = cancel
+	queue := make(chan *DiscoveryEvent, n.config.QueueSize)
+	n.queue = queue
+
+	n.subscriptionID = n.registry.Subscribe(func(event *DiscoveryEvent) {
+		n.enqueue(runCtx, event, queue)
+	})
+
+	n.wg.Add(1)
+	go n.deliverLoop(runCtx, queue)
+	return nil
+}
+
+// Stop 取消订阅并停止投递，尚未投递的事件被丢弃。
+func (n *RegistryNotifier) Stop() {
+	n.mu.Lock()
+	cancel := n.cancel
+	n.cancel = nil
+	n.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	n.registry.Unsubscribe(n.subscriptionID)
+	cancel()
+	n.wg.Wait()
+}
+
+func (n *RegistryNotifier) enqueue(ctx context.Context, event *DiscoveryEvent, queue chan<- *DiscoveryEvent) {
+	if event == nil || !n.events[event.Type] || ctx.Err() != nil {
+		return
+	}
+	select {
+	case queue <- event:
+	default:
+		n.logger.Warn("notification queue full, dead-lettering event",
+			zap.String("event_type", string(event.Type)),
+			zap.String("agent_id", event.AgentID),
+		)
+		for _, sink := range n.sinks {
+			n.deadLetter(ctx, sink, event, 0, fmt.Errorf("notification queue full"))
+		}
+	}
+}
+
+func (n *RegistryNotifier) deliverLoop(ctx context.Context, queue <-chan *DiscoveryEvent) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-queue:
+			var wg sync.WaitGroup
+			for _, sink := range n.sinks {
+				wg.Add(1)
+				go func(sink NotificationSink) {
+					defer wg.Done()
+					n.deliver(ctx, sink, event)
+				}(sink)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+// deliver 以指数退避重试单个目标，重试耗尽后写入死信队列。
+func (n *RegistryNotifier) deliver(ctx context.Context, sink NotificationSink, event *DiscoveryEvent) {
+	backoff := n.config.InitialBackoff
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt <= n.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, n.config.MaxBackoff)
+		}
+		attempts++
+		deliverCtx, cancel := context.WithTimeout(ctx, n.config.DeliveryTimeout)
+		lastErr = sink.Deliver(deliverCtx, event)
+		cancel()
+		if lastErr == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		n.logger.Debug("notification delivery failed",
+			zap.String("sink", sink.Name()),
+			zap.String("event_type", string(event.Type)),
+			zap.Int("attempt", attempts),
+			zap.Error(lastErr),
+		)
+	}
+	n.deadLetter(ctx, sink, event, attempts, lastErr)
+}
+
+func (n *RegistryNotifier) deadLetter(ctx context.Context, sink NotificationSink, event *DiscoveryEvent, attempts int, cause error) {
+	letter := &DeadLetter{
+		Sink:     sink.Name(),
+		Event:    event,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		FailedAt: time.Now(),
+	}
+	if err := n.dlq.Put(context.WithoutCancel(ctx), letter); err != nil {
+		n.logger.Error("failed to dead-letter notification",
+			zap.String("sink", sink.Name()),
+			zap.String("event_type", string(event.Type)),
+			zap.Error(err),
+		)
+		return
+	}
+	n.logger.Warn("notification dead-lettered",
+		zap.String("sink", sink.Name()),
+		zap.String("event_type", string(event.Type)),
+		zap.String("agent_id", event.AgentID),
+		zap.Int("attempts", attempts),
+		zap.Error(cause),
+	)
+}
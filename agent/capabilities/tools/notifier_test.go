@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recordedPublish struct {
+	subject string
+	event   DiscoveryEvent
+}
+
+type recordingPublisher struct {
+	mu        sync.Mutex
+	published []recordedPublish
+	failures  int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, subject string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	var event DiscoveryEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	p.published = append(p.published, recordedPublish{subject: subject, event: event})
+	return nil
+}
+
+func (p *recordingPublisher) snapshot() []recordedPublish {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]recordedPublish(nil), p.published...)
+}
+
+func fastNotifierConfig() NotifierConfig {
+	config := DefaultNotifierConfig()
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 5 * time.Millisecond
+	return config
+}
+
+func TestWebhookSink_SignsPayload(t *testing.T) {
+	secret := []byte("s3cret")
+	var verified atomic.Bool
+	var eventHeader atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified.Store(VerifyWebhookSignature(secret, r.Header.Get(WebhookTimestampHeader), body, r.Header.Get(WebhookSignatureHeader)))
+		eventHeader.Store(r.Header.Get(WebhookEventHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{URL: server.URL, Secret: string(secret)})
+	require.NoError(t, err)
+	require.NoError(t, sink.Deliver(context.Background(), &DiscoveryEvent{Type: DiscoveryEventAgentRegistered, AgentID: "a1"}))
+	assert.True(t, verified.Load())
+	assert.Equal(t, string(DiscoveryEventAgentRegistered), eventHeader.Load())
+
+	assert.False(t, VerifyWebhookSignature(secret, "1", []byte("{}"), "sha256=deadbeef"))
+
+	_, err = NewWebhookSink(WebhookSinkConfig{URL: "ftp://example.com"})
+	assert.Error(t, err)
+}
+
+func TestWebhookSink_Non2xxFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{URL: server.URL})
+	require.NoError(t, err)
+	assert.ErrorContains(t, sink.Deliver(context.Background(), &DiscoveryEvent{Type: DiscoveryEventAgentUnregistered}), "502")
+}
+
+func TestRegistryNotifier_PublishesFilteredEventsWithRetry(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	publisher := &recordingPublisher{failures: 2}
+	sink, err := NewBusSink("", "", publisher)
+	require.NoError(t, err)
+
+	notifier, err := NewRegistryNotifier(reg, fastNotifierConfig(), nil, zap.NewNop(), sink)
+	require.NoError(t, err)
+	require.NoError(t, notifier.Start(context.Background()))
+	defer notifier.Stop()
+
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	require.Eventually(t, func() bool { return len(publisher.snapshot()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// 更新事件不在默认外发范围内
+	require.NoError(t, reg.UpdateAgentStatus(context.Background(), "agent1", AgentStatusBusy))
+	require.NoError(t, reg.UnregisterAgent(context.Background(), "agent1"))
+	require.Eventually(t, func() bool { return len(publisher.snapshot()) == 2 }, time.Second, 5*time.Millisecond)
+
+	published := publisher.snapshot()
+	assert.Equal(t, "agentflow.discovery.agent_registered", published[0].subject)
+	assert.Equal(t, "agent1", published[0].event.AgentID)
+	assert.Equal(t, "agentflow.discovery.agent_unregistered", published[1].subject)
+	assert.Empty(t, notifier.DeadLetters().(*MemoryDeadLetterQueue).List())
+}
+
+func TestRegistryNotifier_DeadLettersAfterRetries(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	var attempts atomic.Int32
+	failing, err := NewBusSink("broken", "", BusPublisherFunc(func(context.Context, string, []byte) error {
+		attempts.Add(1)
+		return errors.New("broker unavailable")
+	}))
+	require.NoError(t, err)
+	healthy := &recordingPublisher{}
+	ok, err := NewBusSink("healthy", "", healthy)
+	require.NoError(t, err)
+
+	dlq := NewMemoryDeadLetterQueue(10)
+	config := fastNotifierConfig()
+	config.MaxRetries = 2
+	notifier, err := NewRegistryNotifier(reg, config, dlq, zap.NewNop(), failing, ok)
+	require.NoError(t, err)
+	require.NoError(t, notifier.Start(context.Background()))
+	defer notifier.Stop()
+
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	require.Eventually(t, func() bool { return len(dlq.List()) == 1 }, time.Second, 5*time.Millisecond)
+
+	letter := dlq.Drain()[0]
+	assert.Equal(t, "broken", letter.Sink)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, DiscoveryEventAgentRegistered, letter.Event.Type)
+	assert.Contains(t, letter.Error, "broker unavailable")
+	assert.Len(t, healthy.snapshot(), 1)
+	assert.Empty(t, dlq.List())
+}
+
+func TestDiscoveryService_StartNotifier(t *testing.T) {
+	svc := NewDiscoveryService(nil, zap.NewNop())
+	publisher := &recordingPublisher{}
+	sink, err := NewBusSink("", "cluster-a", publisher)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	_, err = svc.StartNotifier(ctx, fastNotifierConfig(), nil, sink)
+	require.NoError(t, err)
+	_, err = svc.StartNotifier(ctx, fastNotifierConfig(), nil, sink)
+	assert.Error(t, err)
+
+	registerCovTestAgent(t, svc.registry.(*CapabilityRegistry), "agent1", []string{"search"})
+	require.Eventually(t, func() bool { return len(publisher.snapshot()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "cluster-a.agent_registered", publisher.snapshot()[0].subject)
+
+	require.NoError(t, svc.Stop(ctx))
+}
+
+func TestNewRegistryNotifier_Validation(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	_, err := NewRegistryNotifier(nil, DefaultNotifierConfig(), nil, nil, &BusSink{})
+	assert.Error(t, err)
+	_, err = NewRegistryNotifier(reg, DefaultNotifierConfig(), nil, nil)
+	assert.Error(t, err)
+	_, err = NewBusSink("", "", nil)
+	assert.Error(t, err)
+}
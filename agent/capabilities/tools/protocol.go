@@ -43,6 +43,10 @@ type DiscoveryProtocol struct {
 	multicastConn *net.UDPConn
 	multicastAddr *net.UDPAddr
 
+	// mDNS/DNS-SD 零配置发现
+	mdnsConn *net.UDPConn
+	mdnsAddr *net.UDPAddr
+
 	// 事件处理器
 	handlers   map[string]func(*AgentInfo)
 	handlerMu  sync.RWMutex
@@ -79,6 +83,12 @@ type ProtocolConfig struct {
 	// 多播口是多播口.
 	MulticastPort int `json:"multicast_port"`
 
+	// EnableMDNS 启用 mDNS/DNS-SD 零配置发现，同一局域网内的 Agent 无需中心注册即可互相发现.
+	EnableMDNS bool `json:"enable_mdns"`
+
+	// MDNSService 是 DNS-SD 服务类型，默认 _agentflow._tcp.
+	MDNSService string `json:"mdns_service"`
+
 	// 公告Interval是定期公告的间隔.
 	AnnounceInterval time.Duration `json:"announce_interval"`
 
@@ -99,6 +109,8 @@ func DefaultProtocolConfig() *ProtocolConfig {
 		EnableMulticast:  false,
 		MulticastAddress: "239.255.255.250",
 		MulticastPort:    1900,
+		EnableMDNS:       false,
+		MDNSService:      DefaultMDNSService,
 		AnnounceInterval: 30 * time.Second,
 		DiscoveryTimeout: 5 * time.Second,
 		MaxPeers:         100,
@@ -149,12 +161,19 @@ func (p *DiscoveryProtocol) Start(ctx context.Context) error {
 		}
 	}
 
+	if p.config.EnableMDNS {
+		if err := p.startMDNS(ctx); err != nil {
+			p.logger.Warn("failed to start mDNS", zap.Error(err))
+		}
+	}
+
 	p.running = true
 	p.runMu.Unlock()
 
 	p.logger.Info("discovery protocol started",
 		zap.Bool("http", p.config.EnableHTTP),
 		zap.Bool("multicast", p.config.EnableMulticast),
+		zap.Bool("mdns", p.config.EnableMDNS),
 	)
 
 	return nil
@@ -185,6 +204,9 @@ func (p *DiscoveryProtocol) Stop(ctx context.Context) error {
 		p.multicastConn.Close()
 	}
 
+	// 发送 mDNS goodbye 并停止
+	p.stopMDNS()
+
 	p.wg.Wait()
 
 	p.runMu.Lock()
@@ -228,6 +250,13 @@ func (p *DiscoveryProtocol) Announce(ctx context.Context, info *AgentInfo) error
 		}
 	}
 
+	// 本地代理通过 mDNS 通告
+	if p.config.EnableMDNS && p.mdnsConn != nil && info.IsLocal {
+		if err := p.announceMDNS([]*AgentInfo{info}, mdnsTTL); err != nil {
+			p.logger.Warn("failed to announce via mDNS", zap.Error(err))
+		}
+	}
+
 	p.logger.Debug("agent announced", zap.String("agent_id", agentID))
 
 	// 通知处理者
@@ -267,8 +296,15 @@ func (p *DiscoveryProtocol) Discover(ctx context.Context, filter *DiscoveryFilte
 		}
 	}
 
-	// 如果启用, 通过多播发现
-	if p.config.EnableMulticast {
+	// mDNS 查询异步刷新缓存，本次返回已缓存的结果
+	if p.config.EnableMDNS && p.mdnsConn != nil {
+		if err := p.queryMDNS(); err != nil {
+			p.logger.Debug("failed to send mDNS query", zap.Error(err))
+		}
+	}
+
+	// 如果启用, 通过多播或 mDNS 发现
+	if p.config.EnableMulticast || p.config.EnableMDNS {
 		multicastAgents, err := p.discoverMulticast(ctx, filter)
 		if err != nil {
 			p.logger.Warn("failed to discover via multicast", zap.Error(err))
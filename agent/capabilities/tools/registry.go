@@ -38,6 +38,9 @@ type CapabilityRegistry struct {
 	// purely in-memory.
 	store RegistryStore
 
+	// history 按 (agent, capability) 记录执行结果，提供衰减评分卡。
+	history *ExecutionHistory
+
 	// logger 是日志实例 。
 	logger *zap.Logger
 
@@ -104,6 +107,13 @@ func WithPanicErrorChan(ch chan<- error) RegistryOption {
 	}
 }
 
+// WithExecutionHistory 设置执行历史（可带持久化存储），未设置时使用内存历史。
+func WithExecutionHistory(history *ExecutionHistory) RegistryOption {
+	return func(r *CapabilityRegistry) {
+		r.history = history
+	}
+}
+
 // SetStore sets the persistence backend after construction.
 // This is useful when the store is not available at construction time
 // (e.g., when MongoDB stores are initialized after the registry).
@@ -135,6 +145,9 @@ func NewCapabilityRegistry(config *RegistryConfig, logger *zap.Logger, opts ...R
 	for _, opt := range opts {
 		opt(r)
 	}
+	if r.history == nil {
+		r.history = NewExecutionHistory(nil, nil)
+	}
 
 	// 如果启用, 初始化健康检查器
 	if config.EnableHealthCheck {
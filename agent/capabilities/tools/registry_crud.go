@@ -458,6 +458,21 @@ func (r *CapabilityRegistry) UpdateAgentLoad(ctx context.Context, agentID string
 
 // 记录 Execution 记录一个执行结果 一个能力。
 func (r *CapabilityRegistry) RecordExecution(ctx context.Context, agentID string, capabilityName string, success bool, latency time.Duration) error {
+	if err := r.recordCapabilityExecution(agentID, capabilityName, success, latency); err != nil {
+		return err
+	}
+
+	// 执行历史在注册表锁外写入，避免持久化阻塞其他操作
+	if err := r.history.Record(ctx, agentID, capabilityName, success, latency); err != nil {
+		r.logger.Error("failed to record execution history",
+			zap.String("agent_id", agentID),
+			zap.String("capability", capabilityName),
+			zap.Error(err))
+	}
+	return nil
+}
+
+func (r *CapabilityRegistry) recordCapabilityExecution(agentID string, capabilityName string, success bool, latency time.Duration) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -500,6 +515,25 @@ func (r *CapabilityRegistry) RecordExecution(ctx context.Context, agentID string
 	return fmt.Errorf("capability %s not found for agent %s", capabilityName, agentID)
 }
 
+// Scorecard 返回代理基于执行历史的评分卡。代理注销后历史仍保留，重新注册时继续生效。
+func (r *CapabilityRegistry) Scorecard(ctx context.Context, agentID string) (*AgentScorecard, error) {
+	card := r.history.Scorecard(agentID)
+	if len(card.Capabilities) == 0 {
+		r.mu.RLock()
+		_, exists := r.agents[agentID]
+		r.mu.RUnlock()
+		if !exists {
+			return nil, fmt.Errorf("agent %s not found", agentID)
+		}
+	}
+	return card, nil
+}
+
+// ExecutionHistory 返回注册表使用的执行历史。
+func (r *CapabilityRegistry) ExecutionHistory() *ExecutionHistory {
+	return r.history
+}
+
 // 订阅了发现事件。
 func (r *CapabilityRegistry) Subscribe(handler DiscoveryEventHandler) string {
 	r.handlerMu.Lock()
@@ -254,3 +254,6 @@ func mustMarshal(v any) json.RawMessage {
 
 // 确保能力登记工具注册界面。
 var _ Registry = (*CapabilityRegistry)(nil)
+
+// 确保能力登记提供执行历史评分卡。
+var _ ScorecardProvider = (*CapabilityRegistry)(nil)
@@ -101,6 +101,7 @@ func (r *CapabilityRegistry) copyAgentInfo(info *AgentInfo) *AgentInfo {
 		IsLocal:       info.IsLocal,
 		RegisteredAt:  info.RegisteredAt,
 		LastHeartbeat: info.LastHeartbeat,
+		Marketplace:   info.Marketplace.Clone(),
 	}
 
 	if info.Card != nil {
@@ -111,6 +112,7 @@ func (r *CapabilityRegistry) copyAgentInfo(info *AgentInfo) *AgentInfo {
 	if len(info.Capabilities) > 0 {
 		copy.Capabilities = make([]CapabilityInfo, len(info.Capabilities))
 		for i, cap := range info.Capabilities {
+			cap.Marketplace = cap.Marketplace.Clone()
 			copy.Capabilities[i] = cap
 		}
 	}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultLeaseTTL 是 Reserve/Renew 未指定 ttl 时的租约有效期.
+const DefaultLeaseTTL = 30 * time.Second
+
+var (
+	// ErrInsufficientCapacity 表示代理的剩余容量不足以容纳新的预留.
+	ErrInsufficientCapacity = errors.New("agent has insufficient capacity")
+	// ErrLeaseNotFound 表示租约不存在、已释放或已过期.
+	ErrLeaseNotFound = errors.New("lease not found")
+)
+
+// AgentLease 是对代理容量的一次预留，过期前计入代理的有效负载.
+type AgentLease struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	Load      float64   `json:"load"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReservationManager 管理代理容量租约，防止多个协调者同时选中并压满同一代理.
+// 有效负载 = 代理上报的负载 + 未过期租约的负载；过期租约在每次访问时自动清理.
+type ReservationManager struct {
+	registry Registry
+	logger   *zap.Logger
+
+	mu     sync.Mutex
+	leases map[string]*AgentLease
+	now    func() time.Time
+}
+
+// NewReservationManager 创建基于注册表的租约管理器.
+func NewReservationManager(registry Registry, logger *zap.Logger) *ReservationManager {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ReservationManager{
+		registry: registry,
+		logger:   logger.With(zap.String("component", "reservation_manager")),
+		leases:   make(map[string]*AgentLease),
+		now:      time.Now,
+	}
+}
+
+// Reserve 为代理预留 estimatedLoad (0-1] 的容量，有效负载超过 1 时返回 ErrInsufficientCapacity.
+func (m *ReservationManager) Reserve(ctx context.Context, agentID string, estimatedLoad float64, ttl time.Duration) (*AgentLease, error) {
+	if estimatedLoad <= 0 || estimatedLoad > 1 || math.IsNaN(estimatedLoad) {
+		return nil, fmt.Errorf("estimated load must be in (0, 1], got %v", estimatedLoad)
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	agent, err := m.registry.GetAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.Status != AgentStatusOnline {
+		return nil, fmt.Errorf("agent %s is not online", agentID)
+	}
+
+	now := m.now()
+	m.pruneLocked(now)
+	effective := agent.Load + m.reservedLocked(agentID)
+	if effective+estimatedLoad > 1 {
+		return nil, fmt.Errorf("%w: agent %s effective load %.2f, requested %.2f", ErrInsufficientCapacity, agentID, effective, estimatedLoad)
+	}
+
+	lease := &AgentLease{
+		ID:        fmt.Sprintf("lease_%s", uuid.New().String()),
+		AgentID:   agentID,
+		Load:      estimatedLoad,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	m.leases[lease.ID] = lease
+
+	m.logger.Debug("agent capacity reserved",
+		zap.String("lease_id", lease.ID),
+		zap.String("agent_id", agentID),
+		zap.Float64("load", estimatedLoad),
+		zap.Duration("ttl", ttl),
+	)
+	copied := *lease
+	return &copied, nil
+}
+
+// Renew 将未过期租约的有效期从当前时间起延长 ttl.
+func (m *ReservationManager) Renew(ctx context.Context, leaseID string, ttl time.Duration) (*AgentLease, error) {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.pruneLocked(now)
+	lease, ok := m.leases[leaseID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLeaseNotFound, leaseID)
+	}
+	lease.ExpiresAt = now.Add(ttl)
+
+	copied := *lease
+	return &copied, nil
+}
+
+// Release 提前释放租约，归还预留的容量.
+func (m *ReservationManager) Release(ctx context.Context, leaseID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(m.now())
+	if _, ok := m.leases[leaseID]; !ok {
+		return fmt.Errorf("%w: %s", ErrLeaseNotFound, leaseID)
+	}
+	delete(m.leases, leaseID)
+	return nil
+}
+
+// ReservedLoad 返回代理未过期租约的负载总和.
+func (m *ReservationManager) ReservedLoad(agentID string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(m.now())
+	return m.reservedLocked(agentID)
+}
+
+// Leases 返回代理当前未过期的租约.
+func (m *ReservationManager) Leases(agentID string) []*AgentLease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(m.now())
+	leases := make([]*AgentLease, 0)
+	for _, lease := range m.leases {
+		if lease.AgentID == agentID {
+			copied := *lease
+			leases = append(leases, &copied)
+		}
+	}
+	return leases
+}
+
+// applyReservations 将租约负载叠加到代理副本的 Load 上 (上限为 1).
+func (m *ReservationManager) applyReservations(agents []*AgentInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(m.now())
+	if len(m.leases) == 0 {
+		return
+	}
+	for _, agent := range agents {
+		if reserved := m.reservedLocked(agent.Card.Name); reserved > 0 {
+			agent.Load = math.Min(1, agent.Load+reserved)
+		}
+	}
+}
+
+func (m *ReservationManager) reservedLocked(agentID string) float64 {
+	var reserved float64
+	for _, lease := range m.leases {
+		if lease.AgentID == agentID {
+			reserved += lease.Load
+		}
+	}
+	return reserved
+}
+
+func (m *ReservationManager) pruneLocked(now time.Time) {
+	for id, lease := range m.leases {
+		if !now.Before(lease.ExpiresAt) {
+			delete(m.leases, id)
+			m.logger.Debug("agent lease expired",
+				zap.String("lease_id", id),
+				zap.String("agent_id", lease.AgentID),
+			)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReservationManager_PreventsDoubleBooking(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	ctx := context.Background()
+	require.NoError(t, reg.UpdateAgentLoad(ctx, "agent1", 0.3))
+
+	reservations := NewReservationManager(reg, zap.NewNop())
+	lease, err := reservations.Reserve(ctx, "agent1", 0.5, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "agent1", lease.AgentID)
+	assert.InDelta(t, 0.5, reservations.ReservedLoad("agent1"), 1e-9)
+
+	_, err = reservations.Reserve(ctx, "agent1", 0.4, time.Minute)
+	assert.ErrorIs(t, err, ErrInsufficientCapacity)
+
+	require.NoError(t, reservations.Release(ctx, lease.ID))
+	assert.Zero(t, reservations.ReservedLoad("agent1"))
+	assert.ErrorIs(t, reservations.Release(ctx, lease.ID), ErrLeaseNotFound)
+
+	_, err = reservations.Reserve(ctx, "agent1", 0.4, time.Minute)
+	assert.NoError(t, err)
+}
+
+func TestReservationManager_ExpiryAndRenew(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	ctx := context.Background()
+
+	reservations := NewReservationManager(reg, zap.NewNop())
+	now := time.Now()
+	reservations.now = func() time.Time { return now }
+
+	lease, err := reservations.Reserve(ctx, "agent1", 0.6, 10*time.Second)
+	require.NoError(t, err)
+
+	now = now.Add(8 * time.Second)
+	renewed, err := reservations.Renew(ctx, lease.ID, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(10*time.Second), renewed.ExpiresAt)
+
+	now = now.Add(8 * time.Second)
+	assert.InDelta(t, 0.6, reservations.ReservedLoad("agent1"), 1e-9)
+	assert.Len(t, reservations.Leases("agent1"), 1)
+
+	now = now.Add(5 * time.Second)
+	assert.Zero(t, reservations.ReservedLoad("agent1"))
+	_, err = reservations.Renew(ctx, lease.ID, time.Second)
+	assert.ErrorIs(t, err, ErrLeaseNotFound)
+}
+
+func TestReservationManager_RejectsInvalidRequests(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	ctx := context.Background()
+	reservations := NewReservationManager(reg, zap.NewNop())
+
+	_, err := reservations.Reserve(ctx, "agent1", 0, time.Minute)
+	assert.Error(t, err)
+	_, err = reservations.Reserve(ctx, "agent1", 1.5, time.Minute)
+	assert.Error(t, err)
+	_, err = reservations.Reserve(ctx, "missing", 0.1, time.Minute)
+	assert.Error(t, err)
+
+	require.NoError(t, reg.UpdateAgentStatus(ctx, "agent1", AgentStatusOffline))
+	_, err = reservations.Reserve(ctx, "agent1", 0.1, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestCapabilityMatcher_MaxLoadCountsReservations(t *testing.T) {
+	reg := newCovTestRegistry(t)
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	registerCovTestAgent(t, reg, "agent2", []string{"search"})
+	ctx := context.Background()
+	require.NoError(t, reg.UpdateAgentLoad(ctx, "agent1", 0.1))
+	require.NoError(t, reg.UpdateAgentLoad(ctx, "agent2", 0.2))
+
+	reservations := NewReservationManager(reg, zap.NewNop())
+	matcher := NewCapabilityMatcher(reg, nil, zap.NewNop()).WithReservations(reservations)
+
+	lease, err := reservations.Reserve(ctx, "agent1", 0.7, time.Minute)
+	require.NoError(t, err)
+
+	results, err := matcher.Match(ctx, &MatchRequest{
+		RequiredCapabilities: []string{"search"},
+		MaxLoad:              0.5,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "agent2", results[0].Agent.Card.Name)
+
+	require.NoError(t, reservations.Release(ctx, lease.ID))
+	best, err := matcher.MatchOne(ctx, &MatchRequest{
+		RequiredCapabilities: []string{"search"},
+		Strategy:             MatchStrategyLeastLoaded,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "agent1", best.Agent.Card.Name)
+}
+
+func TestDiscoveryService_ReserveLease(t *testing.T) {
+	svc := NewDiscoveryService(nil, zap.NewNop())
+	reg := svc.registry.(*CapabilityRegistry)
+	registerCovTestAgent(t, reg, "agent1", []string{"search"})
+	ctx := context.Background()
+
+	lease, err := svc.Reserve(ctx, "agent1", 0.9, time.Minute)
+	require.NoError(t, err)
+
+	_, err = svc.FindAgent(ctx, "", []string{"search"})
+	require.NoError(t, err)
+	results, err := svc.FindAgents(ctx, &MatchRequest{RequiredCapabilities: []string{"search"}, MaxLoad: 0.5})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = svc.RenewLease(ctx, lease.ID, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, svc.ReleaseLease(ctx, lease.ID))
+
+	results, err = svc.FindAgents(ctx, &MatchRequest{RequiredCapabilities: []string{"search"}, MaxLoad: 0.5})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
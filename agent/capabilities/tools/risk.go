@@ -1,6 +1,10 @@
 package tools
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/BaSui01/agentflow/types"
+)
 
 const (
 	ToolRiskSafeRead         = "safe_read"
@@ -22,6 +26,25 @@ func ClassifyToolRiskByName(name string) string {
 	}
 }
 
+// ClassifyToolRisk 优先依据工具声明判断风险：Traits 声明只读为 safe_read、声明副作用为 requires_approval，
+// 其次参考 RiskTier，均未声明时回退到 ClassifyToolRiskByName.
+func ClassifyToolRisk(schema types.ToolSchema) string {
+	switch {
+	case schema.Traits.ReadOnly():
+		return ToolRiskSafeRead
+	case schema.Traits.HasSideEffects():
+		return ToolRiskRequiresApproval
+	}
+	switch schema.RiskTier {
+	case types.RiskSafeRead:
+		return ToolRiskSafeRead
+	case "":
+	default:
+		return ToolRiskRequiresApproval
+	}
+	return ClassifyToolRiskByName(schema.Name)
+}
+
 func GroupToolRisks(names []string) map[string][]string {
 	grouped := map[string][]string{
 		ToolRiskSafeRead:         {},
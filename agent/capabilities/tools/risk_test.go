@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyToolRisk(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema types.ToolSchema
+		want   string
+	}{
+		{"declared read only", types.ToolSchema{Name: "run_command", Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectNone}}, ToolRiskSafeRead},
+		{"declared side effect", types.ToolSchema{Name: "read_file", Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectLocal}}, ToolRiskRequiresApproval},
+		{"risk tier safe read", types.ToolSchema{Name: "crm_lookup", RiskTier: types.RiskSafeRead}, ToolRiskSafeRead},
+		{"risk tier mutating", types.ToolSchema{Name: "crm_update", RiskTier: types.RiskMutating}, ToolRiskRequiresApproval},
+		{"empty traits fall back to name", types.ToolSchema{Name: "write_file", Traits: &types.ToolTraits{Idempotent: true}}, ToolRiskRequiresApproval},
+		{"undeclared unknown", types.ToolSchema{Name: "custom"}, ToolRiskUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyToolRisk(tt.schema))
+		})
+	}
+}
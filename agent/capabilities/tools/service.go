@@ -18,6 +18,15 @@ type DiscoveryService struct {
 	composer Composer
 	protocol Protocol
 
+	// 代理容量租约
+	reservations *ReservationManager
+
+	// 跨集群的注册中心联邦（可选）
+	federation *RegistryFederation
+
+	// 注册中心变更的外部通知（可选）
+	notifier *RegistryNotifier
+
 	config *ServiceConfig
 	logger *zap.Logger
 
@@ -82,8 +91,9 @@ func NewDiscoveryService(config *ServiceConfig, logger *zap.Logger) *DiscoverySe
 	// 创建注册
 	registry := NewCapabilityRegistry(config.Registry, logger)
 
-	// 创建匹配器
-	matcher := NewCapabilityMatcher(registry, config.Matcher, logger)
+	// 创建租约管理与匹配器
+	reservations := NewReservationManager(registry, logger)
+	matcher := NewCapabilityMatcher(registry, config.Matcher, logger).WithReservations(reservations)
 
 	// 创建作曲
 	composer := NewCapabilityComposer(registry, matcher, config.Composer, logger)
@@ -92,13 +102,14 @@ func NewDiscoveryService(config *ServiceConfig, logger *zap.Logger) *DiscoverySe
 	protocol := NewDiscoveryProtocol(config.Protocol, registry, logger)
 
 	return &DiscoveryService{
-		registry: registry,
-		matcher:  matcher,
-		composer: composer,
-		protocol: protocol,
-		config:   config,
-		logger:   logger.With(zap.String("component", "discovery_service")),
-		done:     make(chan struct{}),
+		registry:     registry,
+		matcher:      matcher,
+		composer:     composer,
+		protocol:     protocol,
+		reservations: reservations,
+		config:       config,
+		logger:       logger.With(zap.String("component", "discovery_service")),
+		done:         make(chan struct{}),
 	}
 }
 
@@ -149,6 +160,16 @@ func (s *DiscoveryService) Stop(ctx context.Context) error {
 	s.closeOnce.Do(func() { close(s.done) })
 	s.wg.Wait()
 
+	if s.federation != nil {
+		s.federation.Stop()
+		s.federation = nil
+	}
+
+	if s.notifier != nil {
+		s.notifier.Stop()
+		s.notifier = nil
+	}
+
 	// 停止协议
 	if err := s.protocol.Stop(ctx); err != nil {
 		s.logger.Error("failed to stop protocol", zap.Error(err))
@@ -220,6 +241,70 @@ func (s *DiscoveryService) FindAgent(ctx context.Context, taskDescription string
 	return result.Agent, nil
 }
 
+// Reserve 预留代理容量，租约有效期内其负载计入匹配时的有效负载，避免多个协调者同时压满同一代理。
+func (s *DiscoveryService) Reserve(ctx context.Context, agentID string, estimatedLoad float64, ttl time.Duration) (*AgentLease, error) {
+	return s.reservations.Reserve(ctx, agentID, estimatedLoad, ttl)
+}
+
+// RenewLease 延长租约有效期。
+func (s *DiscoveryService) RenewLease(ctx context.Context, leaseID string, ttl time.Duration) (*AgentLease, error) {
+	return s.reservations.Renew(ctx, leaseID, ttl)
+}
+
+// ReleaseLease 释放租约。
+func (s *DiscoveryService) ReleaseLease(ctx context.Context, leaseID string) error {
+	return s.reservations.Release(ctx, leaseID)
+}
+
+// SetEmbeddingProvider 为默认匹配器启用基于向量的能力匹配，使任务描述无需包含精确能力名。
+func (s *DiscoveryService) SetEmbeddingProvider(provider EmbeddingProvider) error {
+	matcher, ok := s.matcher.(*CapabilityMatcher)
+	if !ok {
+		return fmt.Errorf("matcher does not support embedding providers")
+	}
+	matcher.WithEmbeddingProvider(provider)
+	return nil
+}
+
+// StartFederation 启动与对端注册中心的联邦同步，服务停止时一并停止；transport 为 nil 时使用 HTTP。
+// 对端通过返回值的 Handler 访问本注册中心。
+func (s *DiscoveryService) StartFederation(ctx context.Context, config FederationConfig, transport PeerTransport) (*RegistryFederation, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.federation != nil {
+		return nil, fmt.Errorf("federation already started")
+	}
+	federation, err := NewRegistryFederation(s.registry, config, transport, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := federation.Start(ctx); err != nil {
+		return nil, err
+	}
+	s.federation = federation
+	return federation, nil
+}
+
+// StartNotifier 启动注册中心变更的外部通知（Webhook、消息总线），服务停止时一并停止；dlq 为 nil 时使用内存死信队列。
+func (s *DiscoveryService) StartNotifier(ctx context.Context, config NotifierConfig, dlq DeadLetterQueue, sinks ...NotificationSink) (*RegistryNotifier, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.notifier != nil {
+		return nil, fmt.Errorf("notifier already started")
+	}
+	notifier, err := NewRegistryNotifier(s.registry, config, dlq, s.logger, sinks...)
+	if err != nil {
+		return nil, err
+	}
+	if err := notifier.Start(ctx); err != nil {
+		return nil, err
+	}
+	s.notifier = notifier
+	return notifier, nil
+}
+
 // FindAgents发现多个符合标准的代理.
 func (s *DiscoveryService) FindAgents(ctx context.Context, req *MatchRequest) ([]*MatchResult, error) {
 	return s.matcher.Match(ctx, req)
@@ -260,6 +345,15 @@ func (s *DiscoveryService) RecordExecution(ctx context.Context, agentID, capabil
 	return s.registry.RecordExecution(ctx, agentID, capabilityName, success, latency)
 }
 
+// Scorecard 返回代理基于执行历史的评分卡，注册表需实现 ScorecardProvider。
+func (s *DiscoveryService) Scorecard(ctx context.Context, agentID string) (*AgentScorecard, error) {
+	provider, ok := s.registry.(ScorecardProvider)
+	if !ok {
+		return nil, fmt.Errorf("registry does not provide execution scorecards")
+	}
+	return provider.Scorecard(ctx, agentID)
+}
+
 // 订阅了发现事件。
 func (s *DiscoveryService) Subscribe(handler DiscoveryEventHandler) string {
 	return s.registry.Subscribe(handler)
@@ -28,6 +28,10 @@ type Skill struct {
 	Resources    map[string]any `json:"resources"`    // 资源（文件、数据等）
 	Examples     []SkillExample `json:"examples"`     // 使用示例
 
+	// 安装型技能
+	MemorySnippets       []string `json:"memory_snippets,omitempty"`       // 安装后注入上下文的记忆片段
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"` // 运行所需的 Agent 能力
+
 	// 加载策略
 	LazyLoad     bool     `json:"lazy_load"`    // 是否延迟加载
 	Priority     int      `json:"priority"`     // 优先级（用于冲突解决）
@@ -237,6 +241,32 @@ func (s *Skill) RenderInstructions(vars map[string]string) string {
 	return instructions
 }
 
+// PromptFragment 返回注入 system prompt 的技能片段：指令加 few-shot 示例
+func (s *Skill) PromptFragment() string {
+	if s == nil {
+		return ""
+	}
+	instructions := strings.TrimSpace(s.Instructions)
+	if len(s.Examples) == 0 {
+		return instructions
+	}
+
+	var b strings.Builder
+	b.WriteString(instructions)
+	b.WriteString("\n\nExamples:")
+	for _, ex := range s.Examples {
+		b.WriteString("\nInput: ")
+		b.WriteString(strings.TrimSpace(ex.Input))
+		b.WriteString("\nOutput: ")
+		b.WriteString(strings.TrimSpace(ex.Output))
+		if ex.Explanation != "" {
+			b.WriteString("\nWhy: ")
+			b.WriteString(strings.TrimSpace(ex.Explanation))
+		}
+	}
+	return b.String()
+}
+
 // Get Instructions返回技能说明,以便迅速注射/增强.
 func (s *Skill) GetInstructions() string {
 	if s == nil {
@@ -314,6 +344,8 @@ func (s *Skill) Clone() *Skill {
 	clone.Tools = append([]string{}, s.Tools...)
 	clone.Dependencies = append([]string{}, s.Dependencies...)
 	clone.Examples = append([]SkillExample{}, s.Examples...)
+	clone.MemorySnippets = append([]string(nil), s.MemorySnippets...)
+	clone.RequiredCapabilities = append([]string(nil), s.RequiredCapabilities...)
 
 	clone.Resources = make(map[string]any)
 	for k, v := range s.Resources {
@@ -423,6 +455,18 @@ func (b *SkillBuilder) WithDependencies(deps ...string) *SkillBuilder {
 	return b
 }
 
+// WithMemorySnippets 添加安装后注入上下文的记忆片段
+func (b *SkillBuilder) WithMemorySnippets(snippets ...string) *SkillBuilder {
+	b.skill.MemorySnippets = append(b.skill.MemorySnippets, snippets...)
+	return b
+}
+
+// WithRequiredCapabilities 设置技能所需的 Agent 能力
+func (b *SkillBuilder) WithRequiredCapabilities(capabilities ...string) *SkillBuilder {
+	b.skill.RequiredCapabilities = append(b.skill.RequiredCapabilities, capabilities...)
+	return b
+}
+
 // Build 构建技能
 func (b *SkillBuilder) Build() (*Skill, error) {
 	if err := b.skill.Validate(); err != nil {
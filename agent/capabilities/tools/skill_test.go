@@ -79,6 +79,23 @@ func TestSkill_RenderInstructions_NilVars(t *testing.T) {
 	assert.Equal(t, "Hello {{name}}", result)
 }
 
+func TestSkill_PromptFragment(t *testing.T) {
+	s := &Skill{
+		Instructions: "Answer billing questions.",
+		Examples: []SkillExample{
+			{Input: "refund?", Output: "Within 30 days.", Explanation: "policy"},
+			{Input: "invoice?", Output: "See portal."},
+		},
+		MemorySnippets: []string{"prefers email"},
+	}
+	assert.Equal(t, "Answer billing questions.\n\nExamples:\nInput: refund?\nOutput: Within 30 days.\nWhy: policy\nInput: invoice?\nOutput: See portal.", s.PromptFragment())
+	assert.Equal(t, "Answer billing questions.", (&Skill{Instructions: " Answer billing questions. "}).PromptFragment())
+
+	clone := s.Clone()
+	clone.MemorySnippets[0] = "changed"
+	assert.Equal(t, "prefers email", s.MemorySnippets[0])
+}
+
 func TestSkill_GetInstructions_NilSkill(t *testing.T) {
 	var s *Skill
 	assert.Equal(t, "", s.GetInstructions())
@@ -79,6 +79,9 @@ type CapabilityInfo struct {
 
 	// AvgLatency是平均行刑时间.
 	AvgLatency time.Duration `json:"avg_latency"`
+
+	// Marketplace 是能力市场元数据（定价、延迟等级、输入输出 schema），覆盖 Agent 级默认值。
+	Marketplace *MarketplaceInfo `json:"marketplace,omitempty"`
 }
 
 // AgentInfo包含了注册代理的详细信息.
@@ -110,6 +113,9 @@ type AgentInfo struct {
 	// 最后的心跳是收到最后的心跳的时候.
 	LastHeartbeat time.Time `json:"last_heartbeat"`
 
+	// Marketplace 是 Agent 级能力市场元数据，作为各能力的默认值。
+	Marketplace *MarketplaceInfo `json:"marketplace,omitempty"`
+
 	// 元数据包含额外的元数据.
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
@@ -145,8 +151,34 @@ type MatchRequest struct {
 
 	// 超时是匹配操作的超时.
 	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Constraint 是按成本与兼容性筛选的约束表达式，
+	// 如 "cheapest agent with p95 < 2s supporting schema invoice.v1"；带排序关键字时覆盖 Strategy。
+	Constraint string `json:"constraint,omitempty"`
+
+	// EstimatedInputTokens 和 EstimatedOutputTokens 用于按 token 计价的费用估算。
+	EstimatedInputTokens  int `json:"estimated_input_tokens,omitempty"`
+	EstimatedOutputTokens int `json:"estimated_output_tokens,omitempty"`
+
+	// Locality 是对联邦导入的远端代理的就近偏好，默认不区分本地与远端。
+	Locality LocalityPreference `json:"locality,omitempty"`
+
+	// Region 是请求方所在区域，LocalityPreferLocal 时同区域的远端代理优先于其他区域。
+	Region string `json:"region,omitempty"`
 }
 
+// LocalityPreference 定义匹配时对本地与远端代理的偏好.
+type LocalityPreference string
+
+const (
+	// LocalityAny 不区分本地与远端代理.
+	LocalityAny LocalityPreference = ""
+	// LocalityPreferLocal 优先本地代理，仅在本地容量耗尽时委派给远端，同区域优先.
+	LocalityPreferLocal LocalityPreference = "prefer_local"
+	// LocalityLocalOnly 只匹配本地注册的代理.
+	LocalityLocalOnly LocalityPreference = "local_only"
+)
+
 // MatchStrategy定义了匹配代理的战略.
 type MatchStrategy string
 
@@ -161,6 +193,9 @@ const (
 	MatchStrategyRoundRobin MatchStrategy = "round_robin"
 	// MatchStrategyRandom 返回随机匹配代理.
 	MatchStrategyRandom MatchStrategy = "random"
+	// MatchStrategyBestHistorical 综合实时负载、执行历史质量与费用排序，
+	// 需要注册表实现 ScorecardProvider，否则以能力分数代替历史质量。
+	MatchStrategyBestHistorical MatchStrategy = "best_historical"
 )
 
 // MatchResult代表能力匹配的结果.
@@ -179,6 +214,24 @@ type MatchResult struct {
 
 	// 理由就是比赛的原因
 	Reason string `json:"reason,omitempty"`
+
+	// Marketplace 是满足约束的能力的市场元数据，仅在请求带 Constraint 时设置。
+	Marketplace *MarketplaceInfo `json:"marketplace,omitempty"`
+
+	// EstimatedCost 是按请求预估 token 数计算的单次调用费用。
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
+
+	// HistoricalQuality 是匹配能力的历史质量 (0-1)，仅 MatchStrategyBestHistorical 设置。
+	HistoricalQuality float64 `json:"historical_quality,omitempty"`
+
+	// HistoricalScore 是负载、历史质量与费用的加权分 (0-100)，仅 MatchStrategyBestHistorical 设置。
+	HistoricalScore float64 `json:"historical_score,omitempty"`
+
+	// SemanticMatches 解释向量匹配命中的能力及相似度，仅配置 EmbeddingProvider 时设置。
+	SemanticMatches []SemanticCapabilityMatch `json:"semantic_matches,omitempty"`
+
+	// OriginRegistry 是远端代理所在的注册中心，本地代理为空.
+	OriginRegistry string `json:"origin_registry,omitempty"`
 }
 
 // 要求构成要求构成能力。
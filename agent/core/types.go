@@ -91,6 +91,7 @@ const (
 	StopReasonValidationFailed         StopReason = "validation_failed"
 	StopReasonToolFailureUnrecoverable StopReason = "tool_failure_unrecoverable"
 	StopReasonBlocked                  StopReason = "blocked"
+	StopReasonPaused                   StopReason = "paused"
 )
 
 // LoopDecision is the allowed next-step decision set produced after evaluation.
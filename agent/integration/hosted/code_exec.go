@@ -93,7 +93,7 @@ func (t *CodeExecTool) Schema() types.ToolSchema {
 	if err != nil {
 		params = []byte("{}")
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: localExecToolTraits()}
 }
 
 // codeExecArgs represents the arguments for code execution.
@@ -77,9 +77,9 @@ func (t *ReadFileTool) Schema() types.ToolSchema {
 		"required": []string{"path"},
 	})
 	if err != nil {
-		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}")}
+		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}"), Traits: readOnlyToolTraits(types.ToolLatencyFast)}
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: readOnlyToolTraits(types.ToolLatencyFast)}
 }
 
 type readFileArgs struct {
@@ -165,9 +165,9 @@ func (t *WriteFileTool) Schema() types.ToolSchema {
 		"required": []string{"path", "content"},
 	})
 	if err != nil {
-		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}")}
+		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}"), Traits: localWriteToolTraits(true)}
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: localWriteToolTraits(true)}
 }
 
 type writeFileArgs struct {
@@ -227,9 +227,9 @@ func (t *EditFileTool) Schema() types.ToolSchema {
 		"required": []string{"path", "old_string", "new_string"},
 	})
 	if err != nil {
-		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}")}
+		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}"), Traits: localWriteToolTraits(false)}
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: localWriteToolTraits(false)}
 }
 
 type editFileArgs struct {
@@ -298,9 +298,9 @@ func (t *ListDirectoryTool) Schema() types.ToolSchema {
 		"required": []string{"path"},
 	})
 	if err != nil {
-		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}")}
+		return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: []byte("{}"), Traits: readOnlyToolTraits(types.ToolLatencyFast)}
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: readOnlyToolTraits(types.ToolLatencyFast)}
 }
 
 type listDirArgs struct {
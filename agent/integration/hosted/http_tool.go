@@ -0,0 +1,511 @@
+package hosted
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ToolTypeHTTP identifies tools backed by a declared HTTP call template.
+const ToolTypeHTTP HostedToolType = "http"
+
+// DefaultToolSecretEnvPrefix scopes the environment variables HTTP tools may read as secrets.
+const DefaultToolSecretEnvPrefix = "AGENTFLOW_TOOL_SECRET_"
+
+const (
+	defaultHTTPToolTimeout = 30 * time.Second
+	maxHTTPToolTimeout     = 300
+	httpToolErrorBodyLimit = 512
+)
+
+// allowPrivateHTTPToolTargetsEnv is the testing hook shared with the multimodal
+// URL checks; it lets httptest loopback servers act as HTTP tool upstreams.
+const allowPrivateHTTPToolTargetsEnv = "AGENTFLOW_ALLOW_PRIVATE_URLS"
+
+// httpToolPlaceholder matches {{arg}} argument references and {{secret:NAME}} secret references.
+var (
+	httpToolPlaceholder = regexp.MustCompile(`\{\{\s*(secret:)?([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+	httpToolSecretName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	httpToolHeaderName  = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`)
+)
+
+// SecretResolver resolves secret references used by HTTP tool templates.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecretResolver resolves secrets from environment variables named Prefix+NAME.
+// The prefix keeps registrations from reading unrelated process environment.
+type EnvSecretResolver struct {
+	Prefix string
+}
+
+// NewEnvSecretResolver creates an environment-backed resolver; an empty prefix uses DefaultToolSecretEnvPrefix.
+func NewEnvSecretResolver(prefix string) EnvSecretResolver {
+	if strings.TrimSpace(prefix) == "" {
+		prefix = DefaultToolSecretEnvPrefix
+	}
+	return EnvSecretResolver{Prefix: prefix}
+}
+
+func (r EnvSecretResolver) ResolveSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(r.Prefix + name)
+	if !ok {
+		return "", fmt.Errorf("secret %q is not configured", name)
+	}
+	return value, nil
+}
+
+// HTTPToolTemplate declares how an HTTP-backed tool call is built.
+//
+// URL, query and header values may reference tool arguments as {{name}};
+// headers and query values may also reference secrets as {{secret:NAME}}.
+// Body placeholders are replaced with the JSON encoding of the argument, so a
+// template like {"q": {{query}}} stays valid JSON. When Body is empty, POST,
+// PUT and PATCH send the tool arguments as the JSON body.
+//
+// Templates carry no tool traits: an HTTP tool always reaches an external
+// system, so its risk is derived from ToolTypeHTTP and never from the caller.
+type HTTPToolTemplate struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Query          map[string]string `json:"query,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+// ParseHTTPToolTemplate decodes a stored template.
+func ParseHTTPToolTemplate(raw json.RawMessage) (HTTPToolTemplate, error) {
+	var tmpl HTTPToolTemplate
+	if len(raw) == 0 || string(raw) == "null" {
+		return tmpl, fmt.Errorf("http config is required")
+	}
+	if err := json.Unmarshal(raw, &tmpl); err != nil {
+		return tmpl, fmt.Errorf("invalid http config: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Validate checks the template against the tool's parameter schema. Argument
+// placeholders must name declared properties when the schema lists any.
+func (t HTTPToolTemplate) Validate(parameters json.RawMessage) error {
+	switch strings.ToUpper(strings.TrimSpace(t.Method)) {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("http method %q is not supported", t.Method)
+	}
+	if t.TimeoutSeconds < 0 || t.TimeoutSeconds > maxHTTPToolTimeout {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", maxHTTPToolTimeout)
+	}
+
+	if hasSecretPlaceholder(t.URL) {
+		return fmt.Errorf("url must not reference secrets; use headers or query")
+	}
+	parsed, err := url.Parse(httpToolPlaceholder.ReplaceAllString(strings.TrimSpace(t.URL), "x"))
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+	if parsed.Host == "" || httpToolPlaceholder.MatchString(hostPart(t.URL)) {
+		return fmt.Errorf("url host must be static")
+	}
+	if err := checkHTTPToolHost(parsed.Hostname()); err != nil {
+		return err
+	}
+
+	for name, value := range t.Headers {
+		if !httpToolHeaderName.MatchString(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("header %q contains line breaks", name)
+		}
+	}
+	if strings.TrimSpace(t.Body) != "" {
+		if hasSecretPlaceholder(t.Body) {
+			return fmt.Errorf("body must not reference secrets; use headers or query")
+		}
+		if !json.Valid([]byte(httpToolPlaceholder.ReplaceAllString(t.Body, "null"))) {
+			return fmt.Errorf("body template must be valid JSON")
+		}
+	}
+
+	declared := schemaProperties(parameters)
+	for _, text := range t.templateTexts() {
+		for _, match := range httpToolPlaceholder.FindAllStringSubmatch(text, -1) {
+			if match[1] != "" {
+				if !httpToolSecretName.MatchString(match[2]) {
+					return fmt.Errorf("invalid secret name %q", match[2])
+				}
+				continue
+			}
+			if declared != nil {
+				if _, ok := declared[match[2]]; !ok {
+					return fmt.Errorf("placeholder {{%s}} is not a declared parameter", match[2])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SecretRefs lists the secret names referenced by the template.
+func (t HTTPToolTemplate) SecretRefs() []string {
+	seen := make(map[string]struct{})
+	var refs []string
+	for _, text := range t.templateTexts() {
+		for _, match := range httpToolPlaceholder.FindAllStringSubmatch(text, -1) {
+			if match[1] == "" {
+				continue
+			}
+			if _, ok := seen[match[2]]; !ok {
+				seen[match[2]] = struct{}{}
+				refs = append(refs, match[2])
+			}
+		}
+	}
+	return refs
+}
+
+func (t HTTPToolTemplate) templateTexts() []string {
+	texts := []string{t.URL, t.Body}
+	for _, v := range t.Headers {
+		texts = append(texts, v)
+	}
+	for _, v := range t.Query {
+		texts = append(texts, v)
+	}
+	return texts
+}
+
+// HTTPHostedTool executes a declared HTTP call template.
+type HTTPHostedTool struct {
+	name     string
+	schema   types.ToolSchema
+	template HTTPToolTemplate
+	secrets  SecretResolver
+	client   *http.Client
+}
+
+// NewHTTPHostedTool validates the template and builds the tool. parameters is
+// the JSON schema exposed to the model.
+func NewHTTPHostedTool(name, description string, parameters json.RawMessage, tmpl HTTPToolTemplate, secrets SecretResolver) (*HTTPHostedTool, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("tool name is required")
+	}
+	if err := tmpl.Validate(parameters); err != nil {
+		return nil, err
+	}
+	tmpl.Method = strings.ToUpper(strings.TrimSpace(tmpl.Method))
+	tmpl.URL = strings.TrimSpace(tmpl.URL)
+	if len(parameters) == 0 || string(parameters) == "null" {
+		parameters = json.RawMessage(`{"type":"object","additionalProperties":true}`)
+	}
+	if secrets == nil {
+		secrets = NewEnvSecretResolver("")
+	}
+	timeout := defaultHTTPToolTimeout
+	if tmpl.TimeoutSeconds > 0 {
+		timeout = time.Duration(tmpl.TimeoutSeconds) * time.Second
+	}
+	if strings.TrimSpace(description) == "" {
+		description = fmt.Sprintf("HTTP %s %s", tmpl.Method, tmpl.URL)
+	}
+	return &HTTPHostedTool{
+		name: name,
+		schema: types.ToolSchema{
+			Name:        name,
+			Description: description,
+			Parameters:  append(json.RawMessage(nil), parameters...),
+		},
+		template: tmpl,
+		secrets:  secrets,
+		client:   newHTTPToolClient(timeout),
+	}, nil
+}
+
+func (t *HTTPHostedTool) Type() HostedToolType { return ToolTypeHTTP }
+func (t *HTTPHostedTool) Name() string         { return t.name }
+func (t *HTTPHostedTool) Description() string  { return t.schema.Description }
+func (t *HTTPHostedTool) Schema() types.ToolSchema {
+	return t.schema
+}
+
+func (t *HTTPHostedTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	values := make(map[string]any)
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &values); err != nil {
+			return nil, fmt.Errorf("invalid args json: %w", err)
+		}
+	}
+
+	req, err := t.buildRequest(ctx, args, values)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http tool %s: %w", t.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("http tool %s: read response: %w", t.name, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		snippet := string(body)
+		if len(snippet) > httpToolErrorBodyLimit {
+			snippet = snippet[:httpToolErrorBodyLimit]
+		}
+		return nil, fmt.Errorf("http tool %s: upstream returned %d: %s", t.name, resp.StatusCode, snippet)
+	}
+
+	out := map[string]any{"status_code": resp.StatusCode}
+	if json.Valid(body) {
+		out["body"] = json.RawMessage(body)
+	} else {
+		out["body"] = string(body)
+	}
+	return json.Marshal(out)
+}
+
+func (t *HTTPHostedTool) buildRequest(ctx context.Context, args json.RawMessage, values map[string]any) (*http.Request, error) {
+	rawURL, err := t.render(ctx, t.template.URL, values, url.PathEscape)
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("http tool %s: invalid url: %w", t.name, err)
+	}
+	if len(t.template.Query) > 0 {
+		query := target.Query()
+		for key, tmpl := range t.template.Query {
+			value, err := t.render(ctx, tmpl, values, nil)
+			if err != nil {
+				return nil, err
+			}
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	hasBody := false
+	switch {
+	case strings.TrimSpace(t.template.Body) != "":
+		rendered := httpToolPlaceholder.ReplaceAllStringFunc(t.template.Body, func(m string) string {
+			raw, _ := json.Marshal(values[httpToolPlaceholder.FindStringSubmatch(m)[2]])
+			return string(raw)
+		})
+		body, hasBody = strings.NewReader(rendered), true
+	case t.template.Method == http.MethodPost || t.template.Method == http.MethodPut || t.template.Method == http.MethodPatch:
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		}
+		body, hasBody = bytes.NewReader(args), true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.template.Method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("http tool %s: build request: %w", t.name, err)
+	}
+	for name, tmpl := range t.template.Headers {
+		value, err := t.render(ctx, tmpl, values, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+	if hasBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// render substitutes argument and secret placeholders; escape applies to argument values only.
+func (t *HTTPHostedTool) render(ctx context.Context, text string, values map[string]any, escape func(string) string) (string, error) {
+	var renderErr error
+	out := httpToolPlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+		match := httpToolPlaceholder.FindStringSubmatch(m)
+		if match[1] != "" {
+			secret, err := t.secrets.ResolveSecret(ctx, match[2])
+			if err != nil && renderErr == nil {
+				renderErr = fmt.Errorf("http tool %s: resolve secret %s: %w", t.name, match[2], err)
+			}
+			return secret
+		}
+		value := stringifyHTTPToolArg(values[match[2]])
+		if escape != nil {
+			value = escape(value)
+		}
+		return value
+	})
+	return out, renderErr
+}
+
+func stringifyHTTPToolArg(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(raw)
+	}
+}
+
+func hasSecretPlaceholder(text string) bool {
+	for _, match := range httpToolPlaceholder.FindAllStringSubmatch(text, -1) {
+		if match[1] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPart returns the authority section of a raw URL template.
+func hostPart(rawURL string) string {
+	rest := rawURL
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// checkHTTPToolHost rejects literal hosts that point at loopback, private,
+// link-local (cloud metadata) or otherwise non-public addresses. Hostnames are
+// re-checked against their resolved addresses at dial time.
+func checkHTTPToolHost(host string) error {
+	if os.Getenv(allowPrivateHTTPToolTargetsEnv) == "1" {
+		return nil
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("url host %q is not a public address", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && isDisallowedHTTPToolIP(ip) {
+		return fmt.Errorf("url host %q is not a public address", host)
+	}
+	return nil
+}
+
+var blockedHTTPToolIPPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmark testing
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64 can map to private IPv4
+	netip.MustParsePrefix("2001:db8::/32"), // documentation
+	netip.MustParsePrefix("fec0::/10"),     // deprecated site-local
+}
+
+func isDisallowedHTTPToolIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return true
+	}
+	for _, p := range blockedHTTPToolIPPrefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// newHTTPToolClient returns a client that resolves the upstream host itself and
+// only dials public addresses, so DNS rebinding cannot reach internal services.
+func newHTTPToolClient(timeout time.Duration) *http.Client {
+	base := tlsutil.SecureHTTPClient(timeout)
+	transport, ok := base.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		return base
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	cloned := transport.Clone()
+	cloned.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upstream address: %w", err)
+		}
+		if os.Getenv(allowPrivateHTTPToolTargetsEnv) == "1" {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+		if err != nil {
+			return nil, fmt.Errorf("resolve upstream host %s: %w", host, err)
+		}
+		for _, ip := range ips {
+			if isDisallowedHTTPToolIP(ip) {
+				return nil, fmt.Errorf("upstream host %s resolves to non-public address %s", host, ip)
+			}
+		}
+		var lastErr error
+		for _, ip := range ips {
+			conn, dialErr := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if dialErr == nil {
+				return conn, nil
+			}
+			lastErr = dialErr
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("upstream host %s has no addresses", host)
+		}
+		return nil, lastErr
+	}
+	clientCopy := *base
+	clientCopy.Transport = cloned
+	return &clientCopy
+}
+
+// schemaProperties returns declared property names, or nil when the schema lists none.
+func schemaProperties(parameters json.RawMessage) map[string]json.RawMessage {
+	if len(parameters) == 0 {
+		return nil
+	}
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(parameters, &schema); err != nil || len(schema.Properties) == 0 {
+		return nil
+	}
+	return schema.Properties
+}
@@ -0,0 +1,158 @@
+package hosted
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapSecretResolver map[string]string
+
+func (m mapSecretResolver) ResolveSecret(_ context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", assert.AnError
+	}
+	return value, nil
+}
+
+const weatherParams = `{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer"}}}`
+
+func TestHTTPToolTemplate_Validate(t *testing.T) {
+	valid := HTTPToolTemplate{
+		Method:  "get",
+		URL:     "https://api.example.com/weather/{{city}}",
+		Headers: map[string]string{"Authorization": "Bearer {{secret:WEATHER_KEY}}"},
+		Query:   map[string]string{"days": "{{days}}"},
+	}
+	require.NoError(t, valid.Validate(json.RawMessage(weatherParams)))
+	assert.Equal(t, []string{"WEATHER_KEY"}, valid.SecretRefs())
+
+	cases := map[string]HTTPToolTemplate{
+		"method":           {Method: "TRACE", URL: "https://api.example.com"},
+		"scheme":           {Method: "GET", URL: "ftp://api.example.com"},
+		"dynamic host":     {Method: "GET", URL: "https://{{city}}.example.com"},
+		"secret in url":    {Method: "GET", URL: "https://api.example.com/{{secret:KEY}}"},
+		"secret in body":   {Method: "POST", URL: "https://api.example.com", Body: `{"k": {{secret:KEY}}}`},
+		"invalid body":     {Method: "POST", URL: "https://api.example.com", Body: `{"q": {{city}}`},
+		"undeclared param": {Method: "GET", URL: "https://api.example.com/{{country}}"},
+		"header injection": {Method: "GET", URL: "https://api.example.com", Headers: map[string]string{"X-A": "a\r\nX-B: b"}},
+		"timeout":          {Method: "GET", URL: "https://api.example.com", TimeoutSeconds: 3600},
+		"loopback":         {Method: "GET", URL: "http://127.0.0.1:8080/admin"},
+		"localhost":        {Method: "GET", URL: "http://localhost/admin"},
+		"private":          {Method: "GET", URL: "http://10.0.0.5/internal"},
+		"metadata":         {Method: "GET", URL: "http://169.254.169.254/latest/meta-data/"},
+		"mapped metadata":  {Method: "GET", URL: "http://[::ffff:169.254.169.254]/latest/meta-data/"},
+	}
+	for name, tmpl := range cases {
+		assert.Error(t, tmpl.Validate(json.RawMessage(weatherParams)), name)
+	}
+}
+
+func TestHTTPHostedTool_ExecuteRendersTemplate(t *testing.T) {
+	t.Setenv(allowPrivateHTTPToolTargetsEnv, "1")
+	var gotPath, gotQuery, gotAuth, gotBody, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotQuery = r.URL.RawQuery
+		gotAuth = r.Header.Get("Authorization")
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"temp":21}`))
+	}))
+	defer server.Close()
+
+	params := `{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer"},"unit":{"type":"string"}}}`
+	tool, err := NewHTTPHostedTool("weather", "", json.RawMessage(params), HTTPToolTemplate{
+		Method:  "POST",
+		URL:     server.URL + "/weather/{{city}}",
+		Headers: map[string]string{"Authorization": "Bearer {{secret:WEATHER_KEY}}"},
+		Query:   map[string]string{"days": "{{days}}", "unit": "{{unit}}"},
+		Body:    `{"city": {{city}}, "days": {{days}}}`,
+	}, mapSecretResolver{"WEATHER_KEY": "s3cret"})
+	require.NoError(t, err)
+	assert.Equal(t, ToolTypeHTTP, tool.Type())
+	assert.Equal(t, "requires_approval", ClassifyHostedToolPermissionRisk(tool))
+
+	out, err := tool.Execute(context.Background(), json.RawMessage(`{"city":"New York","days":3}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status_code":200,"body":{"temp":21}}`, string(out))
+	assert.Equal(t, "/weather/New%20York", gotPath)
+	assert.Equal(t, "days=3", gotQuery)
+	assert.Equal(t, "Bearer s3cret", gotAuth)
+	assert.Equal(t, "application/json", gotContentType)
+	assert.JSONEq(t, `{"city":"New York","days":3}`, gotBody)
+}
+
+func TestHTTPHostedTool_ExecuteErrors(t *testing.T) {
+	t.Setenv(allowPrivateHTTPToolTargetsEnv, "1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	tool, err := NewHTTPHostedTool("weather", "", nil, HTTPToolTemplate{
+		Method:  "GET",
+		URL:     server.URL,
+		Headers: map[string]string{"X-Key": "{{secret:MISSING}}"},
+	}, mapSecretResolver{})
+	require.NoError(t, err)
+	assert.Equal(t, types.RiskNetworkExecution, ClassifyHostedToolRiskTier(tool))
+
+	_, err = tool.Execute(context.Background(), nil)
+	assert.ErrorContains(t, err, "MISSING")
+
+	tool, err = NewHTTPHostedTool("weather", "", nil, HTTPToolTemplate{Method: "GET", URL: server.URL}, nil)
+	require.NoError(t, err)
+	_, err = tool.Execute(context.Background(), nil)
+	assert.ErrorContains(t, err, "upstream returned 429")
+}
+
+func TestHTTPHostedTool_IgnoresCallerTraits(t *testing.T) {
+	tmpl, err := ParseHTTPToolTemplate(json.RawMessage(`{"method":"GET","url":"https://api.example.com","traits":{"side_effect":"none"}}`))
+	require.NoError(t, err)
+	tool, err := NewHTTPHostedTool("weather", "", nil, tmpl, nil)
+	require.NoError(t, err)
+	assert.Nil(t, tool.Schema().Traits)
+	assert.Equal(t, "requires_approval", ClassifyHostedToolPermissionRisk(tool))
+}
+
+func TestHTTPHostedTool_RefusesPrivateTargetsAtDialTime(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	// A host that passed registration can later resolve to an internal address,
+	// so the dialer re-checks every connection.
+	t.Setenv(allowPrivateHTTPToolTargetsEnv, "1")
+	tool, err := NewHTTPHostedTool("internal", "", nil, HTTPToolTemplate{Method: "GET", URL: server.URL}, nil)
+	require.NoError(t, err)
+	t.Setenv(allowPrivateHTTPToolTargetsEnv, "")
+
+	_, err = tool.Execute(context.Background(), nil)
+	assert.ErrorContains(t, err, "non-public address")
+	assert.Zero(t, hits)
+}
+
+func TestEnvSecretResolver_UsesPrefix(t *testing.T) {
+	t.Setenv(DefaultToolSecretEnvPrefix+"API_KEY", "value")
+	t.Setenv("API_KEY", "unscoped")
+
+	value, err := NewEnvSecretResolver("").ResolveSecret(context.Background(), "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = NewEnvSecretResolver("").ResolveSecret(context.Background(), "OTHER")
+	assert.Error(t, err)
+}
@@ -67,7 +67,7 @@ func (t *RetrievalTool) Schema() types.ToolSchema {
 	if err != nil {
 		params = []byte("{}")
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: readOnlyToolTraits(types.ToolLatencyMedium)}
 }
 
 // retrievalArgs represents the arguments for retrieval.
@@ -127,7 +127,7 @@ func (t *ShellTool) Schema() types.ToolSchema {
 		},
 		"required": []string{"command"},
 	})
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: localExecToolTraits()}
 }
 
 type runCommandArgs struct {
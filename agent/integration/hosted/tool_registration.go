@@ -5,14 +5,23 @@ import (
 	"time"
 )
 
-// ToolRegistration stores DB-managed tool alias configuration.
-// It maps an exposed tool name to an existing runtime target tool.
+// Tool registration kinds.
+const (
+	ToolRegistrationKindAlias = "alias"
+	ToolRegistrationKindHTTP  = "http"
+)
+
+// ToolRegistration stores DB-managed tool configuration.
+// Alias registrations map an exposed tool name to an existing runtime target
+// tool; HTTP registrations declare an HTTPToolTemplate in HTTPConfig.
 type ToolRegistration struct {
 	ID          uint            `gorm:"primaryKey" json:"id"`
 	Name        string          `gorm:"size:120;not null;uniqueIndex" json:"name"`
 	Description string          `gorm:"type:text" json:"description,omitempty"`
+	Kind        string          `gorm:"size:16;not null;default:alias" json:"kind"`
 	Target      string          `gorm:"size:120;not null" json:"target"`
 	Parameters  json.RawMessage `gorm:"type:json" json:"parameters,omitempty"`
+	HTTPConfig  json.RawMessage `gorm:"type:json" json:"http_config,omitempty"`
 	Enabled     bool            `gorm:"default:true;index" json:"enabled"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
@@ -21,3 +30,8 @@ type ToolRegistration struct {
 func (ToolRegistration) TableName() string {
 	return "sc_tool_registrations"
 }
+
+// IsHTTP reports whether the registration declares an HTTP-backed tool.
+func (r ToolRegistration) IsHTTP() bool {
+	return r.Kind == ToolRegistrationKindHTTP
+}
@@ -183,7 +183,7 @@ func (t *FileSearchTool) Schema() types.ToolSchema {
 	if err != nil {
 		params = []byte("{}")
 	}
-	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params}
+	return types.ToolSchema{Name: t.Name(), Description: t.Description(), Parameters: params, Traits: readOnlyToolTraits(types.ToolLatencyMedium)}
 }
 
 func (t *FileSearchTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
@@ -397,18 +397,56 @@ func buildPermissionContext(ctx context.Context, tool HostedTool, args json.RawM
 	return permCtx
 }
 
+// readOnlyToolTraits 只读且幂等的内置工具声明
+func readOnlyToolTraits(latency types.ToolLatencyClass) *types.ToolTraits {
+	return &types.ToolTraits{
+		SideEffect:   types.ToolSideEffectNone,
+		Idempotent:   true,
+		LatencyClass: latency,
+		CostClass:    types.ToolCostFree,
+	}
+}
+
+// localWriteToolTraits 修改本地文件的内置工具声明
+func localWriteToolTraits(idempotent bool) *types.ToolTraits {
+	return &types.ToolTraits{
+		SideEffect:   types.ToolSideEffectLocal,
+		Idempotent:   idempotent,
+		LatencyClass: types.ToolLatencyFast,
+		CostClass:    types.ToolCostFree,
+	}
+}
+
+// localExecToolTraits 在本地执行命令/代码的内置工具声明
+func localExecToolTraits() *types.ToolTraits {
+	return &types.ToolTraits{
+		SideEffect:   types.ToolSideEffectLocal,
+		LatencyClass: types.ToolLatencyMedium,
+		CostClass:    types.ToolCostLow,
+	}
+}
+
 // ClassifyHostedToolPermissionRisk returns the stable policy metadata value
-// used by PermissionManager rules.
+// used by PermissionManager rules. Declared schema traits take precedence
+// over the type/name based classification.
 func ClassifyHostedToolPermissionRisk(tool HostedTool) string {
 	if tool == nil {
 		return "unknown"
 	}
+	if tool.Type() != ToolTypeAlias {
+		if traits := tool.Schema().Traits; traits.Declared() {
+			if traits.ReadOnly() {
+				return "safe_read"
+			}
+			return "requires_approval"
+		}
+	}
 
 	name := strings.TrimSpace(tool.Name())
 	switch tool.Type() {
 	case ToolTypeWebSearch, ToolTypeFileSearch, ToolTypeRetrieval:
 		return "safe_read"
-	case ToolTypeShell, ToolTypeCodeExec, ToolTypeMCP:
+	case ToolTypeShell, ToolTypeCodeExec, ToolTypeMCP, ToolTypeHTTP:
 		return "requires_approval"
 	case ToolTypeFileOps:
 		switch name {
@@ -444,7 +482,7 @@ func ClassifyHostedToolRiskTier(tool HostedTool) types.RiskTier {
 		return types.RiskExecution
 	}
 	switch tool.Type() {
-	case ToolTypeMCP:
+	case ToolTypeMCP, ToolTypeHTTP:
 		return types.RiskNetworkExecution
 	case ToolTypeFileOps:
 		switch strings.TrimSpace(tool.Name()) {
@@ -58,15 +58,16 @@ func (m *mockRetrievalStore) Retrieve(ctx context.Context, query string, topK in
 }
 
 type hostedRiskTestTool struct {
-	typ  HostedToolType
-	name string
+	typ    HostedToolType
+	name   string
+	traits *types.ToolTraits
 }
 
 func (t hostedRiskTestTool) Type() HostedToolType { return t.typ }
 func (t hostedRiskTestTool) Name() string         { return t.name }
 func (t hostedRiskTestTool) Description() string  { return "risk test tool" }
 func (t hostedRiskTestTool) Schema() types.ToolSchema {
-	return types.ToolSchema{Name: t.name, Parameters: json.RawMessage(`{"type":"object"}`)}
+	return types.ToolSchema{Name: t.name, Parameters: json.RawMessage(`{"type":"object"}`), Traits: t.traits}
 }
 func (t hostedRiskTestTool) Execute(context.Context, json.RawMessage) (json.RawMessage, error) {
 	return json.RawMessage(`{}`), nil
@@ -226,6 +227,25 @@ func TestClassifyHostedToolAuthorizationContracts(t *testing.T) {
 			wantTier:       types.RiskNetworkExecution,
 			wantPolicyRisk: "requires_approval",
 		},
+		{
+			name: "mcp declared read only",
+			tool: hostedRiskTestTool{typ: ToolTypeMCP, name: "mcp_lookup", traits: &types.ToolTraits{
+				SideEffect: types.ToolSideEffectNone,
+				Idempotent: true,
+			}},
+			wantResource:   types.ResourceMCPTool,
+			wantTier:       types.RiskSafeRead,
+			wantPolicyRisk: "safe_read",
+		},
+		{
+			name: "file search declared side effect",
+			tool: hostedRiskTestTool{typ: ToolTypeFileSearch, name: "file_search", traits: &types.ToolTraits{
+				SideEffect: types.ToolSideEffectExternal,
+			}},
+			wantResource:   types.ResourceTool,
+			wantTier:       types.RiskExecution,
+			wantPolicyRisk: "requires_approval",
+		},
 	}
 
 	for _, tc := range cases {
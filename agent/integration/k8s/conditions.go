@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+)
+
+// 标准状态条件类型, 与 Deployment 等内置资源保持一致, 使 `kubectl describe agent` 可读.
+const (
+	ConditionAvailable   = "Available"
+	ConditionProgressing = "Progressing"
+	ConditionDegraded    = "Degraded"
+)
+
+// 条件状态取值.
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// GetCondition 返回指定类型的条件, 不存在时返回 nil.
+func (s *AgentCRDStatus) GetCondition(condType string) *AgentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == condType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// updateStandardConditionsLocked 根据副本状态刷新 Available/Progressing/Degraded 条件,
+// 并返回由条件跃迁产生的事件. 调用方需持有 o.mu.
+func (o *AgentOperator) updateStandardConditionsLocked(agent *AgentCRD, scaling bool) []AgentEvent {
+	desired := agent.Spec.Replicas
+	ready := agent.Status.ReadyReplicas
+	replicaMsg := fmt.Sprintf("%d/%d replicas ready", ready, desired)
+
+	var events []AgentEvent
+
+	if ready >= desired {
+		if o.updateAgentConditionLocked(agent, ConditionAvailable, ConditionTrue, "MinimumReplicasAvailable", replicaMsg) && desired > 0 {
+			events = append(events, AgentEvent{Type: EventTypeNormal, Reason: EventReasonAvailable, Message: replicaMsg})
+		}
+	} else {
+		o.updateAgentConditionLocked(agent, ConditionAvailable, ConditionFalse, "MinimumReplicasUnavailable", replicaMsg)
+	}
+
+	switch {
+	case scaling:
+		o.updateAgentConditionLocked(agent, ConditionProgressing, ConditionTrue, "ScalingReplicas", replicaMsg)
+	case ready < desired:
+		o.updateAgentConditionLocked(agent, ConditionProgressing, ConditionTrue, "WaitingForReplicas", replicaMsg)
+	default:
+		o.updateAgentConditionLocked(agent, ConditionProgressing, ConditionFalse, "ReconcileComplete", replicaMsg)
+	}
+
+	if agent.Status.Phase == AgentPhaseDegraded {
+		if o.updateAgentConditionLocked(agent, ConditionDegraded, ConditionTrue, "ReplicasUnhealthy", replicaMsg) {
+			events = append(events, AgentEvent{Type: EventTypeWarning, Reason: EventReasonDegraded, Message: replicaMsg})
+		}
+	} else if prev := agent.Status.GetCondition(ConditionDegraded); prev == nil || prev.Status != ConditionFalse {
+		wasDegraded := prev != nil && prev.Status == ConditionTrue
+		o.updateAgentConditionLocked(agent, ConditionDegraded, ConditionFalse, "AsExpected", replicaMsg)
+		if wasDegraded {
+			events = append(events, AgentEvent{Type: EventTypeNormal, Reason: EventReasonHealed, Message: "Recovered from degraded state: " + replicaMsg})
+		}
+	}
+
+	return events
+}
+
+// recordEvent 通过当前事件记录器记录事件. 调用方不得持有 o.mu.
+func (o *AgentOperator) recordEvent(agent *AgentCRD, eventType, reason, message string) {
+	o.mu.RLock()
+	recorder := o.recorder
+	o.mu.RUnlock()
+	if recorder != nil {
+		recorder.Event(agent, eventType, reason, message)
+	}
+}
+
+// observeReconcileTime 以增量均值更新平均调和耗时.
+func (o *AgentOperator) observeReconcileTime(elapsed time.Duration) {
+	total := o.metrics.ReconcileTotal.Load()
+	if total <= 0 {
+		return
+	}
+	for {
+		prev := o.metrics.AverageReconcileTime.Load()
+		next := prev + (int64(elapsed)-prev)/total
+		if o.metrics.AverageReconcileTime.CompareAndSwap(prev, next) {
+			return
+		}
+	}
+}
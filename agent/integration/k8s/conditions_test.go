@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addTestAgent(op *AgentOperator, agent *AgentCRD) {
+	op.mu.Lock()
+	op.agents[agentKey(agent.Metadata.Namespace, agent.Metadata.Name)] = agent
+	op.mu.Unlock()
+}
+
+func markInstancesRunning(op *AgentOperator, agent *AgentCRD, n int) {
+	for i, inst := range op.GetInstances(agent.Metadata.Namespace, agent.Metadata.Name) {
+		if i >= n {
+			break
+		}
+		op.UpdateInstanceMetrics(inst.ID, InstanceMetrics{})
+	}
+}
+
+func eventReasons(events []AgentEvent) []string {
+	reasons := make([]string, 0, len(events))
+	for _, ev := range events {
+		reasons = append(reasons, ev.Reason)
+	}
+	return reasons
+}
+
+func TestReconcile_StandardConditionsAndEvents(t *testing.T) {
+	op := newTestOperator()
+	agent := newTestAgent("cond-agent", 2)
+	addTestAgent(op, agent)
+
+	// 首次调和: 扩容到 2 个副本, 尚无就绪副本.
+	op.reconcileAgent(agent)
+	assert.Equal(t, ConditionFalse, agent.Status.GetCondition(ConditionAvailable).Status)
+	assert.Equal(t, ConditionTrue, agent.Status.GetCondition(ConditionProgressing).Status)
+	assert.Equal(t, "ScalingReplicas", agent.Status.GetCondition(ConditionProgressing).Reason)
+	assert.Contains(t, eventReasons(op.GetEvents("default", "cond-agent")), EventReasonScaledUp)
+
+	// 仅一个副本就绪: Degraded.
+	markInstancesRunning(op, agent, 1)
+	op.reconcileAgent(agent)
+	assert.Equal(t, AgentPhaseDegraded, agent.Status.Phase)
+	assert.Equal(t, ConditionTrue, agent.Status.GetCondition(ConditionDegraded).Status)
+	assert.Contains(t, eventReasons(op.GetEvents("default", "cond-agent")), EventReasonDegraded)
+
+	// 全部就绪: Available 且从 Degraded 恢复.
+	degradedSince := agent.Status.GetCondition(ConditionDegraded).LastTransitionTime
+	markInstancesRunning(op, agent, 2)
+	op.reconcileAgent(agent)
+	assert.Equal(t, ConditionTrue, agent.Status.GetCondition(ConditionAvailable).Status)
+	assert.Equal(t, ConditionFalse, agent.Status.GetCondition(ConditionProgressing).Status)
+	assert.Equal(t, ConditionFalse, agent.Status.GetCondition(ConditionDegraded).Status)
+	assert.True(t, agent.Status.GetCondition(ConditionDegraded).LastTransitionTime.After(degradedSince))
+	reasons := eventReasons(op.GetEvents("default", "cond-agent"))
+	assert.Contains(t, reasons, EventReasonHealed)
+	assert.Contains(t, reasons, EventReasonAvailable)
+
+	// 稳定状态下重复调和不会刷新 LastTransitionTime, 也不会产生新事件.
+	availableSince := agent.Status.GetCondition(ConditionAvailable).LastTransitionTime
+	eventCount := len(op.GetEvents("default", "cond-agent"))
+	op.reconcileAgent(agent)
+	assert.Equal(t, availableSince, agent.Status.GetCondition(ConditionAvailable).LastTransitionTime)
+	assert.Len(t, op.GetEvents("default", "cond-agent"), eventCount)
+}
+
+func TestInMemoryEventRecorder_DedupAndCap(t *testing.T) {
+	r := NewInMemoryEventRecorder(2)
+	agent := newTestAgent("ev-agent", 1)
+
+	r.Event(agent, EventTypeWarning, EventReasonDegraded, "1/2 replicas ready")
+	r.Event(agent, EventTypeWarning, EventReasonDegraded, "1/2 replicas ready")
+	events := r.Events("default", "ev-agent")
+	require.Len(t, events, 1)
+	assert.Equal(t, int32(2), events[0].Count)
+
+	r.Event(agent, EventTypeNormal, EventReasonScaledUp, "a")
+	r.Event(agent, EventTypeNormal, EventReasonScaledDown, "b")
+	events = r.Events("default", "ev-agent")
+	require.Len(t, events, 2)
+	assert.Equal(t, EventReasonScaledUp, events[0].Reason)
+}
+
+func TestOperatorMetricsHandler(t *testing.T) {
+	op := newTestOperator()
+	agent := newTestAgent("metrics-agent", 1)
+	addTestAgent(op, agent)
+	op.reconcileAgent(agent)
+
+	srv := httptest.NewServer(op.MetricsHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.Contains(t, text, "agentflow_operator_reconcile_total 1")
+	assert.Contains(t, text, `agentflow_operator_scale_events_total{direction="up"} 1`)
+	assert.Contains(t, text, `agentflow_operator_agent_desired_replicas{name="metrics-agent",namespace="default"} 1`)
+	assert.Contains(t, text, `agentflow_operator_agent_condition{name="metrics-agent",namespace="default",status="False",type="Available"} 1`)
+}
@@ -0,0 +1,456 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/tools"
+	toolremote "github.com/BaSui01/agentflow/agent/capabilities/tools/remote"
+	a2ashared "github.com/BaSui01/agentflow/agent/execution/protocol/a2a/shared"
+	"go.uber.org/zap"
+)
+
+// 代理发现使用的标签与注解.
+const (
+	// LabelDiscovery 标记参与发现的资源, 值为 "enabled".
+	LabelDiscovery = "agentflow.io/discovery"
+	// LabelAgentName 指定代理 ID; 缺省时使用 <namespace>/<service 或 app 名>.
+	LabelAgentName = "agentflow.io/agent"
+	// LabelCapabilityPrefix 以标签声明能力, 如 capability.agentflow.io/web_search: "true".
+	LabelCapabilityPrefix = "capability.agentflow.io/"
+	// LabelServiceName 是 EndpointSlice 指向所属 Service 的标准标签.
+	LabelServiceName = "kubernetes.io/service-name"
+
+	// AnnotationCapabilities 以逗号分隔声明能力, 仅 Pod 可用 (EndpointSlice 不继承注解).
+	AnnotationCapabilities = "agentflow.io/capabilities"
+	AnnotationDescription  = "agentflow.io/description"
+	AnnotationVersion      = "agentflow.io/version"
+	// AnnotationPort 覆盖资源上探测到的端口.
+	AnnotationPort     = "agentflow.io/port"
+	AnnotationPriority = "agentflow.io/priority"
+
+	// DefaultDiscoverySelector 是默认的标签选择器.
+	DefaultDiscoverySelector = LabelDiscovery + "=enabled"
+	// DiscoverySourceKubernetes 写入 AgentInfo.Metadata[tools.MetadataDiscoverySource].
+	DiscoverySourceKubernetes = "kubernetes"
+)
+
+// 写入 AgentInfo.Metadata 的 Kubernetes 字段.
+const (
+	MetadataNamespace     = "k8s.namespace"
+	MetadataKind          = "k8s.kind"
+	MetadataReadyReplicas = "k8s.ready_replicas"
+	MetadataReplicas      = "k8s.replicas"
+)
+
+// K8sRegistryConfig 配置 Kubernetes 注册表后端.
+type K8sRegistryConfig struct {
+	// ResyncInterval 为周期性全量 list 的间隔, 用于修正丢失的 watch 事件.
+	ResyncInterval time.Duration `json:"resyncInterval"`
+	// RetryInterval 为 list/watch 失败后的重试间隔.
+	RetryInterval time.Duration `json:"retryInterval"`
+	// DefaultPort 在资源与注解都未给出端口时使用.
+	DefaultPort int `json:"defaultPort"`
+	// Scheme 为代理端点的协议, 默认 http.
+	Scheme string `json:"scheme"`
+}
+
+// DefaultK8sRegistryConfig 返回合理的默认值.
+func DefaultK8sRegistryConfig() K8sRegistryConfig {
+	return K8sRegistryConfig{
+		ResyncInterval: 5 * time.Minute,
+		RetryInterval:  5 * time.Second,
+		DefaultPort:    8080,
+		Scheme:         "http",
+	}
+}
+
+func (c K8sRegistryConfig) withDefaults() K8sRegistryConfig {
+	defaults := DefaultK8sRegistryConfig()
+	if c.ResyncInterval <= 0 {
+		c.ResyncInterval = defaults.ResyncInterval
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaults.RetryInterval
+	}
+	if c.DefaultPort <= 0 {
+		c.DefaultPort = defaults.DefaultPort
+	}
+	if c.Scheme == "" {
+		c.Scheme = defaults.Scheme
+	}
+	return c
+}
+
+// K8sRegistry 是以 Kubernetes 为数据源的 tools.Registry 实现.
+// 它通过 list/watch 把带发现标签的 Pod 或 EndpointSlice 同步到内部注册表,
+// 同一代理的多个副本聚合为一个 AgentInfo. 查询方法全部委托给内部注册表.
+type K8sRegistry struct {
+	tools.Registry
+
+	watcher ResourceWatcher
+	config  K8sRegistryConfig
+	logger  *zap.Logger
+
+	mu        sync.Mutex
+	resources map[string]AgentResource
+	managed   map[string]struct{}
+
+	synced  atomic.Bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+var _ tools.Registry = (*K8sRegistry)(nil)
+
+// NewK8sRegistry 创建 Kubernetes 注册表. inner 为空时使用内存 CapabilityRegistry.
+func NewK8sRegistry(inner tools.Registry, watcher ResourceWatcher, config K8sRegistryConfig, logger *zap.Logger) *K8sRegistry {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if inner == nil {
+		inner = tools.NewCapabilityRegistry(nil, logger)
+	}
+	return &K8sRegistry{
+		Registry:  inner,
+		watcher:   watcher,
+		config:    config.withDefaults(),
+		logger:    logger.With(zap.String("component", "k8s_registry")),
+		resources: make(map[string]AgentResource),
+		managed:   make(map[string]struct{}),
+	}
+}
+
+// Start 同步执行首次 list, 之后在后台持续 watch. 首次 list 失败时返回错误.
+func (r *K8sRegistry) Start(ctx context.Context) error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return fmt.Errorf("k8s registry already running")
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	resourceVersion, err := r.relist(ctx)
+	if err != nil {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+		return fmt.Errorf("initial list: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	r.mu.Lock()
+	r.cancel = cancel
+	r.done = done
+	r.mu.Unlock()
+
+	go r.run(runCtx, resourceVersion, done)
+	return nil
+}
+
+// Stop 停止 watch 并等待后台协程退出. 已同步的代理保留在内部注册表中.
+func (r *K8sRegistry) Stop() {
+	r.mu.Lock()
+	if !r.running || r.cancel == nil {
+		r.mu.Unlock()
+		return
+	}
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.running = false
+	r.mu.Unlock()
+
+	cancel()
+	<-done
+}
+
+// Close 停止 watch 并关闭内部注册表.
+func (r *K8sRegistry) Close() error {
+	r.Stop()
+	return r.Registry.Close()
+}
+
+// HasSynced 报告是否已完成至少一次全量同步.
+func (r *K8sRegistry) HasSynced() bool {
+	return r.synced.Load()
+}
+
+func (r *K8sRegistry) run(ctx context.Context, resourceVersion string, done chan struct{}) {
+	defer close(done)
+
+	resync := time.NewTicker(r.config.ResyncInterval)
+	defer resync.Stop()
+
+	for ctx.Err() == nil {
+		needRelist, err := r.watchOnce(ctx, resourceVersion, resync.C, &resourceVersion)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.logger.Warn("watch failed", zap.Error(err))
+			needRelist = errors.Is(err, ErrResourceVersionExpired)
+			if !needRelist && !sleepCtx(ctx, r.config.RetryInterval) {
+				return
+			}
+		}
+		if !needRelist {
+			continue
+		}
+		rv, err := r.relist(ctx)
+		if err != nil {
+			r.logger.Warn("relist failed", zap.Error(err))
+			if !sleepCtx(ctx, r.config.RetryInterval) {
+				return
+			}
+			continue
+		}
+		resourceVersion = rv
+	}
+}
+
+// watchOnce 消费一次 watch 流, 返回是否需要重新 list. 最新的 resourceVersion 写回 rv.
+func (r *K8sRegistry) watchOnce(ctx context.Context, from string, resync <-chan time.Time, rv *string) (bool, error) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := r.watcher.Watch(watchCtx, from)
+	if err != nil {
+		return false, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-resync:
+			return true, nil
+		case event, ok := <-events:
+			if !ok {
+				return false, nil
+			}
+			if event.Type == ResourceError {
+				return true, nil
+			}
+			r.apply(ctx, event)
+			if event.Resource.ResourceVersion != "" {
+				*rv = event.Resource.ResourceVersion
+			}
+		}
+	}
+}
+
+func (r *K8sRegistry) apply(ctx context.Context, event ResourceEvent) {
+	key := event.Resource.key()
+
+	r.mu.Lock()
+	affected := map[string]struct{}{}
+	if old, ok := r.resources[key]; ok {
+		affected[agentIDFor(old)] = struct{}{}
+	}
+	if event.Type == ResourceDeleted {
+		delete(r.resources, key)
+	} else {
+		r.resources[key] = event.Resource
+		affected[agentIDFor(event.Resource)] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	for agentID := range affected {
+		r.syncAgent(ctx, agentID)
+	}
+}
+
+func (r *K8sRegistry) relist(ctx context.Context) (string, error) {
+	resources, resourceVersion, err := r.watcher.List(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	affected := make(map[string]struct{}, len(resources)+len(r.managed))
+	for agentID := range r.managed {
+		affected[agentID] = struct{}{}
+	}
+	r.resources = make(map[string]AgentResource, len(resources))
+	for _, res := range resources {
+		r.resources[res.key()] = res
+		affected[agentIDFor(res)] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	for agentID := range affected {
+		r.syncAgent(ctx, agentID)
+	}
+	r.synced.Store(true)
+	return resourceVersion, nil
+}
+
+// syncAgent 把 agentID 的所有副本聚合后写入内部注册表.
+// 非本注册表创建的同名代理 (如通过 HTTP 自注册) 只同步状态.
+func (r *K8sRegistry) syncAgent(ctx context.Context, agentID string) {
+	r.mu.Lock()
+	var members []AgentResource
+	for _, res := range r.resources {
+		if agentIDFor(res) == agentID {
+			members = append(members, res)
+		}
+	}
+	_, managed := r.managed[agentID]
+	r.mu.Unlock()
+
+	if len(members) == 0 {
+		if !managed {
+			return
+		}
+		if err := r.Registry.UnregisterAgent(ctx, agentID); err != nil {
+			r.logger.Debug("unregister agent", zap.String("agent_id", agentID), zap.Error(err))
+		}
+		r.mu.Lock()
+		delete(r.managed, agentID)
+		r.mu.Unlock()
+		return
+	}
+
+	info := r.buildAgentInfo(agentID, members)
+	existing, err := r.Registry.GetAgent(ctx, agentID)
+	switch {
+	case err != nil:
+		if err := r.Registry.RegisterAgent(ctx, info); err != nil {
+			r.logger.Warn("register agent", zap.String("agent_id", agentID), zap.Error(err))
+			return
+		}
+		r.mu.Lock()
+		r.managed[agentID] = struct{}{}
+		r.mu.Unlock()
+	case managed:
+		carryCapabilityScores(info, existing)
+		if err := r.Registry.UpdateAgent(ctx, info); err != nil {
+			r.logger.Warn("update agent", zap.String("agent_id", agentID), zap.Error(err))
+		}
+	default:
+		if err := r.Registry.UpdateAgentStatus(ctx, agentID, info.Status); err != nil {
+			r.logger.Debug("update agent status", zap.String("agent_id", agentID), zap.Error(err))
+		}
+	}
+}
+
+func (r *K8sRegistry) buildAgentInfo(agentID string, members []AgentResource) *tools.AgentInfo {
+	sort.Slice(members, func(i, j int) bool { return members[i].key() < members[j].key() })
+
+	primary := members[0]
+	ready := 0
+	for _, m := range members {
+		if m.Ready {
+			if ready == 0 {
+				primary = m
+			}
+			ready++
+		}
+	}
+
+	card := a2ashared.NewAgentCard(agentID, primary.Annotations[AnnotationDescription], r.endpointFor(primary), primary.Annotations[AnnotationVersion])
+	status := tools.AgentStatusOnline
+	if ready == 0 {
+		status = tools.AgentStatusUnhealthy
+	}
+	priority, _ := strconv.Atoi(primary.Annotations[AnnotationPriority])
+
+	seen := make(map[string]struct{})
+	var capabilities []tools.CapabilityInfo
+	for _, m := range members {
+		for _, name := range capabilityNames(m) {
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+			capabilities = append(capabilities, tools.CapabilityInfo{
+				Capability: a2ashared.Capability{Name: name, Type: a2ashared.CapabilityTypeTask},
+				Status:     tools.CapabilityStatusActive,
+			})
+		}
+	}
+	sort.Slice(capabilities, func(i, j int) bool {
+		return capabilities[i].Capability.Name < capabilities[j].Capability.Name
+	})
+
+	return &tools.AgentInfo{
+		Card:         card,
+		Status:       status,
+		Capabilities: capabilities,
+		Priority:     priority,
+		Endpoint:     card.URL,
+		Metadata: map[string]string{
+			tools.MetadataDiscoverySource: DiscoverySourceKubernetes,
+			MetadataNamespace:             primary.Namespace,
+			MetadataKind:                  string(primary.Kind),
+			MetadataReadyReplicas:         strconv.Itoa(ready),
+			MetadataReplicas:              strconv.Itoa(len(members)),
+		},
+	}
+}
+
+func (r *K8sRegistry) endpointFor(res AgentResource) string {
+	if res.Host == "" {
+		return ""
+	}
+	port := res.Port
+	if p, err := strconv.Atoi(res.Annotations[AnnotationPort]); err == nil && p > 0 {
+		port = p
+	}
+	if port <= 0 {
+		port = r.config.DefaultPort
+	}
+	return r.config.Scheme + "://" + net.JoinHostPort(res.Host, strconv.Itoa(port))
+}
+
+// agentIDFor 优先使用 LabelAgentName, 否则按 Service 或应用名生成 <namespace>/<name>.
+func agentIDFor(res AgentResource) string {
+	if name := strings.TrimSpace(res.Labels[LabelAgentName]); name != "" {
+		return name
+	}
+	for _, key := range []string{LabelServiceName, "app.kubernetes.io/name", "app"} {
+		if name := strings.TrimSpace(res.Labels[key]); name != "" {
+			return res.Namespace + "/" + name
+		}
+	}
+	return res.Namespace + "/" + res.Name
+}
+
+func capabilityNames(res AgentResource) []string {
+	var names []string
+	for key, value := range res.Labels {
+		if name, ok := strings.CutPrefix(key, LabelCapabilityPrefix); ok && name != "" && value != "false" {
+			names = append(names, name)
+		}
+	}
+	names = append(names, toolremote.SplitAndTrimCSV(res.Annotations[AnnotationCapabilities])...)
+	return names
+}
+
+// carryCapabilityScores 保留历史执行得分, 避免每次 watch 事件重置能力评分.
+func carryCapabilityScores(info, existing *tools.AgentInfo) {
+	if existing == nil {
+		return
+	}
+	prev := make(map[string]tools.CapabilityInfo, len(existing.Capabilities))
+	for _, c := range existing.Capabilities {
+		prev[c.Capability.Name] = c
+	}
+	for i := range info.Capabilities {
+		if old, ok := prev[info.Capabilities[i].Capability.Name]; ok {
+			info.Capabilities[i].Score = old.Score
+			info.Capabilities[i].RegisteredAt = old.RegisteredAt
+		}
+	}
+	info.Load = existing.Load
+}
@@ -0,0 +1,198 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/tools"
+	a2ashared "github.com/BaSui01/agentflow/agent/execution/protocol/a2a/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeResourceWatcher struct {
+	mu        sync.Mutex
+	items     []AgentResource
+	version   string
+	lists     int
+	watches   chan chan ResourceEvent
+	watchFrom []string
+}
+
+func newFakeResourceWatcher(items ...AgentResource) *fakeResourceWatcher {
+	return &fakeResourceWatcher{items: items, version: "1", watches: make(chan chan ResourceEvent, 8)}
+}
+
+func (f *fakeResourceWatcher) List(context.Context) ([]AgentResource, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists++
+	return append([]AgentResource(nil), f.items...), f.version, nil
+}
+
+func (f *fakeResourceWatcher) Watch(_ context.Context, resourceVersion string) (<-chan ResourceEvent, error) {
+	f.mu.Lock()
+	f.watchFrom = append(f.watchFrom, resourceVersion)
+	f.mu.Unlock()
+	ch := make(chan ResourceEvent, 8)
+	f.watches <- ch
+	return ch, nil
+}
+
+func (f *fakeResourceWatcher) listCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lists
+}
+
+func (f *fakeResourceWatcher) nextWatch(t *testing.T) chan ResourceEvent {
+	t.Helper()
+	select {
+	case ch := <-f.watches:
+		return ch
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch was not opened")
+		return nil
+	}
+}
+
+func agentPod(name, app, ip string, ready bool, capabilityLabels ...string) AgentResource {
+	labels := map[string]string{LabelDiscovery: "enabled", "app": app}
+	for _, c := range capabilityLabels {
+		labels[LabelCapabilityPrefix+c] = "true"
+	}
+	return AgentResource{
+		Kind:        ResourceKindPod,
+		Namespace:   "agents",
+		Name:        name,
+		Labels:      labels,
+		Annotations: map[string]string{AnnotationDescription: "Research agent", AnnotationVersion: "2.0.0"},
+		Host:        ip,
+		Port:        9000,
+		Ready:       ready,
+	}
+}
+
+func TestK8sRegistry_InitialListAggregatesReplicas(t *testing.T) {
+	ctx := context.Background()
+	notReady := agentPod("researcher-b", "researcher", "10.0.0.6", false, "summarize")
+	notReady.Annotations[AnnotationCapabilities] = "translate, web_search"
+	watcher := newFakeResourceWatcher(
+		agentPod("researcher-a", "researcher", "10.0.0.5", true, "web_search"),
+		notReady,
+	)
+	reg := NewK8sRegistry(nil, watcher, K8sRegistryConfig{}, zap.NewNop())
+	require.NoError(t, reg.Start(ctx))
+	defer reg.Close()
+
+	assert.True(t, reg.HasSynced())
+	info, err := reg.GetAgent(ctx, "agents/researcher")
+	require.NoError(t, err)
+	assert.Equal(t, tools.AgentStatusOnline, info.Status)
+	assert.Equal(t, "http://10.0.0.5:9000", info.Endpoint)
+	assert.Equal(t, "2.0.0", info.Card.Version)
+	assert.Equal(t, DiscoverySourceKubernetes, info.Metadata[tools.MetadataDiscoverySource])
+	assert.Equal(t, "1", info.Metadata[MetadataReadyReplicas])
+	assert.Equal(t, "2", info.Metadata[MetadataReplicas])
+
+	names := make([]string, 0, len(info.Capabilities))
+	for _, c := range info.Capabilities {
+		names = append(names, c.Capability.Name)
+	}
+	assert.Equal(t, []string{"summarize", "translate", "web_search"}, names)
+
+	found, err := reg.FindCapabilities(ctx, "translate")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "agents/researcher", found[0].AgentID)
+}
+
+func TestK8sRegistry_WatchEventsKeepRegistryInSync(t *testing.T) {
+	ctx := context.Background()
+	watcher := newFakeResourceWatcher()
+	reg := NewK8sRegistry(nil, watcher, K8sRegistryConfig{}, zap.NewNop())
+	require.NoError(t, reg.Start(ctx))
+	defer reg.Close()
+	events := watcher.nextWatch(t)
+
+	pod := agentPod("coder-0", "coder", "10.0.1.1", false, "code_review")
+	pod.Labels[LabelAgentName] = "coder"
+	pod.ResourceVersion = "5"
+	events <- ResourceEvent{Type: ResourceAdded, Resource: pod}
+	require.Eventually(t, func() bool {
+		info, err := reg.GetAgent(ctx, "coder")
+		return err == nil && info.Status == tools.AgentStatusUnhealthy
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pod.Ready = true
+	pod.Annotations[AnnotationPort] = "7000"
+	events <- ResourceEvent{Type: ResourceModified, Resource: pod}
+	require.Eventually(t, func() bool {
+		info, err := reg.GetAgent(ctx, "coder")
+		return err == nil && info.Status == tools.AgentStatusOnline && info.Endpoint == "http://10.0.1.1:7000"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	events <- ResourceEvent{Type: ResourceDeleted, Resource: pod}
+	require.Eventually(t, func() bool {
+		_, err := reg.GetAgent(ctx, "coder")
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// watch 正常结束后从最新 resourceVersion 续订
+	close(events)
+	watcher.nextWatch(t)
+	watcher.mu.Lock()
+	assert.Equal(t, []string{"1", "5"}, watcher.watchFrom)
+	watcher.mu.Unlock()
+}
+
+func TestK8sRegistry_ErrorEventRelists(t *testing.T) {
+	ctx := context.Background()
+	watcher := newFakeResourceWatcher(agentPod("old-0", "old", "10.0.2.1", true, "plan"))
+	reg := NewK8sRegistry(nil, watcher, K8sRegistryConfig{}, zap.NewNop())
+	require.NoError(t, reg.Start(ctx))
+	defer reg.Close()
+	events := watcher.nextWatch(t)
+
+	watcher.mu.Lock()
+	watcher.items = []AgentResource{agentPod("new-0", "new", "10.0.2.2", true, "plan")}
+	watcher.version = "9"
+	watcher.mu.Unlock()
+	events <- ResourceEvent{Type: ResourceError}
+
+	watcher.nextWatch(t)
+	assert.Equal(t, 2, watcher.listCount())
+	_, err := reg.GetAgent(ctx, "agents/old")
+	assert.Error(t, err)
+	_, err = reg.GetAgent(ctx, "agents/new")
+	assert.NoError(t, err)
+}
+
+func TestK8sRegistry_SelfRegisteredAgentOnlyGetsStatus(t *testing.T) {
+	ctx := context.Background()
+	inner := tools.NewCapabilityRegistry(nil, zap.NewNop())
+	card := a2ashared.NewAgentCard("agents/planner", "Registered over HTTP", "http://planner:8080", "1.0.0")
+	require.NoError(t, inner.RegisterAgent(ctx, &tools.AgentInfo{
+		Card:         card,
+		Capabilities: []tools.CapabilityInfo{{Capability: a2ashared.Capability{Name: "plan", Type: a2ashared.CapabilityTypeTask}}},
+	}))
+
+	watcher := newFakeResourceWatcher(agentPod("planner-0", "planner", "10.0.3.1", false, "other"))
+	reg := NewK8sRegistry(inner, watcher, K8sRegistryConfig{}, zap.NewNop())
+	require.NoError(t, reg.Start(ctx))
+
+	info, err := reg.GetAgent(ctx, "agents/planner")
+	require.NoError(t, err)
+	assert.Equal(t, "Registered over HTTP", info.Card.Description)
+	assert.Equal(t, tools.AgentStatusUnhealthy, info.Status)
+	require.Len(t, info.Capabilities, 1)
+
+	// 停止 watch 不会移除非本注册表创建的代理
+	reg.Stop()
+	_, err = inner.GetAgent(ctx, "agents/planner")
+	assert.NoError(t, err)
+	require.NoError(t, reg.Close())
+}
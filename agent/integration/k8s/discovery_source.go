@@ -0,0 +1,342 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ResourceKind 是参与代理发现的 Kubernetes 资源类型.
+type ResourceKind string
+
+const (
+	// ResourceKindPod 直接从带标签的 Pod 发现代理, 端点为 Pod IP.
+	ResourceKindPod ResourceKind = "Pod"
+	// ResourceKindEndpointSlice 从 Service 的 EndpointSlice 发现代理, 端点为集群内 Service DNS.
+	// EndpointSlice 会继承 Service 的标签, 因此能力需以标签形式声明.
+	ResourceKindEndpointSlice ResourceKind = "EndpointSlice"
+)
+
+// AgentResource 是从 Pod 或 EndpointSlice 中提取的发现所需字段.
+type AgentResource struct {
+	Kind            ResourceKind      `json:"kind"`
+	Namespace       string            `json:"namespace"`
+	Name            string            `json:"name"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	Host            string            `json:"host,omitempty"`
+	Port            int               `json:"port,omitempty"`
+	Ready           bool              `json:"ready"`
+}
+
+func (r AgentResource) key() string {
+	return string(r.Kind) + "/" + r.Namespace + "/" + r.Name
+}
+
+// ResourceEventType 对应 Kubernetes watch 事件类型.
+type ResourceEventType string
+
+const (
+	ResourceAdded    ResourceEventType = "ADDED"
+	ResourceModified ResourceEventType = "MODIFIED"
+	ResourceDeleted  ResourceEventType = "DELETED"
+	// ResourceError 表示 watch 流中断, 消费方应重新 list.
+	ResourceError ResourceEventType = "ERROR"
+)
+
+// ResourceEvent 是一条资源变更事件.
+type ResourceEvent struct {
+	Type     ResourceEventType
+	Resource AgentResource
+}
+
+// ErrResourceVersionExpired 表示 watch 的 resourceVersion 已过期 (HTTP 410), 需要重新 list.
+var ErrResourceVersionExpired = errors.New("k8s: resource version expired")
+
+// ResourceWatcher 抽象 Kubernetes 的 list/watch 语义. client-go 实现可包装 SharedInformer.
+// Watch 返回的通道在 watch 结束时关闭.
+type ResourceWatcher interface {
+	List(ctx context.Context) ([]AgentResource, string, error)
+	Watch(ctx context.Context, resourceVersion string) (<-chan ResourceEvent, error)
+}
+
+// APIServerConfig 描述访问 Kubernetes API Server 的方式.
+type APIServerConfig struct {
+	// Host 为 API Server 地址, 如 https://10.0.0.1:443.
+	Host        string
+	BearerToken string
+	// HTTPClient 为空时使用 http.DefaultClient.
+	HTTPClient *http.Client
+	// Namespace 为空时监听所有命名空间.
+	Namespace string
+}
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// InClusterAPIServerConfig 从 Pod 内的 ServiceAccount 构造配置, 默认只监听当前命名空间.
+func InClusterAPIServerConfig() (APIServerConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return APIServerConfig{}, fmt.Errorf("not running in a kubernetes cluster: KUBERNETES_SERVICE_HOST/PORT not set")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return APIServerConfig{}, fmt.Errorf("read service account token: %w", err)
+	}
+	caData, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return APIServerConfig{}, fmt.Errorf("read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return APIServerConfig{}, fmt.Errorf("service account CA contains no certificates")
+	}
+	namespace, _ := os.ReadFile(serviceAccountDir + "/namespace")
+
+	return APIServerConfig{
+		Host:        "https://" + net.JoinHostPort(host, port),
+		BearerToken: strings.TrimSpace(string(token)),
+		HTTPClient: &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		}},
+		Namespace: strings.TrimSpace(string(namespace)),
+	}, nil
+}
+
+// APIServerWatcher 通过 API Server 的 REST list/watch 接口实现 ResourceWatcher.
+type APIServerWatcher struct {
+	config        APIServerConfig
+	kind          ResourceKind
+	labelSelector string
+}
+
+var _ ResourceWatcher = (*APIServerWatcher)(nil)
+
+// NewAPIServerWatcher 创建按标签选择器监听指定资源类型的 watcher.
+func NewAPIServerWatcher(config APIServerConfig, kind ResourceKind, labelSelector string) *APIServerWatcher {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.Host = strings.TrimRight(config.Host, "/")
+	return &APIServerWatcher{config: config, kind: kind, labelSelector: labelSelector}
+}
+
+// List 列出匹配的资源并返回列表的 resourceVersion.
+func (w *APIServerWatcher) List(ctx context.Context) ([]AgentResource, string, error) {
+	resp, err := w.do(ctx, url.Values{})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Items []k8sObject `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", fmt.Errorf("decode %s list: %w", w.kind, err)
+	}
+	resources := make([]AgentResource, 0, len(list.Items))
+	for i := range list.Items {
+		resources = append(resources, list.Items[i].toResource(w.kind))
+	}
+	return resources, list.Metadata.ResourceVersion, nil
+}
+
+// Watch 从 resourceVersion 开始监听变更. 书签事件会被跳过.
+func (w *APIServerWatcher) Watch(ctx context.Context, resourceVersion string) (<-chan ResourceEvent, error) {
+	query := url.Values{}
+	query.Set("watch", "1")
+	query.Set("allowWatchBookmarks", "true")
+	if resourceVersion != "" {
+		query.Set("resourceVersion", resourceVersion)
+	}
+	resp, err := w.do(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ResourceEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+		for {
+			var raw struct {
+				Type   string          `json:"type"`
+				Object json.RawMessage `json:"object"`
+			}
+			if err := decoder.Decode(&raw); err != nil {
+				return
+			}
+			event := ResourceEvent{Type: ResourceEventType(raw.Type)}
+			switch event.Type {
+			case ResourceAdded, ResourceModified, ResourceDeleted:
+				var obj k8sObject
+				if err := json.Unmarshal(raw.Object, &obj); err != nil {
+					event = ResourceEvent{Type: ResourceError}
+				} else {
+					event.Resource = obj.toResource(w.kind)
+				}
+			case ResourceError:
+			default:
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+			if event.Type == ResourceError {
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (w *APIServerWatcher) do(ctx context.Context, query url.Values) (*http.Response, error) {
+	if w.labelSelector != "" {
+		query.Set("labelSelector", w.labelSelector)
+	}
+	endpoint := w.config.Host + w.resourcePath() + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if w.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.config.BearerToken)
+	}
+	resp, err := w.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request: %w", w.kind, err)
+	}
+	if resp.StatusCode == http.StatusGone {
+		resp.Body.Close()
+		return nil, ErrResourceVersionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s request failed: %s: %s", w.kind, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (w *APIServerWatcher) resourcePath() string {
+	prefix, plural := "/api/v1", "pods"
+	if w.kind == ResourceKindEndpointSlice {
+		prefix, plural = "/apis/discovery.k8s.io/v1", "endpointslices"
+	}
+	if w.config.Namespace != "" {
+		return prefix + "/namespaces/" + url.PathEscape(w.config.Namespace) + "/" + plural
+	}
+	return prefix + "/" + plural
+}
+
+// k8sObject 只解码 Pod 与 EndpointSlice 中发现所需的字段.
+type k8sObject struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		ResourceVersion string            `json:"resourceVersion"`
+		Labels          map[string]string `json:"labels"`
+		Annotations     map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Containers []struct {
+			Ports []struct {
+				ContainerPort int `json:"containerPort"`
+			} `json:"ports"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string `json:"phase"`
+		PodIP      string `json:"podIP"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+	Endpoints []struct {
+		Conditions struct {
+			Ready *bool `json:"ready"`
+		} `json:"conditions"`
+	} `json:"endpoints"`
+	Ports []struct {
+		Port *int `json:"port"`
+	} `json:"ports"`
+}
+
+func (o *k8sObject) toResource(kind ResourceKind) AgentResource {
+	res := AgentResource{
+		Kind:            kind,
+		Namespace:       o.Metadata.Namespace,
+		Name:            o.Metadata.Name,
+		ResourceVersion: o.Metadata.ResourceVersion,
+		Labels:          o.Metadata.Labels,
+		Annotations:     o.Metadata.Annotations,
+	}
+	switch kind {
+	case ResourceKindEndpointSlice:
+		if svc := o.Metadata.Labels[LabelServiceName]; svc != "" {
+			res.Host = svc + "." + o.Metadata.Namespace + ".svc"
+		}
+		for _, p := range o.Ports {
+			if p.Port != nil {
+				res.Port = *p.Port
+				break
+			}
+		}
+		// 按 EndpointSlice 语义, 未设置 ready 视为就绪
+		for _, ep := range o.Endpoints {
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				res.Ready = true
+				break
+			}
+		}
+	default:
+		res.Host = o.Status.PodIP
+		for _, c := range o.Spec.Containers {
+			if len(c.Ports) > 0 {
+				res.Port = c.Ports[0].ContainerPort
+				break
+			}
+		}
+		if o.Status.Phase == "Running" {
+			for _, c := range o.Status.Conditions {
+				if c.Type == "Ready" && c.Status == "True" {
+					res.Ready = true
+				}
+			}
+		}
+	}
+	return res
+}
+
+// sleepCtx 等待 d 或 ctx 结束, ctx 结束时返回 false.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
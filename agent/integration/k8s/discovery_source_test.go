@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const podListJSON = `{
+  "metadata": {"resourceVersion": "100"},
+  "items": [{
+    "metadata": {"name": "researcher-0", "namespace": "agents", "resourceVersion": "99",
+      "labels": {"agentflow.io/discovery": "enabled", "app": "researcher"},
+      "annotations": {"agentflow.io/capabilities": "web_search"}},
+    "spec": {"containers": [{"ports": [{"containerPort": 9000}]}]},
+    "status": {"phase": "Running", "podIP": "10.0.0.5",
+      "conditions": [{"type": "Ready", "status": "True"}]}
+  }]
+}`
+
+func TestAPIServerWatcher_ListPods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/agents/pods", r.URL.Path)
+		assert.Equal(t, DefaultDiscoverySelector, r.URL.Query().Get("labelSelector"))
+		assert.Equal(t, "Bearer token-123", r.Header.Get("Authorization"))
+		fmt.Fprint(w, podListJSON)
+	}))
+	defer srv.Close()
+
+	watcher := NewAPIServerWatcher(APIServerConfig{Host: srv.URL, BearerToken: "token-123", Namespace: "agents"}, ResourceKindPod, DefaultDiscoverySelector)
+	items, rv, err := watcher.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "100", rv)
+	require.Len(t, items, 1)
+	assert.Equal(t, "researcher-0", items[0].Name)
+	assert.Equal(t, "10.0.0.5", items[0].Host)
+	assert.Equal(t, 9000, items[0].Port)
+	assert.True(t, items[0].Ready)
+	assert.Equal(t, "web_search", items[0].Annotations[AnnotationCapabilities])
+}
+
+func TestAPIServerWatcher_WatchEndpointSlices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/discovery.k8s.io/v1/endpointslices", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("watch"))
+		assert.Equal(t, "42", r.URL.Query().Get("resourceVersion"))
+		fmt.Fprintln(w, `{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"43"}}}`)
+		fmt.Fprintln(w, `{"type":"ADDED","object":{"metadata":{"name":"coder-abc","namespace":"agents","resourceVersion":"44","labels":{"kubernetes.io/service-name":"coder","capability.agentflow.io/code_review":"true"}},"endpoints":[{"conditions":{"ready":false}},{"conditions":{"ready":true}}],"ports":[{"port":8443}]}}`)
+		fmt.Fprintln(w, `{"type":"ERROR","object":{"code":410}}`)
+	}))
+	defer srv.Close()
+
+	watcher := NewAPIServerWatcher(APIServerConfig{Host: srv.URL}, ResourceKindEndpointSlice, "")
+	events, err := watcher.Watch(context.Background(), "42")
+	require.NoError(t, err)
+
+	var got []ResourceEvent
+	timeout := time.After(2 * time.Second)
+	for done := false; !done; {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatal("watch stream did not close")
+		}
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, ResourceAdded, got[0].Type)
+	assert.Equal(t, "coder.agents.svc", got[0].Resource.Host)
+	assert.Equal(t, 8443, got[0].Resource.Port)
+	assert.True(t, got[0].Resource.Ready)
+	assert.Equal(t, "agents/coder", agentIDFor(got[0].Resource))
+	assert.Equal(t, ResourceError, got[1].Type)
+}
+
+func TestAPIServerWatcher_ExpiredResourceVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	watcher := NewAPIServerWatcher(APIServerConfig{Host: srv.URL}, ResourceKindPod, "")
+	_, err := watcher.Watch(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrResourceVersionExpired)
+}
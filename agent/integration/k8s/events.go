@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"sync"
+	"time"
+)
+
+// Kubernetes 事件类型.
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// 调和结果对应的事件原因.
+const (
+	EventReasonScaledUp        = "ScaledUp"
+	EventReasonScaledDown      = "ScaledDown"
+	EventReasonDegraded        = "Degraded"
+	EventReasonAvailable       = "Available"
+	EventReasonHealed          = "Healed"
+	EventReasonReconcileFailed = "ReconcileFailed"
+	EventReasonScaleFailed     = "ScaleFailed"
+)
+
+// AgentEvent 对应 Kubernetes core/v1 Event 中 `kubectl describe` 展示的字段.
+// 同一对象上相同 type/reason/message 的事件会被合并并累加 Count.
+type AgentEvent struct {
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int32     `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// EventRecorder 记录代理 CRD 上的事件. client-go 实现可包装 record.EventRecorder.
+type EventRecorder interface {
+	Event(agent *AgentCRD, eventType, reason, message string)
+}
+
+// InMemoryEventRecorder 是默认的事件记录器, 每个代理保留最近的若干条事件.
+type InMemoryEventRecorder struct {
+	mu       sync.RWMutex
+	events   map[string][]AgentEvent
+	maxPerCR int
+}
+
+// NewInMemoryEventRecorder 创建内存事件记录器. maxPerAgent <= 0 时默认保留 50 条.
+func NewInMemoryEventRecorder(maxPerAgent int) *InMemoryEventRecorder {
+	if maxPerAgent <= 0 {
+		maxPerAgent = 50
+	}
+	return &InMemoryEventRecorder{
+		events:   make(map[string][]AgentEvent),
+		maxPerCR: maxPerAgent,
+	}
+}
+
+// Event 记录一条事件.
+func (r *InMemoryEventRecorder) Event(agent *AgentCRD, eventType, reason, message string) {
+	if agent == nil {
+		return
+	}
+	key := agentKey(agent.Metadata.Namespace, agent.Metadata.Name)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[key]
+	if n := len(events); n > 0 {
+		last := &events[n-1]
+		if last.Type == eventType && last.Reason == reason && last.Message == message {
+			last.Count++
+			last.LastTimestamp = now
+			return
+		}
+	}
+	events = append(events, AgentEvent{
+		Namespace:      agent.Metadata.Namespace,
+		Name:           agent.Metadata.Name,
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+	})
+	if len(events) > r.maxPerCR {
+		events = events[len(events)-r.maxPerCR:]
+	}
+	r.events[key] = events
+}
+
+// Events 返回代理的事件副本, 按时间顺序排列.
+func (r *InMemoryEventRecorder) Events(namespace, name string) []AgentEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := r.events[agentKey(namespace, name)]
+	out := make([]AgentEvent, len(events))
+	copy(out, events)
+	return out
+}
+
+func agentKey(namespace, name string) string {
+	return namespace + "/" + name
+}
@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const operatorMetricsNamespace = "agentflow_operator"
+
+var allAgentPhases = []AgentPhase{
+	AgentPhasePending,
+	AgentPhaseRunning,
+	AgentPhaseScaling,
+	AgentPhaseDegraded,
+	AgentPhaseFailed,
+	AgentPhaseTerminating,
+}
+
+// operatorCollector 在每次抓取时从操作员状态生成快照, 不需要在调和路径上维护额外的指标.
+type operatorCollector struct {
+	op *AgentOperator
+
+	reconcileTotal   *prometheus.Desc
+	reconcileErrors  *prometheus.Desc
+	reconcileSeconds *prometheus.Desc
+	scaleEvents      *prometheus.Desc
+	selfHealing      *prometheus.Desc
+
+	desiredReplicas   *prometheus.Desc
+	readyReplicas     *prometheus.Desc
+	availableReplicas *prometheus.Desc
+	phase             *prometheus.Desc
+	condition         *prometheus.Desc
+	targetMetric      *prometheus.Desc
+}
+
+func newOperatorCollector(op *AgentOperator) *operatorCollector {
+	agentLabels := []string{"namespace", "name"}
+	return &operatorCollector{
+		op: op,
+		reconcileTotal: prometheus.NewDesc(operatorMetricsNamespace+"_reconcile_total",
+			"Total number of agent reconciliations.", nil, nil),
+		reconcileErrors: prometheus.NewDesc(operatorMetricsNamespace+"_reconcile_errors_total",
+			"Total number of failed agent reconciliations.", nil, nil),
+		reconcileSeconds: prometheus.NewDesc(operatorMetricsNamespace+"_reconcile_duration_seconds_avg",
+			"Average duration of agent reconciliations in seconds.", nil, nil),
+		scaleEvents: prometheus.NewDesc(operatorMetricsNamespace+"_scale_events_total",
+			"Total number of scaling actions by direction.", []string{"direction"}, nil),
+		selfHealing: prometheus.NewDesc(operatorMetricsNamespace+"_self_healing_total",
+			"Total number of unhealthy instances replaced.", nil, nil),
+		desiredReplicas: prometheus.NewDesc(operatorMetricsNamespace+"_agent_desired_replicas",
+			"Desired replicas from the agent spec.", agentLabels, nil),
+		readyReplicas: prometheus.NewDesc(operatorMetricsNamespace+"_agent_ready_replicas",
+			"Ready replicas reported in the agent status.", agentLabels, nil),
+		availableReplicas: prometheus.NewDesc(operatorMetricsNamespace+"_agent_available_replicas",
+			"Available replicas reported in the agent status.", agentLabels, nil),
+		phase: prometheus.NewDesc(operatorMetricsNamespace+"_agent_phase",
+			"Current agent phase (1 for the active phase, 0 otherwise).", append(agentLabels, "phase"), nil),
+		condition: prometheus.NewDesc(operatorMetricsNamespace+"_agent_condition",
+			"Agent status conditions (1 for the reported status).", append(agentLabels, "type", "status"), nil),
+		targetMetric: prometheus.NewDesc(operatorMetricsNamespace+"_agent_scaling_metric",
+			"Current value of an agent scaling target metric.", append(agentLabels, "metric"), nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector.
+func (c *operatorCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.reconcileTotal, c.reconcileErrors, c.reconcileSeconds, c.scaleEvents, c.selfHealing,
+		c.desiredReplicas, c.readyReplicas, c.availableReplicas, c.phase, c.condition, c.targetMetric,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现 prometheus.Collector.
+func (c *operatorCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.op.metrics
+	ch <- prometheus.MustNewConstMetric(c.reconcileTotal, prometheus.CounterValue, float64(m.ReconcileTotal.Load()))
+	ch <- prometheus.MustNewConstMetric(c.reconcileErrors, prometheus.CounterValue, float64(m.ReconcileErrors.Load()))
+	ch <- prometheus.MustNewConstMetric(c.reconcileSeconds, prometheus.GaugeValue, time.Duration(m.AverageReconcileTime.Load()).Seconds())
+	ch <- prometheus.MustNewConstMetric(c.scaleEvents, prometheus.CounterValue, float64(m.ScaleUpEvents.Load()), "up")
+	ch <- prometheus.MustNewConstMetric(c.scaleEvents, prometheus.CounterValue, float64(m.ScaleDownEvents.Load()), "down")
+	ch <- prometheus.MustNewConstMetric(c.selfHealing, prometheus.CounterValue, float64(m.SelfHealingEvents.Load()))
+
+	c.op.mu.RLock()
+	defer c.op.mu.RUnlock()
+	for _, agent := range c.op.agents {
+		ns, name := agent.Metadata.Namespace, agent.Metadata.Name
+		ch <- prometheus.MustNewConstMetric(c.desiredReplicas, prometheus.GaugeValue, float64(agent.Spec.Replicas), ns, name)
+		ch <- prometheus.MustNewConstMetric(c.readyReplicas, prometheus.GaugeValue, float64(agent.Status.ReadyReplicas), ns, name)
+		ch <- prometheus.MustNewConstMetric(c.availableReplicas, prometheus.GaugeValue, float64(agent.Status.AvailableReplicas), ns, name)
+		for _, phase := range allAgentPhases {
+			value := 0.0
+			if agent.Status.Phase == phase {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.phase, prometheus.GaugeValue, value, ns, name, string(phase))
+		}
+		for _, cond := range agent.Status.Conditions {
+			ch <- prometheus.MustNewConstMetric(c.condition, prometheus.GaugeValue, 1, ns, name, cond.Type, cond.Status)
+		}
+		for _, mv := range agent.Status.CurrentMetrics {
+			ch <- prometheus.MustNewConstMetric(c.targetMetric, prometheus.GaugeValue, float64(mv.CurrentValue), ns, name, mv.Name)
+		}
+	}
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式暴露操作员与各代理 CRD 指标的处理器.
+// 使用独立 registry, 不会与进程默认 registry 冲突.
+func (o *AgentOperator) MetricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newOperatorCollector(o))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// serveMetrics 在 MetricsPort 上提供 /metrics, ctx 结束或操作员停止时关闭.
+func (o *AgentOperator) serveMetrics(ctx context.Context, stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", o.MetricsHandler())
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", o.config.MetricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopCh:
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	o.logger.Info("operator metrics endpoint listening", zap.String("addr", srv.Addr))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		o.logger.Error("operator metrics endpoint failed", zap.Error(err))
+	}
+}
@@ -167,6 +167,8 @@ type OperatorConfig struct {
 	EnableWebhooks          bool          `json:"enableWebhooks"`
 	CertDir                 string        `json:"certDir,omitempty"`
 	MaxConcurrentReconciles int           `json:"maxConcurrentReconciles"`
+	// ServeMetrics 为 true 时 Start 会在 MetricsPort 上暴露 /metrics.
+	ServeMetrics bool `json:"serveMetrics"`
 }
 
 // 默认操作器 Config 返回合理的默认值 。
@@ -190,6 +192,7 @@ type AgentOperator struct {
 	instances        map[string]*AgentInstance
 	instanceProvider InstanceProvider
 	metrics          *OperatorMetrics
+	recorder         EventRecorder
 	logger           *zap.Logger
 	mu               sync.RWMutex
 
@@ -267,6 +270,7 @@ func NewAgentOperator(config OperatorConfig, logger *zap.Logger) *AgentOperator
 		agents:    make(map[string]*AgentCRD),
 		instances: make(map[string]*AgentInstance),
 		metrics:   &OperatorMetrics{},
+		recorder:  NewInMemoryEventRecorder(0),
 		logger:    logger,
 		stopCh:    make(chan struct{}),
 	}
@@ -281,6 +285,24 @@ func (o *AgentOperator) SetInstanceProvider(p InstanceProvider) {
 	o.instanceProvider = p
 }
 
+// SetEventRecorder 替换默认的内存事件记录器, 例如接入 client-go 的 record.EventRecorder.
+func (o *AgentOperator) SetEventRecorder(r EventRecorder) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.recorder = r
+}
+
+// GetEvents 返回代理的事件; 仅在使用默认内存记录器时可用.
+func (o *AgentOperator) GetEvents(namespace, name string) []AgentEvent {
+	o.mu.RLock()
+	recorder := o.recorder
+	o.mu.RUnlock()
+	if mem, ok := recorder.(*InMemoryEventRecorder); ok {
+		return mem.Events(namespace, name)
+	}
+	return nil
+}
+
 // 设置调和 Callback 设置调和调回调 。
 func (o *AgentOperator) SetReconcileCallback(fn func(agent *AgentCRD) error) {
 	o.onReconcile = fn
@@ -322,6 +344,10 @@ func (o *AgentOperator) Start(ctx context.Context) error {
 	// 开始收集衡量标准
 	go o.metricsLoop(ctx, stopCh)
 
+	if o.config.ServeMetrics && o.config.MetricsPort > 0 {
+		go o.serveMetrics(ctx, stopCh)
+	}
+
 	return nil
 }
 
@@ -447,7 +473,12 @@ func (o *AgentOperator) reconcileAgent(agent *AgentCRD) {
 		if err := o.onReconcile(agent); err != nil {
 			o.metrics.ReconcileErrors.Add(1)
 			o.logger.Error("reconcile callback failed", zap.Error(err))
-			o.updateAgentCondition(agent, "Reconciled", "False", "ReconcileFailed", err.Error())
+			o.mu.Lock()
+			o.updateAgentConditionLocked(agent, "Reconciled", ConditionFalse, "ReconcileFailed", err.Error())
+			o.updateAgentConditionLocked(agent, ConditionProgressing, ConditionFalse, "ReconcileFailed", err.Error())
+			o.mu.Unlock()
+			o.recordEvent(agent, EventTypeWarning, EventReasonReconcileFailed, err.Error())
+			o.observeReconcileTime(time.Since(start))
 			return
 		}
 	}
@@ -480,11 +511,17 @@ func (o *AgentOperator) reconcileAgent(agent *AgentCRD) {
 	} else if agent.Status.ReadyReplicas > 0 {
 		agent.Status.Phase = AgentPhaseDegraded
 	}
+
+	o.updateAgentConditionLocked(agent, "Reconciled", ConditionTrue, "ReconcileSucceeded", "")
+	events := o.updateStandardConditionsLocked(agent, currentReplicas != desiredReplicas)
 	o.mu.Unlock()
 
-	o.updateAgentCondition(agent, "Reconciled", "True", "ReconcileSucceeded", "")
+	for _, ev := range events {
+		o.recordEvent(agent, ev.Type, ev.Reason, ev.Message)
+	}
 
 	elapsed := time.Since(start)
+	o.observeReconcileTime(elapsed)
 	o.logger.Debug("reconcile completed",
 		zap.String("name", agent.Metadata.Name),
 		zap.Duration("duration", elapsed))
@@ -575,6 +612,8 @@ func (o *AgentOperator) scaleAgent(agent *AgentCRD, replicas int32) {
 		for i := currentReplicas; i < replicas; i++ {
 			o.createInstance(agent)
 		}
+		o.recordEvent(agent, EventTypeNormal, EventReasonScaledUp,
+			fmt.Sprintf("Scaled up replicas from %d to %d", currentReplicas, replicas))
 	} else if replicas < currentReplicas {
 		// 缩放
 		o.metrics.ScaleDownEvents.Add(1)
@@ -584,12 +623,15 @@ func (o *AgentOperator) scaleAgent(agent *AgentCRD, replicas int32) {
 			zap.Int32("to", replicas))
 
 		o.removeInstances(agent, currentReplicas-replicas)
+		o.recordEvent(agent, EventTypeNormal, EventReasonScaledDown,
+			fmt.Sprintf("Scaled down replicas from %d to %d", currentReplicas, replicas))
 	}
 
 	// 调用缩放回调
 	if o.onScale != nil {
 		if err := o.onScale(agent, replicas); err != nil {
 			o.logger.Error("scale callback failed", zap.Error(err))
+			o.recordEvent(agent, EventTypeWarning, EventReasonScaleFailed, err.Error())
 		}
 	}
 
@@ -733,8 +775,11 @@ func (o *AgentOperator) selfHeal(inst *AgentInstance, agent *AgentCRD) {
 	// 删除失败实例
 	delete(o.instances, inst.ID)
 
-	o.updateAgentConditionLocked(agent, "SelfHealed", "True", "InstanceReplaced",
-		fmt.Sprintf("Replaced unhealthy instance %s", inst.ID))
+	message := fmt.Sprintf("Replaced unhealthy instance %s", inst.ID)
+	o.updateAgentConditionLocked(agent, "SelfHealed", ConditionTrue, "InstanceReplaced", message)
+	if o.recorder != nil {
+		o.recorder.Event(agent, EventTypeNormal, EventReasonHealed, message)
+	}
 }
 
 func (o *AgentOperator) metricsLoop(ctx context.Context, stopCh <-chan struct{}) {
@@ -798,15 +843,9 @@ func (o *AgentOperator) getCurrentMetricValueLocked(agent *AgentCRD, metricName
 	return total / count
 }
 
-func (o *AgentOperator) updateAgentCondition(agent *AgentCRD, condType, status, reason, message string) {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	o.updateAgentConditionLocked(agent, condType, status, reason, message)
-}
-
 // updateAgentConditionLocked updates a condition while the caller already holds o.mu.
-func (o *AgentOperator) updateAgentConditionLocked(agent *AgentCRD, condType, status, reason, message string) {
-
+// LastTransitionTime 仅在 Status 变化时更新, 与 Kubernetes 条件语义一致; 返回 Status 是否发生变化.
+func (o *AgentOperator) updateAgentConditionLocked(agent *AgentCRD, condType, status, reason, message string) bool {
 	now := time.Now()
 	newCondition := AgentCondition{
 		Type:               condType,
@@ -817,17 +856,17 @@ func (o *AgentOperator) updateAgentConditionLocked(agent *AgentCRD, condType, st
 	}
 
 	// 更新或附加条件
-	found := false
 	for i, c := range agent.Status.Conditions {
 		if c.Type == condType {
+			if c.Status == status {
+				newCondition.LastTransitionTime = c.LastTransitionTime
+			}
 			agent.Status.Conditions[i] = newCondition
-			found = true
-			break
+			return c.Status != status
 		}
 	}
-	if !found {
-		agent.Status.Conditions = append(agent.Status.Conditions, newCondition)
-	}
+	agent.Status.Conditions = append(agent.Status.Conditions, newCondition)
+	return true
 }
 
 // 更新InstanceMetrics为实例更新了度量衡.
@@ -0,0 +1,426 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	agentcore "github.com/BaSui01/agentflow/agent/core"
+	observability "github.com/BaSui01/agentflow/agent/observability/monitoring"
+	"go.uber.org/zap"
+)
+
+// 实验运行器记录的指标名称
+const (
+	ExperimentMetricLatencyMs = "latency_ms"
+	ExperimentMetricCost      = "cost"
+	ExperimentMetricTokens    = "tokens"
+	ExperimentMetricQuality   = "quality"
+	ExperimentMetricError     = "error"
+)
+
+// 输出元数据中标记实验分流结果的键
+const (
+	ExperimentMetadataExperimentID = "experiment_id"
+	ExperimentMetadataVariantID    = "experiment_variant_id"
+)
+
+// ErrExperimentArmMissing 实验变体缺少对应的执行入口
+var ErrExperimentArmMissing = errors.New("experiment variant has no agent")
+
+// ExperimentAgent 参与实验的 Agent 执行入口，与 Agent.Execute 签名一致
+type ExperimentAgent interface {
+	Execute(ctx context.Context, input *agentcore.Input) (*agentcore.Output, error)
+}
+
+// ExperimentArm 实验变体对应的执行配置。
+// 对比两套 AgentConfig 时为每个变体提供各自的 Agent；
+// 对比模型/参数时可共用同一个 Agent，通过 Overrides 区分。
+type ExperimentArm struct {
+	VariantID string
+	Agent     ExperimentAgent
+	// Overrides 叠加到请求的运行时覆盖上，变体设置的字段优先
+	Overrides *agentcore.RunConfig
+}
+
+// KillSwitchConfig 自动熔断配置，任一实验组相对对照组劣化超过阈值即暂停实验，全部流量回到对照组。
+// 阈值为 0 表示不检查该项。
+type KillSwitchConfig struct {
+	// MinSamples 对照组与实验组都达到该样本数后才开始判断
+	MinSamples int `json:"min_samples"`
+	// MaxErrorRateIncrease 实验组错误率比对照组高出的最大值（如 0.1 表示 10 个百分点）
+	MaxErrorRateIncrease float64 `json:"max_error_rate_increase"`
+	// MaxLatencyRatio 实验组平均延迟与对照组平均延迟之比的上限
+	MaxLatencyRatio float64 `json:"max_latency_ratio"`
+	// MaxQualityDrop 实验组平均质量分比对照组低出的最大值
+	MaxQualityDrop float64 `json:"max_quality_drop"`
+}
+
+// ExperimentRunnerConfig 实验运行器配置
+type ExperimentRunnerConfig struct {
+	// QualityStrategy 为每次执行打质量分；为空时以执行成功与否作为分数
+	QualityStrategy observability.EvaluationStrategy
+	// AssignmentKey 返回用于粘性分流的键，默认使用 UserID；返回空串的请求走对照组且不计入实验
+	AssignmentKey func(input *agentcore.Input) string
+	KillSwitch    KillSwitchConfig
+}
+
+// DefaultKillSwitchConfig 返回默认熔断配置
+func DefaultKillSwitchConfig() KillSwitchConfig {
+	return KillSwitchConfig{
+		MinSamples:           30,
+		MaxErrorRateIncrease: 0.1,
+		MaxLatencyRatio:      2,
+		MaxQualityDrop:       0.2,
+	}
+}
+
+// armStats 变体的在线累计统计，用于熔断判断
+type armStats struct {
+	samples    int
+	errors     int
+	latencySum float64
+	qualitySum float64
+	qualityN   int
+}
+
+func (s *armStats) errorRate() float64 {
+	if s.samples == 0 {
+		return 0
+	}
+	return float64(s.errors) / float64(s.samples)
+}
+
+func (s *armStats) avgLatency() float64 {
+	if s.samples == 0 {
+		return 0
+	}
+	return s.latencySum / float64(s.samples)
+}
+
+func (s *armStats) avgQuality() (float64, bool) {
+	if s.qualityN == 0 {
+		return 0, false
+	}
+	return s.qualitySum / float64(s.qualityN), true
+}
+
+// ExperimentRunner 在线上 Execute 流量中按用户粘性分流到不同变体，
+// 记录每次执行的延迟、成本与质量分并交给 ABTester 做显著性分析，
+// 实验组明显劣化时自动熔断。ExperimentRunner 本身实现 ExperimentAgent，可直接替换原 Agent。
+type ExperimentRunner struct {
+	tester       *ABTester
+	experimentID string
+	controlID    string
+	arms         map[string]ExperimentArm
+	config       ExperimentRunnerConfig
+	logger       *zap.Logger
+
+	mu         sync.Mutex
+	stats      map[string]*armStats
+	killed     bool
+	killReason string
+}
+
+// NewExperimentRunner 为已创建的实验构建运行器，实验的每个变体都必须有对应的 ExperimentArm
+func NewExperimentRunner(tester *ABTester, experimentID string, arms []ExperimentArm, config ExperimentRunnerConfig, logger *zap.Logger) (*ExperimentRunner, error) {
+	if tester == nil {
+		return nil, errors.New("ab tester is required")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	exp, err := tester.GetExperiment(context.Background(), experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	armByID := make(map[string]ExperimentArm, len(arms))
+	for _, arm := range arms {
+		armByID[arm.VariantID] = arm
+	}
+	stats := make(map[string]*armStats, len(exp.Variants))
+	for _, v := range exp.Variants {
+		if arm, ok := armByID[v.ID]; !ok || arm.Agent == nil {
+			return nil, fmt.Errorf("%w: %s", ErrExperimentArmMissing, v.ID)
+		}
+		stats[v.ID] = &armStats{}
+	}
+	if config.AssignmentKey == nil {
+		config.AssignmentKey = func(input *agentcore.Input) string { return input.UserID }
+	}
+
+	tester.ensureMetrics(context.Background(), exp, []string{
+		ExperimentMetricLatencyMs,
+		ExperimentMetricCost,
+		ExperimentMetricTokens,
+		ExperimentMetricQuality,
+		ExperimentMetricError,
+	})
+
+	return &ExperimentRunner{
+		tester:       tester,
+		experimentID: experimentID,
+		controlID:    controlVariantID(exp),
+		arms:         armByID,
+		config:       config,
+		logger:       logger.With(zap.String("experiment", experimentID)),
+		stats:        stats,
+	}, nil
+}
+
+// Execute 分流并执行请求，输出元数据中标记实验与变体
+func (r *ExperimentRunner) Execute(ctx context.Context, input *agentcore.Input) (*agentcore.Output, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+	variantID, tracked := r.assign(ctx, input)
+	arm := r.arms[variantID]
+
+	armInput := *input
+	armInput.Overrides = mergeArmOverrides(input.Overrides, arm.Overrides)
+
+	start := time.Now()
+	output, err := arm.Agent.Execute(ctx, &armInput)
+	latency := time.Since(start)
+
+	if output != nil {
+		if output.Metadata == nil {
+			output.Metadata = make(map[string]any, 2)
+		}
+		output.Metadata[ExperimentMetadataExperimentID] = r.experimentID
+		output.Metadata[ExperimentMetadataVariantID] = variantID
+	}
+	if tracked {
+		r.record(ctx, variantID, &armInput, output, err, latency)
+	}
+	return output, err
+}
+
+// assign 返回本次请求的变体，以及结果是否计入实验。
+// 实验未运行、已熔断或请求没有分流键时走对照组且不计入。
+func (r *ExperimentRunner) assign(ctx context.Context, input *agentcore.Input) (string, bool) {
+	if killed, _ := r.Killed(); killed {
+		return r.controlID, false
+	}
+	key := r.config.AssignmentKey(input)
+	if key == "" {
+		return r.controlID, false
+	}
+	variant, err := r.tester.Assign(ctx, r.experimentID, key)
+	if err != nil {
+		if !errors.Is(err, ErrExperimentNotActive) {
+			r.logger.Warn("experiment assignment failed, routing to control", zap.Error(err))
+		}
+		return r.controlID, false
+	}
+	return variant.ID, true
+}
+
+// record 记录一次执行的指标并检查熔断条件
+func (r *ExperimentRunner) record(ctx context.Context, variantID string, input *agentcore.Input, output *agentcore.Output, execErr error, latency time.Duration) {
+	result := &EvalResult{
+		TaskID:   input.TraceID,
+		Success:  execErr == nil,
+		Duration: latency,
+		Metrics: map[string]float64{
+			ExperimentMetricLatencyMs: float64(latency.Milliseconds()),
+			ExperimentMetricError:     0,
+		},
+	}
+	quality, hasQuality := 0.0, false
+	if execErr != nil {
+		result.Error = execErr.Error()
+		result.Metrics[ExperimentMetricError] = 1
+	} else if output != nil {
+		result.Output = output.Content
+		result.TokensUsed = output.TokensUsed
+		result.Cost = output.Cost
+		result.Metrics[ExperimentMetricCost] = output.Cost
+		result.Metrics[ExperimentMetricTokens] = float64(output.TokensUsed)
+		quality, hasQuality = r.scoreQuality(ctx, input, output)
+	}
+	switch {
+	case hasQuality:
+		result.Score = quality
+		result.Metrics[ExperimentMetricQuality] = quality
+	case execErr == nil:
+		result.Score = 1
+	}
+
+	if err := r.tester.RecordResult(ctx, r.experimentID, variantID, result); err != nil {
+		r.logger.Warn("failed to record experiment result", zap.String("variant", variantID), zap.Error(err))
+	}
+
+	r.mu.Lock()
+	s := r.stats[variantID]
+	s.samples++
+	s.latencySum += float64(latency.Milliseconds())
+	if execErr != nil {
+		s.errors++
+	}
+	if hasQuality {
+		s.qualitySum += quality
+		s.qualityN++
+	}
+	reason := r.checkKillSwitchLocked()
+	r.mu.Unlock()
+
+	if reason != "" {
+		r.Kill(ctx, reason)
+	}
+}
+
+func (r *ExperimentRunner) scoreQuality(ctx context.Context, input *agentcore.Input, output *agentcore.Output) (float64, bool) {
+	if r.config.QualityStrategy == nil {
+		return 0, false
+	}
+	eval, err := r.config.QualityStrategy.Evaluate(ctx, input, output)
+	if err != nil || eval == nil {
+		r.logger.Debug("quality evaluation failed", zap.Error(err))
+		return 0, false
+	}
+	return clamp(eval.Score, 0, 1), true
+}
+
+// checkKillSwitchLocked 比较各实验组与对照组，返回熔断原因；调用方需持有 r.mu
+func (r *ExperimentRunner) checkKillSwitchLocked() string {
+	if r.killed {
+		return ""
+	}
+	cfg := r.config.KillSwitch
+	control := r.stats[r.controlID]
+	if control == nil || control.samples < cfg.MinSamples || control.samples == 0 {
+		return ""
+	}
+	for variantID, s := range r.stats {
+		if variantID == r.controlID || s.samples < cfg.MinSamples || s.samples == 0 {
+			continue
+		}
+		if cfg.MaxErrorRateIncrease > 0 && s.errorRate()-control.errorRate() > cfg.MaxErrorRateIncrease {
+			return fmt.Sprintf("variant %s error rate %.2f exceeds control %.2f", variantID, s.errorRate(), control.errorRate())
+		}
+		if cfg.MaxLatencyRatio > 0 && control.avgLatency() > 0 && s.avgLatency() > control.avgLatency()*cfg.MaxLatencyRatio {
+			return fmt.Sprintf("variant %s latency %.0fms exceeds %.1fx control %.0fms", variantID, s.avgLatency(), cfg.MaxLatencyRatio, control.avgLatency())
+		}
+		if cfg.MaxQualityDrop > 0 {
+			cq, okC := control.avgQuality()
+			vq, okV := s.avgQuality()
+			if okC && okV && cq-vq > cfg.MaxQualityDrop {
+				return fmt.Sprintf("variant %s quality %.2f below control %.2f", variantID, vq, cq)
+			}
+		}
+	}
+	return ""
+}
+
+// Kill 手动熔断实验：暂停实验，后续流量全部走对照组
+func (r *ExperimentRunner) Kill(ctx context.Context, reason string) {
+	r.mu.Lock()
+	if r.killed {
+		r.mu.Unlock()
+		return
+	}
+	r.killed = true
+	r.killReason = reason
+	r.mu.Unlock()
+
+	if err := r.tester.PauseExperiment(ctx, r.experimentID); err != nil {
+		r.logger.Warn("failed to pause killed experiment", zap.Error(err))
+	}
+	r.logger.Warn("experiment kill switch triggered", zap.String("reason", reason))
+}
+
+// Killed 返回实验是否已熔断及原因
+func (r *ExperimentRunner) Killed() (bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.killed, r.killReason
+}
+
+// Report 生成包含各指标显著性检验的实验报告
+func (r *ExperimentRunner) Report(ctx context.Context) (*StatisticalReport, error) {
+	return r.tester.GenerateReport(ctx, r.experimentID)
+}
+
+// ensureMetrics 将运行器记录的指标加入实验定义，使 Analyze 统计这些指标
+func (t *ABTester) ensureMetrics(ctx context.Context, exp *Experiment, metrics []string) {
+	t.mu.Lock()
+	existing := make(map[string]struct{}, len(exp.Metrics))
+	for _, m := range exp.Metrics {
+		existing[m] = struct{}{}
+	}
+	changed := false
+	for _, m := range metrics {
+		if _, ok := existing[m]; !ok {
+			exp.Metrics = append(exp.Metrics, m)
+			changed = true
+		}
+	}
+	t.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := t.store.SaveExperiment(ctx, exp); err != nil {
+		t.logger.Warn("failed to save experiment metrics", zap.Error(err))
+	}
+}
+
+// controlVariantID 返回对照组变体 ID，未标记对照组时使用第一个变体
+func controlVariantID(exp *Experiment) string {
+	for _, v := range exp.Variants {
+		if v.IsControl {
+			return v.ID
+		}
+	}
+	if len(exp.Variants) > 0 {
+		return exp.Variants[0].ID
+	}
+	return ""
+}
+
+// mergeArmOverrides 将变体覆盖叠加到请求覆盖上，变体设置的字段优先
+func mergeArmOverrides(base, arm *agentcore.RunConfig) *agentcore.RunConfig {
+	if arm == nil {
+		return base
+	}
+	if base == nil {
+		return arm.Clone()
+	}
+	merged := base.Clone()
+	armCopy := arm.Clone()
+	if armCopy.Model != nil {
+		merged.Model = armCopy.Model
+	}
+	if armCopy.Provider != nil {
+		merged.Provider = armCopy.Provider
+	}
+	if armCopy.RoutePolicy != nil {
+		merged.RoutePolicy = armCopy.RoutePolicy
+	}
+	if armCopy.Temperature != nil {
+		merged.Temperature = armCopy.Temperature
+	}
+	if armCopy.MaxTokens != nil {
+		merged.MaxTokens = armCopy.MaxTokens
+	}
+	if armCopy.TopP != nil {
+		merged.TopP = armCopy.TopP
+	}
+	if armCopy.ToolChoice != nil {
+		merged.ToolChoice = armCopy.ToolChoice
+	}
+	if armCopy.MaxReActIterations != nil {
+		merged.MaxReActIterations = armCopy.MaxReActIterations
+	}
+	for k, v := range armCopy.Metadata {
+		if merged.Metadata == nil {
+			merged.Metadata = make(map[string]string, len(armCopy.Metadata))
+		}
+		merged.Metadata[k] = v
+	}
+	return merged
+}
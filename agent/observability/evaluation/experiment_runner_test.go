@@ -0,0 +1,184 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	agentcore "github.com/BaSui01/agentflow/agent/core"
+	observability "github.com/BaSui01/agentflow/agent/observability/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubExperimentAgent struct {
+	content string
+	cost    float64
+	err     error
+
+	mu     sync.Mutex
+	models []string
+}
+
+func (a *stubExperimentAgent) Execute(_ context.Context, input *agentcore.Input) (*agentcore.Output, error) {
+	a.mu.Lock()
+	model := ""
+	if input.Overrides != nil && input.Overrides.Model != nil {
+		model = *input.Overrides.Model
+	}
+	a.models = append(a.models, model)
+	a.mu.Unlock()
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &agentcore.Output{TraceID: input.TraceID, Content: a.content, Cost: a.cost, TokensUsed: 10}, nil
+}
+
+type contentQualityStrategy map[string]float64
+
+func (s contentQualityStrategy) Evaluate(_ context.Context, _ *agentcore.Input, output *agentcore.Output) (*observability.EvaluationResult, error) {
+	return &observability.EvaluationResult{Score: s[output.Content], Timestamp: time.Now()}, nil
+}
+
+func startRunnerExperiment(t *testing.T, tester *ABTester) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, tester.CreateExperiment(ctx, &Experiment{
+		ID:   "agent-exp",
+		Name: "Agent config comparison",
+		Variants: []Variant{
+			{ID: "control", Weight: 0.5, IsControl: true},
+			{ID: "treatment", Weight: 0.5},
+		},
+	}))
+	require.NoError(t, tester.StartExperiment(ctx, "agent-exp"))
+}
+
+func TestExperimentRunnerStickyAssignmentAndMetrics(t *testing.T) {
+	ctx := context.Background()
+	tester := NewABTester(NewMemoryExperimentStore(), nil)
+	startRunnerExperiment(t, tester)
+
+	control := &stubExperimentAgent{content: "a", cost: 0.01}
+	treatment := &stubExperimentAgent{content: "b", cost: 0.02}
+	runner, err := NewExperimentRunner(tester, "agent-exp", []ExperimentArm{
+		{VariantID: "control", Agent: control},
+		{VariantID: "treatment", Agent: treatment},
+	}, ExperimentRunnerConfig{QualityStrategy: contentQualityStrategy{"a": 0.6, "b": 0.9}}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		first, err := runner.Execute(ctx, &agentcore.Input{UserID: user, Content: "hi"})
+		require.NoError(t, err)
+		second, err := runner.Execute(ctx, &agentcore.Input{UserID: user, Content: "again"})
+		require.NoError(t, err)
+		assert.Equal(t, first.Metadata[ExperimentMetadataVariantID], second.Metadata[ExperimentMetadataVariantID], "assignment must be sticky per user")
+		assert.Equal(t, "agent-exp", first.Metadata[ExperimentMetadataExperimentID])
+	}
+	assert.NotEmpty(t, control.models)
+	assert.NotEmpty(t, treatment.models)
+
+	report, err := runner.Report(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 80, report.TotalSamples)
+	require.Len(t, report.Comparisons, 1)
+	comparison := report.Comparisons[0]
+	assert.InDelta(t, 0.3, comparison.MetricDeltas[ExperimentMetricQuality], 1e-9)
+	assert.InDelta(t, 0.01, comparison.MetricDeltas[ExperimentMetricCost], 1e-9)
+	assert.Contains(t, report.VariantReports["treatment"].Metrics, ExperimentMetricLatencyMs)
+}
+
+func TestExperimentRunnerAppliesArmOverrides(t *testing.T) {
+	ctx := context.Background()
+	tester := NewABTester(NewMemoryExperimentStore(), nil)
+	startRunnerExperiment(t, tester)
+
+	shared := &stubExperimentAgent{content: "ok"}
+	modelA, modelB := "gpt-4o", "gpt-4o-mini"
+	runner, err := NewExperimentRunner(tester, "agent-exp", []ExperimentArm{
+		{VariantID: "control", Agent: shared, Overrides: &agentcore.RunConfig{Model: &modelA}},
+		{VariantID: "treatment", Agent: shared, Overrides: &agentcore.RunConfig{Model: &modelB}},
+	}, ExperimentRunnerConfig{}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		out, err := runner.Execute(ctx, &agentcore.Input{UserID: fmt.Sprintf("u%d", i)})
+		require.NoError(t, err)
+		want := modelA
+		if out.Metadata[ExperimentMetadataVariantID] == "treatment" {
+			want = modelB
+		}
+		assert.Equal(t, want, shared.models[len(shared.models)-1])
+	}
+}
+
+func TestExperimentRunnerKillSwitchRoutesToControl(t *testing.T) {
+	ctx := context.Background()
+	tester := NewABTester(NewMemoryExperimentStore(), nil)
+	startRunnerExperiment(t, tester)
+
+	control := &stubExperimentAgent{content: "ok"}
+	treatment := &stubExperimentAgent{err: errors.New("provider down")}
+	runner, err := NewExperimentRunner(tester, "agent-exp", []ExperimentArm{
+		{VariantID: "control", Agent: control},
+		{VariantID: "treatment", Agent: treatment},
+	}, ExperimentRunnerConfig{KillSwitch: KillSwitchConfig{MinSamples: 3, MaxErrorRateIncrease: 0.2}}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 60; i++ {
+		_, _ = runner.Execute(ctx, &agentcore.Input{UserID: fmt.Sprintf("u%d", i)})
+	}
+	killed, reason := runner.Killed()
+	require.True(t, killed)
+	assert.Contains(t, reason, "treatment")
+
+	exp, err := tester.GetExperiment(ctx, "agent-exp")
+	require.NoError(t, err)
+	assert.Equal(t, ExperimentStatusPaused, exp.Status)
+
+	treatmentCalls := len(treatment.models)
+	for i := 0; i < 20; i++ {
+		out, err := runner.Execute(ctx, &agentcore.Input{UserID: fmt.Sprintf("u%d", i)})
+		require.NoError(t, err)
+		assert.Equal(t, "control", out.Metadata[ExperimentMetadataVariantID])
+	}
+	assert.Equal(t, treatmentCalls, len(treatment.models))
+}
+
+func TestExperimentRunnerUntrackedTraffic(t *testing.T) {
+	ctx := context.Background()
+	tester := NewABTester(NewMemoryExperimentStore(), nil)
+	startRunnerExperiment(t, tester)
+
+	control := &stubExperimentAgent{content: "ok"}
+	runner, err := NewExperimentRunner(tester, "agent-exp", []ExperimentArm{
+		{VariantID: "control", Agent: control},
+		{VariantID: "treatment", Agent: &stubExperimentAgent{content: "ok"}},
+	}, ExperimentRunnerConfig{}, nil)
+	require.NoError(t, err)
+
+	out, err := runner.Execute(ctx, &agentcore.Input{Content: "anonymous"})
+	require.NoError(t, err)
+	assert.Equal(t, "control", out.Metadata[ExperimentMetadataVariantID])
+
+	report, err := runner.Report(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.TotalSamples)
+}
+
+func TestNewExperimentRunnerRequiresArmPerVariant(t *testing.T) {
+	tester := NewABTester(NewMemoryExperimentStore(), nil)
+	startRunnerExperiment(t, tester)
+
+	_, err := NewExperimentRunner(tester, "agent-exp", []ExperimentArm{
+		{VariantID: "control", Agent: &stubExperimentAgent{}},
+	}, ExperimentRunnerConfig{}, nil)
+	assert.ErrorIs(t, err, ErrExperimentArmMissing)
+
+	_, err = NewExperimentRunner(tester, "missing", nil, ExperimentRunnerConfig{}, nil)
+	assert.ErrorIs(t, err, ErrExperimentNotFound)
+}
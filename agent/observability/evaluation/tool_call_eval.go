@@ -0,0 +1,635 @@
+package evaluation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ============================================================================
+// 工具调用准确率回归评估
+// ============================================================================
+//
+// 回放带有预期工具调用序列的数据集，逐个参数比对实际调用，
+// 按工具统计召回率、精确率与参数准确率，并支持跨模型/提示词版本对比，
+// 让工具调用退化在上线前被发现。
+// ============================================================================
+
+// ArgumentDiffKind 参数差异类型
+type ArgumentDiffKind string
+
+const (
+	ArgumentDiffMissing    ArgumentDiffKind = "missing"
+	ArgumentDiffUnexpected ArgumentDiffKind = "unexpected"
+	ArgumentDiffMismatch   ArgumentDiffKind = "mismatch"
+)
+
+// ExpectedToolCall 预期的一次工具调用
+type ExpectedToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// IgnoreArguments 不参与比对的参数路径（如 "query"、"filters.limit"、"items[0]"）
+	IgnoreArguments []string `json:"ignore_arguments,omitempty"`
+}
+
+// ToolCallCase 数据集中的一条回放用例
+type ToolCallCase struct {
+	ID       string             `json:"id"`
+	Input    string             `json:"input"`
+	Expected []ExpectedToolCall `json:"expected"`
+	Tags     []string           `json:"tags,omitempty"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+}
+
+// ToolCallDataset 工具调用回归数据集
+type ToolCallDataset struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Version     string         `json:"version,omitempty"`
+	Cases       []ToolCallCase `json:"cases"`
+}
+
+// ParseToolCallDataset 从 JSON 解析数据集并校验用例
+func ParseToolCallDataset(data []byte) (*ToolCallDataset, error) {
+	var dataset ToolCallDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("parse tool call dataset: %w", err)
+	}
+	if err := dataset.Validate(); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// Validate 校验用例 ID 唯一、工具名非空、预期参数为合法 JSON
+func (d *ToolCallDataset) Validate() error {
+	if d == nil {
+		return errors.New("tool call dataset is nil")
+	}
+	seen := make(map[string]struct{}, len(d.Cases))
+	for i, c := range d.Cases {
+		if c.ID == "" {
+			return fmt.Errorf("tool call dataset: cases[%d]: id is required", i)
+		}
+		if _, dup := seen[c.ID]; dup {
+			return fmt.Errorf("tool call dataset: duplicate case id %q", c.ID)
+		}
+		seen[c.ID] = struct{}{}
+		for j, call := range c.Expected {
+			if strings.TrimSpace(call.Name) == "" {
+				return fmt.Errorf("tool call dataset: case %q expected[%d]: name is required", c.ID, j)
+			}
+			if len(call.Arguments) > 0 && !json.Valid(call.Arguments) {
+				return fmt.Errorf("tool call dataset: case %q expected[%d]: arguments must be valid JSON", c.ID, j)
+			}
+		}
+	}
+	return nil
+}
+
+// ToolCallExecutor 执行一条用例输入并返回模型/Agent 实际发起的工具调用
+type ToolCallExecutor interface {
+	ExecuteToolCalls(ctx context.Context, input string) ([]types.ToolCall, error)
+}
+
+// ToolCallExecutorFunc 函数形式的 ToolCallExecutor
+type ToolCallExecutorFunc func(ctx context.Context, input string) ([]types.ToolCall, error)
+
+// ExecuteToolCalls 实现 ToolCallExecutor
+func (f ToolCallExecutorFunc) ExecuteToolCalls(ctx context.Context, input string) ([]types.ToolCall, error) {
+	return f(ctx, input)
+}
+
+// ToolCallEvalConfig 工具调用评估配置
+type ToolCallEvalConfig struct {
+	Concurrency int           `json:"concurrency"`
+	Timeout     time.Duration `json:"timeout"`
+	// OrderSensitive 为 true 时实际调用必须按预期顺序出现
+	OrderSensitive bool `json:"order_sensitive"`
+	// NumericTolerance 数值参数允许的绝对误差
+	NumericTolerance float64 `json:"numeric_tolerance"`
+	// PassThreshold 用例得分达到该值视为通过
+	PassThreshold float64 `json:"pass_threshold"`
+}
+
+// DefaultToolCallEvalConfig 返回默认配置
+func DefaultToolCallEvalConfig() ToolCallEvalConfig {
+	return ToolCallEvalConfig{
+		Concurrency:      5,
+		Timeout:          60 * time.Second,
+		OrderSensitive:   true,
+		NumericTolerance: 1e-9,
+		PassThreshold:    1.0,
+	}
+}
+
+// ArgumentDiff 单个参数路径上的差异
+type ArgumentDiff struct {
+	Path     string           `json:"path"`
+	Kind     ArgumentDiffKind `json:"kind"`
+	Expected any              `json:"expected,omitempty"`
+	Actual   any              `json:"actual,omitempty"`
+}
+
+// ToolCallMatch 一次预期调用与实际调用的配对结果
+type ToolCallMatch struct {
+	Tool          string         `json:"tool"`
+	ExpectedIndex int            `json:"expected_index"`
+	ActualIndex   int            `json:"actual_index"`
+	ArgumentScore float64        `json:"argument_score"` // 0.0 - 1.0
+	Diffs         []ArgumentDiff `json:"diffs,omitempty"`
+}
+
+// ToolCallCaseResult 单条用例的评估结果
+type ToolCallCaseResult struct {
+	CaseID     string           `json:"case_id"`
+	Score      float64          `json:"score"` // 0.0 - 1.0
+	ExactMatch bool             `json:"exact_match"`
+	Passed     bool             `json:"passed"`
+	Matches    []ToolCallMatch  `json:"matches,omitempty"`
+	Missing    []string         `json:"missing,omitempty"`    // 未被调用的预期工具
+	Unexpected []string         `json:"unexpected,omitempty"` // 多余的实际调用
+	Actual     []types.ToolCall `json:"actual,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Duration   time.Duration    `json:"duration"`
+}
+
+// ToolAccuracy 单个工具的准确率统计
+type ToolAccuracy struct {
+	Tool       string `json:"tool"`
+	Expected   int    `json:"expected"`
+	Matched    int    `json:"matched"`
+	ExactArgs  int    `json:"exact_args"`
+	Unexpected int    `json:"unexpected"`
+	// Recall = Matched / Expected
+	Recall float64 `json:"recall"`
+	// Precision = Matched / (Matched + Unexpected)
+	Precision float64 `json:"precision"`
+	// ArgumentAccuracy 已配对调用的平均参数得分
+	ArgumentAccuracy float64 `json:"argument_accuracy"`
+	// Accuracy 预期调用的平均得分（未调用计 0 分）
+	Accuracy float64 `json:"accuracy"`
+
+	argScoreSum float64
+}
+
+// ToolCallEvalReport 一次回放的评估报告
+type ToolCallEvalReport struct {
+	DatasetID      string                   `json:"dataset_id"`
+	DatasetVersion string                   `json:"dataset_version,omitempty"`
+	Label          string                   `json:"label"` // 模型/提示词版本标识
+	Results        []ToolCallCaseResult     `json:"results"`
+	PerTool        map[string]*ToolAccuracy `json:"per_tool"`
+	TotalCases     int                      `json:"total_cases"`
+	PassedCases    int                      `json:"passed_cases"`
+	ExactMatches   int                      `json:"exact_matches"`
+	Errors         int                      `json:"errors"`
+	ExactMatchRate float64                  `json:"exact_match_rate"`
+	PassRate       float64                  `json:"pass_rate"`
+	AverageScore   float64                  `json:"average_score"`
+	StartTime      time.Time                `json:"start_time"`
+	Duration       time.Duration            `json:"duration"`
+}
+
+// ToolCallVariant 参与对比的一个模型/提示词版本
+type ToolCallVariant struct {
+	Label    string
+	Executor ToolCallExecutor
+}
+
+// ToolCallEvaluator 回放数据集并对工具调用打分
+type ToolCallEvaluator struct {
+	config ToolCallEvalConfig
+	logger *zap.Logger
+}
+
+// NewToolCallEvaluator 创建工具调用评估器
+func NewToolCallEvaluator(config ToolCallEvalConfig, logger *zap.Logger) *ToolCallEvaluator {
+	defaults := DefaultToolCallEvalConfig()
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.NumericTolerance < 0 {
+		config.NumericTolerance = 0
+	}
+	if config.PassThreshold <= 0 || config.PassThreshold > 1 {
+		config.PassThreshold = defaults.PassThreshold
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ToolCallEvaluator{config: config, logger: logger.With(zap.String("component", "tool_call_evaluator"))}
+}
+
+// Run 回放数据集中的全部用例并生成报告
+func (e *ToolCallEvaluator) Run(ctx context.Context, dataset *ToolCallDataset, label string, executor ToolCallExecutor) (*ToolCallEvalReport, error) {
+	if err := dataset.Validate(); err != nil {
+		return nil, err
+	}
+	if executor == nil {
+		return nil, errors.New("tool call executor is nil")
+	}
+
+	start := time.Now()
+	results := make([]ToolCallCaseResult, len(dataset.Cases))
+	sem := make(chan struct{}, e.config.Concurrency)
+	var wg sync.WaitGroup
+	for i := range dataset.Cases {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[idx] = e.runCase(ctx, &dataset.Cases[idx], executor)
+		}(i)
+	}
+	wg.Wait()
+
+	report := &ToolCallEvalReport{
+		DatasetID:      dataset.ID,
+		DatasetVersion: dataset.Version,
+		Label:          label,
+		Results:        results,
+		StartTime:      start,
+		Duration:       time.Since(start),
+	}
+	e.summarize(report, dataset)
+	e.logger.Info("tool call evaluation completed",
+		zap.String("dataset_id", dataset.ID),
+		zap.String("label", label),
+		zap.Int("cases", report.TotalCases),
+		zap.Float64("exact_match_rate", report.ExactMatchRate),
+		zap.Float64("average_score", report.AverageScore))
+	return report, nil
+}
+
+// RunVariants 依次用每个版本回放同一数据集，便于横向对比
+func (e *ToolCallEvaluator) RunVariants(ctx context.Context, dataset *ToolCallDataset, variants []ToolCallVariant) ([]*ToolCallEvalReport, error) {
+	reports := make([]*ToolCallEvalReport, 0, len(variants))
+	for _, v := range variants {
+		report, err := e.Run(ctx, dataset, v.Label, v.Executor)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", v.Label, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (e *ToolCallEvaluator) runCase(ctx context.Context, c *ToolCallCase, executor ToolCallExecutor) ToolCallCaseResult {
+	start := time.Now()
+	caseCtx, cancel := context.WithTimeout(ctx, e.config.Timeout)
+	defer cancel()
+
+	actual, err := executor.ExecuteToolCalls(caseCtx, c.Input)
+	if err != nil {
+		result := ToolCallCaseResult{CaseID: c.ID, Error: err.Error(), Duration: time.Since(start)}
+		for _, exp := range c.Expected {
+			result.Missing = append(result.Missing, exp.Name)
+		}
+		return result
+	}
+	result := e.ScoreCase(c, actual)
+	result.Duration = time.Since(start)
+	return result
+}
+
+// ScoreCase 将实际调用与用例预期配对并打分。
+// 得分为各配对参数得分之和除以 max(预期数, 实际数)，遗漏与多余的调用都会拉低得分。
+func (e *ToolCallEvaluator) ScoreCase(c *ToolCallCase, actual []types.ToolCall) ToolCallCaseResult {
+	result := ToolCallCaseResult{CaseID: c.ID, Actual: actual}
+	used := make([]bool, len(actual))
+	cursor := 0
+	total := 0.0
+
+	for i, exp := range c.Expected {
+		expectedArgs := decodeToolArguments(exp.Arguments)
+		ignore := make(map[string]struct{}, len(exp.IgnoreArguments))
+		for _, p := range exp.IgnoreArguments {
+			ignore[p] = struct{}{}
+		}
+
+		best := -1
+		var bestScore float64
+		var bestDiffs []ArgumentDiff
+		from := 0
+		if e.config.OrderSensitive {
+			from = cursor
+		}
+		for j := from; j < len(actual); j++ {
+			if used[j] || actual[j].Name != exp.Name {
+				continue
+			}
+			score, diffs := e.compareArguments(expectedArgs, decodeToolArguments(actual[j].Arguments), ignore)
+			if best < 0 || score > bestScore {
+				best, bestScore, bestDiffs = j, score, diffs
+			}
+			// 顺序敏感时取第一个同名调用
+			if e.config.OrderSensitive || score == 1 {
+				break
+			}
+		}
+		if best < 0 {
+			result.Missing = append(result.Missing, exp.Name)
+			continue
+		}
+		used[best] = true
+		if e.config.OrderSensitive {
+			cursor = best + 1
+		}
+		total += bestScore
+		result.Matches = append(result.Matches, ToolCallMatch{
+			Tool:          exp.Name,
+			ExpectedIndex: i,
+			ActualIndex:   best,
+			ArgumentScore: bestScore,
+			Diffs:         bestDiffs,
+		})
+	}
+	for j, call := range actual {
+		if !used[j] {
+			result.Unexpected = append(result.Unexpected, call.Name)
+		}
+	}
+
+	denominator := max(len(c.Expected), len(actual))
+	if denominator == 0 {
+		result.Score = 1
+	} else {
+		result.Score = total / float64(denominator)
+	}
+	result.ExactMatch = len(result.Missing) == 0 && len(result.Unexpected) == 0 && total == float64(len(c.Expected))
+	result.Passed = result.Score >= e.config.PassThreshold
+	return result
+}
+
+func (e *ToolCallEvaluator) summarize(report *ToolCallEvalReport, dataset *ToolCallDataset) {
+	perTool := make(map[string]*ToolAccuracy)
+	stats := func(name string) *ToolAccuracy {
+		s, ok := perTool[name]
+		if !ok {
+			s = &ToolAccuracy{Tool: name}
+			perTool[name] = s
+		}
+		return s
+	}
+
+	var scoreSum float64
+	for i, r := range report.Results {
+		scoreSum += r.Score
+		if r.Passed {
+			report.PassedCases++
+		}
+		if r.ExactMatch {
+			report.ExactMatches++
+		}
+		if r.Error != "" {
+			report.Errors++
+		}
+		for _, exp := range dataset.Cases[i].Expected {
+			stats(exp.Name).Expected++
+		}
+		for _, m := range r.Matches {
+			s := stats(m.Tool)
+			s.Matched++
+			s.argScoreSum += m.ArgumentScore
+			if m.ArgumentScore == 1 {
+				s.ExactArgs++
+			}
+		}
+		for _, name := range r.Unexpected {
+			stats(name).Unexpected++
+		}
+	}
+
+	for _, s := range perTool {
+		if s.Expected > 0 {
+			s.Recall = float64(s.Matched) / float64(s.Expected)
+			s.Accuracy = s.argScoreSum / float64(s.Expected)
+		}
+		if s.Matched+s.Unexpected > 0 {
+			s.Precision = float64(s.Matched) / float64(s.Matched+s.Unexpected)
+		}
+		if s.Matched > 0 {
+			s.ArgumentAccuracy = s.argScoreSum / float64(s.Matched)
+		}
+	}
+
+	report.PerTool = perTool
+	report.TotalCases = len(report.Results)
+	if report.TotalCases > 0 {
+		n := float64(report.TotalCases)
+		report.AverageScore = scoreSum / n
+		report.PassRate = float64(report.PassedCases) / n
+		report.ExactMatchRate = float64(report.ExactMatches) / n
+	}
+}
+
+// compareArguments 按叶子节点比较参数，返回匹配比例与差异列表。
+// 多余的参数也计入分母，空参数与空参数视为完全匹配。
+func (e *ToolCallEvaluator) compareArguments(expected, actual any, ignore map[string]struct{}) (float64, []ArgumentDiff) {
+	var diffs []ArgumentDiff
+	matched, total := e.diffValue("", expected, actual, ignore, &diffs)
+	if total == 0 {
+		return 1, diffs
+	}
+	return float64(matched) / float64(total), diffs
+}
+
+func (e *ToolCallEvaluator) diffValue(path string, expected, actual any, ignore map[string]struct{}, diffs *[]ArgumentDiff) (int, int) {
+	if _, skip := ignore[path]; skip && path != "" {
+		return 0, 0
+	}
+	switch exp := expected.(type) {
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		matched, total := 0, 0
+		for _, key := range sortedKeys(exp) {
+			childPath := joinArgumentPath(path, key)
+			av, present := act[key]
+			if !present {
+				if _, skip := ignore[childPath]; skip {
+					continue
+				}
+				total++
+				*diffs = append(*diffs, ArgumentDiff{Path: childPath, Kind: ArgumentDiffMissing, Expected: exp[key]})
+				continue
+			}
+			m, t := e.diffValue(childPath, exp[key], av, ignore, diffs)
+			matched += m
+			total += t
+		}
+		for _, key := range sortedKeys(act) {
+			childPath := joinArgumentPath(path, key)
+			if _, present := exp[key]; present {
+				continue
+			}
+			if _, skip := ignore[childPath]; skip {
+				continue
+			}
+			total++
+			*diffs = append(*diffs, ArgumentDiff{Path: childPath, Kind: ArgumentDiffUnexpected, Actual: act[key]})
+		}
+		return matched, total
+	case []any:
+		act, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		matched, total := 0, 0
+		for i := 0; i < max(len(exp), len(act)); i++ {
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(act):
+				total++
+				*diffs = append(*diffs, ArgumentDiff{Path: childPath, Kind: ArgumentDiffMissing, Expected: exp[i]})
+			case i >= len(exp):
+				total++
+				*diffs = append(*diffs, ArgumentDiff{Path: childPath, Kind: ArgumentDiffUnexpected, Actual: act[i]})
+			default:
+				m, t := e.diffValue(childPath, exp[i], act[i], ignore, diffs)
+				matched += m
+				total += t
+			}
+		}
+		return matched, total
+	case float64:
+		if act, ok := actual.(float64); ok && math.Abs(exp-act) <= e.config.NumericTolerance {
+			return 1, 1
+		}
+	default:
+		if reflect.DeepEqual(expected, actual) {
+			return 1, 1
+		}
+	}
+	*diffs = append(*diffs, ArgumentDiff{Path: path, Kind: ArgumentDiffMismatch, Expected: expected, Actual: actual})
+	return 0, 1
+}
+
+// ToolAccuracyChange 单个工具在两个版本间的准确率变化
+type ToolAccuracyChange struct {
+	Tool      string  `json:"tool"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Delta     float64 `json:"delta"`
+}
+
+// ToolCallRegression 候选版本相对基线的退化情况
+type ToolCallRegression struct {
+	BaselineLabel  string               `json:"baseline_label"`
+	CandidateLabel string               `json:"candidate_label"`
+	ScoreDelta     float64              `json:"score_delta"`
+	ToolChanges    []ToolAccuracyChange `json:"tool_changes"`
+	RegressedTools []string             `json:"regressed_tools,omitempty"`
+	RegressedCases []string             `json:"regressed_cases,omitempty"` // 基线完全匹配、候选不再完全匹配的用例
+	FixedCases     []string             `json:"fixed_cases,omitempty"`
+}
+
+// HasRegression 存在退化的工具或用例时返回 true
+func (r *ToolCallRegression) HasRegression() bool {
+	return len(r.RegressedTools) > 0 || len(r.RegressedCases) > 0
+}
+
+// CompareToolCallReports 对比两个版本的报告。工具准确率下降超过 tolerance 视为退化。
+func CompareToolCallReports(baseline, candidate *ToolCallEvalReport, tolerance float64) *ToolCallRegression {
+	regression := &ToolCallRegression{
+		BaselineLabel:  baseline.Label,
+		CandidateLabel: candidate.Label,
+		ScoreDelta:     candidate.AverageScore - baseline.AverageScore,
+	}
+
+	tools := make(map[string]struct{}, len(baseline.PerTool)+len(candidate.PerTool))
+	for name := range baseline.PerTool {
+		tools[name] = struct{}{}
+	}
+	for name := range candidate.PerTool {
+		tools[name] = struct{}{}
+	}
+	for _, name := range sortedKeys(tools) {
+		var before, after float64
+		if s, ok := baseline.PerTool[name]; ok {
+			before = s.Accuracy
+		}
+		if s, ok := candidate.PerTool[name]; ok {
+			after = s.Accuracy
+		}
+		change := ToolAccuracyChange{Tool: name, Baseline: before, Candidate: after, Delta: after - before}
+		regression.ToolChanges = append(regression.ToolChanges, change)
+		if change.Delta < -tolerance {
+			regression.RegressedTools = append(regression.RegressedTools, name)
+		}
+	}
+
+	baselineExact := make(map[string]bool, len(baseline.Results))
+	for _, r := range baseline.Results {
+		baselineExact[r.CaseID] = r.ExactMatch
+	}
+	for _, r := range candidate.Results {
+		wasExact, ok := baselineExact[r.CaseID]
+		if !ok {
+			continue
+		}
+		switch {
+		case wasExact && !r.ExactMatch:
+			regression.RegressedCases = append(regression.RegressedCases, r.CaseID)
+		case !wasExact && r.ExactMatch:
+			regression.FixedCases = append(regression.FixedCases, r.CaseID)
+		}
+	}
+	return regression
+}
+
+func decodeToolArguments(raw json.RawMessage) any {
+	if len(raw) == 0 || string(raw) == "null" {
+		return map[string]any{}
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		// 非法 JSON 按原始字符串比较，确保产生差异而不是静默通过
+		return string(raw)
+	}
+	// 部分模型把参数编码为 JSON 字符串
+	if s, ok := v.(string); ok {
+		var inner any
+		if json.Unmarshal([]byte(s), &inner) == nil {
+			if _, isObj := inner.(map[string]any); isObj {
+				return inner
+			}
+		}
+	}
+	return v
+}
+
+func joinArgumentPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
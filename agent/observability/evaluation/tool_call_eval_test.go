@@ -0,0 +1,165 @@
+package evaluation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func toolCall(name, args string) types.ToolCall {
+	return types.ToolCall{Name: name, Arguments: json.RawMessage(args)}
+}
+
+func scriptedExecutor(calls map[string][]types.ToolCall) ToolCallExecutor {
+	return ToolCallExecutorFunc(func(_ context.Context, input string) ([]types.ToolCall, error) {
+		if input == "boom" {
+			return nil, errors.New("model unavailable")
+		}
+		return calls[input], nil
+	})
+}
+
+func TestToolCallEvaluator_ScoreCaseArgumentDiffs(t *testing.T) {
+	e := NewToolCallEvaluator(DefaultToolCallEvalConfig(), zap.NewNop())
+	c := &ToolCallCase{
+		ID: "c1",
+		Expected: []ExpectedToolCall{
+			{Name: "search", Arguments: json.RawMessage(`{"query":"go","filters":{"lang":"en","limit":5},"tags":["a","b"]}`)},
+			{Name: "summarize", Arguments: json.RawMessage(`{"style":"short","request_id":"x"}`), IgnoreArguments: []string{"request_id"}},
+		},
+	}
+
+	exact := e.ScoreCase(c, []types.ToolCall{
+		toolCall("search", `{"query":"go","filters":{"lang":"en","limit":5.0},"tags":["a","b"]}`),
+		toolCall("summarize", `{"style":"short","request_id":"other"}`),
+	})
+	assert.True(t, exact.ExactMatch)
+	assert.True(t, exact.Passed)
+	assert.Equal(t, 1.0, exact.Score)
+
+	partial := e.ScoreCase(c, []types.ToolCall{
+		toolCall("search", `{"query":"golang","filters":{"lang":"en"},"tags":["a","b","c"],"page":2}`),
+		toolCall("summarize", `"{\"style\":\"short\"}"`),
+	})
+	assert.False(t, partial.ExactMatch)
+	require.Len(t, partial.Matches, 2)
+	search := partial.Matches[0]
+	// 叶子：query(错) lang(对) limit(缺) tags[0](对) tags[1](对) tags[2](多) page(多)
+	assert.InDelta(t, 3.0/7.0, search.ArgumentScore, 1e-9)
+	diffs := map[string]ArgumentDiffKind{}
+	for _, d := range search.Diffs {
+		diffs[d.Path] = d.Kind
+	}
+	assert.Equal(t, map[string]ArgumentDiffKind{
+		"query":         ArgumentDiffMismatch,
+		"filters.limit": ArgumentDiffMissing,
+		"tags[2]":       ArgumentDiffUnexpected,
+		"page":          ArgumentDiffUnexpected,
+	}, diffs)
+	assert.Equal(t, 1.0, partial.Matches[1].ArgumentScore, "string-encoded arguments are decoded")
+	assert.InDelta(t, (3.0/7.0+1)/2, partial.Score, 1e-9)
+}
+
+func TestToolCallEvaluator_MissingUnexpectedAndOrder(t *testing.T) {
+	c := &ToolCallCase{
+		ID: "c1",
+		Expected: []ExpectedToolCall{
+			{Name: "lookup", Arguments: json.RawMessage(`{"id":1}`)},
+			{Name: "notify"},
+		},
+	}
+	reversed := []types.ToolCall{toolCall("notify", `{}`), toolCall("lookup", `{"id":1}`), toolCall("delete", `{}`)}
+
+	ordered := NewToolCallEvaluator(DefaultToolCallEvalConfig(), nil)
+	result := ordered.ScoreCase(c, reversed)
+	assert.Equal(t, []string{"notify"}, result.Missing)
+	assert.ElementsMatch(t, []string{"notify", "delete"}, result.Unexpected)
+	assert.InDelta(t, 1.0/3.0, result.Score, 1e-9)
+
+	config := DefaultToolCallEvalConfig()
+	config.OrderSensitive = false
+	unordered := NewToolCallEvaluator(config, nil)
+	result = unordered.ScoreCase(c, reversed)
+	assert.Empty(t, result.Missing)
+	assert.Equal(t, []string{"delete"}, result.Unexpected)
+	assert.False(t, result.ExactMatch)
+	assert.InDelta(t, 2.0/3.0, result.Score, 1e-9)
+
+	empty := unordered.ScoreCase(&ToolCallCase{ID: "none"}, nil)
+	assert.True(t, empty.ExactMatch)
+	assert.Equal(t, 1.0, empty.Score)
+}
+
+func TestToolCallEvaluator_PerToolAccuracyAndRegression(t *testing.T) {
+	dataset, err := ParseToolCallDataset([]byte(`{
+		"id": "tools-v1",
+		"cases": [
+			{"id": "weather", "input": "weather", "expected": [{"name": "get_weather", "arguments": {"city": "Paris"}}]},
+			{"id": "search", "input": "search", "expected": [{"name": "search", "arguments": {"q": "go"}}]},
+			{"id": "both", "input": "both", "expected": [{"name": "search", "arguments": {"q": "x"}}, {"name": "get_weather", "arguments": {"city": "Rome"}}]},
+			{"id": "error", "input": "boom", "expected": [{"name": "search"}]}
+		]
+	}`))
+	require.NoError(t, err)
+
+	baselineExec := scriptedExecutor(map[string][]types.ToolCall{
+		"weather": {toolCall("get_weather", `{"city":"Paris"}`)},
+		"search":  {toolCall("search", `{"q":"go"}`)},
+		"both":    {toolCall("search", `{"q":"x"}`), toolCall("get_weather", `{"city":"Rome"}`)},
+	})
+	candidateExec := scriptedExecutor(map[string][]types.ToolCall{
+		"weather": {toolCall("get_weather", `{"city":"London"}`)},
+		"search":  {toolCall("search", `{"q":"go"}`), toolCall("search", `{"q":"go"}`)},
+		"both":    {toolCall("search", `{"q":"x"}`), toolCall("get_weather", `{"city":"Rome"}`)},
+	})
+
+	e := NewToolCallEvaluator(DefaultToolCallEvalConfig(), zap.NewNop())
+	reports, err := e.RunVariants(context.Background(), dataset, []ToolCallVariant{
+		{Label: "gpt-a/prompt-1", Executor: baselineExec},
+		{Label: "gpt-b/prompt-2", Executor: candidateExec},
+	})
+	require.NoError(t, err)
+	baseline, candidate := reports[0], reports[1]
+
+	assert.Equal(t, 4, baseline.TotalCases)
+	assert.Equal(t, 3, baseline.ExactMatches)
+	assert.Equal(t, 1, baseline.Errors)
+	assert.Equal(t, []string{"search"}, baseline.Results[3].Missing)
+	search := baseline.PerTool["search"]
+	assert.Equal(t, 3, search.Expected)
+	assert.Equal(t, 2, search.Matched)
+	assert.InDelta(t, 2.0/3.0, search.Recall, 1e-9)
+	assert.Equal(t, 1.0, search.Precision)
+	assert.Equal(t, 1.0, search.ArgumentAccuracy)
+
+	weather := candidate.PerTool["get_weather"]
+	assert.Equal(t, 0.5, weather.Accuracy)
+	assert.Equal(t, 1, weather.ExactArgs)
+	assert.Equal(t, 1, candidate.PerTool["search"].Unexpected)
+
+	regression := CompareToolCallReports(baseline, candidate, 0.01)
+	assert.True(t, regression.HasRegression())
+	assert.Equal(t, []string{"get_weather"}, regression.RegressedTools)
+	assert.Equal(t, []string{"weather", "search"}, regression.RegressedCases)
+	assert.Less(t, regression.ScoreDelta, 0.0)
+
+	assert.False(t, CompareToolCallReports(baseline, baseline, 0).HasRegression())
+}
+
+func TestParseToolCallDataset_Validation(t *testing.T) {
+	_, err := ParseToolCallDataset([]byte(`{"cases":[{"id":"a"},{"id":"a"}]}`))
+	assert.ErrorContains(t, err, "duplicate")
+	_, err = ParseToolCallDataset([]byte(`{"cases":[{"id":"a","expected":[{"name":""}]}]}`))
+	assert.ErrorContains(t, err, "name is required")
+	_, err = ParseToolCallDataset([]byte(`{"cases":[{"expected":[]}]}`))
+	assert.ErrorContains(t, err, "id is required")
+
+	_, err = NewToolCallEvaluator(DefaultToolCallEvalConfig(), nil).Run(context.Background(), &ToolCallDataset{}, "x", nil)
+	assert.Error(t, err)
+}
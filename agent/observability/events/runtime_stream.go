@@ -18,16 +18,17 @@ type SDKStreamEventType string
 type SDKRunItemEventName string
 
 const (
-	RuntimeStreamToken        RuntimeStreamEventType = "token"
-	RuntimeStreamReasoning    RuntimeStreamEventType = "reasoning"
-	RuntimeStreamToolCall     RuntimeStreamEventType = "tool_call"
-	RuntimeStreamToolResult   RuntimeStreamEventType = "tool_result"
-	RuntimeStreamToolProgress RuntimeStreamEventType = "tool_progress"
-	RuntimeStreamApproval     RuntimeStreamEventType = "approval"
-	RuntimeStreamSession      RuntimeStreamEventType = "session"
-	RuntimeStreamStatus       RuntimeStreamEventType = "status"
-	RuntimeStreamSteering     RuntimeStreamEventType = "steering"
-	RuntimeStreamStopAndSend  RuntimeStreamEventType = "stop_and_send"
+	RuntimeStreamToken         RuntimeStreamEventType = "token"
+	RuntimeStreamReasoning     RuntimeStreamEventType = "reasoning"
+	RuntimeStreamToolCall      RuntimeStreamEventType = "tool_call"
+	RuntimeStreamToolResult    RuntimeStreamEventType = "tool_result"
+	RuntimeStreamToolProgress  RuntimeStreamEventType = "tool_progress"
+	RuntimeStreamToolCallDelta RuntimeStreamEventType = "tool_call_delta"
+	RuntimeStreamApproval      RuntimeStreamEventType = "approval"
+	RuntimeStreamSession       RuntimeStreamEventType = "session"
+	RuntimeStreamStatus        RuntimeStreamEventType = "status"
+	RuntimeStreamSteering      RuntimeStreamEventType = "steering"
+	RuntimeStreamStopAndSend   RuntimeStreamEventType = "stop_and_send"
 )
 
 const (
@@ -161,6 +162,8 @@ func runtimeRunEventType(e RuntimeStreamEvent) types.RunEventType {
 		return types.RunEventToolResult
 	case RuntimeStreamToolProgress:
 		return types.RunEventToolProgress
+	case RuntimeStreamToolCallDelta:
+		return types.RunEventToolCallDelta
 	case RuntimeStreamApproval:
 		return types.RunEventApproval
 	case RuntimeStreamSession:
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/BaSui01/agentflow/types"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -23,6 +24,9 @@ const (
 	InterruptTypeError      InterruptType = "error"
 )
 
+// MetadataTenantID 是中断 Metadata 中记录所属租户的键，创建时从 context 自动写入.
+const MetadataTenantID = "tenant_id"
+
 // 中断状态代表中断状态.
 type InterruptStatus string
 
@@ -88,6 +92,9 @@ type InterruptStore interface {
 // 中断汉德勒处理中断事件.
 type InterruptHandler func(ctx context.Context, interrupt *Interrupt) error
 
+// ResolutionHandler 在中断进入终态（批准、拒绝、取消、超时）并持久化后调用。
+type ResolutionHandler func(ctx context.Context, interrupt *Interrupt)
+
 // 中断管理者管理工作流程中断 。
 type InterruptManager struct {
 	store    InterruptStore
@@ -95,6 +102,7 @@ type InterruptManager struct {
 	handlers map[InterruptType][]InterruptHandler
 	named    map[InterruptType]map[string]struct{}
 	pending  map[string]*pendingInterrupt
+	resolved []ResolutionHandler
 	mu       sync.RWMutex
 }
 
@@ -127,6 +135,16 @@ func (m *InterruptManager) RegisterHandler(interruptType InterruptType, handler
 	m.handlers[interruptType] = append(m.handlers[interruptType], handler)
 }
 
+// OnResolution 注册中断终态回调，回调在状态持久化后同步执行，用于审计等旁路记录。
+func (m *InterruptManager) OnResolution(handler ResolutionHandler) {
+	if handler == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolved = append(m.resolved, handler)
+}
+
 // RegisterNamedHandler registers a handler only once for the given interrupt
 // type and stable name. It returns true when a new handler was added.
 func (m *InterruptManager) RegisterNamedHandler(
@@ -221,7 +239,7 @@ func (m *InterruptManager) createPendingInterrupt(
 		InputSchema: opts.InputSchema,
 		CreatedAt:   time.Now(),
 		Timeout:     opts.Timeout,
-		Metadata:    opts.Metadata,
+		Metadata:    metadataWithTenant(ctx, opts.Metadata),
 	}
 
 	if interrupt.Timeout == 0 {
@@ -305,6 +323,7 @@ func (m *InterruptManager) ResolveInterrupt(ctx context.Context, interruptID str
 	}); err != nil {
 		return fmt.Errorf("failed to update interrupt: %w", err)
 	}
+	m.notifyResolution(ctx, interrupt)
 
 	// 发送对等待goroutine的响应
 	pending.resolveOnce.Do(func() {
@@ -337,6 +356,7 @@ func (m *InterruptManager) CancelInterrupt(ctx context.Context, interruptID stri
 	}); err != nil {
 		return err
 	}
+	m.notifyResolution(ctx, pending.interrupt)
 
 	pending.resolveOnce.Do(func() {
 		pending.cancelFn()
@@ -410,6 +430,16 @@ func (m *InterruptManager) handleTimeout(ctx context.Context, interrupt *Interru
 		m.logger.Error("failed to persist timeout interrupt", zap.Error(err), zap.String("id", interrupt.ID))
 	}
 	m.logger.Warn("interrupt timeout", zap.String("id", interrupt.ID))
+	m.notifyResolution(ctx, interrupt)
+}
+
+func (m *InterruptManager) notifyResolution(ctx context.Context, interrupt *Interrupt) {
+	m.mu.RLock()
+	handlers := append([]ResolutionHandler(nil), m.resolved...)
+	m.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(ctx, interrupt)
+	}
 }
 
 // 中断选项配置中断创建 。
@@ -427,6 +457,29 @@ type InterruptOptions struct {
 	Metadata     map[string]any
 }
 
+// metadataWithTenant 在调用方未显式指定时把 context 中的租户写入 Metadata，不修改调用方的 map.
+func metadataWithTenant(ctx context.Context, metadata map[string]any) map[string]any {
+	tenantID, ok := types.TenantID(ctx)
+	if !ok || tenantID == "" {
+		return metadata
+	}
+	if _, exists := metadata[MetadataTenantID]; exists {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataTenantID] = tenantID
+	return out
+}
+
+// TenantID 返回中断所属租户，未记录时为空.
+func (i *Interrupt) TenantID() string {
+	tenantID, _ := i.Metadata[MetadataTenantID].(string)
+	return tenantID
+}
+
 func generateInterruptID() string {
 	return "int_" + uuid.New().String()
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/BaSui01/agentflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -335,6 +336,39 @@ func TestCreatePendingInterruptCancelCleansPendingAndPersistsStatus(t *testing.T
 	assert.NotNil(t, loaded.ResolvedAt)
 }
 
+func TestOnResolutionReceivesTerminalStates(t *testing.T) {
+	m := NewInterruptManager(NewInMemoryInterruptStore(), nil)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	seen := map[string]InterruptStatus{}
+	m.OnResolution(func(_ context.Context, interrupt *Interrupt) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[interrupt.ID] = interrupt.Status
+	})
+
+	approved, err := m.CreatePendingInterrupt(ctx, InterruptOptions{Type: InterruptTypeApproval, Timeout: time.Hour})
+	require.NoError(t, err)
+	canceled, err := m.CreatePendingInterrupt(ctx, InterruptOptions{Type: InterruptTypeApproval, Timeout: time.Hour})
+	require.NoError(t, err)
+	timedOut, err := m.CreatePendingInterrupt(ctx, InterruptOptions{Type: InterruptTypeApproval, Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	require.NoError(t, m.ResolveInterrupt(ctx, approved.ID, &Response{Approved: true, UserID: "reviewer"}))
+	require.NoError(t, m.CancelInterrupt(ctx, canceled.ID))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen[timedOut.ID] == InterruptStatusTimeout
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, InterruptStatusResolved, seen[approved.ID])
+	assert.Equal(t, InterruptStatusCanceled, seen[canceled.ID])
+}
+
 // --- Store Save error ---
 
 func TestCreateInterruptStoreSaveError(t *testing.T) {
@@ -678,3 +712,23 @@ func TestCreateInterruptContextCanceled(t *testing.T) {
 	assert.Equal(t, InterruptStatusCanceled, loaded.Status)
 	assert.NotNil(t, loaded.ResolvedAt)
 }
+
+func TestInterruptManager_StampsTenantFromContext(t *testing.T) {
+	manager := NewInterruptManager(NewInMemoryInterruptStore(), nil)
+	metadata := map[string]any{"tool_name": "search"}
+
+	interrupt, err := manager.CreatePendingInterrupt(types.WithTenantID(context.Background(), "t1"), InterruptOptions{
+		WorkflowID: "wf", Type: InterruptTypeApproval, Metadata: metadata,
+	})
+	require.NoError(t, err)
+	defer func() { _ = manager.CancelInterrupt(context.Background(), interrupt.ID) }()
+
+	assert.Equal(t, "t1", interrupt.TenantID())
+	assert.Equal(t, "search", interrupt.Metadata["tool_name"])
+	assert.NotContains(t, metadata, MetadataTenantID, "caller metadata is not mutated")
+
+	untenanted, err := manager.CreatePendingInterrupt(context.Background(), InterruptOptions{WorkflowID: "wf", Type: InterruptTypeApproval})
+	require.NoError(t, err)
+	defer func() { _ = manager.CancelInterrupt(context.Background(), untenanted.ID) }()
+	assert.Empty(t, untenanted.TenantID())
+}
@@ -0,0 +1,378 @@
+package hitl
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// RiskDecision 是风险评分映射出的处理方式。
+type RiskDecision string
+
+const (
+	// RiskDecisionAutoProceed 直接执行，无需人工介入。
+	RiskDecisionAutoProceed RiskDecision = "auto_proceed"
+	// RiskDecisionNotifyOnly 直接执行，但通知人工知悉。
+	RiskDecisionNotifyOnly RiskDecision = "notify_only"
+	// RiskDecisionRequireApproval 执行前必须创建审批中断。
+	RiskDecisionRequireApproval RiskDecision = "require_approval"
+)
+
+// GuardrailSignal 是护栏对拟执行动作给出的信号。
+// Severity 取值与 guardrails 包一致：critical、high、medium、low。
+type GuardrailSignal struct {
+	Source   string `json:"source"`
+	Code     string `json:"code,omitempty"`
+	Severity string `json:"severity"`
+	Tripwire bool   `json:"tripwire,omitempty"`
+}
+
+// ProposedAction 描述一次待执行的工具调用。
+type ProposedAction struct {
+	TenantID string         `json:"tenant_id,omitempty"`
+	Tool     string         `json:"tool"`
+	Args     map[string]any `json:"args,omitempty"`
+	// Schema 为工具声明，RiskTier 与 Traits 参与评分；为空时仅按工具名覆盖评分。
+	Schema *types.ToolSchema `json:"schema,omitempty"`
+	// Amount 为动作涉及的金额；为 0 时尝试从 Args 的金额字段提取。
+	Amount   float64 `json:"amount,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+	// Irreversible 由调用方标记本次动作不可撤销，与工具声明的 Traits.Irreversible 取或。
+	Irreversible     bool              `json:"irreversible,omitempty"`
+	GuardrailSignals []GuardrailSignal `json:"guardrail_signals,omitempty"`
+}
+
+// RiskThresholds 将 0-100 的风险分映射为处理方式：
+// 低于 NotifyAt 自动执行，[NotifyAt, ApproveAt) 仅通知，达到 ApproveAt 需要审批。
+type RiskThresholds struct {
+	NotifyAt  float64 `json:"notify_at"`
+	ApproveAt float64 `json:"approve_at"`
+}
+
+// Decide 返回 score 对应的处理方式。
+func (t RiskThresholds) Decide(score float64) RiskDecision {
+	switch {
+	case score >= t.ApproveAt:
+		return RiskDecisionRequireApproval
+	case score >= t.NotifyAt:
+		return RiskDecisionNotifyOnly
+	default:
+		return RiskDecisionAutoProceed
+	}
+}
+
+// RiskFactor 是单项风险来源及其贡献 (0-1)。
+type RiskFactor struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// RiskAssessment 是一次评分的结果。
+type RiskAssessment struct {
+	Tool       string         `json:"tool"`
+	TenantID   string         `json:"tenant_id,omitempty"`
+	Score      float64        `json:"score"`
+	Decision   RiskDecision   `json:"decision"`
+	Thresholds RiskThresholds `json:"thresholds"`
+	Factors    []RiskFactor   `json:"factors,omitempty"`
+}
+
+// RequiresApproval 报告是否需要审批中断。
+func (a *RiskAssessment) RequiresApproval() bool {
+	return a != nil && a.Decision == RiskDecisionRequireApproval
+}
+
+// Summary 返回按贡献排序的风险来源说明，用于中断描述。
+func (a *RiskAssessment) Summary() string {
+	if a == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(a.Factors))
+	for _, f := range a.Factors {
+		part := fmt.Sprintf("%s=%.2f", f.Name, f.Score)
+		if f.Detail != "" {
+			part += " (" + f.Detail + ")"
+		}
+		parts = append(parts, part)
+	}
+	return fmt.Sprintf("risk %.0f/100 [%s]: %s", a.Score, a.Decision, strings.Join(parts, ", "))
+}
+
+// RiskScorerConfig 配置风险评分的各项权重与租户阈值。各项评分取值 0-1。
+type RiskScorerConfig struct {
+	// DefaultThresholds 是未配置租户阈值时使用的阈值。
+	DefaultThresholds RiskThresholds `json:"default_thresholds"`
+	// TenantThresholds 按租户覆盖阈值。
+	TenantThresholds map[string]RiskThresholds `json:"tenant_thresholds,omitempty"`
+
+	// TierScores 是工具风险等级的基础分。
+	TierScores map[types.RiskTier]float64 `json:"tier_scores,omitempty"`
+	// SideEffectScores 是工具副作用声明的基础分。
+	SideEffectScores map[types.ToolSideEffect]float64 `json:"side_effect_scores,omitempty"`
+	// ToolScores 按工具名覆盖基础分，优先于等级与副作用。
+	ToolScores map[string]float64 `json:"tool_scores,omitempty"`
+	// UnknownToolScore 是没有任何声明的工具的基础分。
+	UnknownToolScore float64 `json:"unknown_tool_score"`
+
+	// IrreversibleScore 是不可撤销动作的附加分。
+	IrreversibleScore float64 `json:"irreversible_score"`
+
+	// AmountHalfScale 是金额分达到 0.5 时的金额，金额分按 amount/(amount+scale) 饱和增长。
+	AmountHalfScale float64 `json:"amount_half_scale"`
+	// AmountArgKeys 是从 Args 提取金额时识别的字段名（不区分大小写）。
+	AmountArgKeys []string `json:"amount_arg_keys,omitempty"`
+
+	// SeverityScores 是护栏信号按严重级别的附加分；Tripwire 信号直接视为最高风险。
+	SeverityScores map[string]float64 `json:"severity_scores,omitempty"`
+}
+
+// DefaultRiskScorerConfig 返回带有合理默认值的风险评分配置。
+func DefaultRiskScorerConfig() *RiskScorerConfig {
+	return &RiskScorerConfig{
+		DefaultThresholds: RiskThresholds{NotifyAt: 30, ApproveAt: 70},
+		TierScores: map[types.RiskTier]float64{
+			types.RiskSafeRead:         0,
+			types.RiskSensitiveRead:    0.25,
+			types.RiskMutating:         0.4,
+			types.RiskExecution:        0.5,
+			types.RiskNetworkExecution: 0.6,
+			types.RiskAdmin:            0.8,
+		},
+		SideEffectScores: map[types.ToolSideEffect]float64{
+			types.ToolSideEffectNone:     0,
+			types.ToolSideEffectLocal:    0.3,
+			types.ToolSideEffectExternal: 0.5,
+		},
+		UnknownToolScore:  0.2,
+		IrreversibleScore: 0.6,
+		AmountHalfScale:   1000,
+		AmountArgKeys:     []string{"amount", "total", "price", "cost", "value"},
+		SeverityScores: map[string]float64{
+			"critical": 0.9,
+			"high":     0.6,
+			"medium":   0.3,
+			"low":      0.1,
+		},
+	}
+}
+
+// RiskScorer 将拟执行动作评估为 0-100 的风险分，并按租户阈值决定是否需要人工介入。
+// 各风险来源按 1-Π(1-s) 合成：单项高风险足以触发审批，多项中等风险会累积。
+type RiskScorer struct {
+	config *RiskScorerConfig
+	mu     sync.RWMutex
+	tenant map[string]RiskThresholds
+}
+
+// NewRiskScorer 创建风险评分器。
+func NewRiskScorer(config *RiskScorerConfig) *RiskScorer {
+	if config == nil {
+		config = DefaultRiskScorerConfig()
+	}
+	tenant := make(map[string]RiskThresholds, len(config.TenantThresholds))
+	for id, t := range config.TenantThresholds {
+		tenant[id] = t
+	}
+	return &RiskScorer{config: config, tenant: tenant}
+}
+
+// SetTenantThresholds 设置或替换租户阈值。
+func (s *RiskScorer) SetTenantThresholds(tenantID string, thresholds RiskThresholds) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenant[tenantID] = thresholds
+}
+
+// Thresholds 返回租户生效的阈值。
+func (s *RiskScorer) Thresholds(tenantID string) RiskThresholds {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if t, ok := s.tenant[tenantID]; ok {
+		return t
+	}
+	return s.config.DefaultThresholds
+}
+
+// Score 评估动作风险。TenantID 为空时从 ctx 读取租户。
+func (s *RiskScorer) Score(ctx context.Context, action ProposedAction) *RiskAssessment {
+	tenantID := action.TenantID
+	if tenantID == "" {
+		tenantID, _ = types.TenantID(ctx)
+	}
+
+	var factors []RiskFactor
+	add := func(name string, score float64, detail string) {
+		score = math.Max(0, math.Min(1, score))
+		if score > 0 {
+			factors = append(factors, RiskFactor{Name: name, Score: score, Detail: detail})
+		}
+	}
+
+	base, detail := s.toolScore(action)
+	add("tool", base, detail)
+
+	var traits *types.ToolTraits
+	if action.Schema != nil {
+		traits = action.Schema.Traits
+	}
+	if action.Irreversible || (traits != nil && traits.Irreversible) {
+		add("irreversible", s.config.IrreversibleScore, "")
+	}
+
+	if amount := s.amount(action); amount > 0 && s.config.AmountHalfScale > 0 {
+		add("amount", amount/(amount+s.config.AmountHalfScale), strings.TrimSpace(fmt.Sprintf("%g %s", amount, action.Currency)))
+	}
+
+	tripwire := false
+	for _, sig := range action.GuardrailSignals {
+		if sig.Tripwire {
+			tripwire = true
+			add("guardrail", 1, sig.Source+": tripwire")
+			continue
+		}
+		add("guardrail", s.config.SeverityScores[strings.ToLower(sig.Severity)], strings.TrimSpace(sig.Source+" "+sig.Code))
+	}
+
+	safe := 1.0
+	for _, f := range factors {
+		safe *= 1 - f.Score
+	}
+	score := (1 - safe) * 100
+	if tripwire {
+		score = 100
+	}
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].Score > factors[j].Score })
+
+	thresholds := s.Thresholds(tenantID)
+	return &RiskAssessment{
+		Tool:       action.Tool,
+		TenantID:   tenantID,
+		Score:      score,
+		Decision:   thresholds.Decide(score),
+		Thresholds: thresholds,
+		Factors:    factors,
+	}
+}
+
+// toolScore 返回工具基础分：名称覆盖 > 风险等级与副作用中的较高者 > 未声明默认分。
+func (s *RiskScorer) toolScore(action ProposedAction) (float64, string) {
+	if score, ok := s.config.ToolScores[action.Tool]; ok {
+		return score, "override"
+	}
+	if action.Schema == nil {
+		return s.config.UnknownToolScore, "undeclared"
+	}
+	score, detail, declared := 0.0, "", false
+	if tier := action.Schema.RiskTier; tier != "" {
+		score, detail, declared = s.config.TierScores[tier], string(tier), true
+	}
+	if traits := action.Schema.Traits; traits.Declared() {
+		if sideEffect := s.config.SideEffectScores[traits.SideEffect]; !declared || sideEffect > score {
+			score, detail = sideEffect, "side_effect="+string(traits.SideEffect)
+		}
+		declared = true
+	}
+	if !declared {
+		return s.config.UnknownToolScore, "undeclared"
+	}
+	return score, detail
+}
+
+// amount 返回动作金额；未显式设置时取 Args 中首个可解析的金额字段。
+func (s *RiskScorer) amount(action ProposedAction) float64 {
+	if action.Amount != 0 {
+		return math.Abs(action.Amount)
+	}
+	for _, key := range s.config.AmountArgKeys {
+		for argKey, value := range action.Args {
+			if !strings.EqualFold(argKey, key) {
+				continue
+			}
+			if v, ok := toFloat(value); ok {
+				return math.Abs(v)
+			}
+		}
+	}
+	return 0
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case interface{ Float64() (float64, error) }:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// RiskGate 根据风险评分自动放置中断：审批级创建审批中断并等待响应，通知级调用 Notify 后放行。
+type RiskGate struct {
+	Scorer  *RiskScorer
+	Manager *InterruptManager
+	// Notify 在通知级动作放行前调用，为空时忽略。
+	Notify func(ctx context.Context, action ProposedAction, assessment *RiskAssessment)
+}
+
+// Check 评估动作并返回是否允许执行。opts 用于填充审批中断的工作流与节点信息。
+func (g *RiskGate) Check(ctx context.Context, action ProposedAction, opts InterruptOptions) (bool, *RiskAssessment, error) {
+	if g == nil || g.Scorer == nil {
+		return false, nil, fmt.Errorf("risk gate is not configured")
+	}
+	assessment := g.Scorer.Score(ctx, action)
+	switch assessment.Decision {
+	case RiskDecisionAutoProceed:
+		return true, assessment, nil
+	case RiskDecisionNotifyOnly:
+		if g.Notify != nil {
+			g.Notify(ctx, action, assessment)
+		}
+		return true, assessment, nil
+	}
+
+	if g.Manager == nil {
+		return false, assessment, fmt.Errorf("approval required for %s but no interrupt manager configured", action.Tool)
+	}
+	opts.Type = InterruptTypeApproval
+	if opts.Title == "" {
+		opts.Title = "Approve tool call: " + action.Tool
+	}
+	if opts.Description == "" {
+		opts.Description = assessment.Summary()
+	}
+	if opts.Data == nil {
+		opts.Data = map[string]any{"action": action, "assessment": assessment}
+	}
+	metadata := make(map[string]any, len(opts.Metadata)+2)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata["risk_score"] = assessment.Score
+	metadata["risk_decision"] = string(assessment.Decision)
+	opts.Metadata = metadata
+
+	resp, err := g.Manager.CreateInterrupt(ctx, opts)
+	if err != nil {
+		return false, assessment, err
+	}
+	return resp != nil && resp.Approved, assessment, nil
+}
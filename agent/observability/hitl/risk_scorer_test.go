@@ -0,0 +1,146 @@
+package hitl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRiskScorer_Score(t *testing.T) {
+	scorer := NewRiskScorer(nil)
+	ctx := context.Background()
+
+	t.Run("safe read proceeds", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{
+			Tool:   "web_search",
+			Schema: &types.ToolSchema{Name: "web_search", RiskTier: types.RiskSafeRead},
+		})
+		assert.Zero(t, a.Score)
+		assert.Equal(t, RiskDecisionAutoProceed, a.Decision)
+		assert.Empty(t, a.Factors)
+	})
+
+	t.Run("undeclared tool uses base score", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{Tool: "mystery"})
+		assert.InDelta(t, 20, a.Score, 1e-9)
+		assert.Equal(t, RiskDecisionAutoProceed, a.Decision)
+	})
+
+	t.Run("external side effect notifies", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{
+			Tool: "send_email",
+			Schema: &types.ToolSchema{Name: "send_email", RiskTier: types.RiskMutating,
+				Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectExternal}},
+		})
+		assert.InDelta(t, 50, a.Score, 1e-9)
+		assert.Equal(t, RiskDecisionNotifyOnly, a.Decision)
+		assert.Equal(t, "side_effect=external", a.Factors[0].Detail)
+	})
+
+	t.Run("irreversible payment requires approval", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{
+			Tool: "transfer_funds",
+			Args: map[string]any{"Amount": "1000", "to": "acct-1"},
+			Schema: &types.ToolSchema{Name: "transfer_funds",
+				Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectExternal, Irreversible: true}},
+			Currency: "USD",
+		})
+		// 1 - (1-0.5)(1-0.6)(1-0.5)
+		assert.InDelta(t, 90, a.Score, 1e-9)
+		assert.True(t, a.RequiresApproval())
+		require.Len(t, a.Factors, 3)
+		assert.Equal(t, "irreversible", a.Factors[0].Name)
+		assert.Contains(t, a.Summary(), "amount=0.50 (1000 USD)")
+	})
+
+	t.Run("guardrail tripwire forces maximum", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{
+			Tool:             "web_search",
+			Schema:           &types.ToolSchema{Name: "web_search", RiskTier: types.RiskSafeRead},
+			GuardrailSignals: []GuardrailSignal{{Source: "injection", Tripwire: true}},
+		})
+		assert.Equal(t, 100.0, a.Score)
+		assert.True(t, a.RequiresApproval())
+	})
+
+	t.Run("guardrail severity accumulates", func(t *testing.T) {
+		a := scorer.Score(ctx, ProposedAction{
+			Tool:             "web_search",
+			Schema:           &types.ToolSchema{Name: "web_search", RiskTier: types.RiskSafeRead},
+			GuardrailSignals: []GuardrailSignal{{Source: "pii", Severity: "HIGH"}},
+		})
+		assert.InDelta(t, 60, a.Score, 1e-9)
+		assert.Equal(t, RiskDecisionNotifyOnly, a.Decision)
+	})
+}
+
+func TestRiskScorer_TenantThresholds(t *testing.T) {
+	config := DefaultRiskScorerConfig()
+	config.TenantThresholds = map[string]RiskThresholds{"strict": {NotifyAt: 10, ApproveAt: 40}}
+	config.ToolScores = map[string]float64{"deploy": 0.45}
+	scorer := NewRiskScorer(config)
+
+	action := ProposedAction{Tool: "deploy"}
+	assert.Equal(t, RiskDecisionNotifyOnly, scorer.Score(context.Background(), action).Decision)
+
+	strictCtx := types.WithTenantID(context.Background(), "strict")
+	a := scorer.Score(strictCtx, action)
+	assert.Equal(t, "strict", a.TenantID)
+	assert.Equal(t, RiskDecisionRequireApproval, a.Decision)
+
+	scorer.SetTenantThresholds("lenient", RiskThresholds{NotifyAt: 80, ApproveAt: 95})
+	action.TenantID = "lenient"
+	assert.Equal(t, RiskDecisionAutoProceed, scorer.Score(strictCtx, action).Decision)
+}
+
+func TestRiskGate_Check(t *testing.T) {
+	manager := NewInterruptManager(NewInMemoryInterruptStore(), zap.NewNop())
+	var created *Interrupt
+	manager.RegisterHandler(InterruptTypeApproval, func(ctx context.Context, interrupt *Interrupt) error {
+		created = interrupt
+		go func() {
+			_ = manager.ResolveInterrupt(context.Background(), interrupt.ID, &Response{Approved: true})
+		}()
+		return nil
+	})
+
+	var notified []string
+	gate := &RiskGate{
+		Scorer:  NewRiskScorer(nil),
+		Manager: manager,
+		Notify: func(_ context.Context, action ProposedAction, _ *RiskAssessment) {
+			notified = append(notified, action.Tool)
+		},
+	}
+	ctx := context.Background()
+
+	ok, a, err := gate.Check(ctx, ProposedAction{Tool: "lookup", Schema: &types.ToolSchema{RiskTier: types.RiskSafeRead}}, InterruptOptions{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RiskDecisionAutoProceed, a.Decision)
+
+	ok, _, err = gate.Check(ctx, ProposedAction{Tool: "notify_me", Schema: &types.ToolSchema{RiskTier: types.RiskExecution}}, InterruptOptions{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"notify_me"}, notified)
+	assert.Nil(t, created)
+
+	ok, a, err = gate.Check(ctx, ProposedAction{Tool: "drop_table", Irreversible: true, Schema: &types.ToolSchema{RiskTier: types.RiskAdmin}},
+		InterruptOptions{WorkflowID: "wf-1", Timeout: time.Second})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, a.RequiresApproval())
+	require.NotNil(t, created)
+	assert.Equal(t, "wf-1", created.WorkflowID)
+	assert.Equal(t, "Approve tool call: drop_table", created.Title)
+	assert.InDelta(t, a.Score, created.Metadata["risk_score"], 1e-9)
+
+	_, _, err = (&RiskGate{Scorer: NewRiskScorer(nil)}).Check(ctx, ProposedAction{Tool: "drop_table", Irreversible: true,
+		Schema: &types.ToolSchema{RiskTier: types.RiskAdmin}}, InterruptOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,414 @@
+package observability
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecisionGraphFormat 决策图导出格式
+type DecisionGraphFormat string
+
+const (
+	DecisionGraphJSON    DecisionGraphFormat = "json"
+	DecisionGraphDOT     DecisionGraphFormat = "dot"
+	DecisionGraphMermaid DecisionGraphFormat = "mermaid"
+)
+
+// ParseDecisionGraphFormat 解析导出格式，空值默认 JSON。
+func ParseDecisionGraphFormat(raw string) (DecisionGraphFormat, error) {
+	switch DecisionGraphFormat(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", DecisionGraphJSON:
+		return DecisionGraphJSON, nil
+	case DecisionGraphDOT, "graphviz":
+		return DecisionGraphDOT, nil
+	case DecisionGraphMermaid:
+		return DecisionGraphMermaid, nil
+	default:
+		return "", fmt.Errorf("unsupported decision graph format %q", raw)
+	}
+}
+
+// 决策图节点类型
+const (
+	DecisionNodeTrace       = "trace"
+	DecisionNodeStep        = "step"
+	DecisionNodeTimeline    = "timeline"
+	DecisionNodeDecision    = "decision"
+	DecisionNodeAlternative = "alternative"
+	DecisionNodeOutcome     = "outcome"
+)
+
+// DecisionGraphNode 决策图节点，Metadata 携带分数、置信度、成本等供仪表盘交互展示。
+type DecisionGraphNode struct {
+	ID       string            `json:"id"`
+	Kind     string            `json:"kind"`
+	Label    string            `json:"label"`
+	Chosen   bool              `json:"chosen,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// DecisionGraphEdge 决策图边
+type DecisionGraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// DecisionGraph 是一次运行推理轨迹的图表示。
+// 主链按时间串联推理步骤、时间线条目与决策，决策再分叉出其备选方案。
+type DecisionGraph struct {
+	TraceID string              `json:"trace_id"`
+	AgentID string              `json:"agent_id,omitempty"`
+	Nodes   []DecisionGraphNode `json:"nodes"`
+	Edges   []DecisionGraphEdge `json:"edges"`
+}
+
+// DecisionGraphRenderOptions 渲染选项
+type DecisionGraphRenderOptions struct {
+	// NodeURL 可选：为节点生成链接（DOT 的 URL 属性 / Mermaid 的 click href），返回空串表示不链接
+	NodeURL func(node DecisionGraphNode) string
+	// MermaidCallback 可选：Mermaid click 回调的 JS 函数名，NodeURL 未给出链接时使用
+	MermaidCallback string
+	// MaxLabelLength 节点标签最大字符数，默认 60
+	MaxLabelLength int
+}
+
+const defaultDecisionGraphLabelLength = 60
+
+// BuildDecisionGraph 把推理轨迹转换为决策图；trace 为 nil 时返回 nil。
+func BuildDecisionGraph(trace *ReasoningTrace) *DecisionGraph {
+	if trace == nil {
+		return nil
+	}
+	b := &decisionGraphBuilder{graph: &DecisionGraph{TraceID: trace.ID, AgentID: trace.AgentID}}
+
+	rootMeta := map[string]string{"session_id": trace.SessionID, "agent_id": trace.AgentID}
+	if trace.Synopsis != "" {
+		rootMeta["synopsis"] = trace.Synopsis
+	}
+	if !trace.StartTime.IsZero() {
+		rootMeta["start_time"] = trace.StartTime.Format(time.RFC3339Nano)
+	}
+	root := b.addNode(DecisionNodeTrace, "trace "+trace.ID, false, rootMeta)
+
+	type chainItem struct {
+		at    time.Time
+		order int
+		add   func() string
+	}
+	items := make([]chainItem, 0, len(trace.Steps)+len(trace.Timeline)+len(trace.Decisions))
+	for i := range trace.Steps {
+		step := trace.Steps[i]
+		items = append(items, chainItem{at: step.Timestamp, order: len(items), add: func() string { return b.addStep(step) }})
+	}
+	for i := range trace.Timeline {
+		entry := trace.Timeline[i]
+		items = append(items, chainItem{at: entry.Timestamp, order: len(items), add: func() string {
+			return b.addNode(DecisionNodeTimeline, entry.Type+": "+entry.Summary, false, stringifyMetadata(entry.Metadata))
+		}})
+	}
+	for i := range trace.Decisions {
+		decision := trace.Decisions[i]
+		items = append(items, chainItem{at: decision.Timestamp, order: len(items), add: func() string { return b.addDecision(decision) }})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].at.Equal(items[j].at) {
+			return items[i].order < items[j].order
+		}
+		return items[i].at.Before(items[j].at)
+	})
+
+	prev := root
+	for _, item := range items {
+		id := item.add()
+		b.addEdge(prev, id, "")
+		prev = id
+	}
+
+	if !trace.EndTime.IsZero() {
+		outcome := "completed"
+		outcomeMeta := map[string]string{"success": strconv.FormatBool(trace.Success)}
+		if trace.Duration > 0 {
+			outcomeMeta["duration"] = trace.Duration.String()
+		}
+		if trace.Error != "" {
+			outcome = "failed"
+			outcomeMeta["error"] = trace.Error
+		}
+		if trace.FinalOutput != "" {
+			outcomeMeta["output"] = trace.FinalOutput
+		}
+		end := b.addNode(DecisionNodeOutcome, outcome, false, outcomeMeta)
+		b.addEdge(prev, end, "")
+	}
+	return b.graph
+}
+
+type decisionGraphBuilder struct {
+	graph *DecisionGraph
+	seq   int
+}
+
+func (b *decisionGraphBuilder) addNode(kind, label string, chosen bool, metadata map[string]string) string {
+	b.seq++
+	id := "n" + strconv.Itoa(b.seq)
+	for k, v := range metadata {
+		if strings.TrimSpace(v) == "" {
+			delete(metadata, k)
+		}
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	b.graph.Nodes = append(b.graph.Nodes, DecisionGraphNode{ID: id, Kind: kind, Label: strings.TrimSpace(label), Chosen: chosen, Metadata: metadata})
+	return id
+}
+
+func (b *decisionGraphBuilder) addEdge(from, to, label string) {
+	b.graph.Edges = append(b.graph.Edges, DecisionGraphEdge{From: from, To: to, Label: label})
+}
+
+func (b *decisionGraphBuilder) addStep(step ReasoningStep) string {
+	meta := stringifyMetadata(step.Metadata)
+	meta["step_number"] = strconv.Itoa(step.StepNumber)
+	if step.Duration > 0 {
+		meta["duration"] = step.Duration.String()
+	}
+	label := step.Content
+	if step.Type != "" {
+		label = step.Type + ": " + step.Content
+	}
+	id := b.addNode(DecisionNodeStep, label, false, meta)
+	for _, decision := range step.Decisions {
+		b.addEdge(id, b.addDecision(decision), "decides")
+	}
+	return id
+}
+
+func (b *decisionGraphBuilder) addDecision(decision Decision) string {
+	meta := make(map[string]string, len(decision.Metadata)+6)
+	for k, v := range decision.Metadata {
+		meta[k] = v
+	}
+	meta["decision_id"] = decision.ID
+	meta["type"] = string(decision.Type)
+	meta["reasoning"] = decision.Reasoning
+	if decision.Confidence > 0 {
+		meta["confidence"] = formatGraphFloat(decision.Confidence)
+	}
+	if decision.Duration > 0 {
+		meta["duration"] = decision.Duration.String()
+	}
+	if len(decision.Factors) > 0 {
+		factors := make([]string, 0, len(decision.Factors))
+		for _, f := range decision.Factors {
+			factors = append(factors, fmt.Sprintf("%s=%s (weight %s, %s)", f.Name, formatGraphFloat(f.Value), formatGraphFloat(f.Weight), f.Impact))
+		}
+		meta["factors"] = strings.Join(factors, "; ")
+	}
+	label := decision.Description
+	if label == "" {
+		label = string(decision.Type)
+	}
+	if decision.Confidence > 0 {
+		label += " (" + formatGraphFloat(decision.Confidence) + ")"
+	}
+	id := b.addNode(DecisionNodeDecision, label, false, meta)
+	for _, alt := range decision.Alternatives {
+		altID := b.addNode(DecisionNodeAlternative, alt.Option+" ["+formatGraphFloat(alt.Score)+"]", alt.WasChosen, map[string]string{
+			"score":  formatGraphFloat(alt.Score),
+			"reason": alt.Reason,
+		})
+		edgeLabel := ""
+		if alt.WasChosen {
+			edgeLabel = "chosen"
+		}
+		b.addEdge(id, altID, edgeLabel)
+	}
+	return id
+}
+
+// Render 按指定格式渲染；JSON 格式由调用方直接序列化图结构，此处返回错误。
+func (g *DecisionGraph) Render(format DecisionGraphFormat, opts DecisionGraphRenderOptions) (string, error) {
+	switch format {
+	case DecisionGraphDOT:
+		return g.DOT(opts), nil
+	case DecisionGraphMermaid:
+		return g.Mermaid(opts), nil
+	default:
+		return "", fmt.Errorf("decision graph format %q cannot be rendered as text", format)
+	}
+}
+
+// DOT 渲染为 Graphviz DOT；节点 tooltip 携带元数据，选中的备选方案加粗高亮。
+func (g *DecisionGraph) DOT(opts DecisionGraphRenderOptions) string {
+	var sb strings.Builder
+	sb.WriteString("digraph decisions {\n")
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  label=" + dotQuote("trace "+g.TraceID) + ";\n")
+	sb.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range g.Nodes {
+		attrs := []string{
+			"label=" + dotQuote(truncateGraphLabel(node.Label, opts.MaxLabelLength)),
+			"shape=" + dotShape(node.Kind),
+		}
+		if tooltip := graphTooltip(node); tooltip != "" {
+			attrs = append(attrs, "tooltip="+dotQuote(tooltip))
+		}
+		if node.Chosen {
+			attrs = append(attrs, "style=\"bold,filled\"", "fillcolor=\"palegreen\"")
+		} else if node.Kind == DecisionNodeAlternative {
+			attrs = append(attrs, "style=dashed", "fontcolor=\"gray40\"")
+		}
+		if opts.NodeURL != nil {
+			if url := opts.NodeURL(node); url != "" {
+				attrs = append(attrs, "URL="+dotQuote(url))
+			}
+		}
+		sb.WriteString("  " + node.ID + " [" + strings.Join(attrs, ", ") + "];\n")
+	}
+	for _, edge := range g.Edges {
+		sb.WriteString("  " + edge.From + " -> " + edge.To)
+		if edge.Label != "" {
+			sb.WriteString(" [label=" + dotQuote(edge.Label) + "]")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid 渲染为 Mermaid flowchart；配置 NodeURL 或 MermaidCallback 时为节点生成可点击的 tooltip。
+func (g *DecisionGraph) Mermaid(opts DecisionGraphRenderOptions) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	var chosen []string
+	for _, node := range g.Nodes {
+		left, right := mermaidShape(node.Kind)
+		sb.WriteString("  " + node.ID + left + mermaidQuote(truncateGraphLabel(node.Label, opts.MaxLabelLength)) + right + "\n")
+		if node.Chosen {
+			chosen = append(chosen, node.ID)
+		}
+	}
+	for _, edge := range g.Edges {
+		if edge.Label != "" {
+			sb.WriteString("  " + edge.From + " -->|" + mermaidQuote(edge.Label) + "| " + edge.To + "\n")
+		} else {
+			sb.WriteString("  " + edge.From + " --> " + edge.To + "\n")
+		}
+	}
+	for _, node := range g.Nodes {
+		tooltip := mermaidQuote(graphTooltip(node))
+		url := ""
+		if opts.NodeURL != nil {
+			url = opts.NodeURL(node)
+		}
+		switch {
+		case url != "":
+			sb.WriteString("  click " + node.ID + " href " + mermaidQuote(url) + " " + tooltip + "\n")
+		case opts.MermaidCallback != "":
+			sb.WriteString("  click " + node.ID + " call " + opts.MermaidCallback + "(\"" + node.ID + "\") " + tooltip + "\n")
+		}
+	}
+	if len(chosen) > 0 {
+		sb.WriteString("  classDef chosen fill:#c8f7c5,stroke:#2e7d32,stroke-width:2px\n")
+		sb.WriteString("  class " + strings.Join(chosen, ",") + " chosen\n")
+	}
+	return sb.String()
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case DecisionNodeTrace:
+		return "oval"
+	case DecisionNodeDecision:
+		return "diamond"
+	case DecisionNodeOutcome:
+		return "doublecircle"
+	case DecisionNodeTimeline:
+		return "note"
+	default:
+		return "box"
+	}
+}
+
+func mermaidShape(kind string) (string, string) {
+	switch kind {
+	case DecisionNodeTrace:
+		return "([", "])"
+	case DecisionNodeDecision:
+		return "{", "}"
+	case DecisionNodeOutcome:
+		return "((", "))"
+	case DecisionNodeAlternative:
+		return "[/", "/]"
+	default:
+		return "[", "]"
+	}
+}
+
+// graphTooltip 按键排序拼接节点元数据。
+func graphTooltip(node DecisionGraphNode) string {
+	if len(node.Metadata) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(node.Metadata))
+	for k := range node.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+node.Metadata[k])
+	}
+	return strings.Join(parts, "\n")
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// mermaidQuote 用 Mermaid 实体转义标签中的特殊字符，并包上双引号。
+func mermaidQuote(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "\r", "", "\n", "<br/>", "|", "#124;", "<", "#lt;", ">", "#gt;")
+	return `"` + r.Replace(s) + `"`
+}
+
+func truncateGraphLabel(label string, limit int) string {
+	if limit <= 0 {
+		limit = defaultDecisionGraphLabelLength
+	}
+	label = strings.Join(strings.Fields(label), " ")
+	runes := []rune(label)
+	if len(runes) <= limit {
+		return label
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func stringifyMetadata(metadata map[string]any) map[string]string {
+	out := make(map[string]string, len(metadata)+2)
+	for k, v := range metadata {
+		switch typed := v.(type) {
+		case nil:
+			continue
+		case string:
+			out[k] = typed
+		case float64:
+			out[k] = formatGraphFloat(typed)
+		case []string:
+			out[k] = strings.Join(typed, ",")
+		default:
+			out[k] = fmt.Sprint(typed)
+		}
+	}
+	return out
+}
+
+func formatGraphFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,104 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordRoutingTrace(t *testing.T) *ExplainabilityTracker {
+	t.Helper()
+	tracker := NewExplainabilityTracker(DefaultExplainabilityConfig())
+	tracker.StartTraceWithID("trace-1", "session-1", "agent-1")
+	tracker.AddStep("trace-1", ReasoningStep{Type: "thought", Content: "need a \"cheap\" model", Metadata: map[string]any{"cost": 0.002}})
+	tracker.RecordDecision("trace-1", Decision{
+		Type:        DecisionModelRouting,
+		Description: "route to mini",
+		Reasoning:   "simple query",
+		Confidence:  0.9,
+		Metadata:    map[string]string{"cost": "0.001"},
+		Alternatives: []Alternative{
+			{Option: "gpt-4o", Score: 0.6, Reason: "expensive"},
+			{Option: "gpt-4o-mini", Score: 0.9, Reason: "fast", WasChosen: true},
+		},
+	})
+	tracker.AddTimelineEntry("trace-1", DecisionTimelineEntry{Type: "completion_decision", Summary: "done", Metadata: map[string]any{"stop_reason": "solved"}})
+	tracker.EndTrace("trace-1", true, "answer", "")
+	return tracker
+}
+
+func TestBuildDecisionGraph(t *testing.T) {
+	t.Parallel()
+	graph, ok := recordRoutingTrace(t).DecisionGraph("trace-1")
+	require.True(t, ok)
+	assert.Equal(t, "trace-1", graph.TraceID)
+
+	kinds := make([]string, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		kinds = append(kinds, node.Kind)
+	}
+	assert.Equal(t, []string{
+		DecisionNodeTrace, DecisionNodeStep, DecisionNodeDecision,
+		DecisionNodeAlternative, DecisionNodeAlternative, DecisionNodeTimeline, DecisionNodeOutcome,
+	}, kinds)
+
+	decision := graph.Nodes[2]
+	assert.Equal(t, "0.9", decision.Metadata["confidence"])
+	assert.Equal(t, "0.001", decision.Metadata["cost"])
+	assert.Equal(t, "0.002", graph.Nodes[1].Metadata["cost"])
+	assert.False(t, graph.Nodes[3].Chosen)
+	assert.True(t, graph.Nodes[4].Chosen)
+	assert.Contains(t, graph.Edges, DecisionGraphEdge{From: decision.ID, To: graph.Nodes[4].ID, Label: "chosen"})
+	assert.Contains(t, graph.Edges, DecisionGraphEdge{From: decision.ID, To: graph.Nodes[5].ID})
+
+	_, ok = recordRoutingTrace(t).DecisionGraph("missing")
+	assert.False(t, ok)
+}
+
+func TestDecisionGraph_DOT(t *testing.T) {
+	t.Parallel()
+	graph, _ := recordRoutingTrace(t).DecisionGraph("trace-1")
+	dot := graph.DOT(DecisionGraphRenderOptions{NodeURL: func(node DecisionGraphNode) string {
+		if node.Kind == DecisionNodeDecision {
+			return "/decisions/" + node.Metadata["decision_id"]
+		}
+		return ""
+	}})
+
+	assert.True(t, strings.HasPrefix(dot, "digraph decisions {\n"))
+	assert.Contains(t, dot, `label="thought: need a \"cheap\" model"`)
+	assert.Contains(t, dot, `shape=diamond`)
+	assert.Contains(t, dot, `tooltip="reason: fast\nscore: 0.9"`)
+	assert.Contains(t, dot, `URL="/decisions/decision_1"`)
+	assert.Contains(t, dot, `[label="chosen"]`)
+	assert.Contains(t, dot, `fillcolor="palegreen"`)
+}
+
+func TestDecisionGraph_Mermaid(t *testing.T) {
+	t.Parallel()
+	graph, _ := recordRoutingTrace(t).DecisionGraph("trace-1")
+	mermaid := graph.Mermaid(DecisionGraphRenderOptions{MermaidCallback: "showNode"})
+
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart TD\n"))
+	assert.Contains(t, mermaid, `n2["thought: need a #quot;cheap#quot; model"]`)
+	assert.Contains(t, mermaid, `n3{"route to mini (0.9)"}`)
+	assert.Contains(t, mermaid, `-->|"chosen"| n5`)
+	assert.Contains(t, mermaid, `click n5 call showNode("n5") "reason: fast<br/>score: 0.9"`)
+	assert.Contains(t, mermaid, "class n5 chosen\n")
+
+	_, err := graph.Render(DecisionGraphJSON, DecisionGraphRenderOptions{})
+	assert.Error(t, err)
+}
+
+func TestParseDecisionGraphFormat(t *testing.T) {
+	t.Parallel()
+	for raw, want := range map[string]DecisionGraphFormat{"": DecisionGraphJSON, "DOT": DecisionGraphDOT, "graphviz": DecisionGraphDOT, "mermaid": DecisionGraphMermaid} {
+		got, err := ParseDecisionGraphFormat(raw)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseDecisionGraphFormat("svg")
+	assert.Error(t, err)
+}
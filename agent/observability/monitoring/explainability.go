@@ -302,6 +302,17 @@ func (t *ExplainabilityTracker) GetTrace(traceID string) *ReasoningTrace {
 	return t.traces[traceID]
 }
 
+// DecisionGraph 在读锁内把追踪转换为决策图，避免与进行中的记录并发读写。
+func (t *ExplainabilityTracker) DecisionGraph(traceID string) (*DecisionGraph, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	trace, ok := t.traces[traceID]
+	if !ok {
+		return nil, false
+	}
+	return BuildDecisionGraph(trace), true
+}
+
 // Get AgentTraces为特工检索所有痕迹.
 func (t *ExplainabilityTracker) GetAgentTraces(agentID string) []*ReasoningTrace {
 	t.mu.RLock()
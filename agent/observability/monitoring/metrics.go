@@ -287,6 +287,14 @@ func (o *ObservabilitySystem) GetLatestExplainabilitySynopsisSnapshot(sessionID,
 	return o.explainability.LatestSynopsisSnapshot(sessionID, agentID, excludeTraceID)
 }
 
+// DecisionGraph satisfies agent.DecisionGraphReader.
+func (o *ObservabilitySystem) DecisionGraph(traceID string) (*DecisionGraph, bool) {
+	if o.explainability == nil {
+		return nil, false
+	}
+	return o.explainability.DecisionGraph(traceID)
+}
+
 // NewMetricsCollector 创建指标收集器
 func NewMetricsCollector(logger *zap.Logger) *MetricsCollector {
 	return &MetricsCollector{
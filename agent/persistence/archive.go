@@ -0,0 +1,522 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ObjectStorage 是归档使用的对象存储抽象，可由 S3/OSS/GCS 等后端实现
+type ObjectStorage interface {
+	// PutObject 写入（覆盖）对象
+	PutObject(ctx context.Context, key string, data []byte) error
+
+	// GetObject 读取对象，不存在时返回 ErrNotFound
+	GetObject(ctx context.Context, key string) ([]byte, error)
+}
+
+// FileObjectStorage 是基于本地目录的 ObjectStorage 实现
+type FileObjectStorage struct {
+	baseDir string
+}
+
+// NewFileObjectStorage 创建基于本地目录的对象存储
+func NewFileObjectStorage(baseDir string) (*FileObjectStorage, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create object storage dir: %w", err)
+	}
+	return &FileObjectStorage{baseDir: baseDir}, nil
+}
+
+// PutObject 写入对象，先写临时文件再重命名，避免读到半写入的对象
+func (s *FileObjectStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create object dir: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write object %s: %w", key, err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to commit object %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetObject 读取对象
+func (s *FileObjectStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *FileObjectStorage) objectPath(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("%w: invalid object key %q", ErrInvalidInput, key)
+	}
+	return filepath.Join(s.baseDir, filepath.FromSlash(key)), nil
+}
+
+// ArchivePolicy 定义热存储到归档存储的分层策略
+// 注意：MemoryMessageStore/MemoryTaskStore 的 Cleanup 会直接删除过期数据，
+// 启用归档时应让 CleanupConfig 的保留时间长于归档阈值或关闭清理。
+type ArchivePolicy struct {
+	// MessageAge 是已确认/已过期消息在热存储中保留的时长，超过后归档（0 表示不归档消息）
+	MessageAge time.Duration `json:"message_age" yaml:"message_age"`
+
+	// TaskAge 是终态任务在热存储中保留的时长，超过后归档（0 表示不归档任务）
+	TaskAge time.Duration `json:"task_age" yaml:"task_age"`
+
+	// Interval 是后台归档的运行间隔（默认: 1h）
+	Interval time.Duration `json:"interval" yaml:"interval"`
+
+	// BatchSize 是单个归档对象的最大记录数（默认: 1000）
+	BatchSize int `json:"batch_size" yaml:"batch_size"`
+
+	// KeyPrefix 是归档对象键前缀（默认: archive）
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+}
+
+// DefaultArchivePolicy 返回默认归档策略：消息 7 天、任务 30 天后归档
+func DefaultArchivePolicy() ArchivePolicy {
+	return ArchivePolicy{
+		MessageAge: 7 * 24 * time.Hour,
+		TaskAge:    30 * 24 * time.Hour,
+		Interval:   1 * time.Hour,
+		BatchSize:  1000,
+		KeyPrefix:  "archive",
+	}
+}
+
+// ArchiveKind 是归档记录的类型
+type ArchiveKind string
+
+const (
+	ArchiveKindMessage ArchiveKind = "message"
+	ArchiveKindTask    ArchiveKind = "task"
+)
+
+// ArchiveEntry 是归档索引中的一条记录，指向包含该记录的归档对象
+type ArchiveEntry struct {
+	ID         string      `json:"id"`
+	Kind       ArchiveKind `json:"kind"`
+	ObjectKey  string      `json:"object_key"`
+	ArchivedAt time.Time   `json:"archived_at"`
+}
+
+// ArchiveReport 是一次归档运行的结果
+type ArchiveReport struct {
+	Messages int      `json:"messages"`
+	Tasks    int      `json:"tasks"`
+	Objects  []string `json:"objects,omitempty"`
+}
+
+// ArchivableMessageSource 是支持按时间筛选可归档消息的 MessageStore 扩展
+type ArchivableMessageSource interface {
+	// ListArchivableMessages 返回在 cutoff 前确认的消息，以及在 cutoff 前创建且已过期的消息
+	ListArchivableMessages(ctx context.Context, cutoff time.Time, limit int) ([]*Message, error)
+}
+
+// Archiver 将热存储中的旧消息与终态任务压缩归档到对象存储，并维护按 ID 检索的索引。
+// 每批记录先写入归档对象与索引，成功后才从热存储删除。
+type Archiver struct {
+	storage  ObjectStorage
+	messages MessageStore
+	tasks    TaskStore
+	policy   ArchivePolicy
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	index       map[string]ArchiveEntry
+	indexLoaded bool
+	now         func() time.Time
+
+	runMu sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewArchiver 创建归档器，messages 与 tasks 可以只提供其一
+func NewArchiver(storage ObjectStorage, messages MessageStore, tasks TaskStore, policy ArchivePolicy, logger *zap.Logger) (*Archiver, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("%w: object storage is required", ErrInvalidInput)
+	}
+	if messages == nil && tasks == nil {
+		return nil, fmt.Errorf("%w: message store or task store is required", ErrInvalidInput)
+	}
+	if messages != nil && policy.MessageAge > 0 {
+		if _, ok := messages.(ArchivableMessageSource); !ok {
+			return nil, fmt.Errorf("%w: message store %T does not support archival", ErrInvalidInput, messages)
+		}
+	}
+	defaults := DefaultArchivePolicy()
+	if policy.Interval <= 0 {
+		policy.Interval = defaults.Interval
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = defaults.BatchSize
+	}
+	policy.KeyPrefix = strings.Trim(policy.KeyPrefix, "/")
+	if policy.KeyPrefix == "" {
+		policy.KeyPrefix = defaults.KeyPrefix
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Archiver{
+		storage:  storage,
+		messages: messages,
+		tasks:    tasks,
+		policy:   policy,
+		logger:   logger.With(zap.String("component", "persistence_archiver")),
+		index:    make(map[string]ArchiveEntry),
+		now:      time.Now,
+	}, nil
+}
+
+// ArchiveOnce 执行一次归档
+func (a *Archiver) ArchiveOnce(ctx context.Context) (*ArchiveReport, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.loadIndexLocked(ctx); err != nil {
+		return nil, err
+	}
+
+	report := &ArchiveReport{}
+	now := a.now()
+	if a.messages != nil && a.policy.MessageAge > 0 {
+		if err := a.archiveMessagesLocked(ctx, now.Add(-a.policy.MessageAge), report); err != nil {
+			return report, err
+		}
+	}
+	if a.tasks != nil && a.policy.TaskAge > 0 {
+		if err := a.archiveTasksLocked(ctx, now.Add(-a.policy.TaskAge), report); err != nil {
+			return report, err
+		}
+	}
+
+	if report.Messages > 0 || report.Tasks > 0 {
+		a.logger.Info("archived records to object storage",
+			zap.Int("messages", report.Messages),
+			zap.Int("tasks", report.Tasks),
+			zap.Int("objects", len(report.Objects)),
+		)
+	}
+	return report, nil
+}
+
+func (a *Archiver) archiveMessagesLocked(ctx context.Context, cutoff time.Time, report *ArchiveReport) error {
+	source := a.messages.(ArchivableMessageSource)
+	for {
+		msgs, err := source.ListArchivableMessages(ctx, cutoff, a.policy.BatchSize)
+		if err != nil {
+			return fmt.Errorf("list archivable messages: %w", err)
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		records := make([]any, len(msgs))
+		ids := make([]string, len(msgs))
+		for i, msg := range msgs {
+			records[i] = msg
+			ids[i] = msg.ID
+		}
+		key, err := a.writeBatchLocked(ctx, ArchiveKindMessage, ids, records)
+		if err != nil {
+			return err
+		}
+		report.Objects = append(report.Objects, key)
+
+		for _, id := range ids {
+			if err := a.messages.DeleteMessage(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("delete archived message %s: %w", id, err)
+			}
+			report.Messages++
+		}
+		if len(msgs) < a.policy.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (a *Archiver) archiveTasksLocked(ctx context.Context, cutoff time.Time, report *ArchiveReport) error {
+	tasks, err := a.tasks.ListTasks(ctx, TaskFilter{
+		Status: []TaskStatus{TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusTimeout},
+	})
+	if err != nil {
+		return fmt.Errorf("list terminal tasks: %w", err)
+	}
+
+	eligible := make([]*AsyncTask, 0, len(tasks))
+	for _, task := range tasks {
+		finishedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			finishedAt = *task.CompletedAt
+		}
+		if finishedAt.Before(cutoff) {
+			eligible = append(eligible, task)
+		}
+	}
+
+	for start := 0; start < len(eligible); start += a.policy.BatchSize {
+		end := min(start+a.policy.BatchSize, len(eligible))
+		batch := eligible[start:end]
+
+		records := make([]any, len(batch))
+		ids := make([]string, len(batch))
+		for i, task := range batch {
+			records[i] = task
+			ids[i] = task.ID
+		}
+		key, err := a.writeBatchLocked(ctx, ArchiveKindTask, ids, records)
+		if err != nil {
+			return err
+		}
+		report.Objects = append(report.Objects, key)
+
+		for _, id := range ids {
+			if err := a.tasks.DeleteTask(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("delete archived task %s: %w", id, err)
+			}
+			report.Tasks++
+		}
+	}
+	return nil
+}
+
+// writeBatchLocked 写入 gzip 压缩的 JSON Lines 归档对象并更新索引
+func (a *Archiver) writeBatchLocked(ctx context.Context, kind ArchiveKind, ids []string, records []any) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return "", fmt.Errorf("encode %s archive record: %w", kind, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("compress %s archive: %w", kind, err)
+	}
+
+	archivedAt := a.now()
+	key := fmt.Sprintf("%s/%ss/%s/%s.jsonl.gz", a.policy.KeyPrefix, kind, archivedAt.UTC().Format("2006/01/02"), uuid.New().String())
+	if err := a.storage.PutObject(ctx, key, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("write archive object: %w", err)
+	}
+
+	previous := make(map[string]ArchiveEntry, len(ids))
+	for _, id := range ids {
+		indexKey := archiveIndexKey(kind, id)
+		if old, ok := a.index[indexKey]; ok {
+			previous[indexKey] = old
+		}
+		a.index[indexKey] = ArchiveEntry{ID: id, Kind: kind, ObjectKey: key, ArchivedAt: archivedAt}
+	}
+	if err := a.saveIndexLocked(ctx); err != nil {
+		// 索引未持久化时回滚内存索引，热存储中的记录保持不变
+		for _, id := range ids {
+			indexKey := archiveIndexKey(kind, id)
+			if old, ok := previous[indexKey]; ok {
+				a.index[indexKey] = old
+			} else {
+				delete(a.index, indexKey)
+			}
+		}
+		return "", err
+	}
+	return key, nil
+}
+
+// GetArchivedMessage 从归档中读取消息，未归档时返回 ErrNotFound
+func (a *Archiver) GetArchivedMessage(ctx context.Context, msgID string) (*Message, error) {
+	var msg Message
+	if err := a.readArchived(ctx, ArchiveKindMessage, msgID, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetArchivedTask 从归档中读取任务，未归档时返回 ErrNotFound
+func (a *Archiver) GetArchivedTask(ctx context.Context, taskID string) (*AsyncTask, error) {
+	var task AsyncTask
+	if err := a.readArchived(ctx, ArchiveKindTask, taskID, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Lookup 返回记录的归档位置
+func (a *Archiver) Lookup(ctx context.Context, kind ArchiveKind, id string) (*ArchiveEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.loadIndexLocked(ctx); err != nil {
+		return nil, err
+	}
+	entry, ok := a.index[archiveIndexKey(kind, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+func (a *Archiver) readArchived(ctx context.Context, kind ArchiveKind, id string, out any) error {
+	entry, err := a.Lookup(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	data, err := a.storage.GetObject(ctx, entry.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("read archive object %s: %w", entry.ObjectKey, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decompress archive object %s: %w", entry.ObjectKey, err)
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var probe struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &probe); err != nil {
+			return fmt.Errorf("decode archive object %s: %w", entry.ObjectKey, err)
+		}
+		if probe.ID == id {
+			return json.Unmarshal(scanner.Bytes(), out)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scan archive object %s: %w", entry.ObjectKey, err)
+	}
+	return ErrNotFound
+}
+
+// Start 按 Interval 在后台运行归档，重复调用无副作用
+func (a *Archiver) Start(ctx context.Context) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.stop != nil {
+		return
+	}
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.loop(ctx, a.policy.Interval, a.stop, a.done)
+}
+
+// Stop 停止后台归档并等待当前运行结束
+func (a *Archiver) Stop() {
+	a.runMu.Lock()
+	stop, done := a.stop, a.done
+	a.stop, a.done = nil, nil
+	a.runMu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (a *Archiver) loop(ctx context.Context, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := a.ArchiveOnce(ctx); err != nil {
+			if errors.Is(err, ErrStoreClosed) {
+				return
+			}
+			a.logger.Warn("archive run failed", zap.Error(err))
+		}
+	}
+}
+
+func (a *Archiver) indexObjectKey() string {
+	return a.policy.KeyPrefix + "/index.json"
+}
+
+func (a *Archiver) loadIndexLocked(ctx context.Context) error {
+	if a.indexLoaded {
+		return nil
+	}
+	data, err := a.storage.GetObject(ctx, a.indexObjectKey())
+	if errors.Is(err, ErrNotFound) {
+		a.indexLoaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load archive index: %w", err)
+	}
+
+	var entries []ArchiveEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("decode archive index: %w", err)
+	}
+	for _, entry := range entries {
+		a.index[archiveIndexKey(entry.Kind, entry.ID)] = entry
+	}
+	a.indexLoaded = true
+	return nil
+}
+
+func (a *Archiver) saveIndexLocked(ctx context.Context) error {
+	entries := make([]ArchiveEntry, 0, len(a.index))
+	for _, entry := range a.index {
+		entries = append(entries, entry)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encode archive index: %w", err)
+	}
+	if err := a.storage.PutObject(ctx, a.indexObjectKey(), data); err != nil {
+		return fmt.Errorf("write archive index: %w", err)
+	}
+	return nil
+}
+
+func archiveIndexKey(kind ArchiveKind, id string) string {
+	return string(kind) + ":" + id
+}
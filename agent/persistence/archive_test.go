@@ -0,0 +1,163 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingObjectStorage struct {
+	ObjectStorage
+	failKey string
+}
+
+func (s *failingObjectStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	if key == s.failKey {
+		return errors.New("storage unavailable")
+	}
+	return s.ObjectStorage.PutObject(ctx, key, data)
+}
+
+func seedArchiveStores(t *testing.T) (*MemoryMessageStore, *MemoryTaskStore, time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	messages := newTestMemoryMessageStore(t)
+	tasks := newTestMemoryTaskStore(t)
+	now := time.Now()
+
+	old := now.Add(-10 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	expired := now.Add(-9 * 24 * time.Hour)
+	require.NoError(t, messages.SaveMessage(ctx, &Message{ID: "acked-old", Topic: "t", Content: "a", CreatedAt: old, AckedAt: &old}))
+	require.NoError(t, messages.SaveMessage(ctx, &Message{ID: "acked-recent", Topic: "t", Content: "b", CreatedAt: recent, AckedAt: &recent}))
+	require.NoError(t, messages.SaveMessage(ctx, &Message{ID: "expired-old", Topic: "t", Content: "c", CreatedAt: old, ExpiresAt: &expired}))
+	require.NoError(t, messages.SaveMessage(ctx, &Message{ID: "pending-old", Topic: "t", Content: "d", CreatedAt: old}))
+
+	finished := now.Add(-40 * 24 * time.Hour)
+	require.NoError(t, tasks.SaveTask(ctx, &AsyncTask{ID: "done-old", AgentID: "a1", Status: TaskStatusCompleted, Result: "ok", CompletedAt: &finished, Timeout: time.Minute}))
+	require.NoError(t, tasks.SaveTask(ctx, &AsyncTask{ID: "failed-recent", AgentID: "a1", Status: TaskStatusFailed, CompletedAt: &recent}))
+	require.NoError(t, tasks.SaveTask(ctx, &AsyncTask{ID: "running", AgentID: "a1", Status: TaskStatusRunning}))
+	return messages, tasks, now
+}
+
+func TestArchiver_MovesOldRecordsToObjectStorage(t *testing.T) {
+	ctx := context.Background()
+	messages, tasks, _ := seedArchiveStores(t)
+	storage, err := NewFileObjectStorage(t.TempDir())
+	require.NoError(t, err)
+
+	archiver, err := NewArchiver(storage, messages, tasks, DefaultArchivePolicy(), nil)
+	require.NoError(t, err)
+
+	report, err := archiver.ArchiveOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Messages)
+	assert.Equal(t, 1, report.Tasks)
+	require.Len(t, report.Objects, 2)
+	assert.Contains(t, report.Objects[0], "archive/messages/")
+	assert.Contains(t, report.Objects[0], ".jsonl.gz")
+
+	_, err = messages.GetMessage(ctx, "acked-old")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = messages.GetMessage(ctx, "acked-recent")
+	assert.NoError(t, err)
+	_, err = messages.GetMessage(ctx, "pending-old")
+	assert.NoError(t, err)
+	_, err = tasks.GetTask(ctx, "done-old")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = tasks.GetTask(ctx, "running")
+	assert.NoError(t, err)
+
+	msg, err := archiver.GetArchivedMessage(ctx, "expired-old")
+	require.NoError(t, err)
+	assert.Equal(t, "c", msg.Content)
+	task, err := archiver.GetArchivedTask(ctx, "done-old")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", task.Result)
+	assert.Equal(t, time.Minute, task.Timeout)
+
+	_, err = archiver.GetArchivedMessage(ctx, "acked-recent")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 新的归档器从对象存储中恢复索引
+	reopened, err := NewArchiver(storage, messages, tasks, DefaultArchivePolicy(), nil)
+	require.NoError(t, err)
+	entry, err := reopened.Lookup(ctx, ArchiveKindMessage, "acked-old")
+	require.NoError(t, err)
+	assert.Equal(t, report.Objects[0], entry.ObjectKey)
+
+	report, err = reopened.ArchiveOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Messages+report.Tasks)
+}
+
+func TestArchiver_BatchesByBatchSize(t *testing.T) {
+	ctx := context.Background()
+	messages := newTestMemoryMessageStore(t)
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, id := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		require.NoError(t, messages.SaveMessage(ctx, &Message{ID: id, Topic: "t", CreatedAt: old, AckedAt: &old}))
+	}
+	storage, err := NewFileObjectStorage(t.TempDir())
+	require.NoError(t, err)
+
+	archiver, err := NewArchiver(storage, messages, nil, ArchivePolicy{MessageAge: 24 * time.Hour, BatchSize: 2}, nil)
+	require.NoError(t, err)
+	report, err := archiver.ArchiveOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Messages)
+	assert.Len(t, report.Objects, 3)
+
+	for _, id := range []string{"m1", "m5"} {
+		_, err := archiver.GetArchivedMessage(ctx, id)
+		assert.NoError(t, err, id)
+	}
+}
+
+func TestArchiver_KeepsHotRecordsWhenIndexWriteFails(t *testing.T) {
+	ctx := context.Background()
+	messages, tasks, _ := seedArchiveStores(t)
+	files, err := NewFileObjectStorage(t.TempDir())
+	require.NoError(t, err)
+	storage := &failingObjectStorage{ObjectStorage: files, failKey: "archive/index.json"}
+
+	archiver, err := NewArchiver(storage, messages, tasks, DefaultArchivePolicy(), nil)
+	require.NoError(t, err)
+	_, err = archiver.ArchiveOnce(ctx)
+	require.Error(t, err)
+
+	_, err = messages.GetMessage(ctx, "acked-old")
+	assert.NoError(t, err)
+	_, err = archiver.Lookup(ctx, ArchiveKindMessage, "acked-old")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNewArchiver_Validation(t *testing.T) {
+	storage, err := NewFileObjectStorage(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = NewArchiver(nil, newTestMemoryMessageStore(t), nil, DefaultArchivePolicy(), nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = NewArchiver(storage, nil, nil, DefaultArchivePolicy(), nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestFileObjectStorage_RejectsEscapingKeys(t *testing.T) {
+	storage, err := NewFileObjectStorage(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "../x", "a/../../x", "/abs", "a//b"} {
+		assert.ErrorIs(t, storage.PutObject(ctx, key, []byte("x")), ErrInvalidInput, key)
+	}
+	require.NoError(t, storage.PutObject(ctx, "a/b.json", []byte("x")))
+	data, err := storage.GetObject(ctx, "a/b.json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("x"), data)
+	_, err = storage.GetObject(ctx, "a/missing.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
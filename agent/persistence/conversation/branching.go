@@ -17,6 +17,8 @@ type ConversationState struct {
 	Metadata  map[string]any  `json:"metadata,omitempty"`
 	CreatedAt time.Time       `json:"created_at"`
 	Label     string          `json:"label,omitempty"`
+	// Events 该状态上发生的非消息事件（审核拦截、禁言、移出等）
+	Events []ConversationEvent `json:"events,omitempty"`
 }
 
 // 分会代表谈话分会.
@@ -95,6 +97,24 @@ func (t *ConversationTree) AddMessage(msg types.Message) *ConversationState {
 	return newState
 }
 
+// RecordEvent 将事件记录到活动分支的当前状态。
+func (t *ConversationTree) RecordEvent(event ConversationEvent) *ConversationState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	branch := t.Branches[t.ActiveBranch]
+	if branch == nil || len(branch.States) == 0 {
+		return nil
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	state := branch.States[len(branch.States)-1]
+	state.Events = append(state.Events, event)
+	branch.UpdatedAt = time.Now()
+	return state
+}
+
 // GetCurentState 返回活动分支当前状态 。
 func (t *ConversationTree) GetCurrentState() *ConversationState {
 	t.mu.RLock()
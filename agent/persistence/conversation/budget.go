@@ -0,0 +1,321 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"go.uber.org/zap"
+)
+
+// BudgetExhaustedAction 预算耗尽后的处理方式
+type BudgetExhaustedAction string
+
+const (
+	// BudgetActionHardStop 立即结束对话
+	BudgetActionHardStop BudgetExhaustedAction = "hard_stop"
+	// BudgetActionSummarize 请一个代理总结讨论后结束对话
+	BudgetActionSummarize BudgetExhaustedAction = "summarize_and_conclude"
+	// BudgetActionEscalate 创建人工审批中断：批准则追加一份同等额度继续，否则结束对话
+	BudgetActionEscalate BudgetExhaustedAction = "escalate_to_human"
+)
+
+const (
+	EventBudgetConsumed  ConversationEventType = "budget_consumed"
+	EventBudgetExhausted ConversationEventType = "budget_exhausted"
+	EventBudgetExtended  ConversationEventType = "budget_extended"
+)
+
+// 代理通过回复的 Metadata 上报本轮消耗，键名与 agent.Output 的 JSON 字段一致
+const (
+	MetadataTokensUsed = "tokens_used"
+	MetadataCost       = "cost"
+)
+
+const (
+	defaultBudgetSummaryPrompt = "The budget for this conversation is exhausted. Summarize the discussion so far, state the conclusion and any open questions, then end the conversation."
+	// budgetNodeID 是预算升级中断的 NodeID
+	budgetNodeID = "conversation_budget"
+)
+
+// BudgetConfig 对话级预算，在轮次之间检查。零值限额表示不限制。
+// MaxWallTime 与 ConversationConfig.Timeout 不同：前者耗尽时按 OnExhausted 优雅收尾，
+// 后者会中断进行中的回复。
+type BudgetConfig struct {
+	MaxTokens   int           `json:"max_tokens"`
+	MaxCostUSD  float64       `json:"max_cost_usd"`
+	MaxWallTime time.Duration `json:"max_wall_time"`
+	// OnExhausted 耗尽后的处理方式，默认 hard_stop
+	OnExhausted BudgetExhaustedAction `json:"on_exhausted"`
+
+	// Summarizer summarize_and_conclude 时负责总结的代理，为空时使用最近一次发言的活跃代理
+	Summarizer    ConversationAgent `json:"-"`
+	SummaryPrompt string            `json:"summary_prompt,omitempty"`
+
+	// Approvals escalate_to_human 时用于创建审批中断，为空时按 hard_stop 处理
+	Approvals       *hitl.InterruptManager `json:"-"`
+	ApprovalTimeout time.Duration          `json:"approval_timeout,omitempty"`
+}
+
+// BudgetUsage 对话的预算消耗
+type BudgetUsage struct {
+	Tokens   int           `json:"tokens"`
+	CostUSD  float64       `json:"cost_usd"`
+	WallTime time.Duration `json:"wall_time"`
+	Turns    int           `json:"turns"`
+	// Extensions 人工批准追加预算的次数
+	Extensions int `json:"extensions,omitempty"`
+}
+
+// budgetState 记录预算消耗，受 Conversation.mu 保护
+type budgetState struct {
+	started time.Time
+	usage   BudgetUsage
+}
+
+// BudgetUsage 返回当前预算消耗；未配置预算时 ok 为 false。
+func (c *Conversation) BudgetUsage() (BudgetUsage, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Budget == nil || c.budget == nil {
+		return BudgetUsage{}, false
+	}
+	usage := c.budget.usage
+	usage.WallTime = time.Since(c.budget.started)
+	return usage, true
+}
+
+func (c *Conversation) startBudget() {
+	if c.Budget == nil {
+		return
+	}
+	c.mu.Lock()
+	c.budget = &budgetState{started: time.Now()}
+	c.mu.Unlock()
+}
+
+// consumeBudget 计入一次回复的消耗并记录 budget_consumed 事件。
+func (c *Conversation) consumeBudget(speaker ConversationAgent, reply *ChatMessage) {
+	if c.Budget == nil || c.budget == nil {
+		return
+	}
+	tokens, cost := replyConsumption(reply)
+
+	c.mu.Lock()
+	c.budget.usage.Tokens += tokens
+	c.budget.usage.CostUSD += cost
+	c.budget.usage.Turns++
+	usage := c.budget.usage
+	usage.WallTime = time.Since(c.budget.started)
+	c.mu.Unlock()
+
+	metadata := budgetUsageMetadata(usage)
+	metadata["turn_tokens"] = tokens
+	metadata["turn_cost_usd"] = cost
+	for key, value := range c.Budget.remaining(usage) {
+		metadata[key] = value
+	}
+	c.recordEvent(ConversationEvent{
+		Type:     EventBudgetConsumed,
+		AgentID:  speaker.ID(),
+		Metadata: metadata,
+	})
+}
+
+// checkBudget 在轮次之间检查预算，返回非空终止原因表示对话应结束。
+func (c *Conversation) checkBudget(ctx context.Context) string {
+	usage, ok := c.BudgetUsage()
+	if !ok {
+		return ""
+	}
+	cfg := c.Budget
+	exceeded := cfg.exceeded(usage)
+	if len(exceeded) == 0 {
+		return ""
+	}
+
+	action := cfg.OnExhausted
+	if action == "" {
+		action = BudgetActionHardStop
+	}
+	metadata := budgetUsageMetadata(usage)
+	metadata["exceeded"] = exceeded
+	metadata["action"] = string(action)
+	c.recordEvent(ConversationEvent{
+		Type:     EventBudgetExhausted,
+		Reason:   strings.Join(exceeded, ", ") + " budget exhausted",
+		Metadata: metadata,
+	})
+
+	switch action {
+	case BudgetActionSummarize:
+		c.concludeWithSummary(ctx)
+		return "budget_concluded"
+	case BudgetActionEscalate:
+		if c.escalateBudget(ctx, usage, exceeded) {
+			return ""
+		}
+		return "budget_escalation_rejected"
+	default:
+		return "budget_exhausted"
+	}
+}
+
+// concludeWithSummary 请总结代理生成结束语，失败时直接结束。
+func (c *Conversation) concludeWithSummary(ctx context.Context) {
+	summarizer := c.Budget.Summarizer
+	if summarizer == nil {
+		summarizer = c.lastActiveSpeaker()
+	}
+	if summarizer == nil {
+		return
+	}
+	prompt := c.Budget.SummaryPrompt
+	if prompt == "" {
+		prompt = defaultBudgetSummaryPrompt
+	}
+	c.addMessage(ChatMessage{Role: "system", Content: prompt})
+
+	reply, err := summarizer.Reply(ctx, c.GetMessages())
+	if err != nil || reply == nil {
+		c.logger.Warn("budget summary failed", zap.String("agent", summarizer.ID()), zap.Error(err))
+		return
+	}
+	reply.SenderID = summarizer.ID()
+	if reply.Metadata == nil {
+		reply.Metadata = make(map[string]any)
+	}
+	reply.Metadata["budget_summary"] = true
+	c.consumeBudget(summarizer, reply)
+	if c.moderateReply(ctx, summarizer, reply) {
+		c.addMessage(*reply)
+	}
+}
+
+// escalateBudget 创建审批中断并阻塞等待，批准时追加一份预算并返回 true。
+func (c *Conversation) escalateBudget(ctx context.Context, usage BudgetUsage, exceeded []string) bool {
+	if c.Budget.Approvals == nil {
+		c.logger.Warn("budget escalation requested but no interrupt manager configured, stopping conversation")
+		return false
+	}
+	resp, err := c.Budget.Approvals.CreateInterrupt(ctx, hitl.InterruptOptions{
+		WorkflowID:  c.ID,
+		NodeID:      budgetNodeID,
+		Type:        hitl.InterruptTypeApproval,
+		Title:       "Conversation budget exhausted",
+		Description: fmt.Sprintf("Conversation %s exhausted its %s budget. Approve to grant another budget of the same size.", c.ID, strings.Join(exceeded, ", ")),
+		Data:        usage,
+		Timeout:     c.Budget.ApprovalTimeout,
+		Metadata:    map[string]any{"conversation_id": c.ID, "exceeded": exceeded},
+	})
+	if err != nil {
+		c.logger.Warn("budget escalation failed", zap.Error(err))
+		return false
+	}
+	if resp == nil || !resp.Approved {
+		return false
+	}
+
+	c.mu.Lock()
+	c.budget.usage.Extensions++
+	extensions := c.budget.usage.Extensions
+	c.mu.Unlock()
+	c.recordEvent(ConversationEvent{
+		Type:     EventBudgetExtended,
+		Reason:   resp.Comment,
+		Metadata: map[string]any{"extensions": extensions, "approved_by": resp.UserID},
+	})
+	return true
+}
+
+// lastActiveSpeaker 返回最近一次发言且仍可发言的代理。
+func (c *Conversation) lastActiveSpeaker() ConversationAgent {
+	active := c.ActiveAgents()
+	if len(active) == 0 {
+		return nil
+	}
+	messages := c.GetMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		for _, agent := range active {
+			if agent.ID() == messages[i].SenderID {
+				return agent
+			}
+		}
+	}
+	return active[0]
+}
+
+// scale 返回有效限额相对配置限额的倍数，每次人工批准追加一份。
+func (b *BudgetConfig) scale(extensions int) float64 {
+	return float64(extensions + 1)
+}
+
+// exceeded 返回已耗尽的预算维度。
+func (b *BudgetConfig) exceeded(usage BudgetUsage) []string {
+	scale := b.scale(usage.Extensions)
+	var out []string
+	if b.MaxTokens > 0 && float64(usage.Tokens) >= float64(b.MaxTokens)*scale {
+		out = append(out, "tokens")
+	}
+	if b.MaxCostUSD > 0 && usage.CostUSD >= b.MaxCostUSD*scale {
+		out = append(out, "cost")
+	}
+	if b.MaxWallTime > 0 && float64(usage.WallTime) >= float64(b.MaxWallTime)*scale {
+		out = append(out, "wall_time")
+	}
+	return out
+}
+
+// remaining 返回各维度剩余额度，未限制的维度不返回。
+func (b *BudgetConfig) remaining(usage BudgetUsage) map[string]any {
+	scale := b.scale(usage.Extensions)
+	out := make(map[string]any)
+	if b.MaxTokens > 0 {
+		out["remaining_tokens"] = max(int(float64(b.MaxTokens)*scale)-usage.Tokens, 0)
+	}
+	if b.MaxCostUSD > 0 {
+		out["remaining_cost_usd"] = max(b.MaxCostUSD*scale-usage.CostUSD, 0)
+	}
+	if b.MaxWallTime > 0 {
+		out["remaining_wall_time"] = max(time.Duration(float64(b.MaxWallTime)*scale)-usage.WallTime, 0).String()
+	}
+	return out
+}
+
+func budgetUsageMetadata(usage BudgetUsage) map[string]any {
+	return map[string]any{
+		"tokens":    usage.Tokens,
+		"cost_usd":  usage.CostUSD,
+		"wall_time": usage.WallTime.String(),
+		"turns":     usage.Turns,
+	}
+}
+
+// replyConsumption 读取回复 Metadata 中上报的 token 与成本。
+func replyConsumption(reply *ChatMessage) (int, float64) {
+	if reply == nil {
+		return 0, 0
+	}
+	tokens, _ := metadataNumber(reply.Metadata[MetadataTokensUsed])
+	cost, _ := metadataNumber(reply.Metadata[MetadataCost])
+	return int(tokens), cost
+}
+
+func metadataNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case interface{ Float64() (float64, error) }:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
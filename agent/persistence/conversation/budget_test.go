@@ -0,0 +1,186 @@
+package conversation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// replyCosting 每次回复上报固定的 token 与成本
+func replyCosting(tokens int, cost float64) func(context.Context, []ChatMessage) (*ChatMessage, error) {
+	return func(context.Context, []ChatMessage) (*ChatMessage, error) {
+		return &ChatMessage{
+			Role:     "assistant",
+			Content:  "working on it",
+			Metadata: map[string]any{MetadataTokensUsed: tokens, MetadataCost: cost},
+		}, nil
+	}
+}
+
+func budgetedConversation(budget BudgetConfig, agents ...ConversationAgent) *Conversation {
+	cfg := DefaultConversationConfig()
+	cfg.MaxRounds = 10
+	cfg.Timeout = 5 * time.Second
+	conv := NewConversation(ModeRoundRobin, agents, cfg, zap.NewNop())
+	conv.Budget = &budget
+	return conv
+}
+
+func eventsOfType(events []ConversationEvent, typ ConversationEventType) []ConversationEvent {
+	var out []ConversationEvent
+	for _, event := range events {
+		if event.Type == typ {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func TestConversation_BudgetHardStop(t *testing.T) {
+	t.Parallel()
+	conv := budgetedConversation(BudgetConfig{MaxTokens: 250},
+		&mockAgent{id: "a1", replyFn: replyCosting(100, 0.01)},
+		&mockAgent{id: "a2", replyFn: replyCosting(100, 0.01)},
+	)
+
+	result, err := conv.Start(context.Background(), "go")
+	require.NoError(t, err)
+	assert.Equal(t, "budget_exhausted", result.TerminationReason)
+	assert.Equal(t, 3, result.TotalRounds)
+	require.NotNil(t, result.Budget)
+	assert.Equal(t, 300, result.Budget.Tokens)
+	assert.InDelta(t, 0.03, result.Budget.CostUSD, 1e-9)
+	assert.Equal(t, 3, result.Budget.Turns)
+
+	consumed := eventsOfType(result.Events, EventBudgetConsumed)
+	require.Len(t, consumed, 3)
+	assert.Equal(t, "a1", consumed[0].AgentID)
+	assert.Equal(t, 150, consumed[0].Metadata["remaining_tokens"])
+	assert.Equal(t, 0, consumed[2].Metadata["remaining_tokens"])
+
+	exhausted := eventsOfType(result.Events, EventBudgetExhausted)
+	require.Len(t, exhausted, 1)
+	assert.Equal(t, []string{"tokens"}, exhausted[0].Metadata["exceeded"])
+}
+
+func TestConversation_BudgetSummarizeAndConclude(t *testing.T) {
+	t.Parallel()
+	summarizer := &mockAgent{id: "chair", name: "Chair", replyFn: replyWith("summary: agreed")}
+	conv := budgetedConversation(BudgetConfig{
+		MaxCostUSD:  0.015,
+		OnExhausted: BudgetActionSummarize,
+		Summarizer:  summarizer,
+	}, &mockAgent{id: "a1", replyFn: replyCosting(10, 0.01)})
+
+	result, err := conv.Start(context.Background(), "go")
+	require.NoError(t, err)
+	assert.Equal(t, "budget_concluded", result.TerminationReason)
+
+	last := result.Messages[len(result.Messages)-1]
+	assert.Equal(t, "chair", last.SenderID)
+	assert.Equal(t, "summary: agreed", last.Content)
+	assert.Equal(t, true, last.Metadata["budget_summary"])
+	assert.Len(t, eventsOfType(result.Events, EventBudgetConsumed), 3, "summary turn is counted")
+}
+
+func TestConversation_BudgetWallTime(t *testing.T) {
+	t.Parallel()
+	conv := budgetedConversation(BudgetConfig{MaxWallTime: 20 * time.Millisecond},
+		&mockAgent{id: "slow", replyFn: func(context.Context, []ChatMessage) (*ChatMessage, error) {
+			time.Sleep(15 * time.Millisecond)
+			return &ChatMessage{Role: "assistant", Content: "thinking"}, nil
+		}},
+	)
+
+	result, err := conv.Start(context.Background(), "go")
+	require.NoError(t, err)
+	assert.Equal(t, "budget_exhausted", result.TerminationReason)
+	assert.Less(t, result.TotalRounds, 10)
+}
+
+// approveInOrder 依次以给定决定处理对话的预算升级中断
+func approveInOrder(t *testing.T, approvals *hitl.InterruptManager, workflowID string, decisions ...bool) {
+	t.Helper()
+	go func() {
+		for _, approved := range decisions {
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				pending := approvals.GetPendingInterrupts(workflowID)
+				if len(pending) > 0 {
+					_ = approvals.ResolveInterrupt(context.Background(), pending[0].ID, &hitl.Response{Approved: approved, UserID: "ops"})
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+	}()
+}
+
+func TestConversation_BudgetEscalation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		decisions      []bool
+		wantTurns      int
+		wantExtensions int
+	}{
+		{name: "approved grants another budget", decisions: []bool{true, false}, wantTurns: 4, wantExtensions: 1},
+		{name: "rejected stops", decisions: []bool{false}, wantTurns: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			approvals := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), nil)
+			conv := budgetedConversation(BudgetConfig{
+				MaxTokens:       200,
+				OnExhausted:     BudgetActionEscalate,
+				Approvals:       approvals,
+				ApprovalTimeout: 3 * time.Second,
+			}, &mockAgent{id: "a1", replyFn: replyCosting(100, 0)})
+			approveInOrder(t, approvals, conv.ID, tt.decisions...)
+
+			result, err := conv.Start(context.Background(), "go")
+			require.NoError(t, err)
+			assert.Equal(t, "budget_escalation_rejected", result.TerminationReason)
+			require.NotNil(t, result.Budget)
+			assert.Equal(t, tt.wantTurns, result.Budget.Turns)
+			assert.Equal(t, tt.wantExtensions, result.Budget.Extensions)
+
+			extended := eventsOfType(result.Events, EventBudgetExtended)
+			require.Len(t, extended, tt.wantExtensions)
+			if tt.wantExtensions > 0 {
+				assert.Equal(t, "ops", extended[0].Metadata["approved_by"])
+			}
+		})
+	}
+}
+
+func TestConversation_BudgetEscalationWithoutApprovalsStops(t *testing.T) {
+	t.Parallel()
+	conv := budgetedConversation(BudgetConfig{MaxTokens: 100, OnExhausted: BudgetActionEscalate},
+		&mockAgent{id: "a1", replyFn: replyCosting(100, 0)})
+
+	result, err := conv.Start(context.Background(), "go")
+	require.NoError(t, err)
+	assert.Equal(t, "budget_escalation_rejected", result.TerminationReason)
+	assert.Equal(t, 1, result.Budget.Turns)
+}
+
+func TestConversation_NoBudgetLeavesResultUnset(t *testing.T) {
+	t.Parallel()
+	cfg := DefaultConversationConfig()
+	cfg.MaxRounds = 2
+	conv := NewConversation(ModeRoundRobin, []ConversationAgent{&mockAgent{id: "a1"}}, cfg, zap.NewNop())
+
+	result, err := conv.Start(context.Background(), "go")
+	require.NoError(t, err)
+	assert.Nil(t, result.Budget)
+	assert.Empty(t, eventsOfType(result.Events, EventBudgetConsumed))
+	_, ok := conv.BudgetUsage()
+	assert.False(t, ok)
+}
@@ -3,6 +3,7 @@ package conversation
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -52,8 +53,19 @@ type Conversation struct {
 	Messages []ChatMessage
 	Config   ConversationConfig
 	Selector SpeakerSelector
-	logger   *zap.Logger
-	mu       sync.RWMutex
+	// Moderation 可选：广播前审核每条回复，并对违规或停滞的代理禁言/移出
+	Moderation *ModerationConfig
+	// Tree 可选：同步记录消息与审核事件的对话树
+	Tree *ConversationTree
+	// Budget 可选：对话级 token/成本/时长预算，在轮次之间检查
+	Budget *BudgetConfig
+	logger *zap.Logger
+	mu     sync.RWMutex
+
+	participants map[string]*participantState
+	events       []ConversationEvent
+	turn         int
+	budget       *budgetState
 }
 
 // 对话 Config 配置对话 。
@@ -120,6 +132,7 @@ func (c *Conversation) Start(ctx context.Context, initialMessage string) (*Conve
 		ConversationID: c.ID,
 		StartTime:      time.Now(),
 	}
+	c.startBudget()
 
 	round := 0
 	for round < c.Config.MaxRounds && len(c.Messages) < c.Config.MaxMessages {
@@ -130,22 +143,46 @@ func (c *Conversation) Start(ctx context.Context, initialMessage string) (*Conve
 			return result, ctx.Err()
 		default:
 		}
+		if reason := c.checkBudget(ctx); reason != "" {
+			result.TerminationReason = reason
+			break
+		}
 
-		// 选择下一个扬声器
-		speaker, err := c.Selector.SelectNext(ctx, c.Agents, c.Messages)
+		// 选择下一个扬声器（跳过被禁言或移出的代理）
+		agents := c.ActiveAgents()
+		if len(agents) == 0 {
+			result.TerminationReason = "no_active_agents"
+			break
+		}
+		speaker, err := c.Selector.SelectNext(ctx, agents, c.Messages)
 		if err != nil {
 			c.logger.Warn("speaker selection failed", zap.Error(err))
 			break
 		}
+		c.mu.Lock()
+		c.turn++
+		c.mu.Unlock()
 
 		// 得到回复
 		reply, err := speaker.Reply(ctx, c.Messages)
 		if err != nil {
 			c.logger.Warn("agent reply failed", zap.String("agent", speaker.ID()), zap.Error(err))
+			c.recordStall(speaker, err.Error())
+			continue
+		}
+		if reply != nil {
+			c.consumeBudget(speaker, reply)
+		}
+		if reply == nil || (c.Moderation != nil && strings.TrimSpace(reply.Content) == "") {
+			c.recordStall(speaker, "empty reply")
 			continue
 		}
 
 		reply.SenderID = speaker.ID()
+		if !c.moderateReply(ctx, speaker, reply) {
+			continue
+		}
+		c.resetStalls(speaker.ID())
 		c.addMessage(*reply)
 
 		// 检查终止
@@ -159,7 +196,11 @@ func (c *Conversation) Start(ctx context.Context, initialMessage string) (*Conve
 
 	result.EndTime = time.Now()
 	result.Messages = c.Messages
+	result.Events = c.GetEvents()
 	result.TotalRounds = round
+	if usage, ok := c.BudgetUsage(); ok {
+		result.Budget = &usage
+	}
 
 	if result.TerminationReason == "" {
 		result.TerminationReason = "max_rounds"
@@ -179,6 +220,9 @@ func (c *Conversation) addMessage(msg ChatMessage) {
 		msg.Timestamp = time.Now()
 	}
 	c.Messages = append(c.Messages, msg)
+	if c.Tree != nil {
+		c.Tree.AddMessage(toTypesMessage(msg))
+	}
 }
 
 func (c *Conversation) shouldTerminate(content string) bool {
@@ -205,6 +249,10 @@ type ConversationResult struct {
 	StartTime         time.Time     `json:"start_time"`
 	EndTime           time.Time     `json:"end_time"`
 	TerminationReason string        `json:"termination_reason"`
+	// Events 审核拦截、禁言、移出、预算消耗等对话事件
+	Events []ConversationEvent `json:"events,omitempty"`
+	// Budget 配置预算时的最终消耗
+	Budget *BudgetUsage `json:"budget,omitempty"`
 }
 
 // roundRobinSelector按顺序选择代理.
@@ -0,0 +1,302 @@
+package conversation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ConversationEventType 对话事件类型
+type ConversationEventType string
+
+const (
+	EventMessageBlocked ConversationEventType = "message_blocked"
+	EventAgentStalled   ConversationEventType = "agent_stalled"
+	EventAgentMuted     ConversationEventType = "agent_muted"
+	EventAgentUnmuted   ConversationEventType = "agent_unmuted"
+	EventAgentEjected   ConversationEventType = "agent_ejected"
+)
+
+// ConversationEvent 对话中的非消息事件
+type ConversationEvent struct {
+	Type      ConversationEventType `json:"type"`
+	AgentID   string                `json:"agent_id,omitempty"`
+	Reason    string                `json:"reason,omitempty"`
+	Metadata  map[string]any        `json:"metadata,omitempty"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+// ModerationConfig 消息级审核配置
+type ModerationConfig struct {
+	// Validator 在广播前校验每条 Reply，未通过的消息不会进入对话
+	Validator guardrails.Validator `json:"-"`
+	// MaxViolations 累计违规达到该次数后移出对话，默认 3
+	MaxViolations int `json:"max_violations"`
+	// MaxStalls 连续失败或空回复达到该次数后禁言，默认 3
+	MaxStalls int `json:"max_stalls"`
+	// MuteTurns 因停滞被禁言的轮次数，默认 5
+	MuteTurns int `json:"mute_turns"`
+	// EjectOnTripwire 校验结果触发 Tripwire 时立即移出，默认 true
+	EjectOnTripwire bool `json:"eject_on_tripwire"`
+	// OnEvent 事件回调（可选）
+	OnEvent func(ConversationEvent) `json:"-"`
+}
+
+// DefaultModerationConfig 返回默认审核配置
+func DefaultModerationConfig() ModerationConfig {
+	return ModerationConfig{
+		MaxViolations:   3,
+		MaxStalls:       3,
+		MuteTurns:       5,
+		EjectOnTripwire: true,
+	}
+}
+
+func (c ModerationConfig) withDefaults() ModerationConfig {
+	defaults := DefaultModerationConfig()
+	if c.MaxViolations <= 0 {
+		c.MaxViolations = defaults.MaxViolations
+	}
+	if c.MaxStalls <= 0 {
+		c.MaxStalls = defaults.MaxStalls
+	}
+	if c.MuteTurns <= 0 {
+		c.MuteTurns = defaults.MuteTurns
+	}
+	return c
+}
+
+// participantState 记录单个代理的审核状态，受 Conversation.mu 保护
+type participantState struct {
+	violations int
+	stalls     int
+	mutedUntil int // 禁言到第几轮次（不含），-1 表示无限期
+	muted      bool
+	ejected    bool
+}
+
+// Mute 禁言代理，turns<=0 表示直到 Unmute 为止。
+func (c *Conversation) Mute(agentID string, turns int, reason string) {
+	c.mu.Lock()
+	p := c.participantLocked(agentID)
+	p.muted = true
+	p.mutedUntil = -1
+	if turns > 0 {
+		p.mutedUntil = c.turn + turns
+	}
+	c.mu.Unlock()
+
+	c.recordEvent(ConversationEvent{
+		Type:     EventAgentMuted,
+		AgentID:  agentID,
+		Reason:   reason,
+		Metadata: map[string]any{"turns": turns},
+	})
+}
+
+// Unmute 解除代理禁言。
+func (c *Conversation) Unmute(agentID string) {
+	c.mu.Lock()
+	p := c.participantLocked(agentID)
+	wasMuted := p.muted
+	p.muted = false
+	p.stalls = 0
+	c.mu.Unlock()
+
+	if wasMuted {
+		c.recordEvent(ConversationEvent{Type: EventAgentUnmuted, AgentID: agentID})
+	}
+}
+
+// Eject 将代理移出对话，之后不再被选为发言人。
+func (c *Conversation) Eject(agentID, reason string) {
+	c.mu.Lock()
+	p := c.participantLocked(agentID)
+	already := p.ejected
+	p.ejected = true
+	c.mu.Unlock()
+
+	if !already {
+		c.recordEvent(ConversationEvent{Type: EventAgentEjected, AgentID: agentID, Reason: reason})
+	}
+}
+
+// IsMuted 报告代理当前是否被禁言。
+func (c *Conversation) IsMuted(agentID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.participants[agentID]
+	return ok && p.muted
+}
+
+// IsEjected 报告代理是否已被移出对话。
+func (c *Conversation) IsEjected(agentID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.participants[agentID]
+	return ok && p.ejected
+}
+
+// ActiveAgents 返回当前可发言（未禁言、未移出）的代理。
+func (c *Conversation) ActiveAgents() []ConversationAgent {
+	var expired []string
+	c.mu.Lock()
+	active := make([]ConversationAgent, 0, len(c.Agents))
+	for _, agent := range c.Agents {
+		p, ok := c.participants[agent.ID()]
+		if ok && p.muted && p.mutedUntil >= 0 && c.turn >= p.mutedUntil {
+			p.muted = false
+			p.stalls = 0
+			expired = append(expired, agent.ID())
+		}
+		if ok && (p.ejected || p.muted) {
+			continue
+		}
+		active = append(active, agent)
+	}
+	c.mu.Unlock()
+
+	for _, id := range expired {
+		c.recordEvent(ConversationEvent{Type: EventAgentUnmuted, AgentID: id, Reason: "mute expired"})
+	}
+	return active
+}
+
+// GetEvents 返回所有对话事件。
+func (c *Conversation) GetEvents() []ConversationEvent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]ConversationEvent{}, c.events...)
+}
+
+// moderateReply 在广播前校验回复，返回 false 表示消息被拦截。
+func (c *Conversation) moderateReply(ctx context.Context, speaker ConversationAgent, reply *ChatMessage) bool {
+	if c.Moderation == nil || c.Moderation.Validator == nil {
+		return true
+	}
+	cfg := c.Moderation.withDefaults()
+
+	result, err := cfg.Validator.Validate(ctx, reply.Content)
+	if err != nil {
+		// 校验器自身故障不应阻断对话，按放行处理并记录日志
+		c.logger.Warn("reply moderation failed", zap.String("agent", speaker.ID()), zap.Error(err))
+		return true
+	}
+	if result == nil || result.Valid {
+		return true
+	}
+
+	c.mu.Lock()
+	p := c.participantLocked(speaker.ID())
+	p.violations++
+	violations := p.violations
+	c.mu.Unlock()
+
+	c.recordEvent(ConversationEvent{
+		Type:    EventMessageBlocked,
+		AgentID: speaker.ID(),
+		Reason:  validationReason(result),
+		Metadata: map[string]any{
+			"content":    reply.Content,
+			"violations": violations,
+			"tripwire":   result.Tripwire,
+		},
+	})
+
+	switch {
+	case result.Tripwire && cfg.EjectOnTripwire:
+		c.Eject(speaker.ID(), "tripwire triggered")
+	case violations >= cfg.MaxViolations:
+		c.Eject(speaker.ID(), "repeated policy violations")
+	}
+	return false
+}
+
+// recordStall 记录一次失败或空回复，连续停滞达到阈值时禁言。
+func (c *Conversation) recordStall(speaker ConversationAgent, reason string) {
+	if c.Moderation == nil {
+		return
+	}
+	cfg := c.Moderation.withDefaults()
+
+	c.mu.Lock()
+	p := c.participantLocked(speaker.ID())
+	p.stalls++
+	stalls := p.stalls
+	c.mu.Unlock()
+
+	c.recordEvent(ConversationEvent{
+		Type:     EventAgentStalled,
+		AgentID:  speaker.ID(),
+		Reason:   reason,
+		Metadata: map[string]any{"stalls": stalls},
+	})
+	if stalls >= cfg.MaxStalls {
+		c.Mute(speaker.ID(), cfg.MuteTurns, "agent stalled")
+	}
+}
+
+// resetStalls 代理成功发言后清零连续停滞计数。
+func (c *Conversation) resetStalls(agentID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.participants[agentID]; ok {
+		p.stalls = 0
+	}
+}
+
+func (c *Conversation) participantLocked(agentID string) *participantState {
+	if c.participants == nil {
+		c.participants = make(map[string]*participantState)
+	}
+	p, ok := c.participants[agentID]
+	if !ok {
+		p = &participantState{}
+		c.participants[agentID] = p
+	}
+	return p
+}
+
+func (c *Conversation) recordEvent(event ConversationEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	c.mu.Lock()
+	c.events = append(c.events, event)
+	c.mu.Unlock()
+
+	if c.Tree != nil {
+		c.Tree.RecordEvent(event)
+	}
+	if c.Moderation != nil && c.Moderation.OnEvent != nil {
+		c.Moderation.OnEvent(event)
+	}
+	c.logger.Info("conversation event",
+		zap.String("type", string(event.Type)),
+		zap.String("agent", event.AgentID),
+		zap.String("reason", event.Reason))
+}
+
+func validationReason(result *guardrails.ValidationResult) string {
+	if result == nil || len(result.Errors) == 0 {
+		return "validation failed"
+	}
+	parts := make([]string, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		parts = append(parts, e.Code+": "+e.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// toTypesMessage 将对话消息转换为对话树使用的消息格式
+func toTypesMessage(msg ChatMessage) types.Message {
+	return types.Message{
+		Role:    types.Role(msg.Role),
+		Content: msg.Content,
+		Name:    msg.SenderID,
+	}
+}
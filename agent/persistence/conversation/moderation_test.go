@@ -0,0 +1,155 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// keywordValidator 拦截包含指定关键字的消息
+type keywordValidator struct {
+	keyword  string
+	tripwire bool
+}
+
+func (v *keywordValidator) Name() string  { return "keyword" }
+func (v *keywordValidator) Priority() int { return 1 }
+
+func (v *keywordValidator) Validate(_ context.Context, content string) (*guardrails.ValidationResult, error) {
+	result := guardrails.NewValidationResult()
+	if strings.Contains(content, v.keyword) {
+		result.AddError(guardrails.ValidationError{Code: guardrails.ErrCodeBlockedKeyword, Message: "contains " + v.keyword})
+		result.Tripwire = v.tripwire
+	}
+	return result, nil
+}
+
+func replyWith(content string) func(context.Context, []ChatMessage) (*ChatMessage, error) {
+	return func(context.Context, []ChatMessage) (*ChatMessage, error) {
+		return &ChatMessage{Role: "assistant", Content: content}, nil
+	}
+}
+
+func moderatedConversation(agents []ConversationAgent, moderation ModerationConfig) *Conversation {
+	cfg := DefaultConversationConfig()
+	cfg.MaxRounds = 4
+	cfg.Timeout = 5 * time.Second
+	conv := NewConversation(ModeRoundRobin, agents, cfg, zap.NewNop())
+	conv.Moderation = &moderation
+	conv.Tree = NewConversationTree(conv.ID)
+	return conv
+}
+
+func TestConversation_ModerationBlocksAndEjects(t *testing.T) {
+	t.Parallel()
+	agents := []ConversationAgent{
+		&mockAgent{id: "good", name: "Good"},
+		&mockAgent{id: "bad", name: "Bad", replyFn: replyWith("forbidden words")},
+	}
+	conv := moderatedConversation(agents, ModerationConfig{
+		Validator:     &keywordValidator{keyword: "forbidden"},
+		MaxViolations: 2,
+	})
+
+	result, err := conv.Start(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "max_rounds", result.TerminationReason)
+	for _, msg := range result.Messages {
+		assert.NotEqual(t, "bad", msg.SenderID, "blocked replies are never broadcast")
+	}
+	assert.True(t, conv.IsEjected("bad"))
+
+	var types []ConversationEventType
+	for _, event := range result.Events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []ConversationEventType{EventMessageBlocked, EventMessageBlocked, EventAgentEjected}, types)
+
+	// 事件与消息同步写入对话树
+	var treeEvents int
+	for _, state := range conv.Tree.GetHistory() {
+		treeEvents += len(state.Events)
+	}
+	assert.Equal(t, 3, treeEvents)
+	assert.Len(t, conv.Tree.GetMessages(), len(result.Messages))
+}
+
+func TestConversation_TripwireEjectsImmediately(t *testing.T) {
+	t.Parallel()
+	agents := []ConversationAgent{
+		&mockAgent{id: "bad", name: "Bad", replyFn: replyWith("leak secret")},
+	}
+	conv := moderatedConversation(agents, ModerationConfig{
+		Validator:       &keywordValidator{keyword: "secret", tripwire: true},
+		EjectOnTripwire: true,
+	})
+
+	result, err := conv.Start(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "no_active_agents", result.TerminationReason)
+	assert.True(t, conv.IsEjected("bad"))
+}
+
+func TestConversation_StallingAgentIsMuted(t *testing.T) {
+	t.Parallel()
+	var muted []string
+	agents := []ConversationAgent{
+		&mockAgent{id: "stuck", name: "Stuck", replyFn: func(context.Context, []ChatMessage) (*ChatMessage, error) {
+			return nil, errors.New("upstream timeout")
+		}},
+		&mockAgent{id: "ok", name: "OK"},
+	}
+	conv := moderatedConversation(agents, ModerationConfig{
+		MaxStalls: 2,
+		MuteTurns: 100,
+		OnEvent: func(event ConversationEvent) {
+			if event.Type == EventAgentMuted {
+				muted = append(muted, event.AgentID)
+			}
+		},
+	})
+
+	result, err := conv.Start(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stuck"}, muted)
+	assert.True(t, conv.IsMuted("stuck"))
+	assert.Equal(t, 4, result.TotalRounds)
+}
+
+func TestConversation_OrchestratorControls(t *testing.T) {
+	t.Parallel()
+	agents := []ConversationAgent{
+		&mockAgent{id: "a1", name: "A1"},
+		&mockAgent{id: "a2", name: "A2"},
+	}
+	conv := NewConversation(ModeRoundRobin, agents, DefaultConversationConfig(), nil)
+
+	conv.Mute("a1", 0, "manual")
+	require.Len(t, conv.ActiveAgents(), 1)
+	conv.Unmute("a1")
+	assert.Len(t, conv.ActiveAgents(), 2)
+
+	conv.Mute("a2", 1, "cool down")
+	assert.Len(t, conv.ActiveAgents(), 1)
+	conv.turn++
+	assert.Len(t, conv.ActiveAgents(), 2, "timed mute expires")
+
+	conv.Eject("a1", "manual")
+	conv.Eject("a1", "manual")
+	assert.Len(t, conv.ActiveAgents(), 1)
+
+	var ejected int
+	for _, event := range conv.GetEvents() {
+		if event.Type == EventAgentEjected {
+			ejected++
+		}
+	}
+	assert.Equal(t, 1, ejected)
+}
@@ -23,3 +23,15 @@ func NewTaskStore(config StoreConfig) (TaskStore, error) {
 		return nil, fmt.Errorf("unsupported task store type: %s", config.Type)
 	}
 }
+
+// NewSessionStore 创建基于配置的会话存储
+func NewSessionStore(config StoreConfig) (SessionStore, error) {
+	switch config.Type {
+	case StoreTypeMemory:
+		return NewMemorySessionStore(), nil
+	case StoreTypeFile:
+		return NewFileSessionStore(config.BaseDir)
+	default:
+		return nil, fmt.Errorf("unsupported session store type: %s", config.Type)
+	}
+}
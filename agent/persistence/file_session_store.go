@@ -0,0 +1,178 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSessionStore 以 JSON 文件持久化会话，每个会话一个文件。
+type FileSessionStore struct {
+	dir    string
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewFileSessionStore 创建文件会话存储，目录不存在时自动创建
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("%w: session directory is required", ErrInvalidInput)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create session directory: %w", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+func (s *FileSessionStore) path(sessionID string) string {
+	return filepath.Join(s.dir, url.PathEscape(sessionID)+".json")
+}
+
+// Close 关闭存储
+func (s *FileSessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Ping 检查存储目录是否可用
+func (s *FileSessionStore) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	_, err := os.Stat(s.dir)
+	return err
+}
+
+// SaveSession 原子地写入会话文件
+func (s *FileSessionStore) SaveSession(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" {
+		return ErrInvalidInput
+	}
+	raw, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode session: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	path := s.path(session.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	return nil
+}
+
+// GetSession 读取会话文件
+func (s *FileSessionStore) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	return s.read(s.path(sessionID))
+}
+
+func (s *FileSessionStore) read(path string) (*Session, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", filepath.Base(path), err)
+	}
+	return &session, nil
+}
+
+func (s *FileSessionStore) all() ([]*Session, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(paths))
+	for _, path := range paths {
+		session, err := s.read(path)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// ListSessions 检索匹配过滤条件的会话
+func (s *FileSessionStore) ListSessions(ctx context.Context, filter SessionFilter) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	sessions, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Session, 0, len(sessions))
+	for _, session := range sessions {
+		if filter.matches(session) {
+			result = append(result, session)
+		}
+	}
+	return limitSessions(result, filter.Limit), nil
+}
+
+// DeleteSession 删除会话文件
+func (s *FileSessionStore) DeleteSession(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	err := os.Remove(s.path(sessionID))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteExpired 删除已过期的会话文件
+func (s *FileSessionStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrStoreClosed
+	}
+	sessions, err := s.all()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, session := range sessions {
+		if !session.Expired(now) {
+			continue
+		}
+		if err := os.Remove(s.path(session.ID)); err != nil && !os.IsNotExist(err) {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
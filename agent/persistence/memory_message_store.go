@@ -417,6 +417,31 @@ func (s *MemoryMessageStore) Cleanup(ctx context.Context, olderThan time.Duratio
 	return count, nil
 }
 
+// ListArchivableMessages 返回在 cutoff 前确认的消息，以及在 cutoff 前创建且已过期的消息
+func (s *MemoryMessageStore) ListArchivableMessages(ctx context.Context, cutoff time.Time, limit int) ([]*Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+
+	result := make([]*Message, 0)
+	for _, msg := range s.messages {
+		acked := msg.AckedAt != nil && msg.AckedAt.Before(cutoff)
+		expired := msg.IsExpired() && msg.CreatedAt.Before(cutoff)
+		if !acked && !expired {
+			continue
+		}
+		result = append(result, msg)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
 // Stats 返回关于消息库的统计数据
 func (s *MemoryMessageStore) Stats(ctx context.Context) (*MessageStoreStats, error) {
 	s.mu.RLock()
@@ -492,3 +517,6 @@ func (s *MemoryMessageStore) cleanupLoop(interval time.Duration, stop <-chan str
 
 // 确保内存MessageStore执行信件Store
 var _ MessageStore = (*MemoryMessageStore)(nil)
+
+// 确保内存MessageStore支持归档
+var _ ArchivableMessageSource = (*MemoryMessageStore)(nil)
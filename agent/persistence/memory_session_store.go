@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionStore 是 SessionStore 的内存实现，适合开发和测试，重启后数据丢失。
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	closed   bool
+}
+
+// NewMemorySessionStore 创建内存会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*Session)}
+}
+
+// Close 关闭存储
+func (s *MemorySessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Ping 检查存储是否可用
+func (s *MemorySessionStore) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+// SaveSession 保存会话副本
+func (s *MemorySessionStore) SaveSession(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" {
+		return ErrInvalidInput
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	s.sessions[session.ID] = session.Clone()
+	return nil
+}
+
+// GetSession 通过 ID 获取会话副本
+func (s *MemorySessionStore) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return session.Clone(), nil
+}
+
+// ListSessions 检索匹配过滤条件的会话
+func (s *MemorySessionStore) ListSessions(ctx context.Context, filter SessionFilter) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	result := make([]*Session, 0)
+	for _, session := range s.sessions {
+		if filter.matches(session) {
+			result = append(result, session.Clone())
+		}
+	}
+	return limitSessions(result, filter.Limit), nil
+}
+
+// DeleteSession 删除会话
+func (s *MemorySessionStore) DeleteSession(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	if _, ok := s.sessions[sessionID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// DeleteExpired 删除已过期的会话
+func (s *MemorySessionStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrStoreClosed
+	}
+	count := 0
+	for id, session := range s.sessions {
+		if session.Expired(now) {
+			delete(s.sessions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// limitSessions 按更新时间倒序排列并截断
+func limitSessions(sessions []*Session, limit int) []*Session {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].UpdatedAt.Equal(sessions[j].UpdatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions
+}
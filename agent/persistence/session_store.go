@@ -0,0 +1,111 @@
+package persistence
+
+import (
+	"context"
+	"time"
+)
+
+// SessionStore 定义跨多次 Execute 调用的用户会话的持久化接口
+type SessionStore interface {
+	Store
+
+	// SaveSession 保存会话（创建或覆盖）
+	SaveSession(ctx context.Context, session *Session) error
+
+	// GetSession 通过 ID 获取会话，不存在时返回 ErrNotFound
+	GetSession(ctx context.Context, sessionID string) (*Session, error)
+
+	// ListSessions 检索匹配过滤条件的会话，按更新时间倒序
+	ListSessions(ctx context.Context, filter SessionFilter) ([]*Session, error)
+
+	// DeleteSession 删除会话，不存在时返回 ErrNotFound
+	DeleteSession(ctx context.Context, sessionID string) error
+
+	// DeleteExpired 删除在 now 之前过期的会话，返回删除数量
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// Session 是跨多轮对话的会话状态
+type Session struct {
+	ID       string `json:"id"`
+	AgentID  string `json:"agent_id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+
+	// MemoryNamespace 是会话绑定的记忆命名空间
+	MemoryNamespace string `json:"memory_namespace,omitempty"`
+
+	// Messages 是会话的对话历史
+	Messages []ConversationMessage `json:"messages,omitempty"`
+
+	// Budget 是会话级 token/成本预算与已用量
+	Budget SessionBudget `json:"budget"`
+
+	// HITL 是会话的人机协作状态
+	HITL SessionHITLState `json:"hitl"`
+
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	// ExpiresAt 为零值表示永不过期
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// SessionBudget 会话级预算，上限为 0 表示不限制
+type SessionBudget struct {
+	MaxTokens  int     `json:"max_tokens,omitempty"`
+	MaxCost    float64 `json:"max_cost,omitempty"`
+	TokensUsed int     `json:"tokens_used"`
+	CostUsed   float64 `json:"cost_used"`
+}
+
+// Exhausted 判断预算是否已用尽
+func (b SessionBudget) Exhausted() bool {
+	return (b.MaxTokens > 0 && b.TokensUsed >= b.MaxTokens) || (b.MaxCost > 0 && b.CostUsed >= b.MaxCost)
+}
+
+// SessionHITLState 记录会话中等待人工处理的状态
+type SessionHITLState struct {
+	// PendingInterrupts 是尚未解决的中断 ID
+	PendingInterrupts []string `json:"pending_interrupts,omitempty"`
+	// CheckpointID 是最近一次可恢复执行的检查点
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+	// Resumable 表示最近一次执行暂停并可恢复
+	Resumable bool `json:"resumable,omitempty"`
+}
+
+// Expired 判断会话在 now 时是否已过期
+func (s *Session) Expired(now time.Time) bool {
+	return s != nil && !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// Clone 返回会话的深拷贝
+func (s *Session) Clone() *Session {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.Messages = append([]ConversationMessage(nil), s.Messages...)
+	clone.HITL.PendingInterrupts = append([]string(nil), s.HITL.PendingInterrupts...)
+	if s.Metadata != nil {
+		clone.Metadata = make(map[string]string, len(s.Metadata))
+		for k, v := range s.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return &clone
+}
+
+// SessionFilter 会话过滤条件，空字段不参与过滤
+type SessionFilter struct {
+	AgentID  string
+	TenantID string
+	UserID   string
+	Limit    int
+}
+
+func (f SessionFilter) matches(s *Session) bool {
+	return (f.AgentID == "" || s.AgentID == f.AgentID) &&
+		(f.TenantID == "" || s.TenantID == f.TenantID) &&
+		(f.UserID == "" || s.UserID == f.UserID)
+}
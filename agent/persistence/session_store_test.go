@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStores(t *testing.T) {
+	fileStore, err := NewFileSessionStore(t.TempDir())
+	require.NoError(t, err)
+
+	for name, store := range map[string]SessionStore{"memory": NewMemorySessionStore(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			require.NoError(t, store.Ping(ctx))
+			assert.ErrorIs(t, store.SaveSession(ctx, &Session{}), ErrInvalidInput)
+
+			live := &Session{
+				ID: "s/1", AgentID: "agent", UserID: "alice",
+				Messages:  []ConversationMessage{{Role: "user", Content: "hi"}},
+				Budget:    SessionBudget{MaxTokens: 100, TokensUsed: 40},
+				HITL:      SessionHITLState{PendingInterrupts: []string{"int-1"}},
+				UpdatedAt: now,
+			}
+			expired := &Session{ID: "s2", AgentID: "agent", UserID: "bob", UpdatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)}
+			require.NoError(t, store.SaveSession(ctx, live))
+			require.NoError(t, store.SaveSession(ctx, expired))
+			live.Messages[0].Content = "mutated"
+
+			got, err := store.GetSession(ctx, "s/1")
+			require.NoError(t, err)
+			assert.Equal(t, "hi", got.Messages[0].Content)
+			assert.Equal(t, []string{"int-1"}, got.HITL.PendingInterrupts)
+			assert.False(t, got.Budget.Exhausted())
+
+			listed, err := store.ListSessions(ctx, SessionFilter{AgentID: "agent"})
+			require.NoError(t, err)
+			require.Len(t, listed, 2)
+			assert.Equal(t, "s/1", listed[0].ID, "most recently updated first")
+			listed, err = store.ListSessions(ctx, SessionFilter{UserID: "bob"})
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+
+			removed, err := store.DeleteExpired(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, removed)
+			_, err = store.GetSession(ctx, "s2")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			require.NoError(t, store.DeleteSession(ctx, "s/1"))
+			assert.ErrorIs(t, store.DeleteSession(ctx, "s/1"), ErrNotFound)
+			require.NoError(t, store.Close())
+			assert.ErrorIs(t, store.Ping(ctx), ErrStoreClosed)
+		})
+	}
+}
+
+func TestNewSessionStore(t *testing.T) {
+	store, err := NewSessionStore(StoreConfig{Type: StoreTypeFile, BaseDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &FileSessionStore{}, store)
+
+	_, err = NewSessionStore(StoreConfig{Type: StoreTypeRedis})
+	assert.Error(t, err)
+}
@@ -34,6 +34,7 @@ type AgentBuilder struct {
 	// 增强功能配置
 	reflectionConfig       *ReflectionExecutorConfig
 	toolSelectionConfig    *ToolSelectionConfig
+	semanticToolSelection  *semanticToolSelectionSetup
 	promptEnhancerConfig   *PromptEnhancerConfig
 	skillsInstance         SkillDiscoverer
 	mcpInstance            MCPServerRunner
@@ -52,6 +53,10 @@ type AgentBuilder struct {
 	reasoningRegistry    *reasoning.PatternRegistry
 	traceFeedbackPlanner TraceFeedbackPlanner
 	memoryRuntime        MemoryRuntime
+	interceptors         []ExecutionInterceptor
+	skillPackages        []SkillPackage
+	selfHealing          *SelfHealingConfig
+	profiles             *ProfileConfig
 
 	// 并发控制
 	maxConcurrency int
@@ -224,6 +229,21 @@ func (b *AgentBuilder) WithToolSelection(config *ToolSelectionConfig) *AgentBuil
 	return b
 }
 
+// WithSemanticToolSelection 启用基于向量相似度的动态工具选择，取代关键词评分的默认选择器。
+// embedder 为 nil 或语义选择失败时，LLM 通过 browse_tools 元工具按分类浏览工具。
+func (b *AgentBuilder) WithSemanticToolSelection(embedder ToolEmbedder, config *SemanticToolSelectionConfig) *AgentBuilder {
+	if config == nil {
+		config = DefaultSemanticToolSelectionConfig()
+	}
+	b.semanticToolSelection = &semanticToolSelectionSetup{embedder: embedder, config: *config}
+	ensureToolSelectionEnabled(&b.config)
+	b.config.Control.ToolSelection = &types.ToolSelectionConfig{
+		Enabled:  true,
+		MaxTools: config.TopK,
+	}
+	return b
+}
+
 // WithPromptEnhancer 启用提示词增强
 func (b *AgentBuilder) WithPromptEnhancer(config *PromptEnhancerConfig) *AgentBuilder {
 	if config == nil {
@@ -235,6 +255,32 @@ func (b *AgentBuilder) WithPromptEnhancer(config *PromptEnhancerConfig) *AgentBu
 	return b
 }
 
+// WithBudget 配置每次运行与每个会话的 token/成本预算
+func (b *AgentBuilder) WithBudget(config types.BudgetConfig) *AgentBuilder {
+	b.config.Control.Budget = &config
+	return b
+}
+
+// WithSelfHealing 启用失败自愈：可重试的执行失败会先经 Reflexion 反思，再带着修正后的计划重试。
+func (b *AgentBuilder) WithSelfHealing(config SelfHealingConfig) *AgentBuilder {
+	if config.MaxRetries <= 0 {
+		b.errors = append(b.errors, fmt.Errorf("self-healing max retries must be positive"))
+		return b
+	}
+	b.selfHealing = &config
+	return b
+}
+
+// WithProfiles 启用按 用户+Agent 持久化的个性化画像：执行时注入画像，并定期从情节记忆蒸馏更新。
+func (b *AgentBuilder) WithProfiles(config ProfileConfig) *AgentBuilder {
+	if config.Store == nil {
+		b.errors = append(b.errors, fmt.Errorf("profile store is required"))
+		return b
+	}
+	b.profiles = &config
+	return b
+}
+
 // WithSkills 启用 Skills 系统
 func (b *AgentBuilder) WithSkills(discoverer SkillDiscoverer) *AgentBuilder {
 	b.skillsInstance = discoverer
@@ -384,6 +430,28 @@ func (b *AgentBuilder) WithTraceFeedbackPlanner(planner TraceFeedbackPlanner) *A
 	return b
 }
 
+// WithInterceptor 追加执行拦截器，包裹每次 Execute 与工具调用；先添加的位于外层。
+func (b *AgentBuilder) WithInterceptor(interceptor ExecutionInterceptor) *AgentBuilder {
+	if interceptor == nil {
+		b.errors = append(b.errors, fmt.Errorf("interceptor cannot be nil"))
+		return b
+	}
+	b.interceptors = append(b.interceptors, interceptor)
+	return b
+}
+
+// WithSkillPackages 在 Build 时把技能包安装到 Agent 的 SkillRegistry；任一安装失败则 Build 失败。
+func (b *AgentBuilder) WithSkillPackages(pkgs ...SkillPackage) *AgentBuilder {
+	for _, pkg := range pkgs {
+		if pkg.Manifest == nil {
+			b.errors = append(b.errors, fmt.Errorf("skill package manifest cannot be nil"))
+			continue
+		}
+		b.skillPackages = append(b.skillPackages, pkg)
+	}
+	return b
+}
+
 // Orchestrator returns the configured orchestrator runner (may be nil).
 func (b *AgentBuilder) Orchestrator() OrchestratorRunner {
 	return b.orchestratorInstance
@@ -425,6 +493,11 @@ func (b *AgentBuilder) Build() (*BaseAgent, error) {
 	b.enableConfiguredCoreFeatures(agent)
 	b.enableOptionalFeatures(agent)
 	b.finalizeAgent(agent)
+	for _, pkg := range b.skillPackages {
+		if err := agent.Skills().Install(pkg); err != nil {
+			return nil, fmt.Errorf("install skill %s: %w", pkg.Manifest.ID, err)
+		}
+	}
 	return agent, nil
 }
 
@@ -496,7 +569,10 @@ func (b *AgentBuilder) enableConfiguredCoreFeatures(agent *BaseAgent) {
 		agent.EnableReflection(AsReflectionRunner(reflectionExecutor))
 	}
 
-	if b.config.IsToolSelectionEnabled() && b.toolSelectionConfig != nil {
+	if b.config.IsToolSelectionEnabled() && b.semanticToolSelection != nil {
+		setup := b.semanticToolSelection
+		agent.EnableToolSelection(NewSemanticToolSelector(setup.embedder, setup.config, agent.Logger()))
+	} else if b.config.IsToolSelectionEnabled() && b.toolSelectionConfig != nil {
 		toolSelector := NewDynamicToolSelector(agent, *b.toolSelectionConfig)
 		agent.EnableToolSelection(AsToolSelectorRunner(toolSelector))
 	}
@@ -517,6 +593,15 @@ func (b *AgentBuilder) finalizeAgent(agent *BaseAgent) {
 	if b.memoryRuntime != nil {
 		agent.SetMemoryRuntime(b.memoryRuntime)
 	}
+	for _, interceptor := range b.interceptors {
+		agent.UseInterceptor(interceptor)
+	}
+	if b.selfHealing != nil {
+		agent.SetSelfHealing(b.selfHealing)
+	}
+	if b.profiles != nil {
+		agent.SetProfiles(b.profiles)
+	}
 	agent.SetReasoningModeSelector(NewDefaultReasoningModeSelector())
 	agent.SetCompletionJudge(NewDefaultCompletionJudge())
 }
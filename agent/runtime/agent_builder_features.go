@@ -154,6 +154,7 @@ func (b *BaseAgent) coreExecutor(options EnhancedExecutionOptions) ExecutionFunc
 			ReflectionEnabled: options.UseReflection && b.extensions.ReflectionExecutor() != nil,
 			CheckpointManager: b.checkpointManager,
 			Explainability:    explainabilityTimelineRecorder(b.extensions.ObservabilitySystemExt()),
+			PauseSignal:       pauseSignalFromContext(ctx),
 			TraceID:           strings.TrimSpace(input.TraceID),
 			AgentID:           b.ID(),
 			Logger:            b.logger,
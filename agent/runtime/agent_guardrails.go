@@ -1,9 +1,11 @@
 package runtime
 
 import (
+	"context"
 	"strings"
 
 	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
 	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
@@ -24,7 +26,12 @@ func runtimeGuardrailsFromTypes(cfg *types.GuardrailsConfig) *guardrails.Guardra
 		out.BlockedKeywords = append([]string(nil), cfg.BlockedKeywords...)
 	}
 	out.PIIDetectionEnabled = cfg.PIIDetection
+	if len(cfg.PIILocales) > 0 {
+		out.PIILocales = append([]string(nil), cfg.PIILocales...)
+	}
 	out.InjectionDetection = cfg.InjectionDetection
+	out.SecretsDetection = cfg.SecretsDetection
+	out.PolicyFile = cfg.PolicyFile
 	out.MaxRetries = cfg.MaxRetries
 	if v := strings.TrimSpace(cfg.OnInputFailure); v != "" {
 		out.OnInputFailure = guardrails.FailureAction(v)
@@ -44,7 +51,10 @@ func typesGuardrailsFromRuntime(cfg *guardrails.GuardrailsConfig) *types.Guardra
 		MaxInputLength:     cfg.MaxInputLength,
 		BlockedKeywords:    append([]string(nil), cfg.BlockedKeywords...),
 		PIIDetection:       cfg.PIIDetectionEnabled,
+		PIILocales:         append([]string(nil), cfg.PIILocales...),
 		InjectionDetection: cfg.InjectionDetection,
+		SecretsDetection:   cfg.SecretsDetection,
+		PolicyFile:         cfg.PolicyFile,
 		MaxRetries:         cfg.MaxRetries,
 		OnInputFailure:     string(cfg.OnInputFailure),
 		OnOutputFailure:    string(cfg.OnOutputFailure),
@@ -71,6 +81,7 @@ func NewGuardrailsCoordinator(config *guardrails.GuardrailsConfig, logger *zap.L
 
 func (b *BaseAgent) initGuardrails(cfg *guardrails.GuardrailsConfig) {
 	b.guardrailsEnabled = true
+	b.toolCallValidator = cfg.ToolCallValidator
 	b.inputValidatorChain = guardrails.NewValidatorChain(&guardrails.ValidatorChainConfig{
 		Mode: guardrails.ChainModeCollectAll,
 	})
@@ -93,13 +104,32 @@ func (b *BaseAgent) initGuardrails(cfg *guardrails.GuardrailsConfig) {
 		b.inputValidatorChain.Add(guardrails.NewInjectionDetector(nil))
 	}
 	if cfg.PIIDetectionEnabled {
-		b.inputValidatorChain.Add(guardrails.NewPIIDetector(nil))
+		b.inputValidatorChain.Add(guardrails.NewPIIDetector(cfg.PIIDetectorConfig()))
 	}
 	b.outputValidator = guardrails.NewOutputValidator(&guardrails.OutputValidatorConfig{
 		Validators:     cfg.OutputValidators,
 		Filters:        cfg.OutputFilters,
 		EnableAuditLog: true,
 	})
+	if cfg.SecretsDetection {
+		secrets := guardrails.NewSecretsValidator(nil)
+		b.outputValidator.AddValidator(secrets)
+		b.outputValidator.AddFilter(secrets)
+	}
+	if cfg.PolicyFile != "" {
+		engine := guardrails.NewPolicyEngine(&guardrails.PolicyEngineConfig{Logger: b.logger})
+		if err := engine.LoadFile(cfg.PolicyFile); err != nil {
+			b.logger.Error("failed to load guardrail policies", zap.String("file", cfg.PolicyFile), zap.Error(err))
+		} else {
+			b.inputValidatorChain.Add(engine)
+			b.outputValidator.AddValidator(engine)
+			b.outputValidator.AddFilter(engine)
+		}
+	}
+	if cfg.AuditLogger != nil {
+		b.guardrailAuditLogger = cfg.AuditLogger
+	}
+	b.applyGuardrailAuditLogger()
 	b.logger.Info("guardrails initialized",
 		zap.Int("input_validators", b.inputValidatorChain.Len()),
 		zap.Bool("pii_detection", cfg.PIIDetectionEnabled),
@@ -116,23 +146,59 @@ func (b *BaseAgent) SetGuardrails(cfg *guardrails.GuardrailsConfig) {
 		b.guardrailsEnabled = false
 		b.inputValidatorChain = nil
 		b.outputValidator = nil
+		b.toolCallValidator = nil
 		return
 	}
 	b.initGuardrails(cfg)
 }
 
+// SetGuardrailAuditLogger 设置护栏审计日志，已初始化的输入链与输出验证器立即生效
+func (b *BaseAgent) SetGuardrailAuditLogger(logger *guardrails.GuardrailAuditLogger) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.guardrailAuditLogger = logger
+	b.applyGuardrailAuditLogger()
+}
+
+// applyGuardrailAuditLogger 将审计日志挂到当前的输入链与输出验证器，调用方需持有 configMu
+func (b *BaseAgent) applyGuardrailAuditLogger() {
+	if b.guardrailAuditLogger == nil {
+		return
+	}
+	if b.inputValidatorChain != nil {
+		b.inputValidatorChain.SetAuditor(b.guardrailAuditLogger, guardrails.AuditDirectionInput)
+	}
+	if b.outputValidator != nil {
+		b.outputValidator.SetAuditLogger(b.guardrailAuditLogger)
+	}
+}
+
 func (b *BaseAgent) GuardrailsEnabled() bool {
 	b.configMu.RLock()
 	defer b.configMu.RUnlock()
 	return b.guardrailsEnabled
 }
 
+// SetToolCallValidator 设置工具调用参数校验器，nil 表示关闭
+func (b *BaseAgent) SetToolCallValidator(v *guardrails.ToolCallValidator) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.toolCallValidator = v
+}
+
+func (b *BaseAgent) currentToolCallValidator() *guardrails.ToolCallValidator {
+	b.configMu.RLock()
+	defer b.configMu.RUnlock()
+	return b.toolCallValidator
+}
+
 func (b *BaseAgent) AddInputValidator(v guardrails.Validator) {
 	b.configMu.Lock()
 	defer b.configMu.Unlock()
 	if b.inputValidatorChain == nil {
 		b.inputValidatorChain = guardrails.NewValidatorChain(nil)
 		b.guardrailsEnabled = true
+		b.applyGuardrailAuditLogger()
 	}
 	b.inputValidatorChain.Add(v)
 }
@@ -143,6 +209,7 @@ func (b *BaseAgent) AddOutputValidator(v guardrails.Validator) {
 	if b.outputValidator == nil {
 		b.outputValidator = guardrails.NewOutputValidator(nil)
 		b.guardrailsEnabled = true
+		b.applyGuardrailAuditLogger()
 	}
 	b.outputValidator.AddValidator(v)
 }
@@ -153,6 +220,67 @@ func (b *BaseAgent) AddOutputFilter(f guardrails.Filter) {
 	if b.outputValidator == nil {
 		b.outputValidator = guardrails.NewOutputValidator(nil)
 		b.guardrailsEnabled = true
+		b.applyGuardrailAuditLogger()
 	}
 	b.outputValidator.AddFilter(f)
 }
+
+// =============================================================================
+// Tool Call Validation
+// =============================================================================
+
+type validatingToolManager struct {
+	inner     ToolManager
+	validator *guardrails.ToolCallValidator
+}
+
+// NewValidatingToolManager 在工具执行前用 validator 校验每个工具调用，
+// 未通过（含审批被拒）的调用不会执行，直接返回错误结果。
+func NewValidatingToolManager(inner ToolManager, validator *guardrails.ToolCallValidator) ToolManager {
+	if inner == nil || validator == nil {
+		return inner
+	}
+	return &validatingToolManager{inner: inner, validator: validator}
+}
+
+func (m *validatingToolManager) GetAllowedTools(agentID string) []types.ToolSchema {
+	return m.inner.GetAllowedTools(agentID)
+}
+
+func (m *validatingToolManager) ExecuteForAgent(ctx context.Context, agentID string, calls []types.ToolCall) []llmtools.ToolResult {
+	results := make([]llmtools.ToolResult, len(calls))
+	allowed := make([]types.ToolCall, 0, len(calls))
+	allowedIdx := make([]int, 0, len(calls))
+	for i, call := range calls {
+		callCtx := types.WithToolName(types.WithAgentID(ctx, agentID), call.Name)
+		result, err := m.validator.ValidateToolCall(callCtx, call)
+		if err == nil && result.Valid {
+			allowed = append(allowed, call)
+			allowedIdx = append(allowedIdx, i)
+			continue
+		}
+		msg := "tool call rejected by guardrails"
+		if err != nil {
+			msg += ": " + err.Error()
+		} else if len(result.Errors) > 0 {
+			parts := make([]string, 0, len(result.Errors))
+			for _, e := range result.Errors {
+				parts = append(parts, e.Message)
+			}
+			msg += ": " + strings.Join(parts, "; ")
+		}
+		results[i] = llmtools.ToolResult{ToolCallID: call.ID, Name: call.Name, Error: msg}
+	}
+	if len(allowed) == 0 {
+		return results
+	}
+	executed := m.inner.ExecuteForAgent(ctx, agentID, allowed)
+	for j, idx := range allowedIdx {
+		if j < len(executed) {
+			results[idx] = executed[j]
+			continue
+		}
+		results[idx] = llmtools.ToolResult{ToolCallID: allowed[j].ID, Name: allowed[j].Name, Error: "tool result missing"}
+	}
+	return results
+}
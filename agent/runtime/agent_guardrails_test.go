@@ -1,6 +1,9 @@
 package runtime
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
@@ -154,3 +157,47 @@ func TestNewGuardrailsCoordinator(t *testing.T) {
 		t.Fatal("expected non-nil coordinator")
 	}
 }
+
+func TestValidatingToolManager(t *testing.T) {
+	validator, err := guardrails.NewToolCallValidator(&guardrails.ToolCallValidatorConfig{
+		Rules: []guardrails.ToolCallRule{{
+			Name:        "workspace",
+			Tools:       []string{"read_file"},
+			OnViolation: guardrails.ToolCallViolationReject,
+			Properties: map[string]guardrails.ToolArgumentConstraint{
+				"path": {Type: "string", PathPrefixes: []string{"/workspace"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewToolCallValidator: %v", err)
+	}
+	inner := &recordingToolManager{results: []types.ToolResult{
+		{ToolCallID: "ok-1", Name: "read_file", Result: json.RawMessage(`"a"`)},
+		{ToolCallID: "ok-2", Name: "search", Result: json.RawMessage(`"b"`)},
+	}}
+	mgr := NewValidatingToolManager(inner, validator)
+
+	results := mgr.ExecuteForAgent(context.Background(), "agent-1", []types.ToolCall{
+		{ID: "ok-1", Name: "read_file", Arguments: json.RawMessage(`{"path":"/workspace/a.txt"}`)},
+		{ID: "bad", Name: "read_file", Arguments: json.RawMessage(`{"path":"/etc/shadow"}`)},
+		{ID: "ok-2", Name: "search", Arguments: json.RawMessage(`{"q":"x"}`)},
+	})
+
+	if len(inner.calls) != 2 || inner.calls[0].ID != "ok-1" || inner.calls[1].ID != "ok-2" {
+		t.Fatalf("expected only allowed calls to reach the inner manager, got %+v", inner.calls)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].ToolCallID != "ok-1" || results[2].ToolCallID != "ok-2" {
+		t.Errorf("results out of order: %+v", results)
+	}
+	if results[1].ToolCallID != "bad" || !strings.Contains(results[1].Error, "/workspace") {
+		t.Errorf("expected rejected result for bad call, got %+v", results[1])
+	}
+
+	if got := NewValidatingToolManager(inner, nil); got != ToolManager(inner) {
+		t.Error("nil validator should return the inner manager")
+	}
+}
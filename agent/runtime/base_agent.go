@@ -109,6 +109,7 @@ const (
 	StopReasonValidationFailed         StopReason = agentcore.StopReasonValidationFailed
 	StopReasonToolFailureUnrecoverable StopReason = agentcore.StopReasonToolFailureUnrecoverable
 	StopReasonBlocked                  StopReason = agentcore.StopReasonBlocked
+	StopReasonPaused                   StopReason = agentcore.StopReasonPaused
 )
 
 // LoopDecision is the allowed next-step decision set produced after evaluation.
@@ -155,6 +156,7 @@ type LoopState struct {
 	LastError             string               `json:"last_error,omitempty"`
 	LastOutput            *Output              `json:"-"`
 	Observations          []LoopObservation    `json:"observations,omitempty"`
+	GoalProgress          *GoalProgress        `json:"goal_progress,omitempty"`
 	reflectionCritiques   []Critique
 }
 
@@ -253,6 +255,9 @@ func (s *LoopState) CheckpointVariables() map[string]any {
 	if len(s.Observations) > 0 {
 		variables["loop_observations"] = append([]LoopObservation(nil), s.Observations...)
 	}
+	if s.GoalProgress != nil {
+		variables["goal_progress"] = s.GoalProgress.clone()
+	}
 	return variables
 }
 
@@ -320,6 +325,9 @@ func (s *LoopState) restoreFromContext(values map[string]any) {
 	if s == nil || len(values) == 0 {
 		return
 	}
+	if progress, ok := goalProgressFromContext(values["goal_progress"]); ok {
+		s.GoalProgress = progress
+	}
 	if observations, ok := loopContextObservations(values, "loop_observations", "observations"); ok {
 		data := loopStateCheckpointCore(s)
 		data.Observations = checkpointCoreObservations(observations)
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 	types "github.com/BaSui01/agentflow/types"
 	zap "go.uber.org/zap"
@@ -76,6 +77,24 @@ func (b *BaseAgent) Teardown(ctx context.Context) error {
 	b.logger.Info("tearing down agent")
 	return b.extensions.TeardownExtensions(ctx)
 }
+// drainPollInterval Drain 轮询在途执行数的间隔
+const drainPollInterval = 20 * time.Millisecond
+
+// Drain 等待当前在途执行全部结束，ctx 结束时返回 ctx.Err()。
+// Agent 被热替换后由 CachingResolver 调用，确保旧实例在执行完成后才被 Teardown。
+func (b *BaseAgent) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&b.execCount) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
 // execLockWaitTimeout 短超时等待，避免并发请求直接返回 ErrAgentBusy
 const execLockWaitTimeout = 100 * time.Millisecond
 
@@ -156,6 +175,19 @@ func (b *BaseAgent) Config() types.AgentConfig { return b.config }
 
 // Logger 返回日志器
 func (b *BaseAgent) Logger() *zap.Logger { return b.logger }
+
+// DecisionGraph 返回指定追踪的决策图；未启用可观测性或追踪不存在时 ok 为 false
+func (b *BaseAgent) DecisionGraph(traceID string) (*DecisionGraph, bool) {
+	if b.extensions == nil {
+		return nil, false
+	}
+	reader, ok := b.extensions.ObservabilitySystemExt().(DecisionGraphReader)
+	if !ok {
+		return nil, false
+	}
+	return reader.DecisionGraph(traceID)
+}
+
 // ContextEngineEnabled 返回上下文工程是否启用
 func (b *BaseAgent) ContextEngineEnabled() bool {
 	return b.contextEngineEnabled
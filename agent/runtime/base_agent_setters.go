@@ -154,6 +154,14 @@ func (b *BaseAgent) SetCompletionJudge(judge CompletionJudge) {
 	b.completionJudge = judge
 }
 
+// SetGoalAssessor enables per-run goal tracking in the default loop executor.
+// Each execution decomposes its goal into sub-goals, reports progress after
+// every step, and lets sub-goal completion drive termination. Pass nil to disable.
+func (b *BaseAgent) SetGoalAssessor(assessor GoalAssessor, config GoalTrackerConfig) {
+	b.goalAssessor = assessor
+	b.goalTrackerConfig = config
+}
+
 // SetCheckpointManager stores the checkpoint manager used by the default loop executor.
 func (b *BaseAgent) SetCheckpointManager(manager *CheckpointManager) {
 	b.checkpointManager = manager
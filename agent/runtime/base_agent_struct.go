@@ -22,6 +22,7 @@ type BaseAgent struct {
 	config               types.AgentConfig
 	promptBundle         PromptBundle
 	runtimeGuardrailsCfg *guardrails.GuardrailsConfig
+	guardrailAuditLogger *guardrails.GuardrailAuditLogger
 	state                State
 	stateMu              sync.RWMutex
 	execSem              *semaphore.Weighted // 执行信号量，控制并发执行数（默认1）
@@ -58,6 +59,7 @@ type BaseAgent struct {
 	inputValidatorChain *guardrails.ValidatorChain
 	outputValidator     *guardrails.OutputValidator
 	guardrailsEnabled   bool
+	toolCallValidator   *guardrails.ToolCallValidator
 
 	// Composite sub-managers
 	extensions  *ExtensionRegistry
@@ -68,7 +70,16 @@ type BaseAgent struct {
 	reasoningRegistry *reasoning.PatternRegistry
 	reasoningSelector ReasoningModeSelector
 	completionJudge   CompletionJudge
+	goalAssessor      GoalAssessor
+	goalTrackerConfig GoalTrackerConfig
 	checkpointManager *CheckpointManager
+	pauses            pauseRegistry
+	interceptors      interceptorChain
+	budgetSessions    budgetSessions
+	budgetManager     BudgetManager
+	skillRegistry     SkillRegistry
+	selfHealing       *SelfHealingConfig
+	profiles          *profileRuntime
 	optionsResolver   ExecutionOptionsResolver
 	requestAdapter    agentadapters.ChatRequestAdapter
 	toolProtocol      ToolProtocolRuntime
@@ -173,6 +173,12 @@ func (b *BaseAgent) collectContextMemory(ctx context.Context, values map[string]
 
 	}
 
+	for _, snippet := range b.skillRegistry.memorySnippets() {
+
+		appendValue(snippet)
+
+	}
+
 	return memoryContext
 
 }
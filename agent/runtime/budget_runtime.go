@@ -0,0 +1,393 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// Budget scopes reported in BudgetState and BudgetExceededError.
+const (
+	BudgetScopeRun     = "run"
+	BudgetScopeSession = "session"
+)
+
+// BudgetManager is the subset of policy.TokenBudgetManager consulted as a
+// global budget alongside the per-run and per-session limits.
+type BudgetManager interface {
+	CheckBudget(ctx context.Context, estimatedTokens int, estimatedCost float64) error
+	RecordUsage(record policy.UsageRecord)
+}
+
+var _ BudgetManager = (*policy.TokenBudgetManager)(nil)
+
+// BudgetState reports the budget consumption of one run. It is attached to
+// Output.Metadata["budget"] and carried by BudgetExceededError.
+type BudgetState struct {
+	RunTokens     int                `json:"run_tokens"`
+	RunCost       float64            `json:"run_cost"`
+	SessionID     string             `json:"session_id,omitempty"`
+	SessionTokens int                `json:"session_tokens"`
+	SessionCost   float64            `json:"session_cost"`
+	LLMCalls      int                `json:"llm_calls"`
+	ToolCalls     int                `json:"tool_calls"`
+	SandboxCost   float64            `json:"sandbox_cost,omitempty"`
+	Exceeded      bool               `json:"exceeded"`
+	ExceededScope string             `json:"exceeded_scope,omitempty"`
+	Downgraded    bool               `json:"downgraded,omitempty"`
+	Model         string             `json:"model,omitempty"`
+	Limits        types.BudgetConfig `json:"limits"`
+}
+
+// BudgetExceededError is returned when a run or session budget is exhausted
+// and the run cannot be downgraded.
+type BudgetExceededError struct {
+	Scope string      `json:"scope"`
+	State BudgetState `json:"state"`
+}
+
+func (e *BudgetExceededError) Error() string {
+	tokens, cost := e.State.RunTokens, e.State.RunCost
+	if e.Scope == BudgetScopeSession {
+		tokens, cost = e.State.SessionTokens, e.State.SessionCost
+	}
+	return fmt.Sprintf("%s budget exceeded: tokens=%d cost=%.4f", e.Scope, tokens, cost)
+}
+
+type budgetUsage struct {
+	tokens int
+	cost   float64
+}
+
+// budgetSessions accumulates usage per session across runs.
+type budgetSessions struct {
+	mu    sync.Mutex
+	usage map[string]budgetUsage
+}
+
+func (s *budgetSessions) add(sessionID string, tokens int, cost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		s.usage = make(map[string]budgetUsage)
+	}
+	usage := s.usage[sessionID]
+	usage.tokens += tokens
+	usage.cost += cost
+	s.usage[sessionID] = usage
+}
+
+func (s *budgetSessions) get(sessionID string) budgetUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[sessionID]
+}
+
+func (s *budgetSessions) reset(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.usage, sessionID)
+}
+
+// SetBudgetManager installs a global token budget (typically a
+// *policy.TokenBudgetManager) that is checked before and charged after every
+// budgeted LLM call.
+func (b *BaseAgent) SetBudgetManager(manager BudgetManager) {
+	b.budgetManager = manager
+}
+
+// SessionBudgetUsage returns the tokens and cost charged to a session so far.
+func (b *BaseAgent) SessionBudgetUsage(sessionID string) (int, float64) {
+	usage := b.budgetSessions.get(sessionID)
+	return usage.tokens, usage.cost
+}
+
+// ResetBudgetSession clears the usage accumulated for a session.
+func (b *BaseAgent) ResetBudgetSession(sessionID string) {
+	b.budgetSessions.reset(sessionID)
+}
+
+// runBudget tracks consumption of one run against the configured limits.
+type runBudget struct {
+	cfg      types.BudgetConfig
+	sessions *budgetSessions
+	manager  BudgetManager
+	agentID  string
+
+	mu    sync.Mutex
+	state BudgetState
+}
+
+type runBudgetKey struct{}
+
+func withRunBudget(ctx context.Context, budget *runBudget) context.Context {
+	return context.WithValue(ctx, runBudgetKey{}, budget)
+}
+
+func runBudgetFromContext(ctx context.Context) *runBudget {
+	if ctx == nil {
+		return nil
+	}
+	budget, _ := ctx.Value(runBudgetKey{}).(*runBudget)
+	return budget
+}
+
+// startRunBudget installs a run budget into ctx when the agent has a budget
+// configured. Session usage is keyed by the input channel ID.
+func (b *BaseAgent) startRunBudget(ctx context.Context, input *Input) (context.Context, *runBudget) {
+	if input == nil {
+		return ctx, nil
+	}
+	cfg := b.executionOptionsResolver().Resolve(ctx, b.config, input).Control.Budget
+	if !cfg.IsEnabled() {
+		return ctx, nil
+	}
+	budget := &runBudget{
+		cfg:      *cfg,
+		sessions: &b.budgetSessions,
+		manager:  b.budgetManager,
+		agentID:  b.ID(),
+		state: BudgetState{
+			SessionID: strings.TrimSpace(input.ChannelID),
+			Limits:    *cfg,
+		},
+	}
+	return withRunBudget(ctx, budget), budget
+}
+
+// attach reports the budget state in output metadata.
+func (r *runBudget) attach(output *Output) {
+	if r == nil || output == nil {
+		return
+	}
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]any, 1)
+	}
+	output.Metadata["budget"] = r.snapshot()
+}
+
+func (r *runBudget) snapshot() BudgetState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshotLocked()
+}
+
+func (r *runBudget) snapshotLocked() BudgetState {
+	state := r.state
+	session := r.sessions.get(state.SessionID)
+	state.SessionTokens = session.tokens
+	state.SessionCost = session.cost
+	return state
+}
+
+func (r *runBudget) exceededScopeLocked() string {
+	if (r.cfg.MaxRunTokens > 0 && r.state.RunTokens >= r.cfg.MaxRunTokens) ||
+		(r.cfg.MaxRunCost > 0 && r.state.RunCost >= r.cfg.MaxRunCost) {
+		return BudgetScopeRun
+	}
+	if r.cfg.MaxSessionTokens > 0 || r.cfg.MaxSessionCost > 0 {
+		session := r.sessions.get(r.state.SessionID)
+		if (r.cfg.MaxSessionTokens > 0 && session.tokens >= r.cfg.MaxSessionTokens) ||
+			(r.cfg.MaxSessionCost > 0 && session.cost >= r.cfg.MaxSessionCost) {
+			return BudgetScopeSession
+		}
+	}
+	return ""
+}
+
+func (r *runBudget) canDowngrade() bool {
+	return strings.EqualFold(strings.TrimSpace(r.cfg.OnExceeded), types.BudgetActionDowngrade) &&
+		strings.TrimSpace(r.cfg.DowngradeModel) != ""
+}
+
+// admit is called before each LLM call. It returns the model override to use
+// ("" keeps the request model) or an error when the budget is exhausted.
+func (r *runBudget) admit(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	if scope := r.exceededScopeLocked(); scope != "" {
+		r.state.Exceeded = true
+		r.state.ExceededScope = scope
+		if !r.canDowngrade() {
+			err := &BudgetExceededError{Scope: scope, State: r.snapshotLocked()}
+			r.mu.Unlock()
+			return "", err
+		}
+		r.state.Downgraded = true
+		r.state.Model = strings.TrimSpace(r.cfg.DowngradeModel)
+	}
+	model := r.state.Model
+	r.mu.Unlock()
+
+	if r.manager != nil {
+		if err := r.manager.CheckBudget(ctx, 0, 0); err != nil {
+			return "", NewErrorWithCause(types.ErrQuotaExceeded, "token budget exceeded", err)
+		}
+	}
+	return model, nil
+}
+
+// exhausted reports whether tool and sandbox work must stop. Downgraded runs
+// keep executing tools; only their LLM calls switch model.
+func (r *runBudget) exhausted() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scope := r.exceededScopeLocked()
+	if scope == "" || r.canDowngrade() {
+		return nil
+	}
+	r.state.Exceeded = true
+	r.state.ExceededScope = scope
+	return &BudgetExceededError{Scope: scope, State: r.snapshotLocked()}
+}
+
+func (r *runBudget) recordLLM(ctx context.Context, provider, model string, usage types.ChatUsage) {
+	cost := defaultCostCalc.CalculateWithReasoning(provider, model, usage.PromptTokens, usage.CachedPromptTokens(), usage.CacheCreationPromptTokens(), usage.CompletionTokens, usage.ReasoningTokens())
+	r.mu.Lock()
+	r.state.LLMCalls++
+	r.state.RunTokens += usage.TotalTokens
+	r.state.RunCost += cost
+	r.mu.Unlock()
+	r.sessions.add(r.state.SessionID, usage.TotalTokens, cost)
+
+	if r.manager != nil {
+		runID, _ := types.RunID(ctx)
+		userID, _ := types.UserID(ctx)
+		r.manager.RecordUsage(policy.UsageRecord{
+			Timestamp: time.Now(),
+			Tokens:    usage.TotalTokens,
+			Cost:      cost,
+			Model:     model,
+			RequestID: runID,
+			UserID:    userID,
+			AgentID:   r.agentID,
+		})
+	}
+}
+
+// recordCost charges non-LLM spend such as sandbox compute.
+func (r *runBudget) recordCost(cost float64) {
+	r.mu.Lock()
+	r.state.RunCost += cost
+	r.state.SandboxCost += cost
+	r.mu.Unlock()
+	r.sessions.add(r.state.SessionID, 0, cost)
+}
+
+func (r *runBudget) recordToolCalls(n int) {
+	r.mu.Lock()
+	r.state.ToolCalls += n
+	r.mu.Unlock()
+}
+
+func (r *runBudget) wrapProvider(provider llm.Provider) llm.Provider {
+	if r == nil || provider == nil {
+		return provider
+	}
+	return budgetProvider{Provider: provider, budget: r}
+}
+
+// budgetProvider admits every LLM call against the run budget and charges the
+// reported usage afterwards.
+type budgetProvider struct {
+	llm.Provider
+	budget *runBudget
+}
+
+func (p budgetProvider) prepare(ctx context.Context, req *types.ChatRequest) (*types.ChatRequest, error) {
+	model, err := p.budget.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if model != "" && req != nil && req.Model != model {
+		downgraded := *req
+		downgraded.Model = model
+		req = &downgraded
+	}
+	return req, nil
+}
+
+func (p budgetProvider) Completion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	req, err := p.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Completion(ctx, req)
+	if resp != nil {
+		p.budget.recordLLM(ctx, resp.Provider, resp.Model, resp.Usage)
+	}
+	return resp, err
+}
+
+func (p budgetProvider) Stream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	req, err := p.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		var usage *types.ChatUsage
+		var provider, model string
+		defer func() {
+			if usage != nil {
+				p.budget.recordLLM(ctx, provider, model, *usage)
+			}
+		}()
+		for chunk := range upstream {
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+				provider, model = chunk.Provider, chunk.Model
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				for range upstream {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// wrapBudgetToolExecutor counts tool calls against the run budget and refuses
+// them once the budget is exhausted.
+func wrapBudgetToolExecutor(ctx context.Context, next llmtools.ToolExecutor) llmtools.ToolExecutor {
+	budget := runBudgetFromContext(ctx)
+	if budget == nil {
+		return next
+	}
+	return budgetToolExecutor{next: next, budget: budget}
+}
+
+type budgetToolExecutor struct {
+	next   llmtools.ToolExecutor
+	budget *runBudget
+}
+
+func (e budgetToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	if err := e.budget.exhausted(); err != nil {
+		results := make([]types.ToolResult, len(calls))
+		for i, call := range calls {
+			results[i] = types.ToolResult{ToolCallID: call.ID, Name: call.Name, Error: err.Error()}
+		}
+		return results
+	}
+	e.budget.recordToolCalls(len(calls))
+	return e.next.Execute(ctx, calls)
+}
+
+func (e budgetToolExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	return e.Execute(ctx, []types.ToolCall{call})[0]
+}
@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type usageRuntimeProvider struct {
+	captureRuntimeProvider
+	tokens int
+	models []string
+}
+
+func (p *usageRuntimeProvider) Completion(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
+	p.models = append(p.models, req.Model)
+	resp, err := p.captureRuntimeProvider.Completion(ctx, req)
+	if resp != nil {
+		resp.Usage = llmcore.ChatUsage{PromptTokens: p.tokens / 2, CompletionTokens: p.tokens - p.tokens/2, TotalTokens: p.tokens}
+	}
+	return resp, err
+}
+
+func newTestRunBudget(cfg types.BudgetConfig, sessions *budgetSessions, sessionID string) *runBudget {
+	return &runBudget{cfg: cfg, sessions: sessions, state: BudgetState{SessionID: sessionID, Limits: cfg}}
+}
+
+func TestRunBudgetAbortsOnceRunTokensAreExhausted(t *testing.T) {
+	budget := newTestRunBudget(types.BudgetConfig{MaxRunTokens: 100}, &budgetSessions{}, "")
+	provider := budget.wrapProvider(&usageRuntimeProvider{tokens: 120})
+	ctx := withRunBudget(context.Background(), budget)
+
+	_, err := provider.Completion(ctx, &llmcore.ChatRequest{Model: "gpt-4"})
+	require.NoError(t, err)
+	_, err = provider.Completion(ctx, &llmcore.ChatRequest{Model: "gpt-4"})
+	var exceeded *BudgetExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, BudgetScopeRun, exceeded.Scope)
+	assert.Equal(t, 120, exceeded.State.RunTokens)
+
+	results := wrapBudgetToolExecutor(ctx, stubToolExecutor{}).Execute(ctx, []types.ToolCall{{ID: "1", Name: "search"}})
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Error, "run budget exceeded")
+
+	state := budget.snapshot()
+	assert.Equal(t, 1, state.LLMCalls)
+	assert.Zero(t, state.ToolCalls)
+	assert.True(t, state.Exceeded)
+}
+
+func TestRunBudgetDowngradesModelWhenExceeded(t *testing.T) {
+	budget := newTestRunBudget(types.BudgetConfig{
+		MaxRunTokens:   100,
+		OnExceeded:     types.BudgetActionDowngrade,
+		DowngradeModel: "gpt-4o-mini",
+	}, &budgetSessions{}, "")
+	inner := &usageRuntimeProvider{tokens: 150}
+	provider := budget.wrapProvider(inner)
+	ctx := withRunBudget(context.Background(), budget)
+
+	for i := 0; i < 3; i++ {
+		_, err := provider.Completion(ctx, &llmcore.ChatRequest{Model: "gpt-4"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"gpt-4", "gpt-4o-mini", "gpt-4o-mini"}, inner.models)
+
+	results := wrapBudgetToolExecutor(ctx, stubToolExecutor{}).Execute(ctx, []types.ToolCall{{ID: "1", Name: "search"}})
+	assert.Empty(t, results[0].Error, "downgraded runs keep executing tools")
+
+	state := budget.snapshot()
+	assert.True(t, state.Downgraded)
+	assert.Equal(t, "gpt-4o-mini", state.Model)
+	assert.Equal(t, 450, state.RunTokens)
+	assert.Equal(t, 1, state.ToolCalls)
+}
+
+func TestRunBudgetSessionSpansRunsAndSandboxCost(t *testing.T) {
+	sessions := &budgetSessions{}
+	cfg := types.BudgetConfig{MaxSessionCost: 0.5}
+	first := newTestRunBudget(cfg, sessions, "chan-1")
+	ctx := withRunBudget(context.Background(), first)
+
+	meter := NewSandboxMeter(SandboxMeterConfig{Prices: SandboxPriceTable{PerCPUSecond: 1}}, zap.NewNop())
+	require.NoError(t, meter.CheckBudget(ctx))
+	meter.Meter(ctx, &ExecutionRequest{ID: "exec-1", Language: LangPython}, SandboxConfig{}, &ExecutionResult{
+		Resources: &ResourceUsage{CPUSeconds: 0.6},
+	})
+	assert.InDelta(t, 0.6, first.snapshot().SandboxCost, 1e-9)
+
+	second := newTestRunBudget(cfg, sessions, "chan-1")
+	ctx = withRunBudget(context.Background(), second)
+	err := meter.CheckBudget(ctx)
+	var exceeded *BudgetExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, BudgetScopeSession, exceeded.Scope)
+	assert.InDelta(t, 0.6, exceeded.State.SessionCost, 1e-9)
+
+	other := newTestRunBudget(cfg, sessions, "chan-2")
+	assert.NoError(t, other.exhausted())
+}
+
+func TestExecuteReportsBudgetStateInOutput(t *testing.T) {
+	cfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "budget-agent", Name: "Budget", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	provider := &usageRuntimeProvider{captureRuntimeProvider: captureRuntimeProvider{content: "done"}, tokens: 40}
+	ag, err := newAgentBuilder(cfg).
+		WithGateway(testGateway(provider)).
+		WithLogger(zap.NewNop()).
+		WithBudget(types.BudgetConfig{MaxRunTokens: 1000, MaxSessionTokens: 1000}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+
+	output, err := ag.Execute(context.Background(), &Input{TraceID: "trace-b", ChannelID: "chan-1", Content: "hello"})
+	require.NoError(t, err)
+	state, ok := output.Metadata["budget"].(BudgetState)
+	require.True(t, ok)
+	assert.Equal(t, "chan-1", state.SessionID)
+	assert.Positive(t, state.RunTokens)
+	assert.Equal(t, state.RunTokens, state.SessionTokens)
+
+	tokens, _ := ag.SessionBudgetUsage("chan-1")
+	assert.Equal(t, state.RunTokens, tokens)
+	ag.ResetBudgetSession("chan-1")
+	tokens, _ = ag.SessionBudgetUsage("chan-1")
+	assert.Zero(t, tokens)
+}
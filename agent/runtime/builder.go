@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	agentadapters "github.com/BaSui01/agentflow/agent/adapters"
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
 	"github.com/BaSui01/agentflow/agent/capabilities/memory"
 	"github.com/BaSui01/agentflow/agent/capabilities/reasoning"
 	skills "github.com/BaSui01/agentflow/agent/capabilities/tools"
@@ -59,8 +60,10 @@ type BuildOptions struct {
 	ChatRequestAdapter       agentadapters.ChatRequestAdapter
 	ToolProtocolRuntime      ToolProtocolRuntime
 	Authorize                AuthorizeFunc
+	GuardrailAuditLogger     *guardrails.GuardrailAuditLogger
 	ReasoningRuntime         ReasoningRuntime
 	ModelCatalog             *types.ModelCatalog
+	Interceptors             []ExecutionInterceptor
 
 	// Optional pass-throughs for AgentBuilder advanced wiring.
 	PromptStore       PromptStoreProvider
@@ -209,7 +212,13 @@ func (b *Builder) Build(ctx context.Context, cfg types.AgentConfig) (*BaseAgent,
 	ag.SetChatRequestAdapter(opts.ChatRequestAdapter)
 	ag.SetToolProtocolRuntime(opts.ToolProtocolRuntime)
 	ag.SetAuthorizeFunc(opts.Authorize)
+	if opts.GuardrailAuditLogger != nil {
+		ag.SetGuardrailAuditLogger(opts.GuardrailAuditLogger)
+	}
 	ag.SetReasoningRuntime(opts.ReasoningRuntime)
+	for _, interceptor := range opts.Interceptors {
+		ag.UseInterceptor(interceptor)
+	}
 	ag.SetPromptStore(opts.PromptStore)
 	ag.SetConversationStore(opts.ConversationStore)
 	ag.SetRunStore(opts.RunStore)
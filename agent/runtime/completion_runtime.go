@@ -83,6 +83,8 @@ func (b *BaseAgent) startReactStreaming(ctx context.Context, pr *preparedRequest
 	if toolProtocol.Authorize != nil {
 		toolExecutor = authorizedToolExecutor{prepared: toolProtocol}
 	}
+	toolExecutor = b.interceptors.wrapToolExecutor(toolExecutor)
+	toolExecutor = wrapBudgetToolExecutor(ctx, toolExecutor)
 	executor := llmtools.NewReActExecutor(
 		pr.toolProvider,
 		toolExecutor,
@@ -115,6 +117,18 @@ func (b *BaseAgent) handleReactStreamEvent(emit RuntimeStreamEmitter, pr *prepar
 		state.currentIteration = ev.Iteration
 	case llmtools.ReActEventLLMChunk:
 		emitReactLLMChunk(emit, state, ev)
+	case llmtools.ReActEventToolCallDelta:
+		emit(RuntimeStreamEvent{
+			Type:           RuntimeStreamToolCallDelta,
+			Timestamp:      time.Now(),
+			ToolCallID:     ev.ToolCallID,
+			ToolName:       ev.ToolName,
+			Data:           ev.ProgressData,
+			SDKEventType:   SDKRawResponseEvent,
+			CurrentStage:   "reasoning",
+			IterationCount: state.currentIteration,
+			SelectedMode:   state.selectedMode,
+		})
 	case llmtools.ReActEventToolsStart:
 		emitReactToolCalls(emit, pr, state, ev.ToolCalls)
 	case llmtools.ReActEventToolsEnd:
@@ -610,6 +624,14 @@ func (b *BaseAgent) chatCompletionWithTools(ctx context.Context, pr *preparedReq
 	if toolProtocol.Authorize != nil {
 		toolExecutor = authorizedToolExecutor{prepared: toolProtocol}
 	}
+	toolExecutor = b.interceptors.wrapToolExecutor(toolExecutor)
+	toolExecutor = wrapBudgetToolExecutor(ctx, toolExecutor)
+	if signal := pauseSignalFromContext(ctx); signal != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		toolExecutor = pausingToolExecutor{next: toolExecutor, signal: signal, cancel: cancel}
+	}
 	executor := llmtools.NewReActExecutor(
 		pr.toolProvider,
 		toolExecutor,
@@ -94,6 +94,31 @@ func TestFinalizeDirectStreamingResponseEmitsMessageAndLoopStop(t *testing.T) {
 	assert.Equal(t, "loop_stopped", events[2].Data.(map[string]any)["status"])
 }
 
+func TestHandleReactStreamEventForwardsToolCallDelta(t *testing.T) {
+	var events []RuntimeStreamEvent
+	state := &reactStreamingState{currentIteration: 2, selectedMode: "react"}
+	progress := llmcore.ToolCallProgress{Index: 0, ID: "call_2_1", Name: "search", Arguments: `{"q":"go`}
+
+	err := (&BaseAgent{}).handleReactStreamEvent(func(event RuntimeStreamEvent) {
+		events = append(events, event)
+	}, &preparedRequest{}, state, llmtools.ReActStreamEvent{
+		Type:         llmtools.ReActEventToolCallDelta,
+		Iteration:    2,
+		ToolCallID:   progress.ID,
+		ToolName:     progress.Name,
+		ProgressData: progress,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, RuntimeStreamToolCallDelta, events[0].Type)
+	assert.Equal(t, "call_2_1", events[0].ToolCallID)
+	assert.Equal(t, "search", events[0].ToolName)
+	assert.Equal(t, progress, events[0].Data)
+	assert.Equal(t, 2, events[0].IterationCount)
+	assert.Equal(t, types.RunEventToolCallDelta, events[0].RunEvent().Type)
+}
+
 func TestReactToolLoopBudgetDefaultsToExecutorBudget(t *testing.T) {
 	assert.Equal(t, 10, reactToolLoopBudget(nil))
 	assert.Equal(t, 10, reactToolLoopBudget(&preparedRequest{}))
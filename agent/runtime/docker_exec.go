@@ -26,6 +26,13 @@ func NewRealDockerBackend(logger *zap.Logger) *RealDockerBackend {
 	}
 }
 
+// NewRealDockerBackendWithConfig 使用自定义配置（如按 digest 固定镜像的 Catalog）创建真实 Docker 后端.
+func NewRealDockerBackendWithConfig(logger *zap.Logger, cfg DockerBackendConfig) *RealDockerBackend {
+	return &RealDockerBackend{
+		DockerBackend: NewDockerBackendWithConfig(logger, cfg),
+	}
+}
+
 // 执行在真正的多克容器中运行代码 。
 func (d *RealDockerBackend) Execute(ctx context.Context, req *ExecutionRequest, config SandboxConfig) (*ExecutionResult, error) {
 	start := time.Now()
@@ -37,11 +44,18 @@ func (d *RealDockerBackend) Execute(ctx context.Context, req *ExecutionRequest,
 	}
 
 	// 获取语言图像
-	image, ok := d.images[req.Language]
+	image, ok := d.imageFor(req)
 	if !ok {
 		result.Error = fmt.Sprintf("no image configured for language: %s", req.Language)
 		return result, nil
 	}
+	// 有 Setup 时从预置快照启动，跳过重复的依赖安装
+	image, err := d.snapshotImageFor(ctx, req, image)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to prepare sandbox snapshot: %v", err)
+		result.Duration = time.Since(start)
+		return result, nil
+	}
 
 	// 生成唯一容器名称
 	containerName := fmt.Sprintf("%s%s_%d", d.containerPrefix, sanitizeID(req.ID), time.Now().UnixNano())
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
 	"go.uber.org/zap"
 )
 
@@ -47,6 +48,8 @@ type SandboxConfig struct {
 	EnvVars          map[string]string `json:"env_vars,omitempty"`
 	MaxOutputBytes   int               `json:"max_output_bytes"`
 	AllowedLanguages []Language        `json:"allowed_languages"`
+	// Presets overrides timeout/memory/CPU per language; see DefaultResourcePresets.
+	Presets map[Language]ResourcePreset `json:"presets,omitempty"`
 }
 
 // DefaultSandboxConfig returns secure defaults for code execution.
@@ -72,6 +75,12 @@ type ExecutionRequest struct {
 	EnvVars  map[string]string `json:"env_vars,omitempty"`
 	Files    map[string]string `json:"files,omitempty"`
 	Timeout  time.Duration     `json:"timeout,omitempty"`
+	// Setup prepares the environment (dependency install, data load) before Code runs.
+	// Docker backends with a SnapshotManager snapshot the prepared sandbox once per
+	// SnapshotScope and restore it for later runs instead of repeating Setup.
+	Setup string `json:"setup,omitempty"`
+	// SnapshotScope is the session or workflow ID sharing the prepared snapshot.
+	SnapshotScope string `json:"snapshot_scope,omitempty"`
 }
 
 // ExecutionResult is the result of running code in a sandbox.
@@ -85,6 +94,15 @@ type ExecutionResult struct {
 	Duration   time.Duration `json:"duration"`
 	MemoryUsed int64         `json:"memory_used_bytes,omitempty"`
 	Truncated  bool          `json:"truncated,omitempty"`
+	// Files holds generated output files keyed by path; backends may collect them
+	// so they are scanned before being returned or stored as artifacts.
+	Files map[string]string `json:"files,omitempty"`
+	// Quarantine is set when the output scan stage withheld the outputs.
+	Quarantine *QuarantineInfo `json:"quarantine,omitempty"`
+	// Resources is the metered compute usage; backends may pre-fill measured values.
+	Resources *ResourceUsage `json:"resources,omitempty"`
+	// Cost is set when the executor has a SandboxMeter.
+	Cost *CostBreakdown `json:"cost,omitempty"`
 }
 
 // ExecutionBackend abstracts a sandbox execution backend.
@@ -109,6 +127,8 @@ type SandboxExecutor struct {
 	backend   ExecutionBackend
 	validator *SandboxCodeValidator
 	logger    *zap.Logger
+	meter     *SandboxMeter
+	scanner   *OutputScanStage
 	mu        sync.RWMutex
 	stats     ExecutorStats
 }
@@ -126,6 +146,20 @@ func NewSandboxExecutor(config SandboxConfig, backend ExecutionBackend, logger *
 	}
 }
 
+// SetMeter enables per-execution cost accounting. Passing nil disables it.
+func (s *SandboxExecutor) SetMeter(meter *SandboxMeter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meter = meter
+}
+
+// SetOutputScanner enables post-execution scanning of outputs. Passing nil disables it.
+func (s *SandboxExecutor) SetOutputScanner(stage *OutputScanStage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanner = stage
+}
+
 // Execute validates, times, and executes a request using the configured backend.
 func (s *SandboxExecutor) Execute(ctx context.Context, req *ExecutionRequest) (*ExecutionResult, error) {
 	start := time.Now()
@@ -155,10 +189,21 @@ func (s *SandboxExecutor) Execute(ctx context.Context, req *ExecutionRequest) (*
 		)
 	}
 
-	execCtx, cancel := withExecutionTimeout(ctx, s.config.Timeout, req.Timeout)
+	s.mu.RLock()
+	meter := s.meter
+	scanner := s.scanner
+	s.mu.RUnlock()
+	if meter != nil {
+		if err := meter.CheckBudget(ctx); err != nil {
+			return recordFailure(err, false)
+		}
+	}
+
+	config := s.config.ForLanguage(req.Language)
+	execCtx, cancel := withExecutionTimeout(ctx, config.Timeout, req.Timeout)
 	defer cancel()
 
-	result, err := s.backend.Execute(execCtx, req, s.config)
+	result, err := s.backend.Execute(execCtx, req, config)
 	timeout := execCtx.Err() == context.DeadlineExceeded
 	if err != nil {
 		return recordFailure(err, timeout)
@@ -167,12 +212,21 @@ func (s *SandboxExecutor) Execute(ctx context.Context, req *ExecutionRequest) (*
 		return recordFailure(fmt.Errorf("sandbox backend returned nil result"), timeout)
 	}
 
+	if scanner != nil {
+		result, err = scanner.Scan(ctx, result)
+		if err != nil {
+			return recordFailure(err, timeout)
+		}
+	}
 	s.truncateOutput(result)
 
 	elapsed := time.Since(start)
 	if result.Duration <= 0 {
 		result.Duration = elapsed
 	}
+	if meter != nil {
+		meter.Meter(ctx, req, config, result)
+	}
 	s.recordExecution(elapsed, result.Success, timeout)
 	return result, nil
 }
@@ -261,11 +315,18 @@ type DockerBackendConfig struct {
 	ContainerPrefix string
 	CleanupOnExit   bool
 	CustomImages    map[Language]string
+	// Catalog, when set, supplies digest-pinned images and takes precedence
+	// over CustomImages for the languages it covers.
+	Catalog *ImageCatalog
+	// Snapshots, when set, serves requests with Setup from prepared snapshots.
+	Snapshots *SnapshotManager
 }
 
 // DockerBackend executes code inside docker containers.
 type DockerBackend struct {
 	images           map[Language]string
+	catalog          *ImageCatalog
+	snapshots        *SnapshotManager
 	logger           *zap.Logger
 	containerPrefix  string
 	cleanupOnExit    bool
@@ -299,6 +360,8 @@ func NewDockerBackendWithConfig(logger *zap.Logger, cfg DockerBackendConfig) *Do
 
 	return &DockerBackend{
 		images:           images,
+		catalog:          cfg.Catalog,
+		snapshots:        cfg.Snapshots,
 		logger:           logger.With(zap.String("component", "docker_backend")),
 		containerPrefix:  prefix,
 		cleanupOnExit:    cfg.CleanupOnExit || cfg.ContainerPrefix == "",
@@ -325,7 +388,7 @@ func (d *DockerBackend) Execute(ctx context.Context, req *ExecutionRequest, conf
 		ExitCode: -1,
 	}
 
-	image, ok := d.images[req.Language]
+	image, ok := d.imageFor(req)
 	if !ok {
 		result.Error = fmt.Sprintf("no image configured for language: %s", req.Language)
 		result.Duration = time.Since(start)
@@ -362,6 +425,38 @@ func (d *DockerBackend) Execute(ctx context.Context, req *ExecutionRequest, conf
 	return result, nil
 }
 
+// imageFor resolves the image reference for a request, preferring the pinned catalog.
+func (d *DockerBackend) imageFor(req *ExecutionRequest) (string, bool) {
+	if d.catalog != nil {
+		if pin, ok := d.catalog.Resolve(req.Language, req.ID); ok {
+			return pin.Reference(), true
+		}
+	}
+	image, ok := d.images[req.Language]
+	return image, ok
+}
+
+// snapshotImageFor returns the prepared snapshot image for a request with Setup,
+// creating the snapshot on first use; requests without Setup keep the base image.
+func (d *DockerBackend) snapshotImageFor(ctx context.Context, req *ExecutionRequest, baseImage string) (string, error) {
+	if strings.TrimSpace(req.Setup) == "" {
+		return baseImage, nil
+	}
+	if d.snapshots == nil {
+		return "", fmt.Errorf("setup requires a sandbox snapshot manager")
+	}
+	snap, err := d.snapshots.Acquire(ctx, SnapshotSpec{
+		Scope:     req.SnapshotScope,
+		Language:  req.Language,
+		BaseImage: baseImage,
+		Setup:     req.Setup,
+	})
+	if err != nil {
+		return "", err
+	}
+	return snap.Image, nil
+}
+
 func (d *DockerBackend) buildDockerArgs(containerName, image string, req *ExecutionRequest, config SandboxConfig, codeMountDir string) []string {
 	args := []string{
 		"run",
@@ -667,10 +762,12 @@ func findPattern(s, pattern string) int {
 type SandboxTool struct {
 	executor  *SandboxExecutor
 	validator *SandboxCodeValidator
+	secrets   *guardrails.SecretsValidator
 	logger    *zap.Logger
 }
 
 // NewSandboxTool creates a sandbox tool wrapper.
+// Credentials printed by executed code are masked in stdout/stderr by default.
 func NewSandboxTool(executor *SandboxExecutor, logger *zap.Logger) *SandboxTool {
 	if logger == nil {
 		logger = zap.NewNop()
@@ -678,10 +775,17 @@ func NewSandboxTool(executor *SandboxExecutor, logger *zap.Logger) *SandboxTool
 	return &SandboxTool{
 		executor:  executor,
 		validator: NewSandboxCodeValidator(),
+		secrets:   guardrails.NewSecretsValidator(nil),
 		logger:    logger.With(zap.String("component", "sandbox_tool")),
 	}
 }
 
+// SetSecretsValidator replaces the validator applied to execution output.
+// Passing nil disables secret scanning.
+func (t *SandboxTool) SetSecretsValidator(v *guardrails.SecretsValidator) {
+	t.secrets = v
+}
+
 // Execute decodes a request, validates code, and runs the sandbox.
 func (t *SandboxTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
 	if t.executor == nil {
@@ -701,9 +805,37 @@ func (t *SandboxTool) Execute(ctx context.Context, args json.RawMessage) (json.R
 	if err != nil {
 		return nil, err
 	}
+	if t.secrets != nil {
+		result.Stdout = t.scanSecrets(ctx, "stdout", result.Stdout)
+		result.Stderr = t.scanSecrets(ctx, "stderr", result.Stderr)
+	}
 	return json.Marshal(result)
 }
 
+// scanSecrets applies the secrets validator action to one output stream.
+func (t *SandboxTool) scanSecrets(ctx context.Context, stream, content string) string {
+	if content == "" {
+		return content
+	}
+	vr, err := t.secrets.Validate(ctx, content)
+	if err != nil || vr == nil || (vr.Valid && len(vr.Warnings) == 0) {
+		return content
+	}
+	t.logger.Warn("credentials detected in sandbox output",
+		zap.String("stream", stream),
+		zap.String("action", string(t.secrets.GetAction())),
+		zap.Any("types", vr.Metadata["secret_types"]),
+	)
+	switch t.secrets.GetAction() {
+	case guardrails.SecretsActionBlock:
+		return "[output withheld: contains credentials]"
+	case guardrails.SecretsActionMask:
+		return t.secrets.Mask(content)
+	default:
+		return content
+	}
+}
+
 // execCommand wraps os/exec for deterministic testing.
 type execCommand struct {
 	cmd      string
@@ -0,0 +1,483 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// SubGoalStatus is the completion status of a tracked sub-goal.
+type SubGoalStatus string
+
+const (
+	SubGoalPending    SubGoalStatus = "pending"
+	SubGoalInProgress SubGoalStatus = "in_progress"
+	SubGoalDone       SubGoalStatus = "done"
+	SubGoalBlocked    SubGoalStatus = "blocked"
+)
+
+func (s SubGoalStatus) valid() bool {
+	switch s {
+	case SubGoalPending, SubGoalInProgress, SubGoalDone, SubGoalBlocked:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubGoal is one tracked piece of the user's objective.
+type SubGoal struct {
+	ID               string        `json:"id"`
+	Description      string        `json:"description"`
+	Status           SubGoalStatus `json:"status"`
+	Progress         float64       `json:"progress,omitempty"` // partial completion in [0,1]
+	Blocker          string        `json:"blocker,omitempty"`
+	Evidence         string        `json:"evidence,omitempty"`
+	UpdatedIteration int           `json:"updated_iteration,omitempty"`
+}
+
+// SubGoalUpdate is an assessor-reported change to a sub-goal.
+type SubGoalUpdate struct {
+	ID       string        `json:"id"`
+	Status   SubGoalStatus `json:"status"`
+	Progress float64       `json:"progress,omitempty"`
+	Blocker  string        `json:"blocker,omitempty"`
+	Evidence string        `json:"evidence,omitempty"`
+}
+
+// GoalProgress is a snapshot of how far along the run is.
+type GoalProgress struct {
+	Goal              string    `json:"goal"`
+	SubGoals          []SubGoal `json:"sub_goals"`
+	PercentComplete   float64   `json:"percent_complete"`
+	Blockers          []string  `json:"blockers,omitempty"`
+	StalledIterations int       `json:"stalled_iterations,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Completed reports whether every sub-goal is done.
+func (p *GoalProgress) Completed() bool {
+	if p == nil || len(p.SubGoals) == 0 {
+		return false
+	}
+	for _, sub := range p.SubGoals {
+		if sub.Status != SubGoalDone {
+			return false
+		}
+	}
+	return true
+}
+
+// Blocked reports whether every unfinished sub-goal is blocked, i.e. no
+// further progress is possible without outside help.
+func (p *GoalProgress) Blocked() bool {
+	if p == nil {
+		return false
+	}
+	remaining := 0
+	for _, sub := range p.SubGoals {
+		switch sub.Status {
+		case SubGoalDone:
+		case SubGoalBlocked:
+			remaining++
+		default:
+			return false
+		}
+	}
+	return remaining > 0
+}
+
+// Remaining returns the descriptions of sub-goals that are not done.
+func (p *GoalProgress) Remaining() []string {
+	if p == nil {
+		return nil
+	}
+	out := make([]string, 0, len(p.SubGoals))
+	for _, sub := range p.SubGoals {
+		if sub.Status != SubGoalDone {
+			out = append(out, sub.Description)
+		}
+	}
+	return out
+}
+
+func (p GoalProgress) clone() GoalProgress {
+	p.SubGoals = append([]SubGoal(nil), p.SubGoals...)
+	p.Blockers = cloneStringSlice(p.Blockers)
+	return p
+}
+
+// GoalAssessor decomposes goals and judges sub-goal completion.
+type GoalAssessor interface {
+	// Decompose splits goal into ordered sub-goal descriptions.
+	Decompose(ctx context.Context, goal string) ([]string, error)
+	// Assess reports status changes for subGoals after a loop step.
+	Assess(ctx context.Context, goal string, subGoals []SubGoal, output *Output, execErr error) ([]SubGoalUpdate, error)
+}
+
+// GoalTrackerConfig configures a GoalTracker.
+type GoalTrackerConfig struct {
+	// MaxSubGoals caps the decomposition size. Defaults to 8.
+	MaxSubGoals int `json:"max_sub_goals,omitempty"`
+	// StallThreshold is the number of consecutive iterations without progress
+	// after which the tracker reports a stall blocker. Defaults to 3.
+	StallThreshold int `json:"stall_threshold,omitempty"`
+}
+
+// GoalTracker tracks sub-goal completion for a single run.
+type GoalTracker struct {
+	mu       sync.RWMutex
+	assessor GoalAssessor
+	config   GoalTrackerConfig
+	progress *GoalProgress
+	now      func() time.Time
+}
+
+// NewGoalTracker creates a tracker backed by assessor.
+func NewGoalTracker(assessor GoalAssessor, config GoalTrackerConfig) *GoalTracker {
+	if config.MaxSubGoals <= 0 {
+		config.MaxSubGoals = 8
+	}
+	if config.StallThreshold <= 0 {
+		config.StallThreshold = 3
+	}
+	return &GoalTracker{assessor: assessor, config: config, now: time.Now}
+}
+
+// Start decomposes goal into sub-goals. A restored snapshot for the same goal
+// is adopted as-is so resumed runs keep their progress. If decomposition
+// fails, the goal itself becomes the only sub-goal and the error is returned
+// for logging; tracking still proceeds.
+func (t *GoalTracker) Start(ctx context.Context, goal string, restored *GoalProgress) (GoalProgress, error) {
+	goal = strings.TrimSpace(goal)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if restored != nil && len(restored.SubGoals) > 0 && strings.TrimSpace(restored.Goal) == goal {
+		snapshot := restored.clone()
+		t.progress = &snapshot
+		return snapshot.clone(), nil
+	}
+
+	var descriptions []string
+	var err error
+	if t.assessor != nil {
+		descriptions, err = t.assessor.Decompose(ctx, goal)
+	}
+	subGoals := make([]SubGoal, 0, len(descriptions))
+	for _, description := range descriptions {
+		description = strings.TrimSpace(description)
+		if description == "" {
+			continue
+		}
+		if len(subGoals) >= t.config.MaxSubGoals {
+			break
+		}
+		subGoals = append(subGoals, SubGoal{
+			ID:          fmt.Sprintf("g%d", len(subGoals)+1),
+			Description: description,
+			Status:      SubGoalPending,
+		})
+	}
+	if len(subGoals) == 0 {
+		subGoals = []SubGoal{{ID: "g1", Description: goal, Status: SubGoalPending}}
+	}
+	t.progress = &GoalProgress{Goal: goal, SubGoals: subGoals, UpdatedAt: t.now()}
+	t.recompute()
+	return t.progress.clone(), err
+}
+
+// Update asks the assessor for sub-goal changes after a loop step and
+// recomputes progress. On assessor failure the previous snapshot is kept,
+// although stall accounting still advances.
+func (t *GoalTracker) Update(ctx context.Context, iteration int, output *Output, execErr error) (GoalProgress, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.progress == nil {
+		return GoalProgress{}, errors.New("goal tracker not started")
+	}
+	previous := t.progress.PercentComplete
+	var assessErr error
+	if t.assessor != nil {
+		var updates []SubGoalUpdate
+		updates, assessErr = t.assessor.Assess(ctx, t.progress.Goal, append([]SubGoal(nil), t.progress.SubGoals...), output, execErr)
+		if assessErr == nil {
+			t.apply(updates, iteration)
+		}
+	}
+	t.recompute()
+	if t.progress.PercentComplete > previous || t.progress.Completed() {
+		t.progress.StalledIterations = 0
+	} else {
+		t.progress.StalledIterations++
+	}
+	t.recompute()
+	t.progress.UpdatedAt = t.now()
+	return t.progress.clone(), assessErr
+}
+
+// Progress returns the latest snapshot.
+func (t *GoalTracker) Progress() (GoalProgress, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.progress == nil {
+		return GoalProgress{}, false
+	}
+	return t.progress.clone(), true
+}
+
+func (t *GoalTracker) apply(updates []SubGoalUpdate, iteration int) {
+	index := make(map[string]int, len(t.progress.SubGoals))
+	for i, sub := range t.progress.SubGoals {
+		index[sub.ID] = i
+	}
+	for _, update := range updates {
+		i, ok := index[strings.TrimSpace(update.ID)]
+		if !ok || !update.Status.valid() {
+			continue
+		}
+		sub := &t.progress.SubGoals[i]
+		sub.Status = update.Status
+		sub.Progress = clampUnit(update.Progress)
+		if update.Status == SubGoalDone {
+			sub.Progress = 1
+		}
+		sub.Blocker = ""
+		if update.Status == SubGoalBlocked {
+			sub.Blocker = strings.TrimSpace(update.Blocker)
+		}
+		if evidence := strings.TrimSpace(update.Evidence); evidence != "" {
+			sub.Evidence = evidence
+		}
+		sub.UpdatedIteration = iteration
+	}
+}
+
+func (t *GoalTracker) recompute() {
+	p := t.progress
+	total := 0.0
+	p.Blockers = nil
+	for _, sub := range p.SubGoals {
+		if sub.Status == SubGoalDone {
+			total++
+		} else {
+			total += clampUnit(sub.Progress)
+		}
+		if sub.Status == SubGoalBlocked {
+			blocker := sub.Blocker
+			if blocker == "" {
+				blocker = "blocked"
+			}
+			p.Blockers = append(p.Blockers, sub.Description+": "+blocker)
+		}
+	}
+	if len(p.SubGoals) > 0 {
+		p.PercentComplete = math.Round(total/float64(len(p.SubGoals))*1000) / 10
+	}
+	if p.StalledIterations >= t.config.StallThreshold {
+		p.Blockers = append(p.Blockers, fmt.Sprintf("no progress for %d iterations", p.StalledIterations))
+	}
+}
+
+func clampUnit(v float64) float64 {
+	switch {
+	case math.IsNaN(v) || v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
+
+// ====== LLM assessor ======
+
+// GoalChatFunc sends messages to a chat model.
+type GoalChatFunc func(ctx context.Context, messages []types.Message) (*types.ChatResponse, error)
+
+// LLMGoalAssessor decomposes and assesses goals with a chat model that
+// replies in JSON.
+type LLMGoalAssessor struct {
+	chat GoalChatFunc
+	// MaxOutputChars truncates the step output sent for assessment. Defaults to 4000.
+	MaxOutputChars int
+}
+
+// NewLLMGoalAssessor creates an LLM-backed GoalAssessor.
+func NewLLMGoalAssessor(chat GoalChatFunc) *LLMGoalAssessor {
+	return &LLMGoalAssessor{chat: chat, MaxOutputChars: 4000}
+}
+
+const goalDecomposePrompt = `Break the following objective into 2-6 concrete, independently verifiable sub-goals, in execution order.
+Reply with JSON only: {"sub_goals": ["...", "..."]}
+
+Objective:
+%s`
+
+const goalAssessPrompt = `You track progress toward an objective. Given the sub-goals and the latest step result, report every sub-goal whose status changed.
+Statuses: pending, in_progress, done, blocked. "progress" is partial completion between 0 and 1. Give a short "blocker" for blocked sub-goals and short "evidence" for done ones.
+Reply with JSON only: {"updates": [{"id": "g1", "status": "done", "progress": 1, "blocker": "", "evidence": "..."}]}
+
+Objective:
+%s
+
+Sub-goals:
+%s
+
+Latest step output:
+%s
+
+Latest step error:
+%s`
+
+// Decompose implements GoalAssessor.
+func (a *LLMGoalAssessor) Decompose(ctx context.Context, goal string) ([]string, error) {
+	var reply struct {
+		SubGoals []string `json:"sub_goals"`
+	}
+	if err := a.ask(ctx, fmt.Sprintf(goalDecomposePrompt, goal), &reply); err != nil {
+		return nil, fmt.Errorf("decompose goal: %w", err)
+	}
+	return reply.SubGoals, nil
+}
+
+// Assess implements GoalAssessor.
+func (a *LLMGoalAssessor) Assess(ctx context.Context, goal string, subGoals []SubGoal, output *Output, execErr error) ([]SubGoalUpdate, error) {
+	subGoalsJSON, err := json.Marshal(subGoals)
+	if err != nil {
+		return nil, err
+	}
+	content := "(none)"
+	if output != nil && strings.TrimSpace(output.Content) != "" {
+		content = output.Content
+		if limit := a.MaxOutputChars; limit > 0 && len(content) > limit {
+			content = content[:limit] + "..."
+		}
+	}
+	errText := "(none)"
+	if execErr != nil {
+		errText = execErr.Error()
+	}
+	var reply struct {
+		Updates []SubGoalUpdate `json:"updates"`
+	}
+	if err := a.ask(ctx, fmt.Sprintf(goalAssessPrompt, goal, subGoalsJSON, content, errText), &reply); err != nil {
+		return nil, fmt.Errorf("assess goal progress: %w", err)
+	}
+	return reply.Updates, nil
+}
+
+func (a *LLMGoalAssessor) ask(ctx context.Context, prompt string, out any) error {
+	if a == nil || a.chat == nil {
+		return errors.New("goal assessor chat function is nil")
+	}
+	resp, err := a.chat(ctx, []types.Message{{Role: llm.RoleUser, Content: prompt}})
+	if err != nil {
+		return err
+	}
+	choice, err := llm.FirstChoice(resp)
+	if err != nil {
+		return err
+	}
+	raw := extractJSONObject(choice.Message.Content)
+	if raw == "" {
+		return errors.New("response contains no JSON object")
+	}
+	return json.Unmarshal([]byte(raw), out)
+}
+
+// extractJSONObject returns the outermost {...} span, tolerating code fences
+// and surrounding prose.
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
+// ====== termination ======
+
+// GoalAwareCompletionJudge feeds goal progress into termination decisions.
+// It stops the loop once every sub-goal is done, escalates to a human when
+// all remaining sub-goals are blocked, and keeps iterating when the inner
+// judge declares success while sub-goals remain and budget is left.
+type GoalAwareCompletionJudge struct {
+	inner CompletionJudge
+}
+
+// NewGoalAwareCompletionJudge wraps inner; a nil inner uses the default judge.
+func NewGoalAwareCompletionJudge(inner CompletionJudge) *GoalAwareCompletionJudge {
+	if inner == nil {
+		inner = NewDefaultCompletionJudge()
+	}
+	return &GoalAwareCompletionJudge{inner: inner}
+}
+
+// Judge implements CompletionJudge.
+func (j *GoalAwareCompletionJudge) Judge(ctx context.Context, state *LoopState, output *Output, err error) (*CompletionDecision, error) {
+	decision, judgeErr := j.inner.Judge(ctx, state, output, err)
+	if judgeErr != nil || decision == nil || state == nil || state.GoalProgress == nil {
+		return decision, judgeErr
+	}
+	progress := state.GoalProgress
+	switch {
+	case progress.Completed() && err == nil && (decision.Decision == LoopDecisionContinue || decision.Decision == LoopDecisionReplan):
+		return &CompletionDecision{
+			Solved:     true,
+			Decision:   LoopDecisionDone,
+			StopReason: StopReasonSolved,
+			Confidence: math.Max(decision.Confidence, 0.9),
+			Reason:     "all sub-goals complete",
+		}, nil
+	case progress.Blocked() && decision.Decision != LoopDecisionDone:
+		return &CompletionDecision{
+			NeedHuman:  true,
+			Decision:   LoopDecisionEscalate,
+			StopReason: StopReasonBlocked,
+			Confidence: decision.Confidence,
+			Reason:     "all remaining sub-goals blocked: " + strings.Join(progress.Blockers, "; "),
+		}, nil
+	case decision.Decision == LoopDecisionDone && decision.Solved && !progress.Completed() && state.Iteration < state.MaxIterations:
+		return &CompletionDecision{
+			Decision:   LoopDecisionContinue,
+			Confidence: decision.Confidence,
+			Reason:     "sub-goals remaining: " + strings.Join(progress.Remaining(), "; "),
+		}, nil
+	}
+	return decision, nil
+}
+
+// goalProgressFromContext decodes a checkpointed goal_progress value.
+func goalProgressFromContext(raw any) (*GoalProgress, bool) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, false
+	case GoalProgress:
+		copied := v.clone()
+		return &copied, true
+	case *GoalProgress:
+		if v == nil {
+			return nil, false
+		}
+		copied := v.clone()
+		return &copied, true
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var progress GoalProgress
+	if err := json.Unmarshal(data, &progress); err != nil || len(progress.SubGoals) == 0 {
+		return nil, false
+	}
+	return &progress, true
+}
@@ -0,0 +1,184 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type scriptedGoalAssessor struct {
+	subGoals     []string
+	decomposeErr error
+	updates      [][]SubGoalUpdate
+	calls        int
+}
+
+func (s *scriptedGoalAssessor) Decompose(context.Context, string) ([]string, error) {
+	return s.subGoals, s.decomposeErr
+}
+
+func (s *scriptedGoalAssessor) Assess(context.Context, string, []SubGoal, *Output, error) ([]SubGoalUpdate, error) {
+	if s.calls >= len(s.updates) {
+		s.calls++
+		return nil, nil
+	}
+	updates := s.updates[s.calls]
+	s.calls++
+	return updates, nil
+}
+
+func TestGoalTracker_PercentCompleteAndBlockers(t *testing.T) {
+	assessor := &scriptedGoalAssessor{
+		subGoals: []string{"fetch data", "analyze", "write report", "  "},
+		updates: [][]SubGoalUpdate{
+			{{ID: "g1", Status: SubGoalDone}, {ID: "g2", Status: SubGoalInProgress, Progress: 0.5}},
+			{{ID: "g3", Status: SubGoalBlocked, Blocker: "missing template"}, {ID: "unknown", Status: SubGoalDone}},
+		},
+	}
+	tracker := NewGoalTracker(assessor, GoalTrackerConfig{})
+
+	progress, err := tracker.Start(context.Background(), "produce report", nil)
+	require.NoError(t, err)
+	require.Len(t, progress.SubGoals, 3)
+	assert.Equal(t, "g3", progress.SubGoals[2].ID)
+	assert.Zero(t, progress.PercentComplete)
+
+	progress, err = tracker.Update(context.Background(), 1, &Output{Content: "fetched"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, progress.PercentComplete)
+	assert.Equal(t, 1, progress.SubGoals[0].UpdatedIteration)
+	assert.Empty(t, progress.Blockers)
+
+	progress, err = tracker.Update(context.Background(), 2, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"write report: missing template"}, progress.Blockers)
+	assert.Equal(t, 1, progress.StalledIterations)
+	assert.False(t, progress.Blocked(), "analyze is still in progress")
+}
+
+func TestGoalTracker_StallAddsBlocker(t *testing.T) {
+	tracker := NewGoalTracker(&scriptedGoalAssessor{subGoals: []string{"a"}}, GoalTrackerConfig{StallThreshold: 2})
+	_, err := tracker.Start(context.Background(), "goal", nil)
+	require.NoError(t, err)
+
+	_, _ = tracker.Update(context.Background(), 1, nil, nil)
+	progress, _ := tracker.Update(context.Background(), 2, nil, nil)
+	assert.Equal(t, 2, progress.StalledIterations)
+	assert.Equal(t, []string{"no progress for 2 iterations"}, progress.Blockers)
+}
+
+func TestGoalTracker_DecomposeFailureFallsBackToGoal(t *testing.T) {
+	tracker := NewGoalTracker(&scriptedGoalAssessor{decomposeErr: errors.New("llm down")}, GoalTrackerConfig{})
+	progress, err := tracker.Start(context.Background(), "do the thing", nil)
+	require.Error(t, err)
+	require.Len(t, progress.SubGoals, 1)
+	assert.Equal(t, "do the thing", progress.SubGoals[0].Description)
+}
+
+func TestGoalTracker_StartAdoptsRestoredProgress(t *testing.T) {
+	assessor := &scriptedGoalAssessor{subGoals: []string{"x"}}
+	tracker := NewGoalTracker(assessor, GoalTrackerConfig{})
+	restored := &GoalProgress{Goal: "goal", SubGoals: []SubGoal{{ID: "g1", Status: SubGoalDone}, {ID: "g2", Status: SubGoalPending}}, PercentComplete: 50}
+
+	progress, err := tracker.Start(context.Background(), "goal", restored)
+	require.NoError(t, err)
+	assert.Len(t, progress.SubGoals, 2)
+	assert.Equal(t, 50.0, progress.PercentComplete)
+}
+
+func TestLLMGoalAssessor_ParsesFencedJSON(t *testing.T) {
+	replies := []string{
+		"```json\n{\"sub_goals\": [\"one\", \"two\"]}\n```",
+		"Here you go: {\"updates\": [{\"id\": \"g1\", \"status\": \"done\", \"evidence\": \"ok\"}]}",
+	}
+	call := 0
+	assessor := NewLLMGoalAssessor(func(_ context.Context, messages []types.Message) (*types.ChatResponse, error) {
+		require.Len(t, messages, 1)
+		reply := replies[call]
+		call++
+		return &types.ChatResponse{Choices: []types.ChatChoice{{Message: types.Message{Role: types.RoleAssistant, Content: reply}}}}, nil
+	})
+
+	subGoals, err := assessor.Decompose(context.Background(), "goal")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, subGoals)
+
+	updates, err := assessor.Assess(context.Background(), "goal", []SubGoal{{ID: "g1", Description: "one"}}, &Output{Content: "done"}, nil)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, SubGoalDone, updates[0].Status)
+}
+
+func TestGoalAwareCompletionJudge(t *testing.T) {
+	judge := NewGoalAwareCompletionJudge(&mockCompletionJudge{solved: true})
+
+	state := &LoopState{Iteration: 1, MaxIterations: 3, GoalProgress: &GoalProgress{SubGoals: []SubGoal{{ID: "g1", Description: "a", Status: SubGoalDone}, {ID: "g2", Description: "b", Status: SubGoalPending}}}}
+	decision, err := judge.Judge(context.Background(), state, &Output{Content: "partial"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LoopDecisionContinue, decision.Decision)
+	assert.Contains(t, decision.Reason, "b")
+
+	state.GoalProgress.SubGoals[1].Status = SubGoalBlocked
+	state.GoalProgress.Blockers = []string{"b: need credentials"}
+	judge = NewGoalAwareCompletionJudge(&mockCompletionJudge{solved: false})
+	decision, err = judge.Judge(context.Background(), state, &Output{Content: "partial"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LoopDecisionEscalate, decision.Decision)
+	assert.Equal(t, StopReasonBlocked, decision.StopReason)
+	assert.True(t, decision.NeedHuman)
+
+	state.GoalProgress.SubGoals[1].Status = SubGoalDone
+	decision, err = judge.Judge(context.Background(), state, &Output{Content: "all"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LoopDecisionDone, decision.Decision)
+	assert.True(t, decision.Solved)
+}
+
+func TestLoopExecutor_GoalTrackingDrivesTermination(t *testing.T) {
+	assessor := &scriptedGoalAssessor{
+		subGoals: []string{"step one", "step two"},
+		updates: [][]SubGoalUpdate{
+			{{ID: "g1", Status: SubGoalDone}},
+			{{ID: "g2", Status: SubGoalDone}},
+		},
+	}
+	steps := 0
+	executor := &LoopExecutor{
+		MaxIterations: 5,
+		StepExecutor: func(context.Context, *Input, *LoopState, ReasoningSelection) (*Output, error) {
+			steps++
+			return &Output{Content: "progress"}, nil
+		},
+		GoalTracker: NewGoalTracker(assessor, GoalTrackerConfig{}),
+		Judge:       NewGoalAwareCompletionJudge(&mockCompletionJudge{solved: true}),
+		Logger:      zap.NewNop(),
+	}
+
+	output, err := executor.Execute(context.Background(), &Input{TraceID: "goal-trace", Content: "two step goal"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, steps, "judge should keep iterating until every sub-goal is done")
+	assert.Equal(t, 100.0, output.Metadata["goal_percent_complete"])
+	progress, ok := output.Metadata["goal_progress"].(GoalProgress)
+	require.True(t, ok)
+	assert.True(t, progress.Completed())
+}
+
+func TestLoopStateGoalProgressCheckpointRoundTrip(t *testing.T) {
+	state := &LoopState{Goal: "goal", GoalProgress: &GoalProgress{Goal: "goal", SubGoals: []SubGoal{{ID: "g1", Status: SubGoalDone}}, PercentComplete: 100}}
+	variables := state.CheckpointVariables()
+
+	restored := &LoopState{}
+	restored.restoreFromContext(map[string]any{"goal_progress": map[string]any{
+		"goal":             "goal",
+		"percent_complete": 100.0,
+		"sub_goals":        []any{map[string]any{"id": "g1", "status": "done"}},
+	}})
+	require.NotNil(t, restored.GoalProgress)
+	assert.True(t, restored.GoalProgress.Completed())
+	assert.Contains(t, variables, "goal_progress")
+}
@@ -0,0 +1,124 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ExecutionInterceptor 包裹每一次 Agent 执行与工具调用，用于自定义日志、计费、
+// 租户标记、实验开关等横切关注点，无需 fork BaseAgent。
+//
+// BeforeExecute 可返回派生的 ctx（例如写入租户或实验标记），返回 error 时终止执行；
+// AfterExecute 可观察或替换执行结果；AroundToolCall 必须调用 next 才会真正执行工具。
+type ExecutionInterceptor interface {
+	BeforeExecute(ctx context.Context, input *Input) (context.Context, error)
+	AfterExecute(ctx context.Context, input *Input, output *Output, err error) (*Output, error)
+	AroundToolCall(ctx context.Context, call types.ToolCall, next ToolCallHandler) types.ToolResult
+}
+
+// ExecuteHandler 执行一次 Agent 请求
+type ExecuteHandler func(ctx context.Context, input *Input) (*Output, error)
+
+// ToolCallHandler 执行一次工具调用
+type ToolCallHandler func(ctx context.Context, call types.ToolCall) types.ToolResult
+
+// NopInterceptor 是 ExecutionInterceptor 的空实现，可嵌入后只覆盖关心的方法
+type NopInterceptor struct{}
+
+func (NopInterceptor) BeforeExecute(ctx context.Context, _ *Input) (context.Context, error) {
+	return ctx, nil
+}
+
+func (NopInterceptor) AfterExecute(_ context.Context, _ *Input, output *Output, err error) (*Output, error) {
+	return output, err
+}
+
+func (NopInterceptor) AroundToolCall(ctx context.Context, call types.ToolCall, next ToolCallHandler) types.ToolResult {
+	return next(ctx, call)
+}
+
+// interceptorChain 与 llm/middleware.Chain 的组合方式一致：先注册的拦截器位于最外层
+type interceptorChain struct {
+	mu           sync.RWMutex
+	interceptors []ExecutionInterceptor
+}
+
+func (c *interceptorChain) use(interceptor ExecutionInterceptor) {
+	if interceptor == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interceptors = append(c.interceptors, interceptor)
+}
+
+func (c *interceptorChain) snapshot() []ExecutionInterceptor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]ExecutionInterceptor(nil), c.interceptors...)
+}
+
+// wrapExecute 用拦截器链包裹执行处理器
+func (c *interceptorChain) wrapExecute(h ExecuteHandler) ExecuteHandler {
+	interceptors := c.snapshot()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		h = interceptExecute(interceptors[i], h)
+	}
+	return h
+}
+
+func interceptExecute(interceptor ExecutionInterceptor, next ExecuteHandler) ExecuteHandler {
+	return func(ctx context.Context, input *Input) (*Output, error) {
+		ctx, err := interceptor.BeforeExecute(ctx, input)
+		if err != nil {
+			return interceptor.AfterExecute(ctx, input, nil, err)
+		}
+		output, err := next(ctx, input)
+		return interceptor.AfterExecute(ctx, input, output, err)
+	}
+}
+
+// wrapToolCall 用拦截器链包裹单次工具调用
+func (c *interceptorChain) wrapToolCall(h ToolCallHandler) ToolCallHandler {
+	interceptors := c.snapshot()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], h
+		h = func(ctx context.Context, call types.ToolCall) types.ToolResult {
+			return interceptor.AroundToolCall(ctx, call, next)
+		}
+	}
+	return h
+}
+
+// wrapToolExecutor 在存在拦截器时为工具执行器套上 AroundToolCall
+func (c *interceptorChain) wrapToolExecutor(next llmtools.ToolExecutor) llmtools.ToolExecutor {
+	if len(c.snapshot()) == 0 {
+		return next
+	}
+	return interceptingToolExecutor{call: c.wrapToolCall(next.ExecuteOne)}
+}
+
+type interceptingToolExecutor struct {
+	call ToolCallHandler
+}
+
+// Execute 按调用顺序逐个经过拦截器执行，保证带副作用工具的先后顺序不被打乱
+func (e interceptingToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	results := make([]types.ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = e.call(ctx, call)
+	}
+	return results
+}
+
+func (e interceptingToolExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	return e.call(ctx, call)
+}
+
+// UseInterceptor 追加执行拦截器，先追加的位于外层
+func (b *BaseAgent) UseInterceptor(interceptor ExecutionInterceptor) {
+	b.interceptors.use(interceptor)
+}
@@ -0,0 +1,115 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+type recordingInterceptor struct {
+	NopInterceptor
+	name  string
+	trace *[]string
+}
+
+func (r recordingInterceptor) BeforeExecute(ctx context.Context, _ *Input) (context.Context, error) {
+	*r.trace = append(*r.trace, "before:"+r.name)
+	return context.WithValue(ctx, tenantKey{}, r.name), nil
+}
+
+func (r recordingInterceptor) AfterExecute(_ context.Context, _ *Input, output *Output, err error) (*Output, error) {
+	*r.trace = append(*r.trace, "after:"+r.name)
+	if output != nil {
+		output.Metadata = map[string]any{"billed_by": r.name}
+	}
+	return output, err
+}
+
+func (r recordingInterceptor) AroundToolCall(ctx context.Context, call types.ToolCall, next ToolCallHandler) types.ToolResult {
+	*r.trace = append(*r.trace, "tool:"+r.name)
+	result := next(ctx, call)
+	result.Result = append(result.Result, []byte(r.name)...)
+	return result
+}
+
+type stubToolExecutor struct{}
+
+func (stubToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	results := make([]types.ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = types.ToolResult{ToolCallID: call.ID, Name: call.Name}
+	}
+	return results
+}
+
+func (e stubToolExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	return e.Execute(ctx, []types.ToolCall{call})[0]
+}
+
+func TestInterceptorChainWrapsExecuteOutermostFirst(t *testing.T) {
+	var trace []string
+	var chain interceptorChain
+	chain.use(recordingInterceptor{name: "outer", trace: &trace})
+	chain.use(recordingInterceptor{name: "inner", trace: &trace})
+
+	execute := chain.wrapExecute(func(ctx context.Context, _ *Input) (*Output, error) {
+		trace = append(trace, "execute:"+ctx.Value(tenantKey{}).(string))
+		return &Output{Content: "ok"}, nil
+	})
+	output, err := execute(context.Background(), &Input{Content: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"before:outer", "before:inner", "execute:inner", "after:inner", "after:outer"}, trace)
+	assert.Equal(t, "outer", output.Metadata["billed_by"])
+}
+
+type rejectingInterceptor struct{ NopInterceptor }
+
+func (rejectingInterceptor) BeforeExecute(ctx context.Context, _ *Input) (context.Context, error) {
+	return ctx, errors.New("experiment disabled")
+}
+
+func TestInterceptorBeforeExecuteErrorSkipsExecution(t *testing.T) {
+	var chain interceptorChain
+	chain.use(rejectingInterceptor{})
+	called := false
+	_, err := chain.wrapExecute(func(context.Context, *Input) (*Output, error) {
+		called = true
+		return &Output{}, nil
+	})(context.Background(), &Input{Content: "hi"})
+	require.EqualError(t, err, "experiment disabled")
+	assert.False(t, called)
+}
+
+func TestInterceptorChainWrapsToolCalls(t *testing.T) {
+	var trace []string
+	var chain interceptorChain
+	next := stubToolExecutor{}
+	assert.Equal(t, next, chain.wrapToolExecutor(next), "no interceptors leaves the executor untouched")
+
+	chain.use(recordingInterceptor{name: "a", trace: &trace})
+	chain.use(recordingInterceptor{name: "b", trace: &trace})
+	results := chain.wrapToolExecutor(next).Execute(context.Background(), []types.ToolCall{{ID: "1", Name: "search"}, {ID: "2", Name: "fetch"}})
+	require.Len(t, results, 2)
+	assert.Equal(t, "2", results[1].ToolCallID)
+	assert.Equal(t, "ba", string(results[0].Result))
+	assert.Equal(t, []string{"tool:a", "tool:b", "tool:a", "tool:b"}, trace)
+}
+
+func TestAgentBuilderWithInterceptor(t *testing.T) {
+	builder := newAgentBuilder(types.AgentConfig{Core: types.CoreConfig{ID: "agent-1", Name: "Agent"}})
+	builder.WithInterceptor(nil)
+	require.Len(t, builder.errors, 1)
+
+	var trace []string
+	builder = newAgentBuilder(types.AgentConfig{Core: types.CoreConfig{ID: "agent-1", Name: "Agent"}})
+	builder.WithInterceptor(recordingInterceptor{name: "billing", trace: &trace})
+	agent := &BaseAgent{}
+	builder.finalizeAgent(agent)
+	assert.Len(t, agent.interceptors.snapshot(), 1)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	agentcontext "github.com/BaSui01/agentflow/agent/execution/context"
 	agentevents "github.com/BaSui01/agentflow/agent/observability/events"
+	agentobs "github.com/BaSui01/agentflow/agent/observability/monitoring"
 	agentpersistence "github.com/BaSui01/agentflow/agent/persistence"
 	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
 	llm "github.com/BaSui01/agentflow/llm/core"
@@ -112,6 +113,14 @@ type ExplainabilitySynopsisSnapshotReader interface {
 	GetLatestExplainabilitySynopsisSnapshot(sessionID, agentID, excludeTraceID string) ExplainabilitySynopsisSnapshot
 }
 
+type DecisionGraph = agentobs.DecisionGraph
+
+// DecisionGraphReader is an optional extension for exporting a recorded
+// explainability trace as a decision graph (DOT/Mermaid/JSON).
+type DecisionGraphReader interface {
+	DecisionGraph(traceID string) (*DecisionGraph, bool)
+}
+
 type RuntimeStreamEventType = agentevents.RuntimeStreamEventType
 
 type SDKStreamEventType = agentevents.SDKStreamEventType
@@ -127,16 +136,17 @@ type RuntimeStreamEvent = agentevents.RuntimeStreamEvent
 type RuntimeStreamEmitter = agentevents.RuntimeStreamEmitter
 
 const (
-	RuntimeStreamToken        = agentevents.RuntimeStreamToken
-	RuntimeStreamReasoning    = agentevents.RuntimeStreamReasoning
-	RuntimeStreamToolCall     = agentevents.RuntimeStreamToolCall
-	RuntimeStreamToolResult   = agentevents.RuntimeStreamToolResult
-	RuntimeStreamToolProgress = agentevents.RuntimeStreamToolProgress
-	RuntimeStreamApproval     = agentevents.RuntimeStreamApproval
-	RuntimeStreamSession      = agentevents.RuntimeStreamSession
-	RuntimeStreamStatus       = agentevents.RuntimeStreamStatus
-	RuntimeStreamSteering     = agentevents.RuntimeStreamSteering
-	RuntimeStreamStopAndSend  = agentevents.RuntimeStreamStopAndSend
+	RuntimeStreamToken         = agentevents.RuntimeStreamToken
+	RuntimeStreamReasoning     = agentevents.RuntimeStreamReasoning
+	RuntimeStreamToolCall      = agentevents.RuntimeStreamToolCall
+	RuntimeStreamToolResult    = agentevents.RuntimeStreamToolResult
+	RuntimeStreamToolProgress  = agentevents.RuntimeStreamToolProgress
+	RuntimeStreamToolCallDelta = agentevents.RuntimeStreamToolCallDelta
+	RuntimeStreamApproval      = agentevents.RuntimeStreamApproval
+	RuntimeStreamSession       = agentevents.RuntimeStreamSession
+	RuntimeStreamStatus        = agentevents.RuntimeStreamStatus
+	RuntimeStreamSteering      = agentevents.RuntimeStreamSteering
+	RuntimeStreamStopAndSend   = agentevents.RuntimeStreamStopAndSend
 )
 
 const (
@@ -218,7 +218,16 @@ func (b *BaseAgent) toolSelectionMiddleware() ExecutionMiddleware {
 	return func(ctx context.Context, input *Input, next ExecutionFunc) (*Output, error) {
 		b.logger.Debug("selecting tools dynamically", zap.String("trace_id", input.TraceID))
 		availableTools := b.toolManager.GetAllowedTools(b.ID())
-		selected, err := b.extensions.ToolSelector().SelectTools(ctx, input.Content, availableTools)
+		var (
+			selected []types.ToolSchema
+			catalog  *toolCatalog
+			err      error
+		)
+		if selector, ok := b.extensions.ToolSelector().(catalogToolSelector); ok {
+			selected, catalog, err = selector.selectToolsOrCatalog(ctx, input.Content, availableTools)
+		} else {
+			selected, err = b.extensions.ToolSelector().SelectTools(ctx, input.Content, availableTools)
+		}
 		if err != nil {
 			b.logger.Warn("tool selection failed", zap.String("trace_id", input.TraceID), zap.Error(err))
 		} else {
@@ -230,6 +239,11 @@ func (b *BaseAgent) toolSelectionMiddleware() ExecutionMiddleware {
 				}
 				toolNames = append(toolNames, name)
 			}
+			if catalog != nil {
+				// 分类目录模式：元工具不在 toolManager 中，由 prepareChatRequest 追加
+				toolNames = append(toolNames, toolCatalogToolName)
+				ctx = withToolCatalog(ctx, catalog)
+			}
 
 			override := &RunConfig{}
 			if len(toolNames) == 0 {
@@ -243,6 +257,7 @@ func (b *BaseAgent) toolSelectionMiddleware() ExecutionMiddleware {
 				zap.String("trace_id", input.TraceID),
 				zap.Strings("selected_tools", toolNames),
 				zap.Bool("tools_disabled", len(toolNames) == 0),
+				zap.Bool("tool_catalog", catalog != nil),
 			)
 		}
 		return next(ctx, input)
@@ -27,7 +27,9 @@ type LoopExecutor struct {
 	ReasoningRegistry *reasoning.PatternRegistry
 	ReflectionEnabled bool
 	CheckpointManager *CheckpointManager
+	GoalTracker       *GoalTracker
 	Explainability    ExplainabilityTimelineRecorder
+	PauseSignal       LoopPauseSignal
 	TraceID           string
 	AgentID           string
 	Logger            *zap.Logger
@@ -46,12 +48,16 @@ func (e *LoopExecutor) Execute(ctx context.Context, input *Input) (*Output, erro
 	options := e.executionOptions()
 	needPlan := e.Planner != nil && !options.Control.DisablePlanner
 	e.emitStatus(ctx, state, RuntimeStreamStatus, nil)
+	e.startGoalTracking(ctx, state)
 	for {
 		if err := ctx.Err(); err != nil {
 			state.AdvanceStage(LoopStageEvaluate)
 			state.MarkStopped(StopReasonTimeout, LoopDecisionDone)
 			return e.finalize(state, state.LastOutput, err)
 		}
+		if e.pauseRequested() {
+			return e.suspend(ctx, input, state, false)
+		}
 		if state.Iteration >= state.MaxIterations {
 			state.AdvanceStage(LoopStageEvaluate)
 			state.MarkStopped(StopReasonMaxIterations, LoopDecisionDone)
@@ -83,6 +89,7 @@ func (e *LoopExecutor) Execute(ctx context.Context, input *Input) (*Output, erro
 				state.Plan = append([]string(nil), planResult.Steps...)
 				state.SyncCurrentStep()
 				state.AddObservation(LoopObservation{Stage: LoopStagePlan, Content: "plan_ready", Iteration: state.Iteration, Metadata: map[string]any{"steps": len(planResult.Steps)}})
+				e.emitStatus(ctx, state, RuntimeStreamStatus, map[string]any{"status": "plan_generated", "steps": append([]string(nil), planResult.Steps...)})
 			}
 			needPlan = false
 		}
@@ -90,6 +97,9 @@ func (e *LoopExecutor) Execute(ctx context.Context, input *Input) (*Output, erro
 		state.SyncCurrentStep()
 		e.emitStatus(ctx, state, RuntimeStreamStatus, map[string]any{"status": "stage_changed"})
 		output, execErr := e.executeReasoning(ctx, input, state, selection)
+		if execErr != nil && e.pauseRequested() {
+			return e.suspend(ctx, input, state, true)
+		}
 		state.LastOutput = output
 		if output != nil {
 			if strings.TrimSpace(output.CheckpointID) != "" {
@@ -156,6 +166,7 @@ func (e *LoopExecutor) Execute(ctx context.Context, input *Input) (*Output, erro
 				"remaining_risks":     cloneStringSlice(validation.RemainingRisks),
 			})
 		}
+		e.updateGoalProgress(ctx, state, output, execErr)
 		e.saveCheckpoint(ctx, input, state, output)
 		state.AdvanceStage(LoopStageEvaluate)
 		e.emitStatus(ctx, state, RuntimeStreamStatus, map[string]any{"status": "stage_changed"})
@@ -491,6 +502,12 @@ func (e *LoopExecutor) saveCheckpoint(ctx context.Context, input *Input, state *
 	if e.CheckpointManager == nil || state == nil || input == nil {
 		return
 	}
+	if err := e.persistCheckpoint(ctx, input, state, output, StateRunning, nil); err != nil {
+		e.logger().Warn("save loop checkpoint failed", zap.Error(err))
+	}
+}
+
+func (e *LoopExecutor) persistCheckpoint(ctx context.Context, input *Input, state *LoopState, output *Output, status State, metadata map[string]any) error {
 	threadID := strings.TrimSpace(input.ChannelID)
 	if threadID == "" {
 		threadID = strings.TrimSpace(input.TraceID)
@@ -502,7 +519,8 @@ func (e *LoopExecutor) saveCheckpoint(ctx context.Context, input *Input, state *
 		ID:       state.CheckpointID,
 		ThreadID: threadID,
 		AgentID:  e.AgentID,
-		State:    StateRunning,
+		State:    status,
+		Metadata: metadata,
 	}
 	state.PopulateCheckpoint(checkpoint)
 	if output != nil && strings.TrimSpace(output.Content) != "" {
@@ -515,11 +533,49 @@ func (e *LoopExecutor) saveCheckpoint(ctx context.Context, input *Input, state *
 		}}
 	}
 	if err := e.CheckpointManager.SaveCheckpoint(ctx, checkpoint); err != nil {
-		e.logger().Warn("save loop checkpoint failed", zap.Error(err))
-		return
+		return err
 	}
 	state.CheckpointID = checkpoint.ID
 	state.Resumable = true
+	return nil
+}
+
+func (e *LoopExecutor) pauseRequested() bool {
+	return e.PauseSignal != nil && e.PauseSignal.PauseRequested()
+}
+
+// suspend persists the loop state as a paused checkpoint and stops the loop.
+// When interrupted is true the current iteration was cut short between tool
+// calls, so it is rolled back and replayed on resume.
+func (e *LoopExecutor) suspend(ctx context.Context, input *Input, state *LoopState, interrupted bool) (*Output, error) {
+	if interrupted && state.Iteration > 0 {
+		state.Iteration--
+	}
+	state.AddObservation(LoopObservation{Stage: state.CurrentStage, Content: "paused", Iteration: state.Iteration, Metadata: map[string]any{"interrupted": interrupted}})
+	var err error
+	if e.CheckpointManager == nil {
+		err = NewError(types.ErrAgentExecution, "pause requires a checkpoint manager")
+	} else {
+		err = e.persistCheckpoint(ctx, input, state, state.LastOutput, StatePaused, map[string]any{
+			pausedInputKey: pausedInputSnapshot(input),
+		})
+	}
+	e.PauseSignal.Suspended(state.CheckpointID, err)
+	if err != nil {
+		state.MarkStopped(StopReasonBlocked, LoopDecisionDone)
+		return e.finalize(state, state.LastOutput, err)
+	}
+	state.MarkStopped(StopReasonPaused, LoopDecisionDone)
+	e.emitStatus(ctx, state, RuntimeStreamStatus, map[string]any{"status": "loop_paused", "checkpoint_id": state.CheckpointID})
+	e.recordTimeline("loop_paused", "execution paused", map[string]any{
+		"checkpoint_id": state.CheckpointID,
+		"iteration":     state.Iteration,
+		"interrupted":   interrupted,
+	})
+	output, _ := e.finalize(state, state.LastOutput, nil)
+	output.Metadata["paused"] = true
+	output.Metadata["resume_token"] = state.CheckpointID
+	return output, nil
 }
 
 func buildLoopStateID(input *Input, state *LoopState, agentID string) string {
@@ -608,6 +664,55 @@ func (e *LoopExecutor) emitStatus(ctx context.Context, state *LoopState, eventTy
 	})
 }
 
+func (e *LoopExecutor) startGoalTracking(ctx context.Context, state *LoopState) {
+	if e.GoalTracker == nil {
+		return
+	}
+	progress, err := e.GoalTracker.Start(ctx, state.Goal, state.GoalProgress)
+	if err != nil {
+		e.logger().Warn("goal decomposition failed, tracking goal as a single sub-goal", zap.Error(err))
+	}
+	state.GoalProgress = &progress
+	e.emitGoalProgress(ctx, state, "goal_decomposed")
+}
+
+func (e *LoopExecutor) updateGoalProgress(ctx context.Context, state *LoopState, output *Output, execErr error) {
+	if e.GoalTracker == nil {
+		return
+	}
+	progress, err := e.GoalTracker.Update(ctx, state.Iteration, output, execErr)
+	if err != nil {
+		e.logger().Warn("goal progress assessment failed", zap.Int("iteration", state.Iteration), zap.Error(err))
+	}
+	state.GoalProgress = &progress
+	state.AddObservation(LoopObservation{
+		Stage:     LoopStageValidate,
+		Content:   "goal_progress",
+		Iteration: state.Iteration,
+		Metadata: map[string]any{
+			"percent_complete": progress.PercentComplete,
+			"blockers":         cloneStringSlice(progress.Blockers),
+		},
+	})
+	e.emitGoalProgress(ctx, state, "goal_progress")
+}
+
+func (e *LoopExecutor) emitGoalProgress(ctx context.Context, state *LoopState, status string) {
+	progress := state.GoalProgress
+	e.emitStatus(ctx, state, RuntimeStreamStatus, map[string]any{
+		"status":             status,
+		"percent_complete":   progress.PercentComplete,
+		"sub_goals":          append([]SubGoal(nil), progress.SubGoals...),
+		"blockers":           cloneStringSlice(progress.Blockers),
+		"stalled_iterations": progress.StalledIterations,
+	})
+	e.recordTimeline(status, fmt.Sprintf("%.1f%% complete", progress.PercentComplete), map[string]any{
+		"percent_complete": progress.PercentComplete,
+		"sub_goals":        len(progress.SubGoals),
+		"blockers":         cloneStringSlice(progress.Blockers),
+	})
+}
+
 func (e *LoopExecutor) recordTimeline(entryType, summary string, metadata map[string]any) {
 	if e == nil || e.Explainability == nil || strings.TrimSpace(e.TraceID) == "" {
 		return
@@ -661,6 +766,11 @@ func (e *LoopExecutor) finalize(state *LoopState, output *Output, execErr error)
 		finalOutput.Metadata["acceptance_criteria"] = cloneStringSlice(state.AcceptanceCriteria)
 		finalOutput.Metadata["unresolved_items"] = cloneStringSlice(state.UnresolvedItems)
 		finalOutput.Metadata["remaining_risks"] = cloneStringSlice(state.RemainingRisks)
+		if state.GoalProgress != nil {
+			finalOutput.Metadata["goal_progress"] = state.GoalProgress.clone()
+			finalOutput.Metadata["goal_percent_complete"] = state.GoalProgress.PercentComplete
+			finalOutput.Metadata["goal_blockers"] = cloneStringSlice(state.GoalProgress.Blockers)
+		}
 		critiques := mergeReflectionCritiques(
 			append([]Critique(nil), state.reflectionCritiques...),
 			reflectionCritiquesFromObservations(state.Observations),
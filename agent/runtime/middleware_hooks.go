@@ -115,9 +115,10 @@ type AuthzMiddleware struct {
 }
 
 type toolAuthorizationInput struct {
-	ToolCall  *types.ToolCall
-	ToolRisks map[string]string
-	AgentID   string
+	ToolCall   *types.ToolCall
+	ToolRisks  map[string]string
+	ToolScopes map[string][]string
+	AgentID    string
 }
 
 func NewAuthzMiddleware(authorize AuthorizeFunc) *AuthzMiddleware {
@@ -145,25 +146,28 @@ func authzRiskTierForToolRisk(name, risk string) types.RiskTier {
 	}
 }
 
-func authzToolCallInput(input any) (call *types.ToolCall, risks map[string]string, agentID string, ok bool) {
+func authzToolCallInput(input any) (toolAuthorizationInput, bool) {
 	switch v := input.(type) {
 	case *types.ToolCall:
-		return v, nil, "", v != nil
+		return toolAuthorizationInput{ToolCall: v}, v != nil
 	case types.ToolCall:
 		call := v
-		return &call, nil, "", true
+		return toolAuthorizationInput{ToolCall: &call}, true
 	case *toolAuthorizationInput:
 		if v == nil || v.ToolCall == nil {
-			return nil, nil, "", false
+			return toolAuthorizationInput{}, false
 		}
-		return v.ToolCall, v.ToolRisks, strings.TrimSpace(v.AgentID), true
+		out := *v
+		out.AgentID = strings.TrimSpace(out.AgentID)
+		return out, true
 	case toolAuthorizationInput:
 		if v.ToolCall == nil {
-			return nil, nil, "", false
+			return toolAuthorizationInput{}, false
 		}
-		return v.ToolCall, v.ToolRisks, strings.TrimSpace(v.AgentID), true
+		v.AgentID = strings.TrimSpace(v.AgentID)
+		return v, true
 	default:
-		return nil, nil, "", false
+		return toolAuthorizationInput{}, false
 	}
 }
 
@@ -182,12 +186,13 @@ func (m *AuthzMiddleware) Name() string     { return "authz_middleware" }
 func (m *AuthzMiddleware) Point() HookPoint { return HookBeforeTool }
 
 func (m *AuthzMiddleware) Execute(ctx context.Context, input any) (HookResult, error) {
-	toolCall, toolRisks, agentID, ok := authzToolCallInput(input)
+	in, ok := authzToolCallInput(input)
 	if !ok {
 		return HookResult{Action: HookActionPass}, nil
 	}
+	toolCall, agentID := in.ToolCall, in.AgentID
 
-	toolRisk := authzToolRiskFromMap(toolCall.Name, toolRisks)
+	toolRisk := authzToolRiskFromMap(toolCall.Name, in.ToolRisks)
 	if agentID == "" {
 		if ctxAgentID, ok := types.AgentID(ctx); ok {
 			agentID = strings.TrimSpace(ctxAgentID)
@@ -205,6 +210,10 @@ func (m *AuthzMiddleware) Execute(ctx context.Context, input any) (HookResult, e
 		reqContext["agent_id"] = agentID
 		metadata["agent_id"] = agentID
 	}
+	if scopes := in.ToolScopes[strings.TrimSpace(toolCall.Name)]; len(scopes) > 0 {
+		reqContext["required_scopes"] = append([]string(nil), scopes...)
+		metadata["tool_auth_scopes"] = strings.Join(scopes, ",")
+	}
 
 	req := types.AuthorizationRequest{
 		ResourceKind: types.ResourceTool,
@@ -230,3 +230,31 @@ func TestAuthzMiddleware_UsesClassifiedToolRiskTier(t *testing.T) {
 	assert.Equal(t, types.RiskSafeRead, captured[0].RiskTier)
 	assert.Equal(t, types.RiskExecution, captured[1].RiskTier)
 }
+
+func TestAuthzMiddleware_UsesDeclaredToolTraits(t *testing.T) {
+	t.Parallel()
+
+	var captured types.AuthorizationRequest
+	authorize := func(_ context.Context, req types.AuthorizationRequest) (*types.AuthorizationDecision, error) {
+		captured = req
+		return &types.AuthorizationDecision{Decision: types.DecisionAllow, Reason: "ok"}, nil
+	}
+
+	schema := types.ToolSchema{Name: "crm_lookup", Traits: &types.ToolTraits{
+		SideEffect: types.ToolSideEffectNone,
+		AuthScopes: []string{"crm:read"},
+	}}
+	m := NewAuthzMiddleware(authorize)
+	_, err := m.Execute(context.Background(), &toolAuthorizationInput{
+		ToolCall:   &types.ToolCall{Name: "crm_lookup"},
+		ToolRisks:  map[string]string{schema.Name: classifyToolRisk(schema)},
+		ToolScopes: map[string][]string{schema.Name: schema.Traits.AuthScopes},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, types.RiskSafeRead, captured.RiskTier)
+	assert.Equal(t, []string{"crm:read"}, captured.Context["required_scopes"])
+	metadata, ok := captured.Context["metadata"].(map[string]string)
+	require.True(t, ok)
+	assert.Equal(t, "crm:read", metadata["tool_auth_scopes"])
+}
@@ -0,0 +1,382 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Scan targets for the execution output streams; generated files use their path.
+const (
+	ScanTargetStdout = "stdout"
+	ScanTargetStderr = "stderr"
+)
+
+// ScanFinding is one issue reported by an OutputScanner.
+type ScanFinding struct {
+	Scanner string `json:"scanner"`
+	Target  string `json:"target"`
+	Rule    string `json:"rule"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// OutputScanner inspects a single execution output before it leaves the sandbox.
+type OutputScanner interface {
+	Name() string
+	Scan(ctx context.Context, target string, content []byte) ([]ScanFinding, error)
+}
+
+// QuarantineInfo is attached to a result whose outputs were withheld.
+type QuarantineInfo struct {
+	ID       string        `json:"id"`
+	Findings []ScanFinding `json:"findings"`
+}
+
+// QuarantinedOutput is a flagged execution result awaiting release or discard.
+type QuarantinedOutput struct {
+	ID          string           `json:"id"`
+	ExecutionID string           `json:"execution_id"`
+	Findings    []ScanFinding    `json:"findings"`
+	Result      *ExecutionResult `json:"result"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// QuarantineReleaser decides whether a quarantined output may be released.
+type QuarantineReleaser interface {
+	RequestRelease(ctx context.Context, entry QuarantinedOutput) (bool, error)
+}
+
+// OutputScanStage runs stdout, stderr and generated files through the configured
+// scanners. Flagged results are quarantined and replaced by a withheld result
+// unless the releaser approves them. Scanner errors fail closed.
+type OutputScanStage struct {
+	scanners   []OutputScanner
+	releaser   QuarantineReleaser
+	logger     *zap.Logger
+	mu         sync.Mutex
+	quarantine map[string]*QuarantinedOutput
+}
+
+// NewOutputScanStage creates a scan stage with the given scanners.
+func NewOutputScanStage(logger *zap.Logger, scanners ...OutputScanner) *OutputScanStage {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &OutputScanStage{
+		scanners:   scanners,
+		logger:     logger.With(zap.String("component", "output_scan")),
+		quarantine: make(map[string]*QuarantinedOutput),
+	}
+}
+
+// SetReleaser configures the release path for flagged outputs. Passing nil keeps
+// them quarantined until Release is called.
+func (s *OutputScanStage) SetReleaser(releaser QuarantineReleaser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaser = releaser
+}
+
+// Scan inspects the result and returns either the original result or a withheld
+// copy carrying QuarantineInfo.
+func (s *OutputScanStage) Scan(ctx context.Context, result *ExecutionResult) (*ExecutionResult, error) {
+	if result == nil {
+		return nil, nil
+	}
+	findings := s.scanResult(ctx, result)
+	if len(findings) == 0 {
+		return result, nil
+	}
+
+	entry := &QuarantinedOutput{
+		ID:          "qtn_" + uuid.New().String(),
+		ExecutionID: result.ID,
+		Findings:    findings,
+		Result:      result,
+		CreatedAt:   time.Now(),
+	}
+	s.mu.Lock()
+	s.quarantine[entry.ID] = entry
+	releaser := s.releaser
+	s.mu.Unlock()
+	s.logger.Warn("execution output quarantined",
+		zap.String("execution_id", result.ID),
+		zap.String("quarantine_id", entry.ID),
+		zap.Int("findings", len(findings)),
+	)
+
+	if releaser != nil {
+		approved, err := releaser.RequestRelease(ctx, *entry)
+		if err != nil {
+			s.logger.Warn("quarantine release request failed", zap.String("quarantine_id", entry.ID), zap.Error(err))
+		} else if approved {
+			return s.Release(entry.ID)
+		}
+	}
+	return withheldResult(result, entry), nil
+}
+
+func (s *OutputScanStage) scanResult(ctx context.Context, result *ExecutionResult) []ScanFinding {
+	targets := []string{ScanTargetStdout, ScanTargetStderr}
+	contents := map[string]string{ScanTargetStdout: result.Stdout, ScanTargetStderr: result.Stderr}
+	paths := make([]string, 0, len(result.Files))
+	for path, content := range result.Files {
+		paths = append(paths, path)
+		contents[path] = content
+	}
+	sort.Strings(paths)
+	targets = append(targets, paths...)
+
+	var findings []ScanFinding
+	for _, target := range targets {
+		content := contents[target]
+		if content == "" {
+			continue
+		}
+		for _, scanner := range s.scanners {
+			found, err := scanner.Scan(ctx, target, []byte(content))
+			if err != nil {
+				findings = append(findings, ScanFinding{Scanner: scanner.Name(), Target: target, Rule: "scan_error", Detail: err.Error()})
+				continue
+			}
+			findings = append(findings, found...)
+		}
+	}
+	return findings
+}
+
+func withheldResult(result *ExecutionResult, entry *QuarantinedOutput) *ExecutionResult {
+	withheld := *result
+	withheld.Stdout = ""
+	withheld.Stderr = ""
+	withheld.Files = nil
+	withheld.Error = fmt.Sprintf("output quarantined (%s): %d finding(s) pending review", entry.ID, len(entry.Findings))
+	withheld.Quarantine = &QuarantineInfo{ID: entry.ID, Findings: entry.Findings}
+	return &withheld
+}
+
+// Quarantined returns the outputs currently held, oldest first.
+func (s *OutputScanStage) Quarantined() []QuarantinedOutput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]QuarantinedOutput, 0, len(s.quarantine))
+	for _, entry := range s.quarantine {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries
+}
+
+// Release removes an entry from quarantine and returns the original result.
+func (s *OutputScanStage) Release(id string) (*ExecutionResult, error) {
+	s.mu.Lock()
+	entry, ok := s.quarantine[id]
+	delete(s.quarantine, id)
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("quarantined output %s not found", id)
+	}
+	s.logger.Info("quarantined output released", zap.String("quarantine_id", id))
+	return entry.Result, nil
+}
+
+// Discard drops a quarantined output without releasing it.
+func (s *OutputScanStage) Discard(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.quarantine[id]; !ok {
+		return fmt.Errorf("quarantined output %s not found", id)
+	}
+	delete(s.quarantine, id)
+	return nil
+}
+
+// SecretsOutputScanner flags credentials detected by a guardrails.SecretsValidator.
+type SecretsOutputScanner struct {
+	validator *guardrails.SecretsValidator
+}
+
+// NewSecretsOutputScanner wraps a secrets validator; nil uses the default detector.
+func NewSecretsOutputScanner(validator *guardrails.SecretsValidator) *SecretsOutputScanner {
+	if validator == nil {
+		validator = guardrails.NewSecretsValidator(nil)
+	}
+	return &SecretsOutputScanner{validator: validator}
+}
+
+func (s *SecretsOutputScanner) Name() string { return "secrets" }
+
+func (s *SecretsOutputScanner) Scan(_ context.Context, target string, content []byte) ([]ScanFinding, error) {
+	matches := s.validator.Detect(string(content))
+	findings := make([]ScanFinding, 0, len(matches))
+	for _, m := range matches {
+		findings = append(findings, ScanFinding{Scanner: s.Name(), Target: target, Rule: string(m.Type), Detail: m.Preview})
+	}
+	return findings, nil
+}
+
+// ClamAVScanner streams content to a clamd daemon using the INSTREAM command.
+type ClamAVScanner struct {
+	Network   string
+	Address   string
+	Timeout   time.Duration
+	ChunkSize int
+}
+
+// NewClamAVScanner creates a clamd client, e.g. ("tcp", "127.0.0.1:3310") or
+// ("unix", "/run/clamav/clamd.ctl").
+func NewClamAVScanner(network, address string) *ClamAVScanner {
+	return &ClamAVScanner{Network: network, Address: address, Timeout: 30 * time.Second, ChunkSize: 64 * 1024}
+}
+
+func (c *ClamAVScanner) Name() string { return "clamav" }
+
+func (c *ClamAVScanner) Scan(ctx context.Context, target string, content []byte) ([]ScanFinding, error) {
+	dialer := net.Dialer{Timeout: c.Timeout}
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("connect clamd: %w", err)
+	}
+	defer conn.Close()
+	if c.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("send clamd command: %w", err)
+	}
+	chunkSize := c.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 64 * 1024
+	}
+	size := make([]byte, 4)
+	for start := 0; start < len(content); start += chunkSize {
+		end := min(start+chunkSize, len(content))
+		binary.BigEndian.PutUint32(size, uint32(end-start))
+		if _, err := conn.Write(size); err != nil {
+			return nil, fmt.Errorf("stream to clamd: %w", err)
+		}
+		if _, err := conn.Write(content[start:end]); err != nil {
+			return nil, fmt.Errorf("stream to clamd: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, fmt.Errorf("stream to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseClamdReply(c.Name(), target, reply)
+}
+
+// parseClamdReply parses "stream: OK" / "stream: <signature> FOUND" / "<msg> ERROR".
+func parseClamdReply(scanner, target, reply string) ([]ScanFinding, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return nil, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(reply, " FOUND")
+		return []ScanFinding{{Scanner: scanner, Target: target, Rule: signature, Detail: "malware signature"}}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// YARAScanner matches content against YARA rules using the yara command-line tool.
+type YARAScanner struct {
+	Binary    string
+	RulesPath string
+}
+
+// NewYARAScanner creates a scanner for a compiled or source rules file.
+func NewYARAScanner(rulesPath string) *YARAScanner {
+	return &YARAScanner{Binary: "yara", RulesPath: rulesPath}
+}
+
+func (y *YARAScanner) Name() string { return "yara" }
+
+func (y *YARAScanner) Scan(ctx context.Context, target string, content []byte) ([]ScanFinding, error) {
+	file, err := os.CreateTemp("", "agentflow-yara-*")
+	if err != nil {
+		return nil, fmt.Errorf("create yara scan file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, writeErr := file.Write(content)
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		return nil, fmt.Errorf("write yara scan file: %w", err)
+	}
+
+	binaryPath := y.Binary
+	if binaryPath == "" {
+		binaryPath = "yara"
+	}
+	stdout, stderr, err := execCommandContext(ctx, binaryPath, "-w", y.RulesPath, file.Name()).Run()
+	if err != nil {
+		return nil, fmt.Errorf("yara: %w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	var findings []ScanFinding
+	for _, line := range strings.Split(stdout, "\n") {
+		rule, _, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok || rule == "" {
+			continue
+		}
+		findings = append(findings, ScanFinding{Scanner: y.Name(), Target: target, Rule: rule})
+	}
+	return findings, nil
+}
+
+// HITLQuarantineReleaser asks a human reviewer, via an approval interrupt,
+// whether a quarantined output may be released.
+type HITLQuarantineReleaser struct {
+	manager *hitl.InterruptManager
+	timeout time.Duration
+}
+
+// NewHITLQuarantineReleaser creates a releaser; a zero timeout uses the manager default.
+func NewHITLQuarantineReleaser(manager *hitl.InterruptManager, timeout time.Duration) *HITLQuarantineReleaser {
+	return &HITLQuarantineReleaser{manager: manager, timeout: timeout}
+}
+
+// RequestRelease implements QuarantineReleaser.
+func (h *HITLQuarantineReleaser) RequestRelease(ctx context.Context, entry QuarantinedOutput) (bool, error) {
+	if h.manager == nil {
+		return false, errors.New("hitl interrupt manager is nil")
+	}
+	runID, _ := types.RunID(ctx)
+	resp, err := h.manager.CreateInterrupt(ctx, hitl.InterruptOptions{
+		WorkflowID:  runID,
+		NodeID:      "output_scan",
+		Type:        hitl.InterruptTypeApproval,
+		Title:       "Release quarantined execution output",
+		Description: fmt.Sprintf("Execution %s produced %d flagged output(s)", entry.ExecutionID, len(entry.Findings)),
+		Data:        entry.Findings,
+		Timeout:     h.timeout,
+		Metadata:    map[string]any{"quarantine_id": entry.ID, "execution_id": entry.ExecutionID},
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp != nil && resp.Approved, nil
+}
@@ -0,0 +1,189 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// pausedInputKey 保存暂停时的原始输入，Resume 据此重建 Input
+const pausedInputKey = "paused_input"
+
+// LoopPauseSignal lets a caller suspend a running loop at the next safe point:
+// between loop iterations, or between tool-call batches inside the ReAct loop.
+type LoopPauseSignal interface {
+	PauseRequested() bool
+	Suspended(checkpointID string, err error)
+}
+
+type pauseOutcome struct {
+	checkpointID string
+	err          error
+}
+
+// pauseRun 对应一次正在进行的 Execute
+type pauseRun struct {
+	requested atomic.Bool
+	once      sync.Once
+	parked    chan pauseOutcome
+	done      chan struct{}
+}
+
+func newPauseRun() *pauseRun {
+	return &pauseRun{
+		parked: make(chan pauseOutcome, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (r *pauseRun) PauseRequested() bool {
+	return r.requested.Load()
+}
+
+func (r *pauseRun) Suspended(checkpointID string, err error) {
+	r.once.Do(func() {
+		r.parked <- pauseOutcome{checkpointID: checkpointID, err: err}
+	})
+}
+
+// pauseRegistry 跟踪 BaseAgent 上可被暂停的执行，零值可用
+type pauseRegistry struct {
+	mu   sync.Mutex
+	runs map[*pauseRun]struct{}
+}
+
+func (p *pauseRegistry) register(run *pauseRun) func() {
+	p.mu.Lock()
+	if p.runs == nil {
+		p.runs = make(map[*pauseRun]struct{})
+	}
+	p.runs[run] = struct{}{}
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		delete(p.runs, run)
+		p.mu.Unlock()
+		close(run.done)
+	}
+}
+
+func (p *pauseRegistry) active() (*pauseRun, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch len(p.runs) {
+	case 0:
+		return nil, NewError(types.ErrAgentNotReady, "no running execution to pause")
+	case 1:
+		for run := range p.runs {
+			return run, nil
+		}
+	}
+	return nil, NewError(types.ErrAgentBusy, "multiple executions are running; pause is ambiguous")
+}
+
+type pauseRunContextKey struct{}
+
+func withPauseRun(ctx context.Context, run *pauseRun) context.Context {
+	return context.WithValue(ctx, pauseRunContextKey{}, run)
+}
+
+func pauseSignalFromContext(ctx context.Context) LoopPauseSignal {
+	if run, ok := ctx.Value(pauseRunContextKey{}).(*pauseRun); ok && run != nil {
+		return run
+	}
+	return nil
+}
+
+// Pause 请求挂起当前正在运行的 Execute。执行会在下一个安全点（迭代之间或工具调用批次之间）
+// 停下，把完整循环状态写入 checkpoint 并返回 resume token（即 checkpoint ID）。
+// 调用会阻塞到执行挂起、执行结束或 ctx 取消为止。
+func (b *BaseAgent) Pause(ctx context.Context) (string, error) {
+	if b.checkpointManager == nil {
+		return "", NewError(types.ErrAgentNotReady, "pause requires a checkpoint manager")
+	}
+	run, err := b.pauses.active()
+	if err != nil {
+		return "", err
+	}
+	run.requested.Store(true)
+	select {
+	case outcome := <-run.parked:
+		return outcome.checkpointID, outcome.err
+	case <-run.done:
+		select {
+		case outcome := <-run.parked:
+			return outcome.checkpointID, outcome.err
+		default:
+			return "", NewError(types.ErrAgentExecution, "execution finished before reaching a pause point")
+		}
+	case <-ctx.Done():
+		run.requested.Store(false)
+		return "", ctx.Err()
+	}
+}
+
+// Resume 从 Pause 返回的 token 继续执行，可跨进程重启使用。
+func (b *BaseAgent) Resume(ctx context.Context, token string) (*Output, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, NewError(types.ErrInputValidation, "resume token is required")
+	}
+	if b.checkpointManager == nil {
+		return nil, NewError(types.ErrAgentNotReady, "resume requires a checkpoint manager")
+	}
+	checkpoint, err := b.checkpointManager.LoadCheckpoint(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil || checkpoint.State != StatePaused {
+		return nil, NewError(types.ErrInputValidation, "resume token does not reference a paused execution")
+	}
+	input := &Input{ChannelID: checkpoint.ThreadID}
+	if snapshot, ok := checkpoint.Metadata[pausedInputKey].(map[string]any); ok {
+		input.TraceID, _ = snapshot["trace_id"].(string)
+		input.TenantID, _ = snapshot["tenant_id"].(string)
+		input.UserID, _ = snapshot["user_id"].(string)
+		input.Content, _ = snapshot["content"].(string)
+	}
+	input.Context = map[string]any{"checkpoint_id": checkpoint.ID}
+	return b.Execute(ctx, input)
+}
+
+func pausedInputSnapshot(input *Input) map[string]any {
+	if input == nil {
+		return nil
+	}
+	return map[string]any{
+		"trace_id":  input.TraceID,
+		"tenant_id": input.TenantID,
+		"user_id":   input.UserID,
+		"content":   input.Content,
+	}
+}
+
+// pausingToolExecutor 在一批工具调用完成后检查暂停请求，通过取消 ReAct 上下文让循环在下一次 LLM 调用前退出
+type pausingToolExecutor struct {
+	next   llmtools.ToolExecutor
+	signal LoopPauseSignal
+	cancel context.CancelFunc
+}
+
+func (e pausingToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	results := e.next.Execute(ctx, calls)
+	if e.signal.PauseRequested() {
+		e.cancel()
+	}
+	return results
+}
+
+func (e pausingToolExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	result := e.next.ExecuteOne(ctx, call)
+	if e.signal.PauseRequested() {
+		e.cancel()
+	}
+	return result
+}
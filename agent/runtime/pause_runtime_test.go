@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newPauseTestLoopExecutor(manager *CheckpointManager, run *pauseRun, step LoopStepExecutorFunc) *LoopExecutor {
+	return &LoopExecutor{
+		MaxIterations:     4,
+		ExecutionOptions:  types.ExecutionOptions{Control: types.AgentControlOptions{MaxLoopIterations: 4}},
+		StepExecutor:      step,
+		Judge:             &mockCompletionJudge{solved: false},
+		CheckpointManager: manager,
+		PauseSignal:       run,
+		AgentID:           "agent-1",
+		Logger:            zap.NewNop(),
+	}
+}
+
+func TestLoopExecutorPausesBetweenIterationsAndResumes(t *testing.T) {
+	ctx := context.Background()
+	_, manager := newResumeRuntimeTestAgent(t)
+	run := newPauseRun()
+	calls := 0
+	executor := newPauseTestLoopExecutor(manager, run, func(context.Context, *Input, *LoopState, ReasoningSelection) (*Output, error) {
+		calls++
+		if calls == 2 {
+			run.requested.Store(true)
+		}
+		return &Output{Content: "step"}, nil
+	})
+
+	output, err := executor.Execute(ctx, &Input{TraceID: "trace-1", Content: "long task"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, string(StopReasonPaused), output.StopReason)
+	assert.Equal(t, true, output.Metadata["paused"])
+	assert.Equal(t, 2, output.IterationCount)
+
+	outcome := <-run.parked
+	require.NoError(t, outcome.err)
+	assert.Equal(t, output.CheckpointID, outcome.checkpointID)
+	checkpoint, err := manager.LoadCheckpoint(ctx, outcome.checkpointID)
+	require.NoError(t, err)
+	assert.Equal(t, StatePaused, checkpoint.State)
+	snapshot, ok := checkpoint.Metadata[pausedInputKey].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "long task", snapshot["content"])
+
+	resumed := newPauseTestLoopExecutor(manager, newPauseRun(), func(context.Context, *Input, *LoopState, ReasoningSelection) (*Output, error) {
+		calls++
+		return &Output{Content: "step"}, nil
+	})
+	output, err = resumed.Execute(ctx, &Input{TraceID: "trace-1", Content: "long task", Context: map[string]any{"checkpoint_id": outcome.checkpointID}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls, "resume continues from the persisted iteration")
+	assert.Equal(t, 4, output.IterationCount)
+}
+
+func TestLoopExecutorPauseBetweenToolCallsReplaysIteration(t *testing.T) {
+	_, manager := newResumeRuntimeTestAgent(t)
+	run := newPauseRun()
+	executor := newPauseTestLoopExecutor(manager, run, func(context.Context, *Input, *LoopState, ReasoningSelection) (*Output, error) {
+		run.requested.Store(true)
+		return nil, context.Canceled
+	})
+
+	output, err := executor.Execute(context.Background(), &Input{TraceID: "trace-2", Content: "task"})
+	require.NoError(t, err)
+	assert.Equal(t, string(StopReasonPaused), output.StopReason)
+	assert.Equal(t, 0, output.IterationCount)
+	assert.True(t, output.Resumable)
+}
+
+func TestLoopExecutorPauseWithoutCheckpointManagerFails(t *testing.T) {
+	run := newPauseRun()
+	run.requested.Store(true)
+	executor := newPauseTestLoopExecutor(nil, run, func(context.Context, *Input, *LoopState, ReasoningSelection) (*Output, error) {
+		return &Output{Content: "step"}, nil
+	})
+
+	_, err := executor.Execute(context.Background(), &Input{Content: "task"})
+	require.Error(t, err)
+	outcome := <-run.parked
+	assert.Error(t, outcome.err)
+}
+
+func TestBaseAgentPauseWaitsForActiveRun(t *testing.T) {
+	agent, _ := newResumeRuntimeTestAgent(t)
+
+	_, err := agent.Pause(context.Background())
+	require.Error(t, err, "no running execution")
+
+	run := newPauseRun()
+	unregister := agent.pauses.register(run)
+	go func() {
+		for !run.PauseRequested() {
+			time.Sleep(time.Millisecond)
+		}
+		run.Suspended("cp-paused", nil)
+		unregister()
+	}()
+	token, err := agent.Pause(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "cp-paused", token)
+
+	agent.pauses.register(newPauseRun())
+	agent.pauses.register(newPauseRun())
+	_, err = agent.Pause(context.Background())
+	require.Error(t, err, "ambiguous with several runs")
+}
+
+func TestBaseAgentResumeRejectsNonPausedCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	agent, manager := newResumeRuntimeTestAgent(t)
+	require.NoError(t, manager.SaveCheckpoint(ctx, &Checkpoint{ID: "cp-running", ThreadID: "thread-1", AgentID: "agent-1", State: StateRunning}))
+
+	_, err := agent.Resume(ctx, "cp-running")
+	require.Error(t, err)
+	var agentErr *Error
+	require.True(t, errors.As(err, &agentErr))
+	assert.Equal(t, types.ErrInputValidation, agentErr.Base.Code)
+
+	_, err = agent.Resume(ctx, " ")
+	require.Error(t, err)
+}
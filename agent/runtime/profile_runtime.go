@@ -0,0 +1,183 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	memorycore "github.com/BaSui01/agentflow/agent/capabilities/memory"
+	agentcontext "github.com/BaSui01/agentflow/agent/execution/context"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// AgentProfile is the curated per user+agent personalization layer.
+type AgentProfile = memorycore.AgentProfile
+
+// ProfileStyle is the style calibration carried by an AgentProfile.
+type ProfileStyle = memorycore.ProfileStyle
+
+// profileDistillTimeout bounds background distillation after a run.
+const profileDistillTimeout = 2 * time.Minute
+
+// ProfileConfig enables persistent agent profiles.
+type ProfileConfig struct {
+	// Store persists one profile per user+agent. Required.
+	Store memorycore.ProfileStore
+	// Episodes receives one interaction episode per completed run tagged with
+	// the user id, and feeds distillation. Optional; without it profiles are
+	// only injected, never distilled.
+	Episodes memorycore.EpisodicStore
+	// Distiller defaults to an LLM distiller over Episodes on the main gateway.
+	Distiller *memorycore.ProfileDistiller
+	// DistillerConfig configures the default distiller.
+	DistillerConfig memorycore.ProfileDistillerConfig
+}
+
+type profileRuntime struct {
+	config    ProfileConfig
+	distiller *memorycore.ProfileDistiller
+	inflight  sync.Map // agentID\x00userID -> struct{}
+}
+
+// SetProfiles enables persistent profiles. Pass nil to disable.
+func (b *BaseAgent) SetProfiles(config *ProfileConfig) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	if config == nil || config.Store == nil {
+		b.profiles = nil
+		return
+	}
+	rt := &profileRuntime{config: *config, distiller: config.Distiller}
+	if rt.distiller == nil && config.Episodes != nil && b.MainGateway() != nil {
+		distillerCfg := config.DistillerConfig
+		if distillerCfg == (memorycore.ProfileDistillerConfig{}) {
+			distillerCfg = memorycore.DefaultProfileDistillerConfig()
+		}
+		if distillerCfg.Model == "" {
+			distillerCfg.Model = b.config.ExecutionOptions().Model.Model
+		}
+		rt.distiller = memorycore.NewProfileDistiller(b.MainGateway(), config.Episodes, distillerCfg, b.logger)
+	}
+	b.profiles = rt
+}
+
+func (b *BaseAgent) profileRuntime() *profileRuntime {
+	b.configMu.RLock()
+	defer b.configMu.RUnlock()
+	return b.profiles
+}
+
+// Profile loads the stored profile for userID, or nil when none exists yet.
+func (b *BaseAgent) Profile(ctx context.Context, userID string) (*AgentProfile, error) {
+	rt := b.profileRuntime()
+	if rt == nil {
+		return nil, NewError(types.ErrAgentNotReady, "profiles are not enabled")
+	}
+	return rt.config.Store.LoadProfile(ctx, userID, b.ID())
+}
+
+// DistillProfile folds the user's episodes since the last distillation into
+// their profile and persists the result. It returns the current profile when
+// there was not enough new material to distill.
+func (b *BaseAgent) DistillProfile(ctx context.Context, userID string) (*AgentProfile, error) {
+	rt := b.profileRuntime()
+	if rt == nil || rt.distiller == nil {
+		return nil, NewError(types.ErrAgentNotReady, "profile distillation is not enabled")
+	}
+	profile, err := rt.config.Store.LoadProfile(ctx, userID, b.ID())
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = memorycore.NewAgentProfile(userID, b.ID())
+	}
+	next, updated, err := rt.distiller.Distill(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return profile, nil
+	}
+	if err := rt.config.Store.SaveProfile(ctx, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// profilePromptLayer renders the user's profile as a sticky prompt layer.
+func (b *BaseAgent) profilePromptLayer(ctx context.Context, input *Input) (agentcontext.PromptLayer, bool) {
+	rt := b.profileRuntime()
+	if rt == nil || input == nil || strings.TrimSpace(input.UserID) == "" {
+		return agentcontext.PromptLayer{}, false
+	}
+	profile, err := rt.config.Store.LoadProfile(ctx, strings.TrimSpace(input.UserID), b.ID())
+	if err != nil {
+		runtimePromptLogger(b).Warn("failed to load agent profile", zap.String("user_id", input.UserID), zap.Error(err))
+		return agentcontext.PromptLayer{}, false
+	}
+	content := profile.RenderPrompt()
+	if content == "" {
+		return agentcontext.PromptLayer{}, false
+	}
+	return agentcontext.PromptLayer{
+		ID:       "agent_profile",
+		Type:     agentcontext.SegmentEphemeral,
+		Role:     types.RoleSystem,
+		Content:  content,
+		Priority: 88,
+		Sticky:   true,
+		Metadata: map[string]any{
+			"layer_kind":      "agent_profile",
+			"profile_version": profile.Version,
+		},
+	}, true
+}
+
+// observeProfileRun records the completed run as an episode for the user and
+// starts a background distillation when the profile is due.
+func (b *BaseAgent) observeProfileRun(ctx context.Context, input *Input, output *Output) {
+	rt := b.profileRuntime()
+	if rt == nil || rt.config.Episodes == nil || input == nil || output == nil {
+		return
+	}
+	userID := strings.TrimSpace(input.UserID)
+	if userID == "" {
+		return
+	}
+	err := rt.config.Episodes.RecordEvent(ctx, &types.EpisodicEvent{
+		AgentID: b.ID(),
+		Type:    "interaction",
+		Content: "User: " + input.Content + "\nAssistant: " + output.Content,
+		Context: map[string]any{
+			"user_id":  userID,
+			"trace_id": input.TraceID,
+		},
+		Timestamp: time.Now(),
+		Duration:  output.Duration,
+	})
+	if err != nil {
+		b.logger.Warn("failed to record profile episode", zap.String("user_id", userID), zap.Error(err))
+		return
+	}
+	if rt.distiller == nil {
+		return
+	}
+	profile, err := rt.config.Store.LoadProfile(ctx, userID, b.ID())
+	if err != nil || !rt.distiller.Due(profile) {
+		return
+	}
+	key := b.ID() + "\x00" + userID
+	if _, running := rt.inflight.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	distillCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), profileDistillTimeout)
+	go func() {
+		defer cancel()
+		defer rt.inflight.Delete(key)
+		if _, err := b.DistillProfile(distillCtx, userID); err != nil {
+			b.logger.Warn("profile distillation failed", zap.String("user_id", userID), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memorycore "github.com/BaSui01/agentflow/agent/capabilities/memory"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newProfileAgent(t *testing.T, provider *captureRuntimeProvider, cfg ProfileConfig) *BaseAgent {
+	t.Helper()
+	agentCfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "profile-agent", Name: "Profile", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	ag, err := newAgentBuilder(agentCfg).
+		WithGateway(testGateway(provider)).
+		WithLogger(zap.NewNop()).
+		WithProfiles(cfg).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+	return ag
+}
+
+func systemMessages(req []types.Message) []string {
+	var system []string
+	for _, msg := range req {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+		}
+	}
+	return system
+}
+
+func TestExecuteInjectsStoredProfile(t *testing.T) {
+	store := memorycore.NewInMemoryProfileStore()
+	profile := memorycore.NewAgentProfile("alice", "profile-agent")
+	profile.StandingInstructions = []string{"Answer in French"}
+	require.NoError(t, store.SaveProfile(context.Background(), profile))
+
+	provider := &captureRuntimeProvider{content: "d'accord"}
+	ag := newProfileAgent(t, provider, ProfileConfig{Store: store})
+
+	_, err := ag.Execute(context.Background(), &Input{TraceID: "trace-p", UserID: "alice", Content: "hello"})
+	require.NoError(t, err)
+	assert.Contains(t, systemMessages(provider.lastRequest.Messages),
+		"<user_profile>\nStanding instructions from this user:\n- Answer in French\n</user_profile>")
+
+	_, err = ag.Execute(context.Background(), &Input{TraceID: "trace-p2", UserID: "bob", Content: "hello"})
+	require.NoError(t, err)
+	for _, msg := range systemMessages(provider.lastRequest.Messages) {
+		assert.NotContains(t, msg, "<user_profile>", "profiles are scoped to their user")
+	}
+}
+
+func TestExecuteDistillsProfileFromEpisodes(t *testing.T) {
+	store := memorycore.NewInMemoryProfileStore()
+	episodes := memorycore.NewInMemoryEpisodicStore(0, zap.NewNop())
+	provider := &captureRuntimeProvider{content: `{"preferences":{"units":"metric"},"style":{"verbosity":"brief"}}`}
+	ag := newProfileAgent(t, provider, ProfileConfig{
+		Store:           store,
+		Episodes:        episodes,
+		DistillerConfig: memorycore.ProfileDistillerConfig{Interval: time.Hour, MinEvents: 2},
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := ag.Execute(context.Background(), &Input{TraceID: "trace-d", UserID: "alice", Content: "use metric units"})
+		require.NoError(t, err)
+	}
+	events, err := episodes.QueryEvents(context.Background(), memorycore.EpisodicQuery{AgentID: "profile-agent"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "alice", events[0].Context["user_id"])
+
+	require.Eventually(t, func() bool {
+		profile, err := ag.Profile(context.Background(), "alice")
+		return err == nil && profile != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	profile, err := ag.Profile(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "metric", profile.Preferences["units"])
+	assert.Equal(t, ProfileStyle{Verbosity: "brief"}, profile.Style)
+	assert.Equal(t, 1, profile.Version)
+
+	// Without new episodes an explicit distillation returns the current profile.
+	current, err := ag.DistillProfile(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, current.Version)
+}
+
+func TestAgentBuilderRequiresProfileStore(t *testing.T) {
+	_, err := newAgentBuilder(types.AgentConfig{
+		Core: types.CoreConfig{ID: "profile-agent", Name: "Profile", Type: "assistant"},
+		LLM:  types.LLMConfig{Model: "gpt-4"},
+	}).WithGateway(testGateway(&captureRuntimeProvider{})).WithLogger(zap.NewNop()).WithProfiles(ProfileConfig{}).Build()
+	assert.ErrorContains(t, err, "profile store is required")
+}
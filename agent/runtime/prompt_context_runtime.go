@@ -23,6 +23,9 @@ func (b *BaseAgent) prepareRuntimePromptContext(ctx context.Context, input *Inpu
 	logger := runtimePromptLogger(b)
 	memoryContext := b.collectContextMemory(ctx, input.Context)
 	conversation := restoredMessages
+	if len(conversation) == 0 {
+		conversation = sessionMessagesFromInputContext(input.Context)
+	}
 	if handoffMessages := handoffMessagesFromInputContext(input.Context); len(handoffMessages) > 0 {
 		conversation = handoffMessages
 	}
@@ -35,6 +38,9 @@ func (b *BaseAgent) prepareRuntimePromptContext(ctx context.Context, input *Inpu
 	if len(skillContext) == 0 {
 		skillContext = normalizeInstructionList(agentcontext.SkillInstructionsFromContext(ctx))
 	}
+	if fragments := b.skillRegistry.promptFragments(); len(fragments) > 0 {
+		skillContext = append(fragments, skillContext...)
+	}
 	publicContext := publicInputContext(input.Context)
 	retrievalItems := retrievalItemsFromInputContext(input.Context)
 	if len(retrievalItems) == 0 && b.retriever != nil {
@@ -54,6 +60,9 @@ func (b *BaseAgent) prepareRuntimePromptContext(ctx context.Context, input *Inpu
 	}
 
 	ephemeralLayers, traceFeedbackPlan := b.buildEphemeralPromptLayers(ctx, publicContext, input, systemContent, skillContext, memoryContext, conversation, retrievalItems, toolStates)
+	if layer, ok := b.profilePromptLayer(ctx, input); ok {
+		ephemeralLayers = append(ephemeralLayers, layer)
+	}
 	messages, assembled := b.assembleMessages(ctx, systemContent, ephemeralLayers, skillContext, memoryContext, conversation, retrievalItems, toolStates, input.Content)
 	if assembled != nil {
 		logger.Debug("context assembled",
@@ -439,9 +448,17 @@ func (b *BaseAgent) effectivePromptToolNames(ctx context.Context) []string {
 	} else if allowed := b.config.ExecutionOptions().Tools.AllowedTools; len(allowed) > 0 {
 		names = filterStringWhitelist(names, allowed)
 	}
+	skillNames := b.skillRegistry.toolNames()
+	if rc != nil && len(rc.ToolWhitelist) > 0 {
+		skillNames = filterStringWhitelist(skillNames, rc.ToolWhitelist)
+	}
+	names = append(names, skillNames...)
 	for _, target := range runtimeHandoffTargetsFromContext(ctx, b.config.Core.ID) {
 		names = append(names, runtimeHandoffToolSchema(target).Name)
 	}
+	if toolCatalogFromContext(ctx) != nil {
+		names = append(names, toolCatalogToolName)
+	}
 	return normalizeStringSlice(names)
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	llmcore "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/types"
@@ -15,6 +16,17 @@ import (
 // =============================================================================
 // Resolver (merged from resolver.go)
 // =============================================================================
+
+// defaultAgentDrainTimeout bounds how long a replaced agent may keep serving
+// in-flight executions before it is torn down anyway.
+const defaultAgentDrainTimeout = 5 * time.Minute
+
+// agentDrainer is implemented by agents that can wait for their in-flight
+// executions to finish.
+type agentDrainer interface {
+	Drain(ctx context.Context) error
+}
+
 // CachingResolver resolves agent IDs to live Agent instances, creating them
 // on demand via AgentRegistry and caching them for reuse. It uses singleflight
 // to ensure concurrent requests for the same agentID only trigger one
@@ -27,11 +39,13 @@ type CachingResolver struct {
 	tools          ToolManager
 	logger         *zap.Logger
 	agents         sync.Map
+	definitions    sync.Map // agentID -> types.AgentConfig deployed at runtime
 	group          singleflight.Group
 	toolNames      []string
 	modelHint      string
 	providerHint   string
 	modelCatalog   *types.ModelCatalog
+	drainTimeout   time.Duration
 
 	// MongoDB persistence stores (required)
 	promptStore       PromptStoreProvider
@@ -43,12 +57,22 @@ type CachingResolver struct {
 // and main LLM gateway.
 func NewCachingResolver(registry *AgentRegistry, gateway llmcore.Gateway, logger *zap.Logger) *CachingResolver {
 	return &CachingResolver{
-		registry: registry,
-		gateway:  gateway,
-		logger:   logger,
+		registry:     registry,
+		gateway:      gateway,
+		logger:       logger,
+		drainTimeout: defaultAgentDrainTimeout,
 	}
 }
 
+// WithDrainTimeout sets how long a replaced or retired agent may finish its
+// in-flight executions before teardown. Non-positive values keep the default.
+func (r *CachingResolver) WithDrainTimeout(d time.Duration) *CachingResolver {
+	if d > 0 {
+		r.drainTimeout = d
+	}
+	return r
+}
+
 // WithMemory sets the MemoryManager used when creating new agent instances.
 // When non-nil, agents created by this resolver will have memory capabilities.
 func (r *CachingResolver) WithMemory(m MemoryManager) *CachingResolver {
@@ -151,66 +175,192 @@ func (r *CachingResolver) Resolve(ctx context.Context, agentID string) (Agent, e
 			return cached, nil
 		}
 
-		modelProvider, modelID := r.defaultResolverModelParts()
-		cfg := types.AgentConfig{
-			Core: types.CoreConfig{
-				ID:   agentID,
-				Name: agentID,
-				Type: string(TypeGeneric),
-			},
-			Model: types.ModelOptions{
-				Provider: modelProvider,
-				Model:    modelID,
-			},
-			LLM: types.LLMConfig{
-				Provider: modelProvider,
-				Model:    modelID,
-			},
-		}
-		toolNames := r.toolNames
-		if len(toolNames) == 0 && r.tools != nil {
-			schemas := r.tools.GetAllowedTools(agentID)
-			if len(schemas) > 0 {
-				toolNames = make([]string, 0, len(schemas))
-				for _, schema := range schemas {
-					name := strings.TrimSpace(schema.Name)
-					if name == "" {
-						continue
-					}
-					toolNames = append(toolNames, name)
-				}
-			}
-		}
-		if len(toolNames) > 0 {
-			cfg.Tools.AllowedTools = append([]string(nil), toolNames...)
-			cfg.Runtime.Tools = append([]string(nil), toolNames...)
-		}
-		ag, err := r.registry.Create(cfg, r.gateway, r.memory, r.tools, nil, r.logger)
+		cfg := r.resolverConfig(agentID)
+		ag, err := r.instantiate(ctx, cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create agent %q: %w", agentID, err)
+			return nil, err
 		}
 
-		// Inject MongoDB persistence stores.
-		if ba, ok := ag.(*BaseAgent); ok {
-			ba.SetPromptStore(r.promptStore)
-			ba.SetConversationStore(r.conversationStore)
-			ba.SetRunStore(r.runStore)
-			if r.enhancedMemory != nil {
-				ba.EnableEnhancedMemory(r.enhancedMemory)
+		// A concurrent Deploy may have installed an instance while this one was
+		// initialising; the deployed instance wins and the stale one is torn down.
+		if existing, loaded := r.agents.LoadOrStore(agentID, ag); loaded {
+			r.teardown(ctx, agentID, ag)
+			return existing, nil
+		}
+		return ag, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(Agent), nil
+}
+
+// resolverConfig returns the deployed definition for agentID, or a generic
+// config built from resolver defaults.
+func (r *CachingResolver) resolverConfig(agentID string) types.AgentConfig {
+	if deployed, ok := r.definitions.Load(agentID); ok {
+		return deployed.(types.AgentConfig)
+	}
+	modelProvider, modelID := r.defaultResolverModelParts()
+	cfg := types.AgentConfig{
+		Core: types.CoreConfig{
+			ID:   agentID,
+			Name: agentID,
+			Type: string(TypeGeneric),
+		},
+		Model: types.ModelOptions{
+			Provider: modelProvider,
+			Model:    modelID,
+		},
+		LLM: types.LLMConfig{
+			Provider: modelProvider,
+			Model:    modelID,
+		},
+	}
+	if toolNames := r.defaultToolNames(agentID); len(toolNames) > 0 {
+		cfg.Tools.AllowedTools = append([]string(nil), toolNames...)
+		cfg.Runtime.Tools = append([]string(nil), toolNames...)
+	}
+	return cfg
+}
+
+func (r *CachingResolver) defaultToolNames(agentID string) []string {
+	toolNames := r.toolNames
+	if len(toolNames) == 0 && r.tools != nil {
+		schemas := r.tools.GetAllowedTools(agentID)
+		if len(schemas) > 0 {
+			toolNames = make([]string, 0, len(schemas))
+			for _, schema := range schemas {
+				name := strings.TrimSpace(schema.Name)
+				if name == "" {
+					continue
+				}
+				toolNames = append(toolNames, name)
 			}
 		}
+	}
+	return toolNames
+}
+
+// instantiate creates, wires and initialises an agent from cfg without
+// touching the cache.
+func (r *CachingResolver) instantiate(ctx context.Context, cfg types.AgentConfig) (Agent, error) {
+	agentID := cfg.Core.ID
+	ag, err := r.registry.Create(cfg, r.gateway, r.memory, r.tools, nil, r.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent %q: %w", agentID, err)
+	}
 
-		if err := ag.Init(ctx); err != nil {
-			return nil, fmt.Errorf("failed to init agent %q: %w", agentID, err)
+	// Inject MongoDB persistence stores.
+	if ba, ok := ag.(*BaseAgent); ok {
+		ba.SetPromptStore(r.promptStore)
+		ba.SetConversationStore(r.conversationStore)
+		ba.SetRunStore(r.runStore)
+		if r.enhancedMemory != nil {
+			ba.EnableEnhancedMemory(r.enhancedMemory)
 		}
+	}
 
-		r.agents.Store(agentID, ag)
-		return ag, nil
-	})
+	if err := ag.Init(ctx); err != nil {
+		return nil, fmt.Errorf("failed to init agent %q: %w", agentID, err)
+	}
+	return ag, nil
+}
+
+// Deploy hot-instantiates an agent from cfg and makes it the instance returned
+// by Resolve for cfg.Core.ID. A previously running instance is retired only
+// after the replacement initialised successfully, and is torn down once its
+// in-flight executions drain. Empty type and model fields fall back to the
+// resolver defaults.
+func (r *CachingResolver) Deploy(ctx context.Context, cfg types.AgentConfig) (Agent, error) {
+	agentID := strings.TrimSpace(cfg.Core.ID)
+	if agentID == "" {
+		return nil, fmt.Errorf("agent config: id is required")
+	}
+	cfg.Core.ID = agentID
+	if cfg.Core.Name == "" {
+		cfg.Core.Name = agentID
+	}
+	if cfg.Core.Type == "" {
+		cfg.Core.Type = string(TypeGeneric)
+	}
+	if cfg.LLM.Model == "" {
+		provider, model := r.defaultResolverModelParts()
+		cfg.LLM.Provider, cfg.LLM.Model = provider, model
+		cfg.Model.Provider, cfg.Model.Model = provider, model
+	} else if cfg.LLM.Provider == "" {
+		cfg.LLM.Provider = r.defaultResolverProvider()
+		cfg.Model.Provider = cfg.LLM.Provider
+	}
+
+	ag, err := r.instantiate(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return result.(Agent), nil
+	r.definitions.Store(agentID, cfg)
+	if previous, loaded := r.agents.Swap(agentID, ag); loaded {
+		r.drainAndTeardown(ctx, agentID, previous)
+	}
+	return ag, nil
+}
+
+// Retire stops serving the running instance for agentID, tears it down once
+// its in-flight executions drain and forgets its deployed definition. Later
+// Resolve calls fall back to the resolver defaults.
+func (r *CachingResolver) Retire(ctx context.Context, agentID string) bool {
+	_, hadDefinition := r.definitions.LoadAndDelete(agentID)
+	previous, loaded := r.agents.LoadAndDelete(agentID)
+	if loaded {
+		r.drainAndTeardown(ctx, agentID, previous)
+	}
+	return hadDefinition || loaded
+}
+
+// drainAndTeardown tears down an instance that is no longer returned by
+// Resolve. Callers that resolved it earlier may still be executing, so agents
+// that can drain are torn down in the background after their executions
+// finish or the drain timeout elapses.
+func (r *CachingResolver) drainAndTeardown(ctx context.Context, agentID string, value any) {
+	drainer, ok := value.(agentDrainer)
+	if !ok {
+		r.teardown(ctx, agentID, value)
+		return
+	}
+	go func() {
+		drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.drainTimeout)
+		defer cancel()
+		if err := drainer.Drain(drainCtx); err != nil {
+			r.logger.Warn("Tearing down replaced agent before in-flight executions finished",
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+		}
+		r.teardown(context.WithoutCancel(ctx), agentID, value)
+	}()
+}
+
+// InheritDefinitions copies definitions deployed on another resolver, for
+// example when LLM hot reload rebuilds the resolver. Agents are instantiated
+// lazily on the next Resolve.
+func (r *CachingResolver) InheritDefinitions(from *CachingResolver) {
+	if from == nil || from == r {
+		return
+	}
+	from.definitions.Range(func(key, value any) bool {
+		r.definitions.Store(key, value)
+		return true
+	})
+}
+
+func (r *CachingResolver) teardown(ctx context.Context, agentID string, value any) {
+	ag, ok := value.(Agent)
+	if !ok {
+		return
+	}
+	if err := ag.Teardown(ctx); err != nil {
+		r.logger.Warn("Failed to teardown retired agent",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+	}
 }
 
 func (r *CachingResolver) defaultResolverModel() string {
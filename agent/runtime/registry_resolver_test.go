@@ -2,7 +2,9 @@ package runtime
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	llmcore "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/types"
@@ -11,6 +13,176 @@ import (
 	"go.uber.org/zap"
 )
 
+func newDeployTestResolver(t *testing.T) *CachingResolver {
+	t.Helper()
+	logger := zap.NewNop()
+	registry := NewAgentRegistry(logger)
+	registry.Register(TypeGeneric, func(cfg types.AgentConfig, gateway llmcore.Gateway, memory MemoryManager, toolManager ToolManager, bus EventBus, logger *zap.Logger) (Agent, error) {
+		return &resolverAgentStub{id: cfg.Core.ID, cfg: cfg}, nil
+	})
+	return NewCachingResolver(registry, testGateway(&captureRuntimeProvider{content: "ok"}), logger).
+		WithDefaultModel("default-model")
+}
+
+func TestCachingResolver_DeployReplacesRunningAgent(t *testing.T) {
+	ctx := context.Background()
+	resolver := newDeployTestResolver(t)
+
+	generic, err := resolver.Resolve(ctx, "writer")
+	require.NoError(t, err)
+
+	deployed, err := resolver.Deploy(ctx, types.AgentConfig{
+		Core:    types.CoreConfig{ID: "writer"},
+		LLM:     types.LLMConfig{Model: "gpt-writer"},
+		Runtime: types.RuntimeConfig{SystemPrompt: "write well"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, generic.(*resolverAgentStub).teardowns)
+
+	cfg := deployed.(*resolverAgentStub).cfg
+	assert.Equal(t, string(TypeGeneric), cfg.Core.Type)
+	assert.Equal(t, "writer", cfg.Core.Name)
+	assert.Equal(t, "gpt-writer", cfg.LLM.Model)
+	assert.Equal(t, "capture-runtime-provider", cfg.LLM.Provider)
+
+	resolved, err := resolver.Resolve(ctx, "writer")
+	require.NoError(t, err)
+	assert.Same(t, deployed, resolved)
+
+	// A cache reset re-instantiates from the deployed definition.
+	resolver.ResetCache(ctx)
+	resolved, err = resolver.Resolve(ctx, "writer")
+	require.NoError(t, err)
+	assert.Equal(t, "write well", resolved.(*resolverAgentStub).cfg.Runtime.SystemPrompt)
+
+	_, err = resolver.Deploy(ctx, types.AgentConfig{})
+	assert.Error(t, err)
+}
+
+func TestCachingResolver_DeployFailureKeepsPreviousAgent(t *testing.T) {
+	ctx := context.Background()
+	resolver := newDeployTestResolver(t)
+
+	first, err := resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "a1"}})
+	require.NoError(t, err)
+	_, err = resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "a1", Type: "unknown"}})
+	require.Error(t, err)
+
+	resolved, err := resolver.Resolve(ctx, "a1")
+	require.NoError(t, err)
+	assert.Same(t, first, resolved)
+	assert.Zero(t, first.(*resolverAgentStub).teardowns)
+}
+
+func TestCachingResolver_ResolveDoesNotOverwriteConcurrentDeploy(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	creating := make(chan struct{})
+	release := make(chan struct{})
+	var stale *resolverAgentStub
+	registry := NewAgentRegistry(logger)
+	registry.Register(TypeGeneric, func(cfg types.AgentConfig, gateway llmcore.Gateway, memory MemoryManager, toolManager ToolManager, bus EventBus, logger *zap.Logger) (Agent, error) {
+		ag := &resolverAgentStub{id: cfg.Core.ID, cfg: cfg}
+		if cfg.LLM.Model == "default-model" {
+			stale = ag
+			close(creating)
+			<-release
+		}
+		return ag, nil
+	})
+	resolver := NewCachingResolver(registry, testGateway(&captureRuntimeProvider{content: "ok"}), logger).
+		WithDefaultModel("default-model")
+
+	resolved := make(chan Agent, 1)
+	go func() {
+		ag, err := resolver.Resolve(ctx, "writer")
+		assert.NoError(t, err)
+		resolved <- ag
+	}()
+	<-creating
+
+	deployed, err := resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "writer"}, LLM: types.LLMConfig{Model: "gpt-writer"}})
+	require.NoError(t, err)
+	close(release)
+
+	assert.Same(t, deployed, <-resolved)
+	assert.Equal(t, 1, stale.teardowns, "the instance built from the stale definition is torn down")
+
+	current, err := resolver.Resolve(ctx, "writer")
+	require.NoError(t, err)
+	assert.Same(t, deployed, current)
+}
+
+func TestCachingResolver_RetireAndInheritDefinitions(t *testing.T) {
+	ctx := context.Background()
+	resolver := newDeployTestResolver(t)
+
+	deployed, err := resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "a1"}, LLM: types.LLMConfig{Model: "m1"}})
+	require.NoError(t, err)
+
+	rebuilt := newDeployTestResolver(t)
+	rebuilt.InheritDefinitions(resolver)
+	inherited, err := rebuilt.Resolve(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "m1", inherited.(*resolverAgentStub).cfg.LLM.Model)
+
+	assert.True(t, resolver.Retire(ctx, "a1"))
+	assert.Equal(t, 1, deployed.(*resolverAgentStub).teardowns)
+	assert.False(t, resolver.Retire(ctx, "a1"))
+
+	fallback, err := resolver.Resolve(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "default-model", fallback.(*resolverAgentStub).cfg.LLM.Model)
+}
+
+func TestCachingResolver_DeployDrainsReplacedAgent(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	first := &drainingAgentStub{release: make(chan struct{}), torndown: make(chan struct{})}
+	registry := NewAgentRegistry(logger)
+	registry.Register(TypeGeneric, func(cfg types.AgentConfig, gateway llmcore.Gateway, memory MemoryManager, toolManager ToolManager, bus EventBus, logger *zap.Logger) (Agent, error) {
+		if cfg.LLM.Model == "m1" {
+			first.resolverAgentStub = resolverAgentStub{id: cfg.Core.ID, cfg: cfg}
+			return first, nil
+		}
+		return &resolverAgentStub{id: cfg.Core.ID, cfg: cfg}, nil
+	})
+	resolver := NewCachingResolver(registry, testGateway(&captureRuntimeProvider{content: "ok"}), logger)
+
+	_, err := resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "a1"}, LLM: types.LLMConfig{Model: "m1"}})
+	require.NoError(t, err)
+	next, err := resolver.Deploy(ctx, types.AgentConfig{Core: types.CoreConfig{ID: "a1"}, LLM: types.LLMConfig{Model: "m2"}})
+	require.NoError(t, err)
+
+	resolved, err := resolver.Resolve(ctx, "a1")
+	require.NoError(t, err)
+	assert.Same(t, next, resolved, "new calls go to the replacement while the old instance drains")
+	select {
+	case <-first.torndown:
+		t.Fatal("replaced agent torn down while executions are in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(first.release)
+	select {
+	case <-first.torndown:
+	case <-time.After(time.Second):
+		t.Fatal("replaced agent was not torn down after draining")
+	}
+}
+
+func TestBaseAgent_DrainWaitsForInFlightExecutions(t *testing.T) {
+	agent := &BaseAgent{}
+	atomic.StoreInt64(&agent.execCount, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, agent.Drain(ctx), context.DeadlineExceeded)
+
+	atomic.StoreInt64(&agent.execCount, 0)
+	assert.NoError(t, agent.Drain(context.Background()))
+}
+
 func TestCachingResolver_DefaultModelUsesCatalogAlias(t *testing.T) {
 	logger := zap.NewNop()
 	registry := NewAgentRegistry(logger)
@@ -38,16 +210,41 @@ func TestCachingResolver_DefaultModelUsesCatalogAlias(t *testing.T) {
 	assert.Equal(t, "gpt-canonical", created.Model.Model)
 }
 
-type resolverAgentStub struct{ id string }
+type resolverAgentStub struct {
+	id        string
+	cfg       types.AgentConfig
+	teardowns int
+}
 
 func (a *resolverAgentStub) ID() string                     { return a.id }
 func (a *resolverAgentStub) Name() string                   { return a.id }
 func (a *resolverAgentStub) Type() AgentType                { return TypeGeneric }
 func (a *resolverAgentStub) State() State                   { return StateReady }
 func (a *resolverAgentStub) Init(context.Context) error     { return nil }
-func (a *resolverAgentStub) Teardown(context.Context) error { return nil }
+func (a *resolverAgentStub) Teardown(context.Context) error { a.teardowns++; return nil }
 func (a *resolverAgentStub) Plan(context.Context, *Input) (*PlanResult, error) {
 	return &PlanResult{}, nil
 }
 func (a *resolverAgentStub) Execute(context.Context, *Input) (*Output, error) { return &Output{}, nil }
 func (a *resolverAgentStub) Observe(context.Context, *Feedback) error         { return nil }
+
+// drainingAgentStub reports in-flight executions until release is closed.
+type drainingAgentStub struct {
+	resolverAgentStub
+	release  chan struct{}
+	torndown chan struct{}
+}
+
+func (a *drainingAgentStub) Drain(ctx context.Context) error {
+	select {
+	case <-a.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *drainingAgentStub) Teardown(context.Context) error {
+	close(a.torndown)
+	return nil
+}
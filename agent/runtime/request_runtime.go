@@ -25,7 +25,9 @@ type preparedRequest struct {
 	toolProvider llm.Provider // for ReAct loop (may equal chatProvider)
 	hasTools     bool
 	handoffTools map[string]RuntimeHandoffTarget
+	toolCatalog  *toolCatalog
 	toolRisks    map[string]string
+	toolScopes   map[string][]string
 	maxReActIter int
 	maxLoopIter  int
 	options      types.ExecutionOptions
@@ -62,6 +64,21 @@ func (b *BaseAgent) prepareChatRequest(ctx context.Context, messages []types.Mes
 			req.Tools = filterToolSchemasByWhitelist(allowedTools, options.Tools.AllowedTools)
 		}
 	}
+	catalog := toolCatalogFromContext(ctx)
+	if catalog != nil && !options.Tools.DisableTools {
+		req.Tools = append(req.Tools, catalog.schema())
+	} else {
+		catalog = nil
+	}
+	skillTools := b.skillRegistry.toolSchemas()
+	if len(skillTools) > 0 && !options.Tools.DisableTools {
+		if len(options.Tools.ToolWhitelist) > 0 {
+			skillTools = filterToolSchemasByWhitelist(skillTools, options.Tools.ToolWhitelist)
+		}
+		req.Tools = append(req.Tools, skillTools...)
+	} else {
+		skillTools = nil
+	}
 	handoffMap := map[string]RuntimeHandoffTarget(nil)
 	handoffTargets := runtimeHandoffTargetsFromContext(ctx, b.config.Core.ID)
 	if len(handoffTargets) > 0 {
@@ -95,6 +112,14 @@ func (b *BaseAgent) prepareChatRequest(ctx context.Context, messages []types.Mes
 	if b.hasDedicatedToolExecutionSurface() {
 		toolProv = b.gatewayToolProvider()
 	}
+	if catalog != nil {
+		chatProv = catalog.wrapProvider(chatProv)
+		toolProv = catalog.wrapProvider(toolProv)
+	}
+	if budget := runBudgetFromContext(ctx); budget != nil {
+		chatProv = budget.wrapProvider(chatProv)
+		toolProv = budget.wrapProvider(toolProv)
+	}
 
 	// 3. Effective loop budgets
 	effectiveIter := options.Control.MaxReActIterations
@@ -102,21 +127,30 @@ func (b *BaseAgent) prepareChatRequest(ctx context.Context, messages []types.Mes
 		effectiveIter = b.maxReActIterations()
 	}
 	toolRisks := make(map[string]string, len(req.Tools))
+	var toolScopes map[string][]string
 	for _, tool := range req.Tools {
 		name := strings.TrimSpace(tool.Name)
 		if name == "" {
 			continue
 		}
-		toolRisks[name] = classifyToolRiskByName(name)
+		toolRisks[name] = classifyToolRisk(tool)
+		if tool.Traits != nil && len(tool.Traits.AuthScopes) > 0 {
+			if toolScopes == nil {
+				toolScopes = make(map[string][]string)
+			}
+			toolScopes[name] = append([]string(nil), tool.Traits.AuthScopes...)
+		}
 	}
 
 	return &preparedRequest{
 		req:          req,
 		chatProvider: chatProv,
 		toolProvider: toolProv,
-		hasTools:     len(req.Tools) > 0 && (b.toolManager != nil || len(handoffTargets) > 0),
+		hasTools:     len(req.Tools) > 0 && (b.toolManager != nil || len(handoffTargets) > 0 || len(skillTools) > 0),
 		handoffTools: handoffMap,
+		toolCatalog:  catalog,
 		toolRisks:    toolRisks,
+		toolScopes:   toolScopes,
 		maxReActIter: effectiveIter,
 		maxLoopIter:  options.Control.MaxLoopIterations,
 		options:      options,
@@ -327,7 +361,21 @@ func (b *BaseAgent) Execute(ctx context.Context, input *Input) (_ *Output, execE
 	if err != nil {
 		return nil, err
 	}
-	return b.executeWithPipeline(ctx, resumeInput, b.configuredExecutionOptions())
+	if pauseSignalFromContext(ctx) == nil {
+		run := newPauseRun()
+		defer b.pauses.register(run)()
+		ctx = withPauseRun(ctx, run)
+	}
+	ctx, budget := b.startRunBudget(ctx, resumeInput)
+	execute := b.interceptors.wrapExecute(func(ctx context.Context, input *Input) (*Output, error) {
+		return b.executeWithPipeline(ctx, input, b.configuredExecutionOptions())
+	})
+	output, err := b.executeSelfHealing(ctx, resumeInput, execute)
+	budget.attach(output)
+	if err == nil {
+		b.observeProfileRun(ctx, resumeInput, output)
+	}
+	return output, err
 }
 
 func (b *BaseAgent) executeCore(ctx context.Context, input *Input) (_ *Output, execErr error) {
@@ -424,6 +472,7 @@ func (b *BaseAgent) executeCore(ctx context.Context, input *Input) (_ *Output, e
 			if runtimeGuardrailsCfg != nil {
 				failureAction = runtimeGuardrailsCfg.OnInputFailure
 			}
+			emitGuardrailTriggered(ctx, GuardrailsErrorTypeInput, failureAction, validationResult)
 
 			switch failureAction {
 			case guardrails.FailureActionReject:
@@ -510,6 +559,7 @@ func (b *BaseAgent) executeCore(ctx context.Context, input *Input) (_ *Output, e
 				if runtimeGuardrailsCfgForOutput != nil {
 					failureAction = runtimeGuardrailsCfgForOutput.OnOutputFailure
 				}
+				emitGuardrailTriggered(ctx, GuardrailsErrorTypeOutput, failureAction, lastValidationResult)
 
 				if failureAction == guardrails.FailureActionRetry && attempt < maxRetries {
 					continue
@@ -540,7 +590,7 @@ func (b *BaseAgent) executeCore(ctx context.Context, input *Input) (_ *Output, e
 		break
 	}
 
-	estimatedCost := defaultCostCalc.Calculate(resp.Provider, resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	estimatedCost := defaultCostCalc.CalculateWithReasoning(resp.Provider, resp.Model, resp.Usage.PromptTokens, resp.Usage.CachedPromptTokens(), resp.Usage.CacheCreationPromptTokens(), resp.Usage.CompletionTokens, resp.Usage.ReasoningTokens())
 
 	if b.memoryRuntime != nil {
 		if err := b.memoryRuntime.ObserveTurn(ctx, b.ID(), MemoryObservationInput{
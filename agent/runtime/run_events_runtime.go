@@ -0,0 +1,169 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// runEventBufferSize 事件通道缓冲，避免前端短暂卡顿时阻塞执行
+const runEventBufferSize = 64
+
+// ExecuteEvents 在后台执行 Execute，并以类型化事件流的形式返回执行过程：
+// started → plan_generated / llm_chunk / tool_call / tool_result / guardrail_triggered … → completed 或 failed。
+// 每个事件都带有 trace/span ID，前端无需解析日志即可渲染实时活动。
+// 通道在执行结束后关闭；调用方停止读取时应取消 ctx，否则执行会因背压而阻塞。
+func (b *BaseAgent) ExecuteEvents(ctx context.Context, input *Input) (<-chan types.RunEvent, error) {
+	if input == nil {
+		return nil, NewError(types.ErrInputValidation, "input is required")
+	}
+	return streamRunEvents(ctx, b.ID(), input, b.Execute), nil
+}
+
+type runEventExecutor func(ctx context.Context, input *Input) (*Output, error)
+
+// runEventStream 为运行时事件补齐运行标识并保证序号单调递增
+type runEventStream struct {
+	ctx     context.Context
+	ch      chan types.RunEvent
+	runID   string
+	traceID string
+	spanID  string
+	agentID string
+
+	mu       sync.Mutex
+	sequence int64
+	closed   bool
+}
+
+func streamRunEvents(ctx context.Context, agentID string, input *Input, execute runEventExecutor) <-chan types.RunEvent {
+	in := *input
+	stream := &runEventStream{
+		ch:      make(chan types.RunEvent, runEventBufferSize),
+		agentID: agentID,
+	}
+	stream.traceID, stream.spanID = runEventTraceIDs(ctx, in.TraceID)
+	in.TraceID = stream.traceID
+
+	runID, ok := types.RunID(ctx)
+	if !ok {
+		runID = fmt.Sprintf("run_%d", time.Now().UnixNano())
+		ctx = types.WithRunID(ctx, runID)
+	}
+	stream.runID = runID
+	stream.ctx = ctx
+	ctx = WithRuntimeStreamEmitter(ctx, stream.emitRuntime)
+
+	go func() {
+		defer stream.close()
+		stream.send(types.RunEvent{Type: types.RunEventStarted, Data: map[string]any{"content": in.Content}})
+		output, err := execute(ctx, &in)
+		if err != nil {
+			stream.send(types.RunEvent{Type: types.RunEventFailed, Error: err.Error()})
+			return
+		}
+		stream.send(completedRunEvent(output))
+	}()
+	return stream.ch
+}
+
+// emitRuntime 把运行时流事件映射为共享事件；计划与护栏状态提升为独立事件类型
+func (s *runEventStream) emitRuntime(event RuntimeStreamEvent) {
+	runEvent := event.RunEvent()
+	if event.Type == RuntimeStreamStatus {
+		if payload, ok := event.Data.(map[string]any); ok {
+			switch payload["status"] {
+			case "plan_generated":
+				runEvent.Type = types.RunEventPlanGenerated
+			case "guardrail_triggered":
+				runEvent.Type = types.RunEventGuardrail
+			}
+		}
+	}
+	s.send(runEvent)
+}
+
+func (s *runEventStream) send(event types.RunEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.sequence++
+	event.Sequence = s.sequence
+	event.Scope = types.RunScopeAgent
+	event.RunID = s.runID
+	event.TraceID = s.traceID
+	event.SpanID = s.spanID
+	event.AgentID = s.agentID
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	select {
+	case s.ch <- event:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *runEventStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
+
+func completedRunEvent(output *Output) types.RunEvent {
+	event := types.RunEvent{Type: types.RunEventCompleted}
+	if output == nil {
+		return event
+	}
+	event.CheckpointID = output.CheckpointID
+	if output.TokensUsed > 0 {
+		event.Usage = &types.ChatUsage{TotalTokens: output.TokensUsed}
+	}
+	event.Data = map[string]any{
+		"content":       output.Content,
+		"cost":          output.Cost,
+		"duration_ms":   output.Duration.Milliseconds(),
+		"finish_reason": output.FinishReason,
+		"stop_reason":   output.StopReason,
+	}
+	return event
+}
+
+// runEventTraceIDs 依次从输入、上下文和 OpenTelemetry span 中解析 trace/span ID
+func runEventTraceIDs(ctx context.Context, traceID string) (string, string) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if traceID == "" {
+		traceID, _ = types.TraceID(ctx)
+	}
+	if traceID == "" && spanContext.HasTraceID() {
+		traceID = spanContext.TraceID().String()
+	}
+	spanID, _ := types.SpanID(ctx)
+	if spanID == "" && spanContext.HasSpanID() {
+		spanID = spanContext.SpanID().String()
+	}
+	return traceID, spanID
+}
+
+// emitGuardrailTriggered 向运行时流报告护栏校验失败及其处置动作
+func emitGuardrailTriggered(ctx context.Context, direction GuardrailsErrorType, action guardrails.FailureAction, result *guardrails.ValidationResult) {
+	emit, ok := runtimeStreamEmitterFromContext(ctx)
+	if !ok || result == nil {
+		return
+	}
+	emitRuntimeStatus(emit, "guardrail_triggered", RuntimeStreamEvent{
+		Data: map[string]any{
+			"direction": string(direction),
+			"action":    string(action),
+			"tripwire":  result.Tripwire,
+			"errors":    result.Errors,
+		},
+	})
+}
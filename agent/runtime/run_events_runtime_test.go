@@ -0,0 +1,85 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func collectRunEvents(ch <-chan types.RunEvent) []types.RunEvent {
+	var events []types.RunEvent
+	for event := range ch {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStreamRunEventsMapsRuntimeActivity(t *testing.T) {
+	ctx := types.WithSpanID(types.WithRunID(context.Background(), "run-1"), "span-1")
+	var seenTraceID string
+	ch := streamRunEvents(ctx, "agent-1", &Input{TraceID: "trace-1", Content: "hi"}, func(ctx context.Context, input *Input) (*Output, error) {
+		seenTraceID = input.TraceID
+		emit, ok := runtimeStreamEmitterFromContext(ctx)
+		require.True(t, ok)
+		emitRuntimeStatus(emit, "plan_generated", RuntimeStreamEvent{Data: map[string]any{"steps": []string{"search"}}})
+		emit(RuntimeStreamEvent{Type: RuntimeStreamToolCall, ToolCall: &RuntimeToolCall{ID: "call-1", Name: "search"}})
+		emit(RuntimeStreamEvent{Type: RuntimeStreamToolResult, ToolResult: &RuntimeToolResult{ToolCallID: "call-1", Name: "search"}})
+		emit(RuntimeStreamEvent{Type: RuntimeStreamToken, Token: "he", Delta: "he"})
+		emitGuardrailTriggered(ctx, GuardrailsErrorTypeOutput, guardrails.FailureActionWarn, &guardrails.ValidationResult{})
+		return &Output{Content: "hello", TokensUsed: 42}, nil
+	})
+
+	events := collectRunEvents(ch)
+	require.Len(t, events, 7)
+	assert.Equal(t, "trace-1", seenTraceID)
+	wantTypes := []types.RunEventType{
+		types.RunEventStarted,
+		types.RunEventPlanGenerated,
+		types.RunEventToolCall,
+		types.RunEventToolResult,
+		types.RunEventLLMChunk,
+		types.RunEventGuardrail,
+		types.RunEventCompleted,
+	}
+	for i, event := range events {
+		assert.Equal(t, wantTypes[i], event.Type)
+		assert.Equal(t, int64(i+1), event.Sequence)
+		assert.Equal(t, "run-1", event.RunID)
+		assert.Equal(t, "trace-1", event.TraceID)
+		assert.Equal(t, "span-1", event.SpanID)
+		assert.Equal(t, "agent-1", event.AgentID)
+	}
+	assert.Equal(t, "call-1", events[2].ToolCallID)
+	require.NotNil(t, events[6].Usage)
+	assert.Equal(t, 42, events[6].Usage.TotalTokens)
+}
+
+func TestStreamRunEventsReportsFailureWithOTelIDs(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	ch := streamRunEvents(ctx, "agent-1", &Input{Content: "hi"}, func(context.Context, *Input) (*Output, error) {
+		return nil, errors.New("provider down")
+	})
+
+	events := collectRunEvents(ch)
+	require.Len(t, events, 2)
+	assert.Equal(t, types.RunEventFailed, events[1].Type)
+	assert.Equal(t, "provider down", events[1].Error)
+	assert.Equal(t, traceID.String(), events[1].TraceID)
+	assert.Equal(t, spanID.String(), events[1].SpanID)
+	assert.NotEmpty(t, events[1].RunID)
+}
+
+func TestExecuteEventsRequiresInput(t *testing.T) {
+	agent, _ := newResumeRuntimeTestAgent(t)
+	_, err := agent.ExecuteEvents(context.Background(), nil)
+	require.Error(t, err)
+}
@@ -214,7 +214,7 @@ func publicInputContext(values map[string]any) map[string]any {
 	out := make(map[string]any, len(values))
 	for key, value := range values {
 		switch key {
-		case internalContextHandoffMessages, internalContextParentHandoff, internalContextFromAgentID, internalContextHandoffTool:
+		case internalContextHandoffMessages, internalContextParentHandoff, internalContextFromAgentID, internalContextHandoffTool, internalContextSessionMessages:
 			continue
 		case "memory_context", "retrieval_context", "tool_state", "skill_context", "checkpoint_id":
 			continue
@@ -0,0 +1,264 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	observability "github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// SandboxCapability is the ledger capability used for sandbox compute entries.
+const SandboxCapability = "sandbox"
+
+// ResourceUsage captures the compute consumed by one sandbox execution.
+// Backends that can measure usage fill it in; otherwise the meter estimates
+// CPU and memory from the wall-clock duration and the configured limits.
+type ResourceUsage struct {
+	CPUSeconds      float64 `json:"cpu_seconds"`
+	MemoryGBSeconds float64 `json:"memory_gb_seconds"`
+	GPUSeconds      float64 `json:"gpu_seconds,omitempty"`
+	ImagePulls      int     `json:"image_pulls,omitempty"`
+}
+
+// Add accumulates other into u.
+func (u *ResourceUsage) Add(other ResourceUsage) {
+	u.CPUSeconds += other.CPUSeconds
+	u.MemoryGBSeconds += other.MemoryGBSeconds
+	u.GPUSeconds += other.GPUSeconds
+	u.ImagePulls += other.ImagePulls
+}
+
+// SandboxPriceTable converts resource usage into cost.
+type SandboxPriceTable struct {
+	PerCPUSecond      float64 `json:"per_cpu_second"`
+	PerMemoryGBSecond float64 `json:"per_memory_gb_second"`
+	PerGPUSecond      float64 `json:"per_gpu_second"`
+	PerImagePull      float64 `json:"per_image_pull"`
+	Currency          string  `json:"currency,omitempty"`
+}
+
+// DefaultSandboxPriceTable returns list prices roughly in line with
+// on-demand serverless compute. Override them to match your infrastructure.
+func DefaultSandboxPriceTable() SandboxPriceTable {
+	return SandboxPriceTable{
+		PerCPUSecond:      0.000024,
+		PerMemoryGBSecond: 0.0000025,
+		PerGPUSecond:      0.0008,
+		PerImagePull:      0.0001,
+		Currency:          "USD",
+	}
+}
+
+// CostBreakdown itemizes the cost of sandbox usage by resource.
+type CostBreakdown struct {
+	CPU        float64       `json:"cpu"`
+	Memory     float64       `json:"memory"`
+	GPU        float64       `json:"gpu"`
+	ImagePulls float64       `json:"image_pulls"`
+	Total      float64       `json:"total"`
+	Currency   string        `json:"currency,omitempty"`
+	Usage      ResourceUsage `json:"usage"`
+}
+
+// Add accumulates other into b.
+func (b *CostBreakdown) Add(other CostBreakdown) {
+	b.CPU += other.CPU
+	b.Memory += other.Memory
+	b.GPU += other.GPU
+	b.ImagePulls += other.ImagePulls
+	b.Total += other.Total
+	b.Usage.Add(other.Usage)
+	if b.Currency == "" {
+		b.Currency = other.Currency
+	}
+}
+
+// Price converts usage into an itemized cost.
+func (p SandboxPriceTable) Price(usage ResourceUsage) CostBreakdown {
+	b := CostBreakdown{
+		CPU:        usage.CPUSeconds * p.PerCPUSecond,
+		Memory:     usage.MemoryGBSeconds * p.PerMemoryGBSecond,
+		GPU:        usage.GPUSeconds * p.PerGPUSecond,
+		ImagePulls: float64(usage.ImagePulls) * p.PerImagePull,
+		Currency:   p.Currency,
+		Usage:      usage,
+	}
+	b.Total = b.CPU + b.Memory + b.GPU + b.ImagePulls
+	return b
+}
+
+// SandboxBudget is the subset of policy.TokenBudgetManager used to budget
+// sandbox compute alongside token spend.
+type SandboxBudget interface {
+	CheckBudget(ctx context.Context, estimatedTokens int, estimatedCost float64) error
+	RecordUsage(record policy.UsageRecord)
+}
+
+// SandboxMeterConfig configures a SandboxMeter.
+type SandboxMeterConfig struct {
+	Prices SandboxPriceTable
+	// Ledger receives one entry per metered execution (optional).
+	Ledger observability.Ledger
+	// Budget is charged with the execution cost and consulted before running (optional).
+	Budget SandboxBudget
+}
+
+// SandboxMeter meters sandbox executions, prices them, and reports the cost
+// to the usage ledger and budget. Costs are also aggregated per run so the
+// run's total compute spend can be read back.
+type SandboxMeter struct {
+	prices SandboxPriceTable
+	ledger observability.Ledger
+	budget SandboxBudget
+	logger *zap.Logger
+
+	mu   sync.RWMutex
+	runs map[string]*CostBreakdown
+}
+
+// NewSandboxMeter creates a sandbox meter.
+func NewSandboxMeter(cfg SandboxMeterConfig, logger *zap.Logger) *SandboxMeter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if cfg.Ledger == nil {
+		cfg.Ledger = observability.NewNoopLedger()
+	}
+	return &SandboxMeter{
+		prices: cfg.Prices,
+		ledger: cfg.Ledger,
+		budget: cfg.Budget,
+		logger: logger.With(zap.String("component", "sandbox_meter")),
+		runs:   make(map[string]*CostBreakdown),
+	}
+}
+
+// CheckBudget rejects the execution when the budget is throttled or exhausted.
+func (m *SandboxMeter) CheckBudget(ctx context.Context) error {
+	if budget := runBudgetFromContext(ctx); budget != nil {
+		if err := budget.exhausted(); err != nil {
+			return fmt.Errorf("sandbox budget exceeded: %w", err)
+		}
+	}
+	if m.budget == nil {
+		return nil
+	}
+	if err := m.budget.CheckBudget(ctx, 0, 0); err != nil {
+		return fmt.Errorf("sandbox budget exceeded: %w", err)
+	}
+	return nil
+}
+
+// Meter prices a finished execution, attaches the cost to the result, and
+// records it against the run, the ledger, and the budget.
+func (m *SandboxMeter) Meter(ctx context.Context, req *ExecutionRequest, config SandboxConfig, result *ExecutionResult) CostBreakdown {
+	usage := estimateResourceUsage(config, result)
+	cost := m.prices.Price(usage)
+	result.Resources = &usage
+	result.Cost = &cost
+
+	runID, _ := types.RunID(ctx)
+	if runID == "" {
+		runID = req.ID
+	}
+	m.mu.Lock()
+	total, ok := m.runs[runID]
+	if !ok {
+		total = &CostBreakdown{}
+		m.runs[runID] = total
+	}
+	total.Add(cost)
+	m.mu.Unlock()
+	if budget := runBudgetFromContext(ctx); budget != nil {
+		budget.recordCost(cost.Total)
+	}
+
+	agentID, _ := types.AgentID(ctx)
+	model := SandboxCapability + ":" + string(req.Language)
+	if m.budget != nil {
+		m.budget.RecordUsage(policy.UsageRecord{
+			Timestamp: time.Now(),
+			Cost:      cost.Total,
+			Model:     model,
+			RequestID: runID,
+			AgentID:   agentID,
+		})
+	}
+
+	traceID, _ := types.TraceID(ctx)
+	entry := observability.LedgerEntry{
+		Timestamp:  time.Now(),
+		TraceID:    traceID,
+		Capability: SandboxCapability,
+		Provider:   SandboxCapability,
+		Model:      model,
+		Cost:       llmcore.Cost{AmountUSD: cost.Total, Currency: cost.Currency},
+		Metadata: map[string]string{
+			"run_id":            runID,
+			"agent_id":          agentID,
+			"execution_id":      req.ID,
+			"cpu_seconds":       fmt.Sprintf("%.3f", usage.CPUSeconds),
+			"memory_gb_seconds": fmt.Sprintf("%.3f", usage.MemoryGBSeconds),
+			"gpu_seconds":       fmt.Sprintf("%.3f", usage.GPUSeconds),
+			"image_pulls":       fmt.Sprintf("%d", usage.ImagePulls),
+		},
+	}
+	if err := m.ledger.Record(ctx, entry); err != nil {
+		m.logger.Warn("failed to record sandbox cost", zap.String("run_id", runID), zap.Error(err))
+	}
+	return cost
+}
+
+// RunCost returns the accumulated sandbox cost of a run.
+func (m *SandboxMeter) RunCost(runID string) (CostBreakdown, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	total, ok := m.runs[runID]
+	if !ok {
+		return CostBreakdown{}, false
+	}
+	return *total, true
+}
+
+// ReleaseRun drops the accumulated cost of a finished run and returns it.
+func (m *SandboxMeter) ReleaseRun(runID string) (CostBreakdown, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total, ok := m.runs[runID]
+	if !ok {
+		return CostBreakdown{}, false
+	}
+	delete(m.runs, runID)
+	return *total, true
+}
+
+// estimateResourceUsage prefers backend-reported usage and falls back to
+// duration multiplied by the configured CPU and memory limits.
+func estimateResourceUsage(config SandboxConfig, result *ExecutionResult) ResourceUsage {
+	var usage ResourceUsage
+	if result.Resources != nil {
+		usage = *result.Resources
+	}
+	seconds := result.Duration.Seconds()
+	if usage.CPUSeconds <= 0 {
+		cores := 1.0
+		if config.MaxCPUPercent > 0 {
+			cores = float64(config.MaxCPUPercent) / 100.0
+		}
+		usage.CPUSeconds = seconds * cores
+	}
+	if usage.MemoryGBSeconds <= 0 {
+		gb := float64(config.MaxMemoryMB) / 1024.0
+		if result.MemoryUsed > 0 {
+			gb = float64(result.MemoryUsed) / (1024 * 1024 * 1024)
+		}
+		usage.MemoryGBSeconds = seconds * gb
+	}
+	return usage
+}
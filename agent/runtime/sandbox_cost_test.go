@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	observability "github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLedger struct {
+	mu      sync.Mutex
+	entries []observability.LedgerEntry
+}
+
+func (l *recordingLedger) Record(_ context.Context, entry observability.LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+type recordingBudget struct {
+	checkErr error
+	records  []policy.UsageRecord
+}
+
+func (b *recordingBudget) CheckBudget(context.Context, int, float64) error { return b.checkErr }
+
+func (b *recordingBudget) RecordUsage(record policy.UsageRecord) {
+	b.records = append(b.records, record)
+}
+
+func testPrices() SandboxPriceTable {
+	return SandboxPriceTable{PerCPUSecond: 1, PerMemoryGBSecond: 0.5, PerGPUSecond: 10, PerImagePull: 2, Currency: "USD"}
+}
+
+func TestSandboxPriceTable_Price(t *testing.T) {
+	cost := testPrices().Price(ResourceUsage{CPUSeconds: 2, MemoryGBSeconds: 4, GPUSeconds: 1, ImagePulls: 1})
+	assert.InDelta(t, 2.0, cost.CPU, 1e-9)
+	assert.InDelta(t, 2.0, cost.Memory, 1e-9)
+	assert.InDelta(t, 10.0, cost.GPU, 1e-9)
+	assert.InDelta(t, 2.0, cost.ImagePulls, 1e-9)
+	assert.InDelta(t, 16.0, cost.Total, 1e-9)
+}
+
+func TestSandboxExecutor_MetersCostIntoLedgerAndBudget(t *testing.T) {
+	backend := &testBackend{executeFn: func(_ context.Context, req *ExecutionRequest, _ SandboxConfig) (*ExecutionResult, error) {
+		return &ExecutionResult{
+			ID:        req.ID,
+			Success:   true,
+			Duration:  2 * time.Second,
+			Resources: &ResourceUsage{GPUSeconds: 1, ImagePulls: 1},
+		}, nil
+	}}
+	config := DefaultSandboxConfig()
+	config.MaxCPUPercent = 50
+	config.MaxMemoryMB = 1024
+
+	ledger := &recordingLedger{}
+	budget := &recordingBudget{}
+	executor := NewSandboxExecutor(config, backend, nil)
+	meter := NewSandboxMeter(SandboxMeterConfig{Prices: testPrices(), Ledger: ledger, Budget: budget}, nil)
+	executor.SetMeter(meter)
+
+	ctx := types.WithAgentID(types.WithRunID(context.Background(), "run-1"), "agent-a")
+	for i := 0; i < 2; i++ {
+		result, err := executor.Execute(ctx, &ExecutionRequest{ID: "exec", Language: LangPython, Code: "print(1)"})
+		require.NoError(t, err)
+		require.NotNil(t, result.Cost)
+		// cpu 2s*0.5 core + mem 2s*1GB*0.5 + gpu 10 + pull 2
+		assert.InDelta(t, 14.0, result.Cost.Total, 1e-9)
+		assert.InDelta(t, 1.0, result.Resources.CPUSeconds, 1e-9)
+	}
+
+	total, ok := meter.RunCost("run-1")
+	require.True(t, ok)
+	assert.InDelta(t, 28.0, total.Total, 1e-9)
+	assert.Equal(t, 2, total.Usage.ImagePulls)
+
+	require.Len(t, ledger.entries, 2)
+	assert.Equal(t, SandboxCapability, ledger.entries[0].Capability)
+	assert.Equal(t, "run-1", ledger.entries[0].Metadata["run_id"])
+	assert.InDelta(t, 14.0, ledger.entries[0].Cost.AmountUSD, 1e-9)
+
+	require.Len(t, budget.records, 2)
+	assert.Equal(t, "agent-a", budget.records[0].AgentID)
+	assert.Equal(t, "sandbox:python", budget.records[0].Model)
+	assert.Zero(t, budget.records[0].Tokens)
+
+	released, ok := meter.ReleaseRun("run-1")
+	require.True(t, ok)
+	assert.InDelta(t, 28.0, released.Total, 1e-9)
+	_, ok = meter.RunCost("run-1")
+	assert.False(t, ok)
+}
+
+func TestSandboxExecutor_BudgetExhaustedBlocksExecution(t *testing.T) {
+	called := false
+	backend := &testBackend{executeFn: func(_ context.Context, req *ExecutionRequest, _ SandboxConfig) (*ExecutionResult, error) {
+		called = true
+		return &ExecutionResult{ID: req.ID, Success: true}, nil
+	}}
+	executor := NewSandboxExecutor(DefaultSandboxConfig(), backend, nil)
+	executor.SetMeter(NewSandboxMeter(SandboxMeterConfig{
+		Prices: testPrices(),
+		Budget: &recordingBudget{checkErr: errors.New("would exceed daily cost limit")},
+	}, nil))
+
+	_, err := executor.Execute(context.Background(), &ExecutionRequest{ID: "exec", Language: LangPython, Code: "print(1)"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sandbox budget exceeded")
+	assert.False(t, called)
+	assert.Equal(t, int64(1), executor.Stats().FailedExecutions)
+}
+
+func TestSandboxMeter_FeedsTokenBudgetManager(t *testing.T) {
+	cfg := policy.DefaultBudgetConfig()
+	cfg.MaxCostPerDay = 20
+	manager := policy.NewTokenBudgetManager(cfg, nil)
+
+	executor := NewSandboxExecutor(DefaultSandboxConfig(), &testBackend{executeFn: func(_ context.Context, req *ExecutionRequest, _ SandboxConfig) (*ExecutionResult, error) {
+		return &ExecutionResult{ID: req.ID, Success: true, Duration: time.Second, Resources: &ResourceUsage{GPUSeconds: 2}}, nil
+	}}, nil)
+	executor.SetMeter(NewSandboxMeter(SandboxMeterConfig{Prices: testPrices(), Budget: manager}, nil))
+
+	req := &ExecutionRequest{ID: "exec", Language: LangPython, Code: "print(1)"}
+	_, err := executor.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Greater(t, manager.GetStatus().CostUsedDay, 20.0)
+
+	// Compute spend now counts toward the daily cost, so both sandbox and LLM calls are rejected.
+	_, err = executor.Execute(context.Background(), req)
+	require.Error(t, err)
+	assert.Error(t, manager.CheckBudget(context.Background(), 10, 0.01))
+}
@@ -0,0 +1,310 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image pinning errors.
+var (
+	ErrImageNotPinned      = errors.New("sandbox image must be pinned by sha256 digest")
+	ErrImageDigestMismatch = errors.New("sandbox image digest does not match local image")
+	ErrNoImageRollout      = errors.New("no image rollout in progress")
+)
+
+var imageDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ImagePin identifies a container image by repository and content digest.
+// Tags are mutable, so execution always references the digest.
+type ImagePin struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+}
+
+// Validate checks that the pin carries a well-formed sha256 digest.
+func (p ImagePin) Validate() error {
+	if strings.TrimSpace(p.Image) == "" {
+		return fmt.Errorf("%w: image is required", ErrImageNotPinned)
+	}
+	if strings.Contains(p.Image, "@") {
+		return fmt.Errorf("%w: put the digest in the digest field, not in image %q", ErrImageNotPinned, p.Image)
+	}
+	if !imageDigestPattern.MatchString(p.Digest) {
+		return fmt.Errorf("%w: image %q has invalid digest %q", ErrImageNotPinned, p.Image, p.Digest)
+	}
+	return nil
+}
+
+// Reference returns the immutable image reference passed to the container runtime.
+// Any tag on Image is dropped because the digest alone determines the content.
+func (p ImagePin) Reference() string {
+	return imageRepository(p.Image) + "@" + p.Digest
+}
+
+// imageRepository strips a trailing ":tag" while keeping registry ports intact.
+func imageRepository(image string) string {
+	slash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > slash {
+		return image[:colon]
+	}
+	return image
+}
+
+// ResourcePreset holds per-language execution defaults.
+// Zero fields fall back to the sandbox-wide SandboxConfig values.
+type ResourcePreset struct {
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	MaxMemoryMB   int           `json:"max_memory_mb,omitempty"`
+	MaxCPUPercent int           `json:"max_cpu_percent,omitempty"`
+}
+
+// DefaultResourcePresets returns presets sized for each language's toolchain:
+// compiled languages need more memory and time for the build step.
+func DefaultResourcePresets() map[Language]ResourcePreset {
+	return map[Language]ResourcePreset{
+		LangPython:     {Timeout: 30 * time.Second, MaxMemoryMB: 512, MaxCPUPercent: 50},
+		LangJavaScript: {Timeout: 30 * time.Second, MaxMemoryMB: 512, MaxCPUPercent: 50},
+		LangTypeScript: {Timeout: 45 * time.Second, MaxMemoryMB: 768, MaxCPUPercent: 50},
+		LangBash:       {Timeout: 15 * time.Second, MaxMemoryMB: 128, MaxCPUPercent: 25},
+		LangGo:         {Timeout: 60 * time.Second, MaxMemoryMB: 1024, MaxCPUPercent: 100},
+		LangRust:       {Timeout: 120 * time.Second, MaxMemoryMB: 2048, MaxCPUPercent: 100},
+	}
+}
+
+// ForLanguage returns a copy of the config with the language preset applied.
+func (c SandboxConfig) ForLanguage(lang Language) SandboxConfig {
+	preset, ok := c.Presets[lang]
+	if !ok {
+		return c
+	}
+	if preset.Timeout > 0 {
+		c.Timeout = preset.Timeout
+	}
+	if preset.MaxMemoryMB > 0 {
+		c.MaxMemoryMB = preset.MaxMemoryMB
+	}
+	if preset.MaxCPUPercent > 0 {
+		c.MaxCPUPercent = preset.MaxCPUPercent
+	}
+	return c
+}
+
+// ImageRollout gradually shifts a share of executions to a candidate image.
+type ImageRollout struct {
+	Candidate ImagePin  `json:"candidate"`
+	Percent   int       `json:"percent"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LanguageImageStatus describes the pinned image and any rollout for a language.
+type LanguageImageStatus struct {
+	Language Language      `json:"language"`
+	Stable   ImagePin      `json:"stable"`
+	Rollout  *ImageRollout `json:"rollout,omitempty"`
+}
+
+// DigestResolver reports the repository digests of a locally available image.
+type DigestResolver interface {
+	RepoDigests(ctx context.Context, reference string) ([]string, error)
+}
+
+// ImageCatalog maps languages to digest-pinned images and manages gradual rollouts.
+// It is safe for concurrent use.
+type ImageCatalog struct {
+	mu       sync.RWMutex
+	stable   map[Language]ImagePin
+	rollouts map[Language]*ImageRollout
+	now      func() time.Time
+}
+
+// NewImageCatalog creates a catalog; every pin must carry a sha256 digest.
+func NewImageCatalog(pins map[Language]ImagePin) (*ImageCatalog, error) {
+	stable := make(map[Language]ImagePin, len(pins))
+	for lang, pin := range pins {
+		if err := pin.Validate(); err != nil {
+			return nil, fmt.Errorf("language %s: %w", lang, err)
+		}
+		stable[lang] = pin
+	}
+	return &ImageCatalog{
+		stable:   stable,
+		rollouts: make(map[Language]*ImageRollout),
+		now:      time.Now,
+	}, nil
+}
+
+// Resolve picks the image for an execution. The routing key (usually the
+// request ID) is hashed into a stable bucket so retries land on the same image.
+func (c *ImageCatalog) Resolve(lang Language, routingKey string) (ImagePin, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	pin, ok := c.stable[lang]
+	if !ok {
+		return ImagePin{}, false
+	}
+	if rollout := c.rollouts[lang]; rollout != nil && rolloutBucket(lang, routingKey) < rollout.Percent {
+		return rollout.Candidate, true
+	}
+	return pin, true
+}
+
+func rolloutBucket(lang Language, routingKey string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(string(lang) + "\x00" + routingKey))
+	return int(h.Sum32() % 100)
+}
+
+// StartRollout begins routing percent% of executions to the candidate image.
+// Starting a rollout while another is active replaces it.
+func (c *ImageCatalog) StartRollout(lang Language, candidate ImagePin, percent int) (*ImageRollout, error) {
+	if err := candidate.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateRolloutPercent(percent); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.stable[lang]; !ok {
+		return nil, fmt.Errorf("no image pinned for language %s", lang)
+	}
+	now := c.now()
+	rollout := &ImageRollout{Candidate: candidate, Percent: percent, StartedAt: now, UpdatedAt: now}
+	c.rollouts[lang] = rollout
+	copied := *rollout
+	return &copied, nil
+}
+
+// SetRolloutPercent adjusts the share of traffic on the candidate image.
+func (c *ImageCatalog) SetRolloutPercent(lang Language, percent int) (*ImageRollout, error) {
+	if err := validateRolloutPercent(percent); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rollout := c.rollouts[lang]
+	if rollout == nil {
+		return nil, fmt.Errorf("%w for language %s", ErrNoImageRollout, lang)
+	}
+	rollout.Percent = percent
+	rollout.UpdatedAt = c.now()
+	copied := *rollout
+	return &copied, nil
+}
+
+// PromoteRollout makes the candidate the stable image and ends the rollout.
+func (c *ImageCatalog) PromoteRollout(lang Language) (ImagePin, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rollout := c.rollouts[lang]
+	if rollout == nil {
+		return ImagePin{}, fmt.Errorf("%w for language %s", ErrNoImageRollout, lang)
+	}
+	c.stable[lang] = rollout.Candidate
+	delete(c.rollouts, lang)
+	return rollout.Candidate, nil
+}
+
+// AbortRollout ends the rollout and routes all executions back to the stable image.
+func (c *ImageCatalog) AbortRollout(lang Language) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rollouts[lang] == nil {
+		return fmt.Errorf("%w for language %s", ErrNoImageRollout, lang)
+	}
+	delete(c.rollouts, lang)
+	return nil
+}
+
+// Status returns the catalog state sorted by language.
+func (c *ImageCatalog) Status() []LanguageImageStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]LanguageImageStatus, 0, len(c.stable))
+	for lang, pin := range c.stable {
+		status := LanguageImageStatus{Language: lang, Stable: pin}
+		if rollout := c.rollouts[lang]; rollout != nil {
+			copied := *rollout
+			status.Rollout = &copied
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Language < out[j].Language })
+	return out
+}
+
+// Verify checks that every pinned and candidate image is present locally with
+// the pinned digest. Call it at startup so a tampered or missing image fails fast.
+func (c *ImageCatalog) Verify(ctx context.Context, resolver DigestResolver) error {
+	var errs []error
+	for _, status := range c.Status() {
+		pins := []ImagePin{status.Stable}
+		if status.Rollout != nil {
+			pins = append(pins, status.Rollout.Candidate)
+		}
+		for _, pin := range pins {
+			if err := pin.Verify(ctx, resolver); err != nil {
+				errs = append(errs, fmt.Errorf("language %s: %w", status.Language, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Verify checks that the image is present locally with the pinned digest.
+func (p ImagePin) Verify(ctx context.Context, resolver DigestResolver) error {
+	digests, err := resolver.RepoDigests(ctx, p.Reference())
+	if err != nil {
+		return fmt.Errorf("inspect image %s: %w", p.Reference(), err)
+	}
+	repo := imageRepository(p.Image)
+	for _, d := range digests {
+		name, digest, ok := strings.Cut(d, "@")
+		if ok && digest == p.Digest && repoNameMatches(name, repo) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s (local digests: %s)", ErrImageDigestMismatch, p.Reference(), strings.Join(digests, ", "))
+}
+
+// repoNameMatches tolerates the implicit docker.io/library/ prefix docker reports.
+func repoNameMatches(reported, pinned string) bool {
+	normalize := func(name string) string {
+		name = strings.TrimPrefix(name, "docker.io/")
+		return strings.TrimPrefix(name, "library/")
+	}
+	return normalize(reported) == normalize(pinned)
+}
+
+func validateRolloutPercent(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("rollout percent must be in [0, 100], got %d", percent)
+	}
+	return nil
+}
+
+// DockerDigestResolver resolves repository digests via `docker image inspect`.
+type DockerDigestResolver struct{}
+
+// RepoDigests returns the RepoDigests of a local image.
+func (DockerDigestResolver) RepoDigests(ctx context.Context, reference string) ([]string, error) {
+	cmd := execCommandContext(ctx, "docker", "image", "inspect", "--format", "{{json .RepoDigests}}", reference)
+	stdout, stderr, err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	var digests []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &digests); err != nil {
+		return nil, fmt.Errorf("parse repo digests: %w", err)
+	}
+	return digests, nil
+}
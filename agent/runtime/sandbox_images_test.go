@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDigest(seed string) string {
+	return "sha256:" + strings.Repeat(seed, 64)[:64]
+}
+
+type fakeDigestResolver struct {
+	digests map[string][]string
+}
+
+func (r *fakeDigestResolver) RepoDigests(_ context.Context, reference string) ([]string, error) {
+	digests, ok := r.digests[reference]
+	if !ok {
+		return nil, fmt.Errorf("no such image: %s", reference)
+	}
+	return digests, nil
+}
+
+func TestImagePin_ValidateAndReference(t *testing.T) {
+	pin := ImagePin{Image: "python:3.12-slim", Digest: testDigest("a")}
+	require.NoError(t, pin.Validate())
+	assert.Equal(t, "python@"+testDigest("a"), pin.Reference())
+
+	registry := ImagePin{Image: "registry.local:5000/sandbox/node:20", Digest: testDigest("b")}
+	assert.Equal(t, "registry.local:5000/sandbox/node@"+testDigest("b"), registry.Reference())
+
+	assert.ErrorIs(t, ImagePin{Image: "python:3.12-slim"}.Validate(), ErrImageNotPinned)
+	assert.ErrorIs(t, ImagePin{Image: "python", Digest: "sha256:abc"}.Validate(), ErrImageNotPinned)
+	assert.ErrorIs(t, ImagePin{Image: "python@" + testDigest("a"), Digest: testDigest("a")}.Validate(), ErrImageNotPinned)
+
+	_, err := NewImageCatalog(map[Language]ImagePin{LangPython: {Image: "python:latest"}})
+	assert.ErrorIs(t, err, ErrImageNotPinned)
+}
+
+func TestImageCatalog_GradualRollout(t *testing.T) {
+	stable := ImagePin{Image: "python", Digest: testDigest("a")}
+	candidate := ImagePin{Image: "python", Digest: testDigest("b")}
+	catalog, err := NewImageCatalog(map[Language]ImagePin{LangPython: stable})
+	require.NoError(t, err)
+
+	pin, ok := catalog.Resolve(LangPython, "req-1")
+	require.True(t, ok)
+	assert.Equal(t, stable, pin)
+	_, ok = catalog.Resolve(LangGo, "req-1")
+	assert.False(t, ok)
+
+	_, err = catalog.StartRollout(LangPython, candidate, 25)
+	require.NoError(t, err)
+	onCandidate := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("req-%d", i)
+		first, _ := catalog.Resolve(LangPython, key)
+		again, _ := catalog.Resolve(LangPython, key)
+		assert.Equal(t, first, again, "routing must be sticky per key")
+		if first == candidate {
+			onCandidate++
+		}
+	}
+	assert.InDelta(t, 250, onCandidate, 60)
+
+	_, err = catalog.SetRolloutPercent(LangPython, 100)
+	require.NoError(t, err)
+	pin, _ = catalog.Resolve(LangPython, "req-1")
+	assert.Equal(t, candidate, pin)
+
+	promoted, err := catalog.PromoteRollout(LangPython)
+	require.NoError(t, err)
+	assert.Equal(t, candidate, promoted)
+	status := catalog.Status()
+	require.Len(t, status, 1)
+	assert.Equal(t, candidate, status[0].Stable)
+	assert.Nil(t, status[0].Rollout)
+
+	assert.ErrorIs(t, catalog.AbortRollout(LangPython), ErrNoImageRollout)
+	_, err = catalog.SetRolloutPercent(LangPython, 10)
+	assert.ErrorIs(t, err, ErrNoImageRollout)
+	_, err = catalog.StartRollout(LangPython, candidate, 101)
+	assert.Error(t, err)
+}
+
+func TestImageCatalog_Verify(t *testing.T) {
+	stable := ImagePin{Image: "python:3.12-slim", Digest: testDigest("a")}
+	candidate := ImagePin{Image: "python:3.13-slim", Digest: testDigest("c")}
+	catalog, err := NewImageCatalog(map[Language]ImagePin{LangPython: stable})
+	require.NoError(t, err)
+
+	resolver := &fakeDigestResolver{digests: map[string][]string{
+		stable.Reference(): {"docker.io/library/python@" + testDigest("a")},
+	}}
+	require.NoError(t, catalog.Verify(context.Background(), resolver))
+
+	// 候选镜像本地缺失或 digest 不符时启动校验失败
+	_, err = catalog.StartRollout(LangPython, candidate, 10)
+	require.NoError(t, err)
+	assert.Error(t, catalog.Verify(context.Background(), resolver))
+
+	resolver.digests[candidate.Reference()] = []string{"python@" + testDigest("d")}
+	assert.ErrorIs(t, catalog.Verify(context.Background(), resolver), ErrImageDigestMismatch)
+}
+
+func TestSandboxExecutor_AppliesLanguagePresets(t *testing.T) {
+	var seen SandboxConfig
+	backend := &testBackend{
+		executeFn: func(ctx context.Context, req *ExecutionRequest, config SandboxConfig) (*ExecutionResult, error) {
+			seen = config
+			if req.Language == LangGo {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+				assert.Greater(t, time.Until(deadline), 50*time.Second)
+			}
+			return &ExecutionResult{ID: req.ID, Success: true}, nil
+		},
+	}
+	cfg := DefaultSandboxConfig()
+	cfg.AllowedLanguages = []Language{LangPython, LangGo}
+	cfg.Presets = DefaultResourcePresets()
+	cfg.Presets[LangPython] = ResourcePreset{MaxMemoryMB: 256}
+	exec := NewSandboxExecutor(cfg, backend, nil)
+
+	_, err := exec.Execute(context.Background(), &ExecutionRequest{ID: "go", Language: LangGo, Code: "package main"})
+	require.NoError(t, err)
+	assert.Equal(t, 1024, seen.MaxMemoryMB)
+	assert.Equal(t, 100, seen.MaxCPUPercent)
+
+	_, err = exec.Execute(context.Background(), &ExecutionRequest{ID: "py", Language: LangPython, Code: "pass", Timeout: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 256, seen.MaxMemoryMB)
+	assert.Equal(t, cfg.MaxCPUPercent, seen.MaxCPUPercent)
+}
+
+func TestDockerBackend_UsesPinnedCatalogImage(t *testing.T) {
+	pin := ImagePin{Image: "python:3.12-slim", Digest: testDigest("e")}
+	catalog, err := NewImageCatalog(map[Language]ImagePin{LangPython: pin})
+	require.NoError(t, err)
+	d := NewDockerBackendWithConfig(nil, DockerBackendConfig{Catalog: catalog})
+
+	image, ok := d.imageFor(&ExecutionRequest{ID: "r", Language: LangPython})
+	require.True(t, ok)
+	assert.Equal(t, pin.Reference(), image)
+
+	// 未在 catalog 中固定的语言回退到默认镜像
+	image, ok = d.imageFor(&ExecutionRequest{ID: "r", Language: LangBash})
+	require.True(t, ok)
+	assert.Equal(t, "alpine:latest", image)
+}
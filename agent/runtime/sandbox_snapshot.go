@@ -0,0 +1,505 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrSnapshotNotFound is returned when no snapshot exists for a scope and setup.
+var ErrSnapshotNotFound = errors.New("sandbox snapshot not found")
+
+// SnapshotFormat selects how a prepared sandbox is persisted.
+type SnapshotFormat string
+
+const (
+	// SnapshotImage commits the prepared container as a local image layer.
+	SnapshotImage SnapshotFormat = "image"
+	// SnapshotTarball additionally saves the image to a tarball so it can be
+	// restored after the local image is pruned or on another host.
+	SnapshotTarball SnapshotFormat = "tarball"
+)
+
+// SnapshotSpec describes the environment to prepare: a base image plus a setup
+// script (dependency install, data load) run once before snapshotting.
+type SnapshotSpec struct {
+	// Scope ties the snapshot to a session or workflow; empty shares it across
+	// every run with the same base image and setup.
+	Scope     string   `json:"scope,omitempty"`
+	Language  Language `json:"language"`
+	BaseImage string   `json:"base_image"`
+	Setup     string   `json:"setup"`
+	// Files are written to /workspace before Setup runs.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// Fingerprint identifies the prepared content independent of scope.
+func (s SnapshotSpec) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", s.BaseImage, s.Language, s.Setup)
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, s.Files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func (s SnapshotSpec) validate() error {
+	if strings.TrimSpace(s.BaseImage) == "" {
+		return fmt.Errorf("snapshot base image is required")
+	}
+	for name := range s.Files {
+		if name == "" || strings.Contains(name, "..") || filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+			return fmt.Errorf("invalid snapshot filename: %s (path traversal not allowed)", name)
+		}
+	}
+	return nil
+}
+
+// Key is the snapshot cache key: scope plus content fingerprint.
+func (s SnapshotSpec) Key() string {
+	return s.Scope + "/" + s.Fingerprint()
+}
+
+// SandboxSnapshot is a reusable prepared sandbox environment.
+type SandboxSnapshot struct {
+	Key         string         `json:"key"`
+	Scope       string         `json:"scope,omitempty"`
+	Language    Language       `json:"language"`
+	BaseImage   string         `json:"base_image"`
+	Fingerprint string         `json:"fingerprint"`
+	Format      SnapshotFormat `json:"format"`
+	// Image is the committed image reference executions run from.
+	Image string `json:"image"`
+	// Archive is the tarball path when Format is SnapshotTarball.
+	Archive       string        `json:"archive,omitempty"`
+	SetupDuration time.Duration `json:"setup_duration"`
+	CreatedAt     time.Time     `json:"created_at"`
+	LastUsedAt    time.Time     `json:"last_used_at"`
+	Uses          int64         `json:"uses"`
+}
+
+// SnapshotDriver creates, restores and removes snapshots in a container runtime.
+type SnapshotDriver interface {
+	// Create runs spec.Setup on spec.BaseImage and persists the result.
+	Create(ctx context.Context, spec SnapshotSpec, format SnapshotFormat) (*SandboxSnapshot, error)
+	// Restore makes the snapshot image runnable locally, loading the archive if needed.
+	Restore(ctx context.Context, snapshot *SandboxSnapshot) error
+	// Remove deletes the snapshot image and archive.
+	Remove(ctx context.Context, snapshot *SandboxSnapshot) error
+}
+
+// SnapshotManagerConfig configures snapshot reuse and retention.
+type SnapshotManagerConfig struct {
+	Format SnapshotFormat
+	// TTL evicts snapshots unused for longer than this; zero keeps them until evicted.
+	TTL time.Duration
+	// MaxSnapshots caps retained snapshots, evicting the least recently used; zero means unlimited.
+	MaxSnapshots int
+}
+
+// SnapshotStats reports snapshot reuse.
+type SnapshotStats struct {
+	Created  int64 `json:"created"`
+	Restored int64 `json:"restored"`
+	Evicted  int64 `json:"evicted"`
+	// SetupTimeSaved sums the setup durations skipped by restoring snapshots.
+	SetupTimeSaved time.Duration `json:"setup_time_saved"`
+}
+
+// SnapshotManager snapshots prepared sandboxes and restores them on demand, so
+// later runs of the same session or workflow skip the setup step.
+type SnapshotManager struct {
+	driver   SnapshotDriver
+	config   SnapshotManagerConfig
+	logger   *zap.Logger
+	now      func() time.Time
+	mu       sync.Mutex
+	items    map[string]*SandboxSnapshot
+	inflight map[string]*snapshotCall
+	stats    SnapshotStats
+}
+
+type snapshotCall struct {
+	done     chan struct{}
+	snapshot *SandboxSnapshot
+	err      error
+}
+
+// NewSnapshotManager creates a snapshot manager backed by driver.
+func NewSnapshotManager(driver SnapshotDriver, config SnapshotManagerConfig, logger *zap.Logger) *SnapshotManager {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if config.Format == "" {
+		config.Format = SnapshotImage
+	}
+	return &SnapshotManager{
+		driver:   driver,
+		config:   config,
+		logger:   logger.With(zap.String("component", "sandbox_snapshots")),
+		now:      time.Now,
+		items:    make(map[string]*SandboxSnapshot),
+		inflight: make(map[string]*snapshotCall),
+	}
+}
+
+// Acquire returns a runnable snapshot for spec, restoring an existing one or
+// creating it on first use. Concurrent callers for the same key share one setup.
+// Creation ignores ctx cancellation so that a short execution timeout does not
+// abort a long first-time setup; the driver bounds setup time instead.
+func (m *SnapshotManager) Acquire(ctx context.Context, spec SnapshotSpec) (*SandboxSnapshot, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	key := spec.Key()
+
+	m.mu.Lock()
+	if snap, ok := m.items[key]; ok && !m.expiredLocked(snap) {
+		m.mu.Unlock()
+		return m.restore(ctx, snap)
+	}
+	if call, ok := m.inflight[key]; ok {
+		m.mu.Unlock()
+		select {
+		case <-call.done:
+			if call.err != nil {
+				return nil, call.err
+			}
+			return m.restore(ctx, call.snapshot)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &snapshotCall{done: make(chan struct{})}
+	m.inflight[key] = call
+	m.mu.Unlock()
+
+	snap, err := m.driver.Create(context.WithoutCancel(ctx), spec, m.config.Format)
+	if err == nil {
+		snap.Key = key
+		snap.LastUsedAt = m.now()
+		snap.Uses = 1
+	}
+
+	m.mu.Lock()
+	delete(m.inflight, key)
+	call.snapshot, call.err = snap, err
+	var out *SandboxSnapshot
+	if err == nil {
+		m.items[key] = snap
+		m.stats.Created++
+		out = cloneSnapshot(snap)
+	}
+	evicted := m.collectEvictionsLocked()
+	m.mu.Unlock()
+	close(call.done)
+
+	m.removeAll(evicted)
+	if err != nil {
+		return nil, fmt.Errorf("create sandbox snapshot: %w", err)
+	}
+	m.logger.Info("sandbox snapshot created",
+		zap.String("key", key),
+		zap.String("image", out.Image),
+		zap.Duration("setup_duration", out.SetupDuration))
+	return out, nil
+}
+
+// Lookup returns the snapshot for spec without creating or restoring it.
+func (m *SnapshotManager) Lookup(spec SnapshotSpec) (*SandboxSnapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap, ok := m.items[spec.Key()]
+	if !ok || m.expiredLocked(snap) {
+		return nil, false
+	}
+	return cloneSnapshot(snap), true
+}
+
+// List returns retained snapshots, most recently used first.
+func (m *SnapshotManager) List() []SandboxSnapshot {
+	m.mu.Lock()
+	out := make([]SandboxSnapshot, 0, len(m.items))
+	for _, snap := range m.items {
+		out = append(out, *snap)
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+	return out
+}
+
+// EvictScope removes every snapshot of a session or workflow, e.g. when it ends.
+func (m *SnapshotManager) EvictScope(ctx context.Context, scope string) int {
+	m.mu.Lock()
+	var evicted []*SandboxSnapshot
+	for key, snap := range m.items {
+		if snap.Scope == scope {
+			delete(m.items, key)
+			evicted = append(evicted, snap)
+		}
+	}
+	m.stats.Evicted += int64(len(evicted))
+	m.mu.Unlock()
+	m.removeAllContext(ctx, evicted)
+	return len(evicted)
+}
+
+// Prune removes expired snapshots and returns how many were evicted.
+func (m *SnapshotManager) Prune(ctx context.Context) int {
+	m.mu.Lock()
+	evicted := m.collectEvictionsLocked()
+	m.mu.Unlock()
+	m.removeAllContext(ctx, evicted)
+	return len(evicted)
+}
+
+// Stats returns a snapshot of reuse counters.
+func (m *SnapshotManager) Stats() SnapshotStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+func (m *SnapshotManager) restore(ctx context.Context, snap *SandboxSnapshot) (*SandboxSnapshot, error) {
+	if err := m.driver.Restore(ctx, snap); err != nil {
+		return nil, fmt.Errorf("restore sandbox snapshot %s: %w", snap.Key, err)
+	}
+	m.mu.Lock()
+	snap.LastUsedAt = m.now()
+	snap.Uses++
+	m.stats.Restored++
+	m.stats.SetupTimeSaved += snap.SetupDuration
+	out := cloneSnapshot(snap)
+	m.mu.Unlock()
+	return out, nil
+}
+
+func (m *SnapshotManager) expiredLocked(snap *SandboxSnapshot) bool {
+	return m.config.TTL > 0 && m.now().Sub(snap.LastUsedAt) > m.config.TTL
+}
+
+// collectEvictionsLocked drops expired snapshots and, above MaxSnapshots, the
+// least recently used ones. The caller removes the returned snapshots.
+func (m *SnapshotManager) collectEvictionsLocked() []*SandboxSnapshot {
+	var evicted []*SandboxSnapshot
+	for key, snap := range m.items {
+		if m.expiredLocked(snap) {
+			delete(m.items, key)
+			evicted = append(evicted, snap)
+		}
+	}
+	if m.config.MaxSnapshots > 0 && len(m.items) > m.config.MaxSnapshots {
+		ordered := make([]*SandboxSnapshot, 0, len(m.items))
+		for _, snap := range m.items {
+			ordered = append(ordered, snap)
+		}
+		sort.Slice(ordered, func(i, j int) bool { return ordered[i].LastUsedAt.Before(ordered[j].LastUsedAt) })
+		for _, snap := range ordered[:len(ordered)-m.config.MaxSnapshots] {
+			delete(m.items, snap.Key)
+			evicted = append(evicted, snap)
+		}
+	}
+	m.stats.Evicted += int64(len(evicted))
+	return evicted
+}
+
+func (m *SnapshotManager) removeAll(snapshots []*SandboxSnapshot) {
+	if len(snapshots) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	m.removeAllContext(ctx, snapshots)
+}
+
+func (m *SnapshotManager) removeAllContext(ctx context.Context, snapshots []*SandboxSnapshot) {
+	for _, snap := range snapshots {
+		if err := m.driver.Remove(ctx, snap); err != nil {
+			m.logger.Warn("failed to remove sandbox snapshot", zap.String("key", snap.Key), zap.Error(err))
+		}
+	}
+}
+
+func cloneSnapshot(snap *SandboxSnapshot) *SandboxSnapshot {
+	out := *snap
+	return &out
+}
+
+// DockerSnapshotDriverConfig configures docker-backed snapshots.
+type DockerSnapshotDriverConfig struct {
+	// Repository names committed snapshot images; defaults to "agentflow-sandbox-snapshot".
+	Repository string
+	// ArchiveDir stores tarballs for SnapshotTarball; defaults to a temp subdirectory.
+	ArchiveDir string
+	// SetupNetworkEnabled allows network access while running setup, typically
+	// needed to download dependencies. Executions keep their own network policy.
+	SetupNetworkEnabled bool
+	// SetupTimeout bounds the setup step; defaults to 10 minutes.
+	SetupTimeout time.Duration
+}
+
+// DockerSnapshotDriver snapshots sandboxes with `docker commit` / `docker save`.
+type DockerSnapshotDriver struct {
+	config DockerSnapshotDriverConfig
+	logger *zap.Logger
+	now    func() time.Time
+	run    func(ctx context.Context, args ...string) (stdout, stderr string, err error)
+}
+
+// NewDockerSnapshotDriver creates a docker snapshot driver.
+func NewDockerSnapshotDriver(config DockerSnapshotDriverConfig, logger *zap.Logger) *DockerSnapshotDriver {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if config.Repository == "" {
+		config.Repository = "agentflow-sandbox-snapshot"
+	}
+	if config.ArchiveDir == "" {
+		config.ArchiveDir = filepath.Join(os.TempDir(), "agentflow-sandbox-snapshots")
+	}
+	if config.SetupTimeout <= 0 {
+		config.SetupTimeout = 10 * time.Minute
+	}
+	return &DockerSnapshotDriver{
+		config: config,
+		logger: logger.With(zap.String("component", "docker_snapshot_driver")),
+		now:    time.Now,
+		run: func(ctx context.Context, args ...string) (string, string, error) {
+			return execCommandContext(ctx, "docker", args...).Run()
+		},
+	}
+}
+
+// Create runs the setup in a throwaway container, commits it and optionally saves a tarball.
+func (d *DockerSnapshotDriver) Create(ctx context.Context, spec SnapshotSpec, format SnapshotFormat) (*SandboxSnapshot, error) {
+	fingerprint := spec.Fingerprint()
+	tag := snapshotTag(spec.Scope, fingerprint)
+	image := d.config.Repository + ":" + tag
+	container := "sandbox_snapshot_" + tag + fmt.Sprintf("_%d", d.now().UnixNano())
+
+	setupCtx, cancel := context.WithTimeout(ctx, d.config.SetupTimeout)
+	defer cancel()
+
+	start := d.now()
+	args := []string{"run", "--name", container, "-w", "/workspace", "--security-opt", "no-new-privileges"}
+	if !d.config.SetupNetworkEnabled {
+		args = append(args, "--network", "none")
+	}
+	args = append(args, spec.BaseImage, "sh", "-c", snapshotSetupScript(spec))
+	defer d.removeContainer(container)
+	if _, stderr, err := d.run(setupCtx, args...); err != nil {
+		return nil, fmt.Errorf("run setup: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	setupDuration := d.now().Sub(start)
+
+	if _, stderr, err := d.run(ctx, "commit", container, image); err != nil {
+		return nil, fmt.Errorf("commit snapshot: %w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	snap := &SandboxSnapshot{
+		Scope:         spec.Scope,
+		Language:      spec.Language,
+		BaseImage:     spec.BaseImage,
+		Fingerprint:   fingerprint,
+		Format:        format,
+		Image:         image,
+		SetupDuration: setupDuration,
+		CreatedAt:     d.now(),
+	}
+	if format == SnapshotTarball {
+		if err := os.MkdirAll(d.config.ArchiveDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create snapshot archive dir: %w", err)
+		}
+		snap.Archive = filepath.Join(d.config.ArchiveDir, tag+".tar")
+		if _, stderr, err := d.run(ctx, "save", "-o", snap.Archive, image); err != nil {
+			return nil, fmt.Errorf("save snapshot archive: %w: %s", err, strings.TrimSpace(stderr))
+		}
+	}
+	return snap, nil
+}
+
+// Restore loads the archive when the snapshot image is no longer present locally.
+func (d *DockerSnapshotDriver) Restore(ctx context.Context, snapshot *SandboxSnapshot) error {
+	if _, _, err := d.run(ctx, "image", "inspect", "--format", "{{.Id}}", snapshot.Image); err == nil {
+		return nil
+	}
+	if snapshot.Archive == "" {
+		return fmt.Errorf("%w: image %s is gone and no archive was saved", ErrSnapshotNotFound, snapshot.Image)
+	}
+	if _, stderr, err := d.run(ctx, "load", "-i", snapshot.Archive); err != nil {
+		return fmt.Errorf("load snapshot archive: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	d.logger.Debug("sandbox snapshot loaded from archive", zap.String("image", snapshot.Image))
+	return nil
+}
+
+// Remove deletes the snapshot image and archive.
+func (d *DockerSnapshotDriver) Remove(ctx context.Context, snapshot *SandboxSnapshot) error {
+	var errs []error
+	if _, stderr, err := d.run(ctx, "rmi", "-f", snapshot.Image); err != nil {
+		errs = append(errs, fmt.Errorf("remove snapshot image: %w: %s", err, strings.TrimSpace(stderr)))
+	}
+	if snapshot.Archive != "" {
+		if err := os.Remove(snapshot.Archive); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove snapshot archive: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *DockerSnapshotDriver) removeContainer(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, _, err := d.run(ctx, "rm", "-f", name); err != nil {
+		d.logger.Debug("failed to remove snapshot container", zap.String("name", name), zap.Error(err))
+	}
+}
+
+// snapshotSetupScript writes spec.Files into the working directory and then runs spec.Setup.
+// File contents are passed through quoted heredocs so they are not shell-expanded.
+func snapshotSetupScript(spec SnapshotSpec) string {
+	names := make([]string, 0, len(spec.Files))
+	for name := range spec.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	for i, name := range names {
+		marker := fmt.Sprintf("AGENTFLOW_SNAPSHOT_EOF_%d", i)
+		quoted := shellQuote(name)
+		fmt.Fprintf(&b, "mkdir -p \"$(dirname %s)\"\ncat > %s <<'%s'\n%s\n%s\n", quoted, quoted, marker, spec.Files[name], marker)
+	}
+	b.WriteString(spec.Setup)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// snapshotTag builds a docker tag from scope and fingerprint.
+func snapshotTag(scope, fingerprint string) string {
+	scope = strings.ToLower(sanitizeID(scope))
+	if scope == "" {
+		return fingerprint
+	}
+	return scope + "-" + fingerprint
+}
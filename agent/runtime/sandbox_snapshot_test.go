@@ -0,0 +1,211 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSnapshotDriver struct {
+	mu       sync.Mutex
+	creates  atomic.Int32
+	restores atomic.Int32
+	removed  []string
+	gate     chan struct{}
+	err      error
+}
+
+func (d *fakeSnapshotDriver) Create(_ context.Context, spec SnapshotSpec, format SnapshotFormat) (*SandboxSnapshot, error) {
+	d.creates.Add(1)
+	if d.gate != nil {
+		<-d.gate
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &SandboxSnapshot{
+		Scope:         spec.Scope,
+		Language:      spec.Language,
+		BaseImage:     spec.BaseImage,
+		Fingerprint:   spec.Fingerprint(),
+		Format:        format,
+		Image:         "snap:" + snapshotTag(spec.Scope, spec.Fingerprint()),
+		SetupDuration: 2 * time.Minute,
+	}, nil
+}
+
+func (d *fakeSnapshotDriver) Restore(context.Context, *SandboxSnapshot) error {
+	d.restores.Add(1)
+	return nil
+}
+
+func (d *fakeSnapshotDriver) Remove(_ context.Context, snap *SandboxSnapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removed = append(d.removed, snap.Key)
+	return nil
+}
+
+func TestSnapshotManager_CreateOnceThenRestore(t *testing.T) {
+	driver := &fakeSnapshotDriver{}
+	mgr := NewSnapshotManager(driver, SnapshotManagerConfig{}, nil)
+	spec := SnapshotSpec{Scope: "session-1", Language: LangPython, BaseImage: "python:3.12-slim", Setup: "pip install pandas"}
+
+	first, err := mgr.Acquire(context.Background(), spec)
+	require.NoError(t, err)
+	second, err := mgr.Acquire(context.Background(), spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Image, second.Image)
+	assert.Equal(t, int32(1), driver.creates.Load())
+	assert.Equal(t, int32(1), driver.restores.Load())
+	assert.Equal(t, int64(2), second.Uses)
+	stats := mgr.Stats()
+	assert.Equal(t, int64(1), stats.Created)
+	assert.Equal(t, int64(1), stats.Restored)
+	assert.Equal(t, 2*time.Minute, stats.SetupTimeSaved)
+
+	// 不同 scope 或不同 setup 不共享快照
+	other := spec
+	other.Scope = "session-2"
+	_, err = mgr.Acquire(context.Background(), other)
+	require.NoError(t, err)
+	changed := spec
+	changed.Setup = "pip install polars"
+	_, err = mgr.Acquire(context.Background(), changed)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), driver.creates.Load())
+	assert.Len(t, mgr.List(), 3)
+
+	assert.Equal(t, 1, mgr.EvictScope(context.Background(), "session-2"))
+	_, ok := mgr.Lookup(other)
+	assert.False(t, ok)
+	assert.Len(t, driver.removed, 1)
+}
+
+func TestSnapshotManager_ConcurrentAcquireSharesSetup(t *testing.T) {
+	driver := &fakeSnapshotDriver{gate: make(chan struct{})}
+	mgr := NewSnapshotManager(driver, SnapshotManagerConfig{}, nil)
+	spec := SnapshotSpec{Scope: "wf-1", Language: LangPython, BaseImage: "python", Setup: "pip install numpy"}
+
+	var wg sync.WaitGroup
+	images := make([]string, 4)
+	errs := make([]error, 4)
+	for i := range images {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snap, err := mgr.Acquire(context.Background(), spec)
+			errs[i] = err
+			if err == nil {
+				images[i] = snap.Image
+			}
+		}(i)
+	}
+	require.Eventually(t, func() bool { return driver.creates.Load() == 1 }, time.Second, time.Millisecond)
+	close(driver.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), driver.creates.Load())
+	for i := range images {
+		require.NoError(t, errs[i])
+		assert.Equal(t, images[0], images[i])
+	}
+}
+
+func TestSnapshotManager_EvictionAndErrors(t *testing.T) {
+	driver := &fakeSnapshotDriver{}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mgr := NewSnapshotManager(driver, SnapshotManagerConfig{TTL: time.Hour, MaxSnapshots: 2}, nil)
+	mgr.now = func() time.Time { return now }
+
+	acquire := func(setup string) {
+		_, err := mgr.Acquire(context.Background(), SnapshotSpec{BaseImage: "python", Setup: setup})
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+	}
+	acquire("a")
+	acquire("b")
+	acquire("c")
+	assert.Len(t, mgr.List(), 2, "least recently used snapshot is evicted above the cap")
+	_, ok := mgr.Lookup(SnapshotSpec{BaseImage: "python", Setup: "a"})
+	assert.False(t, ok)
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, 2, mgr.Prune(context.Background()))
+	assert.Equal(t, int64(3), mgr.Stats().Evicted)
+
+	_, err := mgr.Acquire(context.Background(), SnapshotSpec{BaseImage: "python", Setup: "x", Files: map[string]string{"../etc/passwd": ""}})
+	assert.Error(t, err)
+
+	driver.err = errors.New("boom")
+	_, err = mgr.Acquire(context.Background(), SnapshotSpec{BaseImage: "python", Setup: "fails"})
+	assert.ErrorContains(t, err, "boom")
+	assert.Empty(t, mgr.List())
+}
+
+func TestDockerSnapshotDriver_TarballLifecycle(t *testing.T) {
+	var calls [][]string
+	imagePresent := true
+	driver := NewDockerSnapshotDriver(DockerSnapshotDriverConfig{ArchiveDir: t.TempDir(), Repository: "snap"}, nil)
+	driver.run = func(_ context.Context, args ...string) (string, string, error) {
+		calls = append(calls, args)
+		if args[0] == "image" && !imagePresent {
+			return "", "No such image", errors.New("exit 1")
+		}
+		return "", "", nil
+	}
+
+	spec := SnapshotSpec{Scope: "Session_1", Language: LangPython, BaseImage: "python:3.12-slim",
+		Setup: "pip install pandas", Files: map[string]string{"data/input.csv": "a,b\n1,2"}}
+	snap, err := driver.Create(context.Background(), spec, SnapshotTarball)
+	require.NoError(t, err)
+
+	assert.Equal(t, "snap:session_1-"+spec.Fingerprint(), snap.Image)
+	require.Len(t, calls, 4)
+	run := strings.Join(calls[0], " ")
+	assert.Contains(t, run, "--network none")
+	assert.Contains(t, run, "python:3.12-slim sh -c")
+	script := calls[0][len(calls[0])-1]
+	assert.Contains(t, script, "cat > 'data/input.csv' <<'AGENTFLOW_SNAPSHOT_EOF_0'\na,b\n1,2\n")
+	assert.True(t, strings.HasSuffix(script, "pip install pandas\n"))
+	assert.Equal(t, []string{"commit"}, calls[1][:1])
+	assert.Equal(t, []string{"save", "-o", snap.Archive, snap.Image}, calls[2])
+	assert.Equal(t, "rm", calls[3][0])
+
+	calls = nil
+	require.NoError(t, driver.Restore(context.Background(), snap))
+	assert.Len(t, calls, 1, "present image is reused without loading the archive")
+
+	calls = nil
+	imagePresent = false
+	require.NoError(t, driver.Restore(context.Background(), snap))
+	assert.Equal(t, []string{"load", "-i", snap.Archive}, calls[1])
+
+	snap.Archive = ""
+	assert.ErrorIs(t, driver.Restore(context.Background(), snap), ErrSnapshotNotFound)
+}
+
+func TestRealDockerBackend_SetupUsesSnapshot(t *testing.T) {
+	backend := NewRealDockerBackend(nil)
+	result, err := backend.Execute(context.Background(), &ExecutionRequest{ID: "r1", Language: LangPython, Code: "print(1)", Setup: "pip install x"}, DefaultSandboxConfig())
+	require.NoError(t, err)
+	assert.Contains(t, result.Error, "snapshot manager")
+
+	driver := &fakeSnapshotDriver{}
+	backend = NewRealDockerBackendWithConfig(nil, DockerBackendConfig{Snapshots: NewSnapshotManager(driver, SnapshotManagerConfig{}, nil)})
+	image, err := backend.snapshotImageFor(context.Background(), &ExecutionRequest{Language: LangPython, Setup: "pip install x", SnapshotScope: "s1"}, "python:3.12-slim")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(image, "snap:s1-"))
+
+	image, err = backend.snapshotImageFor(context.Background(), &ExecutionRequest{Language: LangPython}, "python:3.12-slim")
+	require.NoError(t, err)
+	assert.Equal(t, "python:3.12-slim", image)
+}
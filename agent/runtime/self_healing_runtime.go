@@ -0,0 +1,217 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	reasoning "github.com/BaSui01/agentflow/agent/capabilities/reasoning"
+	agentcontext "github.com/BaSui01/agentflow/agent/execution/context"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// FailureReflector analyzes a failed execution and proposes an amended
+// strategy. reasoning.ReflexionExecutor implements it.
+type FailureReflector interface {
+	ReflectOnFailure(ctx context.Context, task, failure string) (*reasoning.Reflection, int, error)
+}
+
+var _ FailureReflector = (*reasoning.ReflexionExecutor)(nil)
+
+// SelfHealingConfig enables retry-with-reflection on failed executions.
+type SelfHealingConfig struct {
+	// MaxRetries bounds the reflected retries after the first attempt.
+	MaxRetries int
+	// Reflector defaults to a Reflexion executor on the agent's main gateway.
+	Reflector FailureReflector
+	// Retryable decides which failures are worth reflecting on. Defaults to
+	// DefaultSelfHealingRetryable.
+	Retryable func(error) bool
+}
+
+// SelfHealingAttempt records one failed attempt and the reflection it produced.
+type SelfHealingAttempt struct {
+	Attempt    int                   `json:"attempt"`
+	Error      string                `json:"error"`
+	Reflection *reasoning.Reflection `json:"reflection,omitempty"`
+}
+
+// SelfHealingTrace is attached to Output.Metadata["self_healing"] when a run
+// needed at least one reflected retry, and carried by SelfHealingError.
+type SelfHealingTrace struct {
+	Attempts  []SelfHealingAttempt `json:"attempts"`
+	Recovered bool                 `json:"recovered"`
+}
+
+// SelfHealingError is returned when every reflected retry failed. It unwraps
+// to the last execution error.
+type SelfHealingError struct {
+	Trace SelfHealingTrace
+	Err   error
+}
+
+func (e *SelfHealingError) Error() string {
+	return fmt.Sprintf("execution failed after %d reflected attempts: %v", len(e.Trace.Attempts), e.Err)
+}
+
+func (e *SelfHealingError) Unwrap() error { return e.Err }
+
+// DefaultSelfHealingRetryable retries tool errors, output validation failures
+// and parse errors. Cancellation, budget exhaustion, input validation and
+// pending approvals are never retried.
+func DefaultSelfHealingRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var budgetErr *BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return false
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return true
+	}
+	code := types.GetErrorCode(err)
+	var agentErr *Error
+	if errors.As(err, &agentErr) && agentErr.Base != nil {
+		code = agentErr.Base.Code
+	}
+	switch code {
+	case types.ErrToolValidation, types.ErrToolInvalidArgs, types.ErrToolValidationError,
+		types.ErrToolExecutionTimeout, types.ErrOutputValidation, types.ErrLLMResponseEmpty,
+		types.ErrAgentExecution:
+		return true
+	}
+	return false
+}
+
+// SetSelfHealing enables retry-with-reflection for Execute. Pass nil to disable.
+func (b *BaseAgent) SetSelfHealing(config *SelfHealingConfig) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	if config == nil {
+		b.selfHealing = nil
+		return
+	}
+	cfg := *config
+	b.selfHealing = &cfg
+}
+
+func (b *BaseAgent) selfHealingConfig() *SelfHealingConfig {
+	b.configMu.RLock()
+	defer b.configMu.RUnlock()
+	return b.selfHealing
+}
+
+func (b *BaseAgent) failureReflector(cfg *SelfHealingConfig) FailureReflector {
+	if cfg.Reflector != nil {
+		return cfg.Reflector
+	}
+	gateway := b.MainGateway()
+	if gateway == nil {
+		return nil
+	}
+	refCfg := reasoning.DefaultReflexionConfig()
+	refCfg.Model = b.config.ExecutionOptions().Model.Model
+	return reasoning.NewReflexionExecutor(gateway, nil, nil, refCfg, b.logger)
+}
+
+// executeSelfHealing runs execute and, on a retryable failure, reflects on the
+// failure and retries with the amended strategy injected as an instruction.
+func (b *BaseAgent) executeSelfHealing(ctx context.Context, input *Input, execute func(context.Context, *Input) (*Output, error)) (*Output, error) {
+	cfg := b.selfHealingConfig()
+	output, err := execute(ctx, input)
+	if cfg == nil || cfg.MaxRetries <= 0 || err == nil || input == nil {
+		return output, err
+	}
+	retryable := cfg.Retryable
+	if retryable == nil {
+		retryable = DefaultSelfHealingRetryable
+	}
+	reflector := b.failureReflector(cfg)
+	if reflector == nil {
+		return output, err
+	}
+
+	var trace SelfHealingTrace
+	var lessons []string
+	for attempt := 1; err != nil && attempt <= cfg.MaxRetries && retryable(err) && ctx.Err() == nil; attempt++ {
+		record := SelfHealingAttempt{Attempt: attempt, Error: err.Error()}
+		reflection, _, reflectErr := reflector.ReflectOnFailure(ctx, input.Content, err.Error())
+		if reflectErr != nil {
+			b.logger.Warn("self-healing reflection failed", zap.Int("attempt", attempt), zap.Error(reflectErr))
+		}
+		record.Reflection = reflection
+		trace.Attempts = append(trace.Attempts, record)
+		if lesson := reflectionInstruction(reflection, err); lesson != "" {
+			lessons = append(lessons, lesson)
+		}
+
+		b.logger.Info("retrying execution after reflection",
+			zap.String("trace_id", input.TraceID),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+		output, err = execute(ctx, withReflectionInstructions(ctx, input, lessons))
+	}
+
+	if len(trace.Attempts) == 0 {
+		return output, err
+	}
+	if err != nil {
+		return output, &SelfHealingError{Trace: trace, Err: err}
+	}
+	trace.Recovered = true
+	if output != nil {
+		if output.Metadata == nil {
+			output.Metadata = make(map[string]any, 1)
+		}
+		output.Metadata["self_healing"] = trace
+	}
+	return output, nil
+}
+
+func reflectionInstruction(reflection *reasoning.Reflection, failure error) string {
+	var sb strings.Builder
+	sb.WriteString("A previous attempt at this task failed: ")
+	sb.WriteString(failure.Error())
+	if reflection == nil {
+		sb.WriteString("\nAvoid repeating the same approach.")
+		return sb.String()
+	}
+	if analysis := strings.TrimSpace(reflection.Analysis); analysis != "" {
+		sb.WriteString("\nAnalysis: ")
+		sb.WriteString(analysis)
+	}
+	for _, mistake := range reflection.Mistakes {
+		if mistake = strings.TrimSpace(mistake); mistake != "" {
+			sb.WriteString("\n- Mistake: ")
+			sb.WriteString(mistake)
+		}
+	}
+	if strategy := strings.TrimSpace(reflection.NextStrategy); strategy != "" {
+		sb.WriteString("\nAmended plan: ")
+		sb.WriteString(strategy)
+	}
+	return sb.String()
+}
+
+// withReflectionInstructions returns a copy of input whose skill context
+// carries the accumulated reflections after any existing instructions.
+func withReflectionInstructions(ctx context.Context, input *Input, lessons []string) *Input {
+	retry := *input
+	retry.Context = make(map[string]any, len(input.Context)+1)
+	for key, value := range input.Context {
+		retry.Context[key] = value
+	}
+	instructions := skillInstructionsFromInputContext(input.Context)
+	if len(instructions) == 0 {
+		instructions = normalizeInstructionList(agentcontext.SkillInstructionsFromContext(ctx))
+	}
+	retry.Context["skill_context"] = append(append([]string(nil), instructions...), lessons...)
+	return &retry
+}
@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	reasoning "github.com/BaSui01/agentflow/agent/capabilities/reasoning"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type stubReflector struct {
+	failures []string
+}
+
+func (r *stubReflector) ReflectOnFailure(_ context.Context, _ string, failure string) (*reasoning.Reflection, int, error) {
+	r.failures = append(r.failures, failure)
+	return &reasoning.Reflection{
+		Analysis:     "the answer was not valid JSON",
+		Mistakes:     []string{"wrapped the JSON in prose"},
+		NextStrategy: "reply with a bare JSON object",
+	}, 10, nil
+}
+
+// failingInterceptor fails the first `failures` executions with err.
+type failingInterceptor struct {
+	NopInterceptor
+	failures int
+	err      error
+	calls    int
+	inputs   []*Input
+}
+
+func (f *failingInterceptor) AfterExecute(_ context.Context, input *Input, output *Output, err error) (*Output, error) {
+	f.calls++
+	f.inputs = append(f.inputs, input)
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return output, err
+}
+
+func newSelfHealingAgent(t *testing.T, provider *captureRuntimeProvider, interceptor ExecutionInterceptor, cfg SelfHealingConfig) *BaseAgent {
+	t.Helper()
+	agentCfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "healing-agent", Name: "Healing", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	ag, err := newAgentBuilder(agentCfg).
+		WithGateway(testGateway(provider)).
+		WithLogger(zap.NewNop()).
+		WithInterceptor(interceptor).
+		WithSelfHealing(cfg).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+	return ag
+}
+
+func TestSelfHealingRetriesWithReflection(t *testing.T) {
+	provider := &captureRuntimeProvider{content: "done"}
+	failing := &failingInterceptor{failures: 1, err: NewError(types.ErrOutputValidation, "output is not valid JSON")}
+	reflector := &stubReflector{}
+	ag := newSelfHealingAgent(t, provider, failing, SelfHealingConfig{MaxRetries: 2, Reflector: reflector})
+
+	output, err := ag.Execute(context.Background(), &Input{TraceID: "trace-h", Content: "give me json"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, failing.calls)
+	require.Len(t, reflector.failures, 1)
+	assert.Contains(t, reflector.failures[0], "output is not valid JSON")
+
+	trace, ok := output.Metadata["self_healing"].(SelfHealingTrace)
+	require.True(t, ok)
+	assert.True(t, trace.Recovered)
+	require.Len(t, trace.Attempts, 1)
+	assert.Equal(t, "reply with a bare JSON object", trace.Attempts[0].Reflection.NextStrategy)
+
+	var system []string
+	for _, msg := range provider.lastRequest.Messages {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+		}
+	}
+	require.NotEmpty(t, system)
+	assert.Contains(t, system[len(system)-1], "Amended plan: reply with a bare JSON object")
+	assert.Nil(t, failing.inputs[0].Context, "the caller's input is not mutated")
+}
+
+func TestSelfHealingGivesUpAfterMaxRetries(t *testing.T) {
+	provider := &captureRuntimeProvider{content: "done"}
+	failing := &failingInterceptor{failures: 10, err: NewError(types.ErrToolValidation, "bad tool args")}
+	reflector := &stubReflector{}
+	ag := newSelfHealingAgent(t, provider, failing, SelfHealingConfig{MaxRetries: 2, Reflector: reflector})
+
+	_, err := ag.Execute(context.Background(), &Input{TraceID: "trace-h2", Content: "call the tool"})
+	var healingErr *SelfHealingError
+	require.True(t, errors.As(err, &healingErr))
+	assert.Len(t, healingErr.Trace.Attempts, 2)
+	assert.False(t, healingErr.Trace.Recovered)
+	assert.Equal(t, types.ErrToolValidation, GetErrorCode(healingErr.Err))
+	assert.Equal(t, 3, failing.calls)
+}
+
+func TestSelfHealingSkipsNonRetryableFailures(t *testing.T) {
+	provider := &captureRuntimeProvider{content: "done"}
+	failing := &failingInterceptor{failures: 1, err: NewError(types.ErrInputValidation, "bad input")}
+	reflector := &stubReflector{}
+	ag := newSelfHealingAgent(t, provider, failing, SelfHealingConfig{MaxRetries: 3, Reflector: reflector})
+
+	_, err := ag.Execute(context.Background(), &Input{TraceID: "trace-h3", Content: "hello"})
+	require.Error(t, err)
+	var healingErr *SelfHealingError
+	assert.False(t, errors.As(err, &healingErr))
+	assert.Empty(t, reflector.failures)
+	assert.Equal(t, 1, failing.calls)
+}
+
+func TestDefaultSelfHealingRetryable(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{Offset: 3}
+	assert.True(t, DefaultSelfHealingRetryable(syntaxErr))
+	assert.True(t, DefaultSelfHealingRetryable(NewError(types.ErrLLMResponseEmpty, "empty")))
+	assert.False(t, DefaultSelfHealingRetryable(context.Canceled))
+	assert.False(t, DefaultSelfHealingRetryable(&BudgetExceededError{Scope: BudgetScopeRun}))
+	assert.False(t, DefaultSelfHealingRetryable(nil))
+}
@@ -0,0 +1,305 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	memorycore "github.com/BaSui01/agentflow/agent/capabilities/memory"
+	agentpersistence "github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/google/uuid"
+)
+
+type Session = agentpersistence.Session
+type SessionStore = agentpersistence.SessionStore
+type SessionBudget = agentpersistence.SessionBudget
+type SessionHITLState = agentpersistence.SessionHITLState
+type SessionFilter = agentpersistence.SessionFilter
+
+// internalContextSessionMessages carries session history into the prompt when
+// no conversation store restored one.
+const internalContextSessionMessages = "_agentflow_session_messages"
+
+const defaultSessionMaxHistory = 200
+
+var (
+	// ErrSessionNotFound is returned for unknown session IDs.
+	ErrSessionNotFound = fmt.Errorf("session %w", agentpersistence.ErrNotFound)
+	// ErrSessionExpired is returned when a session outlived its TTL. Expired
+	// sessions are deleted on access.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// UserSessionManagerConfig configures a UserSessionManager.
+type UserSessionManagerConfig struct {
+	// TTL is the sliding idle timeout; every turn extends the session by TTL.
+	// Zero keeps sessions until they are deleted.
+	TTL time.Duration
+	// MaxHistory bounds the messages kept per session. Defaults to 200.
+	MaxHistory int
+	// Memory backs the per-session memory namespace returned by Memory.
+	Memory MemoryManager
+}
+
+// UserSessionOptions describes a new session.
+type UserSessionOptions struct {
+	TenantID string
+	UserID   string
+	// MemoryNamespace defaults to "session/<id>".
+	MemoryNamespace string
+	// Budget sets the session token/cost limits.
+	Budget   SessionBudget
+	Metadata map[string]string
+}
+
+// UserSessionManager owns multi-turn user sessions for one agent: conversation
+// history, a memory namespace, a session budget and HITL state, persisted in a
+// SessionStore across Execute calls and process restarts. Unlike
+// SessionManager, which tracks in-flight streaming executions, these sessions
+// outlive individual runs.
+type UserSessionManager struct {
+	agent  Agent
+	store  SessionStore
+	config UserSessionManagerConfig
+	locks  sync.Map // session ID -> *sync.Mutex
+	now    func() time.Time
+}
+
+// NewUserSessionManager creates a session manager for agent. A nil store keeps
+// sessions in memory.
+func NewUserSessionManager(agent Agent, store SessionStore, config UserSessionManagerConfig) *UserSessionManager {
+	if store == nil {
+		store = agentpersistence.NewMemorySessionStore()
+	}
+	if config.MaxHistory <= 0 {
+		config.MaxHistory = defaultSessionMaxHistory
+	}
+	return &UserSessionManager{agent: agent, store: store, config: config, now: time.Now}
+}
+
+func (m *UserSessionManager) lock(sessionID string) func() {
+	mu, _ := m.locks.LoadOrStore(sessionID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func (m *UserSessionManager) touch(session *Session) {
+	session.UpdatedAt = m.now()
+	if m.config.TTL > 0 {
+		session.ExpiresAt = session.UpdatedAt.Add(m.config.TTL)
+	}
+}
+
+// Create starts a new session.
+func (m *UserSessionManager) Create(ctx context.Context, opts UserSessionOptions) (*Session, error) {
+	now := m.now()
+	session := &Session{
+		ID:              "sess_" + uuid.New().String(),
+		AgentID:         m.agent.ID(),
+		TenantID:        opts.TenantID,
+		UserID:          opts.UserID,
+		MemoryNamespace: opts.MemoryNamespace,
+		Budget:          SessionBudget{MaxTokens: opts.Budget.MaxTokens, MaxCost: opts.Budget.MaxCost},
+		Metadata:        opts.Metadata,
+		CreatedAt:       now,
+	}
+	if session.MemoryNamespace == "" {
+		session.MemoryNamespace = "session/" + session.ID
+	}
+	m.touch(session)
+	if err := m.store.SaveSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("save session: %w", err)
+	}
+	return session, nil
+}
+
+// Get loads a live session.
+func (m *UserSessionManager) Get(ctx context.Context, sessionID string) (*Session, error) {
+	session, err := m.store.GetSession(ctx, sessionID)
+	if errors.Is(err, agentpersistence.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.Expired(m.now()) {
+		if err := m.store.DeleteSession(ctx, sessionID); err != nil && !errors.Is(err, agentpersistence.ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
+
+// List returns live sessions matching filter.
+func (m *UserSessionManager) List(ctx context.Context, filter SessionFilter) ([]*Session, error) {
+	sessions, err := m.store.ListSessions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	live := sessions[:0]
+	for _, session := range sessions {
+		if !session.Expired(now) {
+			live = append(live, session)
+		}
+	}
+	return live, nil
+}
+
+// Delete ends a session.
+func (m *UserSessionManager) Delete(ctx context.Context, sessionID string) error {
+	defer m.lock(sessionID)()
+	defer m.locks.Delete(sessionID)
+	if err := m.store.DeleteSession(ctx, sessionID); err != nil {
+		if errors.Is(err, agentpersistence.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ExpireSessions deletes every expired session and returns how many were removed.
+func (m *UserSessionManager) ExpireSessions(ctx context.Context) (int, error) {
+	return m.store.DeleteExpired(ctx, m.now())
+}
+
+// Update applies fn to a live session and persists the result.
+func (m *UserSessionManager) Update(ctx context.Context, sessionID string, fn func(*Session) error) (*Session, error) {
+	defer m.lock(sessionID)()
+	session, err := m.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(session); err != nil {
+		return nil, err
+	}
+	m.touch(session)
+	if err := m.store.SaveSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("save session: %w", err)
+	}
+	return session, nil
+}
+
+// TrackInterrupt records a pending HITL interrupt on the session.
+func (m *UserSessionManager) TrackInterrupt(ctx context.Context, sessionID, interruptID string) (*Session, error) {
+	return m.Update(ctx, sessionID, func(session *Session) error {
+		for _, id := range session.HITL.PendingInterrupts {
+			if id == interruptID {
+				return nil
+			}
+		}
+		session.HITL.PendingInterrupts = append(session.HITL.PendingInterrupts, interruptID)
+		return nil
+	})
+}
+
+// ResolveInterrupt removes a resolved HITL interrupt from the session.
+func (m *UserSessionManager) ResolveInterrupt(ctx context.Context, sessionID, interruptID string) (*Session, error) {
+	return m.Update(ctx, sessionID, func(session *Session) error {
+		pending := session.HITL.PendingInterrupts[:0]
+		for _, id := range session.HITL.PendingInterrupts {
+			if id != interruptID {
+				pending = append(pending, id)
+			}
+		}
+		session.HITL.PendingInterrupts = pending
+		return nil
+	})
+}
+
+// Memory returns the session's namespaced view of the configured memory
+// manager, or nil when none is configured.
+func (m *UserSessionManager) Memory(session *Session) MemoryManager {
+	if m.config.Memory == nil || session == nil {
+		return nil
+	}
+	return memorycore.NewNamespacedManager(m.config.Memory, session.MemoryNamespace)
+}
+
+// Execute runs one turn of the session. Turns of the same session are
+// serialized. The session ID becomes the input's channel, so the agent's
+// conversation store, session budget accounting and checkpoints all key on
+// it. A resume request without a checkpoint_id resumes the session's last
+// checkpoint.
+func (m *UserSessionManager) Execute(ctx context.Context, sessionID string, input *Input) (*Output, error) {
+	if input == nil {
+		return nil, NewError(types.ErrInputValidation, "input is nil")
+	}
+	defer m.lock(sessionID)()
+	session, err := m.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Budget.Exhausted() {
+		return nil, &BudgetExceededError{Scope: BudgetScopeSession, State: BudgetState{
+			SessionID:     session.ID,
+			SessionTokens: session.Budget.TokensUsed,
+			SessionCost:   session.Budget.CostUsed,
+			Exceeded:      true,
+			ExceededScope: BudgetScopeSession,
+		}}
+	}
+
+	output, execErr := m.agent.Execute(ctx, m.sessionInput(session, input))
+	if output != nil {
+		session.HITL.CheckpointID = output.CheckpointID
+		session.HITL.Resumable = output.Resumable
+		session.Budget.TokensUsed += output.TokensUsed
+		session.Budget.CostUsed += output.Cost
+	}
+	if execErr == nil && output != nil {
+		now := m.now()
+		session.Messages = append(session.Messages,
+			ConversationMessage{Role: string(types.RoleUser), Content: input.Content, Timestamp: now},
+			ConversationMessage{Role: string(types.RoleAssistant), Content: output.Content, Timestamp: now},
+		)
+		if overflow := len(session.Messages) - m.config.MaxHistory; overflow > 0 {
+			session.Messages = append([]ConversationMessage(nil), session.Messages[overflow:]...)
+		}
+	}
+	m.touch(session)
+	if err := m.store.SaveSession(ctx, session); err != nil {
+		return output, errors.Join(execErr, fmt.Errorf("save session: %w", err))
+	}
+	return output, execErr
+}
+
+func (m *UserSessionManager) sessionInput(session *Session, input *Input) *Input {
+	turn := *input
+	turn.ChannelID = session.ID
+	if turn.TenantID == "" {
+		turn.TenantID = session.TenantID
+	}
+	if turn.UserID == "" {
+		turn.UserID = session.UserID
+	}
+	turn.Context = make(map[string]any, len(input.Context)+2)
+	for key, value := range input.Context {
+		turn.Context[key] = value
+	}
+	turn.Context["memory_namespace"] = session.MemoryNamespace
+	if len(session.Messages) > 0 {
+		history := make([]types.Message, 0, len(session.Messages))
+		for _, msg := range session.Messages {
+			history = append(history, types.Message{Role: types.Role(msg.Role), Content: msg.Content})
+		}
+		turn.Context[internalContextSessionMessages] = history
+	}
+	if resume, _ := turn.Context["resume"].(bool); resume && session.HITL.CheckpointID != "" {
+		if id, _ := turn.Context["checkpoint_id"].(string); strings.TrimSpace(id) == "" {
+			turn.Context["checkpoint_id"] = session.HITL.CheckpointID
+		}
+	}
+	return &turn
+}
+
+func sessionMessagesFromInputContext(values map[string]any) []types.Message {
+	messages, _ := values[internalContextSessionMessages].([]types.Message)
+	return messages
+}
@@ -0,0 +1,114 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	agentpersistence "github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newSessionTestAgent(t *testing.T, provider *captureRuntimeProvider) *BaseAgent {
+	t.Helper()
+	cfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "session-agent", Name: "Session", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	ag, err := newAgentBuilder(cfg).WithGateway(testGateway(provider)).WithLogger(zap.NewNop()).Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+	return ag
+}
+
+func TestUserSessionManagerCarriesHistoryAcrossTurns(t *testing.T) {
+	provider := &captureRuntimeProvider{content: "noted"}
+	store, err := agentpersistence.NewFileSessionStore(t.TempDir())
+	require.NoError(t, err)
+	ag := newSessionTestAgent(t, provider)
+	manager := NewUserSessionManager(ag, store, UserSessionManagerConfig{MaxHistory: 4})
+
+	session, err := manager.Create(context.Background(), UserSessionOptions{UserID: "alice", TenantID: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "session/"+session.ID, session.MemoryNamespace)
+
+	_, err = manager.Execute(context.Background(), session.ID, &Input{TraceID: "t1", Content: "my name is Alice"})
+	require.NoError(t, err)
+
+	// A fresh manager over the same store picks the session back up.
+	manager = NewUserSessionManager(ag, store, UserSessionManagerConfig{MaxHistory: 4})
+	_, err = manager.Execute(context.Background(), session.ID, &Input{TraceID: "t2", Content: "what is my name?"})
+	require.NoError(t, err)
+
+	var contents []string
+	for _, msg := range provider.lastRequest.Messages {
+		if msg.Role != types.RoleSystem {
+			contents = append(contents, msg.Content)
+		}
+	}
+	assert.Equal(t, []string{"my name is Alice", "noted", "what is my name?"}, contents)
+
+	_, err = manager.Execute(context.Background(), session.ID, &Input{TraceID: "t3", Content: "third"})
+	require.NoError(t, err)
+	stored, err := manager.Get(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Messages, 4)
+	assert.Equal(t, "what is my name?", stored.Messages[0].Content)
+	assert.Equal(t, "acme", stored.TenantID)
+}
+
+func TestUserSessionManagerEnforcesBudgetAndExpiry(t *testing.T) {
+	ag := newSessionTestAgent(t, &captureRuntimeProvider{content: "ok"})
+	manager := NewUserSessionManager(ag, nil, UserSessionManagerConfig{TTL: time.Minute})
+	now := time.Now()
+	manager.now = func() time.Time { return now }
+
+	session, err := manager.Create(context.Background(), UserSessionOptions{Budget: SessionBudget{MaxTokens: 10}})
+	require.NoError(t, err)
+	_, err = manager.Update(context.Background(), session.ID, func(s *Session) error {
+		s.Budget.TokensUsed = 10
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = manager.Execute(context.Background(), session.ID, &Input{Content: "hello"})
+	var budgetErr *BudgetExceededError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, BudgetScopeSession, budgetErr.Scope)
+	assert.Equal(t, session.ID, budgetErr.State.SessionID)
+
+	now = now.Add(2 * time.Minute)
+	_, err = manager.Get(context.Background(), session.ID)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	_, err = manager.Get(context.Background(), session.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound, "expired sessions are deleted on access")
+}
+
+func TestUserSessionManagerTracksHITLState(t *testing.T) {
+	ag := newSessionTestAgent(t, &captureRuntimeProvider{content: "ok"})
+	manager := NewUserSessionManager(ag, nil, UserSessionManagerConfig{})
+	session, err := manager.Create(context.Background(), UserSessionOptions{})
+	require.NoError(t, err)
+
+	_, err = manager.TrackInterrupt(context.Background(), session.ID, "int-1")
+	require.NoError(t, err)
+	_, err = manager.TrackInterrupt(context.Background(), session.ID, "int-2")
+	require.NoError(t, err)
+	updated, err := manager.ResolveInterrupt(context.Background(), session.ID, "int-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"int-2"}, updated.HITL.PendingInterrupts)
+
+	session.HITL.CheckpointID = "ckpt-9"
+	turn := manager.sessionInput(session, &Input{Content: "continue", Context: map[string]any{"resume": true}})
+	assert.Equal(t, "ckpt-9", turn.Context["checkpoint_id"])
+	assert.Equal(t, session.ID, turn.ChannelID)
+	assert.Equal(t, session.MemoryNamespace, turn.Context["memory_namespace"])
+
+	require.NoError(t, manager.Delete(context.Background(), session.ID))
+	assert.ErrorIs(t, manager.Delete(context.Background(), session.ID), ErrSessionNotFound)
+}
@@ -0,0 +1,408 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	skills "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// Capabilities derived from the agent's wiring and checked against
+// Skill.RequiredCapabilities on install.
+const (
+	SkillCapabilityTools     = "tools"
+	SkillCapabilityMemory    = "memory"
+	SkillCapabilityRetrieval = "retrieval"
+)
+
+var (
+	ErrSkillAlreadyInstalled  = errors.New("skill already installed")
+	ErrSkillNotInstalled      = errors.New("skill not installed")
+	ErrSkillRequirementsUnmet = errors.New("skill requirements unmet")
+)
+
+// SkillTool is a tool shipped inside a skill package. Its handler receives
+// the raw tool call arguments and returns the raw tool result.
+type SkillTool struct {
+	Schema  types.ToolSchema
+	Handler skills.SkillHandler
+}
+
+// SkillPackage bundles a skill manifest (instructions, few-shot examples,
+// memory snippets, required tools and capabilities) with the tools it ships.
+type SkillPackage struct {
+	Manifest *skills.Skill
+	Tools    []SkillTool
+}
+
+// InstalledSkill describes a skill currently installed on an agent.
+type InstalledSkill struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Tools       []string  `json:"tools,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+type installedSkill struct {
+	manifest    *skills.Skill
+	tools       []SkillTool
+	installedAt time.Time
+}
+
+// SkillRegistry holds the skills installed on one agent. Installed skills
+// contribute system-prompt fragments, memory snippets and tools to every
+// subsequent run; installing or uninstalling takes effect on the next run.
+type SkillRegistry struct {
+	mu           sync.RWMutex
+	owner        *BaseAgent
+	skills       map[string]*installedSkill
+	order        []string
+	tools        map[string]string // tool name -> owning skill ID
+	capabilities map[string]struct{}
+}
+
+// Skills returns the agent's skill registry.
+func (b *BaseAgent) Skills() *SkillRegistry {
+	r := &b.skillRegistry
+	r.mu.Lock()
+	r.owner = b
+	r.mu.Unlock()
+	return r
+}
+
+// ProvideCapabilities declares capabilities beyond the ones derived from the
+// agent's wiring, for skills whose RequiredCapabilities name them.
+func (r *SkillRegistry) ProvideCapabilities(capabilities ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.capabilities == nil {
+		r.capabilities = make(map[string]struct{}, len(capabilities))
+	}
+	for _, capability := range capabilities {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			r.capabilities[capability] = struct{}{}
+		}
+	}
+}
+
+// Install validates the package against the agent and installs it. Every
+// unmet requirement is reported in one error wrapping ErrSkillRequirementsUnmet.
+func (r *SkillRegistry) Install(pkg SkillPackage) error {
+	if pkg.Manifest == nil {
+		return fmt.Errorf("skill manifest is nil")
+	}
+	manifest := pkg.Manifest.Clone()
+	if err := manifest.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.skills[manifest.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrSkillAlreadyInstalled, manifest.ID)
+	}
+
+	var problems []string
+	for _, dep := range manifest.Dependencies {
+		if _, ok := r.skills[dep]; !ok {
+			problems = append(problems, fmt.Sprintf("dependency %q is not installed", dep))
+		}
+	}
+
+	packaged := make(map[string]struct{}, len(pkg.Tools))
+	tools := make([]SkillTool, 0, len(pkg.Tools))
+	for _, tool := range pkg.Tools {
+		name := strings.TrimSpace(tool.Schema.Name)
+		switch {
+		case name == "":
+			problems = append(problems, "packaged tool has no name")
+			continue
+		case tool.Handler == nil:
+			problems = append(problems, fmt.Sprintf("packaged tool %q has no handler", name))
+			continue
+		}
+		if _, dup := packaged[name]; dup {
+			problems = append(problems, fmt.Sprintf("packaged tool %q is declared twice", name))
+			continue
+		}
+		if owner, taken := r.tools[name]; taken {
+			problems = append(problems, fmt.Sprintf("tool %q is already provided by skill %q", name, owner))
+			continue
+		}
+		if r.agentHasTool(name) {
+			problems = append(problems, fmt.Sprintf("tool %q conflicts with an agent tool", name))
+			continue
+		}
+		packaged[name] = struct{}{}
+		tool.Schema.Name = name
+		tools = append(tools, tool)
+	}
+
+	for _, name := range manifest.Tools {
+		if _, ok := packaged[name]; ok {
+			continue
+		}
+		if _, ok := r.tools[name]; ok {
+			continue
+		}
+		if !r.agentHasTool(name) {
+			problems = append(problems, fmt.Sprintf("required tool %q is not available", name))
+		}
+	}
+
+	available := r.availableCapabilities()
+	for _, capability := range manifest.RequiredCapabilities {
+		if _, ok := available[capability]; !ok {
+			problems = append(problems, fmt.Sprintf("required capability %q is not available", capability))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: skill %s: %s", ErrSkillRequirementsUnmet, manifest.ID, strings.Join(problems, "; "))
+	}
+
+	if r.skills == nil {
+		r.skills = make(map[string]*installedSkill)
+		r.tools = make(map[string]string)
+	}
+	now := time.Now()
+	manifest.Loaded = true
+	manifest.LoadedAt = now
+	r.skills[manifest.ID] = &installedSkill{manifest: manifest, tools: tools, installedAt: now}
+	r.order = append(r.order, manifest.ID)
+	for _, tool := range tools {
+		r.tools[tool.Schema.Name] = manifest.ID
+	}
+	return nil
+}
+
+// Uninstall removes an installed skill and its tools. It fails while another
+// installed skill depends on it.
+func (r *SkillRegistry) Uninstall(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	installed, ok := r.skills[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSkillNotInstalled, id)
+	}
+	for _, otherID := range r.order {
+		for _, dep := range r.skills[otherID].manifest.Dependencies {
+			if dep == id {
+				return fmt.Errorf("skill %s is required by %s", id, otherID)
+			}
+		}
+	}
+	for _, tool := range installed.tools {
+		delete(r.tools, tool.Schema.Name)
+	}
+	delete(r.skills, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// List returns the installed skills in install order.
+func (r *SkillRegistry) List() []InstalledSkill {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]InstalledSkill, 0, len(r.order))
+	for _, id := range r.order {
+		out = append(out, r.skills[id].describe())
+	}
+	return out
+}
+
+// Get returns a copy of an installed skill's manifest.
+func (r *SkillRegistry) Get(id string) (*skills.Skill, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	installed, ok := r.skills[id]
+	if !ok {
+		return nil, false
+	}
+	return installed.manifest.Clone(), true
+}
+
+func (s *installedSkill) describe() InstalledSkill {
+	names := make([]string, 0, len(s.tools))
+	for _, tool := range s.tools {
+		names = append(names, tool.Schema.Name)
+	}
+	return InstalledSkill{
+		ID:          s.manifest.ID,
+		Name:        s.manifest.Name,
+		Version:     s.manifest.Version,
+		Tools:       names,
+		InstalledAt: s.installedAt,
+	}
+}
+
+// agentHasTool must be called with r.mu held.
+func (r *SkillRegistry) agentHasTool(name string) bool {
+	if r.owner == nil || r.owner.toolManager == nil {
+		return false
+	}
+	for _, schema := range r.owner.toolManager.GetAllowedTools(r.owner.config.Core.ID) {
+		if schema.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// availableCapabilities must be called with r.mu held.
+func (r *SkillRegistry) availableCapabilities() map[string]struct{} {
+	available := make(map[string]struct{}, len(r.capabilities)+3)
+	for capability := range r.capabilities {
+		available[capability] = struct{}{}
+	}
+	if r.owner != nil {
+		if r.owner.toolManager != nil {
+			available[SkillCapabilityTools] = struct{}{}
+		}
+		if r.owner.memory != nil || r.owner.memoryFacade != nil {
+			available[SkillCapabilityMemory] = struct{}{}
+		}
+		if r.owner.retriever != nil {
+			available[SkillCapabilityRetrieval] = struct{}{}
+		}
+	}
+	return available
+}
+
+func (r *SkillRegistry) promptFragments() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var fragments []string
+	for _, id := range r.order {
+		if fragment := r.skills[id].manifest.PromptFragment(); fragment != "" {
+			fragments = append(fragments, fragment)
+		}
+	}
+	return fragments
+}
+
+func (r *SkillRegistry) memorySnippets() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var snippets []string
+	for _, id := range r.order {
+		snippets = append(snippets, r.skills[id].manifest.MemorySnippets...)
+	}
+	return snippets
+}
+
+func (r *SkillRegistry) toolSchemas() []types.ToolSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var schemas []types.ToolSchema
+	for _, id := range r.order {
+		for _, tool := range r.skills[id].tools {
+			schemas = append(schemas, tool.Schema)
+		}
+	}
+	return schemas
+}
+
+func (r *SkillRegistry) toolNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wrapExecutor routes calls to skill tools to their handlers and everything
+// else to next. Handlers are snapshotted so a run is unaffected by concurrent
+// installs.
+func (r *SkillRegistry) wrapExecutor(next llmtools.ToolExecutor) llmtools.ToolExecutor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.tools) == 0 {
+		return next
+	}
+	handlers := make(map[string]skills.SkillHandler, len(r.tools))
+	for name, id := range r.tools {
+		for _, tool := range r.skills[id].tools {
+			if tool.Schema.Name == name {
+				handlers[name] = tool.Handler
+			}
+		}
+	}
+	return &skillToolExecutor{next: next, handlers: handlers}
+}
+
+type skillToolExecutor struct {
+	next     llmtools.ToolExecutor
+	handlers map[string]skills.SkillHandler
+}
+
+func (e *skillToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	if len(calls) == 0 {
+		return nil
+	}
+	results := make([]types.ToolResult, 0, len(calls))
+	for _, call := range calls {
+		results = append(results, e.ExecuteOne(ctx, call))
+	}
+	return results
+}
+
+func (e *skillToolExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	handler, ok := e.handlers[call.Name]
+	if !ok {
+		return e.next.ExecuteOne(ctx, call)
+	}
+	start := time.Now()
+	result := types.ToolResult{ToolCallID: call.ID, Name: call.Name}
+	out, err := handler(ctx, call.Arguments)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = out
+	return result
+}
+
+func (e *skillToolExecutor) ExecuteOneStream(ctx context.Context, call types.ToolCall) <-chan llmtools.ToolStreamEvent {
+	if _, ok := e.handlers[call.Name]; !ok {
+		if streamer, ok := e.next.(llmtools.StreamableToolExecutor); ok {
+			return streamer.ExecuteOneStream(ctx, call)
+		}
+	}
+	ch := make(chan llmtools.ToolStreamEvent, 1)
+	go func() {
+		defer close(ch)
+		result := e.ExecuteOne(ctx, call)
+		if result.Error != "" {
+			ch <- llmtools.ToolStreamEvent{
+				Type:     llmtools.ToolStreamError,
+				ToolName: call.Name,
+				Error:    errors.New(result.Error),
+			}
+			return
+		}
+		ch <- llmtools.ToolStreamEvent{
+			Type:     llmtools.ToolStreamComplete,
+			ToolName: call.Name,
+			Data:     result,
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,154 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	skills "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestSkillPackage(t *testing.T, id string, deps ...string) SkillPackage {
+	t.Helper()
+	manifest, err := skills.NewSkillBuilder(id, id+" skill").
+		WithInstructions("Use "+id+" carefully.").
+		WithExample("ping", "pong", "echo the greeting").
+		WithTools(id + "_lookup").
+		WithMemorySnippets(id + " customers prefer short answers").
+		WithDependencies(deps...).
+		Build()
+	require.NoError(t, err)
+	return SkillPackage{
+		Manifest: manifest,
+		Tools: []SkillTool{{
+			Schema: types.ToolSchema{Name: id + "_lookup", Description: "lookup for " + id},
+			Handler: func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+				return json.RawMessage(`{"skill":"` + id + `","args":` + string(args) + `}`), nil
+			},
+		}},
+	}
+}
+
+func TestSkillRegistryInstallAndUninstall(t *testing.T) {
+	var registry SkillRegistry
+	require.NoError(t, registry.Install(newTestSkillPackage(t, "billing")))
+	require.NoError(t, registry.Install(newTestSkillPackage(t, "refunds", "billing")))
+
+	err := registry.Install(newTestSkillPackage(t, "billing"))
+	assert.True(t, errors.Is(err, ErrSkillAlreadyInstalled))
+
+	installed := registry.List()
+	require.Len(t, installed, 2)
+	assert.Equal(t, "billing", installed[0].ID)
+	assert.Equal(t, []string{"billing_lookup"}, installed[0].Tools)
+	assert.Equal(t, []string{"billing_lookup", "refunds_lookup"}, registry.toolNames())
+
+	err = registry.Uninstall("billing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "required by refunds")
+
+	require.NoError(t, registry.Uninstall("refunds"))
+	require.NoError(t, registry.Uninstall("billing"))
+	assert.Empty(t, registry.List())
+	assert.True(t, errors.Is(registry.Uninstall("billing"), ErrSkillNotInstalled))
+}
+
+func TestSkillRegistryReportsUnmetRequirements(t *testing.T) {
+	var registry SkillRegistry
+	pkg := newTestSkillPackage(t, "research", "search")
+	pkg.Manifest.Tools = append(pkg.Manifest.Tools, "web_search")
+	pkg.Manifest.RequiredCapabilities = []string{SkillCapabilityRetrieval, "browser"}
+	pkg.Tools = append(pkg.Tools, SkillTool{Schema: types.ToolSchema{Name: "broken"}})
+
+	err := registry.Install(pkg)
+	require.True(t, errors.Is(err, ErrSkillRequirementsUnmet))
+	for _, want := range []string{
+		`dependency "search" is not installed`,
+		`packaged tool "broken" has no handler`,
+		`required tool "web_search" is not available`,
+		`required capability "retrieval" is not available`,
+		`required capability "browser" is not available`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+	assert.Empty(t, registry.List())
+
+	pkg = newTestSkillPackage(t, "browse")
+	pkg.Manifest.RequiredCapabilities = []string{"browser"}
+	registry.ProvideCapabilities("browser")
+	require.NoError(t, registry.Install(pkg))
+}
+
+func TestSkillRegistryDispatchesSkillTools(t *testing.T) {
+	var registry SkillRegistry
+	require.NoError(t, registry.Install(newTestSkillPackage(t, "billing")))
+	executor := registry.wrapExecutor(stubToolExecutor{})
+
+	results := executor.Execute(context.Background(), []types.ToolCall{
+		{ID: "1", Name: "billing_lookup", Arguments: json.RawMessage(`{"id":7}`)},
+		{ID: "2", Name: "search"},
+	})
+	require.Len(t, results, 2)
+	assert.JSONEq(t, `{"skill":"billing","args":{"id":7}}`, string(results[0].Result))
+	assert.Equal(t, "1", results[0].ToolCallID)
+	assert.Equal(t, "search", results[1].Name)
+	assert.Nil(t, results[1].Result, "non-skill calls reach the wrapped executor")
+}
+
+func TestExecuteInjectsInstalledSkills(t *testing.T) {
+	cfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "skill-agent", Name: "Skill", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	provider := &captureRuntimeProvider{content: "done"}
+	ag, err := newAgentBuilder(cfg).
+		WithGateway(testGateway(provider)).
+		WithLogger(zap.NewNop()).
+		WithSkillPackages(newTestSkillPackage(t, "billing")).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+
+	_, err = ag.Execute(context.Background(), &Input{TraceID: "trace-s", Content: "hello"})
+	require.NoError(t, err)
+	require.NotNil(t, provider.lastRequest)
+
+	var system []string
+	for _, msg := range provider.lastRequest.Messages {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+		}
+	}
+	assert.Contains(t, system, "Use billing carefully.\n\nExamples:\nInput: ping\nOutput: pong\nWhy: echo the greeting")
+	assert.Contains(t, system, "billing customers prefer short answers")
+	require.Len(t, provider.lastRequest.Tools, 1)
+	assert.Equal(t, "billing_lookup", provider.lastRequest.Tools[0].Name)
+
+	require.NoError(t, ag.Skills().Uninstall("billing"))
+	_, err = ag.Execute(context.Background(), &Input{TraceID: "trace-s2", Content: "hello"})
+	require.NoError(t, err)
+	assert.Empty(t, provider.lastRequest.Tools)
+}
+
+func TestAgentBuilderFailsOnUninstallableSkill(t *testing.T) {
+	cfg := types.AgentConfig{
+		Core: types.CoreConfig{ID: "skill-agent", Name: "Skill", Type: "assistant"},
+		LLM:  types.LLMConfig{Model: "gpt-4"},
+	}
+	pkg := newTestSkillPackage(t, "billing")
+	pkg.Manifest.RequiredCapabilities = []string{SkillCapabilityTools}
+
+	_, err := newAgentBuilder(cfg).
+		WithGateway(testGateway(&captureRuntimeProvider{})).
+		WithLogger(zap.NewNop()).
+		WithSkillPackages(pkg).
+		Build()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrSkillRequirementsUnmet))
+}
@@ -0,0 +1,615 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/tools"
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// TypeSupervisor is the agent type reported by SupervisorAgent.
+const TypeSupervisor AgentType = "supervisor"
+
+// SupervisorFailurePolicy decides what happens when a subtask exhausts its retries.
+type SupervisorFailurePolicy string
+
+const (
+	// SupervisorFailFast fails the whole run as soon as one subtask fails.
+	SupervisorFailFast SupervisorFailurePolicy = "fail_fast"
+	// SupervisorPartialResult aggregates whatever succeeded and reports the rest.
+	SupervisorPartialResult SupervisorFailurePolicy = "partial_result"
+)
+
+// SupervisorSubtask is one unit of work produced by the planner.
+type SupervisorSubtask struct {
+	ID                   string   `json:"id"`
+	Description          string   `json:"description"`
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+	// WorkerID pins the subtask to a specific worker; empty lets discovery choose.
+	WorkerID string `json:"worker_id,omitempty"`
+}
+
+// SupervisorSubtaskResult records how a subtask was executed.
+type SupervisorSubtaskResult struct {
+	Subtask  SupervisorSubtask `json:"subtask"`
+	WorkerID string            `json:"worker_id,omitempty"`
+	Attempts int               `json:"attempts"`
+	Output   *Output           `json:"output,omitempty"`
+	Err      error             `json:"-"`
+	Error    string            `json:"error,omitempty"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// SupervisorPlanner decomposes an input into subtasks for the worker pool.
+type SupervisorPlanner interface {
+	Decompose(ctx context.Context, input *Input, workers []Agent) ([]SupervisorSubtask, error)
+}
+
+// SupervisorPlannerFunc adapts a function to SupervisorPlanner.
+type SupervisorPlannerFunc func(ctx context.Context, input *Input, workers []Agent) ([]SupervisorSubtask, error)
+
+// Decompose implements SupervisorPlanner.
+func (f SupervisorPlannerFunc) Decompose(ctx context.Context, input *Input, workers []Agent) ([]SupervisorSubtask, error) {
+	return f(ctx, input, workers)
+}
+
+// SupervisorAggregator merges subtask results into the supervisor's output.
+type SupervisorAggregator interface {
+	Aggregate(ctx context.Context, input *Input, results []SupervisorSubtaskResult) (*Output, error)
+}
+
+// SupervisorAggregatorFunc adapts a function to SupervisorAggregator.
+type SupervisorAggregatorFunc func(ctx context.Context, input *Input, results []SupervisorSubtaskResult) (*Output, error)
+
+// Aggregate implements SupervisorAggregator.
+func (f SupervisorAggregatorFunc) Aggregate(ctx context.Context, input *Input, results []SupervisorSubtaskResult) (*Output, error) {
+	return f(ctx, input, results)
+}
+
+// SupervisorAgentConfig configures a SupervisorAgent.
+type SupervisorAgentConfig struct {
+	ID   string
+	Name string
+	// Planner decomposes inputs. Required.
+	Planner SupervisorPlanner
+	// Matcher selects workers through agent discovery. Worker IDs must match
+	// the discovery agent card names. When nil, or when discovery finds no
+	// pooled worker, subtasks are assigned round-robin.
+	Matcher tools.Matcher
+	// Aggregator merges results. Defaults to concatenating successful outputs.
+	Aggregator SupervisorAggregator
+	// MaxConcurrency bounds concurrently running subtasks. Defaults to the pool size.
+	MaxConcurrency int
+	// MaxRetries is the number of extra attempts per subtask. Retries prefer a
+	// different worker than the one that failed. Defaults to 1; negative disables retries.
+	MaxRetries int
+	// SubtaskTimeout bounds a single attempt. Zero means no per-attempt timeout.
+	SubtaskTimeout time.Duration
+	// FailurePolicy defaults to SupervisorPartialResult.
+	FailurePolicy SupervisorFailurePolicy
+}
+
+// SupervisorAgent owns a pool of worker agents. It decomposes each input into
+// subtasks with a planner, dispatches them concurrently to workers selected
+// through discovery, retries failed subtasks, and aggregates the results.
+// It implements Agent, so it can be nested inside teams or other supervisors.
+type SupervisorAgent struct {
+	id         string
+	name       string
+	planner    SupervisorPlanner
+	matcher    tools.Matcher
+	aggregator SupervisorAggregator
+	config     SupervisorAgentConfig
+	logger     *zap.Logger
+
+	mu       sync.RWMutex
+	state    State
+	workers  []Agent
+	byID     map[string]Agent
+	rrCursor int
+}
+
+var _ Agent = (*SupervisorAgent)(nil)
+
+// NewSupervisorAgent creates a supervisor over the given workers.
+func NewSupervisorAgent(cfg SupervisorAgentConfig, workers []Agent, logger *zap.Logger) (*SupervisorAgent, error) {
+	if cfg.Planner == nil {
+		return nil, errors.New("supervisor planner is required")
+	}
+	if len(workers) == 0 {
+		return nil, errors.New("supervisor requires at least one worker")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if cfg.ID == "" {
+		cfg.ID = fmt.Sprintf("supervisor_%d", time.Now().UnixNano())
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.ID
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 1
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.FailurePolicy == "" {
+		cfg.FailurePolicy = SupervisorPartialResult
+	}
+	aggregator := cfg.Aggregator
+	if aggregator == nil {
+		aggregator = SupervisorAggregatorFunc(concatSupervisorResults)
+	}
+
+	s := &SupervisorAgent{
+		id:         cfg.ID,
+		name:       cfg.Name,
+		planner:    cfg.Planner,
+		matcher:    cfg.Matcher,
+		aggregator: aggregator,
+		config:     cfg,
+		logger:     logger.With(zap.String("component", "supervisor_agent"), zap.String("agent_id", cfg.ID)),
+		state:      StateInit,
+		byID:       make(map[string]Agent, len(workers)),
+	}
+	for _, w := range workers {
+		if err := s.AddWorker(w); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *SupervisorAgent) ID() string      { return s.id }
+func (s *SupervisorAgent) Name() string    { return s.name }
+func (s *SupervisorAgent) Type() AgentType { return TypeSupervisor }
+
+// State returns the supervisor's lifecycle state.
+func (s *SupervisorAgent) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Init initializes every worker and marks the supervisor ready.
+func (s *SupervisorAgent) Init(ctx context.Context) error {
+	for _, w := range s.Workers() {
+		if w.State() != StateInit {
+			continue
+		}
+		if err := w.Init(ctx); err != nil {
+			return fmt.Errorf("init worker %s: %w", w.ID(), err)
+		}
+	}
+	s.setState(StateReady)
+	return nil
+}
+
+// Teardown tears down every worker.
+func (s *SupervisorAgent) Teardown(ctx context.Context) error {
+	var errs []error
+	for _, w := range s.Workers() {
+		if err := w.Teardown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("teardown worker %s: %w", w.ID(), err))
+		}
+	}
+	s.setState(StateCompleted)
+	return errors.Join(errs...)
+}
+
+// Observe forwards feedback to every worker.
+func (s *SupervisorAgent) Observe(ctx context.Context, feedback *Feedback) error {
+	var errs []error
+	for _, w := range s.Workers() {
+		if err := w.Observe(ctx, feedback); err != nil {
+			errs = append(errs, fmt.Errorf("observe worker %s: %w", w.ID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Plan returns the planner's decomposition without dispatching it.
+func (s *SupervisorAgent) Plan(ctx context.Context, input *Input) (*PlanResult, error) {
+	subtasks, err := s.decompose(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]string, len(subtasks))
+	for i, st := range subtasks {
+		steps[i] = st.Description
+	}
+	return &PlanResult{
+		Steps:    steps,
+		Metadata: map[string]any{"subtasks": subtasks},
+	}, nil
+}
+
+// AddWorker adds a worker to the pool.
+func (s *SupervisorAgent) AddWorker(w Agent) error {
+	if w == nil {
+		return errors.New("worker is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.byID[w.ID()]; exists {
+		return fmt.Errorf("worker %s already registered", w.ID())
+	}
+	s.workers = append(s.workers, w)
+	s.byID[w.ID()] = w
+	return nil
+}
+
+// RemoveWorker removes a worker from the pool.
+func (s *SupervisorAgent) RemoveWorker(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byID[id]; !ok {
+		return false
+	}
+	delete(s.byID, id)
+	for i, w := range s.workers {
+		if w.ID() == id {
+			s.workers = append(s.workers[:i], s.workers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Workers returns a snapshot of the worker pool.
+func (s *SupervisorAgent) Workers() []Agent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Agent(nil), s.workers...)
+}
+
+// Execute decomposes the input, runs subtasks on workers and aggregates the results.
+func (s *SupervisorAgent) Execute(ctx context.Context, input *Input) (*Output, error) {
+	if input == nil {
+		return nil, errors.New("input is nil")
+	}
+	start := time.Now()
+	if _, ok := types.RunID(ctx); !ok {
+		ctx = types.WithRunID(ctx, fmt.Sprintf("sup_%d", start.UnixNano()))
+	}
+	s.setState(StateRunning)
+
+	subtasks, err := s.decompose(ctx, input)
+	if err != nil {
+		s.setState(StateFailed)
+		return nil, err
+	}
+	s.logger.Info("supervisor dispatching subtasks",
+		zap.String("trace_id", input.TraceID),
+		zap.Int("subtasks", len(subtasks)))
+
+	results, err := s.dispatch(ctx, input, subtasks)
+	if err != nil {
+		s.setState(StateFailed)
+		return nil, err
+	}
+
+	out, err := s.aggregator.Aggregate(ctx, input, results)
+	if err != nil {
+		s.setState(StateFailed)
+		return nil, fmt.Errorf("aggregate subtask results: %w", err)
+	}
+	if out.TraceID == "" {
+		out.TraceID = input.TraceID
+	}
+	if out.Metadata == nil {
+		out.Metadata = make(map[string]any)
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	out.Metadata["supervisor_id"] = s.id
+	out.Metadata["subtask_results"] = results
+	out.Metadata["subtasks_failed"] = failed
+	out.Duration = time.Since(start)
+
+	s.setState(StateReady)
+	s.logger.Info("supervisor completed",
+		zap.String("trace_id", input.TraceID),
+		zap.Int("subtasks", len(results)),
+		zap.Int("failed", failed),
+		zap.Duration("duration", out.Duration))
+	return out, nil
+}
+
+func (s *SupervisorAgent) decompose(ctx context.Context, input *Input) ([]SupervisorSubtask, error) {
+	subtasks, err := s.planner.Decompose(ctx, input, s.Workers())
+	if err != nil {
+		return nil, fmt.Errorf("decompose task: %w", err)
+	}
+	out := make([]SupervisorSubtask, 0, len(subtasks))
+	for i, st := range subtasks {
+		if strings.TrimSpace(st.Description) == "" {
+			continue
+		}
+		if st.ID == "" {
+			st.ID = fmt.Sprintf("subtask_%d", i+1)
+		}
+		out = append(out, st)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("decompose task: planner returned no subtasks")
+	}
+	return out, nil
+}
+
+func (s *SupervisorAgent) dispatch(ctx context.Context, input *Input, subtasks []SupervisorSubtask) ([]SupervisorSubtaskResult, error) {
+	limit := s.config.MaxConcurrency
+	if limit <= 0 {
+		limit = len(s.Workers())
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]SupervisorSubtaskResult, len(subtasks))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+
+	for i, st := range subtasks {
+		wg.Add(1)
+		go func(idx int, st SupervisorSubtask) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[idx] = SupervisorSubtaskResult{Subtask: st, Err: ctx.Err(), Error: ctx.Err().Error()}
+				return
+			}
+			results[idx] = s.runSubtask(ctx, input, st)
+			if results[idx].Err != nil && s.config.FailurePolicy == SupervisorFailFast {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("subtask %s failed: %w", st.ID, results[idx].Err)
+					cancel()
+				})
+			}
+		}(i, st)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for _, r := range results {
+		if r.Err == nil {
+			return results, nil
+		}
+	}
+	return nil, fmt.Errorf("all %d subtasks failed: %w", len(results), results[0].Err)
+}
+
+// runSubtask executes a subtask with retries, preferring a fresh worker on each retry.
+func (s *SupervisorAgent) runSubtask(ctx context.Context, input *Input, st SupervisorSubtask) SupervisorSubtaskResult {
+	result := SupervisorSubtaskResult{Subtask: st}
+	start := time.Now()
+	var failedWorkers []string
+
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			break
+		}
+		worker, err := s.selectWorker(ctx, st, failedWorkers)
+		if err != nil {
+			result.Err = err
+			break
+		}
+		result.WorkerID = worker.ID()
+		result.Attempts = attempt + 1
+
+		out, err := s.runAttempt(ctx, worker, input, st)
+		if err == nil {
+			result.Output = out
+			result.Err = nil
+			break
+		}
+		result.Err = err
+		failedWorkers = append(failedWorkers, worker.ID())
+		s.logger.Warn("subtask attempt failed",
+			zap.String("subtask_id", st.ID),
+			zap.String("worker_id", worker.ID()),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err))
+	}
+
+	result.Duration = time.Since(start)
+	if result.Err != nil {
+		result.Error = result.Err.Error()
+	}
+	return result
+}
+
+func (s *SupervisorAgent) runAttempt(ctx context.Context, worker Agent, input *Input, st SupervisorSubtask) (out *Output, err error) {
+	if s.config.SubtaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.SubtaskTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("worker %s panicked: %v", worker.ID(), r)
+		}
+	}()
+
+	subInput := &Input{
+		TraceID:   input.TraceID,
+		TenantID:  input.TenantID,
+		UserID:    input.UserID,
+		ChannelID: input.ChannelID,
+		Content:   st.Description,
+		Context:   cloneAnyMap(input.Context),
+		Variables: input.Variables,
+	}
+	if subInput.Context == nil {
+		subInput.Context = make(map[string]any)
+	}
+	subInput.Context["supervisor_id"] = s.id
+	subInput.Context["subtask_id"] = st.ID
+	subInput.Context["original_task"] = input.Content
+
+	out, err = worker.Execute(ctx, subInput)
+	if err == nil && out == nil {
+		err = fmt.Errorf("worker %s returned no output", worker.ID())
+	}
+	return out, err
+}
+
+// selectWorker resolves a pinned worker, then asks discovery, then falls back to round-robin.
+func (s *SupervisorAgent) selectWorker(ctx context.Context, st SupervisorSubtask, exclude []string) (Agent, error) {
+	s.mu.RLock()
+	pinned, hasPinned := s.byID[st.WorkerID]
+	s.mu.RUnlock()
+	if hasPinned && !slices.Contains(exclude, st.WorkerID) {
+		return pinned, nil
+	}
+
+	if s.matcher != nil {
+		matches, err := s.matcher.Match(ctx, &tools.MatchRequest{
+			TaskDescription:      st.Description,
+			RequiredCapabilities: st.RequiredCapabilities,
+			ExcludedAgents:       exclude,
+		})
+		if err != nil {
+			s.logger.Warn("worker discovery failed, falling back to round-robin",
+				zap.String("subtask_id", st.ID), zap.Error(err))
+		}
+		s.mu.RLock()
+		for _, m := range matches {
+			if m == nil || m.Agent == nil || m.Agent.Card == nil {
+				continue
+			}
+			if w, ok := s.byID[m.Agent.Card.Name]; ok {
+				s.mu.RUnlock()
+				return w, nil
+			}
+		}
+		s.mu.RUnlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.workers) == 0 {
+		return nil, errors.New("supervisor has no workers")
+	}
+	for i := 0; i < len(s.workers); i++ {
+		w := s.workers[(s.rrCursor+i)%len(s.workers)]
+		if !slices.Contains(exclude, w.ID()) {
+			s.rrCursor = (s.rrCursor + i + 1) % len(s.workers)
+			return w, nil
+		}
+	}
+	// 所有 worker 都失败过时仍然重试轮询到的 worker
+	w := s.workers[s.rrCursor%len(s.workers)]
+	s.rrCursor = (s.rrCursor + 1) % len(s.workers)
+	return w, nil
+}
+
+func (s *SupervisorAgent) setState(state State) {
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+}
+
+// concatSupervisorResults is the default aggregator: successful outputs in plan order.
+func concatSupervisorResults(_ context.Context, input *Input, results []SupervisorSubtaskResult) (*Output, error) {
+	var b strings.Builder
+	out := &Output{TraceID: input.TraceID}
+	for _, r := range results {
+		if r.Err != nil || r.Output == nil {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%s] %s", r.Subtask.Description, r.Output.Content)
+		out.TokensUsed += r.Output.TokensUsed
+		out.Cost += r.Output.Cost
+	}
+	out.Content = b.String()
+	out.FinishReason = "stop"
+	return out, nil
+}
+
+// ====== LLM planner ======
+
+// SupervisorChatFunc sends messages to a chat model.
+type SupervisorChatFunc func(ctx context.Context, messages []types.Message) (*types.ChatResponse, error)
+
+// LLMSupervisorPlanner decomposes inputs with a chat model that replies in JSON.
+type LLMSupervisorPlanner struct {
+	chat SupervisorChatFunc
+	// MaxSubtasks caps the number of subtasks kept from the plan. Defaults to 8.
+	MaxSubtasks int
+}
+
+// NewLLMSupervisorPlanner creates an LLM-backed SupervisorPlanner.
+// BaseAgent.ChatCompletion can be passed directly as chat.
+func NewLLMSupervisorPlanner(chat SupervisorChatFunc) *LLMSupervisorPlanner {
+	return &LLMSupervisorPlanner{chat: chat, MaxSubtasks: 8}
+}
+
+const supervisorPlanPrompt = `You are a supervisor coordinating a team of worker agents. Split the task into independent subtasks that can run in parallel.
+Each subtask must be self-contained: a worker sees only its own description.
+Assign "worker_id" only when one listed worker is clearly the right fit; otherwise leave it empty. List any "required_capabilities" by name.
+Reply with JSON only: {"subtasks": [{"id": "s1", "description": "...", "worker_id": "", "required_capabilities": []}]}
+
+Workers:
+%s
+
+Task:
+%s`
+
+// Decompose implements SupervisorPlanner.
+func (p *LLMSupervisorPlanner) Decompose(ctx context.Context, input *Input, workers []Agent) ([]SupervisorSubtask, error) {
+	if p == nil || p.chat == nil {
+		return nil, errors.New("supervisor planner chat function is nil")
+	}
+	var roster strings.Builder
+	for _, w := range workers {
+		fmt.Fprintf(&roster, "- id=%s name=%s type=%s\n", w.ID(), w.Name(), w.Type())
+	}
+	resp, err := p.chat(ctx, []types.Message{{
+		Role:    llm.RoleUser,
+		Content: fmt.Sprintf(supervisorPlanPrompt, roster.String(), input.Content),
+	}})
+	if err != nil {
+		return nil, err
+	}
+	choice, err := llm.FirstChoice(resp)
+	if err != nil {
+		return nil, err
+	}
+	raw := extractJSONObject(choice.Message.Content)
+	if raw == "" {
+		return nil, errors.New("planner response contains no JSON object")
+	}
+	var reply struct {
+		Subtasks []SupervisorSubtask `json:"subtasks"`
+	}
+	if err := json.Unmarshal([]byte(raw), &reply); err != nil {
+		return nil, fmt.Errorf("parse planner response: %w", err)
+	}
+	if p.MaxSubtasks > 0 && len(reply.Subtasks) > p.MaxSubtasks {
+		reply.Subtasks = reply.Subtasks[:p.MaxSubtasks]
+	}
+	return reply.Subtasks, nil
+}
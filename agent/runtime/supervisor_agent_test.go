@@ -0,0 +1,252 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/tools"
+	a2ashared "github.com/BaSui01/agentflow/agent/execution/protocol/a2a/shared"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type supervisorTestWorker struct {
+	id       string
+	fail     int32 // number of leading calls that fail
+	calls    atomic.Int32
+	delay    time.Duration
+	inflight *atomic.Int32
+	peak     *atomic.Int32
+	mu       sync.Mutex
+	inputs   []*Input
+}
+
+func (w *supervisorTestWorker) ID() string                               { return w.id }
+func (w *supervisorTestWorker) Name() string                             { return w.id }
+func (w *supervisorTestWorker) Type() AgentType                          { return TypeGeneric }
+func (w *supervisorTestWorker) State() State                             { return StateReady }
+func (w *supervisorTestWorker) Init(context.Context) error               { return nil }
+func (w *supervisorTestWorker) Teardown(context.Context) error           { return nil }
+func (w *supervisorTestWorker) Observe(context.Context, *Feedback) error { return nil }
+func (w *supervisorTestWorker) Plan(context.Context, *Input) (*PlanResult, error) {
+	return &PlanResult{}, nil
+}
+
+func (w *supervisorTestWorker) Execute(ctx context.Context, input *Input) (*Output, error) {
+	n := w.calls.Add(1)
+	w.mu.Lock()
+	w.inputs = append(w.inputs, input)
+	w.mu.Unlock()
+	if w.inflight != nil {
+		cur := w.inflight.Add(1)
+		defer w.inflight.Add(-1)
+		for {
+			peak := w.peak.Load()
+			if cur <= peak || w.peak.CompareAndSwap(peak, cur) {
+				break
+			}
+		}
+	}
+	if w.delay > 0 {
+		select {
+		case <-time.After(w.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if n <= w.fail {
+		return nil, errors.New(w.id + " unavailable")
+	}
+	return &Output{Content: w.id + ":" + input.Content, TokensUsed: 10, Cost: 0.01}, nil
+}
+
+type supervisorTestMatcher struct {
+	byCapability map[string]string
+	requests     []*tools.MatchRequest
+	mu           sync.Mutex
+}
+
+func (m *supervisorTestMatcher) Match(_ context.Context, req *tools.MatchRequest) ([]*tools.MatchResult, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	m.mu.Unlock()
+	var out []*tools.MatchResult
+	for _, capName := range req.RequiredCapabilities {
+		if id, ok := m.byCapability[capName]; ok {
+			out = append(out, &tools.MatchResult{Agent: &tools.AgentInfo{Card: &a2ashared.AgentCard{Name: id}}})
+		}
+	}
+	return out, nil
+}
+
+func (m *supervisorTestMatcher) MatchOne(ctx context.Context, req *tools.MatchRequest) (*tools.MatchResult, error) {
+	results, err := m.Match(ctx, req)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (m *supervisorTestMatcher) Score(context.Context, *tools.AgentInfo, *tools.MatchRequest) (float64, error) {
+	return 0, nil
+}
+
+func staticSupervisorPlanner(subtasks ...SupervisorSubtask) SupervisorPlanner {
+	return SupervisorPlannerFunc(func(context.Context, *Input, []Agent) ([]SupervisorSubtask, error) {
+		return subtasks, nil
+	})
+}
+
+func TestSupervisorAgent_DispatchesThroughDiscovery(t *testing.T) {
+	research := &supervisorTestWorker{id: "researcher"}
+	writer := &supervisorTestWorker{id: "writer"}
+	matcher := &supervisorTestMatcher{byCapability: map[string]string{"search": "researcher", "writing": "writer"}}
+
+	sup, err := NewSupervisorAgent(SupervisorAgentConfig{
+		ID: "sup",
+		Planner: staticSupervisorPlanner(
+			SupervisorSubtask{ID: "s1", Description: "find sources", RequiredCapabilities: []string{"search"}},
+			SupervisorSubtask{ID: "s2", Description: "draft summary", RequiredCapabilities: []string{"writing"}},
+		),
+		Matcher: matcher,
+	}, []Agent{writer, research}, nil)
+	require.NoError(t, err)
+	require.NoError(t, sup.Init(context.Background()))
+
+	out, err := sup.Execute(context.Background(), &Input{TraceID: "t1", Content: "write a report", Context: map[string]any{"k": "v"}})
+	require.NoError(t, err)
+	assert.Equal(t, "t1", out.TraceID)
+	assert.Equal(t, "[find sources] researcher:find sources\n\n[draft summary] writer:draft summary", out.Content)
+	assert.Equal(t, 20, out.TokensUsed)
+	assert.InDelta(t, 0.02, out.Cost, 1e-9)
+	assert.Equal(t, 0, out.Metadata["subtasks_failed"])
+	assert.Equal(t, StateReady, sup.State())
+
+	require.Len(t, research.inputs, 1)
+	assert.Equal(t, "write a report", research.inputs[0].Context["original_task"])
+	assert.Equal(t, "v", research.inputs[0].Context["k"])
+	assert.Equal(t, "s1", research.inputs[0].Context["subtask_id"])
+}
+
+func TestSupervisorAgent_RetriesOnAnotherWorker(t *testing.T) {
+	flaky := &supervisorTestWorker{id: "flaky", fail: 10}
+	steady := &supervisorTestWorker{id: "steady"}
+	sup, err := NewSupervisorAgent(SupervisorAgentConfig{
+		Planner: staticSupervisorPlanner(SupervisorSubtask{ID: "s1", Description: "task", WorkerID: "flaky"}),
+	}, []Agent{flaky, steady}, nil)
+	require.NoError(t, err)
+
+	out, err := sup.Execute(context.Background(), &Input{Content: "go"})
+	require.NoError(t, err)
+	results := out.Metadata["subtask_results"].([]SupervisorSubtaskResult)
+	require.Len(t, results, 1)
+	assert.Equal(t, "steady", results[0].WorkerID)
+	assert.Equal(t, 2, results[0].Attempts)
+	assert.Equal(t, int32(1), flaky.calls.Load())
+}
+
+func TestSupervisorAgent_FailurePolicies(t *testing.T) {
+	plan := staticSupervisorPlanner(
+		SupervisorSubtask{ID: "ok", Description: "ok", WorkerID: "good"},
+		SupervisorSubtask{ID: "bad", Description: "bad", WorkerID: "broken"},
+	)
+
+	t.Run("partial result", func(t *testing.T) {
+		sup, err := NewSupervisorAgent(SupervisorAgentConfig{Planner: plan, MaxRetries: -1},
+			[]Agent{&supervisorTestWorker{id: "good"}, &supervisorTestWorker{id: "broken", fail: 10}}, nil)
+		require.NoError(t, err)
+		out, err := sup.Execute(context.Background(), &Input{Content: "x"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, out.Metadata["subtasks_failed"])
+		assert.Contains(t, out.Content, "good:ok")
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		sup, err := NewSupervisorAgent(SupervisorAgentConfig{Planner: plan, MaxRetries: -1, FailurePolicy: SupervisorFailFast},
+			[]Agent{&supervisorTestWorker{id: "good"}, &supervisorTestWorker{id: "broken", fail: 10}}, nil)
+		require.NoError(t, err)
+		_, err = sup.Execute(context.Background(), &Input{Content: "x"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "subtask bad failed")
+		assert.Equal(t, StateFailed, sup.State())
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		sup, err := NewSupervisorAgent(SupervisorAgentConfig{Planner: staticSupervisorPlanner(SupervisorSubtask{Description: "bad"})},
+			[]Agent{&supervisorTestWorker{id: "broken", fail: 10}}, nil)
+		require.NoError(t, err)
+		_, err = sup.Execute(context.Background(), &Input{Content: "x"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "all 1 subtasks failed")
+	})
+}
+
+func TestSupervisorAgent_BoundsConcurrency(t *testing.T) {
+	inflight, peak := &atomic.Int32{}, &atomic.Int32{}
+	var workers []Agent
+	var subtasks []SupervisorSubtask
+	for _, id := range []string{"a", "b", "c", "d"} {
+		workers = append(workers, &supervisorTestWorker{id: id, delay: 20 * time.Millisecond, inflight: inflight, peak: peak})
+		subtasks = append(subtasks, SupervisorSubtask{Description: "task " + id, WorkerID: id})
+	}
+	sup, err := NewSupervisorAgent(SupervisorAgentConfig{Planner: staticSupervisorPlanner(subtasks...), MaxConcurrency: 2}, workers, nil)
+	require.NoError(t, err)
+
+	_, err = sup.Execute(context.Background(), &Input{Content: "x"})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestSupervisorAgent_PlanAndValidation(t *testing.T) {
+	_, err := NewSupervisorAgent(SupervisorAgentConfig{}, []Agent{&supervisorTestWorker{id: "a"}}, nil)
+	assert.Error(t, err)
+	_, err = NewSupervisorAgent(SupervisorAgentConfig{Planner: staticSupervisorPlanner()}, nil, nil)
+	assert.Error(t, err)
+	_, err = NewSupervisorAgent(SupervisorAgentConfig{Planner: staticSupervisorPlanner()},
+		[]Agent{&supervisorTestWorker{id: "a"}, &supervisorTestWorker{id: "a"}}, nil)
+	assert.Error(t, err)
+
+	sup, err := NewSupervisorAgent(SupervisorAgentConfig{
+		Planner: staticSupervisorPlanner(SupervisorSubtask{Description: "one"}, SupervisorSubtask{Description: "  "}),
+	}, []Agent{&supervisorTestWorker{id: "a"}}, nil)
+	require.NoError(t, err)
+	plan, err := sup.Plan(context.Background(), &Input{Content: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one"}, plan.Steps)
+	assert.Equal(t, TypeSupervisor, sup.Type())
+
+	empty, err := NewSupervisorAgent(SupervisorAgentConfig{Planner: staticSupervisorPlanner()}, []Agent{&supervisorTestWorker{id: "a"}}, nil)
+	require.NoError(t, err)
+	_, err = empty.Execute(context.Background(), &Input{Content: "x"})
+	assert.ErrorContains(t, err, "no subtasks")
+}
+
+func TestLLMSupervisorPlanner_Decompose(t *testing.T) {
+	var prompt string
+	planner := NewLLMSupervisorPlanner(func(_ context.Context, messages []types.Message) (*types.ChatResponse, error) {
+		prompt = messages[0].Content
+		reply := "```json\n{\"subtasks\": [{\"id\": \"s1\", \"description\": \"look up\", \"worker_id\": \"researcher\"}, {\"description\": \"write\", \"required_capabilities\": [\"writing\"]}]}\n```"
+		return &types.ChatResponse{Choices: []types.ChatChoice{{Message: types.Message{Role: types.RoleAssistant, Content: reply}}}}, nil
+	})
+
+	subtasks, err := planner.Decompose(context.Background(), &Input{Content: "research and write"}, []Agent{&supervisorTestWorker{id: "researcher"}})
+	require.NoError(t, err)
+	require.Len(t, subtasks, 2)
+	assert.Equal(t, "researcher", subtasks[0].WorkerID)
+	assert.Equal(t, []string{"writing"}, subtasks[1].RequiredCapabilities)
+	assert.True(t, strings.Contains(prompt, "id=researcher"))
+	assert.True(t, strings.Contains(prompt, "research and write"))
+
+	bad := NewLLMSupervisorPlanner(func(context.Context, []types.Message) (*types.ChatResponse, error) {
+		return &types.ChatResponse{Choices: []types.ChatChoice{{Message: types.Message{Content: "no plan"}}}}, nil
+	})
+	_, err = bad.Decompose(context.Background(), &Input{Content: "x"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	toolcap "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ToolEmbedder 语义工具选择所需的向量化能力
+type ToolEmbedder = toolcap.ToolEmbedder
+
+// SemanticToolSelectionConfig 语义工具选择配置
+type SemanticToolSelectionConfig = toolcap.SemanticToolSelectorConfig
+
+// DefaultSemanticToolSelectionConfig 返回默认语义工具选择配置
+func DefaultSemanticToolSelectionConfig() *SemanticToolSelectionConfig {
+	config := toolcap.DefaultSemanticToolSelectorConfig()
+	return &config
+}
+
+type semanticToolSelectionSetup struct {
+	embedder ToolEmbedder
+	config   SemanticToolSelectionConfig
+}
+
+// toolCatalogToolName 分类目录元工具名称
+const toolCatalogToolName = "browse_tools"
+
+// SemanticToolSelector 按工具描述向量与任务的相似度选择 Top-K 工具，固定工具始终保留。
+// 语义选择不可用时，只下发固定工具和 browse_tools 元工具，由 LLM 逐级浏览分类目录，
+// 浏览到的工具在本次运行的后续迭代中可直接调用。
+type SemanticToolSelector struct {
+	inner  *toolcap.SemanticToolSelector
+	logger *zap.Logger
+}
+
+// NewSemanticToolSelector 创建语义工具选择器，embedder 为 nil 时总是使用分类目录
+func NewSemanticToolSelector(embedder ToolEmbedder, config SemanticToolSelectionConfig, logger *zap.Logger) *SemanticToolSelector {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SemanticToolSelector{
+		inner:  toolcap.NewSemanticToolSelector(embedder, config),
+		logger: logger.With(zap.String("component", "semantic_tool_selector")),
+	}
+}
+
+// SelectTools 选择与任务最相关的工具；退化为分类目录时只返回固定工具
+func (s *SemanticToolSelector) SelectTools(ctx context.Context, task string, availableTools []types.ToolSchema) ([]types.ToolSchema, error) {
+	selected, _, err := s.selectToolsOrCatalog(ctx, task, availableTools)
+	return selected, err
+}
+
+func (s *SemanticToolSelector) selectToolsOrCatalog(ctx context.Context, task string, availableTools []types.ToolSchema) ([]types.ToolSchema, *toolCatalog, error) {
+	selection, err := s.inner.Select(ctx, task, availableTools)
+	if err != nil {
+		return nil, nil, err
+	}
+	if selection.Catalog == nil {
+		return selection.Tools, nil, nil
+	}
+	s.logger.Debug("semantic tool selection unavailable, exposing tool catalog",
+		zap.Int("catalog_tools", selection.Catalog.Len()),
+		zap.Int("pinned_tools", len(selection.Tools)),
+	)
+	return selection.Tools, newToolCatalog(selection.Catalog), nil
+}
+
+// catalogToolSelector 由支持分类目录回退的选择器实现
+type catalogToolSelector interface {
+	selectToolsOrCatalog(ctx context.Context, task string, availableTools []types.ToolSchema) ([]types.ToolSchema, *toolCatalog, error)
+}
+
+// toolCatalog 是单次运行内的分类目录状态，记录 LLM 已浏览解锁的工具
+type toolCatalog struct {
+	tree *toolcap.ToolCategoryTree
+
+	mu       sync.Mutex
+	unlocked []types.ToolSchema
+	seen     map[string]struct{}
+}
+
+func newToolCatalog(tree *toolcap.ToolCategoryTree) *toolCatalog {
+	return &toolCatalog{tree: tree, seen: make(map[string]struct{})}
+}
+
+type toolCatalogKey struct{}
+
+func withToolCatalog(ctx context.Context, catalog *toolCatalog) context.Context {
+	return context.WithValue(ctx, toolCatalogKey{}, catalog)
+}
+
+func toolCatalogFromContext(ctx context.Context) *toolCatalog {
+	if ctx == nil {
+		return nil
+	}
+	catalog, _ := ctx.Value(toolCatalogKey{}).(*toolCatalog)
+	return catalog
+}
+
+func (c *toolCatalog) schema() types.ToolSchema {
+	return types.ToolSchema{
+		Name: toolCatalogToolName,
+		Description: "Browse the tool catalog by category. Call with an empty category to list top-level categories, " +
+			"then drill into a category path. Tools listed in the result become callable.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{"category":{"type":"string","description":"Category path such as \"dev/github\"; empty for the top level"}}}`),
+		Traits:     &types.ToolTraits{SideEffect: types.ToolSideEffectNone, Idempotent: true},
+	}
+}
+
+// browse 返回分类视图并解锁其中的工具
+func (c *toolCatalog) browse(arguments json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Category string `json:"category"`
+	}
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, errors.New("invalid browse_tools arguments: " + err.Error())
+		}
+	}
+	view, err := c.tree.Browse(args.Category)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	for _, summary := range view.Tools {
+		if _, ok := c.seen[summary.Name]; ok {
+			continue
+		}
+		if tool, ok := c.tree.Tool(summary.Name); ok {
+			c.seen[summary.Name] = struct{}{}
+			c.unlocked = append(c.unlocked, tool)
+		}
+	}
+	c.mu.Unlock()
+	return json.Marshal(view)
+}
+
+func (c *toolCatalog) unlockedTools() []types.ToolSchema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]types.ToolSchema(nil), c.unlocked...)
+}
+
+// withUnlockedTools 把已解锁的工具追加到请求的工具列表
+func (c *toolCatalog) withUnlockedTools(req *types.ChatRequest) *types.ChatRequest {
+	unlocked := c.unlockedTools()
+	if req == nil || len(unlocked) == 0 {
+		return req
+	}
+	present := make(map[string]struct{}, len(req.Tools))
+	for _, tool := range req.Tools {
+		present[tool.Name] = struct{}{}
+	}
+	extended := *req
+	extended.Tools = append([]types.ToolSchema(nil), req.Tools...)
+	for _, tool := range unlocked {
+		if _, ok := present[tool.Name]; !ok {
+			extended.Tools = append(extended.Tools, tool)
+		}
+	}
+	return &extended
+}
+
+func (c *toolCatalog) wrapProvider(provider llm.Provider) llm.Provider {
+	if c == nil || provider == nil {
+		return provider
+	}
+	return toolCatalogProvider{Provider: provider, catalog: c}
+}
+
+func (c *toolCatalog) wrapExecutor(next llmtools.ToolExecutor) llmtools.ToolExecutor {
+	if c == nil {
+		return next
+	}
+	return &toolCatalogExecutor{next: next, catalog: c}
+}
+
+// toolCatalogProvider 在每次 LLM 调用前附加本次运行已解锁的工具
+type toolCatalogProvider struct {
+	llm.Provider
+	catalog *toolCatalog
+}
+
+func (p toolCatalogProvider) Completion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	return p.Provider.Completion(ctx, p.catalog.withUnlockedTools(req))
+}
+
+func (p toolCatalogProvider) Stream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return p.Provider.Stream(ctx, p.catalog.withUnlockedTools(req))
+}
+
+// toolCatalogExecutor 处理 browse_tools 调用，其余调用交给下游执行器
+type toolCatalogExecutor struct {
+	next    llmtools.ToolExecutor
+	catalog *toolCatalog
+}
+
+func (e *toolCatalogExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
+	if len(calls) == 0 {
+		return nil
+	}
+	results := make([]types.ToolResult, 0, len(calls))
+	for _, call := range calls {
+		results = append(results, e.ExecuteOne(ctx, call))
+	}
+	return results
+}
+
+func (e *toolCatalogExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) types.ToolResult {
+	if call.Name != toolCatalogToolName {
+		return e.next.ExecuteOne(ctx, call)
+	}
+	start := time.Now()
+	result := types.ToolResult{ToolCallID: call.ID, Name: call.Name}
+	out, err := e.catalog.browse(call.Arguments)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = out
+	return result
+}
+
+func (e *toolCatalogExecutor) ExecuteOneStream(ctx context.Context, call types.ToolCall) <-chan llmtools.ToolStreamEvent {
+	if call.Name != toolCatalogToolName {
+		if streamer, ok := e.next.(llmtools.StreamableToolExecutor); ok {
+			return streamer.ExecuteOneStream(ctx, call)
+		}
+	}
+	ch := make(chan llmtools.ToolStreamEvent, 1)
+	go func() {
+		defer close(ch)
+		result := e.ExecuteOne(ctx, call)
+		if result.Error != "" {
+			ch <- llmtools.ToolStreamEvent{
+				Type:     llmtools.ToolStreamError,
+				ToolName: call.Name,
+				Error:    errors.New(result.Error),
+			}
+			return
+		}
+		ch <- llmtools.ToolStreamEvent{
+			Type:     llmtools.ToolStreamComplete,
+			ToolName: call.Name,
+			Data:     result,
+		}
+	}()
+	return ch
+}
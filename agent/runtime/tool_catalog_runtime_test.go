@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type toolRecordingProvider struct {
+	toolCallingProvider
+	requestTools [][]string
+}
+
+func (p *toolRecordingProvider) Completion(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
+	names := make([]string, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		names = append(names, tool.Name)
+	}
+	p.requestTools = append(p.requestTools, names)
+	return p.toolCallingProvider.Completion(ctx, req)
+}
+
+type keywordToolEmbedder struct{}
+
+func (keywordToolEmbedder) vector(text string) []float64 {
+	text = strings.ToLower(text)
+	dims := []string{"weather", "issue", "file"}
+	vec := make([]float64, len(dims))
+	for i, dim := range dims {
+		if strings.Contains(text, dim) {
+			vec[i] = 1
+		}
+	}
+	return vec
+}
+
+func (e keywordToolEmbedder) EmbedQuery(_ context.Context, query string) ([]float64, error) {
+	return e.vector(query), nil
+}
+
+func (e keywordToolEmbedder) EmbedDocuments(_ context.Context, documents []string) ([][]float64, error) {
+	out := make([][]float64, len(documents))
+	for i, doc := range documents {
+		out[i] = e.vector(doc)
+	}
+	return out, nil
+}
+
+func catalogTestTools() []types.ToolSchema {
+	object := json.RawMessage(`{"type":"object"}`)
+	return []types.ToolSchema{
+		{Name: "fs_read", Description: "Read a file", Parameters: object},
+		{Name: "fs_write", Description: "Write a file", Parameters: object},
+		{Name: "github_create_issue", Description: "Create an issue", Parameters: object},
+		{Name: "github_close_issue", Description: "Close an issue", Parameters: object},
+		{Name: "weather_forecast", Description: "Weather forecast for a city", Parameters: object},
+		{Name: "calendar_create_event", Description: "Create a calendar event", Parameters: object},
+	}
+}
+
+func toolCall(id, name, args string) types.ChatResponse {
+	return types.ChatResponse{
+		Model: "gpt-4",
+		Choices: []types.ChatChoice{{
+			Message: types.Message{
+				Role:      types.RoleAssistant,
+				ToolCalls: []types.ToolCall{{ID: id, Name: name, Arguments: json.RawMessage(args)}},
+			},
+		}},
+	}
+}
+
+func finalAnswer(content string) types.ChatResponse {
+	return types.ChatResponse{
+		Model:   "gpt-4",
+		Choices: []types.ChatChoice{{Message: types.Message{Role: types.RoleAssistant, Content: content}}},
+	}
+}
+
+func buildCatalogTestAgent(t *testing.T, provider *toolRecordingProvider, manager *recordingToolManager, embedder ToolEmbedder) *BaseAgent {
+	t.Helper()
+	cfg := types.AgentConfig{
+		Core:    types.CoreConfig{ID: "catalog-agent", Name: "Catalog Agent", Type: "assistant"},
+		LLM:     types.LLMConfig{Model: "gpt-4"},
+		Runtime: types.RuntimeConfig{MaxReActIterations: 4},
+		Control: types.AgentControlOptions{DisablePlanner: true, MaxLoopIterations: 1},
+	}
+	ag, err := newAgentBuilder(cfg).
+		WithGateway(testGateway(provider)).
+		WithToolManager(manager).
+		WithLogger(zap.NewNop()).
+		WithSemanticToolSelection(embedder, &SemanticToolSelectionConfig{TopK: 1, PinnedTools: []string{"fs_read"}}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, ag.Init(context.Background()))
+	return ag
+}
+
+func TestSemanticToolSelectionSendsPinnedAndTopKTools(t *testing.T) {
+	provider := &toolRecordingProvider{toolCallingProvider: toolCallingProvider{
+		responses: []types.ChatResponse{finalAnswer("sunny")},
+	}}
+	manager := &recordingToolManager{schemas: catalogTestTools()}
+	ag := buildCatalogTestAgent(t, provider, manager, keywordToolEmbedder{})
+
+	out, err := ag.Execute(context.Background(), &Input{Content: "what is the weather in Paris"})
+	require.NoError(t, err)
+	assert.Equal(t, "sunny", out.Content)
+	require.NotEmpty(t, provider.requestTools)
+	assert.ElementsMatch(t, []string{"fs_read", "weather_forecast"}, provider.requestTools[0])
+}
+
+func TestSemanticToolSelectionFallsBackToCatalogMetaTool(t *testing.T) {
+	provider := &toolRecordingProvider{toolCallingProvider: toolCallingProvider{
+		responses: []types.ChatResponse{
+			toolCall("call-1", toolCatalogToolName, `{"category":"weather"}`),
+			toolCall("call-2", "weather_forecast", `{"city":"Paris"}`),
+			finalAnswer("sunny"),
+		},
+	}}
+	manager := &recordingToolManager{
+		schemas: catalogTestTools(),
+		results: []types.ToolResult{{ToolCallID: "call-2", Name: "weather_forecast", Result: json.RawMessage(`"sunny"`)}},
+	}
+	ag := buildCatalogTestAgent(t, provider, manager, nil)
+
+	out, err := ag.Execute(context.Background(), &Input{Content: "what is the weather in Paris"})
+	require.NoError(t, err)
+	assert.Equal(t, "sunny", out.Content)
+
+	require.Len(t, provider.requestTools, 3)
+	assert.ElementsMatch(t, []string{"fs_read", toolCatalogToolName}, provider.requestTools[0])
+	assert.ElementsMatch(t, []string{"fs_read", toolCatalogToolName, "weather_forecast"}, provider.requestTools[1])
+	require.Len(t, manager.calls, 1)
+	assert.Equal(t, "weather_forecast", manager.calls[0].Name)
+}
+
+func TestToolCatalogBrowseReportsUnknownCategory(t *testing.T) {
+	selector := NewSemanticToolSelector(nil, SemanticToolSelectionConfig{TopK: 1}, nil)
+	selected, catalog, err := selector.selectToolsOrCatalog(context.Background(), "anything", catalogTestTools())
+	require.NoError(t, err)
+	assert.Empty(t, selected)
+	require.NotNil(t, catalog)
+
+	_, err = catalog.browse(json.RawMessage(`{"category":"nope"}`))
+	assert.Error(t, err)
+
+	raw, err := catalog.browse(nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"path":"github"`)
+	assert.Empty(t, catalog.unlockedTools())
+}
@@ -19,6 +19,10 @@ func classifyToolRiskByName(name string) string {
 	return toolcap.ClassifyToolRiskByName(name)
 }
 
+func classifyToolRisk(schema types.ToolSchema) string {
+	return toolcap.ClassifyToolRisk(schema)
+}
+
 func groupToolRisks(names []string) map[string][]string {
 	return toolcap.GroupToolRisks(normalizeStringSlice(names))
 }
@@ -29,6 +33,7 @@ type PreparedToolProtocol struct {
 	Executor     llmtools.ToolExecutor
 	HandoffTools map[string]RuntimeHandoffTarget
 	ToolRisks    map[string]string
+	ToolScopes   map[string][]string
 	AllowedTools []string
 	Authorize    AuthorizeFunc
 }
@@ -59,7 +64,8 @@ func (DefaultToolProtocolRuntime) Prepare(owner *BaseAgent, pr *preparedRequest)
 		}
 	}
 	allowed := append([]string(nil), pr.options.Tools.AllowedTools...)
-	base := newToolManagerExecutor(owner.toolManager, owner.config.Core.ID, allowed, owner.bus)
+	toolManager := NewValidatingToolManager(owner.toolManager, owner.currentToolCallValidator())
+	base := newToolManagerExecutor(toolManager, owner.config.Core.ID, allowed, owner.bus)
 	executor := llmtools.ToolExecutor(base)
 	if len(pr.handoffTools) > 0 {
 		targets := make([]RuntimeHandoffTarget, 0, len(pr.handoffTools))
@@ -68,10 +74,13 @@ func (DefaultToolProtocolRuntime) Prepare(owner *BaseAgent, pr *preparedRequest)
 		}
 		executor = newRuntimeHandoffExecutor(owner, base, targets)
 	}
+	executor = owner.skillRegistry.wrapExecutor(executor)
+	executor = pr.toolCatalog.wrapExecutor(executor)
 	return &PreparedToolProtocol{
 		Executor:     executor,
 		HandoffTools: cloneRuntimeHandoffMap(pr.handoffTools),
 		ToolRisks:    cloneStringMap(pr.toolRisks),
+		ToolScopes:   pr.toolScopes,
 		AllowedTools: allowed,
 		Authorize:    owner.authorize,
 	}
@@ -95,8 +104,9 @@ func executeAuthorizedToolCalls(ctx context.Context, prepared *PreparedToolProto
 	authz := NewAuthzMiddleware(prepared.Authorize)
 	for _, call := range calls {
 		result, err := authz.Execute(ctx, &toolAuthorizationInput{
-			ToolCall:  &call,
-			ToolRisks: prepared.ToolRisks,
+			ToolCall:   &call,
+			ToolRisks:  prepared.ToolRisks,
+			ToolScopes: prepared.ToolScopes,
 		})
 		if err != nil {
 			out = append(out, types.ToolResult{ToolCallID: call.ID, Name: call.Name, Error: err.Error()})
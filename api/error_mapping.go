@@ -40,9 +40,9 @@ func HTTPStatusFromErrorCode(code types.ErrorCode) int {
 		return http.StatusRequestEntityTooLarge
 	case types.ErrContentFiltered:
 		return http.StatusUnprocessableEntity
-	case types.ErrToolValidation:
+	case types.ErrToolValidation, types.ErrCapabilityUnsupported:
 		return http.StatusBadRequest
-	case types.ErrGuardrailsViolated:
+	case types.ErrGuardrailsViolated, types.ErrDataResidency:
 		return http.StatusForbidden
 
 	// 5xx 服务端错误
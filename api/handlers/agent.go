@@ -9,6 +9,7 @@ import (
 	"time"
 
 	discovery "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	agentobs "github.com/BaSui01/agentflow/agent/observability/monitoring"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 	"github.com/BaSui01/agentflow/api"
 	"github.com/BaSui01/agentflow/internal/usecase"
@@ -29,6 +30,7 @@ var validAgentID = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,127}$`)
 type AgentHandler struct {
 	BaseHandler[usecase.AgentService]
 	sessionMgr *agent.SessionManager
+	chatWS     ChatWebSocketOptions
 }
 
 // AgentInfo Agent information returned by the API
@@ -60,6 +62,7 @@ func NewAgentHandlerWithService(service usecase.AgentService, sessionMgr *agent.
 	return &AgentHandler{
 		BaseHandler: NewBaseHandler(service, logger),
 		sessionMgr:  sessionMgr,
+		chatWS:      ChatWebSocketOptions{MaxRunsPerConnection: defaultChatWSMaxRuns},
 	}
 }
 
@@ -170,6 +173,67 @@ func (h *AgentHandler) HandleGetAgent(w http.ResponseWriter, r *http.Request) {
 	WriteSuccess(w, toAgentInfo(info))
 }
 
+// HandleDecisionGraph exports an agent run's reasoning trace as a decision graph
+// @Summary Export decision graph
+// @Description Render the recorded reasoning steps, decisions and alternatives of a trace as JSON, Graphviz DOT or Mermaid
+// @Tags agent
+// @Produce json,text/vnd.graphviz,text/plain
+// @Param id path string true "Agent ID"
+// @Param trace_id path string true "Trace ID"
+// @Param format query string false "json (default), dot or mermaid"
+// @Success 200 {object} Response{data=agent.DecisionGraph} "Decision graph"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 404 {object} Response "Agent or trace not found"
+// @Security ApiKeyAuth
+// @Router /api/v1/agents/{id}/traces/{trace_id}/graph [get]
+func (h *AgentHandler) HandleDecisionGraph(w http.ResponseWriter, r *http.Request) {
+	agentID := extractAgentID(r)
+	if agentID == "" {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "agent ID is required", h.logger)
+		return
+	}
+	traceID := pathStringValue(r, "trace_id", 5)
+	if traceID == "" {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "trace ID is required", h.logger)
+		return
+	}
+	format, err := agentobs.ParseDecisionGraphFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, err.Error(), h.logger)
+		return
+	}
+
+	service, svcErr := h.currentServiceOrError()
+	if svcErr != nil {
+		h.handleAgentError(w, svcErr)
+		return
+	}
+	graph, svcErr := service.GetDecisionGraph(r.Context(), agentID, traceID)
+	if svcErr != nil {
+		h.handleAgentError(w, svcErr)
+		return
+	}
+	if format == agentobs.DecisionGraphJSON {
+		WriteSuccess(w, graph)
+		return
+	}
+
+	rendered, err := graph.Render(format, agentobs.DecisionGraphRenderOptions{})
+	if err != nil {
+		h.handleAgentError(w, err)
+		return
+	}
+	contentType := "text/plain; charset=utf-8"
+	if format == agentobs.DecisionGraphDOT {
+		contentType = "text/vnd.graphviz; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(rendered)); err != nil {
+		h.logger.Debug("failed to write decision graph", zap.Error(err))
+	}
+}
+
 // HandleExecuteAgent executes an agent
 // @Summary Execute agent
 // @Description Execute an agent with the given input
@@ -297,44 +361,11 @@ func (h *AgentHandler) HandleAgentStream(w http.ResponseWriter, r *http.Request)
 
 	// Build the RuntimeStreamEmitter that bridges agent events to SSE
 	emitter := func(event agent.RuntimeStreamEvent) {
-		var sseEvent string
-		var data []byte
-		var err error
-
-		switch event.Type {
-		case agent.RuntimeStreamToken:
-			sseEvent = "token"
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(map[string]any{"content": event.Delta}, event)))
-		case agent.RuntimeStreamReasoning:
-			sseEvent = "reasoning"
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(map[string]any{"reasoning_content": event.Reasoning}, event)))
-		case agent.RuntimeStreamToolCall:
-			sseEvent = "tool_call"
-			if event.ToolCall != nil {
-				data, err = json.Marshal(streamPayload(mergeExecutionFields(toolCallPayload(event.ToolCall), event)))
-			}
-		case agent.RuntimeStreamToolResult:
-			sseEvent = "tool_result"
-			if event.ToolResult != nil {
-				data, err = json.Marshal(streamPayload(mergeExecutionFields(toolResultPayload(event.ToolResult), event)))
-			}
-		case agent.RuntimeStreamToolProgress:
-			sseEvent = "tool_progress"
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(map[string]any{
-				"tool_call_id": event.ToolCallID,
-				"tool_name":    event.ToolName,
-				"progress":     event.Data,
-			}, event)))
-		case agent.RuntimeStreamStatus:
-			sseEvent = "status"
-			fields := map[string]any{}
-			if event.Data != nil {
-				if payload, ok := event.Data.(map[string]any); ok {
-					for key, value := range payload {
-						fields[key] = value
-					}
-				}
-			}
+		sseEvent, payload := runtimeStreamEventPayload(event)
+		if payload == nil {
+			return
+		}
+		if event.Type == agent.RuntimeStreamStatus {
 			h.logger.Debug("agent stream status",
 				zap.String("agent_id", req.AgentID),
 				zap.String("current_stage", event.CurrentStage),
@@ -342,17 +373,8 @@ func (h *AgentHandler) HandleAgentStream(w http.ResponseWriter, r *http.Request)
 				zap.String("selected_reasoning_mode", event.SelectedMode),
 				zap.String("stop_reason", event.StopReason),
 			)
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(fields, event)))
-		case agent.RuntimeStreamSteering:
-			sseEvent = "steering"
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(map[string]any{"content": event.SteeringContent}, event)))
-		case agent.RuntimeStreamStopAndSend:
-			sseEvent = "stop_and_send"
-			data, err = json.Marshal(streamPayload(mergeExecutionFields(map[string]any{"status": "restarting"}, event)))
-		default:
-			return
 		}
-
+		data, err := json.Marshal(payload)
 		if err != nil || data == nil {
 			return
 		}
@@ -698,6 +720,53 @@ func mergeExecutionFields(fields map[string]any, event agent.RuntimeStreamEvent)
 	return fields
 }
 
+// runtimeStreamEventPayload maps a runtime stream event to its client event name
+// and payload. It returns a nil payload for events that are not forwarded.
+func runtimeStreamEventPayload(event agent.RuntimeStreamEvent) (string, map[string]any) {
+	switch event.Type {
+	case agent.RuntimeStreamToken:
+		return "token", streamPayload(mergeExecutionFields(map[string]any{"content": event.Delta}, event))
+	case agent.RuntimeStreamReasoning:
+		return "reasoning", streamPayload(mergeExecutionFields(map[string]any{"reasoning_content": event.Reasoning}, event))
+	case agent.RuntimeStreamToolCall:
+		if event.ToolCall == nil {
+			return "tool_call", nil
+		}
+		return "tool_call", streamPayload(mergeExecutionFields(toolCallPayload(event.ToolCall), event))
+	case agent.RuntimeStreamToolResult:
+		if event.ToolResult == nil {
+			return "tool_result", nil
+		}
+		return "tool_result", streamPayload(mergeExecutionFields(toolResultPayload(event.ToolResult), event))
+	case agent.RuntimeStreamToolProgress:
+		return "tool_progress", streamPayload(mergeExecutionFields(map[string]any{
+			"tool_call_id": event.ToolCallID,
+			"tool_name":    event.ToolName,
+			"progress":     event.Data,
+		}, event))
+	case agent.RuntimeStreamToolCallDelta:
+		return "tool_call_delta", streamPayload(mergeExecutionFields(map[string]any{
+			"tool_call_id": event.ToolCallID,
+			"tool_name":    event.ToolName,
+			"delta":        event.Data,
+		}, event))
+	case agent.RuntimeStreamStatus:
+		fields := map[string]any{}
+		if payload, ok := event.Data.(map[string]any); ok {
+			for key, value := range payload {
+				fields[key] = value
+			}
+		}
+		return "status", streamPayload(mergeExecutionFields(fields, event))
+	case agent.RuntimeStreamSteering:
+		return "steering", streamPayload(mergeExecutionFields(map[string]any{"content": event.SteeringContent}, event))
+	case agent.RuntimeStreamStopAndSend:
+		return "stop_and_send", streamPayload(mergeExecutionFields(map[string]any{"status": "restarting"}, event))
+	default:
+		return "", nil
+	}
+}
+
 func streamSessionPayload(executionID string) map[string]any {
 	return streamPayload(map[string]any{
 		"execution_id": executionID,
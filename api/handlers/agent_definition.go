@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/api"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// AgentDefinitionHandler manages agents declared as YAML/JSON definitions.
+// Each create or update stores a new definition version and hot-deploys it.
+type AgentDefinitionHandler struct {
+	BaseHandler[appservice.AgentDefinitionService]
+}
+
+func NewAgentDefinitionHandler(service appservice.AgentDefinitionService, logger *zap.Logger) *AgentDefinitionHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AgentDefinitionHandler{BaseHandler: NewBaseHandler(service, logger)}
+}
+
+// HandleList returns the active version of every stored agent definition.
+func (h *AgentDefinitionHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	rows, err := service.List()
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, rows)
+}
+
+// HandleGet returns the active definition version of one agent.
+func (h *AgentDefinitionHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	row, err := service.Get(r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, row)
+}
+
+// HandleListVersions returns every stored version of one agent, newest first.
+func (h *AgentDefinitionHandler) HandleListVersions(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	rows, err := service.ListVersions(r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, rows)
+}
+
+// HandleCreate stores and deploys a new agent from a YAML or JSON body.
+func (h *AgentDefinitionHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	input, ok := h.readDefinition(w, r)
+	if !ok {
+		return
+	}
+	row, err := service.Create(r.Context(), input)
+	if err != nil {
+		logToolRequestWarn(h.logger, r, "agent_definition", "create", "failed", "agent definition request completed", zap.Error(err))
+		WriteError(w, err, h.logger)
+		return
+	}
+	logToolRequestInfo(h.logger, r, "agent_definition", "create", "success", "agent definition request completed",
+		zap.String("agent_id", row.AgentID), zap.Int("version", row.Version))
+	WriteJSON(w, http.StatusCreated, api.Response{Success: true, Data: row, Timestamp: time.Now(), RequestID: w.Header().Get("X-Request-ID")})
+}
+
+// HandleUpdate stores a new version of an existing agent and redeploys it.
+func (h *AgentDefinitionHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	input, ok := h.readDefinition(w, r)
+	if !ok {
+		return
+	}
+	agentID := r.PathValue("id")
+	row, err := service.Update(r.Context(), agentID, input)
+	if err != nil {
+		logToolRequestWarn(h.logger, r, "agent_definition", "update", "failed", "agent definition request completed", zap.Error(err), zap.String("agent_id", agentID))
+		WriteError(w, err, h.logger)
+		return
+	}
+	logToolRequestInfo(h.logger, r, "agent_definition", "update", "success", "agent definition request completed",
+		zap.String("agent_id", row.AgentID), zap.Int("version", row.Version))
+	WriteSuccess(w, row)
+}
+
+// HandleDelete removes all versions of an agent and retires its running instance.
+func (h *AgentDefinitionHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("agent definitions")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	agentID := r.PathValue("id")
+	if err := service.Delete(r.Context(), agentID); err != nil {
+		logToolRequestWarn(h.logger, r, "agent_definition", "delete", "failed", "agent definition request completed", zap.Error(err), zap.String("agent_id", agentID))
+		WriteError(w, err, h.logger)
+		return
+	}
+	logToolRequestInfo(h.logger, r, "agent_definition", "delete", "success", "agent definition request completed", zap.String("agent_id", agentID))
+	WriteSuccess(w, map[string]any{"deleted": agentID})
+}
+
+// readDefinition reads the raw definition body. The format comes from the
+// "format" query parameter, falling back to the Content-Type header.
+func (h *AgentDefinitionHandler) readDefinition(w http.ResponseWriter, r *http.Request) (appservice.AgentDefinitionInput, bool) {
+	if r.Body == nil {
+		WriteError(w, types.NewInvalidRequestError("request body is empty"), h.logger)
+		return appservice.AgentDefinitionInput{}, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteErrorMessage(w, http.StatusRequestEntityTooLarge, types.ErrInvalidRequest, "request body too large", h.logger)
+			return appservice.AgentDefinitionInput{}, false
+		}
+		WriteError(w, types.NewInvalidRequestError("failed to read request body").WithCause(err), h.logger)
+		return appservice.AgentDefinitionInput{}, false
+	}
+	return appservice.AgentDefinitionInput{Body: body, Format: definitionFormat(r)}, true
+}
+
+func definitionFormat(r *http.Request) string {
+	if format := strings.TrimSpace(r.URL.Query().Get("format")); format != "" {
+		return format
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.Contains(mediaType, "yaml"):
+		return "yaml"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/adapters/declarative"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type agentDefinitionRuntimeStub struct {
+	deployed map[string]types.AgentConfig
+	retired  []string
+	failNext bool
+	onDeploy func(cfg types.AgentConfig)
+}
+
+func (s *agentDefinitionRuntimeStub) Deploy(_ context.Context, cfg types.AgentConfig) error {
+	if s.onDeploy != nil {
+		s.onDeploy(cfg)
+	}
+	if s.failNext {
+		s.failNext = false
+		return errors.New("agent type not registered")
+	}
+	s.deployed[cfg.Core.ID] = cfg
+	return nil
+}
+
+func (s *agentDefinitionRuntimeStub) Retire(_ context.Context, agentID string) {
+	delete(s.deployed, agentID)
+	s.retired = append(s.retired, agentID)
+}
+
+func setupAgentDefinitionHandler(t *testing.T) (*AgentDefinitionHandler, *agentDefinitionRuntimeStub, *appservice.DefaultAgentDefinitionService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&declarative.AgentDefinitionRecord{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+	runtime := &agentDefinitionRuntimeStub{deployed: map[string]types.AgentConfig{}}
+	service := appservice.NewDefaultAgentDefinitionService(declarative.NewGormDefinitionStore(db), runtime, zap.NewNop())
+	return NewAgentDefinitionHandler(service, zap.NewNop()), runtime, service
+}
+
+func serveAgentDefinition(handler http.HandlerFunc, method, target, contentType string, body []byte, id string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	if id != "" {
+		r.SetPathValue("id", id)
+	}
+	handler(w, r)
+	return w
+}
+
+func decodeAgentDefinitionRecord(t *testing.T, w *httptest.ResponseRecorder) declarative.AgentDefinitionRecord {
+	t.Helper()
+	var resp struct {
+		Success bool                              `json:"success"`
+		Data    declarative.AgentDefinitionRecord `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.True(t, resp.Success)
+	return resp.Data
+}
+
+func TestAgentDefinitionHandler_VersionedLifecycle(t *testing.T) {
+	handler, runtime, _ := setupAgentDefinitionHandler(t)
+
+	yamlBody := []byte("name: researcher\nmodel: gpt-4o\nsystem_prompt: dig deep\ntools: [search]\n")
+	w := serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", yamlBody, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decodeAgentDefinitionRecord(t, w)
+	assert.Equal(t, "researcher", created.AgentID)
+	assert.Equal(t, 1, created.Version)
+	assert.Equal(t, "yaml", created.Format)
+	assert.True(t, created.Active)
+	assert.Equal(t, "dig deep", runtime.deployed["researcher"].Runtime.SystemPrompt)
+	assert.Equal(t, []string{"search"}, runtime.deployed["researcher"].Runtime.Tools)
+
+	w = serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", yamlBody, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	jsonBody := []byte(`{"name":"researcher","model":"gpt-4o-mini"}`)
+	w = serveAgentDefinition(handler.HandleUpdate, http.MethodPut, "/api/v1/agents/definitions/researcher", "application/json", jsonBody, "researcher")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := decodeAgentDefinitionRecord(t, w)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, "json", updated.Format)
+	assert.Equal(t, "gpt-4o-mini", runtime.deployed["researcher"].LLM.Model)
+
+	w = serveAgentDefinition(handler.HandleGet, http.MethodGet, "/api/v1/agents/definitions/researcher", "", nil, "researcher")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, decodeAgentDefinitionRecord(t, w).Version)
+
+	w = serveAgentDefinition(handler.HandleListVersions, http.MethodGet, "/api/v1/agents/definitions/researcher/versions", "", nil, "researcher")
+	require.Equal(t, http.StatusOK, w.Code)
+	var versions struct {
+		Data []declarative.AgentDefinitionRecord `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &versions))
+	require.Len(t, versions.Data, 2)
+	assert.Equal(t, 2, versions.Data[0].Version)
+	assert.True(t, versions.Data[0].Active)
+	assert.False(t, versions.Data[1].Active)
+	assert.Contains(t, versions.Data[1].Source, "dig deep")
+
+	w = serveAgentDefinition(handler.HandleDelete, http.MethodDelete, "/api/v1/agents/definitions/researcher", "", nil, "researcher")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"researcher"}, runtime.retired)
+
+	w = serveAgentDefinition(handler.HandleGet, http.MethodGet, "/api/v1/agents/definitions/researcher", "", nil, "researcher")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serveAgentDefinition(handler.HandleDelete, http.MethodDelete, "/api/v1/agents/definitions/researcher", "", nil, "researcher")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAgentDefinitionHandler_RejectsInvalidDefinitions(t *testing.T) {
+	handler, runtime, _ := setupAgentDefinitionHandler(t)
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "missing model", contentType: "application/yaml", body: "name: a1\n"},
+		{name: "malformed json", contentType: "application/json", body: `{"name":`},
+		{name: "invalid id", contentType: "application/json", body: `{"id":"../x","name":"a1","model":"m"}`},
+		{name: "empty body", contentType: "application/yaml", body: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", tc.contentType, []byte(tc.body), "")
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	w := serveAgentDefinition(handler.HandleUpdate, http.MethodPut, "/api/v1/agents/definitions/missing", "application/yaml", []byte("name: missing\nmodel: m\n"), "missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, runtime.deployed)
+}
+
+func TestAgentDefinitionHandler_UpdateRequiresMatchingID(t *testing.T) {
+	handler, _, _ := setupAgentDefinitionHandler(t)
+
+	w := serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions?format=json", "text/plain", []byte(`{"id":"a1","name":"Assistant","model":"m"}`), "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serveAgentDefinition(handler.HandleUpdate, http.MethodPut, "/api/v1/agents/definitions/a1", "application/json", []byte(`{"id":"a2","name":"Assistant","model":"m"}`), "a1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A body without id inherits the path id even when the name differs.
+	w = serveAgentDefinition(handler.HandleUpdate, http.MethodPut, "/api/v1/agents/definitions/a1", "application/json", []byte(`{"name":"Assistant v2","model":"m"}`), "a1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "a1", decodeAgentDefinitionRecord(t, w).AgentID)
+}
+
+func TestAgentDefinitionHandler_DeployFailureIsNotPersisted(t *testing.T) {
+	handler, runtime, service := setupAgentDefinitionHandler(t)
+
+	w := serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", []byte("name: a1\nmodel: m1\n"), "")
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	runtime.failNext = true
+	w = serveAgentDefinition(handler.HandleUpdate, http.MethodPut, "/api/v1/agents/definitions/a1", "application/yaml", []byte("name: a1\nmodel: m2\ntype: bogus\n"), "a1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	row, err := service.Get("a1")
+	require.Nil(t, err)
+	assert.Equal(t, 1, row.Version)
+	assert.Equal(t, "m1", runtime.deployed["a1"].LLM.Model)
+
+	// Restore redeploys the active version, e.g. after a restart.
+	runtime.deployed = map[string]types.AgentConfig{}
+	deployed, restoreErr := service.Restore(context.Background())
+	require.NoError(t, restoreErr)
+	assert.Equal(t, 1, deployed)
+	assert.Equal(t, "m1", runtime.deployed["a1"].LLM.Model)
+}
+
+func TestAgentDefinitionHandler_DeploysAfterCommit(t *testing.T) {
+	handler, runtime, service := setupAgentDefinitionHandler(t)
+
+	// The store allows a single connection, so reading it from Deploy would
+	// block if the save transaction were still open.
+	var activeAtDeploy int
+	runtime.onDeploy = func(cfg types.AgentConfig) {
+		row, err := service.Get(cfg.Core.ID)
+		if err == nil {
+			activeAtDeploy = row.Version
+		}
+	}
+	w := serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", []byte("name: a1\nmodel: m1\n"), "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 1, activeAtDeploy)
+
+	runtime.onDeploy = nil
+	runtime.failNext = true
+	w = serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", []byte("name: a2\nmodel: m1\n"), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	_, err := service.ListVersions("a2")
+	require.NotNil(t, err, "a definition that fails to deploy is removed")
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+
+	w = serveAgentDefinition(handler.HandleCreate, http.MethodPost, "/api/v1/agents/definitions", "application/yaml", []byte("name: a2\nmodel: m1\n"), "")
+	assert.Equal(t, http.StatusCreated, w.Code, "the rolled back id can be created again")
+}
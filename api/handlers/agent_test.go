@@ -13,6 +13,7 @@ import (
 
 	"github.com/BaSui01/agentflow/agent/capabilities/tools"
 	"github.com/BaSui01/agentflow/agent/execution/protocol/a2a"
+	agentobs "github.com/BaSui01/agentflow/agent/observability/monitoring"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 	"github.com/BaSui01/agentflow/internal/usecase"
 	"github.com/BaSui01/agentflow/types"
@@ -120,6 +121,7 @@ type stubAgentService struct {
 	getAgentFn            func(ctx context.Context, agentID string) (*tools.AgentInfo, *types.Error)
 	executeAgentFn        func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string) (*usecase.AgentExecuteResponse, time.Duration, *types.Error)
 	executeAgentStreamFn  func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error
+	getDecisionGraphFn    func(ctx context.Context, agentID, traceID string) (*agent.DecisionGraph, *types.Error)
 }
 
 func (s *stubAgentService) ResolveForOperation(ctx context.Context, agentID string, op usecase.AgentOperation) (agent.Agent, *types.Error) {
@@ -157,6 +159,13 @@ func (s *stubAgentService) ExecuteAgentStream(ctx context.Context, req usecase.A
 	return nil
 }
 
+func (s *stubAgentService) GetDecisionGraph(ctx context.Context, agentID, traceID string) (*agent.DecisionGraph, *types.Error) {
+	if s.getDecisionGraphFn != nil {
+		return s.getDecisionGraphFn(ctx, agentID, traceID)
+	}
+	return nil, nil
+}
+
 // =============================================================================
 // Test helpers
 // =============================================================================
@@ -287,6 +296,56 @@ func TestAgentHandler_HandleAgentInterrupt_MissingTypeUsesValidateRequest(t *tes
 	assert.Equal(t, "type is required", resp.Error.Message)
 }
 
+func TestAgentHandler_HandleDecisionGraph(t *testing.T) {
+	var gotAgent, gotTrace string
+	service := &stubAgentService{
+		getDecisionGraphFn: func(_ context.Context, agentID, traceID string) (*agent.DecisionGraph, *types.Error) {
+			gotAgent, gotTrace = agentID, traceID
+			if traceID != "trace-1" {
+				return nil, types.NewNotFoundError("trace not found")
+			}
+			return &agent.DecisionGraph{
+				TraceID: traceID,
+				Nodes: []agentobs.DecisionGraphNode{
+					{ID: "n1", Kind: agentobs.DecisionNodeTrace, Label: "trace trace-1"},
+					{ID: "n2", Kind: agentobs.DecisionNodeDecision, Label: "use search", Metadata: map[string]string{"confidence": "0.8"}},
+				},
+				Edges: []agentobs.DecisionGraphEdge{{From: "n1", To: "n2"}},
+			}, nil
+		},
+	}
+	handler := NewAgentHandlerWithService(service, nil, zap.NewNop())
+	t.Cleanup(handler.Shutdown)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/agents/{id}/traces/{trace_id}/graph", handler.HandleDecisionGraph)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/agents/a1/traces/trace-1/graph", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a1", gotAgent)
+	assert.Equal(t, "trace-1", gotTrace)
+	assert.Contains(t, w.Body.String(), `"confidence":"0.8"`)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/agents/a1/traces/trace-1/graph?format=dot", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/vnd.graphviz; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "n1 -> n2;")
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/agents/a1/traces/trace-1/graph?format=mermaid", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `n2{"use search"}`)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/agents/a1/traces/trace-1/graph?format=svg", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/agents/a1/traces/missing/graph", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestAgentHandler_HandleGetAgent_NotFound(t *testing.T) {
 	reg := newMockRegistry()
 	handler := newTestHandler(t, reg)
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/BaSui01/agentflow/api"
 	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/pkg/audit"
 	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
@@ -14,6 +16,7 @@ import (
 // APIKeyHandler 处理 API Key 管理的 CRUD 操作
 type APIKeyHandler struct {
 	BaseHandler[usecase.APIKeyService]
+	auditRecorder audit.Recorder
 }
 
 func NewAPIKeyHandler(service usecase.APIKeyService, logger *zap.Logger) *APIKeyHandler {
@@ -23,6 +26,28 @@ func NewAPIKeyHandler(service usecase.APIKeyService, logger *zap.Logger) *APIKey
 	return &APIKeyHandler{BaseHandler: NewBaseHandler(service, logger)}
 }
 
+// SetAuditRecorder 设置统一审计记录器，Key 的创建、轮换与删除会写入审计轨迹
+func (h *APIKeyHandler) SetAuditRecorder(recorder audit.Recorder) {
+	h.auditRecorder = recorder
+}
+
+func (h *APIKeyHandler) recordAudit(r *http.Request, action string, providerID, keyID uint, after any) {
+	if h.auditRecorder == nil {
+		return
+	}
+	_, err := h.auditRecorder.Record(r.Context(), audit.Event{
+		Category:   audit.CategoryAPIKey,
+		Action:     action,
+		Resource:   "api_key",
+		ResourceID: fmt.Sprintf("%d/%d", providerID, keyID),
+		After:      after,
+		Metadata:   map[string]any{"provider_id": providerID, "remote_addr": r.RemoteAddr},
+	})
+	if err != nil {
+		h.logger.Warn("failed to record api key audit event", zap.String("action", action), zap.Error(err))
+	}
+}
+
 // maskAPIKey 脱敏 API Key，仅显示末 4 位
 func maskAPIKey(key string) string {
 	if len(key) <= 4 {
@@ -159,6 +184,7 @@ func (h *APIKeyHandler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Reques
 		WriteError(w, svcErr, h.logger)
 		return
 	}
+	h.recordAudit(r, "api_key.create", providerID, resp.ID, resp)
 
 	WriteJSON(w, http.StatusCreated, api.Response{Success: true, Data: resp, Timestamp: time.Now(), RequestID: w.Header().Get("X-Request-ID")})
 }
@@ -211,6 +237,7 @@ func (h *APIKeyHandler) HandleUpdateAPIKey(w http.ResponseWriter, r *http.Reques
 		WriteError(w, svcErr, h.logger)
 		return
 	}
+	h.recordAudit(r, "api_key.update", providerID, keyID, resp)
 	WriteSuccess(w, resp)
 }
 
@@ -238,6 +265,7 @@ func (h *APIKeyHandler) HandleDeleteAPIKey(w http.ResponseWriter, r *http.Reques
 		WriteError(w, svcErr, h.logger)
 		return
 	}
+	h.recordAudit(r, "api_key.delete", providerID, keyID, nil)
 	WriteSuccess(w, map[string]string{"message": "API key deleted"})
 }
 
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+type AuditTrailHandler struct {
+	BaseHandler[usecase.AuditTrailService]
+}
+
+func NewAuditTrailHandler(service usecase.AuditTrailService, logger *zap.Logger) *AuditTrailHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AuditTrailHandler{BaseHandler: NewBaseHandler(service, logger)}
+}
+
+// HandleList GET /api/v1/audit
+// 不带 format 时返回 JSON 分页结果；format=jsonl|cef 时以附件形式导出，供 SIEM 采集。
+func (h *AuditTrailHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("audit trail")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	input, ok := parseAuditTrailListInput(w, r, h.logger)
+	if !ok {
+		return
+	}
+
+	rawFormat := strings.TrimSpace(r.URL.Query().Get("format"))
+	if rawFormat == "" {
+		rows, err := service.List(r.Context(), input)
+		if err != nil {
+			logToolRequestWarn(h.logger, r, "audit_trail", "list", "failed", "audit trail request completed", zap.Error(err))
+			WriteError(w, err, h.logger)
+			return
+		}
+		logToolRequestInfo(h.logger, r, "audit_trail", "list", "success", "audit trail request completed", zap.Int("count", len(rows)))
+		WriteSuccess(w, map[string]any{"events": rows})
+		return
+	}
+
+	format, parseErr := audit.ParseFormat(rawFormat)
+	if parseErr != nil {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "format must be one of: jsonl, cef", h.logger)
+		return
+	}
+	rows, err := service.Export(r.Context(), input)
+	if err != nil {
+		logToolRequestWarn(h.logger, r, "audit_trail", "export", "failed", "audit trail request completed", zap.Error(err))
+		WriteError(w, err, h.logger)
+		return
+	}
+	contentType := "application/x-ndjson"
+	if format == audit.FormatCEF {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="agentflow-audit.`+string(format)+`"`)
+	w.WriteHeader(http.StatusOK)
+	if exportErr := audit.Export(w, format, rows); exportErr != nil {
+		h.logger.Warn("audit trail export interrupted", zap.Error(exportErr))
+		return
+	}
+	logToolRequestInfo(h.logger, r, "audit_trail", "export", "success", "audit trail request completed",
+		zap.String("format", string(format)), zap.Int("count", len(rows)))
+}
+
+func parseAuditTrailListInput(
+	w http.ResponseWriter,
+	r *http.Request,
+	logger *zap.Logger,
+) (usecase.ListAuditEventsInput, bool) {
+	query := r.URL.Query()
+	input := usecase.ListAuditEventsInput{
+		Category:   strings.TrimSpace(query.Get("category")),
+		Actor:      strings.TrimSpace(query.Get("actor")),
+		Action:     strings.TrimSpace(query.Get("action")),
+		Resource:   strings.TrimSpace(query.Get("resource")),
+		ResourceID: strings.TrimSpace(query.Get("resource_id")),
+		Outcome:    strings.TrimSpace(query.Get("outcome")),
+		TenantID:   strings.TrimSpace(query.Get("tenant_id")),
+	}
+	limit, err := parsePositiveQueryInt(query.Get("limit"), "limit")
+	if err != nil {
+		WriteError(w, err.WithHTTPStatus(http.StatusBadRequest), logger)
+		return input, false
+	}
+	input.Limit = limit
+	offset, err := parseNonNegativeQueryInt(query.Get("offset"), "offset")
+	if err != nil {
+		WriteError(w, err.WithHTTPStatus(http.StatusBadRequest), logger)
+		return input, false
+	}
+	input.Offset = offset
+	for _, bound := range []struct {
+		field  string
+		target *time.Time
+	}{{"since", &input.Since}, {"until", &input.Until}} {
+		raw := strings.TrimSpace(query.Get(bound.field))
+		if raw == "" {
+			continue
+		}
+		parsed, parseErr := time.Parse(time.RFC3339, raw)
+		if parseErr != nil {
+			WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, bound.field+" must be an RFC3339 timestamp", logger)
+			return input, false
+		}
+		*bound.target = parsed
+	}
+	return input, true
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	llmrouter "github.com/BaSui01/agentflow/llm/runtime/router"
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAuditTrailTestHandler(t *testing.T) (*AuditTrailHandler, *audit.Trail) {
+	t.Helper()
+	trail := audit.NewTrail(audit.TrailConfig{})
+	for _, event := range []audit.Event{
+		{Category: audit.CategoryConfig, Action: "config.update", Resource: "config", ResourceID: "Log.Level", Before: "info", After: "debug"},
+		{Category: audit.CategoryGuardrail, Action: "guardrail.block", Resource: "keyword_validator", Outcome: audit.OutcomeDenied},
+	} {
+		_, err := trail.Record(context.Background(), event)
+		require.NoError(t, err)
+	}
+	return NewAuditTrailHandler(usecase.NewDefaultAuditTrailService(trail), zap.NewNop()), trail
+}
+
+func TestAuditTrailHandler_ListFiltersEvents(t *testing.T) {
+	handler, _ := newAuditTrailTestHandler(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?category=guardrail", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleList(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "\"events\"")
+	assert.Contains(t, rec.Body.String(), "\"action\":\"guardrail.block\"")
+	assert.NotContains(t, rec.Body.String(), "config.update")
+}
+
+func TestAuditTrailHandler_ExportFormats(t *testing.T) {
+	handler, _ := newAuditTrailTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?format=jsonl", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleList(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	var first audit.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "config.update", first.Action)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/audit?format=cef&outcome=denied", nil)
+	rec = httptest.NewRecorder()
+	handler.HandleList(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "CEF:0|AgentFlow|agentflow|"))
+	assert.Contains(t, rec.Body.String(), "|guardrail:guardrail.block|")
+	assert.Equal(t, 1, strings.Count(rec.Body.String(), "\n"))
+}
+
+func TestAuditTrailHandler_RejectsInvalidQuery(t *testing.T) {
+	handler, _ := newAuditTrailTestHandler(t)
+	for _, query := range []string{"limit=bad", "offset=-1", "since=yesterday", "format=xml", "limit=501"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.HandleList(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestAPIKeyHandler_RecordsAuditEvents(t *testing.T) {
+	db := setupTestDB(t)
+	trail := audit.NewTrail(audit.TrailConfig{})
+	h := NewAPIKeyHandler(usecase.NewDefaultAPIKeyService(llmrouter.NewGormAPIKeyStore(db)), zap.NewNop())
+	h.SetAuditRecorder(trail)
+
+	body, _ := json.Marshal(createAPIKeyRequest{APIKey: "sk-rotate-1234567890", BaseURL: "https://api.openai.com"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/providers/1/api-keys", bytes.NewReader(body))
+	req = req.WithContext(types.WithUserID(req.Context(), "ops-admin"))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	h.HandleCreateAPIKey(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/providers/1/api-keys/1", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("keyId", "1")
+	rec = httptest.NewRecorder()
+	h.HandleDeleteAPIKey(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	events, err := trail.Query(context.Background(), audit.Filter{Categories: []audit.Category{audit.CategoryAPIKey}})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "api_key.create", events[0].Action)
+	assert.Equal(t, "ops-admin", events[0].Actor)
+	assert.Equal(t, "1/1", events[0].ResourceID)
+	after, _ := json.Marshal(events[0].After)
+	assert.NotContains(t, string(after), "sk-rotate")
+	assert.Equal(t, "api_key.delete", events[1].Action)
+}
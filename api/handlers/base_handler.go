@@ -55,6 +55,11 @@ func (h *BaseHandler[S]) currentService() S {
 	return h.service
 }
 
+// Service returns the current service, reflecting hot reload swaps.
+func (h *BaseHandler[S]) Service() S {
+	return h.currentService()
+}
+
 func serviceIsNil(value any) bool {
 	if value == nil {
 		return true
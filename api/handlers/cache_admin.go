@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"go.uber.org/zap"
+)
+
+type CacheAdminHandler struct {
+	BaseHandler[usecase.CacheAdminService]
+}
+
+func NewCacheAdminHandler(service usecase.CacheAdminService, logger *zap.Logger) *CacheAdminHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CacheAdminHandler{
+		BaseHandler: NewBaseHandler(service, logger),
+	}
+}
+
+// HandlePurgeTenant 清除指定租户的全部响应缓存.
+func (h *CacheAdminHandler) HandlePurgeTenant(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("response cache")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	result, err := service.PurgeTenant(r.Context(), r.PathValue("tenant"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("tenant response cache purged",
+		zap.String("tenant_id", result.TenantID),
+		zap.Int("purged_keys", result.PurgedKeys))
+	WriteSuccess(w, result)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -28,6 +29,8 @@ const maxTokensUpperBound = 128000
 type ChatHandler struct {
 	BaseHandler[usecase.ChatService]
 	converter ChatConverter
+	streaming StreamingOptions
+	batch     usecase.ChatBatchService
 }
 
 // NewChatHandler 创建聊天处理器
@@ -95,7 +98,8 @@ func (h *ChatHandler) HandleCompletion(w http.ResponseWriter, r *http.Request) {
 
 // HandleStream 处理流式聊天请求
 // @Summary 流式聊天完成
-// @Description 发送流式聊天完成请求
+// @Description 发送流式聊天完成请求。每个事件带有 id，空闲时发送注释心跳；
+// @Description 启用流恢复时响应头 X-Stream-Resume-Token 返回恢复令牌，断线后携带 Last-Event-ID 重连即可续传
 // @Tags 聊天
 // @Accept json
 // @Produce text/event-stream
@@ -105,7 +109,18 @@ func (h *ChatHandler) HandleCompletion(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} Response "内部错误"
 // @Security ApiKeyAuth
 // @Router /api/v1/chat/completions/stream [post]
+// @Router /api/v1/chat/completions/stream [get]
 func (h *ChatHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	// 携带 Last-Event-ID 的重连请求直接从缓冲续传，不再调用上游
+	if token, seq, ok := h.resumeRequest(r); ok {
+		h.resumeStream(w, r, token, seq)
+		return
+	}
+	if r.Method != http.MethodPost {
+		WriteError(w, types.NewInvalidRequestError("resuming a stream requires a Last-Event-ID"), h.logger)
+		return
+	}
+
 	// 验证 Content-Type
 	if !ValidateContentType(w, r, h.logger) {
 		return
@@ -127,10 +142,7 @@ func (h *ChatHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 设置 SSE 响应头
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+	setSSEHeaders(w)
 
 	service, svcErr := h.currentServiceOrUnavailable("chat")
 	if svcErr != nil {
@@ -138,70 +150,201 @@ func (h *ChatHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stream, err := service.Stream(r.Context(), h.converter.ToUsecaseRequest(&req))
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := types.NewInternalError("streaming not supported")
+		WriteError(w, err, h.logger)
+		return
+	}
+
+	// 可恢复的流与请求连接解耦：客户端断开后上游继续生成并写入缓冲，直到无人重连超过 TTL
+	var stream *sseStream
+	streamCtx, cancel := context.WithCancel(r.Context())
+	if h.streaming.Resume != nil {
+		if stream = h.streaming.Resume.open(streamOwner(r)); stream != nil {
+			cancel()
+			streamCtx, cancel = context.WithCancel(context.WithoutCancel(r.Context()))
+			w.Header().Set(streamResumeTokenHeader, stream.token)
+		}
+	}
+	if stream == nil {
+		stream = newSSEStream("", "", defaultStreamResumeEvents)
+	}
+
+	events, err := service.Stream(streamCtx, h.converter.ToUsecaseRequest(&req))
 	if err != nil {
+		cancel()
+		if stream.token != "" {
+			h.streaming.Resume.remove(stream.token)
+		}
 		WriteError(w, err, h.logger)
 		return
 	}
 
-	// 发送流式数据
+	requestID := w.Header().Get("X-Request-ID")
+	go h.produceSSEEvents(streamCtx, cancel, stream, events, requestID)
+	h.deliverSSEEvents(w, r, flusher, stream, 0, requestID)
+}
+
+// ConfigureStreaming 配置 HandleStream 的心跳与断线恢复
+func (h *ChatHandler) ConfigureStreaming(opts StreamingOptions) {
+	h.streaming = opts
+}
+
+// resumeRequest 解析重连请求携带的事件 ID（Last-Event-ID 头或 last_event_id 查询参数）。
+func (h *ChatHandler) resumeRequest(r *http.Request) (string, uint64, bool) {
+	if h.streaming.Resume == nil {
+		return "", 0, false
+	}
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	return parseSSEEventID(raw)
+}
+
+// resumeStream 从缓冲中续传序号 after 之后的事件
+func (h *ChatHandler) resumeStream(w http.ResponseWriter, r *http.Request, token string, after uint64) {
+	stream, ok := h.streaming.Resume.lookup(token, streamOwner(r))
+	if !ok {
+		WriteError(w, types.NewNotFoundError("stream is unknown or has expired").WithHTTPStatus(http.StatusGone), h.logger)
+		return
+	}
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		err := types.NewInternalError("streaming not supported")
-		WriteError(w, err, h.logger)
+		WriteError(w, types.NewInternalError("streaming not supported"), h.logger)
 		return
 	}
-
+	setSSEHeaders(w)
+	w.Header().Set(streamResumeTokenHeader, token)
 	requestID := w.Header().Get("X-Request-ID")
+	h.logger.Info("resuming chat stream",
+		zap.String("request_id", requestID),
+		zap.Uint64("last_event_seq", after))
+	h.deliverSSEEvents(w, r, flusher, stream, after, requestID)
+}
 
-	for chunk := range stream {
-		if chunk.Err != nil {
-			h.logger.Error("stream error",
-				zap.String("request_id", requestID),
-				zap.Error(chunk.Err),
-			)
-			if err := writeSSETypesErrorEvent(w, chunk.Err, requestID); err != nil {
-				h.logger.Error("failed to write SSE error event", zap.Error(err))
-			}
-			flusher.Flush()
+// produceSSEEvents 消费上游事件并写入流缓冲；错误与结束标记同样作为事件缓冲，以便重连后补发。
+func (h *ChatHandler) produceSSEEvents(ctx context.Context, cancel context.CancelFunc, stream *sseStream, events <-chan usecase.ChatStreamEvent, requestID string) {
+	defer cancel()
+	defer stream.finish()
+
+	var abandonCheck <-chan time.Time
+	if stream.token != "" {
+		ticker := time.NewTicker(h.streaming.Resume.config.TTL / 4)
+		defer ticker.Stop()
+		abandonCheck = ticker.C
+	}
+
+	appendError := func(err *types.Error) {
+		data, marshalErr := json.Marshal(sseErrorEnvelope{Error: errorInfoFromTypesError(err), RequestID: requestID})
+		if marshalErr != nil {
+			h.logger.Error("failed to encode SSE error event", zap.Error(marshalErr))
 			return
 		}
+		stream.append(ctx, "error", data)
+	}
 
-		if chunk.Chunk == nil {
-			h.logger.Error("invalid stream chunk payload",
-				zap.String("request_id", requestID),
-			)
-			WriteError(w, types.NewInternalError("invalid stream chunk payload"), h.logger)
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case <-abandonCheck:
+			if stream.abandoned(time.Now(), h.streaming.Resume.config.TTL) {
+				h.logger.Info("abandoning chat stream without reconnecting client", zap.String("request_id", requestID))
+				return
+			}
+		case chunk, ok := <-events:
+			if !ok {
+				// 发送结束标记
+				stream.append(ctx, "", []byte("[DONE]"))
+				return
+			}
+			if chunk.Err != nil {
+				h.logger.Error("stream error",
+					zap.String("request_id", requestID),
+					zap.Error(chunk.Err),
+				)
+				appendError(chunk.Err)
+				return
+			}
+			if chunk.Chunk == nil {
+				h.logger.Error("invalid stream chunk payload",
+					zap.String("request_id", requestID),
+				)
+				appendError(types.NewInternalError("invalid stream chunk payload"))
+				return
+			}
+			data, err := json.Marshal(h.convertToAPIStreamChunk(chunk.Chunk))
+			if err != nil {
+				h.logger.Error("failed to encode chunk",
+					zap.String("request_id", requestID),
+					zap.Error(err),
+				)
+				appendError(types.NewInternalError("failed to encode stream chunk"))
+				return
+			}
+			stream.append(ctx, "", data)
 		}
+	}
+}
 
-		// 转换为 API 格式
-		apiChunk := h.convertToAPIStreamChunk(chunk.Chunk)
+// deliverSSEEvents 把序号 after 之后的事件写给当前连接，空闲时发送注释心跳，直到流结束或连接断开。
+func (h *ChatHandler) deliverSSEEvents(w http.ResponseWriter, r *http.Request, flusher http.Flusher, stream *sseStream, after uint64, requestID string) {
+	stream.attach()
+	defer stream.detach()
 
-		// 发送 SSE 事件
-		if err := writeSSE(w, []byte("data: ")); err != nil {
-			h.logger.Error("failed to write SSE data prefix", zap.Error(err))
-			return
-		}
-		if err := writeJSON(w, apiChunk); err != nil {
-			h.logger.Error("failed to write chunk",
+	interval := h.streaming.HeartbeatInterval
+	if interval == 0 {
+		interval = defaultSSEHeartbeatInterval
+	}
+	var heartbeat <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		events, done, changed, ok := stream.since(after)
+		if !ok {
+			h.logger.Warn("stream events are no longer available for resumption",
 				zap.String("request_id", requestID),
-				zap.Error(err),
-			)
+				zap.Uint64("last_event_seq", after))
+			if err := writeSSETypesErrorEvent(w, types.NewNotFoundError("stream events after the given Last-Event-ID are no longer available").WithHTTPStatus(http.StatusGone), requestID); err != nil {
+				h.logger.Error("failed to write SSE error event", zap.Error(err))
+			}
+			flusher.Flush()
 			return
 		}
-		if err := writeSSE(w, []byte("\n\n")); err != nil {
-			h.logger.Error("failed to write SSE data suffix", zap.Error(err))
+		for _, ev := range events {
+			if err := writeSSEEvent(w, stream.eventID(ev.seq), ev.name, ev.data); err != nil {
+				h.logger.Error("failed to write SSE event",
+					zap.String("request_id", requestID),
+					zap.Error(err),
+				)
+				return
+			}
+			after = ev.seq
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		if done {
 			return
 		}
-		flusher.Flush()
-	}
 
-	// 发送结束标记
-	if err := writeSSE(w, []byte("data: [DONE]\n\n")); err != nil {
-		h.logger.Error("failed to write SSE done marker", zap.Error(err))
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-heartbeat:
+			if err := writeSSE(w, []byte(": heartbeat\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
 	}
-	flusher.Flush()
 }
 
 // =============================================================================
@@ -118,6 +118,8 @@ func (h *ChatHandler) HandleAnthropicCompatMessages(w http.ResponseWriter, r *ht
 		writeAnthropicCompatError(w, err)
 		return
 	}
+	// 从认证上下文强制覆盖身份字段，租户作用域缓存与配额依赖此字段隔离
+	enforceTenantID(r, apiReq)
 	if err := h.validateChatRequest(apiReq); err != nil {
 		writeAnthropicCompatError(w, err)
 		return
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BaSui01/agentflow/api"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ChatBatchRequest 批量聊天请求
+type ChatBatchRequest struct {
+	Requests []api.ChatRequest `json:"requests"`
+}
+
+// ChatBatchItemResponse 批量任务中单个请求的结果
+type ChatBatchItemResponse struct {
+	Index      int               `json:"index"`
+	Status     string            `json:"status"`
+	Response   *api.ChatResponse `json:"response,omitempty"`
+	Error      *types.Error      `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// ChatBatchJobResponse 批量任务状态
+type ChatBatchJobResponse struct {
+	ID          string                  `json:"id"`
+	Type        string                  `json:"type"`
+	Status      string                  `json:"status"`
+	Total       int                     `json:"total"`
+	Completed   int                     `json:"completed"`
+	Failed      int                     `json:"failed"`
+	Progress    float64                 `json:"progress"`
+	Error       string                  `json:"error,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	StartedAt   *time.Time              `json:"started_at,omitempty"`
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+	Results     []ChatBatchItemResponse `json:"results,omitempty"`
+}
+
+// ConfigureBatch 配置 /v1/chat/batch 与 /v1/jobs/{id} 使用的批量任务服务
+func (h *ChatHandler) ConfigureBatch(batch usecase.ChatBatchService) {
+	h.batch = batch
+}
+
+// HandleBatchSubmit 提交批量聊天任务
+// @Summary 提交批量聊天任务
+// @Description 提交一组聊天请求，异步经网关并发执行，返回任务 ID；通过 GET /v1/jobs/{id} 轮询结果
+// @Tags 聊天
+// @Accept json
+// @Produce json
+// @Param request body ChatBatchRequest true "批量聊天请求"
+// @Success 202 {object} ChatBatchJobResponse "已接受的任务"
+// @Failure 400 {object} Response "无效请求"
+// @Failure 503 {object} Response "批量任务不可用"
+// @Security ApiKeyAuth
+// @Router /v1/chat/batch [post]
+func (h *ChatHandler) HandleBatchSubmit(w http.ResponseWriter, r *http.Request) {
+	if h.batch == nil {
+		WriteError(w, types.NewServiceUnavailableError("chat batch is not configured"), h.logger)
+		return
+	}
+	var req ChatBatchRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	if len(req.Requests) == 0 {
+		WriteError(w, types.NewInvalidRequestError("requests cannot be empty"), h.logger)
+		return
+	}
+
+	reqs := make([]*usecase.ChatRequest, 0, len(req.Requests))
+	for i := range req.Requests {
+		chatReq := &req.Requests[i]
+		// 从 JWT 上下文强制覆盖身份字段，防止水平越权
+		enforceTenantID(r, chatReq)
+		if err := h.validateChatRequest(chatReq); err != nil {
+			WriteError(w, types.NewInvalidRequestError(fmt.Sprintf("requests[%d]: %s", i, err.Message)), h.logger)
+			return
+		}
+		reqs = append(reqs, h.converter.ToUsecaseRequest(chatReq))
+	}
+
+	job, err := h.batch.Submit(r.Context(), reqs)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("chat batch accepted",
+		zap.String("request_id", w.Header().Get("X-Request-ID")),
+		zap.String("job_id", job.ID),
+		zap.Int("requests", len(reqs)),
+	)
+	WriteJSON(w, http.StatusAccepted, api.Response{
+		Success:   true,
+		Data:      h.toChatBatchJobResponse(job),
+		Timestamp: time.Now(),
+		RequestID: w.Header().Get("X-Request-ID"),
+	})
+}
+
+// HandleGetJob 查询批量任务状态与已完成结果
+// @Summary 查询任务
+// @Description 返回任务状态、进度以及已完成请求的结果
+// @Tags 聊天
+// @Produce json
+// @Param id path string true "任务 ID"
+// @Success 200 {object} ChatBatchJobResponse "任务状态"
+// @Failure 404 {object} Response "任务不存在"
+// @Security ApiKeyAuth
+// @Router /v1/jobs/{id} [get]
+func (h *ChatHandler) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	if h.batch == nil {
+		WriteError(w, types.NewServiceUnavailableError("chat batch is not configured"), h.logger)
+		return
+	}
+	job, err := h.batch.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, h.toChatBatchJobResponse(job))
+}
+
+func (h *ChatHandler) toChatBatchJobResponse(job *usecase.ChatBatchJob) *ChatBatchJobResponse {
+	resp := &ChatBatchJobResponse{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      string(job.Status),
+		Total:       job.Total,
+		Completed:   job.Completed,
+		Failed:      job.Failed,
+		Progress:    job.Progress,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	for _, item := range job.Results {
+		out := ChatBatchItemResponse{
+			Index:      item.Index,
+			Status:     item.Status,
+			Error:      item.Error,
+			DurationMs: item.DurationMs,
+		}
+		if item.Response != nil {
+			out.Response = h.converter.ToAPIResponseFromUsecase(item.Response)
+		}
+		resp.Results = append(resp.Results, out)
+	}
+	return resp
+}
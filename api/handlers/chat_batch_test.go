@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/api/handlers"
+	"github.com/BaSui01/agentflow/api/routes"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type chatBatchEnvelope struct {
+	Success bool                          `json:"success"`
+	Data    handlers.ChatBatchJobResponse `json:"data"`
+	Error   *types.Error                  `json:"error"`
+}
+
+func newChatBatchMux(t *testing.T, svc *mockChatService) *http.ServeMux {
+	t.Helper()
+	chatHandler, err := handlers.NewChatHandler(svc, zap.NewNop())
+	require.NoError(t, err)
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	batch := usecase.NewDefaultChatBatchService(store, chatHandler.Service, usecase.ChatBatchOptions{MaxRequests: 3}, nil)
+	t.Cleanup(func() {
+		batch.Close()
+		_ = store.Close()
+	})
+	chatHandler.ConfigureBatch(batch)
+
+	mux := http.NewServeMux()
+	routes.RegisterChat(mux, chatHandler, zap.NewNop())
+	return mux
+}
+
+func doChatBatch(t *testing.T, mux *http.ServeMux, method, path string, body any) (int, chatBatchEnvelope) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var env chatBatchEnvelope
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &env), rec.Body.String())
+	return rec.Code, env
+}
+
+func TestChatBatch_SubmitAndPollJob(t *testing.T) {
+	svc := &mockChatService{}
+	mux := newChatBatchMux(t, svc)
+
+	code, env := doChatBatch(t, mux, http.MethodPost, "/v1/chat/batch", map[string]any{
+		"requests": []map[string]any{
+			{"model": "gpt-4o", "messages": []map[string]any{{"role": "user", "content": "one"}}},
+			{"model": "gpt-4o-mini", "messages": []map[string]any{{"role": "user", "content": "two"}}},
+		},
+	})
+	require.Equal(t, http.StatusAccepted, code)
+	require.True(t, env.Success)
+	assert.Equal(t, 2, env.Data.Total)
+	jobID := env.Data.ID
+	require.NotEmpty(t, jobID)
+
+	var job handlers.ChatBatchJobResponse
+	require.Eventually(t, func() bool {
+		code, env := doChatBatch(t, mux, http.MethodGet, "/v1/jobs/"+jobID, nil)
+		require.Equal(t, http.StatusOK, code)
+		job = env.Data
+		return job.Status == string(persistence.TaskStatusCompleted)
+	}, 5*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, 2, job.Completed)
+	require.Len(t, job.Results, 2)
+	assert.Equal(t, "gpt-4o-mini", job.Results[1].Response.Model)
+	assert.Equal(t, "Hello from mock", job.Results[0].Response.Choices[0].Message.Content)
+}
+
+func TestChatBatch_RejectsInvalidBatches(t *testing.T) {
+	mux := newChatBatchMux(t, &mockChatService{
+		completeFunc: func(ctx context.Context, req *usecase.ChatRequest) (*usecase.ChatCompletionResult, *types.Error) {
+			t.Fatal("invalid batches must not reach the gateway")
+			return nil, nil
+		},
+	})
+	message := []map[string]any{{"role": "user", "content": "hi"}}
+
+	code, env := doChatBatch(t, mux, http.MethodPost, "/v1/chat/batch", map[string]any{"requests": []any{}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, types.ErrInvalidRequest, env.Error.Code)
+
+	code, env = doChatBatch(t, mux, http.MethodPost, "/v1/chat/batch", map[string]any{
+		"requests": []map[string]any{{"model": "gpt-4o", "messages": message}, {"messages": message}},
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, env.Error.Message, "requests[1]")
+
+	four := []map[string]any{}
+	for range 4 {
+		four = append(four, map[string]any{"model": "gpt-4o", "messages": message})
+	}
+	code, env = doChatBatch(t, mux, http.MethodPost, "/v1/chat/batch", map[string]any{"requests": four})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, env.Error.Message, "cannot exceed 3")
+
+	code, env = doChatBatch(t, mux, http.MethodGet, "/v1/jobs/unknown", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, types.ErrTaskNotFound, env.Error.Code)
+}
+
+func TestChatBatch_UnavailableWithoutBatchService(t *testing.T) {
+	chatHandler, err := handlers.NewChatHandler(&mockChatService{}, zap.NewNop())
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	routes.RegisterChat(mux, chatHandler, zap.NewNop())
+
+	code, _ := doChatBatch(t, mux, http.MethodGet, "/v1/jobs/any", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -142,6 +142,7 @@ func (c *DefaultChatConverter) ToAPIChoices(choices []types.ChatChoice) []api.Ch
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
 			Message:      convertTypesMessageToAPI(choice.Message),
+			LogProbs:     choice.LogProbs,
 		}
 	}
 	return result
@@ -181,6 +182,7 @@ func (c *DefaultChatConverter) ToAPIStreamChunk(chunk *types.StreamChunk) *api.S
 		Index:        chunk.Index,
 		Delta:        convertTypesMessageToAPI(chunk.Delta),
 		FinishReason: chunk.FinishReason,
+		LogProbs:     chunk.LogProbs,
 		Usage:        convertStreamUsage(chunk.Usage),
 	}
 }
@@ -196,6 +198,7 @@ func (c *DefaultChatConverter) ToAPIStreamChunkFromUsecase(chunk *usecase.ChatSt
 		Index:        chunk.Index,
 		Delta:        convertUsecaseMessageToAPI(chunk.Delta),
 		FinishReason: chunk.FinishReason,
+		LogProbs:     chunk.LogProbs,
 	}
 	if chunk.Usage != nil {
 		usage := convertUsecaseUsageToAPI(*chunk.Usage)
@@ -338,6 +341,7 @@ func (c *DefaultChatConverter) ToAPIResponseFromUsecase(resp *usecase.ChatRespon
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
 			Message:      convertUsecaseMessageToAPI(choice.Message),
+			LogProbs:     choice.LogProbs,
 		}
 	}
 
@@ -231,6 +231,7 @@ func convertAPIResponseToUsecase(resp *api.ChatResponse) *usecase.ChatResponse {
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
 			Message:      convertAPIMessageToUsecase(choice.Message),
+			LogProbs:     choice.LogProbs,
 		}
 	}
 	out := &usecase.ChatResponse{
@@ -178,6 +178,7 @@ type openAICompatChatChoice struct {
 	Index        int                     `json:"index"`
 	Message      openAICompatOutboundMsg `json:"message"`
 	FinishReason string                  `json:"finish_reason,omitempty"`
+	LogProbs     *types.ChoiceLogProbs   `json:"logprobs,omitempty"`
 }
 
 type openAICompatChatChunkResponse struct {
@@ -192,6 +193,7 @@ type openAICompatChatChunkChoice struct {
 	Index        int                     `json:"index"`
 	Delta        openAICompatOutboundMsg `json:"delta"`
 	FinishReason any                     `json:"finish_reason,omitempty"`
+	LogProbs     *types.ChoiceLogProbs   `json:"logprobs,omitempty"`
 }
 
 type openAICompatOutboundMsg struct {
@@ -313,6 +315,8 @@ func (h *ChatHandler) HandleOpenAICompatChatCompletions(w http.ResponseWriter, r
 		writeOpenAICompatError(w, err)
 		return
 	}
+	// 从认证上下文强制覆盖身份字段，租户作用域缓存与配额依赖此字段隔离
+	enforceTenantID(r, apiReq)
 	if err := h.validateChatRequest(apiReq); err != nil {
 		writeOpenAICompatError(w, err)
 		return
@@ -357,6 +361,8 @@ func (h *ChatHandler) HandleOpenAICompatResponses(w http.ResponseWriter, r *http
 		writeOpenAICompatError(w, err)
 		return
 	}
+	// 从认证上下文强制覆盖身份字段，租户作用域缓存与配额依赖此字段隔离
+	enforceTenantID(r, apiReq)
 	if err := h.validateChatRequest(apiReq); err != nil {
 		writeOpenAICompatError(w, err)
 		return
@@ -430,13 +436,14 @@ func openAICompatErrorType(err *types.Error) string {
 	case types.ErrInvalidRequest,
 		types.ErrContextTooLong,
 		types.ErrContentFiltered,
-		types.ErrToolValidation:
+		types.ErrToolValidation,
+		types.ErrCapabilityUnsupported:
 		return "invalid_request_error"
 	// 4xx 凭证问题
 	case types.ErrUnauthorized, types.ErrAuthentication:
 		return "authentication_error"
 	// 4xx 授权/策略拒绝
-	case types.ErrForbidden, types.ErrGuardrailsViolated:
+	case types.ErrForbidden, types.ErrGuardrailsViolated, types.ErrDataResidency:
 		return "permission_error"
 	// 4xx 资源不存在
 	case types.ErrModelNotFound:
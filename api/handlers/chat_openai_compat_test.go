@@ -70,6 +70,11 @@ func TestChatHandler_OpenAICompatChatCompletions(t *testing.T) {
 							Role:    "assistant",
 							Content: "ok",
 						},
+						LogProbs: &types.ChoiceLogProbs{Content: []types.TokenLogProb{{
+							Token:       "ok",
+							LogProb:     -0.01,
+							TopLogProbs: []types.TopLogProb{{Token: "ok", LogProb: -0.01}, {Token: "no", LogProb: -4.6}},
+						}}},
 					},
 				},
 				Usage: usecase.ChatUsage{
@@ -146,6 +151,10 @@ func TestChatHandler_OpenAICompatChatCompletions(t *testing.T) {
 	assert.Equal(t, "gpt-5.2", resp.Model)
 	require.Len(t, resp.Choices, 1)
 	assert.Equal(t, "ok", resp.Choices[0].Message.Content)
+	require.NotNil(t, resp.Choices[0].LogProbs)
+	require.Len(t, resp.Choices[0].LogProbs.Content, 1)
+	assert.Equal(t, -0.01, resp.Choices[0].LogProbs.Content[0].LogProb)
+	assert.Len(t, resp.Choices[0].LogProbs.Content[0].TopLogProbs, 2)
 }
 
 func TestChatHandler_OpenAICompatChatCompletions_Stream(t *testing.T) {
@@ -161,6 +170,7 @@ func TestChatHandler_OpenAICompatChatCompletions_Stream(t *testing.T) {
 						Content: "ok",
 					},
 					FinishReason: "stop",
+					LogProbs:     &types.ChoiceLogProbs{Content: []types.TokenLogProb{{Token: "ok", LogProb: -0.25}}},
 				},
 			},
 		},
@@ -184,6 +194,7 @@ func TestChatHandler_OpenAICompatChatCompletions_Stream(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
 	assert.Contains(t, w.Body.String(), "chat.completion.chunk")
+	assert.Contains(t, w.Body.String(), `"logprobs":{"content":[{"token":"ok","logprob":-0.25}]}`)
 	assert.Contains(t, w.Body.String(), "data: [DONE]")
 }
 
@@ -516,3 +527,39 @@ func TestChatHandler_OpenAICompatResponses_InputReasoningRoundTrip(t *testing.T)
 	assert.Equal(t, "enc_prev", svc.completeReq.Messages[0].OpaqueReasoning[0].State)
 	assert.Equal(t, "next question", svc.completeReq.Messages[1].Content)
 }
+
+func TestChatHandler_CompatRoutesScopeRequestsToAuthenticatedTenant(t *testing.T) {
+	routes := []struct {
+		name   string
+		body   string
+		handle func(h *ChatHandler) http.HandlerFunc
+	}{
+		{"chat completions", `{"model":"gpt-5.2","messages":[{"role":"user","content":"hello"}]}`, func(h *ChatHandler) http.HandlerFunc { return h.HandleOpenAICompatChatCompletions }},
+		{"responses", `{"model":"gpt-5.2","input":"hello"}`, func(h *ChatHandler) http.HandlerFunc { return h.HandleOpenAICompatResponses }},
+		{"messages", `{"model":"claude-sonnet-4.6","max_tokens":64,"messages":[{"role":"user","content":"hello"}]}`, func(h *ChatHandler) http.HandlerFunc { return h.HandleAnthropicCompatMessages }},
+	}
+	for _, route := range routes {
+		t.Run(route.name, func(t *testing.T) {
+			svc := &openAICompatServiceStub{completeResult: &usecase.ChatCompletionResult{Response: &usecase.ChatResponse{
+				Model:   "gpt-5.2",
+				Choices: []usecase.ChatChoice{{Message: usecase.Message{Role: "assistant", Content: "ok"}}},
+			}}}
+			handler, err := NewChatHandler(svc, zap.NewNop())
+			require.NoError(t, err)
+
+			var tenants []string
+			for _, identity := range [][2]string{{"tenant-a", "user-a"}, {"tenant-b", "user-b"}} {
+				ctx := types.WithUserID(types.WithTenantID(context.Background(), identity[0]), identity[1])
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(route.body)).WithContext(ctx)
+				r.Header.Set("Content-Type", "application/json")
+				route.handle(handler)(w, r)
+				require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+				require.NotNil(t, svc.completeReq)
+				assert.Equal(t, identity[1], svc.completeReq.UserID)
+				tenants = append(tenants, svc.completeReq.TenantID)
+			}
+			assert.Equal(t, []string{"tenant-a", "tenant-b"}, tenants, "cache and quota scopes follow the authenticated tenant")
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+type openAICompatModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type openAICompatModelList struct {
+	Object string              `json:"object"`
+	Data   []openAICompatModel `json:"data"`
+}
+
+type openAICompatEmbeddingsRequest struct {
+	Model          string `json:"model"`
+	Input          any    `json:"input"`
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	Dimensions     int    `json:"dimensions,omitempty"`
+	User           string `json:"user,omitempty"`
+	Provider       string `json:"provider,omitempty"`
+}
+
+type openAICompatEmbedding struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"`
+}
+
+type openAICompatEmbeddingsUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type openAICompatEmbeddingsResponse struct {
+	Object string                      `json:"object"`
+	Data   []openAICompatEmbedding     `json:"data"`
+	Model  string                      `json:"model"`
+	Usage  openAICompatEmbeddingsUsage `json:"usage"`
+}
+
+// HandleOpenAICompatModels 以 OpenAI /v1/models 格式列出可用模型.
+func (h *ChatHandler) HandleOpenAICompatModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeOpenAICompatError(w, types.NewError(types.ErrInvalidRequest, "method not allowed").WithHTTPStatus(http.StatusMethodNotAllowed))
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("chat")
+	if svcErr != nil {
+		writeOpenAICompatError(w, svcErr)
+		return
+	}
+	lister, ok := service.(usecase.ChatModelLister)
+	if !ok {
+		writeOpenAICompatError(w, types.NewServiceUnavailableError("model listing is not supported by the chat service"))
+		return
+	}
+	models, err := lister.ListModels(r.Context())
+	if err != nil {
+		writeOpenAICompatError(w, err)
+		return
+	}
+
+	out := openAICompatModelList{Object: "list", Data: make([]openAICompatModel, 0, len(models))}
+	for _, model := range models {
+		out.Data = append(out.Data, openAICompatModel{
+			ID:      model.ID,
+			Object:  "model",
+			Created: model.Created,
+			OwnedBy: model.OwnedBy,
+		})
+	}
+	if err := writeOpenAICompatJSON(w, http.StatusOK, out); err != nil {
+		h.logger.Debug("OpenAI compatible models write failed", zap.Error(err))
+	}
+}
+
+// HandleOpenAICompatEmbeddings 以 OpenAI /v1/embeddings 格式生成向量，支持 float 与 base64 编码.
+func (h *ChatHandler) HandleOpenAICompatEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOpenAICompatError(w, types.NewError(types.ErrInvalidRequest, "method not allowed").WithHTTPStatus(http.StatusMethodNotAllowed))
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("chat")
+	if svcErr != nil {
+		writeOpenAICompatError(w, svcErr)
+		return
+	}
+	embedder, ok := service.(usecase.ChatEmbedder)
+	if !ok {
+		writeOpenAICompatError(w, types.NewServiceUnavailableError("embeddings are not supported by the chat service"))
+		return
+	}
+
+	var req openAICompatEmbeddingsRequest
+	if err := decodeOpenAICompatJSON(w, r, &req); err != nil {
+		writeOpenAICompatError(w, err)
+		return
+	}
+	inputs, err := openAICompatEmbeddingInputs(req.Input)
+	if err != nil {
+		writeOpenAICompatError(w, err)
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(req.EncodingFormat))
+	if format != "" && format != "float" && format != "base64" {
+		writeOpenAICompatError(w, types.NewInvalidRequestError("encoding_format must be float or base64"))
+		return
+	}
+
+	result, svcErr := embedder.Embed(r.Context(), &usecase.ChatEmbeddingRequest{
+		Model:      strings.TrimSpace(req.Model),
+		Input:      inputs,
+		Dimensions: req.Dimensions,
+		Provider:   req.Provider,
+	})
+	if svcErr != nil {
+		writeOpenAICompatError(w, svcErr)
+		return
+	}
+
+	out := openAICompatEmbeddingsResponse{
+		Object: "list",
+		Data:   make([]openAICompatEmbedding, 0, len(result.Embeddings)),
+		Model:  result.Model,
+		Usage: openAICompatEmbeddingsUsage{
+			PromptTokens: result.PromptTokens,
+			TotalTokens:  result.TotalTokens,
+		},
+	}
+	for i, vector := range result.Embeddings {
+		var encoded any = vector
+		if format == "base64" {
+			encoded = encodeOpenAICompatEmbeddingBase64(vector)
+		}
+		out.Data = append(out.Data, openAICompatEmbedding{Object: "embedding", Index: i, Embedding: encoded})
+	}
+	if err := writeOpenAICompatJSON(w, http.StatusOK, out); err != nil {
+		h.logger.Debug("OpenAI compatible embeddings write failed", zap.Error(err))
+	}
+}
+
+// openAICompatEmbeddingInputs 接受字符串或字符串数组；token 数组输入不支持.
+func openAICompatEmbeddingInputs(raw any) ([]string, *types.Error) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		inputs := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, types.NewInvalidRequestError("input must be a string or an array of strings")
+			}
+			inputs = append(inputs, text)
+		}
+		if len(inputs) == 0 {
+			return nil, types.NewInvalidRequestError("input is required")
+		}
+		return inputs, nil
+	case nil:
+		return nil, types.NewInvalidRequestError("input is required")
+	default:
+		return nil, types.NewInvalidRequestError("input must be a string or an array of strings")
+	}
+}
+
+// encodeOpenAICompatEmbeddingBase64 按 OpenAI 约定编码为 little-endian float32 的 base64.
+func encodeOpenAICompatEmbeddingBase64(vector []float64) string {
+	buf := make([]byte, 4*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(value)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BaSui01/agentflow/llm/capabilities"
+	"github.com/BaSui01/agentflow/llm/capabilities/embedding"
+	"github.com/BaSui01/agentflow/llm/capabilities/multimodal"
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type modelListingProviderStub struct {
+	chatProviderStub
+	models []llmcore.Model
+}
+
+func (s *modelListingProviderStub) ListModels(_ context.Context) ([]llmcore.Model, error) {
+	return s.models, nil
+}
+
+type embeddingProviderStub struct {
+	lastReq *embedding.EmbeddingRequest
+}
+
+func (s *embeddingProviderStub) Embed(_ context.Context, req *embedding.EmbeddingRequest) (*embedding.EmbeddingResponse, error) {
+	s.lastReq = req
+	resp := &embedding.EmbeddingResponse{Provider: "embed-stub", Model: "text-embedding-3-small"}
+	// 乱序返回，验证按 index 归位
+	for i := len(req.Input) - 1; i >= 0; i-- {
+		resp.Embeddings = append(resp.Embeddings, embedding.EmbeddingData{Index: i, Embedding: []float64{float64(i), 0.5}})
+	}
+	resp.Usage.PromptTokens = 7
+	resp.Usage.TotalTokens = 7
+	return resp, nil
+}
+
+func (s *embeddingProviderStub) EmbedQuery(context.Context, string) ([]float64, error) {
+	return []float64{0, 0.5}, nil
+}
+
+func (s *embeddingProviderStub) EmbedDocuments(context.Context, []string) ([][]float64, error) {
+	return nil, nil
+}
+
+func (s *embeddingProviderStub) Name() string      { return "embed-stub" }
+func (s *embeddingProviderStub) Dimensions() int   { return 2 }
+func (s *embeddingProviderStub) MaxBatchSize() int { return 16 }
+
+func newOpenAICompatModelsHandler(t *testing.T, embedder *embeddingProviderStub) *ChatHandler {
+	t.Helper()
+	provider := &modelListingProviderStub{models: []llmcore.Model{
+		{ID: "gpt-b", OwnedBy: "openai", Created: 2},
+		{ID: "gpt-a"},
+		{ID: "gpt-b"},
+	}}
+	router := multimodal.NewRouter()
+	router.RegisterEmbedding(embedder.Name(), embedder, true)
+	gateway := llmgateway.New(llmgateway.Config{
+		ChatProvider: provider,
+		Capabilities: capabilities.NewEntry(router),
+		Logger:       zap.NewNop(),
+	})
+	handler, err := NewChatHandler(newChatServiceUnderTest(gateway, provider, nil), zap.NewNop())
+	require.NoError(t, err)
+	return handler
+}
+
+func TestChatHandler_OpenAICompatModels(t *testing.T) {
+	handler := newOpenAICompatModelsHandler(t, &embeddingProviderStub{})
+
+	w := httptest.NewRecorder()
+	handler.HandleOpenAICompatModels(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var out openAICompatModelList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	assert.Equal(t, "list", out.Object)
+	require.Len(t, out.Data, 2)
+	assert.Equal(t, openAICompatModel{ID: "gpt-a", Object: "model", OwnedBy: "chat-provider-stub"}, out.Data[0])
+	assert.Equal(t, openAICompatModel{ID: "gpt-b", Object: "model", Created: 2, OwnedBy: "openai"}, out.Data[1])
+}
+
+func TestChatHandler_OpenAICompatEmbeddings(t *testing.T) {
+	embedder := &embeddingProviderStub{}
+	handler := newOpenAICompatModelsHandler(t, embedder)
+
+	body := `{"model":"text-embedding-3-small","input":["a","b"],"dimensions":2}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	handler.HandleOpenAICompatEmbeddings(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, embedder.lastReq)
+	assert.Equal(t, []string{"a", "b"}, embedder.lastReq.Input)
+	assert.Equal(t, 2, embedder.lastReq.Dimensions)
+
+	var out struct {
+		Object string `json:"object"`
+		Model  string `json:"model"`
+		Data   []struct {
+			Object    string    `json:"object"`
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Usage openAICompatEmbeddingsUsage `json:"usage"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	assert.Equal(t, "list", out.Object)
+	assert.Equal(t, "text-embedding-3-small", out.Model)
+	require.Len(t, out.Data, 2)
+	assert.Equal(t, []float64{0, 0.5}, out.Data[0].Embedding)
+	assert.Equal(t, []float64{1, 0.5}, out.Data[1].Embedding)
+	assert.Equal(t, 7, out.Usage.PromptTokens)
+}
+
+func TestChatHandler_OpenAICompatEmbeddingsBase64(t *testing.T) {
+	handler := newOpenAICompatModelsHandler(t, &embeddingProviderStub{})
+
+	body := `{"model":"text-embedding-3-small","input":"hello","encoding_format":"base64"}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	handler.HandleOpenAICompatEmbeddings(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var out struct {
+		Data []struct {
+			Embedding string `json:"embedding"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Len(t, out.Data, 1)
+	raw, err := base64.StdEncoding.DecodeString(out.Data[0].Embedding)
+	require.NoError(t, err)
+	require.Len(t, raw, 8)
+	assert.Equal(t, float32(0.5), math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])))
+}
+
+func TestChatHandler_OpenAICompatEmbeddingsRejectsInvalidInput(t *testing.T) {
+	handler := newOpenAICompatModelsHandler(t, &embeddingProviderStub{})
+
+	for _, body := range []string{
+		`{"model":"m","input":[1,2,3]}`,
+		`{"model":"m"}`,
+		`{"model":"m","input":"x","encoding_format":"int8"}`,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		handler.HandleOpenAICompatEmbeddings(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
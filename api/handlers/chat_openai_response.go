@@ -40,6 +40,7 @@ func toOpenAICompatChatResponse(resp *api.ChatResponse) openAICompatChatResponse
 				Annotations:      toOpenAICompatAnnotations(c.Message.Annotations),
 			},
 			FinishReason: c.FinishReason,
+			LogProbs:     c.LogProbs,
 		})
 	}
 	return out
@@ -64,6 +65,7 @@ func toOpenAICompatChatChunkResponse(chunk *usecase.ChatStreamChunk, created int
 					ToolCalls:        toOpenAICompatOutboundToolCalls(chunk.Delta.ToolCalls),
 				},
 				FinishReason: nil,
+				LogProbs:     chunk.LogProbs,
 			},
 		},
 	}
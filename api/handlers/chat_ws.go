@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/streaming"
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/pkg/middleware"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// =============================================================================
+// Chat WebSocket
+// =============================================================================
+
+const (
+	defaultChatWSMaxRuns       = 8
+	maxChatWSRunIDLength       = 128
+	chatWSWriteTimeout         = 10 * time.Second
+	chatWSInterruptHandlerName = "chat_ws"
+)
+
+// Client frame types accepted on /v1/chat/ws.
+const (
+	chatWSFrameRun    = "run"
+	chatWSFrameSteer  = "steer"
+	chatWSFrameCancel = "cancel"
+	chatWSFramePing   = "ping"
+)
+
+// Run completion statuses reported in the "done" frame.
+const (
+	chatWSRunCompleted = "completed"
+	chatWSRunCancelled = "cancelled"
+	chatWSRunFailed    = "failed"
+)
+
+// chatWSInterruptRuns maps pending HITL interrupt IDs to the run that raised
+// them, so resolutions can be routed back to the originating connection.
+var chatWSInterruptRuns sync.Map
+
+// ChatWebSocketOptions configures the bidirectional chat endpoint.
+type ChatWebSocketOptions struct {
+	// AllowedOrigins restricts browser origins; empty means same-origin only.
+	AllowedOrigins []string
+	// MaxRunsPerConnection caps the runs multiplexed on one connection.
+	MaxRunsPerConnection int
+	// Interrupts forwards HITL interrupts raised inside a run to the
+	// connection that started it. Nil disables interrupt notifications.
+	Interrupts *hitl.InterruptManager
+}
+
+// ConfigureChatWebSocket configures HandleChatWebSocket.
+func (h *AgentHandler) ConfigureChatWebSocket(opts ChatWebSocketOptions) {
+	if opts.MaxRunsPerConnection <= 0 {
+		opts.MaxRunsPerConnection = defaultChatWSMaxRuns
+	}
+	h.chatWS = opts
+	if opts.Interrupts == nil {
+		return
+	}
+	// Handlers are bound to the manager rather than this handler, so register
+	// them once per manager and route through the run carried by the context.
+	if opts.Interrupts.RegisterNamedHandler(hitl.InterruptTypeApproval, chatWSInterruptHandlerName, forwardChatWSInterrupt) {
+		opts.Interrupts.RegisterNamedHandler(hitl.InterruptTypeInput, chatWSInterruptHandlerName, forwardChatWSInterrupt)
+		opts.Interrupts.RegisterNamedHandler(hitl.InterruptTypeReview, chatWSInterruptHandlerName, forwardChatWSInterrupt)
+		opts.Interrupts.OnResolution(forwardChatWSResolution)
+	}
+}
+
+// chatWSClientFrame is a message sent by the client.
+type chatWSClientFrame struct {
+	Type    string                       `json:"type"`
+	RunID   string                       `json:"run_id,omitempty"`
+	Request *usecase.AgentExecuteRequest `json:"request,omitempty"`
+	Mode    string                       `json:"mode,omitempty"`
+	Content string                       `json:"content,omitempty"`
+}
+
+// chatWSServerFrame is a message sent to the client. Type carries the same
+// event names as the agent SSE stream plus the run lifecycle frames.
+type chatWSServerFrame struct {
+	Type  string `json:"type"`
+	RunID string `json:"run_id,omitempty"`
+	Data  any    `json:"data,omitempty"`
+	Error any    `json:"error,omitempty"`
+}
+
+// HandleChatWebSocket serves GET /v1/chat/ws.
+// A single connection multiplexes agent runs keyed by run_id. Clients send
+// "run" to start a run, "steer" to inject guidance or stop-and-send content
+// mid-generation, and "cancel" to abort a run. The server streams runtime
+// events, HITL interrupt notifications and a final "done" frame per run.
+// @Summary Bidirectional chat over WebSocket
+// @Description Multiplexed agent runs with mid-generation steering, cancellation and HITL interrupt notifications
+// @Tags agent
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 403 {object} Response "Origin not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/chat/ws [get]
+func (h *AgentHandler) HandleChatWebSocket(w http.ResponseWriter, r *http.Request) {
+	if _, svcErr := h.currentServiceOrError(); svcErr != nil {
+		h.handleAgentError(w, svcErr)
+		return
+	}
+
+	ws, err := streaming.AcceptWebSocket(w, r, h.chatWS.AllowedOrigins)
+	if err != nil {
+		h.logger.Warn("chat websocket upgrade failed", zap.Error(err))
+		return
+	}
+	ws.SetReadLimit(maxRequestBodyBytes)
+
+	requestID := middleware.RequestIDFromContext(r.Context())
+	if requestID == "" {
+		requestID = w.Header().Get("X-Request-ID")
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	conn := &chatWSConn{
+		handler:   h,
+		ws:        ws,
+		ctx:       ctx,
+		requestID: requestID,
+		logger:    h.logger.With(zap.String("request_id", requestID)),
+		runs:      make(map[string]*chatWSRun),
+	}
+	conn.logger.Info("chat websocket connected")
+
+	conn.serve()
+
+	cancel()
+	conn.wg.Wait()
+	_ = ws.Close(websocket.StatusNormalClosure, "")
+	conn.logger.Info("chat websocket closed")
+}
+
+// chatWSConn is one client connection and the runs multiplexed on it.
+type chatWSConn struct {
+	handler   *AgentHandler
+	ws        *websocket.Conn
+	ctx       context.Context
+	requestID string
+	logger    *zap.Logger
+
+	writeMu sync.Mutex
+
+	mu   sync.Mutex
+	runs map[string]*chatWSRun
+	wg   sync.WaitGroup
+}
+
+// chatWSRun is one agent execution started on a connection.
+type chatWSRun struct {
+	id        string
+	conn      *chatWSConn
+	session   *agent.ExecutionSession
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+
+	mu         sync.Mutex
+	interrupts []string
+}
+
+type chatWSRunKey struct{}
+
+// serve reads client frames until the connection closes.
+func (c *chatWSConn) serve() {
+	for {
+		_, data, err := c.ws.Read(c.ctx)
+		if err != nil {
+			status := websocket.CloseStatus(err)
+			if status != websocket.StatusNormalClosure && status != websocket.StatusGoingAway && !errors.Is(err, context.Canceled) {
+				c.logger.Debug("chat websocket read ended", zap.Error(err))
+			}
+			return
+		}
+		var frame chatWSClientFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			c.sendError("", types.NewInvalidRequestError("invalid frame: "+err.Error()))
+			continue
+		}
+		switch frame.Type {
+		case chatWSFrameRun:
+			c.startRun(frame)
+		case chatWSFrameSteer:
+			c.steerRun(frame)
+		case chatWSFrameCancel:
+			c.cancelRun(frame.RunID)
+		case chatWSFramePing:
+			c.send(chatWSServerFrame{Type: "pong", RunID: frame.RunID})
+		default:
+			c.sendError(frame.RunID, types.NewInvalidRequestError(fmt.Sprintf("unsupported frame type %q", frame.Type)))
+		}
+	}
+}
+
+func (c *chatWSConn) startRun(frame chatWSClientFrame) {
+	h := c.handler
+	if frame.Request == nil {
+		c.sendError(frame.RunID, types.NewInvalidRequestError("request is required"))
+		return
+	}
+	req := *frame.Request
+	if apiErr := h.validateAgentExecuteRequest(&req); apiErr != nil {
+		c.sendError(frame.RunID, apiErr)
+		return
+	}
+	if len(req.AgentIDs) > 0 {
+		c.sendError(frame.RunID, types.NewInvalidRequestError("agent_ids is not supported for streaming"))
+		return
+	}
+
+	runID := strings.TrimSpace(frame.RunID)
+	if runID == "" {
+		runID = "run_" + uuid.New().String()[:12]
+	}
+	if len(runID) > maxChatWSRunIDLength {
+		c.sendError(frame.RunID, types.NewInvalidRequestError(fmt.Sprintf("run_id must be at most %d characters", maxChatWSRunIDLength)))
+		return
+	}
+
+	service, svcErr := h.currentServiceOrError()
+	if svcErr != nil {
+		c.sendError(runID, svcErr)
+		return
+	}
+	if _, svcErr := service.ResolveForOperation(c.ctx, req.AgentID, usecase.AgentOperationStream); svcErr != nil {
+		c.sendError(runID, svcErr)
+		return
+	}
+
+	c.mu.Lock()
+	if _, exists := c.runs[runID]; exists {
+		c.mu.Unlock()
+		c.sendError(runID, types.NewError(types.ErrInvalidRequest, "run_id is already active on this connection"))
+		return
+	}
+	if len(c.runs) >= h.chatWS.MaxRunsPerConnection {
+		c.mu.Unlock()
+		c.sendError(runID, types.NewError(types.ErrRateLimit,
+			fmt.Sprintf("at most %d concurrent runs are allowed per connection", h.chatWS.MaxRunsPerConnection)))
+		return
+	}
+	session := h.sessionMgr.Create(req.AgentID)
+	if session == nil {
+		c.mu.Unlock()
+		c.sendError(runID, types.NewServiceUnavailableError("agent sessions are shutting down"))
+		return
+	}
+	runCtx, cancel := context.WithCancel(c.ctx)
+	run := &chatWSRun{id: runID, conn: c, session: session, cancel: cancel}
+	c.runs[runID] = run
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	go c.executeRun(runCtx, run, service, req)
+}
+
+func (c *chatWSConn) executeRun(ctx context.Context, run *chatWSRun, service usecase.AgentService, req usecase.AgentExecuteRequest) {
+	defer c.wg.Done()
+
+	c.send(chatWSServerFrame{Type: "run_started", RunID: run.id, Data: map[string]any{
+		"execution_id": run.session.ID,
+		"agent_id":     req.AgentID,
+	}})
+
+	ctx = agent.WithSteeringChannel(ctx, run.session.SteeringCh)
+	ctx = context.WithValue(ctx, chatWSRunKey{}, run)
+	emitter := func(event agent.RuntimeStreamEvent) {
+		name, payload := runtimeStreamEventPayload(event)
+		if payload == nil || ctx.Err() != nil {
+			return
+		}
+		c.send(chatWSServerFrame{Type: name, RunID: run.id, Data: payload})
+	}
+
+	status := chatWSRunCompleted
+	execErr := service.ExecuteAgentStream(ctx, req, c.requestID, emitter)
+	switch {
+	case run.cancelled.Load():
+		status = chatWSRunCancelled
+	case execErr != nil:
+		status = chatWSRunFailed
+		c.logger.Error("chat websocket run failed",
+			zap.String("run_id", run.id),
+			zap.String("agent_id", req.AgentID),
+			zap.String("execution_id", run.session.ID),
+			zap.Error(execErr),
+		)
+		c.sendError(run.id, execErr)
+	}
+	// Release the run before reporting completion so the client may reuse run_id.
+	c.finishRun(run)
+	c.send(chatWSServerFrame{Type: "done", RunID: run.id, Data: map[string]any{"status": status}})
+	c.logger.Info("chat websocket run finished",
+		zap.String("run_id", run.id),
+		zap.String("agent_id", req.AgentID),
+		zap.String("status", status),
+	)
+}
+
+func (c *chatWSConn) finishRun(run *chatWSRun) {
+	run.cancel()
+	c.handler.sessionMgr.Remove(run.session.ID)
+	run.mu.Lock()
+	for _, id := range run.interrupts {
+		chatWSInterruptRuns.Delete(id)
+	}
+	run.mu.Unlock()
+	c.mu.Lock()
+	delete(c.runs, run.id)
+	c.mu.Unlock()
+}
+
+func (c *chatWSConn) lookupRun(runID string) (*chatWSRun, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	run, ok := c.runs[strings.TrimSpace(runID)]
+	return run, ok
+}
+
+func (c *chatWSConn) steerRun(frame chatWSClientFrame) {
+	run, ok := c.lookupRun(frame.RunID)
+	if !ok {
+		c.sendError(frame.RunID, types.NewError(types.ErrAgentNotFound, "run not found"))
+		return
+	}
+	mode := agent.SteeringMessageType(strings.TrimSpace(frame.Mode))
+	if mode == "" {
+		mode = agent.SteeringTypeGuide
+	}
+	if mode != agent.SteeringTypeGuide && mode != agent.SteeringTypeStopAndSend {
+		c.sendError(run.id, types.NewInvalidRequestError("mode must be one of: guide, stop_and_send"))
+		return
+	}
+	if strings.TrimSpace(frame.Content) == "" {
+		c.sendError(run.id, types.NewInvalidRequestError("content is required"))
+		return
+	}
+	err := run.session.SteeringCh.Send(agent.SteeringMessage{Type: mode, Content: frame.Content})
+	switch {
+	case errors.Is(err, agent.ErrSteeringChannelClosed):
+		c.sendError(run.id, types.NewError(types.ErrInvalidRequest, "run already completed"))
+	case err != nil:
+		c.sendError(run.id, types.NewError(types.ErrRateLimit, "steering channel is full, try again later"))
+	default:
+		c.send(chatWSServerFrame{Type: "steer_accepted", RunID: run.id, Data: map[string]any{"mode": string(mode)}})
+	}
+}
+
+func (c *chatWSConn) cancelRun(runID string) {
+	run, ok := c.lookupRun(runID)
+	if !ok {
+		c.sendError(runID, types.NewError(types.ErrAgentNotFound, "run not found"))
+		return
+	}
+	run.cancelled.Store(true)
+	run.cancel()
+}
+
+// send writes one frame; websocket writes are serialized across runs.
+func (c *chatWSConn) send(frame chatWSServerFrame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		c.logger.Error("failed to encode chat websocket frame", zap.String("type", frame.Type), zap.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, chatWSWriteTimeout)
+	defer cancel()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ws.Write(ctx, websocket.MessageText, data); err != nil {
+		c.logger.Debug("chat websocket write failed", zap.String("type", frame.Type), zap.Error(err))
+	}
+}
+
+func (c *chatWSConn) sendError(runID string, err *types.Error) {
+	c.send(chatWSServerFrame{Type: "error", RunID: runID, Error: errorInfoFromTypesError(err)})
+}
+
+// forwardChatWSInterrupt notifies the run that raised an interrupt, if the
+// interrupt was created inside a WebSocket chat run.
+func forwardChatWSInterrupt(ctx context.Context, interrupt *hitl.Interrupt) error {
+	run, ok := ctx.Value(chatWSRunKey{}).(*chatWSRun)
+	if !ok || run == nil || interrupt == nil {
+		return nil
+	}
+	run.mu.Lock()
+	run.interrupts = append(run.interrupts, interrupt.ID)
+	run.mu.Unlock()
+	chatWSInterruptRuns.Store(interrupt.ID, run)
+	run.conn.send(chatWSServerFrame{Type: "interrupt", RunID: run.id, Data: interrupt})
+	return nil
+}
+
+// forwardChatWSResolution notifies the originating run that an interrupt
+// reached a terminal state.
+func forwardChatWSResolution(_ context.Context, interrupt *hitl.Interrupt) {
+	if interrupt == nil {
+		return
+	}
+	value, ok := chatWSInterruptRuns.LoadAndDelete(interrupt.ID)
+	if !ok {
+		return
+	}
+	run := value.(*chatWSRun)
+	run.conn.send(chatWSServerFrame{Type: "interrupt_resolved", RunID: run.id, Data: interrupt})
+}
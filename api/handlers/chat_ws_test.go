@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/tools"
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type chatWSTestClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dialChatWS(t *testing.T, handler *AgentHandler) *chatWSTestClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleChatWebSocket))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "") })
+	return &chatWSTestClient{t: t, conn: conn}
+}
+
+func (c *chatWSTestClient) send(frame map[string]any) {
+	c.t.Helper()
+	data, err := json.Marshal(frame)
+	require.NoError(c.t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(c.t, c.conn.Write(ctx, websocket.MessageText, data))
+}
+
+func (c *chatWSTestClient) read() map[string]any {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, data, err := c.conn.Read(ctx)
+	require.NoError(c.t, err)
+	var frame map[string]any
+	require.NoError(c.t, json.Unmarshal(data, &frame))
+	return frame
+}
+
+// readUntil reads frames until one of the given type arrives for runID.
+func (c *chatWSTestClient) readUntil(frameType, runID string) map[string]any {
+	c.t.Helper()
+	for {
+		frame := c.read()
+		if frame["type"] == frameType && frame["run_id"] == runID {
+			return frame
+		}
+	}
+}
+
+func newChatWSTestHandler(t *testing.T, service *stubAgentService) *AgentHandler {
+	t.Helper()
+	reg := newMockRegistry().withAgent(newTestAgentInfo("ws-agent", tools.AgentStatusOnline))
+	handler := newTestHandler(t, reg)
+	if service.resolveForOperationFn == nil {
+		service.resolveForOperationFn = func(ctx context.Context, agentID string, op usecase.AgentOperation) (agent.Agent, *types.Error) {
+			return nil, nil
+		}
+	}
+	handler.service = service
+	return handler
+}
+
+func TestAgentHandler_HandleChatWebSocket_StreamsRunAndAppliesSteering(t *testing.T) {
+	handler := newChatWSTestHandler(t, &stubAgentService{
+		executeAgentStreamFn: func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error {
+			emitter(agent.RuntimeStreamEvent{Type: agent.RuntimeStreamToken, Delta: "thinking"})
+			steerCh, ok := agent.SteeringChannelFromContext(ctx)
+			if !ok {
+				return types.NewInternalError("missing steering channel")
+			}
+			select {
+			case msg := <-steerCh.Receive():
+				emitter(agent.RuntimeStreamEvent{Type: agent.RuntimeStreamSteering, SteeringContent: msg.Content})
+			case <-ctx.Done():
+				return types.NewInternalError("run cancelled")
+			}
+			return nil
+		},
+	})
+	client := dialChatWS(t, handler)
+
+	client.send(map[string]any{"type": "run", "run_id": "r1", "request": map[string]any{"agent_id": "ws-agent", "content": "hi"}})
+	started := client.readUntil("run_started", "r1")
+	assert.NotEmpty(t, started["data"].(map[string]any)["execution_id"])
+	token := client.readUntil("token", "r1")
+	assert.Equal(t, "thinking", token["data"].(map[string]any)["content"])
+
+	client.send(map[string]any{"type": "steer", "run_id": "r1", "content": "focus on Go"})
+	assert.Equal(t, "guide", client.readUntil("steer_accepted", "r1")["data"].(map[string]any)["mode"])
+	steering := client.readUntil("steering", "r1")
+	assert.Equal(t, "focus on Go", steering["data"].(map[string]any)["content"])
+	done := client.readUntil("done", "r1")
+	assert.Equal(t, "completed", done["data"].(map[string]any)["status"])
+}
+
+func TestAgentHandler_HandleChatWebSocket_MultiplexesAndCancelsRuns(t *testing.T) {
+	handler := newChatWSTestHandler(t, &stubAgentService{
+		executeAgentStreamFn: func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error {
+			if req.Content == "quick" {
+				emitter(agent.RuntimeStreamEvent{Type: agent.RuntimeStreamToken, Delta: "fast"})
+				return nil
+			}
+			<-ctx.Done()
+			return types.NewInternalError("run cancelled")
+		},
+	})
+	client := dialChatWS(t, handler)
+
+	client.send(map[string]any{"type": "run", "run_id": "slow", "request": map[string]any{"agent_id": "ws-agent", "content": "slow"}})
+	client.readUntil("run_started", "slow")
+	client.send(map[string]any{"type": "run", "run_id": "slow", "request": map[string]any{"agent_id": "ws-agent", "content": "again"}})
+	dup := client.readUntil("error", "slow")
+	assert.Equal(t, string(types.ErrInvalidRequest), dup["error"].(map[string]any)["code"])
+
+	client.send(map[string]any{"type": "run", "run_id": "fast", "request": map[string]any{"agent_id": "ws-agent", "content": "quick"}})
+	assert.Equal(t, "completed", client.readUntil("done", "fast")["data"].(map[string]any)["status"])
+
+	client.send(map[string]any{"type": "cancel", "run_id": "slow"})
+	assert.Equal(t, "cancelled", client.readUntil("done", "slow")["data"].(map[string]any)["status"])
+
+	client.send(map[string]any{"type": "steer", "run_id": "slow", "content": "too late"})
+	missing := client.readUntil("error", "slow")
+	assert.Equal(t, string(types.ErrAgentNotFound), missing["error"].(map[string]any)["code"])
+}
+
+func TestAgentHandler_HandleChatWebSocket_RejectsRunsOverLimit(t *testing.T) {
+	release := make(chan struct{})
+	handler := newChatWSTestHandler(t, &stubAgentService{
+		executeAgentStreamFn: func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+	handler.ConfigureChatWebSocket(ChatWebSocketOptions{MaxRunsPerConnection: 1})
+	client := dialChatWS(t, handler)
+
+	client.send(map[string]any{"type": "run", "run_id": "a", "request": map[string]any{"agent_id": "ws-agent", "content": "one"}})
+	client.readUntil("run_started", "a")
+	client.send(map[string]any{"type": "run", "run_id": "b", "request": map[string]any{"agent_id": "ws-agent", "content": "two"}})
+	rejected := client.readUntil("error", "b")
+	assert.Equal(t, string(types.ErrRateLimit), rejected["error"].(map[string]any)["code"])
+
+	close(release)
+	client.readUntil("done", "a")
+}
+
+func TestAgentHandler_HandleChatWebSocket_ForwardsInterrupts(t *testing.T) {
+	manager := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), zap.NewNop())
+	interruptIDs := make(chan string, 1)
+	handler := newChatWSTestHandler(t, &stubAgentService{
+		executeAgentStreamFn: func(ctx context.Context, req usecase.AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error {
+			interrupt, err := manager.CreatePendingInterrupt(ctx, hitl.InterruptOptions{
+				WorkflowID: "tool_approval",
+				Type:       hitl.InterruptTypeApproval,
+				Title:      "Tool approval required: shell",
+				Timeout:    time.Minute,
+			})
+			if err != nil {
+				return types.NewInternalError(err.Error())
+			}
+			interruptIDs <- interrupt.ID
+			<-ctx.Done()
+			return nil
+		},
+	})
+	handler.ConfigureChatWebSocket(ChatWebSocketOptions{Interrupts: manager})
+	client := dialChatWS(t, handler)
+
+	client.send(map[string]any{"type": "run", "run_id": "hitl", "request": map[string]any{"agent_id": "ws-agent", "content": "run shell"}})
+	notified := client.readUntil("interrupt", "hitl")
+	id := <-interruptIDs
+	assert.Equal(t, id, notified["data"].(map[string]any)["id"])
+	assert.Equal(t, "pending", notified["data"].(map[string]any)["status"])
+
+	require.NoError(t, manager.ResolveInterrupt(context.Background(), id, &hitl.Response{Approved: true}))
+	resolved := client.readUntil("interrupt_resolved", "hitl")
+	assert.Equal(t, "resolved", resolved["data"].(map[string]any)["status"])
+
+	client.send(map[string]any{"type": "cancel", "run_id": "hitl"})
+	client.readUntil("done", "hitl")
+}
+
+func TestAgentHandler_HandleChatWebSocket_ReportsInvalidFrames(t *testing.T) {
+	handler := newChatWSTestHandler(t, &stubAgentService{})
+	client := dialChatWS(t, handler)
+
+	client.send(map[string]any{"type": "bogus", "run_id": "x"})
+	assert.Equal(t, string(types.ErrInvalidRequest), client.readUntil("error", "x")["error"].(map[string]any)["code"])
+
+	client.send(map[string]any{"type": "run", "run_id": "y", "request": map[string]any{"content": "no agent"}})
+	assert.Equal(t, "error", client.readUntil("error", "y")["type"])
+
+	client.send(map[string]any{"type": "ping", "run_id": "p"})
+	client.readUntil("pong", "p")
+}
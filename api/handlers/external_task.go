@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// ExternalTaskHandler 处理工作流外部活动 worker 的 HTTP 请求.
+type ExternalTaskHandler struct {
+	BaseHandler[usecase.ExternalTaskService]
+}
+
+func NewExternalTaskHandler(service usecase.ExternalTaskService, logger *zap.Logger) *ExternalTaskHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ExternalTaskHandler{
+		BaseHandler: NewBaseHandler(service, logger),
+	}
+}
+
+// HandleClaim 领取下一个待执行任务；没有任务时返回 task=null.
+func (h *ExternalTaskHandler) HandleClaim(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("external tasks")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.ClaimExternalTaskRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	task, err := service.Claim(r.Context(), req)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	if task != nil {
+		h.logger.Info("external task claimed",
+			zap.String("task_id", task.ID),
+			zap.String("task_type", task.TaskType),
+			zap.String("worker_id", req.WorkerID))
+	}
+	WriteSuccess(w, map[string]any{"task": task})
+}
+
+// HandleGet 查询任务状态.
+func (h *ExternalTaskHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("external tasks")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	task, err := service.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, task)
+}
+
+// HandleHeartbeat 记录 worker 心跳；任务已被超时或取消时返回 409，worker 应停止执行.
+func (h *ExternalTaskHandler) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("external tasks")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.ExternalTaskHeartbeatRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	task, err := service.Heartbeat(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, task)
+}
+
+// HandleComplete 回传任务结果.
+func (h *ExternalTaskHandler) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("external tasks")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.CompleteExternalTaskRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	if err := service.Complete(r.Context(), r.PathValue("id"), req); err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("external task completed",
+		zap.String("task_id", r.PathValue("id")),
+		zap.String("worker_id", req.WorkerID))
+	WriteSuccess(w, map[string]any{"task_id": r.PathValue("id"), "status": "completed"})
+}
+
+// HandleFail 回传任务失败，由工作流步骤决定是否重试.
+func (h *ExternalTaskHandler) HandleFail(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("external tasks")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.FailExternalTaskRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	if err := service.Fail(r.Context(), r.PathValue("id"), req); err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("external task failed",
+		zap.String("task_id", r.PathValue("id")),
+		zap.String("worker_id", req.WorkerID),
+		zap.String("error", req.Error))
+	WriteSuccess(w, map[string]any{"task_id": r.PathValue("id"), "status": "failed"})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"go.uber.org/zap"
+)
+
+type GuardrailAuditHandler struct {
+	BaseHandler[usecase.GuardrailAuditService]
+}
+
+func NewGuardrailAuditHandler(service usecase.GuardrailAuditService, logger *zap.Logger) *GuardrailAuditHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &GuardrailAuditHandler{BaseHandler: NewBaseHandler(service, logger)}
+}
+
+func (h *GuardrailAuditHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("guardrail audit")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	input, ok := parseGuardrailAuditListInput(w, r, h.logger)
+	if !ok {
+		return
+	}
+	rows, err := service.List(r.Context(), input)
+	if err != nil {
+		logToolRequestWarn(h.logger, r, "guardrail_audit", "list", "failed", "guardrail audit request completed", zap.Error(err))
+		WriteError(w, err, h.logger)
+		return
+	}
+	logToolRequestInfo(h.logger, r, "guardrail_audit", "list", "success", "guardrail audit request completed", zap.Int("count", len(rows)))
+	WriteSuccess(w, map[string]any{"audits": rows})
+}
+
+func parseGuardrailAuditListInput(
+	w http.ResponseWriter,
+	r *http.Request,
+	logger *zap.Logger,
+) (usecase.ListGuardrailAuditInput, bool) {
+	query := r.URL.Query()
+	input := usecase.ListGuardrailAuditInput{
+		Validator: strings.TrimSpace(query.Get("validator")),
+		Decision:  strings.TrimSpace(query.Get("decision")),
+		Direction: strings.TrimSpace(query.Get("direction")),
+		TenantID:  strings.TrimSpace(query.Get("tenant_id")),
+		TraceID:   strings.TrimSpace(query.Get("trace_id")),
+	}
+	limit, err := parsePositiveQueryInt(query.Get("limit"), "limit")
+	if err != nil {
+		WriteError(w, err.WithHTTPStatus(http.StatusBadRequest), logger)
+		return input, false
+	}
+	input.Limit = limit
+	offset, err := parseNonNegativeQueryInt(query.Get("offset"), "offset")
+	if err != nil {
+		WriteError(w, err.WithHTTPStatus(http.StatusBadRequest), logger)
+		return input, false
+	}
+	input.Offset = offset
+	return input, true
+}
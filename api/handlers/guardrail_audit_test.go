@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGuardrailAuditHandler_ListFiltersDecisions(t *testing.T) {
+	auditLogger := guardrails.NewGuardrailAuditLogger(nil)
+	blocked := guardrails.NewValidationResult()
+	blocked.AddError(guardrails.ValidationError{Code: guardrails.ErrCodeBlockedKeyword, Message: "blocked", Severity: guardrails.SeverityHigh})
+	ctx := types.WithTenantID(context.Background(), "tenant-a")
+	auditLogger.RecordDecision(ctx, guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "keyword_validator", Result: blocked})
+	auditLogger.RecordDecision(ctx, guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "length_validator", Result: guardrails.NewValidationResult()})
+
+	handler := NewGuardrailAuditHandler(usecase.NewDefaultGuardrailAuditService(auditLogger), zap.NewNop())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/guardrails/audit?decision=block&tenant_id=tenant-a", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleList(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "\"audits\"")
+	assert.Contains(t, rec.Body.String(), "\"validator_name\":\"keyword_validator\"")
+	assert.Contains(t, rec.Body.String(), "\"matched_rules\":[\"BLOCKED_KEYWORD\"]")
+	assert.NotContains(t, rec.Body.String(), "length_validator")
+}
+
+func TestGuardrailAuditHandler_ListRejectsInvalidQuery(t *testing.T) {
+	handler := NewGuardrailAuditHandler(usecase.NewDefaultGuardrailAuditService(guardrails.NewGuardrailAuditLogger(nil)), zap.NewNop())
+
+	for _, query := range []string{"limit=bad", "offset=-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/guardrails/audit?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.HandleList(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// InterruptHandler 通过 HTTP 暴露 hitl.InterruptManager：列出与查看中断、提交人工响应，
+// 以及推送新中断的 SSE 流，审批控制台无需额外胶水代码即可接入
+type InterruptHandler struct {
+	BaseHandler[usecase.InterruptService]
+	heartbeatInterval time.Duration
+}
+
+func NewInterruptHandler(service usecase.InterruptService, logger *zap.Logger) *InterruptHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &InterruptHandler{
+		BaseHandler:       NewBaseHandler(service, logger),
+		heartbeatInterval: defaultSSEHeartbeatInterval,
+	}
+}
+
+// HandleList GET /api/v1/interrupts
+// 支持 workflow_id、tenant_id、status（默认 pending）过滤，按创建时间升序返回。
+func (h *InterruptHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("interrupt")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	rows, err := service.List(r.Context(), interruptListFilter(r))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, map[string]any{"interrupts": rows})
+}
+
+// HandleGet GET /api/v1/interrupts/{id}
+// 返回中断详情，包括 input_schema 与可选项。
+func (h *InterruptHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("interrupt")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	interrupt, err := service.Get(r.Context(), extractInterruptID(r), callerTenantID(r))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, interrupt)
+}
+
+// HandleRespond POST /api/v1/interrupts/{id}/responses
+// action 为 approve、reject 或 input；input 按中断的 input_schema 校验。
+func (h *InterruptHandler) HandleRespond(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("interrupt")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.RespondInterruptInput
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	id := extractInterruptID(r)
+	interrupt, err := service.Respond(r.Context(), id, callerTenantID(r), req)
+	if err != nil {
+		h.logger.Warn("interrupt response rejected", zap.String("interrupt_id", id), zap.String("action", req.Action), zap.Error(err))
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("interrupt resolved", zap.String("interrupt_id", id), zap.String("action", req.Action))
+	WriteSuccess(w, interrupt)
+}
+
+// HandleStream GET /api/v1/interrupts/stream
+// 以 SSE 推送中断：先发送当前待处理的中断，之后实时推送新中断，过滤参数同列表接口。
+func (h *InterruptHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("interrupt")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, types.NewInternalError("streaming not supported").WithHTTPStatus(http.StatusInternalServerError), h.logger)
+		return
+	}
+
+	filter := interruptListFilter(r)
+	// 先订阅再列出待处理中断，避免两者之间创建的中断丢失
+	live, err := service.Subscribe(r.Context(), filter)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	filter.Status = string(hitl.InterruptStatusPending)
+	pending, err := service.List(r.Context(), filter)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+
+	setSSEHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	seen := make(map[string]struct{}, len(pending))
+	send := func(interrupt *hitl.Interrupt) bool {
+		if _, dup := seen[interrupt.ID]; dup {
+			return true
+		}
+		seen[interrupt.ID] = struct{}{}
+		data, err := json.Marshal(interrupt)
+		if err != nil {
+			h.logger.Warn("failed to encode interrupt", zap.String("interrupt_id", interrupt.ID), zap.Error(err))
+			return true
+		}
+		if err := writeSSEEvent(w, interrupt.ID, "interrupt", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	for _, interrupt := range pending {
+		if !send(interrupt) {
+			return
+		}
+	}
+
+	var heartbeat <-chan time.Time
+	if h.heartbeatInterval > 0 {
+		ticker := time.NewTicker(h.heartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case interrupt, ok := <-live:
+			if !ok || !send(interrupt) {
+				return
+			}
+		case <-heartbeat:
+			if err := writeSSE(w, []byte(": heartbeat\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// interruptListFilter 读取过滤参数；绑定租户的调用方只能看到本租户的中断。
+func interruptListFilter(r *http.Request) usecase.InterruptListFilter {
+	query := r.URL.Query()
+	tenantID := callerTenantID(r)
+	if tenantID == "" {
+		tenantID = strings.TrimSpace(query.Get("tenant_id"))
+	}
+	return usecase.InterruptListFilter{
+		WorkflowID: strings.TrimSpace(query.Get("workflow_id")),
+		TenantID:   tenantID,
+		Status:     query.Get("status"),
+	}
+}
+
+func callerTenantID(r *http.Request) string {
+	tenantID, _ := types.TenantID(r.Context())
+	return tenantID
+}
+
+func extractInterruptID(r *http.Request) string {
+	return pathStringValue(r, "id", 3)
+}
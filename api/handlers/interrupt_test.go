@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// managerInterruptRuntime 直接包装单个 InterruptManager，新中断经 created 推送
+type managerInterruptRuntime struct {
+	*hitl.InterruptManager
+	created chan *hitl.Interrupt
+}
+
+func (m *managerInterruptRuntime) SubscribeInterrupts(ctx context.Context) <-chan *hitl.Interrupt {
+	out := make(chan *hitl.Interrupt)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case interrupt := <-m.created:
+				out <- interrupt
+			}
+		}
+	}()
+	return out
+}
+
+func newTestInterruptHandler(t *testing.T) (*InterruptHandler, *hitl.InterruptManager, *managerInterruptRuntime) {
+	t.Helper()
+	manager := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), zap.NewNop())
+	runtime := &managerInterruptRuntime{InterruptManager: manager, created: make(chan *hitl.Interrupt, 4)}
+	return NewInterruptHandler(usecase.NewDefaultInterruptService(runtime), zap.NewNop()), manager, runtime
+}
+
+func createTestInterrupt(t *testing.T, manager *hitl.InterruptManager, tenantID string, opts hitl.InterruptOptions) *hitl.Interrupt {
+	t.Helper()
+	ctx := context.Background()
+	if tenantID != "" {
+		ctx = types.WithTenantID(ctx, tenantID)
+	}
+	interrupt, err := manager.CreatePendingInterrupt(ctx, opts)
+	require.NoError(t, err)
+	// 取消未解决的中断，释放其超时等待协程
+	t.Cleanup(func() { _ = manager.CancelInterrupt(context.Background(), interrupt.ID) })
+	return interrupt
+}
+
+func serveInterruptRequest(h *InterruptHandler, ctx context.Context, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/interrupts", h.HandleList)
+	mux.HandleFunc("GET /api/v1/interrupts/{id}", h.HandleGet)
+	mux.HandleFunc("POST /api/v1/interrupts/{id}/responses", h.HandleRespond)
+	req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestInterruptHandler_ListFiltersByWorkflowAndTenant(t *testing.T) {
+	h, manager, _ := newTestInterruptHandler(t)
+	createTestInterrupt(t, manager, "t1", hitl.InterruptOptions{WorkflowID: "wf-1", Type: hitl.InterruptTypeApproval, Title: "first"})
+	createTestInterrupt(t, manager, "t2", hitl.InterruptOptions{WorkflowID: "wf-1", Type: hitl.InterruptTypeApproval, Title: "other tenant"})
+	createTestInterrupt(t, manager, "t1", hitl.InterruptOptions{WorkflowID: "wf-2", Type: hitl.InterruptTypeApproval, Title: "other workflow"})
+
+	rec := serveInterruptRequest(h, context.Background(), http.MethodGet, "/api/v1/interrupts?workflow_id=wf-1&tenant_id=t1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"first"`)
+	assert.NotContains(t, rec.Body.String(), "other")
+
+	// 绑定租户的调用方忽略 tenant_id 参数
+	rec = serveInterruptRequest(h, types.WithTenantID(context.Background(), "t2"), http.MethodGet, "/api/v1/interrupts?tenant_id=t1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"other tenant"`)
+	assert.NotContains(t, rec.Body.String(), `"title":"first"`)
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodGet, "/api/v1/interrupts?status=bogus", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestInterruptHandler_GetAndRespond(t *testing.T) {
+	h, manager, _ := newTestInterruptHandler(t)
+	interrupt := createTestInterrupt(t, manager, "t1", hitl.InterruptOptions{
+		WorkflowID:  "wf-1",
+		Type:        hitl.InterruptTypeInput,
+		Title:       "need a number",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"count":{"type":"integer"}},"required":["count"]}`),
+		Options:     []hitl.Option{{ID: "submit", Label: "Submit"}},
+	})
+	target := "/api/v1/interrupts/" + interrupt.ID
+
+	rec := serveInterruptRequest(h, context.Background(), http.MethodGet, target, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"input_schema"`)
+	assert.Contains(t, rec.Body.String(), `"id":"submit"`)
+
+	rec = serveInterruptRequest(h, types.WithTenantID(context.Background(), "t2"), http.MethodGet, target, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodPost, target+"/responses", `{"action":"input","input":{"count":"three"}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodPost, target+"/responses", `{"action":"input","option_id":"nope","input":{"count":3}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	ctx := types.WithUserID(context.Background(), "reviewer")
+	rec = serveInterruptRequest(h, ctx, http.MethodPost, target+"/responses", `{"action":"input","option_id":"submit","input":{"count":3},"user_id":"spoofed"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodPost, target+"/responses", `{"action":"approve","user_id":"reviewer"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "unauthenticated callers cannot name an approver")
+
+	rec = serveInterruptRequest(h, ctx, http.MethodPost, target+"/responses", `{"action":"input","option_id":"submit","input":{"count":3}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"status":"resolved"`)
+
+	stored, err := manager.GetInterrupt(context.Background(), interrupt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hitl.InterruptStatusResolved, stored.Status)
+	require.NotNil(t, stored.Response)
+	assert.Equal(t, "reviewer", stored.Response.UserID)
+	assert.Equal(t, map[string]any{"count": float64(3)}, stored.Response.Input)
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodPost, target+"/responses", `{"action":"approve"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestInterruptHandler_RejectAndInvalidAction(t *testing.T) {
+	h, manager, _ := newTestInterruptHandler(t)
+	interrupt := createTestInterrupt(t, manager, "", hitl.InterruptOptions{WorkflowID: "wf-1", Type: hitl.InterruptTypeApproval})
+	target := "/api/v1/interrupts/" + interrupt.ID + "/responses"
+
+	rec := serveInterruptRequest(h, context.Background(), http.MethodPost, target, `{"action":"maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveInterruptRequest(h, context.Background(), http.MethodPost, target, `{"action":"reject","comment":"too risky"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"status":"rejected"`)
+}
+
+func TestInterruptHandler_StreamSendsPendingThenNew(t *testing.T) {
+	h, manager, runtime := newTestInterruptHandler(t)
+	existing := createTestInterrupt(t, manager, "", hitl.InterruptOptions{WorkflowID: "wf-1", Type: hitl.InterruptTypeApproval, Title: "existing"})
+	createTestInterrupt(t, manager, "", hitl.InterruptOptions{WorkflowID: "wf-2", Type: hitl.InterruptTypeApproval, Title: "filtered"})
+
+	srv := httptest.NewServer(http.HandlerFunc(h.HandleStream))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/interrupts/stream?workflow_id=wf-1", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() map[string]string {
+		fields := map[string]string{}
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+			if line == "" {
+				if len(fields) > 0 {
+					return fields
+				}
+				continue
+			}
+			if key, value, ok := strings.Cut(line, ": "); ok {
+				fields[key] = value
+			}
+		}
+	}
+
+	first := readEvent()
+	assert.Equal(t, existing.ID, first["id"])
+	assert.Equal(t, "interrupt", first["event"])
+
+	fresh := createTestInterrupt(t, manager, "", hitl.InterruptOptions{WorkflowID: "wf-1", Type: hitl.InterruptTypeReview, Title: "fresh"})
+	runtime.created <- &hitl.Interrupt{ID: "ignored", WorkflowID: "wf-2"}
+	runtime.created <- fresh
+
+	second := readEvent()
+	assert.Equal(t, fresh.ID, second["id"])
+	assert.Contains(t, second["data"], `"title":"fresh"`)
+}
+
+func TestInterruptHandler_Unavailable(t *testing.T) {
+	rec := serveInterruptRequest(NewInterruptHandler(nil, zap.NewNop()), context.Background(), http.MethodGet, "/api/v1/interrupts", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
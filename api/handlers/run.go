@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// RunHandler 提供运行检查接口：按 run ID 返回追踪树（LLM 调用、工具调用、护栏决策）
+// 与 token/成本明细，便于运维通过 API 而非日志排查 Agent 行为
+type RunHandler struct {
+	BaseHandler[usecase.RunInspectionService]
+}
+
+func NewRunHandler(service usecase.RunInspectionService, logger *zap.Logger) *RunHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RunHandler{
+		BaseHandler: NewBaseHandler(service, logger),
+	}
+}
+
+// HandleGet GET /v1/runs/{id}
+// 返回运行的追踪树与用量明细（含子运行）。绑定租户的调用方只能查看本租户的运行。
+func (h *RunHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("run recorder")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	tenantID, _ := types.TenantID(r.Context())
+	view, err := service.Get(r.Context(), pathStringValue(r, "id", 2), tenantID)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, view)
+}
+
+// HandleEvents GET /v1/runs/{id}/events
+// 返回运行的原始事件流，after=<sequence> 只返回序号更大的事件，便于增量轮询。
+func (h *RunHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("run recorder")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	after, err := parseNonNegativeQueryInt(r.URL.Query().Get("after"), "after")
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	tenantID, _ := types.TenantID(r.Context())
+	view, err := service.Events(r.Context(), pathStringValue(r, "id", 2), tenantID, int64(after))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	WriteSuccess(w, view)
+}
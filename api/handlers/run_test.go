@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRunReader map[string]*usecase.RunRecordView
+
+func (f fakeRunReader) RunRecord(runID string) (*usecase.RunRecordView, bool) {
+	record, ok := f[runID]
+	return record, ok
+}
+
+func newTestRunHandler() *RunHandler {
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	reader := fakeRunReader{"run-1": {
+		RunID:    "run-1",
+		TenantID: "t1",
+		Events: []types.RunEvent{
+			{Type: types.RunEventStarted, Sequence: 1, Timestamp: ts, AgentID: "assistant"},
+			{Type: types.RunEventUsage, Sequence: 2, Timestamp: ts.Add(time.Second),
+				Usage: &types.ChatUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				Data:  map[string]any{"provider": "openai", "model": "gpt-5.4", "cost_usd": 0.02}},
+			{Type: types.RunEventCompleted, Sequence: 3, Timestamp: ts.Add(2 * time.Second)},
+		},
+	}}
+	return NewRunHandler(usecase.NewDefaultRunInspectionService(reader), zap.NewNop())
+}
+
+func serveRunRequest(h *RunHandler, ctx context.Context, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/runs/{id}", h.HandleGet)
+	mux.HandleFunc("GET /v1/runs/{id}/events", h.HandleEvents)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx))
+	return rec
+}
+
+func TestRunHandler_Get(t *testing.T) {
+	rec := serveRunRequest(newTestRunHandler(), context.Background(), "/v1/runs/run-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `"status":"completed"`)
+	assert.Contains(t, body, `"type":"llm"`)
+	assert.Contains(t, body, `"llm_calls":1`)
+}
+
+func TestRunHandler_Events(t *testing.T) {
+	h := newTestRunHandler()
+	rec := serveRunRequest(h, context.Background(), "/v1/runs/run-1/events?after=2")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"sequence":3`)
+	assert.NotContains(t, rec.Body.String(), `"sequence":2`)
+
+	rec = serveRunRequest(h, context.Background(), "/v1/runs/run-1/events?after=-1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRunHandler_TenantBoundCaller(t *testing.T) {
+	h := newTestRunHandler()
+	rec := serveRunRequest(h, types.WithTenantID(context.Background(), "t2"), "/v1/runs/run-1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveRunRequest(h, types.WithTenantID(context.Background(), "t1"), "/v1/runs/run-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRunHandler_Unavailable(t *testing.T) {
+	rec := serveRunRequest(NewRunHandler(nil, zap.NewNop()), context.Background(), "/v1/runs/run-1")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"go.uber.org/zap"
+)
+
+type SandboxImageAdminHandler struct {
+	BaseHandler[usecase.SandboxImageAdminService]
+}
+
+func NewSandboxImageAdminHandler(service usecase.SandboxImageAdminService, logger *zap.Logger) *SandboxImageAdminHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SandboxImageAdminHandler{
+		BaseHandler: NewBaseHandler(service, logger),
+	}
+}
+
+// HandleListImages 列出各语言固定的沙箱镜像及灰度状态.
+func (h *SandboxImageAdminHandler) HandleListImages(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("sandbox images")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	WriteSuccess(w, service.ListImages(r.Context()))
+}
+
+// HandleStartRollout 校验候选镜像 digest 后开始灰度.
+func (h *SandboxImageAdminHandler) HandleStartRollout(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("sandbox images")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.StartImageRolloutRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	rollout, err := service.StartRollout(r.Context(), r.PathValue("language"), req)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("sandbox image rollout started",
+		zap.String("language", r.PathValue("language")),
+		zap.String("image", rollout.Candidate.Reference()),
+		zap.Int("percent", rollout.Percent))
+	WriteSuccess(w, rollout)
+}
+
+// HandleUpdateRollout 调整候选镜像的流量比例.
+func (h *SandboxImageAdminHandler) HandleUpdateRollout(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPatch, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("sandbox images")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req usecase.UpdateImageRolloutRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	rollout, err := service.UpdateRollout(r.Context(), r.PathValue("language"), req)
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("sandbox image rollout updated",
+		zap.String("language", r.PathValue("language")),
+		zap.Int("percent", rollout.Percent))
+	WriteSuccess(w, rollout)
+}
+
+// HandlePromoteRollout 将候选镜像提升为稳定镜像.
+func (h *SandboxImageAdminHandler) HandlePromoteRollout(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("sandbox images")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	status, err := service.PromoteRollout(r.Context(), r.PathValue("language"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("sandbox image rollout promoted",
+		zap.String("language", string(status.Language)),
+		zap.String("image", status.Stable.Reference()))
+	WriteSuccess(w, status)
+}
+
+// HandleAbortRollout 终止灰度，全部流量回到稳定镜像.
+func (h *SandboxImageAdminHandler) HandleAbortRollout(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("sandbox images")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	if err := service.AbortRollout(r.Context(), r.PathValue("language")); err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	h.logger.Info("sandbox image rollout aborted", zap.String("language", r.PathValue("language")))
+	WriteSuccess(w, map[string]string{"language": r.PathValue("language"), "status": "aborted"})
+}
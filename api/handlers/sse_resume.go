@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	// streamResumeTokenHeader 返回给客户端的流恢复令牌，事件 ID 形如 "<token>:<seq>"
+	streamResumeTokenHeader = "X-Stream-Resume-Token"
+
+	defaultSSEHeartbeatInterval = 15 * time.Second
+	defaultStreamResumeTTL      = 2 * time.Minute
+	defaultStreamResumeEvents   = 4096
+	defaultStreamResumeStreams  = 1024
+)
+
+// StreamingOptions 配置 SSE 流的心跳与断线恢复。
+type StreamingOptions struct {
+	// HeartbeatInterval 空闲时发送注释心跳的间隔，默认 15 秒，负数表示关闭
+	HeartbeatInterval time.Duration
+	// Resume 可选：配置后流在客户端断开后继续在服务端缓冲，客户端可携带
+	// Last-Event-ID 重连并从断点继续接收，不丢失也不重复
+	Resume *StreamResumeBuffer
+}
+
+// StreamResumeConfig 可恢复流缓冲配置
+type StreamResumeConfig struct {
+	TTL        time.Duration // 无客户端连接后流的保留时长（含上游生成），默认 2 分钟
+	MaxEvents  int           // 单个流最多缓冲的事件数，默认 4096，超出后丢弃最早事件
+	MaxStreams int           // 同时保留的流数，默认 1024，超出后拒绝为新流开启恢复
+}
+
+// StreamResumeBuffer 是短时有效的 SSE 事件缓冲，按恢复令牌保存进行中与刚结束的流。
+type StreamResumeBuffer struct {
+	mu      sync.Mutex
+	config  StreamResumeConfig
+	streams map[string]*sseStream
+}
+
+// NewStreamResumeBuffer 创建流恢复缓冲
+func NewStreamResumeBuffer(config StreamResumeConfig) *StreamResumeBuffer {
+	if config.TTL <= 0 {
+		config.TTL = defaultStreamResumeTTL
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = defaultStreamResumeEvents
+	}
+	if config.MaxStreams <= 0 {
+		config.MaxStreams = defaultStreamResumeStreams
+	}
+	return &StreamResumeBuffer{
+		config:  config,
+		streams: make(map[string]*sseStream),
+	}
+}
+
+// open 为新流分配恢复令牌；容量已满时返回 nil，调用方退化为不可恢复的流。
+func (b *StreamResumeBuffer) open(owner string) *sseStream {
+	token := newStreamResumeToken()
+	if token == "" {
+		return nil
+	}
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pruneLocked(now)
+	if len(b.streams) >= b.config.MaxStreams {
+		return nil
+	}
+	stream := newSSEStream(token, owner, b.config.MaxEvents)
+	b.streams[token] = stream
+	return stream
+}
+
+// lookup 返回属于 owner 且仍在有效期内的流。
+func (b *StreamResumeBuffer) lookup(token, owner string) (*sseStream, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pruneLocked(time.Now())
+	stream, ok := b.streams[token]
+	if !ok || stream.owner != owner {
+		return nil, false
+	}
+	return stream, true
+}
+
+func (b *StreamResumeBuffer) remove(token string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.streams, token)
+}
+
+func (b *StreamResumeBuffer) pruneLocked(now time.Time) {
+	for token, stream := range b.streams {
+		if stream.abandoned(now, b.config.TTL) {
+			delete(b.streams, token)
+		}
+	}
+}
+
+func newStreamResumeToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return "sr_" + hex.EncodeToString(buf)
+}
+
+type sseEvent struct {
+	seq  uint64
+	name string
+	data []byte
+}
+
+// sseStream 保存一个流已产生的事件；生产者与任意数量的客户端连接解耦。
+// 可恢复的流（token 非空）以环形缓冲保留最近 maxEvents 个事件供重连补发；
+// 不可恢复的流只有当前连接一个消费者，事件写出后即丢弃，缓冲满时阻塞生产者形成背压。
+type sseStream struct {
+	token     string
+	owner     string
+	maxEvents int
+
+	mu          sync.Mutex
+	events      []sseEvent // 环形缓冲，按需扩容至 maxEvents
+	head        int        // 最早事件在 events 中的下标
+	count       int
+	lastSeq     uint64
+	done        bool
+	changed     chan struct{}
+	drained     chan struct{}
+	subscribers int
+	idleSince   time.Time
+}
+
+func newSSEStream(token, owner string, maxEvents int) *sseStream {
+	return &sseStream{
+		token:     token,
+		owner:     owner,
+		maxEvents: maxEvents,
+		changed:   make(chan struct{}),
+		drained:   make(chan struct{}),
+	}
+}
+
+// append 追加事件。不可恢复的流缓冲已满时阻塞，直到当前连接消费或 ctx 结束。
+func (s *sseStream) append(ctx context.Context, name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.token == "" && s.maxEvents > 0 && s.count >= s.maxEvents && !s.done {
+		drained := s.drained
+		s.mu.Unlock()
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			s.mu.Lock()
+			return
+		}
+		s.mu.Lock()
+	}
+	if s.done {
+		return
+	}
+	s.lastSeq++
+	s.pushLocked(sseEvent{seq: s.lastSeq, name: name, data: data})
+	s.notifyLocked()
+}
+
+// pushLocked 写入环形缓冲；已达 maxEvents 时覆盖最早事件。
+func (s *sseStream) pushLocked(ev sseEvent) {
+	if s.count == len(s.events) && (s.maxEvents <= 0 || s.count < s.maxEvents) {
+		size := 2 * len(s.events)
+		if size == 0 {
+			size = 16
+		}
+		if s.maxEvents > 0 && size > s.maxEvents {
+			size = s.maxEvents
+		}
+		grown := make([]sseEvent, size)
+		for i := 0; i < s.count; i++ {
+			grown[i] = s.events[(s.head+i)%len(s.events)]
+		}
+		s.events, s.head = grown, 0
+	}
+	if s.count == len(s.events) {
+		s.events[s.head] = ev
+		s.head = (s.head + 1) % len(s.events)
+		return
+	}
+	s.events[(s.head+s.count)%len(s.events)] = ev
+	s.count++
+}
+
+func (s *sseStream) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.done = true
+	s.notifyLocked()
+}
+
+func (s *sseStream) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// since 返回序号大于 after 的事件；ok 为 false 表示所需事件已被淘汰，无法无损恢复。
+// 不可恢复的流返回的事件视为已消费，随即从缓冲移除并唤醒等待中的生产者。
+func (s *sseStream) since(after uint64) (events []sseEvent, done bool, changed <-chan struct{}, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if after > s.lastSeq {
+		return nil, s.done, s.changed, false
+	}
+	if s.count == 0 {
+		return nil, s.done, s.changed, true
+	}
+	first := s.events[s.head].seq
+	if after+1 < first {
+		return nil, s.done, s.changed, false
+	}
+	skip := int(after + 1 - first)
+	for i := skip; i < s.count; i++ {
+		events = append(events, s.events[(s.head+i)%len(s.events)])
+	}
+	if s.token == "" && len(events) > 0 {
+		for i := 0; i < s.count; i++ {
+			s.events[(s.head+i)%len(s.events)] = sseEvent{}
+		}
+		s.head, s.count = 0, 0
+		close(s.drained)
+		s.drained = make(chan struct{})
+	}
+	return events, s.done, s.changed, true
+}
+
+func (s *sseStream) attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers++
+}
+
+func (s *sseStream) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers--
+	if s.subscribers <= 0 {
+		s.subscribers = 0
+		s.idleSince = time.Now()
+	}
+}
+
+// abandoned 报告流是否已无客户端连接超过 ttl。
+func (s *sseStream) abandoned(now time.Time, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribers == 0 && !s.idleSince.IsZero() && now.Sub(s.idleSince) >= ttl
+}
+
+func (s *sseStream) eventID(seq uint64) string {
+	if s.token == "" {
+		return strconv.FormatUint(seq, 10)
+	}
+	return s.token + ":" + strconv.FormatUint(seq, 10)
+}
+
+// parseSSEEventID 解析 "<token>:<seq>" 形式的事件 ID。
+func parseSSEEventID(raw string) (token string, seq uint64, ok bool) {
+	raw = strings.TrimSpace(raw)
+	idx := strings.LastIndexByte(raw, ':')
+	if idx <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(raw[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return raw[:idx], seq, true
+}
+
+// streamOwner 标识流的归属，恢复时必须与原请求一致，防止凭令牌读取他人的流。
+func streamOwner(r *http.Request) string {
+	tenantID, _ := types.TenantID(r.Context())
+	userID, _ := types.UserID(r.Context())
+	return tenantID + "/" + userID
+}
+
+// writeSSEEvent 写入带 ID 的 SSE 事件；name 为空时省略 event 字段。
+func writeSSEEvent(w http.ResponseWriter, id, name string, data []byte) error {
+	parts := [][]byte{[]byte("id: " + id + "\n")}
+	if name != "" {
+		parts = append(parts, []byte("event: "+name+"\n"))
+	}
+	parts = append(parts, []byte("data: "), data, []byte("\n\n"))
+	return writeSSE(w, parts...)
+}
+
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+}
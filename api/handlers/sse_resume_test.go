@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/api"
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func streamRequestBody(t *testing.T) []byte {
+	t.Helper()
+	body, err := json.Marshal(api.ChatRequest{
+		Model:    "gpt-4",
+		Messages: []api.Message{{Role: "user", Content: "Hello"}},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+func TestChatHandler_HandleStream_EventIDsAndHeartbeat(t *testing.T) {
+	provider := &mockProvider{
+		streamFunc: func(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+			ch := make(chan llm.StreamChunk)
+			go func() {
+				defer close(ch)
+				time.Sleep(30 * time.Millisecond)
+				ch <- llm.StreamChunk{ID: "c1", Delta: types.Message{Content: "slow"}}
+			}()
+			return ch, nil
+		},
+	}
+	handler := newChatHandlerForProvider(provider, zap.NewNop())
+	handler.ConfigureStreaming(StreamingOptions{HeartbeatInterval: 5 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/chat/completions/stream", bytes.NewReader(streamRequestBody(t)))
+	r.Header.Set("Content-Type", "application/json")
+	handler.HandleStream(w, r)
+
+	body := w.Body.String()
+	assert.Contains(t, body, ": heartbeat\n\n")
+	assert.Contains(t, body, "id: 1\ndata: ")
+	assert.Contains(t, body, "id: 2\ndata: [DONE]\n\n")
+	assert.Empty(t, w.Header().Get(streamResumeTokenHeader), "resumption is disabled by default")
+}
+
+func TestChatHandler_HandleStream_ResumesFromLastEventID(t *testing.T) {
+	release := make(chan struct{})
+	provider := &mockProvider{
+		streamFunc: func(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+			ch := make(chan llm.StreamChunk)
+			go func() {
+				defer close(ch)
+				ch <- llm.StreamChunk{ID: "c1", Delta: types.Message{Content: "first"}}
+				<-release
+				ch <- llm.StreamChunk{ID: "c2", Delta: types.Message{Content: "second"}}
+			}()
+			return ch, nil
+		},
+	}
+	handler := newChatHandlerForProvider(provider, zap.NewNop())
+	handler.ConfigureStreaming(StreamingOptions{Resume: NewStreamResumeBuffer(StreamResumeConfig{})})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /stream", handler.HandleStream)
+	mux.HandleFunc("GET /stream", handler.HandleStream)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/stream", "application/json", bytes.NewReader(streamRequestBody(t)))
+	require.NoError(t, err)
+	token := resp.Header.Get(streamResumeTokenHeader)
+	require.NotEmpty(t, token)
+
+	reader := bufio.NewReader(resp.Body)
+	idLine, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "id: "+token+":1\n", idLine)
+	dataLine, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, dataLine, "first")
+	// 模拟移动网络断线：读到第一条事件后断开
+	require.NoError(t, resp.Body.Close())
+
+	close(release)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", token+":1")
+	resumed, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resumed.Body.Close()
+	require.Equal(t, http.StatusOK, resumed.StatusCode)
+	rest, err := io.ReadAll(resumed.Body)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(rest), "first", "already delivered events must not be repeated")
+	assert.Contains(t, string(rest), "id: "+token+":2\n")
+	assert.Contains(t, string(rest), "second")
+	assert.True(t, strings.HasSuffix(string(rest), "id: "+token+":3\ndata: [DONE]\n\n"))
+}
+
+func TestChatHandler_HandleStream_ResumeUnknownToken(t *testing.T) {
+	handler := newChatHandlerForProvider(&mockProvider{}, zap.NewNop())
+	handler.ConfigureStreaming(StreamingOptions{Resume: NewStreamResumeBuffer(StreamResumeConfig{})})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/chat/completions/stream?last_event_id=sr_missing:4", nil)
+	handler.HandleStream(w, r)
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/chat/completions/stream", nil)
+	handler.HandleStream(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestStreamResumeBuffer_OwnershipAndExpiry(t *testing.T) {
+	buffer := NewStreamResumeBuffer(StreamResumeConfig{TTL: 20 * time.Millisecond, MaxStreams: 1})
+	stream := buffer.open("tenant-a/user-1")
+	require.NotNil(t, stream)
+	assert.Nil(t, buffer.open("tenant-a/user-1"), "capacity reached")
+
+	_, ok := buffer.lookup(stream.token, "tenant-b/user-2")
+	assert.False(t, ok)
+	_, ok = buffer.lookup(stream.token, "tenant-a/user-1")
+	assert.True(t, ok)
+
+	stream.attach()
+	stream.detach()
+	time.Sleep(30 * time.Millisecond)
+	_, ok = buffer.lookup(stream.token, "tenant-a/user-1")
+	assert.False(t, ok, "stream without a client for longer than TTL is dropped")
+}
+
+func TestSSEStream_SinceDetectsEvictedEvents(t *testing.T) {
+	stream := newSSEStream("tok", "", 2)
+	for _, data := range []string{"a", "b", "c"} {
+		stream.append(context.Background(), "", []byte(data))
+	}
+
+	_, _, _, ok := stream.since(0)
+	assert.False(t, ok, "event 1 was evicted")
+
+	events, done, _, ok := stream.since(1)
+	require.True(t, ok)
+	assert.False(t, done)
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(2), events[0].seq)
+
+	_, _, _, ok = stream.since(9)
+	assert.False(t, ok)
+
+	token, seq, ok := parseSSEEventID(stream.eventID(3))
+	require.True(t, ok)
+	assert.Equal(t, "tok", token)
+	assert.Equal(t, uint64(3), seq)
+}
+
+func TestSSEStream_RingKeepsLatestEvents(t *testing.T) {
+	stream := newSSEStream("tok", "", 3)
+	for i := 0; i < 20; i++ {
+		stream.append(context.Background(), "", []byte(strconv.Itoa(i)))
+	}
+
+	events, _, _, ok := stream.since(17)
+	require.True(t, ok)
+	require.Len(t, events, 3)
+	assert.Equal(t, []uint64{18, 19, 20}, []uint64{events[0].seq, events[1].seq, events[2].seq})
+	assert.Equal(t, "19", string(events[2].data))
+
+	events, _, _, ok = stream.since(18)
+	require.True(t, ok)
+	require.Len(t, events, 2, "resumable streams keep events for other readers")
+}
+
+func TestSSEStream_NonResumableAppliesBackpressure(t *testing.T) {
+	stream := newSSEStream("", "", 2)
+	appended := make(chan struct{})
+	go func() {
+		defer close(appended)
+		for _, data := range []string{"a", "b", "c"} {
+			stream.append(context.Background(), "", []byte(data))
+		}
+	}()
+
+	select {
+	case <-appended:
+		t.Fatal("append should block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	events, _, _, ok := stream.since(0)
+	require.True(t, ok)
+	require.Len(t, events, 2)
+	<-appended
+
+	events, _, _, ok = stream.since(2)
+	require.True(t, ok, "slow clients never lose events")
+	require.Len(t, events, 1)
+	assert.Equal(t, "c", string(events[0].data))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream.append(ctx, "", []byte("d"))
+	stream.append(ctx, "", []byte("e"))
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		stream.append(ctx, "", []byte("f"))
+	}()
+	cancel()
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("blocked append did not return after cancellation")
+	}
+}
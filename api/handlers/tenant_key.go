@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/api"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// TenantKeyHandler 管理租户 API Key（签发、更新、轮换、吊销）。
+// 绑定租户的调用方只能管理本租户的密钥；未绑定租户的管理员可指定任意租户。
+type TenantKeyHandler struct {
+	BaseHandler[appservice.TenantKeyService]
+	auditRecorder audit.Recorder
+}
+
+func NewTenantKeyHandler(service appservice.TenantKeyService, logger *zap.Logger) *TenantKeyHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TenantKeyHandler{BaseHandler: NewBaseHandler(service, logger)}
+}
+
+// SetAuditRecorder 设置统一审计记录器，密钥的签发、更新、轮换与吊销会写入审计轨迹
+func (h *TenantKeyHandler) SetAuditRecorder(recorder audit.Recorder) {
+	h.auditRecorder = recorder
+}
+
+// createTenantKeyRequest 签发密钥请求体
+type createTenantKeyRequest struct {
+	TenantID             string     `json:"tenant_id"`
+	Name                 string     `json:"name"`
+	Scopes               []string   `json:"scopes"`
+	RateLimitRPM         int        `json:"rate_limit_rpm"`
+	MonthlyRequestBudget int64      `json:"monthly_request_budget"`
+	ExpiresAt            *time.Time `json:"expires_at"`
+}
+
+// updateTenantKeyRequest 更新密钥请求体，省略的字段保持不变
+type updateTenantKeyRequest struct {
+	Name                 *string    `json:"name"`
+	Scopes               []string   `json:"scopes"`
+	RateLimitRPM         *int       `json:"rate_limit_rpm"`
+	MonthlyRequestBudget *int64     `json:"monthly_request_budget"`
+	ExpiresAt            *time.Time `json:"expires_at"`
+}
+
+// rotateTenantKeyRequest 轮换请求体；宽限期内旧密钥仍可使用
+type rotateTenantKeyRequest struct {
+	GracePeriodSeconds int64 `json:"grace_period_seconds"`
+}
+
+// HandleList GET /api/v1/keys
+func (h *TenantKeyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	tenantID, svcErr := resolveKeyTenant(r, r.URL.Query().Get("tenant_id"))
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	keys, svcErr := service.List(tenantID)
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	WriteSuccess(w, keys)
+}
+
+// HandleGet GET /api/v1/keys/{id}
+func (h *TenantKeyHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	keyID, ok := pathUintID(r, "id", 3)
+	if !ok {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid key ID", h.logger)
+		return
+	}
+	view, svcErr := service.Get(callerKeyTenant(r), keyID)
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	WriteSuccess(w, view)
+}
+
+// HandleCreate POST /api/v1/keys，明文密钥仅在响应中返回一次
+func (h *TenantKeyHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	var req createTenantKeyRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	tenantID, svcErr := resolveKeyTenant(r, req.TenantID)
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	issued, svcErr := service.Create(r.Context(), appservice.TenantKeyInput{
+		TenantID:             tenantID,
+		Name:                 req.Name,
+		Scopes:               req.Scopes,
+		RateLimitRPM:         req.RateLimitRPM,
+		MonthlyRequestBudget: req.MonthlyRequestBudget,
+		ExpiresAt:            req.ExpiresAt,
+	})
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	h.recordAudit(r, "tenant_api_key.create", issued.TenantKeyView)
+	WriteJSON(w, http.StatusCreated, api.Response{Success: true, Data: issued, Timestamp: time.Now(), RequestID: w.Header().Get("X-Request-ID")})
+}
+
+// HandleUpdate PATCH /api/v1/keys/{id}
+func (h *TenantKeyHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPatch, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	keyID, ok := pathUintID(r, "id", 3)
+	if !ok {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid key ID", h.logger)
+		return
+	}
+	var req updateTenantKeyRequest
+	if !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	view, svcErr := service.Update(r.Context(), callerKeyTenant(r), keyID, appservice.TenantKeyUpdate{
+		Name:                 req.Name,
+		Scopes:               req.Scopes,
+		RateLimitRPM:         req.RateLimitRPM,
+		MonthlyRequestBudget: req.MonthlyRequestBudget,
+		ExpiresAt:            req.ExpiresAt,
+	})
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	h.recordAudit(r, "tenant_api_key.update", *view)
+	WriteSuccess(w, view)
+}
+
+// HandleRotate POST /api/v1/keys/{id}/rotate，返回新密钥明文
+func (h *TenantKeyHandler) HandleRotate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	keyID, ok := pathUintID(r, "id", 3)
+	if !ok {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid key ID", h.logger)
+		return
+	}
+	var req rotateTenantKeyRequest
+	if r.ContentLength != 0 && !ValidateRequest(w, r, &req, h.logger) {
+		return
+	}
+	issued, svcErr := service.Rotate(r.Context(), callerKeyTenant(r), keyID, time.Duration(req.GracePeriodSeconds)*time.Second)
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	h.recordAudit(r, "tenant_api_key.rotate", issued.TenantKeyView)
+	WriteSuccess(w, issued)
+}
+
+// HandleRevoke POST /api/v1/keys/{id}/revoke
+func (h *TenantKeyHandler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tenant api key")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	keyID, ok := pathUintID(r, "id", 3)
+	if !ok {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid key ID", h.logger)
+		return
+	}
+	view, svcErr := service.Revoke(r.Context(), callerKeyTenant(r), keyID)
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	h.recordAudit(r, "tenant_api_key.revoke", *view)
+	WriteSuccess(w, view)
+}
+
+func (h *TenantKeyHandler) recordAudit(r *http.Request, action string, view appservice.TenantKeyView) {
+	if h.auditRecorder == nil {
+		return
+	}
+	_, err := h.auditRecorder.Record(r.Context(), audit.Event{
+		Category:   audit.CategoryAPIKey,
+		Action:     action,
+		Resource:   "tenant_api_key",
+		ResourceID: strconv.FormatUint(uint64(view.ID), 10),
+		After:      view,
+		Metadata:   map[string]any{"tenant_id": view.TenantID, "remote_addr": r.RemoteAddr},
+	})
+	if err != nil {
+		h.logger.Warn("failed to record tenant api key audit event", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// callerKeyTenant 返回调用方绑定的租户，未绑定时为空
+func callerKeyTenant(r *http.Request) string {
+	tenantID, _ := types.TenantID(r.Context())
+	return tenantID
+}
+
+// resolveKeyTenant 确定操作的目标租户：绑定租户的调用方不能指定其他租户
+func resolveKeyTenant(r *http.Request, requested string) (string, *types.Error) {
+	requested = strings.TrimSpace(requested)
+	caller := callerKeyTenant(r)
+	if caller == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != caller {
+		return "", types.NewError(types.ErrForbidden, "cannot manage API keys of another tenant")
+	}
+	return caller, nil
+}
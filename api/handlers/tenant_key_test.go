@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func setupTenantKeyHandler(t *testing.T) (*TenantKeyHandler, *appservice.DefaultTenantKeyService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&tenantkey.Key{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+	service := appservice.NewDefaultTenantKeyService(tenantkey.NewGormStore(db), zap.NewNop())
+	return NewTenantKeyHandler(service, zap.NewNop()), service
+}
+
+func serveTenantKey(ctx context.Context, handler http.HandlerFunc, method, target string, body any, id string) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, bytes.NewReader(payload)).WithContext(ctx)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if id != "" {
+		r.SetPathValue("id", id)
+	}
+	handler(w, r)
+	return w
+}
+
+func decodeIssuedTenantKey(t *testing.T, w *httptest.ResponseRecorder) appservice.IssuedTenantKey {
+	t.Helper()
+	var resp struct {
+		Success bool                       `json:"success"`
+		Data    appservice.IssuedTenantKey `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.True(t, resp.Success)
+	return resp.Data
+}
+
+func TestTenantKeyHandler_IssueRotateRevoke(t *testing.T) {
+	handler, service := setupTenantKeyHandler(t)
+	ctx := context.Background()
+
+	w := serveTenantKey(ctx, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"tenant_id":              "tenant-a",
+		"name":                   "ci",
+		"scopes":                 []string{"chat:write", "agents:execute"},
+		"rate_limit_rpm":         60,
+		"monthly_request_budget": 1000,
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	issued := decodeIssuedTenantKey(t, w)
+	assert.Equal(t, "tenant-a", issued.TenantID)
+	assert.Equal(t, []string{"agents:execute", "chat:write"}, issued.Scopes)
+	assert.Equal(t, appservice.TenantKeyStatusActive, issued.Status)
+	require.NotEmpty(t, issued.Key)
+	assert.NotContains(t, w.Body.String(), tenantkey.Hash(issued.Key), "hash is never exposed")
+
+	principal, err := service.ResolveAPIKey(ctx, issued.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", principal.TenantID)
+	assert.Equal(t, 60, principal.RateLimitRPM)
+
+	w = serveTenantKey(ctx, handler.HandleList, http.MethodGet, "/api/v1/keys?tenant_id=tenant-a", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), issued.Key, "secrets are only returned once")
+
+	id := "1"
+	w = serveTenantKey(ctx, handler.HandleRotate, http.MethodPost, "/api/v1/keys/1/rotate", map[string]any{"grace_period_seconds": 3600}, id)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	rotated := decodeIssuedTenantKey(t, w)
+	require.NotNil(t, rotated.RotatedFromID)
+	assert.Equal(t, issued.ID, *rotated.RotatedFromID)
+	assert.Equal(t, issued.Scopes, rotated.Scopes)
+	assert.NotEqual(t, issued.Key, rotated.Key)
+
+	_, err = service.ResolveAPIKey(ctx, issued.Key)
+	assert.NoError(t, err, "old key stays valid during the grace period")
+	_, err = service.ResolveAPIKey(ctx, rotated.Key)
+	assert.NoError(t, err)
+
+	w = serveTenantKey(ctx, handler.HandleRevoke, http.MethodPost, "/api/v1/keys/1/revoke", nil, id)
+	require.Equal(t, http.StatusOK, w.Code)
+	_, err = service.ResolveAPIKey(ctx, issued.Key)
+	var typedErr *types.Error
+	require.ErrorAs(t, err, &typedErr)
+	assert.Equal(t, types.ErrAuthentication, typedErr.Code)
+
+	w = serveTenantKey(ctx, handler.HandleRotate, http.MethodPost, "/api/v1/keys/1/rotate", nil, id)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "revoked keys cannot be rotated")
+}
+
+func TestTenantKeyHandler_TenantBoundCallers(t *testing.T) {
+	handler, _ := setupTenantKeyHandler(t)
+	admin := context.Background()
+
+	w := serveTenantKey(admin, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"tenant_id": "tenant-b",
+		"scopes":    []string{"rag:read"},
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	caller := types.WithScopes(types.WithTenantID(context.Background(), "tenant-a"), []string{tenantkey.ScopeKeysAdmin, tenantkey.ScopeChatRead})
+
+	w = serveTenantKey(caller, handler.HandleGet, http.MethodGet, "/api/v1/keys/1", nil, "1")
+	assert.Equal(t, http.StatusNotFound, w.Code, "keys of other tenants are hidden")
+
+	w = serveTenantKey(caller, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"tenant_id": "tenant-b",
+		"scopes":    []string{"chat:read"},
+	}, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveTenantKey(caller, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"scopes": []string{"chat:write"},
+	}, "")
+	assert.Equal(t, http.StatusForbidden, w.Code, "callers cannot grant scopes they do not hold")
+
+	w = serveTenantKey(caller, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"scopes": []string{"chat:read"},
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "tenant-a", decodeIssuedTenantKey(t, w).TenantID)
+
+	w = serveTenantKey(caller, handler.HandleUpdate, http.MethodPatch, "/api/v1/keys/2", map[string]any{"rate_limit_rpm": 10}, "2")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"rate_limit_rpm":10`)
+
+	w = serveTenantKey(caller, handler.HandleRevoke, http.MethodPost, "/api/v1/keys/1/revoke", nil, "1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTenantKeyHandler_RotateRequiresCallerScopes(t *testing.T) {
+	handler, service := setupTenantKeyHandler(t)
+	admin := context.Background()
+
+	w := serveTenantKey(admin, handler.HandleCreate, http.MethodPost, "/api/v1/keys", map[string]any{
+		"tenant_id": "tenant-a",
+		"scopes":    []string{tenantkey.ScopeKeysAdmin, tenantkey.ScopeChatWrite},
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	issued := decodeIssuedTenantKey(t, w)
+
+	caller := types.WithScopes(types.WithTenantID(context.Background(), "tenant-a"), []string{tenantkey.ScopeKeysAdmin})
+	w = serveTenantKey(caller, handler.HandleRotate, http.MethodPost, "/api/v1/keys/1/rotate", nil, "1")
+	assert.Equal(t, http.StatusForbidden, w.Code, "rotating would mint a key with scopes the caller does not hold")
+
+	_, err := service.ResolveAPIKey(admin, issued.Key)
+	assert.NoError(t, err, "a rejected rotation leaves the original key untouched")
+
+	w = serveTenantKey(admin, handler.HandleGet, http.MethodGet, "/api/v1/keys/2", nil, "2")
+	assert.Equal(t, http.StatusNotFound, w.Code, "no replacement key is issued")
+}
+
+func TestTenantKeyHandler_RejectsInvalidInput(t *testing.T) {
+	handler, _ := setupTenantKeyHandler(t)
+	ctx := context.Background()
+
+	cases := []map[string]any{
+		{"scopes": []string{"chat:read"}},
+		{"tenant_id": "tenant-a"},
+		{"tenant_id": "tenant-a", "scopes": []string{"chat:delete"}},
+		{"tenant_id": "tenant-a", "scopes": []string{"chat:read"}, "rate_limit_rpm": -1},
+	}
+	for _, body := range cases {
+		w := serveTenantKey(ctx, handler.HandleCreate, http.MethodPost, "/api/v1/keys", body, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	w := serveTenantKey(ctx, handler.HandleGet, http.MethodGet, "/api/v1/keys/abc", nil, "abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	unavailable := NewTenantKeyHandler(nil, zap.NewNop())
+	w = serveTenantKey(ctx, unavailable.HandleList, http.MethodGet, "/api/v1/keys", nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
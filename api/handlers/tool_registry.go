@@ -11,7 +11,8 @@ import (
 	"go.uber.org/zap"
 )
 
-// ToolRegistryHandler manages DB-backed hosted tool registrations.
+// ToolRegistryHandler manages DB-backed hosted tool registrations, including
+// HTTP-backed tools declared at runtime.
 type ToolRegistryHandler struct {
 	BaseHandler[appservice.ToolRegistryService]
 }
@@ -26,8 +27,10 @@ func NewToolRegistryHandler(service appservice.ToolRegistryService, logger *zap.
 type createToolRegistrationRequest struct {
 	Name        string          `json:"name" binding:"required"`
 	Description string          `json:"description"`
-	Target      string          `json:"target" binding:"required"`
+	Kind        string          `json:"kind"`
+	Target      string          `json:"target"`
 	Parameters  json.RawMessage `json:"parameters"`
+	HTTPConfig  json.RawMessage `json:"http_config"`
 	Enabled     *bool           `json:"enabled"`
 }
 
@@ -36,6 +39,7 @@ type updateToolRegistrationRequest struct {
 	Description *string          `json:"description"`
 	Target      *string          `json:"target"`
 	Parameters  *json.RawMessage `json:"parameters"`
+	HTTPConfig  *json.RawMessage `json:"http_config"`
 	Enabled     *bool            `json:"enabled"`
 }
 
@@ -91,11 +95,13 @@ func (h *ToolRegistryHandler) HandleCreate(w http.ResponseWriter, r *http.Reques
 	if !ValidateRequest(w, r, &req, h.logger) {
 		return
 	}
-	row, svcErr := service.Create(appservice.CreateToolRegistrationInput{
+	row, svcErr := service.Create(r.Context(), appservice.CreateToolRegistrationInput{
 		Name:        req.Name,
 		Description: req.Description,
+		Kind:        req.Kind,
 		Target:      req.Target,
 		Parameters:  req.Parameters,
+		HTTPConfig:  req.HTTPConfig,
 		Enabled:     req.Enabled,
 	})
 	if svcErr != nil {
@@ -130,6 +136,7 @@ func (h *ToolRegistryHandler) HandleUpdate(w http.ResponseWriter, r *http.Reques
 		Description: req.Description,
 		Target:      req.Target,
 		Parameters:  req.Parameters,
+		HTTPConfig:  req.HTTPConfig,
 		Enabled:     req.Enabled,
 	})
 	if svcErr != nil {
@@ -141,6 +148,44 @@ func (h *ToolRegistryHandler) HandleUpdate(w http.ResponseWriter, r *http.Reques
 	WriteSuccess(w, row)
 }
 
+// HandleEnable re-enables a registration and makes it available to agents.
+func (h *ToolRegistryHandler) HandleEnable(w http.ResponseWriter, r *http.Request) {
+	h.handleSetEnabled(w, r, true)
+}
+
+// HandleDisable disables a registration without deleting it.
+func (h *ToolRegistryHandler) HandleDisable(w http.ResponseWriter, r *http.Request) {
+	h.handleSetEnabled(w, r, false)
+}
+
+func (h *ToolRegistryHandler) handleSetEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if !requireMethod(w, r, http.MethodPost, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("tool registry")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	id, ok := extractToolRegistrationID(r)
+	if !ok {
+		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid or missing registration ID", h.logger)
+		return
+	}
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	row, svcErr := service.SetEnabled(r.Context(), id, enabled)
+	if svcErr != nil {
+		logToolRequestWarn(h.logger, r, "tool_registry", action, "failed", "tool registry request completed", zap.Error(svcErr), zap.Uint("registration_id", id))
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	logToolRequestInfo(h.logger, r, "tool_registry", action, "success", "tool registry request completed", zap.Uint("registration_id", id))
+	WriteSuccess(w, row)
+}
+
 func (h *ToolRegistryHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodDelete, h.logger) {
 		return
@@ -155,7 +200,7 @@ func (h *ToolRegistryHandler) HandleDelete(w http.ResponseWriter, r *http.Reques
 		WriteErrorMessage(w, http.StatusBadRequest, types.ErrInvalidRequest, "invalid or missing registration ID", h.logger)
 		return
 	}
-	if err := service.Delete(id); err != nil {
+	if err := service.Delete(r.Context(), id); err != nil {
 		logToolRequestWarn(h.logger, r, "tool_registry", "delete", "failed", "tool registry request completed", zap.Error(err), zap.Uint("registration_id", id))
 		WriteError(w, err, h.logger)
 		return
@@ -173,7 +218,7 @@ func (h *ToolRegistryHandler) HandleReload(w http.ResponseWriter, r *http.Reques
 		WriteError(w, svcErr, h.logger)
 		return
 	}
-	if err := service.Reload(); err != nil {
+	if err := service.Reload(r.Context()); err != nil {
 		logToolRequestWarn(h.logger, r, "tool_registry", "reload", "failed", "tool registry request completed", zap.Error(err))
 		WriteError(w, err, h.logger)
 		return
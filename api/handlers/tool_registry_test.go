@@ -10,6 +10,8 @@ import (
 
 	"github.com/BaSui01/agentflow/agent/integration/hosted"
 	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+	"github.com/BaSui01/agentflow/types"
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -145,3 +147,117 @@ func TestToolRegistryHandler_Create_AuditLogFields(t *testing.T) {
 	assert.Equal(t, "create", fields["action"])
 	assert.Equal(t, "success", fields["result"])
 }
+
+func TestToolRegistryHandler_CreateHTTPToolAndDisable(t *testing.T) {
+	db := setupToolRegistryDB(t)
+	runtime := &toolRuntimeStub{targets: []string{"retrieval"}}
+	handler := NewToolRegistryHandler(appservice.NewDefaultToolRegistryService(hosted.NewGormToolRegistryStore(db), runtime), zap.NewNop())
+
+	body := []byte(`{
+		"name": "weather",
+		"kind": "http",
+		"parameters": {"type":"object","properties":{"city":{"type":"string"}}},
+		"http_config": {
+			"method": "get",
+			"url": "https://api.example.com/weather/{{city}}",
+			"headers": {"Authorization": "Bearer {{secret:WEATHER_KEY}}"}
+		}
+	}`)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tools", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	handler.HandleCreate(w, r)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 1, runtime.reloadCalls)
+
+	row, err := hosted.NewGormToolRegistryStore(db).GetByName("weather")
+	require.NoError(t, err)
+	assert.Equal(t, hosted.ToolRegistrationKindHTTP, row.Kind)
+	assert.Empty(t, row.Target)
+	tmpl, err := hosted.ParseHTTPToolTemplate(row.HTTPConfig)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, tmpl.Method)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/tools/1/disable", nil)
+	r.SetPathValue("id", "1")
+	handler.HandleDisable(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 2, runtime.reloadCalls)
+	row, err = hosted.NewGormToolRegistryStore(db).GetByID(1)
+	require.NoError(t, err)
+	assert.False(t, row.Enabled)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/tools/1/enable", nil)
+	r.SetPathValue("id", "1")
+	handler.HandleEnable(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	row, err = hosted.NewGormToolRegistryStore(db).GetByID(1)
+	require.NoError(t, err)
+	assert.True(t, row.Enabled)
+}
+
+func TestToolRegistryHandler_CreateHTTPToolRejectsInvalidConfig(t *testing.T) {
+	db := setupToolRegistryDB(t)
+	runtime := &toolRuntimeStub{targets: []string{"retrieval"}}
+	handler := NewToolRegistryHandler(appservice.NewDefaultToolRegistryService(hosted.NewGormToolRegistryStore(db), runtime), zap.NewNop())
+
+	for _, body := range []string{
+		`{"name":"weather","kind":"http"}`,
+		`{"name":"weather","kind":"http","http_config":{"method":"GET","url":"file:///etc/passwd"}}`,
+		`{"name":"weather","kind":"http","parameters":{"type":"object","properties":{"city":{}}},"http_config":{"method":"GET","url":"https://api.example.com/{{country}}"}}`,
+		`{"name":"weather","kind":"http","target":"retrieval","http_config":{"method":"GET","url":"https://api.example.com"}}`,
+		`{"name":"weather","kind":"grpc","target":"retrieval"}`,
+		`{"name":"weather","kind":"http","http_config":{"method":"GET","url":"http://169.254.169.254/latest/meta-data/"}}`,
+		`{"name":"weather","kind":"http","http_config":{"method":"GET","url":"http://10.1.2.3:8500/v1/kv"}}`,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/tools", bytes.NewReader([]byte(body)))
+		r.Header.Set("Content-Type", "application/json")
+		handler.HandleCreate(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	assert.Equal(t, 0, runtime.reloadCalls)
+}
+
+func TestToolRegistryHandler_HTTPToolsRequireOperator(t *testing.T) {
+	db := setupToolRegistryDB(t)
+	runtime := &toolRuntimeStub{targets: []string{"retrieval"}}
+	handler := NewToolRegistryHandler(appservice.NewDefaultToolRegistryService(hosted.NewGormToolRegistryStore(db), runtime), zap.NewNop())
+
+	body := `{"name":"weather","kind":"http","http_config":{"method":"GET","url":"https://api.example.com","headers":{"X-Key":"{{secret:WEATHER_KEY}}"}}}`
+	create := func(ctx context.Context) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/tools", bytes.NewReader([]byte(body))).WithContext(ctx)
+		r.Header.Set("Content-Type", "application/json")
+		handler.HandleCreate(w, r)
+		return w
+	}
+
+	tenantAdmin := types.WithScopes(types.WithTenantID(context.Background(), "tenant-a"), []string{tenantkey.ScopeToolsAdmin})
+	w := create(tenantAdmin)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	toolsAdmin := types.WithScopes(context.Background(), []string{tenantkey.ScopeToolsAdmin})
+	w = create(toolsAdmin)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.Equal(t, 0, runtime.reloadCalls)
+
+	operator := types.WithScopes(context.Background(), []string{tenantkey.ScopeAdmin})
+	w = create(operator)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tools/1/disable", nil).WithContext(tenantAdmin)
+	r.SetPathValue("id", "1")
+	handler.HandleDisable(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// alias registrations stay available to tenant tool admins
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/tools", bytes.NewReader([]byte(`{"name":"search","target":"retrieval"}`))).WithContext(tenantAdmin)
+	r.Header.Set("Content-Type", "application/json")
+	handler.HandleCreate(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
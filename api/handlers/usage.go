@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// usageCSVHeader 用量 CSV 导出的列，顺序与 usageCSVRow 一致
+var usageCSVHeader = []string{
+	"day", "tenant_id", "provider", "model", "requests", "cache_hits",
+	"prompt_tokens", "completion_tokens", "total_tokens", "cached_tokens",
+	"cost_usd", "cache_savings_usd",
+}
+
+// UsageHandler 提供按租户/模型/日聚合的用量与账单报表
+type UsageHandler struct {
+	BaseHandler[usecase.UsageQueryService]
+}
+
+func NewUsageHandler(service usecase.UsageQueryService, logger *zap.Logger) *UsageHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &UsageHandler{
+		BaseHandler: NewBaseHandler(service, logger),
+	}
+}
+
+// HandleUsage GET /v1/usage
+// 查询参数：tenant_id、provider、model、from、to（YYYY-MM-DD，闭区间）；
+// format=csv 或 Accept: text/csv 时以 CSV 导出。绑定租户的调用方只能查询本租户。
+func (h *UsageHandler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet, h.logger) {
+		return
+	}
+	service, svcErr := h.currentServiceOrUnavailable("usage ledger")
+	if svcErr != nil {
+		WriteError(w, svcErr, h.logger)
+		return
+	}
+	q := r.URL.Query()
+	tenantID, err := resolveUsageTenant(r, q.Get("tenant_id"))
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	report, err := service.Query(r.Context(), usecase.UsageQueryInput{
+		TenantID: tenantID,
+		Provider: q.Get("provider"),
+		Model:    q.Get("model"),
+		From:     q.Get("from"),
+		To:       q.Get("to"),
+	})
+	if err != nil {
+		WriteError(w, err, h.logger)
+		return
+	}
+	if wantsUsageCSV(r) {
+		h.writeUsageCSV(w, report)
+		return
+	}
+	WriteSuccess(w, report)
+}
+
+func (h *UsageHandler) writeUsageCSV(w http.ResponseWriter, report *usecase.UsageReportView) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="usage_`+report.From+`_`+report.To+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(usageCSVHeader); err != nil {
+		h.logger.Warn("failed to write usage csv", zap.Error(err))
+		return
+	}
+	for _, b := range report.Buckets {
+		if err := cw.Write(usageCSVRow(b)); err != nil {
+			h.logger.Warn("failed to write usage csv", zap.Error(err))
+			return
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Warn("failed to write usage csv", zap.Error(err))
+	}
+}
+
+func usageCSVRow(b usecase.UsageBucketView) []string {
+	return []string{
+		b.Day, b.TenantID, b.Provider, b.Model,
+		strconv.FormatInt(b.Requests, 10),
+		strconv.FormatInt(b.CacheHits, 10),
+		strconv.FormatInt(b.PromptTokens, 10),
+		strconv.FormatInt(b.CompletionTokens, 10),
+		strconv.FormatInt(b.TotalTokens, 10),
+		strconv.FormatInt(b.CachedTokens, 10),
+		strconv.FormatFloat(b.CostUSD, 'f', 6, 64),
+		strconv.FormatFloat(b.CacheSavingsUSD, 'f', 6, 64),
+	}
+}
+
+func wantsUsageCSV(r *http.Request) bool {
+	if format := strings.TrimSpace(r.URL.Query().Get("format")); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// resolveUsageTenant 确定查询的租户：绑定租户的调用方不能查询其他租户的用量
+func resolveUsageTenant(r *http.Request, requested string) (string, *types.Error) {
+	requested = strings.TrimSpace(requested)
+	caller, _ := types.TenantID(r.Context())
+	if caller == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != caller {
+		return "", types.NewError(types.ErrForbidden, "cannot read usage of another tenant")
+	}
+	return caller, nil
+}
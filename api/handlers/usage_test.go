@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeUsageReader struct {
+	last    usecase.UsageFilter
+	buckets []usecase.UsageBucketView
+}
+
+func (f *fakeUsageReader) QueryUsage(_ context.Context, filter usecase.UsageFilter) ([]usecase.UsageBucketView, error) {
+	f.last = filter
+	return f.buckets, nil
+}
+
+func newTestUsageHandler() (*UsageHandler, *fakeUsageReader) {
+	reader := &fakeUsageReader{buckets: []usecase.UsageBucketView{
+		{Day: "2026-03-01", TenantID: "t1", Provider: "openai", Model: "gpt-5.4", Requests: 2, CacheHits: 1, TotalTokens: 300, CostUSD: 0.25, CacheSavingsUSD: 0.01},
+		{Day: "2026-03-02", TenantID: "t1", Provider: "openai", Model: "gpt-5.4", Requests: 1, TotalTokens: 100, CostUSD: 0.5},
+	}}
+	return NewUsageHandler(usecase.NewDefaultUsageQueryService(reader), zap.NewNop()), reader
+}
+
+func TestUsageHandler_JSONReport(t *testing.T) {
+	handler, reader := newTestUsageHandler()
+	req := httptest.NewRequest(http.MethodGet, "/v1/usage?tenant_id=t1&model=gpt-5.4&from=2026-03-01&to=2026-03-02", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleUsage(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "t1", reader.last.TenantID)
+	assert.Equal(t, "gpt-5.4", reader.last.Model)
+	assert.Equal(t, "2026-03-02", reader.last.To.Format("2006-01-02"))
+	body := rec.Body.String()
+	assert.Contains(t, body, `"from":"2026-03-01"`)
+	assert.Contains(t, body, `"totals":{"requests":3,"cache_hits":1`)
+	assert.Contains(t, body, `"cost_usd":0.75`)
+}
+
+func TestUsageHandler_CSVExport(t *testing.T) {
+	handler, _ := newTestUsageHandler()
+	req := httptest.NewRequest(http.MethodGet, "/v1/usage?from=2026-03-01&to=2026-03-02", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	handler.HandleUsage(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "usage_2026-03-01_2026-03-02.csv")
+	rows, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, usageCSVHeader, rows[0])
+	assert.Equal(t, []string{"2026-03-01", "t1", "openai", "gpt-5.4", "2", "1", "0", "0", "300", "0", "0.250000", "0.010000"}, rows[1])
+}
+
+func TestUsageHandler_TenantBoundCaller(t *testing.T) {
+	handler, reader := newTestUsageHandler()
+	ctx := types.WithTenantID(context.Background(), "t1")
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/usage", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handler.HandleUsage(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "t1", reader.last.TenantID, "tenant-bound callers default to their own tenant")
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/usage?tenant_id=t2", nil).WithContext(ctx)
+	rec = httptest.NewRecorder()
+	handler.HandleUsage(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestUsageHandler_RejectsInvalidQuery(t *testing.T) {
+	handler, _ := newTestUsageHandler()
+	for _, query := range []string{"from=03/01/2026", "from=2026-03-02&to=2026-03-01", "from=2024-01-01&to=2026-01-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/usage?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.HandleUsage(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	unconfigured := NewUsageHandler(nil, zap.NewNop())
+	rec := httptest.NewRecorder()
+	unconfigured.HandleUsage(rec, httptest.NewRequest(http.MethodGet, "/v1/usage", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
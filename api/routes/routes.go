@@ -27,7 +27,12 @@ func RegisterChat(mux *http.ServeMux, chatHandler *handlers.ChatHandler, logger
 	mux.HandleFunc("GET /api/v1/chat/capabilities", chatHandler.HandleCapabilities)
 	mux.HandleFunc("POST /api/v1/chat/completions", chatHandler.HandleCompletion)
 	mux.HandleFunc("POST /api/v1/chat/completions/stream", chatHandler.HandleStream)
+	mux.HandleFunc("GET /api/v1/chat/completions/stream", chatHandler.HandleStream) // Last-Event-ID 断线续传
 	mux.HandleFunc("POST /v1/chat/completions", chatHandler.HandleOpenAICompatChatCompletions)
+	mux.HandleFunc("POST /v1/chat/batch", chatHandler.HandleBatchSubmit)
+	mux.HandleFunc("GET /v1/jobs/{id}", chatHandler.HandleGetJob)
+	mux.HandleFunc("POST /v1/embeddings", chatHandler.HandleOpenAICompatEmbeddings)
+	mux.HandleFunc("GET /v1/models", chatHandler.HandleOpenAICompatModels)
 	mux.HandleFunc("POST /v1/responses", chatHandler.HandleOpenAICompatResponses)
 	mux.HandleFunc("POST /v1/messages", chatHandler.HandleAnthropicCompatMessages)
 	logger.Info("Chat API routes registered")
@@ -39,14 +44,30 @@ func RegisterAgent(mux *http.ServeMux, agentHandler *handlers.AgentHandler, logg
 	}
 	mux.HandleFunc("GET /api/v1/agents", agentHandler.HandleListAgents)
 	mux.HandleFunc("GET /api/v1/agents/{id}", agentHandler.HandleGetAgent)
+	mux.HandleFunc("GET /api/v1/agents/{id}/traces/{trace_id}/graph", agentHandler.HandleDecisionGraph)
 	mux.HandleFunc("GET /api/v1/agents/capabilities", agentHandler.HandleCapabilities)
 	mux.HandleFunc("POST /api/v1/agents/execute", agentHandler.HandleExecuteAgent)
 	mux.HandleFunc("POST /api/v1/agents/execute/stream", agentHandler.HandleAgentStream)
 	mux.HandleFunc("POST /api/v1/agents/execute/interrupt", agentHandler.HandleAgentInterrupt)
 	mux.HandleFunc("GET /api/v1/agents/health", agentHandler.HandleAgentHealth)
+	mux.HandleFunc("GET /v1/chat/ws", agentHandler.HandleChatWebSocket)
 	logger.Info("Agent API routes registered")
 }
 
+// RegisterAgentDefinitions registers CRUD routes for declarative agent definitions.
+func RegisterAgentDefinitions(mux *http.ServeMux, definitionHandler *handlers.AgentDefinitionHandler, logger *zap.Logger) {
+	if definitionHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/agents/definitions", definitionHandler.HandleList)
+	mux.HandleFunc("POST /api/v1/agents/definitions", definitionHandler.HandleCreate)
+	mux.HandleFunc("GET /api/v1/agents/definitions/{id}", definitionHandler.HandleGet)
+	mux.HandleFunc("PUT /api/v1/agents/definitions/{id}", definitionHandler.HandleUpdate)
+	mux.HandleFunc("DELETE /api/v1/agents/definitions/{id}", definitionHandler.HandleDelete)
+	mux.HandleFunc("GET /api/v1/agents/definitions/{id}/versions", definitionHandler.HandleListVersions)
+	logger.Info("Agent definition routes registered")
+}
+
 func RegisterProvider(mux *http.ServeMux, apiKeyHandler *handlers.APIKeyHandler, logger *zap.Logger) {
 	if apiKeyHandler == nil {
 		return
@@ -60,6 +81,20 @@ func RegisterProvider(mux *http.ServeMux, apiKeyHandler *handlers.APIKeyHandler,
 	logger.Info("Provider API key routes registered")
 }
 
+// RegisterTenantKeys registers tenant API key management routes.
+func RegisterTenantKeys(mux *http.ServeMux, keyHandler *handlers.TenantKeyHandler, logger *zap.Logger) {
+	if keyHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/keys", keyHandler.HandleList)
+	mux.HandleFunc("POST /api/v1/keys", keyHandler.HandleCreate)
+	mux.HandleFunc("GET /api/v1/keys/{id}", keyHandler.HandleGet)
+	mux.HandleFunc("PATCH /api/v1/keys/{id}", keyHandler.HandleUpdate)
+	mux.HandleFunc("POST /api/v1/keys/{id}/rotate", keyHandler.HandleRotate)
+	mux.HandleFunc("POST /api/v1/keys/{id}/revoke", keyHandler.HandleRevoke)
+	logger.Info("Tenant API key routes registered")
+}
+
 func RegisterTools(
 	mux *http.ServeMux,
 	toolHandler *handlers.ToolRegistryHandler,
@@ -77,6 +112,8 @@ func RegisterTools(
 		mux.HandleFunc("POST /api/v1/tools/reload", toolHandler.HandleReload)
 		mux.HandleFunc("PUT /api/v1/tools/{id}", toolHandler.HandleUpdate)
 		mux.HandleFunc("DELETE /api/v1/tools/{id}", toolHandler.HandleDelete)
+		mux.HandleFunc("POST /api/v1/tools/{id}/enable", toolHandler.HandleEnable)
+		mux.HandleFunc("POST /api/v1/tools/{id}/disable", toolHandler.HandleDisable)
 	}
 	if providerHandler != nil {
 		mux.HandleFunc("GET /api/v1/tools/providers", providerHandler.HandleList)
@@ -105,6 +142,22 @@ func RegisterAuthorization(mux *http.ServeMux, auditHandler *handlers.Authorizat
 	logger.Info("Authorization routes registered")
 }
 
+func RegisterGuardrails(mux *http.ServeMux, auditHandler *handlers.GuardrailAuditHandler, logger *zap.Logger) {
+	if auditHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/guardrails/audit", auditHandler.HandleList)
+	logger.Info("Guardrail routes registered")
+}
+
+func RegisterAudit(mux *http.ServeMux, auditHandler *handlers.AuditTrailHandler, logger *zap.Logger) {
+	if auditHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/audit", auditHandler.HandleList)
+	logger.Info("Audit routes registered")
+}
+
 func RegisterMultimodal(mux *http.ServeMux, multimodalHandler *handlers.MultimodalHandler, logger *zap.Logger) {
 	if multimodalHandler == nil {
 		return
@@ -152,6 +205,18 @@ func RegisterWorkflow(mux *http.ServeMux, workflowHandler *handlers.WorkflowHand
 	logger.Info("Workflow API routes registered")
 }
 
+func RegisterExternalTasks(mux *http.ServeMux, externalTaskHandler *handlers.ExternalTaskHandler, logger *zap.Logger) {
+	if externalTaskHandler == nil {
+		return
+	}
+	mux.HandleFunc("POST /api/v1/workflows/tasks/claim", externalTaskHandler.HandleClaim)
+	mux.HandleFunc("GET /api/v1/workflows/tasks/{id}", externalTaskHandler.HandleGet)
+	mux.HandleFunc("POST /api/v1/workflows/tasks/{id}/heartbeat", externalTaskHandler.HandleHeartbeat)
+	mux.HandleFunc("POST /api/v1/workflows/tasks/{id}/complete", externalTaskHandler.HandleComplete)
+	mux.HandleFunc("POST /api/v1/workflows/tasks/{id}/fail", externalTaskHandler.HandleFail)
+	logger.Info("Workflow external task API routes registered")
+}
+
 func RegisterCost(mux *http.ServeMux, costHandler *handlers.CostHandler, logger *zap.Logger) {
 	if costHandler == nil {
 		return
@@ -162,6 +227,54 @@ func RegisterCost(mux *http.ServeMux, costHandler *handlers.CostHandler, logger
 	logger.Info("Cost API routes registered")
 }
 
+func RegisterUsage(mux *http.ServeMux, usageHandler *handlers.UsageHandler, logger *zap.Logger) {
+	if usageHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /v1/usage", usageHandler.HandleUsage)
+	logger.Info("Usage API routes registered")
+}
+
+func RegisterRuns(mux *http.ServeMux, runHandler *handlers.RunHandler, logger *zap.Logger) {
+	if runHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /v1/runs/{id}", runHandler.HandleGet)
+	mux.HandleFunc("GET /v1/runs/{id}/events", runHandler.HandleEvents)
+	logger.Info("Run inspection API routes registered")
+}
+
+func RegisterInterrupts(mux *http.ServeMux, interruptHandler *handlers.InterruptHandler, logger *zap.Logger) {
+	if interruptHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/interrupts", interruptHandler.HandleList)
+	mux.HandleFunc("GET /api/v1/interrupts/stream", interruptHandler.HandleStream)
+	mux.HandleFunc("GET /api/v1/interrupts/{id}", interruptHandler.HandleGet)
+	mux.HandleFunc("POST /api/v1/interrupts/{id}/responses", interruptHandler.HandleRespond)
+	logger.Info("Interrupt API routes registered")
+}
+
+func RegisterCache(mux *http.ServeMux, cacheHandler *handlers.CacheAdminHandler, logger *zap.Logger) {
+	if cacheHandler == nil {
+		return
+	}
+	mux.HandleFunc("DELETE /api/v1/cache/tenants/{tenant}", cacheHandler.HandlePurgeTenant)
+	logger.Info("Cache admin API routes registered")
+}
+
+func RegisterSandboxImages(mux *http.ServeMux, sandboxHandler *handlers.SandboxImageAdminHandler, logger *zap.Logger) {
+	if sandboxHandler == nil {
+		return
+	}
+	mux.HandleFunc("GET /api/v1/sandbox/images", sandboxHandler.HandleListImages)
+	mux.HandleFunc("POST /api/v1/sandbox/images/{language}/rollout", sandboxHandler.HandleStartRollout)
+	mux.HandleFunc("PATCH /api/v1/sandbox/images/{language}/rollout", sandboxHandler.HandleUpdateRollout)
+	mux.HandleFunc("DELETE /api/v1/sandbox/images/{language}/rollout", sandboxHandler.HandleAbortRollout)
+	mux.HandleFunc("POST /api/v1/sandbox/images/{language}/rollout/promote", sandboxHandler.HandlePromoteRollout)
+	logger.Info("Sandbox image admin API routes registered")
+}
+
 func RegisterConfig(mux *http.ServeMux, cfgHandler *config.ConfigAPIHandler, firstAPIKey string, logger *zap.Logger) {
 	if cfgHandler == nil {
 		return
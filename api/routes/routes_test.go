@@ -29,6 +29,11 @@ func TestCompatibilityEndpointRoutes(t *testing.T) {
 			path: "/v1/chat/completions",
 			body: `{"model":"gpt-5.2","messages":[{"role":"user","content":"hi"}]}`,
 		},
+		{
+			name: "openai embeddings",
+			path: "/v1/embeddings",
+			body: `{"model":"text-embedding-3-small","input":"hi"}`,
+		},
 		{
 			name: "openai responses",
 			path: "/v1/responses",
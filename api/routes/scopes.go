@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+
+	mw "github.com/BaSui01/agentflow/pkg/middleware"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+)
+
+// APIKeyScopeRules maps the registered routes to the scope an API key needs
+// to call them. Rules match in order; routes not listed require the admin scope.
+func APIKeyScopeRules() []mw.ScopeRule {
+	return []mw.ScopeRule{
+		{PathPrefix: "/api/v1/keys", Scope: tenantkey.ScopeKeysAdmin},
+
+		{PathPrefix: "/api/v1/agents/definitions", Scope: tenantkey.ScopeAgentsAdmin},
+		{PathPrefix: "/api/v1/agents", Scope: tenantkey.ScopeAgentsExecute},
+		{PathPrefix: "/api/v1/a2a", Scope: tenantkey.ScopeAgentsExecute},
+		{Method: http.MethodGet, PathPrefix: "/v1/chat/ws", Scope: tenantkey.ScopeAgentsExecute},
+		{Method: http.MethodGet, PathPrefix: "/v1/runs", Scope: tenantkey.ScopeAgentsExecute},
+
+		{Method: http.MethodGet, PathPrefix: "/api/v1/chat/capabilities", Scope: tenantkey.ScopeChatRead},
+		{Method: http.MethodGet, PathPrefix: "/v1/models", Scope: tenantkey.ScopeChatRead},
+		{Method: http.MethodGet, PathPrefix: "/v1/jobs", Scope: tenantkey.ScopeChatRead},
+		{Method: http.MethodGet, PathPrefix: "/api/v1/multimodal/capabilities", Scope: tenantkey.ScopeChatRead},
+		{PathPrefix: "/api/v1/chat", Scope: tenantkey.ScopeChatWrite},
+		{PathPrefix: "/v1/chat", Scope: tenantkey.ScopeChatWrite},
+		{PathPrefix: "/v1/embeddings", Scope: tenantkey.ScopeChatWrite},
+		{PathPrefix: "/v1/responses", Scope: tenantkey.ScopeChatWrite},
+		{PathPrefix: "/v1/messages", Scope: tenantkey.ScopeChatWrite},
+		{PathPrefix: "/api/v1/multimodal", Scope: tenantkey.ScopeChatWrite},
+
+		{PathPrefix: "/api/v1/workflows", Scope: tenantkey.ScopeWorkflowsExecute},
+		{PathPrefix: "/api/v1/interrupts", Scope: tenantkey.ScopeWorkflowsExecute},
+
+		{Method: http.MethodGet, PathPrefix: "/api/v1/rag", Scope: tenantkey.ScopeRAGRead},
+		{PathPrefix: "/api/v1/rag/query", Scope: tenantkey.ScopeRAGRead},
+		{PathPrefix: "/api/v1/rag", Scope: tenantkey.ScopeRAGWrite},
+
+		{Method: http.MethodGet, PathPrefix: "/v1/usage", Scope: tenantkey.ScopeUsageRead},
+
+		{PathPrefix: "/api/v1/tools", Scope: tenantkey.ScopeToolsAdmin},
+		{PathPrefix: "/api/v1/mcp", Scope: tenantkey.ScopeToolsAdmin},
+	}
+}
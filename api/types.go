@@ -217,6 +217,8 @@ type ChatChoice struct {
 	FinishReason string `json:"finish_reason,omitempty" example:"stop"`
 	// 响应消息
 	Message Message `json:"message"`
+	// token 级对数概率（请求 logprobs 时返回）
+	LogProbs *types.ChoiceLogProbs `json:"logprobs,omitempty"`
 }
 
 // ChatUsage 表示响应中的 Token 使用情况。
@@ -264,6 +266,8 @@ type StreamChunk struct {
 	Delta Message `json:"delta"`
 	// 完成原因（仅在最后一块）
 	FinishReason string `json:"finish_reason,omitempty" example:"stop"`
+	// 本块增量 token 的对数概率（请求 logprobs 时返回）
+	LogProbs *types.ChoiceLogProbs `json:"logprobs,omitempty"`
 	// 使用统计（仅在最终块中）
 	Usage *ChatUsage `json:"usage,omitempty"`
 	// 错误信息
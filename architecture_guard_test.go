@@ -133,7 +133,6 @@ func TestPkgOneFileDirectoryAllowlist(t *testing.T) {
 		"cache":      "single cohesive cache manager entrypoint",
 		"httpclient": "single HTTP client factory entrypoint",
 		"httputil":   "single shared HTTP ResponseWriter recorder",
-		"jsonschema": "single JSON schema validator entrypoint",
 		"jsonutil":   "single JSON utility entrypoint",
 		"metrics":    "single metrics collector entrypoint",
 		"openapi":    "single OpenAPI helper entrypoint",
@@ -190,6 +190,11 @@ Commands:
 Options for 'serve':
   --config <path>   Path to configuration file (YAML)
 
+OpenAI-compatible endpoints (served by 'serve', drop-in for OpenAI SDK clients):
+  POST /v1/chat/completions   Chat completions (JSON or SSE stream)
+  POST /v1/embeddings         Embeddings
+  GET  /v1/models             Model list
+
 Migration subcommands:
   migrate up        Apply all pending migrations
   migrate down      Rollback the last migration
@@ -22,19 +22,30 @@ func (s *Server) initHandlers() error {
 	s.handlers.chatHandler = set.ChatHandler
 	s.text.chatService = set.ChatService
 	s.handlers.agentHandler = set.AgentHandler
+	s.handlers.agentDefinitionHandler = set.AgentDefinitionHandler
 	s.handlers.apiKeyHandler = set.APIKeyHandler
+	s.handlers.tenantKeyHandler = set.TenantKeyHandler
+	s.handlers.tenantKeyService = set.TenantKeyService
 	s.handlers.toolRegistryHandler = set.ToolRegistryHandler
 	s.handlers.toolProviderHandler = set.ToolProviderHandler
 	s.handlers.toolApprovalHandler = set.ToolApprovalHandler
 	s.handlers.authAuditHandler = set.AuthAuditHandler
+	s.handlers.guardrailAuditHandler = set.GuardrailAuditHandler
+	s.handlers.auditTrailHandler = set.AuditTrailHandler
 	s.handlers.ragHandler = set.RAGHandler
 	s.handlers.workflowHandler = set.WorkflowHandler
 	s.handlers.protocolHandler = set.ProtocolHandler
 	s.handlers.multimodalHandler = set.MultimodalHandler
 	s.handlers.costHandler = set.CostHandler
+	s.handlers.cacheAdminHandler = set.CacheAdminHandler
+	s.handlers.usageHandler = set.UsageHandler
+	s.handlers.runHandler = set.RunHandler
+	s.handlers.interruptHandler = set.InterruptHandler
+	s.handlers.externalTaskHandler = set.ExternalTaskHandler
 
 	s.infra.multimodalRedis = set.MultimodalRedis
 	s.infra.toolApprovalRedis = set.ToolApprovalRedis
+	s.infra.rateLimitRedis = set.RateLimitRedis
 
 	s.text.provider = set.Provider
 	s.text.toolProvider = set.ToolProvider
@@ -43,16 +54,21 @@ func (s *Server) initHandlers() error {
 	s.text.llmCache = set.LLMCache
 	s.text.llmMetrics = set.LLMMetrics
 	s.text.modelCatalog = set.ModelCatalog
+	s.text.runRecorder = set.RunRecorder
 
 	s.tooling.discoveryRegistry = set.DiscoveryRegistry
 	s.tooling.agentRegistry = set.AgentRegistry
 	s.tooling.toolingRuntime = set.ToolingRuntime
 	s.tooling.capabilityCatalog = set.CapabilityCatalog
+	s.tooling.guardrailAuditLogger = set.GuardrailAuditLogger
+	s.tooling.auditTrail = set.AuditTrail
 	s.workflow.resolver = set.Resolver
+	s.workflow.agentDefinitionRuntime = set.AgentDefinitionRuntime
 
 	s.workflow.checkpointStore = set.CheckpointStore
 	s.workflow.checkpointManager = set.CheckpointManager
 	s.workflow.workflowCheckpointStore = set.WorkflowCheckpointStore
+	s.workflow.externalTasks = set.WorkflowExternalTasks
 	s.workflow.ragStore = set.RAGStore
 	s.workflow.ragEmbedding = set.RAGEmbedding
 
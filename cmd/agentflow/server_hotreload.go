@@ -26,6 +26,9 @@ func (s *Server) initHotReloadManager() error {
 		}
 		s.cfg = newConfig
 	})
+	if s.tooling.auditTrail != nil {
+		bootstrap.RegisterConfigAuditTrail(s.ops.hotReloadManager, s.tooling.auditTrail, s.logger)
+	}
 
 	return nil
 }
@@ -40,10 +43,11 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 		return fmt.Errorf("rebuild model catalog: %w", err)
 	}
 
-	llmRuntime, err := bootstrap.BuildLLMHandlerRuntime(cfg, s.infra.db, s.logger)
+	llmRuntime, err := bootstrap.BuildLLMHandlerRuntime(cfg, s.infra.db, s.infra.rateLimitRedis, s.logger, s.text.runRecorder)
 	if err != nil {
 		return fmt.Errorf("rebuild llm runtime: %w", err)
 	}
+	modelCatalog = bootstrap.ApplyProviderModelPricing(context.Background(), cfg, llmRuntime, modelCatalog, s.logger)
 
 	var (
 		provider      llmcore.Provider
@@ -90,6 +94,7 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 		EmbeddingProvider:       s.workflow.ragEmbedding,
 		CheckpointStore:         s.workflow.checkpointStore,
 		WorkflowCheckpointStore: s.workflow.workflowCheckpointStore,
+		ExternalTasks:           s.workflow.externalTasks,
 		HITLManager:             s.currentWorkflowHITLManager(),
 		AuthorizationService:    s.currentWorkflowAuthorizationService(),
 		Logger:                  s.logger,
@@ -103,6 +108,9 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 	s.text.llmMetrics = llmMetrics
 	s.text.modelCatalog = modelCatalog
 	s.workflow.resolver = resolver
+	if s.workflow.agentDefinitionRuntime != nil {
+		s.workflow.agentDefinitionRuntime.SwapResolver(resolver)
+	}
 
 	previousChatService := s.text.chatService
 	var chatService usecase.ChatService
@@ -113,6 +121,7 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 			PolicyManager:       llmRuntime.PolicyManager,
 			Ledger:              ledger,
 			ToolingRuntime:      s.tooling.toolingRuntime,
+			Embedding:           bootstrap.BuildChatEmbeddingProvider(cfg),
 			ExistingChatService: s.text.chatService,
 			Logger:              s.logger,
 		})
@@ -129,9 +138,12 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 		ChatHandler:         s.handlers.chatHandler,
 		CostTracker:         costTracker,
 		CostHandler:         s.handlers.costHandler,
+		LLMCache:            llmCache,
+		CacheAdminHandler:   s.handlers.cacheAdminHandler,
 		AgentHandler:        s.handlers.agentHandler,
 		DiscoveryRegistry:   s.tooling.discoveryRegistry,
 		Resolver:            resolver,
+		RunRecorder:         s.text.runRecorder,
 		WorkflowRuntime:     workflowRuntime,
 		WorkflowHandler:     s.handlers.workflowHandler,
 		HTTPRoutesBound:     s.ops.httpManager != nil,
@@ -143,6 +155,7 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 	s.text.chatService = bindings.ChatService
 	s.handlers.chatHandler = bindings.ChatHandler
 	s.handlers.costHandler = bindings.CostHandler
+	s.handlers.cacheAdminHandler = bindings.CacheAdminHandler
 
 	if bindings.ChatRouteRequiresRestart {
 		s.logger.Warn("LLM hot reload rebuilt chat runtime but chat routes were not bound at startup; restart required to activate chat endpoints")
@@ -150,17 +163,23 @@ func (s *Server) reloadLLMRuntime(cfg *config.Config) error {
 	if bindings.CostRouteRequiresRestart {
 		s.logger.Warn("LLM hot reload rebuilt cost runtime but cost routes were not bound at startup; restart required to activate cost endpoints")
 	}
+	if bindings.CacheRouteRequiresRestart {
+		s.logger.Warn("LLM hot reload rebuilt response cache but cache admin routes were not bound at startup; restart required to activate cache admin endpoints")
+	}
 
 	if s.tooling.agentRegistry != nil {
 		if gateway != nil {
-			bootstrap.RegisterDefaultRuntimeAgentFactoryWithAuthorization(
+			bootstrap.RegisterDefaultRuntimeAgentFactoryWithOptions(
 				s.tooling.agentRegistry,
 				gateway,
 				toolGateway,
 				s.workflow.checkpointManager,
 				s.text.modelCatalog,
 				ledger,
-				s.currentWorkflowAuthorizationService(),
+				bootstrap.RuntimeAgentFactoryOptions{
+					AuthorizationService: s.currentWorkflowAuthorizationService(),
+					GuardrailAuditLogger: s.tooling.guardrailAuditLogger,
+				},
 				s.logger,
 			)
 		} else {
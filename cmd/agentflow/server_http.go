@@ -16,20 +16,29 @@ func (s *Server) startHTTPServer() error {
 	bootstrap.RegisterHTTPRoutes(
 		mux,
 		bootstrap.HTTPRouteHandlers{
-			Health:        s.handlers.healthHandler,
-			Chat:          s.handlers.chatHandler,
-			Agent:         s.handlers.agentHandler,
-			APIKey:        s.handlers.apiKeyHandler,
-			Tools:         s.handlers.toolRegistryHandler,
-			ToolProviders: s.handlers.toolProviderHandler,
-			ToolApprovals: s.handlers.toolApprovalHandler,
-			AuthAudit:     s.handlers.authAuditHandler,
-			Multimodal:    s.handlers.multimodalHandler,
-			Protocol:      s.handlers.protocolHandler,
-			RAG:           s.handlers.ragHandler,
-			Workflow:      s.handlers.workflowHandler,
-			ConfigAPI:     s.ops.configAPIHandler,
-			Cost:          s.handlers.costHandler,
+			Health:           s.handlers.healthHandler,
+			Chat:             s.handlers.chatHandler,
+			Agent:            s.handlers.agentHandler,
+			AgentDefinitions: s.handlers.agentDefinitionHandler,
+			APIKey:           s.handlers.apiKeyHandler,
+			TenantKeys:       s.handlers.tenantKeyHandler,
+			Tools:            s.handlers.toolRegistryHandler,
+			ToolProviders:    s.handlers.toolProviderHandler,
+			ToolApprovals:    s.handlers.toolApprovalHandler,
+			AuthAudit:        s.handlers.authAuditHandler,
+			GuardrailAudit:   s.handlers.guardrailAuditHandler,
+			AuditTrail:       s.handlers.auditTrailHandler,
+			Multimodal:       s.handlers.multimodalHandler,
+			Protocol:         s.handlers.protocolHandler,
+			RAG:              s.handlers.ragHandler,
+			Workflow:         s.handlers.workflowHandler,
+			ConfigAPI:        s.ops.configAPIHandler,
+			Cost:             s.handlers.costHandler,
+			CacheAdmin:       s.handlers.cacheAdminHandler,
+			Usage:            s.handlers.usageHandler,
+			Runs:             s.handlers.runHandler,
+			Interrupts:       s.handlers.interruptHandler,
+			ExternalTasks:    s.handlers.externalTaskHandler,
 		},
 		Version,
 		BuildTime,
@@ -38,7 +47,11 @@ func (s *Server) startHTTPServer() error {
 		s.logger,
 	)
 
-	httpMiddlewares, err := bootstrap.BuildHTTPMiddlewares(s.cfg.Server, s.ops.metricsCollector, s.logger)
+	var keyResolver mw.APIKeyResolver
+	if s.handlers.tenantKeyService != nil {
+		keyResolver = s.handlers.tenantKeyService
+	}
+	httpMiddlewares, err := bootstrap.BuildHTTPMiddlewares(s.cfg.Server, s.ops.metricsCollector, keyResolver, s.logger)
 	if err != nil {
 		return err
 	}
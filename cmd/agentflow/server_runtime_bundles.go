@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
 	agentmemory "github.com/BaSui01/agentflow/agent/capabilities/memory"
 	discovery "github.com/BaSui01/agentflow/agent/capabilities/tools"
 	"github.com/BaSui01/agentflow/agent/observability/evaluation"
@@ -12,12 +13,14 @@ import (
 	"github.com/BaSui01/agentflow/api/handlers"
 	"github.com/BaSui01/agentflow/config"
 	"github.com/BaSui01/agentflow/internal/app/bootstrap"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
 	"github.com/BaSui01/agentflow/internal/usecase"
 	"github.com/BaSui01/agentflow/llm/cache"
 	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
 	llm "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/llm/observability"
 	llmpolicy "github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/pkg/audit"
 	"github.com/BaSui01/agentflow/pkg/metrics"
 	mongoclient "github.com/BaSui01/agentflow/pkg/mongodb"
 	"github.com/BaSui01/agentflow/pkg/server"
@@ -37,6 +40,7 @@ type serverInfraBundle struct {
 	mongoClient       *mongoclient.Client
 	multimodalRedis   *redis.Client
 	toolApprovalRedis *redis.Client
+	rateLimitRedis    *redis.Client
 
 	auditLogger    *llmtools.DefaultAuditLogger
 	abTester       *evaluation.ABTester
@@ -57,19 +61,29 @@ type serverOpsBundle struct {
 }
 
 type serverHandlerBundle struct {
-	healthHandler       *handlers.HealthHandler
-	chatHandler         *handlers.ChatHandler
-	agentHandler        *handlers.AgentHandler
-	apiKeyHandler       *handlers.APIKeyHandler
-	toolRegistryHandler *handlers.ToolRegistryHandler
-	toolProviderHandler *handlers.ToolProviderHandler
-	toolApprovalHandler *handlers.ToolApprovalHandler
-	authAuditHandler    *handlers.AuthorizationAuditHandler
-	ragHandler          *handlers.RAGHandler
-	workflowHandler     *handlers.WorkflowHandler
-	protocolHandler     *handlers.ProtocolHandler
-	multimodalHandler   *handlers.MultimodalHandler
-	costHandler         *handlers.CostHandler
+	healthHandler          *handlers.HealthHandler
+	chatHandler            *handlers.ChatHandler
+	agentHandler           *handlers.AgentHandler
+	agentDefinitionHandler *handlers.AgentDefinitionHandler
+	apiKeyHandler          *handlers.APIKeyHandler
+	tenantKeyHandler       *handlers.TenantKeyHandler
+	tenantKeyService       *appservice.DefaultTenantKeyService
+	toolRegistryHandler    *handlers.ToolRegistryHandler
+	toolProviderHandler    *handlers.ToolProviderHandler
+	toolApprovalHandler    *handlers.ToolApprovalHandler
+	authAuditHandler       *handlers.AuthorizationAuditHandler
+	guardrailAuditHandler  *handlers.GuardrailAuditHandler
+	auditTrailHandler      *handlers.AuditTrailHandler
+	ragHandler             *handlers.RAGHandler
+	workflowHandler        *handlers.WorkflowHandler
+	protocolHandler        *handlers.ProtocolHandler
+	multimodalHandler      *handlers.MultimodalHandler
+	costHandler            *handlers.CostHandler
+	cacheAdminHandler      *handlers.CacheAdminHandler
+	usageHandler           *handlers.UsageHandler
+	runHandler             *handlers.RunHandler
+	interruptHandler       *handlers.InterruptHandler
+	externalTaskHandler    *handlers.ExternalTaskHandler
 }
 
 type serverTextRuntimeBundle struct {
@@ -83,6 +97,7 @@ type serverTextRuntimeBundle struct {
 	llmCache      *cache.MultiLevelCache
 	llmMetrics    *observability.Metrics
 	modelCatalog  *types.ModelCatalog
+	runRecorder   *observability.RunRecorder
 }
 
 type serverToolingBundle struct {
@@ -90,18 +105,22 @@ type serverToolingBundle struct {
 	agentRegistry     *agent.AgentRegistry
 	toolingRuntime    *bootstrap.AgentToolingRuntime
 
-	toolApprovalManager *hitl.InterruptManager
-	capabilityCatalog   *bootstrap.CapabilityCatalog
+	toolApprovalManager  *hitl.InterruptManager
+	capabilityCatalog    *bootstrap.CapabilityCatalog
+	guardrailAuditLogger *guardrails.GuardrailAuditLogger
+	auditTrail           *audit.Trail
 }
 
 type serverWorkflowBundle struct {
-	resolver *agent.CachingResolver
+	resolver               *agent.CachingResolver
+	agentDefinitionRuntime *bootstrap.AgentDefinitionRuntimeAdapter
 
 	workflowHITLManager *hitl.InterruptManager
 
 	checkpointStore         agentcheckpoint.Store
 	checkpointManager       *agent.CheckpointManager
 	workflowCheckpointStore workflowpkg.CheckpointStore
+	externalTasks           workflowpkg.ExternalTaskQueue
 	ragStore                core.VectorStore
 	ragEmbedding            core.EmbeddingProvider
 }
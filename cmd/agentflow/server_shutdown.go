@@ -85,6 +85,12 @@ func (s *Server) Shutdown() {
 		}
 	}
 
+	if s.infra.rateLimitRedis != nil {
+		if err := s.infra.rateLimitRedis.Close(); err != nil {
+			s.logger.Error("Distributed rate limit Redis close error", zap.Error(err))
+		}
+	}
+
 	// 7.5 关闭 AuditLogger
 	if s.infra.auditLogger != nil {
 		if err := s.infra.auditLogger.Close(); err != nil {
@@ -92,6 +98,20 @@ func (s *Server) Shutdown() {
 		}
 	}
 
+	// 7.6 关闭护栏审计日志文件
+	if s.tooling.guardrailAuditLogger != nil {
+		if err := s.tooling.guardrailAuditLogger.Close(); err != nil {
+			s.logger.Error("Guardrail audit logger close error", zap.Error(err))
+		}
+	}
+
+	// 7.7 关闭统一审计轨迹文件
+	if s.tooling.auditTrail != nil {
+		if err := s.tooling.auditTrail.Close(); err != nil {
+			s.logger.Error("Audit trail close error", zap.Error(err))
+		}
+	}
+
 	// 8. 等待所有 goroutine 完成
 	s.wg.Wait()
 
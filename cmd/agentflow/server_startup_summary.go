@@ -68,7 +68,11 @@ func (s *Server) startupSummary() startupSummary {
 	appendCapabilityState("workflow", s.handlers.workflowHandler != nil)
 	appendCapabilityState("multimodal", s.handlers.multimodalHandler != nil)
 	appendCapabilityState("cost", s.handlers.costHandler != nil)
+	appendCapabilityState("usage", s.handlers.usageHandler != nil)
+	appendCapabilityState("run_inspection", s.handlers.runHandler != nil)
+	appendCapabilityState("interrupts", s.handlers.interruptHandler != nil)
 	appendCapabilityState("api_key_management", s.handlers.apiKeyHandler != nil)
+	appendCapabilityState("tenant_api_keys", s.handlers.tenantKeyHandler != nil)
 	appendCapabilityState("tool_registry", s.handlers.toolRegistryHandler != nil)
 	appendCapabilityState("tool_provider_config", s.handlers.toolProviderHandler != nil)
 	appendCapabilityState("tool_approval", s.handlers.toolApprovalHandler != nil)
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ToRuntimeConfig 将部署层的扁平 Agent 配置转换为运行时使用的 types.AgentConfig。
+// Agent ID 取名称，名称为空时使用 "default"。
+func (c AgentConfig) ToRuntimeConfig() types.AgentConfig {
+	id := strings.TrimSpace(c.Name)
+	if id == "" {
+		id = "default"
+	}
+
+	cfg := types.AgentConfig{
+		Core: types.CoreConfig{
+			ID:          id,
+			Name:        c.Name,
+			Description: c.Description,
+		},
+		Model: types.ModelOptions{
+			Model:       c.Model,
+			MaxTokens:   c.MaxTokens,
+			Temperature: float32(c.Temperature),
+		},
+		Control: types.AgentControlOptions{
+			SystemPrompt:       c.SystemPrompt,
+			Timeout:            c.Timeout,
+			MaxReActIterations: c.MaxIterations,
+		},
+		Tools: types.ToolProtocolOptions{
+			ToolModel: c.ToolModel,
+		},
+		LLM: types.LLMConfig{
+			Model:       c.Model,
+			MaxTokens:   c.MaxTokens,
+			Temperature: float32(c.Temperature),
+		},
+		Runtime: types.RuntimeConfig{
+			SystemPrompt:       c.SystemPrompt,
+			MaxReActIterations: c.MaxIterations,
+			ToolModel:          c.ToolModel,
+		},
+	}
+
+	if c.Memory.Enabled {
+		memory := &types.MemoryConfig{
+			Enabled:          true,
+			MaxShortTermSize: c.Memory.MaxMessages,
+		}
+		cfg.Control.Memory = memory
+		cfg.Features.Memory = memory
+	}
+	if c.Guardrails.Enabled {
+		guardrails := &types.GuardrailsConfig{
+			Enabled:            true,
+			MaxInputLength:     c.Guardrails.MaxInputLength,
+			BlockedKeywords:    append([]string(nil), c.Guardrails.BlockedKeywords...),
+			PIIDetection:       c.Guardrails.PIIDetection,
+			PIILocales:         append([]string(nil), c.Guardrails.PIILocales...),
+			InjectionDetection: c.Guardrails.InjectionDetection,
+			SecretsDetection:   c.Guardrails.SecretsDetection,
+			PolicyFile:         strings.TrimSpace(c.Guardrails.PolicyFile),
+			OnInputFailure:     strings.ToLower(strings.TrimSpace(c.Guardrails.OnInputFailure)),
+			OnOutputFailure:    strings.ToLower(strings.TrimSpace(c.Guardrails.OnOutputFailure)),
+		}
+		cfg.Control.Guardrails = guardrails
+		cfg.Features.Guardrails = guardrails
+	}
+	return cfg
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentConfig_ToRuntimeConfig(t *testing.T) {
+	cfg := DefaultAgentConfig()
+	cfg.ToolModel = "gpt-4o-mini"
+	cfg.Guardrails = AgentGuardrailsConfig{
+		Enabled:         true,
+		MaxInputLength:  2000,
+		BlockedKeywords: []string{"secret"},
+		PIIDetection:    true,
+		OnInputFailure:  " Reject ",
+	}
+
+	rt := cfg.ToRuntimeConfig()
+	assert.Equal(t, "default-agent", rt.Core.ID)
+	options := rt.ExecutionOptions()
+	assert.Equal(t, "gpt-4", options.Model.Model)
+	assert.Equal(t, "gpt-4o-mini", options.Tools.ToolModel)
+	assert.Equal(t, cfg.SystemPrompt, options.Control.SystemPrompt)
+	assert.Equal(t, cfg.MaxIterations, options.Control.MaxReActIterations)
+
+	require.NotNil(t, options.Control.Memory)
+	assert.Equal(t, 100, options.Control.Memory.MaxShortTermSize)
+	require.NotNil(t, options.Control.Guardrails)
+	assert.Equal(t, []string{"secret"}, options.Control.Guardrails.BlockedKeywords)
+	assert.Equal(t, "reject", options.Control.Guardrails.OnInputFailure)
+
+	cfg.Name = ""
+	cfg.Memory.Enabled = false
+	cfg.Guardrails.Enabled = false
+	rt = cfg.ToRuntimeConfig()
+	assert.Equal(t, "default", rt.Core.ID)
+	assert.Nil(t, rt.Control.Memory)
+	assert.Nil(t, rt.Control.Guardrails)
+}
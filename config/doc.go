@@ -4,6 +4,11 @@
 // overrides. Configuration is structured into sections (Server, LLM, Database,
 // RAG, Telemetry, etc.) with sensible defaults defined in defaults.go.
 //
+// String values may be committed encrypted as ENC[<provider>:<base64>] (see
+// SealYAML and EncryptValue); Loader decrypts them with the providers passed to
+// WithKeyProvider, the local key in <PREFIX>_CONFIG_KEY / _CONFIG_KEY_FILE, or
+// the Vault Transit key named by <PREFIX>_CONFIG_VAULT_TRANSIT_KEY.
+//
 // This package sits in the infrastructure layer and may be imported by
 // internal/app/bootstrap (composition root) and cmd/ (entry points).
 // Domain packages (agent/, rag/, llm/) must not import config directly.
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/BaSui01/agentflow/pkg/cryptoutil"
+	"gopkg.in/yaml.v3"
+)
+
+// --- 加密配置值 ---
+//
+// 配置文件中的任意字符串值都可以写成 ENC[<provider>:<base64>] 形式，
+// Load 在合并 YAML 与环境变量之后使用配置的 KeyProvider 解密。
+// 未显式配置 KeyProvider 时，会从 <前缀>_CONFIG_KEY 或 <前缀>_CONFIG_KEY_FILE
+// 读取本地 AES-256-GCM 密钥（cryptoutil.GenerateKey 生成的 base64 字符串）；
+// 设置 <前缀>_CONFIG_VAULT_TRANSIT_KEY 时还会注册 Vault Transit KMS 提供者（ENC[vault:...]），
+// 地址与令牌读取 <前缀>_CONFIG_VAULT_ADDR / _TOKEN，缺省回退到 VAULT_ADDR / VAULT_TOKEN。
+
+// WithKeyProvider 添加用于解密 ENC[...] 值的密钥提供者（本地密钥或 KMS）
+func (l *Loader) WithKeyProvider(providers ...cryptoutil.KeyProvider) *Loader {
+	l.keyProviders = append(l.keyProviders, providers...)
+	return l
+}
+
+// keyRing 组装显式提供者与环境变量中的本地密钥
+func (l *Loader) keyRing() (*cryptoutil.KeyRing, error) {
+	providers := append([]cryptoutil.KeyProvider(nil), l.keyProviders...)
+
+	encoded := os.Getenv(l.envPrefix + "_CONFIG_KEY")
+	if encoded == "" {
+		if path := os.Getenv(l.envPrefix + "_CONFIG_KEY_FILE"); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read config key file: %w", err)
+			}
+			encoded = string(data)
+		}
+	}
+	if strings.TrimSpace(encoded) != "" {
+		key, err := cryptoutil.DecodeKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		local, err := cryptoutil.NewAESGCMKeyProvider(cryptoutil.LocalKeyProviderID, key)
+		if err != nil {
+			return nil, err
+		}
+		// 显式提供者优先于环境变量中的同名本地密钥
+		providers = append([]cryptoutil.KeyProvider{local}, providers...)
+	}
+	if keyName := os.Getenv(l.envPrefix + "_CONFIG_VAULT_TRANSIT_KEY"); strings.TrimSpace(keyName) != "" {
+		vault, err := cryptoutil.NewVaultTransitKeyProvider(cryptoutil.VaultTransitConfig{
+			Address:   firstEnv(l.envPrefix+"_CONFIG_VAULT_ADDR", "VAULT_ADDR"),
+			Token:     firstEnv(l.envPrefix+"_CONFIG_VAULT_TOKEN", "VAULT_TOKEN"),
+			KeyName:   keyName,
+			Mount:     os.Getenv(l.envPrefix + "_CONFIG_VAULT_TRANSIT_MOUNT"),
+			Namespace: firstEnv(l.envPrefix+"_CONFIG_VAULT_NAMESPACE", "VAULT_NAMESPACE"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers = append([]cryptoutil.KeyProvider{vault}, providers...)
+	}
+	return cryptoutil.NewKeyRing(providers...), nil
+}
+
+// firstEnv 返回第一个非空的环境变量值
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// openSealedValues 原地解密配置中所有 ENC[...] 字符串
+func (l *Loader) openSealedValues(cfg *Config) error {
+	kr, err := l.keyRing()
+	if err != nil {
+		return err
+	}
+	return DecryptConfig(context.Background(), cfg, kr)
+}
+
+// DecryptConfig 原地解密 cfg 中所有 ENC[...] 字符串，错误信息包含字段路径
+func DecryptConfig(ctx context.Context, cfg *Config, kr *cryptoutil.KeyRing) error {
+	if cfg == nil {
+		return nil
+	}
+	return openSealedField(ctx, reflect.ValueOf(cfg).Elem(), "", kr)
+}
+
+func openSealedField(ctx context.Context, v reflect.Value, path string, kr *cryptoutil.KeyRing) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !cryptoutil.IsSealed(v.String()) {
+			return nil
+		}
+		plaintext, err := kr.Open(ctx, v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if v.CanSet() {
+			v.SetString(plaintext)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return openSealedField(ctx, v.Elem(), path, kr)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := openSealedField(ctx, v.Field(i), joinConfigPath(path, t.Field(i).Name), kr); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := openSealedField(ctx, v.Index(i), fmt.Sprintf("%s[%d]", path, i), kr); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map 元素不可寻址，复制后解密再写回
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := openSealedField(ctx, elem, fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), kr); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
+
+func joinConfigPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// EncryptValue 使用 provider 加密单个值，返回可直接写入 YAML 的 ENC[...] 字符串
+func EncryptValue(ctx context.Context, provider cryptoutil.KeyProvider, plaintext string) (string, error) {
+	return cryptoutil.Seal(ctx, provider, plaintext)
+}
+
+// SealYAML 加密 YAML 文档中的字符串值并保留注释与顺序，适用于 GitOps 提交前的加密步骤。
+// paths 为点分隔的 YAML 键路径（如 "llm.api_key"）；为空时加密所有敏感键
+// （password、api_key、secret、token 等）。已加密的值保持不变。
+func SealYAML(ctx context.Context, data []byte, provider cryptoutil.KeyProvider, paths ...string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+	targets := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		targets[strings.TrimSpace(p)] = true
+	}
+	if err := sealYAMLNode(ctx, &doc, "", "", targets, provider); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&doc)
+}
+
+func sealYAMLNode(ctx context.Context, node *yaml.Node, key, path string, targets map[string]bool, provider cryptoutil.KeyProvider) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := sealYAMLNode(ctx, child, key, path, targets, provider); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childKey := node.Content[i].Value
+			if err := sealYAMLNode(ctx, node.Content[i+1], childKey, joinConfigPath(path, childKey), targets, provider); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := sealYAMLNode(ctx, child, key, path, targets, provider); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		selected := targets[path]
+		if len(targets) == 0 {
+			selected = isSensitiveConfigKey(key)
+		}
+		if !selected || node.Tag != "!!str" || node.Value == "" || cryptoutil.IsSealed(node.Value) {
+			return nil
+		}
+		sealed, err := cryptoutil.Seal(ctx, provider, node.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		node.Value = sealed
+		node.Style = 0
+	}
+	return nil
+}
+
+// sensitiveConfigKeys 是按键名判定敏感字段的子串
+var sensitiveConfigKeys = []string{"password", "api_key", "apikey", "secret", "token", "credential"}
+
+func isSensitiveConfigKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, s := range sensitiveConfigKeys {
+		if strings.Contains(lowerKey, s) {
+			return true
+		}
+	}
+	return false
+}
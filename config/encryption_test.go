@@ -0,0 +1,177 @@
+// 加密配置值测试。
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/pkg/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestKeyProvider(t *testing.T) (*cryptoutil.AESGCMKeyProvider, string) {
+	t.Helper()
+	encoded, err := cryptoutil.GenerateKey()
+	require.NoError(t, err)
+	key, err := cryptoutil.DecodeKey(encoded)
+	require.NoError(t, err)
+	p, err := cryptoutil.NewAESGCMKeyProvider("", key)
+	require.NoError(t, err)
+	return p, encoded
+}
+
+func TestLoader_DecryptsSealedValues(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newTestKeyProvider(t)
+	apiKey, err := EncryptValue(ctx, provider, "sk-live-123")
+	require.NoError(t, err)
+	dbPassword, err := EncryptValue(ctx, provider, "db-pass")
+	require.NoError(t, err)
+	serverKey, err := EncryptValue(ctx, provider, "server-key")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+llm:
+  api_key: "`+apiKey+`"
+database:
+  password: "`+dbPassword+`"
+server:
+  api_keys:
+    - plain-key
+    - "`+serverKey+`"
+`), 0o600))
+
+	cfg, err := NewLoader().WithConfigPath(path).WithEnvPrefix("ENCTEST").WithKeyProvider(provider).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-live-123", cfg.LLM.APIKey)
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+	assert.Equal(t, []string{"plain-key", "server-key"}, cfg.Server.APIKeys)
+}
+
+func TestHotReloadManager_ReloadReusesLoaderKeyProviders(t *testing.T) {
+	provider, _ := newTestKeyProvider(t)
+	sealed, err := EncryptValue(context.Background(), provider, "sk-rotated")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("llm:\n  api_key: plain\n"), 0o600))
+	loader := NewLoader().WithConfigPath(path).WithEnvPrefix("ENCRELOAD").WithKeyProvider(provider)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	manager := NewHotReloadManager(cfg, WithConfigPath(path), WithLoader(loader))
+	require.NoError(t, os.WriteFile(path, []byte("llm:\n  api_key: \""+sealed+"\"\n"), 0o600))
+	require.NoError(t, manager.ReloadFromFile())
+	assert.Equal(t, "sk-rotated", manager.GetConfig().LLM.APIKey)
+
+	withoutLoader := NewHotReloadManager(cfg, WithConfigPath(path))
+	assert.ErrorIs(t, withoutLoader.ReloadFromFile(), cryptoutil.ErrUnknownKeyProvider)
+}
+
+func TestLoader_UsesKeyFromEnvironment(t *testing.T) {
+	provider, encoded := newTestKeyProvider(t)
+	sealed, err := EncryptValue(context.Background(), provider, "from-env")
+	require.NoError(t, err)
+
+	t.Setenv("ENCENV_CONFIG_KEY", encoded)
+	t.Setenv("ENCENV_LLM_API_KEY", sealed)
+	cfg, err := NewLoader().WithEnvPrefix("ENCENV").Load()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.LLM.APIKey)
+}
+
+func TestLoader_UsesVaultTransitFromEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.URL.Path {
+		case "/v1/kms/encrypt/config":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}})
+		case "/v1/kms/decrypt/config":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("ENCVAULT_CONFIG_VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "s.token")
+	t.Setenv("ENCVAULT_CONFIG_VAULT_TRANSIT_KEY", "config")
+	t.Setenv("ENCVAULT_CONFIG_VAULT_TRANSIT_MOUNT", "kms")
+	vault, err := cryptoutil.NewVaultTransitKeyProvider(cryptoutil.VaultTransitConfig{Address: server.URL, Token: "s.token", KeyName: "config", Mount: "kms"})
+	require.NoError(t, err)
+	sealed, err := EncryptValue(context.Background(), vault, "from-vault")
+	require.NoError(t, err)
+
+	t.Setenv("ENCVAULT_LLM_API_KEY", sealed)
+	cfg, err := NewLoader().WithEnvPrefix("ENCVAULT").Load()
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", cfg.LLM.APIKey)
+}
+
+func TestLoader_SealedValueWithoutKeyFails(t *testing.T) {
+	provider, _ := newTestKeyProvider(t)
+	sealed, err := EncryptValue(context.Background(), provider, "secret")
+	require.NoError(t, err)
+
+	t.Setenv("ENCMISS_REDIS_PASSWORD", sealed)
+	_, err = NewLoader().WithEnvPrefix("ENCMISS").Load()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cryptoutil.ErrUnknownKeyProvider)
+	assert.Contains(t, err.Error(), "Redis.Password")
+}
+
+func TestSealYAML(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newTestKeyProvider(t)
+	input := []byte(`# 生产配置
+llm:
+  provider: openai
+  api_key: sk-live-123 # 主 key
+  max_tokens: 4096
+database:
+  password: db-pass
+agent:
+  model: gpt-4o
+`)
+
+	out, err := SealYAML(ctx, input, provider)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "# 生产配置")
+	assert.NotContains(t, string(out), "sk-live-123")
+	assert.NotContains(t, string(out), "db-pass")
+
+	var doc map[string]map[string]any
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+	assert.Equal(t, "openai", doc["llm"]["provider"])
+	assert.Equal(t, 4096, doc["llm"]["max_tokens"])
+	assert.True(t, cryptoutil.IsSealed(doc["llm"]["api_key"].(string)))
+
+	// 已加密的值保持不变；显式路径只加密指定键
+	again, err := SealYAML(ctx, out, provider)
+	require.NoError(t, err)
+	assert.Equal(t, string(out), string(again))
+
+	only, err := SealYAML(ctx, input, provider, "agent.model")
+	require.NoError(t, err)
+	assert.Contains(t, string(only), "sk-live-123")
+	assert.NotContains(t, string(only), "gpt-4o")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, out, 0o600))
+	cfg, err := NewLoader().WithConfigPath(path).WithEnvPrefix("ENCYAML").WithKeyProvider(provider).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-live-123", cfg.LLM.APIKey)
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+}
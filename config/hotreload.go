@@ -24,6 +24,7 @@ type HotReloadManager struct {
 	// 当前配置
 	config     *Config
 	configPath string
+	loader     *Loader // 重新加载文件时使用，沿用启动时的环境变量前缀、验证器与密钥提供者
 
 	// 回滚支持
 	previousConfig *Config          // 上一个成功应用的配置（用于回滚）
@@ -198,6 +199,15 @@ func WithConfigPath(path string) HotReloadOption {
 	}
 }
 
+// WithLoader 设置重新加载配置文件时使用的加载器。
+// 传入启动时的 Loader 可复用其 WithKeyProvider 配置的密钥提供者，
+// 否则重新加载时无法解密只能由这些提供者解密的 ENC[...] 值。
+func WithLoader(loader *Loader) HotReloadOption {
+	return func(m *HotReloadManager) {
+		m.loader = loader
+	}
+}
+
 // WithMaxHistorySize 设置配置历史最大记录数
 func WithMaxHistorySize(size int) HotReloadOption {
 	return func(m *HotReloadManager) {
@@ -401,8 +411,11 @@ func (m *HotReloadManager) ReloadFromFile() error {
 		return fmt.Errorf("no config path set")
 	}
 
-	loader := NewLoader().WithConfigPath(m.configPath)
-	newConfig, err := loader.Load()
+	loader := NewLoader()
+	if m.loader != nil {
+		loader = m.loader.clone()
+	}
+	newConfig, err := loader.WithConfigPath(m.configPath).Load()
 	if err != nil {
 		m.logger.Error("failed to load config from file, keeping current config",
 			zap.Error(err), zap.String("path", m.configPath))
@@ -944,15 +957,6 @@ func sanitizeConfig(cfg *Config) map[string]any {
 
 // redactSensitiveFields 递归地编辑敏感字段
 func redactSensitiveFields(data map[string]any, prefix string) {
-	sensitiveKeys := map[string]bool{
-		"password":   true,
-		"api_key":    true,
-		"apikey":     true,
-		"secret":     true,
-		"token":      true,
-		"credential": true,
-	}
-
 	for key, value := range data {
 		fullPath := key
 		if prefix != "" {
@@ -960,21 +964,17 @@ func redactSensitiveFields(data map[string]any, prefix string) {
 		}
 
 		// 检查这是否是敏感字段
-		lowerKey := strings.ToLower(key)
-		for sensitiveKey := range sensitiveKeys {
-			if strings.Contains(lowerKey, sensitiveKey) {
-				switch v := value.(type) {
-				case string:
-					if v != "" {
-						data[key] = "[REDACTED]"
-					}
-				case []any:
-					// X-005: 脱敏 API Keys 等敏感字段的切片值
-					if len(v) > 0 {
-						data[key] = "[REDACTED]"
-					}
+		if isSensitiveConfigKey(key) {
+			switch v := value.(type) {
+			case string:
+				if v != "" {
+					data[key] = "[REDACTED]"
+				}
+			case []any:
+				// X-005: 脱敏 API Keys 等敏感字段的切片值
+				if len(v) > 0 {
+					data[key] = "[REDACTED]"
 				}
-				break
 			}
 		}
 
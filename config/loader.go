@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BaSui01/agentflow/pkg/cryptoutil"
 	"gopkg.in/yaml.v3"
 )
 
@@ -102,6 +103,9 @@ type Config struct {
 
 	// RAG RAG 检索配置
 	RAG RAGConfig `yaml:"rag" env:"RAG"`
+
+	// Audit 统一审计轨迹配置
+	Audit AuditTrailConfig `yaml:"audit" env:"AUDIT"`
 }
 
 // ServerConfig 服务器配置
@@ -184,6 +188,61 @@ type AgentConfig struct {
 	Memory MemoryConfig `yaml:"memory" env:"MEMORY"`
 	// 检查点配置
 	Checkpoint CheckpointConfig `yaml:"checkpoint" env:"CHECKPOINT"`
+	// 护栏配置
+	Guardrails AgentGuardrailsConfig `yaml:"guardrails" env:"GUARDRAILS"`
+	// 声明式 Agent 定义文件或目录（.yaml/.yml/.json），由 sdk.Bootstrap 加载并注册
+	DefinitionPaths []string `yaml:"definition_paths" env:"DEFINITION_PATHS"`
+}
+
+// AgentGuardrailsConfig Agent 输入/输出护栏配置
+type AgentGuardrailsConfig struct {
+	// 是否启用护栏
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// 输入最大长度，0 表示不限制
+	MaxInputLength int `yaml:"max_input_length" env:"MAX_INPUT_LENGTH"`
+	// 禁止的关键词
+	BlockedKeywords []string `yaml:"blocked_keywords" env:"BLOCKED_KEYWORDS"`
+	// 是否启用 PII 检测
+	PIIDetection bool `yaml:"pii_detection" env:"PII_DETECTION"`
+	// PII 地区规则包，如 zh-CN、eu、ja-JP、pt-BR；auto 表示按内容语言自动选择，为空时仅使用默认规则
+	PIILocales []string `yaml:"pii_locales" env:"PII_LOCALES"`
+	// 是否启用提示注入检测
+	InjectionDetection bool `yaml:"injection_detection" env:"INJECTION_DETECTION"`
+	// 是否在输出中脱敏 API Key、私钥、连接串等凭据
+	SecretsDetection bool `yaml:"secrets_detection" env:"SECRETS_DETECTION"`
+	// 声明式护栏策略 YAML 文件路径，配置热重载时重新加载
+	PolicyFile string `yaml:"policy_file" env:"POLICY_FILE" reload:"Guardrail policy file" restart:"false" sensitive:"false"`
+	// 输入校验失败时的处理方式: reject, warn, retry
+	OnInputFailure string `yaml:"on_input_failure" env:"ON_INPUT_FAILURE"`
+	// 输出校验失败时的处理方式: reject, warn, retry
+	OnOutputFailure string `yaml:"on_output_failure" env:"ON_OUTPUT_FAILURE"`
+	// 校验结论审计日志
+	Audit GuardrailAuditConfig `yaml:"audit" env:"AUDIT"`
+}
+
+// GuardrailAuditConfig 护栏审计日志配置，记录每个验证器的校验结论并可通过 API 查询
+type GuardrailAuditConfig struct {
+	// 是否启用审计日志
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// 内存环形缓冲容量，0 表示默认 10000
+	MaxEntries int `yaml:"max_entries" env:"MAX_ENTRIES"`
+	// JSON Lines 审计文件路径，为空时不写文件
+	FilePath string `yaml:"file_path" env:"FILE_PATH"`
+	// 是否将校验结论作为 span 事件随 OTLP trace 导出
+	OTelEvents bool `yaml:"otel_events" env:"OTEL_EVENTS"`
+}
+
+// AuditTrailConfig 统一审计轨迹配置，汇聚配置变更、Key 轮换、护栏拦截、
+// 人工审批与预算覆盖，并可通过 /api/v1/audit 查询或导出
+type AuditTrailConfig struct {
+	// 是否启用统一审计
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// 内存环形缓冲容量，0 表示默认 10000
+	MaxEntries int `yaml:"max_entries" env:"MAX_ENTRIES"`
+	// 审计文件路径，为空时不写文件
+	FilePath string `yaml:"file_path" env:"FILE_PATH"`
+	// 审计文件格式：jsonl（默认）或 cef
+	FileFormat string `yaml:"file_format" env:"FILE_FORMAT"`
 }
 
 // CheckpointConfig Agent 检查点存储配置。
@@ -399,6 +458,121 @@ type LLMConnectionConfig struct {
 	ToolMaxRetries int `yaml:"tool_max_retries" env:"TOOL_MAX_RETRIES"`
 	// 模型目录 JSON 快照路径（可选，未设置时使用内置默认快照）。
 	ModelCatalogPath string `yaml:"model_catalog_path" env:"MODEL_CATALOG_PATH"`
+	// 启动时从 default_provider 的模型列表导入定价（仅 openrouter/together），
+	// 写入成本计算器与模型目录；拉取失败只记录告警，继续使用内置价格。
+	ImportModelPricing bool `yaml:"import_model_pricing" env:"IMPORT_MODEL_PRICING"`
+	// 能力描述覆盖（可选，仅支持文件配置）。键为 provider 或 provider/model，
+	// 覆盖项整体替换 provider 自身声明的能力，用于请求协商。
+	CapabilityOverrides map[string]CapabilityOverrideConfig `yaml:"capability_overrides" env:"-"`
+	// 各 provider 公布的限额（可选，仅支持文件配置）。键为 provider code，
+	// 路由器据此优先选择仍有余量的 provider，并在配额不足时排队等待。
+	ProviderRateLimits map[string]ProviderRateLimitConfig `yaml:"provider_rate_limits" env:"-"`
+	// 配额不足时请求排队等待的最长时间（可选，0 使用默认 10s，负数不等待）
+	RateLimitMaxWait time.Duration `yaml:"rate_limit_max_wait" env:"RATE_LIMIT_MAX_WAIT"`
+	// 所有 provider 共享的 HTTP 传输层调优（零值字段使用默认值）
+	Transport LLMTransportConfig `yaml:"transport" env:"TRANSPORT"`
+	// 基于 Redis 的跨副本限流（按 tenant/provider/model 计数，使用 redis 段的连接配置）
+	DistributedRateLimit DistributedRateLimitConfig `yaml:"distributed_rate_limit" env:"DISTRIBUTED_RATE_LIMIT"`
+	// gateway 前的优先级调度（interactive / background / batch 分级排队与准入）
+	PriorityScheduler PrioritySchedulerConfig `yaml:"priority_scheduler" env:"PRIORITY_SCHEDULER"`
+}
+
+// PrioritySchedulerConfig gateway 优先级调度配置
+type PrioritySchedulerConfig struct {
+	// 是否启用
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// 所有级别共享的并发上限，0 表示不限制
+	MaxConcurrent int `yaml:"max_concurrent" env:"MAX_CONCURRENT"`
+	// 未标注优先级请求的级别，默认 interactive
+	DefaultClass string `yaml:"default_class" env:"DEFAULT_CLASS"`
+	// 按级别（interactive / background / batch）覆盖准入配置（仅支持文件配置），缺省级别使用内置默认值
+	Classes map[string]PriorityClassConfig `yaml:"classes" env:"-"`
+}
+
+// PriorityClassConfig 单个优先级的准入配置
+type PriorityClassConfig struct {
+	// 该级别最大并发，0 表示不单独限制
+	MaxConcurrent int `yaml:"max_concurrent"`
+	// 最大排队数，0 表示不排队
+	MaxQueue int `yaml:"max_queue"`
+	// 排队最长等待时间，0 表示只受请求 ctx 约束
+	MaxWait time.Duration `yaml:"max_wait"`
+	// 上游限流压力达到该值时暂停放行，0 表示不延迟
+	DelayAbovePressure float64 `yaml:"delay_above_pressure"`
+	// 上游限流压力达到该值时直接拒绝，0 表示不丢弃
+	ShedAbovePressure float64 `yaml:"shed_above_pressure"`
+}
+
+// DistributedRateLimitConfig Redis 分布式限流配置
+type DistributedRateLimitConfig struct {
+	// 是否启用
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// Redis key 前缀，默认 "agentflow:ratelimit:"
+	Prefix string `yaml:"prefix" env:"PREFIX"`
+	// 只计数与记录判定结果，不拒绝请求
+	DryRun bool `yaml:"dry_run" env:"DRY_RUN"`
+	// 未命中 quotas 时每秒请求数上限，0 表示不限
+	RequestsPerSecond int `yaml:"requests_per_second" env:"REQUESTS_PER_SECOND"`
+	// 未命中 quotas 时每分钟 token 上限，0 表示不限
+	TokensPerMinute int `yaml:"tokens_per_minute" env:"TOKENS_PER_MINUTE"`
+	// 按 "tenant/provider/model" 覆盖配额（仅支持文件配置），provider、model 段可写 "*"
+	Quotas map[string]DistributedRateLimitQuota `yaml:"quotas" env:"-"`
+}
+
+// DistributedRateLimitQuota 单个 key 的分布式限流配额，0 表示该维度不限
+type DistributedRateLimitQuota struct {
+	RequestsPerSecond int `yaml:"requests_per_second"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+}
+
+// LLMTransportConfig provider 共享 HTTP 传输层配置
+type LLMTransportConfig struct {
+	// 全局最大空闲连接数
+	MaxIdleConns int `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS"`
+	// 每个 host 最大空闲连接数
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host" env:"MAX_IDLE_CONNS_PER_HOST"`
+	// 每个 host 最大连接数，0 表示不限制
+	MaxConnsPerHost int `yaml:"max_conns_per_host" env:"MAX_CONNS_PER_HOST"`
+	// 空闲连接保留时间
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout" env:"IDLE_CONN_TIMEOUT"`
+	// TLS 握手超时
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout" env:"TLS_HANDSHAKE_TIMEOUT"`
+	// HTTP/2 连接空闲多久后发送 PING 探活，负数关闭
+	HTTP2PingInterval time.Duration `yaml:"http2_ping_interval" env:"HTTP2_PING_INTERVAL"`
+	// HTTP/2 PING 超时
+	HTTP2PingTimeout time.Duration `yaml:"http2_ping_timeout" env:"HTTP2_PING_TIMEOUT"`
+	// DNS 解析缓存时间，负数关闭
+	DNSCacheTTL time.Duration `yaml:"dns_cache_ttl" env:"DNS_CACHE_TTL"`
+	// TLS 会话缓存容量
+	TLSSessionCacheSize int `yaml:"tls_session_cache_size" env:"TLS_SESSION_CACHE_SIZE"`
+	// 按 provider 配置代理 URL（仅支持文件配置），"direct" 表示直连
+	Proxies map[string]string `yaml:"proxies" env:"-"`
+}
+
+// ProviderRateLimitConfig provider 公布的每分钟限额，0 表示不限制
+type ProviderRateLimitConfig struct {
+	// 每分钟请求数
+	RPM int `yaml:"rpm"`
+	// 每分钟 token 数
+	TPM int `yaml:"tpm"`
+}
+
+// CapabilityOverrideConfig provider / 模型能力描述覆盖
+type CapabilityOverrideConfig struct {
+	// 上下文窗口 token 数，0 表示未知
+	MaxContextTokens int `yaml:"max_context_tokens"`
+	// 单次最大输出 token 数，0 表示未知
+	MaxOutputTokens int `yaml:"max_output_tokens"`
+	// 是否支持图片输入
+	SupportsVision bool `yaml:"supports_vision"`
+	// 是否支持原生工具调用
+	SupportsTools bool `yaml:"supports_tools"`
+	// 是否支持原生 JSON 模式
+	SupportsJSONMode bool `yaml:"supports_json_mode"`
+	// 是否支持流式工具调用
+	SupportsStreamingToolCalls bool `yaml:"supports_streaming_tool_calls"`
+	// 支持的输入模态（text/image/audio/video），为空表示不限制
+	Modalities []string `yaml:"modalities"`
 }
 
 // NormalizeLLMMainProviderMode canonicalizes configured main provider mode.
@@ -604,9 +778,10 @@ type BingToolConfig struct {
 
 // Loader 配置加载器（Builder 模式）
 type Loader struct {
-	configPath string
-	envPrefix  string
-	validators []func(*Config) error
+	configPath   string
+	envPrefix    string
+	validators   []func(*Config) error
+	keyProviders []cryptoutil.KeyProvider
 }
 
 // NewLoader 创建新的配置加载器
@@ -617,6 +792,14 @@ func NewLoader() *Loader {
 	}
 }
 
+// clone 返回加载器的副本，修改副本的配置路径不影响原加载器
+func (l *Loader) clone() *Loader {
+	cp := *l
+	cp.validators = append([]func(*Config) error(nil), l.validators...)
+	cp.keyProviders = append([]cryptoutil.KeyProvider(nil), l.keyProviders...)
+	return &cp
+}
+
 // WithConfigPath 设置配置文件路径
 func (l *Loader) WithConfigPath(path string) *Loader {
 	l.configPath = path
@@ -636,7 +819,7 @@ func (l *Loader) WithValidator(v func(*Config) error) *Loader {
 }
 
 // Load 加载配置
-// 优先级: 默认值 → YAML 文件 → 环境变量
+// 优先级: 默认值 → YAML 文件 → 环境变量，随后解密 ENC[...] 值
 func (l *Loader) Load() (*Config, error) {
 	// 1. 从默认值开始
 	cfg := DefaultConfig()
@@ -653,6 +836,11 @@ func (l *Loader) Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load config from env: %w", err)
 	}
 
+	// 3.1 解密 ENC[...] 加密值
+	if err := l.openSealedValues(cfg); err != nil {
+		return nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
+
 	// 4. X-012: JWT 默认 exp 值
 	if (cfg.Server.JWT.Secret != "" || cfg.Server.JWT.PublicKey != "") && cfg.Server.JWT.Expiration == 0 {
 		cfg.Server.JWT.Expiration = time.Hour
@@ -858,6 +1046,21 @@ func (c *Config) Validate() error {
 			errs = append(errs, "agent.checkpoint.backend must be one of: file, redis, postgres")
 		}
 	}
+	switch strings.TrimSpace(strings.ToLower(c.RAG.VectorStore)) {
+	case "", "memory", "qdrant", "weaviate", "milvus", "pinecone":
+	default:
+		errs = append(errs, "rag.vector_store must be one of: memory, qdrant, weaviate, milvus, pinecone")
+	}
+	switch strings.TrimSpace(strings.ToLower(c.Agent.Guardrails.OnInputFailure)) {
+	case "", "reject", "warn", "retry":
+	default:
+		errs = append(errs, "agent.guardrails.on_input_failure must be one of: reject, warn, retry")
+	}
+	switch strings.TrimSpace(strings.ToLower(c.Agent.Guardrails.OnOutputFailure)) {
+	case "", "reject", "warn", "retry":
+	default:
+		errs = append(errs, "agent.guardrails.on_output_failure must be one of: reject, warn, retry")
+	}
 	if c.Multimodal.ReferenceMaxSizeBytes <= 0 {
 		errs = append(errs, "multimodal.reference_max_size_bytes must be positive")
 	}
@@ -871,6 +1074,28 @@ func (c *Config) Validate() error {
 	if c.Multimodal.Enabled && strings.TrimSpace(c.Redis.Addr) == "" {
 		errs = append(errs, "redis.addr is required when multimodal.reference_store_backend=redis")
 	}
+	if c.LLM.DistributedRateLimit.Enabled {
+		if strings.TrimSpace(c.Redis.Addr) == "" {
+			errs = append(errs, "redis.addr is required when llm.distributed_rate_limit.enabled=true")
+		}
+		if c.LLM.DistributedRateLimit.RequestsPerSecond < 0 || c.LLM.DistributedRateLimit.TokensPerMinute < 0 {
+			errs = append(errs, "llm.distributed_rate_limit quotas must not be negative")
+		}
+	}
+	if c.LLM.PriorityScheduler.Enabled {
+		for class := range c.LLM.PriorityScheduler.Classes {
+			switch strings.ToLower(strings.TrimSpace(class)) {
+			case "interactive", "background", "batch":
+			default:
+				errs = append(errs, fmt.Sprintf("llm.priority_scheduler.classes has unknown class %q (want interactive, background or batch)", class))
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(c.LLM.PriorityScheduler.DefaultClass)) {
+		case "", "interactive", "background", "batch":
+		default:
+			errs = append(errs, "llm.priority_scheduler.default_class must be one of: interactive, background, batch")
+		}
+	}
 	if c.HostedTools.Approval.GrantTTL <= 0 {
 		errs = append(errs, "hosted_tools.approval.grant_ttl must be positive")
 	}
@@ -1009,6 +1234,18 @@ type CacheConfig struct {
 	RedisTTL time.Duration `yaml:"redis_ttl" env:"REDIS_TTL"`
 	// 缓存键策略: hash | hierarchical
 	KeyStrategy string `yaml:"key_strategy" env:"KEY_STRATEGY"`
+	// 是否在租户作用域内再按用户隔离缓存
+	IsolateByUser bool `yaml:"isolate_by_user" env:"ISOLATE_BY_USER"`
+	// 租户级缓存策略（按租户 ID 索引），仅支持文件配置
+	TenantPolicies map[string]TenantCachePolicyConfig `yaml:"tenant_policies" env:"-"`
+}
+
+// TenantCachePolicyConfig 租户级缓存策略
+type TenantCachePolicyConfig struct {
+	// 是否禁用该租户的响应缓存
+	Disabled bool `yaml:"disabled"`
+	// 该租户的缓存 TTL，为 0 时使用全局 TTL
+	TTL time.Duration `yaml:"ttl"`
 }
 
 // BudgetConfig Token 预算管理配置
@@ -1027,6 +1264,16 @@ type BudgetConfig struct {
 	MaxCostPerRequest float64 `yaml:"max_cost_per_request" env:"MAX_COST_PER_REQUEST"`
 	// 每天最大花费 (USD)
 	MaxCostPerDay float64 `yaml:"max_cost_per_day" env:"MAX_COST_PER_DAY"`
+	// 每自然月最大 Token 数，0 表示不限制
+	MaxTokensPerMonth int `yaml:"max_tokens_per_month" env:"MAX_TOKENS_PER_MONTH"`
+	// 每自然月最大花费 (USD)，0 表示不限制
+	MaxCostPerMonth float64 `yaml:"max_cost_per_month" env:"MAX_COST_PER_MONTH"`
+	// 每自然季度最大 Token 数，0 表示不限制
+	MaxTokensPerQuarter int `yaml:"max_tokens_per_quarter" env:"MAX_TOKENS_PER_QUARTER"`
+	// 每自然季度最大花费 (USD)，0 表示不限制
+	MaxCostPerQuarter float64 `yaml:"max_cost_per_quarter" env:"MAX_COST_PER_QUARTER"`
+	// 日/月/季度窗口重置时区 (IANA 名称)，默认 UTC
+	Timezone string `yaml:"timezone" env:"TIMEZONE"`
 	// 告警阈值 (0.0-1.0)
 	AlertThreshold float64 `yaml:"alert_threshold" env:"ALERT_THRESHOLD"`
 	// 是否在达到分钟阈值时自动节流
@@ -1037,6 +1284,14 @@ type BudgetConfig struct {
 
 // RAGConfig RAG 检索配置
 type RAGConfig struct {
+	// 是否由 sdk.Bootstrap 装配 RAG 检索管线
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// 向量存储后端: memory, qdrant, weaviate, milvus, pinecone；空值按 memory 处理
+	VectorStore string `yaml:"vector_store" env:"VECTOR_STORE"`
+	// Embedding 提供者类型（可选，未设置时回退 llm.default_provider）
+	EmbeddingProvider string `yaml:"embedding_provider" env:"EMBEDDING_PROVIDER"`
+	// Rerank 提供者类型（可选，未设置时回退 llm.default_provider）
+	RerankProvider string `yaml:"rerank_provider" env:"RERANK_PROVIDER"`
 	// WebSearch 网络检索增强配置
 	WebSearch RAGWebSearchConfig `yaml:"web_search" env:"WEB_SEARCH"`
 }
@@ -498,6 +498,20 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid rag vector store",
+			modify: func(c *Config) {
+				c.RAG.VectorStore = "faiss"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid agent guardrails failure action",
+			modify: func(c *Config) {
+				c.Agent.Guardrails.OnOutputFailure = "ignore"
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,82 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/BaSui01/agentflow/agent/adapters/declarative"
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/api/handlers"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AgentDefinitionRuntimeAdapter adapts CachingResolver to appservice.AgentDefinitionRuntime.
+// LLM hot reload rebuilds the resolver, so the adapter holds a swappable reference.
+type AgentDefinitionRuntimeAdapter struct {
+	resolver atomic.Pointer[agent.CachingResolver]
+}
+
+// NewAgentDefinitionRuntimeAdapter creates an AgentDefinitionRuntimeAdapter.
+func NewAgentDefinitionRuntimeAdapter(resolver *agent.CachingResolver) *AgentDefinitionRuntimeAdapter {
+	a := &AgentDefinitionRuntimeAdapter{}
+	a.resolver.Store(resolver)
+	return a
+}
+
+// Deploy hot-instantiates the agent described by cfg on the current resolver.
+func (a *AgentDefinitionRuntimeAdapter) Deploy(ctx context.Context, cfg types.AgentConfig) error {
+	resolver := a.resolver.Load()
+	if resolver == nil {
+		return fmt.Errorf("agent resolver is not available")
+	}
+	_, err := resolver.Deploy(ctx, cfg)
+	return err
+}
+
+// Retire tears down the running agent and drops its deployed definition.
+func (a *AgentDefinitionRuntimeAdapter) Retire(ctx context.Context, agentID string) {
+	if resolver := a.resolver.Load(); resolver != nil {
+		resolver.Retire(ctx, agentID)
+	}
+}
+
+// SwapResolver points the adapter at a rebuilt resolver. Deployed definitions
+// are carried over and re-instantiated on first use.
+func (a *AgentDefinitionRuntimeAdapter) SwapResolver(next *agent.CachingResolver) {
+	if a == nil || next == nil {
+		return
+	}
+	if previous := a.resolver.Swap(next); previous != nil && previous != next {
+		next.InheritDefinitions(previous)
+	}
+}
+
+// BuildAgentDefinitionHandler creates the declarative agent definition handler
+// and deploys every stored active definition. It returns nils without a database.
+func BuildAgentDefinitionHandler(
+	ctx context.Context,
+	db *gorm.DB,
+	resolver *agent.CachingResolver,
+	logger *zap.Logger,
+) (*handlers.AgentDefinitionHandler, *AgentDefinitionRuntimeAdapter) {
+	if db == nil || resolver == nil {
+		return nil, nil
+	}
+	runtime := NewAgentDefinitionRuntimeAdapter(resolver)
+	service := appservice.NewDefaultAgentDefinitionService(
+		declarative.NewGormDefinitionStore(db),
+		runtime,
+		logger,
+	)
+	deployed, err := service.Restore(ctx)
+	if err != nil {
+		logger.Warn("Failed to restore stored agent definitions", zap.Error(err))
+	} else if deployed > 0 {
+		logger.Info("Stored agent definitions deployed", zap.Int("count", deployed))
+	}
+	return handlers.NewAgentDefinitionHandler(service, logger), runtime
+}
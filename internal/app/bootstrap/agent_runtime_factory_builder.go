@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
 	agenttools "github.com/BaSui01/agentflow/agent/capabilities/tools"
 	"github.com/BaSui01/agentflow/agent/runtime"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
@@ -40,6 +41,32 @@ func RegisterDefaultRuntimeAgentFactoryWithAuthorization(
 		Authorize(context.Context, types.AuthorizationRequest) (*types.AuthorizationDecision, error)
 	},
 	logger *zap.Logger,
+) {
+	RegisterDefaultRuntimeAgentFactoryWithOptions(agentRegistry, gateway, toolGateway, checkpointManager, modelCatalog, ledger, RuntimeAgentFactoryOptions{
+		AuthorizationService: authorizationService,
+	}, logger)
+}
+
+// RuntimeAgentFactoryOptions carries optional shared services injected into
+// every agent built by the default runtime factory.
+type RuntimeAgentFactoryOptions struct {
+	AuthorizationService interface {
+		Authorize(context.Context, types.AuthorizationRequest) (*types.AuthorizationDecision, error)
+	}
+	GuardrailAuditLogger *guardrails.GuardrailAuditLogger
+}
+
+// RegisterDefaultRuntimeAgentFactoryWithOptions wires the default
+// runtime-backed agent factory with the given shared services.
+func RegisterDefaultRuntimeAgentFactoryWithOptions(
+	agentRegistry *agent.AgentRegistry,
+	gateway llmcore.Gateway,
+	toolGateway llmcore.Gateway,
+	checkpointManager *agent.CheckpointManager,
+	modelCatalog *types.ModelCatalog,
+	ledger observability.Ledger,
+	factoryOpts RuntimeAgentFactoryOptions,
+	logger *zap.Logger,
 ) {
 	if gateway == nil {
 		return
@@ -67,9 +94,10 @@ func RegisterDefaultRuntimeAgentFactoryWithAuthorization(
 			CheckpointManager:    checkpointManager,
 			ModelCatalog:         modelCatalog,
 		}
-		if authorizationService != nil {
-			opts.Authorize = authorizationService.Authorize
+		if factoryOpts.AuthorizationService != nil {
+			opts.Authorize = factoryOpts.AuthorizationService.Authorize
 		}
+		opts.GuardrailAuditLogger = factoryOpts.GuardrailAuditLogger
 		opts.EnableAll = false
 		if factoryLogger == nil {
 			factoryLogger = logger
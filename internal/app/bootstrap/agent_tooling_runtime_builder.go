@@ -37,6 +37,9 @@ type AgentToolingOptions struct {
 	ToolApprovalManager  *hitl.InterruptManager
 	ToolApprovalConfig   ToolApprovalConfig
 	AuthorizationService usecase.AuthorizationService
+	// ToolSecretResolver resolves secret refs of HTTP-backed tool registrations.
+	// Defaults to environment variables prefixed with hosted.DefaultToolSecretEnvPrefix.
+	ToolSecretResolver hosted.SecretResolver
 }
 
 // AgentToolingRuntime groups runtime-managed tools exposed to Agent execution.
@@ -48,6 +51,7 @@ type AgentToolingRuntime struct {
 	AuthorizationService usecase.AuthorizationService
 
 	db               *gorm.DB
+	secrets          hosted.SecretResolver
 	logger           *zap.Logger
 	mu               sync.RWMutex
 	baseToolNames    map[string]struct{}
@@ -115,6 +119,10 @@ func (r *AgentToolingRuntime) ReloadBindings(ctx context.Context) error {
 	r.dynamicToolNames = make(map[string]struct{}, len(rows))
 
 	for _, row := range rows {
+		if row.IsHTTP() {
+			r.bindHTTPToolLocked(row)
+			continue
+		}
 		aliasName := strings.TrimSpace(row.Name)
 		targetName := strings.TrimSpace(row.Target)
 		if aliasName == "" || targetName == "" {
@@ -165,6 +173,44 @@ func (r *AgentToolingRuntime) ReloadBindings(ctx context.Context) error {
 	return nil
 }
 
+// bindHTTPToolLocked registers an HTTP-backed tool declared by a registration row.
+func (r *AgentToolingRuntime) bindHTTPToolLocked(row hosted.ToolRegistration) {
+	name := strings.TrimSpace(row.Name)
+	if _, reserved := r.baseToolNames[name]; reserved {
+		r.logger.Warn("skip tool registration using reserved base tool name",
+			zap.Uint("id", row.ID),
+			zap.String("name", name))
+		return
+	}
+	tmpl, err := hosted.ParseHTTPToolTemplate(row.HTTPConfig)
+	if err == nil {
+		var tool *hosted.HTTPHostedTool
+		tool, err = hosted.NewHTTPHostedTool(name, row.Description, row.Parameters, tmpl, r.secrets)
+		if err == nil {
+			r.Registry.Register(tool)
+			r.dynamicToolNames[name] = struct{}{}
+			return
+		}
+	}
+	r.logger.Warn("skip invalid http tool registration",
+		zap.Uint("id", row.ID),
+		zap.String("name", name),
+		zap.Error(err))
+}
+
+// ValidateSecretRefs checks that every referenced secret can be resolved.
+func (r *AgentToolingRuntime) ValidateSecretRefs(ctx context.Context, names []string) error {
+	if r == nil {
+		return nil
+	}
+	for _, name := range names {
+		if _, err := r.secrets.ResolveSecret(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // BuildAgentToolingRuntime creates a hosted tool registry and ToolManager bridge
 // for agent runtime. It supports retrieval (RAG) and optional MCP tool bridging.
 func BuildAgentToolingRuntime(opts AgentToolingOptions, logger *zap.Logger) (*AgentToolingRuntime, error) {
@@ -238,16 +284,23 @@ func BuildAgentToolingRuntime(opts AgentToolingOptions, logger *zap.Logger) (*Ag
 	}
 
 	var manager agent.ToolManager
-	if len(registry.List()) > 0 {
+	// DB-managed registrations may add tools later, so keep a manager ready.
+	if len(registry.List()) > 0 || opts.DB != nil {
 		manager = newHostedToolManager(registry, permissionManager, authorizationService, logger)
 	}
 
+	secrets := opts.ToolSecretResolver
+	if secrets == nil {
+		secrets = hosted.NewEnvSecretResolver("")
+	}
+
 	runtime := &AgentToolingRuntime{
 		Registry:             registry,
 		ToolManager:          manager,
 		Permissions:          permissionManager,
 		AuthorizationService: authorizationService,
 		db:                   opts.DB,
+		secrets:              secrets,
 		logger:               logger.With(zap.String("component", "agent_tooling_runtime")),
 		baseToolNames:        baseToolNames,
 		dynamicToolNames:     make(map[string]struct{}, 8),
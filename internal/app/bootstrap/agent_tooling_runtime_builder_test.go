@@ -3,6 +3,9 @@ package bootstrap
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -289,6 +292,68 @@ func TestBuildAgentToolingRuntime_HighRiskAliasesPreserveAuthorizationShape(t *t
 	assert.Equal(t, types.RiskExecution, byResource["safe_echo"].RiskTier)
 }
 
+type staticToolSecrets map[string]string
+
+func (s staticToolSecrets) ResolveSecret(_ context.Context, name string) (string, error) {
+	if value, ok := s[name]; ok {
+		return value, nil
+	}
+	return "", errors.New("secret not found: " + name)
+}
+
+func TestBuildAgentToolingRuntime_BindsHTTPToolRegistrations(t *testing.T) {
+	t.Setenv("AGENTFLOW_ALLOW_PRIVATE_URLS", "1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k-123" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"city":"` + r.URL.Query().Get("city") + `"}`))
+	}))
+	defer server.Close()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&hosted.ToolRegistration{}))
+	require.NoError(t, db.AutoMigrate(&hosted.ToolProviderConfig{}))
+
+	auth := &toolingAuthorizationServiceStub{}
+	runtime, err := BuildAgentToolingRuntime(AgentToolingOptions{
+		DB:                   db,
+		ToolSecretResolver:   staticToolSecrets{"WEATHER_KEY": "k-123"},
+		AuthorizationService: auth,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, runtime.ToolManager, "tool manager must exist so runtime registrations are usable")
+	assert.NoError(t, runtime.ValidateSecretRefs(context.Background(), []string{"WEATHER_KEY"}))
+	assert.Error(t, runtime.ValidateSecretRefs(context.Background(), []string{"OTHER"}))
+
+	row := &hosted.ToolRegistration{
+		Name:       "weather",
+		Kind:       hosted.ToolRegistrationKindHTTP,
+		Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		HTTPConfig: json.RawMessage(`{"method":"GET","url":"` + server.URL + `","query":{"city":"{{city}}"},"headers":{"X-Api-Key":"{{secret:WEATHER_KEY}}"},"traits":{"side_effect":"none"}}`),
+		Enabled:    true,
+	}
+	require.NoError(t, db.Create(row).Error)
+	require.NoError(t, runtime.ReloadBindings(context.Background()))
+	assert.Contains(t, runtime.ToolNames, "weather")
+
+	results := runtime.ToolManager.ExecuteForAgent(context.Background(), "agent-a", []types.ToolCall{
+		{ID: "call-weather", Name: "weather", Arguments: json.RawMessage(`{"city":"Paris"}`)},
+	})
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Error, "approval required", "declared traits cannot downgrade http tools")
+	assert.Empty(t, results[0].Result)
+
+	require.NoError(t, db.Model(row).Update("enabled", false).Error)
+	require.NoError(t, runtime.ReloadBindings(context.Background()))
+	assert.NotContains(t, runtime.ToolNames, "weather")
+	_, ok := runtime.Registry.Get("weather")
+	assert.False(t, ok)
+}
+
 func TestBuildAgentToolingRuntime_WithMCPTools(t *testing.T) {
 	server := &testMCPServer{
 		tools: []mcpproto.ToolDefinition{
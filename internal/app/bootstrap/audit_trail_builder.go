@@ -0,0 +1,235 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/api/handlers"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"go.uber.org/zap"
+)
+
+const auditRedactedValue = "[REDACTED]"
+
+// BuildAuditTrail builds the unified audit trail from config. It returns nil
+// when auditing is disabled.
+func BuildAuditTrail(cfg config.AuditTrailConfig, logger *zap.Logger) (*audit.Trail, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	var sinks []audit.Sink
+	if path := strings.TrimSpace(cfg.FilePath); path != "" {
+		format := audit.FormatJSONLines
+		if raw := strings.TrimSpace(cfg.FileFormat); raw != "" {
+			parsed, err := audit.ParseFormat(raw)
+			if err != nil {
+				return nil, fmt.Errorf("build audit trail file sink: %w", err)
+			}
+			format = parsed
+		}
+		fileSink, err := audit.NewFileSink(path, format)
+		if err != nil {
+			return nil, fmt.Errorf("build audit trail file sink: %w", err)
+		}
+		sinks = append(sinks, fileSink)
+	}
+	return audit.NewTrail(audit.TrailConfig{
+		MaxEntries: cfg.MaxEntries,
+		Sinks:      sinks,
+		Logger:     logger,
+	}), nil
+}
+
+// NewGuardrailAuditTrailSink forwards non-allow guardrail decisions (blocks,
+// redactions, warnings, escalations and validator errors) into the audit trail.
+func NewGuardrailAuditTrailSink(recorder audit.Recorder) guardrails.AuditSink {
+	return guardrails.AuditSinkFunc(func(ctx context.Context, entry *guardrails.AuditLogEntry) error {
+		if entry == nil || entry.Decision == "" || entry.Decision == guardrails.AuditDecisionAllow {
+			return nil
+		}
+		outcome := audit.OutcomeSuccess
+		switch entry.Decision {
+		case guardrails.AuditDecisionBlock:
+			outcome = audit.OutcomeDenied
+		case guardrails.AuditDecisionError:
+			outcome = audit.OutcomeFailure
+		}
+		metadata := map[string]any{
+			"guardrail_audit_id": entry.ID,
+			"direction":          string(entry.Direction),
+			"content_hash":       entry.ContentHash,
+		}
+		if len(entry.MatchedRules) > 0 {
+			metadata["matched_rules"] = entry.MatchedRules
+		}
+		_, err := recorder.Record(ctx, audit.Event{
+			Timestamp: entry.Timestamp,
+			Category:  audit.CategoryGuardrail,
+			Action:    "guardrail." + string(entry.Decision),
+			Resource:  entry.ValidatorName,
+			Outcome:   outcome,
+			TenantID:  entry.TenantID,
+			TraceID:   entry.TraceID,
+			Metadata:  metadata,
+		})
+		return err
+	})
+}
+
+// RegisterConfigAuditTrail records applied config changes and rollbacks.
+// Budget.* changes are filed as budget overrides and API key fields as key
+// rotations so compliance reviews can filter them directly.
+func RegisterConfigAuditTrail(manager *config.HotReloadManager, recorder audit.Recorder, logger *zap.Logger) {
+	if manager == nil || recorder == nil {
+		return
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	sensitive := config.GetHotReloadableFields()
+	manager.OnChange(func(change config.ConfigChange) {
+		category, action := audit.CategoryConfig, "config.update"
+		switch {
+		case strings.HasPrefix(change.Path, "Budget."):
+			category, action = audit.CategoryBudget, "budget.override"
+		case isAPIKeyConfigPath(change.Path):
+			category, action = audit.CategoryAPIKey, "api_key.rotate"
+		}
+		before, after := change.OldValue, change.NewValue
+		if field, ok := sensitive[change.Path]; (ok && field.Sensitive) || isAPIKeyConfigPath(change.Path) {
+			before, after = auditRedactedValue, auditRedactedValue
+		}
+		outcome := audit.OutcomeSuccess
+		if !change.Applied {
+			outcome = audit.OutcomeFailure
+		}
+		if _, err := recorder.Record(context.Background(), audit.Event{
+			Timestamp:  change.Timestamp,
+			Category:   category,
+			Actor:      "config:" + change.Source,
+			Action:     action,
+			Resource:   "config",
+			ResourceID: change.Path,
+			Outcome:    outcome,
+			Before:     before,
+			After:      after,
+			Reason:     change.Error,
+			Metadata:   map[string]any{"requires_restart": change.RequiresRestart},
+		}); err != nil {
+			logger.Warn("failed to record config audit event", zap.String("path", change.Path), zap.Error(err))
+		}
+	})
+	manager.OnRollback(func(event config.RollbackEvent) {
+		outcome, reason := audit.OutcomeSuccess, event.Reason
+		if event.Error != nil {
+			outcome = audit.OutcomeFailure
+			reason = fmt.Sprintf("%s: %v", event.Reason, event.Error)
+		}
+		if _, err := recorder.Record(context.Background(), audit.Event{
+			Timestamp:  event.Timestamp,
+			Category:   audit.CategoryConfig,
+			Actor:      "config:rollback",
+			Action:     "config.rollback",
+			Resource:   "config",
+			ResourceID: fmt.Sprintf("version:%d", event.Version),
+			Outcome:    outcome,
+			Reason:     reason,
+		}); err != nil {
+			logger.Warn("failed to record config rollback audit event", zap.Error(err))
+		}
+	})
+}
+
+// RegisterHITLAuditTrail records every terminal interrupt state with the
+// reviewer as actor.
+func RegisterHITLAuditTrail(manager *hitl.InterruptManager, recorder audit.Recorder, logger *zap.Logger) {
+	if manager == nil || recorder == nil {
+		return
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	manager.OnResolution(func(ctx context.Context, interrupt *hitl.Interrupt) {
+		event := audit.Event{
+			Category:   audit.CategoryHITL,
+			Action:     "hitl." + string(interrupt.Status),
+			Resource:   "interrupt:" + string(interrupt.Type),
+			ResourceID: interrupt.ID,
+			Before:     string(hitl.InterruptStatusPending),
+			After:      string(interrupt.Status),
+			Metadata: map[string]any{
+				"workflow_id": interrupt.WorkflowID,
+				"node_id":     interrupt.NodeID,
+				"title":       interrupt.Title,
+			},
+		}
+		if interrupt.ResolvedAt != nil {
+			event.Timestamp = *interrupt.ResolvedAt
+		}
+		switch interrupt.Status {
+		case hitl.InterruptStatusRejected:
+			event.Outcome = audit.OutcomeDenied
+		case hitl.InterruptStatusTimeout:
+			event.Actor = "system:timeout"
+			event.Outcome = audit.OutcomeFailure
+		}
+		if response := interrupt.Response; response != nil {
+			if response.UserID != "" {
+				event.Actor = response.UserID
+			}
+			event.Reason = response.Comment
+			if response.OptionID != "" {
+				event.Metadata["option_id"] = response.OptionID
+			}
+		}
+		// 回调上下文可能已随中断结束而取消，审计写入不依赖其生命周期
+		if _, err := recorder.Record(context.WithoutCancel(ctx), event); err != nil {
+			logger.Warn("failed to record hitl audit event", zap.String("interrupt_id", interrupt.ID), zap.Error(err))
+		}
+	})
+}
+
+func isAPIKeyConfigPath(path string) bool {
+	name := path
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		name = path[idx+1:]
+	}
+	return strings.HasSuffix(name, "APIKey") || strings.HasSuffix(name, "APIKeys")
+}
+
+func buildServeAuditTrail(set *ServeHandlerSet, in ServeHandlerSetBuildInput) error {
+	trail, err := BuildAuditTrail(in.Cfg.Audit, in.Logger)
+	if err != nil {
+		return err
+	}
+	if trail == nil {
+		return nil
+	}
+	set.AuditTrail = trail
+	set.AuditTrailHandler = handlers.NewAuditTrailHandler(usecase.NewDefaultAuditTrailService(trail), in.Logger)
+	if set.GuardrailAuditLogger != nil {
+		set.GuardrailAuditLogger.AddSink(NewGuardrailAuditTrailSink(trail))
+	}
+	if set.APIKeyHandler != nil {
+		set.APIKeyHandler.SetAuditRecorder(trail)
+	}
+	if set.TenantKeyHandler != nil {
+		set.TenantKeyHandler.SetAuditRecorder(trail)
+	}
+	RegisterHITLAuditTrail(in.ToolApprovalManager, trail, in.Logger)
+	if in.WorkflowHITLManager != in.ToolApprovalManager {
+		RegisterHITLAuditTrail(in.WorkflowHITLManager, trail, in.Logger)
+	}
+	in.Logger.Info("Audit trail handler initialized",
+		zap.String("file", in.Cfg.Audit.FilePath),
+		zap.String("file_format", in.Cfg.Audit.FileFormat))
+	return nil
+}
@@ -0,0 +1,125 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBuildAuditTrail(t *testing.T) {
+	t.Parallel()
+
+	disabled, err := BuildAuditTrail(config.AuditTrailConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, disabled)
+
+	_, err = BuildAuditTrail(config.AuditTrailConfig{Enabled: true, FilePath: filepath.Join(t.TempDir(), "a.log"), FileFormat: "xml"}, zap.NewNop())
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "audit.cef")
+	trail, err := BuildAuditTrail(config.AuditTrailConfig{Enabled: true, FilePath: path, FileFormat: "cef"}, zap.NewNop())
+	require.NoError(t, err)
+	_, err = trail.Record(context.Background(), audit.Event{Category: audit.CategoryBudget, Action: "budget.override", Resource: "budget"})
+	require.NoError(t, err)
+	require.NoError(t, trail.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "CEF:0|"))
+}
+
+func TestGuardrailAuditTrailSink_RecordsViolationsOnly(t *testing.T) {
+	t.Parallel()
+
+	trail := audit.NewTrail(audit.TrailConfig{})
+	auditLogger := guardrails.NewGuardrailAuditLogger(&guardrails.GuardrailAuditLoggerConfig{
+		Sinks: []guardrails.AuditSink{NewGuardrailAuditTrailSink(trail)},
+	})
+	blocked := guardrails.NewValidationResult()
+	blocked.AddError(guardrails.ValidationError{Code: guardrails.ErrCodeInjectionDetected, Message: "injection", Severity: guardrails.SeverityCritical})
+	auditLogger.RecordDecision(context.Background(), guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "injection_detector", Result: blocked})
+	auditLogger.RecordDecision(context.Background(), guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "length_validator", Result: guardrails.NewValidationResult()})
+
+	events, err := trail.Query(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "guardrail.block", events[0].Action)
+	assert.Equal(t, "injection_detector", events[0].Resource)
+	assert.Equal(t, audit.OutcomeDenied, events[0].Outcome)
+	assert.Equal(t, []string{guardrails.ErrCodeInjectionDetected}, events[0].Metadata["matched_rules"])
+}
+
+func TestRegisterConfigAuditTrail_ClassifiesChanges(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.DefaultConfig()
+	manager := config.NewHotReloadManager(cfg)
+	trail := audit.NewTrail(audit.TrailConfig{})
+	RegisterConfigAuditTrail(manager, trail, zap.NewNop())
+
+	next := *cfg
+	next.Log.Level = "debug"
+	next.Budget.MaxTokensPerDay = cfg.Budget.MaxTokensPerDay + 1000
+	next.LLM.APIKey = "sk-new-secret"
+	require.NoError(t, manager.ApplyConfig(&next, "api"))
+
+	byPath := map[string]*audit.Event{}
+	events, err := trail.Query(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	for _, event := range events {
+		byPath[event.ResourceID] = event
+	}
+
+	require.Contains(t, byPath, "Log.Level")
+	assert.Equal(t, audit.CategoryConfig, byPath["Log.Level"].Category)
+	assert.Equal(t, "config:api", byPath["Log.Level"].Actor)
+	assert.Equal(t, "debug", byPath["Log.Level"].After)
+
+	require.Contains(t, byPath, "Budget.MaxTokensPerDay")
+	assert.Equal(t, "budget.override", byPath["Budget.MaxTokensPerDay"].Action)
+
+	require.Contains(t, byPath, "LLM.APIKey")
+	assert.Equal(t, "api_key.rotate", byPath["LLM.APIKey"].Action)
+	assert.Equal(t, auditRedactedValue, byPath["LLM.APIKey"].After)
+}
+
+func TestRegisterHITLAuditTrail_RecordsReviewer(t *testing.T) {
+	t.Parallel()
+
+	manager := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), zap.NewNop())
+	trail := audit.NewTrail(audit.TrailConfig{})
+	RegisterHITLAuditTrail(manager, trail, zap.NewNop())
+
+	interrupt, err := manager.CreatePendingInterrupt(context.Background(), hitl.InterruptOptions{
+		WorkflowID: "wf-1",
+		Type:       hitl.InterruptTypeApproval,
+		Title:      "approve refund",
+		Timeout:    time.Hour,
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.ResolveInterrupt(context.Background(), interrupt.ID, &hitl.Response{
+		Approved: false,
+		UserID:   "reviewer-1",
+		Comment:  "amount too high",
+	}))
+
+	events, err := trail.Query(context.Background(), audit.Filter{Categories: []audit.Category{audit.CategoryHITL}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "reviewer-1", events[0].Actor)
+	assert.Equal(t, "hitl.rejected", events[0].Action)
+	assert.Equal(t, audit.OutcomeDenied, events[0].Outcome)
+	assert.Equal(t, "amount too high", events[0].Reason)
+	assert.Equal(t, "wf-1", events[0].Metadata["workflow_id"])
+}
@@ -1287,9 +1287,15 @@ func BuildToolApprovalHistoryStore(
 }
 
 func newToolApprovalRedisClient(cfg *config.Config, logger *zap.Logger) (*redis.Client, error) {
+	return newRedisClient(cfg, "hosted_tools.approval.backend=redis", "tool approval store", logger)
+}
+
+// newRedisClient 按 cfg.Redis 创建并探活客户端；非 loopback 地址必须使用 rediss://。
+// requiredBy 用于缺少地址时的报错，component 用于明文连接告警。
+func newRedisClient(cfg *config.Config, requiredBy, component string, logger *zap.Logger) (*redis.Client, error) {
 	addr := strings.TrimSpace(cfg.Redis.Addr)
 	if addr == "" {
-		return nil, fmt.Errorf("redis address is required when hosted_tools.approval.backend=redis")
+		return nil, fmt.Errorf("redis address is required when %s", requiredBy)
 	}
 
 	var (
@@ -1327,7 +1333,7 @@ func newToolApprovalRedisClient(cfg *config.Config, logger *zap.Logger) (*redis.
 			opts.TLSConfig = tlsutil.DefaultTLSConfig()
 		}
 		if scheme == "redis" && isLoopbackHost(host) {
-			logger.Warn("using insecure redis:// for loopback host in tool approval store", zap.String("host", host))
+			logger.Warn("using insecure redis:// for loopback host in "+component, zap.String("host", host))
 		}
 	} else {
 		host := hostFromRedisAddr(addr)
@@ -1341,7 +1347,7 @@ func newToolApprovalRedisClient(cfg *config.Config, logger *zap.Logger) (*redis.
 			PoolSize:     cfg.Redis.PoolSize,
 			MinIdleConns: cfg.Redis.MinIdleConns,
 		}
-		logger.Warn("using insecure plaintext redis connection for loopback host in tool approval store", zap.String("host", host))
+		logger.Warn("using insecure plaintext redis connection for loopback host in "+component, zap.String("host", host))
 	}
 
 	client := redis.NewClient(opts)
@@ -0,0 +1,14 @@
+package bootstrap
+
+import (
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/llm/cache"
+)
+
+// NewCacheAdminService creates a CacheAdminService backed by the LLM response cache.
+func NewCacheAdminService(llmCache *cache.MultiLevelCache) usecase.CacheAdminService {
+	if llmCache == nil {
+		return nil
+	}
+	return usecase.NewDefaultCacheAdminService(llmCache)
+}
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/llm/capabilities/embedding"
+)
+
+// BuildChatEmbeddingProvider builds the embedding provider served by the
+// OpenAI-compatible /v1/embeddings endpoint from the main LLM connection.
+// It returns nil when the default provider has no embedding API.
+func BuildChatEmbeddingProvider(cfg *config.Config) embedding.Provider {
+	if cfg == nil || strings.TrimSpace(cfg.LLM.APIKey) == "" {
+		return nil
+	}
+	providerType := embedding.ProviderType(strings.ToLower(strings.TrimSpace(cfg.LLM.DefaultProvider)))
+	switch providerType {
+	case embedding.ProviderOpenAI, embedding.ProviderCohere, embedding.ProviderVoyage, embedding.ProviderJina, embedding.ProviderGemini:
+	default:
+		return nil
+	}
+	provider, err := embedding.NewProviderFromConfig(embedding.FactoryConfig{
+		Type:    providerType,
+		APIKey:  cfg.LLM.APIKey,
+		BaseURL: cfg.LLM.BaseURL,
+		Timeout: cfg.LLM.Timeout,
+	})
+	if err != nil {
+		return nil
+	}
+	return provider
+}
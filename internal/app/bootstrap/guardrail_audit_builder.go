@@ -0,0 +1,64 @@
+package bootstrap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/api/handlers"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	"go.uber.org/zap"
+)
+
+const defaultGuardrailAuditMaxEntries = 10000
+
+// BuildGuardrailAuditLogger builds the shared guardrail decision log from
+// config. It returns nil when auditing is disabled.
+func BuildGuardrailAuditLogger(cfg config.GuardrailAuditConfig, logger *zap.Logger) (*guardrails.GuardrailAuditLogger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultGuardrailAuditMaxEntries
+	}
+	var sinks []guardrails.AuditSink
+	if path := strings.TrimSpace(cfg.FilePath); path != "" {
+		fileSink, err := guardrails.NewFileAuditSink(path)
+		if err != nil {
+			return nil, fmt.Errorf("build guardrail audit file sink: %w", err)
+		}
+		sinks = append(sinks, fileSink)
+	}
+	if cfg.OTelEvents {
+		sinks = append(sinks, guardrails.NewSpanEventAuditSink())
+	}
+	return guardrails.NewGuardrailAuditLogger(&guardrails.GuardrailAuditLoggerConfig{
+		Store:  guardrails.NewMemoryAuditLogger(maxEntries),
+		Sinks:  sinks,
+		Logger: logger,
+	}), nil
+}
+
+func buildServeGuardrailAudit(set *ServeHandlerSet, in ServeHandlerSetBuildInput) error {
+	auditLogger, err := BuildGuardrailAuditLogger(in.Cfg.Agent.Guardrails.Audit, in.Logger)
+	if err != nil {
+		return err
+	}
+	if auditLogger == nil {
+		return nil
+	}
+	set.GuardrailAuditLogger = auditLogger
+	set.GuardrailAuditHandler = handlers.NewGuardrailAuditHandler(
+		usecase.NewDefaultGuardrailAuditService(auditLogger),
+		in.Logger,
+	)
+	in.Logger.Info("Guardrail audit handler initialized",
+		zap.String("file", in.Cfg.Agent.Guardrails.Audit.FilePath),
+		zap.Bool("otel_events", in.Cfg.Agent.Guardrails.Audit.OTelEvents))
+	return nil
+}
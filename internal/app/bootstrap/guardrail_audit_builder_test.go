@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBuildGuardrailAuditLogger(t *testing.T) {
+	t.Parallel()
+
+	disabled, err := BuildGuardrailAuditLogger(config.GuardrailAuditConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, disabled)
+
+	path := filepath.Join(t.TempDir(), "guardrails.jsonl")
+	auditLogger, err := BuildGuardrailAuditLogger(config.GuardrailAuditConfig{
+		Enabled:    true,
+		FilePath:   path,
+		OTelEvents: true,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, auditLogger)
+
+	auditLogger.RecordDecision(context.Background(), guardrails.AuditDecisionEvent{
+		Direction: guardrails.AuditDirectionInput,
+		Validator: "length_validator",
+		Result:    guardrails.NewValidationResult(),
+	})
+	require.NoError(t, auditLogger.Close())
+
+	count, err := auditLogger.Count(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\"validator_name\":\"length_validator\"")
+}
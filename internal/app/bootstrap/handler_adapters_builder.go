@@ -15,6 +15,10 @@ import (
 	"github.com/BaSui01/agentflow/config"
 	appservice "github.com/BaSui01/agentflow/internal/app/service"
 	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/llm/cache"
+	"github.com/BaSui01/agentflow/llm/capabilities"
+	"github.com/BaSui01/agentflow/llm/capabilities/embedding"
+	"github.com/BaSui01/agentflow/llm/capabilities/multimodal"
 	llmcore "github.com/BaSui01/agentflow/llm/core"
 	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
 	llmobservability "github.com/BaSui01/agentflow/llm/observability"
@@ -36,8 +40,13 @@ func BuildAgentRegistries(logger *zap.Logger) (*discovery.CapabilityRegistry, *a
 func BuildAgentService(
 	discoveryRegistry discovery.Registry,
 	resolver usecase.AgentResolver,
+	runs *llmobservability.RunRecorder,
 ) usecase.AgentService {
-	return usecase.NewDefaultAgentService(discoveryRegistry, resolver)
+	svc := usecase.NewDefaultAgentService(discoveryRegistry, resolver)
+	if runs != nil {
+		svc.WithRunRecorder(runs)
+	}
+	return svc
 }
 
 // ChatServiceBuildInput defines the inputs required to build or refresh the
@@ -47,6 +56,7 @@ type ChatServiceBuildInput struct {
 	PolicyManager       *llmpolicy.Manager
 	Ledger              llmobservability.Ledger
 	ToolingRuntime      *AgentToolingRuntime
+	Embedding           embedding.Provider
 	ExistingChatService usecase.ChatService
 	Logger              *zap.Logger
 }
@@ -56,12 +66,18 @@ type ChatServiceBuildInput struct {
 func BuildChatService(in ChatServiceBuildInput) (usecase.ChatService, error) {
 	var runtime usecase.ChatRuntime
 	if in.Provider != nil {
-		gateway := llmgateway.New(llmgateway.Config{
+		gatewayCfg := llmgateway.Config{
 			ChatProvider:  in.Provider,
 			PolicyManager: in.PolicyManager,
 			Ledger:        in.Ledger,
 			Logger:        in.Logger,
-		})
+		}
+		if in.Embedding != nil {
+			router := multimodal.NewRouter()
+			router.RegisterEmbedding(in.Embedding.Name(), in.Embedding, true)
+			gatewayCfg.Capabilities = capabilities.NewEntry(router)
+		}
+		gateway := llmgateway.New(gatewayCfg)
 		runtime = usecase.ChatRuntime{
 			Gateway:      gateway,
 			ChatProvider: llmgateway.NewChatProviderAdapter(gateway, in.Provider),
@@ -95,9 +111,13 @@ type ReloadedTextRuntimeBindingsInput struct {
 	CostTracker *llmobservability.CostTracker
 	CostHandler *handlers.CostHandler
 
+	LLMCache          *cache.MultiLevelCache
+	CacheAdminHandler *handlers.CacheAdminHandler
+
 	AgentHandler      *handlers.AgentHandler
 	DiscoveryRegistry discovery.Registry
 	Resolver          *agent.CachingResolver
+	RunRecorder       *llmobservability.RunRecorder
 
 	WorkflowRuntime *WorkflowRuntime
 	WorkflowHandler *handlers.WorkflowHandler
@@ -112,8 +132,11 @@ type ReloadedTextRuntimeBindingsResult struct {
 	ChatHandler *handlers.ChatHandler
 	CostHandler *handlers.CostHandler
 
-	ChatRouteRequiresRestart bool
-	CostRouteRequiresRestart bool
+	CacheAdminHandler *handlers.CacheAdminHandler
+
+	ChatRouteRequiresRestart  bool
+	CostRouteRequiresRestart  bool
+	CacheRouteRequiresRestart bool
 }
 
 // ApplyReloadedTextRuntimeBindings keeps hot-reload handler/service rebinding out
@@ -133,6 +156,8 @@ func ApplyReloadedTextRuntimeBindings(in ReloadedTextRuntimeBindingsInput) (Relo
 		ChatService: chatService,
 		ChatHandler: in.ChatHandler,
 		CostHandler: in.CostHandler,
+
+		CacheAdminHandler: in.CacheAdminHandler,
 	}
 
 	if in.ChatHandler != nil {
@@ -144,6 +169,8 @@ func ApplyReloadedTextRuntimeBindings(in ReloadedTextRuntimeBindingsInput) (Relo
 		if err != nil {
 			return result, fmt.Errorf("failed to create chat handler: %w", err)
 		}
+		chatHandler.ConfigureStreaming(defaultChatStreamingOptions())
+		configureChatBatch(chatHandler, logger)
 		result.ChatHandler = chatHandler
 	} else if chatService != nil {
 		result.ChatRouteRequiresRestart = true
@@ -157,12 +184,20 @@ func ApplyReloadedTextRuntimeBindings(in ReloadedTextRuntimeBindingsInput) (Relo
 		result.CostRouteRequiresRestart = true
 	}
 
+	if in.CacheAdminHandler != nil {
+		in.CacheAdminHandler.UpdateService(NewCacheAdminService(in.LLMCache))
+	} else if in.LLMCache != nil && !in.HTTPRoutesBound {
+		result.CacheAdminHandler = handlers.NewCacheAdminHandler(NewCacheAdminService(in.LLMCache), logger)
+	} else if in.LLMCache != nil {
+		result.CacheRouteRequiresRestart = true
+	}
+
 	if in.AgentHandler != nil {
 		var agentResolver usecase.AgentResolver
 		if in.Resolver != nil {
 			agentResolver = in.Resolver.Resolve
 		}
-		in.AgentHandler.UpdateService(BuildAgentService(in.DiscoveryRegistry, agentResolver))
+		in.AgentHandler.UpdateService(BuildAgentService(in.DiscoveryRegistry, agentResolver, in.RunRecorder))
 	}
 
 	if in.WorkflowHandler != nil && in.WorkflowRuntime != nil {
@@ -399,6 +434,7 @@ type ReloadedWorkflowRuntimeBuildInput struct {
 	EmbeddingProvider       ragcore.EmbeddingProvider
 	CheckpointStore         agentcheckpoint.Store
 	WorkflowCheckpointStore workflowcore.CheckpointStore
+	ExternalTasks           workflowcore.ExternalTaskQueue
 	HITLManager             *hitl.InterruptManager
 	AuthorizationService    usecase.AuthorizationService
 
@@ -415,6 +451,7 @@ func BuildReloadedWorkflowRuntime(in ReloadedWorkflowRuntimeBuildInput) *Workflo
 		EmbeddingProvider:       in.EmbeddingProvider,
 		CheckpointStore:         in.CheckpointStore,
 		WorkflowCheckpointStore: in.WorkflowCheckpointStore,
+		ExternalTasks:           in.ExternalTasks,
 		HITLManager:             in.HITLManager,
 		AuthorizationService:    in.AuthorizationService,
 	}
@@ -30,7 +30,7 @@ func TestBuildAgentService_WithResolver(t *testing.T) {
 		return nil, assert.AnError
 	}
 
-	svc := BuildAgentService(registry, resolver)
+	svc := BuildAgentService(registry, resolver, nil)
 	require.NotNil(t, svc)
 	_, err := svc.ResolveForOperation(context.Background(), "agent-1", usecase.AgentOperationExecute)
 	require.NotNil(t, err)
@@ -39,7 +39,7 @@ func TestBuildAgentService_WithResolver(t *testing.T) {
 
 func TestBuildAgentService_WithoutResolverFallsBackToRegistry(t *testing.T) {
 	registry := discovery.NewCapabilityRegistry(nil, zap.NewNop())
-	svc := BuildAgentService(registry, nil)
+	svc := BuildAgentService(registry, nil, nil)
 	require.NotNil(t, svc)
 	_, err := svc.ResolveForOperation(context.Background(), "missing", usecase.AgentOperationExecute)
 	require.NotNil(t, err)
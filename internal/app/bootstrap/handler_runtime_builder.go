@@ -6,7 +6,10 @@ import (
 
 	"github.com/BaSui01/agentflow/config"
 	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/observability"
 	llmcompose "github.com/BaSui01/agentflow/llm/runtime/compose"
+	"github.com/BaSui01/agentflow/sdk"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -15,18 +18,48 @@ import (
 type LLMHandlerRuntime = llmcompose.Runtime
 
 // BuildLLMHandlerRuntime creates the LLM runtime required by handler layer.
-// The main provider entry is selected by cfg.LLM.MainProviderMode.
-func BuildLLMHandlerRuntime(cfg *config.Config, db *gorm.DB, logger *zap.Logger) (*LLMHandlerRuntime, error) {
+// The main provider entry is selected by cfg.LLM.MainProviderMode. When db is
+// available, gateway usage is also persisted to the usage ledger. extraLedgers
+// receive every gateway usage entry alongside the default ledgers.
+// rateLimitRedis backs llm.distributed_rate_limit (see BuildLLMRateLimitRedis)
+// and may be nil when the limiter is disabled.
+func BuildLLMHandlerRuntime(cfg *config.Config, db *gorm.DB, rateLimitRedis *redis.Client, logger *zap.Logger, extraLedgers ...observability.Ledger) (*LLMHandlerRuntime, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is required for llm handler runtime")
 	}
+	if _, err := ConfigureProviderTransport(cfg.LLM.Transport, logger); err != nil {
+		return nil, err
+	}
 
 	baseProvider, err := BuildMainProvider(context.Background(), cfg, db, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	return BuildLLMHandlerRuntimeFromProvider(cfg, baseProvider, logger)
+	composeCfg := buildComposeConfig(cfg)
+	if db != nil {
+		composeCfg.UsageStore = observability.NewGormUsageStore(db)
+	}
+	composeCfg.ExtraLedgers = extraLedgers
+	if cfg.LLM.DistributedRateLimit.Enabled {
+		if rateLimitRedis == nil {
+			logger.Warn("llm.distributed_rate_limit is enabled but no redis client is available, distributed rate limiting disabled")
+		}
+		composeCfg.RateLimit.Client = rateLimitRedis
+	}
+	return llmcompose.Build(composeCfg, baseProvider, logger)
+}
+
+// BuildLLMRateLimitRedis creates the redis client used by
+// llm.distributed_rate_limit. It returns nil when the limiter is disabled.
+func BuildLLMRateLimitRedis(cfg *config.Config, logger *zap.Logger) (*redis.Client, error) {
+	if cfg == nil || !cfg.LLM.DistributedRateLimit.Enabled {
+		return nil, nil
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return newRedisClient(cfg, "llm.distributed_rate_limit.enabled=true", "distributed rate limiter", logger)
 }
 
 // BuildLLMHandlerRuntimeFromProvider assembles the handler-facing runtime around
@@ -39,38 +72,10 @@ func BuildLLMHandlerRuntimeFromProvider(cfg *config.Config, mainProvider llm.Pro
 }
 
 func buildComposeConfig(cfg *config.Config) llmcompose.Config {
-	return llmcompose.Config{
-		Timeout:    cfg.LLM.Timeout,
-		MaxRetries: cfg.LLM.MaxRetries,
-		Budget: llmcompose.BudgetConfig{
-			Enabled:             cfg.Budget.Enabled,
-			MaxTokensPerRequest: cfg.Budget.MaxTokensPerRequest,
-			MaxTokensPerMinute:  cfg.Budget.MaxTokensPerMinute,
-			MaxTokensPerHour:    cfg.Budget.MaxTokensPerHour,
-			MaxTokensPerDay:     cfg.Budget.MaxTokensPerDay,
-			MaxCostPerRequest:   cfg.Budget.MaxCostPerRequest,
-			MaxCostPerDay:       cfg.Budget.MaxCostPerDay,
-			AlertThreshold:      cfg.Budget.AlertThreshold,
-			AutoThrottle:        cfg.Budget.AutoThrottle,
-			ThrottleDelay:       cfg.Budget.ThrottleDelay,
-		},
-		Cache: llmcompose.CacheConfig{
-			Enabled:      cfg.Cache.Enabled,
-			LocalMaxSize: cfg.Cache.LocalMaxSize,
-			LocalTTL:     cfg.Cache.LocalTTL,
-			EnableRedis:  cfg.Cache.EnableRedis,
-			RedisTTL:     cfg.Cache.RedisTTL,
-			KeyStrategy:  cfg.Cache.KeyStrategy,
-		},
-		Tool: llmcompose.ToolProviderConfig{
-			Provider:        cfg.LLM.ToolProvider,
-			DefaultProvider: cfg.LLM.DefaultProvider,
-			APIKey:          cfg.LLM.ToolAPIKey,
-			DefaultAPIKey:   cfg.LLM.APIKey,
-			BaseURL:         cfg.LLM.ToolBaseURL,
-			DefaultBaseURL:  cfg.LLM.BaseURL,
-			Timeout:         cfg.LLM.ToolTimeout,
-			MaxRetries:      cfg.LLM.ToolMaxRetries,
-		},
-	}
+	return sdk.ComposeConfigFromApp(cfg)
+}
+
+// buildCapabilityRegistry 创建能力注册表并登记配置中的覆盖项（键为 provider 或 provider/model）。
+func buildCapabilityRegistry(overrides map[string]config.CapabilityOverrideConfig) *llm.CapabilityRegistry {
+	return sdk.CapabilityRegistryFromApp(overrides)
 }
@@ -132,7 +132,7 @@ func TestBuildLLMHandlerRuntime_LegacyPathStillRequiresDatabase(t *testing.T) {
 
 	cfg := config.DefaultConfig()
 
-	runtime, err := BuildLLMHandlerRuntime(cfg, nil, zap.NewNop())
+	runtime, err := BuildLLMHandlerRuntime(cfg, nil, nil, zap.NewNop())
 	require.Error(t, err)
 	require.Nil(t, runtime)
 	require.ErrorContains(t, err, "database is required for legacy multi-provider router runtime")
@@ -144,7 +144,7 @@ func TestBuildLLMHandlerRuntime_ChannelModeRequiresRegisteredBuilder(t *testing.
 
 	UnregisterMainProviderBuilder(config.LLMMainProviderModeChannelRouted)
 
-	runtime, err := BuildLLMHandlerRuntime(cfg, nil, zap.NewNop())
+	runtime, err := BuildLLMHandlerRuntime(cfg, nil, nil, zap.NewNop())
 	require.Error(t, err)
 	require.Nil(t, runtime)
 	require.ErrorContains(t, err, `no main provider builder registered for mode "channel_routed"`)
@@ -167,7 +167,7 @@ func TestBuildLLMHandlerRuntime_ChannelModeUsesRegisteredBuilderWithoutDatabase(
 		UnregisterMainProviderBuilder(config.LLMMainProviderModeChannelRouted)
 	})
 
-	runtime, err := BuildLLMHandlerRuntime(cfg, nil, zap.NewNop())
+	runtime, err := BuildLLMHandlerRuntime(cfg, nil, nil, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, runtime)
 	require.NotNil(t, runtime.Gateway)
@@ -245,7 +245,7 @@ func TestBuildLLMHandlerRuntime_ChannelModePropagatesStaticStoreRoutingAndUsage(
 		UnregisterMainProviderBuilder(config.LLMMainProviderModeChannelRouted)
 	})
 
-	runtime, err := BuildLLMHandlerRuntime(cfg, nil, zap.NewNop())
+	runtime, err := BuildLLMHandlerRuntime(cfg, nil, nil, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, runtime)
 	require.NotNil(t, runtime.Provider)
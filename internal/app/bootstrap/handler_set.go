@@ -7,19 +7,28 @@ import (
 // HTTPHandlerSet aggregates all HTTP handlers built at startup.
 // This struct has a single responsibility: hold handler references.
 type HTTPHandlerSet struct {
-	HealthHandler       *handlers.HealthHandler
-	ChatHandler         *handlers.ChatHandler
-	AgentHandler        *handlers.AgentHandler
-	APIKeyHandler       *handlers.APIKeyHandler
-	ToolRegistryHandler *handlers.ToolRegistryHandler
-	ToolProviderHandler *handlers.ToolProviderHandler
-	ToolApprovalHandler *handlers.ToolApprovalHandler
-	AuthAuditHandler    *handlers.AuthorizationAuditHandler
-	RAGHandler          *handlers.RAGHandler
-	WorkflowHandler     *handlers.WorkflowHandler
-	ProtocolHandler     *handlers.ProtocolHandler
-	MultimodalHandler   *handlers.MultimodalHandler
-	CostHandler         *handlers.CostHandler
+	HealthHandler          *handlers.HealthHandler
+	ChatHandler            *handlers.ChatHandler
+	AgentHandler           *handlers.AgentHandler
+	AgentDefinitionHandler *handlers.AgentDefinitionHandler
+	APIKeyHandler          *handlers.APIKeyHandler
+	TenantKeyHandler       *handlers.TenantKeyHandler
+	ToolRegistryHandler    *handlers.ToolRegistryHandler
+	ToolProviderHandler    *handlers.ToolProviderHandler
+	ToolApprovalHandler    *handlers.ToolApprovalHandler
+	AuthAuditHandler       *handlers.AuthorizationAuditHandler
+	GuardrailAuditHandler  *handlers.GuardrailAuditHandler
+	AuditTrailHandler      *handlers.AuditTrailHandler
+	RAGHandler             *handlers.RAGHandler
+	WorkflowHandler        *handlers.WorkflowHandler
+	ProtocolHandler        *handlers.ProtocolHandler
+	MultimodalHandler      *handlers.MultimodalHandler
+	CostHandler            *handlers.CostHandler
+	CacheAdminHandler      *handlers.CacheAdminHandler
+	UsageHandler           *handlers.UsageHandler
+	RunHandler             *handlers.RunHandler
+	InterruptHandler       *handlers.InterruptHandler
+	ExternalTaskHandler    *handlers.ExternalTaskHandler
 }
 
 // Count returns the number of non-nil handlers in the set.
@@ -34,9 +43,15 @@ func (s *HTTPHandlerSet) Count() int {
 	if s.AgentHandler != nil {
 		count++
 	}
+	if s.AgentDefinitionHandler != nil {
+		count++
+	}
 	if s.APIKeyHandler != nil {
 		count++
 	}
+	if s.TenantKeyHandler != nil {
+		count++
+	}
 	if s.ToolRegistryHandler != nil {
 		count++
 	}
@@ -49,6 +64,12 @@ func (s *HTTPHandlerSet) Count() int {
 	if s.AuthAuditHandler != nil {
 		count++
 	}
+	if s.GuardrailAuditHandler != nil {
+		count++
+	}
+	if s.AuditTrailHandler != nil {
+		count++
+	}
 	if s.RAGHandler != nil {
 		count++
 	}
@@ -64,5 +85,20 @@ func (s *HTTPHandlerSet) Count() int {
 	if s.CostHandler != nil {
 		count++
 	}
+	if s.CacheAdminHandler != nil {
+		count++
+	}
+	if s.UsageHandler != nil {
+		count++
+	}
+	if s.RunHandler != nil {
+		count++
+	}
+	if s.InterruptHandler != nil {
+		count++
+	}
+	if s.ExternalTaskHandler != nil {
+		count++
+	}
 	return count
 }
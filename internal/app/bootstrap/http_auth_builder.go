@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/BaSui01/agentflow/api"
+	"github.com/BaSui01/agentflow/api/routes"
 	"github.com/BaSui01/agentflow/config"
 	mw "github.com/BaSui01/agentflow/pkg/middleware"
 	"github.com/BaSui01/agentflow/types"
@@ -13,10 +14,23 @@ import (
 
 // BuildAuthMiddleware selects and creates the HTTP auth middleware.
 // Priority: JWT (if secret or public key configured) > API Key > fail-closed.
-func BuildAuthMiddleware(serverCfg config.ServerConfig, skipPaths []string, logger *zap.Logger) (mw.Middleware, error) {
+//
+// keyResolver, when non-nil, enables tenant API keys managed in the database.
+// They are accepted alongside JWT (requests carrying X-API-Key) or the static
+// server.api_keys, which act as admin bootstrap keys. Managed keys alone never
+// enable authentication, so allow_no_auth deployments keep working.
+func BuildAuthMiddleware(serverCfg config.ServerConfig, skipPaths []string, keyResolver mw.APIKeyResolver, logger *zap.Logger) (mw.Middleware, error) {
 	jwtCfg := serverCfg.JWT
 	hasJWT := jwtCfg.Secret != "" || jwtCfg.PublicKey != ""
 	hasAPIKeys := len(serverCfg.APIKeys) > 0
+	apiKeyAuth := func() mw.Middleware {
+		return mw.TenantAPIKeyAuth(mw.TenantAPIKeyConfig{
+			Resolver:   keyResolver,
+			StaticKeys: serverCfg.APIKeys,
+			Rules:      routes.APIKeyScopeRules(),
+			SkipPaths:  skipPaths,
+		}, logger)
+	}
 
 	switch {
 	case hasJWT:
@@ -25,18 +39,24 @@ func BuildAuthMiddleware(serverCfg config.ServerConfig, skipPaths []string, logg
 			zap.Bool("rsa", jwtCfg.PublicKey != ""),
 			zap.String("issuer", jwtCfg.Issuer),
 		)
-		return mw.JWTAuth(mw.JWTAuthConfig{
+		jwtAuth, err := mw.JWTAuth(mw.JWTAuthConfig{
 			Secret:   jwtCfg.Secret,
 			PublicKey: jwtCfg.PublicKey,
 			Issuer:   jwtCfg.Issuer,
 			Audience: jwtCfg.Audience,
 			Expiration: jwtCfg.Expiration,
 		}, skipPaths, logger)
+		if err != nil || keyResolver == nil {
+			return jwtAuth, err
+		}
+		logger.Info("Authentication: tenant API keys accepted alongside JWT")
+		return apiKeyOrJWTAuth(apiKeyAuth(), jwtAuth), nil
 	case hasAPIKeys:
 		logger.Info("Authentication: API Key enabled",
 			zap.Int("key_count", len(serverCfg.APIKeys)),
+			zap.Bool("tenant_keys", keyResolver != nil),
 		)
-		return mw.APIKeyAuth(serverCfg.APIKeys, skipPaths, logger), nil
+		return apiKeyAuth(), nil
 	default:
 		if serverCfg.AllowNoAuth {
 			logger.Warn("Authentication is disabled (allow_no_auth=true). " +
@@ -67,3 +87,19 @@ func BuildAuthMiddleware(serverCfg config.ServerConfig, skipPaths []string, logg
 		}, nil
 	}
 }
+
+// apiKeyOrJWTAuth authenticates requests carrying X-API-Key with keyAuth and
+// all others with jwtAuth.
+func apiKeyOrJWTAuth(keyAuth, jwtAuth mw.Middleware) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		byKey := keyAuth(next)
+		byJWT := jwtAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-API-Key") != "" {
+				byKey.ServeHTTP(w, r)
+				return
+			}
+			byJWT.ServeHTTP(w, r)
+		})
+	}
+}
@@ -16,10 +16,12 @@ type HTTPMiddlewares struct {
 	TenantRateLimiterCancel context.CancelFunc
 }
 
-// BuildHTTPMiddlewares creates the default HTTP middleware chain.
+// BuildHTTPMiddlewares creates the default HTTP middleware chain. keyResolver
+// is optional and enables database-managed tenant API keys.
 func BuildHTTPMiddlewares(
 	serverCfg config.ServerConfig,
 	collector *metrics.Collector,
+	keyResolver mw.APIKeyResolver,
 	logger *zap.Logger,
 ) (HTTPMiddlewares, error) {
 	// skipAuthPaths: 主 HTTP 服务的免认证路径。/metrics 运行在独立 Metrics 端口，不经过此中间件；
@@ -28,7 +30,7 @@ func BuildHTTPMiddlewares(
 	rateLimiterCtx, rateLimiterCancel := context.WithCancel(context.Background())
 	tenantRateLimiterCtx, tenantRateLimiterCancel := context.WithCancel(context.Background())
 
-	authMiddleware, err := BuildAuthMiddleware(serverCfg, skipAuthPaths, logger)
+	authMiddleware, err := BuildAuthMiddleware(serverCfg, skipAuthPaths, keyResolver, logger)
 	if err != nil {
 		rateLimiterCancel()
 		tenantRateLimiterCancel()
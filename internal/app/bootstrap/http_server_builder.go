@@ -15,20 +15,30 @@ import (
 
 // HTTPRouteHandlers groups handler dependencies used by HTTP route registration.
 type HTTPRouteHandlers struct {
-	Health        *handlers.HealthHandler
-	Chat          *handlers.ChatHandler
-	Agent         *handlers.AgentHandler
-	APIKey        *handlers.APIKeyHandler
-	Tools         *handlers.ToolRegistryHandler
-	ToolProviders *handlers.ToolProviderHandler
-	ToolApprovals *handlers.ToolApprovalHandler
-	AuthAudit     *handlers.AuthorizationAuditHandler
-	Multimodal    *handlers.MultimodalHandler
-	Protocol      *handlers.ProtocolHandler
-	RAG           *handlers.RAGHandler
-	Workflow      *handlers.WorkflowHandler
-	ConfigAPI     *config.ConfigAPIHandler
-	Cost          *handlers.CostHandler
+	Health           *handlers.HealthHandler
+	Chat             *handlers.ChatHandler
+	Agent            *handlers.AgentHandler
+	AgentDefinitions *handlers.AgentDefinitionHandler
+	APIKey           *handlers.APIKeyHandler
+	TenantKeys       *handlers.TenantKeyHandler
+	Tools            *handlers.ToolRegistryHandler
+	ToolProviders    *handlers.ToolProviderHandler
+	ToolApprovals    *handlers.ToolApprovalHandler
+	AuthAudit        *handlers.AuthorizationAuditHandler
+	GuardrailAudit   *handlers.GuardrailAuditHandler
+	AuditTrail       *handlers.AuditTrailHandler
+	Multimodal       *handlers.MultimodalHandler
+	Protocol         *handlers.ProtocolHandler
+	RAG              *handlers.RAGHandler
+	Workflow         *handlers.WorkflowHandler
+	ConfigAPI        *config.ConfigAPIHandler
+	Cost             *handlers.CostHandler
+	CacheAdmin       *handlers.CacheAdminHandler
+	Usage            *handlers.UsageHandler
+	Runs             *handlers.RunHandler
+	Interrupts       *handlers.InterruptHandler
+	SandboxImages    *handlers.SandboxImageAdminHandler
+	ExternalTasks    *handlers.ExternalTaskHandler
 }
 
 // RegisterHTTPRoutes wires all API routes into the provided mux and logs route summary.
@@ -44,15 +54,25 @@ func RegisterHTTPRoutes(
 	routes.RegisterSystem(mux, handlers.Health, version, buildTime, gitCommit)
 	routes.RegisterChat(mux, handlers.Chat, logger)
 	routes.RegisterAgent(mux, handlers.Agent, logger)
+	routes.RegisterAgentDefinitions(mux, handlers.AgentDefinitions, logger)
 	routes.RegisterProvider(mux, handlers.APIKey, logger)
+	routes.RegisterTenantKeys(mux, handlers.TenantKeys, logger)
 	routes.RegisterTools(mux, handlers.Tools, handlers.ToolProviders, handlers.ToolApprovals, logger)
 	routes.RegisterAuthorization(mux, handlers.AuthAudit, logger)
+	routes.RegisterGuardrails(mux, handlers.GuardrailAudit, logger)
+	routes.RegisterAudit(mux, handlers.AuditTrail, logger)
 	routes.RegisterMultimodal(mux, handlers.Multimodal, logger)
 	routes.RegisterProtocol(mux, handlers.Protocol, logger)
 	routes.RegisterRAG(mux, handlers.RAG, logger)
 	routes.RegisterWorkflow(mux, handlers.Workflow, logger)
+	routes.RegisterExternalTasks(mux, handlers.ExternalTasks, logger)
 	routes.RegisterConfig(mux, handlers.ConfigAPI, firstAPIKey, logger)
 	routes.RegisterCost(mux, handlers.Cost, logger)
+	routes.RegisterCache(mux, handlers.CacheAdmin, logger)
+	routes.RegisterUsage(mux, handlers.Usage, logger)
+	routes.RegisterRuns(mux, handlers.Runs, logger)
+	routes.RegisterInterrupts(mux, handlers.Interrupts, logger)
+	routes.RegisterSandboxImages(mux, handlers.SandboxImages, logger)
 
 	logger.Info("HTTP routes registered",
 		zap.Strings("routes", []string{
@@ -63,19 +83,30 @@ func RegisterHTTPRoutes(
 			"/version",
 			"/api/v1/chat/completions",
 			"/v1/chat/completions",
+			"/v1/embeddings",
+			"/v1/models",
 			"/v1/responses",
 			"/v1/messages",
+			"/v1/usage",
+			"/v1/runs/*",
 			"/api/v1/agents/*",
+			"/api/v1/agents/definitions/*",
 			"/api/v1/providers/*",
+			"/api/v1/keys/*",
 			"/api/v1/tools/*",
 			"/api/v1/tools/approvals/*",
+			"/api/v1/interrupts/*",
 			"/api/v1/authorization/audit",
+			"/api/v1/guardrails/audit",
+			"/api/v1/audit",
 			"/api/v1/multimodal/*",
 			"/api/v1/mcp/*",
 			"/api/v1/rag/*",
 			"/api/v1/workflows/*",
 			"/api/v1/config/*",
 			"/api/v1/config/rollback",
+			"/api/v1/cache/tenants/*",
+			"/api/v1/sandbox/images/*",
 			"/metrics",
 		}))
 }
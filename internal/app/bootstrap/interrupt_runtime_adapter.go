@@ -0,0 +1,141 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/api/handlers"
+	"github.com/BaSui01/agentflow/internal/usecase"
+)
+
+// interruptStreamHandlerName is the named interrupt handler that feeds the
+// interrupt SSE stream; registering by name keeps it idempotent per manager.
+const interruptStreamHandlerName = "interrupt_api_stream"
+
+// interruptSubscriberBuffer bounds how many new interrupts a slow stream
+// client may lag behind before further interrupts are dropped for it.
+const interruptSubscriberBuffer = 32
+
+var streamedInterruptTypes = []hitl.InterruptType{
+	hitl.InterruptTypeApproval,
+	hitl.InterruptTypeInput,
+	hitl.InterruptTypeReview,
+	hitl.InterruptTypeBreakpoint,
+	hitl.InterruptTypeError,
+}
+
+// interruptRuntimeAdapter adapts one or more hitl.InterruptManager instances
+// (workflow HITL and tool approvals) to usecase.InterruptRuntime interface.
+type interruptRuntimeAdapter struct {
+	managers []*hitl.InterruptManager
+
+	mu          sync.Mutex
+	subscribers map[chan *hitl.Interrupt]struct{}
+}
+
+// NewInterruptRuntime aggregates the given managers, skipping nil and
+// duplicate entries, and subscribes to their newly created interrupts.
+func NewInterruptRuntime(managers ...*hitl.InterruptManager) usecase.InterruptRuntime {
+	a := &interruptRuntimeAdapter{subscribers: make(map[chan *hitl.Interrupt]struct{})}
+	for _, manager := range managers {
+		if manager == nil || a.owns(manager) {
+			continue
+		}
+		a.managers = append(a.managers, manager)
+		for _, typ := range streamedInterruptTypes {
+			manager.RegisterNamedHandler(typ, interruptStreamHandlerName, a.publish)
+		}
+	}
+	if len(a.managers) == 0 {
+		return nil
+	}
+	return a
+}
+
+func (a *interruptRuntimeAdapter) owns(manager *hitl.InterruptManager) bool {
+	for _, existing := range a.managers {
+		if existing == manager {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *interruptRuntimeAdapter) ListInterrupts(ctx context.Context, workflowID string, status hitl.InterruptStatus) ([]*hitl.Interrupt, error) {
+	var out []*hitl.Interrupt
+	for _, manager := range a.managers {
+		rows, err := manager.ListInterrupts(ctx, workflowID, status)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rows...)
+	}
+	return out, nil
+}
+
+func (a *interruptRuntimeAdapter) GetInterrupt(ctx context.Context, interruptID string) (*hitl.Interrupt, error) {
+	_, interrupt, err := a.locate(ctx, interruptID)
+	return interrupt, err
+}
+
+func (a *interruptRuntimeAdapter) ResolveInterrupt(ctx context.Context, interruptID string, response *hitl.Response) error {
+	manager, _, err := a.locate(ctx, interruptID)
+	if err != nil {
+		return err
+	}
+	return manager.ResolveInterrupt(ctx, interruptID, response)
+}
+
+// locate finds the manager whose store holds the interrupt.
+func (a *interruptRuntimeAdapter) locate(ctx context.Context, interruptID string) (*hitl.InterruptManager, *hitl.Interrupt, error) {
+	var errs []error
+	for _, manager := range a.managers {
+		interrupt, err := manager.GetInterrupt(ctx, interruptID)
+		if err == nil && interrupt != nil {
+			return manager, interrupt, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return nil, nil, fmt.Errorf("interrupt %s not found: %w", interruptID, errors.Join(errs...))
+}
+
+func (a *interruptRuntimeAdapter) SubscribeInterrupts(ctx context.Context) <-chan *hitl.Interrupt {
+	ch := make(chan *hitl.Interrupt, interruptSubscriberBuffer)
+	a.mu.Lock()
+	a.subscribers[ch] = struct{}{}
+	a.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		a.mu.Lock()
+		delete(a.subscribers, ch)
+		close(ch)
+		a.mu.Unlock()
+	}()
+	return ch
+}
+
+func (a *interruptRuntimeAdapter) publish(_ context.Context, interrupt *hitl.Interrupt) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for ch := range a.subscribers {
+		select {
+		case ch <- interrupt:
+		default:
+		}
+	}
+	return nil
+}
+
+func buildServeInterruptHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput) {
+	runtime := NewInterruptRuntime(in.WorkflowHITLManager, in.ToolApprovalManager)
+	if runtime == nil {
+		return
+	}
+	set.InterruptHandler = handlers.NewInterruptHandler(usecase.NewDefaultInterruptService(runtime), in.Logger)
+}
@@ -0,0 +1,60 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptRuntime_AggregatesManagersAndStreams(t *testing.T) {
+	workflow := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), nil)
+	approvals := hitl.NewInterruptManager(hitl.NewInMemoryInterruptStore(), nil)
+	require.Nil(t, NewInterruptRuntime(nil, nil))
+
+	runtime := NewInterruptRuntime(workflow, approvals, workflow)
+	require.NotNil(t, runtime)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	live := runtime.SubscribeInterrupts(ctx)
+
+	created, err := approvals.CreatePendingInterrupt(context.Background(), hitl.InterruptOptions{WorkflowID: "tool_approval", Type: hitl.InterruptTypeApproval})
+	require.NoError(t, err)
+	_, err = workflow.CreatePendingInterrupt(context.Background(), hitl.InterruptOptions{WorkflowID: "wf", Type: hitl.InterruptTypeInput})
+	require.NoError(t, err)
+
+	rows, err := runtime.ListInterrupts(context.Background(), "", hitl.InterruptStatusPending)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2, "duplicate managers are listed once")
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case interrupt := <-live:
+			seen[interrupt.WorkflowID] = true
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for streamed interrupts")
+		}
+	}
+
+	require.NoError(t, runtime.ResolveInterrupt(context.Background(), created.ID, &hitl.Response{Approved: true}))
+	stored, err := approvals.GetInterrupt(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hitl.InterruptStatusResolved, stored.Status)
+
+	_, err = runtime.GetInterrupt(context.Background(), "missing")
+	assert.Error(t, err)
+
+	for _, row := range rows {
+		_ = workflow.CancelInterrupt(context.Background(), row.ID)
+	}
+	cancel()
+	_, open := <-live
+	for open {
+		_, open = <-live
+	}
+}
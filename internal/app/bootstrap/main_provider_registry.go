@@ -91,7 +91,11 @@ func buildLegacyMainProvider(ctx context.Context, cfg *config.Config, db *gorm.D
 		Timeout: cfg.LLM.Timeout,
 		Logger:  logger,
 	}
-	router := llmrouter.NewMultiProviderRouter(db, factory, llmrouter.RouterOptions{Logger: logger})
+	router := llmrouter.NewMultiProviderRouter(db, factory, llmrouter.RouterOptions{
+		Logger:             logger,
+		ProviderRateLimits: providerRateLimitsFromConfig(cfg.LLM.ProviderRateLimits),
+		RateLimitMaxWait:   cfg.LLM.RateLimitMaxWait,
+	})
 	if err := router.InitAPIKeyPools(ctx); err != nil {
 		router.Stop()
 		return nil, fmt.Errorf("failed to initialize llm router api key pools: %w", err)
@@ -108,9 +112,22 @@ func buildLegacyMainProvider(ctx context.Context, cfg *config.Config, db *gorm.D
 	return llmrouter.NewRoutedChatProvider(router, llmrouter.RoutedChatProviderOptions{
 		DefaultStrategy: llmrouter.StrategyQPSBased,
 		Logger:          logger,
+		Capabilities:    buildCapabilityRegistry(cfg.LLM.CapabilityOverrides),
+		Transcoder:      llm.NewConversationTranscoder(),
 	}), nil
 }
 
+func providerRateLimitsFromConfig(limits map[string]config.ProviderRateLimitConfig) map[string]llmrouter.ProviderRateLimits {
+	if len(limits) == 0 {
+		return nil
+	}
+	out := make(map[string]llmrouter.ProviderRateLimits, len(limits))
+	for provider, limit := range limits {
+		out[provider] = llmrouter.ProviderRateLimits{RPM: limit.RPM, TPM: limit.TPM}
+	}
+	return out
+}
+
 func normalizeMainProviderMode(raw string) string {
 	return strings.TrimSpace(config.NormalizeLLMMainProviderMode(raw))
 }
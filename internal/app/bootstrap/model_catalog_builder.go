@@ -1,12 +1,21 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/llm/providers/vendor"
 	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
 )
 
+// modelPricingFetchTimeout bounds the startup model-list pricing import so a
+// slow aggregator never blocks serving.
+const modelPricingFetchTimeout = 15 * time.Second
+
 // BuildModelCatalog loads the optional runtime model catalog snapshot.
 // Empty paths deliberately fall back to the built-in snapshot so startup keeps
 // a deterministic catalog even before an operator wires generated model data.
@@ -20,3 +29,39 @@ func BuildModelCatalog(path string) (*types.ModelCatalog, error) {
 	}
 	return catalog, nil
 }
+
+// ApplyProviderModelPricing imports published model pricing from the default
+// provider (OpenRouter / Together AI) when llm.import_model_pricing is set. The
+// prices are loaded into the runtime cost calculator and the descriptors are
+// merged into the returned catalog. Fetch failures are logged and leave the
+// catalog and built-in prices unchanged.
+func ApplyProviderModelPricing(ctx context.Context, cfg *config.Config, llmRuntime *LLMHandlerRuntime, catalog *types.ModelCatalog, logger *zap.Logger) *types.ModelCatalog {
+	if cfg == nil || !cfg.LLM.ImportModelPricing {
+		return catalog
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	providerCode := strings.TrimSpace(cfg.LLM.DefaultProvider)
+	if !vendor.SupportsModelPricing(providerCode) {
+		logger.Warn("Model pricing import skipped: provider does not publish pricing", zap.String("provider", providerCode))
+		return catalog
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, modelPricingFetchTimeout)
+	defer cancel()
+	pricing, err := vendor.FetchModelPricing(fetchCtx, providerCode, vendor.ChatProviderConfig{
+		APIKey:  cfg.LLM.APIKey,
+		BaseURL: cfg.LLM.BaseURL,
+		Timeout: cfg.LLM.Timeout,
+	})
+	if err != nil {
+		logger.Warn("Model pricing import failed, keeping built-in prices", zap.String("provider", providerCode), zap.Error(err))
+		return catalog
+	}
+	if llmRuntime != nil && llmRuntime.CostCalculator != nil {
+		llmRuntime.CostCalculator.UpdatePrices(vendor.ModelPrices(pricing))
+	}
+	logger.Info("Model pricing imported", zap.String("provider", providerCode), zap.Int("models", len(pricing)))
+	return catalog.Merge(vendor.ModelDescriptors(pricing))
+}
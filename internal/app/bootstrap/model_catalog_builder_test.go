@@ -1,11 +1,16 @@
 package bootstrap
 
 import (
-	"github.com/BaSui01/agentflow/config"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/llm/observability"
 	"github.com/BaSui01/agentflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,3 +67,47 @@ func TestBuildServeHandlerSet_ReturnsModelCatalogLoadError(t *testing.T) {
 	require.Nil(t, set)
 	assert.Contains(t, err.Error(), "build model catalog")
 }
+
+func TestApplyProviderModelPricing_ImportsOpenRouterPricing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"data":[{"id":"openai/gpt-4o","pricing":{"prompt":"0.0000025","completion":"0.00001"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.LLM.DefaultProvider = "openrouter"
+	cfg.LLM.APIKey = "sk-or"
+	cfg.LLM.BaseURL = server.URL
+	runtime := &LLMHandlerRuntime{CostCalculator: observability.NewCostCalculator()}
+	base := types.DefaultModelCatalog()
+
+	// 未开启导入时保持原目录
+	assert.Same(t, base, ApplyProviderModelPricing(context.Background(), cfg, runtime, base, zap.NewNop()))
+	assert.Nil(t, runtime.CostCalculator.GetPrice("openrouter", "openai/gpt-4o"))
+
+	cfg.LLM.ImportModelPricing = true
+	catalog := ApplyProviderModelPricing(context.Background(), cfg, runtime, base, zap.NewNop())
+	_, ok := catalog.Lookup("openrouter", "openai/gpt-4o")
+	assert.True(t, ok)
+	_, ok = catalog.Lookup("openai", "gpt-5.4")
+	assert.True(t, ok, "built-in snapshot entries are kept")
+	price := runtime.CostCalculator.GetPrice("openrouter", "openai/gpt-4o")
+	require.NotNil(t, price)
+	assert.InDelta(t, 0.0025, price.PriceInput, 1e-12)
+}
+
+func TestApplyProviderModelPricing_FailureKeepsCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.LLM.DefaultProvider = "together"
+	cfg.LLM.BaseURL = server.URL
+	cfg.LLM.ImportModelPricing = true
+	base := types.DefaultModelCatalog()
+
+	assert.Same(t, base, ApplyProviderModelPricing(context.Background(), cfg, nil, base, zap.NewNop()))
+}
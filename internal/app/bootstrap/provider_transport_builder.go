@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/BaSui01/agentflow/config"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
+	"go.uber.org/zap"
+)
+
+// ConfigureProviderTransport builds the shared provider HTTP transport from
+// config and installs it as the process default, so every provider constructed
+// afterwards shares its connection pools, DNS cache and TLS session cache.
+func ConfigureProviderTransport(cfg config.LLMTransportConfig, logger *zap.Logger) (*providertransport.Manager, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	manager, err := providertransport.NewManager(providertransport.Config{
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+		HTTP2PingInterval:   cfg.HTTP2PingInterval,
+		HTTP2PingTimeout:    cfg.HTTP2PingTimeout,
+		DNSCacheTTL:         cfg.DNSCacheTTL,
+		TLSSessionCacheSize: cfg.TLSSessionCacheSize,
+		Proxies:             cfg.Proxies,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configure provider transport: %w", err)
+	}
+	providertransport.SetDefault(manager)
+	logger.Info("LLM provider transport configured",
+		zap.Int("max_idle_conns_per_host", cfg.MaxIdleConnsPerHost),
+		zap.Int("max_conns_per_host", cfg.MaxConnsPerHost),
+		zap.Duration("dns_cache_ttl", cfg.DNSCacheTTL),
+		zap.Int("proxied_providers", len(cfg.Proxies)))
+	return manager, nil
+}
@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/BaSui01/agentflow/config"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConfigureProviderTransport(t *testing.T) {
+	_, err := ConfigureProviderTransport(config.LLMTransportConfig{
+		Proxies: map[string]string{"openai": "not a url"},
+	}, zap.NewNop())
+	require.Error(t, err)
+
+	manager, err := ConfigureProviderTransport(config.LLMTransportConfig{
+		MaxIdleConnsPerHost: 32,
+		Proxies:             map[string]string{"qwen": providertransport.ProxyDirect},
+	}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Same(t, manager, providertransport.Default())
+}
@@ -0,0 +1,151 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/api/handlers"
+	"github.com/BaSui01/agentflow/internal/usecase"
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	llmobservability "github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// runRecordReaderAdapter adapts observability.RunRecorder to usecase.RunRecordReader interface.
+type runRecordReaderAdapter struct {
+	recorder *llmobservability.RunRecorder
+}
+
+func (a *runRecordReaderAdapter) RunRecord(runID string) (*usecase.RunRecordView, bool) {
+	snap, ok := a.recorder.Snapshot(runID)
+	if !ok {
+		return nil, false
+	}
+	return &usecase.RunRecordView{
+		RunID:       snap.RunID,
+		TenantID:    snap.TenantID,
+		Events:      snap.Events,
+		ChildRunIDs: snap.ChildRunIDs,
+		Truncated:   snap.Truncated,
+	}, true
+}
+
+// NewRunInspectionService creates a RunInspectionService from the run recorder.
+func NewRunInspectionService(recorder *llmobservability.RunRecorder) usecase.RunInspectionService {
+	if recorder == nil {
+		return nil
+	}
+	return usecase.NewDefaultRunInspectionService(&runRecordReaderAdapter{recorder: recorder})
+}
+
+// runRecordingToolManager records tool calls and results of runs into the run
+// recorder before delegating to the wrapped ToolManager.
+type runRecordingToolManager struct {
+	agent.ToolManager
+	recorder *llmobservability.RunRecorder
+}
+
+// WrapToolManagerWithRunRecorder records each tool execution made inside a run
+// (a context carrying a run ID) as tool_call/tool_result run events.
+func WrapToolManagerWithRunRecorder(manager agent.ToolManager, recorder *llmobservability.RunRecorder) agent.ToolManager {
+	if manager == nil || recorder == nil {
+		return manager
+	}
+	return &runRecordingToolManager{ToolManager: manager, recorder: recorder}
+}
+
+func (m *runRecordingToolManager) ExecuteForAgent(ctx context.Context, agentID string, calls []types.ToolCall) []llmtools.ToolResult {
+	runID, _ := types.RunID(ctx)
+	if runID == "" {
+		return m.ToolManager.ExecuteForAgent(ctx, agentID, calls)
+	}
+	base := runEventFromContext(ctx, runID, agentID)
+	for i := range calls {
+		event := base
+		event.Type = types.RunEventToolCall
+		event.ToolCallID = calls[i].ID
+		event.ToolName = calls[i].Name
+		event.ToolCall = &calls[i]
+		m.recorder.AppendRunEvent(event)
+	}
+
+	results := m.ToolManager.ExecuteForAgent(ctx, agentID, calls)
+
+	now := time.Now()
+	for i := range results {
+		result := results[i]
+		event := base
+		event.Type = types.RunEventToolResult
+		event.Timestamp = now
+		event.ToolCallID = result.ToolCallID
+		event.ToolName = result.Name
+		event.ToolResult = &result
+		event.Error = result.Error
+		m.recorder.AppendRunEvent(event)
+	}
+	return results
+}
+
+// NewGuardrailRunEventSink returns an audit sink that records guardrail
+// decisions made inside a run as guardrail_triggered run events.
+func NewGuardrailRunEventSink(recorder *llmobservability.RunRecorder) guardrails.AuditSink {
+	return guardrails.AuditSinkFunc(func(ctx context.Context, entry *guardrails.AuditLogEntry) error {
+		if recorder == nil || entry == nil || entry.RunID == "" {
+			return nil
+		}
+		agentID, _ := types.AgentID(ctx)
+		event := runEventFromContext(ctx, entry.RunID, agentID)
+		event.Type = types.RunEventGuardrail
+		event.Timestamp = entry.Timestamp
+		event.TraceID = entry.TraceID
+		event.ToolName = entry.ToolName
+		event.Metadata = map[string]string{
+			usecase.RunEventMetadataValidator: entry.ValidatorName,
+			usecase.RunEventMetadataDecision:  string(entry.Decision),
+			"direction":                       string(entry.Direction),
+		}
+		if entry.TenantID != "" {
+			event.Metadata[llmobservability.RunMetadataTenantID] = entry.TenantID
+		}
+		data := map[string]any{}
+		if len(entry.MatchedRules) > 0 {
+			data["matched_rules"] = entry.MatchedRules
+		}
+		if len(entry.Errors) > 0 {
+			data["errors"] = entry.Errors
+		}
+		if entry.Redaction != nil {
+			data["redaction"] = entry.Redaction
+		}
+		if len(data) > 0 {
+			event.Data = data
+		}
+		recorder.AppendRunEvent(event)
+		return nil
+	})
+}
+
+func runEventFromContext(ctx context.Context, runID, agentID string) types.RunEvent {
+	event := types.RunEvent{RunID: runID, AgentID: agentID, Timestamp: time.Now()}
+	event.ParentRunID, _ = types.ParentRunID(ctx)
+	event.TraceID, _ = types.TraceID(ctx)
+	if tenantID, ok := types.TenantID(ctx); ok && tenantID != "" {
+		event.Metadata = map[string]string{llmobservability.RunMetadataTenantID: tenantID}
+	}
+	return event
+}
+
+func buildServeRunInspection(set *ServeHandlerSet, in ServeHandlerSetBuildInput) {
+	if set.RunRecorder == nil {
+		return
+	}
+	if set.ToolingRuntime != nil {
+		set.ToolingRuntime.ToolManager = WrapToolManagerWithRunRecorder(set.ToolingRuntime.ToolManager, set.RunRecorder)
+	}
+	if set.GuardrailAuditLogger != nil {
+		set.GuardrailAuditLogger.AddSink(NewGuardrailRunEventSink(set.RunRecorder))
+	}
+	set.RunHandler = handlers.NewRunHandler(NewRunInspectionService(set.RunRecorder), in.Logger)
+}
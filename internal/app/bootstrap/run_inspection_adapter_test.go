@@ -0,0 +1,51 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	llmtools "github.com/BaSui01/agentflow/llm/capabilities/tools"
+	llmobservability "github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRunToolManager struct{}
+
+func (stubRunToolManager) GetAllowedTools(string) []types.ToolSchema { return nil }
+
+func (stubRunToolManager) ExecuteForAgent(_ context.Context, _ string, calls []types.ToolCall) []llmtools.ToolResult {
+	out := make([]llmtools.ToolResult, len(calls))
+	for i, call := range calls {
+		out[i] = llmtools.ToolResult{ToolCallID: call.ID, Name: call.Name, Result: json.RawMessage(`"ok"`)}
+	}
+	return out
+}
+
+func TestRunInspection_RecordsToolCallsAndGuardrailDecisions(t *testing.T) {
+	recorder := llmobservability.NewRunRecorder(llmobservability.RunRecorderConfig{}, nil)
+	manager := WrapToolManagerWithRunRecorder(stubRunToolManager{}, recorder)
+	sink := NewGuardrailRunEventSink(recorder)
+
+	// 不属于任何运行的调用不记录
+	manager.ExecuteForAgent(context.Background(), "assistant", []types.ToolCall{{ID: "c0", Name: "search"}})
+	require.NoError(t, sink.Write(context.Background(), &guardrails.AuditLogEntry{ValidatorName: "pii"}))
+
+	ctx := types.WithTenantID(types.WithRunID(context.Background(), "run-1"), "t1")
+	results := manager.ExecuteForAgent(ctx, "assistant", []types.ToolCall{{ID: "c1", Name: "search"}})
+	require.Len(t, results, 1)
+	require.NoError(t, sink.Write(ctx, &guardrails.AuditLogEntry{
+		RunID: "run-1", ValidatorName: "pii", Decision: guardrails.AuditDecisionRedact, MatchedRules: []string{"email"},
+	}))
+
+	view, err := NewRunInspectionService(recorder).Get(context.Background(), "run-1", "t1")
+	require.Nil(t, err)
+	assert.Equal(t, 1, view.Usage.ToolCalls)
+	assert.Equal(t, 1, view.Usage.GuardrailDecisions)
+	require.Len(t, view.Trace.Children, 2)
+	assert.Equal(t, "ok", view.Trace.Children[0].Status)
+	assert.Equal(t, "redact", view.Trace.Children[1].Status)
+}
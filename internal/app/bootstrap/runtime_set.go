@@ -19,9 +19,13 @@ type LLMRuntimeSet struct {
 	LLMMetrics    *observability.Metrics
 	Ledger        observability.Ledger
 	ModelCatalog  *types.ModelCatalog
+	// RunRecorder 按运行归集生命周期、LLM 用量、工具调用与护栏决策，供运行检查接口读取
+	RunRecorder *observability.RunRecorder
 
 	MultimodalRedis   *redis.Client
 	ToolApprovalRedis *redis.Client
+	// RateLimitRedis 供 llm.distributed_rate_limit 使用，热重载 LLM runtime 时复用
+	RateLimitRedis *redis.Client
 }
 
 // IsAvailable returns true if the main LLM provider is configured.
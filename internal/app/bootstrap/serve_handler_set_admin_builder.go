@@ -6,8 +6,10 @@ import (
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 
 	"github.com/BaSui01/agentflow/api/handlers"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
 	"github.com/BaSui01/agentflow/internal/usecase"
 	llmrouter "github.com/BaSui01/agentflow/llm/runtime/router"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
 	"go.uber.org/zap"
 )
 
@@ -25,6 +27,16 @@ func buildServeAPIKeyHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput)
 	}
 }
 
+func buildServeTenantKeyHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput) {
+	if in.DB == nil {
+		in.Logger.Info("Database not available, tenant API key management disabled")
+		return
+	}
+	set.TenantKeyService = appservice.NewDefaultTenantKeyService(tenantkey.NewGormStore(in.DB), in.Logger)
+	set.TenantKeyHandler = handlers.NewTenantKeyHandler(set.TenantKeyService, in.Logger)
+	in.Logger.Info("Tenant API key handler initialized")
+}
+
 func buildServeProtocolHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput) *ProtocolRuntime {
 	protocolRuntime := BuildProtocolRuntime(in.lifecycleCtx(), in.Logger)
 	set.ProtocolHandler = handlers.NewProtocolHandler(protocolRuntime.MCPServer, protocolRuntime.A2AServer, in.Logger)
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
 	discovery "github.com/BaSui01/agentflow/agent/capabilities/tools"
 	"github.com/BaSui01/agentflow/agent/observability/hitl"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 	"github.com/BaSui01/agentflow/config"
+	appservice "github.com/BaSui01/agentflow/internal/app/service"
 	"github.com/BaSui01/agentflow/internal/usecase"
+	"github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/pkg/audit"
 	mongoclient "github.com/BaSui01/agentflow/pkg/mongodb"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -48,8 +52,12 @@ type ServeHandlerSet struct {
 
 	ChatService usecase.ChatService
 
-	ToolingRuntime    *AgentToolingRuntime
-	CapabilityCatalog *CapabilityCatalog
+	TenantKeyService       *appservice.DefaultTenantKeyService
+	ToolingRuntime         *AgentToolingRuntime
+	AgentDefinitionRuntime *AgentDefinitionRuntimeAdapter
+	CapabilityCatalog      *CapabilityCatalog
+	GuardrailAuditLogger   *guardrails.GuardrailAuditLogger
+	AuditTrail             *audit.Trail
 }
 
 // BuildServeHandlerSet builds serve-time handlers and runtime dependencies in one entry.
@@ -62,6 +70,7 @@ func BuildServeHandlerSet(in ServeHandlerSetBuildInput) (*ServeHandlerSet, error
 	}
 
 	set := &ServeHandlerSet{}
+	set.RunRecorder = observability.NewRunRecorder(observability.RunRecorderConfig{}, in.Logger)
 	modelCatalog, err := BuildModelCatalog(in.Cfg.LLM.ModelCatalogPath)
 	if err != nil {
 		return nil, err
@@ -75,6 +84,7 @@ func BuildServeHandlerSet(in ServeHandlerSetBuildInput) (*ServeHandlerSet, error
 	}
 	buildServeAgentRegistries(set, in.Logger)
 	buildServeAPIKeyHandler(set, in)
+	buildServeTenantKeyHandler(set, in)
 
 	if err := buildServeMultimodal(set, in, llmRuntime); err != nil {
 		return nil, err
@@ -90,6 +100,14 @@ func BuildServeHandlerSet(in ServeHandlerSetBuildInput) (*ServeHandlerSet, error
 	if err := buildServeChatHandler(set, in, llmRuntime); err != nil {
 		return nil, err
 	}
+	if err := buildServeGuardrailAudit(set, in); err != nil {
+		return nil, err
+	}
+	buildServeRunInspection(set, in)
+	buildServeInterruptHandler(set, in)
+	if err := buildServeAuditTrail(set, in); err != nil {
+		return nil, err
+	}
 	if err := buildServeAgentHandler(set, in, llmRuntime); err != nil {
 		return nil, err
 	}
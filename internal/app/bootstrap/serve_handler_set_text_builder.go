@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/BaSui01/agentflow/agent/persistence"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 	"github.com/BaSui01/agentflow/api/handlers"
 	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/internal/usecase"
 	"github.com/BaSui01/agentflow/llm/observability"
 	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
@@ -14,7 +16,12 @@ import (
 
 func buildServeLLMRuntime(set *ServeHandlerSet, in ServeHandlerSetBuildInput) (*LLMHandlerRuntime, error) {
 	mainProviderMode := config.NormalizeLLMMainProviderMode(in.Cfg.LLM.MainProviderMode)
-	llmRuntime, err := BuildLLMHandlerRuntime(in.Cfg, in.DB, in.Logger)
+	rateLimitRedis, err := BuildLLMRateLimitRedis(in.Cfg, in.Logger)
+	if err != nil {
+		in.Logger.Warn("Failed to connect distributed rate limit redis, distributed rate limiting disabled", zap.Error(err))
+	}
+	set.RateLimitRedis = rateLimitRedis
+	llmRuntime, err := BuildLLMHandlerRuntime(in.Cfg, in.DB, rateLimitRedis, in.Logger, set.RunRecorder)
 	if err != nil {
 		in.Logger.Warn("Failed to create LLM runtime, chat endpoints disabled",
 			zap.String("mode", mainProviderMode),
@@ -26,6 +33,7 @@ func buildServeLLMRuntime(set *ServeHandlerSet, in ServeHandlerSetBuildInput) (*
 		in.Logger.Info("LLM main provider not configured, chat endpoints disabled", zap.String("mode", mainProviderMode))
 		return nil, nil
 	}
+	set.ModelCatalog = ApplyProviderModelPricing(in.lifecycleCtx(), in.Cfg, llmRuntime, set.ModelCatalog, in.Logger)
 	set.Provider = llmRuntime.Provider
 	set.ToolProvider = llmRuntime.ToolProvider
 	set.BudgetManager = llmRuntime.BudgetManager
@@ -33,6 +41,14 @@ func buildServeLLMRuntime(set *ServeHandlerSet, in ServeHandlerSetBuildInput) (*
 	set.LLMCache = llmRuntime.Cache
 	set.LLMMetrics = llmRuntime.Metrics
 	set.CostHandler = handlers.NewCostHandler(NewCostQueryService(llmRuntime.CostTracker), in.Logger)
+	if llmRuntime.Cache != nil {
+		set.CacheAdminHandler = handlers.NewCacheAdminHandler(NewCacheAdminService(llmRuntime.Cache), in.Logger)
+	}
+	if llmRuntime.UsageLedger != nil {
+		set.UsageHandler = handlers.NewUsageHandler(NewUsageQueryService(llmRuntime.UsageLedger), in.Logger)
+		rollup := observability.NewUsageRollupJob(llmRuntime.UsageLedger.Store(), observability.UsageRollupConfig{}, in.Logger)
+		go rollup.Start(in.lifecycleCtx())
+	}
 	return llmRuntime, nil
 }
 
@@ -50,6 +66,7 @@ func buildServeChatHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput, l
 		PolicyManager:  llmRuntime.PolicyManager,
 		Ledger:         llmRuntime.Ledger,
 		ToolingRuntime: set.ToolingRuntime,
+		Embedding:      BuildChatEmbeddingProvider(in.Cfg),
 		Logger:         in.Logger,
 	})
 	if err != nil {
@@ -60,6 +77,8 @@ func buildServeChatHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput, l
 	if err != nil {
 		return fmt.Errorf("failed to create chat handler: %w", err)
 	}
+	chatHandler.ConfigureStreaming(defaultChatStreamingOptions())
+	configureChatBatch(chatHandler, in.Logger)
 	set.ChatHandler = chatHandler
 	in.Logger.Info("Chat handler initialized with middleware chain",
 		zap.String("mode", mainProviderMode),
@@ -107,24 +126,67 @@ func buildServeAgentHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInput,
 		if set.ToolingRuntime != nil {
 			authorizationService = set.ToolingRuntime.AuthorizationService
 		}
-		RegisterDefaultRuntimeAgentFactoryWithAuthorization(
+		RegisterDefaultRuntimeAgentFactoryWithOptions(
 			set.AgentRegistry,
 			llmRuntime.Gateway,
 			llmRuntime.ToolGateway,
 			set.CheckpointManager,
 			set.ModelCatalog,
 			ledger,
-			authorizationService,
+			RuntimeAgentFactoryOptions{
+				AuthorizationService: authorizationService,
+				GuardrailAuditLogger: set.GuardrailAuditLogger,
+			},
 			in.Logger,
 		)
 		in.Logger.Info("Default runtime agent factory registered")
 
-		set.AgentHandler = handlers.NewAgentHandlerWithService(BuildAgentService(set.DiscoveryRegistry, set.Resolver.Resolve), nil, in.Logger)
+		set.AgentHandler = handlers.NewAgentHandlerWithService(BuildAgentService(set.DiscoveryRegistry, set.Resolver.Resolve, set.RunRecorder), nil, in.Logger)
+		set.AgentHandler.ConfigureChatWebSocket(chatWebSocketOptions(in))
 		in.Logger.Info("Agent handler initialized with resolver")
+
+		set.AgentDefinitionHandler, set.AgentDefinitionRuntime = BuildAgentDefinitionHandler(in.lifecycleCtx(), in.DB, set.Resolver, in.Logger)
+		if set.AgentDefinitionHandler != nil {
+			in.Logger.Info("Agent definition handler initialized")
+		} else {
+			in.Logger.Info("Database not available, agent definition management disabled")
+		}
 		return nil
 	}
 
-	set.AgentHandler = handlers.NewAgentHandlerWithService(BuildAgentService(set.DiscoveryRegistry, nil), nil, in.Logger)
+	set.AgentHandler = handlers.NewAgentHandlerWithService(BuildAgentService(set.DiscoveryRegistry, nil, set.RunRecorder), nil, in.Logger)
+	set.AgentHandler.ConfigureChatWebSocket(chatWebSocketOptions(in))
 	in.Logger.Info("Agent handler initialized without resolver (no LLM provider)")
 	return nil
 }
+
+// chatWebSocketOptions restricts /v1/chat/ws to the configured CORS origins and
+// forwards tool approval interrupts to the connection whose run raised them.
+func chatWebSocketOptions(in ServeHandlerSetBuildInput) handlers.ChatWebSocketOptions {
+	return handlers.ChatWebSocketOptions{
+		AllowedOrigins: in.Cfg.Server.CORSAllowedOrigins,
+		Interrupts:     in.ToolApprovalManager,
+	}
+}
+
+// defaultChatStreamingOptions 为流式聊天启用注释心跳与基于 Last-Event-ID 的断线续传。
+// configureChatBatch backs /v1/chat/batch with a TaskStore and resumes jobs
+// the store still holds as pending or running.
+func configureChatBatch(chatHandler *handlers.ChatHandler, logger *zap.Logger) {
+	store, err := persistence.NewTaskStore(persistence.DefaultStoreConfig())
+	if err != nil {
+		logger.Warn("Chat batch store unavailable, batch endpoints disabled", zap.Error(err))
+		return
+	}
+	batch := usecase.NewDefaultChatBatchService(store, chatHandler.Service, usecase.ChatBatchOptions{}, logger)
+	if _, err := batch.Recover(context.Background()); err != nil {
+		logger.Warn("Failed to recover chat batch jobs", zap.Error(err))
+	}
+	chatHandler.ConfigureBatch(batch)
+}
+
+func defaultChatStreamingOptions() handlers.StreamingOptions {
+	return handlers.StreamingOptions{
+		Resume: handlers.NewStreamResumeBuffer(handlers.StreamResumeConfig{}),
+	}
+}
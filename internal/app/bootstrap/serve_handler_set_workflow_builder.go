@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"context"
 
+	"github.com/BaSui01/agentflow/agent/persistence"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
 	"github.com/BaSui01/agentflow/api/handlers"
 	"github.com/BaSui01/agentflow/internal/usecase"
@@ -32,8 +33,13 @@ func buildServeWorkflowHandler(set *ServeHandlerSet, in ServeHandlerSetBuildInpu
 		}
 	}
 
+	externalTasks := usecase.NewTaskStoreExternalTaskQueue(persistence.NewMemoryTaskStore(persistence.DefaultStoreConfig()))
+	workflowOpts.ExternalTasks = externalTasks
+	set.WorkflowExternalTasks = externalTasks
+
 	workflowRuntime := BuildWorkflowRuntime(in.Logger, workflowOpts)
 	set.WorkflowHandler = handlers.NewWorkflowHandler(usecase.NewDefaultWorkflowService(workflowRuntime.Facade, workflowRuntime.Parser), in.Logger)
+	set.ExternalTaskHandler = handlers.NewExternalTaskHandler(usecase.NewDefaultExternalTaskService(externalTasks), in.Logger)
 	in.Logger.Info("Workflow handler initialized")
 	return nil
 }
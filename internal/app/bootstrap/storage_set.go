@@ -17,6 +17,7 @@ type StorageSet struct {
 	CheckpointStore         agentcheckpoint.Store
 	CheckpointManager       *agent.CheckpointManager
 	WorkflowCheckpointStore workflowpkg.CheckpointStore
+	WorkflowExternalTasks   workflowpkg.ExternalTaskQueue
 	RAGStore                core.VectorStore
 	RAGEmbedding            core.EmbeddingProvider
 }
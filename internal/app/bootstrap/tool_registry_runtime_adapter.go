@@ -38,3 +38,11 @@ func (a *ToolRegistryRuntimeAdapter) BaseToolNames() []string {
 	}
 	return a.runtime.BaseToolNames()
 }
+
+// ValidateSecretRefs checks secret refs of HTTP-backed tool registrations.
+func (a *ToolRegistryRuntimeAdapter) ValidateSecretRefs(ctx context.Context, names []string) error {
+	if a == nil || a.runtime == nil {
+		return nil
+	}
+	return a.runtime.ValidateSecretRefs(ctx, names)
+}
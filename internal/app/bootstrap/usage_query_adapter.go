@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/BaSui01/agentflow/internal/usecase"
+	llmobservability "github.com/BaSui01/agentflow/llm/observability"
+)
+
+// usageReaderAdapter adapts observability.UsageStore to usecase.UsageReader interface.
+type usageReaderAdapter struct {
+	store llmobservability.UsageStore
+}
+
+func (a *usageReaderAdapter) QueryUsage(ctx context.Context, filter usecase.UsageFilter) ([]usecase.UsageBucketView, error) {
+	buckets, err := a.store.QueryUsage(ctx, llmobservability.UsageFilter{
+		TenantID: filter.TenantID,
+		Provider: filter.Provider,
+		Model:    filter.Model,
+		From:     filter.From,
+		To:       filter.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]usecase.UsageBucketView, len(buckets))
+	for i, b := range buckets {
+		out[i] = usecase.UsageBucketView{
+			Day:              b.Day,
+			TenantID:         b.TenantID,
+			Provider:         b.Provider,
+			Model:            b.Model,
+			Requests:         b.Requests,
+			CacheHits:        b.CacheHits,
+			PromptTokens:     b.PromptTokens,
+			CompletionTokens: b.CompletionTokens,
+			TotalTokens:      b.TotalTokens,
+			CachedTokens:     b.CachedTokens,
+			CostUSD:          b.CostUSD,
+			CacheSavingsUSD:  b.CacheSavingsUSD,
+		}
+	}
+	return out, nil
+}
+
+// NewUsageQueryService creates a UsageQueryService from the runtime usage ledger.
+func NewUsageQueryService(ledger *llmobservability.UsageLedger) usecase.UsageQueryService {
+	if ledger == nil || ledger.Store() == nil {
+		return nil
+	}
+	return usecase.NewDefaultUsageQueryService(&usageReaderAdapter{store: ledger.Store()})
+}
@@ -30,6 +30,7 @@ type WorkflowRuntimeOptions struct {
 	EmbeddingProvider       ragcore.EmbeddingProvider
 	CheckpointStore         agentcheckpoint.Store
 	WorkflowCheckpointStore workflow.CheckpointStore
+	ExternalTasks           workflow.ExternalTaskQueue
 	HITLManager             *hitl.InterruptManager
 	AuthorizationService    usecase.AuthorizationService
 }
@@ -66,6 +67,7 @@ func buildStepDependencies(opts WorkflowRuntimeOptions, logger *zap.Logger) engi
 			authorization: opts.AuthorizationService,
 			policy:        defaultWorkflowCodeExecutionPolicy(),
 		}.Execute,
+		ExternalTasks: opts.ExternalTasks,
 	}
 }
 
@@ -0,0 +1,8 @@
+package service
+
+// AgentDefinitionInput carries a raw declarative agent definition body.
+// Format is "yaml" or "json"; empty defaults to YAML, which also accepts JSON.
+type AgentDefinitionInput struct {
+	Body   []byte
+	Format string
+}
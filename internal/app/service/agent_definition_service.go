@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/adapters/declarative"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+var agentDefinitionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,119}$`)
+
+// AgentDefinitionRuntime hot-instantiates and retires agents built from
+// declarative definitions.
+type AgentDefinitionRuntime interface {
+	Deploy(ctx context.Context, cfg types.AgentConfig) error
+	Retire(ctx context.Context, agentID string)
+}
+
+type AgentDefinitionService interface {
+	List() ([]declarative.AgentDefinitionRecord, *types.Error)
+	Get(agentID string) (*declarative.AgentDefinitionRecord, *types.Error)
+	ListVersions(agentID string) ([]declarative.AgentDefinitionRecord, *types.Error)
+	Create(ctx context.Context, req AgentDefinitionInput) (*declarative.AgentDefinitionRecord, *types.Error)
+	Update(ctx context.Context, agentID string, req AgentDefinitionInput) (*declarative.AgentDefinitionRecord, *types.Error)
+	Delete(ctx context.Context, agentID string) *types.Error
+}
+
+// DefaultAgentDefinitionService stores versioned declarative agent definitions
+// and keeps the runtime in sync with the active version of each agent.
+type DefaultAgentDefinitionService struct {
+	store   declarative.DefinitionStore
+	runtime AgentDefinitionRuntime
+	loader  *declarative.YAMLLoader
+	factory *declarative.AgentFactory
+	logger  *zap.Logger
+}
+
+func NewDefaultAgentDefinitionService(store declarative.DefinitionStore, runtime AgentDefinitionRuntime, logger *zap.Logger) *DefaultAgentDefinitionService {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DefaultAgentDefinitionService{
+		store:   store,
+		runtime: runtime,
+		loader:  declarative.NewYAMLLoader(),
+		factory: declarative.NewAgentFactory(logger),
+		logger:  logger,
+	}
+}
+
+func (s *DefaultAgentDefinitionService) List() ([]declarative.AgentDefinitionRecord, *types.Error) {
+	rows, err := s.store.ListActive()
+	if err != nil {
+		return nil, types.NewInternalError("failed to list agent definitions").WithCause(err)
+	}
+	return rows, nil
+}
+
+func (s *DefaultAgentDefinitionService) Get(agentID string) (*declarative.AgentDefinitionRecord, *types.Error) {
+	row, err := s.store.GetActive(strings.TrimSpace(agentID))
+	if err != nil {
+		if errors.Is(err, declarative.ErrDefinitionNotFound) {
+			return nil, types.NewNotFoundError("agent definition not found")
+		}
+		return nil, types.NewInternalError("failed to get agent definition").WithCause(err)
+	}
+	return &row, nil
+}
+
+func (s *DefaultAgentDefinitionService) ListVersions(agentID string) ([]declarative.AgentDefinitionRecord, *types.Error) {
+	rows, err := s.store.ListVersions(strings.TrimSpace(agentID))
+	if err != nil {
+		return nil, types.NewInternalError("failed to list agent definition versions").WithCause(err)
+	}
+	if len(rows) == 0 {
+		return nil, types.NewNotFoundError("agent definition not found")
+	}
+	return rows, nil
+}
+
+// Create stores version 1 of a new agent definition and deploys it.
+func (s *DefaultAgentDefinitionService) Create(ctx context.Context, req AgentDefinitionInput) (*declarative.AgentDefinitionRecord, *types.Error) {
+	return s.save(ctx, "", req)
+}
+
+// Update appends a new version for an existing agent and redeploys it. The
+// previously running instance is retired once the new one is initialised.
+func (s *DefaultAgentDefinitionService) Update(ctx context.Context, agentID string, req AgentDefinitionInput) (*declarative.AgentDefinitionRecord, *types.Error) {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return nil, types.NewError(types.ErrInvalidRequest, "agent id is required")
+	}
+	return s.save(ctx, agentID, req)
+}
+
+func (s *DefaultAgentDefinitionService) Delete(ctx context.Context, agentID string) *types.Error {
+	if s.runtime == nil {
+		return types.NewInternalError("agent runtime is not configured")
+	}
+	agentID = strings.TrimSpace(agentID)
+	rowsAffected, err := s.store.Delete(agentID)
+	if err != nil {
+		return types.NewInternalError("failed to delete agent definition").WithCause(err)
+	}
+	if rowsAffected == 0 {
+		return types.NewNotFoundError("agent definition not found")
+	}
+	s.runtime.Retire(ctx, agentID)
+	return nil
+}
+
+// Restore deploys the active version of every stored definition. It is meant
+// to run once at startup; agents that fail to deploy are logged and skipped.
+func (s *DefaultAgentDefinitionService) Restore(ctx context.Context) (int, error) {
+	if s.runtime == nil {
+		return 0, errors.New("agent runtime is not configured")
+	}
+	rows, err := s.store.ListActive()
+	if err != nil {
+		return 0, err
+	}
+	deployed := 0
+	for _, row := range rows {
+		def, err := row.Decode()
+		if err != nil {
+			s.logger.Warn("Skipping undecodable agent definition",
+				zap.String("agent_id", row.AgentID), zap.Int("version", row.Version), zap.Error(err))
+			continue
+		}
+		if err := s.runtime.Deploy(ctx, s.factory.ToAgentConfig(def)); err != nil {
+			s.logger.Warn("Failed to deploy stored agent definition",
+				zap.String("agent_id", row.AgentID), zap.Int("version", row.Version), zap.Error(err))
+			continue
+		}
+		deployed++
+	}
+	return deployed, nil
+}
+
+// save parses and validates req, appends it as the next version of the agent
+// and deploys it. An empty pathID means create; otherwise the agent must exist
+// and the body ID, when set, must match pathID.
+func (s *DefaultAgentDefinitionService) save(ctx context.Context, pathID string, req AgentDefinitionInput) (*declarative.AgentDefinitionRecord, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("agent runtime is not configured")
+	}
+	def, format, parseErr := s.parse(req)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if pathID != "" {
+		if def.ID != "" && def.ID != pathID {
+			return nil, types.NewError(types.ErrInvalidRequest, "definition id does not match path id")
+		}
+		def.ID = pathID
+	} else if def.ID == "" {
+		def.ID = strings.TrimSpace(def.Name)
+	}
+	if !agentDefinitionIDPattern.MatchString(def.ID) {
+		return nil, types.NewError(types.ErrInvalidRequest, "agent id format is invalid")
+	}
+	normalized, err := json.Marshal(def)
+	if err != nil {
+		return nil, types.NewInternalError("failed to encode agent definition").WithCause(err)
+	}
+
+	var saved declarative.AgentDefinitionRecord
+	previousActive := 0
+	err = s.store.WithTransaction(ctx, func(txStore declarative.DefinitionStore) error {
+		latest, err := txStore.LatestVersion(def.ID)
+		if err != nil {
+			return err
+		}
+		if pathID == "" && latest > 0 {
+			return types.NewError(types.ErrInvalidRequest, "agent definition already exists")
+		}
+		if pathID != "" && latest == 0 {
+			return declarative.ErrDefinitionNotFound
+		}
+		active, err := txStore.GetActive(def.ID)
+		switch {
+		case err == nil:
+			previousActive = active.Version
+		case !errors.Is(err, declarative.ErrDefinitionNotFound):
+			return err
+		}
+		if err := txStore.Deactivate(def.ID); err != nil {
+			return err
+		}
+		saved = declarative.AgentDefinitionRecord{
+			AgentID:    def.ID,
+			Version:    latest + 1,
+			Format:     format,
+			Source:     string(req.Body),
+			Definition: normalized,
+			Active:     true,
+		}
+		return txStore.Create(&saved)
+	})
+	if err != nil {
+		if errors.Is(err, declarative.ErrDefinitionNotFound) {
+			return nil, types.NewNotFoundError("agent definition not found")
+		}
+		if te, ok := err.(*types.Error); ok {
+			return nil, te
+		}
+		if isUniqueViolation(err) {
+			return nil, types.NewError(types.ErrInvalidRequest, "agent definition version conflict, retry the request")
+		}
+		return nil, types.NewInternalError("failed to save agent definition").WithCause(err)
+	}
+
+	// Deploy only after the version is committed so agent initialisation never
+	// holds a DB transaction open; a definition that cannot be instantiated is
+	// rolled back to the previously active version.
+	if err := s.runtime.Deploy(ctx, s.factory.ToAgentConfig(def)); err != nil {
+		if rollbackErr := s.rollbackVersion(ctx, saved, previousActive); rollbackErr != nil {
+			s.logger.Error("Failed to roll back undeployable agent definition",
+				zap.String("agent_id", saved.AgentID), zap.Int("version", saved.Version), zap.Error(rollbackErr))
+			return nil, types.NewInternalError("failed to deploy agent and roll back definition").WithCause(errors.Join(err, rollbackErr))
+		}
+		return nil, types.NewError(types.ErrInvalidRequest, "failed to deploy agent: "+err.Error()).WithCause(err)
+	}
+	s.logger.Info("Agent definition deployed",
+		zap.String("agent_id", saved.AgentID), zap.Int("version", saved.Version))
+	return &saved, nil
+}
+
+// rollbackVersion removes a saved version whose deployment failed and
+// reactivates the version that was active before it, if any.
+func (s *DefaultAgentDefinitionService) rollbackVersion(ctx context.Context, saved declarative.AgentDefinitionRecord, previousActive int) error {
+	return s.store.WithTransaction(context.WithoutCancel(ctx), func(txStore declarative.DefinitionStore) error {
+		if err := txStore.DeleteVersion(saved.AgentID, saved.Version); err != nil {
+			return err
+		}
+		if previousActive == 0 {
+			return nil
+		}
+		return txStore.Activate(saved.AgentID, previousActive)
+	})
+}
+
+func (s *DefaultAgentDefinitionService) parse(req AgentDefinitionInput) (*declarative.AgentDefinition, string, *types.Error) {
+	if len(strings.TrimSpace(string(req.Body))) == 0 {
+		return nil, "", types.NewError(types.ErrInvalidRequest, "definition body is required")
+	}
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	switch format {
+	case "", "yml":
+		format = "yaml"
+	case "yaml", "json":
+	default:
+		return nil, "", types.NewError(types.ErrInvalidRequest, "format must be yaml or json")
+	}
+	def, err := s.loader.LoadBytes(req.Body, format)
+	if err != nil {
+		return nil, "", types.NewError(types.ErrInvalidRequest, "invalid agent definition: "+err.Error())
+	}
+	if err := s.factory.Validate(def); err != nil {
+		return nil, "", types.NewError(types.ErrInvalidRequest, err.Error())
+	}
+	def.ID = strings.TrimSpace(def.ID)
+	return def, format, nil
+}
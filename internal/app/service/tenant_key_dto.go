@@ -0,0 +1,48 @@
+package service
+
+import "time"
+
+// TenantKeyInput describes a tenant API key to issue.
+type TenantKeyInput struct {
+	TenantID             string
+	Name                 string
+	Scopes               []string
+	RateLimitRPM         int
+	MonthlyRequestBudget int64
+	ExpiresAt            *time.Time
+}
+
+// TenantKeyUpdate carries the mutable settings of a key; nil fields are left unchanged.
+type TenantKeyUpdate struct {
+	Name                 *string
+	Scopes               []string
+	RateLimitRPM         *int
+	MonthlyRequestBudget *int64
+	ExpiresAt            *time.Time
+}
+
+// TenantKeyView is the stored state of a key without its secret.
+type TenantKeyView struct {
+	ID                   uint       `json:"id"`
+	TenantID             string     `json:"tenant_id"`
+	Name                 string     `json:"name"`
+	Prefix               string     `json:"prefix"`
+	Scopes               []string   `json:"scopes"`
+	Status               string     `json:"status"`
+	RateLimitRPM         int        `json:"rate_limit_rpm"`
+	MonthlyRequestBudget int64      `json:"monthly_request_budget"`
+	PeriodRequests       int64      `json:"period_requests"`
+	UsagePeriod          string     `json:"usage_period,omitempty"`
+	RotatedFromID        *uint      `json:"rotated_from_id,omitempty"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt           *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// IssuedTenantKey is returned when a key is created or rotated. Key holds the
+// plaintext secret, which is never retrievable again.
+type IssuedTenantKey struct {
+	TenantKeyView
+	Key string `json:"key"`
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mw "github.com/BaSui01/agentflow/pkg/middleware"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// MaxTenantKeyRotationGrace bounds how long a rotated key keeps working.
+const MaxTenantKeyRotationGrace = 7 * 24 * time.Hour
+
+const (
+	TenantKeyStatusActive  = "active"
+	TenantKeyStatusRevoked = "revoked"
+	TenantKeyStatusExpired = "expired"
+)
+
+// TenantKeyService manages tenant API keys. A non-empty tenantID restricts an
+// operation to keys of that tenant; an empty tenantID is unrestricted.
+type TenantKeyService interface {
+	List(tenantID string) ([]TenantKeyView, *types.Error)
+	Get(tenantID string, id uint) (*TenantKeyView, *types.Error)
+	Create(ctx context.Context, in TenantKeyInput) (*IssuedTenantKey, *types.Error)
+	Update(ctx context.Context, tenantID string, id uint, in TenantKeyUpdate) (*TenantKeyView, *types.Error)
+	Rotate(ctx context.Context, tenantID string, id uint, grace time.Duration) (*IssuedTenantKey, *types.Error)
+	Revoke(ctx context.Context, tenantID string, id uint) (*TenantKeyView, *types.Error)
+}
+
+// DefaultTenantKeyService stores hashed tenant API keys and resolves them for
+// the X-API-Key middleware.
+type DefaultTenantKeyService struct {
+	store  tenantkey.Store
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+var (
+	_ TenantKeyService  = (*DefaultTenantKeyService)(nil)
+	_ mw.APIKeyResolver = (*DefaultTenantKeyService)(nil)
+)
+
+func NewDefaultTenantKeyService(store tenantkey.Store, logger *zap.Logger) *DefaultTenantKeyService {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DefaultTenantKeyService{store: store, logger: logger, now: time.Now}
+}
+
+func (s *DefaultTenantKeyService) List(tenantID string) ([]TenantKeyView, *types.Error) {
+	rows, err := s.store.List(strings.TrimSpace(tenantID))
+	if err != nil {
+		return nil, types.NewInternalError("failed to list API keys").WithCause(err)
+	}
+	now := s.now()
+	views := make([]TenantKeyView, 0, len(rows))
+	for _, row := range rows {
+		views = append(views, toTenantKeyView(row, now))
+	}
+	return views, nil
+}
+
+func (s *DefaultTenantKeyService) Get(tenantID string, id uint) (*TenantKeyView, *types.Error) {
+	row, svcErr := s.load(s.store, tenantID, id)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	view := toTenantKeyView(row, s.now())
+	return &view, nil
+}
+
+// Create issues a new key. Callers authenticated by an API key may only grant
+// scopes they hold themselves.
+func (s *DefaultTenantKeyService) Create(ctx context.Context, in TenantKeyInput) (*IssuedTenantKey, *types.Error) {
+	tenantID := strings.TrimSpace(in.TenantID)
+	if tenantID == "" {
+		return nil, types.NewInvalidRequestError("tenant_id is required")
+	}
+	scopes, svcErr := grantableScopes(ctx, in.Scopes)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	if len(scopes) == 0 {
+		return nil, types.NewInvalidRequestError("at least one scope is required")
+	}
+	if svcErr := validateTenantKeyLimits(in.RateLimitRPM, in.MonthlyRequestBudget); svcErr != nil {
+		return nil, svcErr
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(s.now()) {
+		return nil, types.NewInvalidRequestError("expires_at must be in the future")
+	}
+
+	secret, prefix, hash, err := tenantkey.Generate()
+	if err != nil {
+		return nil, types.NewInternalError("failed to generate API key").WithCause(err)
+	}
+	row := tenantkey.Key{
+		TenantID:             tenantID,
+		Name:                 strings.TrimSpace(in.Name),
+		Prefix:               prefix,
+		KeyHash:              hash,
+		Scopes:               strings.Join(scopes, " "),
+		RateLimitRPM:         in.RateLimitRPM,
+		MonthlyRequestBudget: in.MonthlyRequestBudget,
+		ExpiresAt:            in.ExpiresAt,
+	}
+	if err := s.store.Create(&row); err != nil {
+		return nil, types.NewInternalError("failed to create API key").WithCause(err)
+	}
+	s.logger.Info("Tenant API key issued", zap.String("tenant_id", tenantID), zap.Uint("key_id", row.ID), zap.Strings("scopes", scopes))
+	return &IssuedTenantKey{TenantKeyView: toTenantKeyView(row, s.now()), Key: secret}, nil
+}
+
+func (s *DefaultTenantKeyService) Update(ctx context.Context, tenantID string, id uint, in TenantKeyUpdate) (*TenantKeyView, *types.Error) {
+	row, svcErr := s.load(s.store, tenantID, id)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	updates := map[string]any{}
+	if in.Name != nil {
+		updates["name"] = strings.TrimSpace(*in.Name)
+	}
+	if in.Scopes != nil {
+		scopes, svcErr := grantableScopes(ctx, in.Scopes)
+		if svcErr != nil {
+			return nil, svcErr
+		}
+		if len(scopes) == 0 {
+			return nil, types.NewInvalidRequestError("at least one scope is required")
+		}
+		updates["scopes"] = strings.Join(scopes, " ")
+	}
+	rpm, budget := row.RateLimitRPM, row.MonthlyRequestBudget
+	if in.RateLimitRPM != nil {
+		rpm = *in.RateLimitRPM
+		updates["rate_limit_rpm"] = rpm
+	}
+	if in.MonthlyRequestBudget != nil {
+		budget = *in.MonthlyRequestBudget
+		updates["monthly_request_budget"] = budget
+	}
+	if svcErr := validateTenantKeyLimits(rpm, budget); svcErr != nil {
+		return nil, svcErr
+	}
+	if in.ExpiresAt != nil {
+		if !in.ExpiresAt.After(s.now()) {
+			return nil, types.NewInvalidRequestError("expires_at must be in the future")
+		}
+		updates["expires_at"] = *in.ExpiresAt
+	}
+	if len(updates) == 0 {
+		return nil, types.NewInvalidRequestError("no fields to update")
+	}
+	if err := s.store.Update(&row, updates); err != nil {
+		return nil, types.NewInternalError("failed to update API key").WithCause(err)
+	}
+	return s.Get(tenantID, id)
+}
+
+// Rotate issues a replacement key with the same tenant, scopes, limits and
+// current budget usage. The old key is revoked immediately, or expires after
+// grace so clients can switch over.
+func (s *DefaultTenantKeyService) Rotate(ctx context.Context, tenantID string, id uint, grace time.Duration) (*IssuedTenantKey, *types.Error) {
+	if grace < 0 || grace > MaxTenantKeyRotationGrace {
+		return nil, types.NewInvalidRequestError(fmt.Sprintf("grace period must be between 0 and %s", MaxTenantKeyRotationGrace))
+	}
+	secret, prefix, hash, err := tenantkey.Generate()
+	if err != nil {
+		return nil, types.NewInternalError("failed to generate API key").WithCause(err)
+	}
+
+	now := s.now()
+	var replacement tenantkey.Key
+	err = s.store.WithTransaction(ctx, func(tx tenantkey.Store) error {
+		old, svcErr := s.load(tx, tenantID, id)
+		if svcErr != nil {
+			return svcErr
+		}
+		if !old.Active(now) {
+			return types.NewInvalidRequestError("only active API keys can be rotated")
+		}
+		// 轮换会签发携带原 scopes 的新明文密钥，调用方必须持有全部 scopes
+		if _, svcErr := grantableScopes(ctx, old.ScopeList()); svcErr != nil {
+			return svcErr
+		}
+		oldID := old.ID
+		replacement = tenantkey.Key{
+			TenantID:             old.TenantID,
+			Name:                 old.Name,
+			Prefix:               prefix,
+			KeyHash:              hash,
+			Scopes:               old.Scopes,
+			RateLimitRPM:         old.RateLimitRPM,
+			MonthlyRequestBudget: old.MonthlyRequestBudget,
+			PeriodRequests:       old.PeriodRequests,
+			UsagePeriod:          old.UsagePeriod,
+			RotatedFromID:        &oldID,
+			ExpiresAt:            old.ExpiresAt,
+		}
+		if err := tx.Create(&replacement); err != nil {
+			return err
+		}
+		if grace == 0 {
+			return tx.Update(&old, map[string]any{"revoked_at": now})
+		}
+		cutoff := now.Add(grace)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(cutoff) {
+			return nil
+		}
+		return tx.Update(&old, map[string]any{"expires_at": cutoff})
+	})
+	if err != nil {
+		var svcErr *types.Error
+		if errors.As(err, &svcErr) {
+			return nil, svcErr
+		}
+		return nil, types.NewInternalError("failed to rotate API key").WithCause(err)
+	}
+	s.logger.Info("Tenant API key rotated",
+		zap.String("tenant_id", replacement.TenantID),
+		zap.Uint("old_key_id", id),
+		zap.Uint("key_id", replacement.ID),
+		zap.Duration("grace", grace))
+	return &IssuedTenantKey{TenantKeyView: toTenantKeyView(replacement, now), Key: secret}, nil
+}
+
+// Revoke disables a key immediately. Revoking an already revoked key is a no-op.
+func (s *DefaultTenantKeyService) Revoke(ctx context.Context, tenantID string, id uint) (*TenantKeyView, *types.Error) {
+	row, svcErr := s.load(s.store, tenantID, id)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	if row.RevokedAt == nil {
+		if err := s.store.Update(&row, map[string]any{"revoked_at": s.now()}); err != nil {
+			return nil, types.NewInternalError("failed to revoke API key").WithCause(err)
+		}
+		s.logger.Info("Tenant API key revoked", zap.String("tenant_id", row.TenantID), zap.Uint("key_id", row.ID))
+	}
+	return s.Get(tenantID, id)
+}
+
+// ResolveAPIKey implements middleware.APIKeyResolver.
+func (s *DefaultTenantKeyService) ResolveAPIKey(_ context.Context, key string) (*mw.APIKeyPrincipal, error) {
+	row, err := s.store.GetByHash(tenantkey.Hash(key))
+	if err != nil {
+		if errors.Is(err, tenantkey.ErrKeyNotFound) {
+			return nil, types.NewError(types.ErrAuthentication, "invalid or missing API key")
+		}
+		return nil, err
+	}
+	if !row.Active(s.now()) {
+		return nil, types.NewError(types.ErrAuthentication, "API key has been revoked or has expired")
+	}
+	return &mw.APIKeyPrincipal{
+		KeyID:        strconv.FormatUint(uint64(row.ID), 10),
+		TenantID:     row.TenantID,
+		Scopes:       row.ScopeList(),
+		RateLimitRPM: row.RateLimitRPM,
+	}, nil
+}
+
+// ConsumeAPIKeyBudget implements middleware.APIKeyResolver.
+func (s *DefaultTenantKeyService) ConsumeAPIKeyBudget(_ context.Context, principal *mw.APIKeyPrincipal) error {
+	id, err := strconv.ParseUint(principal.KeyID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid tenant api key id %q: %w", principal.KeyID, err)
+	}
+	ok, err := s.store.ConsumeRequest(uint(id), s.now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return types.NewError(types.ErrQuotaExceeded, "API key monthly request budget exhausted")
+	}
+	return nil
+}
+
+func (s *DefaultTenantKeyService) load(store tenantkey.Store, tenantID string, id uint) (tenantkey.Key, *types.Error) {
+	row, err := store.Get(id)
+	if err != nil {
+		if errors.Is(err, tenantkey.ErrKeyNotFound) {
+			return row, types.NewNotFoundError("API key not found")
+		}
+		return row, types.NewInternalError("failed to load API key").WithCause(err)
+	}
+	// Keys of other tenants are reported as missing so their IDs do not leak.
+	if tenantID = strings.TrimSpace(tenantID); tenantID != "" && row.TenantID != tenantID {
+		return row, types.NewNotFoundError("API key not found")
+	}
+	return row, nil
+}
+
+// grantableScopes normalizes requested scopes and, for callers authenticated
+// by an API key, rejects scopes the caller does not hold.
+func grantableScopes(ctx context.Context, requested []string) ([]string, *types.Error) {
+	scopes, err := tenantkey.NormalizeScopes(requested)
+	if err != nil {
+		return nil, types.NewInvalidRequestError(err.Error())
+	}
+	if granted, ok := types.Scopes(ctx); ok {
+		for _, scope := range scopes {
+			if !tenantkey.HasScope(granted, scope) {
+				return nil, types.NewError(types.ErrForbidden, fmt.Sprintf("cannot grant scope %q that the caller does not hold", scope))
+			}
+		}
+	}
+	return scopes, nil
+}
+
+func validateTenantKeyLimits(rpm int, budget int64) *types.Error {
+	if rpm < 0 {
+		return types.NewInvalidRequestError("rate_limit_rpm must be non-negative")
+	}
+	if budget < 0 {
+		return types.NewInvalidRequestError("monthly_request_budget must be non-negative")
+	}
+	return nil
+}
+
+func toTenantKeyView(row tenantkey.Key, now time.Time) TenantKeyView {
+	status := TenantKeyStatusActive
+	switch {
+	case row.RevokedAt != nil:
+		status = TenantKeyStatusRevoked
+	case !row.Active(now):
+		status = TenantKeyStatusExpired
+	}
+	periodRequests := row.PeriodRequests
+	if row.UsagePeriod != tenantkey.UsagePeriodOf(now) {
+		periodRequests = 0
+	}
+	return TenantKeyView{
+		ID:                   row.ID,
+		TenantID:             row.TenantID,
+		Name:                 row.Name,
+		Prefix:               row.Prefix,
+		Scopes:               row.ScopeList(),
+		Status:               status,
+		RateLimitRPM:         row.RateLimitRPM,
+		MonthlyRequestBudget: row.MonthlyRequestBudget,
+		PeriodRequests:       periodRequests,
+		UsagePeriod:          tenantkey.UsagePeriodOf(now),
+		RotatedFromID:        row.RotatedFromID,
+		ExpiresAt:            row.ExpiresAt,
+		RevokedAt:            row.RevokedAt,
+		LastUsedAt:           row.LastUsedAt,
+		CreatedAt:            row.CreatedAt,
+	}
+}
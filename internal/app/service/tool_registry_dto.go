@@ -5,8 +5,10 @@ import "encoding/json"
 type CreateToolRegistrationInput struct {
 	Name        string
 	Description string
+	Kind        string
 	Target      string
 	Parameters  json.RawMessage
+	HTTPConfig  json.RawMessage
 	Enabled     *bool
 }
 
@@ -15,5 +17,6 @@ type UpdateToolRegistrationInput struct {
 	Description *string
 	Target      *string
 	Parameters  *json.RawMessage
+	HTTPConfig  *json.RawMessage
 	Enabled     *bool
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/BaSui01/agentflow/agent/integration/hosted"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
 	"github.com/BaSui01/agentflow/types"
 )
 
@@ -21,13 +22,20 @@ type ToolRegistryRuntime interface {
 	BaseToolNames() []string
 }
 
+// ToolSecretValidator is optionally implemented by runtimes that can check
+// secret references of HTTP-backed tools before they are stored.
+type ToolSecretValidator interface {
+	ValidateSecretRefs(ctx context.Context, names []string) error
+}
+
 type ToolRegistryService interface {
 	List() ([]hosted.ToolRegistration, *types.Error)
 	ListTargets() ([]string, *types.Error)
-	Create(req CreateToolRegistrationInput) (*hosted.ToolRegistration, *types.Error)
+	Create(ctx context.Context, req CreateToolRegistrationInput) (*hosted.ToolRegistration, *types.Error)
 	Update(ctx context.Context, id uint, req UpdateToolRegistrationInput) (*hosted.ToolRegistration, *types.Error)
-	Delete(id uint) *types.Error
-	Reload() *types.Error
+	SetEnabled(ctx context.Context, id uint, enabled bool) (*hosted.ToolRegistration, *types.Error)
+	Delete(ctx context.Context, id uint) *types.Error
+	Reload(ctx context.Context) *types.Error
 }
 
 type DefaultToolRegistryService struct {
@@ -59,10 +67,14 @@ func (s *DefaultToolRegistryService) ListTargets() ([]string, *types.Error) {
 	return targets, nil
 }
 
-func (s *DefaultToolRegistryService) Create(req CreateToolRegistrationInput) (*hosted.ToolRegistration, *types.Error) {
+func (s *DefaultToolRegistryService) Create(ctx context.Context, req CreateToolRegistrationInput) (*hosted.ToolRegistration, *types.Error) {
 	if s.runtime == nil {
 		return nil, types.NewInternalError("tool runtime is not configured")
 	}
+	kind, kindErr := normalizeToolRegistrationKind(req.Kind)
+	if kindErr != nil {
+		return nil, kindErr
+	}
 	name := strings.TrimSpace(req.Name)
 	target := strings.TrimSpace(req.Target)
 	if err := validateToolRegistrationName(name); err != nil {
@@ -71,12 +83,6 @@ func (s *DefaultToolRegistryService) Create(req CreateToolRegistrationInput) (*h
 	if err := s.validateAliasName(name); err != nil {
 		return nil, err
 	}
-	if err := s.validateTarget(target); err != nil {
-		return nil, err
-	}
-	if name == target {
-		return nil, types.NewError(types.ErrInvalidRequest, "name and target must be different")
-	}
 	if err := validateToolRegistrationParameters(req.Parameters); err != nil {
 		return nil, err
 	}
@@ -84,17 +90,42 @@ func (s *DefaultToolRegistryService) Create(req CreateToolRegistrationInput) (*h
 	row := &hosted.ToolRegistration{
 		Name:        name,
 		Description: strings.TrimSpace(req.Description),
-		Target:      target,
+		Kind:        kind,
 		Parameters:  req.Parameters,
 		Enabled:     req.Enabled == nil || *req.Enabled,
 	}
+	if kind == hosted.ToolRegistrationKindHTTP {
+		if err := requireToolOperator(ctx); err != nil {
+			return nil, err
+		}
+		if target != "" {
+			return nil, types.NewError(types.ErrInvalidRequest, "target is not supported for http tools")
+		}
+		config, err := s.validateHTTPConfig(ctx, req.HTTPConfig, req.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		row.HTTPConfig = config
+	} else {
+		if len(req.HTTPConfig) > 0 && string(req.HTTPConfig) != "null" {
+			return nil, types.NewError(types.ErrInvalidRequest, "http_config is only supported for http tools")
+		}
+		if err := s.validateTarget(target); err != nil {
+			return nil, err
+		}
+		if name == target {
+			return nil, types.NewError(types.ErrInvalidRequest, "name and target must be different")
+		}
+		row.Target = target
+	}
+
 	if err := s.store.Create(row); err != nil {
 		if isUniqueViolation(err) {
 			return nil, types.NewError(types.ErrInvalidRequest, "tool name already exists")
 		}
 		return nil, types.NewInternalError("failed to create tool registration").WithCause(err)
 	}
-	if err := s.runtime.ReloadBindings(context.Background()); err != nil {
+	if err := s.runtime.ReloadBindings(ctx); err != nil {
 		return nil, types.NewInternalError("created but failed to reload tool runtime").WithCause(err)
 	}
 	return row, nil
@@ -127,30 +158,57 @@ func (s *DefaultToolRegistryService) Update(ctx context.Context, id uint, req Up
 		if req.Description != nil {
 			updates["description"] = strings.TrimSpace(*req.Description)
 		}
-		if req.Target != nil {
-			target := strings.TrimSpace(*req.Target)
-			if err := s.validateTarget(target); err != nil {
-				return err
-			}
-			updates["target"] = target
-		}
-		nextName := row.Name
-		if v, ok := updates["name"].(string); ok {
-			nextName = v
-		}
-		nextTarget := row.Target
-		if v, ok := updates["target"].(string); ok {
-			nextTarget = v
-		}
-		if nextName == nextTarget {
-			return types.NewError(types.ErrInvalidRequest, "name and target must be different")
-		}
 		if req.Parameters != nil {
 			if err := validateToolRegistrationParameters(*req.Parameters); err != nil {
 				return err
 			}
 			updates["parameters"] = *req.Parameters
 		}
+		if row.IsHTTP() {
+			if err := requireToolOperator(ctx); err != nil {
+				return err
+			}
+			if req.Target != nil {
+				return types.NewError(types.ErrInvalidRequest, "target is not supported for http tools")
+			}
+			if req.HTTPConfig != nil || req.Parameters != nil {
+				nextConfig := row.HTTPConfig
+				if req.HTTPConfig != nil {
+					nextConfig = *req.HTTPConfig
+				}
+				nextParameters := row.Parameters
+				if req.Parameters != nil {
+					nextParameters = *req.Parameters
+				}
+				config, err := s.validateHTTPConfig(ctx, nextConfig, nextParameters)
+				if err != nil {
+					return err
+				}
+				updates["http_config"] = config
+			}
+		} else {
+			if req.HTTPConfig != nil {
+				return types.NewError(types.ErrInvalidRequest, "http_config is only supported for http tools")
+			}
+			if req.Target != nil {
+				target := strings.TrimSpace(*req.Target)
+				if err := s.validateTarget(target); err != nil {
+					return err
+				}
+				updates["target"] = target
+			}
+			nextName := row.Name
+			if v, ok := updates["name"].(string); ok {
+				nextName = v
+			}
+			nextTarget := row.Target
+			if v, ok := updates["target"].(string); ok {
+				nextTarget = v
+			}
+			if nextName == nextTarget {
+				return types.NewError(types.ErrInvalidRequest, "name and target must be different")
+			}
+		}
 		if req.Enabled != nil {
 			updates["enabled"] = *req.Enabled
 		}
@@ -179,13 +237,18 @@ func (s *DefaultToolRegistryService) Update(ctx context.Context, id uint, req Up
 		}
 		return nil, types.NewInternalError("failed to update tool registration").WithCause(err)
 	}
-	if err := s.runtime.ReloadBindings(context.Background()); err != nil {
+	if err := s.runtime.ReloadBindings(ctx); err != nil {
 		return nil, types.NewInternalError("updated but failed to reload tool runtime").WithCause(err)
 	}
 	return &updatedRow, nil
 }
 
-func (s *DefaultToolRegistryService) Delete(id uint) *types.Error {
+// SetEnabled enables or disables a registration; disabled tools are removed from the runtime on reload.
+func (s *DefaultToolRegistryService) SetEnabled(ctx context.Context, id uint, enabled bool) (*hosted.ToolRegistration, *types.Error) {
+	return s.Update(ctx, id, UpdateToolRegistrationInput{Enabled: &enabled})
+}
+
+func (s *DefaultToolRegistryService) Delete(ctx context.Context, id uint) *types.Error {
 	if s.runtime == nil {
 		return types.NewInternalError("tool runtime is not configured")
 	}
@@ -196,17 +259,17 @@ func (s *DefaultToolRegistryService) Delete(id uint) *types.Error {
 	if rowsAffected == 0 {
 		return types.NewNotFoundError("tool registration not found")
 	}
-	if err := s.runtime.ReloadBindings(context.Background()); err != nil {
+	if err := s.runtime.ReloadBindings(ctx); err != nil {
 		return types.NewInternalError("deleted but failed to reload tool runtime").WithCause(err)
 	}
 	return nil
 }
 
-func (s *DefaultToolRegistryService) Reload() *types.Error {
+func (s *DefaultToolRegistryService) Reload(ctx context.Context) *types.Error {
 	if s.runtime == nil {
 		return types.NewInternalError("tool runtime is not configured")
 	}
-	if err := s.runtime.ReloadBindings(context.Background()); err != nil {
+	if err := s.runtime.ReloadBindings(ctx); err != nil {
 		return types.NewInternalError("failed to reload tool runtime").WithCause(err)
 	}
 	return nil
@@ -235,6 +298,54 @@ func (s *DefaultToolRegistryService) validateAliasName(name string) *types.Error
 	return nil
 }
 
+// requireToolOperator restricts HTTP tool registrations to operators. HTTP
+// tools are process-wide and can read AGENTFLOW_TOOL_SECRET_* secrets, so
+// tenant-bound callers are refused even when they hold tools:admin. Callers
+// without scopes in ctx come from trusted in-process paths.
+func requireToolOperator(ctx context.Context) *types.Error {
+	if tenantID, ok := types.TenantID(ctx); ok && tenantID != "" {
+		return types.NewError(types.ErrForbidden, "http tools can only be managed by operators")
+	}
+	if granted, ok := types.Scopes(ctx); ok && !tenantkey.HasScope(granted, tenantkey.ScopeAdmin) {
+		return types.NewError(types.ErrForbidden, "http tools require the admin scope")
+	}
+	return nil
+}
+
+// validateHTTPConfig checks the HTTP call template and returns its normalized JSON.
+func (s *DefaultToolRegistryService) validateHTTPConfig(ctx context.Context, raw json.RawMessage, parameters json.RawMessage) (json.RawMessage, *types.Error) {
+	tmpl, err := hosted.ParseHTTPToolTemplate(raw)
+	if err != nil {
+		return nil, types.NewError(types.ErrInvalidRequest, err.Error())
+	}
+	if err := tmpl.Validate(parameters); err != nil {
+		return nil, types.NewError(types.ErrInvalidRequest, "invalid http_config: "+err.Error())
+	}
+	if validator, ok := s.runtime.(ToolSecretValidator); ok {
+		if err := validator.ValidateSecretRefs(ctx, tmpl.SecretRefs()); err != nil {
+			return nil, types.NewError(types.ErrInvalidRequest, "invalid http_config: "+err.Error())
+		}
+	}
+	tmpl.Method = strings.ToUpper(strings.TrimSpace(tmpl.Method))
+	tmpl.URL = strings.TrimSpace(tmpl.URL)
+	normalized, err := json.Marshal(tmpl)
+	if err != nil {
+		return nil, types.NewInternalError("failed to encode http_config").WithCause(err)
+	}
+	return normalized, nil
+}
+
+func normalizeToolRegistrationKind(kind string) (string, *types.Error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", hosted.ToolRegistrationKindAlias:
+		return hosted.ToolRegistrationKindAlias, nil
+	case hosted.ToolRegistrationKindHTTP:
+		return hosted.ToolRegistrationKindHTTP, nil
+	default:
+		return "", types.NewError(types.ErrInvalidRequest, "kind must be alias or http")
+	}
+}
+
 func validateToolRegistrationName(name string) *types.Error {
 	if name == "" {
 		return types.NewError(types.ErrInvalidRequest, "name is required")
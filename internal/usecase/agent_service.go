@@ -23,6 +23,7 @@ type AgentOperation string
 const (
 	AgentOperationExecute AgentOperation = "execution"
 	AgentOperationStream  AgentOperation = "streaming"
+	AgentOperationExplain AgentOperation = "explainability"
 	maxExecuteAgentCount                 = 5
 )
 
@@ -44,6 +45,7 @@ type AgentExecuteRequest struct {
 // AgentExecuteResponse is the response payload for agent execute operations.
 type AgentExecuteResponse struct {
 	TraceID               string         `json:"trace_id"`
+	RunID                 string         `json:"run_id,omitempty"`
 	Content               string         `json:"content"`
 	Metadata              map[string]any `json:"metadata,omitempty"`
 	TokensUsed            int            `json:"tokens_used,omitempty"`
@@ -65,12 +67,14 @@ type AgentService interface {
 	GetAgent(ctx context.Context, agentID string) (*discovery.AgentInfo, *types.Error)
 	ExecuteAgent(ctx context.Context, req AgentExecuteRequest, traceID string) (*AgentExecuteResponse, time.Duration, *types.Error)
 	ExecuteAgentStream(ctx context.Context, req AgentExecuteRequest, traceID string, emitter agent.RuntimeStreamEmitter) *types.Error
+	GetDecisionGraph(ctx context.Context, agentID, traceID string) (*agent.DecisionGraph, *types.Error)
 }
 
 // DefaultAgentService is the default AgentService implementation used by AgentHandler.
 type DefaultAgentService struct {
 	registry discovery.Registry
 	resolver AgentResolver
+	runs     RunEventRecorder
 }
 
 // NewDefaultAgentService constructs a service with resolver+registry fallback strategy.
@@ -81,6 +85,13 @@ func NewDefaultAgentService(registry discovery.Registry, resolver AgentResolver)
 	}
 }
 
+// WithRunRecorder records the lifecycle of API-initiated runs so they can be
+// inspected through the run endpoints.
+func (s *DefaultAgentService) WithRunRecorder(recorder RunEventRecorder) *DefaultAgentService {
+	s.runs = recorder
+	return s
+}
+
 // ResolveForOperation resolves an agent for execute/stream operations.
 func (s *DefaultAgentService) ResolveForOperation(ctx context.Context, agentID string, op AgentOperation) (agent.Agent, *types.Error) {
 	if s.resolver != nil {
@@ -128,11 +139,12 @@ func (s *DefaultAgentService) GetAgent(ctx context.Context, agentID string) (*di
 }
 
 func (s *DefaultAgentService) ExecuteAgent(ctx context.Context, req AgentExecuteRequest, traceID string) (*AgentExecuteResponse, time.Duration, *types.Error) {
-	execCtx := applyAgentRoutingContext(ctx, req)
+	execCtx, run := beginAgentRun(applyAgentRoutingContext(ctx, req), s.runs, req, traceID)
 	input := toAgentInput(req, traceID)
 	start := time.Now()
 	output, execErr := s.executeWithResolvedAgents(execCtx, req, input)
 	duration := time.Since(start)
+	run.finish(output, execErr)
 	if execErr != nil {
 		return nil, duration, ToTypesAgentError(execErr)
 	}
@@ -140,6 +152,7 @@ func (s *DefaultAgentService) ExecuteAgent(ctx context.Context, req AgentExecute
 
 	return &AgentExecuteResponse{
 		TraceID:               output.TraceID,
+		RunID:                 run.runID(),
 		Content:               output.Content,
 		Metadata:              output.Metadata,
 		TokensUsed:            output.TokensUsed,
@@ -155,6 +168,24 @@ func (s *DefaultAgentService) ExecuteAgent(ctx context.Context, req AgentExecute
 	}, duration, nil
 }
 
+// GetDecisionGraph returns the decision graph recorded by an agent for the given trace.
+func (s *DefaultAgentService) GetDecisionGraph(ctx context.Context, agentID, traceID string) (*agent.DecisionGraph, *types.Error) {
+	ag, err := s.ResolveForOperation(ctx, agentID, AgentOperationExplain)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := ag.(agent.DecisionGraphReader)
+	if !ok {
+		return nil, types.NewInternalError("agent does not record explainability traces").
+			WithHTTPStatus(http.StatusNotImplemented)
+	}
+	graph, found := reader.DecisionGraph(traceID)
+	if !found || graph == nil {
+		return nil, types.NewNotFoundError(fmt.Sprintf("trace %q not found", traceID))
+	}
+	return graph, nil
+}
+
 // PlanAgent remains an internal helper for package-level tests and direct usecase calls.
 // It is no longer part of the public HTTP/API execution surface.
 func (s *DefaultAgentService) PlanAgent(ctx context.Context, req AgentExecuteRequest, traceID string) (*agent.PlanResult, *types.Error) {
@@ -184,8 +215,10 @@ func (s *DefaultAgentService) ExecuteAgentStream(ctx context.Context, req AgentE
 	if handoffErr != nil {
 		return handoffErr
 	}
+	streamCtx, run := beginAgentRun(streamCtx, s.runs, req, traceID)
 	streamCtx = agent.WithRuntimeStreamEmitter(streamCtx, emitter)
-	_, execErr := ag.Execute(streamCtx, toAgentInput(req, traceID))
+	output, execErr := ag.Execute(streamCtx, toAgentInput(req, traceID))
+	run.finish(output, execErr)
 	if execErr != nil {
 		return ToTypesAgentError(execErr)
 	}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	defaultAuditTrailLimit = 100
+	maxAuditTrailLimit     = 500
+	maxAuditExportLimit    = 10000
+)
+
+// AuditTrailRuntime is the queryable store behind the unified audit API.
+// *audit.Trail satisfies it.
+type AuditTrailRuntime interface {
+	Query(ctx context.Context, filter audit.Filter) ([]*audit.Event, error)
+}
+
+type ListAuditEventsInput struct {
+	Limit      int
+	Offset     int
+	Category   string
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	Outcome    string
+	TenantID   string
+	Since      time.Time
+	Until      time.Time
+}
+
+type AuditTrailService interface {
+	// List returns matching events newest first, for interactive review.
+	List(ctx context.Context, input ListAuditEventsInput) ([]*audit.Event, *types.Error)
+	// Export returns matching events oldest first, for SIEM ingestion.
+	Export(ctx context.Context, input ListAuditEventsInput) ([]*audit.Event, *types.Error)
+}
+
+type DefaultAuditTrailService struct {
+	runtime AuditTrailRuntime
+}
+
+func NewDefaultAuditTrailService(runtime AuditTrailRuntime) *DefaultAuditTrailService {
+	return &DefaultAuditTrailService{runtime: runtime}
+}
+
+func (s *DefaultAuditTrailService) List(ctx context.Context, input ListAuditEventsInput) ([]*audit.Event, *types.Error) {
+	limit, err := normalizeAuditTrailLimit(input.Limit, defaultAuditTrailLimit, maxAuditTrailLimit)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*audit.Event, 0, min(limit, len(rows)))
+	skipped := 0
+	for i := len(rows) - 1; i >= 0 && len(out) < limit; i-- {
+		if skipped < input.Offset {
+			skipped++
+			continue
+		}
+		out = append(out, rows[i])
+	}
+	return out, nil
+}
+
+func (s *DefaultAuditTrailService) Export(ctx context.Context, input ListAuditEventsInput) ([]*audit.Event, *types.Error) {
+	limit, err := normalizeAuditTrailLimit(input.Limit, maxAuditExportLimit, maxAuditExportLimit)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if input.Offset >= len(rows) {
+		return []*audit.Event{}, nil
+	}
+	rows = rows[input.Offset:]
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (s *DefaultAuditTrailService) query(ctx context.Context, input ListAuditEventsInput) ([]*audit.Event, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("audit trail runtime is not configured")
+	}
+	if input.Offset < 0 {
+		return nil, types.NewInvalidRequestError("offset must not be negative")
+	}
+	if !input.Since.IsZero() && !input.Until.IsZero() && input.Until.Before(input.Since) {
+		return nil, types.NewInvalidRequestError("until must not be before since")
+	}
+	filter := audit.Filter{
+		Actor:      strings.TrimSpace(input.Actor),
+		Action:     strings.TrimSpace(input.Action),
+		Resource:   strings.TrimSpace(input.Resource),
+		ResourceID: strings.TrimSpace(input.ResourceID),
+		Outcome:    audit.Outcome(strings.TrimSpace(input.Outcome)),
+		TenantID:   strings.TrimSpace(input.TenantID),
+		Since:      input.Since,
+		Until:      input.Until,
+	}
+	for _, category := range strings.Split(input.Category, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			filter.Categories = append(filter.Categories, audit.Category(category))
+		}
+	}
+	rows, queryErr := s.runtime.Query(ctx, filter)
+	if queryErr != nil {
+		return nil, types.NewInternalError("failed to query audit trail").WithCause(queryErr)
+	}
+	return rows, nil
+}
+
+func normalizeAuditTrailLimit(limit, defaultLimit, maxLimit int) (int, *types.Error) {
+	if limit == 0 {
+		return defaultLimit, nil
+	}
+	if limit < 0 {
+		return 0, types.NewInvalidRequestError("limit must be greater than 0")
+	}
+	if limit > maxLimit {
+		return 0, types.NewInvalidRequestError(fmt.Sprintf("limit must be less than or equal to %d", maxLimit))
+	}
+	return limit, nil
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/audit"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditTrailFixture(t *testing.T) *audit.Trail {
+	t.Helper()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	trail := audit.NewTrail(audit.TrailConfig{})
+	events := []audit.Event{
+		{Category: audit.CategoryConfig, Action: "config.update", Resource: "config", ResourceID: "Log.Level", Actor: "admin"},
+		{Category: audit.CategoryAPIKey, Action: "api_key.create", Resource: "api_key", ResourceID: "7", Actor: "admin"},
+		{Category: audit.CategoryGuardrail, Action: "guardrail.block", Resource: "injection_detector", Outcome: audit.OutcomeDenied, TenantID: "tenant-a"},
+		{Category: audit.CategoryHITL, Action: "hitl.approve", Resource: "interrupt", Actor: "reviewer"},
+	}
+	for i, event := range events {
+		event.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		_, err := trail.Record(context.Background(), event)
+		require.NoError(t, err)
+	}
+	return trail
+}
+
+func TestAuditTrailService_ListFiltersNewestFirst(t *testing.T) {
+	service := NewDefaultAuditTrailService(newAuditTrailFixture(t))
+
+	rows, err := service.List(context.Background(), ListAuditEventsInput{Actor: "admin"})
+	require.Nil(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "api_key.create", rows[0].Action)
+	assert.Equal(t, "config.update", rows[1].Action)
+
+	rows, err = service.List(context.Background(), ListAuditEventsInput{Category: "guardrail, hitl"})
+	require.Nil(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, audit.CategoryHITL, rows[0].Category)
+
+	rows, err = service.List(context.Background(), ListAuditEventsInput{
+		Since: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC),
+		Limit: 1,
+	})
+	require.Nil(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "guardrail.block", rows[0].Action)
+}
+
+func TestAuditTrailService_ExportOldestFirst(t *testing.T) {
+	service := NewDefaultAuditTrailService(newAuditTrailFixture(t))
+
+	rows, err := service.Export(context.Background(), ListAuditEventsInput{Offset: 1, Limit: 2})
+	require.Nil(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "api_key.create", rows[0].Action)
+	assert.Equal(t, "guardrail.block", rows[1].Action)
+
+	rows, err = service.Export(context.Background(), ListAuditEventsInput{Offset: 10})
+	require.Nil(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestAuditTrailService_ValidatesInput(t *testing.T) {
+	service := NewDefaultAuditTrailService(newAuditTrailFixture(t))
+
+	_, err := service.List(context.Background(), ListAuditEventsInput{Limit: 501})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = service.List(context.Background(), ListAuditEventsInput{
+		Since: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = NewDefaultAuditTrailService(nil).Export(context.Background(), ListAuditEventsInput{})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInternalError, err.Code)
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// CachePurgeResult reports the outcome of a tenant cache purge.
+type CachePurgeResult struct {
+	TenantID   string `json:"tenant_id"`
+	PurgedKeys int    `json:"purged_keys"`
+}
+
+// TenantCachePurger abstracts the tenant-scoped cache operations needed by CacheAdminService.
+type TenantCachePurger interface {
+	PurgeTenant(ctx context.Context, tenantID string) (int, error)
+}
+
+// CacheAdminService provides response-cache administration for the API layer.
+type CacheAdminService interface {
+	// PurgeTenant removes every cached completion belonging to the tenant.
+	PurgeTenant(ctx context.Context, tenantID string) (*CachePurgeResult, *types.Error)
+}
+
+// DefaultCacheAdminService is the default implementation of CacheAdminService.
+type DefaultCacheAdminService struct {
+	cache TenantCachePurger
+}
+
+// NewDefaultCacheAdminService creates a new CacheAdminService with the given cache.
+func NewDefaultCacheAdminService(cache TenantCachePurger) *DefaultCacheAdminService {
+	return &DefaultCacheAdminService{cache: cache}
+}
+
+// PurgeTenant removes every cached completion belonging to the tenant.
+func (s *DefaultCacheAdminService) PurgeTenant(ctx context.Context, tenantID string) (*CachePurgeResult, *types.Error) {
+	if s.cache == nil {
+		return nil, types.NewInternalError("response cache is not configured")
+	}
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, types.NewInvalidRequestError("tenant id is required")
+	}
+	purged, err := s.cache.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return nil, types.NewInternalError("failed to purge tenant cache").WithCause(err)
+	}
+	return &CachePurgeResult{TenantID: tenantID, PurgedKeys: purged}, nil
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTenantCachePurger struct {
+	tenantID string
+	purged   int
+	err      error
+}
+
+func (s *stubTenantCachePurger) PurgeTenant(_ context.Context, tenantID string) (int, error) {
+	s.tenantID = tenantID
+	return s.purged, s.err
+}
+
+func TestCacheAdminService_PurgeTenant(t *testing.T) {
+	purger := &stubTenantCachePurger{purged: 3}
+	svc := NewDefaultCacheAdminService(purger)
+
+	result, err := svc.PurgeTenant(context.Background(), " tenant-a ")
+	require.Nil(t, err)
+	assert.Equal(t, "tenant-a", purger.tenantID)
+	assert.Equal(t, &CachePurgeResult{TenantID: "tenant-a", PurgedKeys: 3}, result)
+}
+
+func TestCacheAdminService_PurgeTenantErrors(t *testing.T) {
+	_, err := NewDefaultCacheAdminService(&stubTenantCachePurger{}).PurgeTenant(context.Background(), " ")
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = NewDefaultCacheAdminService(&stubTenantCachePurger{err: errors.New("redis down")}).PurgeTenant(context.Background(), "t")
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInternalError, err.Code)
+
+	_, err = NewDefaultCacheAdminService(nil).PurgeTenant(context.Background(), "t")
+	require.NotNil(t, err)
+}
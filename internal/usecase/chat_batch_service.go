@@ -0,0 +1,427 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/persistence"
+	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+const (
+	chatBatchTaskType = "chat_batch"
+
+	chatBatchMetaTenantID = "tenant_id"
+	chatBatchMetaUserID   = "user_id"
+
+	chatBatchInputRequests = "requests"
+
+	defaultChatBatchMaxConcurrency = 4
+	defaultChatBatchMaxRequests    = 100
+)
+
+// Chat batch item statuses.
+const (
+	ChatBatchItemCompleted = "completed"
+	ChatBatchItemFailed    = "failed"
+)
+
+// ChatBatchItem is the outcome of one request in a batch.
+type ChatBatchItem struct {
+	Index      int           `json:"index"`
+	Status     string        `json:"status"`
+	Response   *ChatResponse `json:"response,omitempty"`
+	Error      *types.Error  `json:"error,omitempty"`
+	DurationMs int64         `json:"duration_ms"`
+}
+
+// ChatBatchJob is the pollable state of a batch chat job.
+type ChatBatchJob struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	Status      persistence.TaskStatus `json:"status"`
+	Total       int                    `json:"total"`
+	Completed   int                    `json:"completed"`
+	Failed      int                    `json:"failed"`
+	Progress    float64                `json:"progress"`
+	Error       string                 `json:"error,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Results     []ChatBatchItem        `json:"results,omitempty"`
+}
+
+// ChatBatchService runs arrays of chat requests as asynchronous jobs.
+type ChatBatchService interface {
+	// Submit persists a new job and starts it in the background.
+	Submit(ctx context.Context, reqs []*ChatRequest) (*ChatBatchJob, *types.Error)
+	// Get returns the job state, including results finished so far.
+	Get(ctx context.Context, jobID string) (*ChatBatchJob, *types.Error)
+}
+
+// ChatBatchOptions configures DefaultChatBatchService.
+type ChatBatchOptions struct {
+	// MaxConcurrency bounds gateway calls in flight across all jobs (default 4).
+	MaxConcurrency int
+	// MaxRequests bounds the number of requests in one job (default 100).
+	MaxRequests int
+}
+
+// DefaultChatBatchService runs batch jobs through the chat service and keeps
+// their state in a persistence.TaskStore. Jobs left pending or running by a
+// previous process are resumed by Recover; requests that already have a
+// result are not sent again.
+type DefaultChatBatchService struct {
+	store  persistence.TaskStore
+	chat   func() ChatService
+	sem    chan struct{}
+	max    int
+	logger *zap.Logger
+
+	// mu serializes store access so job reads never race with result writes.
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDefaultChatBatchService creates a batch service. chat is resolved for
+// every request so hot-reloaded chat services are picked up by running jobs.
+func NewDefaultChatBatchService(store persistence.TaskStore, chat func() ChatService, opts ChatBatchOptions, logger *zap.Logger) *DefaultChatBatchService {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = defaultChatBatchMaxConcurrency
+	}
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = defaultChatBatchMaxRequests
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DefaultChatBatchService{
+		store:  store,
+		chat:   chat,
+		sem:    make(chan struct{}, opts.MaxConcurrency),
+		max:    opts.MaxRequests,
+		logger: logger.With(zap.String("component", "chat_batch")),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Submit persists a new job and starts it in the background.
+func (s *DefaultChatBatchService) Submit(ctx context.Context, reqs []*ChatRequest) (*ChatBatchJob, *types.Error) {
+	if s.store == nil {
+		return nil, types.NewServiceUnavailableError("chat batch store is not configured")
+	}
+	if len(reqs) == 0 {
+		return nil, types.NewInvalidRequestError("requests cannot be empty")
+	}
+	if len(reqs) > s.max {
+		return nil, types.NewInvalidRequestError(fmt.Sprintf("requests cannot exceed %d items", s.max))
+	}
+	for i, req := range reqs {
+		if req == nil {
+			return nil, types.NewInvalidRequestError(fmt.Sprintf("requests[%d] is required", i))
+		}
+	}
+
+	record := &persistence.AsyncTask{
+		Type:     chatBatchTaskType,
+		Status:   persistence.TaskStatusPending,
+		Input:    map[string]any{chatBatchInputRequests: reqs},
+		Metadata: map[string]string{},
+	}
+	if tenantID, ok := types.TenantID(ctx); ok {
+		record.Metadata[chatBatchMetaTenantID] = tenantID
+	}
+	if userID, ok := types.UserID(ctx); ok {
+		record.Metadata[chatBatchMetaUserID] = userID
+	}
+
+	s.mu.Lock()
+	err := s.store.SaveTask(ctx, record)
+	var job *ChatBatchJob
+	if err == nil {
+		job = chatBatchJobFromRecord(record, nil)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, types.NewInternalError("chat batch store error").WithCause(err)
+	}
+
+	s.start(record.ID, record.Metadata, reqs, nil)
+	s.logger.Info("chat batch submitted", zap.String("job_id", job.ID), zap.Int("requests", len(reqs)))
+	return job, nil
+}
+
+// Get returns the job state. Jobs of another tenant are reported as not found.
+func (s *DefaultChatBatchService) Get(ctx context.Context, jobID string) (*ChatBatchJob, *types.Error) {
+	if s.store == nil {
+		return nil, types.NewServiceUnavailableError("chat batch store is not configured")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.store.GetTask(ctx, jobID)
+	if err != nil || record == nil || record.Type != chatBatchTaskType {
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return nil, types.NewInternalError("chat batch store error").WithCause(err)
+		}
+		return nil, chatBatchNotFound(jobID)
+	}
+	if tenantID, ok := types.TenantID(ctx); ok && record.Metadata[chatBatchMetaTenantID] != tenantID {
+		return nil, chatBatchNotFound(jobID)
+	}
+	results, decodeErr := decodeChatBatchResults(record.Result)
+	if decodeErr != nil {
+		return nil, types.NewInternalError("decode chat batch results").WithCause(decodeErr)
+	}
+	return chatBatchJobFromRecord(record, results), nil
+}
+
+// Recover resumes jobs left pending or running by a previous process and
+// returns how many were restarted.
+func (s *DefaultChatBatchService) Recover(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	type pendingJob struct {
+		id       string
+		metadata map[string]string
+		reqs     []*ChatRequest
+		results  []ChatBatchItem
+	}
+	var jobs []pendingJob
+
+	s.mu.Lock()
+	records, err := s.store.GetRecoverableTasks(ctx)
+	if err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	for _, record := range records {
+		if record.Type != chatBatchTaskType {
+			continue
+		}
+		reqs, decodeErr := decodeChatBatchRequests(record.Input[chatBatchInputRequests])
+		if decodeErr == nil {
+			var results []ChatBatchItem
+			if results, decodeErr = decodeChatBatchResults(record.Result); decodeErr == nil {
+				jobs = append(jobs, pendingJob{id: record.ID, metadata: record.Metadata, reqs: reqs, results: results})
+				continue
+			}
+		}
+		s.logger.Warn("chat batch job cannot be recovered", zap.String("job_id", record.ID), zap.Error(decodeErr))
+		_ = s.store.UpdateStatus(ctx, record.ID, persistence.TaskStatusFailed, nil, "recover: "+decodeErr.Error())
+	}
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		s.start(job.id, job.metadata, job.reqs, job.results)
+	}
+	if len(jobs) > 0 {
+		s.logger.Info("chat batch jobs recovered", zap.Int("jobs", len(jobs)))
+	}
+	return len(jobs), nil
+}
+
+// Close stops running jobs and waits for them to exit. Interrupted jobs keep
+// their running status and are resumed by Recover on the next start.
+func (s *DefaultChatBatchService) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *DefaultChatBatchService) start(jobID string, metadata map[string]string, reqs []*ChatRequest, done []ChatBatchItem) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(jobID, metadata, reqs, done)
+	}()
+}
+
+func (s *DefaultChatBatchService) run(jobID string, metadata map[string]string, reqs []*ChatRequest, done []ChatBatchItem) {
+	// 批处理请求按最低优先级进入 gateway 调度，不挤占交互式对话。
+	ctx := types.WithRequestPriority(s.ctx, string(llmgateway.PriorityBatch))
+	// 恢复提交者身份，使租户配额、限流与审计在后台执行时仍按原租户/用户计。
+	if tenantID := metadata[chatBatchMetaTenantID]; tenantID != "" {
+		ctx = types.WithTenantID(ctx, tenantID)
+	}
+	if userID := metadata[chatBatchMetaUserID]; userID != "" {
+		ctx = types.WithUserID(ctx, userID)
+	}
+	results := make([]*ChatBatchItem, len(reqs))
+	finished := 0
+	for i := range done {
+		if item := done[i]; item.Index >= 0 && item.Index < len(reqs) {
+			results[item.Index] = &item
+			finished++
+		}
+	}
+	if err := s.update(jobID, persistence.TaskStatusRunning, results, finished); err != nil {
+		s.logger.Error("chat batch start failed", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	var (
+		resultsMu sync.Mutex
+		wg        sync.WaitGroup
+	)
+	for i, req := range reqs {
+		if results[i] != nil {
+			continue
+		}
+		acquired := false
+		select {
+		case s.sem <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+		if !acquired {
+			break
+		}
+		wg.Add(1)
+		go func(index int, req *ChatRequest) {
+			defer wg.Done()
+			defer func() { <-s.sem }()
+			item := s.complete(ctx, index, req)
+			if ctx.Err() != nil {
+				// Shutting down: leave the item unfinished so Recover retries it.
+				return
+			}
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			results[index] = item
+			finished++
+			if err := s.update(jobID, persistence.TaskStatusRunning, results, finished); err != nil {
+				s.logger.Warn("chat batch progress update failed", zap.String("job_id", jobID), zap.Error(err))
+			}
+		}(i, req)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	status := persistence.TaskStatusCompleted
+	if err := s.update(jobID, status, results, finished); err != nil {
+		s.logger.Error("chat batch completion update failed", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+	s.logger.Info("chat batch completed", zap.String("job_id", jobID), zap.Int("requests", len(reqs)))
+}
+
+func (s *DefaultChatBatchService) complete(ctx context.Context, index int, req *ChatRequest) *ChatBatchItem {
+	started := time.Now()
+	item := &ChatBatchItem{Index: index}
+	var chat ChatService
+	if s.chat != nil {
+		chat = s.chat()
+	}
+	if chat == nil {
+		item.Status = ChatBatchItemFailed
+		item.Error = types.NewServiceUnavailableError("chat service is not configured")
+		return item
+	}
+	result, err := chat.Complete(ctx, req)
+	item.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		item.Status = ChatBatchItemFailed
+		item.Error = err
+		return item
+	}
+	item.Status = ChatBatchItemCompleted
+	if result != nil {
+		item.Response = result.Response
+	}
+	return item
+}
+
+// update persists a snapshot of finished results together with status and progress.
+func (s *DefaultChatBatchService) update(jobID string, status persistence.TaskStatus, results []*ChatBatchItem, finished int) error {
+	snapshot := make([]ChatBatchItem, 0, finished)
+	for _, item := range results {
+		if item != nil {
+			snapshot = append(snapshot, *item)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := context.Background()
+	if err := s.store.UpdateStatus(ctx, jobID, status, snapshot, ""); err != nil {
+		return err
+	}
+	return s.store.UpdateProgress(ctx, jobID, float64(finished)*100/float64(len(results)))
+}
+
+func chatBatchJobFromRecord(record *persistence.AsyncTask, results []ChatBatchItem) *ChatBatchJob {
+	job := &ChatBatchJob{
+		ID:          record.ID,
+		Type:        record.Type,
+		Status:      record.Status,
+		Progress:    record.Progress,
+		Error:       record.Error,
+		CreatedAt:   record.CreatedAt,
+		StartedAt:   record.StartedAt,
+		CompletedAt: record.CompletedAt,
+		Results:     results,
+	}
+	if reqs, err := decodeChatBatchRequests(record.Input[chatBatchInputRequests]); err == nil {
+		job.Total = len(reqs)
+	}
+	for _, item := range results {
+		if item.Status == ChatBatchItemFailed {
+			job.Failed++
+		} else {
+			job.Completed++
+		}
+	}
+	return job
+}
+
+// decodeChatBatchRequests accepts the in-memory form and the JSON-decoded form
+// produced by stores that serialize task input.
+func decodeChatBatchRequests(raw any) ([]*ChatRequest, error) {
+	if reqs, ok := raw.([]*ChatRequest); ok {
+		return reqs, nil
+	}
+	var reqs []*ChatRequest
+	if err := remarshalChatBatch(raw, &reqs); err != nil {
+		return nil, fmt.Errorf("decode chat batch requests: %w", err)
+	}
+	return reqs, nil
+}
+
+func decodeChatBatchResults(raw any) ([]ChatBatchItem, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if items, ok := raw.([]ChatBatchItem); ok {
+		return items, nil
+	}
+	var items []ChatBatchItem
+	if err := remarshalChatBatch(raw, &items); err != nil {
+		return nil, fmt.Errorf("decode chat batch results: %w", err)
+	}
+	return items, nil
+}
+
+func remarshalChatBatch(raw any, dst any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func chatBatchNotFound(jobID string) *types.Error {
+	return types.NewError(types.ErrTaskNotFound, fmt.Sprintf("job %s not found", jobID)).WithHTTPStatus(http.StatusNotFound)
+}
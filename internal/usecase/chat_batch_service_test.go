@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchTestChatService struct {
+	complete func(ctx context.Context, req *ChatRequest) (*ChatCompletionResult, *types.Error)
+}
+
+func (s *batchTestChatService) Complete(ctx context.Context, req *ChatRequest) (*ChatCompletionResult, *types.Error) {
+	return s.complete(ctx, req)
+}
+
+func (s *batchTestChatService) Stream(context.Context, *ChatRequest) (<-chan ChatStreamEvent, *types.Error) {
+	return nil, types.NewInternalError("not implemented")
+}
+
+func (s *batchTestChatService) SupportedRoutePolicies() []string { return nil }
+func (s *batchTestChatService) DefaultRoutePolicy() string       { return "" }
+
+func echoChatResult(req *ChatRequest) *ChatCompletionResult {
+	return &ChatCompletionResult{Response: &ChatResponse{
+		Model:   req.Model,
+		Choices: []ChatChoice{{Message: Message{Role: "assistant", Content: req.Messages[0].Content}}},
+	}}
+}
+
+func newTestChatBatchService(t *testing.T, store persistence.TaskStore, chat ChatService, opts ChatBatchOptions) *DefaultChatBatchService {
+	t.Helper()
+	svc := NewDefaultChatBatchService(store, func() ChatService { return chat }, opts, nil)
+	t.Cleanup(svc.Close)
+	return svc
+}
+
+func waitChatBatchJob(t *testing.T, svc *DefaultChatBatchService, ctx context.Context, id string) *ChatBatchJob {
+	t.Helper()
+	var job *ChatBatchJob
+	require.Eventually(t, func() bool {
+		var err *types.Error
+		job, err = svc.Get(ctx, id)
+		require.Nil(t, err)
+		return job.Status.IsTerminal()
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func batchRequests(contents ...string) []*ChatRequest {
+	reqs := make([]*ChatRequest, 0, len(contents))
+	for _, content := range contents {
+		reqs = append(reqs, &ChatRequest{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: content}}})
+	}
+	return reqs
+}
+
+func TestDefaultChatBatchService_RunsJobWithBoundedConcurrency(t *testing.T) {
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	t.Cleanup(func() { _ = store.Close() })
+
+	var inFlight, peak atomic.Int32
+	chat := &batchTestChatService{complete: func(ctx context.Context, req *ChatRequest) (*ChatCompletionResult, *types.Error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if priority, _ := types.RequestPriority(ctx); priority != "batch" {
+			return nil, types.NewInternalError("batch requests must be scheduled with the batch priority")
+		}
+		if tenantID, _ := types.TenantID(ctx); tenantID != "tenant-a" {
+			return nil, types.NewInternalError("batch requests must run as the submitting tenant")
+		}
+		if userID, _ := types.UserID(ctx); userID != "user-1" {
+			return nil, types.NewInternalError("batch requests must run as the submitting user")
+		}
+		if req.Messages[0].Content == "bad" {
+			return nil, types.NewInvalidRequestError("rejected by provider")
+		}
+		return echoChatResult(req), nil
+	}}
+	svc := newTestChatBatchService(t, store, chat, ChatBatchOptions{MaxConcurrency: 2})
+
+	ctx := types.WithUserID(types.WithTenantID(context.Background(), "tenant-a"), "user-1")
+	job, err := svc.Submit(ctx, batchRequests("a", "b", "bad", "c", "d"))
+	require.Nil(t, err)
+	assert.Equal(t, 5, job.Total)
+	assert.NotEmpty(t, job.ID)
+
+	done := waitChatBatchJob(t, svc, ctx, job.ID)
+	assert.Equal(t, persistence.TaskStatusCompleted, done.Status)
+	assert.Equal(t, 4, done.Completed)
+	assert.Equal(t, 1, done.Failed)
+	assert.InDelta(t, 100, done.Progress, 1e-9)
+	require.Len(t, done.Results, 5)
+	assert.Equal(t, ChatBatchItemFailed, done.Results[2].Status)
+	assert.Equal(t, types.ErrInvalidRequest, done.Results[2].Error.Code)
+	assert.Equal(t, "d", done.Results[4].Response.Choices[0].Message.Content)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+
+	_, err = svc.Get(types.WithTenantID(context.Background(), "tenant-b"), job.ID)
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrTaskNotFound, err.Code, "jobs are scoped to their tenant")
+}
+
+func TestDefaultChatBatchService_ValidatesSubmissions(t *testing.T) {
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	t.Cleanup(func() { _ = store.Close() })
+	svc := newTestChatBatchService(t, store, nil, ChatBatchOptions{MaxRequests: 2})
+
+	_, err := svc.Submit(context.Background(), nil)
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = svc.Submit(context.Background(), batchRequests("a", "b", "c"))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Message, "cannot exceed 2")
+
+	_, err = svc.Get(context.Background(), "missing")
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrTaskNotFound, err.Code)
+
+	require.NoError(t, store.SaveTask(context.Background(), &persistence.AsyncTask{ID: "other", Type: "image.resize"}))
+	_, err = svc.Get(context.Background(), "other")
+	require.NotNil(t, err, "tasks of other kinds are not jobs")
+}
+
+func TestDefaultChatBatchService_RecoverResumesUnfinishedRequests(t *testing.T) {
+	ctx := context.Background()
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	t.Cleanup(func() { _ = store.Close() })
+
+	// Simulate a job persisted as JSON by a previous process that finished one of three requests.
+	var reqsJSON, resultsJSON any
+	require.NoError(t, remarshalChatBatch(batchRequests("a", "b", "c"), &reqsJSON))
+	require.NoError(t, remarshalChatBatch([]ChatBatchItem{{Index: 1, Status: ChatBatchItemCompleted, Response: &ChatResponse{Model: "cached"}}}, &resultsJSON))
+	require.NoError(t, store.SaveTask(ctx, &persistence.AsyncTask{
+		ID:       "job-1",
+		Type:     chatBatchTaskType,
+		Status:   persistence.TaskStatusRunning,
+		Input:    map[string]any{chatBatchInputRequests: reqsJSON},
+		Result:   resultsJSON,
+		Metadata: map[string]string{chatBatchMetaTenantID: "tenant-a"},
+	}))
+
+	var mu sync.Mutex
+	var sent []string
+	chat := &batchTestChatService{complete: func(ctx context.Context, req *ChatRequest) (*ChatCompletionResult, *types.Error) {
+		if tenantID, _ := types.TenantID(ctx); tenantID != "tenant-a" {
+			return nil, types.NewInternalError("recovered requests must run as the submitting tenant")
+		}
+		mu.Lock()
+		sent = append(sent, req.Messages[0].Content)
+		mu.Unlock()
+		return echoChatResult(req), nil
+	}}
+	svc := newTestChatBatchService(t, store, chat, ChatBatchOptions{})
+
+	recovered, err := svc.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	done := waitChatBatchJob(t, svc, ctx, "job-1")
+	assert.Equal(t, 3, done.Completed)
+	assert.Equal(t, "cached", done.Results[1].Response.Model)
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"a", "c"}, sent, "finished requests are not sent again")
+	mu.Unlock()
+}
+
+func TestDefaultChatBatchService_CloseLeavesJobRecoverable(t *testing.T) {
+	ctx := context.Background()
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	t.Cleanup(func() { _ = store.Close() })
+
+	started := make(chan struct{}, 1)
+	chat := &batchTestChatService{complete: func(ctx context.Context, req *ChatRequest) (*ChatCompletionResult, *types.Error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, types.NewInternalError("cancelled")
+	}}
+	svc := NewDefaultChatBatchService(store, func() ChatService { return chat }, ChatBatchOptions{MaxConcurrency: 1}, nil)
+	job, err := svc.Submit(ctx, batchRequests("a", "b"))
+	require.Nil(t, err)
+	<-started
+	svc.Close()
+
+	record, getErr := store.GetTask(ctx, job.ID)
+	require.NoError(t, getErr)
+	assert.True(t, record.IsRecoverable())
+	results, decodeErr := decodeChatBatchResults(record.Result)
+	require.NoError(t, decodeErr)
+	assert.Empty(t, results, "interrupted requests are not recorded as failures")
+}
@@ -108,6 +108,7 @@ type ChatChoice struct {
 	Index        int
 	FinishReason string
 	Message      Message
+	LogProbs     *types.ChoiceLogProbs
 }
 
 type ChatUsage struct {
@@ -171,3 +172,24 @@ type ToolFormat struct {
 	Syntax     string
 	Definition string
 }
+
+type ChatModelInfo struct {
+	ID      string
+	OwnedBy string
+	Created int64
+}
+
+type ChatEmbeddingRequest struct {
+	Model      string
+	Input      []string
+	Dimensions int
+	Provider   string
+}
+
+type ChatEmbeddingResult struct {
+	Model        string
+	Provider     string
+	Embeddings   [][]float64
+	PromptTokens int
+	TotalTokens  int
+}
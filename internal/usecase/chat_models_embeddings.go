@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/BaSui01/agentflow/llm/capabilities/embedding"
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ChatModelLister is an optional ChatService capability that lists the models
+// served by the chat runtime.
+type ChatModelLister interface {
+	ListModels(ctx context.Context) ([]ChatModelInfo, *types.Error)
+}
+
+// ChatEmbedder is an optional ChatService capability that creates embeddings
+// through the same gateway (policy, budget, ledger) as chat completions.
+type ChatEmbedder interface {
+	Embed(ctx context.Context, req *ChatEmbeddingRequest) (*ChatEmbeddingResult, *types.Error)
+}
+
+var (
+	_ ChatModelLister = (*DefaultChatService)(nil)
+	_ ChatEmbedder    = (*DefaultChatService)(nil)
+)
+
+// ListModels returns the models reported by the chat provider, sorted by ID.
+func (s *DefaultChatService) ListModels(ctx context.Context) ([]ChatModelInfo, *types.Error) {
+	runtime := s.runtime()
+	if runtime.ChatProvider == nil {
+		return nil, types.NewServiceUnavailableError("chat runtime is not configured")
+	}
+	models, err := runtime.ChatProvider.ListModels(ctx)
+	if err != nil {
+		return nil, toTypesChatError(err)
+	}
+
+	seen := make(map[string]struct{}, len(models))
+	out := make([]ChatModelInfo, 0, len(models))
+	for _, model := range models {
+		id := strings.TrimSpace(model.ID)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, ChatModelInfo{
+			ID:      id,
+			OwnedBy: firstNonEmptyNonBlank(model.OwnedBy, runtime.ChatProvider.Name()),
+			Created: model.Created,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// Embed creates embeddings via the gateway embedding capability.
+func (s *DefaultChatService) Embed(ctx context.Context, req *ChatEmbeddingRequest) (*ChatEmbeddingResult, *types.Error) {
+	if req == nil || len(req.Input) == 0 {
+		return nil, types.NewInvalidRequestError("input is required")
+	}
+	for _, input := range req.Input {
+		if strings.TrimSpace(input) == "" {
+			return nil, types.NewInvalidRequestError("input must not contain empty strings")
+		}
+	}
+	provider, err := NormalizeProviderHint(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+	runtime := s.runtime()
+	if runtime.Gateway == nil {
+		return nil, types.NewServiceUnavailableError("chat runtime is not configured")
+	}
+
+	resp, invokeErr := runtime.Gateway.Invoke(ctx, &llmcore.UnifiedRequest{
+		Capability:   llmcore.CapabilityEmbedding,
+		ProviderHint: provider,
+		ModelHint:    req.Model,
+		Payload: &llmgateway.EmbeddingInput{
+			Provider: provider,
+			Request: &embedding.EmbeddingRequest{
+				Input:      req.Input,
+				Model:      req.Model,
+				Dimensions: req.Dimensions,
+			},
+		},
+	})
+	if invokeErr != nil {
+		return nil, toTypesChatError(invokeErr)
+	}
+	raw, ok := resp.Output.(*embedding.EmbeddingResponse)
+	if !ok || raw == nil {
+		return nil, types.NewInternalError("invalid embedding gateway response")
+	}
+
+	vectors := make([][]float64, len(req.Input))
+	for _, item := range raw.Embeddings {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, types.NewInternalError("embedding index out of range")
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return &ChatEmbeddingResult{
+		Model:        firstNonEmptyNonBlank(raw.Model, req.Model),
+		Provider:     resp.ProviderDecision.Provider,
+		Embeddings:   vectors,
+		PromptTokens: resp.Usage.PromptTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	}, nil
+}
@@ -359,6 +359,7 @@ func convertLLMStreamChunkToUsecase(chunk *llmcore.StreamChunk) *ChatStreamChunk
 		Index:        chunk.Index,
 		Delta:        messageFromTypes(chunk.Delta),
 		FinishReason: chunk.FinishReason,
+		LogProbs:     chunk.LogProbs,
 		Usage:        chatUsageFromLLM(chunk.Usage),
 	}
 }
@@ -16,5 +16,6 @@ type ChatStreamChunk struct {
 	Index        int
 	Delta        Message
 	FinishReason string
+	LogProbs     *types.ChoiceLogProbs
 	Usage        *ChatUsage
 }
@@ -0,0 +1,399 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/types"
+	workflow "github.com/BaSui01/agentflow/workflow/core"
+)
+
+const (
+	externalTaskKind = "workflow_external_activity"
+
+	externalTaskMetaKind             = "kind"
+	externalTaskMetaStepID           = "step_id"
+	externalTaskMetaHeartbeatTimeout = "heartbeat_timeout"
+
+	defaultExternalClaimPollInterval = 250 * time.Millisecond
+	maxExternalClaimWait             = 30 * time.Second
+)
+
+var (
+	// ErrExternalTaskNotFound is returned when the task does not exist or is not an external activity.
+	ErrExternalTaskNotFound = errors.New("external task not found")
+	// ErrExternalTaskNotRunning is returned when a worker reports on a task that is no longer running,
+	// e.g. because the workflow timed it out; the worker should stop processing it.
+	ErrExternalTaskNotRunning = errors.New("external task is not running")
+	// ErrExternalTaskLeaseMismatch is returned when a worker reports on a task claimed by another worker.
+	ErrExternalTaskLeaseMismatch = errors.New("external task is claimed by another worker")
+)
+
+// TaskStoreExternalTaskQueue implements workflow.ExternalTaskQueue on top of
+// persistence.TaskStore and exposes the worker-side claim, heartbeat, complete
+// and fail operations. Claims are serialized within the process; the worker
+// that holds each claim is tracked in memory.
+type TaskStoreExternalTaskQueue struct {
+	store persistence.TaskStore
+
+	mu     sync.Mutex
+	leases map[string]string
+}
+
+// NewTaskStoreExternalTaskQueue creates an external task queue backed by store.
+func NewTaskStoreExternalTaskQueue(store persistence.TaskStore) *TaskStoreExternalTaskQueue {
+	return &TaskStoreExternalTaskQueue{store: store, leases: make(map[string]string)}
+}
+
+// Publish stores a pending task for workers to claim.
+func (q *TaskStoreExternalTaskQueue) Publish(ctx context.Context, task *workflow.ExternalTask) (string, error) {
+	if task == nil || strings.TrimSpace(task.TaskType) == "" {
+		return "", fmt.Errorf("external task type is required")
+	}
+	record := &persistence.AsyncTask{
+		Type:       task.TaskType,
+		Status:     persistence.TaskStatusPending,
+		Input:      task.Input,
+		Timeout:    task.StartToCloseTimeout,
+		RetryCount: task.Attempt,
+		MaxRetries: task.MaxAttempts - 1,
+		Metadata: map[string]string{
+			externalTaskMetaKind:   externalTaskKind,
+			externalTaskMetaStepID: task.StepID,
+		},
+	}
+	if task.HeartbeatTimeout > 0 {
+		record.Metadata[externalTaskMetaHeartbeatTimeout] = task.HeartbeatTimeout.String()
+	}
+	if err := q.store.SaveTask(ctx, record); err != nil {
+		return "", err
+	}
+	return record.ID, nil
+}
+
+// Get returns the current state of an external task.
+func (q *TaskStoreExternalTaskQueue) Get(ctx context.Context, taskID string) (*workflow.ExternalTask, error) {
+	record, err := q.getRecord(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.toExternalTask(record), nil
+}
+
+// Abort moves a task that has not finished to cancelled or timeout.
+func (q *TaskStoreExternalTaskQueue) Abort(ctx context.Context, taskID string, status workflow.ExternalTaskStatus, reason string) error {
+	if status != workflow.ExternalTaskCancelled && status != workflow.ExternalTaskTimeout {
+		return fmt.Errorf("external task can only be aborted as cancelled or timeout, got %q", status)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record, err := q.getRecord(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if record.IsTerminal() {
+		return nil
+	}
+	delete(q.leases, taskID)
+	return q.store.UpdateStatus(ctx, taskID, persistence.TaskStatus(status), nil, reason)
+}
+
+// Claim hands the oldest pending task of the given types to workerID.
+// An empty taskTypes matches any type. It returns nil when nothing is pending.
+func (q *TaskStoreExternalTaskQueue) Claim(ctx context.Context, workerID string, taskTypes []string) (*workflow.ExternalTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending, err := q.store.ListTasks(ctx, persistence.TaskFilter{
+		Status:  []persistence.TaskStatus{persistence.TaskStatusPending},
+		OrderBy: "created_at",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range pending {
+		if !isExternalTask(record) || !matchesTaskType(record.Type, taskTypes) {
+			continue
+		}
+		if err := q.store.UpdateStatus(ctx, record.ID, persistence.TaskStatusRunning, nil, ""); err != nil {
+			return nil, err
+		}
+		q.leases[record.ID] = workerID
+		claimed, err := q.getRecord(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		return q.toExternalTask(claimed), nil
+	}
+	return nil, nil
+}
+
+// Heartbeat records worker liveness and optional progress (0-100).
+func (q *TaskStoreExternalTaskQueue) Heartbeat(ctx context.Context, taskID, workerID string, progress float64) (*workflow.ExternalTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record, err := q.runningRecord(ctx, taskID, workerID)
+	if err != nil {
+		return nil, err
+	}
+	if progress <= 0 {
+		progress = record.Progress
+	}
+	if err := q.store.UpdateProgress(ctx, taskID, progress); err != nil {
+		return nil, err
+	}
+	updated, err := q.getRecord(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return q.toExternalTask(updated), nil
+}
+
+// Complete records the task result reported by the worker.
+func (q *TaskStoreExternalTaskQueue) Complete(ctx context.Context, taskID, workerID string, result any) error {
+	return q.finish(ctx, taskID, workerID, persistence.TaskStatusCompleted, result, "")
+}
+
+// Fail records a task failure reported by the worker.
+func (q *TaskStoreExternalTaskQueue) Fail(ctx context.Context, taskID, workerID, message string) error {
+	if strings.TrimSpace(message) == "" {
+		message = "worker reported failure"
+	}
+	return q.finish(ctx, taskID, workerID, persistence.TaskStatusFailed, nil, message)
+}
+
+func (q *TaskStoreExternalTaskQueue) finish(ctx context.Context, taskID, workerID string, status persistence.TaskStatus, result any, message string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.runningRecord(ctx, taskID, workerID); err != nil {
+		return err
+	}
+	delete(q.leases, taskID)
+	return q.store.UpdateStatus(ctx, taskID, status, result, message)
+}
+
+// runningRecord loads a running task and checks that workerID holds its claim.
+// Must be called with q.mu held.
+func (q *TaskStoreExternalTaskQueue) runningRecord(ctx context.Context, taskID, workerID string) (*persistence.AsyncTask, error) {
+	record, err := q.getRecord(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != persistence.TaskStatusRunning {
+		return nil, fmt.Errorf("%w: status is %s", ErrExternalTaskNotRunning, record.Status)
+	}
+	if owner, ok := q.leases[taskID]; ok && owner != workerID {
+		return nil, ErrExternalTaskLeaseMismatch
+	}
+	return record, nil
+}
+
+func (q *TaskStoreExternalTaskQueue) getRecord(ctx context.Context, taskID string) (*persistence.AsyncTask, error) {
+	record, err := q.store.GetTask(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, ErrExternalTaskNotFound
+		}
+		return nil, err
+	}
+	if !isExternalTask(record) {
+		return nil, ErrExternalTaskNotFound
+	}
+	return record, nil
+}
+
+// toExternalTask converts a stored record. Must be called with q.mu held.
+func (q *TaskStoreExternalTaskQueue) toExternalTask(record *persistence.AsyncTask) *workflow.ExternalTask {
+	task := &workflow.ExternalTask{
+		ID:                  record.ID,
+		TaskType:            record.Type,
+		StepID:              record.Metadata[externalTaskMetaStepID],
+		Input:               record.Input,
+		Status:              workflow.ExternalTaskStatus(record.Status),
+		Result:              record.Result,
+		Error:               record.Error,
+		WorkerID:            q.leases[record.ID],
+		Attempt:             record.RetryCount,
+		MaxAttempts:         record.MaxRetries + 1,
+		StartToCloseTimeout: record.Timeout,
+		CreatedAt:           record.CreatedAt,
+	}
+	if raw := record.Metadata[externalTaskMetaHeartbeatTimeout]; raw != "" {
+		task.HeartbeatTimeout, _ = time.ParseDuration(raw)
+	}
+	if record.StartedAt != nil {
+		startedAt := *record.StartedAt
+		task.StartedAt = &startedAt
+	}
+	// 运行期间的每次心跳都会刷新 UpdatedAt
+	if record.Status == persistence.TaskStatusRunning {
+		heartbeatAt := record.UpdatedAt
+		task.LastHeartbeatAt = &heartbeatAt
+	}
+	return task
+}
+
+func isExternalTask(record *persistence.AsyncTask) bool {
+	return record != nil && record.Metadata[externalTaskMetaKind] == externalTaskKind
+}
+
+func matchesTaskType(taskType string, taskTypes []string) bool {
+	if len(taskTypes) == 0 {
+		return true
+	}
+	for _, t := range taskTypes {
+		if t == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimExternalTaskRequest asks for the next pending task.
+type ClaimExternalTaskRequest struct {
+	WorkerID  string   `json:"worker_id" binding:"required"`
+	TaskTypes []string `json:"task_types,omitempty"`
+	// WaitMs long-polls for up to this many milliseconds (max 30000) when nothing is pending.
+	WaitMs int `json:"wait_ms,omitempty"`
+}
+
+// ExternalTaskHeartbeatRequest reports worker liveness.
+type ExternalTaskHeartbeatRequest struct {
+	WorkerID string  `json:"worker_id" binding:"required"`
+	Progress float64 `json:"progress,omitempty"`
+}
+
+// CompleteExternalTaskRequest reports a successful result.
+type CompleteExternalTaskRequest struct {
+	WorkerID string `json:"worker_id" binding:"required"`
+	Result   any    `json:"result"`
+}
+
+// FailExternalTaskRequest reports a failed attempt; the workflow step decides whether to retry.
+type FailExternalTaskRequest struct {
+	WorkerID string `json:"worker_id" binding:"required"`
+	Error    string `json:"error"`
+}
+
+// ExternalTaskService exposes workflow external activity tasks to out-of-process workers.
+type ExternalTaskService interface {
+	// Claim returns the next pending task, or nil when none is available.
+	Claim(ctx context.Context, req ClaimExternalTaskRequest) (*workflow.ExternalTask, *types.Error)
+	// Get returns the task state.
+	Get(ctx context.Context, taskID string) (*workflow.ExternalTask, *types.Error)
+	// Heartbeat records worker liveness.
+	Heartbeat(ctx context.Context, taskID string, req ExternalTaskHeartbeatRequest) (*workflow.ExternalTask, *types.Error)
+	// Complete records the task result.
+	Complete(ctx context.Context, taskID string, req CompleteExternalTaskRequest) *types.Error
+	// Fail records a task failure.
+	Fail(ctx context.Context, taskID string, req FailExternalTaskRequest) *types.Error
+}
+
+// DefaultExternalTaskService is the default implementation of ExternalTaskService.
+type DefaultExternalTaskService struct {
+	queue        *TaskStoreExternalTaskQueue
+	pollInterval time.Duration
+}
+
+// NewDefaultExternalTaskService creates a new ExternalTaskService.
+func NewDefaultExternalTaskService(queue *TaskStoreExternalTaskQueue) *DefaultExternalTaskService {
+	return &DefaultExternalTaskService{queue: queue, pollInterval: defaultExternalClaimPollInterval}
+}
+
+// Claim returns the next pending task, long-polling up to req.WaitMs.
+func (s *DefaultExternalTaskService) Claim(ctx context.Context, req ClaimExternalTaskRequest) (*workflow.ExternalTask, *types.Error) {
+	if s.queue == nil {
+		return nil, types.NewServiceUnavailableError("external task queue is not configured")
+	}
+	wait := time.Duration(req.WaitMs) * time.Millisecond
+	if wait > maxExternalClaimWait {
+		wait = maxExternalClaimWait
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		task, err := s.queue.Claim(ctx, req.WorkerID, req.TaskTypes)
+		if err != nil {
+			return nil, externalTaskError(err)
+		}
+		if task != nil || !time.Now().Before(deadline) {
+			return task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// Get returns the task state.
+func (s *DefaultExternalTaskService) Get(ctx context.Context, taskID string) (*workflow.ExternalTask, *types.Error) {
+	if s.queue == nil {
+		return nil, types.NewServiceUnavailableError("external task queue is not configured")
+	}
+	task, err := s.queue.Get(ctx, taskID)
+	if err != nil {
+		return nil, externalTaskError(err)
+	}
+	return task, nil
+}
+
+// Heartbeat records worker liveness.
+func (s *DefaultExternalTaskService) Heartbeat(ctx context.Context, taskID string, req ExternalTaskHeartbeatRequest) (*workflow.ExternalTask, *types.Error) {
+	if s.queue == nil {
+		return nil, types.NewServiceUnavailableError("external task queue is not configured")
+	}
+	if req.Progress < 0 || req.Progress > 100 {
+		return nil, types.NewInvalidRequestError("progress must be between 0 and 100, got " + strconv.FormatFloat(req.Progress, 'f', -1, 64))
+	}
+	task, err := s.queue.Heartbeat(ctx, taskID, req.WorkerID, req.Progress)
+	if err != nil {
+		return nil, externalTaskError(err)
+	}
+	return task, nil
+}
+
+// Complete records the task result.
+func (s *DefaultExternalTaskService) Complete(ctx context.Context, taskID string, req CompleteExternalTaskRequest) *types.Error {
+	if s.queue == nil {
+		return types.NewServiceUnavailableError("external task queue is not configured")
+	}
+	if err := s.queue.Complete(ctx, taskID, req.WorkerID, req.Result); err != nil {
+		return externalTaskError(err)
+	}
+	return nil
+}
+
+// Fail records a task failure.
+func (s *DefaultExternalTaskService) Fail(ctx context.Context, taskID string, req FailExternalTaskRequest) *types.Error {
+	if s.queue == nil {
+		return types.NewServiceUnavailableError("external task queue is not configured")
+	}
+	if err := s.queue.Fail(ctx, taskID, req.WorkerID, req.Error); err != nil {
+		return externalTaskError(err)
+	}
+	return nil
+}
+
+func externalTaskError(err error) *types.Error {
+	switch {
+	case errors.Is(err, ErrExternalTaskNotFound):
+		return types.NewError(types.ErrTaskNotFound, err.Error()).WithHTTPStatus(http.StatusNotFound)
+	case errors.Is(err, ErrExternalTaskNotRunning), errors.Is(err, ErrExternalTaskLeaseMismatch):
+		return types.NewError(types.ErrInvalidTransition, err.Error()).WithHTTPStatus(http.StatusConflict)
+	default:
+		return types.NewInternalError("external task store error").WithCause(err)
+	}
+}
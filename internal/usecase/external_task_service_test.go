@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/persistence"
+	"github.com/BaSui01/agentflow/types"
+	workflow "github.com/BaSui01/agentflow/workflow/core"
+	workflowsteps "github.com/BaSui01/agentflow/workflow/steps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExternalTaskQueue(t *testing.T) (*TaskStoreExternalTaskQueue, persistence.TaskStore) {
+	t.Helper()
+	store := persistence.NewMemoryTaskStore(persistence.StoreConfig{})
+	t.Cleanup(func() { _ = store.Close() })
+	return NewTaskStoreExternalTaskQueue(store), store
+}
+
+func TestTaskStoreExternalTaskQueue_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	queue, store := newTestExternalTaskQueue(t)
+
+	// 非外部活动任务不会被领取
+	require.NoError(t, store.SaveTask(ctx, &persistence.AsyncTask{ID: "agent-task", Type: "image.resize", Status: persistence.TaskStatusPending}))
+
+	id, err := queue.Publish(ctx, &workflow.ExternalTask{
+		TaskType:            "image.resize",
+		StepID:              "resize",
+		Input:               map[string]any{"url": "s3://img.png"},
+		MaxAttempts:         3,
+		HeartbeatTimeout:    time.Minute,
+		StartToCloseTimeout: time.Hour,
+	})
+	require.NoError(t, err)
+
+	task, err := queue.Claim(ctx, "go-worker", []string{"video.encode"})
+	require.NoError(t, err)
+	assert.Nil(t, task, "task types are filtered")
+
+	task, err = queue.Claim(ctx, "py-worker", []string{"image.resize"})
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, id, task.ID)
+	assert.Equal(t, workflow.ExternalTaskRunning, task.Status)
+	assert.Equal(t, "py-worker", task.WorkerID)
+	assert.Equal(t, "resize", task.StepID)
+	assert.Equal(t, 3, task.MaxAttempts)
+	assert.Equal(t, time.Minute, task.HeartbeatTimeout)
+	require.NotNil(t, task.StartedAt)
+	require.NotNil(t, task.LastHeartbeatAt)
+
+	task, err = queue.Claim(ctx, "py-worker", nil)
+	require.NoError(t, err)
+	assert.Nil(t, task, "claimed tasks are not handed out twice")
+
+	_, err = queue.Heartbeat(ctx, id, "other-worker", 10)
+	require.ErrorIs(t, err, ErrExternalTaskLeaseMismatch)
+
+	heartbeat, err := queue.Heartbeat(ctx, id, "py-worker", 50)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.ExternalTaskRunning, heartbeat.Status)
+
+	require.NoError(t, queue.Complete(ctx, id, "py-worker", map[string]any{"width": 128}))
+	task, err = queue.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.ExternalTaskCompleted, task.Status)
+	assert.Equal(t, map[string]any{"width": 128}, task.Result)
+
+	require.ErrorIs(t, queue.Complete(ctx, id, "py-worker", nil), ErrExternalTaskNotRunning)
+	_, err = queue.Get(ctx, "agent-task")
+	require.ErrorIs(t, err, ErrExternalTaskNotFound)
+}
+
+func TestTaskStoreExternalTaskQueue_AbortRejectsLateWorkers(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestExternalTaskQueue(t)
+
+	id, err := queue.Publish(ctx, &workflow.ExternalTask{TaskType: "slow", MaxAttempts: 1})
+	require.NoError(t, err)
+	_, err = queue.Claim(ctx, "w1", nil)
+	require.NoError(t, err)
+
+	require.Error(t, queue.Abort(ctx, id, workflow.ExternalTaskCompleted, ""))
+	require.NoError(t, queue.Abort(ctx, id, workflow.ExternalTaskTimeout, "heartbeat timeout"))
+	require.NoError(t, queue.Abort(ctx, id, workflow.ExternalTaskCancelled, "ignored once terminal"))
+
+	task, err := queue.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.ExternalTaskTimeout, task.Status)
+	assert.Equal(t, "heartbeat timeout", task.Error)
+
+	_, err = queue.Heartbeat(ctx, id, "w1", 0)
+	require.ErrorIs(t, err, ErrExternalTaskNotRunning)
+	require.ErrorIs(t, queue.Fail(ctx, id, "w1", "boom"), ErrExternalTaskNotRunning)
+}
+
+func TestDefaultExternalTaskService(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestExternalTaskQueue(t)
+	service := NewDefaultExternalTaskService(queue)
+	service.pollInterval = 5 * time.Millisecond
+
+	task, apiErr := service.Claim(ctx, ClaimExternalTaskRequest{WorkerID: "w1", WaitMs: 20})
+	require.Nil(t, apiErr)
+	assert.Nil(t, task)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = queue.Publish(ctx, &workflow.ExternalTask{TaskType: "echo", MaxAttempts: 1})
+	}()
+	task, apiErr = service.Claim(ctx, ClaimExternalTaskRequest{WorkerID: "w1", WaitMs: 2000})
+	require.Nil(t, apiErr)
+	require.NotNil(t, task, "long polling picks up tasks published while waiting")
+
+	_, apiErr = service.Heartbeat(ctx, task.ID, ExternalTaskHeartbeatRequest{WorkerID: "w1", Progress: 120})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, types.ErrInvalidRequest, apiErr.Code)
+
+	apiErr = service.Fail(ctx, task.ID, FailExternalTaskRequest{WorkerID: "w2", Error: "boom"})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.HTTPStatus)
+
+	require.Nil(t, service.Fail(ctx, task.ID, FailExternalTaskRequest{WorkerID: "w1", Error: "boom"}))
+	got, apiErr := service.Get(ctx, task.ID)
+	require.Nil(t, apiErr)
+	assert.Equal(t, workflow.ExternalTaskFailed, got.Status)
+	assert.Equal(t, "boom", got.Error)
+
+	_, apiErr = service.Get(ctx, "missing")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.HTTPStatus)
+}
+
+func TestExternalActivityStep_WithTaskStoreQueue(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestExternalTaskQueue(t)
+	service := NewDefaultExternalTaskService(queue)
+
+	step := workflowsteps.NewExternalActivityStep("translate", queue)
+	step.TaskType = "translate"
+	step.PollInterval = 5 * time.Millisecond
+	step.MaxRetries = 1
+
+	// 模拟进程外 worker：第一次失败，第二次成功
+	go func() {
+		for attempt := 0; attempt < 2; attempt++ {
+			var task *workflow.ExternalTask
+			for task == nil {
+				task, _ = service.Claim(ctx, ClaimExternalTaskRequest{WorkerID: "node-worker", TaskTypes: []string{"translate"}, WaitMs: 100})
+			}
+			if attempt == 0 {
+				_ = service.Fail(ctx, task.ID, FailExternalTaskRequest{WorkerID: "node-worker", Error: "rate limited"})
+				continue
+			}
+			_ = service.Complete(ctx, task.ID, CompleteExternalTaskRequest{WorkerID: "node-worker", Result: "bonjour " + task.Input["text"].(string)})
+		}
+	}()
+
+	out, err := step.Execute(ctx, workflow.StepInput{Data: map[string]any{"text": "monde"}})
+	require.NoError(t, err)
+	assert.Equal(t, "bonjour monde", out.Data["result"])
+	assert.Equal(t, 2, out.Data["attempts"])
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	defaultGuardrailAuditLimit = 100
+	maxGuardrailAuditLimit     = 500
+)
+
+// GuardrailAuditRuntime is the queryable store behind the guardrail audit API.
+// *guardrails.GuardrailAuditLogger satisfies it.
+type GuardrailAuditRuntime interface {
+	Query(ctx context.Context, filter *guardrails.AuditLogFilter) ([]*guardrails.AuditLogEntry, error)
+}
+
+type ListGuardrailAuditInput struct {
+	Limit     int
+	Offset    int
+	Validator string
+	Decision  string
+	Direction string
+	TenantID  string
+	TraceID   string
+}
+
+type GuardrailAuditService interface {
+	List(ctx context.Context, input ListGuardrailAuditInput) ([]*guardrails.AuditLogEntry, *types.Error)
+}
+
+type DefaultGuardrailAuditService struct {
+	runtime GuardrailAuditRuntime
+}
+
+func NewDefaultGuardrailAuditService(runtime GuardrailAuditRuntime) *DefaultGuardrailAuditService {
+	return &DefaultGuardrailAuditService{runtime: runtime}
+}
+
+// List returns matching guardrail decisions, newest first.
+func (s *DefaultGuardrailAuditService) List(
+	ctx context.Context,
+	input ListGuardrailAuditInput,
+) ([]*guardrails.AuditLogEntry, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("guardrail audit runtime is not configured")
+	}
+	limit, err := normalizeGuardrailAuditLimit(input.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if input.Offset < 0 {
+		return nil, types.NewInvalidRequestError("offset must not be negative")
+	}
+	filter := &guardrails.AuditLogFilter{
+		TenantID: strings.TrimSpace(input.TenantID),
+		TraceID:  strings.TrimSpace(input.TraceID),
+	}
+	if v := strings.TrimSpace(input.Validator); v != "" {
+		filter.ValidatorNames = []string{v}
+	}
+	if v := strings.TrimSpace(input.Decision); v != "" {
+		filter.Decisions = []guardrails.AuditDecision{guardrails.AuditDecision(v)}
+	}
+	if v := strings.TrimSpace(input.Direction); v != "" {
+		filter.Directions = []guardrails.AuditDirection{guardrails.AuditDirection(v)}
+	}
+
+	rows, queryErr := s.runtime.Query(ctx, filter)
+	if queryErr != nil {
+		return nil, types.NewInternalError("failed to query guardrail audit log").WithCause(queryErr)
+	}
+
+	out := make([]*guardrails.AuditLogEntry, 0, limit)
+	skipped := 0
+	for i := len(rows) - 1; i >= 0 && len(out) < limit; i-- {
+		if rows[i] == nil {
+			continue
+		}
+		if skipped < input.Offset {
+			skipped++
+			continue
+		}
+		out = append(out, rows[i])
+	}
+	return out, nil
+}
+
+func normalizeGuardrailAuditLimit(limit int) (int, *types.Error) {
+	if limit == 0 {
+		return defaultGuardrailAuditLimit, nil
+	}
+	if limit < 0 {
+		return 0, types.NewInvalidRequestError("limit must be greater than 0")
+	}
+	if limit > maxGuardrailAuditLimit {
+		return 0, types.NewInvalidRequestError("limit must be less than or equal to 500")
+	}
+	return limit, nil
+}
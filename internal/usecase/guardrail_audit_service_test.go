@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BaSui01/agentflow/agent/capabilities/guardrails"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGuardrailAuditFixture(t *testing.T) *guardrails.GuardrailAuditLogger {
+	t.Helper()
+	auditLogger := guardrails.NewGuardrailAuditLogger(nil)
+	blocked := guardrails.NewValidationResult()
+	blocked.AddError(guardrails.ValidationError{Code: guardrails.ErrCodeInjectionDetected, Message: "injection", Severity: guardrails.SeverityCritical})
+
+	ctxA := types.WithTenantID(context.Background(), "tenant-a")
+	ctxB := types.WithTenantID(context.Background(), "tenant-b")
+	auditLogger.RecordDecision(ctxA, guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "injection_detector", Result: blocked})
+	auditLogger.RecordDecision(ctxA, guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionOutput, Validator: "secrets_validator", Result: guardrails.NewValidationResult()})
+	auditLogger.RecordDecision(ctxB, guardrails.AuditDecisionEvent{Direction: guardrails.AuditDirectionInput, Validator: "injection_detector", Result: blocked})
+	return auditLogger
+}
+
+func TestGuardrailAuditService_ListFiltersNewestFirst(t *testing.T) {
+	service := NewDefaultGuardrailAuditService(newGuardrailAuditFixture(t))
+
+	rows, err := service.List(context.Background(), ListGuardrailAuditInput{Decision: "block"})
+	require.Nil(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "tenant-b", rows[0].TenantID)
+	assert.Equal(t, "tenant-a", rows[1].TenantID)
+
+	rows, err = service.List(context.Background(), ListGuardrailAuditInput{TenantID: "tenant-a", Direction: "output"})
+	require.Nil(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "secrets_validator", rows[0].ValidatorName)
+
+	rows, err = service.List(context.Background(), ListGuardrailAuditInput{Validator: "injection_detector", Limit: 1, Offset: 1})
+	require.Nil(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "tenant-a", rows[0].TenantID)
+}
+
+func TestGuardrailAuditService_ListValidatesInput(t *testing.T) {
+	service := NewDefaultGuardrailAuditService(newGuardrailAuditFixture(t))
+
+	_, err := service.List(context.Background(), ListGuardrailAuditInput{Limit: 501})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = NewDefaultGuardrailAuditService(nil).List(context.Background(), ListGuardrailAuditInput{})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInternalError, err.Code)
+}
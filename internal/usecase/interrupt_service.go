@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/pkg/jsonschema"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// 人工响应中断的动作
+const (
+	InterruptActionApprove = "approve"
+	InterruptActionReject  = "reject"
+	InterruptActionInput   = "input"
+)
+
+// InterruptRuntime 汇总一个或多个 hitl.InterruptManager，并推送新创建的中断。
+type InterruptRuntime interface {
+	ListInterrupts(ctx context.Context, workflowID string, status hitl.InterruptStatus) ([]*hitl.Interrupt, error)
+	GetInterrupt(ctx context.Context, interruptID string) (*hitl.Interrupt, error)
+	ResolveInterrupt(ctx context.Context, interruptID string, response *hitl.Response) error
+	// SubscribeInterrupts 返回新中断的通道，ctx 结束后通道关闭。
+	SubscribeInterrupts(ctx context.Context) <-chan *hitl.Interrupt
+}
+
+// InterruptListFilter 过滤中断列表与推送流。TenantID 非空时只返回该租户的中断。
+type InterruptListFilter struct {
+	WorkflowID string
+	TenantID   string
+	Status     string
+}
+
+func (f InterruptListFilter) matches(interrupt *hitl.Interrupt) bool {
+	if interrupt == nil {
+		return false
+	}
+	if f.WorkflowID != "" && interrupt.WorkflowID != f.WorkflowID {
+		return false
+	}
+	return f.TenantID == "" || interrupt.TenantID() == f.TenantID
+}
+
+// RespondInterruptInput 人工对中断的响应。
+type RespondInterruptInput struct {
+	Action   string          `json:"action"`
+	OptionID string          `json:"option_id,omitempty"`
+	Input    json.RawMessage `json:"input,omitempty"`
+	Comment  string          `json:"comment,omitempty"`
+	UserID   string          `json:"user_id,omitempty"`
+}
+
+type InterruptService interface {
+	List(ctx context.Context, filter InterruptListFilter) ([]*hitl.Interrupt, *types.Error)
+	Get(ctx context.Context, interruptID, tenantID string) (*hitl.Interrupt, *types.Error)
+	Respond(ctx context.Context, interruptID, tenantID string, input RespondInterruptInput) (*hitl.Interrupt, *types.Error)
+	Subscribe(ctx context.Context, filter InterruptListFilter) (<-chan *hitl.Interrupt, *types.Error)
+}
+
+type DefaultInterruptService struct {
+	runtime InterruptRuntime
+}
+
+func NewDefaultInterruptService(runtime InterruptRuntime) *DefaultInterruptService {
+	return &DefaultInterruptService{runtime: runtime}
+}
+
+func (s *DefaultInterruptService) List(ctx context.Context, filter InterruptListFilter) ([]*hitl.Interrupt, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("interrupt runtime is not configured")
+	}
+	status, err := parseInterruptStatus(filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	rows, listErr := s.runtime.ListInterrupts(ctx, strings.TrimSpace(filter.WorkflowID), status)
+	if listErr != nil {
+		return nil, types.NewInternalError("failed to list interrupts").WithCause(listErr)
+	}
+	out := make([]*hitl.Interrupt, 0, len(rows))
+	for _, row := range rows {
+		if filter.matches(row) {
+			out = append(out, row)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *DefaultInterruptService) Get(ctx context.Context, interruptID, tenantID string) (*hitl.Interrupt, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("interrupt runtime is not configured")
+	}
+	id := strings.TrimSpace(interruptID)
+	if id == "" {
+		return nil, types.NewInvalidRequestError("interrupt ID is required")
+	}
+	interrupt, err := s.runtime.GetInterrupt(ctx, id)
+	if err != nil || !(InterruptListFilter{TenantID: tenantID}).matches(interrupt) {
+		return nil, types.NewNotFoundError("interrupt not found")
+	}
+	return interrupt, nil
+}
+
+// Respond 以批准、拒绝或输入数据解决待处理中断。输入按中断声明的 input_schema 校验，
+// 选项必须是中断提供的选项之一。
+func (s *DefaultInterruptService) Respond(ctx context.Context, interruptID, tenantID string, input RespondInterruptInput) (*hitl.Interrupt, *types.Error) {
+	interrupt, err := s.Get(ctx, interruptID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if interrupt.Status != hitl.InterruptStatusPending {
+		return nil, types.NewError(types.ErrInvalidTransition, "interrupt is no longer pending").WithHTTPStatus(http.StatusConflict)
+	}
+	approver, _ := types.UserID(ctx)
+	response, err := buildInterruptResponse(interrupt, input, approver)
+	if err != nil {
+		return nil, err
+	}
+	if resolveErr := s.runtime.ResolveInterrupt(ctx, interrupt.ID, response); resolveErr != nil {
+		return nil, types.NewError(types.ErrInvalidTransition, "failed to resolve interrupt").
+			WithCause(resolveErr).WithHTTPStatus(http.StatusConflict)
+	}
+	// 重新读取，返回解决后的状态而非解决前的快照
+	resolved, loadErr := s.runtime.GetInterrupt(ctx, interrupt.ID)
+	if loadErr != nil || resolved == nil {
+		return nil, types.NewInternalError("interrupt resolved but could not be reloaded").WithCause(loadErr)
+	}
+	return resolved, nil
+}
+
+func (s *DefaultInterruptService) Subscribe(ctx context.Context, filter InterruptListFilter) (<-chan *hitl.Interrupt, *types.Error) {
+	if s.runtime == nil {
+		return nil, types.NewInternalError("interrupt runtime is not configured")
+	}
+	source := s.runtime.SubscribeInterrupts(ctx)
+	out := make(chan *hitl.Interrupt)
+	go func() {
+		defer close(out)
+		for interrupt := range source {
+			if !filter.matches(interrupt) {
+				continue
+			}
+			select {
+			case out <- interrupt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// buildInterruptResponse 构造中断响应。审批人只取自认证上下文 approver，
+// 请求体中的 user_id 仅可与其一致，不能冒用他人身份。
+func buildInterruptResponse(interrupt *hitl.Interrupt, input RespondInterruptInput, approver string) (*hitl.Response, *types.Error) {
+	if claimed := strings.TrimSpace(input.UserID); claimed != "" && claimed != approver {
+		return nil, types.NewError(types.ErrForbidden, "user_id does not match the authenticated user").WithHTTPStatus(http.StatusForbidden)
+	}
+	response := &hitl.Response{
+		OptionID: strings.TrimSpace(input.OptionID),
+		Comment:  strings.TrimSpace(input.Comment),
+		UserID:   approver,
+	}
+	switch strings.ToLower(strings.TrimSpace(input.Action)) {
+	case InterruptActionApprove:
+		response.Approved = true
+	case InterruptActionReject:
+	case InterruptActionInput:
+		if len(input.Input) == 0 || string(input.Input) == "null" {
+			return nil, types.NewInvalidRequestError("input is required for action input")
+		}
+		value, err := validateInterruptInput(interrupt.InputSchema, input.Input)
+		if err != nil {
+			return nil, err
+		}
+		response.Input = value
+		response.Approved = true
+	default:
+		return nil, types.NewInvalidRequestError("action must be one of approve,reject,input")
+	}
+
+	if response.OptionID != "" && len(interrupt.Options) > 0 && !hasInterruptOption(interrupt.Options, response.OptionID) {
+		return nil, types.NewInvalidRequestError("option_id is not one of the interrupt options")
+	}
+	return response, nil
+}
+
+func validateInterruptInput(schema json.RawMessage, raw json.RawMessage) (any, *types.Error) {
+	if len(schema) > 0 {
+		checked := jsonschema.ValidateValue(raw, schema, jsonschema.ValueOptions{})
+		if !checked.Valid() {
+			messages := make([]string, 0, len(checked.Errors))
+			for _, violation := range checked.Errors {
+				messages = append(messages, violation.Error())
+			}
+			return nil, types.NewError(types.ErrInputValidation, "input does not match input_schema: "+strings.Join(messages, "; ")).
+				WithHTTPStatus(http.StatusBadRequest)
+		}
+		raw = checked.Value
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, types.NewInvalidRequestError("input must be valid JSON")
+	}
+	return value, nil
+}
+
+func hasInterruptOption(options []hitl.Option, optionID string) bool {
+	for _, option := range options {
+		if option.ID == optionID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/agent/observability/hitl"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInterruptRuntime struct {
+	rows     []*hitl.Interrupt
+	resolved map[string]*hitl.Response
+	live     chan *hitl.Interrupt
+}
+
+func (f *fakeInterruptRuntime) ListInterrupts(_ context.Context, workflowID string, status hitl.InterruptStatus) ([]*hitl.Interrupt, error) {
+	var out []*hitl.Interrupt
+	for _, row := range f.rows {
+		if (workflowID == "" || row.WorkflowID == workflowID) && (status == "" || row.Status == status) {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeInterruptRuntime) GetInterrupt(_ context.Context, interruptID string) (*hitl.Interrupt, error) {
+	for _, row := range f.rows {
+		if row.ID == interruptID {
+			return row, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeInterruptRuntime) ResolveInterrupt(_ context.Context, interruptID string, response *hitl.Response) error {
+	if f.resolved == nil {
+		f.resolved = map[string]*hitl.Response{}
+	}
+	f.resolved[interruptID] = response
+	// 与持久化存储一致：解决后以新记录替换，调用方持有的快照保持不变
+	for i, row := range f.rows {
+		if row.ID == interruptID {
+			next := *row
+			next.Status = hitl.InterruptStatusResolved
+			if !response.Approved {
+				next.Status = hitl.InterruptStatusRejected
+			}
+			next.Response = response
+			f.rows[i] = &next
+		}
+	}
+	return nil
+}
+
+func (f *fakeInterruptRuntime) SubscribeInterrupts(context.Context) <-chan *hitl.Interrupt {
+	return f.live
+}
+
+func newInterruptFixture() *fakeInterruptRuntime {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &fakeInterruptRuntime{rows: []*hitl.Interrupt{
+		{ID: "i-2", WorkflowID: "wf", Status: hitl.InterruptStatusPending, CreatedAt: t0.Add(time.Minute),
+			Metadata: map[string]any{hitl.MetadataTenantID: "t1"}},
+		{ID: "i-1", WorkflowID: "wf", Status: hitl.InterruptStatusPending, CreatedAt: t0,
+			Type: hitl.InterruptTypeInput, InputSchema: json.RawMessage(`{"type":"string","enum":["a","b"]}`)},
+		{ID: "i-3", WorkflowID: "wf", Status: hitl.InterruptStatusResolved, CreatedAt: t0},
+	}}
+}
+
+func TestInterruptService_ListSortsAndFiltersTenant(t *testing.T) {
+	svc := NewDefaultInterruptService(newInterruptFixture())
+
+	rows, err := svc.List(context.Background(), InterruptListFilter{})
+	require.Nil(t, err)
+	require.Len(t, rows, 2, "defaults to pending")
+	assert.Equal(t, "i-1", rows[0].ID)
+
+	rows, err = svc.List(context.Background(), InterruptListFilter{TenantID: "t1"})
+	require.Nil(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "i-2", rows[0].ID)
+
+	_, err = svc.Get(context.Background(), "i-2", "t2")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+}
+
+func TestInterruptService_Respond(t *testing.T) {
+	runtime := newInterruptFixture()
+	svc := NewDefaultInterruptService(runtime)
+
+	_, err := svc.Respond(context.Background(), "i-1", "", RespondInterruptInput{Action: InterruptActionInput, Input: json.RawMessage(`"c"`)})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.HTTPStatus)
+
+	_, err = svc.Respond(context.Background(), "i-1", "", RespondInterruptInput{Action: InterruptActionInput})
+	require.NotNil(t, err)
+
+	_, err = svc.Respond(context.Background(), "i-1", "", RespondInterruptInput{Action: InterruptActionApprove, UserID: "mallory"})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.HTTPStatus)
+
+	ctx := types.WithUserID(context.Background(), "reviewer")
+	resolved, err := svc.Respond(ctx, "i-1", "", RespondInterruptInput{Action: "INPUT", Input: json.RawMessage(`"b"`), Comment: " ok ", UserID: "reviewer"})
+	require.Nil(t, err)
+	assert.Equal(t, hitl.InterruptStatusResolved, resolved.Status, "returns the resolved state")
+	require.NotNil(t, resolved.Response)
+	response := runtime.resolved["i-1"]
+	require.NotNil(t, response)
+	assert.True(t, response.Approved)
+	assert.Equal(t, "b", response.Input)
+	assert.Equal(t, "ok", response.Comment)
+	assert.Equal(t, "reviewer", response.UserID)
+
+	_, err = svc.Respond(context.Background(), "i-3", "", RespondInterruptInput{Action: InterruptActionApprove})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusConflict, err.HTTPStatus)
+}
+
+func TestInterruptService_SubscribeFilters(t *testing.T) {
+	runtime := newInterruptFixture()
+	runtime.live = make(chan *hitl.Interrupt, 2)
+	runtime.live <- &hitl.Interrupt{ID: "other", WorkflowID: "wf-other"}
+	runtime.live <- &hitl.Interrupt{ID: "match", WorkflowID: "wf"}
+	close(runtime.live)
+
+	ch, err := NewDefaultInterruptService(runtime).Subscribe(context.Background(), InterruptListFilter{WorkflowID: "wf"})
+	require.Nil(t, err)
+	var ids []string
+	for interrupt := range ch {
+		ids = append(ids, interrupt.ID)
+	}
+	assert.Equal(t, []string{"match"}, ids)
+}
@@ -491,6 +491,7 @@ func directChatResponseConversion(resp *types.ChatResponse) *ChatResponse {
 			Index:        c.Index,
 			FinishReason: c.FinishReason,
 			Message:      convertTypesMessageToUsecase(c.Message),
+			LogProbs:     c.LogProbs,
 		}
 	}
 	return &ChatResponse{
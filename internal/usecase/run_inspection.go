@@ -0,0 +1,493 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+
+	RunSpanRun       = "run"
+	RunSpanLLM       = "llm"
+	RunSpanTool      = "tool"
+	RunSpanGuardrail = "guardrail"
+
+	// RunEventMetadataValidator / RunEventMetadataDecision 是护栏决策事件 Metadata 的键
+	RunEventMetadataValidator = "validator"
+	RunEventMetadataDecision  = "decision"
+
+	runMetadataTenantID = "tenant_id"
+	// maxRunInspectionDepth 限制子运行嵌套展开的深度
+	maxRunInspectionDepth = 8
+)
+
+// RunRecordView mirrors observability.RunSnapshot for usecase layer isolation.
+type RunRecordView struct {
+	RunID       string
+	TenantID    string
+	Events      []types.RunEvent
+	ChildRunIDs []string
+	Truncated   bool
+}
+
+// RunRecordReader reads recorded runs by ID.
+type RunRecordReader interface {
+	RunRecord(runID string) (*RunRecordView, bool)
+}
+
+// RunEventRecorder receives lifecycle events of runs started through the API.
+// *observability.RunRecorder satisfies it.
+type RunEventRecorder interface {
+	AppendRunEvent(event types.RunEvent)
+}
+
+// RunTokensView is the token breakdown of a span or run.
+type RunTokensView struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+	Total      int `json:"total"`
+	Cached     int `json:"cached,omitempty"`
+}
+
+func (t *RunTokensView) add(o RunTokensView) {
+	t.Prompt += o.Prompt
+	t.Completion += o.Completion
+	t.Total += o.Total
+	t.Cached += o.Cached
+}
+
+// RunSpanView is one node of the run trace tree.
+type RunSpanView struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Name       string         `json:"name"`
+	Status     string         `json:"status,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	StartTime  time.Time      `json:"start_time"`
+	EndTime    *time.Time     `json:"end_time,omitempty"`
+	DurationMS int64          `json:"duration_ms,omitempty"`
+	Input      any            `json:"input,omitempty"`
+	Output     any            `json:"output,omitempty"`
+	Tokens     *RunTokensView `json:"tokens,omitempty"`
+	CostUSD    float64        `json:"cost_usd,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+	Children   []*RunSpanView `json:"children,omitempty"`
+}
+
+// RunModelUsageView aggregates LLM calls per provider/model.
+type RunModelUsageView struct {
+	Provider string        `json:"provider"`
+	Model    string        `json:"model"`
+	Calls    int           `json:"calls"`
+	Tokens   RunTokensView `json:"tokens"`
+	CostUSD  float64       `json:"cost_usd"`
+}
+
+// RunUsageView is the token/cost breakdown of a run including its child runs.
+type RunUsageView struct {
+	LLMCalls           int                 `json:"llm_calls"`
+	Tokens             RunTokensView       `json:"tokens"`
+	CostUSD            float64             `json:"cost_usd"`
+	ToolCalls          int                 `json:"tool_calls"`
+	ToolErrors         int                 `json:"tool_errors"`
+	GuardrailDecisions int                 `json:"guardrail_decisions"`
+	ByModel            []RunModelUsageView `json:"by_model,omitempty"`
+}
+
+func (u *RunUsageView) addLLMCall(provider, model string, tokens RunTokensView, cost float64) {
+	u.LLMCalls++
+	u.Tokens.add(tokens)
+	u.CostUSD += cost
+	m := u.modelUsage(provider, model)
+	m.Calls++
+	m.Tokens.add(tokens)
+	m.CostUSD += cost
+}
+
+func (u *RunUsageView) merge(o RunUsageView) {
+	u.LLMCalls += o.LLMCalls
+	u.Tokens.add(o.Tokens)
+	u.CostUSD += o.CostUSD
+	u.ToolCalls += o.ToolCalls
+	u.ToolErrors += o.ToolErrors
+	u.GuardrailDecisions += o.GuardrailDecisions
+	for _, other := range o.ByModel {
+		m := u.modelUsage(other.Provider, other.Model)
+		m.Calls += other.Calls
+		m.Tokens.add(other.Tokens)
+		m.CostUSD += other.CostUSD
+	}
+}
+
+func (u *RunUsageView) modelUsage(provider, model string) *RunModelUsageView {
+	for i := range u.ByModel {
+		if u.ByModel[i].Provider == provider && u.ByModel[i].Model == model {
+			return &u.ByModel[i]
+		}
+	}
+	u.ByModel = append(u.ByModel, RunModelUsageView{Provider: provider, Model: model})
+	return &u.ByModel[len(u.ByModel)-1]
+}
+
+// RunInspectionView is the response of GET /v1/runs/{id}.
+type RunInspectionView struct {
+	RunID       string       `json:"run_id"`
+	ParentRunID string       `json:"parent_run_id,omitempty"`
+	TraceID     string       `json:"trace_id,omitempty"`
+	AgentID     string       `json:"agent_id,omitempty"`
+	TenantID    string       `json:"tenant_id,omitempty"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	StartTime   time.Time    `json:"start_time"`
+	EndTime     *time.Time   `json:"end_time,omitempty"`
+	DurationMS  int64        `json:"duration_ms,omitempty"`
+	Truncated   bool         `json:"truncated,omitempty"`
+	Trace       *RunSpanView `json:"trace"`
+	Usage       RunUsageView `json:"usage"`
+}
+
+// RunEventsView is the response of GET /v1/runs/{id}/events.
+type RunEventsView struct {
+	RunID     string           `json:"run_id"`
+	Events    []types.RunEvent `json:"events"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// RunInspectionService exposes recorded runs for debugging through the API.
+// tenantID is the caller's bound tenant; runs of other tenants are reported
+// as not found.
+type RunInspectionService interface {
+	Get(ctx context.Context, runID, tenantID string) (*RunInspectionView, *types.Error)
+	Events(ctx context.Context, runID, tenantID string, afterSequence int64) (*RunEventsView, *types.Error)
+}
+
+type DefaultRunInspectionService struct {
+	reader RunRecordReader
+}
+
+func NewDefaultRunInspectionService(reader RunRecordReader) *DefaultRunInspectionService {
+	return &DefaultRunInspectionService{reader: reader}
+}
+
+func (s *DefaultRunInspectionService) Get(_ context.Context, runID, tenantID string) (*RunInspectionView, *types.Error) {
+	record, err := s.lookup(runID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	view := &RunInspectionView{RunID: record.RunID, TenantID: record.TenantID, Truncated: record.Truncated}
+	view.Trace, view.Usage = s.buildRunSpan(record, map[string]bool{record.RunID: true}, 0, view)
+	view.Status = view.Trace.Status
+	view.Error = view.Trace.Error
+	view.StartTime = view.Trace.StartTime
+	view.EndTime = view.Trace.EndTime
+	view.DurationMS = view.Trace.DurationMS
+	return view, nil
+}
+
+func (s *DefaultRunInspectionService) Events(_ context.Context, runID, tenantID string, afterSequence int64) (*RunEventsView, *types.Error) {
+	if afterSequence < 0 {
+		return nil, types.NewInvalidRequestError("after must not be negative")
+	}
+	record, err := s.lookup(runID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]types.RunEvent, 0, len(record.Events))
+	for _, event := range record.Events {
+		if event.Sequence > afterSequence {
+			events = append(events, event)
+		}
+	}
+	return &RunEventsView{RunID: record.RunID, Events: events, Truncated: record.Truncated}, nil
+}
+
+func (s *DefaultRunInspectionService) lookup(runID, tenantID string) (*RunRecordView, *types.Error) {
+	if s.reader == nil {
+		return nil, types.NewServiceUnavailableError("run recorder is not configured")
+	}
+	runID = strings.TrimSpace(runID)
+	if runID == "" {
+		return nil, types.NewInvalidRequestError("run id is required")
+	}
+	record, ok := s.reader.RunRecord(runID)
+	if !ok || (tenantID != "" && record.TenantID != tenantID) {
+		return nil, types.NewNotFoundError(fmt.Sprintf("run %q not found", runID))
+	}
+	return record, nil
+}
+
+// buildRunSpan 将运行事件还原为追踪树：根节点为运行本身，子节点按时间顺序排列
+// LLM 调用、工具调用、护栏决策与子运行；返回的用量包含全部子运行
+func (s *DefaultRunInspectionService) buildRunSpan(record *RunRecordView, visited map[string]bool, depth int, header *RunInspectionView) (*RunSpanView, RunUsageView) {
+	root := &RunSpanView{ID: record.RunID, Type: RunSpanRun, Name: record.RunID, Status: RunStatusRunning}
+	var usage RunUsageView
+	tools := make(map[string]*RunSpanView)
+
+	for i, event := range record.Events {
+		if i == 0 {
+			root.StartTime = event.Timestamp
+		}
+		if event.AgentID != "" && root.Name == record.RunID {
+			root.Name = event.AgentID
+		}
+		if header != nil {
+			fillRunHeader(header, event)
+		}
+		switch event.Type {
+		case types.RunEventStarted:
+			root.StartTime = event.Timestamp
+			root.Input = event.Data
+		case types.RunEventCompleted, types.RunEventFailed:
+			root.Status = RunStatusCompleted
+			if event.Type == types.RunEventFailed {
+				root.Status = RunStatusFailed
+				root.Error = event.Error
+			}
+			root.Output = event.Data
+			finishRunSpan(root, event.Timestamp, 0)
+		case types.RunEventUsage:
+			span := llmRunSpan(event)
+			root.Children = append(root.Children, span)
+			usage.addLLMCall(runEventDataString(event, "provider"), runEventDataString(event, "model"), *span.Tokens, span.CostUSD)
+		case types.RunEventToolCall:
+			span := toolRunSpan(tools, event)
+			span.StartTime = event.Timestamp
+			if event.ToolCall != nil && len(event.ToolCall.Arguments) > 0 {
+				span.Input = event.ToolCall.Arguments
+			}
+			if span.Status == "" {
+				span.Status = RunStatusRunning
+				root.Children = append(root.Children, span)
+				usage.ToolCalls++
+			}
+		case types.RunEventToolResult:
+			span := toolRunSpan(tools, event)
+			if span.Status == "" {
+				// 未观察到调用事件时，以结果时间补齐
+				span.StartTime = event.Timestamp
+				root.Children = append(root.Children, span)
+				usage.ToolCalls++
+			}
+			span.Status = "ok"
+			var duration time.Duration
+			if result := event.ToolResult; result != nil {
+				if len(result.Result) > 0 {
+					span.Output = result.Result
+				}
+				duration = result.Duration
+			}
+			if event.Error != "" {
+				span.Status = "error"
+				span.Error = event.Error
+				usage.ToolErrors++
+			}
+			finishRunSpan(span, event.Timestamp, duration)
+		case types.RunEventGuardrail:
+			root.Children = append(root.Children, guardrailRunSpan(event))
+			usage.GuardrailDecisions++
+		}
+	}
+
+	if depth < maxRunInspectionDepth {
+		for _, childID := range record.ChildRunIDs {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			child, ok := s.reader.RunRecord(childID)
+			if !ok {
+				continue
+			}
+			childSpan, childUsage := s.buildRunSpan(child, visited, depth+1, nil)
+			root.Children = append(root.Children, childSpan)
+			usage.merge(childUsage)
+		}
+	}
+	sort.SliceStable(root.Children, func(i, j int) bool {
+		return root.Children[i].StartTime.Before(root.Children[j].StartTime)
+	})
+	return root, usage
+}
+
+func fillRunHeader(header *RunInspectionView, event types.RunEvent) {
+	if header.ParentRunID == "" {
+		header.ParentRunID = event.ParentRunID
+	}
+	if header.TraceID == "" {
+		header.TraceID = event.TraceID
+	}
+	if header.AgentID == "" {
+		header.AgentID = event.AgentID
+	}
+}
+
+func finishRunSpan(span *RunSpanView, end time.Time, duration time.Duration) {
+	if duration <= 0 && !span.StartTime.IsZero() {
+		duration = end.Sub(span.StartTime)
+	}
+	span.EndTime = &end
+	span.DurationMS = duration.Milliseconds()
+}
+
+func llmRunSpan(event types.RunEvent) *RunSpanView {
+	tokens := RunTokensView{}
+	if u := event.Usage; u != nil {
+		tokens = RunTokensView{Prompt: u.PromptTokens, Completion: u.CompletionTokens, Total: u.TotalTokens}
+		if u.PromptTokensDetails != nil {
+			tokens.Cached = u.PromptTokensDetails.CachedTokens
+		}
+	}
+	name := runEventDataString(event, "model")
+	if name == "" {
+		name = RunSpanLLM
+	}
+	ts := event.Timestamp
+	span := &RunSpanView{
+		ID:        fmt.Sprintf("llm-%d", event.Sequence),
+		Type:      RunSpanLLM,
+		Name:      name,
+		Status:    "ok",
+		StartTime: ts,
+		EndTime:   &ts,
+		Tokens:    &tokens,
+		CostUSD:   runEventDataFloat(event, "cost_usd"),
+		Metadata:  map[string]any{},
+	}
+	for _, key := range []string{"provider", "capability", "strategy"} {
+		if v := runEventDataString(event, key); v != "" {
+			span.Metadata[key] = v
+		}
+	}
+	return span
+}
+
+func toolRunSpan(tools map[string]*RunSpanView, event types.RunEvent) *RunSpanView {
+	id := event.ToolCallID
+	if id == "" {
+		id = fmt.Sprintf("tool-%d", event.Sequence)
+	}
+	if span, ok := tools[id]; ok {
+		return span
+	}
+	span := &RunSpanView{ID: id, Type: RunSpanTool, Name: event.ToolName}
+	tools[id] = span
+	return span
+}
+
+func guardrailRunSpan(event types.RunEvent) *RunSpanView {
+	ts := event.Timestamp
+	span := &RunSpanView{
+		ID:        fmt.Sprintf("guardrail-%d", event.Sequence),
+		Type:      RunSpanGuardrail,
+		Name:      event.Metadata[RunEventMetadataValidator],
+		Status:    event.Metadata[RunEventMetadataDecision],
+		Error:     event.Error,
+		StartTime: ts,
+		EndTime:   &ts,
+		Output:    event.Data,
+	}
+	if span.Name == "" {
+		span.Name = RunSpanGuardrail
+	}
+	if event.ToolName != "" {
+		span.Metadata = map[string]any{"tool_name": event.ToolName}
+	}
+	return span
+}
+
+func runEventDataString(event types.RunEvent, key string) string {
+	data, _ := event.Data.(map[string]any)
+	s, _ := data[key].(string)
+	return s
+}
+
+func runEventDataFloat(event types.RunEvent, key string) float64 {
+	data, _ := event.Data.(map[string]any)
+	f, _ := data[key].(float64)
+	return f
+}
+
+// agentRunTracker 为一次经 API 发起的 Agent 执行确定 run ID 并记录起止事件，
+// 执行期间的 LLM 用量、工具调用与护栏决策由各自的记录器按 context 中的 run ID 归集
+type agentRunTracker struct {
+	recorder RunEventRecorder
+	base     types.RunEvent
+	start    time.Time
+}
+
+// beginAgentRun 沿用 context 中已有的 run ID，否则以请求 trace ID 作为 run ID
+func beginAgentRun(ctx context.Context, recorder RunEventRecorder, req AgentExecuteRequest, traceID string) (context.Context, *agentRunTracker) {
+	runID, _ := types.RunID(ctx)
+	if runID == "" {
+		runID = strings.TrimSpace(traceID)
+	}
+	if runID == "" {
+		runID = fmt.Sprintf("run_%d", time.Now().UnixNano())
+	}
+	ctx = types.WithRunID(ctx, runID)
+
+	tracker := &agentRunTracker{recorder: recorder, start: time.Now()}
+	tracker.base = types.RunEvent{
+		Scope:   types.RunScopeAgent,
+		RunID:   runID,
+		TraceID: traceID,
+		AgentID: strings.TrimSpace(req.AgentID),
+	}
+	tracker.base.ParentRunID, _ = types.ParentRunID(ctx)
+	if tenantID, ok := types.TenantID(ctx); ok {
+		tracker.base.Metadata = map[string]string{runMetadataTenantID: tenantID}
+	}
+	if recorder != nil {
+		event := tracker.base
+		event.Type = types.RunEventStarted
+		event.Timestamp = tracker.start
+		data := map[string]any{"content": req.Content}
+		if ids := normalizedAgentIDs(req); len(ids) > 1 {
+			data["agent_ids"] = ids
+			data["mode"] = normalizedExecutionMode(req)
+		}
+		event.Data = data
+		recorder.AppendRunEvent(event)
+	}
+	return ctx, tracker
+}
+
+func (t *agentRunTracker) runID() string {
+	return t.base.RunID
+}
+
+func (t *agentRunTracker) finish(output *agent.Output, err error) {
+	if t == nil || t.recorder == nil {
+		return
+	}
+	event := t.base
+	event.Timestamp = time.Now()
+	if err != nil {
+		event.Type = types.RunEventFailed
+		event.Error = err.Error()
+		event.Data = map[string]any{"duration_ms": event.Timestamp.Sub(t.start).Milliseconds()}
+		t.recorder.AppendRunEvent(event)
+		return
+	}
+	event.Type = types.RunEventCompleted
+	data := map[string]any{"duration_ms": event.Timestamp.Sub(t.start).Milliseconds()}
+	if output != nil {
+		if output.TokensUsed > 0 {
+			event.Usage = &types.ChatUsage{TotalTokens: output.TokensUsed}
+		}
+		data["content"] = output.Content
+		data["cost"] = output.Cost
+		data["finish_reason"] = output.FinishReason
+	}
+	event.Data = data
+	t.recorder.AppendRunEvent(event)
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunRecords map[string]*RunRecordView
+
+func (f fakeRunRecords) RunRecord(runID string) (*RunRecordView, bool) {
+	record, ok := f[runID]
+	return record, ok
+}
+
+func (f fakeRunRecords) AppendRunEvent(event types.RunEvent) {
+	record, ok := f[event.RunID]
+	if !ok {
+		record = &RunRecordView{RunID: event.RunID, TenantID: event.Metadata[runMetadataTenantID]}
+		f[event.RunID] = record
+	}
+	event.Sequence = int64(len(record.Events) + 1)
+	record.Events = append(record.Events, event)
+}
+
+func newRunInspectionFixture() fakeRunRecords {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	usage := func(seq int64, ts time.Time, model string, prompt, completion int, cost float64) types.RunEvent {
+		return types.RunEvent{
+			Type: types.RunEventUsage, Sequence: seq, Timestamp: ts,
+			Usage: &types.ChatUsage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+			Data:  map[string]any{"provider": "openai", "model": model, "cost_usd": cost},
+		}
+	}
+	return fakeRunRecords{
+		"run-1": {
+			RunID:       "run-1",
+			TenantID:    "t1",
+			ChildRunIDs: []string{"run-1-child"},
+			Events: []types.RunEvent{
+				{Type: types.RunEventStarted, Sequence: 1, Timestamp: at(0), AgentID: "assistant", TraceID: "trace-1"},
+				usage(2, at(100), "gpt-5.4", 100, 20, 0.5),
+				{Type: types.RunEventToolCall, Sequence: 3, Timestamp: at(150), ToolCallID: "call-1", ToolName: "search",
+					ToolCall: &types.ToolCall{ID: "call-1", Name: "search", Arguments: json.RawMessage(`{"q":"go"}`)}},
+				{Type: types.RunEventToolResult, Sequence: 4, Timestamp: at(400), ToolCallID: "call-1", ToolName: "search", Error: "timeout",
+					ToolResult: &types.ToolResult{ToolCallID: "call-1", Name: "search", Error: "timeout", Duration: 250 * time.Millisecond}},
+				{Type: types.RunEventGuardrail, Sequence: 5, Timestamp: at(500),
+					Metadata: map[string]string{RunEventMetadataValidator: "pii", RunEventMetadataDecision: "redact"}},
+				usage(6, at(900), "gpt-5.4", 50, 10, 0.25),
+				{Type: types.RunEventCompleted, Sequence: 7, Timestamp: at(1000)},
+			},
+		},
+		"run-1-child": {
+			RunID: "run-1-child",
+			Events: []types.RunEvent{
+				{Type: types.RunEventStarted, Sequence: 1, Timestamp: at(200), ParentRunID: "run-1"},
+				usage(2, at(300), "gpt-5.4-mini", 10, 5, 0.01),
+				{Type: types.RunEventFailed, Sequence: 3, Timestamp: at(350), Error: "boom"},
+			},
+		},
+	}
+}
+
+func TestRunInspectionService_BuildsTraceTree(t *testing.T) {
+	svc := NewDefaultRunInspectionService(newRunInspectionFixture())
+	view, err := svc.Get(context.Background(), "run-1", "")
+	require.Nil(t, err)
+
+	assert.Equal(t, RunStatusCompleted, view.Status)
+	assert.Equal(t, "assistant", view.AgentID)
+	assert.Equal(t, "trace-1", view.TraceID)
+	assert.Equal(t, int64(1000), view.DurationMS)
+
+	root := view.Trace
+	require.Len(t, root.Children, 5)
+	kinds := make([]string, len(root.Children))
+	for i, child := range root.Children {
+		kinds[i] = child.Type
+	}
+	assert.Equal(t, []string{RunSpanLLM, RunSpanTool, RunSpanRun, RunSpanGuardrail, RunSpanLLM}, kinds)
+
+	tool := root.Children[1]
+	assert.Equal(t, "search", tool.Name)
+	assert.Equal(t, "error", tool.Status)
+	assert.Equal(t, int64(250), tool.DurationMS)
+	assert.JSONEq(t, `{"q":"go"}`, string(tool.Input.(json.RawMessage)))
+
+	child := root.Children[2]
+	assert.Equal(t, RunStatusFailed, child.Status)
+	assert.Equal(t, "boom", child.Error)
+	require.Len(t, child.Children, 1)
+
+	guard := root.Children[3]
+	assert.Equal(t, "pii", guard.Name)
+	assert.Equal(t, "redact", guard.Status)
+
+	assert.Equal(t, 3, view.Usage.LLMCalls)
+	assert.Equal(t, 195, view.Usage.Tokens.Total)
+	assert.InDelta(t, 0.76, view.Usage.CostUSD, 1e-9)
+	assert.Equal(t, 1, view.Usage.ToolCalls)
+	assert.Equal(t, 1, view.Usage.ToolErrors)
+	assert.Equal(t, 1, view.Usage.GuardrailDecisions)
+	require.Len(t, view.Usage.ByModel, 2)
+	assert.Equal(t, "gpt-5.4", view.Usage.ByModel[0].Model)
+	assert.Equal(t, 2, view.Usage.ByModel[0].Calls)
+	assert.Equal(t, 15, view.Usage.ByModel[1].Tokens.Total)
+}
+
+func TestRunInspectionService_TenantIsolationAndEvents(t *testing.T) {
+	svc := NewDefaultRunInspectionService(newRunInspectionFixture())
+
+	_, err := svc.Get(context.Background(), "run-1", "t2")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+
+	events, err := svc.Events(context.Background(), "run-1", "t1", 5)
+	require.Nil(t, err)
+	require.Len(t, events.Events, 2)
+	assert.Equal(t, int64(6), events.Events[0].Sequence)
+
+	_, err = svc.Events(context.Background(), "missing", "", 0)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+}
+
+func TestAgentRunTracker_RecordsLifecycle(t *testing.T) {
+	records := fakeRunRecords{}
+	ctx := types.WithTenantID(context.Background(), "t1")
+
+	runCtx, run := beginAgentRun(ctx, records, AgentExecuteRequest{AgentID: "assistant", Content: "hi"}, "trace-9")
+	runID, _ := types.RunID(runCtx)
+	assert.Equal(t, "trace-9", runID, "run ID defaults to the request trace ID")
+	run.finish(&agent.Output{Content: "hello", TokensUsed: 12, Cost: 0.1}, nil)
+
+	record := records["trace-9"]
+	require.NotNil(t, record)
+	assert.Equal(t, "t1", record.TenantID)
+	require.Len(t, record.Events, 2)
+	assert.Equal(t, types.RunEventStarted, record.Events[0].Type)
+	assert.Equal(t, types.RunEventCompleted, record.Events[1].Type)
+	assert.Equal(t, 12, record.Events[1].Usage.TotalTokens)
+
+	_, failed := beginAgentRun(types.WithRunID(ctx, "existing"), records, AgentExecuteRequest{AgentID: "assistant"}, "trace-10")
+	failed.finish(nil, errors.New("boom"))
+	require.Len(t, records["existing"].Events, 2)
+	assert.Equal(t, "boom", records["existing"].Events[1].Error)
+}
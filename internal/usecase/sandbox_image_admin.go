@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// StartImageRolloutRequest starts routing a share of executions to a candidate image.
+type StartImageRolloutRequest struct {
+	Image   string `json:"image"`
+	Digest  string `json:"digest"`
+	Percent int    `json:"percent"`
+}
+
+// UpdateImageRolloutRequest adjusts the share of executions on the candidate image.
+type UpdateImageRolloutRequest struct {
+	Percent int `json:"percent"`
+}
+
+// SandboxImageAdminService manages digest-pinned sandbox images for the API layer.
+type SandboxImageAdminService interface {
+	// ListImages returns the pinned image and rollout state per language.
+	ListImages(ctx context.Context) []agent.LanguageImageStatus
+	// StartRollout verifies the candidate image and starts a gradual rollout.
+	StartRollout(ctx context.Context, language string, req StartImageRolloutRequest) (*agent.ImageRollout, *types.Error)
+	// UpdateRollout changes the rollout percentage.
+	UpdateRollout(ctx context.Context, language string, req UpdateImageRolloutRequest) (*agent.ImageRollout, *types.Error)
+	// PromoteRollout makes the candidate the stable image.
+	PromoteRollout(ctx context.Context, language string) (*agent.LanguageImageStatus, *types.Error)
+	// AbortRollout routes all executions back to the stable image.
+	AbortRollout(ctx context.Context, language string) *types.Error
+}
+
+// DefaultSandboxImageAdminService is the default implementation of SandboxImageAdminService.
+type DefaultSandboxImageAdminService struct {
+	catalog  *agent.ImageCatalog
+	resolver agent.DigestResolver
+}
+
+// NewDefaultSandboxImageAdminService creates a new SandboxImageAdminService.
+// When resolver is non-nil, candidate images must exist locally with the
+// pinned digest before any traffic is routed to them.
+func NewDefaultSandboxImageAdminService(catalog *agent.ImageCatalog, resolver agent.DigestResolver) *DefaultSandboxImageAdminService {
+	return &DefaultSandboxImageAdminService{catalog: catalog, resolver: resolver}
+}
+
+// ListImages returns the pinned image and rollout state per language.
+func (s *DefaultSandboxImageAdminService) ListImages(_ context.Context) []agent.LanguageImageStatus {
+	if s.catalog == nil {
+		return []agent.LanguageImageStatus{}
+	}
+	return s.catalog.Status()
+}
+
+// StartRollout verifies the candidate image and starts a gradual rollout.
+func (s *DefaultSandboxImageAdminService) StartRollout(ctx context.Context, language string, req StartImageRolloutRequest) (*agent.ImageRollout, *types.Error) {
+	lang, apiErr := s.languageFor(language)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	candidate := agent.ImagePin{Image: strings.TrimSpace(req.Image), Digest: strings.TrimSpace(req.Digest)}
+	if err := candidate.Validate(); err != nil {
+		return nil, types.NewInvalidRequestError(err.Error())
+	}
+	if s.resolver != nil {
+		if err := candidate.Verify(ctx, s.resolver); err != nil {
+			return nil, types.NewInvalidRequestError("candidate image failed digest verification").WithCause(err)
+		}
+	}
+	rollout, err := s.catalog.StartRollout(lang, candidate, req.Percent)
+	if err != nil {
+		return nil, types.NewInvalidRequestError(err.Error())
+	}
+	return rollout, nil
+}
+
+// UpdateRollout changes the rollout percentage.
+func (s *DefaultSandboxImageAdminService) UpdateRollout(_ context.Context, language string, req UpdateImageRolloutRequest) (*agent.ImageRollout, *types.Error) {
+	lang, apiErr := s.languageFor(language)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	rollout, err := s.catalog.SetRolloutPercent(lang, req.Percent)
+	if err != nil {
+		return nil, imageRolloutError(err)
+	}
+	return rollout, nil
+}
+
+// PromoteRollout makes the candidate the stable image.
+func (s *DefaultSandboxImageAdminService) PromoteRollout(_ context.Context, language string) (*agent.LanguageImageStatus, *types.Error) {
+	lang, apiErr := s.languageFor(language)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	pin, err := s.catalog.PromoteRollout(lang)
+	if err != nil {
+		return nil, imageRolloutError(err)
+	}
+	return &agent.LanguageImageStatus{Language: lang, Stable: pin}, nil
+}
+
+// AbortRollout routes all executions back to the stable image.
+func (s *DefaultSandboxImageAdminService) AbortRollout(_ context.Context, language string) *types.Error {
+	lang, apiErr := s.languageFor(language)
+	if apiErr != nil {
+		return apiErr
+	}
+	if err := s.catalog.AbortRollout(lang); err != nil {
+		return imageRolloutError(err)
+	}
+	return nil
+}
+
+func (s *DefaultSandboxImageAdminService) languageFor(language string) (agent.Language, *types.Error) {
+	if s.catalog == nil {
+		return "", types.NewInternalError("sandbox image catalog is not configured")
+	}
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return "", types.NewInvalidRequestError("language is required")
+	}
+	lang := agent.Language(language)
+	if _, ok := s.catalog.Resolve(lang, ""); !ok {
+		return "", types.NewNotFoundError("no image pinned for language " + language)
+	}
+	return lang, nil
+}
+
+func imageRolloutError(err error) *types.Error {
+	if errors.Is(err, agent.ErrNoImageRollout) {
+		return types.NewNotFoundError(err.Error())
+	}
+	return types.NewInvalidRequestError(err.Error())
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDigestResolver struct {
+	digests map[string][]string
+}
+
+func (r *stubDigestResolver) RepoDigests(_ context.Context, reference string) ([]string, error) {
+	digests, ok := r.digests[reference]
+	if !ok {
+		return nil, errors.New("no such image")
+	}
+	return digests, nil
+}
+
+func sandboxDigest(seed string) string {
+	return "sha256:" + strings.Repeat(seed, 64)
+}
+
+func newSandboxImageAdmin(t *testing.T, resolver agent.DigestResolver) *DefaultSandboxImageAdminService {
+	t.Helper()
+	catalog, err := agent.NewImageCatalog(map[agent.Language]agent.ImagePin{
+		agent.LangPython: {Image: "python:3.12-slim", Digest: sandboxDigest("a")},
+	})
+	require.NoError(t, err)
+	return NewDefaultSandboxImageAdminService(catalog, resolver)
+}
+
+func TestSandboxImageAdminService_RolloutLifecycle(t *testing.T) {
+	resolver := &stubDigestResolver{digests: map[string][]string{
+		"python@" + sandboxDigest("b"): {"python@" + sandboxDigest("b")},
+	}}
+	svc := newSandboxImageAdmin(t, resolver)
+	ctx := context.Background()
+
+	rollout, err := svc.StartRollout(ctx, "Python", StartImageRolloutRequest{Image: "python:3.13-slim", Digest: sandboxDigest("b"), Percent: 10})
+	require.Nil(t, err)
+	assert.Equal(t, 10, rollout.Percent)
+
+	rollout, err = svc.UpdateRollout(ctx, "python", UpdateImageRolloutRequest{Percent: 50})
+	require.Nil(t, err)
+	assert.Equal(t, 50, rollout.Percent)
+
+	status, err := svc.PromoteRollout(ctx, "python")
+	require.Nil(t, err)
+	assert.Equal(t, sandboxDigest("b"), status.Stable.Digest)
+
+	images := svc.ListImages(ctx)
+	require.Len(t, images, 1)
+	assert.Nil(t, images[0].Rollout)
+}
+
+func TestSandboxImageAdminService_Errors(t *testing.T) {
+	svc := newSandboxImageAdmin(t, &stubDigestResolver{})
+	ctx := context.Background()
+
+	// 本地不存在的候选镜像不允许灰度
+	_, err := svc.StartRollout(ctx, "python", StartImageRolloutRequest{Image: "python", Digest: sandboxDigest("c"), Percent: 10})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = svc.StartRollout(ctx, "python", StartImageRolloutRequest{Image: "python:latest", Percent: 10})
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInvalidRequest, err.Code)
+
+	_, err = svc.UpdateRollout(ctx, "rust", UpdateImageRolloutRequest{Percent: 10})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+
+	err = svc.AbortRollout(ctx, "python")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus)
+
+	_, err = NewDefaultSandboxImageAdminService(nil, nil).PromoteRollout(ctx, "python")
+	require.NotNil(t, err)
+	assert.Equal(t, types.ErrInternalError, err.Code)
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	usageDateLayout      = "2006-01-02"
+	defaultUsageRangeDay = 30
+	maxUsageRangeDay     = 366
+)
+
+// UsageBucketView is the usage aggregated for one day/tenant/provider/model.
+type UsageBucketView struct {
+	Day              string  `json:"day"`
+	TenantID         string  `json:"tenant_id"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Requests         int64   `json:"requests"`
+	CacheHits        int64   `json:"cache_hits"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CacheSavingsUSD  float64 `json:"cache_savings_usd"`
+}
+
+// UsageTotalsView sums all buckets of a usage report.
+type UsageTotalsView struct {
+	Requests         int64   `json:"requests"`
+	CacheHits        int64   `json:"cache_hits"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CacheSavingsUSD  float64 `json:"cache_savings_usd"`
+}
+
+// UsageReportView is the response of a usage query.
+type UsageReportView struct {
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Buckets []UsageBucketView `json:"buckets"`
+	Totals  UsageTotalsView   `json:"totals"`
+}
+
+// UsageFilter is the normalized filter passed to UsageReader.
+// From/To are inclusive UTC days.
+type UsageFilter struct {
+	TenantID string
+	Provider string
+	Model    string
+	From     time.Time
+	To       time.Time
+}
+
+// UsageReader abstracts the usage ledger store needed by UsageQueryService.
+// This decouples the usecase layer from llm/observability.
+type UsageReader interface {
+	QueryUsage(ctx context.Context, filter UsageFilter) ([]UsageBucketView, error)
+}
+
+// UsageQueryInput carries raw query parameters; From/To use YYYY-MM-DD.
+type UsageQueryInput struct {
+	TenantID string
+	Provider string
+	Model    string
+	From     string
+	To       string
+}
+
+// UsageQueryService provides usage and billing reports for the API layer.
+type UsageQueryService interface {
+	// Query returns daily usage buckets and totals. When the range is omitted
+	// it defaults to the last 30 days ending today (UTC).
+	Query(ctx context.Context, input UsageQueryInput) (*UsageReportView, *types.Error)
+}
+
+// DefaultUsageQueryService is the default implementation of UsageQueryService.
+type DefaultUsageQueryService struct {
+	reader UsageReader
+	now    func() time.Time
+}
+
+// NewDefaultUsageQueryService creates a new UsageQueryService with the given reader.
+func NewDefaultUsageQueryService(reader UsageReader) *DefaultUsageQueryService {
+	return &DefaultUsageQueryService{reader: reader, now: time.Now}
+}
+
+// Query returns daily usage buckets and totals.
+func (s *DefaultUsageQueryService) Query(ctx context.Context, input UsageQueryInput) (*UsageReportView, *types.Error) {
+	if s.reader == nil {
+		return nil, types.NewInternalError("usage ledger is not configured")
+	}
+	from, to, err := s.resolveUsageRange(input.From, input.To)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, queryErr := s.reader.QueryUsage(ctx, UsageFilter{
+		TenantID: strings.TrimSpace(input.TenantID),
+		Provider: strings.TrimSpace(input.Provider),
+		Model:    strings.TrimSpace(input.Model),
+		From:     from,
+		To:       to,
+	})
+	if queryErr != nil {
+		return nil, types.NewInternalError("failed to query usage").WithCause(queryErr)
+	}
+	if buckets == nil {
+		buckets = []UsageBucketView{}
+	}
+
+	report := &UsageReportView{
+		From:    from.Format(usageDateLayout),
+		To:      to.Format(usageDateLayout),
+		Buckets: buckets,
+	}
+	for _, b := range buckets {
+		report.Totals.Requests += b.Requests
+		report.Totals.CacheHits += b.CacheHits
+		report.Totals.PromptTokens += b.PromptTokens
+		report.Totals.CompletionTokens += b.CompletionTokens
+		report.Totals.TotalTokens += b.TotalTokens
+		report.Totals.CachedTokens += b.CachedTokens
+		report.Totals.CostUSD += b.CostUSD
+		report.Totals.CacheSavingsUSD += b.CacheSavingsUSD
+	}
+	return report, nil
+}
+
+func (s *DefaultUsageQueryService) resolveUsageRange(rawFrom, rawTo string) (time.Time, time.Time, *types.Error) {
+	to, err := parseUsageDate(rawTo, "to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to.IsZero() {
+		now := s.now().UTC()
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	from, err := parseUsageDate(rawFrom, "from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -(defaultUsageRangeDay - 1))
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, types.NewInvalidRequestError("from must not be after to")
+	}
+	if to.Sub(from) >= maxUsageRangeDay*24*time.Hour {
+		return time.Time{}, time.Time{}, types.NewInvalidRequestError("date range must not exceed 366 days")
+	}
+	return from, to, nil
+}
+
+func parseUsageDate(raw, field string) (time.Time, *types.Error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(usageDateLayout, raw)
+	if err != nil {
+		return time.Time{}, types.NewInvalidRequestError(field + " must be a date in YYYY-MM-DD format")
+	}
+	return t, nil
+}
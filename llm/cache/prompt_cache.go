@@ -46,6 +46,10 @@ type CacheConfig struct {
 	EnableRedis     bool               // 是否启用 Redis 缓存
 	KeyStrategyType string             // 缓存键策略类型：hash | hierarchical
 	CacheableCheck  func(req any) bool // 判断请求是否可缓存
+	IsolateByUser   bool               // 是否在租户作用域内再按用户隔离缓存
+
+	// TenantPolicies 租户级缓存策略（按租户 ID 索引），未配置的租户沿用全局设置
+	TenantPolicies map[string]TenantCachePolicy
 }
 
 // DefaultCacheConfig 默认配置
@@ -90,6 +94,9 @@ type MultiLevelCache struct {
 	config   *CacheConfig
 	strategy KeyStrategy // 缓存键生成策略
 	logger   *zap.Logger
+
+	policyMu       sync.RWMutex
+	tenantPolicies map[string]TenantCachePolicy
 }
 
 // NewMultiLevelCache 创建多级缓存
@@ -114,12 +121,18 @@ func NewMultiLevelCache(rdb *redis.Client, config *CacheConfig, logger *zap.Logg
 		logger.Info("using hash cache key strategy")
 	}
 
+	policies := make(map[string]TenantCachePolicy, len(config.TenantPolicies))
+	for tenantID, policy := range config.TenantPolicies {
+		policies[tenantID] = policy
+	}
+
 	return &MultiLevelCache{
-		local:    local,
-		redis:    rdb,
-		config:   config,
-		strategy: strategy,
-		logger:   logger,
+		local:          local,
+		redis:          rdb,
+		config:         config,
+		strategy:       strategy,
+		logger:         logger,
+		tenantPolicies: policies,
 	}
 }
 
@@ -159,12 +172,21 @@ func (c *MultiLevelCache) Get(ctx context.Context, key string) (*CacheEntry, err
 
 // Set 设置缓存
 func (c *MultiLevelCache) Set(ctx context.Context, key string, entry *CacheEntry) error {
+	redisTTL := c.config.RedisTTL
+	var localTTL time.Duration
+	if tenantID, ok := tenantFromKey(key); ok {
+		if policy, ok := c.TenantPolicy(tenantID); ok && policy.TTL > 0 {
+			redisTTL = policy.TTL
+			localTTL = policy.TTL
+		}
+	}
+
 	entry.CreatedAt = time.Now()
-	entry.ExpiresAt = time.Now().Add(c.config.RedisTTL)
+	entry.ExpiresAt = time.Now().Add(redisTTL)
 
 	// 1. 写本地缓存
 	if c.config.EnableLocal && c.local != nil {
-		c.local.Set(key, entry)
+		c.local.SetWithTTL(key, entry, localTTL)
 	}
 
 	// 2. 写 Redis 缓存
@@ -173,7 +195,7 @@ func (c *MultiLevelCache) Set(ctx context.Context, key string, entry *CacheEntry
 		if err != nil {
 			return err
 		}
-		if err := c.redis.Set(ctx, c.redisKey(key), data, c.config.RedisTTL).Err(); err != nil {
+		if err := c.redis.Set(ctx, c.redisKey(key), data, redisTTL).Err(); err != nil {
 			c.logger.Warn("redis set error", zap.Error(err))
 			return err
 		}
@@ -201,6 +223,7 @@ func (c *MultiLevelCache) Delete(ctx context.Context, key string) error {
 }
 
 // GenerateKey 生成缓存键（使用策略模式）
+// ChatRequest 的缓存键始终带有租户作用域（IsolateByUser 时再加用户作用域）。
 func (c *MultiLevelCache) GenerateKey(req any) string {
 	// 尝试转换为 ChatRequest
 	chatReq, ok := req.(*llmpkg.ChatRequest)
@@ -214,11 +237,16 @@ func (c *MultiLevelCache) GenerateKey(req any) string {
 		return "llm:cache:" + hex.EncodeToString(hash[:16])
 	}
 
-	return c.strategy.GenerateKey(chatReq)
+	return scopeKey(chatReq, c.strategy.GenerateKey(chatReq), c.config.IsolateByUser)
 }
 
 // IsCacheable 判断请求是否可缓存
 func (c *MultiLevelCache) IsCacheable(req any) bool {
+	if tenantID, ok := tenantIDOf(req); ok {
+		if policy, ok := c.TenantPolicy(tenantID); ok && policy.Disabled {
+			return false
+		}
+	}
 	if c.config.CacheableCheck != nil {
 		return c.config.CacheableCheck(req)
 	}
@@ -265,6 +293,69 @@ func (c *MultiLevelCache) InvalidateByVersion(ctx context.Context, promptVersion
 	return nil
 }
 
+// TenantPolicy 返回租户的缓存策略
+func (c *MultiLevelCache) TenantPolicy(tenantID string) (TenantCachePolicy, bool) {
+	c.policyMu.RLock()
+	defer c.policyMu.RUnlock()
+	policy, ok := c.tenantPolicies[tenantID]
+	return policy, ok
+}
+
+// SetTenantPolicy 设置租户的缓存策略，运行时生效
+func (c *MultiLevelCache) SetTenantPolicy(tenantID string, policy TenantCachePolicy) {
+	c.policyMu.Lock()
+	defer c.policyMu.Unlock()
+	c.tenantPolicies[tenantID] = policy
+}
+
+// PurgeTenant 清除租户的全部缓存条目（本地与 Redis），返回被清除的缓存键数量。
+func (c *MultiLevelCache) PurgeTenant(ctx context.Context, tenantID string) (int, error) {
+	prefix := tenantScopePrefix(tenantID)
+	purged := make(map[string]struct{})
+
+	if c.local != nil {
+		for _, key := range c.local.DeleteByPrefix(prefix) {
+			purged[key] = struct{}{}
+		}
+	}
+
+	if c.config.EnableRedis && c.redis != nil {
+		iter := c.redis.Scan(ctx, 0, c.redisKey(prefix+"*"), 500).Iterator()
+		batch := make([]string, 0, 500)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			if err := c.redis.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+			return nil
+		}
+		for iter.Next(ctx) {
+			redisKey := iter.Val()
+			batch = append(batch, redisKey)
+			purged[strings.TrimPrefix(redisKey, "llm:prompt_cache:")] = struct{}{}
+			if len(batch) == cap(batch) {
+				if err := flush(); err != nil {
+					return len(purged), err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return len(purged), err
+		}
+		if err := flush(); err != nil {
+			return len(purged), err
+		}
+	}
+
+	c.logger.Info("tenant cache purged",
+		zap.String("tenant_id", tenantID),
+		zap.Int("keys", len(purged)))
+	return len(purged), nil
+}
+
 // Warmup 预热本地缓存：从 Redis 加载访问频率最高的条目到本地 LRU。
 func (c *MultiLevelCache) Warmup(ctx context.Context, maxKeys int) error {
 	if c.redis == nil || c.local == nil || maxKeys <= 0 {
@@ -346,13 +437,22 @@ func (c *LRUCache) Get(key string) (*CacheEntry, bool) {
 }
 
 func (c *LRUCache) Set(key string, entry *CacheEntry) {
+	c.SetWithTTL(key, entry, 0)
+}
+
+// SetWithTTL 写入条目，ttl 仅在短于缓存默认 TTL 时生效
+func (c *LRUCache) SetWithTTL(key string, entry *CacheEntry, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+
 	// 如果已存在，更新并移动到头部
 	if node, ok := c.items[key]; ok {
 		node.entry = entry
-		node.expiresAt = time.Now().Add(c.ttl)
+		node.expiresAt = time.Now().Add(ttl)
 		c.moveToHead(node)
 		return
 	}
@@ -366,7 +466,7 @@ func (c *LRUCache) Set(key string, entry *CacheEntry) {
 	node := &lruNode{
 		key:       key,
 		entry:     entry,
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: time.Now().Add(ttl),
 	}
 	c.items[key] = node
 	c.addToHead(node)
@@ -382,6 +482,22 @@ func (c *LRUCache) Delete(key string) {
 	}
 }
 
+// DeleteByPrefix 删除所有以 prefix 开头的条目，返回被删除的键
+func (c *LRUCache) DeleteByPrefix(prefix string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var deleted []string
+	for key, node := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeNode(node)
+			delete(c.items, key)
+			deleted = append(deleted, key)
+		}
+	}
+	return deleted
+}
+
 func (c *LRUCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
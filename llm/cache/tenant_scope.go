@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+)
+
+// tenantKeyPrefix 租户作用域缓存键前缀
+// 格式：llm:cache:t:{tenantID}[:u:{userID}]:{strategyKey}
+// 所有 ChatRequest 生成的缓存键都带有租户作用域，避免相同 prompt 在租户间串用响应。
+const tenantKeyPrefix = "llm:cache:t:"
+
+// TenantCachePolicy 租户级缓存策略
+type TenantCachePolicy struct {
+	Disabled bool          // 是否禁用该租户的响应缓存
+	TTL      time.Duration // 该租户的缓存 TTL，<=0 时使用全局 TTL
+}
+
+// keySegmentEscaper 转义键分隔符与 Redis SCAN 通配符，保证按前缀清理时不会误伤其他租户
+var keySegmentEscaper = strings.NewReplacer(
+	"%", "%25",
+	":", "%3A",
+	"*", "%2A",
+	"?", "%3F",
+	"[", "%5B",
+	"]", "%5D",
+	"\\", "%5C",
+)
+
+func escapeKeySegment(s string) string {
+	return keySegmentEscaper.Replace(s)
+}
+
+// tenantScopePrefix 返回租户作用域前缀（包含末尾分隔符）
+func tenantScopePrefix(tenantID string) string {
+	return tenantKeyPrefix + escapeKeySegment(tenantID) + ":"
+}
+
+// scopeKey 为策略生成的缓存键加上租户（以及可选的用户）作用域
+func scopeKey(req *llmpkg.ChatRequest, strategyKey string, isolateByUser bool) string {
+	scope := tenantScopePrefix(req.TenantID)
+	if isolateByUser {
+		scope += "u:" + escapeKeySegment(req.UserID) + ":"
+	}
+	return scope + strings.TrimPrefix(strategyKey, "llm:cache:")
+}
+
+// tenantFromKey 从作用域缓存键中解析租户 ID
+func tenantFromKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, tenantKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	segment, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", false
+	}
+	tenantID, err := url.PathUnescape(segment)
+	if err != nil {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// tenantIDOf 提取请求的租户 ID
+func tenantIDOf(req any) (string, bool) {
+	chatReq, ok := req.(*llmpkg.ChatRequest)
+	if !ok || chatReq == nil {
+		return "", false
+	}
+	return chatReq.TenantID, true
+}
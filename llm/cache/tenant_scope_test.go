@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func tenantRequest(tenantID, userID string) *llmpkg.ChatRequest {
+	return &llmpkg.ChatRequest{
+		TenantID: tenantID,
+		UserID:   userID,
+		Model:    "gpt-4",
+		Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: "hello"}},
+	}
+}
+
+func TestMultiLevelCache_GenerateKey_TenantScoped(t *testing.T) {
+	for _, strategy := range []string{"hash", "hierarchical"} {
+		t.Run(strategy, func(t *testing.T) {
+			c := NewMultiLevelCache(nil, &CacheConfig{KeyStrategyType: strategy}, zap.NewNop())
+
+			keyA := c.GenerateKey(tenantRequest("tenant-a", "u1"))
+			keyB := c.GenerateKey(tenantRequest("tenant-b", "u1"))
+			assert.NotEqual(t, keyA, keyB, "identical prompts from different tenants must not share a key")
+
+			tenantID, ok := tenantFromKey(keyA)
+			require.True(t, ok)
+			assert.Equal(t, "tenant-a", tenantID)
+		})
+	}
+}
+
+func TestMultiLevelCache_GenerateKey_IsolateByUser(t *testing.T) {
+	c := NewMultiLevelCache(nil, &CacheConfig{KeyStrategyType: "hierarchical", IsolateByUser: true}, zap.NewNop())
+
+	assert.NotEqual(t, c.GenerateKey(tenantRequest("tenant-a", "u1")), c.GenerateKey(tenantRequest("tenant-a", "u2")))
+}
+
+func TestTenantScopePrefix_EscapesSeparators(t *testing.T) {
+	// 租户 "a" 的前缀不能匹配到租户 "a:b" 或 "a*" 的键
+	keyAB := scopeKey(&llmpkg.ChatRequest{TenantID: "a:b"}, "llm:cache:x", false)
+	keyGlob := scopeKey(&llmpkg.ChatRequest{TenantID: "a*"}, "llm:cache:x", false)
+	assert.NotContains(t, keyAB, tenantScopePrefix("a"))
+	assert.NotContains(t, keyGlob, tenantScopePrefix("a"))
+
+	tenantID, ok := tenantFromKey(keyAB)
+	require.True(t, ok)
+	assert.Equal(t, "a:b", tenantID)
+}
+
+func TestMultiLevelCache_TenantPolicies(t *testing.T) {
+	c := NewMultiLevelCache(nil, &CacheConfig{
+		EnableLocal:  true,
+		LocalMaxSize: 10,
+		LocalTTL:     time.Hour,
+		RedisTTL:     time.Hour,
+		TenantPolicies: map[string]TenantCachePolicy{
+			"no-cache": {Disabled: true},
+			"short":    {TTL: 10 * time.Millisecond},
+		},
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	assert.False(t, c.IsCacheable(tenantRequest("no-cache", "")))
+	assert.True(t, c.IsCacheable(tenantRequest("other", "")))
+
+	shortKey := c.GenerateKey(tenantRequest("short", ""))
+	require.NoError(t, c.Set(ctx, shortKey, &CacheEntry{TokensSaved: 1}))
+	_, err := c.Get(ctx, shortKey)
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = c.Get(ctx, shortKey)
+	assert.Error(t, err, "tenant TTL should expire the entry")
+
+	c.SetTenantPolicy("other", TenantCachePolicy{Disabled: true})
+	assert.False(t, c.IsCacheable(tenantRequest("other", "")))
+}
+
+func TestMultiLevelCache_PurgeTenant(t *testing.T) {
+	c, mr := newTestMultiLevelCache(t, &CacheConfig{
+		EnableLocal:  true,
+		EnableRedis:  true,
+		LocalMaxSize: 10,
+		LocalTTL:     time.Hour,
+		RedisTTL:     time.Hour,
+	})
+	ctx := context.Background()
+
+	keyA := c.GenerateKey(tenantRequest("tenant-a", ""))
+	keyB := c.GenerateKey(tenantRequest("tenant-b", ""))
+	require.NoError(t, c.Set(ctx, keyA, &CacheEntry{TokensSaved: 1}))
+	require.NoError(t, c.Set(ctx, keyB, &CacheEntry{TokensSaved: 2}))
+
+	purged, err := c.PurgeTenant(ctx, "tenant-a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = c.Get(ctx, keyA)
+	assert.Error(t, err)
+	assert.False(t, mr.Exists(c.redisKey(keyA)))
+
+	got, err := c.Get(ctx, keyB)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.TokensSaved)
+}
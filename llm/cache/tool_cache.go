@@ -255,6 +255,7 @@ type CachingToolExecutor struct {
 	executor tools.ToolExecutor
 	cache    *ToolResultCache
 	logger   *zap.Logger
+	bypass   map[string]struct{} // 依据工具声明不可缓存的工具
 }
 
 // NewCachingToolExecutor 创建缓存工具执行器.
@@ -266,6 +267,26 @@ func NewCachingToolExecutor(executor tools.ToolExecutor, cache *ToolResultCache,
 	}
 }
 
+// WithToolSchemas 依据工具 Traits 声明跳过不可缓存的工具（声明了副作用的工具既不读也不写缓存）；
+// 未声明 Traits 的工具仍按 ToolCacheConfig 处理.
+func (e *CachingToolExecutor) WithToolSchemas(schemas []types.ToolSchema) *CachingToolExecutor {
+	for _, schema := range schemas {
+		if schema.Traits.Cacheable() {
+			continue
+		}
+		if e.bypass == nil {
+			e.bypass = make(map[string]struct{})
+		}
+		e.bypass[schema.Name] = struct{}{}
+	}
+	return e
+}
+
+func (e *CachingToolExecutor) cacheable(toolName string) bool {
+	_, skip := e.bypass[toolName]
+	return !skip
+}
+
 // Execute 使用缓存执行工具调用.
 func (e *CachingToolExecutor) Execute(ctx context.Context, calls []types.ToolCall) []tools.ToolResult {
 	results := make([]tools.ToolResult, len(calls))
@@ -274,6 +295,11 @@ func (e *CachingToolExecutor) Execute(ctx context.Context, calls []types.ToolCal
 
 	// 检查每次调用的缓存
 	for i, call := range calls {
+		if !e.cacheable(call.Name) {
+			uncachedCalls = append(uncachedCalls, call)
+			uncachedIndices = append(uncachedIndices, i)
+			continue
+		}
 		if cached, ok := e.cache.Get(call.Name, call.Arguments); ok {
 			results[i] = tools.ToolResult{
 				ToolCallID: call.ID,
@@ -303,7 +329,9 @@ func (e *CachingToolExecutor) Execute(ctx context.Context, calls []types.ToolCal
 			}
 
 			// 缓存结果
-			e.cache.Set(execResult.Name, uncachedCalls[j].Arguments, execResult.Result, execResult.Error)
+			if e.cacheable(execResult.Name) {
+				e.cache.Set(execResult.Name, uncachedCalls[j].Arguments, execResult.Result, execResult.Error)
+			}
 		}
 	}
 
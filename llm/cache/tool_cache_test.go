@@ -282,6 +282,42 @@ func TestCachingToolExecutor_MixedCacheHitMiss(t *testing.T) {
 	assert.False(t, results[1].FromCache)
 }
 
+func TestCachingToolExecutor_BypassesDeclaredSideEffects(t *testing.T) {
+	cache := NewToolResultCache(DefaultToolCacheConfig(), nil)
+	execCount := 0
+	executor := &mockToolExecutor{
+		executeFn: func(ctx context.Context, calls []types.ToolCall) []tools.ToolResult {
+			execCount += len(calls)
+			results := make([]tools.ToolResult, len(calls))
+			for i, c := range calls {
+				results[i] = tools.ToolResult{ToolCallID: c.ID, Name: c.Name, Result: json.RawMessage(`{}`)}
+			}
+			return results
+		},
+	}
+
+	cachingExec := NewCachingToolExecutor(executor, cache, nil).WithToolSchemas([]types.ToolSchema{
+		{Name: "send_email", Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectExternal, Idempotent: true}},
+		{Name: "lookup", Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectNone}},
+		{Name: "legacy"},
+	})
+
+	calls := []types.ToolCall{
+		{ID: "c1", Name: "send_email", Arguments: json.RawMessage(`{"to":"a"}`)},
+		{ID: "c2", Name: "lookup", Arguments: json.RawMessage(`{"q":"a"}`)},
+		{ID: "c3", Name: "legacy", Arguments: json.RawMessage(`{"q":"a"}`)},
+	}
+	cachingExec.Execute(context.Background(), calls)
+	results := cachingExec.Execute(context.Background(), calls)
+	require.Len(t, results, 3)
+
+	assert.False(t, results[0].FromCache)
+	assert.True(t, results[1].FromCache)
+	assert.True(t, results[2].FromCache)
+	assert.Equal(t, 4, execCount)
+	assert.Equal(t, 2, cache.Stats().Size)
+}
+
 // ====== HashKeyStrategy Additional Tests ======
 
 func TestHashKeyStrategy_DifferentRequestsDifferentKeys(t *testing.T) {
@@ -0,0 +1,275 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/observability"
+	pkgcache "github.com/BaSui01/agentflow/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// WarmupCandidate 是一条可回放的历史请求
+type WarmupCandidate struct {
+	Fingerprint string
+	Request     *llmpkg.ChatRequest
+	Count       int64
+}
+
+// WarmupSource 按出现频率提供历史请求，通常由可观测性存储实现
+type WarmupSource interface {
+	TopPrompts(ctx context.Context, n int) ([]WarmupCandidate, error)
+}
+
+// WarmupGenerator 为回放请求生成响应，通常直接调用未经缓存的 provider
+type WarmupGenerator func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error)
+
+// trafficWarmupSource 将 observability.PromptTrafficStore 适配为 WarmupSource
+type trafficWarmupSource struct {
+	store *observability.PromptTrafficStore
+}
+
+// NewTrafficWarmupSource 以网关采样的生产流量作为预热来源
+func NewTrafficWarmupSource(store *observability.PromptTrafficStore) WarmupSource {
+	return &trafficWarmupSource{store: store}
+}
+
+func (s *trafficWarmupSource) TopPrompts(_ context.Context, n int) ([]WarmupCandidate, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	samples := s.store.Top(n)
+	out := make([]WarmupCandidate, 0, len(samples))
+	for _, sample := range samples {
+		if sample.Request == nil {
+			continue
+		}
+		out = append(out, WarmupCandidate{Fingerprint: sample.Fingerprint, Request: sample.Request, Count: sample.Count})
+	}
+	return out, nil
+}
+
+// CacheWarmerConfig 缓存预热配置
+type CacheWarmerConfig struct {
+	TopN        int // 每轮回放的请求数，默认 100
+	Concurrency int // 并发生成数，默认 4
+
+	// ModelVersion 写入预热条目的模型版本；Regenerate 为 true 时，
+	// 版本不一致（含未标记版本）的已有条目会被重新生成，用于模型升级后刷新缓存
+	ModelVersion string
+	Regenerate   bool
+
+	// 低峰窗口（本地时间整点，[OffPeakStartHour, OffPeakEndHour)，可跨零点）；
+	// 起止相同表示全天可运行。每个窗口最多运行一轮
+	OffPeakStartHour int
+	OffPeakEndHour   int
+	CheckInterval    time.Duration // 调度检查间隔，默认 10 分钟
+}
+
+// WarmupReport 一轮预热的结果
+type WarmupReport struct {
+	Candidates  int           `json:"candidates"`
+	Warmed      int           `json:"warmed"`
+	Regenerated int           `json:"regenerated"`
+	Skipped     int           `json:"skipped"` // 已命中且无需再生成，或请求不可缓存
+	Failed      int           `json:"failed"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// CacheWarmer 在低峰期回放高频历史请求以预热缓存，避免发布后冷缓存带来的延迟与成本尖峰
+type CacheWarmer struct {
+	cache    *MultiLevelCache
+	source   WarmupSource
+	generate WarmupGenerator
+	config   CacheWarmerConfig
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+	now     func() time.Time
+}
+
+// NewCacheWarmer 创建缓存预热器
+func NewCacheWarmer(c *MultiLevelCache, source WarmupSource, generate WarmupGenerator, config CacheWarmerConfig, logger *zap.Logger) *CacheWarmer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if config.TopN <= 0 {
+		config.TopN = 100
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 10 * time.Minute
+	}
+	return &CacheWarmer{
+		cache:    c,
+		source:   source,
+		generate: generate,
+		config:   config,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// ErrWarmupInProgress 表示已有一轮预热在运行
+var ErrWarmupInProgress = errors.New("cache warmup already in progress")
+
+// WarmOnce 立即执行一轮预热
+func (w *CacheWarmer) WarmOnce(ctx context.Context) (WarmupReport, error) {
+	if w.cache == nil || w.source == nil || w.generate == nil {
+		return WarmupReport{}, errors.New("cache warmer requires cache, source and generator")
+	}
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return WarmupReport{}, ErrWarmupInProgress
+	}
+	w.running = true
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.running = false
+		w.mu.Unlock()
+	}()
+
+	start := w.now()
+	candidates, err := w.source.TopPrompts(ctx, w.config.TopN)
+	if err != nil {
+		return WarmupReport{}, err
+	}
+
+	report := WarmupReport{Candidates: len(candidates)}
+	var reportMu sync.Mutex
+	count := func(field *int) {
+		reportMu.Lock()
+		*field++
+		reportMu.Unlock()
+	}
+
+	sem := make(chan struct{}, w.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(candidate WarmupCandidate) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			count(w.warmCandidate(ctx, candidate, &report))
+		}(candidate)
+	}
+	wg.Wait()
+
+	report.Duration = w.now().Sub(start)
+	w.mu.Lock()
+	w.lastRun = start
+	w.mu.Unlock()
+	w.logger.Info("cache warmup completed",
+		zap.Int("candidates", report.Candidates),
+		zap.Int("warmed", report.Warmed),
+		zap.Int("regenerated", report.Regenerated),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("failed", report.Failed),
+		zap.Duration("duration", report.Duration))
+	return report, ctx.Err()
+}
+
+// warmCandidate 预热单条请求，返回应计数的报告字段
+func (w *CacheWarmer) warmCandidate(ctx context.Context, candidate WarmupCandidate, report *WarmupReport) *int {
+	req := candidate.Request
+	if req == nil || !w.cache.IsCacheable(req) {
+		return &report.Skipped
+	}
+	key := w.cache.GenerateKey(req)
+	if key == "" {
+		return &report.Skipped
+	}
+
+	regenerate := false
+	entry, err := w.cache.Get(ctx, key)
+	switch {
+	case err == nil && entry != nil:
+		if !w.config.Regenerate || entry.ModelVersion == w.config.ModelVersion {
+			return &report.Skipped
+		}
+		regenerate = true
+	case err != nil && !errors.Is(err, pkgcache.ErrCacheMiss):
+		w.logger.Warn("cache warmup lookup failed", zap.String("fingerprint", candidate.Fingerprint), zap.Error(err))
+	}
+
+	replay := *req
+	resp, err := w.generate(ctx, &replay)
+	if err != nil || resp == nil {
+		w.logger.Warn("cache warmup generation failed", zap.String("fingerprint", candidate.Fingerprint), zap.Error(err))
+		return &report.Failed
+	}
+	if err := w.cache.Set(ctx, key, &CacheEntry{
+		Response:     resp,
+		TokensSaved:  resp.Usage.TotalTokens,
+		ModelVersion: w.config.ModelVersion,
+	}); err != nil {
+		w.logger.Warn("cache warmup store failed", zap.String("fingerprint", candidate.Fingerprint), zap.Error(err))
+		return &report.Failed
+	}
+	if regenerate {
+		return &report.Regenerated
+	}
+	return &report.Warmed
+}
+
+// Start 按配置的低峰窗口周期性预热，直到 ctx 取消
+func (w *CacheWarmer) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+
+	w.logger.Info("cache warmer started",
+		zap.Int("off_peak_start_hour", w.config.OffPeakStartHour),
+		zap.Int("off_peak_end_hour", w.config.OffPeakEndHour))
+	w.runIfDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("cache warmer stopped")
+			return
+		case <-ticker.C:
+			w.runIfDue(ctx)
+		}
+	}
+}
+
+func (w *CacheWarmer) runIfDue(ctx context.Context) {
+	if !w.due(w.now()) {
+		return
+	}
+	if _, err := w.WarmOnce(ctx); err != nil && !errors.Is(err, ErrWarmupInProgress) {
+		w.logger.Warn("scheduled cache warmup failed", zap.Error(err))
+	}
+}
+
+// due 判断当前是否处于低峰窗口且本窗口尚未运行
+func (w *CacheWarmer) due(now time.Time) bool {
+	start := ((w.config.OffPeakStartHour % 24) + 24) % 24
+	end := ((w.config.OffPeakEndHour % 24) + 24) % 24
+	window := time.Duration((end-start+24)%24) * time.Hour
+	if window == 0 {
+		window = 24 * time.Hour
+	}
+	elapsed := time.Duration((now.Hour()-start+24)%24)*time.Hour +
+		time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
+	if elapsed >= window {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastRun.IsZero() || now.Sub(w.lastRun) >= window
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newWarmupTestCache() *MultiLevelCache {
+	return NewMultiLevelCache(nil, &CacheConfig{LocalMaxSize: 16, LocalTTL: time.Minute, EnableLocal: true}, zap.NewNop())
+}
+
+func warmupRequest(content string) *llmpkg.ChatRequest {
+	return &llmpkg.ChatRequest{Model: "m", Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: content}}}
+}
+
+func TestCacheWarmer_WarmOnceReplaysTopPrompts(t *testing.T) {
+	c := newWarmupTestCache()
+	store := observability.NewPromptTrafficStore(0)
+	store.Record(warmupRequest("a"))
+	store.Record(warmupRequest("a"))
+	store.Record(warmupRequest("b"))
+	store.Record(warmupRequest("fails"))
+
+	var calls atomic.Int32
+	generate := func(_ context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		calls.Add(1)
+		if req.Messages[0].Content == "fails" {
+			return nil, errors.New("upstream down")
+		}
+		return &llmpkg.ChatResponse{Model: req.Model, Usage: llmpkg.ChatUsage{TotalTokens: 12}}, nil
+	}
+	warmer := NewCacheWarmer(c, NewTrafficWarmupSource(store), generate, CacheWarmerConfig{ModelVersion: "v1"}, zap.NewNop())
+
+	report, err := warmer.WarmOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Candidates)
+	assert.Equal(t, 2, report.Warmed)
+	assert.Equal(t, 1, report.Failed)
+
+	entry, err := c.Get(context.Background(), c.GenerateKey(warmupRequest("a")))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", entry.ModelVersion)
+	assert.Equal(t, 12, entry.TokensSaved)
+
+	// 已预热的条目再次运行时跳过
+	report, err = warmer.WarmOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestCacheWarmer_RegeneratesAfterModelUpgrade(t *testing.T) {
+	c := newWarmupTestCache()
+	key := c.GenerateKey(warmupRequest("a"))
+	require.NoError(t, c.Set(context.Background(), key, &CacheEntry{Response: &llmpkg.ChatResponse{Model: "old"}, ModelVersion: "v1"}))
+
+	store := observability.NewPromptTrafficStore(0)
+	store.Record(warmupRequest("a"))
+	generate := func(_ context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		return &llmpkg.ChatResponse{Model: "new"}, nil
+	}
+
+	skipping := NewCacheWarmer(c, NewTrafficWarmupSource(store), generate, CacheWarmerConfig{ModelVersion: "v2"}, zap.NewNop())
+	report, err := skipping.WarmOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Skipped)
+
+	regenerating := NewCacheWarmer(c, NewTrafficWarmupSource(store), generate, CacheWarmerConfig{ModelVersion: "v2", Regenerate: true}, zap.NewNop())
+	report, err = regenerating.WarmOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Regenerated)
+
+	entry, err := c.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", entry.ModelVersion)
+	assert.Equal(t, "new", entry.Response.(*llmpkg.ChatResponse).Model)
+}
+
+func TestCacheWarmer_DueOnlyOncePerOffPeakWindow(t *testing.T) {
+	warmer := NewCacheWarmer(newWarmupTestCache(), nil, nil, CacheWarmerConfig{OffPeakStartHour: 23, OffPeakEndHour: 3}, zap.NewNop())
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 1, day, hour, minute, 0, 0, time.Local) }
+
+	assert.False(t, warmer.due(at(1, 12, 0)), "outside window")
+	assert.True(t, warmer.due(at(1, 23, 30)))
+	assert.True(t, warmer.due(at(2, 2, 59)), "window wraps midnight")
+	assert.False(t, warmer.due(at(2, 3, 0)))
+
+	warmer.lastRun = at(1, 23, 30)
+	assert.False(t, warmer.due(at(2, 1, 0)), "already ran in this window")
+	assert.True(t, warmer.due(at(2, 23, 30)), "next night's window")
+}
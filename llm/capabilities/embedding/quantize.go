@@ -0,0 +1,151 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Int8Quantizer 逐维仿射标量量化，每维 1 字节（相对 float32 压缩 4 倍）.
+// 每维的 min/scale 由 FitInt8Quantizer 在样本上校准，入库与查询共享同一组参数。
+type Int8Quantizer struct {
+	min   []float64
+	scale []float64
+}
+
+// FitInt8Quantizer 根据样本各维的取值范围校准量化参数.
+func FitInt8Quantizer(samples [][]float64) (*Int8Quantizer, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("int8 quantizer requires at least 1 sample")
+	}
+	dims := len(samples[0])
+	lo := make([]float64, dims)
+	hi := make([]float64, dims)
+	for j := range lo {
+		lo[j], hi[j] = math.Inf(1), math.Inf(-1)
+	}
+	for i, s := range samples {
+		if len(s) != dims {
+			return nil, fmt.Errorf("%w: sample %d has %d dims, expected %d", ErrDimensionMismatch, i, len(s), dims)
+		}
+		for j, v := range s {
+			lo[j] = math.Min(lo[j], v)
+			hi[j] = math.Max(hi[j], v)
+		}
+	}
+	scale := make([]float64, dims)
+	for j := range scale {
+		scale[j] = (hi[j] - lo[j]) / 255
+	}
+	return &Int8Quantizer{min: lo, scale: scale}, nil
+}
+
+// Quantize 将向量量化为每维 1 字节；超出校准范围的值被截断.
+func (q *Int8Quantizer) Quantize(vec []float64) ([]byte, error) {
+	if len(vec) != len(q.min) {
+		return nil, fmt.Errorf("%w: int8 quantizer expects %d dims, got %d", ErrDimensionMismatch, len(q.min), len(vec))
+	}
+	code := make([]byte, len(vec))
+	for j, v := range vec {
+		if q.scale[j] == 0 {
+			continue
+		}
+		level := math.Round((v - q.min[j]) / q.scale[j])
+		code[j] = byte(math.Max(0, math.Min(255, level)))
+	}
+	return code, nil
+}
+
+func (q *Int8Quantizer) Dequantize(code []byte) ([]float64, error) {
+	if len(code) != len(q.min) {
+		return nil, fmt.Errorf("%w: int8 code has %d bytes, expected %d", ErrDimensionMismatch, len(code), len(q.min))
+	}
+	vec := make([]float64, len(code))
+	for j, c := range code {
+		vec[j] = q.min[j] + float64(c)*q.scale[j]
+	}
+	return vec, nil
+}
+
+func (q *Int8Quantizer) Spec() TransformSpec {
+	return TransformSpec{
+		Kind:       TransformInt8,
+		InputDims:  len(q.min),
+		OutputDims: len(q.min),
+		Min:        q.min,
+		Scale:      q.scale,
+	}
+}
+
+// BinaryQuantizer 按符号位量化，每维 1 bit（相对 float32 压缩 32 倍），
+// 适合用 HammingDistance 粗排后再用原始向量精排.
+type BinaryQuantizer struct {
+	dims int
+}
+
+// NewBinaryQuantizer 创建二值量化器.
+func NewBinaryQuantizer(dims int) (*BinaryQuantizer, error) {
+	if dims <= 0 {
+		return nil, fmt.Errorf("binary quantizer dims must be positive, got %d", dims)
+	}
+	return &BinaryQuantizer{dims: dims}, nil
+}
+
+func (q *BinaryQuantizer) Quantize(vec []float64) ([]byte, error) {
+	if len(vec) != q.dims {
+		return nil, fmt.Errorf("%w: binary quantizer expects %d dims, got %d", ErrDimensionMismatch, q.dims, len(vec))
+	}
+	code := make([]byte, (q.dims+7)/8)
+	for j, v := range vec {
+		if v > 0 {
+			code[j/8] |= 1 << (7 - uint(j%8))
+		}
+	}
+	return code, nil
+}
+
+// Dequantize 将每一位还原为 ±1.
+func (q *BinaryQuantizer) Dequantize(code []byte) ([]float64, error) {
+	if len(code) != (q.dims+7)/8 {
+		return nil, fmt.Errorf("%w: binary code has %d bytes, expected %d", ErrDimensionMismatch, len(code), (q.dims+7)/8)
+	}
+	vec := make([]float64, q.dims)
+	for j := range vec {
+		if code[j/8]&(1<<(7-uint(j%8))) != 0 {
+			vec[j] = 1
+		} else {
+			vec[j] = -1
+		}
+	}
+	return vec, nil
+}
+
+func (q *BinaryQuantizer) Spec() TransformSpec {
+	return TransformSpec{Kind: TransformBinary, InputDims: q.dims, OutputDims: q.dims}
+}
+
+// HammingDistance 返回两个二值编码不同的位数.
+func HammingDistance(a, b []byte) (int, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("%w: binary codes have %d and %d bytes", ErrDimensionMismatch, len(a), len(b))
+	}
+	dist := 0
+	for i := range a {
+		dist += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return dist, nil
+}
+
+func quantizerFromSpec(spec TransformSpec) (Quantizer, error) {
+	switch spec.Kind {
+	case TransformInt8:
+		if spec.InputDims == 0 || len(spec.Min) != spec.InputDims || len(spec.Scale) != spec.InputDims {
+			return nil, fmt.Errorf("invalid int8 spec: %d dims, %d min, %d scale", spec.InputDims, len(spec.Min), len(spec.Scale))
+		}
+		return &Int8Quantizer{min: spec.Min, scale: spec.Scale}, nil
+	case TransformBinary:
+		return NewBinaryQuantizer(spec.InputDims)
+	default:
+		return nil, fmt.Errorf("unsupported quantization %q", spec.Kind)
+	}
+}
@@ -0,0 +1,489 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 向量后处理错误。
+var (
+	ErrTransformMismatch = errors.New("embedding: transform does not match stored metadata")
+	ErrDimensionMismatch = errors.New("embedding: vector dimension mismatch")
+)
+
+// TransformKind 标识向量后处理类型.
+type TransformKind string
+
+const (
+	TransformMatryoshka TransformKind = "matryoshka"
+	TransformPCA        TransformKind = "pca"
+	TransformInt8       TransformKind = "int8"
+	TransformBinary     TransformKind = "binary"
+)
+
+// TransformSpec 是可序列化的变换参数.
+// 入库时随索引一起持久化，查询时据此重建完全相同的变换，避免训练/服务偏差。
+type TransformSpec struct {
+	Kind       TransformKind `json:"kind"`
+	InputDims  int           `json:"input_dims,omitempty"` // 0 表示不限（仅 Matryoshka）
+	OutputDims int           `json:"output_dims"`
+	Normalize  bool          `json:"normalize,omitempty"`
+	Mean       []float64     `json:"mean,omitempty"`       // PCA
+	Components [][]float64   `json:"components,omitempty"` // PCA，每行一个主成分
+	Min        []float64     `json:"min,omitempty"`        // int8
+	Scale      []float64     `json:"scale,omitempty"`      // int8
+}
+
+// VectorTransform 对浮点嵌入做确定性后处理（降维等）.
+type VectorTransform interface {
+	Apply(vec []float64) ([]float64, error)
+	OutputDims() int
+	Spec() TransformSpec
+}
+
+// Quantizer 将浮点向量压缩为紧凑的字节编码.
+type Quantizer interface {
+	Quantize(vec []float64) ([]byte, error)
+	Dequantize(code []byte) ([]float64, error)
+	Spec() TransformSpec
+}
+
+// =============================================================================
+// Matryoshka 截断
+// =============================================================================
+
+// MatryoshkaTruncation 保留前 N 维，适用于以 Matryoshka 方式训练的模型
+// （如 text-embedding-3、nomic、jina-v3），截断后通常需要重新归一化.
+type MatryoshkaTruncation struct {
+	dims      int
+	normalize bool
+}
+
+// NewMatryoshkaTruncation 创建 Matryoshka 截断变换.
+func NewMatryoshkaTruncation(dims int, normalize bool) (*MatryoshkaTruncation, error) {
+	if dims <= 0 {
+		return nil, fmt.Errorf("matryoshka dims must be positive, got %d", dims)
+	}
+	return &MatryoshkaTruncation{dims: dims, normalize: normalize}, nil
+}
+
+func (t *MatryoshkaTruncation) Apply(vec []float64) ([]float64, error) {
+	if len(vec) < t.dims {
+		return nil, fmt.Errorf("%w: matryoshka needs at least %d dims, got %d", ErrDimensionMismatch, t.dims, len(vec))
+	}
+	out := append([]float64(nil), vec[:t.dims]...)
+	if t.normalize {
+		normalizeL2(out)
+	}
+	return out, nil
+}
+
+func (t *MatryoshkaTruncation) OutputDims() int { return t.dims }
+
+func (t *MatryoshkaTruncation) Spec() TransformSpec {
+	return TransformSpec{Kind: TransformMatryoshka, OutputDims: t.dims, Normalize: t.normalize}
+}
+
+// =============================================================================
+// PCA
+// =============================================================================
+
+const (
+	pcaMaxIterations = 300
+	pcaTolerance     = 1e-10
+)
+
+// PCA 主成分投影，由 FitPCA 在样本向量上拟合.
+type PCA struct {
+	mean       []float64
+	components [][]float64
+	normalize  bool
+}
+
+// FitPCA 在样本上拟合前 k 个主成分.
+// 使用带正交化的幂迭代，直接在中心化数据上计算 X^T(Xv)，不构造 d×d 协方差矩阵；
+// 初始向量与符号约定固定，相同样本总是得到相同结果。
+func FitPCA(samples [][]float64, k int, normalize bool) (*PCA, error) {
+	if len(samples) < 2 {
+		return nil, fmt.Errorf("pca requires at least 2 samples, got %d", len(samples))
+	}
+	dims := len(samples[0])
+	if k <= 0 || k > dims {
+		return nil, fmt.Errorf("pca components must be in [1, %d], got %d", dims, k)
+	}
+
+	mean := make([]float64, dims)
+	for i, s := range samples {
+		if len(s) != dims {
+			return nil, fmt.Errorf("%w: sample %d has %d dims, expected %d", ErrDimensionMismatch, i, len(s), dims)
+		}
+		for j, v := range s {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(samples))
+	}
+	centered := make([][]float64, len(samples))
+	for i, s := range samples {
+		row := make([]float64, dims)
+		for j, v := range s {
+			row[j] = v - mean[j]
+		}
+		centered[i] = row
+	}
+
+	components := make([][]float64, 0, k)
+	for c := 0; c < k; c++ {
+		v := make([]float64, dims)
+		for j := range v {
+			// 确定性初始化，避免与已有成分恰好正交
+			v[j] = 1 + float64((j*7+c*13)%17)/17
+		}
+		orthogonalize(v, components)
+		if normalizeL2(v) == 0 {
+			break
+		}
+		for iter := 0; iter < pcaMaxIterations; iter++ {
+			next := covarianceProduct(centered, v)
+			orthogonalize(next, components)
+			if normalizeL2(next) == 0 {
+				break
+			}
+			delta := 0.0
+			for j := range next {
+				delta += math.Abs(math.Abs(next[j]) - math.Abs(v[j]))
+			}
+			v = next
+			if delta < pcaTolerance {
+				break
+			}
+		}
+		canonicalSign(v)
+		components = append(components, v)
+	}
+	if len(components) < k {
+		return nil, fmt.Errorf("pca: samples only span %d dimensions, requested %d", len(components), k)
+	}
+	return &PCA{mean: mean, components: components, normalize: normalize}, nil
+}
+
+func (p *PCA) Apply(vec []float64) ([]float64, error) {
+	if len(vec) != len(p.mean) {
+		return nil, fmt.Errorf("%w: pca expects %d dims, got %d", ErrDimensionMismatch, len(p.mean), len(vec))
+	}
+	out := make([]float64, len(p.components))
+	for i, comp := range p.components {
+		sum := 0.0
+		for j, v := range vec {
+			sum += (v - p.mean[j]) * comp[j]
+		}
+		out[i] = sum
+	}
+	if p.normalize {
+		normalizeL2(out)
+	}
+	return out, nil
+}
+
+func (p *PCA) OutputDims() int { return len(p.components) }
+
+func (p *PCA) Spec() TransformSpec {
+	return TransformSpec{
+		Kind:       TransformPCA,
+		InputDims:  len(p.mean),
+		OutputDims: len(p.components),
+		Normalize:  p.normalize,
+		Mean:       p.mean,
+		Components: p.components,
+	}
+}
+
+func covarianceProduct(centered [][]float64, v []float64) []float64 {
+	out := make([]float64, len(v))
+	for _, row := range centered {
+		proj := 0.0
+		for j, x := range row {
+			proj += x * v[j]
+		}
+		for j, x := range row {
+			out[j] += proj * x
+		}
+	}
+	return out
+}
+
+func orthogonalize(v []float64, basis [][]float64) {
+	for _, b := range basis {
+		dot := 0.0
+		for j := range v {
+			dot += v[j] * b[j]
+		}
+		for j := range v {
+			v[j] -= dot * b[j]
+		}
+	}
+}
+
+// canonicalSign 令绝对值最大的分量为正，消除特征向量的符号歧义
+func canonicalSign(v []float64) {
+	maxIdx := 0
+	for j := range v {
+		if math.Abs(v[j]) > math.Abs(v[maxIdx]) {
+			maxIdx = j
+		}
+	}
+	if v[maxIdx] < 0 {
+		for j := range v {
+			v[j] = -v[j]
+		}
+	}
+}
+
+// normalizeL2 原地 L2 归一化并返回原始范数；零向量保持不变
+func normalizeL2(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	norm := math.Sqrt(sum)
+	if norm < 1e-12 {
+		return 0
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+	return norm
+}
+
+// =============================================================================
+// 变换流水线
+// =============================================================================
+
+// TransformMetadata 描述一条变换流水线，应与向量索引一同存储.
+type TransformMetadata struct {
+	Transforms   []TransformSpec `json:"transforms,omitempty"`
+	Quantization *TransformSpec  `json:"quantization,omitempty"`
+	Fingerprint  string          `json:"fingerprint"`
+}
+
+// TransformPipeline 按顺序应用降维变换，再可选地量化.
+// 入库与查询两侧必须使用同一条流水线（或由 LoadTransformPipeline 从存储的元数据重建）。
+type TransformPipeline struct {
+	transforms []VectorTransform
+	quantizer  Quantizer
+}
+
+// NewTransformPipeline 创建变换流水线并校验相邻变换的维度衔接.
+// quantizer 可为 nil，此时 Encode 不可用。
+func NewTransformPipeline(quantizer Quantizer, transforms ...VectorTransform) (*TransformPipeline, error) {
+	dims := 0
+	for i, t := range transforms {
+		spec := t.Spec()
+		if i > 0 && spec.InputDims > 0 && spec.InputDims != dims {
+			return nil, fmt.Errorf("%w: transform %d (%s) expects %d dims, previous outputs %d",
+				ErrDimensionMismatch, i, spec.Kind, spec.InputDims, dims)
+		}
+		if i > 0 && spec.Kind == TransformMatryoshka && spec.OutputDims > dims {
+			return nil, fmt.Errorf("%w: matryoshka %d dims exceeds previous output %d",
+				ErrDimensionMismatch, spec.OutputDims, dims)
+		}
+		dims = t.OutputDims()
+	}
+	if quantizer != nil && len(transforms) > 0 {
+		if in := quantizer.Spec().InputDims; in > 0 && in != dims {
+			return nil, fmt.Errorf("%w: quantizer expects %d dims, transforms output %d", ErrDimensionMismatch, in, dims)
+		}
+	}
+	return &TransformPipeline{transforms: transforms, quantizer: quantizer}, nil
+}
+
+// Apply 依次应用所有浮点变换.
+func (p *TransformPipeline) Apply(vec []float64) ([]float64, error) {
+	out := vec
+	for _, t := range p.transforms {
+		var err error
+		if out, err = t.Apply(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Encode 应用变换后量化，得到用于存储的紧凑编码.
+func (p *TransformPipeline) Encode(vec []float64) ([]byte, error) {
+	if p.quantizer == nil {
+		return nil, fmt.Errorf("embedding: transform pipeline has no quantizer")
+	}
+	out, err := p.Apply(vec)
+	if err != nil {
+		return nil, err
+	}
+	return p.quantizer.Quantize(out)
+}
+
+// Decode 将量化编码还原为近似浮点向量.
+func (p *TransformPipeline) Decode(code []byte) ([]float64, error) {
+	if p.quantizer == nil {
+		return nil, fmt.Errorf("embedding: transform pipeline has no quantizer")
+	}
+	return p.quantizer.Dequantize(code)
+}
+
+// OutputDims 返回浮点变换后的维度；没有变换时返回 0（与输入一致）.
+func (p *TransformPipeline) OutputDims() int {
+	if len(p.transforms) == 0 {
+		return 0
+	}
+	return p.transforms[len(p.transforms)-1].OutputDims()
+}
+
+// Metadata 返回可持久化的流水线描述.
+func (p *TransformPipeline) Metadata() TransformMetadata {
+	meta := TransformMetadata{}
+	for _, t := range p.transforms {
+		meta.Transforms = append(meta.Transforms, t.Spec())
+	}
+	if p.quantizer != nil {
+		spec := p.quantizer.Spec()
+		meta.Quantization = &spec
+	}
+	meta.Fingerprint = meta.computeFingerprint()
+	return meta
+}
+
+// Verify 校验当前流水线与索引存储的元数据一致，不一致时返回 ErrTransformMismatch.
+func (p *TransformPipeline) Verify(stored TransformMetadata) error {
+	current := p.Metadata().Fingerprint
+	if stored.Fingerprint != current {
+		return fmt.Errorf("%w: stored %s, current %s", ErrTransformMismatch, stored.Fingerprint, current)
+	}
+	return nil
+}
+
+func (m TransformMetadata) computeFingerprint() string {
+	payload, err := json.Marshal(struct {
+		Transforms   []TransformSpec `json:"transforms"`
+		Quantization *TransformSpec  `json:"quantization"`
+	}{m.Transforms, m.Quantization})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:16])
+}
+
+// LoadTransformPipeline 从存储的元数据重建流水线，并校验指纹防止元数据被篡改或截断.
+func LoadTransformPipeline(meta TransformMetadata) (*TransformPipeline, error) {
+	if meta.Fingerprint != "" && meta.Fingerprint != meta.computeFingerprint() {
+		return nil, fmt.Errorf("%w: fingerprint does not match transform specs", ErrTransformMismatch)
+	}
+	transforms := make([]VectorTransform, 0, len(meta.Transforms))
+	for i, spec := range meta.Transforms {
+		t, err := transformFromSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i, err)
+		}
+		transforms = append(transforms, t)
+	}
+	var quantizer Quantizer
+	if meta.Quantization != nil {
+		q, err := quantizerFromSpec(*meta.Quantization)
+		if err != nil {
+			return nil, fmt.Errorf("quantization: %w", err)
+		}
+		quantizer = q
+	}
+	return NewTransformPipeline(quantizer, transforms...)
+}
+
+func transformFromSpec(spec TransformSpec) (VectorTransform, error) {
+	switch spec.Kind {
+	case TransformMatryoshka:
+		return NewMatryoshkaTruncation(spec.OutputDims, spec.Normalize)
+	case TransformPCA:
+		if len(spec.Mean) != spec.InputDims || len(spec.Components) != spec.OutputDims || spec.OutputDims == 0 {
+			return nil, fmt.Errorf("invalid pca spec: %d inputs, %d outputs", spec.InputDims, spec.OutputDims)
+		}
+		for i, comp := range spec.Components {
+			if len(comp) != spec.InputDims {
+				return nil, fmt.Errorf("invalid pca spec: component %d has %d dims", i, len(comp))
+			}
+		}
+		return &PCA{mean: spec.Mean, components: spec.Components, normalize: spec.Normalize}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector transform %q", spec.Kind)
+	}
+}
+
+// =============================================================================
+// Provider 包装
+// =============================================================================
+
+// TransformingProvider 在底层 provider 的输出上应用同一条流水线的浮点变换，
+// 使入库（EmbedDocuments）与查询（EmbedQuery）两侧得到同一向量空间.
+type TransformingProvider struct {
+	Provider
+	pipeline *TransformPipeline
+}
+
+// NewTransformingProvider 包装 provider.
+func NewTransformingProvider(inner Provider, pipeline *TransformPipeline) *TransformingProvider {
+	return &TransformingProvider{Provider: inner, pipeline: pipeline}
+}
+
+// Pipeline 返回使用的变换流水线.
+func (p *TransformingProvider) Pipeline() *TransformPipeline { return p.pipeline }
+
+func (p *TransformingProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	resp, err := p.Provider.Embed(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	out := *resp
+	out.Embeddings = make([]EmbeddingData, len(resp.Embeddings))
+	for i, data := range resp.Embeddings {
+		vec, err := p.pipeline.Apply(data.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		data.Embedding = vec
+		out.Embeddings[i] = data
+	}
+	return &out, nil
+}
+
+func (p *TransformingProvider) EmbedQuery(ctx context.Context, query string) ([]float64, error) {
+	vec, err := p.Provider.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return p.pipeline.Apply(vec)
+}
+
+func (p *TransformingProvider) EmbedDocuments(ctx context.Context, documents []string) ([][]float64, error) {
+	vecs, err := p.Provider.EmbedDocuments(ctx, documents)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, len(vecs))
+	for i, vec := range vecs {
+		if out[i], err = p.pipeline.Apply(vec); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Dimensions 返回变换后的维度.
+func (p *TransformingProvider) Dimensions() int {
+	if dims := p.pipeline.OutputDims(); dims > 0 {
+		return dims
+	}
+	return p.Provider.Dimensions()
+}
@@ -0,0 +1,211 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticEmbedProvider struct {
+	vectors map[string][]float64
+}
+
+func (p *staticEmbedProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	resp := &EmbeddingResponse{Provider: "static"}
+	for i, in := range req.Input {
+		resp.Embeddings = append(resp.Embeddings, EmbeddingData{Index: i, Embedding: p.vectors[in]})
+	}
+	return resp, nil
+}
+
+func (p *staticEmbedProvider) EmbedQuery(ctx context.Context, query string) ([]float64, error) {
+	return p.vectors[query], nil
+}
+
+func (p *staticEmbedProvider) EmbedDocuments(ctx context.Context, docs []string) ([][]float64, error) {
+	out := make([][]float64, len(docs))
+	for i, d := range docs {
+		out[i] = p.vectors[d]
+	}
+	return out, nil
+}
+
+func (p *staticEmbedProvider) Name() string      { return "static" }
+func (p *staticEmbedProvider) Dimensions() int   { return 4 }
+func (p *staticEmbedProvider) MaxBatchSize() int { return 10 }
+
+// planeSamples 生成主要分布在前两维的样本
+func planeSamples() [][]float64 {
+	var out [][]float64
+	for i := 0; i < 40; i++ {
+		x := float64(i%8) - 3.5
+		y := float64(i%5) - 2
+		out = append(out, []float64{3 * x, y, 0.01 * float64(i%3), 0.5})
+	}
+	return out
+}
+
+func TestMatryoshkaTruncation(t *testing.T) {
+	tr, err := NewMatryoshkaTruncation(2, true)
+	require.NoError(t, err)
+
+	out, err := tr.Apply([]float64{3, 4, 100, 100})
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0.6, 0.8}, out, 1e-9)
+
+	_, err = tr.Apply([]float64{1})
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+
+	_, err = NewMatryoshkaTruncation(0, false)
+	assert.Error(t, err)
+}
+
+func TestFitPCA_DeterministicAndVarianceOrdered(t *testing.T) {
+	samples := planeSamples()
+	a, err := FitPCA(samples, 2, false)
+	require.NoError(t, err)
+	b, err := FitPCA(samples, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, a.Spec(), b.Spec())
+
+	// 第一主成分对应方差最大的第 0 维
+	first := a.Spec().Components[0]
+	assert.InDelta(t, 1.0, math.Abs(first[0]), 1e-6)
+
+	out, err := a.Apply([]float64{3, 0, 0, 0.5})
+	require.NoError(t, err)
+	assert.Len(t, out, 2)
+
+	_, err = FitPCA(samples, 5, false)
+	assert.Error(t, err)
+	_, err = a.Apply([]float64{1, 2})
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+}
+
+func TestInt8Quantizer_RoundTrip(t *testing.T) {
+	samples := planeSamples()
+	q, err := FitInt8Quantizer(samples)
+	require.NoError(t, err)
+
+	vec := samples[7]
+	code, err := q.Quantize(vec)
+	require.NoError(t, err)
+	assert.Len(t, code, len(vec))
+
+	back, err := q.Dequantize(code)
+	require.NoError(t, err)
+	for j := range vec {
+		assert.InDelta(t, vec[j], back[j], q.scale[j]/2+1e-9)
+	}
+
+	// 超出校准范围的值被截断而非溢出
+	clipped, err := q.Quantize([]float64{1e6, -1e6, 0, 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, byte(255), clipped[0])
+	assert.Equal(t, byte(0), clipped[1])
+}
+
+func TestBinaryQuantizer(t *testing.T) {
+	q, err := NewBinaryQuantizer(10)
+	require.NoError(t, err)
+
+	a, err := q.Quantize([]float64{1, -1, 1, -1, 1, -1, 1, -1, 1, -1})
+	require.NoError(t, err)
+	assert.Len(t, a, 2)
+	b, err := q.Quantize([]float64{1, -1, 1, -1, 1, -1, 1, -1, -1, 1})
+	require.NoError(t, err)
+
+	dist, err := HammingDistance(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dist)
+
+	back, err := q.Dequantize(a)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, -1, 1, -1, 1, -1, 1, -1, 1, -1}, back)
+}
+
+func TestTransformPipeline_MetadataRoundTrip(t *testing.T) {
+	samples := planeSamples()
+	pca, err := FitPCA(samples, 3, true)
+	require.NoError(t, err)
+	mat, err := NewMatryoshkaTruncation(2, true)
+	require.NoError(t, err)
+	quant, err := NewBinaryQuantizer(2)
+	require.NoError(t, err)
+
+	ingest, err := NewTransformPipeline(quant, pca, mat)
+	require.NoError(t, err)
+	meta := ingest.Metadata()
+	require.NotEmpty(t, meta.Fingerprint)
+
+	// 元数据随索引持久化，查询侧据此重建
+	raw, err := json.Marshal(meta)
+	require.NoError(t, err)
+	var stored TransformMetadata
+	require.NoError(t, json.Unmarshal(raw, &stored))
+
+	query, err := LoadTransformPipeline(stored)
+	require.NoError(t, err)
+	require.NoError(t, query.Verify(meta))
+
+	for _, s := range samples[:5] {
+		want, err := ingest.Encode(s)
+		require.NoError(t, err)
+		got, err := query.Encode(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// 查询侧配置漂移时显式报错
+	other, err := NewTransformPipeline(nil, mat)
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.Verify(meta), ErrTransformMismatch)
+
+	stored.Transforms[0].Normalize = false
+	_, err = LoadTransformPipeline(stored)
+	assert.ErrorIs(t, err, ErrTransformMismatch)
+}
+
+func TestNewTransformPipeline_RejectsDimensionGaps(t *testing.T) {
+	pca, err := FitPCA(planeSamples(), 2, false)
+	require.NoError(t, err)
+	mat, err := NewMatryoshkaTruncation(3, false)
+	require.NoError(t, err)
+	_, err = NewTransformPipeline(nil, pca, mat)
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+
+	quant, err := NewBinaryQuantizer(4)
+	require.NoError(t, err)
+	_, err = NewTransformPipeline(quant, pca)
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+}
+
+func TestTransformingProvider_AppliesToQueriesAndDocuments(t *testing.T) {
+	inner := &staticEmbedProvider{vectors: map[string][]float64{
+		"doc":   {3, 4, 1, 1},
+		"query": {3, 4, 1, 1},
+	}}
+	mat, err := NewMatryoshkaTruncation(2, true)
+	require.NoError(t, err)
+	pipeline, err := NewTransformPipeline(nil, mat)
+	require.NoError(t, err)
+	p := NewTransformingProvider(inner, pipeline)
+	assert.Equal(t, 2, p.Dimensions())
+	assert.Equal(t, "static", p.Name())
+
+	docs, err := p.EmbedDocuments(context.Background(), []string{"doc"})
+	require.NoError(t, err)
+	q, err := p.EmbedQuery(context.Background(), "query")
+	require.NoError(t, err)
+	assert.Equal(t, docs[0], q)
+	assert.InDeltaSlice(t, []float64{0.6, 0.8}, q, 1e-9)
+
+	resp, err := p.Embed(context.Background(), &EmbeddingRequest{Input: []string{"doc"}})
+	require.NoError(t, err)
+	assert.Equal(t, q, resp.Embeddings[0].Embedding)
+	assert.Len(t, inner.vectors["doc"], 4, "inner vectors must not be modified")
+}
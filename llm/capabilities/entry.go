@@ -243,6 +243,22 @@ func (e *Entry) GenerateImage(ctx context.Context, req *image.GenerateRequest, p
 	return e.router.GenerateImage(ctx, req, providerName)
 }
 
+// EditImage 调用图像编辑/局部重绘能力。
+func (e *Entry) EditImage(ctx context.Context, req *image.EditRequest, providerName string) (*image.GenerateResponse, error) {
+	if e == nil || e.router == nil {
+		return nil, fmt.Errorf("capabilities entry is not configured")
+	}
+	return e.router.EditImage(ctx, req, providerName)
+}
+
+// CreateImageVariation 调用图像变体能力。
+func (e *Entry) CreateImageVariation(ctx context.Context, req *image.VariationRequest, providerName string) (*image.GenerateResponse, error) {
+	if e == nil || e.router == nil {
+		return nil, fmt.Errorf("capabilities entry is not configured")
+	}
+	return e.router.CreateImageVariation(ctx, req, providerName)
+}
+
 // GenerateVideo 调用视频生成能力。
 func (e *Entry) GenerateVideo(ctx context.Context, req *video.GenerateRequest, providerName string) (*video.GenerateResponse, error) {
 	if e == nil || e.router == nil {
@@ -540,6 +540,8 @@ func (p *GeminiProvider) Generate(ctx context.Context, req *GenerateRequest) (*G
 
 // Edit 利用 Gemini 多模态能力编辑修改已有图像.
 //
+// 提供 Mask 时以第二张图传入并在提示中说明蒙版语义（局部重绘）；
+// ReferenceImages 作为额外参考图依次传入.
 // 支持与 Generate 相同的 req.Metadata 扩展参数（image_size/aspect_ratio/enable_search 等）.
 func (p *GeminiProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateResponse, error) {
 	if req.Image == nil {
@@ -550,6 +552,27 @@ func (p *GeminiProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image: %w", err)
 	}
+	parts := []*genai.Part{genai.NewPartFromBytes(imageData, detectImageMIME(imageData))}
+
+	var instructions []string
+	if req.Mask != nil {
+		maskData, err := io.ReadAll(req.Mask)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mask: %w", err)
+		}
+		parts = append(parts, genai.NewPartFromBytes(maskData, detectImageMIME(maskData)))
+		instructions = append(instructions, "The second image is a mask: only modify the regions that are transparent or white in the mask and keep every other pixel of the first image unchanged.")
+	}
+	for i, ref := range req.ReferenceImages {
+		refData, err := io.ReadAll(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reference image %d: %w", i, err)
+		}
+		parts = append(parts, genai.NewPartFromBytes(refData, detectImageMIME(refData)))
+	}
+	if len(req.ReferenceImages) > 0 {
+		instructions = append(instructions, fmt.Sprintf("The last %d image(s) are references for style and subject; edit the first image accordingly.", len(req.ReferenceImages)))
+	}
 
 	model := req.Model
 	if model == "" {
@@ -563,12 +586,11 @@ func (p *GeminiProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 
 	genReq := &GenerateRequest{Prompt: req.Prompt, Size: req.Size, Metadata: req.Metadata}
 	config, prompt := buildGenerateContentConfigFromImageRequest(genReq, true)
-	contents := []*genai.Content{
-		genai.NewContentFromParts([]*genai.Part{
-			genai.NewPartFromBytes(imageData, "image/png"),
-			genai.NewPartFromText(prompt),
-		}, genai.RoleUser),
+	if len(instructions) > 0 {
+		prompt = strings.Join(instructions, "\n") + "\n" + prompt
 	}
+	parts = append(parts, genai.NewPartFromText(prompt))
+	contents := []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
 
 	resp, err := client.Models.GenerateContent(ctx, model, contents, config)
 	if err != nil {
@@ -577,13 +599,24 @@ func (p *GeminiProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 	images := imageDataFromGenerateContentResponse(resp)
 
 	return &GenerateResponse{
-		Provider:  p.Name(),
-		Model:     model,
-		Images:    images,
+		Provider: p.Name(),
+		Model:    model,
+		Images:   images,
+		Usage: ImageUsage{
+			ImagesGenerated: len(images),
+		},
 		CreatedAt: time.Now(),
 	}, nil
 }
 
+// detectImageMIME 按内容嗅探图片 MIME 类型，无法识别时按 PNG 处理.
+func detectImageMIME(data []byte) string {
+	if mime := http.DetectContentType(data); strings.HasPrefix(mime, "image/") {
+		return mime
+	}
+	return "image/png"
+}
+
 // CreateVariation 使用 Gemini 创建图像变体.
 func (p *GeminiProvider) CreateVariation(ctx context.Context, req *VariationRequest) (*GenerateResponse, error) {
 	if req.Image == nil {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -265,3 +266,85 @@ func TestFluxProvider_Generate_PollError(t *testing.T) {
 	assert.Contains(t, err.Error(), "flux generation failed")
 }
 
+
+// --- gpt-image-1 edit with reference images ---
+
+func TestOpenAIProvider_Edit_GPTImageReferences(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(10<<20))
+		assert.Len(t, r.MultipartForm.File["image[]"], 3)
+		assert.Len(t, r.MultipartForm.File["mask"], 1)
+		assert.Equal(t, "gpt-image-1", r.FormValue("model"))
+		assert.Equal(t, "high", r.FormValue("quality"))
+		assert.Equal(t, "transparent", r.FormValue("background"))
+		assert.Equal(t, "webp", r.FormValue("output_format"))
+		assert.Empty(t, r.FormValue("response_format"), "gpt-image models always return b64_json")
+		_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"aW1n"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewOpenAIProvider(OpenAIConfig{BaseProviderConfig: providers.BaseProviderConfig{APIKey: "k", BaseURL: srv.URL}})
+	req := &EditRequest{
+		Image:           bytes.NewReader([]byte("img")),
+		Mask:            bytes.NewReader([]byte("mask")),
+		ReferenceImages: []io.Reader{bytes.NewReader([]byte("ref1")), bytes.NewReader([]byte("ref2"))},
+		Prompt:          "put the logo on the mug",
+		Model:           "gpt-image-1",
+		Quality:         "high",
+		Background:      "transparent",
+		OutputFormat:    "webp",
+		ResponseFormat:  "url",
+	}
+	assert.True(t, req.IsInpaint())
+	resp, err := p.Edit(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Images, 1)
+	assert.Equal(t, "aW1n", resp.Images[0].B64JSON)
+	assert.Equal(t, 1, resp.Usage.ImagesGenerated)
+}
+
+func TestOpenAIProvider_Edit_ReferencesRequireGPTImage(t *testing.T) {
+	p := NewOpenAIProvider(OpenAIConfig{BaseProviderConfig: providers.BaseProviderConfig{APIKey: "k"}})
+	_, err := p.Edit(context.Background(), &EditRequest{
+		Image:           bytes.NewReader([]byte("img")),
+		ReferenceImages: []io.Reader{bytes.NewReader([]byte("ref"))},
+		Prompt:          "blend",
+		Model:           "dall-e-2",
+	})
+	require.Error(t, err)
+
+	_, err = p.Edit(context.Background(), &EditRequest{Image: bytes.NewReader([]byte("img"))})
+	require.Error(t, err, "prompt is required")
+}
+
+// --- Gemini inpainting with mask and reference images ---
+
+func TestGeminiProvider_Edit_MaskAndReferences(t *testing.T) {
+	var captured map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&captured))
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"ZWRpdGVk"}}]}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewGeminiProvider(GeminiConfig{BaseProviderConfig: providers.BaseProviderConfig{APIKey: "k"}})
+	p.client = &http.Client{Transport: &redirectTransport{targetURL: srv.URL, inner: http.DefaultTransport}}
+
+	pngHeader := []byte("\x89PNG\r\n\x1a\n0000")
+	resp, err := p.Edit(context.Background(), &EditRequest{
+		Image:           bytes.NewReader(pngHeader),
+		Mask:            bytes.NewReader(pngHeader),
+		ReferenceImages: []io.Reader{bytes.NewReader([]byte("\xff\xd8\xff\xe0jpeg"))},
+		Prompt:          "replace the sky",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Images, 1)
+
+	contents := captured["contents"].([]any)
+	parts := contents[0].(map[string]any)["parts"].([]any)
+	require.Len(t, parts, 4, "image, mask, reference, prompt")
+	assert.Equal(t, "image/jpeg", parts[2].(map[string]any)["inlineData"].(map[string]any)["mimeType"])
+	prompt := parts[3].(map[string]any)["text"].(string)
+	assert.Contains(t, prompt, "mask")
+	assert.Contains(t, prompt, "replace the sky")
+}
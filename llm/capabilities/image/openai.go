@@ -147,21 +147,34 @@ func (p *OpenAIProvider) Generate(ctx context.Context, req *GenerateRequest) (*G
 }
 
 // 编辑修改已存在的图像。
+// gpt-image-1 支持多张输入图（image[]）与 quality/background/output_format 参数，
+// 始终返回 b64_json；dall-e-2 仅支持单张输入图。
 func (p *OpenAIProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateResponse, error) {
-	if req.Image == nil {
-		return nil, fmt.Errorf("image is required")
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	gptImage := isGPTImageModel(req.Model)
+	if len(req.ReferenceImages) > 0 && !gptImage {
+		return nil, fmt.Errorf("reference images require a gpt-image model, got %q", req.Model)
 	}
 
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
-	// 添加图像
-	part, err := writer.CreateFormFile("image", "image.png")
-	if err != nil {
-		return nil, err
+	// 添加图像；gpt-image 模型的多图输入使用 image[] 字段
+	imageField := "image"
+	if gptImage {
+		imageField = "image[]"
 	}
-	if _, err := io.Copy(part, req.Image); err != nil {
-		return nil, err
+	inputs := append([]io.Reader{req.Image}, req.ReferenceImages...)
+	for i, input := range inputs {
+		part, err := writer.CreateFormFile(imageField, fmt.Sprintf("image_%d.png", i))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(part, input); err != nil {
+			return nil, err
+		}
 	}
 
 	// 提供后添加口罩
@@ -183,6 +196,20 @@ func (p *OpenAIProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 			return nil, fmt.Errorf("failed to write model field: %w", err)
 		}
 	}
+	if gptImage {
+		for _, field := range [][2]string{
+			{"quality", req.Quality},
+			{"background", req.Background},
+			{"output_format", req.OutputFormat},
+		} {
+			if field[1] == "" {
+				continue
+			}
+			if err := writer.WriteField(field[0], field[1]); err != nil {
+				return nil, fmt.Errorf("failed to write %s field: %w", field[0], err)
+			}
+		}
+	}
 	if req.N > 0 {
 		if err := writer.WriteField("n", fmt.Sprintf("%d", req.N)); err != nil {
 			return nil, fmt.Errorf("failed to write n field: %w", err)
@@ -193,7 +220,7 @@ func (p *OpenAIProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 			return nil, fmt.Errorf("failed to write size field: %w", err)
 		}
 	}
-	if req.ResponseFormat != "" {
+	if req.ResponseFormat != "" && !gptImage {
 		if err := writer.WriteField("response_format", req.ResponseFormat); err != nil {
 			return nil, fmt.Errorf("failed to write response_format field: %w", err)
 		}
@@ -232,13 +259,21 @@ func (p *OpenAIProvider) Edit(ctx context.Context, req *EditRequest) (*GenerateR
 	}
 
 	return &GenerateResponse{
-		Provider:  p.Name(),
-		Model:     req.Model,
-		Images:    images,
+		Provider: p.Name(),
+		Model:    req.Model,
+		Images:   images,
+		Usage: ImageUsage{
+			ImagesGenerated: len(images),
+		},
 		CreatedAt: time.Now(),
 	}, nil
 }
 
+// isGPTImageModel 判断是否为 gpt-image 系列模型（gpt-image-1 等）.
+func isGPTImageModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(model), "gpt-image")
+}
+
 // Create Variation 创建图像的变体 。
 func (p *OpenAIProvider) CreateVariation(ctx context.Context, req *VariationRequest) (*GenerateResponse, error) {
 	if req.Image == nil {
@@ -2,7 +2,9 @@ package image
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -46,15 +48,38 @@ type ImageUsage struct {
 }
 
 // 编辑请求代表图像编辑请求 。
+// 提供 Mask 时为局部重绘（inpainting）：仅修改蒙版透明/白色区域，其余像素保持不变。
 type EditRequest struct {
-	Image          io.Reader         `json:"-"`
-	Mask           io.Reader         `json:"-"`
-	Prompt         string            `json:"prompt"`
-	Model          string            `json:"model,omitempty"`
-	N              int               `json:"n,omitempty"`
-	Size           string            `json:"size,omitempty"`
-	ResponseFormat string            `json:"response_format,omitempty"`
-	Metadata       map[string]string `json:"metadata,omitempty"`
+	Image io.Reader `json:"-"`
+	Mask  io.Reader `json:"-"`
+	// ReferenceImages 额外参考图（风格、主体、构图参考），与 Image 一起作为输入；
+	// gpt-image-1 最多支持 16 张输入图，dall-e-2 不支持参考图
+	ReferenceImages []io.Reader       `json:"-"`
+	Prompt          string            `json:"prompt"`
+	Model           string            `json:"model,omitempty"`
+	N               int               `json:"n,omitempty"`
+	Size            string            `json:"size,omitempty"`
+	Quality         string            `json:"quality,omitempty"`       // gpt-image-1: low, medium, high, auto
+	Background      string            `json:"background,omitempty"`    // gpt-image-1: transparent, opaque, auto
+	OutputFormat    string            `json:"output_format,omitempty"` // gpt-image-1: png, jpeg, webp
+	ResponseFormat  string            `json:"response_format,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate 校验编辑请求的必填项.
+func (r *EditRequest) Validate() error {
+	if r == nil || r.Image == nil {
+		return fmt.Errorf("image is required")
+	}
+	if strings.TrimSpace(r.Prompt) == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	return nil
+}
+
+// IsInpaint 报告请求是否为带蒙版的局部重绘.
+func (r *EditRequest) IsInpaint() bool {
+	return r != nil && r.Mask != nil
 }
 
 // 变异请求代表图像变异请求.
@@ -0,0 +1,370 @@
+package multimodal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FallbackStep 是回退链中的一个提供者及其单次调用的超时预算（0 表示不单独限时）。
+type FallbackStep struct {
+	Provider string
+	Timeout  time.Duration
+}
+
+// FallbackPolicy 控制回退链的健康感知：连续失败达到阈值的提供者在冷却期内被跳过。
+type FallbackPolicy struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// DefaultFallbackPolicy 返回默认健康策略：连续失败 3 次后冷却 30 秒。
+func DefaultFallbackPolicy() FallbackPolicy {
+	return FallbackPolicy{FailureThreshold: 3, Cooldown: 30 * time.Second}
+}
+
+// RouteAttempt 记录路由过程中对某个提供者的一次尝试。
+type RouteAttempt struct {
+	Provider string        `json:"provider"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"` // 因处于冷却期而未调用
+}
+
+// RouteAnnotation 标注一次请求最终由哪个提供者服务，以及回退过程中的全部尝试。
+type RouteAnnotation struct {
+	Capability Capability     `json:"capability"`
+	Provider   string         `json:"provider,omitempty"`
+	Fallback   bool           `json:"fallback"` // 是否由非首选提供者服务
+	Attempts   []RouteAttempt `json:"attempts,omitempty"`
+}
+
+type routeAnnotationKey struct{}
+
+// WithRouteAnnotation 返回携带路由标注的上下文；Router 在调用结束后填充返回的标注。
+func WithRouteAnnotation(ctx context.Context) (context.Context, *RouteAnnotation) {
+	annotation := &RouteAnnotation{}
+	return context.WithValue(ctx, routeAnnotationKey{}, annotation), annotation
+}
+
+// RouteAnnotationFromContext 取出 WithRouteAnnotation 注入的标注。
+func RouteAnnotationFromContext(ctx context.Context) (*RouteAnnotation, bool) {
+	annotation, ok := ctx.Value(routeAnnotationKey{}).(*RouteAnnotation)
+	return annotation, ok && annotation != nil
+}
+
+// FallbackError 在回退链中所有提供者都失败时返回。
+type FallbackError struct {
+	Capability Capability
+	Attempts   []RouteAttempt
+	errs       []error
+}
+
+func (e *FallbackError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		if attempt.Skipped {
+			parts = append(parts, attempt.Provider+": skipped (unhealthy)")
+			continue
+		}
+		parts = append(parts, attempt.Provider+": "+attempt.Error)
+	}
+	return fmt.Sprintf("all %s providers failed: %s", e.Capability, strings.Join(parts, "; "))
+}
+
+// Unwrap 暴露各提供者的原始错误，便于 errors.Is/As 判断。
+func (e *FallbackError) Unwrap() []error {
+	return e.errs
+}
+
+type providerHealth struct {
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+// fallbackState 保存回退链与提供者健康状态，与 Router 的提供者注册表分开加锁。
+type fallbackState struct {
+	mu     sync.Mutex
+	chains map[Capability][]FallbackStep
+	health map[string]*providerHealth
+	policy FallbackPolicy
+	now    func() time.Time
+}
+
+// RegisterFallbackChain 为能力注册有序回退链（如 TTS: openai → elevenlabs → local）。
+// 链中的提供者必须已注册到该能力；重复注册会替换旧链。
+func (r *Router) RegisterFallbackChain(cap Capability, steps ...FallbackStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("fallback chain for %s is empty", cap)
+	}
+	seen := make(map[string]struct{}, len(steps))
+	for _, step := range steps {
+		if step.Timeout < 0 {
+			return fmt.Errorf("fallback chain for %s: negative timeout for provider %q", cap, step.Provider)
+		}
+		if _, dup := seen[step.Provider]; dup {
+			return fmt.Errorf("fallback chain for %s: provider %q listed twice", cap, step.Provider)
+		}
+		seen[step.Provider] = struct{}{}
+		if !r.hasProvider(cap, step.Provider) {
+			return fmt.Errorf("fallback chain for %s: provider %q not registered", cap, step.Provider)
+		}
+	}
+
+	r.fallback.mu.Lock()
+	defer r.fallback.mu.Unlock()
+	if r.fallback.chains == nil {
+		r.fallback.chains = make(map[Capability][]FallbackStep)
+	}
+	r.fallback.chains[cap] = append([]FallbackStep(nil), steps...)
+	return nil
+}
+
+// FallbackChain 返回能力当前的回退链副本。
+func (r *Router) FallbackChain(cap Capability) []FallbackStep {
+	r.fallback.mu.Lock()
+	defer r.fallback.mu.Unlock()
+	return append([]FallbackStep(nil), r.fallback.chains[cap]...)
+}
+
+// SetFallbackPolicy 设置健康感知策略；非正值字段回落到默认值。
+func (r *Router) SetFallbackPolicy(policy FallbackPolicy) {
+	defaults := DefaultFallbackPolicy()
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = defaults.FailureThreshold
+	}
+	if policy.Cooldown <= 0 {
+		policy.Cooldown = defaults.Cooldown
+	}
+	r.fallback.mu.Lock()
+	r.fallback.policy = policy
+	r.fallback.mu.Unlock()
+}
+
+// ProviderHealthy 报告提供者当前是否可参与回退（未处于冷却期）。
+func (r *Router) ProviderHealthy(cap Capability, name string) bool {
+	r.fallback.mu.Lock()
+	defer r.fallback.mu.Unlock()
+	return r.healthyLocked(cap, name)
+}
+
+func (r *Router) hasProvider(cap Capability, name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ok bool
+	switch cap {
+	case CapabilityEmbedding:
+		_, ok = r.embeddingProviders[name]
+	case CapabilityRerank:
+		_, ok = r.rerankProviders[name]
+	case CapabilityTTS:
+		_, ok = r.ttsProviders[name]
+	case CapabilitySTT:
+		_, ok = r.sttProviders[name]
+	case CapabilityImage:
+		_, ok = r.imageProviders[name]
+	case CapabilityVideo:
+		_, ok = r.videoProviders[name]
+	case CapabilityMusic:
+		_, ok = r.musicProviders[name]
+	case CapabilityThreeD:
+		_, ok = r.threeDProviders[name]
+	case CapabilityModeration:
+		_, ok = r.moderationProviders[name]
+	}
+	return ok
+}
+
+// candidates 计算本次调用的尝试顺序：显式指定的提供者优先，其后是回退链中的其余提供者。
+// 未注册回退链时仅尝试指定（或默认）提供者，保持原有行为。
+func (r *Router) candidates(cap Capability, providerName string) []FallbackStep {
+	chain := r.FallbackChain(cap)
+	if len(chain) == 0 {
+		if providerName == "" {
+			providerName = r.defaultProvider(cap)
+		}
+		return []FallbackStep{{Provider: providerName}}
+	}
+	if providerName == "" {
+		return chain
+	}
+	out := make([]FallbackStep, 0, len(chain)+1)
+	first := FallbackStep{Provider: providerName}
+	for _, step := range chain {
+		if step.Provider == providerName {
+			first = step
+			continue
+		}
+		out = append(out, step)
+	}
+	return append([]FallbackStep{first}, out...)
+}
+
+func (r *Router) defaultProvider(cap Capability) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	switch cap {
+	case CapabilityEmbedding:
+		return r.defaultEmbedding
+	case CapabilityRerank:
+		return r.defaultRerank
+	case CapabilityTTS:
+		return r.defaultTTS
+	case CapabilitySTT:
+		return r.defaultSTT
+	case CapabilityImage:
+		return r.defaultImage
+	case CapabilityVideo:
+		return r.defaultVideo
+	case CapabilityMusic:
+		return r.defaultMusic
+	case CapabilityThreeD:
+		return r.defaultThreeD
+	case CapabilityModeration:
+		return r.defaultModeration
+	}
+	return ""
+}
+
+func (r *Router) healthyLocked(cap Capability, name string) bool {
+	h, ok := r.fallback.health[healthKey(cap, name)]
+	if !ok {
+		return true
+	}
+	return !r.nowLocked().Before(h.unhealthyUntil)
+}
+
+func (r *Router) recordOutcome(cap Capability, name string, err error) {
+	r.fallback.mu.Lock()
+	defer r.fallback.mu.Unlock()
+	key := healthKey(cap, name)
+	if err == nil {
+		delete(r.fallback.health, key)
+		return
+	}
+	if r.fallback.health == nil {
+		r.fallback.health = make(map[string]*providerHealth)
+	}
+	h, ok := r.fallback.health[key]
+	if !ok {
+		h = &providerHealth{}
+		r.fallback.health[key] = h
+	}
+	h.consecutiveFailures++
+	policy := r.fallback.policy
+	if policy.FailureThreshold <= 0 {
+		policy = DefaultFallbackPolicy()
+	}
+	if h.consecutiveFailures >= policy.FailureThreshold {
+		h.unhealthyUntil = r.nowLocked().Add(policy.Cooldown)
+	}
+}
+
+func (r *Router) nowLocked() time.Time {
+	if r.fallback.now != nil {
+		return r.fallback.now()
+	}
+	return time.Now()
+}
+
+func healthKey(cap Capability, name string) string {
+	return string(cap) + "/" + name
+}
+
+// routeWithFallback 按候选顺序调用提供者，失败时故障转移到下一个；
+// 冷却期内的提供者被跳过，但若所有候选都不健康则仍按顺序尝试，避免直接失败。
+func routeWithFallback[P any, R any](
+	ctx context.Context,
+	r *Router,
+	cap Capability,
+	providerName string,
+	lookup func(string) (P, error),
+	call func(context.Context, P) (R, error),
+) (R, error) {
+	var zero R
+	steps := r.candidates(cap, providerName)
+
+	r.fallback.mu.Lock()
+	anyHealthy := false
+	healthy := make([]bool, len(steps))
+	for i, step := range steps {
+		healthy[i] = r.healthyLocked(cap, step.Provider)
+		anyHealthy = anyHealthy || healthy[i]
+	}
+	r.fallback.mu.Unlock()
+
+	annotation, annotate := RouteAnnotationFromContext(ctx)
+	if annotate {
+		*annotation = RouteAnnotation{Capability: cap}
+	}
+	var attempts []RouteAttempt
+	var errs []error
+	var lastErr error
+	for i, step := range steps {
+		if anyHealthy && !healthy[i] {
+			attempts = append(attempts, RouteAttempt{Provider: step.Provider, Skipped: true})
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			lastErr = err
+			errs = append(errs, err)
+			break
+		}
+		provider, err := lookup(step.Provider)
+		if err != nil {
+			// 未注册的提供者只可能来自显式指定，保持原有的直接报错语义
+			if len(steps) == 1 {
+				return zero, err
+			}
+			attempts = append(attempts, RouteAttempt{Provider: step.Provider, Error: err.Error()})
+			errs = append(errs, err)
+			continue
+		}
+
+		start := time.Now()
+		resp, err := callWithTimeout(ctx, step.Timeout, provider, call)
+		attempt := RouteAttempt{Provider: step.Provider, Duration: time.Since(start)}
+		if err == nil || ctx.Err() == nil {
+			// 调用方取消不计入提供者健康度
+			r.recordOutcome(cap, step.Provider, err)
+		}
+		if err == nil {
+			attempts = append(attempts, attempt)
+			if annotate {
+				annotation.Provider = step.Provider
+				annotation.Fallback = i > 0
+				annotation.Attempts = attempts
+			}
+			return resp, nil
+		}
+		attempt.Error = err.Error()
+		attempts = append(attempts, attempt)
+		lastErr = err
+		errs = append(errs, fmt.Errorf("%s: %w", step.Provider, err))
+	}
+
+	if annotate {
+		annotation.Attempts = attempts
+	}
+	if len(steps) == 1 {
+		return zero, lastErr
+	}
+	return zero, &FallbackError{Capability: cap, Attempts: attempts, errs: errs}
+}
+
+func callWithTimeout[P any, R any](ctx context.Context, timeout time.Duration, provider P, call func(context.Context, P) (R, error)) (R, error) {
+	if timeout <= 0 {
+		return call(ctx, provider)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := call(callCtx, provider)
+	if err == nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		// 提供者忽略了上下文并在超时后返回，按超时处理以遵守预算
+		var zero R
+		return zero, fmt.Errorf("timeout budget %s exceeded: %w", timeout, context.DeadlineExceeded)
+	}
+	return resp, err
+}
@@ -0,0 +1,154 @@
+package multimodal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/llm/capabilities/audio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flakyTTSProvider struct {
+	mockTTSProvider
+	mu    sync.Mutex
+	err   error
+	delay time.Duration
+	calls int
+}
+
+func (p *flakyTTSProvider) Synthesize(ctx context.Context, req *speech.TTSRequest) (*speech.TTSResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	err := p.err
+	p.mu.Unlock()
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.mockTTSProvider.Synthesize(ctx, req)
+}
+
+func (p *flakyTTSProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func newTTSChainRouter(t *testing.T, providers map[string]*flakyTTSProvider, steps ...FallbackStep) *Router {
+	t.Helper()
+	r := NewRouter()
+	for _, step := range steps {
+		r.RegisterTTS(step.Provider, providers[step.Provider], false)
+	}
+	require.NoError(t, r.RegisterFallbackChain(CapabilityTTS, steps...))
+	return r
+}
+
+func TestRouter_FallbackChainFailsOverAndAnnotates(t *testing.T) {
+	errDown := errors.New("service unavailable")
+	providers := map[string]*flakyTTSProvider{
+		"openai":     {mockTTSProvider: mockTTSProvider{name: "openai"}, err: errDown},
+		"elevenlabs": {mockTTSProvider: mockTTSProvider{name: "elevenlabs"}},
+		"local":      {mockTTSProvider: mockTTSProvider{name: "local"}},
+	}
+	r := newTTSChainRouter(t, providers,
+		FallbackStep{Provider: "openai"},
+		FallbackStep{Provider: "elevenlabs"},
+		FallbackStep{Provider: "local"},
+	)
+
+	ctx, annotation := WithRouteAnnotation(context.Background())
+	resp, err := r.Synthesize(ctx, &speech.TTSRequest{Text: "hi"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "elevenlabs", resp.Provider)
+	assert.Equal(t, CapabilityTTS, annotation.Capability)
+	assert.Equal(t, "elevenlabs", annotation.Provider)
+	assert.True(t, annotation.Fallback)
+	require.Len(t, annotation.Attempts, 2)
+	assert.Equal(t, "service unavailable", annotation.Attempts[0].Error)
+	assert.Zero(t, providers["local"].callCount())
+
+	// 显式指定的提供者优先，其余按链顺序兜底
+	resp, err = r.Synthesize(ctx, &speech.TTSRequest{Text: "hi"}, "local")
+	require.NoError(t, err)
+	assert.Equal(t, "local", resp.Provider)
+	assert.False(t, annotation.Fallback)
+}
+
+func TestRouter_FallbackChainSkipsUnhealthyProviders(t *testing.T) {
+	providers := map[string]*flakyTTSProvider{
+		"primary": {mockTTSProvider: mockTTSProvider{name: "primary"}, err: errors.New("boom")},
+		"backup":  {mockTTSProvider: mockTTSProvider{name: "backup"}},
+	}
+	r := newTTSChainRouter(t, providers, FallbackStep{Provider: "primary"}, FallbackStep{Provider: "backup"})
+	r.SetFallbackPolicy(FallbackPolicy{FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	r.fallback.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Synthesize(context.Background(), &speech.TTSRequest{}, "")
+		require.NoError(t, err)
+	}
+	assert.False(t, r.ProviderHealthy(CapabilityTTS, "primary"))
+
+	ctx, annotation := WithRouteAnnotation(context.Background())
+	_, err := r.Synthesize(ctx, &speech.TTSRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, providers["primary"].callCount(), "unhealthy provider is skipped during cooldown")
+	require.Len(t, annotation.Attempts, 2)
+	assert.True(t, annotation.Attempts[0].Skipped)
+
+	now = now.Add(2 * time.Minute)
+	providers["primary"].err = nil
+	resp, err := r.Synthesize(context.Background(), &speech.TTSRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", resp.Provider)
+	assert.True(t, r.ProviderHealthy(CapabilityTTS, "primary"))
+}
+
+func TestRouter_FallbackChainTimeoutBudgetAndExhaustion(t *testing.T) {
+	errBackup := errors.New("quota exceeded")
+	providers := map[string]*flakyTTSProvider{
+		"slow":   {mockTTSProvider: mockTTSProvider{name: "slow"}, delay: time.Second},
+		"backup": {mockTTSProvider: mockTTSProvider{name: "backup"}, err: errBackup},
+	}
+	r := newTTSChainRouter(t, providers,
+		FallbackStep{Provider: "slow", Timeout: 20 * time.Millisecond},
+		FallbackStep{Provider: "backup"},
+	)
+
+	_, err := r.Synthesize(context.Background(), &speech.TTSRequest{}, "")
+	var fallbackErr *FallbackError
+	require.True(t, errors.As(err, &fallbackErr))
+	require.Len(t, fallbackErr.Attempts, 2)
+	assert.Less(t, fallbackErr.Attempts[0].Duration, time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errBackup)
+	assert.Contains(t, err.Error(), "all tts providers failed")
+}
+
+func TestRouter_RegisterFallbackChainValidation(t *testing.T) {
+	r := NewRouter()
+	r.RegisterTTS("openai", &mockTTSProvider{name: "openai"}, true)
+
+	assert.Error(t, r.RegisterFallbackChain(CapabilityTTS))
+	assert.ErrorContains(t, r.RegisterFallbackChain(CapabilityTTS, FallbackStep{Provider: "missing"}), "not registered")
+	assert.ErrorContains(t, r.RegisterFallbackChain(CapabilityTTS, FallbackStep{Provider: "openai"}, FallbackStep{Provider: "openai"}), "listed twice")
+	assert.ErrorContains(t, r.RegisterFallbackChain(CapabilityTTS, FallbackStep{Provider: "openai", Timeout: -time.Second}), "negative timeout")
+	require.NoError(t, r.RegisterFallbackChain(CapabilityTTS, FallbackStep{Provider: "openai"}))
+	assert.Equal(t, []FallbackStep{{Provider: "openai"}}, r.FallbackChain(CapabilityTTS))
+
+	// 未注册回退链的能力保持原有语义：直接返回提供者错误
+	_, err := r.GenerateImage(context.Background(), nil, "")
+	assert.ErrorContains(t, err, `image provider "" not found`)
+}
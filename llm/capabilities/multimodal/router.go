@@ -53,6 +53,8 @@ type Router struct {
 	defaultMusic      string
 	defaultThreeD     string
 	defaultModeration string
+
+	fallback fallbackState
 }
 
 // 新路特创建了新的多模式路由器.
@@ -291,83 +293,79 @@ func (r *Router) Moderation(name string) (moderation.ModerationProvider, error)
 
 // 嵌入使用默认或指定的提供者生成嵌入.
 func (r *Router) Embed(ctx context.Context, req *embedding.EmbeddingRequest, providerName string) (*embedding.EmbeddingResponse, error) {
-	p, err := r.Embedding(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Embed(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityEmbedding, providerName, r.Embedding, func(ctx context.Context, p embedding.Provider) (*embedding.EmbeddingResponse, error) {
+		return p.Embed(ctx, req)
+	})
 }
 
 // 重新排序 Docs 使用默认或指定的提供者重新排序文档 。
 func (r *Router) RerankDocs(ctx context.Context, req *rerank.RerankRequest, providerName string) (*rerank.RerankResponse, error) {
-	p, err := r.Rerank(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Rerank(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityRerank, providerName, r.Rerank, func(ctx context.Context, p rerank.Provider) (*rerank.RerankResponse, error) {
+		return p.Rerank(ctx, req)
+	})
 }
 
 // 合成大小使用默认或指定的提供者生成语音.
 func (r *Router) Synthesize(ctx context.Context, req *speech.TTSRequest, providerName string) (*speech.TTSResponse, error) {
-	p, err := r.TTS(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Synthesize(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityTTS, providerName, r.TTS, func(ctx context.Context, p speech.TTSProvider) (*speech.TTSResponse, error) {
+		return p.Synthesize(ctx, req)
+	})
 }
 
 // 使用默认或指定的提供者将语音转换为文本。
 func (r *Router) Transcribe(ctx context.Context, req *speech.STTRequest, providerName string) (*speech.STTResponse, error) {
-	p, err := r.STT(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Transcribe(ctx, req)
+	return routeWithFallback(ctx, r, CapabilitySTT, providerName, r.STT, func(ctx context.Context, p speech.STTProvider) (*speech.STTResponse, error) {
+		return p.Transcribe(ctx, req)
+	})
 }
 
 // 生成图像使用默认或指定的提供者生成图像.
 func (r *Router) GenerateImage(ctx context.Context, req *image.GenerateRequest, providerName string) (*image.GenerateResponse, error) {
-	p, err := r.Image(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Generate(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityImage, providerName, r.Image, func(ctx context.Context, p image.Provider) (*image.GenerateResponse, error) {
+		return p.Generate(ctx, req)
+	})
+}
+
+// EditImage 使用默认或指定的提供者编辑图像；提供蒙版时为局部重绘.
+func (r *Router) EditImage(ctx context.Context, req *image.EditRequest, providerName string) (*image.GenerateResponse, error) {
+	return routeWithFallback(ctx, r, CapabilityImage, providerName, r.Image, func(ctx context.Context, p image.Provider) (*image.GenerateResponse, error) {
+		return p.Edit(ctx, req)
+	})
+}
+
+// CreateImageVariation 使用默认或指定的提供者创建图像变体.
+func (r *Router) CreateImageVariation(ctx context.Context, req *image.VariationRequest, providerName string) (*image.GenerateResponse, error) {
+	return routeWithFallback(ctx, r, CapabilityImage, providerName, r.Image, func(ctx context.Context, p image.Provider) (*image.GenerateResponse, error) {
+		return p.CreateVariation(ctx, req)
+	})
 }
 
 // 生成视频使用默认或指定的提供者生成.
 func (r *Router) GenerateVideo(ctx context.Context, req *video.GenerateRequest, providerName string) (*video.GenerateResponse, error) {
-	p, err := r.Video(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Generate(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityVideo, providerName, r.Video, func(ctx context.Context, p video.Provider) (*video.GenerateResponse, error) {
+		return p.Generate(ctx, req)
+	})
 }
 
 // 生成音乐使用默认或指定的提供者生成音乐.
 func (r *Router) GenerateMusic(ctx context.Context, req *music.GenerateRequest, providerName string) (*music.GenerateResponse, error) {
-	p, err := r.Music(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Generate(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityMusic, providerName, r.Music, func(ctx context.Context, p music.MusicProvider) (*music.GenerateResponse, error) {
+		return p.Generate(ctx, req)
+	})
 }
 
 // 生成3D使用默认或指定的提供者生成3D模型.
 func (r *Router) Generate3D(ctx context.Context, req *threed.GenerateRequest, providerName string) (*threed.GenerateResponse, error) {
-	p, err := r.ThreeD(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Generate(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityThreeD, providerName, r.ThreeD, func(ctx context.Context, p threed.ThreeDProvider) (*threed.GenerateResponse, error) {
+		return p.Generate(ctx, req)
+	})
 }
 
 // 适度检查政策违规内容.
 func (r *Router) Moderate(ctx context.Context, req *moderation.ModerationRequest, providerName string) (*moderation.ModerationResponse, error) {
-	p, err := r.Moderation(providerName)
-	if err != nil {
-		return nil, err
-	}
-	return p.Moderate(ctx, req)
+	return routeWithFallback(ctx, r, CapabilityModeration, providerName, r.Moderation, func(ctx context.Context, p moderation.ModerationProvider) (*moderation.ModerationResponse, error) {
+		return p.Moderate(ctx, req)
+	})
 }
 
 // ============================================================
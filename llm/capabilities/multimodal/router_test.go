@@ -69,10 +69,10 @@ func (m *mockImageProvider) Generate(_ context.Context, _ *image.GenerateRequest
 	return &image.GenerateResponse{Provider: m.name}, nil
 }
 func (m *mockImageProvider) Edit(_ context.Context, _ *image.EditRequest) (*image.GenerateResponse, error) {
-	return nil, nil
+	return &image.GenerateResponse{Provider: m.name, Model: "edit"}, nil
 }
 func (m *mockImageProvider) CreateVariation(_ context.Context, _ *image.VariationRequest) (*image.GenerateResponse, error) {
-	return nil, nil
+	return &image.GenerateResponse{Provider: m.name, Model: "variation"}, nil
 }
 func (m *mockImageProvider) Name() string              { return m.name }
 func (m *mockImageProvider) SupportedSizes() []string  { return []string{"1024x1024"} }
@@ -260,6 +260,22 @@ func TestRouter_GenerateImage(t *testing.T) {
 	assert.Equal(t, "img", resp.Provider)
 }
 
+func TestRouter_EditImageAndVariation(t *testing.T) {
+	r := NewRouter()
+	r.RegisterImage("img", &mockImageProvider{name: "img"}, true)
+
+	resp, err := r.EditImage(context.Background(), &image.EditRequest{Prompt: "add a hat"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "edit", resp.Model)
+
+	resp, err = r.CreateImageVariation(context.Background(), &image.VariationRequest{}, "img")
+	require.NoError(t, err)
+	assert.Equal(t, "variation", resp.Model)
+
+	_, err = r.EditImage(context.Background(), &image.EditRequest{}, "missing")
+	require.Error(t, err)
+}
+
 func TestRouter_GenerateVideo(t *testing.T) {
 	r := NewRouter()
 	r.RegisterVideo("vid", &mockVideoProvider{name: "vid"}, true)
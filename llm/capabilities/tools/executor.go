@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -100,6 +101,12 @@ type ExecutorConfig struct {
 	MaxRetries   int           // 单个工具失败时的最大重试次数（0 表示不重试）
 	RetryDelay   time.Duration // 首次重试前的等待时间
 	RetryBackoff float64       // 重试间隔的指数退避乘数（例如 2.0 表示每次翻倍）
+	// DeadlineReserve 为调用方保留的截止时间预算（0 表示不保留），
+	// 工具最多使用剩余预算减去该值，耗尽时结果标记 Truncated=deadline
+	DeadlineReserve time.Duration
+	// DisableResultCoercion 为 true 时声明了 ResultSchema 的工具结果只校验、不做类型修正
+	// （如字符串化的数字/布尔值）
+	DisableResultCoercion bool
 }
 
 // DefaultExecutorConfig 返回默认的执行器配置（不重试）.
@@ -286,12 +293,22 @@ func NewDefaultExecutorWithConfig(registry ToolRegistry, logger *zap.Logger, con
 func (e *DefaultExecutor) Execute(ctx context.Context, calls []types.ToolCall) []types.ToolResult {
 	results := make([]types.ToolResult, len(calls))
 
-	// 并发执行所有工具调用，单个工具失败不阻塞其他工具
+	// 并发执行所有工具调用，单个工具失败不阻塞其他工具；
+	// 声明了副作用的工具按调用顺序串行执行
 	var wg sync.WaitGroup
+	var gate sideEffectGate
 	for i, call := range calls {
+		wait, done := gate.next(lookupToolTraits(e.registry, call.Name).ParallelSafe())
 		wg.Add(1)
 		go func(idx int, c types.ToolCall) {
 			defer wg.Done()
+			if done != nil {
+				defer close(done)
+			}
+			if err := waitTurn(ctx, wait); err != nil {
+				results[idx] = types.ToolResult{ToolCallID: c.ID, Name: c.Name, Error: fmt.Sprintf("execution cancelled: %v", err)}
+				return
+			}
 			results[idx] = e.executeWithRetry(ctx, c)
 		}(i, call)
 	}
@@ -303,7 +320,11 @@ func (e *DefaultExecutor) Execute(ctx context.Context, calls []types.ToolCall) [
 // executeWithRetry 执行单个工具调用，失败时按配置重试.
 func (e *DefaultExecutor) executeWithRetry(ctx context.Context, call types.ToolCall) types.ToolResult {
 	result := e.ExecuteOne(ctx, call)
-	if !result.IsError() || e.config.MaxRetries <= 0 {
+	if !result.IsError() || e.config.MaxRetries <= 0 || result.Truncated != "" {
+		return result
+	}
+	// 声明为非幂等的有副作用工具不自动重试，避免重复执行
+	if !lookupToolTraits(e.registry, call.Name).RetrySafe() {
 		return result
 	}
 
@@ -389,8 +410,10 @@ func (e *DefaultExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) t
 		}
 	}
 
-	// 4. 执行工具（带超时控制）
-	execCtx, cancel := context.WithTimeout(ctx, meta.Timeout)
+	// 4. 执行工具（带超时控制），工具名写入 context 供护栏策略匹配
+	budgetCtx, budgetCancel := types.ReserveBudget(ctx, e.config.DeadlineReserve)
+	defer budgetCancel()
+	execCtx, cancel := context.WithTimeout(types.WithToolName(budgetCtx, call.Name), meta.Timeout)
 	defer cancel()
 
 	// 使用带缓冲的 channel 防止 goroutine 泄漏
@@ -430,21 +453,36 @@ func (e *DefaultExecutor) ExecuteOne(ctx context.Context, call types.ToolCall) t
 		if done.err != nil {
 			result.Error = done.err.Error()
 			result.Duration = time.Since(start)
+			if types.DeadlineExceeded(budgetCtx, nil) {
+				result.Truncated = types.TruncatedDeadline
+			}
 			e.logger.Error("tool execution failed",
 				zap.String("name", call.Name),
 				zap.Error(done.err),
 				zap.Duration("duration", result.Duration))
 		} else {
 			result.Result = done.res
+			e.applyResultSchema(meta, &result)
 			result.Duration = time.Since(start)
-			e.logger.Info("tool executed successfully",
-				zap.String("name", call.Name),
-				zap.Duration("duration", result.Duration))
+			if result.Error == "" {
+				e.logger.Info("tool executed successfully",
+					zap.String("name", call.Name),
+					zap.Duration("duration", result.Duration))
+			}
 		}
 
 	case <-execCtx.Done():
-		result.Error = fmt.Sprintf("execution timeout after %s", meta.Timeout)
 		result.Duration = time.Since(start)
+		if types.DeadlineExceeded(budgetCtx, nil) {
+			// 截止时间预算先于工具超时耗尽
+			result.Error = fmt.Sprintf("deadline budget exhausted after %s", result.Duration.Round(time.Millisecond))
+			result.Truncated = types.TruncatedDeadline
+			e.logger.Warn("tool execution truncated by deadline budget",
+				zap.String("name", call.Name),
+				zap.Duration("duration", result.Duration))
+			break
+		}
+		result.Error = fmt.Sprintf("execution timeout after %s", meta.Timeout)
 		e.logger.Error("tool execution timeout",
 			zap.String("name", call.Name),
 			zap.Duration("timeout", meta.Timeout))
@@ -563,13 +601,18 @@ func (e *DefaultExecutor) executeStreamingTool(ctx context.Context, call types.T
 			ch <- ToolStreamEvent{Type: ToolStreamError, ToolName: call.Name, Error: done.err}
 			return
 		}
+		res, violation := e.checkToolResult(call.Name, meta, done.res)
+		if violation != "" {
+			ch <- ToolStreamEvent{Type: ToolStreamError, ToolName: call.Name, Error: errors.New(violation)}
+			return
+		}
 		result := types.ToolResult{
 			ToolCallID: call.ID,
 			Name:       call.Name,
-			Result:     done.res,
+			Result:     res,
 			Duration:   duration,
 		}
-		ch <- ToolStreamEvent{Type: ToolStreamOutput, ToolName: call.Name, Data: done.res}
+		ch <- ToolStreamEvent{Type: ToolStreamOutput, ToolName: call.Name, Data: res}
 		ch <- ToolStreamEvent{Type: ToolStreamComplete, ToolName: call.Name, Data: result}
 
 	case <-execCtx.Done():
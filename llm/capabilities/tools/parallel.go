@@ -108,22 +108,36 @@ func (p *ParallelExecutor) Execute(ctx context.Context, calls []llmpkg.ToolCall)
 	var wg sync.WaitGroup
 	var firstError atomic.Value
 
+	// 声明了副作用的工具按调用顺序串行执行
+	var gate sideEffectGate
+
 	for i, call := range calls {
+		wait, done := gate.next(lookupToolTraits(p.registry, call.Name).ParallelSafe())
 		wg.Add(1)
 		go func(idx int, c llmpkg.ToolCall) {
 			defer wg.Done()
-
-			// 获取分母
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-execCtx.Done():
+			if done != nil {
+				defer close(done)
+			}
+			cancelledBeforeStart := func() {
 				result.Results[idx] = llmpkg.ToolResult{
 					ToolCallID: c.ID,
 					Name:       c.Name,
 					Error:      "execution cancelled before start",
 				}
 				atomic.AddInt64(&p.failedExecutions, 1)
+			}
+			if err := waitTurn(execCtx, wait); err != nil {
+				cancelledBeforeStart()
+				return
+			}
+
+			// 获取分母
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-execCtx.Done():
+				cancelledBeforeStart()
 				return
 			}
 
@@ -178,7 +192,7 @@ func (p *ParallelExecutor) Execute(ctx context.Context, calls []llmpkg.ToolCall)
 func (p *ParallelExecutor) executeWithRetry(ctx context.Context, call llmpkg.ToolCall) llmpkg.ToolResult {
 	var lastResult llmpkg.ToolResult
 	maxAttempts := 1
-	if p.config.RetryOnError {
+	if p.config.RetryOnError && lookupToolTraits(p.registry, call.Name).RetrySafe() {
 		maxAttempts = p.config.MaxRetries + 1
 	}
 
@@ -19,22 +19,11 @@ import (
 const DefaultInactivityTimeout = 5 * time.Minute
 const steeringDrainTimeout = 100 * time.Millisecond
 
-// toolCallAccumulator 从流式 chunk 中累积工具调用数据.
-type toolCallAccumulator struct {
-	id           string
-	name         string
-	argsFinal    json.RawMessage
-	argsBuilding strings.Builder
-}
-
 // reactPools holds sync.Pool instances to reduce GC pressure on hot paths.
 var (
 	messageSlicePool = sync.Pool{
 		New: func() any { return make([]types.Message, 0, 16) },
 	}
-	toolCallByIDPool = sync.Pool{
-		New: func() any { return make(map[string]*toolCallAccumulator, 4) },
-	}
 )
 
 // ReActConfig 定义了 ReAct 循环配置.
@@ -245,79 +234,35 @@ func resetInactivityTimer(timer *time.Timer, timeout time.Duration) {
 	timer.Reset(timeout)
 }
 
-// collectToolCallsFromDelta 从流式 chunk delta 中累积工具调用数据.
-func (r *ReActExecutor) collectToolCallsFromDelta(
-	deltaToolCalls []types.ToolCall,
-	toolCallByID *map[string]*toolCallAccumulator,
-	toolCallOrder *[]string,
-	iteration int,
-) {
-	if len(deltaToolCalls) == 0 {
-		return
-	}
-	if *toolCallByID == nil {
-		*toolCallByID = make(map[string]*toolCallAccumulator)
-	}
-	for _, tc := range deltaToolCalls {
-		key := fmt.Sprintf("idx_%d", tc.Index)
-		acc := (*toolCallByID)[key]
-		if acc == nil {
-			acc = &toolCallAccumulator{}
-			(*toolCallByID)[key] = acc
-			*toolCallOrder = append(*toolCallOrder, key)
-		}
-		if strings.TrimSpace(tc.ID) != "" {
-			acc.id = strings.TrimSpace(tc.ID)
-		}
-		if strings.TrimSpace(tc.Name) != "" {
-			acc.name = strings.TrimSpace(tc.Name)
-		}
-		if acc.id == "" {
-			acc.id = fmt.Sprintf("call_%d_%d", iteration, tc.Index+1)
-		}
-		if len(tc.Arguments) == 0 || len(acc.argsFinal) > 0 {
-			continue
-		}
-		var argSegStr string
-		if err := json.Unmarshal(tc.Arguments, &argSegStr); err == nil {
-			acc.argsBuilding.WriteString(argSegStr)
-			continue
-		}
-		if json.Valid(tc.Arguments) {
-			acc.argsFinal = append([]byte(nil), tc.Arguments...)
-			continue
-		}
-		acc.argsBuilding.WriteString(string(tc.Arguments))
-	}
+// newToolCallAssembler 创建本轮迭代的工具调用组装器，组装进度作为 "tool_call_delta" 事件转发，
+// 供 UI 展示进行中的工具调用。
+func (r *ReActExecutor) newToolCallAssembler(iteration int, eventCh chan<- ReActStreamEvent) *llm.ToolCallAssembler {
+	return llm.NewToolCallAssembler(llm.ToolCallAssemblerOptions{
+		OnProgress: func(progress llm.ToolCallProgress) {
+			eventCh <- ReActStreamEvent{
+				Type:         ReActEventToolCallDelta,
+				Iteration:    iteration,
+				ToolCallID:   progress.ID,
+				ToolName:     progress.Name,
+				ProgressData: progress,
+			}
+		},
+		IDGenerator: func(_ string, index int) string {
+			return fmt.Sprintf("call_%d_%d", iteration, index+1)
+		},
+	})
 }
 
-// buildNativeToolCalls 从累积器构建原生工具调用列表.
+// buildNativeToolCalls 从组装器取出原生工具调用列表.
 // 返回 nil 表示参数无效且已发送错误事件，调用方应 return.
 func (r *ReActExecutor) buildNativeToolCalls(
-	toolCallByID map[string]*toolCallAccumulator,
-	toolCallOrder []string,
+	assembler *llm.ToolCallAssembler,
 	eventCh chan<- ReActStreamEvent,
 ) []types.ToolCall {
-	nativeToolCalls := make([]types.ToolCall, 0, len(toolCallOrder))
-	for _, id := range toolCallOrder {
-		acc := toolCallByID[id]
-		if acc == nil {
-			continue
-		}
-		args := json.RawMessage(nil)
-		if len(acc.argsFinal) > 0 {
-			args = acc.argsFinal
-		} else {
-			raw := strings.TrimSpace(acc.argsBuilding.String())
-			if raw != "" {
-				if !json.Valid([]byte(raw)) {
-					eventCh <- ReActStreamEvent{Type: ReActEventError, Error: fmt.Sprintf("invalid tool call arguments (id=%s tool=%s): %s", acc.id, acc.name, raw)}
-					return nil
-				}
-				args = json.RawMessage(raw)
-			}
-		}
-		nativeToolCalls = append(nativeToolCalls, types.ToolCall{ID: acc.id, Name: acc.name, Arguments: args})
+	nativeToolCalls, err := assembler.ToolCalls()
+	if err != nil {
+		eventCh <- ReActStreamEvent{Type: ReActEventError, Error: err.Error()}
+		return nil
 	}
 	return nativeToolCalls
 }
@@ -409,9 +354,8 @@ func (r *ReActExecutor) ExecuteStream(ctx context.Context, req *llm.ChatRequest)
 			}
 
 			var (
-				assembledMessage types.Message
-				toolCallOrder    []string
-				toolCallByID     map[string]*toolCallAccumulator
+				assembledMessage                                       types.Message
+				toolCalls                                              = r.newToolCallAssembler(i+1, eventCh)
 				lastChunkID, lastProvider, lastModel, lastFinishReason string
 				lastUsage                                              *llm.ChatUsage
 				steering                                               *SteeringMessage
@@ -478,18 +422,7 @@ func (r *ReActExecutor) ExecuteStream(ctx context.Context, req *llm.ChatRequest)
 					if len(chunk.Delta.ThinkingBlocks) > 0 {
 						assembledMessage.ThinkingBlocks = append(assembledMessage.ThinkingBlocks, chunk.Delta.ThinkingBlocks...)
 					}
-					if len(chunk.Delta.ToolCalls) > 0 {
-					if toolCallByID == nil {
-						toolCallByID = toolCallByIDPool.Get().(map[string]*toolCallAccumulator)
-						defer func() {
-							for k := range toolCallByID {
-								delete(toolCallByID, k)
-							}
-							toolCallByIDPool.Put(toolCallByID)
-						}()
-					}
-					r.collectToolCallsFromDelta(chunk.Delta.ToolCalls, &toolCallByID, &toolCallOrder, i+1)
-				}
+					toolCalls.Feed(chunk)
 
 				case steerMsg := <-r.steerChOrNil():
 					steering = &steerMsg
@@ -548,7 +481,7 @@ func (r *ReActExecutor) ExecuteStream(ctx context.Context, req *llm.ChatRequest)
 			}
 
 			assembledMessage.Role = llm.RoleAssistant
-			assembledMessage.ToolCalls = r.buildNativeToolCalls(toolCallByID, toolCallOrder, eventCh)
+			assembledMessage.ToolCalls = r.buildNativeToolCalls(toolCalls, eventCh)
 			if assembledMessage.ToolCalls == nil {
 				// buildNativeToolCalls 已发送错误事件
 				return
@@ -630,6 +563,7 @@ func (r *ReActExecutor) applySteering(msg SteeringMessage, messages []types.Mess
 const (
 	ReActEventIterationStart = "iteration_start"
 	ReActEventLLMChunk       = "llm_chunk"
+	ReActEventToolCallDelta  = "tool_call_delta" // 流式工具调用组装进度（ProgressData 为 llm.ToolCallProgress）
 	ReActEventToolsStart     = "tools_start"
 	ReActEventToolsEnd       = "tools_end"
 	ReActEventToolProgress   = "tool_progress"
@@ -291,12 +291,15 @@ func TestReActExecutor_ExecuteStream_AssemblesToolCallArgumentsAcrossChunks(t *t
 
 	var (
 		toolCalls []llmpkg.ToolCall
+		progress  []llmpkg.ToolCallProgress
 		final     *llmpkg.ChatResponse
 	)
 	for ev := range evCh {
 		switch ev.Type {
 		case "tools_start":
 			toolCalls = ev.ToolCalls
+		case ReActEventToolCallDelta:
+			progress = append(progress, ev.ProgressData.(llmpkg.ToolCallProgress))
 		case "completed":
 			final = ev.FinalResponse
 		case "error":
@@ -313,6 +316,9 @@ func TestReActExecutor_ExecuteStream_AssemblesToolCallArgumentsAcrossChunks(t *t
 	if got, want := string(toolCalls[0].Arguments), `{"text":"hi"}`; got != want {
 		t.Fatalf("arguments mismatch: got=%s want=%s", got, want)
 	}
+	if len(progress) == 0 || !progress[len(progress)-1].Complete || progress[len(progress)-1].ID != "call_1" {
+		t.Fatalf("expected tool call progress ending with completion, got %#v", progress)
+	}
 	if final == nil || len(final.Choices) == 0 || final.Choices[0].Message.Content != "done" {
 		t.Fatalf("unexpected final response: %#v", final)
 	}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/BaSui01/agentflow/pkg/jsonschema"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// ResultSchemaViolation 是工具输出不符合声明的 ResultSchema 时返回给模型的结构化错误.
+type ResultSchemaViolation struct {
+	Error      string                       `json:"error"`
+	Tool       string                       `json:"tool"`
+	Violations []jsonschema.ValidationError `json:"violations"`
+}
+
+// resultSchemaViolationCode 标识结果 schema 校验失败，模型可据此调整参数后重试.
+const resultSchemaViolationCode = "result_schema_violation"
+
+// checkToolResult 按工具声明的 ResultSchema 校验输出并修正常见类型偏差.
+// 返回修正后的结果；不符合 schema 时返回可直接写入 ToolResult.Error 的结构化错误信息.
+// 未声明 ResultSchema 时原样返回.
+func (e *DefaultExecutor) checkToolResult(name string, meta ToolMetadata, res json.RawMessage) (json.RawMessage, string) {
+	if len(meta.Schema.ResultSchema) == 0 {
+		return res, ""
+	}
+	checked := jsonschema.ValidateValue(res, meta.Schema.ResultSchema, jsonschema.ValueOptions{
+		Coerce: !e.config.DisableResultCoercion,
+	})
+	if !checked.Valid() {
+		e.logger.Warn("tool result violates declared schema",
+			zap.String("name", name),
+			zap.Int("violations", len(checked.Errors)))
+		return nil, formatResultSchemaViolation(name, checked.Errors)
+	}
+	if len(checked.Coercions) > 0 {
+		e.logger.Debug("tool result coerced to declared schema",
+			zap.String("name", name),
+			zap.Any("coercions", checked.Coercions))
+	}
+	return checked.Value, ""
+}
+
+func formatResultSchemaViolation(name string, errs []jsonschema.ValidationError) string {
+	payload, err := json.Marshal(ResultSchemaViolation{
+		Error:      resultSchemaViolationCode,
+		Tool:       name,
+		Violations: errs,
+	})
+	if err != nil {
+		return fmt.Sprintf("%s: %v", resultSchemaViolationCode, errs)
+	}
+	return "tool result does not match declared result_schema: " + string(payload)
+}
+
+// applyResultSchema 对成功的执行结果应用 ResultSchema 校验，违规时将结果转为错误.
+func (e *DefaultExecutor) applyResultSchema(meta ToolMetadata, result *types.ToolResult) {
+	if result.Error != "" {
+		return
+	}
+	res, violation := e.checkToolResult(result.Name, meta, result.Result)
+	if violation != "" {
+		result.Result = nil
+		result.Error = violation
+		return
+	}
+	result.Result = res
+}
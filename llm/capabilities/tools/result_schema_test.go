@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var quoteResultSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {"symbol": {"type": "string"}, "price": {"type": "number"}},
+	"required": ["symbol", "price"]
+}`)
+
+func registerQuoteTool(t *testing.T, output string) *DefaultRegistry {
+	t.Helper()
+	reg := NewDefaultRegistry(zap.NewNop())
+	require.NoError(t, reg.Register("quote", func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(output), nil
+	}, ToolMetadata{Schema: types.ToolSchema{Name: "quote", ResultSchema: quoteResultSchema}}))
+	return reg
+}
+
+func TestDefaultExecutor_CoercesResultToSchema(t *testing.T) {
+	exec := NewDefaultExecutor(registerQuoteTool(t, `{"symbol":"ACME","price":"12.50"}`), zap.NewNop())
+
+	result := exec.ExecuteOne(context.Background(), types.ToolCall{ID: "c1", Name: "quote"})
+	require.False(t, result.IsError(), result.Error)
+	assert.JSONEq(t, `{"symbol":"ACME","price":12.50}`, string(result.Result))
+}
+
+func TestDefaultExecutor_ResultSchemaViolationBecomesStructuredError(t *testing.T) {
+	exec := NewDefaultExecutor(registerQuoteTool(t, `{"symbol":"ACME","price":"n/a"}`), zap.NewNop())
+
+	result := exec.ExecuteOne(context.Background(), types.ToolCall{ID: "c1", Name: "quote"})
+	require.True(t, result.IsError())
+	assert.Nil(t, result.Result)
+
+	payload := result.Error[strings.Index(result.Error, "{"):]
+	var violation ResultSchemaViolation
+	require.NoError(t, json.Unmarshal([]byte(payload), &violation))
+	assert.Equal(t, "result_schema_violation", violation.Error)
+	assert.Equal(t, "quote", violation.Tool)
+	require.Len(t, violation.Violations, 1)
+	assert.Equal(t, "price", violation.Violations[0].Field)
+	assert.True(t, result.ToMessage().IsToolError)
+}
+
+func TestDefaultExecutor_ResultCoercionCanBeDisabled(t *testing.T) {
+	exec := NewDefaultExecutorWithConfig(registerQuoteTool(t, `{"symbol":"ACME","price":"12.50"}`), zap.NewNop(),
+		ExecutorConfig{DisableResultCoercion: true})
+
+	result := exec.ExecuteOne(context.Background(), types.ToolCall{ID: "c1", Name: "quote"})
+	assert.Contains(t, result.Error, "result_schema_violation")
+}
+
+func TestDefaultExecutor_StreamingToolResultSchema(t *testing.T) {
+	reg := NewDefaultRegistry(zap.NewNop())
+	require.NoError(t, reg.RegisterStreaming("quote", func(ctx context.Context, args json.RawMessage, emit ToolProgressEmitter) (json.RawMessage, error) {
+		return json.RawMessage(`{"symbol":"ACME","price":"7"}`), nil
+	}, ToolMetadata{Schema: types.ToolSchema{Name: "quote", ResultSchema: quoteResultSchema}}))
+	exec := NewDefaultExecutor(reg, zap.NewNop())
+
+	var output any
+	for ev := range exec.ExecuteOneStream(context.Background(), types.ToolCall{ID: "c1", Name: "quote"}) {
+		require.NotEqual(t, ToolStreamError, ev.Type, "%v", ev.Error)
+		if ev.Type == ToolStreamOutput {
+			output = ev.Data
+		}
+	}
+	assert.JSONEq(t, `{"symbol":"ACME","price":7}`, string(output.(json.RawMessage)))
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// lookupToolTraits 返回注册表中工具声明的 Traits；未注册或未声明时返回 nil.
+func lookupToolTraits(registry ToolRegistry, name string) *types.ToolTraits {
+	if registry == nil {
+		return nil
+	}
+	_, meta, err := registry.Get(name)
+	if err != nil {
+		return nil
+	}
+	return meta.Schema.Traits
+}
+
+// sideEffectGate 让声明了副作用的调用按出现顺序依次执行，只读与未声明的调用不受影响.
+type sideEffectGate struct {
+	prev chan struct{}
+}
+
+// next 为下一个调用分配等待/完成通道；parallelSafe 为 true 时均返回 nil.
+// 调用方须在执行结束后关闭 done.
+func (g *sideEffectGate) next(parallelSafe bool) (wait <-chan struct{}, done chan struct{}) {
+	if parallelSafe {
+		return nil, nil
+	}
+	wait, done = g.prev, make(chan struct{})
+	g.prev = done
+	return wait, done
+}
+
+// waitTurn 等待前一个有副作用的调用结束；ctx 取消时返回其错误.
+func waitTurn(ctx context.Context, wait <-chan struct{}) error {
+	if wait == nil {
+		return nil
+	}
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDefaultExecutor_SerializesDeclaredSideEffects(t *testing.T) {
+	reg := NewDefaultRegistry(zap.NewNop())
+
+	var mu sync.Mutex
+	var order []string
+	var running, maxRunning int32
+	write := func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			cur := atomic.LoadInt32(&maxRunning)
+			if n <= cur || atomic.CompareAndSwapInt32(&maxRunning, cur, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		order = append(order, string(args))
+		mu.Unlock()
+		return json.RawMessage(`{}`), nil
+	}
+	require.NoError(t, reg.Register("write", write, ToolMetadata{Schema: types.ToolSchema{
+		Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectLocal},
+	}}))
+
+	exec := NewDefaultExecutor(reg, zap.NewNop())
+	calls := make([]types.ToolCall, 4)
+	for i := range calls {
+		calls[i] = types.ToolCall{ID: fmt.Sprintf("c%d", i), Name: "write", Arguments: json.RawMessage(fmt.Sprintf(`%d`, i))}
+	}
+	results := exec.Execute(context.Background(), calls)
+	require.Len(t, results, 4)
+	for _, r := range results {
+		assert.False(t, r.IsError(), r.Error)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+	assert.Equal(t, []string{"0", "1", "2", "3"}, order)
+}
+
+func TestDefaultExecutor_SkipsRetryForNonIdempotentTools(t *testing.T) {
+	reg := NewDefaultRegistry(zap.NewNop())
+	var sends, lookups int32
+	require.NoError(t, reg.Register("send", func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		atomic.AddInt32(&sends, 1)
+		return nil, fmt.Errorf("upstream timeout")
+	}, ToolMetadata{Schema: types.ToolSchema{Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectExternal}}}))
+	require.NoError(t, reg.Register("lookup", func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		atomic.AddInt32(&lookups, 1)
+		return nil, fmt.Errorf("upstream timeout")
+	}, ToolMetadata{Schema: types.ToolSchema{Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectNone}}}))
+
+	exec := NewDefaultExecutorWithConfig(reg, zap.NewNop(), ExecutorConfig{MaxRetries: 2, RetryDelay: time.Millisecond})
+	exec.Execute(context.Background(), []types.ToolCall{
+		{ID: "c1", Name: "send", Arguments: json.RawMessage(`{}`)},
+		{ID: "c2", Name: "lookup", Arguments: json.RawMessage(`{}`)},
+	})
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sends))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&lookups))
+}
+
+func TestParallelExecutor_RespectsToolTraits(t *testing.T) {
+	reg := NewDefaultRegistry(zap.NewNop())
+	var charges int32
+	require.NoError(t, reg.Register("charge", func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		atomic.AddInt32(&charges, 1)
+		return nil, fmt.Errorf("gateway error")
+	}, ToolMetadata{Schema: types.ToolSchema{Traits: &types.ToolTraits{SideEffect: types.ToolSideEffectExternal}}}))
+
+	cfg := DefaultParallelConfig()
+	cfg.RetryOnError = true
+	cfg.RetryDelay = time.Millisecond
+	pe := NewParallelExecutor(reg, cfg, zap.NewNop())
+
+	result := pe.Execute(context.Background(), []types.ToolCall{{ID: "c1", Name: "charge", Arguments: json.RawMessage(`{}`)}})
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&charges))
+}
+
+func TestWaitTurn_Cancelled(t *testing.T) {
+	var gate sideEffectGate
+	_, first := gate.next(false)
+	wait, _ := gate.next(false)
+	assert.NotNil(t, first)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, waitTurn(ctx, wait), context.Canceled)
+	assert.NoError(t, waitTurn(ctx, nil))
+
+	w, d := gate.next(true)
+	assert.Nil(t, w)
+	assert.Nil(t, d)
+}
+
+func TestDefaultExecutor_DeadlineBudgetTruncatesTool(t *testing.T) {
+	reg := NewDefaultRegistry(zap.NewNop())
+	var calls int32
+	require.NoError(t, reg.Register("slow", func(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, ToolMetadata{Timeout: time.Minute}))
+
+	exec := NewDefaultExecutorWithConfig(reg, zap.NewNop(), ExecutorConfig{
+		MaxRetries:      2,
+		RetryDelay:      time.Millisecond,
+		DeadlineReserve: 150 * time.Millisecond,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	results := exec.Execute(ctx, []types.ToolCall{{ID: "c1", Name: "slow", Arguments: json.RawMessage(`{}`)}})
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsError())
+	assert.Equal(t, types.TruncatedDeadline, results[0].Truncated)
+	// 预留的预算仍留给调用方，截断结果不重试
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -206,6 +206,12 @@ func NewWebScrapeTool(config WebScrapeToolConfig, logger *zap.Logger) (ToolFunc,
 				},
 				"required": ["url"]
 			}`),
+			Traits: &types.ToolTraits{
+				SideEffect:   types.ToolSideEffectNone,
+				Idempotent:   true,
+				LatencyClass: types.ToolLatencyMedium,
+				CostClass:    types.ToolCostLow,
+			},
 		},
 		Timeout:     config.Timeout,
 		RateLimit:   config.RateLimit,
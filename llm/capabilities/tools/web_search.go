@@ -199,6 +199,12 @@ func NewWebSearchTool(config WebSearchToolConfig, logger *zap.Logger) (ToolFunc,
 				},
 				"required": ["query"]
 			}`),
+			Traits: &types.ToolTraits{
+				SideEffect:   types.ToolSideEffectNone,
+				Idempotent:   true,
+				LatencyClass: types.ToolLatencyMedium,
+				CostClass:    types.ToolCostLow,
+			},
 		},
 		Timeout:     config.Timeout,
 		RateLimit:   config.RateLimit,
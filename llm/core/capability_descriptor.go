@@ -0,0 +1,214 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// 输入模态名称，与 types.ModelDescriptor.InputModalities 保持一致。
+const (
+	ModalityText  = "text"
+	ModalityImage = "image"
+	ModalityAudio = "audio"
+	ModalityVideo = "video"
+)
+
+// CapabilityDescriptor 描述 provider（或具体模型）在对话接口上可承载的能力，
+// 供路由器/网关在请求发往上游之前做能力协商。
+type CapabilityDescriptor struct {
+	MaxContextTokens           int      `json:"max_context_tokens,omitempty"` // 上下文窗口，0 表示未知
+	MaxOutputTokens            int      `json:"max_output_tokens,omitempty"`  // 单次最大输出，0 表示未知
+	SupportsVision             bool     `json:"supports_vision"`
+	SupportsTools              bool     `json:"supports_tools"`
+	SupportsJSONMode           bool     `json:"supports_json_mode"`
+	SupportsStreamingToolCalls bool     `json:"supports_streaming_tool_calls"`
+	Modalities                 []string `json:"modalities,omitempty"` // 支持的输入模态，为空表示不限制
+}
+
+// CapabilityDescriber 由 provider 实现，声明自身的对话能力。
+type CapabilityDescriber interface {
+	Capabilities() CapabilityDescriptor
+}
+
+// SupportsModality 报告是否支持指定输入模态；Modalities 为空时视为不限制。
+func (d CapabilityDescriptor) SupportsModality(modality string) bool {
+	if len(d.Modalities) == 0 {
+		return true
+	}
+	return slices.Contains(d.Modalities, modality)
+}
+
+// DescribeProvider 返回 provider 的能力描述。
+// 未实现 CapabilityDescriber 的 provider 按宽松默认值处理，仅工具能力取自 SupportsNativeFunctionCalling。
+func DescribeProvider(p Provider) CapabilityDescriptor {
+	if p == nil {
+		return CapabilityDescriptor{}
+	}
+	if d, ok := p.(CapabilityDescriber); ok {
+		return d.Capabilities()
+	}
+	tools := p.SupportsNativeFunctionCalling()
+	return CapabilityDescriptor{
+		SupportsVision:             true,
+		SupportsTools:              tools,
+		SupportsJSONMode:           true,
+		SupportsStreamingToolCalls: tools,
+	}
+}
+
+// CapabilityRegistry 维护 provider / 模型级能力描述覆盖。
+// 查找顺序：provider+model 注册项 → provider 注册项 → provider 自身声明。
+type CapabilityRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]CapabilityDescriptor
+	models    map[string]CapabilityDescriptor
+}
+
+// NewCapabilityRegistry 创建空的能力注册表。
+func NewCapabilityRegistry() *CapabilityRegistry {
+	return &CapabilityRegistry{
+		providers: make(map[string]CapabilityDescriptor),
+		models:    make(map[string]CapabilityDescriptor),
+	}
+}
+
+// Register 注册 provider 级能力描述，覆盖 provider 自身声明。
+func (r *CapabilityRegistry) Register(provider string, desc CapabilityDescriptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[normalizeCapabilityKey(provider)] = desc
+}
+
+// RegisterModel 注册模型级能力描述，优先级最高。
+func (r *CapabilityRegistry) RegisterModel(provider, model string, desc CapabilityDescriptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[normalizeCapabilityKey(provider)+"/"+normalizeCapabilityKey(model)] = desc
+}
+
+// Resolve 解析 provider/model 的最终能力描述；p 用于回退到 provider 自身声明，可为 nil。
+func (r *CapabilityRegistry) Resolve(provider, model string, p Provider) CapabilityDescriptor {
+	if r != nil {
+		r.mu.RLock()
+		desc, ok := r.models[normalizeCapabilityKey(provider)+"/"+normalizeCapabilityKey(model)]
+		if !ok {
+			desc, ok = r.providers[normalizeCapabilityKey(provider)]
+		}
+		r.mu.RUnlock()
+		if ok {
+			return desc
+		}
+	}
+	return DescribeProvider(p)
+}
+
+// Negotiate 按 provider/model 的能力描述协商请求，见 NegotiateCapabilities。
+func (r *CapabilityRegistry) Negotiate(provider, model string, p Provider, req *ChatRequest, stream bool) (*ChatRequest, error) {
+	return NegotiateCapabilities(r.Resolve(provider, model, p), req, stream)
+}
+
+// NegotiateCapabilities 在请求发往上游之前按能力描述协商：
+//   - 可降级的差异会被改写：不支持 JSON 模式时改为提示词约束，MaxTokens 超过输出上限时截断；
+//   - 无法降级的差异直接返回 ErrCapabilityUnsupported / ErrContextTooLong，而不是让上游返回含糊的错误。
+//
+// 需要改写时返回浅拷贝后的新请求，原请求不会被修改。
+func NegotiateCapabilities(desc CapabilityDescriptor, req *ChatRequest, stream bool) (*ChatRequest, error) {
+	if req == nil {
+		return nil, nil
+	}
+
+	for _, msg := range req.Messages {
+		if len(msg.Images) > 0 && (!desc.SupportsVision || !desc.SupportsModality(ModalityImage)) {
+			return nil, capabilityUnsupportedError(req, "image input")
+		}
+		if len(msg.Videos) > 0 && !desc.SupportsModality(ModalityVideo) {
+			return nil, capabilityUnsupportedError(req, "video input")
+		}
+	}
+
+	nativeTools := len(req.Tools) > 0 && req.ToolCallMode != ToolCallModeXML
+	if nativeTools && !desc.SupportsTools {
+		return nil, capabilityUnsupportedError(req, "native tool calling")
+	}
+	if nativeTools && stream && !desc.SupportsStreamingToolCalls {
+		return nil, capabilityUnsupportedError(req, "streaming tool calls")
+	}
+
+	if desc.MaxContextTokens > 0 {
+		// 输出预算按协商后的上限计算（下面会把超过 MaxOutputTokens 的请求收敛到上限）
+		completionBudget := req.MaxTokens
+		if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > 0 {
+			completionBudget = *req.MaxCompletionTokens
+		}
+		if desc.MaxOutputTokens > 0 && completionBudget > desc.MaxOutputTokens {
+			completionBudget = desc.MaxOutputTokens
+		}
+		tokenizer := types.NewEstimateTokenizer()
+		promptTokens := tokenizer.CountMessagesTokens(req.Messages) + tokenizer.EstimateToolTokens(req.Tools)
+		if promptTokens+completionBudget > desc.MaxContextTokens {
+			return nil, types.NewError(ErrContextTooLong,
+				fmt.Sprintf("estimated %d prompt tokens plus %d completion tokens exceeds the %d token context window of model %q",
+					promptTokens, completionBudget, desc.MaxContextTokens, req.Model)).
+				WithHTTPStatus(http.StatusRequestEntityTooLarge).
+				WithRetryable(false)
+		}
+	}
+
+	out := req
+	clone := func() {
+		if out == req {
+			cp := *req
+			out = &cp
+		}
+	}
+
+	if desc.MaxOutputTokens > 0 {
+		if req.MaxTokens > desc.MaxOutputTokens {
+			clone()
+			out.MaxTokens = desc.MaxOutputTokens
+		}
+		if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > desc.MaxOutputTokens {
+			clone()
+			limit := desc.MaxOutputTokens
+			out.MaxCompletionTokens = &limit
+		}
+	}
+
+	if rf := req.ResponseFormat; rf != nil && rf.Type != ResponseFormatText && !desc.SupportsJSONMode {
+		clone()
+		out.ResponseFormat = nil
+		out.Messages = append([]Message{{Role: RoleSystem, Content: jsonModeInstruction(rf)}}, req.Messages...)
+	}
+
+	return out, nil
+}
+
+// jsonModeInstruction 生成替代原生 JSON 模式的系统提示。
+func jsonModeInstruction(rf *ResponseFormat) string {
+	var b strings.Builder
+	b.WriteString("Respond only with a single valid JSON value. Do not wrap it in markdown or add any other text.")
+	if rf.JSONSchema != nil && len(rf.JSONSchema.Schema) > 0 {
+		if schema, err := json.Marshal(rf.JSONSchema.Schema); err == nil {
+			b.WriteString(" The JSON must conform to this JSON Schema: ")
+			b.Write(schema)
+		}
+	}
+	return b.String()
+}
+
+func capabilityUnsupportedError(req *ChatRequest, feature string) *types.Error {
+	return types.NewError(ErrCapabilityUnsupported,
+		fmt.Sprintf("model %q does not support %s", req.Model, feature)).
+		WithHTTPStatus(http.StatusBadRequest).
+		WithRetryable(false)
+}
+
+func normalizeCapabilityKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
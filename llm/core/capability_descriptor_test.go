@@ -0,0 +1,182 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describedProvider struct {
+	scriptedStreamProvider
+	desc CapabilityDescriptor
+}
+
+func (p *describedProvider) Capabilities() CapabilityDescriptor { return p.desc }
+
+func TestDescribeProvider(t *testing.T) {
+	desc := CapabilityDescriptor{SupportsTools: true, Modalities: []string{ModalityText}}
+	assert.Equal(t, desc, DescribeProvider(&describedProvider{desc: desc}))
+
+	fallback := DescribeProvider(&scriptedStreamProvider{})
+	assert.True(t, fallback.SupportsTools)
+	assert.True(t, fallback.SupportsVision)
+	assert.True(t, fallback.SupportsModality(ModalityVideo))
+
+	wrapped := &ThoughtSignatureMiddleware{provider: &describedProvider{desc: desc}}
+	assert.Equal(t, desc, DescribeProvider(wrapped))
+}
+
+func TestCapabilityRegistry_ResolvePrecedence(t *testing.T) {
+	self := CapabilityDescriptor{SupportsTools: true}
+	p := &describedProvider{desc: self}
+	registry := NewCapabilityRegistry()
+
+	assert.Equal(t, self, registry.Resolve("openai", "gpt-4o", p))
+
+	providerLevel := CapabilityDescriptor{SupportsVision: true}
+	registry.Register("OpenAI", providerLevel)
+	assert.Equal(t, providerLevel, registry.Resolve("openai", "gpt-4o", p))
+
+	modelLevel := CapabilityDescriptor{MaxContextTokens: 128000}
+	registry.RegisterModel("openai", "GPT-4o", modelLevel)
+	assert.Equal(t, modelLevel, registry.Resolve("openai", "gpt-4o", p))
+	assert.Equal(t, providerLevel, registry.Resolve("openai", "gpt-4.1", p))
+
+	var nilRegistry *CapabilityRegistry
+	assert.Equal(t, self, nilRegistry.Resolve("openai", "gpt-4o", p))
+}
+
+func TestNegotiateCapabilities_Rejects(t *testing.T) {
+	textOnly := CapabilityDescriptor{SupportsTools: true, SupportsJSONMode: true, Modalities: []string{ModalityText}}
+	tools := []ToolSchema{{Name: "lookup"}}
+
+	tests := []struct {
+		name string
+		desc CapabilityDescriptor
+		req  *ChatRequest
+		want types.ErrorCode
+	}{
+		{
+			name: "image input",
+			desc: textOnly,
+			req:  &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Images: []types.ImageContent{{URL: "https://x/y.png"}}}}},
+			want: ErrCapabilityUnsupported,
+		},
+		{
+			name: "video input",
+			desc: CapabilityDescriptor{SupportsVision: true, Modalities: []string{ModalityText, ModalityImage}},
+			req:  &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Videos: []types.VideoContent{{URL: "https://x/y.mp4"}}}}},
+			want: ErrCapabilityUnsupported,
+		},
+		{
+			name: "native tools",
+			desc: CapabilityDescriptor{},
+			req:  &ChatRequest{Model: "m", Tools: tools},
+			want: ErrCapabilityUnsupported,
+		},
+		{
+			name: "completion budget beyond context window",
+			desc: CapabilityDescriptor{MaxContextTokens: 1000},
+			req:  &ChatRequest{Model: "m", MaxTokens: 4000},
+			want: ErrContextTooLong,
+		},
+		{
+			name: "prompt plus completion beyond context window",
+			desc: CapabilityDescriptor{MaxContextTokens: 1000},
+			req:  &ChatRequest{Model: "m", MaxTokens: 600, Messages: []Message{{Role: RoleUser, Content: strings.Repeat("word ", 400)}}},
+			want: ErrContextTooLong,
+		},
+		{
+			name: "long prompt without completion budget",
+			desc: CapabilityDescriptor{MaxContextTokens: 1000},
+			req:  &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: strings.Repeat("word ", 1000)}}},
+			want: ErrContextTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := NegotiateCapabilities(tt.desc, tt.req, false)
+			require.Error(t, err)
+			assert.Nil(t, out)
+			assert.True(t, types.IsErrorCode(err, tt.want), "got %v", err)
+		})
+	}
+}
+
+func TestNegotiateCapabilities_StreamingToolCalls(t *testing.T) {
+	desc := CapabilityDescriptor{SupportsTools: true}
+	req := &ChatRequest{Model: "m", Tools: []ToolSchema{{Name: "lookup"}}}
+
+	_, err := NegotiateCapabilities(desc, req, false)
+	require.NoError(t, err)
+
+	_, err = NegotiateCapabilities(desc, req, true)
+	assert.True(t, types.IsErrorCode(err, ErrCapabilityUnsupported))
+
+	// XML 工具模式不依赖原生工具调用
+	req.ToolCallMode = ToolCallModeXML
+	_, err = NegotiateCapabilities(CapabilityDescriptor{}, req, true)
+	assert.NoError(t, err)
+}
+
+func TestNegotiateCapabilities_Transforms(t *testing.T) {
+	maxCompletion := 9000
+	req := &ChatRequest{
+		Model:               "m",
+		MaxTokens:           9000,
+		MaxCompletionTokens: &maxCompletion,
+		Messages:            []Message{{Role: RoleUser, Content: "hi"}},
+		ResponseFormat: &ResponseFormat{
+			Type:       ResponseFormatJSONSchema,
+			JSONSchema: &types.JSONSchemaParam{Name: "answer", Schema: map[string]any{"type": "object"}},
+		},
+	}
+	desc := CapabilityDescriptor{MaxOutputTokens: 4096}
+
+	out, err := NegotiateCapabilities(desc, req, false)
+	require.NoError(t, err)
+	require.NotSame(t, req, out)
+
+	assert.Equal(t, 4096, out.MaxTokens)
+	assert.Equal(t, 4096, *out.MaxCompletionTokens)
+	assert.Nil(t, out.ResponseFormat)
+	require.Len(t, out.Messages, 2)
+	assert.Equal(t, RoleSystem, out.Messages[0].Role)
+	assert.Contains(t, out.Messages[0].Content, `{"type":"object"}`)
+
+	// 原请求保持不变
+	assert.Equal(t, 9000, req.MaxTokens)
+	assert.Equal(t, 9000, maxCompletion)
+	assert.NotNil(t, req.ResponseFormat)
+	assert.Len(t, req.Messages, 1)
+}
+
+func TestNegotiateCapabilities_ContextWindowUsesNegotiatedOutput(t *testing.T) {
+	desc := CapabilityDescriptor{MaxContextTokens: 1000, MaxOutputTokens: 500}
+	req := &ChatRequest{Model: "m", MaxTokens: 4000, Messages: []Message{{Role: RoleUser, Content: strings.Repeat("word ", 200)}}}
+
+	// 输出预算先收敛到 MaxOutputTokens，250 + 500 仍在窗口内
+	out, err := NegotiateCapabilities(desc, req, false)
+	require.NoError(t, err)
+	assert.Equal(t, 500, out.MaxTokens)
+}
+
+func TestNegotiateCapabilities_PassThrough(t *testing.T) {
+	req := &ChatRequest{
+		Model:          "m",
+		Tools:          []ToolSchema{{Name: "lookup"}},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+		Messages:       []Message{{Role: RoleUser, Images: []types.ImageContent{{URL: "https://x/y.png"}}}},
+	}
+	desc := DescribeProvider(&scriptedStreamProvider{})
+
+	out, err := NegotiateCapabilities(desc, req, true)
+	require.NoError(t, err)
+	assert.Same(t, req, out)
+
+	_, err = NewCapabilityRegistry().Negotiate("scripted", "m", &scriptedStreamProvider{}, req, true)
+	assert.NoError(t, err)
+}
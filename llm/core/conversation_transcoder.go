@@ -0,0 +1,454 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// Dialect 表示上游对消息历史的结构约束（角色顺序、工具调用配对、推理状态回传等）。
+type Dialect string
+
+const (
+	// DialectOpenAI OpenAI Chat Completions 及其兼容协议：system/developer 可出现在任意位置，
+	// 每个 tool_calls 必须紧跟对应的 tool 消息。
+	DialectOpenAI Dialect = "openai"
+	// DialectAnthropic Anthropic Messages：system 单独提取，user/assistant 交替，
+	// tool_result 必须紧跟对应的 tool_use，thinking 块必须带签名。
+	DialectAnthropic Dialect = "anthropic"
+	// DialectGemini Gemini generateContent：仅一条 systemInstruction，functionResponse 需要函数名。
+	DialectGemini Dialect = "gemini"
+)
+
+// DialectForProvider 返回 provider 编码对应的消息方言，未知 provider 按 OpenAI 兼容协议处理。
+func DialectForProvider(provider string) Dialect {
+	switch normalizeCapabilityKey(provider) {
+	case "anthropic", "claude":
+		return DialectAnthropic
+	case "gemini", "google", "vertex", "vertexai":
+		return DialectGemini
+	default:
+		return DialectOpenAI
+	}
+}
+
+const (
+	maxToolCallIDLength = 40
+	// incompleteToolResult 是为缺少结果的工具调用补齐的占位结果。
+	incompleteToolResult = "Tool call did not complete before the conversation was transferred to another model."
+	// continuationPrompt 是历史以 assistant 开头时补齐的首条 user 消息。
+	continuationPrompt = "(conversation continued)"
+)
+
+// TranscodeTarget 描述历史要转换到的目标 provider。
+type TranscodeTarget struct {
+	Dialect  Dialect
+	Provider string // provider 编码，用于判断不透明推理状态是否可回传；为空时取 Dialect
+	// Capabilities 可选：目标模型不支持的历史图片/视频会被替换为文本说明。
+	// 最后一条 user 消息保持原样，是否可承载交由能力协商决定。
+	Capabilities *CapabilityDescriptor
+}
+
+// TranscodeReport 汇总一次转换中对历史做的改写。
+type TranscodeReport struct {
+	RenamedToolCallIDs     int `json:"renamed_tool_call_ids,omitempty"`
+	SynthesizedToolResults int `json:"synthesized_tool_results,omitempty"`
+	OrphanedToolResults    int `json:"orphaned_tool_results,omitempty"`
+	ReorderedToolResults   int `json:"reordered_tool_results,omitempty"`
+	HoistedSystemMessages  int `json:"hoisted_system_messages,omitempty"`
+	MergedMessages         int `json:"merged_messages,omitempty"`
+	InsertedMessages       int `json:"inserted_messages,omitempty"`
+	DroppedReasoning       int `json:"dropped_reasoning,omitempty"`
+	OmittedMedia           int `json:"omitted_media,omitempty"`
+}
+
+// Changed 报告转换是否改写了历史。
+func (r TranscodeReport) Changed() bool {
+	return r != TranscodeReport{}
+}
+
+// ConversationTranscoder 把累积的统一格式消息历史改写为目标 provider 可接受的形状，
+// 使路由器可以在对话中途切换 provider（例如 OpenAI → Anthropic）而不丢失工具调用状态：
+//   - 工具调用 ID 规范化为各家都接受的字符集与长度，并同步改写对应的 tool 结果；
+//   - tool 结果移动到发起调用的 assistant 消息之后，缺失的结果补齐为错误结果，
+//     找不到调用方的孤立结果降级为 user 文本；
+//   - Anthropic/Gemini 目标会合并 system/developer 消息、合并连续同角色消息并保证首条为 user；
+//   - 只保留目标 provider 能验证的推理状态（签名 thinking 块、同源不透明推理）。
+//
+// 转换不修改输入切片。
+type ConversationTranscoder struct{}
+
+// NewConversationTranscoder 创建对话历史转换器。
+func NewConversationTranscoder() *ConversationTranscoder {
+	return &ConversationTranscoder{}
+}
+
+// TranscodeRequest 转换请求的消息历史；有改写时返回浅拷贝后的新请求，原请求不会被修改。
+func (t *ConversationTranscoder) TranscodeRequest(req *ChatRequest, target TranscodeTarget) (*ChatRequest, TranscodeReport) {
+	if req == nil || len(req.Messages) == 0 {
+		return req, TranscodeReport{}
+	}
+	messages, report := t.Transcode(req.Messages, target)
+	if !report.Changed() {
+		return req, report
+	}
+	cp := *req
+	cp.Messages = messages
+	return &cp, report
+}
+
+// Transcode 将消息历史转换为目标方言。
+func (t *ConversationTranscoder) Transcode(messages []Message, target TranscodeTarget) ([]Message, TranscodeReport) {
+	var report TranscodeReport
+	if len(messages) == 0 {
+		return messages, report
+	}
+	if target.Dialect == "" {
+		target.Dialect = DialectForProvider(target.Provider)
+	}
+	if target.Provider == "" {
+		target.Provider = string(target.Dialect)
+	}
+
+	out := make([]Message, 0, len(messages))
+	lastUser := lastUserIndex(messages)
+	for i, msg := range messages {
+		if dropped := filterReasoning(&msg, target); dropped > 0 {
+			report.DroppedReasoning += dropped
+		}
+		if target.Capabilities != nil && i < lastUser {
+			report.OmittedMedia += omitUnsupportedMedia(&msg, *target.Capabilities)
+		}
+		if target.Dialect == DialectAnthropic && msg.Role == RoleAssistant && len(msg.Images) > 0 {
+			// Anthropic 不接受 assistant 消息中的图片块
+			report.OmittedMedia += omitImages(&msg, "assistant images are not accepted by the target model")
+		}
+		out = append(out, msg)
+	}
+
+	out = pairToolResults(out, &report)
+	if target.Dialect == DialectAnthropic || target.Dialect == DialectGemini {
+		out = hoistSystemMessages(out, &report)
+		out = enforceAlternation(out, &report)
+	}
+	return out, report
+}
+
+// filterReasoning 丢弃目标 provider 无法验证或不应回放的推理状态，返回丢弃的条目数。
+func filterReasoning(msg *Message, target TranscodeTarget) int {
+	if msg.Role != RoleAssistant {
+		return 0
+	}
+	dropped := 0
+
+	if len(msg.ThinkingBlocks) > 0 {
+		kept := make([]ThinkingBlock, 0, len(msg.ThinkingBlocks))
+		for _, tb := range msg.ThinkingBlocks {
+			// 只有 Anthropic 回传 thinking 块，且未签名的块会被拒绝
+			if target.Dialect == DialectAnthropic && strings.TrimSpace(tb.Signature) != "" {
+				kept = append(kept, tb)
+				continue
+			}
+			dropped++
+		}
+		msg.ThinkingBlocks = nilIfEmpty(kept)
+	}
+
+	sameOrigin := false
+	if len(msg.OpaqueReasoning) > 0 {
+		kept := make([]OpaqueReasoning, 0, len(msg.OpaqueReasoning))
+		for _, opaque := range msg.OpaqueReasoning {
+			provider := strings.TrimSpace(opaque.Provider)
+			if provider == "" || normalizeCapabilityKey(provider) == normalizeCapabilityKey(target.Provider) ||
+				(target.Dialect != DialectOpenAI && DialectForProvider(provider) == target.Dialect) {
+				kept = append(kept, opaque)
+				sameOrigin = sameOrigin || provider != ""
+				continue
+			}
+			dropped++
+		}
+		msg.OpaqueReasoning = nilIfEmpty(kept)
+	}
+
+	// Gemini 会把 reasoning_content 作为模型自身的 thought 回放，异源推理没有对应签名
+	if target.Dialect == DialectGemini && msg.ReasoningContent != nil && !sameOrigin {
+		msg.ReasoningContent = nil
+		dropped++
+	}
+	return dropped
+}
+
+// omitUnsupportedMedia 按能力描述把历史中的图片/视频替换为文本说明，返回替换的条目数。
+func omitUnsupportedMedia(msg *Message, desc CapabilityDescriptor) int {
+	omitted := 0
+	if len(msg.Images) > 0 && (!desc.SupportsVision || !desc.SupportsModality(ModalityImage)) {
+		omitted += omitImages(msg, "the target model does not accept image input")
+	}
+	if len(msg.Videos) > 0 && !desc.SupportsModality(ModalityVideo) {
+		omitted += len(msg.Videos)
+		msg.Content = appendNote(msg.Content, fmt.Sprintf("[%d video(s) omitted: the target model does not accept video input]", len(msg.Videos)))
+		msg.Videos = nil
+	}
+	return omitted
+}
+
+func omitImages(msg *Message, reason string) int {
+	n := len(msg.Images)
+	msg.Content = appendNote(msg.Content, fmt.Sprintf("[%d image(s) omitted: %s]", n, reason))
+	msg.Images = nil
+	return n
+}
+
+// pairToolResults 规范化工具调用 ID，并保证每个工具调用后紧跟且仅跟一条对应结果。
+func pairToolResults(messages []Message, report *TranscodeReport) []Message {
+	ids := newToolCallIDMapper()
+	// 预先登记所有合法 ID，避免生成的新 ID 与其冲突
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			ids.reserve(tc.ID)
+		}
+	}
+
+	consumed := make([]bool, len(messages))
+	out := make([]Message, 0, len(messages))
+	for i, msg := range messages {
+		if consumed[i] {
+			continue
+		}
+		switch {
+		case msg.Role == RoleAssistant && len(msg.ToolCalls) > 0:
+			msg.ToolCalls = append([]ToolCall(nil), msg.ToolCalls...)
+			for j := range msg.ToolCalls {
+				msg.ToolCalls[j].ID = ids.normalize(messages[i].ToolCalls[j].ID, i, j)
+				if msg.ToolCalls[j].ID != messages[i].ToolCalls[j].ID {
+					report.RenamedToolCallIDs++
+				}
+			}
+			out = append(out, msg)
+			for j, tc := range msg.ToolCalls {
+				idx := findToolResult(messages, consumed, i+1, messages[i].ToolCalls[j].ID)
+				if idx < 0 {
+					report.SynthesizedToolResults++
+					out = append(out, Message{
+						Role:        RoleTool,
+						ToolCallID:  tc.ID,
+						Name:        tc.Name,
+						Content:     incompleteToolResult,
+						IsToolError: true,
+					})
+					continue
+				}
+				if idx != i+1+j {
+					report.ReorderedToolResults++
+				}
+				consumed[idx] = true
+				result := messages[idx]
+				result.ToolCallID = tc.ID
+				if strings.TrimSpace(result.Name) == "" {
+					result.Name = tc.Name
+				}
+				out = append(out, result)
+			}
+		case msg.Role == RoleTool:
+			// 调用方缺失（或位于结果之后）的结果无法作为 tool 结果回传
+			report.OrphanedToolResults++
+			out = append(out, Message{
+				Role:      RoleUser,
+				Content:   orphanedToolResultText(msg),
+				Timestamp: msg.Timestamp,
+			})
+		default:
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func findToolResult(messages []Message, consumed []bool, from int, callID string) int {
+	if callID == "" {
+		return -1
+	}
+	for i := from; i < len(messages); i++ {
+		if !consumed[i] && messages[i].Role == RoleTool && messages[i].ToolCallID == callID {
+			return i
+		}
+	}
+	return -1
+}
+
+func orphanedToolResultText(msg Message) string {
+	label := "Tool result"
+	if name := strings.TrimSpace(msg.Name); name != "" {
+		label += " from " + name
+	}
+	if msg.IsToolError {
+		label += " (error)"
+	}
+	return label + ":\n" + msg.Content
+}
+
+// hoistSystemMessages 把 system/developer 消息合并为首条 system 消息。
+func hoistSystemMessages(messages []Message, report *TranscodeReport) []Message {
+	var parts []string
+	count := 0
+	firstIsSystem := len(messages) > 0 && messages[0].Role == RoleSystem
+	rest := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == RoleSystem || msg.Role == RoleDeveloper {
+			count++
+			if strings.TrimSpace(msg.Content) != "" {
+				parts = append(parts, msg.Content)
+			}
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	if count == 0 || (count == 1 && firstIsSystem) {
+		return messages
+	}
+	report.HoistedSystemMessages += count
+	if len(parts) == 0 {
+		return rest
+	}
+	return append([]Message{{Role: RoleSystem, Content: strings.Join(parts, "\n\n")}}, rest...)
+}
+
+// enforceAlternation 合并连续的同角色消息，并保证首条对话消息为 user。
+// tool 结果按 user 轮次处理；带推理状态或工具调用的 assistant 消息不参与合并，以免破坏签名与配对。
+func enforceAlternation(messages []Message, report *TranscodeReport) []Message {
+	out := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role != RoleSystem && !hasConversationTurn(out) && msg.Role == RoleAssistant {
+			report.InsertedMessages++
+			out = append(out, Message{Role: RoleUser, Content: continuationPrompt})
+		}
+		if n := len(out); n > 0 && mergeable(out[n-1], msg) {
+			prev := &out[n-1]
+			prev.Content = joinContent(prev.Content, msg.Content)
+			prev.Images = append(append([]types.ImageContent(nil), prev.Images...), msg.Images...)
+			prev.Videos = append(append([]types.VideoContent(nil), prev.Videos...), msg.Videos...)
+			prev.ToolCalls = append(prev.ToolCalls, msg.ToolCalls...)
+			report.MergedMessages++
+			continue
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func hasConversationTurn(messages []Message) bool {
+	for _, msg := range messages {
+		if msg.Role != RoleSystem {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeable(prev, next Message) bool {
+	switch {
+	case prev.Role == RoleUser && next.Role == RoleUser:
+		return true
+	case prev.Role == RoleAssistant && next.Role == RoleAssistant:
+		return len(prev.ToolCalls) == 0 && !hasReasoningState(prev) && !hasReasoningState(next)
+	default:
+		return false
+	}
+}
+
+func hasReasoningState(msg Message) bool {
+	return len(msg.ThinkingBlocks) > 0 || len(msg.OpaqueReasoning) > 0 || msg.ReasoningContent != nil
+}
+
+func lastUserIndex(messages []Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+func joinContent(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "\n\n" + b
+	}
+}
+
+func appendNote(content, note string) string {
+	if content == "" {
+		return note
+	}
+	return content + "\n" + note
+}
+
+func nilIfEmpty[T any](items []T) []T {
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+// toolCallIDMapper 把工具调用 ID 规范化为 [A-Za-z0-9_-]{1,40}（Anthropic 字符集与 OpenAI 长度上限的交集），
+// 同一原始 ID 始终映射为同一结果。
+type toolCallIDMapper struct {
+	mapped map[string]string
+	used   map[string]bool
+}
+
+func newToolCallIDMapper() *toolCallIDMapper {
+	return &toolCallIDMapper{mapped: make(map[string]string), used: make(map[string]bool)}
+}
+
+func (m *toolCallIDMapper) reserve(id string) {
+	if id != "" && validToolCallID(id) {
+		m.used[id] = true
+	}
+}
+
+func (m *toolCallIDMapper) normalize(id string, msgIndex, callIndex int) string {
+	if id != "" {
+		if validToolCallID(id) {
+			return id
+		}
+		if mapped, ok := m.mapped[id]; ok {
+			return mapped
+		}
+	}
+	seed := id
+	if seed == "" {
+		seed = fmt.Sprintf("%d:%d", msgIndex, callIndex)
+	}
+	sum := sha256.Sum256([]byte(seed))
+	candidate := "call_" + hex.EncodeToString(sum[:12])
+	for n := 1; m.used[candidate]; n++ {
+		candidate = fmt.Sprintf("call_%s_%d", hex.EncodeToString(sum[:10]), n)
+	}
+	m.used[candidate] = true
+	if id != "" {
+		m.mapped[id] = candidate
+	}
+	return candidate
+}
+
+func validToolCallID(id string) bool {
+	if len(id) == 0 || len(id) > maxToolCallIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
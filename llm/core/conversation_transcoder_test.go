@@ -0,0 +1,141 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectForProvider(t *testing.T) {
+	assert.Equal(t, DialectAnthropic, DialectForProvider(" Claude "))
+	assert.Equal(t, DialectGemini, DialectForProvider("gemini"))
+	assert.Equal(t, DialectOpenAI, DialectForProvider("deepseek"))
+}
+
+func TestConversationTranscoder_OpenAIToAnthropic(t *testing.T) {
+	reasoning := "thinking in openai"
+	history := []Message{
+		{Role: RoleDeveloper, Content: "be terse"},
+		{Role: RoleUser, Content: "check weather and time", Images: []types.ImageContent{{Type: "url", URL: "https://x/map.png"}}},
+		{
+			Role:             RoleAssistant,
+			ReasoningContent: &reasoning,
+			OpaqueReasoning:  []OpaqueReasoning{{Provider: "openai", Kind: "encrypted_content", State: "enc"}},
+			ToolCalls: []ToolCall{
+				{ID: "call_weather", Name: "weather", Arguments: []byte(`{"city":"Paris"}`)},
+				{ID: "call.time/1", Name: "time", Arguments: []byte(`{}`)},
+			},
+		},
+		{Role: RoleSystem, Content: "tools may be slow"},
+		{Role: RoleTool, ToolCallID: "call.time/1", Content: "12:00"},
+		{Role: RoleTool, ToolCallID: "call_unknown", Name: "lookup", Content: "stale"},
+		{Role: RoleUser, Content: "thanks"},
+		{Role: RoleUser, Content: "anything else?"},
+	}
+
+	out, report := NewConversationTranscoder().Transcode(history, TranscodeTarget{Provider: "anthropic"})
+
+	require.Len(t, out, 6)
+	assert.Equal(t, Message{Role: RoleSystem, Content: "be terse\n\ntools may be slow"}, out[0])
+	assert.Len(t, out[1].Images, 1, "user images are kept when no capability limits are given")
+
+	assistant := out[2]
+	assert.Nil(t, assistant.OpaqueReasoning)
+	assert.Equal(t, "call_weather", assistant.ToolCalls[0].ID)
+	timeID := assistant.ToolCalls[1].ID
+	assert.NotEqual(t, "call.time/1", timeID)
+	assert.True(t, validToolCallID(timeID))
+
+	assert.Equal(t, RoleTool, out[3].Role)
+	assert.Equal(t, "call_weather", out[3].ToolCallID)
+	assert.True(t, out[3].IsToolError, "missing result is synthesized as an error")
+	assert.Equal(t, timeID, out[4].ToolCallID)
+	assert.Equal(t, "time", out[4].Name)
+
+	assert.Equal(t, RoleUser, out[5].Role)
+	assert.Contains(t, out[5].Content, "Tool result from lookup:\nstale")
+	assert.Contains(t, out[5].Content, "thanks\n\nanything else?")
+
+	assert.Equal(t, 1, report.RenamedToolCallIDs)
+	assert.Equal(t, 1, report.SynthesizedToolResults)
+	assert.Equal(t, 1, report.OrphanedToolResults)
+	assert.Equal(t, 2, report.HoistedSystemMessages)
+	assert.Equal(t, 2, report.MergedMessages)
+	assert.Equal(t, 1, report.DroppedReasoning)
+
+	assert.Equal(t, "call.time/1", history[2].ToolCalls[1].ID, "input history must not be mutated")
+	assert.NotNil(t, history[2].OpaqueReasoning)
+}
+
+func TestConversationTranscoder_AnthropicToOpenAIDropsThinking(t *testing.T) {
+	history := []Message{
+		{Role: RoleUser, Content: "hi"},
+		{
+			Role:            RoleAssistant,
+			Content:         "hello",
+			ThinkingBlocks:  []ThinkingBlock{{Thinking: "greet", Signature: "sig"}},
+			OpaqueReasoning: []OpaqueReasoning{{Provider: "anthropic", Kind: "redacted_thinking", State: "x"}},
+		},
+		{Role: RoleUser, Content: "again"},
+	}
+
+	out, report := NewConversationTranscoder().Transcode(history, TranscodeTarget{Provider: "openai"})
+	require.Len(t, out, 3)
+	assert.Nil(t, out[1].ThinkingBlocks)
+	assert.Nil(t, out[1].OpaqueReasoning)
+	assert.Equal(t, 2, report.DroppedReasoning)
+
+	back, report := NewConversationTranscoder().Transcode(history, TranscodeTarget{Provider: "anthropic"})
+	assert.Equal(t, history, back)
+	assert.False(t, report.Changed())
+}
+
+func TestConversationTranscoder_GeminiNeedsLeadingUserAndSignedThoughts(t *testing.T) {
+	reasoning := "foreign thought"
+	history := []Message{
+		{Role: RoleSystem, Content: "sys"},
+		{Role: RoleAssistant, Content: "welcome back", ReasoningContent: &reasoning},
+		{Role: RoleUser, Content: "go on"},
+	}
+
+	out, report := NewConversationTranscoder().Transcode(history, TranscodeTarget{Dialect: DialectGemini})
+	require.Len(t, out, 4)
+	assert.Equal(t, RoleSystem, out[0].Role)
+	assert.Equal(t, Message{Role: RoleUser, Content: continuationPrompt}, out[1])
+	assert.Nil(t, out[2].ReasoningContent)
+	assert.Equal(t, 1, report.InsertedMessages)
+	assert.Equal(t, 1, report.DroppedReasoning)
+}
+
+func TestConversationTranscoder_OmitsHistoryMediaOnlyBeforeLastUserTurn(t *testing.T) {
+	desc := CapabilityDescriptor{SupportsTools: true, Modalities: []string{ModalityText}}
+	history := []Message{
+		{Role: RoleUser, Content: "what is this", Images: []types.ImageContent{{URL: "https://x/a.png"}}},
+		{Role: RoleAssistant, Content: "a cat"},
+		{Role: RoleUser, Content: "and this", Images: []types.ImageContent{{URL: "https://x/b.png"}}},
+	}
+
+	out, report := NewConversationTranscoder().Transcode(history, TranscodeTarget{Provider: "deepseek", Capabilities: &desc})
+	assert.Nil(t, out[0].Images)
+	assert.Contains(t, out[0].Content, "[1 image(s) omitted")
+	assert.Len(t, out[2].Images, 1, "current turn is left for capability negotiation")
+	assert.Equal(t, 1, report.OmittedMedia)
+}
+
+func TestConversationTranscoder_TranscodeRequest(t *testing.T) {
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	out, report := NewConversationTranscoder().TranscodeRequest(req, TranscodeTarget{Dialect: DialectAnthropic})
+	assert.Same(t, req, out)
+	assert.False(t, report.Changed())
+
+	req.Messages = append(req.Messages, Message{Role: RoleAssistant, ToolCalls: []ToolCall{{Name: "f"}}})
+	out, report = NewConversationTranscoder().TranscodeRequest(req, TranscodeTarget{Dialect: DialectAnthropic})
+	require.NotSame(t, req, out)
+	require.Len(t, out.Messages, 3)
+	assert.NotEmpty(t, out.Messages[1].ToolCalls[0].ID)
+	assert.Equal(t, out.Messages[1].ToolCalls[0].ID, out.Messages[2].ToolCallID)
+	assert.Empty(t, req.Messages[1].ToolCalls[0].ID)
+	assert.Equal(t, 1, report.RenamedToolCallIDs)
+}
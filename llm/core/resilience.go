@@ -233,6 +233,14 @@ func (rp *ResilientProvider) Completion(ctx context.Context, req *ChatRequest) (
 	return resp, nil
 }
 
+// ResumeStream 透传内部 provider 的服务端续流。
+func (rp *ResilientProvider) ResumeStream(ctx context.Context, req *ChatRequest, token StreamResumeToken) (<-chan StreamChunk, error) {
+	if rp.circuitBreaker.State() == CircuitOpen {
+		return nil, ErrCircuitOpen
+	}
+	return ResumeInnerStream(ctx, rp.provider, req, token)
+}
+
 // Stream 执行 streaming 请求（不重试，但记录成功/失败到 circuit breaker）。
 func (rp *ResilientProvider) Stream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
 	if rp.circuitBreaker.State() == CircuitOpen {
@@ -290,6 +298,11 @@ func (rp *ResilientProvider) SupportsNativeFunctionCalling() bool {
 	return rp.provider.SupportsNativeFunctionCalling()
 }
 
+// Capabilities 返回被包装提供者的能力描述.
+func (rp *ResilientProvider) Capabilities() CapabilityDescriptor {
+	return DescribeProvider(rp.provider)
+}
+
 // ListModels 执行提供者 。
 func (rp *ResilientProvider) ListModels(ctx context.Context) ([]Model, error) {
 	return rp.provider.ListModels(ctx)
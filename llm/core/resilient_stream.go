@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	llmpolicy "github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// defaultStreamContinuationPrompt 是回放已生成内容后要求模型续写的提示。
+const defaultStreamContinuationPrompt = "Your previous response was interrupted. Continue exactly where it stopped, without repeating any text already written."
+
+// StreamResumeToken 标识服务端可续传的流位置。
+type StreamResumeToken struct {
+	ResponseID string `json:"response_id"`
+	Sequence   int    `json:"sequence"`
+}
+
+// StreamResumer 由支持服务端续流的 provider 实现，从 token 位置之后继续推送。
+// 无法续传时返回错误，ResilientStream 会改用回放续写；包装型 provider 应透传给内部 provider。
+type StreamResumer interface {
+	ResumeStream(ctx context.Context, req *ChatRequest, token StreamResumeToken) (<-chan StreamChunk, error)
+}
+
+// ResumeInnerStream 供包装型 provider 实现 StreamResumer：inner 支持时透传，否则返回错误。
+func ResumeInnerStream(ctx context.Context, inner Provider, req *ChatRequest, token StreamResumeToken) (<-chan StreamChunk, error) {
+	resumer, ok := inner.(StreamResumer)
+	if !ok {
+		return nil, types.NewServiceUnavailableError("provider does not support server-side stream resume")
+	}
+	return resumer.ResumeStream(ctx, req, token)
+}
+
+// ResilientStreamConfig 配置流式中途失败后的续流行为。
+type ResilientStreamConfig struct {
+	// MaxResumes 是单次请求允许的最大续流次数。
+	MaxResumes int
+	// RetryPolicy 控制续流之间的退避；为空时使用默认策略。
+	RetryPolicy *llmpolicy.RetryPolicy
+	// ContinuationPrompt 是回放模式下追加的续写指令。
+	ContinuationPrompt string
+}
+
+// DefaultResilientStreamConfig 返回默认续流配置。
+func DefaultResilientStreamConfig() *ResilientStreamConfig {
+	return &ResilientStreamConfig{
+		MaxResumes:         2,
+		RetryPolicy:        llmpolicy.DefaultRetryPolicy(),
+		ContinuationPrompt: defaultStreamContinuationPrompt,
+	}
+}
+
+// ResilientStream 包装 provider.Stream：中途出现可重试错误时透明续流。
+// provider 实现 StreamResumer 且已获得响应 ID 时优先使用服务端续传；
+// 否则将已生成的内容作为 assistant 消息回放，并要求模型从断点续写。
+// 已输出工具调用增量的流无法安全回放，此时直接透传分类后的错误。
+func ResilientStream(ctx context.Context, provider Provider, req *ChatRequest, cfg *ResilientStreamConfig, logger *zap.Logger) (<-chan StreamChunk, error) {
+	if cfg == nil {
+		cfg = DefaultResilientStreamConfig()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	source, err := provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &resilientStream{
+		provider: provider,
+		req:      req,
+		cfg:      cfg,
+		logger:   logger,
+		out:      make(chan StreamChunk),
+	}
+	go rs.run(ctx, source)
+	return rs.out, nil
+}
+
+type resilientStream struct {
+	provider Provider
+	req      *ChatRequest
+	cfg      *ResilientStreamConfig
+	logger   *zap.Logger
+	out      chan StreamChunk
+
+	content     strings.Builder
+	responseID  string
+	sequence    int
+	sawToolCall bool
+}
+
+func (rs *resilientStream) run(ctx context.Context, source <-chan StreamChunk) {
+	defer func() {
+		if r := recover(); r != nil {
+			rs.logger.Error("panic in resilient stream goroutine", zap.Any("panic", r))
+		}
+		close(rs.out)
+	}()
+
+	policy := rs.cfg.RetryPolicy
+	if policy == nil {
+		policy = llmpolicy.DefaultRetryPolicy()
+	}
+	backoff := policy.InitialBackoff
+
+	for resumes := 0; ; resumes++ {
+		streamErr, ok := rs.forward(ctx, source)
+		if !ok || streamErr == nil {
+			return
+		}
+		if !streamErr.Retryable || rs.sawToolCall || resumes >= rs.cfg.MaxResumes {
+			rs.emit(ctx, StreamChunk{Err: streamErr})
+			return
+		}
+
+		rs.logger.Warn("stream interrupted, resuming",
+			zap.String("provider", rs.provider.Name()),
+			zap.String("code", string(streamErr.Code)),
+			zap.Int("attempt", resumes+1),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(time.Duration(float64(backoff)*policy.Multiplier), policy.MaxBackoff)
+
+		next, err := rs.resume(ctx)
+		if err != nil {
+			rs.emit(ctx, StreamChunk{Err: ClassifyStreamError(err, rs.provider.Name())})
+			return
+		}
+		source = next
+	}
+}
+
+// forward 转发 source 中的正常块并累积内容，返回遇到的分类错误；ok=false 表示 ctx 已结束。
+func (rs *resilientStream) forward(ctx context.Context, source <-chan StreamChunk) (*types.Error, bool) {
+	for chunk := range source {
+		if chunk.Err != nil {
+			return ClassifyStreamError(chunk.Err, firstNonEmptyString(chunk.Err.Provider, rs.provider.Name())), true
+		}
+		if chunk.ID != "" {
+			rs.responseID = chunk.ID
+		}
+		if chunk.Sequence > 0 {
+			rs.sequence = chunk.Sequence
+		} else {
+			rs.sequence++
+		}
+		rs.content.WriteString(chunk.Delta.Content)
+		if len(chunk.Delta.ToolCalls) > 0 {
+			rs.sawToolCall = true
+		}
+		if !rs.emit(ctx, chunk) {
+			return nil, false
+		}
+	}
+	return nil, true
+}
+
+func (rs *resilientStream) resume(ctx context.Context) (<-chan StreamChunk, error) {
+	if resumer, ok := rs.provider.(StreamResumer); ok && rs.responseID != "" {
+		source, err := resumer.ResumeStream(ctx, rs.req, StreamResumeToken{ResponseID: rs.responseID, Sequence: rs.sequence})
+		if err == nil {
+			return source, nil
+		}
+		// 该响应无法在服务端续传（如未以后台模式创建），退回回放续写。
+		rs.logger.Debug("server-side stream resume unavailable, replaying content",
+			zap.String("provider", rs.provider.Name()), zap.Error(err))
+	}
+	if rs.content.Len() == 0 {
+		return rs.provider.Stream(ctx, rs.req)
+	}
+
+	prompt := rs.cfg.ContinuationPrompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = defaultStreamContinuationPrompt
+	}
+	continued := *rs.req
+	continued.Messages = append(append([]Message(nil), rs.req.Messages...),
+		Message{Role: RoleAssistant, Content: rs.content.String()},
+		Message{Role: RoleUser, Content: prompt},
+	)
+	return rs.provider.Stream(ctx, &continued)
+}
+
+func (rs *resilientStream) emit(ctx context.Context, chunk StreamChunk) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case rs.out <- chunk:
+		return true
+	}
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	llmpolicy "github.com/BaSui01/agentflow/llm/runtime/policy"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scriptedStreamProvider struct {
+	scripts  [][]StreamChunk
+	requests []*ChatRequest
+	resumed  []StreamResumeToken
+}
+
+func (p *scriptedStreamProvider) next(req *ChatRequest) <-chan StreamChunk {
+	p.requests = append(p.requests, req)
+	chunks := p.scripts[0]
+	p.scripts = p.scripts[1:]
+	ch := make(chan StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func (p *scriptedStreamProvider) Completion(context.Context, *ChatRequest) (*ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *scriptedStreamProvider) Stream(_ context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	return p.next(req), nil
+}
+
+func (p *scriptedStreamProvider) HealthCheck(context.Context) (*HealthStatus, error) {
+	return &HealthStatus{Healthy: true}, nil
+}
+func (p *scriptedStreamProvider) Name() string                                { return "scripted" }
+func (p *scriptedStreamProvider) SupportsNativeFunctionCalling() bool         { return true }
+func (p *scriptedStreamProvider) ListModels(context.Context) ([]Model, error) { return nil, nil }
+func (p *scriptedStreamProvider) Endpoints() ProviderEndpoints                { return ProviderEndpoints{} }
+
+type resumableStreamProvider struct {
+	scriptedStreamProvider
+}
+
+func (p *resumableStreamProvider) ResumeStream(_ context.Context, req *ChatRequest, token StreamResumeToken) (<-chan StreamChunk, error) {
+	p.resumed = append(p.resumed, token)
+	return p.next(req), nil
+}
+
+func fastResilientStreamConfig(maxResumes int) *ResilientStreamConfig {
+	return &ResilientStreamConfig{
+		MaxResumes: maxResumes,
+		RetryPolicy: &llmpolicy.RetryPolicy{
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+			Multiplier:     2,
+		},
+	}
+}
+
+func textChunk(id, content string) StreamChunk {
+	return StreamChunk{ID: id, Delta: Message{Role: RoleAssistant, Content: content}}
+}
+
+func collectStream(t *testing.T, ch <-chan StreamChunk) (string, *types.Error) {
+	t.Helper()
+	var text string
+	var lastErr *types.Error
+	for c := range ch {
+		if c.Err != nil {
+			lastErr = c.Err
+			continue
+		}
+		text += c.Delta.Content
+	}
+	return text, lastErr
+}
+
+func TestClassifyStreamError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      types.ErrorCode
+		retryable bool
+	}{
+		{"unexpected eof", io.ErrUnexpectedEOF, types.ErrUpstreamError, true},
+		{"deadline", context.DeadlineExceeded, types.ErrUpstreamTimeout, true},
+		{"canceled", context.Canceled, types.ErrRuntimeAborted, false},
+		{"rate limit message", errors.New("Rate limit reached for requests"), types.ErrRateLimit, true},
+		{"overloaded event", types.NewError(types.ErrUpstreamError, "overloaded_error: Overloaded"), types.ErrModelOverloaded, true},
+		{"context length", types.NewError(types.ErrUpstreamError, "maximum context length exceeded"), types.ErrContextTooLong, false},
+		{"specific code kept", types.NewError(types.ErrAuthentication, "bad key"), types.ErrAuthentication, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyStreamError(tt.err, "p")
+			require.NotNil(t, got)
+			assert.Equal(t, tt.code, got.Code)
+			assert.Equal(t, tt.retryable, got.Retryable)
+		})
+	}
+	assert.Nil(t, ClassifyStreamError(nil, "p"))
+}
+
+func TestResilientStream_ReplaysAccumulatedContent(t *testing.T) {
+	p := &scriptedStreamProvider{scripts: [][]StreamChunk{
+		{textChunk("r1", "Hello, "), {Err: types.NewError(types.ErrUpstreamError, "connection reset").WithRetryable(true)}},
+		{textChunk("r2", "world")},
+	}}
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "greet"}}}
+
+	ch, err := ResilientStream(context.Background(), p, req, fastResilientStreamConfig(2), nil)
+	require.NoError(t, err)
+	text, streamErr := collectStream(t, ch)
+
+	assert.Nil(t, streamErr)
+	assert.Equal(t, "Hello, world", text)
+	require.Len(t, p.requests, 2)
+	resumed := p.requests[1].Messages
+	require.Len(t, resumed, 3)
+	assert.Equal(t, RoleAssistant, resumed[1].Role)
+	assert.Equal(t, "Hello, ", resumed[1].Content)
+	assert.Equal(t, defaultStreamContinuationPrompt, resumed[2].Content)
+	assert.Len(t, req.Messages, 1, "original request must not be mutated")
+}
+
+func TestResilientStream_UsesProviderResumeToken(t *testing.T) {
+	p := &resumableStreamProvider{scriptedStreamProvider{scripts: [][]StreamChunk{
+		{textChunk("resp_1", "a"), textChunk("resp_1", "b"), {Err: types.NewError(types.ErrUpstreamTimeout, "timeout").WithRetryable(true)}},
+		{textChunk("resp_1", "c")},
+	}}}
+
+	ch, err := ResilientStream(context.Background(), p, &ChatRequest{Model: "m"}, fastResilientStreamConfig(1), nil)
+	require.NoError(t, err)
+	text, streamErr := collectStream(t, ch)
+
+	assert.Nil(t, streamErr)
+	assert.Equal(t, "abc", text)
+	assert.Equal(t, []StreamResumeToken{{ResponseID: "resp_1", Sequence: 2}}, p.resumed)
+}
+
+func TestResilientStream_SurfacesNonRetryableAndExhaustedErrors(t *testing.T) {
+	t.Run("non retryable", func(t *testing.T) {
+		p := &scriptedStreamProvider{scripts: [][]StreamChunk{
+			{textChunk("r", "x"), {Err: types.NewError(types.ErrUpstreamError, "content_filter triggered")}},
+		}}
+		ch, err := ResilientStream(context.Background(), p, &ChatRequest{}, fastResilientStreamConfig(3), nil)
+		require.NoError(t, err)
+		_, streamErr := collectStream(t, ch)
+		require.NotNil(t, streamErr)
+		assert.Equal(t, types.ErrContentFiltered, streamErr.Code)
+		assert.Len(t, p.requests, 1)
+	})
+
+	t.Run("tool call deltas", func(t *testing.T) {
+		toolChunk := StreamChunk{Delta: Message{ToolCalls: []types.ToolCall{{ID: "c1", Name: "f"}}}}
+		p := &scriptedStreamProvider{scripts: [][]StreamChunk{
+			{toolChunk, {Err: types.NewError(types.ErrUpstreamTimeout, "timeout").WithRetryable(true)}},
+		}}
+		ch, err := ResilientStream(context.Background(), p, &ChatRequest{}, fastResilientStreamConfig(3), nil)
+		require.NoError(t, err)
+		_, streamErr := collectStream(t, ch)
+		require.NotNil(t, streamErr)
+		assert.Len(t, p.requests, 1)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		failing := []StreamChunk{{Err: types.NewError(types.ErrRateLimit, "slow down").WithRetryable(true)}}
+		p := &scriptedStreamProvider{scripts: [][]StreamChunk{failing, failing}}
+		ch, err := ResilientStream(context.Background(), p, &ChatRequest{}, fastResilientStreamConfig(1), nil)
+		require.NoError(t, err)
+		_, streamErr := collectStream(t, ch)
+		require.NotNil(t, streamErr)
+		assert.Equal(t, types.ErrRateLimit, streamErr.Code)
+		assert.Len(t, p.requests, 2)
+	})
+}
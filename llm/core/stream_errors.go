@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// streamErrorRule 将上游错误消息中的关键字映射到统一错误码。
+type streamErrorRule struct {
+	keywords   []string
+	code       types.ErrorCode
+	httpStatus int
+	retryable  bool
+}
+
+// streamErrorRules 按优先级排列：先判断不可重试的语义错误，再判断可重试的瞬时错误。
+var streamErrorRules = []streamErrorRule{
+	{keywords: []string{"context_length", "context length", "maximum context", "too many tokens", "prompt is too long"}, code: types.ErrContextTooLong, httpStatus: http.StatusBadRequest},
+	{keywords: []string{"content_filter", "content filter", "safety", "blocked by"}, code: types.ErrContentFiltered, httpStatus: http.StatusBadRequest},
+	{keywords: []string{"invalid_api_key", "invalid api key", "authentication"}, code: types.ErrAuthentication, httpStatus: http.StatusUnauthorized},
+	{keywords: []string{"insufficient_quota", "quota"}, code: types.ErrQuotaExceeded, httpStatus: http.StatusPaymentRequired},
+	{keywords: []string{"rate_limit", "rate limit", "too many requests"}, code: types.ErrRateLimit, httpStatus: http.StatusTooManyRequests, retryable: true},
+	{keywords: []string{"overloaded", "capacity"}, code: types.ErrModelOverloaded, httpStatus: http.StatusServiceUnavailable, retryable: true},
+	{keywords: []string{"timeout", "timed out", "deadline exceeded"}, code: types.ErrUpstreamTimeout, httpStatus: http.StatusGatewayTimeout, retryable: true},
+}
+
+// ClassifyStreamError 使用统一 ErrorCode 体系对流式传输中途的失败进行分类。
+// 已是 *types.Error 且错误码明确的错误原样返回；通用的 UPSTREAM_ERROR/INTERNAL_ERROR
+// 会根据错误消息细化为更具体的错误码。
+func ClassifyStreamError(err error, provider string) *types.Error {
+	if err == nil {
+		return nil
+	}
+
+	if typed, ok := types.AsError(err); ok {
+		if typed.Code != "" && typed.Code != types.ErrUpstreamError && typed.Code != types.ErrInternalError {
+			return typed
+		}
+		refined := *typed
+		if refined.Provider == "" {
+			refined.Provider = provider
+		}
+		if rule, ok := matchStreamErrorRule(refined.Message); ok {
+			refined.Code = rule.code
+			refined.HTTPStatus = rule.httpStatus
+			refined.Retryable = rule.retryable
+		}
+		if refined.Code == "" {
+			refined.Code = types.ErrUpstreamError
+		}
+		return &refined
+	}
+
+	classified := &types.Error{
+		Code:       types.ErrUpstreamError,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusBadGateway,
+		Retryable:  true,
+		Provider:   provider,
+		Cause:      err,
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		classified.Code = types.ErrRuntimeAborted
+		classified.HTTPStatus = 499
+		classified.Retryable = false
+		return classified
+	case errors.Is(err, context.DeadlineExceeded):
+		classified.Code = types.ErrUpstreamTimeout
+		classified.HTTPStatus = http.StatusGatewayTimeout
+		return classified
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return classified
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		classified.Code = types.ErrUpstreamTimeout
+		classified.HTTPStatus = http.StatusGatewayTimeout
+		return classified
+	}
+
+	if rule, ok := matchStreamErrorRule(err.Error()); ok {
+		classified.Code = rule.code
+		classified.HTTPStatus = rule.httpStatus
+		classified.Retryable = rule.retryable
+	}
+	return classified
+}
+
+func matchStreamErrorRule(message string) (streamErrorRule, bool) {
+	lower := strings.ToLower(message)
+	for _, rule := range streamErrorRules {
+		for _, kw := range rule.keywords {
+			if strings.Contains(lower, kw) {
+				return rule, true
+			}
+		}
+	}
+	return streamErrorRule{}, false
+}
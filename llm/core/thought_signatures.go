@@ -223,6 +223,11 @@ func (m *ThoughtSignatureMiddleware) SupportsNativeFunctionCalling() bool {
 	return m.provider.SupportsNativeFunctionCalling()
 }
 
+// Capabilities 委托给被包装的提供者.
+func (m *ThoughtSignatureMiddleware) Capabilities() CapabilityDescriptor {
+	return DescribeProvider(m.provider)
+}
+
 // ListModels 委托给被包装的提供者.
 func (m *ThoughtSignatureMiddleware) ListModels(ctx context.Context) ([]Model, error) {
 	return m.provider.ListModels(ctx)
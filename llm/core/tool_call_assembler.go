@@ -0,0 +1,384 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BaSui01/agentflow/pkg/jsonutil"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ToolCallProgress 描述一个进行中（或刚完成）的流式工具调用，供 UI 展示。
+type ToolCallProgress struct {
+	Key       string `json:"key"`
+	Index     int    `json:"index"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"` // 目前为止收到的参数原文
+	Complete  bool   `json:"complete"`
+}
+
+// ToolCallAssemblerOptions 工具调用组装器选项
+type ToolCallAssemblerOptions struct {
+	// OnProgress 在工具调用出现、收到参数片段或完成时回调，在 Feed/Append/Complete 的调用方 goroutine 中执行
+	OnProgress func(ToolCallProgress)
+	// IDGenerator 为上游未给出 ID 的调用生成 ID，默认 "call_<index+1>"
+	IDGenerator func(key string, index int) string
+}
+
+// ToolCallAssembler 把流式增量中的工具调用片段组装为完整、参数已校验的 ToolCall。
+//
+// 两种用法：
+//   - provider 按上游事件驱动：Start 注册调用，AppendArguments 追加参数片段，Complete 取出完整调用；
+//   - 消费方按 StreamChunk 驱动：Feed 每个 chunk，流结束后 ToolCalls 取出全部调用。
+//
+// 参数无法解析时会尝试修复（去除代码围栏、尾随逗号，补齐被截断的字符串与括号）。
+// ToolCallAssembler 不是并发安全的。
+type ToolCallAssembler struct {
+	opts  ToolCallAssemblerOptions
+	calls map[string]*pendingToolCall
+	order []string
+}
+
+type pendingToolCall struct {
+	key      string
+	index    int
+	id       string
+	name     string
+	toolType string
+	args     strings.Builder
+	final    json.RawMessage // 上游一次性给出的完整参数
+	done     bool
+	result   ToolCall
+	err      error
+}
+
+// NewToolCallAssembler 创建工具调用组装器
+func NewToolCallAssembler(opts ToolCallAssemblerOptions) *ToolCallAssembler {
+	if opts.IDGenerator == nil {
+		opts.IDGenerator = func(_ string, index int) string { return fmt.Sprintf("call_%d", index+1) }
+	}
+	return &ToolCallAssembler{
+		opts:  opts,
+		calls: make(map[string]*pendingToolCall),
+	}
+}
+
+// Start 注册（或补全）key 对应的工具调用；id/name/toolType 为空时保留已有值。
+func (a *ToolCallAssembler) Start(key string, index int, id, name, toolType string) {
+	call := a.pending(key, index)
+	if call == nil {
+		return
+	}
+	if v := strings.TrimSpace(id); v != "" {
+		call.id = v
+	}
+	if v := strings.TrimSpace(name); v != "" {
+		call.name = v
+	}
+	if v := strings.TrimSpace(toolType); v != "" {
+		call.toolType = v
+	}
+	a.notify(call)
+}
+
+// AppendArguments 追加参数片段。
+func (a *ToolCallAssembler) AppendArguments(key, fragment string) {
+	call, ok := a.calls[key]
+	if !ok || call.done || fragment == "" {
+		return
+	}
+	call.args.WriteString(fragment)
+	a.notify(call)
+}
+
+// Arguments 返回 key 目前累积的参数原文。
+func (a *ToolCallAssembler) Arguments(key string) string {
+	call, ok := a.calls[key]
+	if !ok {
+		return ""
+	}
+	if len(call.final) > 0 {
+		return string(call.final)
+	}
+	return call.args.String()
+}
+
+// Ready 报告 key 的参数是否已构成合法 JSON。
+func (a *ToolCallAssembler) Ready(key string) bool {
+	raw := strings.TrimSpace(a.Arguments(key))
+	return raw != "" && json.Valid([]byte(raw))
+}
+
+// Complete 结束 key 对应的调用并返回组装结果。
+// ok 为 false 表示调用不存在或缺少工具名；err 非 nil 表示参数无法修复为合法 JSON，
+// 此时返回的调用保留参数原文，由调用方决定是否继续使用。
+func (a *ToolCallAssembler) Complete(key string) (call ToolCall, ok bool, err error) {
+	pending, exists := a.calls[key]
+	if !exists {
+		return ToolCall{}, false, nil
+	}
+	if !pending.done {
+		a.finalize(pending)
+	}
+	if pending.name == "" {
+		return ToolCall{}, false, nil
+	}
+	return pending.result, true, pending.err
+}
+
+// Feed 消费一个流式 chunk 中的工具调用增量。
+func (a *ToolCallAssembler) Feed(chunk StreamChunk) {
+	for _, delta := range chunk.Delta.ToolCalls {
+		a.FeedDelta(delta)
+	}
+}
+
+// FeedDelta 消费一个工具调用增量，按 Index 归并。
+// Arguments 可以是 JSON 字符串形式的片段、原始片段或一次性给出的完整 JSON。
+func (a *ToolCallAssembler) FeedDelta(delta ToolCall) {
+	key := fmt.Sprintf("idx_%d", delta.Index)
+	a.Start(key, delta.Index, delta.ID, delta.Name, delta.Type)
+	call := a.calls[key]
+	if call == nil || call.done {
+		return
+	}
+	if delta.Input != "" && normalizeAssemblerToolType(call.toolType) == types.ToolTypeCustom {
+		a.AppendArguments(key, delta.Input)
+		return
+	}
+	if len(delta.Arguments) == 0 || len(call.final) > 0 {
+		return
+	}
+	var fragment string
+	if err := json.Unmarshal(delta.Arguments, &fragment); err == nil {
+		a.AppendArguments(key, fragment)
+		return
+	}
+	if call.args.Len() == 0 && json.Valid(delta.Arguments) {
+		call.final = append(json.RawMessage(nil), delta.Arguments...)
+		a.notify(call)
+		return
+	}
+	a.AppendArguments(key, string(delta.Arguments))
+}
+
+// ToolCalls 结束所有调用并按首次出现的顺序返回；缺少工具名的调用会被忽略。
+// 任一调用参数无法修复时返回第一个错误。
+func (a *ToolCallAssembler) ToolCalls() ([]ToolCall, error) {
+	out := make([]ToolCall, 0, len(a.order))
+	var firstErr error
+	for _, key := range a.order {
+		call, ok, err := a.Complete(key)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ok {
+			out = append(out, call)
+		}
+	}
+	return out, firstErr
+}
+
+// InFlight 返回尚未完成的调用快照。
+func (a *ToolCallAssembler) InFlight() []ToolCallProgress {
+	out := make([]ToolCallProgress, 0, len(a.order))
+	for _, key := range a.order {
+		if call := a.calls[key]; call != nil && !call.done {
+			out = append(out, call.progress())
+		}
+	}
+	return out
+}
+
+// Len 返回已登记的调用数（含已完成）。
+func (a *ToolCallAssembler) Len() int {
+	return len(a.order)
+}
+
+// Discard 丢弃 key 对应的调用，之后同一 key 的增量会开始一个新调用。
+func (a *ToolCallAssembler) Discard(key string) {
+	if _, ok := a.calls[key]; !ok {
+		return
+	}
+	delete(a.calls, key)
+	for i, k := range a.order {
+		if k == key {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Reset 清空全部状态，以便复用。
+func (a *ToolCallAssembler) Reset() {
+	clear(a.calls)
+	a.order = a.order[:0]
+}
+
+func (a *ToolCallAssembler) pending(key string, index int) *pendingToolCall {
+	if strings.TrimSpace(key) == "" {
+		return nil
+	}
+	call, ok := a.calls[key]
+	if !ok {
+		call = &pendingToolCall{key: key, index: index}
+		a.calls[key] = call
+		a.order = append(a.order, key)
+	}
+	return call
+}
+
+func (a *ToolCallAssembler) finalize(call *pendingToolCall) {
+	call.done = true
+	if call.id == "" {
+		call.id = a.opts.IDGenerator(call.key, call.index)
+	}
+	result := ToolCall{Index: call.index, ID: call.id, Name: call.name}
+	if normalizeAssemblerToolType(call.toolType) == types.ToolTypeCustom {
+		result.Type = types.ToolTypeCustom
+		result.Input = call.args.String()
+	} else {
+		result.Type = types.ToolTypeFunction
+		raw := string(call.final)
+		if raw == "" {
+			raw = call.args.String()
+		}
+		args, err := RepairToolArguments(raw)
+		if err != nil {
+			call.err = fmt.Errorf("invalid tool call arguments (id=%s tool=%s): %w", call.id, call.name, err)
+			args = json.RawMessage(strings.TrimSpace(raw))
+		}
+		result.Arguments = args
+	}
+	call.result = result
+	a.notify(call)
+}
+
+func (a *ToolCallAssembler) notify(call *pendingToolCall) {
+	if a.opts.OnProgress != nil && call.name != "" {
+		a.opts.OnProgress(call.progress())
+	}
+}
+
+func (c *pendingToolCall) progress() ToolCallProgress {
+	args := string(c.final)
+	if args == "" {
+		args = c.args.String()
+	}
+	if c.done && c.result.Type != types.ToolTypeCustom {
+		args = string(c.result.Arguments)
+	}
+	return ToolCallProgress{Key: c.key, Index: c.index, ID: c.id, Name: c.name, Arguments: args, Complete: c.done}
+}
+
+func normalizeAssemblerToolType(toolType string) string {
+	if strings.EqualFold(strings.TrimSpace(toolType), types.ToolTypeCustom) {
+		return types.ToolTypeCustom
+	}
+	return types.ToolTypeFunction
+}
+
+// RepairToolArguments 校验并尽力修复模型生成的工具参数 JSON：
+// 空参数视为 {}；去除 markdown 代码围栏与尾随逗号；补齐被截断的字符串、数组与对象；
+// 展开被二次序列化的 JSON 字符串。无法修复时返回错误。
+func RepairToolArguments(raw string) (json.RawMessage, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return json.RawMessage("{}"), nil
+	}
+	if json.Valid([]byte(s)) {
+		return jsonutil.UnwrapStringifiedRawMessage(json.RawMessage(s)), nil
+	}
+	s = stripJSONCodeFence(s)
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s), nil
+	}
+	if repaired := closeTruncatedJSON(s); json.Valid([]byte(repaired)) {
+		return json.RawMessage(repaired), nil
+	}
+	return nil, fmt.Errorf("arguments are not valid JSON: %s", truncateForError(raw, 200))
+}
+
+func stripJSONCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 && !strings.ContainsAny(s[:nl], "{[") {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// closeTruncatedJSON 去除对象/数组中的尾随逗号，并补齐截断处未闭合的字符串与括号。
+func closeTruncatedJSON(s string) string {
+	var (
+		out      strings.Builder
+		stack    []byte
+		inString bool
+		escaped  bool
+	)
+	trimTrailingComma := func() string {
+		str := strings.TrimRight(out.String(), " \t\r\n")
+		return strings.TrimSuffix(str, ",")
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, c)
+		case '}', ']':
+			trimmed := trimTrailingComma()
+			out.Reset()
+			out.WriteString(trimmed)
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		out.WriteByte(c)
+	}
+
+	result := out.String()
+	if inString {
+		if escaped {
+			result = result[:len(result)-1]
+		}
+		result += `"`
+	}
+	result = strings.TrimRight(result, " \t\r\n")
+	result = strings.TrimSuffix(result, ",")
+	if strings.HasSuffix(result, ":") {
+		result += "null"
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			result += "}"
+		} else {
+			result += "]"
+		}
+	}
+	return result
+}
+
+func truncateForError(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallAssembler_FeedFragments(t *testing.T) {
+	var progress []ToolCallProgress
+	asm := NewToolCallAssembler(ToolCallAssemblerOptions{
+		OnProgress: func(p ToolCallProgress) { progress = append(progress, p) },
+	})
+
+	asm.Feed(StreamChunk{Delta: Message{ToolCalls: []ToolCall{{Index: 0, ID: "call_a", Name: "search", Arguments: json.RawMessage(`"{\"q\":"`)}}}})
+	asm.Feed(StreamChunk{Delta: Message{ToolCalls: []ToolCall{
+		{Index: 0, Arguments: json.RawMessage(`"\"go\"}"`)},
+		{Index: 1, Name: "now", Arguments: json.RawMessage(`{"tz":"UTC"}`)},
+	}}})
+	require.Len(t, asm.InFlight(), 2)
+	assert.True(t, asm.Ready("idx_0"))
+
+	calls, err := asm.ToolCalls()
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "call_a", calls[0].ID)
+	assert.Equal(t, types.ToolTypeFunction, calls[0].Type)
+	assert.JSONEq(t, `{"q":"go"}`, string(calls[0].Arguments))
+	assert.Equal(t, "call_2", calls[1].ID, "missing id is generated")
+	assert.JSONEq(t, `{"tz":"UTC"}`, string(calls[1].Arguments))
+	assert.Empty(t, asm.InFlight())
+
+	last := progress[len(progress)-1]
+	assert.True(t, last.Complete)
+	assert.Equal(t, "now", last.Name)
+	assert.Empty(t, progress[0].Arguments, "first notification announces the call")
+	assert.Equal(t, `{"q":`, progress[1].Arguments)
+}
+
+func TestToolCallAssembler_StartAppendComplete(t *testing.T) {
+	asm := NewToolCallAssembler(ToolCallAssemblerOptions{})
+	asm.Start("blk", 0, "toolu_1", "write", types.ToolTypeFunction)
+	asm.AppendArguments("blk", `{"path":"a.txt","body":"hel`)
+	assert.False(t, asm.Ready("blk"))
+
+	call, ok, err := asm.Complete("blk")
+	require.True(t, ok)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path":"a.txt","body":"hel"}`, string(call.Arguments), "truncated arguments are repaired")
+
+	asm.Start("custom", 1, "", "patch", types.ToolTypeCustom)
+	asm.AppendArguments("custom", "*** Begin Patch")
+	call, ok, err = asm.Complete("custom")
+	require.True(t, ok)
+	require.NoError(t, err)
+	assert.Equal(t, types.ToolTypeCustom, call.Type)
+	assert.Equal(t, "*** Begin Patch", call.Input)
+
+	_, ok, _ = asm.Complete("missing")
+	assert.False(t, ok)
+}
+
+func TestToolCallAssembler_UnrepairableArguments(t *testing.T) {
+	asm := NewToolCallAssembler(ToolCallAssemblerOptions{})
+	asm.FeedDelta(ToolCall{Index: 0, ID: "c1", Name: "f", Arguments: json.RawMessage(`"{\"a\": tru}"`)})
+
+	calls, err := asm.ToolCalls()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "id=c1 tool=f")
+	require.Len(t, calls, 1)
+	assert.Equal(t, `{"a": tru}`, string(calls[0].Arguments))
+}
+
+func TestRepairToolArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "empty", raw: "  ", want: `{}`},
+		{name: "valid", raw: `{"a":1}`, want: `{"a":1}`},
+		{name: "stringified", raw: `"{\"a\":1}"`, want: `{"a":1}`},
+		{name: "code fence", raw: "```json\n{\"a\":1}\n```", want: `{"a":1}`},
+		{name: "trailing comma", raw: `{"a":[1,2,],}`, want: `{"a":[1,2]}`},
+		{name: "truncated string", raw: `{"a":"x\`, want: `{"a":"x"}`},
+		{name: "truncated after colon", raw: `{"a":`, want: `{"a":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepairToolArguments(tt.raw)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := RepairToolArguments(`{"a": tru}`)
+	assert.Error(t, err)
+}
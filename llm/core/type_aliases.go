@@ -5,6 +5,7 @@ import "github.com/BaSui01/agentflow/types"
 // Re-export canonical shared types used across llm package internals.
 type Message = types.Message
 type Error = types.Error
+type Remediation = types.Remediation
 type ToolCall = types.ToolCall
 type ToolResult = types.ToolResult
 type ToolSchema = types.ToolSchema
@@ -16,6 +17,9 @@ type ChatRequest = types.ChatRequest
 type ChatResponse = types.ChatResponse
 type ChatChoice = types.ChatChoice
 type ChatUsage = types.ChatUsage
+type ChoiceLogProbs = types.ChoiceLogProbs
+type TokenLogProb = types.TokenLogProb
+type TopLogProb = types.TopLogProb
 type StreamChunk = types.StreamChunk
 type PromptTokensDetails = types.PromptTokensDetails
 type CompletionTokensDetails = types.CompletionTokensDetails
@@ -24,6 +28,7 @@ type ResponseFormatType = types.ResponseFormatType
 type JSONSchemaParam = types.JSONSchemaParam
 type StreamOptions = types.StreamOptions
 type CacheControl = types.CacheControl
+type PromptCacheHint = types.PromptCacheHint
 type WebSearchOptions = types.WebSearchOptions
 type WebSearchLocation = types.WebSearchLocation
 type ToolCallMode = types.ToolCallMode
@@ -64,4 +69,17 @@ const (
 	ErrInternalError       = types.ErrInternalError
 	ErrServiceUnavailable  = types.ErrServiceUnavailable
 	ErrProviderUnavailable = types.ErrProviderUnavailable
+
+	ErrCapabilityUnsupported = types.ErrCapabilityUnsupported
+)
+
+const (
+	RemediationCheckCredentials = types.RemediationCheckCredentials
+	RemediationAddCredits       = types.RemediationAddCredits
+	RemediationBackoff          = types.RemediationBackoff
+	RemediationRetry            = types.RemediationRetry
+	RemediationReduceInput      = types.RemediationReduceInput
+	RemediationReviseContent    = types.RemediationReviseContent
+	RemediationSwitchModel      = types.RemediationSwitchModel
+	RemediationFixRequest       = types.RemediationFixRequest
 )
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// UpstreamResponse captures the HTTP status and headers returned by the upstream
+// provider for a single call, e.g. so key pools can read rate-limit headers.
+type UpstreamResponse struct {
+	StatusCode int
+	Header     http.Header
+}
+
+type upstreamResponseRecorderKey struct{}
+
+// UpstreamResponseRecorder stores the last upstream response observed during a call.
+type UpstreamResponseRecorder struct {
+	mu   sync.RWMutex
+	resp UpstreamResponse
+	ok   bool
+}
+
+// WithUpstreamResponseRecorder attaches a fresh recorder to ctx. Unlike the resolved
+// provider call recorder, each call gets its own recorder so nested calls do not
+// overwrite the caller's view.
+func WithUpstreamResponseRecorder(ctx context.Context) (context.Context, *UpstreamResponseRecorder) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recorder := &UpstreamResponseRecorder{}
+	return context.WithValue(ctx, upstreamResponseRecorderKey{}, recorder), recorder
+}
+
+// RecordUpstreamResponse reports the upstream HTTP status and headers for the current call.
+func RecordUpstreamResponse(ctx context.Context, statusCode int, header http.Header) {
+	if ctx == nil {
+		return
+	}
+	recorder, ok := ctx.Value(upstreamResponseRecorderKey{}).(*UpstreamResponseRecorder)
+	if !ok || recorder == nil {
+		return
+	}
+	recorder.Store(UpstreamResponse{StatusCode: statusCode, Header: header.Clone()})
+}
+
+// Store saves the upstream response.
+func (r *UpstreamResponseRecorder) Store(resp UpstreamResponse) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resp = resp
+	r.ok = true
+}
+
+// Load returns the last upstream response stored in the recorder.
+func (r *UpstreamResponseRecorder) Load() (UpstreamResponse, bool) {
+	if r == nil {
+		return UpstreamResponse{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.ok {
+		return UpstreamResponse{}, false
+	}
+	return r.resp, true
+}
@@ -5,6 +5,10 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens,omitempty"`
 	CompletionTokens int `json:"completion_tokens,omitempty"`
 	TotalTokens      int `json:"total_tokens,omitempty"`
+	CachedTokens     int `json:"cached_tokens,omitempty"`
+	// CacheCreationTokens 写入 prompt 缓存的输入 token（Anthropic 语义下不包含在 PromptTokens 内）
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	ReasoningTokens     int `json:"reasoning_tokens,omitempty"` // 已包含在 CompletionTokens 内
 
 	InputUnits  int `json:"input_units,omitempty"`
 	OutputUnits int `json:"output_units,omitempty"`
@@ -16,6 +20,9 @@ func (u Usage) IsZero() bool {
 	return u.PromptTokens == 0 &&
 		u.CompletionTokens == 0 &&
 		u.TotalTokens == 0 &&
+		u.CachedTokens == 0 &&
+		u.CacheCreationTokens == 0 &&
+		u.ReasoningTokens == 0 &&
 		u.InputUnits == 0 &&
 		u.OutputUnits == 0 &&
 		u.TotalUnits == 0
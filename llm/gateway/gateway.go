@@ -32,6 +32,18 @@ type Config struct {
 	Ledger         observability.Ledger
 	PolicyManager  *llmpolicy.Manager
 	Logger         *zap.Logger
+
+	// PromptFingerprints 可选：登记已评审的 prompt 版本，用于检测生产漂移。
+	PromptFingerprints *observability.PromptFingerprintRegistry
+
+	// PromptTraffic 可选：采样 chat 请求用于按频率回放（如缓存预热）。
+	PromptTraffic *observability.PromptTrafficStore
+
+	// CapabilityRegistry 可选：chat 请求发往 provider 前按其能力描述做协商（拒绝或改写不支持的特性）。
+	CapabilityRegistry *llmcore.CapabilityRegistry
+
+	// StreamResume 可选：chat 流中途出现可重试错误时经 llmcore.ResilientStream 续流，为空时直接透传错误。
+	StreamResume *llmcore.ResilientStreamConfig
 }
 
 // ToolsInput 是 tools 能力统一 payload。
@@ -40,10 +52,12 @@ type ToolsInput struct {
 }
 
 // ImageInput 是 image 能力统一 payload。
+// Generate/Edit/Variation 三选一；Edit 携带蒙版时为局部重绘。
 type ImageInput struct {
-	Provider string
-	Generate *image.GenerateRequest
-	Edit     *image.EditRequest
+	Provider  string
+	Generate  *image.GenerateRequest
+	Edit      *image.EditRequest
+	Variation *image.VariationRequest
 }
 
 // VideoInput 是 video 能力统一 payload。
@@ -103,6 +117,11 @@ type Service struct {
 	ledger         observability.Ledger
 	policyManager  *llmpolicy.Manager
 	logger         *zap.Logger
+
+	promptFingerprints *observability.PromptFingerprintRegistry
+	promptTraffic      *observability.PromptTrafficStore
+	capabilityRegistry *llmcore.CapabilityRegistry
+	streamResume       *llmcore.ResilientStreamConfig
 }
 
 var _ llmcore.Gateway = (*Service)(nil)
@@ -128,6 +147,11 @@ func New(cfg Config) *Service {
 		ledger:         ledger,
 		policyManager:  cfg.PolicyManager,
 		logger:         logger,
+
+		promptFingerprints: cfg.PromptFingerprints,
+		promptTraffic:      cfg.PromptTraffic,
+		capabilityRegistry: cfg.CapabilityRegistry,
+		streamResume:       cfg.StreamResume,
 	}
 }
 
@@ -218,13 +242,22 @@ func (s *Service) Stream(ctx context.Context, req *llmcore.UnifiedRequest) (<-ch
 		return nil, llmcore.InvalidPayloadError(llmcore.CapabilityChat, "*llmcore.ChatRequest")
 	}
 	mergeChatRoutingMetadata(req, chatReq)
+	chatReq = s.annotatePromptFingerprint(req, chatReq)
+	if s.promptTraffic != nil {
+		s.promptTraffic.Record(chatReq)
+	}
 	provider := s.prepareChatExecutionProvider(chatReq)
 	if provider == nil {
 		return nil, llmcore.GatewayUnavailableError("chat provider is not available")
 	}
 
+	chatReq, err := s.negotiateChatCapabilities(provider, chatReq, true)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, resolvedCallRecorder := llmcore.WithResolvedProviderCallRecorder(ctx)
-	source, err := provider.Stream(ctx, chatReq)
+	source, err := s.openChatStream(ctx, provider, chatReq)
 	if err != nil {
 		return nil, err
 	}
@@ -325,11 +358,20 @@ func (s *Service) invokeChat(ctx context.Context, req *llmcore.UnifiedRequest) (
 		return nil, llmcore.InvalidPayloadError(llmcore.CapabilityChat, "*llmcore.ChatRequest")
 	}
 	mergeChatRoutingMetadata(req, chatReq)
+	chatReq = s.annotatePromptFingerprint(req, chatReq)
+	if s.promptTraffic != nil {
+		s.promptTraffic.Record(chatReq)
+	}
 	provider := s.prepareChatExecutionProvider(chatReq)
 	if provider == nil {
 		return nil, llmcore.GatewayUnavailableError("chat provider is not available")
 	}
 
+	chatReq, err := s.negotiateChatCapabilities(provider, chatReq, false)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, resolvedCallRecorder := llmcore.WithResolvedProviderCallRecorder(ctx)
 	resp, err := provider.Completion(ctx, chatReq)
 	if err != nil {
@@ -406,16 +448,20 @@ func (s *Service) invokeImage(ctx context.Context, req *llmcore.UnifiedRequest)
 		err  error
 	)
 
-	if input.Edit != nil {
-		provider, providerErr := s.capabilities.Image(providerName)
-		if providerErr != nil {
+	switch {
+	case input.Edit != nil || input.Variation != nil:
+		if _, providerErr := s.capabilities.Image(providerName); providerErr != nil {
 			return nil, types.WrapError(providerErr, types.ErrInvalidRequest, providerErr.Error())
 		}
-		resp, err = provider.Edit(ctx, input.Edit)
-	} else if input.Generate != nil {
+		if input.Edit != nil {
+			resp, err = s.capabilities.EditImage(ctx, input.Edit, providerName)
+		} else {
+			resp, err = s.capabilities.CreateImageVariation(ctx, input.Variation, providerName)
+		}
+	case input.Generate != nil:
 		resp, err = s.capabilities.GenerateImage(ctx, input.Generate, providerName)
-	} else {
-		return nil, llmcore.InvalidPayloadError(llmcore.CapabilityImage, "ImageInput with Generate or Edit")
+	default:
+		return nil, llmcore.InvalidPayloadError(llmcore.CapabilityImage, "ImageInput with Generate, Edit or Variation")
 	}
 	if err != nil {
 		return nil, err
@@ -827,9 +873,12 @@ func (s *Service) invokeAvatar(ctx context.Context, req *llmcore.UnifiedRequest)
 
 func fromChatUsage(u llmcore.ChatUsage) llmcore.Usage {
 	return llmcore.Usage{
-		PromptTokens:     u.PromptTokens,
-		CompletionTokens: u.CompletionTokens,
-		TotalTokens:      u.TotalTokens,
+		PromptTokens:        u.PromptTokens,
+		CompletionTokens:    u.CompletionTokens,
+		TotalTokens:         u.TotalTokens,
+		CachedTokens:        u.CachedPromptTokens(),
+		CacheCreationTokens: u.CacheCreationPromptTokens(),
+		ReasoningTokens:     u.ReasoningTokens(),
 	}
 }
 
@@ -843,6 +892,15 @@ func normalizeUsage(usage llmcore.Usage) llmcore.Usage {
 	if usage.TotalTokens < 0 {
 		usage.TotalTokens = 0
 	}
+	if usage.CachedTokens < 0 {
+		usage.CachedTokens = 0
+	}
+	if usage.CacheCreationTokens < 0 {
+		usage.CacheCreationTokens = 0
+	}
+	if usage.ReasoningTokens < 0 {
+		usage.ReasoningTokens = 0
+	}
 	if usage.InputUnits < 0 {
 		usage.InputUnits = 0
 	}
@@ -881,11 +939,14 @@ func (s *Service) normalizeCost(decision llmcore.ProviderDecision, usage llmcore
 	}
 
 	if currency == "USD" && cost.AmountUSD == 0 && (usage.PromptTokens > 0 || usage.CompletionTokens > 0) {
-		cost.AmountUSD = s.costCalculator.Calculate(
+		cost.AmountUSD = s.costCalculator.CalculateWithReasoning(
 			decision.Provider,
 			decision.Model,
 			usage.PromptTokens,
+			usage.CachedTokens,
+			usage.CacheCreationTokens,
 			usage.CompletionTokens,
+			usage.ReasoningTokens,
 		)
 		if cost.AmountUSD < 0 {
 			cost.AmountUSD = 0
@@ -1003,6 +1064,22 @@ func (s *Service) prepareChatExecutionProvider(req *llmcore.ChatRequest) llmcore
 	return middleware.NewXMLToolCallProvider(s.chatProvider, s.logger)
 }
 
+// openChatStream 打开 chat 流；配置了 StreamResume 时中途的可重试错误由 ResilientStream 续流。
+func (s *Service) openChatStream(ctx context.Context, provider llmcore.Provider, req *llmcore.ChatRequest) (<-chan llmcore.StreamChunk, error) {
+	if s.streamResume == nil {
+		return provider.Stream(ctx, req)
+	}
+	return llmcore.ResilientStream(ctx, provider, req, s.streamResume, s.logger)
+}
+
+// negotiateChatCapabilities 在配置了能力注册表时按 provider 能力协商 chat 请求。
+func (s *Service) negotiateChatCapabilities(provider llmcore.Provider, req *llmcore.ChatRequest, stream bool) (*llmcore.ChatRequest, error) {
+	if s.capabilityRegistry == nil {
+		return req, nil
+	}
+	return s.capabilityRegistry.Negotiate(provider.Name(), req.Model, provider, req, stream)
+}
+
 func (s *Service) recordResponseUsage(req *llmcore.UnifiedRequest, resp *llmcore.UnifiedResponse) {
 	if s == nil || s.policyManager == nil || resp == nil {
 		return
@@ -1048,6 +1125,37 @@ func (s *Service) recordLedger(
 	}
 }
 
+// annotatePromptFingerprint 为请求打上 system prompt 指纹，随 metadata 进入 ledger 与追踪，
+// 并在配置了登记表时记录观测结果；未登记的指纹在首次出现时记录告警。
+// 调用方传入的 ChatRequest 不会被修改，带指纹的 metadata 写入返回的副本。
+func (s *Service) annotatePromptFingerprint(req *llmcore.UnifiedRequest, chatReq *llmcore.ChatRequest) *llmcore.ChatRequest {
+	fingerprint := observability.FingerprintSystemPrompt(chatReq.Messages)
+	if fingerprint == "" {
+		return chatReq
+	}
+	ensureMetadata(req)[observability.MetadataKeyPromptFingerprint] = fingerprint
+	annotated := *chatReq
+	annotated.Metadata = cloneMetadata(chatReq.Metadata)
+	if annotated.Metadata == nil {
+		annotated.Metadata = make(map[string]string, 1)
+	}
+	annotated.Metadata[observability.MetadataKeyPromptFingerprint] = fingerprint
+
+	if s.promptFingerprints == nil {
+		return &annotated
+	}
+	name := metadataValue(req, observability.MetadataKeyPromptName)
+	obs, ok := s.promptFingerprints.Observe(name, fingerprint)
+	if !ok && obs.Count == 1 && s.promptFingerprints.Managed(name) {
+		s.logger.Warn("unregistered prompt fingerprint observed",
+			zap.String("prompt_name", name),
+			zap.String("fingerprint", fingerprint),
+			zap.String("trace_id", req.TraceID),
+		)
+	}
+	return &annotated
+}
+
 func metadataValue(req *llmcore.UnifiedRequest, key string) string {
 	if req == nil || req.Metadata == nil {
 		return ""
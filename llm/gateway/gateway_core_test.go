@@ -149,3 +149,47 @@ func (p *stubProvider) ListModels(_ context.Context) ([]llmcore.Model, error) {
 func (p *stubProvider) Endpoints() llmcore.ProviderEndpoints {
 	return llmcore.ProviderEndpoints{}
 }
+
+// interruptedStreamProvider 第一次流式调用在输出部分内容后以可重试错误中断，之后的调用正常结束。
+type interruptedStreamProvider struct {
+	stubProvider
+	requests []*llmcore.ChatRequest
+}
+
+func (p *interruptedStreamProvider) Stream(_ context.Context, req *llmcore.ChatRequest) (<-chan llmcore.StreamChunk, error) {
+	p.requests = append(p.requests, req)
+	ch := make(chan llmcore.StreamChunk, 2)
+	if len(p.requests) == 1 {
+		ch <- llmcore.StreamChunk{Delta: types.Message{Role: types.RoleAssistant, Content: "Hello "}}
+		ch <- llmcore.StreamChunk{Err: &types.Error{Code: types.ErrUpstreamError, Message: "connection reset by peer", Retryable: true}}
+	} else {
+		ch <- llmcore.StreamChunk{Delta: types.Message{Role: types.RoleAssistant, Content: "world"}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestService_Stream_ResumesInterruptedStream(t *testing.T) {
+	provider := &interruptedStreamProvider{}
+	resume := llmcore.DefaultResilientStreamConfig()
+	resume.RetryPolicy.InitialBackoff = time.Millisecond
+	s := New(Config{ChatProvider: provider, Logger: zap.NewNop(), StreamResume: resume})
+
+	stream, err := s.Stream(context.Background(), &llmcore.UnifiedRequest{
+		Capability: llmcore.CapabilityChat,
+		Payload:    &llmcore.ChatRequest{Model: "m", Messages: []types.Message{{Role: types.RoleUser, Content: "hi"}}},
+	})
+	require.NoError(t, err)
+	var content string
+	for chunk := range stream {
+		require.Nil(t, chunk.Err)
+		output, ok := chunk.Output.(*llmcore.StreamChunk)
+		require.True(t, ok)
+		content += output.Delta.Content
+	}
+	assert.Equal(t, "Hello world", content)
+	require.Len(t, provider.requests, 2)
+	replayed := provider.requests[1].Messages
+	require.Len(t, replayed, 3)
+	assert.Equal(t, "Hello ", replayed[1].Content, "partial output is replayed for continuation")
+}
@@ -151,6 +151,21 @@ func TestNormalizeCost_CalculatorFillsZeroUSD(t *testing.T) {
 	assert.Greater(t, cost.AmountUSD, 0.0)
 }
 
+func TestNormalizeCost_IncludesAnthropicCacheCreation(t *testing.T) {
+	calc := observability.NewCostCalculator()
+	calc.SetPrice("anthropic", "model", 1.0, 2.0)
+	svc := New(Config{CostCalculator: calc, Logger: zap.NewNop()})
+
+	usage := fromChatUsage(llmcore.ChatUsage{
+		PromptTokens:        1000,
+		PromptTokensDetails: &llmcore.PromptTokensDetails{CacheCreationTokens: 2000},
+	})
+	assert.Equal(t, 2000, usage.CacheCreationTokens)
+
+	cost := svc.normalizeCost(llmcore.ProviderDecision{Provider: "anthropic", Model: "model"}, usage, llmcore.Cost{Currency: "USD"})
+	assert.InDelta(t, 1.0+2.5, cost.AmountUSD, 1e-9)
+}
+
 // ═══ normalizeUsage ═══
 
 func TestNormalizeUsage_AllNegative(t *testing.T) {
@@ -672,6 +687,20 @@ func TestInvokeImage_EditPath(t *testing.T) {
 	assert.Equal(t, "mock-image-edit", imgResp.Provider)
 }
 
+func TestInvokeImage_VariationPath(t *testing.T) {
+	svc := newImageServiceForTest()
+	resp, err := svc.Invoke(context.Background(), &llmcore.UnifiedRequest{
+		Capability: llmcore.CapabilityImage,
+		Payload: &ImageInput{
+			Variation: &image.VariationRequest{N: 2},
+		},
+	})
+	require.NoError(t, err)
+	imgResp, ok := resp.Output.(*image.GenerateResponse)
+	require.True(t, ok)
+	assert.Equal(t, "mock-image-variation", imgResp.Provider)
+}
+
 func TestInvokeImage_OutputUnitsFallbackToLen(t *testing.T) {
 	svc := newImageServiceForTest()
 	// The mock returns ImagesGenerated=0 and empty Images, so outputUnits should be 0
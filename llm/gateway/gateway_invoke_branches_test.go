@@ -171,7 +171,7 @@ func (p *gatewayMockImageProvider) Edit(_ context.Context, _ *image.EditRequest)
 	return &image.GenerateResponse{Provider: "mock-image-edit"}, nil
 }
 func (p *gatewayMockImageProvider) CreateVariation(_ context.Context, _ *image.VariationRequest) (*image.GenerateResponse, error) {
-	return &image.GenerateResponse{}, nil
+	return &image.GenerateResponse{Provider: "mock-image-variation"}, nil
 }
 func (p *gatewayMockImageProvider) Name() string             { return "mock-image" }
 func (p *gatewayMockImageProvider) SupportedSizes() []string { return []string{"1024x1024"} }
@@ -12,6 +12,7 @@ import (
 	"github.com/BaSui01/agentflow/types"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestService_Invoke_RecordsLedger(t *testing.T) {
@@ -296,3 +297,80 @@ func (p *ledgerMultiUsageProvider) Endpoints() llmcore.ProviderEndpoints {
 func (p *ledgerMultiUsageProvider) CountTokens(context.Context, *llmcore.ChatRequest) (*llmcore.TokenCountResponse, error) {
 	return &llmcore.TokenCountResponse{InputTokens: 1, TotalTokens: 1}, nil
 }
+
+func TestService_Invoke_AttachesPromptFingerprint(t *testing.T) {
+	ledger := &recordingLedger{}
+	registry := observability.NewPromptFingerprintRegistry()
+	registry.Register("assistant", "v1", "reviewed prompt")
+
+	service := New(Config{
+		ChatProvider:       &ledgerProvider{},
+		Ledger:             ledger,
+		PromptFingerprints: registry,
+	})
+
+	_, err := service.Invoke(context.Background(), &llmcore.UnifiedRequest{
+		Capability: llmcore.CapabilityChat,
+		TraceID:    "trace-fingerprint",
+		Metadata:   map[string]string{observability.MetadataKeyPromptName: "assistant"},
+		Payload: &llmcore.ChatRequest{
+			Model: "ledger-model",
+			Messages: []types.Message{
+				{Role: types.RoleSystem, Content: "unreviewed prompt"},
+				{Role: types.RoleUser, Content: "hello"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	entries := ledger.Entries()
+	require.Len(t, entries, 1)
+	fingerprint := entries[0].Metadata[observability.MetadataKeyPromptFingerprint]
+	require.Equal(t, observability.FingerprintPrompt("system:unreviewed prompt"), fingerprint)
+
+	drifts := registry.Drifts()
+	require.Len(t, drifts, 1)
+	require.Equal(t, fingerprint, drifts[0].Fingerprint)
+}
+
+func TestService_Invoke_RegisteredPromptDoesNotDrift(t *testing.T) {
+	ledger := &recordingLedger{}
+	registry := observability.NewPromptFingerprintRegistry()
+	registered := registry.Register("assistant", "v1", "You are a reviewed assistant.")
+	core, logs := observer.New(zap.WarnLevel)
+
+	service := New(Config{
+		ChatProvider:       &ledgerProvider{},
+		Ledger:             ledger,
+		Logger:             zap.New(core),
+		PromptFingerprints: registry,
+	})
+
+	invoke := func(system string) *llmcore.ChatRequest {
+		chatReq := &llmcore.ChatRequest{
+			Model:    "ledger-model",
+			Metadata: map[string]string{"caller": "test"},
+			Messages: []types.Message{
+				{Role: types.RoleSystem, Content: system},
+				{Role: types.RoleUser, Content: "hello"},
+			},
+		}
+		_, err := service.Invoke(context.Background(), &llmcore.UnifiedRequest{
+			Capability: llmcore.CapabilityChat,
+			Metadata:   map[string]string{observability.MetadataKeyPromptName: "assistant"},
+			Payload:    chatReq,
+		})
+		require.NoError(t, err)
+		return chatReq
+	}
+
+	chatReq := invoke("You are a reviewed assistant.")
+	require.NotContains(t, chatReq.Metadata, observability.MetadataKeyPromptFingerprint, "caller metadata is not annotated in place")
+	require.Equal(t, registered, ledger.Entries()[0].Metadata[observability.MetadataKeyPromptFingerprint])
+	require.Empty(t, registry.Drifts())
+
+	invoke("You are an edited assistant.")
+	invoke("You are an edited assistant.")
+	require.Len(t, registry.Drifts(), 1)
+	require.Equal(t, 1, logs.FilterMessage("unregistered prompt fingerprint observed").Len(), "drift is logged once per fingerprint")
+}
@@ -44,8 +44,8 @@ func validateCapabilityPayload(req *llmcore.UnifiedRequest) *types.Error {
 		}
 	case llmcore.CapabilityImage:
 		payload, ok := req.Payload.(*ImageInput)
-		if !ok || payload == nil || (payload.Generate == nil && payload.Edit == nil) {
-			return types.NewInvalidRequestError("image payload must include generate or edit request")
+		if !ok || payload == nil || (payload.Generate == nil && payload.Edit == nil && payload.Variation == nil) {
+			return types.NewInvalidRequestError("image payload must include generate, edit or variation request")
 		}
 	case llmcore.CapabilityVideo:
 		payload, ok := req.Payload.(*VideoInput)
@@ -0,0 +1,367 @@
+package gateway
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// PriorityClass 是请求的调度优先级。
+type PriorityClass string
+
+const (
+	// PriorityInteractive 面向用户的实时对话，优先级最高。
+	PriorityInteractive PriorityClass = "interactive"
+	// PriorityBackground Agent 后台任务（记忆整理、反思、规划等）。
+	PriorityBackground PriorityClass = "background"
+	// PriorityBatch 离线批处理，优先级最低。
+	PriorityBatch PriorityClass = "batch"
+)
+
+// priorityOrder 是出队时的优先顺序。
+var priorityOrder = []PriorityClass{PriorityInteractive, PriorityBackground, PriorityBatch}
+
+// metadataKeyPriority 是 UnifiedRequest.Metadata 中优先级的回退键。
+const metadataKeyPriority = "priority"
+
+// ParsePriorityClass 解析优先级名称，未知值返回 false。
+func ParsePriorityClass(v string) (PriorityClass, bool) {
+	switch PriorityClass(strings.ToLower(strings.TrimSpace(v))) {
+	case PriorityInteractive:
+		return PriorityInteractive, true
+	case PriorityBackground:
+		return PriorityBackground, true
+	case PriorityBatch:
+		return PriorityBatch, true
+	default:
+		return "", false
+	}
+}
+
+// PriorityClassConfig 是单个优先级的准入配置。
+type PriorityClassConfig struct {
+	// MaxConcurrent 是该级别的最大并发，0 表示不单独限制。
+	MaxConcurrent int
+	// MaxQueue 是该级别的最大排队数，超出后直接拒绝；0 表示不排队。
+	MaxQueue int
+	// MaxWait 是排队的最长等待时间，0 表示只受 ctx 约束。
+	MaxWait time.Duration
+	// DelayAbovePressure 上游限流压力达到该值时暂停放行（请求留在队列中），0 表示不延迟。
+	DelayAbovePressure float64
+	// ShedAbovePressure 上游限流压力达到该值时直接拒绝新请求，0 表示不丢弃。
+	ShedAbovePressure float64
+}
+
+// PrioritySchedulerConfig 配置 PriorityScheduler。
+type PrioritySchedulerConfig struct {
+	// Classes 按优先级配置准入；缺省项使用 DefaultPrioritySchedulerConfig 中的值。
+	Classes map[PriorityClass]PriorityClassConfig
+	// MaxConcurrent 是所有级别共享的并发上限，0 表示不限制。
+	MaxConcurrent int
+	// DefaultClass 是未标注优先级请求的级别，默认 interactive。
+	DefaultClass PriorityClass
+	// Pressure 返回上游限流压力（0-1，例如已用配额比例），为空时视为 0。
+	Pressure func() float64
+	// PressurePollInterval 是被延迟请求重新检查压力的间隔，默认 200ms。
+	PressurePollInterval time.Duration
+}
+
+// DefaultPrioritySchedulerConfig 返回默认配置：交互请求不受压力影响，
+// 后台与批处理请求在接近限流时依次被延迟和丢弃。
+func DefaultPrioritySchedulerConfig() PrioritySchedulerConfig {
+	return PrioritySchedulerConfig{
+		Classes: map[PriorityClass]PriorityClassConfig{
+			PriorityInteractive: {MaxQueue: 256, MaxWait: 30 * time.Second},
+			PriorityBackground:  {MaxConcurrent: 16, MaxQueue: 256, MaxWait: 2 * time.Minute, DelayAbovePressure: 0.8, ShedAbovePressure: 0.95},
+			PriorityBatch:       {MaxConcurrent: 4, MaxQueue: 1024, MaxWait: 10 * time.Minute, DelayAbovePressure: 0.6, ShedAbovePressure: 0.85},
+		},
+		DefaultClass:         PriorityInteractive,
+		PressurePollInterval: 200 * time.Millisecond,
+	}
+}
+
+// PriorityStats 是某个优先级的调度统计快照。
+type PriorityStats struct {
+	InFlight int   `json:"in_flight"`
+	Queued   int   `json:"queued"`
+	Admitted int64 `json:"admitted"`
+	Rejected int64 `json:"rejected"`
+}
+
+type priorityWaiter struct {
+	class PriorityClass
+	ready chan struct{}
+}
+
+// PriorityScheduler 位于 gateway 之前，按 context 中的优先级对请求分级排队与限流，
+// 避免 Agent 后台任务挤占面向用户的对话容量。
+type PriorityScheduler struct {
+	next   llmcore.Gateway
+	cfg    PrioritySchedulerConfig
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	total    int
+	inFlight map[PriorityClass]int
+	waiters  map[PriorityClass]*list.List
+	admitted map[PriorityClass]int64
+	rejected map[PriorityClass]int64
+}
+
+var _ llmcore.Gateway = (*PriorityScheduler)(nil)
+
+// NewPriorityScheduler 创建优先级调度器。
+func NewPriorityScheduler(next llmcore.Gateway, cfg PrioritySchedulerConfig, logger *zap.Logger) *PriorityScheduler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	defaults := DefaultPrioritySchedulerConfig()
+	classes := make(map[PriorityClass]PriorityClassConfig, len(priorityOrder))
+	for _, class := range priorityOrder {
+		if c, ok := cfg.Classes[class]; ok {
+			classes[class] = c
+		} else {
+			classes[class] = defaults.Classes[class]
+		}
+	}
+	cfg.Classes = classes
+	if _, ok := ParsePriorityClass(string(cfg.DefaultClass)); !ok {
+		cfg.DefaultClass = defaults.DefaultClass
+	}
+	if cfg.PressurePollInterval <= 0 {
+		cfg.PressurePollInterval = defaults.PressurePollInterval
+	}
+
+	s := &PriorityScheduler{
+		next:     next,
+		cfg:      cfg,
+		logger:   logger.With(zap.String("component", "priority_scheduler")),
+		inFlight: make(map[PriorityClass]int),
+		waiters:  make(map[PriorityClass]*list.List),
+		admitted: make(map[PriorityClass]int64),
+		rejected: make(map[PriorityClass]int64),
+	}
+	for _, class := range priorityOrder {
+		s.waiters[class] = list.New()
+	}
+	return s
+}
+
+// Classify 返回请求的优先级：context 优先，其次是请求 metadata，最后是默认级别。
+func (s *PriorityScheduler) Classify(ctx context.Context, req *llmcore.UnifiedRequest) PriorityClass {
+	if v, ok := types.RequestPriority(ctx); ok {
+		if class, ok := ParsePriorityClass(v); ok {
+			return class
+		}
+	}
+	if req != nil && req.Metadata != nil {
+		if class, ok := ParsePriorityClass(req.Metadata[metadataKeyPriority]); ok {
+			return class
+		}
+	}
+	return s.cfg.DefaultClass
+}
+
+// Invoke 在获得准入后执行同步调用。
+func (s *PriorityScheduler) Invoke(ctx context.Context, req *llmcore.UnifiedRequest) (*llmcore.UnifiedResponse, error) {
+	class := s.Classify(ctx, req)
+	if err := s.acquire(ctx, class); err != nil {
+		return nil, err
+	}
+	defer s.release(class)
+	return s.next.Invoke(ctx, req)
+}
+
+// Stream 在获得准入后执行流式调用，准入名额在流结束时释放。
+func (s *PriorityScheduler) Stream(ctx context.Context, req *llmcore.UnifiedRequest) (<-chan llmcore.UnifiedChunk, error) {
+	class := s.Classify(ctx, req)
+	if err := s.acquire(ctx, class); err != nil {
+		return nil, err
+	}
+	source, err := s.next.Stream(ctx, req)
+	if err != nil {
+		s.release(class)
+		return nil, err
+	}
+
+	out := make(chan llmcore.UnifiedChunk)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("priority stream relay panic recovered", zap.Any("panic", r))
+			}
+			close(out)
+			s.release(class)
+		}()
+		for chunk := range source {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// 名额在上游真正结束后才归还，否则取消后的上游仍在运行却不占用并发名额。
+				for range source {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Stats 返回各优先级的调度统计。
+func (s *PriorityScheduler) Stats() map[PriorityClass]PriorityStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[PriorityClass]PriorityStats, len(priorityOrder))
+	for _, class := range priorityOrder {
+		out[class] = PriorityStats{
+			InFlight: s.inFlight[class],
+			Queued:   s.waiters[class].Len(),
+			Admitted: s.admitted[class],
+			Rejected: s.rejected[class],
+		}
+	}
+	return out
+}
+
+func (s *PriorityScheduler) acquire(ctx context.Context, class PriorityClass) error {
+	cfg := s.cfg.Classes[class]
+	pressure := s.pressure()
+
+	s.mu.Lock()
+	if cfg.ShedAbovePressure > 0 && pressure >= cfg.ShedAbovePressure {
+		s.rejected[class]++
+		s.mu.Unlock()
+		return s.rejectError(class, fmt.Sprintf("shed under upstream rate-limit pressure %.2f", pressure))
+	}
+	if !s.queuedAtOrAboveLocked(class) && s.canRunLocked(class, pressure) {
+		s.grantLocked(class)
+		s.mu.Unlock()
+		return nil
+	}
+	if s.waiters[class].Len() >= cfg.MaxQueue {
+		s.rejected[class]++
+		s.mu.Unlock()
+		return s.rejectError(class, "queue is full")
+	}
+	w := &priorityWaiter{class: class, ready: make(chan struct{})}
+	elem := s.waiters[class].PushBack(w)
+	s.mu.Unlock()
+	// 排在更高优先级等待者之后时，由 dispatch 按优先级顺序分配可能空闲的名额。
+	s.dispatch()
+
+	var deadline <-chan time.Time
+	if cfg.MaxWait > 0 {
+		timer := time.NewTimer(cfg.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	poll := time.NewTicker(s.cfg.PressurePollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-w.ready:
+			return nil
+		case <-poll.C:
+			s.dispatch()
+		case <-deadline:
+			return s.abandon(class, elem, w, s.rejectError(class, "timed out waiting in queue"))
+		case <-ctx.Done():
+			return s.abandon(class, elem, w, ctx.Err())
+		}
+	}
+}
+
+// abandon 将等待者移出队列；若其已被授予名额则归还。
+func (s *PriorityScheduler) abandon(class PriorityClass, elem *list.Element, w *priorityWaiter, err error) error {
+	s.mu.Lock()
+	select {
+	case <-w.ready:
+		s.mu.Unlock()
+		s.release(class)
+	default:
+		s.waiters[class].Remove(elem)
+		s.rejected[class]++
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *PriorityScheduler) release(class PriorityClass) {
+	s.mu.Lock()
+	s.inFlight[class]--
+	s.total--
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// dispatch 按优先级顺序唤醒可以执行的等待者，共享并发名额总是先分配给高优先级。
+func (s *PriorityScheduler) dispatch() {
+	pressure := s.pressure()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, class := range priorityOrder {
+		queue := s.waiters[class]
+		for queue.Len() > 0 && s.canRunLocked(class, pressure) {
+			w := queue.Remove(queue.Front()).(*priorityWaiter)
+			s.grantLocked(class)
+			close(w.ready)
+		}
+	}
+}
+
+// queuedAtOrAboveLocked 报告同级或更高优先级是否仍有等待者；此时新请求不能绕过队列直接执行。
+func (s *PriorityScheduler) queuedAtOrAboveLocked(class PriorityClass) bool {
+	for _, c := range priorityOrder {
+		if s.waiters[c].Len() > 0 {
+			return true
+		}
+		if c == class {
+			return false
+		}
+	}
+	return false
+}
+
+func (s *PriorityScheduler) canRunLocked(class PriorityClass, pressure float64) bool {
+	cfg := s.cfg.Classes[class]
+	if cfg.DelayAbovePressure > 0 && pressure >= cfg.DelayAbovePressure {
+		return false
+	}
+	if cfg.MaxConcurrent > 0 && s.inFlight[class] >= cfg.MaxConcurrent {
+		return false
+	}
+	return !s.globalFullLocked()
+}
+
+func (s *PriorityScheduler) globalFullLocked() bool {
+	return s.cfg.MaxConcurrent > 0 && s.total >= s.cfg.MaxConcurrent
+}
+
+func (s *PriorityScheduler) grantLocked(class PriorityClass) {
+	s.inFlight[class]++
+	s.total++
+	s.admitted[class]++
+}
+
+func (s *PriorityScheduler) pressure() float64 {
+	if s.cfg.Pressure == nil {
+		return 0
+	}
+	return s.cfg.Pressure()
+}
+
+func (s *PriorityScheduler) rejectError(class PriorityClass, reason string) *types.Error {
+	s.logger.Debug("request rejected by priority scheduler",
+		zap.String("class", string(class)),
+		zap.String("reason", reason))
+	return types.NewRateLimitError(fmt.Sprintf("%s request rejected: %s", class, reason))
+}
@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingGateway 阻塞每个请求直到 release 被关闭，并记录执行顺序。
+type blockingGateway struct {
+	release chan struct{}
+	mu      sync.Mutex
+	order   []string
+	started chan string
+}
+
+func newBlockingGateway() *blockingGateway {
+	return &blockingGateway{release: make(chan struct{}), started: make(chan string, 16)}
+}
+
+func (g *blockingGateway) Invoke(ctx context.Context, req *llmcore.UnifiedRequest) (*llmcore.UnifiedResponse, error) {
+	g.mu.Lock()
+	g.order = append(g.order, req.TraceID)
+	g.mu.Unlock()
+	g.started <- req.TraceID
+	<-g.release
+	return &llmcore.UnifiedResponse{TraceID: req.TraceID}, nil
+}
+
+func (g *blockingGateway) Stream(ctx context.Context, req *llmcore.UnifiedRequest) (<-chan llmcore.UnifiedChunk, error) {
+	ch := make(chan llmcore.UnifiedChunk, 1)
+	ch <- llmcore.UnifiedChunk{TraceID: req.TraceID}
+	close(ch)
+	return ch, nil
+}
+
+func priorityCtx(class PriorityClass) context.Context {
+	return types.WithRequestPriority(context.Background(), string(class))
+}
+
+func TestPriorityScheduler_Classify(t *testing.T) {
+	s := NewPriorityScheduler(newBlockingGateway(), PrioritySchedulerConfig{}, nil)
+
+	assert.Equal(t, PriorityBatch, s.Classify(priorityCtx(PriorityBatch), nil))
+	assert.Equal(t, PriorityBackground, s.Classify(context.Background(),
+		&llmcore.UnifiedRequest{Metadata: map[string]string{"priority": "Background"}}))
+	assert.Equal(t, PriorityInteractive, s.Classify(types.WithRequestPriority(context.Background(), "bogus"), nil))
+}
+
+func TestPriorityScheduler_InteractiveJumpsAheadOfBackground(t *testing.T) {
+	next := newBlockingGateway()
+	s := NewPriorityScheduler(next, PrioritySchedulerConfig{MaxConcurrent: 1}, nil)
+
+	var wg sync.WaitGroup
+	invoke := func(class PriorityClass, id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Invoke(priorityCtx(class), &llmcore.UnifiedRequest{TraceID: id})
+			assert.NoError(t, err)
+		}()
+	}
+
+	invoke(PriorityBackground, "bg-1")
+	require.Equal(t, "bg-1", <-next.started)
+
+	invoke(PriorityBackground, "bg-2")
+	require.Eventually(t, func() bool { return s.Stats()[PriorityBackground].Queued == 1 }, time.Second, 5*time.Millisecond)
+	invoke(PriorityInteractive, "chat-1")
+	require.Eventually(t, func() bool { return s.Stats()[PriorityInteractive].Queued == 1 }, time.Second, 5*time.Millisecond)
+
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"bg-1", "chat-1", "bg-2"}, next.order)
+	stats := s.Stats()
+	assert.Equal(t, 0, stats[PriorityInteractive].InFlight)
+	assert.Equal(t, int64(2), stats[PriorityBackground].Admitted)
+}
+
+func TestPriorityScheduler_ShedsAndDelaysUnderPressure(t *testing.T) {
+	var pressure atomic.Value
+	pressure.Store(0.9)
+	next := newBlockingGateway()
+	close(next.release)
+	s := NewPriorityScheduler(next, PrioritySchedulerConfig{
+		Pressure:             func() float64 { return pressure.Load().(float64) },
+		PressurePollInterval: 5 * time.Millisecond,
+	}, nil)
+
+	// batch 在 0.85 以上被直接丢弃。
+	_, err := s.Invoke(priorityCtx(PriorityBatch), &llmcore.UnifiedRequest{TraceID: "batch"})
+	require.Error(t, err)
+	typed, ok := types.AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+
+	// interactive 不受压力影响。
+	_, err = s.Invoke(priorityCtx(PriorityInteractive), &llmcore.UnifiedRequest{TraceID: "chat"})
+	require.NoError(t, err)
+
+	// background 在 0.8 以上被延迟，压力回落后放行。
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Invoke(priorityCtx(PriorityBackground), &llmcore.UnifiedRequest{TraceID: "bg"})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return s.Stats()[PriorityBackground].Queued == 1 }, time.Second, 5*time.Millisecond)
+	pressure.Store(0.1)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("delayed background request was not admitted after pressure dropped")
+	}
+}
+
+func TestPriorityScheduler_QueueLimitsAndTimeout(t *testing.T) {
+	next := newBlockingGateway()
+	s := NewPriorityScheduler(next, PrioritySchedulerConfig{
+		Classes: map[PriorityClass]PriorityClassConfig{
+			PriorityBatch: {MaxConcurrent: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond},
+		},
+	}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = s.Invoke(priorityCtx(PriorityBatch), &llmcore.UnifiedRequest{TraceID: "b1"})
+	}()
+	<-next.started
+
+	// 第二个请求排队后超时；期间第三个请求因队列已满被拒绝。
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := s.Invoke(priorityCtx(PriorityBatch), &llmcore.UnifiedRequest{TraceID: "b2"})
+		waitErr <- err
+	}()
+	require.Eventually(t, func() bool { return s.Stats()[PriorityBatch].Queued == 1 }, time.Second, time.Millisecond)
+	_, err := s.Invoke(priorityCtx(PriorityBatch), &llmcore.UnifiedRequest{TraceID: "b3"})
+	assert.ErrorContains(t, err, "queue is full")
+	assert.ErrorContains(t, <-waitErr, "timed out")
+
+	close(next.release)
+	<-done
+	stats := s.Stats()[PriorityBatch]
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, int64(2), stats.Rejected)
+}
+
+func TestPriorityScheduler_StreamReleasesOnClose(t *testing.T) {
+	s := NewPriorityScheduler(newBlockingGateway(), PrioritySchedulerConfig{MaxConcurrent: 1}, nil)
+
+	ch, err := s.Stream(priorityCtx(PriorityInteractive), &llmcore.UnifiedRequest{TraceID: "s1"})
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Eventually(t, func() bool { return s.Stats()[PriorityInteractive].InFlight == 0 }, time.Second, time.Millisecond)
+}
+
+func TestPriorityScheduler_FastPathDoesNotBypassHigherPriorityWaiters(t *testing.T) {
+	next := newBlockingGateway()
+	close(next.release)
+	s := NewPriorityScheduler(next, PrioritySchedulerConfig{MaxConcurrent: 1}, nil)
+
+	// 模拟名额刚被归还、interactive 等待者尚未被 dispatch 唤醒的瞬间。
+	waiter := &priorityWaiter{class: PriorityInteractive, ready: make(chan struct{})}
+	s.mu.Lock()
+	s.waiters[PriorityInteractive].PushBack(waiter)
+	s.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Invoke(priorityCtx(PriorityBackground), &llmcore.UnifiedRequest{TraceID: "bg"})
+		done <- err
+	}()
+
+	select {
+	case <-waiter.ready:
+	case <-time.After(time.Second):
+		t.Fatal("queued interactive waiter was not granted the free slot")
+	}
+	require.Eventually(t, func() bool { return s.Stats()[PriorityBackground].Queued == 1 }, time.Second, time.Millisecond)
+
+	s.release(PriorityInteractive)
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"bg"}, next.order)
+}
+
+// producingGateway 的流在 Stream 返回后持续产出，直到写完全部 chunk。
+type producingGateway struct {
+	blockingGateway
+	chunks   int
+	finished chan struct{}
+}
+
+func (g *producingGateway) Stream(ctx context.Context, req *llmcore.UnifiedRequest) (<-chan llmcore.UnifiedChunk, error) {
+	ch := make(chan llmcore.UnifiedChunk)
+	go func() {
+		defer close(g.finished)
+		defer close(ch)
+		for i := 0; i < g.chunks; i++ {
+			ch <- llmcore.UnifiedChunk{TraceID: req.TraceID}
+		}
+	}()
+	return ch, nil
+}
+
+func TestPriorityScheduler_StreamDrainsUpstreamAfterCancel(t *testing.T) {
+	next := &producingGateway{chunks: 5, finished: make(chan struct{})}
+	s := NewPriorityScheduler(next, PrioritySchedulerConfig{MaxConcurrent: 1}, nil)
+
+	ctx, cancel := context.WithCancel(priorityCtx(PriorityInteractive))
+	ch, err := s.Stream(ctx, &llmcore.UnifiedRequest{TraceID: "s1"})
+	require.NoError(t, err)
+	<-ch
+	cancel()
+
+	select {
+	case <-next.finished:
+	case <-time.After(time.Second):
+		t.Fatal("upstream stream was not drained after cancellation")
+	}
+	require.Eventually(t, func() bool { return s.Stats()[PriorityInteractive].InFlight == 0 }, time.Second, time.Millisecond)
+}
@@ -105,10 +105,21 @@ func TimeoutMiddleware(timeout time.Duration) Middleware {
 // RetryMiddleware 重试失败的请求.
 // 只重试可重试的错误（*types.Error 且 Retryable=true），不可重试的错误立即返回。
 func RetryMiddleware(maxRetries int, backoff time.Duration) Middleware {
+	return retryMiddleware(maxRetries, backoff, nil)
+}
+
+func retryMiddleware(maxRetries int, backoff time.Duration, budget *BudgetRetryConfig) Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
 			var lastErr error
 			for i := 0; i <= maxRetries; i++ {
+				if i > 0 && budget != nil {
+					retryReq, ok := budget.admitRetry(req)
+					if !ok {
+						return nil, lastErr
+					}
+					req = retryReq
+				}
 				resp, err := next(ctx, req)
 				if err == nil {
 					return resp, nil
@@ -172,11 +183,14 @@ func HeadersMiddleware(headers map[string]string) Middleware {
 	}
 }
 
-// CacheMiddleware 缓存响应.
+// CacheMiddleware 缓存响应. Key 返回空字符串时跳过缓存.
 func CacheMiddleware(cache Cache) Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
 			key := cache.Key(req)
+			if key == "" {
+				return next(ctx, req)
+			}
 			if cached, ok := cache.Get(key); ok {
 				return cached, nil
 			}
@@ -193,6 +207,7 @@ func CacheMiddleware(cache Cache) Middleware {
 
 // Cache 定义缓存接口.
 type Cache interface {
+	// Key 返回请求的缓存键, 返回空字符串表示该请求不可缓存.
 	Key(req *llmpkg.ChatRequest) string
 	Get(key string) (*llmpkg.ChatResponse, bool)
 	Set(key string, resp *llmpkg.ChatResponse)
@@ -363,6 +363,22 @@ func TestCacheMiddleware(t *testing.T) {
 		_, _ = h(context.Background(), simpleReq())
 		assert.Equal(t, 2, calls) // called twice because error not cached
 	})
+
+	t.Run("empty key bypasses cache", func(t *testing.T) {
+		cache := newTestCache()
+		calls := 0
+		inner := func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+			calls++
+			return &llmpkg.ChatResponse{Model: req.Model}, nil
+		}
+		h := NewChain(CacheMiddleware(cache)).Then(inner)
+		req := simpleReq()
+		req.Model = ""
+		_, _ = h(context.Background(), req)
+		_, _ = h(context.Background(), req)
+		assert.Equal(t, 2, calls)
+		assert.Empty(t, cache.store)
+	})
 }
 
 // --- RateLimitMiddleware ---
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// DeadlineBudgetMiddleware 为下游调用预留截止时间预算：下游只能使用剩余时间减去 reserve，
+// 留给上层做降级或组装结果；剩余时间不足时下游至少获得一半。
+func DeadlineBudgetMiddleware(reserve time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+			budgetCtx, cancel := types.ReserveBudget(ctx, reserve)
+			defer cancel()
+			return next(budgetCtx, req)
+		}
+	}
+}
+
+// WithDeadlineReserve 为 Stream 启用截止时间预算：上游流使用预留后的预算，
+// 预算耗尽时已收到的内容以 Truncated=deadline 的结束块返回，而不是整体失败。
+func (p *MiddlewareProvider) WithDeadlineReserve(reserve time.Duration) *MiddlewareProvider {
+	p.deadlineReserve = reserve
+	p.partialOnDeadline = true
+	return p
+}
+
+func (p *MiddlewareProvider) openStream(ctx context.Context, req *llmpkg.ChatRequest) (<-chan llmpkg.StreamChunk, error) {
+	if !p.partialOnDeadline {
+		return p.inner.Stream(ctx, req)
+	}
+	upstreamCtx, cancel := types.ReserveBudget(ctx, p.deadlineReserve)
+	source, err := p.inner.Stream(upstreamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return PartialResultStream(upstreamCtx, source, cancel), nil
+}
+
+// PartialResultStream 转发 source，直到 ctx 的截止时间到达：
+// 已输出内容时追加 FinishReason/Truncated 为 deadline 的结束块并关闭，未输出内容时返回超时错误。
+// 关闭输出后继续在后台排空 source，done 在 source 关闭后调用（可为 nil）。
+func PartialResultStream(ctx context.Context, source <-chan llmpkg.StreamChunk, done func()) <-chan llmpkg.StreamChunk {
+	out := make(chan llmpkg.StreamChunk)
+	go func() {
+		defer func() {
+			go func() {
+				for range source {
+				}
+				if done != nil {
+					done()
+				}
+			}()
+		}()
+		defer close(out)
+
+		var last llmpkg.StreamChunk
+		emitted, completed := false, false
+		finish := func(cause error) {
+			if completed {
+				return
+			}
+			if !emitted {
+				out <- llmpkg.StreamChunk{Err: types.NewError(types.ErrTimeout, "deadline budget exhausted before first token").WithCause(cause)}
+				return
+			}
+			out <- llmpkg.StreamChunk{
+				ID:           last.ID,
+				Provider:     last.Provider,
+				Model:        last.Model,
+				Index:        last.Index,
+				FinishReason: types.FinishReasonDeadline,
+				Truncated:    types.TruncatedDeadline,
+			}
+		}
+
+		for {
+			select {
+			case chunk, ok := <-source:
+				if !ok {
+					if types.DeadlineExceeded(ctx, nil) {
+						finish(ctx.Err())
+					}
+					return
+				}
+				if chunk.Err != nil && types.DeadlineExceeded(ctx, chunk.Err) {
+					finish(chunk.Err)
+					return
+				}
+				out <- chunk
+				// 正常结束或非截止时间错误后不再标记截断
+				if chunk.Err != nil || chunk.FinishReason != "" {
+					completed = true
+				}
+				if observability.IsTokenChunk(chunk) {
+					emitted = true
+				}
+				last = chunk
+			case <-ctx.Done():
+				if types.DeadlineExceeded(ctx, nil) {
+					finish(ctx.Err())
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectChunks(ch <-chan llmpkg.StreamChunk) []llmpkg.StreamChunk {
+	var chunks []llmpkg.StreamChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func TestPartialResultStream_TruncatesOnDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	source := make(chan llmpkg.StreamChunk, 1)
+	source <- llmpkg.StreamChunk{ID: "c1", Provider: "openai", Model: "gpt-4o", Delta: types.Message{Content: "partial"}}
+	done := make(chan struct{})
+	out := PartialResultStream(ctx, source, func() { close(done) })
+
+	chunks := collectChunks(out)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "partial", chunks[0].Delta.Content)
+	last := chunks[1]
+	assert.Nil(t, last.Err)
+	assert.Equal(t, "c1", last.ID)
+	assert.Equal(t, types.FinishReasonDeadline, last.FinishReason)
+	assert.Equal(t, types.TruncatedDeadline, last.Truncated)
+
+	// 上游关闭后才回调 done
+	close(source)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("done not called after source drained")
+	}
+}
+
+func TestPartialResultStream_ErrorsWithoutContent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	source := make(chan llmpkg.StreamChunk)
+	defer close(source)
+
+	chunks := collectChunks(PartialResultStream(ctx, source, nil))
+	require.Len(t, chunks, 1)
+	assert.True(t, types.DeadlineExceeded(nil, chunks[0].Err))
+	assert.Empty(t, chunks[0].Truncated)
+}
+
+func TestPartialResultStream_CompletedStreamUntouched(t *testing.T) {
+	source := make(chan llmpkg.StreamChunk, 2)
+	source <- llmpkg.StreamChunk{Delta: types.Message{Content: "hi"}}
+	source <- llmpkg.StreamChunk{FinishReason: "stop"}
+	close(source)
+
+	chunks := collectChunks(PartialResultStream(context.Background(), source, nil))
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "stop", chunks[1].FinishReason)
+	assert.Empty(t, chunks[1].Truncated)
+}
+
+func TestMiddlewareProvider_WithDeadlineReserve(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	ch := make(chan llmpkg.StreamChunk, 1)
+	ch <- llmpkg.StreamChunk{Delta: types.Message{Content: "so far"}}
+	defer close(ch)
+	wrapped := NewMiddlewareProvider(&mockProvider{name: "test", streamCh: ch}, NewChain()).
+		WithDeadlineReserve(150 * time.Millisecond)
+
+	start := time.Now()
+	stream, err := wrapped.Stream(ctx, &llmpkg.ChatRequest{Model: "gpt-4o"})
+	require.NoError(t, err)
+	chunks := collectChunks(stream)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, types.TruncatedDeadline, chunks[1].Truncated)
+	// 上游预算只有剩余时间的一半，调用方仍有剩余预算
+	assert.Less(t, time.Since(start), 180*time.Millisecond)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestDeadlineBudgetMiddleware(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var remaining time.Duration
+	handler := DeadlineBudgetMiddleware(400 * time.Millisecond)(func(ctx context.Context, _ *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		remaining, _ = types.RemainingBudget(ctx)
+		return &llmpkg.ChatResponse{}, nil
+	})
+	_, err := handler(ctx, &llmpkg.ChatRequest{})
+	require.NoError(t, err)
+	assert.InDelta(t, float64(600*time.Millisecond), float64(remaining), float64(50*time.Millisecond))
+}
@@ -0,0 +1,365 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDistributedRateLimitPrefix = "agentflow:ratelimit:"
+
+	rateLimitRequestWindow = time.Second
+	rateLimitTokenWindow   = time.Minute
+
+	// RateLimitDimensionRequests 请求数维度（每秒）
+	RateLimitDimensionRequests = "requests"
+	// RateLimitDimensionTokens token 维度（每分钟）
+	RateLimitDimensionTokens = "tokens"
+)
+
+// RateLimitKey 分布式限流的计数维度。
+type RateLimitKey struct {
+	Tenant   string
+	Provider string
+	Model    string
+}
+
+// String 返回 "tenant/provider/model"，各段经 url.QueryEscape 转义：
+// 段内的 "/"、"{"、"}"、"*" 等都会被转义，不同的 key 不会映射到同一计数器或误命中通配配额。
+func (k RateLimitKey) String() string {
+	return rateLimitSegment(k.Tenant) + "/" + rateLimitSegment(k.Provider) + "/" + rateLimitSegment(k.Model)
+}
+
+// RateLimitQuota 单个 key 的配额，0 表示该维度不限。
+type RateLimitQuota struct {
+	RequestsPerSecond int `json:"requests_per_second" yaml:"requests_per_second"`
+	TokensPerMinute   int `json:"tokens_per_minute" yaml:"tokens_per_minute"`
+}
+
+func (q RateLimitQuota) unlimited() bool {
+	return q.RequestsPerSecond <= 0 && q.TokensPerMinute <= 0
+}
+
+// DistributedRateLimitConfig Redis 分布式限流配置。
+type DistributedRateLimitConfig struct {
+	// Prefix Redis key 前缀，默认 "agentflow:ratelimit:"
+	Prefix string
+	// DefaultQuota 未命中 Quotas 时使用的配额
+	DefaultQuota RateLimitQuota
+	// Quotas 按 "tenant/provider/model" 覆盖配额，provider、model 段可写 "*"；
+	// 各段按 RateLimitKey.String 的规则转义（普通字母数字值无需处理）。
+	// 查找顺序：t/p/m → t/p/* → t/*/* → */p/m → */p/* → DefaultQuota
+	Quotas map[string]RateLimitQuota
+	// Provider 中间件链对应的 provider 名（ChatRequest 不携带 provider）
+	Provider string
+	// DryRun 只记录计数与判定结果，不拒绝请求，用于上线前观察配额是否合理
+	DryRun bool
+	// Tokenizer 估算请求 token，默认 types.NewEstimateTokenizer()
+	Tokenizer types.Tokenizer
+	// OnDecision 可选：每次判定后回调，用于指标上报
+	OnDecision func(RateLimitDecision)
+	Logger     *zap.Logger
+}
+
+// RateLimitDecision 一次限流判定结果。
+type RateLimitDecision struct {
+	Key        RateLimitKey
+	Allowed    bool
+	DryRun     bool
+	Dimension  string        // 触发限流的维度，允许时为空
+	RetryAfter time.Duration // 被限流时建议的重试等待
+	Requests   float64       // 判定前滑动窗口内的请求数
+	Tokens     float64       // 判定前滑动窗口内的 token 数
+	Reserved   int           // 本次预占的 token 数
+
+	tokenKey string
+}
+
+// RedisRateLimiter 基于 Redis 滑动窗口计数器的分布式限流器，多副本共享同一配额。
+// 每个维度用当前窗口与上一窗口两个计数器按时间加权近似滑动窗口，判定与计数在 Lua 脚本中原子完成。
+type RedisRateLimiter struct {
+	client *redis.Client
+	cfg    DistributedRateLimitConfig
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewRedisRateLimiter 创建 Redis 分布式限流器。
+func NewRedisRateLimiter(client *redis.Client, cfg DistributedRateLimitConfig) *RedisRateLimiter {
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultDistributedRateLimitPrefix
+	}
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = types.NewEstimateTokenizer()
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RedisRateLimiter{
+		client: client,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "distributed_rate_limiter")),
+		now:    time.Now,
+	}
+}
+
+// QuotaFor 返回 key 生效的配额。
+func (l *RedisRateLimiter) QuotaFor(key RateLimitKey) RateLimitQuota {
+	t, p, m := rateLimitSegment(key.Tenant), rateLimitSegment(key.Provider), rateLimitSegment(key.Model)
+	for _, candidate := range []string{
+		t + "/" + p + "/" + m,
+		t + "/" + p + "/*",
+		t + "/*/*",
+		"*/" + p + "/" + m,
+		"*/" + p + "/*",
+	} {
+		if quota, ok := l.cfg.Quotas[candidate]; ok {
+			return quota
+		}
+	}
+	return l.cfg.DefaultQuota
+}
+
+// slidingWindowScript 按滑动窗口检查请求数与 token 两个维度，未超限（或 dry-run）时计数。
+// KEYS: 请求当前窗口, 请求上一窗口, token 当前窗口, token 上一窗口
+// ARGV: 请求上限, 上一请求窗口权重, token 上限, 上一 token 窗口权重, 本次 token, dry-run, 请求 TTL ms, token TTL ms
+// 返回 {被限流维度(0/1/2), 请求用量, token 用量}
+var slidingWindowScript = redis.NewScript(`
+local reqLimit = tonumber(ARGV[1])
+local reqWeight = tonumber(ARGV[2])
+local tokLimit = tonumber(ARGV[3])
+local tokWeight = tonumber(ARGV[4])
+local tokens = tonumber(ARGV[5])
+local dryRun = ARGV[6] == "1"
+
+local reqUsed = tonumber(redis.call("GET", KEYS[1]) or "0") + tonumber(redis.call("GET", KEYS[2]) or "0") * reqWeight
+local tokUsed = tonumber(redis.call("GET", KEYS[3]) or "0") + tonumber(redis.call("GET", KEYS[4]) or "0") * tokWeight
+
+local blocked = 0
+if reqLimit > 0 and reqUsed + 1 > reqLimit then
+	blocked = 1
+elseif tokLimit > 0 and tokUsed + tokens > tokLimit then
+	blocked = 2
+end
+
+if blocked == 0 or dryRun then
+	redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ARGV[7])
+	if tokens > 0 then
+		redis.call("INCRBY", KEYS[3], tokens)
+		redis.call("PEXPIRE", KEYS[3], ARGV[8])
+	end
+end
+return {blocked, tostring(reqUsed), tostring(tokUsed)}
+`)
+
+// Acquire 为一次请求判定并计数；tokens 为预估消耗（输入 + 最大输出）。
+// DryRun 下始终允许，但仍计数并返回真实判定。
+func (l *RedisRateLimiter) Acquire(ctx context.Context, key RateLimitKey, tokens int) (RateLimitDecision, error) {
+	decision := RateLimitDecision{Key: key, Allowed: true, DryRun: l.cfg.DryRun}
+	quota := l.QuotaFor(key)
+	if quota.unlimited() {
+		return decision, nil
+	}
+	if tokens < 0 {
+		tokens = 0
+	}
+
+	now := l.now()
+	reqKeys, reqWeight, reqRemaining := l.windowKeys(key, RateLimitDimensionRequests, rateLimitRequestWindow, now)
+	tokKeys, tokWeight, tokRemaining := l.windowKeys(key, RateLimitDimensionTokens, rateLimitTokenWindow, now)
+	dryRun := "0"
+	if l.cfg.DryRun {
+		dryRun = "1"
+	}
+	raw, err := slidingWindowScript.Run(ctx, l.client,
+		[]string{reqKeys[0], reqKeys[1], tokKeys[0], tokKeys[1]},
+		quota.RequestsPerSecond, reqWeight, quota.TokensPerMinute, tokWeight, tokens, dryRun,
+		(2 * rateLimitRequestWindow).Milliseconds(), (2 * rateLimitTokenWindow).Milliseconds(),
+	).Slice()
+	if err != nil {
+		return decision, fmt.Errorf("distributed rate limit: %w", err)
+	}
+	if len(raw) != 3 {
+		return decision, fmt.Errorf("distributed rate limit: unexpected script result %v", raw)
+	}
+	blocked, _ := raw[0].(int64)
+	decision.Requests = parseScriptFloat(raw[1])
+	decision.Tokens = parseScriptFloat(raw[2])
+	decision.tokenKey = tokKeys[0]
+
+	switch blocked {
+	case 1:
+		decision.Dimension = RateLimitDimensionRequests
+		decision.RetryAfter = reqRemaining
+	case 2:
+		decision.Dimension = RateLimitDimensionTokens
+		decision.RetryAfter = tokRemaining
+	}
+	if blocked == 0 || l.cfg.DryRun {
+		decision.Reserved = tokens
+	}
+	if blocked != 0 {
+		decision.Allowed = l.cfg.DryRun
+		l.logger.Info("rate limit exceeded",
+			zap.String("key", key.String()),
+			zap.String("dimension", decision.Dimension),
+			zap.Bool("dry_run", l.cfg.DryRun),
+			zap.Float64("requests", decision.Requests),
+			zap.Float64("tokens", decision.Tokens),
+			zap.Int("quota_rps", quota.RequestsPerSecond),
+			zap.Int("quota_tpm", quota.TokensPerMinute))
+	}
+	if l.cfg.OnDecision != nil {
+		l.cfg.OnDecision(decision)
+	}
+	return decision, nil
+}
+
+// Reconcile 用实际 token 用量修正 Acquire 时的预占量。
+func (l *RedisRateLimiter) Reconcile(ctx context.Context, decision RateLimitDecision, actualTokens int) error {
+	if decision.tokenKey == "" {
+		return nil
+	}
+	delta := actualTokens - decision.Reserved
+	if delta == 0 {
+		return nil
+	}
+	pipe := l.client.TxPipeline()
+	pipe.IncrBy(ctx, decision.tokenKey, int64(delta))
+	pipe.PExpire(ctx, decision.tokenKey, 2*rateLimitTokenWindow)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// windowKeys 返回当前与上一窗口的计数 key、上一窗口权重以及当前窗口剩余时长。
+// key 使用 hash tag 保证同一维度的计数落在同一 Redis Cluster slot。
+func (l *RedisRateLimiter) windowKeys(key RateLimitKey, dimension string, window time.Duration, now time.Time) ([2]string, string, time.Duration) {
+	ms := now.UnixMilli()
+	size := window.Milliseconds()
+	current := ms / size
+	elapsed := float64(ms%size) / float64(size)
+	base := l.cfg.Prefix + "{" + key.String() + "}:" + dimension + ":"
+	keys := [2]string{base + strconv.FormatInt(current, 10), base + strconv.FormatInt(current-1, 10)}
+	remaining := time.Duration(size-ms%size) * time.Millisecond
+	return keys, strconv.FormatFloat(1-elapsed, 'f', 4, 64), remaining
+}
+
+// DistributedRateLimitMiddleware 按 (tenant, provider, model) 应用 Redis 分布式限流。
+// 租户取自 ChatRequest.TenantID 或 context；Redis 不可用时放行并记录告警，避免限流组件拖垮主链路。
+func DistributedRateLimitMiddleware(limiter *RedisRateLimiter) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+			if limiter == nil || req == nil {
+				return next(ctx, req)
+			}
+			decision, err := limiter.acquireFor(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next(ctx, req)
+			switch {
+			case err != nil:
+				// 上游失败未消耗 token，退还预占量
+				limiter.settle(ctx, decision, 0)
+			case resp != nil && resp.Usage.TotalTokens > 0:
+				limiter.settle(ctx, decision, resp.Usage.TotalTokens)
+			}
+			return resp, err
+		}
+	}
+}
+
+// acquireFor 为请求判定配额；被限流时返回 RateLimitError。
+// Redis 不可用时放行，返回的 decision 不持有预占量。
+func (l *RedisRateLimiter) acquireFor(ctx context.Context, req *llmpkg.ChatRequest) (RateLimitDecision, error) {
+	key := RateLimitKey{Tenant: req.TenantID, Provider: l.cfg.Provider, Model: req.Model}
+	if key.Tenant == "" {
+		key.Tenant, _ = types.TenantID(ctx)
+	}
+	decision, err := l.Acquire(ctx, key, l.estimateTokens(req))
+	if err != nil {
+		l.logger.Warn("distributed rate limit unavailable, allowing request", zap.Error(err))
+		return RateLimitDecision{Key: key, Allowed: true}, nil
+	}
+	if !decision.Allowed {
+		return decision, types.NewRateLimitError(fmt.Sprintf(
+			"rate limit exceeded for %s (%s), retry after %s",
+			key.String(), decision.Dimension, decision.RetryAfter.Round(time.Millisecond)))
+	}
+	return decision, nil
+}
+
+// settle 以实际用量结算预占量；请求可能已被取消，结算不跟随 ctx 取消。
+func (l *RedisRateLimiter) settle(ctx context.Context, decision RateLimitDecision, actualTokens int) {
+	if err := l.Reconcile(context.WithoutCancel(ctx), decision, actualTokens); err != nil {
+		l.logger.Debug("failed to reconcile rate limit tokens", zap.Error(err))
+	}
+}
+
+// limitStream 在流结束后结算预占量：有 usage 时按实际用量，出错时全额退还，
+// 未上报 usage 的正常结束保留预估值。
+func (l *RedisRateLimiter) limitStream(ctx context.Context, decision RateLimitDecision, source <-chan llmpkg.StreamChunk) <-chan llmpkg.StreamChunk {
+	out := make(chan llmpkg.StreamChunk)
+	go func() {
+		defer close(out)
+		usage, failed := 0, false
+		defer func() {
+			switch {
+			case usage > 0:
+				l.settle(ctx, decision, usage)
+			case failed:
+				l.settle(ctx, decision, 0)
+			}
+		}()
+		for chunk := range source {
+			if chunk.Usage != nil && chunk.Usage.TotalTokens > 0 {
+				usage = chunk.Usage.TotalTokens
+			}
+			if chunk.Err != nil {
+				failed = true
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				failed = true
+				for range source {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (l *RedisRateLimiter) estimateTokens(req *llmpkg.ChatRequest) int {
+	tokens := l.cfg.Tokenizer.CountMessagesTokens(req.Messages)
+	if len(req.Tools) > 0 {
+		tokens += l.cfg.Tokenizer.EstimateToolTokens(req.Tools)
+	}
+	if req.MaxTokens > 0 {
+		tokens += req.MaxTokens
+	}
+	return tokens
+}
+
+func rateLimitSegment(s string) string {
+	return url.QueryEscape(strings.TrimSpace(s))
+}
+
+func parseScriptFloat(v any) float64 {
+	s, _ := v.(string)
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisRateLimiter(t *testing.T, cfg DistributedRateLimitConfig, now *time.Time) (*RedisRateLimiter, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	limiter := NewRedisRateLimiter(rdb, cfg)
+	limiter.now = func() time.Time { return *now }
+	return limiter, rdb
+}
+
+func TestRedisRateLimiter_RequestsPerSecondSharedAcrossReplicas(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	cfg := DistributedRateLimitConfig{DefaultQuota: RateLimitQuota{RequestsPerSecond: 2}}
+	replicaA, rdb := newTestRedisRateLimiter(t, cfg, &now)
+	replicaB := NewRedisRateLimiter(rdb, cfg)
+	replicaB.now = replicaA.now
+	key := RateLimitKey{Tenant: "t1", Provider: "openai", Model: "gpt-4o"}
+	ctx := context.Background()
+
+	d, err := replicaA.Acquire(ctx, key, 0)
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+	d, err = replicaB.Acquire(ctx, key, 0)
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+
+	d, err = replicaA.Acquire(ctx, key, 0)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, RateLimitDimensionRequests, d.Dimension)
+	assert.Equal(t, time.Second, d.RetryAfter)
+
+	other, err := replicaA.Acquire(ctx, RateLimitKey{Tenant: "t2", Provider: "openai", Model: "gpt-4o"}, 0)
+	require.NoError(t, err)
+	assert.True(t, other.Allowed, "tenants are limited independently")
+
+	// 下一窗口过半时，上一窗口的 2 次按 50% 计入，仍有 1 次余量
+	now = now.Add(1500 * time.Millisecond)
+	d, err = replicaA.Acquire(ctx, key, 0)
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+	assert.InDelta(t, 1.0, d.Requests, 0.01)
+	d, err = replicaA.Acquire(ctx, key, 0)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+}
+
+func TestRedisRateLimiter_TokensPerMinuteAndReconcile(t *testing.T) {
+	now := time.UnixMilli(1_700_000_040_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		Quotas: map[string]RateLimitQuota{"t1/openai/*": {TokensPerMinute: 1000}},
+	}, &now)
+	key := RateLimitKey{Tenant: "t1", Provider: "openai", Model: "gpt-4o"}
+	ctx := context.Background()
+
+	d, err := limiter.Acquire(ctx, key, 800)
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	require.NoError(t, limiter.Reconcile(ctx, d, 300))
+
+	d, err = limiter.Acquire(ctx, key, 600)
+	require.NoError(t, err)
+	assert.True(t, d.Allowed, "reconciled usage frees the over-estimated reservation")
+	assert.InDelta(t, 300, d.Tokens, 0.01)
+
+	d, err = limiter.Acquire(ctx, key, 200)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, RateLimitDimensionTokens, d.Dimension)
+
+	unlimited, err := limiter.Acquire(ctx, RateLimitKey{Tenant: "t1", Provider: "anthropic"}, 1_000_000)
+	require.NoError(t, err)
+	assert.True(t, unlimited.Allowed)
+}
+
+func TestRedisRateLimiter_DryRunOnlyRecords(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	var decisions []RateLimitDecision
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+		DryRun:       true,
+		OnDecision:   func(d RateLimitDecision) { decisions = append(decisions, d) },
+	}, &now)
+	key := RateLimitKey{Tenant: "t1"}
+
+	for i := 0; i < 3; i++ {
+		d, err := limiter.Acquire(context.Background(), key, 0)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	}
+	require.Len(t, decisions, 3)
+	assert.Empty(t, decisions[0].Dimension)
+	assert.Equal(t, RateLimitDimensionRequests, decisions[2].Dimension)
+	assert.InDelta(t, 2, decisions[2].Requests, 0.01, "dry-run still records every request")
+}
+
+func TestRedisRateLimiter_QuotaLookupOrder(t *testing.T) {
+	limiter := NewRedisRateLimiter(nil, DistributedRateLimitConfig{
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+		Quotas: map[string]RateLimitQuota{
+			"t1/openai/gpt-4o": {RequestsPerSecond: 10},
+			"t1/*/*":           {RequestsPerSecond: 5},
+			"*/openai/*":       {RequestsPerSecond: 3},
+		},
+	})
+	assert.Equal(t, 10, limiter.QuotaFor(RateLimitKey{"t1", "openai", "gpt-4o"}).RequestsPerSecond)
+	assert.Equal(t, 5, limiter.QuotaFor(RateLimitKey{"t1", "openai", "gpt-4o-mini"}).RequestsPerSecond)
+	assert.Equal(t, 3, limiter.QuotaFor(RateLimitKey{"t2", "openai", "gpt-4o"}).RequestsPerSecond)
+	assert.Equal(t, 1, limiter.QuotaFor(RateLimitKey{"t2", "gemini", "pro"}).RequestsPerSecond)
+}
+
+func TestDistributedRateLimitMiddleware(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		Provider:     "openai",
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+	}, &now)
+	calls := 0
+	handler := DistributedRateLimitMiddleware(limiter)(func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		calls++
+		return &llmpkg.ChatResponse{Usage: llmpkg.ChatUsage{TotalTokens: 10}}, nil
+	})
+	ctx := types.WithTenantID(context.Background(), "t1")
+	req := &llmpkg.ChatRequest{Model: "gpt-4o", Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: "hi"}}}
+
+	_, err := handler(ctx, req)
+	require.NoError(t, err)
+	_, err = handler(ctx, req)
+	require.Error(t, err)
+	var typed *types.Error
+	require.True(t, errors.As(err, &typed))
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+	assert.Contains(t, err.Error(), "t1/openai/gpt-4o")
+
+	_, err = handler(types.WithTenantID(context.Background(), "t2"), req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRedisRateLimiter_KeySegmentsDoNotCollide(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+	}, &now)
+	ctx := context.Background()
+
+	keys := []RateLimitKey{
+		{Tenant: "a/b", Provider: "openai", Model: "gpt-4o"},
+		{Tenant: "a_b", Provider: "openai", Model: "gpt-4o"},
+		{Tenant: "a", Provider: "b/openai", Model: "gpt-4o"},
+		{Tenant: "a{b}", Provider: "openai", Model: "gpt-4o"},
+		{Tenant: "", Provider: "openai", Model: "gpt-4o"},
+		{Tenant: "_", Provider: "openai", Model: "gpt-4o"},
+	}
+	seen := make(map[string]RateLimitKey, len(keys))
+	for _, key := range keys {
+		prev, dup := seen[key.String()]
+		require.False(t, dup, "%+v and %+v encode to the same key", prev, key)
+		seen[key.String()] = key
+
+		d, err := limiter.Acquire(ctx, key, 0)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed, "%+v must not share a counter with another key", key)
+	}
+}
+
+func TestRedisRateLimiter_WildcardQuotaNotMatchedByLiteralStar(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+		Quotas: map[string]RateLimitQuota{
+			"t1/*/*": {RequestsPerSecond: 5},
+		},
+	}, &now)
+
+	assert.Equal(t, 5, limiter.QuotaFor(RateLimitKey{Tenant: "t1", Provider: "openai", Model: "gpt-4o"}).RequestsPerSecond)
+	assert.Equal(t, 1, limiter.QuotaFor(RateLimitKey{Tenant: "*", Provider: "openai", Model: "gpt-4o"}).RequestsPerSecond,
+		"a literal * tenant is not the wildcard")
+}
+
+func TestDistributedRateLimitMiddleware_RefundsOnUpstreamError(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		Provider:     "openai",
+		DefaultQuota: RateLimitQuota{TokensPerMinute: 100},
+	}, &now)
+	upstreamErr := errors.New("upstream unavailable")
+	handler := DistributedRateLimitMiddleware(limiter)(func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		return nil, upstreamErr
+	})
+	ctx := types.WithTenantID(context.Background(), "t1")
+	req := &llmpkg.ChatRequest{Model: "gpt-4o", MaxTokens: 60, Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: "hi"}}}
+
+	for i := 0; i < 3; i++ {
+		_, err := handler(ctx, req)
+		require.ErrorIs(t, err, upstreamErr, "attempt %d must reach upstream, reserved tokens are refunded", i)
+	}
+}
+
+func TestMiddlewareProvider_StreamUsesDistributedRateLimit(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		Provider:     "openai",
+		DefaultQuota: RateLimitQuota{RequestsPerSecond: 1},
+	}, &now)
+	inner := &mockProvider{name: "openai"}
+	provider := NewMiddlewareProvider(inner, NewChain(DistributedRateLimitMiddleware(limiter))).WithRateLimiter(limiter)
+	ctx := types.WithTenantID(context.Background(), "t1")
+	req := &llmpkg.ChatRequest{Model: "gpt-4o", Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: "hi"}}}
+
+	inner.streamCh = make(chan llmpkg.StreamChunk, 1)
+	inner.streamCh <- llmpkg.StreamChunk{Delta: llmpkg.Message{Content: "hello"}}
+	close(inner.streamCh)
+	stream, err := provider.Stream(ctx, req)
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	_, err = provider.Stream(ctx, req)
+	var typed *types.Error
+	require.True(t, errors.As(err, &typed))
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+
+	inner.completionResp = &llmpkg.ChatResponse{}
+	_, err = provider.Completion(ctx, req)
+	require.True(t, errors.As(err, &typed), "stream and completion share the same quota")
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+}
+
+func TestMiddlewareProvider_StreamReconcilesTokens(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	limiter, _ := newTestRedisRateLimiter(t, DistributedRateLimitConfig{
+		Provider:     "openai",
+		DefaultQuota: RateLimitQuota{TokensPerMinute: 100},
+	}, &now)
+	inner := &mockProvider{name: "openai"}
+	provider := NewMiddlewareProvider(inner, NewChain()).WithRateLimiter(limiter)
+	ctx := types.WithTenantID(context.Background(), "t1")
+	req := &llmpkg.ChatRequest{Model: "gpt-4o", MaxTokens: 60, Messages: []llmpkg.Message{{Role: llmpkg.RoleUser, Content: "hi"}}}
+	drain := func(chunks ...llmpkg.StreamChunk) {
+		t.Helper()
+		inner.streamCh = make(chan llmpkg.StreamChunk, len(chunks))
+		for _, chunk := range chunks {
+			inner.streamCh <- chunk
+		}
+		close(inner.streamCh)
+		stream, err := provider.Stream(ctx, req)
+		require.NoError(t, err)
+		for range stream {
+		}
+	}
+
+	// 出错的流退还全部预占量
+	drain(llmpkg.StreamChunk{Err: types.NewServiceUnavailableError("upstream reset")})
+	// 按实际用量结算
+	drain(llmpkg.StreamChunk{Usage: &llmpkg.ChatUsage{TotalTokens: 5}})
+
+	key := RateLimitKey{Tenant: "t1", Provider: "openai", Model: "gpt-4o"}
+	require.Eventually(t, func() bool {
+		d, err := limiter.Acquire(ctx, key, 0)
+		return err == nil && d.Tokens == 5
+	}, time.Second, 10*time.Millisecond)
+}
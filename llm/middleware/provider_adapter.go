@@ -13,10 +13,19 @@ import (
 // MiddlewareProvider 将中间件链包装为 Provider 接口。
 // Completion 请求走中间件链，其他方法直接委托给内部 Provider。
 type MiddlewareProvider struct {
-	inner   llmpkg.Provider
-	handler Handler
+	inner          llmpkg.Provider
+	handler        Handler
+	streamObserver StreamObserver
+	rateLimiter    *RedisRateLimiter
+
+	// deadlineReserve 为 Stream 之上的层预留的截止时间，见 WithDeadlineReserve
+	deadlineReserve   time.Duration
+	partialOnDeadline bool
 }
 
+// StreamObserver 在流式调用结束后接收时延画像。
+type StreamObserver func(ctx context.Context, req *llmpkg.ChatRequest, timing observability.StreamTiming)
+
 // NewMiddlewareProvider 创建一个中间件包装的 Provider。
 func NewMiddlewareProvider(inner llmpkg.Provider, chain *Chain) *MiddlewareProvider {
 	return &MiddlewareProvider{
@@ -25,12 +34,57 @@ func NewMiddlewareProvider(inner llmpkg.Provider, chain *Chain) *MiddlewareProvi
 	}
 }
 
+// WithStreamObserver 为 Stream 启用 TTFT / token 间隔观测。
+func (p *MiddlewareProvider) WithStreamObserver(observer StreamObserver) *MiddlewareProvider {
+	p.streamObserver = observer
+	return p
+}
+
+// WithRateLimiter 让 Stream 与中间件链中的 DistributedRateLimitMiddleware 共用同一分布式配额。
+// Completion 的限流由链上的中间件负责，这里只覆盖不经过中间件链的 Stream。
+func (p *MiddlewareProvider) WithRateLimiter(limiter *RedisRateLimiter) *MiddlewareProvider {
+	p.rateLimiter = limiter
+	return p
+}
+
 func (p *MiddlewareProvider) Completion(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
 	return p.handler(ctx, req)
 }
 
 func (p *MiddlewareProvider) Stream(ctx context.Context, req *llmpkg.ChatRequest) (<-chan llmpkg.StreamChunk, error) {
-	return p.inner.Stream(ctx, req)
+	if p.rateLimiter == nil || req == nil {
+		return p.observeStream(ctx, req)
+	}
+	decision, err := p.rateLimiter.acquireFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	source, err := p.observeStream(ctx, req)
+	if err != nil {
+		p.rateLimiter.settle(ctx, decision, 0)
+		return nil, err
+	}
+	return p.rateLimiter.limitStream(ctx, decision, source), nil
+}
+
+func (p *MiddlewareProvider) observeStream(ctx context.Context, req *llmpkg.ChatRequest) (<-chan llmpkg.StreamChunk, error) {
+	if p.streamObserver == nil {
+		return p.openStream(ctx, req)
+	}
+	start := time.Now()
+	source, err := p.openStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	observer := p.streamObserver
+	return observability.InstrumentStream(ctx, start, source, func(timing observability.StreamTiming) {
+		observer(ctx, req, timing)
+	}), nil
+}
+
+// ResumeStream 透传内部 provider 的服务端续流。
+func (p *MiddlewareProvider) ResumeStream(ctx context.Context, req *llmpkg.ChatRequest, token llmpkg.StreamResumeToken) (<-chan llmpkg.StreamChunk, error) {
+	return llmpkg.ResumeInnerStream(ctx, p.inner, req, token)
 }
 
 func (p *MiddlewareProvider) HealthCheck(ctx context.Context) (*llmpkg.HealthStatus, error) {
@@ -45,6 +99,10 @@ func (p *MiddlewareProvider) SupportsNativeFunctionCalling() bool {
 	return p.inner.SupportsNativeFunctionCalling()
 }
 
+func (p *MiddlewareProvider) Capabilities() llmpkg.CapabilityDescriptor {
+	return llmpkg.DescribeProvider(p.inner)
+}
+
 func (p *MiddlewareProvider) ListModels(ctx context.Context) ([]llmpkg.Model, error) {
 	return p.inner.ListModels(ctx)
 }
@@ -93,12 +151,33 @@ func (a *OtelMetricsAdapter) RecordTokens(model string, tokens int) {
 	})
 }
 
+// RecordStreamTiming 实现 StreamObserver，按 provider+model 记录流式时延直方图。
+func (a *OtelMetricsAdapter) RecordStreamTiming(ctx context.Context, req *llmpkg.ChatRequest, timing observability.StreamTiming) {
+	if req == nil {
+		return
+	}
+	model := timing.Model
+	if model == "" {
+		model = req.Model
+	}
+	a.Metrics.RecordStreamTiming(context.WithoutCancel(ctx), observability.RequestAttrs{
+		Provider: timing.Provider,
+		Model:    model,
+		TenantID: req.TenantID,
+		UserID:   req.UserID,
+		TraceID:  req.TraceID,
+	}, timing)
+}
+
 // PromptCacheAdapter 适配 cache.MultiLevelCache → middleware.Cache 接口。
 type PromptCacheAdapter struct {
 	Cache *cache.MultiLevelCache
 }
 
 func (a *PromptCacheAdapter) Key(req *llmpkg.ChatRequest) string {
+	if !a.Cache.IsCacheable(req) {
+		return ""
+	}
 	return a.Cache.GenerateKey(req)
 }
 
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/llm/observability"
+	"github.com/BaSui01/agentflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -43,3 +45,33 @@ func TestMiddlewareProvider_CountTokens(t *testing.T) {
 	assert.Equal(t, 7, resp.InputTokens)
 	assert.Equal(t, 19, resp.TotalTokens)
 }
+
+func TestMiddlewareProvider_StreamObserverReceivesTiming(t *testing.T) {
+	ch := make(chan llmpkg.StreamChunk, 3)
+	ch <- llmpkg.StreamChunk{Provider: "openai", Model: "gpt-4o", Delta: types.Message{Content: "he"}}
+	ch <- llmpkg.StreamChunk{Delta: types.Message{Content: "llo"}}
+	ch <- llmpkg.StreamChunk{FinishReason: "stop", Usage: &llmpkg.ChatUsage{CompletionTokens: 2}}
+	close(ch)
+
+	done := make(chan observability.StreamTiming, 1)
+	wrapped := NewMiddlewareProvider(&mockProvider{name: "test", streamCh: ch}, NewChain()).
+		WithStreamObserver(func(_ context.Context, req *llmpkg.ChatRequest, timing observability.StreamTiming) {
+			assert.Equal(t, "gpt-4o", req.Model)
+			done <- timing
+		})
+
+	stream, err := wrapped.Stream(context.Background(), &llmpkg.ChatRequest{Model: "gpt-4o"})
+	require.NoError(t, err)
+	count := 0
+	for range stream {
+		count++
+	}
+	assert.Equal(t, 3, count)
+
+	timing := <-done
+	assert.Equal(t, "openai", timing.Provider)
+	assert.Equal(t, 2, timing.TokenEvents)
+	assert.Equal(t, 2, timing.CompletionTokens)
+	assert.Len(t, timing.InterTokenGaps, 1)
+	assert.False(t, timing.FirstToken.IsZero())
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+)
+
+// 预算不足时对重试采取的动作，作为 RetryBudgetMetrics 的 action 标签。
+const (
+	RetryBudgetActionSkipped    = "skipped"
+	RetryBudgetActionDowngraded = "downgraded"
+)
+
+// defaultMinRemainingBudgetRatio 未配置阈值时，剩余预算低于 10% 即不再按原模型重试
+const defaultMinRemainingBudgetRatio = 0.1
+
+// RetryBudget 提供当前剩余预算比例（0-1）。
+// *policy.TokenBudgetManager 实现了该接口。
+type RetryBudget interface {
+	RemainingRatio() float64
+}
+
+// RetryBudgetMetrics 记录因预算不足被跳过或降级的重试（retry_suppressed_budget）。
+// *metrics.Collector 实现了该接口。
+type RetryBudgetMetrics interface {
+	RecordRetrySuppressedBudget(model, action string)
+}
+
+// BudgetRetryConfig 配置预算感知重试。
+type BudgetRetryConfig struct {
+	// Budget 每次重试前查询的预算来源，为 nil 时等价于 RetryMiddleware
+	Budget RetryBudget
+	// MinRemainingRatio 剩余预算比例低于该值时抑制重试，<=0 时使用默认值 0.1
+	MinRemainingRatio float64
+	// FallbackModel 预算不足时降级使用的廉价模型；为空则直接跳过重试
+	FallbackModel string
+	// Metrics 可选的指标记录器
+	Metrics RetryBudgetMetrics
+}
+
+// BudgetAwareRetryMiddleware 在每次重试前查询预算：剩余比例低于阈值时，
+// 配置了 FallbackModel 则改用该模型重试，否则放弃重试并返回上一次的错误。
+// 事故期间上游大面积失败时，可避免盲目重试耗尽窗口预算。
+func BudgetAwareRetryMiddleware(maxRetries int, backoff time.Duration, cfg BudgetRetryConfig) Middleware {
+	if cfg.Budget == nil {
+		return RetryMiddleware(maxRetries, backoff)
+	}
+	if cfg.MinRemainingRatio <= 0 {
+		cfg.MinRemainingRatio = defaultMinRemainingBudgetRatio
+	}
+	return retryMiddleware(maxRetries, backoff, &cfg)
+}
+
+// admitRetry 决定是否允许重试，返回实际用于重试的请求。
+// 已降级到 FallbackModel 后预算仍不足则不再重试。
+func (c *BudgetRetryConfig) admitRetry(req *llmpkg.ChatRequest) (*llmpkg.ChatRequest, bool) {
+	if c.Budget.RemainingRatio() >= c.MinRemainingRatio {
+		return req, true
+	}
+	model := ""
+	if req != nil {
+		model = req.Model
+	}
+	if c.FallbackModel != "" && req != nil && model != c.FallbackModel {
+		c.record(model, RetryBudgetActionDowngraded)
+		downgraded := *req
+		downgraded.Model = c.FallbackModel
+		return &downgraded, true
+	}
+	c.record(model, RetryBudgetActionSkipped)
+	return req, false
+}
+
+func (c *BudgetRetryConfig) record(model, action string) {
+	if c.Metrics != nil {
+		c.Metrics.RecordRetrySuppressedBudget(model, action)
+	}
+}
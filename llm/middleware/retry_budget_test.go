@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedBudget float64
+
+func (b fixedBudget) RemainingRatio() float64 { return float64(b) }
+
+type recordedSuppression struct{ model, action string }
+
+type testRetryBudgetMetrics struct{ events []recordedSuppression }
+
+func (m *testRetryBudgetMetrics) RecordRetrySuppressedBudget(model, action string) {
+	m.events = append(m.events, recordedSuppression{model, action})
+}
+
+func failingThenOK(models *[]string, failures int) Handler {
+	return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		*models = append(*models, req.Model)
+		if len(*models) <= failures {
+			return nil, &types.Error{Code: "TRANSIENT", Retryable: true}
+		}
+		return &llmpkg.ChatResponse{Model: req.Model}, nil
+	}
+}
+
+func TestBudgetAwareRetryMiddleware(t *testing.T) {
+	t.Run("retries normally with enough budget", func(t *testing.T) {
+		var models []string
+		metrics := &testRetryBudgetMetrics{}
+		mw := BudgetAwareRetryMiddleware(3, time.Millisecond, BudgetRetryConfig{Budget: fixedBudget(0.5), Metrics: metrics})
+		resp, err := NewChain(mw).Then(failingThenOK(&models, 2))(context.Background(), simpleReq())
+		require.NoError(t, err)
+		assert.Equal(t, "test-model", resp.Model)
+		assert.Len(t, models, 3)
+		assert.Empty(t, metrics.events)
+	})
+
+	t.Run("skips retry when budget is low", func(t *testing.T) {
+		var models []string
+		metrics := &testRetryBudgetMetrics{}
+		mw := BudgetAwareRetryMiddleware(3, time.Millisecond, BudgetRetryConfig{Budget: fixedBudget(0.05), Metrics: metrics})
+		_, err := NewChain(mw).Then(failingThenOK(&models, 2))(context.Background(), simpleReq())
+		require.Error(t, err)
+		assert.True(t, types.IsRetryable(err))
+		assert.Len(t, models, 1)
+		assert.Equal(t, []recordedSuppression{{"test-model", RetryBudgetActionSkipped}}, metrics.events)
+	})
+
+	t.Run("downgrades to fallback model", func(t *testing.T) {
+		var models []string
+		metrics := &testRetryBudgetMetrics{}
+		req := simpleReq()
+		mw := BudgetAwareRetryMiddleware(3, time.Millisecond, BudgetRetryConfig{
+			Budget:            fixedBudget(0.2),
+			MinRemainingRatio: 0.3,
+			FallbackModel:     "cheap-model",
+			Metrics:           metrics,
+		})
+		resp, err := NewChain(mw).Then(failingThenOK(&models, 1))(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "cheap-model", resp.Model)
+		assert.Equal(t, []string{"test-model", "cheap-model"}, models)
+		assert.Equal(t, "test-model", req.Model, "caller request must not be mutated")
+		assert.Equal(t, []recordedSuppression{{"test-model", RetryBudgetActionDowngraded}}, metrics.events)
+	})
+
+	t.Run("stops after fallback model also fails", func(t *testing.T) {
+		var models []string
+		metrics := &testRetryBudgetMetrics{}
+		mw := BudgetAwareRetryMiddleware(3, time.Millisecond, BudgetRetryConfig{
+			Budget:        fixedBudget(0),
+			FallbackModel: "cheap-model",
+			Metrics:       metrics,
+		})
+		_, err := NewChain(mw).Then(failingThenOK(&models, 10))(context.Background(), simpleReq())
+		require.Error(t, err)
+		assert.Equal(t, []string{"test-model", "cheap-model"}, models)
+		assert.Equal(t, []recordedSuppression{
+			{"test-model", RetryBudgetActionDowngraded},
+			{"cheap-model", RetryBudgetActionSkipped},
+		}, metrics.events)
+	})
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// defaultTrimmingReserveForOutput 请求未设置 MaxTokens 时为输出预留的 token 数
+const defaultTrimmingReserveForOutput = 4096
+
+// MessageTrimmer 将消息压缩/裁剪到上下文窗口内.
+// agent/execution/context.AgentContextManager 实现了该接口。
+type MessageTrimmer interface {
+	PrepareMessages(ctx context.Context, messages []types.Message, currentQuery string) ([]types.Message, error)
+}
+
+// TrimmerFactory 按模型及其上下文窗口创建裁剪器，例如：
+//
+//	func(model string, window int) MessageTrimmer {
+//		cfg := agentcontext.DefaultAgentContextConfig(model)
+//		cfg.MaxContextTokens = window
+//		return agentcontext.NewAgentContextManager(cfg, logger)
+//	}
+type TrimmerFactory func(model string, contextWindow int) MessageTrimmer
+
+// TrimmingConfig 配置上下文裁剪中间件.
+type TrimmingConfig struct {
+	// ContextWindows 按模型名（不区分大小写）配置上下文窗口 token 数
+	ContextWindows map[string]int
+	// DefaultContextWindow 未在 ContextWindows 中配置的模型使用的窗口，<=0 表示不裁剪
+	DefaultContextWindow int
+	// ReserveForOutput 请求未设置 MaxTokens 时为输出预留的 token，默认 4096
+	ReserveForOutput int
+	// NewTrimmer 创建压缩策略；为 nil 或策略结果仍超窗口时，退化为保留 system 消息并丢弃最早的对话
+	NewTrimmer TrimmerFactory
+	// Tokenizer 估算 token，默认 types.NewEstimateTokenizer()
+	Tokenizer types.Tokenizer
+}
+
+// TrimmingMiddleware 在发送前按模型上下文窗口裁剪请求消息，
+// 让经过中间件链的调用自动适配上下文，而不是收到 ErrContextTooLong.
+// 调用方传入的请求不会被修改。
+func TrimmingMiddleware(cfg TrimmingConfig) Middleware {
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = types.NewEstimateTokenizer()
+	}
+	if cfg.ReserveForOutput <= 0 {
+		cfg.ReserveForOutput = defaultTrimmingReserveForOutput
+	}
+	windows := make(map[string]int, len(cfg.ContextWindows))
+	for model, window := range cfg.ContextWindows {
+		windows[strings.ToLower(model)] = window
+	}
+	t := &contextTrimmer{cfg: cfg, windows: windows}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+			trimmed, err := t.trim(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, trimmed)
+		}
+	}
+}
+
+type contextTrimmer struct {
+	cfg      TrimmingConfig
+	windows  map[string]int
+	trimmers sync.Map // model -> MessageTrimmer
+}
+
+func (t *contextTrimmer) trim(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatRequest, error) {
+	if req == nil || len(req.Messages) == 0 {
+		return req, nil
+	}
+	window := t.contextWindow(req.Model)
+	if window <= 0 {
+		return req, nil
+	}
+	reserve := req.MaxTokens
+	if reserve <= 0 {
+		reserve = t.cfg.ReserveForOutput
+	}
+	budget := window - reserve - t.cfg.Tokenizer.EstimateToolTokens(req.Tools)
+	if budget <= 0 || t.cfg.Tokenizer.CountMessagesTokens(req.Messages) <= budget {
+		return req, nil
+	}
+
+	messages := req.Messages
+	if trimmer := t.trimmerFor(req.Model, window); trimmer != nil {
+		prepared, err := trimmer.PrepareMessages(ctx, cloneMessages(messages), lastUserQuery(messages))
+		if err != nil {
+			return nil, err
+		}
+		if len(prepared) > 0 {
+			messages = prepared
+		}
+	}
+	if t.cfg.Tokenizer.CountMessagesTokens(messages) > budget {
+		messages = dropOldestMessages(messages, budget, t.cfg.Tokenizer)
+	}
+
+	out := *req
+	out.Messages = messages
+	return &out, nil
+}
+
+func (t *contextTrimmer) contextWindow(model string) int {
+	if window, ok := t.windows[strings.ToLower(model)]; ok {
+		return window
+	}
+	return t.cfg.DefaultContextWindow
+}
+
+func (t *contextTrimmer) trimmerFor(model string, window int) MessageTrimmer {
+	if t.cfg.NewTrimmer == nil {
+		return nil
+	}
+	if cached, ok := t.trimmers.Load(model); ok {
+		return cached.(MessageTrimmer)
+	}
+	trimmer := t.cfg.NewTrimmer(model, window)
+	if trimmer == nil {
+		return nil
+	}
+	actual, _ := t.trimmers.LoadOrStore(model, trimmer)
+	return actual.(MessageTrimmer)
+}
+
+// dropOldestMessages 保留全部 system 消息与最新的对话，从最早的非 system 消息开始丢弃，
+// 且不让保留部分以孤立的 tool 结果开头。最后一条消息总是保留。
+func dropOldestMessages(messages []types.Message, budget int, tokenizer types.Tokenizer) []types.Message {
+	used := 0
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			used += tokenizer.CountMessageTokens(msg)
+		}
+	}
+	keepFrom := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == types.RoleSystem {
+			continue
+		}
+		cost := tokenizer.CountMessageTokens(messages[i])
+		if used+cost > budget && keepFrom < len(messages) {
+			break
+		}
+		used += cost
+		keepFrom = i
+	}
+	for keepFrom < len(messages)-1 && messages[keepFrom].Role == types.RoleTool {
+		keepFrom++
+	}
+
+	out := make([]types.Message, 0, len(messages))
+	for i, msg := range messages {
+		if msg.Role == types.RoleSystem || i >= keepFrom {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func lastUserQuery(messages []types.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == types.RoleUser {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+func cloneMessages(messages []types.Message) []types.Message {
+	return append([]types.Message(nil), messages...)
+}
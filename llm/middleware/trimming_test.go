@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keepLastTrimmer struct {
+	calls int
+	query string
+}
+
+func (t *keepLastTrimmer) PrepareMessages(_ context.Context, messages []types.Message, query string) ([]types.Message, error) {
+	t.calls++
+	t.query = query
+	return []types.Message{messages[0], messages[len(messages)-1]}, nil
+}
+
+func longConversation() []types.Message {
+	filler := strings.Repeat("x", 400) // ~100 tokens
+	msgs := []types.Message{{Role: types.RoleSystem, Content: "be helpful"}}
+	for i := 0; i < 10; i++ {
+		msgs = append(msgs,
+			types.Message{Role: types.RoleUser, Content: filler},
+			types.Message{Role: types.RoleAssistant, Content: filler},
+		)
+	}
+	return append(msgs, types.Message{Role: types.RoleUser, Content: "final question"})
+}
+
+func captureHandler(seen **llmpkg.ChatRequest) Handler {
+	return func(ctx context.Context, req *llmpkg.ChatRequest) (*llmpkg.ChatResponse, error) {
+		*seen = req
+		return &llmpkg.ChatResponse{}, nil
+	}
+}
+
+func TestTrimmingMiddleware_PassesThroughWithinWindow(t *testing.T) {
+	var seen *llmpkg.ChatRequest
+	req := &llmpkg.ChatRequest{Model: "small", Messages: longConversation()}
+	h := NewChain(TrimmingMiddleware(TrimmingConfig{DefaultContextWindow: 100000})).Then(captureHandler(&seen))
+	_, err := h(context.Background(), req)
+	require.NoError(t, err)
+	assert.Same(t, req, seen)
+}
+
+func TestTrimmingMiddleware_UsesPerModelTrimmer(t *testing.T) {
+	var seen *llmpkg.ChatRequest
+	trimmer := &keepLastTrimmer{}
+	var gotWindow int
+	mw := TrimmingMiddleware(TrimmingConfig{
+		ContextWindows: map[string]int{"Small-Model": 1500},
+		NewTrimmer: func(model string, window int) MessageTrimmer {
+			gotWindow = window
+			return trimmer
+		},
+	})
+	req := &llmpkg.ChatRequest{Model: "small-model", MaxTokens: 500, Messages: longConversation()}
+	original := len(req.Messages)
+	h := NewChain(mw).Then(captureHandler(&seen))
+
+	for i := 0; i < 2; i++ {
+		_, err := h(context.Background(), req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1500, gotWindow)
+	assert.Equal(t, 2, trimmer.calls)
+	assert.Equal(t, "final question", trimmer.query)
+	require.Len(t, seen.Messages, 2)
+	assert.Equal(t, types.RoleSystem, seen.Messages[0].Role)
+	assert.Len(t, req.Messages, original, "caller request must not be mutated")
+
+	// 未配置窗口的模型不裁剪
+	other := &llmpkg.ChatRequest{Model: "other", Messages: longConversation()}
+	_, err := h(context.Background(), other)
+	require.NoError(t, err)
+	assert.Same(t, other, seen)
+}
+
+func TestTrimmingMiddleware_FallbackDropsOldest(t *testing.T) {
+	var seen *llmpkg.ChatRequest
+	tokenizer := types.NewEstimateTokenizer()
+	mw := TrimmingMiddleware(TrimmingConfig{DefaultContextWindow: 1000, ReserveForOutput: 500, Tokenizer: tokenizer})
+	_, err := NewChain(mw).Then(captureHandler(&seen))(context.Background(),
+		&llmpkg.ChatRequest{Model: "m", Messages: longConversation()})
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, tokenizer.CountMessagesTokens(seen.Messages), 500)
+	assert.Equal(t, types.RoleSystem, seen.Messages[0].Role)
+	assert.Equal(t, "final question", seen.Messages[len(seen.Messages)-1].Content)
+	assert.Less(t, len(seen.Messages), len(longConversation()))
+}
+
+func TestDropOldestMessages_SkipsOrphanToolResults(t *testing.T) {
+	tokenizer := types.NewEstimateTokenizer()
+	filler := strings.Repeat("y", 400)
+	msgs := []types.Message{
+		{Role: types.RoleSystem, Content: "sys"},
+		{Role: types.RoleUser, Content: filler},
+		{Role: types.RoleAssistant, ToolCalls: []types.ToolCall{{ID: "c1", Name: "lookup"}}},
+		{Role: types.RoleTool, ToolCallID: "c1", Content: filler},
+		{Role: types.RoleUser, Content: "next"},
+	}
+	budget := tokenizer.CountMessageTokens(msgs[0]) + tokenizer.CountMessageTokens(msgs[3]) + tokenizer.CountMessageTokens(msgs[4])
+	out := dropOldestMessages(msgs, budget, tokenizer)
+	require.Len(t, out, 2)
+	assert.Equal(t, types.RoleSystem, out[0].Role)
+	assert.Equal(t, "next", out[1].Content)
+}
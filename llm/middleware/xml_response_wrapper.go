@@ -183,6 +183,10 @@ func (p *XMLToolCallProvider) SupportsNativeFunctionCalling() bool {
 	return p.inner.SupportsNativeFunctionCalling()
 }
 
+func (p *XMLToolCallProvider) Capabilities() llmpkg.CapabilityDescriptor {
+	return llmpkg.DescribeProvider(p.inner)
+}
+
 func (p *XMLToolCallProvider) ListModels(ctx context.Context) ([]llmpkg.Model, error) {
 	return p.inner.ListModels(ctx)
 }
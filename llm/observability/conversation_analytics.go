@@ -0,0 +1,365 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// ConversationQualityJudge 对整段对话打质量分（0-1），通常由 LLM 评审实现.
+type ConversationQualityJudge interface {
+	JudgeConversation(ctx context.Context, conv *ConversationTrace) (float64, error)
+}
+
+// ConversationQualityJudgeFunc 函数式评审适配器.
+type ConversationQualityJudgeFunc func(ctx context.Context, conv *ConversationTrace) (float64, error)
+
+// JudgeConversation 实现 ConversationQualityJudge.
+func (f ConversationQualityJudgeFunc) JudgeConversation(ctx context.Context, conv *ConversationTrace) (float64, error) {
+	return f(ctx, conv)
+}
+
+// ConversationRollup 对话结束时的汇总指标.
+type ConversationRollup struct {
+	Turns        int             `json:"turns"`
+	Tokens       TokenUsage      `json:"tokens"`
+	Cost         float64         `json:"cost"`
+	ToolCalls    int             `json:"tool_calls"`
+	ToolErrors   int             `json:"tool_errors"`
+	Latency      time.Duration   `json:"latency"`
+	Duration     time.Duration   `json:"duration"`
+	QualityScore *float64        `json:"quality_score,omitempty"`
+	Feedback     FeedbackSummary `json:"feedback"`
+}
+
+// FeedbackThumbs 点赞/点踩.
+type FeedbackThumbs string
+
+const (
+	FeedbackThumbsUp   FeedbackThumbs = "up"
+	FeedbackThumbsDown FeedbackThumbs = "down"
+)
+
+// ConversationFeedback 用户对对话的反馈，可关联到对话、链路或回合.
+type ConversationFeedback struct {
+	ID             string         `json:"id"`
+	ConversationID string         `json:"conversation_id"`
+	TraceID        string         `json:"trace_id,omitempty"`
+	TurnID         string         `json:"turn_id,omitempty"`
+	TenantID       string         `json:"tenant_id,omitempty"`
+	UserID         string         `json:"user_id,omitempty"`
+	Thumbs         FeedbackThumbs `json:"thumbs,omitempty"`
+	Rating         int            `json:"rating,omitempty"` // 1-5，0 表示未评分
+	Comment        string         `json:"comment,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// FeedbackSummary 反馈汇总.
+type FeedbackSummary struct {
+	Count         int     `json:"count"`
+	ThumbsUp      int     `json:"thumbs_up"`
+	ThumbsDown    int     `json:"thumbs_down"`
+	Ratings       int     `json:"ratings"`
+	AverageRating float64 `json:"average_rating,omitempty"`
+	Comments      int     `json:"comments"`
+}
+
+// ConversationFilter 对话查询条件.
+type ConversationFilter struct {
+	TenantID string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// FeedbackFilter 反馈查询条件.
+type FeedbackFilter struct {
+	TenantID       string
+	ConversationID string
+	TraceID        string
+	Limit          int
+}
+
+// ConversationAnalytics 租户维度的对话汇总.
+type ConversationAnalytics struct {
+	TenantID       string          `json:"tenant_id,omitempty"`
+	Conversations  int             `json:"conversations"`
+	Turns          int             `json:"turns"`
+	Tokens         TokenUsage      `json:"tokens"`
+	Cost           float64         `json:"cost"`
+	ToolCalls      int             `json:"tool_calls"`
+	ToolErrors     int             `json:"tool_errors"`
+	Judged         int             `json:"judged"`
+	AverageQuality float64         `json:"average_quality,omitempty"`
+	Feedback       FeedbackSummary `json:"feedback"`
+}
+
+// SubmitFeedback 记录用户反馈.
+// 通过 ConversationID 或 TraceID 定位对话，TraceID 可以是对话的链路 ID、运行 ID 或回合 ID.
+// 反馈与对话的租户不一致时拒绝写入.
+func (c *ConversationTracer) SubmitFeedback(ctx context.Context, feedback ConversationFeedback) (*ConversationFeedback, error) {
+	if feedback.Thumbs == "" && feedback.Rating == 0 && strings.TrimSpace(feedback.Comment) == "" {
+		return nil, fmt.Errorf("feedback must include thumbs, rating or comment")
+	}
+	if feedback.Thumbs != "" && feedback.Thumbs != FeedbackThumbsUp && feedback.Thumbs != FeedbackThumbsDown {
+		return nil, fmt.Errorf("invalid thumbs value: %s", feedback.Thumbs)
+	}
+	if feedback.Rating < 0 || feedback.Rating > 5 {
+		return nil, fmt.Errorf("rating must be between 1 and 5")
+	}
+	if feedback.TenantID == "" {
+		if tenantID, ok := types.TenantID(ctx); ok {
+			feedback.TenantID = tenantID
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conv, turnID := c.resolveConversationLocked(feedback.ConversationID, feedback.TraceID)
+	if conv == nil {
+		return nil, fmt.Errorf("conversation not found for feedback: %s", firstNonEmpty(feedback.ConversationID, feedback.TraceID))
+	}
+	if feedback.TenantID != "" && conv.TenantID != "" && feedback.TenantID != conv.TenantID {
+		return nil, fmt.Errorf("conversation %s does not belong to tenant %s", conv.ID, feedback.TenantID)
+	}
+
+	feedback.ConversationID = conv.ID
+	if feedback.TraceID == "" {
+		feedback.TraceID = conv.TraceID
+	}
+	if feedback.TurnID == "" {
+		feedback.TurnID = turnID
+	}
+	if feedback.TenantID == "" {
+		feedback.TenantID = conv.TenantID
+	}
+	if feedback.CreatedAt.IsZero() {
+		feedback.CreatedAt = time.Now()
+	}
+	feedback.ID = fmt.Sprintf("fb_%d", feedback.CreatedAt.UnixNano())
+	conv.Feedback = append(conv.Feedback, feedback)
+	if conv.Rollup != nil {
+		conv.Rollup.Feedback = summarizeFeedback(conv.Feedback)
+	}
+	return &feedback, nil
+}
+
+// resolveConversationLocked 按对话 ID 或链路 ID 查找对话，命中回合时返回回合 ID.
+func (c *ConversationTracer) resolveConversationLocked(convID, traceID string) (*ConversationTrace, string) {
+	if convID != "" {
+		return c.conversations[convID], ""
+	}
+	if traceID == "" {
+		return nil, ""
+	}
+	for _, conv := range c.conversations {
+		if conv.ID == traceID || conv.TraceID == traceID || conv.RunID == traceID {
+			return conv, ""
+		}
+		for _, turn := range conv.Turns {
+			if turn.ID == traceID {
+				return conv, turn.ID
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Rollup 返回对话当前的汇总；对话结束后包含评审质量分.
+func (c *ConversationTracer) Rollup(convID string) (*ConversationRollup, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	conv, ok := c.conversations[convID]
+	if !ok {
+		return nil, fmt.Errorf("conversation not found: %s", convID)
+	}
+	if conv.Rollup != nil {
+		rollup := *conv.Rollup
+		return &rollup, nil
+	}
+	return buildConversationRollup(conv, nil), nil
+}
+
+// ListConversations 按开始时间升序返回匹配的对话.
+func (c *ConversationTracer) ListConversations(filter ConversationFilter) []*ConversationTrace {
+	c.mu.RLock()
+	out := make([]*ConversationTrace, 0, len(c.conversations))
+	for _, conv := range c.conversations {
+		if filter.matches(conv) {
+			out = append(out, conv)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].StartTime.Before(out[j].StartTime) })
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[len(out)-filter.Limit:]
+	}
+	return out
+}
+
+// ListFeedback 按提交时间升序返回匹配的反馈.
+func (c *ConversationTracer) ListFeedback(filter FeedbackFilter) []ConversationFeedback {
+	c.mu.RLock()
+	var out []ConversationFeedback
+	for _, conv := range c.conversations {
+		if filter.ConversationID != "" && conv.ID != filter.ConversationID {
+			continue
+		}
+		for _, fb := range conv.Feedback {
+			if filter.TenantID != "" && fb.TenantID != filter.TenantID {
+				continue
+			}
+			if filter.TraceID != "" && fb.TraceID != filter.TraceID && fb.TurnID != filter.TraceID {
+				continue
+			}
+			out = append(out, fb)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[len(out)-filter.Limit:]
+	}
+	return out
+}
+
+// TenantAnalytics 汇总租户在时间窗口内的对话指标；TenantID 为空时汇总全部租户.
+func (c *ConversationTracer) TenantAnalytics(filter ConversationFilter) ConversationAnalytics {
+	filter.Limit = 0
+	convs := c.ListConversations(filter)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	analytics := ConversationAnalytics{TenantID: filter.TenantID, Conversations: len(convs)}
+	var qualitySum float64
+	var feedback []ConversationFeedback
+	for _, conv := range convs {
+		rollup := conv.Rollup
+		if rollup == nil {
+			rollup = buildConversationRollup(conv, nil)
+		}
+		analytics.Turns += rollup.Turns
+		analytics.Tokens.Prompt += rollup.Tokens.Prompt
+		analytics.Tokens.Completion += rollup.Tokens.Completion
+		analytics.Tokens.Total += rollup.Tokens.Total
+		analytics.Cost += rollup.Cost
+		analytics.ToolCalls += rollup.ToolCalls
+		analytics.ToolErrors += rollup.ToolErrors
+		if rollup.QualityScore != nil {
+			analytics.Judged++
+			qualitySum += *rollup.QualityScore
+		}
+		feedback = append(feedback, conv.Feedback...)
+	}
+	if analytics.Judged > 0 {
+		analytics.AverageQuality = qualitySum / float64(analytics.Judged)
+	}
+	analytics.Feedback = summarizeFeedback(feedback)
+	return analytics
+}
+
+func (f ConversationFilter) matches(conv *ConversationTrace) bool {
+	if f.TenantID != "" && conv.TenantID != f.TenantID {
+		return false
+	}
+	if !f.Since.IsZero() && conv.StartTime.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !conv.StartTime.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// buildConversationRollup 汇总回合指标；未提供对话级评审分时取回合评审分的平均值.
+func buildConversationRollup(conv *ConversationTrace, judged *float64) *ConversationRollup {
+	rollup := &ConversationRollup{Turns: len(conv.Turns)}
+	var turnScores float64
+	var scored int
+	for _, turn := range conv.Turns {
+		rollup.Tokens.Prompt += turn.Tokens.Prompt
+		rollup.Tokens.Completion += turn.Tokens.Completion
+		total := turn.Tokens.Total
+		if total == 0 {
+			total = turn.Tokens.Prompt + turn.Tokens.Completion
+		}
+		rollup.Tokens.Total += total
+		rollup.Cost += turn.Cost
+		rollup.Latency += turn.Latency
+		rollup.ToolCalls += len(turn.ToolCalls)
+		for _, call := range turn.ToolCalls {
+			if call.Error != "" {
+				rollup.ToolErrors++
+			}
+		}
+		if turn.QualityScore != nil {
+			turnScores += *turn.QualityScore
+			scored++
+		}
+	}
+	if !conv.EndTime.IsZero() {
+		rollup.Duration = conv.EndTime.Sub(conv.StartTime)
+	}
+	switch {
+	case judged != nil:
+		score := *judged
+		rollup.QualityScore = &score
+	case scored > 0:
+		score := turnScores / float64(scored)
+		rollup.QualityScore = &score
+	}
+	rollup.Feedback = summarizeFeedback(conv.Feedback)
+	return rollup
+}
+
+func summarizeFeedback(feedback []ConversationFeedback) FeedbackSummary {
+	summary := FeedbackSummary{Count: len(feedback)}
+	var ratingSum int
+	for _, fb := range feedback {
+		switch fb.Thumbs {
+		case FeedbackThumbsUp:
+			summary.ThumbsUp++
+		case FeedbackThumbsDown:
+			summary.ThumbsDown++
+		}
+		if fb.Rating > 0 {
+			summary.Ratings++
+			ratingSum += fb.Rating
+		}
+		if strings.TrimSpace(fb.Comment) != "" {
+			summary.Comments++
+		}
+	}
+	if summary.Ratings > 0 {
+		summary.AverageRating = float64(ratingSum) / float64(summary.Ratings)
+	}
+	return summary
+}
+
+// conversationTraceID 优先取上下文中的链路 ID，其次取当前 OTel span 的 TraceID.
+func conversationTraceID(ctx context.Context) string {
+	if traceID, ok := types.TraceID(ctx); ok && traceID != "" {
+		return traceID
+	}
+	if sc := oteltrace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestConversationTracer_EndConversationRollup(t *testing.T) {
+	calc := NewCostCalculator()
+	calc.SetPrice("openai", "test-model", 0.001, 0.002)
+	judge := ConversationQualityJudgeFunc(func(_ context.Context, conv *ConversationTrace) (float64, error) {
+		return 0.9, nil
+	})
+	ct := NewConversationTracer(NewTracer(TracerConfig{}, nil, nil),
+		WithConversationCostCalculator(calc),
+		WithConversationQualityJudge(judge),
+	)
+
+	ctx := types.WithTraceID(types.WithTenantID(context.Background(), "tenant-a"), "trace-1")
+	ctx, conv := ct.StartConversation(ctx, "support")
+	assert.Equal(t, "tenant-a", conv.TenantID)
+	assert.Equal(t, "trace-1", conv.TraceID)
+
+	_, err := ct.TraceTurnResult(ctx, "where is my order", func() (TurnResult, error) {
+		return TurnResult{
+			Response: "checking",
+			Provider: "openai",
+			Model:    "test-model",
+			Tokens:   TokenUsage{Prompt: 1000, Completion: 500},
+			ToolCalls: []TurnToolCall{
+				{Name: "order_lookup"},
+				{Name: "shipping_status", Error: "timeout"},
+			},
+		}, nil
+	})
+	require.NoError(t, err)
+	_, err = ct.TraceTurnResult(ctx, "thanks", func() (TurnResult, error) {
+		return TurnResult{Response: "you're welcome", Cost: 0.01, Tokens: TokenUsage{Prompt: 10, Completion: 5, Total: 15}}, nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ct.EndConversation(ctx, conv.ID))
+	rollup, err := ct.Rollup(conv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rollup.Turns)
+	assert.Equal(t, TokenUsage{Prompt: 1010, Completion: 505, Total: 1515}, rollup.Tokens)
+	assert.InDelta(t, 0.001+0.001+0.01, rollup.Cost, 1e-9)
+	assert.Equal(t, 2, rollup.ToolCalls)
+	assert.Equal(t, 1, rollup.ToolErrors)
+	require.NotNil(t, rollup.QualityScore)
+	assert.InDelta(t, 0.9, *rollup.QualityScore, 1e-9)
+}
+
+func TestConversationTracer_RollupFallsBackToTurnScores(t *testing.T) {
+	failing := ConversationQualityJudgeFunc(func(context.Context, *ConversationTrace) (float64, error) {
+		return 0, errors.New("judge unavailable")
+	})
+	ct := NewConversationTracer(NewTracer(TracerConfig{}, nil, nil), WithConversationQualityJudge(failing))
+	ctx, conv := ct.StartConversation(context.Background(), "c")
+	for _, score := range []float64{0.4, 0.8} {
+		_, err := ct.TraceTurnResult(ctx, "q", func() (TurnResult, error) {
+			return TurnResult{Response: "a", QualityScore: floatPtr(score)}, nil
+		})
+		require.NoError(t, err)
+	}
+
+	live, err := ct.Rollup(conv.ID)
+	require.NoError(t, err)
+	require.NotNil(t, live.QualityScore)
+	assert.InDelta(t, 0.6, *live.QualityScore, 1e-9)
+
+	require.NoError(t, ct.EndConversation(ctx, conv.ID), "judge errors must not fail the conversation")
+	final, err := ct.Rollup(conv.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.6, *final.QualityScore, 1e-9)
+}
+
+func TestConversationTracer_SubmitFeedback(t *testing.T) {
+	ct := NewConversationTracer(NewTracer(TracerConfig{}, nil, nil))
+	ctx := types.WithTraceID(types.WithTenantID(context.Background(), "tenant-a"), "trace-1")
+	ctx, conv := ct.StartConversation(ctx, "c")
+	turn, err := ct.TraceTurn(ctx, "q", func() (string, TokenUsage, error) { return "a", TokenUsage{}, nil })
+	require.NoError(t, err)
+	require.NoError(t, ct.EndConversation(ctx, conv.ID))
+
+	fb, err := ct.SubmitFeedback(context.Background(), ConversationFeedback{TraceID: "trace-1", Thumbs: FeedbackThumbsUp, UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, conv.ID, fb.ConversationID)
+	assert.Equal(t, "tenant-a", fb.TenantID)
+	assert.NotEmpty(t, fb.ID)
+
+	fb, err = ct.SubmitFeedback(context.Background(), ConversationFeedback{TraceID: turn.ID, Rating: 4, Comment: "helpful"})
+	require.NoError(t, err)
+	assert.Equal(t, turn.ID, fb.TurnID)
+
+	_, err = ct.SubmitFeedback(context.Background(), ConversationFeedback{ConversationID: conv.ID, Rating: 2, Thumbs: FeedbackThumbsDown})
+	require.NoError(t, err)
+
+	rollup, err := ct.Rollup(conv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, FeedbackSummary{Count: 3, ThumbsUp: 1, ThumbsDown: 1, Ratings: 2, AverageRating: 3, Comments: 1}, rollup.Feedback)
+
+	rows := ct.ListFeedback(FeedbackFilter{TenantID: "tenant-a", TraceID: turn.ID})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "helpful", rows[0].Comment)
+}
+
+func TestConversationTracer_SubmitFeedbackValidation(t *testing.T) {
+	ct := NewConversationTracer(NewTracer(TracerConfig{}, nil, nil))
+	_, conv := ct.StartConversation(types.WithTenantID(context.Background(), "tenant-a"), "c")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		feedback ConversationFeedback
+	}{
+		{"empty", context.Background(), ConversationFeedback{ConversationID: conv.ID}},
+		{"bad rating", context.Background(), ConversationFeedback{ConversationID: conv.ID, Rating: 6}},
+		{"bad thumbs", context.Background(), ConversationFeedback{ConversationID: conv.ID, Thumbs: "sideways"}},
+		{"unknown trace", context.Background(), ConversationFeedback{TraceID: "missing", Rating: 3}},
+		{"other tenant", types.WithTenantID(context.Background(), "tenant-b"), ConversationFeedback{ConversationID: conv.ID, Rating: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ct.SubmitFeedback(tt.ctx, tt.feedback)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConversationTracer_TenantAnalytics(t *testing.T) {
+	ct := NewConversationTracer(NewTracer(TracerConfig{}, nil, nil))
+	run := func(tenant string, cost float64, score float64) string {
+		ctx, conv := ct.StartConversation(types.WithTenantID(context.Background(), tenant), "c")
+		_, err := ct.TraceTurnResult(ctx, "q", func() (TurnResult, error) {
+			return TurnResult{Response: "a", Cost: cost, Tokens: TokenUsage{Total: 10}, QualityScore: floatPtr(score)}, nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, ct.EndConversation(ctx, conv.ID))
+		return conv.ID
+	}
+	first := run("tenant-a", 0.5, 1.0)
+	run("tenant-a", 0.25, 0.5)
+	run("tenant-b", 9, 0)
+
+	_, err := ct.SubmitFeedback(context.Background(), ConversationFeedback{ConversationID: first, Thumbs: FeedbackThumbsDown})
+	require.NoError(t, err)
+
+	analytics := ct.TenantAnalytics(ConversationFilter{TenantID: "tenant-a"})
+	assert.Equal(t, 2, analytics.Conversations)
+	assert.Equal(t, 2, analytics.Turns)
+	assert.Equal(t, 20, analytics.Tokens.Total)
+	assert.InDelta(t, 0.75, analytics.Cost, 1e-9)
+	assert.InDelta(t, 0.75, analytics.AverageQuality, 1e-9)
+	assert.Equal(t, 1, analytics.Feedback.ThumbsDown)
+
+	assert.Len(t, ct.ListConversations(ConversationFilter{TenantID: "tenant-b"}), 1)
+	assert.Len(t, ct.ListConversations(ConversationFilter{Limit: 2}), 2)
+}
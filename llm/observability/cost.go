@@ -12,10 +12,38 @@ type CostCalculator struct {
 
 // ModelPrice 模型价格
 type ModelPrice struct {
-	Provider    string
-	Model       string
-	PriceInput  float64 // USD per 1K tokens
-	PriceOutput float64 // USD per 1K tokens
+	Provider         string
+	Model            string
+	PriceInput       float64 // USD per 1K tokens
+	PriceOutput      float64 // USD per 1K tokens
+	PriceCachedInput float64 // USD per 1K cached tokens，0 表示按 provider 默认折扣计算
+	PriceCacheWrite  float64 // USD per 1K cache-write tokens，0 表示按 provider 默认溢价计算
+	PriceReasoning   float64 // USD per 1K reasoning tokens，0 表示按输出单价计费
+}
+
+// defaultCachedInputRatios 是命中 prompt 缓存的输入 token 相对原价的默认计费比例。
+var defaultCachedInputRatios = map[string]float64{
+	"openai":    0.1,
+	"anthropic": 0.1,
+	"claude":    0.1,
+	"gemini":    0.25,
+	"deepseek":  0.1,
+	"qwen":      0.4,
+	"glm":       0.2,
+	"grok":      0.25,
+}
+
+// defaultCacheWriteRatios 是写入 prompt 缓存的输入 token 相对原价的默认计费比例（Anthropic 5 分钟缓存为 1.25 倍），
+// 未列出的 provider 按输入原价计费。
+var defaultCacheWriteRatios = map[string]float64{
+	"anthropic": 1.25,
+	"claude":    1.25,
+}
+
+// cacheReportedSeparately 标记 prompt_tokens 不包含缓存命中与缓存写入 token 的 provider（Anthropic 语义）。
+var cacheReportedSeparately = map[string]bool{
+	"anthropic": true,
+	"claude":    true,
 }
 
 // NewCostCalculator 创建成本计算器
@@ -53,7 +81,7 @@ func (c *CostCalculator) loadDefaultPrices() {
 		{Provider: "deepseek", Model: "deepseek-v4-flash", PriceInput: 0.00014, PriceOutput: 0.00028},
 		// 通义千问 Qwen 系列
 		{Provider: "qwen", Model: "qwen3-max-2026-01-23", PriceInput: 0.00036, PriceOutput: 0.00143},
-		{Provider: "qwen", Model: "qwen-plus", PriceInput: 0.00012, PriceOutput: 0.00029},
+		{Provider: "qwen", Model: "qwen-plus", PriceInput: 0.00012, PriceOutput: 0.00029, PriceReasoning: 0.00115}, // 思考模式输出单价更高
 		{Provider: "qwen", Model: "qwen3-coder-next", PriceInput: 0.0002, PriceOutput: 0.0015},
 		// 智谱 GLM 系列
 		{Provider: "glm", Model: "glm-5.1", PriceInput: 0.00174, PriceOutput: 0.00696},
@@ -69,9 +97,7 @@ func (c *CostCalculator) loadDefaultPrices() {
 		{Provider: "doubao", Model: "Doubao-1.5-pro-256k", PriceInput: 0.0008, PriceOutput: 0.002},
 	}
 
-	for _, p := range defaults {
-		c.SetPrice(p.Provider, p.Model, p.PriceInput, p.PriceOutput)
-	}
+	c.UpdatePrices(defaults)
 }
 
 // SetPrice 设置模型价格
@@ -110,6 +136,80 @@ func (c *CostCalculator) Calculate(provider, model string, tokensInput, tokensOu
 	return inputCost + outputCost
 }
 
+// CalculateWithCache 计算考虑 prompt 缓存折扣与缓存写入溢价的成本。
+// cachedTokens 为命中缓存的输入 token 数，cacheWriteTokens 为写入缓存的输入 token 数（Anthropic cache_creation_input_tokens）；
+// 对 OpenAI 语义的 provider 它们包含在 tokensInput 内，对 Anthropic 语义的 provider 它们与 tokensInput 分开上报。
+func (c *CostCalculator) CalculateWithCache(provider, model string, tokensInput, cachedTokens, cacheWriteTokens, tokensOutput int) float64 {
+	price := c.GetPrice(provider, model)
+	if price == nil {
+		return 0
+	}
+	cachedTokens = max(cachedTokens, 0)
+	cacheWriteTokens = max(cacheWriteTokens, 0)
+	if cachedTokens == 0 && cacheWriteTokens == 0 {
+		return c.Calculate(provider, model, tokensInput, tokensOutput)
+	}
+
+	uncached := tokensInput
+	if !cacheReportedSeparately[provider] {
+		uncached = max(tokensInput-cachedTokens-cacheWriteTokens, 0)
+	}
+
+	cachedPrice := price.PriceCachedInput
+	if cachedPrice <= 0 {
+		ratio, ok := defaultCachedInputRatios[provider]
+		if !ok {
+			ratio = 1
+		}
+		cachedPrice = price.PriceInput * ratio
+	}
+
+	writePrice := price.PriceCacheWrite
+	if writePrice <= 0 {
+		ratio, ok := defaultCacheWriteRatios[provider]
+		if !ok {
+			ratio = 1
+		}
+		writePrice = price.PriceInput * ratio
+	}
+
+	inputCost := float64(uncached) / 1000 * price.PriceInput
+	cachedCost := float64(cachedTokens) / 1000 * cachedPrice
+	writeCost := float64(cacheWriteTokens) / 1000 * writePrice
+	outputCost := float64(tokensOutput) / 1000 * price.PriceOutput
+
+	return inputCost + cachedCost + writeCost + outputCost
+}
+
+// CacheSavings 返回 cachedTokens 个输入 token 命中 prompt 缓存相对原价节省的费用。
+func (c *CostCalculator) CacheSavings(provider, model string, cachedTokens int) float64 {
+	price := c.GetPrice(provider, model)
+	if price == nil || cachedTokens <= 0 {
+		return 0
+	}
+	cachedPrice := price.PriceCachedInput
+	if cachedPrice <= 0 {
+		ratio, ok := defaultCachedInputRatios[provider]
+		if !ok {
+			return 0
+		}
+		cachedPrice = price.PriceInput * ratio
+	}
+	return max(price.PriceInput-cachedPrice, 0) * float64(cachedTokens) / 1000
+}
+
+// CalculateWithReasoning 在 CalculateWithCache 基础上按推理单价计费推理 token。
+// reasoningTokens 按 OpenAI 语义包含在 tokensOutput 内；未配置 PriceReasoning 时与 CalculateWithCache 一致。
+func (c *CostCalculator) CalculateWithReasoning(provider, model string, tokensInput, cachedTokens, cacheWriteTokens, tokensOutput, reasoningTokens int) float64 {
+	cost := c.CalculateWithCache(provider, model, tokensInput, cachedTokens, cacheWriteTokens, tokensOutput)
+	price := c.GetPrice(provider, model)
+	if price == nil || price.PriceReasoning <= 0 || reasoningTokens <= 0 {
+		return cost
+	}
+	reasoningTokens = min(reasoningTokens, tokensOutput)
+	return cost + float64(reasoningTokens)/1000*(price.PriceReasoning-price.PriceOutput)
+}
+
 // UpdatePrices 批量更新价格（从配置/数据库）
 func (c *CostCalculator) UpdatePrices(prices []ModelPrice) {
 	c.mu.Lock()
@@ -118,10 +218,13 @@ func (c *CostCalculator) UpdatePrices(prices []ModelPrice) {
 	for _, p := range prices {
 		key := p.Provider + ":" + p.Model
 		c.prices[key] = &ModelPrice{
-			Provider:    p.Provider,
-			Model:       p.Model,
-			PriceInput:  p.PriceInput,
-			PriceOutput: p.PriceOutput,
+			Provider:         p.Provider,
+			Model:            p.Model,
+			PriceInput:       p.PriceInput,
+			PriceOutput:      p.PriceOutput,
+			PriceCachedInput: p.PriceCachedInput,
+			PriceCacheWrite:  p.PriceCacheWrite,
+			PriceReasoning:   p.PriceReasoning,
 		}
 	}
 }
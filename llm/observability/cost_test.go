@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"math"
 	"testing"
 )
 
@@ -68,3 +69,81 @@ func TestCostCalculator_SetPrice(t *testing.T) {
 	}
 }
 
+
+func TestCostCalculator_CalculateWithCache(t *testing.T) {
+	calc := NewCostCalculator()
+	calc.SetPrice("openai", "cached-model", 0.01, 0.02)
+	calc.SetPrice("anthropic", "cached-model", 0.01, 0.02)
+
+	// OpenAI 语义：cached 包含在 prompt tokens 内，按 10% 计价。
+	got := calc.CalculateWithCache("openai", "cached-model", 2000, 1000, 0, 0)
+	if want := 0.01 + 0.001; math.Abs(got-want) > 1e-9 {
+		t.Errorf("openai CalculateWithCache() = %v, want %v", got, want)
+	}
+
+	// Anthropic 语义：cached 与 prompt tokens 分开上报。
+	got = calc.CalculateWithCache("anthropic", "cached-model", 1000, 1000, 0, 0)
+	if want := 0.01 + 0.001; math.Abs(got-want) > 1e-9 {
+		t.Errorf("anthropic CalculateWithCache() = %v, want %v", got, want)
+	}
+
+	// 显式缓存价格优先于默认折扣。
+	calc.UpdatePrices([]ModelPrice{{Provider: "openai", Model: "cached-model", PriceInput: 0.01, PriceOutput: 0.02, PriceCachedInput: 0.005}})
+	got = calc.CalculateWithCache("openai", "cached-model", 1000, 1000, 0, 1000)
+	if want := 0.005 + 0.02; math.Abs(got-want) > 1e-9 {
+		t.Errorf("explicit cached price CalculateWithCache() = %v, want %v", got, want)
+	}
+
+	if got := calc.CalculateWithCache("unknown", "unknown", 1000, 500, 0, 1000); got != 0 {
+		t.Errorf("unknown model CalculateWithCache() = %v, want 0", got)
+	}
+}
+
+func TestCostCalculator_CalculateWithCacheWrite(t *testing.T) {
+	calc := NewCostCalculator()
+	calc.SetPrice("anthropic", "cached-model", 0.01, 0.02)
+	calc.SetPrice("openai", "cached-model", 0.01, 0.02)
+
+	// Anthropic 语义：缓存写入 token 与 prompt tokens 分开上报，默认按 1.25 倍原价计费。
+	got := calc.CalculateWithCache("anthropic", "cached-model", 1000, 1000, 2000, 0)
+	if want := 0.01 + 0.001 + 0.025; math.Abs(got-want) > 1e-9 {
+		t.Errorf("anthropic cache write CalculateWithCache() = %v, want %v", got, want)
+	}
+
+	// OpenAI 语义：写入 token 包含在 prompt tokens 内，未配置溢价时按原价计费。
+	got = calc.CalculateWithCache("openai", "cached-model", 3000, 1000, 1000, 0)
+	if want := 0.01 + 0.001 + 0.01; math.Abs(got-want) > 1e-9 {
+		t.Errorf("openai cache write CalculateWithCache() = %v, want %v", got, want)
+	}
+
+	// 显式缓存写入价格优先于默认溢价。
+	calc.UpdatePrices([]ModelPrice{{Provider: "anthropic", Model: "cached-model", PriceInput: 0.01, PriceOutput: 0.02, PriceCacheWrite: 0.02}})
+	got = calc.CalculateWithCache("anthropic", "cached-model", 0, 0, 1000, 0)
+	if want := 0.02; math.Abs(got-want) > 1e-9 {
+		t.Errorf("explicit cache write price CalculateWithCache() = %v, want %v", got, want)
+	}
+}
+
+func TestCostCalculator_CalculateWithReasoning(t *testing.T) {
+	calc := NewCostCalculator()
+	calc.UpdatePrices([]ModelPrice{{Provider: "qwen", Model: "thinking-model", PriceInput: 0.001, PriceOutput: 0.002, PriceReasoning: 0.008}})
+
+	// 推理 token 包含在输出内：1000 普通输出 + 1000 推理输出。
+	got := calc.CalculateWithReasoning("qwen", "thinking-model", 1000, 0, 0, 2000, 1000)
+	if want := 0.001 + 0.002 + 0.008; math.Abs(got-want) > 1e-9 {
+		t.Errorf("CalculateWithReasoning() = %v, want %v", got, want)
+	}
+
+	// 推理 token 数超过输出时按输出上限截断。
+	got = calc.CalculateWithReasoning("qwen", "thinking-model", 0, 0, 0, 1000, 5000)
+	if want := 0.008; math.Abs(got-want) > 1e-9 {
+		t.Errorf("clamped CalculateWithReasoning() = %v, want %v", got, want)
+	}
+
+	// 未配置推理单价时按输出单价计费。
+	calc.SetPrice("openai", "o-model", 0.001, 0.004)
+	got = calc.CalculateWithReasoning("openai", "o-model", 1000, 0, 0, 1000, 800)
+	if want := calc.CalculateWithCache("openai", "o-model", 1000, 0, 0, 1000); math.Abs(got-want) > 1e-9 {
+		t.Errorf("default reasoning price CalculateWithReasoning() = %v, want %v", got, want)
+	}
+}
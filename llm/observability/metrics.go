@@ -28,6 +28,7 @@ type Metrics struct {
 	requestDuration metric.Float64Histogram
 	tokenCount      metric.Int64Histogram
 	costPerRequest  metric.Float64Histogram
+	stream          streamInstruments
 	// 高地语
 	activeRequests metric.Int64UpDownCounter
 }
@@ -119,6 +120,12 @@ func NewMetrics() (*Metrics, error) {
 		return nil, err
 	}
 
+	// 流式时延
+	m.stream, err = newStreamInstruments(meter)
+	if err != nil {
+		return nil, err
+	}
+
 	// 活跃请求数
 	m.activeRequests, err = meter.Int64UpDownCounter("llm.request.active",
 		metric.WithDescription("Number of active requests"),
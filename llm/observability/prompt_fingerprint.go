@@ -0,0 +1,258 @@
+package observability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+)
+
+const (
+	// MetadataKeyPromptFingerprint 是请求 metadata 中 system prompt 指纹的键。
+	MetadataKeyPromptFingerprint = "prompt_fingerprint"
+	// MetadataKeyPromptName 是请求 metadata 中已登记 prompt 名称的键。
+	MetadataKeyPromptName = "prompt_name"
+
+	promptFingerprintPrefix = "pfp_"
+	defaultPromptName       = "default"
+
+	// maxPromptObservations 限制观测表的条目总数；prompt 名称与内容来自请求，
+	// 超出后淘汰最久未出现的观测，避免任意 prompt 使登记表无限增长。
+	maxPromptObservations = 1024
+)
+
+// FingerprintPrompt 计算 prompt 文本的内容寻址指纹。
+// 计算前统一换行符并折叠行内空白，因此仅有格式差异的 prompt 得到相同指纹。
+func FingerprintPrompt(text string) string {
+	normalized := normalizePromptText(text)
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return promptFingerprintPrefix + hex.EncodeToString(sum[:16])
+}
+
+// FingerprintSystemPrompt 对消息列表中的 system/developer 消息整体计算指纹。
+func FingerprintSystemPrompt(messages []llmcore.Message) string {
+	var parts []string
+	for _, m := range messages {
+		if m.Role != llmcore.RoleSystem && m.Role != llmcore.RoleDeveloper {
+			continue
+		}
+		if strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		parts = append(parts, string(m.Role)+":"+m.Content)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return FingerprintPrompt(strings.Join(parts, "\n"))
+}
+
+func normalizePromptText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// PromptVersion 是一次登记的 prompt 版本。
+type PromptVersion struct {
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Fingerprint  string    `json:"fingerprint"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// PromptObservation 汇总生产流量中观测到的一个指纹。
+type PromptObservation struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	Version     string    `json:"version,omitempty"`
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// PromptDrift 描述一个未对应任何登记版本的生产指纹。
+type PromptDrift struct {
+	PromptObservation
+	RegisteredFingerprints []string `json:"registered_fingerprints,omitempty"`
+}
+
+// PromptFingerprintRegistry 登记已评审的 prompt 版本，并记录生产请求实际使用的指纹，
+// 用于发现未经评审就进入生产的 prompt 变更。
+type PromptFingerprintRegistry struct {
+	mu           sync.RWMutex
+	versions     map[string]map[string]PromptVersion // name -> fingerprint -> version
+	observations map[string]map[string]*PromptObservation
+	observed     int
+	now          func() time.Time
+}
+
+// NewPromptFingerprintRegistry 创建 prompt 指纹登记表。
+func NewPromptFingerprintRegistry() *PromptFingerprintRegistry {
+	return &PromptFingerprintRegistry{
+		versions:     make(map[string]map[string]PromptVersion),
+		observations: make(map[string]map[string]*PromptObservation),
+		now:          time.Now,
+	}
+}
+
+// Register 登记一个 prompt 版本并返回其指纹。
+// prompt 按单条 system 消息计算指纹，与 gateway 对请求 system prompt 计算的指纹一致。
+func (r *PromptFingerprintRegistry) Register(name, version, prompt string) string {
+	return r.RegisterFingerprint(name, version, FingerprintSystemPrompt([]llmcore.Message{
+		{Role: llmcore.RoleSystem, Content: prompt},
+	}))
+}
+
+// RegisterFingerprint 以预先计算好的指纹登记一个 prompt 版本。
+func (r *PromptFingerprintRegistry) RegisterFingerprint(name, version, fingerprint string) string {
+	if fingerprint == "" {
+		return ""
+	}
+	name = promptNameOrDefault(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byFP, ok := r.versions[name]
+	if !ok {
+		byFP = make(map[string]PromptVersion)
+		r.versions[name] = byFP
+	}
+	byFP[fingerprint] = PromptVersion{
+		Name:         name,
+		Version:      version,
+		Fingerprint:  fingerprint,
+		RegisteredAt: r.now(),
+	}
+	if obs, ok := r.observations[name][fingerprint]; ok {
+		obs.Version = version
+	}
+	return fingerprint
+}
+
+// Observe 记录一次生产请求使用的指纹，返回该指纹的观测快照以及是否匹配登记版本。
+// 快照的 Count 为 1 表示首次观测到该指纹。
+func (r *PromptFingerprintRegistry) Observe(name, fingerprint string) (PromptObservation, bool) {
+	if fingerprint == "" {
+		return PromptObservation{}, false
+	}
+	name = promptNameOrDefault(name)
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	registered, matched := r.versions[name][fingerprint]
+
+	obs, ok := r.observations[name][fingerprint]
+	if !ok {
+		if r.observed >= maxPromptObservations {
+			r.evictOldestObservationLocked()
+		}
+		byFP, ok := r.observations[name]
+		if !ok {
+			byFP = make(map[string]*PromptObservation)
+			r.observations[name] = byFP
+		}
+		obs = &PromptObservation{Name: name, Fingerprint: fingerprint, FirstSeen: now}
+		byFP[fingerprint] = obs
+		r.observed++
+	}
+	obs.Count++
+	obs.LastSeen = now
+	if matched {
+		obs.Version = registered.Version
+	}
+	return *obs, matched
+}
+
+func (r *PromptFingerprintRegistry) evictOldestObservationLocked() {
+	var oldest *PromptObservation
+	for _, byFP := range r.observations {
+		for _, obs := range byFP {
+			if oldest == nil || obs.LastSeen.Before(oldest.LastSeen) {
+				oldest = obs
+			}
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	delete(r.observations[oldest.Name], oldest.Fingerprint)
+	if len(r.observations[oldest.Name]) == 0 {
+		delete(r.observations, oldest.Name)
+	}
+	r.observed--
+}
+
+// Managed 报告 prompt 名称下是否已有登记版本。
+func (r *PromptFingerprintRegistry) Managed(name string) bool {
+	name = promptNameOrDefault(name)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.versions[name]) > 0
+}
+
+// Versions 返回某个 prompt 名称下所有登记版本。
+func (r *PromptFingerprintRegistry) Versions(name string) []PromptVersion {
+	name = promptNameOrDefault(name)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]PromptVersion, 0, len(r.versions[name]))
+	for _, v := range r.versions[name] {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RegisteredAt.Before(out[j].RegisteredAt) })
+	return out
+}
+
+// Drifts 返回生产中出现、但未对应任何登记版本的指纹，按最近出现时间倒序。
+// 仅报告已有登记版本的 prompt 名称，未纳管的 prompt 不视为漂移。
+func (r *PromptFingerprintRegistry) Drifts() []PromptDrift {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []PromptDrift
+	for name, byFP := range r.observations {
+		registered, managed := r.versions[name]
+		if !managed {
+			continue
+		}
+		for fp, obs := range byFP {
+			if _, ok := registered[fp]; ok {
+				continue
+			}
+			drift := PromptDrift{PromptObservation: *obs}
+			for regFP := range registered {
+				drift.RegisteredFingerprints = append(drift.RegisteredFingerprints, regFP)
+			}
+			sort.Strings(drift.RegisteredFingerprints)
+			out = append(out, drift)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeen.After(out[j].LastSeen) })
+	return out
+}
+
+func promptNameOrDefault(name string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return defaultPromptName
+}
@@ -0,0 +1,95 @@
+package observability
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintPrompt_NormalizesWhitespace(t *testing.T) {
+	a := FingerprintPrompt("You are helpful.\r\n\n  Answer   briefly. ")
+	b := FingerprintPrompt("You are helpful.\nAnswer briefly.")
+	require.NotEmpty(t, a)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, FingerprintPrompt("You are helpful.\nAnswer in detail."))
+	assert.Empty(t, FingerprintPrompt("   "))
+}
+
+func TestFingerprintSystemPrompt_OnlySystemMessages(t *testing.T) {
+	base := []llmcore.Message{
+		{Role: llmcore.RoleSystem, Content: "sys"},
+		{Role: llmcore.RoleUser, Content: "hello"},
+	}
+	changedUser := []llmcore.Message{
+		{Role: llmcore.RoleSystem, Content: "sys"},
+		{Role: llmcore.RoleUser, Content: "bye"},
+	}
+	assert.Equal(t, FingerprintSystemPrompt(base), FingerprintSystemPrompt(changedUser))
+	assert.Empty(t, FingerprintSystemPrompt([]llmcore.Message{{Role: llmcore.RoleUser, Content: "hello"}}))
+}
+
+func TestPromptFingerprintRegistry_Drifts(t *testing.T) {
+	reg := NewPromptFingerprintRegistry()
+	v1 := reg.Register("support", "v1", "You are a support agent.")
+
+	obs, ok := reg.Observe("support", v1)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", obs.Version)
+	assert.Empty(t, reg.Drifts())
+
+	rogue := FingerprintPrompt("You are a support agent. Offer refunds freely.")
+	obs, ok = reg.Observe("support", rogue)
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), obs.Count)
+	obs, _ = reg.Observe("support", rogue)
+	assert.Equal(t, int64(2), obs.Count)
+	// 未纳管的 prompt 名称不报告漂移。
+	_, _ = reg.Observe("unmanaged", rogue)
+
+	drifts := reg.Drifts()
+	require.Len(t, drifts, 1)
+	assert.Equal(t, "support", drifts[0].Name)
+	assert.Equal(t, rogue, drifts[0].Fingerprint)
+	assert.Equal(t, int64(2), drifts[0].Count)
+	assert.Equal(t, []string{v1}, drifts[0].RegisteredFingerprints)
+
+	// 补登记后漂移消失。
+	reg.RegisterFingerprint("support", "v2", rogue)
+	assert.Empty(t, reg.Drifts())
+	assert.Len(t, reg.Versions("support"), 2)
+}
+
+func TestPromptFingerprintRegistry_RegisterMatchesSystemPrompt(t *testing.T) {
+	reg := NewPromptFingerprintRegistry()
+	fp := reg.Register("support", "v1", "You are a support agent.")
+
+	assert.Equal(t, FingerprintSystemPrompt([]llmcore.Message{
+		{Role: llmcore.RoleSystem, Content: "You are a support agent."},
+		{Role: llmcore.RoleUser, Content: "hello"},
+	}), fp)
+	assert.Empty(t, reg.Register("support", "v2", "  "))
+}
+
+func TestPromptFingerprintRegistry_BoundsObservations(t *testing.T) {
+	reg := NewPromptFingerprintRegistry()
+	reg.Register("support", "v1", "You are a support agent.")
+	now := time.Unix(0, 0)
+	reg.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	first := FingerprintPrompt("prompt-0")
+	for i := 0; i < maxPromptObservations+10; i++ {
+		_, _ = reg.Observe("support", FingerprintPrompt(fmt.Sprintf("prompt-%d", i)))
+	}
+	drifts := reg.Drifts()
+	assert.Len(t, drifts, maxPromptObservations)
+	for _, drift := range drifts {
+		assert.NotEqual(t, first, drift.Fingerprint, "the least recently seen observation is evicted")
+	}
+}
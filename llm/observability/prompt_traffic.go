@@ -0,0 +1,171 @@
+package observability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+)
+
+const (
+	requestFingerprintPrefix     = "rfp_"
+	defaultPromptTrafficCapacity = 10000
+)
+
+// PromptTrafficSample 是一个请求指纹的流量统计与可回放样本。
+type PromptTrafficSample struct {
+	Fingerprint string               `json:"fingerprint"`
+	Model       string               `json:"model,omitempty"`
+	TenantID    string               `json:"tenant_id,omitempty"`
+	Request     *llmcore.ChatRequest `json:"request,omitempty"`
+	Count       int64                `json:"count"`
+	FirstSeen   time.Time            `json:"first_seen"`
+	LastSeen    time.Time            `json:"last_seen"`
+}
+
+// PromptTrafficStore 按请求内容聚合生产流量，为每个请求指纹保留一份可回放样本，
+// 供缓存预热等离线任务按频率回放。容量满时淘汰最不常见的样本。
+type PromptTrafficStore struct {
+	mu       sync.RWMutex
+	capacity int
+	samples  map[string]*PromptTrafficSample
+	now      func() time.Time
+}
+
+// NewPromptTrafficStore 创建流量样本存储，capacity<=0 时使用默认容量 10000。
+func NewPromptTrafficStore(capacity int) *PromptTrafficStore {
+	if capacity <= 0 {
+		capacity = defaultPromptTrafficCapacity
+	}
+	return &PromptTrafficStore{
+		capacity: capacity,
+		samples:  make(map[string]*PromptTrafficSample),
+		now:      time.Now,
+	}
+}
+
+// FingerprintRequest 计算决定响应内容的请求字段（模型、消息与采样参数）的指纹，
+// trace/metadata 等不影响输出的字段不参与计算。
+func FingerprintRequest(req *llmcore.ChatRequest) string {
+	if req == nil || len(req.Messages) == 0 {
+		return ""
+	}
+	type message struct {
+		Role    string `json:"r"`
+		Name    string `json:"n,omitempty"`
+		Content string `json:"c"`
+	}
+	payload := struct {
+		Model          string                  `json:"model"`
+		TenantID       string                  `json:"tenant,omitempty"`
+		Messages       []message               `json:"messages"`
+		MaxTokens      int                     `json:"max_tokens,omitempty"`
+		Temperature    float32                 `json:"temperature,omitempty"`
+		TopP           float32                 `json:"top_p,omitempty"`
+		Stop           []string                `json:"stop,omitempty"`
+		ResponseFormat *llmcore.ResponseFormat `json:"response_format,omitempty"`
+	}{
+		Model:          req.Model,
+		TenantID:       req.TenantID,
+		Messages:       make([]message, 0, len(req.Messages)),
+		MaxTokens:      req.MaxTokens,
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		Stop:           req.Stop,
+		ResponseFormat: req.ResponseFormat,
+	}
+	for _, m := range req.Messages {
+		payload.Messages = append(payload.Messages, message{Role: string(m.Role), Name: m.Name, Content: m.Content})
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return requestFingerprintPrefix + hex.EncodeToString(sum[:16])
+}
+
+// Record 记录一次请求并返回其指纹；样本保留最近一次请求的副本。
+func (s *PromptTrafficStore) Record(req *llmcore.ChatRequest) string {
+	fingerprint := FingerprintRequest(req)
+	if fingerprint == "" {
+		return ""
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sample, ok := s.samples[fingerprint]
+	if !ok {
+		if len(s.samples) >= s.capacity {
+			s.evictLocked()
+		}
+		sample = &PromptTrafficSample{Fingerprint: fingerprint, FirstSeen: now}
+		s.samples[fingerprint] = sample
+	}
+	sample.Model = req.Model
+	sample.TenantID = req.TenantID
+	sample.Request = replayableRequest(req)
+	sample.Count++
+	sample.LastSeen = now
+	return fingerprint
+}
+
+// Top 返回出现次数最多的 n 个样本，次数相同时最近出现的优先。
+func (s *PromptTrafficStore) Top(n int) []PromptTrafficSample {
+	s.mu.RLock()
+	out := make([]PromptTrafficSample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		out = append(out, *sample)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Len 返回当前保留的样本数。
+func (s *PromptTrafficStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.samples)
+}
+
+func (s *PromptTrafficStore) evictLocked() {
+	var victim *PromptTrafficSample
+	for _, sample := range s.samples {
+		if victim == nil || sample.Count < victim.Count ||
+			(sample.Count == victim.Count && sample.LastSeen.Before(victim.LastSeen)) {
+			victim = sample
+		}
+	}
+	if victim != nil {
+		delete(s.samples, victim.Fingerprint)
+	}
+}
+
+// replayableRequest 复制请求并去掉与单次调用绑定的字段。
+func replayableRequest(req *llmcore.ChatRequest) *llmcore.ChatRequest {
+	clone := *req
+	clone.TraceID = ""
+	clone.Messages = append([]llmcore.Message(nil), req.Messages...)
+	if req.Metadata != nil {
+		clone.Metadata = make(map[string]string, len(req.Metadata))
+		for k, v := range req.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func trafficRequest(content string) *llmcore.ChatRequest {
+	return &llmcore.ChatRequest{
+		TraceID:  "trace-" + content,
+		Model:    "gpt-4o-mini",
+		Messages: []llmcore.Message{{Role: llmcore.RoleUser, Content: content}},
+	}
+}
+
+func TestFingerprintRequest_IgnoresTraceAndMetadata(t *testing.T) {
+	a := trafficRequest("hello")
+	b := trafficRequest("hello")
+	b.TraceID = "other"
+	b.Metadata = map[string]string{"k": "v"}
+
+	require.NotEmpty(t, FingerprintRequest(a))
+	assert.Equal(t, FingerprintRequest(a), FingerprintRequest(b))
+	assert.NotEqual(t, FingerprintRequest(a), FingerprintRequest(trafficRequest("bye")))
+
+	b.Temperature = 0.7
+	assert.NotEqual(t, FingerprintRequest(a), FingerprintRequest(b))
+	assert.Empty(t, FingerprintRequest(&llmcore.ChatRequest{Model: "m"}))
+}
+
+func TestPromptTrafficStore_TopAndEviction(t *testing.T) {
+	store := NewPromptTrafficStore(2)
+	clock := time.Unix(1700000000, 0)
+	store.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	for i := 0; i < 3; i++ {
+		store.Record(trafficRequest("popular"))
+	}
+	store.Record(trafficRequest("rare"))
+	store.Record(trafficRequest("newcomer")) // 淘汰 rare
+
+	top := store.Top(10)
+	require.Len(t, top, 2)
+	assert.Equal(t, int64(3), top[0].Count)
+	assert.Equal(t, "popular", top[0].Request.Messages[0].Content)
+	assert.Empty(t, top[0].Request.TraceID, "replay samples drop trace ids")
+	assert.Equal(t, "newcomer", top[1].Request.Messages[0].Content)
+
+	assert.Len(t, store.Top(1), 1)
+	assert.Equal(t, 2, store.Len())
+}
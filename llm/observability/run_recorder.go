@@ -0,0 +1,217 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRunRecorderMaxRuns   = 1000
+	defaultRunRecorderMaxEvents = 2000
+)
+
+// RunMetadataTenantID 是运行事件 Metadata 中携带租户 ID 的键.
+const RunMetadataTenantID = "tenant_id"
+
+// RunRecorderConfig 配置运行记录器.
+type RunRecorderConfig struct {
+	MaxRuns         int // 内存中保留的运行数，超出后淘汰最早创建的运行（默认 1000）
+	MaxEventsPerRun int // 单个运行保留的事件数，超出后丢弃非终态事件（默认 2000）
+	// Calculator 在落账条目未携带成本时按价格表估算，默认 NewCostCalculator()
+	Calculator *CostCalculator
+}
+
+// RunRecorder 按运行 ID 汇总运行事件：生命周期、LLM 调用用量与成本、工具调用、护栏决策，
+// 供运行检查接口还原追踪树与 token/成本明细。同时实现 Ledger，可并入 gateway 落账链路，
+// 落账时从 context 中读取 run ID；没有 run ID 的事件会被忽略。
+type RunRecorder struct {
+	cfg    RunRecorderConfig
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	runs  map[string]*recordedRun
+	order []string
+}
+
+type recordedRun struct {
+	tenantID  string
+	sequence  int64
+	events    []types.RunEvent
+	children  []string
+	truncated bool
+}
+
+// NewRunRecorder 创建运行记录器.
+func NewRunRecorder(cfg RunRecorderConfig, logger *zap.Logger) *RunRecorder {
+	if cfg.MaxRuns <= 0 {
+		cfg.MaxRuns = defaultRunRecorderMaxRuns
+	}
+	if cfg.MaxEventsPerRun <= 0 {
+		cfg.MaxEventsPerRun = defaultRunRecorderMaxEvents
+	}
+	if cfg.Calculator == nil {
+		cfg.Calculator = NewCostCalculator()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RunRecorder{
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "run_recorder")),
+		now:    time.Now,
+		runs:   make(map[string]*recordedRun),
+	}
+}
+
+// AppendRunEvent 记录一条运行事件，序号按运行单调递增.
+// 首次出现的运行会被创建，并在父运行存在时挂到父运行下.
+func (r *RunRecorder) AppendRunEvent(event types.RunEvent) {
+	if r == nil || event.RunID == "" {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = r.now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[event.RunID]
+	if !ok {
+		run = &recordedRun{}
+		r.runs[event.RunID] = run
+		r.order = append(r.order, event.RunID)
+		if parent, ok := r.runs[event.ParentRunID]; ok && event.ParentRunID != event.RunID {
+			parent.children = append(parent.children, event.RunID)
+		}
+		r.evictLocked()
+	}
+	if run.tenantID == "" {
+		run.tenantID = event.Metadata[RunMetadataTenantID]
+	}
+	if len(run.events) >= r.cfg.MaxEventsPerRun && !isTerminalRunEvent(event.Type) {
+		if !run.truncated {
+			r.logger.Warn("run event limit reached, dropping events", zap.String("run_id", event.RunID))
+		}
+		run.truncated = true
+		return
+	}
+	run.sequence++
+	event.Sequence = run.sequence
+	run.events = append(run.events, event)
+}
+
+func (r *RunRecorder) evictLocked() {
+	for len(r.order) > r.cfg.MaxRuns {
+		delete(r.runs, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// Record 实现 Ledger：将一次落账记录为所属运行的 usage 事件.
+// run ID 优先取自 context，其次取自 entry.Metadata["run_id"].
+func (r *RunRecorder) Record(ctx context.Context, entry LedgerEntry) error {
+	if r == nil {
+		return nil
+	}
+	runID, _ := types.RunID(ctx)
+	if runID == "" {
+		runID = entry.Metadata["run_id"]
+	}
+	if runID == "" {
+		return nil
+	}
+	parentRunID, _ := types.ParentRunID(ctx)
+	agentID, _ := types.AgentID(ctx)
+	if agentID == "" {
+		agentID = entry.Metadata["agent_id"]
+	}
+	tenantID, _ := types.TenantID(ctx)
+	if tenantID == "" {
+		tenantID = entry.Metadata["tenant_id"]
+	}
+
+	usage := &types.ChatUsage{
+		PromptTokens:     entry.Usage.PromptTokens,
+		CompletionTokens: entry.Usage.CompletionTokens,
+		TotalTokens:      entry.Usage.TotalTokens,
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	cost := entry.Cost.AmountUSD
+	if cost == 0 {
+		cost = r.cfg.Calculator.CalculateWithReasoning(entry.Provider, entry.Model,
+			entry.Usage.PromptTokens, entry.Usage.CachedTokens, entry.Usage.CacheCreationTokens, entry.Usage.CompletionTokens, entry.Usage.ReasoningTokens)
+	}
+	if entry.Usage.CachedTokens > 0 {
+		usage.PromptTokensDetails = &types.PromptTokensDetails{CachedTokens: entry.Usage.CachedTokens}
+	}
+	event := types.RunEvent{
+		Type:        types.RunEventUsage,
+		RunID:       runID,
+		ParentRunID: parentRunID,
+		TraceID:     entry.TraceID,
+		AgentID:     agentID,
+		Timestamp:   entry.Timestamp,
+		Usage:       usage,
+		Data: map[string]any{
+			"capability": entry.Capability,
+			"provider":   entry.Provider,
+			"model":      entry.Model,
+			"strategy":   entry.Strategy,
+			"cost_usd":   cost,
+			"currency":   entry.Cost.Currency,
+		},
+	}
+	if tenantID != "" {
+		event.Metadata = map[string]string{RunMetadataTenantID: tenantID}
+	}
+	r.AppendRunEvent(event)
+	return nil
+}
+
+// RunSnapshot 是一个运行记录的只读副本.
+type RunSnapshot struct {
+	RunID    string
+	TenantID string
+	// Events 按序号升序排列
+	Events []types.RunEvent
+	// ChildRunIDs 为仍在保留期内的子运行，按创建顺序排列
+	ChildRunIDs []string
+	// Truncated 表示运行因事件上限丢弃过事件
+	Truncated bool
+}
+
+// Snapshot 返回运行记录的副本；运行不存在或已被淘汰时 ok 为 false.
+func (r *RunRecorder) Snapshot(runID string) (*RunSnapshot, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.runs[runID]
+	if !ok {
+		return nil, false
+	}
+	snap := &RunSnapshot{
+		RunID:     runID,
+		TenantID:  run.tenantID,
+		Events:    append([]types.RunEvent(nil), run.events...),
+		Truncated: run.truncated,
+	}
+	for _, id := range run.children {
+		if _, ok := r.runs[id]; ok {
+			snap.ChildRunIDs = append(snap.ChildRunIDs, id)
+		}
+	}
+	return snap, true
+}
+
+func isTerminalRunEvent(t types.RunEventType) bool {
+	return t == types.RunEventCompleted || t == types.RunEventFailed
+}
@@ -0,0 +1,72 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRecorder_RecordsLedgerEntriesPerRun(t *testing.T) {
+	rec := NewRunRecorder(RunRecorderConfig{}, nil)
+	ctx := types.WithTenantID(types.WithRunID(context.Background(), "run-1"), "t1")
+
+	require.NoError(t, rec.Record(ctx, LedgerEntry{
+		Provider: "openai", Model: "gpt-5.4", Capability: "chat",
+		Usage: core.Usage{PromptTokens: 100, CompletionTokens: 20, CachedTokens: 40},
+		Cost:  core.Cost{AmountUSD: 0.5, Currency: "USD"},
+	}))
+	require.NoError(t, rec.Record(context.Background(), LedgerEntry{Model: "ignored"}), "entries without run ID are ignored")
+
+	snap, ok := rec.Snapshot("run-1")
+	require.True(t, ok)
+	assert.Equal(t, "t1", snap.TenantID)
+	require.Len(t, snap.Events, 1)
+	event := snap.Events[0]
+	assert.Equal(t, types.RunEventUsage, event.Type)
+	assert.Equal(t, int64(1), event.Sequence)
+	assert.Equal(t, 120, event.Usage.TotalTokens)
+	assert.Equal(t, 40, event.Usage.PromptTokensDetails.CachedTokens)
+	data := event.Data.(map[string]any)
+	assert.Equal(t, "gpt-5.4", data["model"])
+	assert.Equal(t, 0.5, data["cost_usd"])
+
+	_, ok = rec.Snapshot("missing")
+	assert.False(t, ok)
+}
+
+func TestRunRecorder_LinksChildRuns(t *testing.T) {
+	rec := NewRunRecorder(RunRecorderConfig{}, nil)
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventStarted, RunID: "parent"})
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventStarted, RunID: "child", ParentRunID: "parent"})
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventStarted, RunID: "orphan", ParentRunID: "unknown"})
+
+	snap, ok := rec.Snapshot("parent")
+	require.True(t, ok)
+	assert.Equal(t, []string{"child"}, snap.ChildRunIDs)
+}
+
+func TestRunRecorder_Limits(t *testing.T) {
+	rec := NewRunRecorder(RunRecorderConfig{MaxRuns: 2, MaxEventsPerRun: 2}, nil)
+	for i := 0; i < 3; i++ {
+		rec.AppendRunEvent(types.RunEvent{Type: types.RunEventToolCall, RunID: "run-1"})
+	}
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventCompleted, RunID: "run-1"})
+
+	snap, ok := rec.Snapshot("run-1")
+	require.True(t, ok)
+	assert.True(t, snap.Truncated)
+	require.Len(t, snap.Events, 3, "terminal events are kept past the limit")
+	assert.Equal(t, types.RunEventCompleted, snap.Events[2].Type)
+	assert.Equal(t, int64(3), snap.Events[2].Sequence)
+
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventStarted, RunID: "run-2"})
+	rec.AppendRunEvent(types.RunEvent{Type: types.RunEventStarted, RunID: "run-3"})
+	_, ok = rec.Snapshot("run-1")
+	assert.False(t, ok, "oldest run is evicted")
+	_, ok = rec.Snapshot("run-3")
+	assert.True(t, ok)
+}
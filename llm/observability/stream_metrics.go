@@ -0,0 +1,290 @@
+package observability
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/pkg/telemetry"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// maxRecordedTokenGaps 单次流保留的 token 间隔上限，避免超长输出占用过多内存
+const maxRecordedTokenGaps = 4096
+
+// StreamTiming 单次流式调用的时延画像
+type StreamTiming struct {
+	// Provider 与 Model 取自流式块，反映实际服务的上游
+	Provider    string
+	Model       string
+	Start       time.Time
+	FirstToken  time.Time
+	LastToken   time.Time
+	End         time.Time
+	TokenEvents int
+	// CompletionTokens 来自 provider 上报的用量，缺失时为 0
+	CompletionTokens int
+	// InterTokenGaps 相邻 token 事件之间的间隔
+	InterTokenGaps []time.Duration
+	Failed         bool
+}
+
+// TimeToFirstToken 返回首 token 时延，未产出 token 时返回 0
+func (t StreamTiming) TimeToFirstToken() time.Duration {
+	if t.FirstToken.IsZero() {
+		return 0
+	}
+	return t.FirstToken.Sub(t.Start)
+}
+
+// AvgInterTokenLatency 返回平均 token 间隔
+func (t StreamTiming) AvgInterTokenLatency() time.Duration {
+	if t.TokenEvents < 2 {
+		return 0
+	}
+	return t.LastToken.Sub(t.FirstToken) / time.Duration(t.TokenEvents-1)
+}
+
+// TokensPerSecond 返回首 token 之后的有效生成速率。
+// 优先使用 provider 上报的 completion tokens，否则按 token 事件数估算。
+func (t StreamTiming) TokensPerSecond() float64 {
+	tokens := t.CompletionTokens
+	if tokens <= 0 {
+		tokens = t.TokenEvents
+	}
+	if tokens <= 0 || t.FirstToken.IsZero() {
+		return 0
+	}
+	window := t.LastToken.Sub(t.FirstToken)
+	if window <= 0 {
+		window = t.End.Sub(t.FirstToken)
+	}
+	if window <= 0 {
+		return 0
+	}
+	return float64(tokens) / window.Seconds()
+}
+
+// StreamTimer 逐块观测流式输出并生成 StreamTiming
+type StreamTimer struct {
+	timing StreamTiming
+}
+
+// NewStreamTimer 以 start 作为请求发起时间创建计时器
+func NewStreamTimer(start time.Time) *StreamTimer {
+	return &StreamTimer{timing: StreamTiming{Start: start}}
+}
+
+// Observe 记录一个流式块
+func (s *StreamTimer) Observe(chunk llmpkg.StreamChunk, at time.Time) {
+	if chunk.Provider != "" {
+		s.timing.Provider = chunk.Provider
+	}
+	if chunk.Model != "" {
+		s.timing.Model = chunk.Model
+	}
+	if chunk.Usage != nil && chunk.Usage.CompletionTokens > 0 {
+		s.timing.CompletionTokens = chunk.Usage.CompletionTokens
+	}
+	if chunk.Err != nil {
+		s.timing.Failed = true
+		return
+	}
+	if !IsTokenChunk(chunk) {
+		return
+	}
+	if s.timing.FirstToken.IsZero() {
+		s.timing.FirstToken = at
+	} else if len(s.timing.InterTokenGaps) < maxRecordedTokenGaps {
+		s.timing.InterTokenGaps = append(s.timing.InterTokenGaps, at.Sub(s.timing.LastToken))
+	}
+	s.timing.LastToken = at
+	s.timing.TokenEvents++
+}
+
+// Finish 结束计时并返回结果
+func (s *StreamTimer) Finish(at time.Time) StreamTiming {
+	s.timing.End = at
+	return s.timing
+}
+
+// IsTokenChunk 判断流式块是否携带模型生成内容
+func IsTokenChunk(chunk llmpkg.StreamChunk) bool {
+	delta := chunk.Delta
+	return delta.Content != "" ||
+		(delta.ReasoningContent != nil && *delta.ReasoningContent != "") ||
+		len(delta.ToolCalls) > 0
+}
+
+// InstrumentStream 包装流式通道，在流结束时回调 onDone。
+// ctx 取消后不再向下游转发，但会继续排空上游以免其 goroutine 泄漏。
+func InstrumentStream(ctx context.Context, start time.Time, source <-chan llmpkg.StreamChunk, onDone func(StreamTiming)) <-chan llmpkg.StreamChunk {
+	out := make(chan llmpkg.StreamChunk)
+	go func() {
+		defer close(out)
+		timer := NewStreamTimer(start)
+		forwarding := true
+		for chunk := range source {
+			timer.Observe(chunk, time.Now())
+			if !forwarding {
+				continue
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				forwarding = false
+			}
+		}
+		if onDone != nil {
+			onDone(timer.Finish(time.Now()))
+		}
+	}()
+	return out
+}
+
+// streamInstruments 流式时延直方图
+type streamInstruments struct {
+	ttft            metric.Float64Histogram
+	interToken      metric.Float64Histogram
+	tokensPerSecond metric.Float64Histogram
+}
+
+func newStreamInstruments(meter metric.Meter) (streamInstruments, error) {
+	var (
+		s   streamInstruments
+		err error
+	)
+
+	// 首 token 时延
+	s.ttft, err = meter.Float64Histogram("llm.stream.time_to_first_token",
+		metric.WithDescription("Time from stream request to first generated token in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15))
+	if err != nil {
+		return s, err
+	}
+
+	// token 间隔
+	s.interToken, err = meter.Float64Histogram("llm.stream.inter_token_latency",
+		metric.WithDescription("Latency between consecutive streamed tokens in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.02, 0.05, 0.1, 0.25, 0.5, 1))
+	if err != nil {
+		return s, err
+	}
+
+	// 生成速率
+	s.tokensPerSecond, err = meter.Float64Histogram("llm.stream.tokens_per_second",
+		metric.WithDescription("Effective streaming generation rate after the first token"),
+		metric.WithUnit("{token}/s"),
+		metric.WithExplicitBucketBoundaries(5, 10, 20, 40, 60, 100, 150, 250))
+	return s, err
+}
+
+// RecordStreamTiming 记录流式调用的 TTFT、token 间隔分布与生成速率
+func (m *Metrics) RecordStreamTiming(ctx context.Context, attrs RequestAttrs, timing StreamTiming) {
+	status := "success"
+	if timing.Failed {
+		status = "error"
+	}
+	opt := metric.WithAttributes(telemetry.LLMRequestAttrs(
+		attrs.Provider,
+		attrs.Model,
+		attrs.TenantID,
+		attrs.UserID,
+		attrs.Feature,
+		status,
+	)...)
+
+	if ttft := timing.TimeToFirstToken(); ttft > 0 {
+		m.stream.ttft.Record(ctx, ttft.Seconds(), opt)
+	}
+	for _, gap := range timing.InterTokenGaps {
+		m.stream.interToken.Record(ctx, gap.Seconds(), opt)
+	}
+	if tps := timing.TokensPerSecond(); tps > 0 {
+		m.stream.tokensPerSecond.Record(ctx, tps, opt)
+	}
+}
+
+// StreamLatencyStats provider+model 维度的流式时延滑动平均
+type StreamLatencyStats struct {
+	Samples              int64
+	AvgTimeToFirstToken  time.Duration
+	AvgInterTokenLatency time.Duration
+	TokensPerSecond      float64
+	LastUpdated          time.Time
+}
+
+// StreamLatencyTracker 以 EWMA 聚合各 provider+model 的流式时延，供路由决策读取
+type StreamLatencyTracker struct {
+	alpha float64
+	mu    sync.RWMutex
+	stats map[string]*StreamLatencyStats
+}
+
+// NewStreamLatencyTracker 创建聚合器，alpha 为新样本权重，非法值回退为 0.2
+func NewStreamLatencyTracker(alpha float64) *StreamLatencyTracker {
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.2
+	}
+	return &StreamLatencyTracker{alpha: alpha, stats: make(map[string]*StreamLatencyStats)}
+}
+
+// Record 合并一次流式调用的时延，未产出 token 的调用不计入
+func (t *StreamLatencyTracker) Record(provider, model string, timing StreamTiming) {
+	if timing.FirstToken.IsZero() {
+		return
+	}
+	key := streamLatencyKey(provider, model)
+	ttft := timing.TimeToFirstToken()
+	itl := timing.AvgInterTokenLatency()
+	tps := timing.TokensPerSecond()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.stats[key]
+	if !ok {
+		t.stats[key] = &StreamLatencyStats{
+			Samples:              1,
+			AvgTimeToFirstToken:  ttft,
+			AvgInterTokenLatency: itl,
+			TokensPerSecond:      tps,
+			LastUpdated:          timing.End,
+		}
+		return
+	}
+	s.Samples++
+	s.AvgTimeToFirstToken = time.Duration(ewma(float64(s.AvgTimeToFirstToken), float64(ttft), t.alpha))
+	if itl > 0 {
+		s.AvgInterTokenLatency = time.Duration(ewma(float64(s.AvgInterTokenLatency), float64(itl), t.alpha))
+	}
+	if tps > 0 {
+		s.TokensPerSecond = ewma(s.TokensPerSecond, tps, t.alpha)
+	}
+	s.LastUpdated = timing.End
+}
+
+// Get 返回指定 provider+model 的聚合快照
+func (t *StreamLatencyTracker) Get(provider, model string) (StreamLatencyStats, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.stats[streamLatencyKey(provider, model)]
+	if !ok {
+		return StreamLatencyStats{}, false
+	}
+	return *s, true
+}
+
+func streamLatencyKey(provider, model string) string {
+	return strings.ToLower(strings.TrimSpace(provider)) + "/" + strings.TrimSpace(model)
+}
+
+func ewma(prev, sample, alpha float64) float64 {
+	if prev == 0 {
+		return sample
+	}
+	return alpha*sample + (1-alpha)*prev
+}
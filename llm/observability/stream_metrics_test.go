@@ -0,0 +1,114 @@
+package observability
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmpkg "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTimer_ComputesLatencyProfile(t *testing.T) {
+	start := time.Unix(1000, 0)
+	timer := NewStreamTimer(start)
+	reasoning := "thinking"
+
+	timer.Observe(llmpkg.StreamChunk{Provider: "openai", Model: "gpt-4o"}, start.Add(50*time.Millisecond))
+	timer.Observe(llmpkg.StreamChunk{Delta: types.Message{ReasoningContent: &reasoning}}, start.Add(500*time.Millisecond))
+	timer.Observe(llmpkg.StreamChunk{Delta: types.Message{Content: "a"}}, start.Add(600*time.Millisecond))
+	timer.Observe(llmpkg.StreamChunk{Delta: types.Message{Content: "b"}}, start.Add(900*time.Millisecond))
+	timer.Observe(llmpkg.StreamChunk{FinishReason: "stop"}, start.Add(950*time.Millisecond))
+	timing := timer.Finish(start.Add(time.Second))
+
+	assert.Equal(t, "openai", timing.Provider)
+	assert.Equal(t, "gpt-4o", timing.Model)
+	assert.Equal(t, 500*time.Millisecond, timing.TimeToFirstToken())
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 300 * time.Millisecond}, timing.InterTokenGaps)
+	assert.Equal(t, 200*time.Millisecond, timing.AvgInterTokenLatency())
+	assert.InDelta(t, 7.5, timing.TokensPerSecond(), 1e-9)
+	assert.False(t, timing.Failed)
+}
+
+func TestStreamTimer_NoTokens(t *testing.T) {
+	timer := NewStreamTimer(time.Now())
+	timer.Observe(llmpkg.StreamChunk{Err: types.NewServiceUnavailableError("down")}, time.Now())
+	timing := timer.Finish(time.Now())
+
+	assert.True(t, timing.Failed)
+	assert.Zero(t, timing.TimeToFirstToken())
+	assert.Zero(t, timing.TokensPerSecond())
+}
+
+func TestInstrumentStream_ForwardsAndReports(t *testing.T) {
+	source := make(chan llmpkg.StreamChunk, 2)
+	source <- llmpkg.StreamChunk{Delta: types.Message{Content: "hi"}}
+	source <- llmpkg.StreamChunk{Delta: types.Message{Content: "!"}}
+	close(source)
+
+	done := make(chan StreamTiming, 1)
+	out := InstrumentStream(context.Background(), time.Now(), source, func(timing StreamTiming) { done <- timing })
+	var content string
+	for chunk := range out {
+		content += chunk.Delta.Content
+	}
+	assert.Equal(t, "hi!", content)
+	assert.Equal(t, 2, (<-done).TokenEvents)
+}
+
+func TestInstrumentStream_DrainsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := make(chan llmpkg.StreamChunk, 3)
+	for i := 0; i < 3; i++ {
+		source <- llmpkg.StreamChunk{Delta: types.Message{Content: "x"}}
+	}
+	close(source)
+
+	done := make(chan StreamTiming, 1)
+	cancel()
+	InstrumentStream(ctx, time.Now(), source, func(timing StreamTiming) { done <- timing })
+
+	select {
+	case timing := <-done:
+		assert.Equal(t, 3, timing.TokenEvents)
+	case <-time.After(time.Second):
+		t.Fatal("instrumented stream did not drain source after cancel")
+	}
+}
+
+func TestStreamLatencyTracker_EWMA(t *testing.T) {
+	tracker := NewStreamLatencyTracker(0.5)
+	start := time.Unix(0, 0)
+
+	tracker.Record("OpenAI", "gpt-4o", StreamTiming{Start: start, FirstToken: start.Add(time.Second), LastToken: start.Add(2 * time.Second), End: start.Add(2 * time.Second), TokenEvents: 21})
+	tracker.Record("openai", "gpt-4o", StreamTiming{Start: start, FirstToken: start.Add(3 * time.Second), LastToken: start.Add(4 * time.Second), End: start.Add(4 * time.Second), TokenEvents: 11})
+	tracker.Record("openai", "gpt-4o", StreamTiming{Start: start, End: start.Add(time.Second), Failed: true})
+
+	stats, ok := tracker.Get("openai", "gpt-4o")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), stats.Samples)
+	assert.Equal(t, 2*time.Second, stats.AvgTimeToFirstToken)
+	assert.Equal(t, 75*time.Millisecond, stats.AvgInterTokenLatency)
+	assert.InDelta(t, 16, stats.TokensPerSecond, 1e-9)
+
+	_, ok = tracker.Get("anthropic", "claude")
+	assert.False(t, ok)
+}
+
+func TestMetrics_RecordStreamTiming(t *testing.T) {
+	m, err := NewMetrics()
+	require.NoError(t, err)
+	start := time.Now()
+	assert.NotPanics(t, func() {
+		m.RecordStreamTiming(context.Background(), RequestAttrs{Provider: "openai", Model: "gpt-4o"}, StreamTiming{
+			Start:          start,
+			FirstToken:     start.Add(200 * time.Millisecond),
+			LastToken:      start.Add(time.Second),
+			End:            start.Add(time.Second),
+			TokenEvents:    9,
+			InterTokenGaps: []time.Duration{100 * time.Millisecond},
+		})
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/BaSui01/agentflow/types"
 	"go.opentelemetry.io/otel/attribute"
 	oteltrace "go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -278,30 +279,39 @@ func (t *Tracer) TraceToolCall(ctx context.Context, toolName string, input any,
 type ConversationTracer struct {
 	tracer        *Tracer
 	conversations map[string]*ConversationTrace
+	costCalc      *CostCalculator
+	judge         ConversationQualityJudge
 	mu            sync.RWMutex
 }
 
 // ConversationTrace 表示追踪的对话.
 type ConversationTrace struct {
-	ID        string         `json:"id"`
-	RunID     string         `json:"run_id"`
-	Turns     []*TurnTrace   `json:"turns"`
-	StartTime time.Time      `json:"start_time"`
-	EndTime   time.Time      `json:"end_time,omitempty"`
-	Metadata  map[string]any `json:"metadata,omitempty"`
+	ID        string                 `json:"id"`
+	RunID     string                 `json:"run_id"`
+	TenantID  string                 `json:"tenant_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	Turns     []*TurnTrace           `json:"turns"`
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time,omitempty"`
+	Metadata  map[string]any         `json:"metadata,omitempty"`
+	Rollup    *ConversationRollup    `json:"rollup,omitempty"`
+	Feedback  []ConversationFeedback `json:"feedback,omitempty"`
 }
 
 // TurnTrace 表示单次对话回合.
 type TurnTrace struct {
-	ID         string         `json:"id"`
-	TurnNumber int            `json:"turn_number"`
-	UserInput  string         `json:"user_input"`
-	Response   string         `json:"response"`
-	Model      string         `json:"model"`
-	Tokens     TokenUsage     `json:"tokens"`
-	Latency    time.Duration  `json:"latency"`
-	ToolCalls  []TurnToolCall `json:"tool_calls,omitempty"`
-	Timestamp  time.Time      `json:"timestamp"`
+	ID           string         `json:"id"`
+	TurnNumber   int            `json:"turn_number"`
+	UserInput    string         `json:"user_input"`
+	Response     string         `json:"response"`
+	Provider     string         `json:"provider,omitempty"`
+	Model        string         `json:"model"`
+	Tokens       TokenUsage     `json:"tokens"`
+	Cost         float64        `json:"cost,omitempty"`
+	Latency      time.Duration  `json:"latency"`
+	ToolCalls    []TurnToolCall `json:"tool_calls,omitempty"`
+	QualityScore *float64       `json:"quality_score,omitempty"`
+	Timestamp    time.Time      `json:"timestamp"`
 }
 
 // TurnToolCall 表示回合内的工具调用.
@@ -313,12 +323,31 @@ type TurnToolCall struct {
 	Error    string        `json:"error,omitempty"`
 }
 
+// ConversationTracerOption 配置对话追踪器.
+type ConversationTracerOption func(*ConversationTracer)
+
+// WithConversationCostCalculator 在回合未给出费用时按模型单价计算.
+func WithConversationCostCalculator(calc *CostCalculator) ConversationTracerOption {
+	return func(c *ConversationTracer) { c.costCalc = calc }
+}
+
+// WithConversationQualityJudge 在对话结束时对整段对话打质量分.
+func WithConversationQualityJudge(judge ConversationQualityJudge) ConversationTracerOption {
+	return func(c *ConversationTracer) { c.judge = judge }
+}
+
 // NewConversationTracer 创建新的对话追踪器.
-func NewConversationTracer(tracer *Tracer) *ConversationTracer {
-	return &ConversationTracer{
+func NewConversationTracer(tracer *Tracer, opts ...ConversationTracerOption) *ConversationTracer {
+	c := &ConversationTracer{
 		tracer:        tracer,
 		conversations: make(map[string]*ConversationTrace),
 	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c
 }
 
 // StartConversation 开始追踪对话.
@@ -328,10 +357,14 @@ func (c *ConversationTracer) StartConversation(ctx context.Context, name string)
 	conv := &ConversationTrace{
 		ID:        fmt.Sprintf("conv_%d", time.Now().UnixNano()),
 		RunID:     run.ID,
+		TraceID:   conversationTraceID(ctx),
 		Turns:     make([]*TurnTrace, 0),
 		StartTime: time.Now(),
 		Metadata:  make(map[string]any),
 	}
+	if tenantID, ok := types.TenantID(ctx); ok {
+		conv.TenantID = tenantID
+	}
 
 	c.mu.Lock()
 	c.conversations[conv.ID] = conv
@@ -345,6 +378,25 @@ const convIDKey contextKey = "conv_id"
 
 // TraceTurn 追踪对话的一个回合.
 func (c *ConversationTracer) TraceTurn(ctx context.Context, userInput string, fn func() (string, TokenUsage, error)) (*TurnTrace, error) {
+	return c.TraceTurnResult(ctx, userInput, func() (TurnResult, error) {
+		response, tokens, err := fn()
+		return TurnResult{Response: response, Tokens: tokens}, err
+	})
+}
+
+// TurnResult 是一个回合的完整结果，用于汇总费用、工具调用和质量分.
+type TurnResult struct {
+	Response     string
+	Provider     string
+	Model        string
+	Tokens       TokenUsage
+	Cost         float64 // 为 0 且配置了 CostCalculator 时按模型单价计算
+	ToolCalls    []TurnToolCall
+	QualityScore *float64 // 回合级评审分（0-1），可选
+}
+
+// TraceTurnResult 追踪对话的一个回合，并记录模型、费用与工具调用.
+func (c *ConversationTracer) TraceTurnResult(ctx context.Context, userInput string, fn func() (TurnResult, error)) (*TurnTrace, error) {
 	convID, _ := ctx.Value(convIDKey).(string)
 
 	c.mu.RLock()
@@ -363,23 +415,32 @@ func (c *ConversationTracer) TraceTurn(ctx context.Context, userInput string, fn
 	}
 
 	start := time.Now()
-	response, tokens, err := fn()
+	result, err := fn()
 	turn.Latency = time.Since(start)
-	turn.Response = response
-	turn.Tokens = tokens
+	turn.Response = result.Response
+	turn.Provider = result.Provider
+	turn.Model = result.Model
+	turn.Tokens = result.Tokens
+	turn.Cost = result.Cost
+	turn.ToolCalls = result.ToolCalls
+	turn.QualityScore = result.QualityScore
+	if turn.Cost == 0 && c.costCalc != nil && turn.Model != "" {
+		turn.Cost = c.costCalc.Calculate(turn.Provider, turn.Model, turn.Tokens.Prompt, turn.Tokens.Completion)
+	}
 
 	if err != nil {
 		return turn, err
 	}
 
 	c.mu.Lock()
+	turn.TurnNumber = len(conv.Turns) + 1
 	conv.Turns = append(conv.Turns, turn)
 	c.mu.Unlock()
 
 	return turn, nil
 }
 
-// EndConversation 结束对话追踪.
+// EndConversation 结束对话追踪，并生成对话级汇总（费用、Token、工具调用、质量分）.
 func (c *ConversationTracer) EndConversation(ctx context.Context, convID string) error {
 	c.mu.Lock()
 	conv, ok := c.conversations[convID]
@@ -392,6 +453,20 @@ func (c *ConversationTracer) EndConversation(ctx context.Context, convID string)
 		return fmt.Errorf("conversation not found: %s", convID)
 	}
 
+	var judged *float64
+	if c.judge != nil {
+		score, err := c.judge.JudgeConversation(ctx, conv)
+		if err != nil {
+			c.tracer.logger.Warn("conversation quality judge failed", zap.String("conversation_id", convID), zap.Error(err))
+		} else {
+			judged = &score
+		}
+	}
+
+	c.mu.Lock()
+	conv.Rollup = buildConversationRollup(conv, judged)
+	c.mu.Unlock()
+
 	return c.tracer.EndRun(ctx, conv.RunID, "completed")
 }
 
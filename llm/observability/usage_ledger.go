@@ -0,0 +1,218 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// UsageDayLayout 用量按 UTC 自然日聚合时的日期格式。
+const UsageDayLayout = "2006-01-02"
+
+// MetadataKeyTenantID 是请求 metadata 中租户 ID 的键；上下文中无租户时作为回退。
+const MetadataKeyTenantID = "tenant_id"
+
+// UsageRecord 是一次 LLM 调用的用量明细，由 UsageLedger 写入、由汇总任务折叠为日报。
+type UsageRecord struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Timestamp        time.Time `gorm:"not null;index" json:"timestamp"`
+	Day              string    `gorm:"size:10;not null;index" json:"day"`
+	TenantID         string    `gorm:"size:120;index" json:"tenant_id"`
+	Provider         string    `gorm:"size:100" json:"provider"`
+	Model            string    `gorm:"size:200" json:"model"`
+	Capability       string    `gorm:"size:50" json:"capability"`
+	TraceID          string    `gorm:"size:100" json:"trace_id,omitempty"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	CachedTokens     int64     `json:"cached_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CacheSavingsUSD  float64   `json:"cache_savings_usd"`
+}
+
+func (UsageRecord) TableName() string {
+	return "sc_usage_records"
+}
+
+// UsageDailyRollup 是按 日/租户/provider/模型 汇总的用量日报。
+type UsageDailyRollup struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Day              string    `gorm:"size:10;not null;uniqueIndex:idx_sc_usage_daily_key" json:"day"`
+	TenantID         string    `gorm:"size:120;not null;default:'';uniqueIndex:idx_sc_usage_daily_key" json:"tenant_id"`
+	Provider         string    `gorm:"size:100;not null;default:'';uniqueIndex:idx_sc_usage_daily_key" json:"provider"`
+	Model            string    `gorm:"size:200;not null;default:'';uniqueIndex:idx_sc_usage_daily_key" json:"model"`
+	Requests         int64     `json:"requests"`
+	CacheHits        int64     `json:"cache_hits"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	CachedTokens     int64     `json:"cached_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CacheSavingsUSD  float64   `json:"cache_savings_usd"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (UsageDailyRollup) TableName() string {
+	return "sc_usage_daily"
+}
+
+// UsageFilter 用量查询条件；空字段不参与过滤，From/To 为闭区间的 UTC 日期。
+type UsageFilter struct {
+	TenantID string
+	Provider string
+	Model    string
+	From     time.Time
+	To       time.Time
+}
+
+// UsageBucket 是一个 日/租户/provider/模型 维度上的用量汇总。
+type UsageBucket struct {
+	Day              string  `json:"day"`
+	TenantID         string  `json:"tenant_id"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Requests         int64   `json:"requests"`
+	CacheHits        int64   `json:"cache_hits"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CacheSavingsUSD  float64 `json:"cache_savings_usd"`
+}
+
+// Add 将 other 的计数累加到 b。
+func (b *UsageBucket) Add(other UsageBucket) {
+	b.Requests += other.Requests
+	b.CacheHits += other.CacheHits
+	b.PromptTokens += other.PromptTokens
+	b.CompletionTokens += other.CompletionTokens
+	b.TotalTokens += other.TotalTokens
+	b.CachedTokens += other.CachedTokens
+	b.CostUSD += other.CostUSD
+	b.CacheSavingsUSD += other.CacheSavingsUSD
+}
+
+// UsageStore 持久化用量明细与日报。
+type UsageStore interface {
+	// AppendUsage 写入一条用量明细。
+	AppendUsage(ctx context.Context, record *UsageRecord) error
+	// QueryUsage 合并日报与尚未汇总的明细，按 日/租户/provider/模型 返回汇总，按日期升序。
+	QueryUsage(ctx context.Context, filter UsageFilter) ([]UsageBucket, error)
+	// RollupUsage 将 before 之前的明细累加进日报并删除已汇总的明细，返回折叠的明细条数。
+	RollupUsage(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UsageLedger 将网关落账写入 UsageStore，按租户/模型/日统计 token、请求数、费用与缓存节省。
+type UsageLedger struct {
+	store      UsageStore
+	calculator *CostCalculator
+	logger     *zap.Logger
+}
+
+// NewUsageLedger 创建用量落账器；calculator 为 nil 时使用默认价格表。
+func NewUsageLedger(store UsageStore, calculator *CostCalculator, logger *zap.Logger) *UsageLedger {
+	if calculator == nil {
+		calculator = NewCostCalculator()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &UsageLedger{store: store, calculator: calculator, logger: logger.With(zap.String("component", "usage_ledger"))}
+}
+
+// Store 返回底层用量存储。
+func (l *UsageLedger) Store() UsageStore {
+	return l.store
+}
+
+// Record 实现 Ledger。租户取自上下文，缺失时回退到 metadata 中的 tenant_id；
+// 网关未给出费用时按价格表补算。
+func (l *UsageLedger) Record(ctx context.Context, entry LedgerEntry) error {
+	if l == nil || l.store == nil {
+		return nil
+	}
+	ts := entry.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	ts = ts.UTC()
+
+	tenantID, _ := types.TenantID(ctx)
+	if tenantID == "" && entry.Metadata != nil {
+		tenantID = strings.TrimSpace(entry.Metadata[MetadataKeyTenantID])
+	}
+
+	usage := entry.Usage
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	cost := entry.Cost.AmountUSD
+	if cost == 0 {
+		cost = l.calculator.CalculateWithReasoning(entry.Provider, entry.Model,
+			usage.PromptTokens, usage.CachedTokens, usage.CacheCreationTokens, usage.CompletionTokens, usage.ReasoningTokens)
+	}
+
+	record := &UsageRecord{
+		Timestamp:        ts,
+		Day:              ts.Format(UsageDayLayout),
+		TenantID:         tenantID,
+		Provider:         entry.Provider,
+		Model:            entry.Model,
+		Capability:       entry.Capability,
+		TraceID:          entry.TraceID,
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		TotalTokens:      int64(total),
+		CachedTokens:     int64(usage.CachedTokens),
+		CostUSD:          cost,
+		CacheSavingsUSD:  l.calculator.CacheSavings(entry.Provider, entry.Model, usage.CachedTokens),
+	}
+	if err := l.store.AppendUsage(ctx, record); err != nil {
+		return fmt.Errorf("append usage record: %w", err)
+	}
+	return nil
+}
+
+// UsageDayStart 返回 t 所在 UTC 自然日的零点。
+func UsageDayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FanoutLedger 将每条落账依次写入多个 Ledger，返回遇到的第一个错误。
+type FanoutLedger struct {
+	ledgers []Ledger
+}
+
+// NewFanoutLedger 组合多个 Ledger，忽略 nil；仅剩一个时直接返回它。
+func NewFanoutLedger(ledgers ...Ledger) Ledger {
+	out := make([]Ledger, 0, len(ledgers))
+	for _, l := range ledgers {
+		if l != nil {
+			out = append(out, l)
+		}
+	}
+	switch len(out) {
+	case 0:
+		return NewNoopLedger()
+	case 1:
+		return out[0]
+	}
+	return &FanoutLedger{ledgers: out}
+}
+
+func (f *FanoutLedger) Record(ctx context.Context, entry LedgerEntry) error {
+	var firstErr error
+	for _, l := range f.ledgers {
+		if err := l.Record(ctx, entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,139 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openUsageTestStore(t *testing.T) *GormUsageStore {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&UsageRecord{}, &UsageDailyRollup{}))
+	return NewGormUsageStore(db)
+}
+
+func TestUsageLedger_RecordAndQuery(t *testing.T) {
+	store := openUsageTestStore(t)
+	ledger := NewUsageLedger(store, nil, nil)
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	tenantCtx := types.WithTenantID(context.Background(), "t1")
+	require.NoError(t, ledger.Record(tenantCtx, LedgerEntry{
+		Timestamp: day1, Provider: "openai", Model: "gpt-5.4",
+		Usage: core.Usage{PromptTokens: 2000, CompletionTokens: 500, CachedTokens: 1000},
+	}))
+	require.NoError(t, ledger.Record(tenantCtx, LedgerEntry{
+		Timestamp: day1.Add(time.Hour), Provider: "openai", Model: "gpt-5.4",
+		Usage: core.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+		Cost:  core.Cost{AmountUSD: 0.5},
+	}))
+	require.NoError(t, ledger.Record(context.Background(), LedgerEntry{
+		Timestamp: day2, Provider: "openai", Model: "gpt-5.4",
+		Usage:    core.Usage{PromptTokens: 10, CompletionTokens: 10},
+		Metadata: map[string]string{MetadataKeyTenantID: "t2"},
+	}))
+
+	buckets, err := store.QueryUsage(context.Background(), UsageFilter{TenantID: "t1"})
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	b := buckets[0]
+	assert.Equal(t, "2026-03-01", b.Day)
+	assert.Equal(t, int64(2), b.Requests)
+	assert.Equal(t, int64(1), b.CacheHits)
+	assert.Equal(t, int64(2650), b.TotalTokens)
+	assert.Equal(t, int64(1000), b.CachedTokens)
+	assert.InDelta(t, 0.00225, b.CacheSavingsUSD, 1e-9)
+	assert.Greater(t, b.CostUSD, 0.5, "missing gateway cost is priced from the catalog")
+
+	buckets, err = store.QueryUsage(context.Background(), UsageFilter{From: day2, To: day2})
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, "t2", buckets[0].TenantID, "metadata tenant is used when context has none")
+}
+
+func TestGormUsageStore_RollupMergesWithPending(t *testing.T) {
+	store := openUsageTestStore(t)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	appendAt := func(ts time.Time, cost float64) {
+		require.NoError(t, store.AppendUsage(ctx, &UsageRecord{
+			Timestamp: ts, Day: ts.Format(UsageDayLayout), TenantID: "t1",
+			Provider: "openai", Model: "gpt-5.4", TotalTokens: 10, CostUSD: cost,
+		}))
+	}
+	appendAt(day.Add(time.Hour), 1)
+	appendAt(day.Add(2*time.Hour), 2)
+
+	folded, err := store.RollupUsage(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), folded)
+
+	// 再次汇总同一天的迟到明细会累加到已有日报行
+	appendAt(day.Add(3*time.Hour), 4)
+	folded, err = store.RollupUsage(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), folded)
+
+	// 尚未汇总的明细在查询时与日报合并
+	appendAt(day.Add(4*time.Hour), 8)
+	buckets, err := store.QueryUsage(ctx, UsageFilter{})
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, int64(4), buckets[0].Requests)
+	assert.Equal(t, int64(40), buckets[0].TotalTokens)
+	assert.InDelta(t, 15.0, buckets[0].CostUSD, 1e-9)
+
+	var rollups int64
+	require.NoError(t, store.db.Model(&UsageDailyRollup{}).Count(&rollups).Error)
+	assert.Equal(t, int64(1), rollups)
+}
+
+func TestUsageRollupJob_RunOnceWaitsForSettle(t *testing.T) {
+	store := openUsageTestStore(t)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.AppendUsage(ctx, &UsageRecord{Timestamp: day.Add(23 * time.Hour), Day: "2026-03-01"}))
+
+	job := NewUsageRollupJob(store, UsageRollupConfig{Settle: time.Hour}, nil)
+	job.now = func() time.Time { return day.Add(24*time.Hour + 30*time.Minute) }
+	folded, err := job.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, folded, "day is not rolled up before the settle delay")
+
+	job.now = func() time.Time { return day.Add(25*time.Hour + time.Minute) }
+	folded, err = job.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), folded)
+}
+
+func TestNewFanoutLedger(t *testing.T) {
+	assert.Equal(t, NewNoopLedger(), NewFanoutLedger())
+
+	a, b := &countingLedger{}, &countingLedger{}
+	assert.Same(t, a, NewFanoutLedger(nil, a))
+
+	fan := NewFanoutLedger(a, nil, b)
+	require.NoError(t, fan.Record(context.Background(), LedgerEntry{}))
+	assert.Equal(t, 1, a.n)
+	assert.Equal(t, 1, b.n)
+}
+
+type countingLedger struct{ n int }
+
+func (c *countingLedger) Record(context.Context, LedgerEntry) error {
+	c.n++
+	return nil
+}
@@ -0,0 +1,273 @@
+package observability
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// usageAggregateColumns 将明细聚合为 UsageBucket 的 SELECT 列。
+const usageAggregateColumns = "day, tenant_id, provider, model, " +
+	"COUNT(*) AS requests, " +
+	"SUM(CASE WHEN cached_tokens > 0 THEN 1 ELSE 0 END) AS cache_hits, " +
+	"SUM(prompt_tokens) AS prompt_tokens, " +
+	"SUM(completion_tokens) AS completion_tokens, " +
+	"SUM(total_tokens) AS total_tokens, " +
+	"SUM(cached_tokens) AS cached_tokens, " +
+	"SUM(cost_usd) AS cost_usd, " +
+	"SUM(cache_savings_usd) AS cache_savings_usd"
+
+const usageGroupColumns = "day, tenant_id, provider, model"
+
+// GormUsageStore 基于 gorm 的 UsageStore 实现，明细写入 sc_usage_records，日报写入 sc_usage_daily。
+type GormUsageStore struct {
+	db *gorm.DB
+}
+
+// NewGormUsageStore 创建基于 gorm 的用量存储。
+func NewGormUsageStore(db *gorm.DB) *GormUsageStore {
+	return &GormUsageStore{db: db}
+}
+
+func (s *GormUsageStore) AppendUsage(ctx context.Context, record *UsageRecord) error {
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *GormUsageStore) QueryUsage(ctx context.Context, filter UsageFilter) ([]UsageBucket, error) {
+	db := s.db.WithContext(ctx)
+
+	var daily []UsageDailyRollup
+	if err := applyUsageFilter(db.Model(&UsageDailyRollup{}), filter).Find(&daily).Error; err != nil {
+		return nil, err
+	}
+	var pending []UsageBucket
+	err := applyUsageFilter(db.Model(&UsageRecord{}), filter).
+		Select(usageAggregateColumns).
+		Group(usageGroupColumns).
+		Scan(&pending).Error
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[usageBucketKey]*UsageBucket, len(daily)+len(pending))
+	add := func(b UsageBucket) {
+		key := usageBucketKey{day: b.Day, tenantID: b.TenantID, provider: b.Provider, model: b.Model}
+		if existing, ok := merged[key]; ok {
+			existing.Add(b)
+			return
+		}
+		copied := b
+		merged[key] = &copied
+	}
+	for _, row := range daily {
+		add(row.bucket())
+	}
+	for _, b := range pending {
+		add(b)
+	}
+
+	out := make([]UsageBucket, 0, len(merged))
+	for _, b := range merged {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return out, nil
+}
+
+func (s *GormUsageStore) RollupUsage(ctx context.Context, before time.Time) (int64, error) {
+	var folded int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 以快照时的最大 ID 为界，避免删除聚合之后才写入的明细。
+		var maxID sql.NullInt64
+		if err := tx.Model(&UsageRecord{}).Where("timestamp < ?", before).
+			Select("MAX(id)").Row().Scan(&maxID); err != nil {
+			return err
+		}
+		if !maxID.Valid {
+			return nil
+		}
+
+		var buckets []UsageBucket
+		err := tx.Model(&UsageRecord{}).
+			Where("timestamp < ? AND id <= ?", before, maxID.Int64).
+			Select(usageAggregateColumns).
+			Group(usageGroupColumns).
+			Scan(&buckets).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, b := range buckets {
+			if err := mergeDailyRollup(tx, b, now); err != nil {
+				return err
+			}
+		}
+		result := tx.Where("timestamp < ? AND id <= ?", before, maxID.Int64).Delete(&UsageRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		folded = result.RowsAffected
+		return nil
+	})
+	return folded, err
+}
+
+// mergeDailyRollup 将 b 累加到已有日报行，不存在时新建。
+func mergeDailyRollup(tx *gorm.DB, b UsageBucket, now time.Time) error {
+	var row UsageDailyRollup
+	err := tx.Where("day = ? AND tenant_id = ? AND provider = ? AND model = ?", b.Day, b.TenantID, b.Provider, b.Model).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&UsageDailyRollup{
+			Day:              b.Day,
+			TenantID:         b.TenantID,
+			Provider:         b.Provider,
+			Model:            b.Model,
+			Requests:         b.Requests,
+			CacheHits:        b.CacheHits,
+			PromptTokens:     b.PromptTokens,
+			CompletionTokens: b.CompletionTokens,
+			TotalTokens:      b.TotalTokens,
+			CachedTokens:     b.CachedTokens,
+			CostUSD:          b.CostUSD,
+			CacheSavingsUSD:  b.CacheSavingsUSD,
+			UpdatedAt:        now,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&row).UpdateColumns(map[string]any{
+		"requests":          gorm.Expr("requests + ?", b.Requests),
+		"cache_hits":        gorm.Expr("cache_hits + ?", b.CacheHits),
+		"prompt_tokens":     gorm.Expr("prompt_tokens + ?", b.PromptTokens),
+		"completion_tokens": gorm.Expr("completion_tokens + ?", b.CompletionTokens),
+		"total_tokens":      gorm.Expr("total_tokens + ?", b.TotalTokens),
+		"cached_tokens":     gorm.Expr("cached_tokens + ?", b.CachedTokens),
+		"cost_usd":          gorm.Expr("cost_usd + ?", b.CostUSD),
+		"cache_savings_usd": gorm.Expr("cache_savings_usd + ?", b.CacheSavingsUSD),
+		"updated_at":        now,
+	}).Error
+}
+
+func applyUsageFilter(db *gorm.DB, filter UsageFilter) *gorm.DB {
+	if filter.TenantID != "" {
+		db = db.Where("tenant_id = ?", filter.TenantID)
+	}
+	if filter.Provider != "" {
+		db = db.Where("provider = ?", filter.Provider)
+	}
+	if filter.Model != "" {
+		db = db.Where("model = ?", filter.Model)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("day >= ?", filter.From.UTC().Format(UsageDayLayout))
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("day <= ?", filter.To.UTC().Format(UsageDayLayout))
+	}
+	return db
+}
+
+type usageBucketKey struct {
+	day, tenantID, provider, model string
+}
+
+func (r UsageDailyRollup) bucket() UsageBucket {
+	return UsageBucket{
+		Day:              r.Day,
+		TenantID:         r.TenantID,
+		Provider:         r.Provider,
+		Model:            r.Model,
+		Requests:         r.Requests,
+		CacheHits:        r.CacheHits,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		TotalTokens:      r.TotalTokens,
+		CachedTokens:     r.CachedTokens,
+		CostUSD:          r.CostUSD,
+		CacheSavingsUSD:  r.CacheSavingsUSD,
+	}
+}
+
+// UsageRollupConfig 控制日报汇总任务。
+type UsageRollupConfig struct {
+	// Interval 检查间隔，默认 1 小时。
+	Interval time.Duration
+	// Settle 自然日结束后等待迟到明细（如长流式响应）的时间，默认 15 分钟。
+	Settle time.Duration
+}
+
+// UsageRollupJob 周期性地将已结束自然日的明细折叠为日报。
+type UsageRollupJob struct {
+	store  UsageStore
+	config UsageRollupConfig
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewUsageRollupJob 创建日报汇总任务。
+func NewUsageRollupJob(store UsageStore, config UsageRollupConfig, logger *zap.Logger) *UsageRollupJob {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.Settle <= 0 {
+		config.Settle = 15 * time.Minute
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &UsageRollupJob{store: store, config: config, logger: logger.With(zap.String("component", "usage_rollup")), now: time.Now}
+}
+
+// RunOnce 汇总 Settle 之前已结束的所有自然日，返回折叠的明细条数。
+func (j *UsageRollupJob) RunOnce(ctx context.Context) (int64, error) {
+	before := UsageDayStart(j.now().Add(-j.config.Settle))
+	folded, err := j.store.RollupUsage(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if folded > 0 {
+		j.logger.Info("usage rolled up", zap.Int64("records", folded), zap.String("before", before.Format(UsageDayLayout)))
+	}
+	return folded, nil
+}
+
+// Start 立即执行一次汇总，之后按 Interval 周期执行，直到 ctx 取消。
+func (j *UsageRollupJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	j.runLogged(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runLogged(ctx)
+		}
+	}
+}
+
+func (j *UsageRollupJob) runLogged(ctx context.Context) {
+	if _, err := j.RunOnce(ctx); err != nil && ctx.Err() == nil {
+		j.logger.Warn("usage rollup failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,101 @@
+package claude
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	anthropicsdk "github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxClaudeCacheBreakpoints 是 Claude 单次请求允许的 cache_control 断点上限。
+const maxClaudeCacheBreakpoints = 4
+
+// applyClaudePromptCacheHint 将 PromptCacheHint 翻译为 Claude 的 cache_control 断点：
+// system 最后一个块、最后一个工具定义，以及 Breakpoints 指向的消息的最后一个内容块。
+// sourceIndex 是 convertToClaudeMessagesIndexed 返回的下标映射。
+func applyClaudePromptCacheHint(params *anthropicsdk.MessageNewParams, hint *llm.PromptCacheHint, src []types.Message, sourceIndex []int) *types.Error {
+	if params == nil || hint == nil {
+		return nil
+	}
+
+	breakpoint := claudeCacheBreakpoint(hint.TTL)
+	marked := map[int]struct{}{}
+	systemCached := false
+	count := 0
+
+	if hint.System && len(params.System) > 0 {
+		params.System[len(params.System)-1].CacheControl = breakpoint
+		systemCached = true
+		count++
+	}
+	if hint.Tools && len(params.Tools) > 0 {
+		if cc := params.Tools[len(params.Tools)-1].GetCacheControl(); cc != nil {
+			*cc = breakpoint
+			count++
+		}
+	}
+
+	for _, idx := range hint.Breakpoints {
+		if idx < 0 || idx >= len(src) {
+			return claudePromptCacheError(fmt.Sprintf("Claude prompt cache breakpoint %d is out of range [0,%d)", idx, len(src)))
+		}
+		target := resolveClaudeBreakpointTarget(sourceIndex, idx)
+		if target < 0 {
+			// system 消息上的断点等价于缓存整个 system prompt。
+			if len(params.System) > 0 && !systemCached {
+				params.System[len(params.System)-1].CacheControl = breakpoint
+				systemCached = true
+				count++
+			}
+			continue
+		}
+		if _, ok := marked[target]; ok {
+			continue
+		}
+		content := params.Messages[target].Content
+		if len(content) == 0 {
+			continue
+		}
+		if cc := content[len(content)-1].GetCacheControl(); cc != nil {
+			*cc = breakpoint
+			marked[target] = struct{}{}
+			count++
+		}
+	}
+
+	if count > maxClaudeCacheBreakpoints {
+		return claudePromptCacheError(fmt.Sprintf("Claude allows at most %d cache breakpoints per request, got %d", maxClaudeCacheBreakpoints, count))
+	}
+	return nil
+}
+
+// resolveClaudeBreakpointTarget 找到源消息 idx 及其之前最近一条被保留的 Claude 消息下标。
+func resolveClaudeBreakpointTarget(sourceIndex []int, idx int) int {
+	for i := idx; i >= 0 && i < len(sourceIndex); i-- {
+		if sourceIndex[i] >= 0 {
+			return sourceIndex[i]
+		}
+	}
+	return -1
+}
+
+// claudeCacheBreakpoint 将期望 TTL 就近映射到 Claude 支持的 5m / 1h 档位。
+func claudeCacheBreakpoint(ttl time.Duration) anthropicsdk.CacheControlEphemeralParam {
+	ccp := anthropicsdk.NewCacheControlEphemeralParam()
+	if ttl > 5*time.Minute {
+		ccp.TTL = anthropicsdk.CacheControlEphemeralTTLTTL1h
+	}
+	return ccp
+}
+
+func claudePromptCacheError(msg string) *types.Error {
+	return &types.Error{
+		Code:       llm.ErrInvalidRequest,
+		Message:    msg,
+		HTTPStatus: http.StatusBadRequest,
+		Provider:   "claude",
+	}
+}
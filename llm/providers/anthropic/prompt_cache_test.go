@@ -0,0 +1,63 @@
+package claude
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	anthropicsdk "github.com/anthropics/anthropic-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyClaudePromptCacheHint_MarksSystemToolsAndMessages(t *testing.T) {
+	msgs := []types.Message{
+		{Role: llm.RoleSystem, Content: "You are helpful"},
+		{Role: llm.RoleUser, Content: "long document"},
+		{Role: llm.RoleTool, Content: "dropped: no call id"},
+		{Role: llm.RoleAssistant, Content: "ack"},
+	}
+	system, claudeMsgs, sourceIndex := convertToClaudeMessagesIndexed(msgs)
+	params := anthropicsdk.MessageNewParams{
+		System:   system,
+		Messages: claudeMsgs,
+		Tools:    convertToClaudeTools([]types.ToolSchema{{Name: "search"}}, nil),
+	}
+
+	hint := &llm.PromptCacheHint{
+		TTL:         time.Hour,
+		System:      true,
+		Tools:       true,
+		Breakpoints: []int{2},
+	}
+	require.Nil(t, applyClaudePromptCacheHint(&params, hint, msgs, sourceIndex))
+
+	assert.Equal(t, anthropicsdk.CacheControlEphemeralTTLTTL1h, params.System[0].CacheControl.TTL)
+	assert.Equal(t, anthropicsdk.CacheControlEphemeralTTLTTL1h, params.Tools[0].GetCacheControl().TTL)
+	// Breakpoint on the dropped tool message falls back to the preceding user message.
+	assert.Equal(t, anthropicsdk.CacheControlEphemeralTTLTTL1h, params.Messages[0].Content[0].GetCacheControl().TTL)
+	assert.Empty(t, params.Messages[1].Content[0].GetCacheControl().TTL)
+}
+
+func TestApplyClaudePromptCacheHint_RejectsInvalidBreakpoints(t *testing.T) {
+	msgs := []types.Message{
+		{Role: llm.RoleUser, Content: "a"},
+		{Role: llm.RoleAssistant, Content: "b"},
+		{Role: llm.RoleUser, Content: "c"},
+		{Role: llm.RoleAssistant, Content: "d"},
+		{Role: llm.RoleUser, Content: "e"},
+	}
+	system, claudeMsgs, sourceIndex := convertToClaudeMessagesIndexed(msgs)
+
+	params := anthropicsdk.MessageNewParams{System: system, Messages: claudeMsgs}
+	err := applyClaudePromptCacheHint(&params, &llm.PromptCacheHint{Breakpoints: []int{9}}, msgs, sourceIndex)
+	require.NotNil(t, err)
+	assert.Equal(t, llm.ErrInvalidRequest, err.Code)
+
+	params = anthropicsdk.MessageNewParams{System: system, Messages: claudeMsgs}
+	err = applyClaudePromptCacheHint(&params, &llm.PromptCacheHint{Breakpoints: []int{0, 1, 2, 3, 4}}, msgs, sourceIndex)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Message, "at most 4")
+}
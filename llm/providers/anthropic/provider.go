@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -18,7 +19,7 @@ import (
 	llm "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/llm/middleware"
 	"github.com/BaSui01/agentflow/llm/providers"
-	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
 	"github.com/BaSui01/agentflow/types"
 	anthropicsdk "github.com/anthropics/anthropic-sdk-go"
 	anthropicsdkoption "github.com/anthropics/anthropic-sdk-go/option"
@@ -61,7 +62,7 @@ func NewClaudeProvider(cfg providers.ClaudeConfig, logger *zap.Logger) *ClaudePr
 	return &ClaudeProvider{
 		MultimodalAdapter: providerbase.NewMultimodalAdapter(providerbase.MultimodalAdapterConfig{ProviderName: "claude"}),
 		cfg:               cfg,
-		client:            tlsutil.SecureHTTPClient(timeout),
+		client:            providertransport.Client("claude", timeout),
 		logger:            logger,
 		rewriterChain: middleware.NewRewriterChain(
 			middleware.NewXMLToolRewriter(),
@@ -116,6 +117,18 @@ func (p *ClaudeProvider) HealthCheck(ctx context.Context) (*llm.HealthStatus, er
 
 func (p *ClaudeProvider) SupportsNativeFunctionCalling() bool { return true }
 
+// Capabilities 返回 Claude Messages API 的对话能力：支持图片输入与流式工具调用；
+// 不支持原生 response_format，JSON 模式由能力协商改写为提示词约束。
+func (p *ClaudeProvider) Capabilities() llm.CapabilityDescriptor {
+	return llm.CapabilityDescriptor{
+		SupportsVision:             true,
+		SupportsTools:              true,
+		SupportsJSONMode:           false,
+		SupportsStreamingToolCalls: true,
+		Modalities:                 []string{llm.ModalityText, llm.ModalityImage},
+	}
+}
+
 // Endpoints 返回该提供者使用的所有 API 端点完整 URL。
 func (p *ClaudeProvider) Endpoints() llm.ProviderEndpoints {
 	base := strings.TrimRight(p.cfg.BaseURL, "/")
@@ -366,10 +379,19 @@ func (p *ClaudeProvider) resolveAPIKey(ctx context.Context) string {
 // 2. 消息必须是 user/assistant 交替出现
 // 3. content 是数组形式，可包含文本和工具调用
 func convertToClaudeMessages(msgs []types.Message) ([]anthropicsdk.TextBlockParam, []anthropicsdk.MessageParam) {
+	systemParts, claudeMsgs, _ := convertToClaudeMessagesIndexed(msgs)
+	return systemParts, claudeMsgs
+}
+
+// convertToClaudeMessagesIndexed 与 convertToClaudeMessages 相同，额外返回
+// 源消息下标到 Claude 消息下标的映射（system 或被丢弃的消息为 -1）。
+func convertToClaudeMessagesIndexed(msgs []types.Message) ([]anthropicsdk.TextBlockParam, []anthropicsdk.MessageParam, []int) {
 	var systemParts []anthropicsdk.TextBlockParam
 	var claudeMsgs []anthropicsdk.MessageParam
+	sourceIndex := make([]int, len(msgs))
 
-	for _, m := range msgs {
+	for i, m := range msgs {
+		sourceIndex[i] = -1
 		// 提取 system 消息
 		if m.Role == llm.RoleSystem || m.Role == llm.RoleDeveloper {
 			if m.Content != "" {
@@ -402,6 +424,7 @@ func convertToClaudeMessages(msgs []types.Message) ([]anthropicsdk.TextBlockPara
 			if isError {
 				tr.IsError = anthropicsdkparam.NewOpt(true)
 			}
+			sourceIndex[i] = len(claudeMsgs)
 			claudeMsgs = append(claudeMsgs, anthropicsdk.MessageParam{
 				Role:    anthropicsdk.MessageParamRoleUser,
 				Content: []anthropicsdk.ContentBlockParamUnion{{OfToolResult: &tr}},
@@ -524,6 +547,7 @@ func convertToClaudeMessages(msgs []types.Message) ([]anthropicsdk.TextBlockPara
 					}
 				}
 			}
+			sourceIndex[i] = len(claudeMsgs)
 			claudeMsgs = append(claudeMsgs, anthropicsdk.MessageParam{
 				Role:    role,
 				Content: blocks,
@@ -531,7 +555,7 @@ func convertToClaudeMessages(msgs []types.Message) ([]anthropicsdk.TextBlockPara
 		}
 	}
 
-	return systemParts, claudeMsgs
+	return systemParts, claudeMsgs, sourceIndex
 }
 
 // appendServerToolBlock 将原始 server_tool_use / web_search_tool_result 块追加为 SDK 类型。
@@ -678,7 +702,7 @@ func (p *ClaudeProvider) Completion(ctx context.Context, req *llm.ChatRequest) (
 	req = rewrittenReq
 
 	apiKey := p.resolveAPIKey(ctx)
-	system, messages := convertToClaudeMessages(req.Messages)
+	system, messages, sourceIndex := convertToClaudeMessagesIndexed(req.Messages)
 	model := providerbase.ChooseModel(req, p.cfg.Model, defaultClaudeModel)
 	if err := validateClaudeRequest(req, model); err != nil {
 		return nil, err
@@ -723,6 +747,9 @@ func (p *ClaudeProvider) Completion(ctx context.Context, req *llm.ChatRequest) (
 	if cacheControl != nil {
 		params.CacheControl = *cacheControl
 	}
+	if err := applyClaudePromptCacheHint(&params, req.PromptCache, req.Messages, sourceIndex); err != nil {
+		return nil, err
+	}
 
 	// Claude thinking mode only supports compatible tool_choice combinations.
 	if err := validateThinkingConstraints(thinking, tc); err != nil {
@@ -758,7 +785,7 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 	req = rewrittenReq
 
 	apiKey := p.resolveAPIKey(ctx)
-	system, messages := convertToClaudeMessages(req.Messages)
+	system, messages, sourceIndex := convertToClaudeMessagesIndexed(req.Messages)
 	model := providerbase.ChooseModel(req, p.cfg.Model, defaultClaudeModel)
 	if err := validateClaudeRequest(req, model); err != nil {
 		return nil, err
@@ -803,6 +830,9 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 	if cacheControl != nil {
 		params.CacheControl = *cacheControl
 	}
+	if err := applyClaudePromptCacheHint(&params, req.PromptCache, req.Messages, sourceIndex); err != nil {
+		return nil, err
+	}
 
 	// Claude thinking mode only supports compatible tool_choice combinations.
 	if err := validateThinkingConstraints(thinking, tc); err != nil {
@@ -849,10 +879,10 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 		// Claude 流式响应累积状态
 		var currentID string
 		var currentModel string
-		var toolCallAccumulator = make(map[int]*types.ToolCall)  // 累积工具调用
-		var startUsage *claudeUsage                              // message_start 中的初始 usage
-		var webSearchBlockIndices = make(map[int]bool)           // 标记 server_tool_use / web_search_tool_result 块索引
-		var citationAccumulator = make(map[int][]claudeCitation) // 累积 text 块的引用（流式中通过 content_block_stop 发送）
+		var toolCallAccumulator = providerbase.NewToolCallDeltaAccumulator() // 累积工具调用
+		var startUsage *claudeUsage                                          // message_start 中的初始 usage
+		var webSearchBlockIndices = make(map[int]bool)                       // 标记 server_tool_use / web_search_tool_result 块索引
+		var citationAccumulator = make(map[int][]claudeCitation)             // 累积 text 块的引用（流式中通过 content_block_stop 发送）
 		type thinkingBlockState struct {
 			blockType string
 			thinking  strings.Builder
@@ -899,8 +929,7 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 					switch event.ContentBlock.Type {
 					case "tool_use":
 						// Start an empty tool-call accumulator and build arguments through input_json_delta.
-						call := providerbase.NewFunctionToolCall(event.ContentBlock.ID, event.ContentBlock.Name, nil)
-						toolCallAccumulator[event.Index] = &call
+						toolCallAccumulator.Register(strconv.Itoa(event.Index), types.ToolTypeFunction, event.ContentBlock.Name, event.ContentBlock.ID)
 					case "server_tool_use", "web_search_tool_result":
 						// 标记为搜索相关块，静默跳过其增量
 						webSearchBlockIndices[event.Index] = true
@@ -938,9 +967,7 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 						sendChunk = true
 					case "input_json_delta":
 						// 累积工具调用参数，不发送空 chunk
-						if tc, ok := toolCallAccumulator[event.Index]; ok {
-							tc.Arguments = providerbase.AppendToolJSONDelta(tc.Arguments, event.Delta.PartialJSON)
-						}
+						toolCallAccumulator.Append(strconv.Itoa(event.Index), event.Delta.PartialJSON)
 					case "thinking_delta":
 						thinking := event.Delta.Thinking
 						if state, ok := thinkingAccumulator[event.Index]; ok {
@@ -971,7 +998,7 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 
 			case "content_block_stop":
 				// 工具调用块结束，发送完整的工具调用
-				if tc, ok := toolCallAccumulator[event.Index]; ok {
+				if tc, ok := toolCallAccumulator.CompleteFunction(strconv.Itoa(event.Index)); ok {
 					select {
 					case <-ctx.Done():
 						return
@@ -982,11 +1009,10 @@ func (p *ClaudeProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 						Index:    event.Index,
 						Delta: types.Message{
 							Role:      llm.RoleAssistant,
-							ToolCalls: providerbase.ToolCallChunk(*tc),
+							ToolCalls: providerbase.ToolCallChunk(tc),
 						},
 					}:
 					}
-					delete(toolCallAccumulator, event.Index)
 				}
 
 				if state, ok := thinkingAccumulator[event.Index]; ok {
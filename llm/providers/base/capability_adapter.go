@@ -13,7 +13,7 @@ import (
 	"github.com/BaSui01/agentflow/types"
 
 	llm "github.com/BaSui01/agentflow/llm/core"
-	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
 )
 
 // HeaderFunc 自定义 HTTP 请求头构建函数。
@@ -52,7 +52,7 @@ func NewBaseCapabilityProvider(cfg CapabilityConfig) *BaseCapabilityProvider {
 	}
 	return &BaseCapabilityProvider{
 		ProviderName: cfg.Name,
-		Client:       tlsutil.SecureHTTPClient(timeout),
+		Client:       providertransport.Client(cfg.Name, timeout),
 		BaseURL:      strings.TrimRight(cfg.BaseURL, "/"),
 		APIKey:       cfg.APIKey,
 		Model:        cfg.Model,
@@ -156,8 +156,7 @@ func (p *BaseCapabilityProvider) DoRaw(ctx context.Context, method, endpoint str
 	}
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(bytes.NewReader(respBody))
-		return nil, MapHTTPError(resp.StatusCode, msg, p.ProviderName)
+		return nil, MapProviderError(resp.StatusCode, respBody, p.ProviderName)
 	}
 
 	return respBody, nil
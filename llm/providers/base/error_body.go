@@ -0,0 +1,212 @@
+package providerbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ProviderErrorBody 是从各提供者错误响应体中解析出的结构化字段
+type ProviderErrorBody struct {
+	Message string
+	// Type 为 OpenAI error.type 或 Anthropic error.type
+	Type string
+	// Code 为 OpenAI error.code
+	Code string
+	// Status 为 Gemini error.status（gRPC 状态名）
+	Status string
+	// Reason 为 Gemini ErrorInfo.reason
+	Reason string
+	Param  string
+}
+
+// identifiers 返回用于分类的全部提供者标识（小写）
+func (b ProviderErrorBody) identifiers() []string {
+	var ids []string
+	for _, v := range []string{b.Code, b.Type, b.Reason, b.Status} {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			ids = append(ids, v)
+		}
+	}
+	return ids
+}
+
+// providerCode 返回最具体的提供者错误标识
+func (b ProviderErrorBody) providerCode() string {
+	for _, v := range []string{b.Code, b.Reason, b.Type, b.Status} {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseProviderErrorBody 解析 OpenAI、Anthropic、Gemini 风格的错误响应体，
+// 同时接受 SDK 去掉 error 外层后的裸错误对象。无法识别时 ok 为 false。
+func ParseProviderErrorBody(data []byte) (body ProviderErrorBody, ok bool) {
+	trimmed := []byte(strings.TrimSpace(string(data)))
+	// Gemini REST 接口可能返回数组包裹的错误
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(trimmed, &list); err != nil || len(list) == 0 {
+			return body, false
+		}
+		trimmed = list[0]
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return body, false
+	}
+	if len(envelope.Error) > 0 {
+		// 部分兼容提供者直接返回 {"error": "message"}
+		var message string
+		if json.Unmarshal(envelope.Error, &message) == nil {
+			return ProviderErrorBody{Message: message}, message != ""
+		}
+		trimmed = envelope.Error
+	}
+
+	var payload struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+		Status  string          `json:"status"`
+		Param   string          `json:"param"`
+		Details []struct {
+			Reason string `json:"reason"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(trimmed, &payload); err != nil || payload.Message == "" {
+		return body, false
+	}
+	body = ProviderErrorBody{
+		Message: payload.Message,
+		Type:    payload.Type,
+		Status:  payload.Status,
+		Param:   payload.Param,
+	}
+	// OpenAI 的 code 是字符串，Gemini 的 code 是数字 HTTP 状态，仅保留字符串形式
+	var code string
+	if json.Unmarshal(payload.Code, &code) == nil {
+		body.Code = code
+	}
+	for _, detail := range payload.Details {
+		if detail.Reason != "" {
+			body.Reason = detail.Reason
+			break
+		}
+	}
+	return body, true
+}
+
+// ReadProviderError 读取错误响应体并映射为结构化的 types.Error
+func ReadProviderError(status int, body io.Reader, provider string) *types.Error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return MapHTTPError(status, "failed to read error response", provider)
+	}
+	return MapProviderError(status, data, provider)
+}
+
+// MapProviderError 在 HTTP 状态码映射的基础上解析提供者错误体，
+// 区分配额耗尽、密钥无效、内容过滤、上下文超长等情况并给出处置建议。
+func MapProviderError(status int, data []byte, provider string) *types.Error {
+	body, ok := ParseProviderErrorBody(data)
+	if !ok {
+		mapped := MapHTTPError(status, truncateErrorText(string(data)), provider)
+		mapped.Remediation = remediationForCode(mapped.Code)
+		return mapped
+	}
+	msg := body.Message
+	if body.Type != "" {
+		msg = fmt.Sprintf("%s (type: %s)", body.Message, body.Type)
+	}
+	mapped := MapHTTPError(status, msg, provider)
+	mapped.ProviderCode = body.providerCode()
+	if code, retryable, classified := classifyProviderError(body); classified {
+		mapped.Code = code
+		mapped.Retryable = retryable
+	}
+	mapped.Remediation = remediationForCode(mapped.Code)
+	return mapped
+}
+
+// classifyProviderError 根据提供者错误标识与消息判断统一错误码
+func classifyProviderError(body ProviderErrorBody) (types.ErrorCode, bool, bool) {
+	msg := strings.ToLower(body.Message)
+	for _, id := range body.identifiers() {
+		switch id {
+		case "insufficient_quota", "billing_hard_limit_reached", "billing_not_active", "billing_error":
+			return llm.ErrQuotaExceeded, false, true
+		case "invalid_api_key", "authentication_error", "api_key_invalid", "unauthenticated", "incorrect_api_key":
+			return llm.ErrUnauthorized, false, true
+		case "permission_error", "permission_denied":
+			return llm.ErrForbidden, false, true
+		case "content_filter", "content_policy_violation", "safety", "prohibited_content":
+			return llm.ErrContentFiltered, false, true
+		case "context_length_exceeded", "request_too_large":
+			return llm.ErrContextTooLong, false, true
+		case "model_not_found", "not_found_error":
+			return llm.ErrModelNotFound, false, true
+		case "rate_limit_exceeded", "rate_limit_error", "tokens_exceeded":
+			return llm.ErrRateLimit, true, true
+		case "resource_exhausted":
+			// Gemini 的 RESOURCE_EXHAUSTED 同时用于限流与配额，按消息区分账单类配额
+			if strings.Contains(msg, "billing") || strings.Contains(msg, "check your plan") {
+				return llm.ErrQuotaExceeded, false, true
+			}
+			return llm.ErrRateLimit, true, true
+		case "overloaded_error":
+			return llm.ErrModelOverloaded, true, true
+		case "api_error", "server_error", "internal", "unavailable":
+			return llm.ErrUpstreamError, true, true
+		}
+	}
+	switch {
+	case strings.Contains(msg, "prompt is too long") ||
+		strings.Contains(msg, "maximum context length") ||
+		strings.Contains(msg, "exceeds the maximum number of tokens"):
+		return llm.ErrContextTooLong, false, true
+	case strings.Contains(msg, "api key not valid") || strings.Contains(msg, "invalid api key"):
+		return llm.ErrUnauthorized, false, true
+	}
+	return "", false, false
+}
+
+// remediationForCode 返回统一错误码对应的处置建议
+func remediationForCode(code types.ErrorCode) types.Remediation {
+	switch code {
+	case llm.ErrUnauthorized, llm.ErrAuthentication, llm.ErrForbidden:
+		return llm.RemediationCheckCredentials
+	case llm.ErrQuotaExceeded:
+		return llm.RemediationAddCredits
+	case llm.ErrRateLimit:
+		return llm.RemediationBackoff
+	case llm.ErrModelOverloaded, llm.ErrUpstreamError, llm.ErrUpstreamTimeout, llm.ErrServiceUnavailable:
+		return llm.RemediationRetry
+	case llm.ErrContextTooLong:
+		return llm.RemediationReduceInput
+	case llm.ErrContentFiltered:
+		return llm.RemediationReviseContent
+	case llm.ErrModelNotFound:
+		return llm.RemediationSwitchModel
+	case llm.ErrInvalidRequest:
+		return llm.RemediationFixRequest
+	}
+	return ""
+}
+
+func truncateErrorText(s string) string {
+	const maxFallbackLen = 256
+	if len(s) > maxFallbackLen {
+		return s[:maxFallbackLen] + "...(truncated)"
+	}
+	return s
+}
@@ -0,0 +1,181 @@
+package providerbase
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+)
+
+func TestParseProviderErrorBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want ProviderErrorBody
+		ok   bool
+	}{
+		{
+			name: "openai envelope",
+			body: `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+			want: ProviderErrorBody{Message: "You exceeded your current quota", Type: "insufficient_quota", Code: "insufficient_quota"},
+			ok:   true,
+		},
+		{
+			name: "openai sdk unwrapped",
+			body: `{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}`,
+			want: ProviderErrorBody{Message: "Incorrect API key provided", Type: "invalid_request_error", Code: "invalid_api_key"},
+			ok:   true,
+		},
+		{
+			name: "anthropic",
+			body: `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			want: ProviderErrorBody{Message: "Overloaded", Type: "overloaded_error"},
+			ok:   true,
+		},
+		{
+			name: "gemini array",
+			body: `[{"error":{"code":400,"message":"API key not valid.","status":"INVALID_ARGUMENT","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"API_KEY_INVALID"}]}}]`,
+			want: ProviderErrorBody{Message: "API key not valid.", Status: "INVALID_ARGUMENT", Reason: "API_KEY_INVALID"},
+			ok:   true,
+		},
+		{
+			name: "string error",
+			body: `{"error":"bad gateway"}`,
+			want: ProviderErrorBody{Message: "bad gateway"},
+			ok:   true,
+		},
+		{name: "plain text", body: "upstream exploded", ok: false},
+		{name: "unrelated json", body: `{"foo":"bar"}`, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseProviderErrorBody([]byte(tt.body))
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapProviderError(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		code         types.ErrorCode
+		retryable    bool
+		providerCode string
+		remediation  types.Remediation
+	}{
+		{
+			name:         "openai quota exceeded on 429",
+			status:       http.StatusTooManyRequests,
+			body:         `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`,
+			code:         llm.ErrQuotaExceeded,
+			providerCode: "insufficient_quota",
+			remediation:  llm.RemediationAddCredits,
+		},
+		{
+			name:         "openai rate limit",
+			status:       http.StatusTooManyRequests,
+			body:         `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			code:         llm.ErrRateLimit,
+			retryable:    true,
+			providerCode: "rate_limit_exceeded",
+			remediation:  llm.RemediationBackoff,
+		},
+		{
+			name:         "openai invalid key",
+			status:       http.StatusUnauthorized,
+			body:         `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			code:         llm.ErrUnauthorized,
+			providerCode: "invalid_api_key",
+			remediation:  llm.RemediationCheckCredentials,
+		},
+		{
+			name:         "openai content filter",
+			status:       http.StatusBadRequest,
+			body:         `{"error":{"message":"Your request was rejected by the safety system","type":"invalid_request_error","code":"content_policy_violation"}}`,
+			code:         llm.ErrContentFiltered,
+			providerCode: "content_policy_violation",
+			remediation:  llm.RemediationReviseContent,
+		},
+		{
+			name:         "anthropic prompt too long",
+			status:       http.StatusBadRequest,
+			body:         `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			code:         llm.ErrContextTooLong,
+			providerCode: "invalid_request_error",
+			remediation:  llm.RemediationReduceInput,
+		},
+		{
+			name:         "anthropic overloaded",
+			status:       529,
+			body:         `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			code:         llm.ErrModelOverloaded,
+			retryable:    true,
+			providerCode: "overloaded_error",
+			remediation:  llm.RemediationRetry,
+		},
+		{
+			name:         "gemini invalid key",
+			status:       http.StatusBadRequest,
+			body:         `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`,
+			code:         llm.ErrUnauthorized,
+			providerCode: "API_KEY_INVALID",
+			remediation:  llm.RemediationCheckCredentials,
+		},
+		{
+			name:         "gemini rate limit",
+			status:       http.StatusTooManyRequests,
+			body:         `{"error":{"code":429,"message":"Resource has been exhausted (e.g. check quota).","status":"RESOURCE_EXHAUSTED"}}`,
+			code:         llm.ErrRateLimit,
+			retryable:    true,
+			providerCode: "RESOURCE_EXHAUSTED",
+			remediation:  llm.RemediationBackoff,
+		},
+		{
+			name:        "unparsed body falls back to status mapping",
+			status:      http.StatusBadRequest,
+			body:        "bad things",
+			code:        llm.ErrInvalidRequest,
+			remediation: llm.RemediationFixRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MapProviderError(tt.status, []byte(tt.body), "test")
+			if got.Code != tt.code {
+				t.Errorf("Code = %v, want %v", got.Code, tt.code)
+			}
+			if got.Retryable != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", got.Retryable, tt.retryable)
+			}
+			if got.ProviderCode != tt.providerCode {
+				t.Errorf("ProviderCode = %q, want %q", got.ProviderCode, tt.providerCode)
+			}
+			if got.Remediation != tt.remediation {
+				t.Errorf("Remediation = %q, want %q", got.Remediation, tt.remediation)
+			}
+			if got.HTTPStatus != tt.status || got.Provider != "test" {
+				t.Errorf("unexpected status/provider: %d %q", got.HTTPStatus, got.Provider)
+			}
+		})
+	}
+}
+
+func TestReadProviderError_KeepsMessageFormat(t *testing.T) {
+	body := `{"error":{"message":"model gpt-x does not exist","type":"invalid_request_error","code":"model_not_found"}}`
+	got := ReadProviderError(http.StatusNotFound, strings.NewReader(body), "openai")
+	if got.Message != "model gpt-x does not exist (type: invalid_request_error)" {
+		t.Errorf("Message = %q", got.Message)
+	}
+	if got.Code != llm.ErrModelNotFound || got.Remediation != llm.RemediationSwitchModel {
+		t.Errorf("got code %v remediation %q", got.Code, got.Remediation)
+	}
+}
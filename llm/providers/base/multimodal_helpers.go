@@ -60,8 +60,7 @@ func doOpenAICompatRequest[Req any, Resp any](
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(resp.Body)
-		return nil, MapHTTPError(resp.StatusCode, msg, p.ProviderName)
+		return nil, ReadProviderError(resp.StatusCode, resp.Body, p.ProviderName)
 	}
 
 	var result Resp
@@ -125,8 +124,7 @@ func GenerateAudioOpenAICompat(ctx context.Context, p OpenAICompatParams, req *l
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(resp.Body)
-		return nil, MapHTTPError(resp.StatusCode, msg, p.ProviderName)
+		return nil, ReadProviderError(resp.StatusCode, resp.Body, p.ProviderName)
 	}
 
 	// 读取音频数据（直接从已有的 resp.Body 读取）
@@ -179,8 +177,7 @@ func ListFineTuningJobsOpenAICompat(ctx context.Context, p OpenAICompatParams) (
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(resp.Body)
-		return nil, MapHTTPError(resp.StatusCode, msg, p.ProviderName)
+		return nil, ReadProviderError(resp.StatusCode, resp.Body, p.ProviderName)
 	}
 
 	var listResp struct {
@@ -231,8 +228,7 @@ func doFineTuningJobRequest(httpReq *http.Request, p OpenAICompatParams) (*llm.F
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(resp.Body)
-		return nil, MapHTTPError(resp.StatusCode, msg, p.ProviderName)
+		return nil, ReadProviderError(resp.StatusCode, resp.Body, p.ProviderName)
 	}
 
 	var job llm.FineTuningJob
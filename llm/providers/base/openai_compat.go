@@ -1,6 +1,7 @@
 package providerbase
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -128,6 +129,7 @@ type OpenAICompatMessage struct {
 	Role             string                 `json:"role"`
 	Content          string                 `json:"content,omitempty"`
 	ReasoningContent *string                `json:"reasoning_content,omitempty"` // 推理内容
+	Reasoning        *string                `json:"reasoning,omitempty"`         // 部分网关（OpenRouter、新版 vLLM）使用的推理字段，仅用于解析响应
 	Refusal          *string                `json:"refusal,omitempty"`           // 模型拒绝内容
 	MultiContent     []map[string]any       `json:"multi_content,omitempty"`     // multimodal content parts
 	Name             string                 `json:"name,omitempty"`
@@ -245,6 +247,20 @@ type OpenAICompatRequest struct {
 	ConversationID     string   `json:"conversation_id,omitempty"`      // OpenAI server-managed conversation ID
 	Include            []string `json:"include,omitempty"`              // include 字段
 	Truncation         string   `json:"truncation,omitempty"`           // auto/disabled
+
+	// 聚合平台扩展字段
+	Provider *ProviderPreferences `json:"provider,omitempty"` // OpenRouter 上游路由偏好
+}
+
+// ProviderPreferences 控制 OpenRouter 等聚合平台在多个上游之间的路由选择。
+type ProviderPreferences struct {
+	Order             []string `json:"order,omitempty"`              // 按顺序优先尝试的上游
+	Only              []string `json:"only,omitempty"`               // 仅允许的上游
+	Ignore            []string `json:"ignore,omitempty"`             // 排除的上游
+	AllowFallbacks    *bool    `json:"allow_fallbacks,omitempty"`    // 首选上游不可用时是否回退
+	RequireParameters *bool    `json:"require_parameters,omitempty"` // 仅路由到支持全部请求参数的上游
+	DataCollection    string   `json:"data_collection,omitempty"`    // allow/deny
+	Sort              string   `json:"sort,omitempty"`               // price/throughput/latency
 }
 
 // StreamOptions 控制流式响应中的额外信息。
@@ -284,6 +300,8 @@ type OpenAICompatChoice struct {
 	FinishReason string               `json:"finish_reason"`
 	Message      OpenAICompatMessage  `json:"message"`
 	Delta        *OpenAICompatMessage `json:"delta,omitempty"`
+	// LogProbs 的线上格式与 types.ChoiceLogProbs 一致，直接复用。
+	LogProbs *types.ChoiceLogProbs `json:"logprobs,omitempty"`
 }
 
 // OpenAICompatUsage 表示 OpenAI 兼容响应中的 token 用量.
@@ -495,10 +513,16 @@ func ToLLMChatResponse(oa OpenAICompatResponse, provider string) *llm.ChatRespon
 		msg := types.Message{
 			Role:             llm.RoleAssistant,
 			Content:          c.Message.Content,
-			ReasoningContent: c.Message.ReasoningContent,
+			ReasoningContent: c.Message.reasoningText(),
 			Refusal:          c.Message.Refusal,
 			Name:             c.Message.Name,
 		}
+		if msg.ReasoningContent == nil {
+			if reasoning, answer, ok := splitInlineThinking(msg.Content); ok {
+				msg.ReasoningContent = &reasoning
+				msg.Content = answer
+			}
+		}
 		if len(c.Message.ToolCalls) > 0 {
 			msg.ToolCalls = make([]types.ToolCall, 0, len(c.Message.ToolCalls))
 			for _, tc := range c.Message.ToolCalls {
@@ -544,6 +568,7 @@ func ToLLMChatResponse(oa OpenAICompatResponse, provider string) *llm.ChatRespon
 			Index:        c.Index,
 			FinishReason: c.FinishReason,
 			Message:      msg,
+			LogProbs:     c.LogProbs,
 		})
 	}
 	resp := &llm.ChatResponse{
@@ -557,27 +582,60 @@ func ToLLMChatResponse(oa OpenAICompatResponse, provider string) *llm.ChatRespon
 		resp.CreatedAt = time.Unix(oa.Created, 0)
 	}
 	if oa.Usage != nil {
-		resp.Usage = llm.ChatUsage{
-			PromptTokens:     oa.Usage.PromptTokens,
-			CompletionTokens: oa.Usage.CompletionTokens,
-			TotalTokens:      oa.Usage.TotalTokens,
-		}
-		if oa.Usage.PromptTokensDetails != nil {
-			resp.Usage.PromptTokensDetails = &llm.PromptTokensDetails{
-				CachedTokens: oa.Usage.PromptTokensDetails.CachedTokens,
-				AudioTokens:  oa.Usage.PromptTokensDetails.AudioTokens,
-			}
+		resp.Usage = *ToLLMChatUsage(oa.Usage)
+	}
+	return resp
+}
+
+// ToLLMChatUsage 将 OpenAI 兼容的 usage（含缓存与推理 token 明细）转换为 llm.ChatUsage.
+func ToLLMChatUsage(u *OpenAICompatUsage) *llm.ChatUsage {
+	if u == nil {
+		return nil
+	}
+	usage := &llm.ChatUsage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.PromptTokensDetails = &llm.PromptTokensDetails{
+			CachedTokens: u.PromptTokensDetails.CachedTokens,
+			AudioTokens:  u.PromptTokensDetails.AudioTokens,
 		}
-		if oa.Usage.CompletionTokensDetails != nil {
-			resp.Usage.CompletionTokensDetails = &llm.CompletionTokensDetails{
-				ReasoningTokens:          oa.Usage.CompletionTokensDetails.ReasoningTokens,
-				AudioTokens:              oa.Usage.CompletionTokensDetails.AudioTokens,
-				AcceptedPredictionTokens: oa.Usage.CompletionTokensDetails.AcceptedPredictionTokens,
-				RejectedPredictionTokens: oa.Usage.CompletionTokensDetails.RejectedPredictionTokens,
-			}
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.CompletionTokensDetails = &llm.CompletionTokensDetails{
+			ReasoningTokens:          u.CompletionTokensDetails.ReasoningTokens,
+			AudioTokens:              u.CompletionTokensDetails.AudioTokens,
+			AcceptedPredictionTokens: u.CompletionTokensDetails.AcceptedPredictionTokens,
+			RejectedPredictionTokens: u.CompletionTokensDetails.RejectedPredictionTokens,
 		}
 	}
-	return resp
+	return usage
+}
+
+// reasoningText 返回推理内容，兼容 reasoning_content 与 reasoning 两种字段.
+func (m *OpenAICompatMessage) reasoningText() *string {
+	if m.ReasoningContent != nil {
+		return m.ReasoningContent
+	}
+	return m.Reasoning
+}
+
+// splitInlineThinking 拆分以 <think>...</think> 内联推理开头的内容（部分托管 DeepSeek-R1/QwQ 的返回格式）.
+func splitInlineThinking(content string) (reasoning, answer string, ok bool) {
+	const openTag, closeTag = "<think>", "</think>"
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, openTag) {
+		return "", content, false
+	}
+	end := strings.Index(trimmed, closeTag)
+	if end < 0 {
+		return "", content, false
+	}
+	reasoning = strings.TrimSpace(trimmed[len(openTag):end])
+	answer = strings.TrimLeft(trimmed[end+len(closeTag):], " \t\r\n")
+	return reasoning, answer, true
 }
 
 // ChooseModel 根据请求和默认值选择模型
@@ -658,15 +716,20 @@ func ListModelsOpenAICompat(ctx context.Context, client *http.Client, baseURL, a
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := ReadErrorMessage(resp.Body)
-		return nil, MapHTTPError(resp.StatusCode, msg, providerName)
+		return nil, ReadProviderError(resp.StatusCode, resp.Body, providerName)
 	}
 
-	var modelsResp struct {
-		Object string      `json:"object"`
-		Data   []llm.Model `json:"data"`
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, &types.Error{
+			Code:    llm.ErrUpstreamError,
+			Message: err.Error(), Cause: err, HTTPStatus: http.StatusBadGateway,
+			Retryable: true,
+			Provider:  providerName,
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+	models, err := DecodeModelList[llm.Model](raw)
+	if err != nil {
 		return nil, &types.Error{
 			Code:    llm.ErrUpstreamError,
 			Message: err.Error(), Cause: err, HTTPStatus: http.StatusBadGateway,
@@ -674,8 +737,27 @@ func ListModelsOpenAICompat(ctx context.Context, client *http.Client, baseURL, a
 			Provider:  providerName,
 		}
 	}
+	return models, nil
+}
 
-	return modelsResp.Data, nil
+// DecodeModelList 解析模型列表响应，兼容 OpenAI 的 {"data": [...]} 包装与
+// Together AI 等直接返回数组的形式。
+func DecodeModelList[T any](raw json.RawMessage) ([]T, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var models []T
+		if err := json.Unmarshal(trimmed, &models); err != nil {
+			return nil, err
+		}
+		return models, nil
+	}
+	var wrapped struct {
+		Data []T `json:"data"`
+	}
+	if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Data, nil
 }
 
 // UnwrapStringifiedJSON 检测并修复双重序列化的 JSON 参数。
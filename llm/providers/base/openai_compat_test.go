@@ -256,6 +256,43 @@ func TestToLLMChatResponse(t *testing.T) {
 		assert.False(t, resp.CreatedAt.IsZero())
 	})
 
+	t.Run("response with reasoning", func(t *testing.T) {
+		var oa OpenAICompatResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"42","reasoning":"6*7"}}],
+			"usage":{"prompt_tokens":5,"completion_tokens":12,"total_tokens":17,"completion_tokens_details":{"reasoning_tokens":10}}}`), &oa))
+		resp := ToLLMChatResponse(oa, "openrouter")
+		assert.Equal(t, "6*7", resp.ReasoningContent())
+		assert.Equal(t, 10, resp.ReasoningTokens())
+	})
+
+	t.Run("response with inline think tags", func(t *testing.T) {
+		oa := OpenAICompatResponse{Choices: []OpenAICompatChoice{{Message: OpenAICompatMessage{
+			Role:    "assistant",
+			Content: "<think>\nadd the numbers\n</think>\n\nThe sum is 3.",
+		}}}}
+		resp := ToLLMChatResponse(oa, "deepseek")
+		assert.Equal(t, "The sum is 3.", resp.Choices[0].Message.Content)
+		assert.Equal(t, "add the numbers", resp.ReasoningContent())
+
+		unterminated := OpenAICompatResponse{Choices: []OpenAICompatChoice{{Message: OpenAICompatMessage{Role: "assistant", Content: "<think>partial"}}}}
+		assert.Equal(t, "<think>partial", ToLLMChatResponse(unterminated, "deepseek").Choices[0].Message.Content)
+	})
+
+	t.Run("response with logprobs", func(t *testing.T) {
+		var oa OpenAICompatResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},
+			"logprobs":{"content":[{"token":"Hi","logprob":-0.1,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":-0.1},{"token":"Hey","logprob":-2.5}]}]}}]}`), &oa))
+		resp := ToLLMChatResponse(oa, "openai")
+		require.Len(t, resp.Choices, 1)
+		lp := resp.Choices[0].LogProbs
+		require.NotNil(t, lp)
+		require.Len(t, lp.Content, 1)
+		assert.Equal(t, "Hi", lp.Content[0].Token)
+		assert.Equal(t, []int{72, 105}, lp.Content[0].Bytes)
+		require.Len(t, lp.Content[0].TopLogProbs, 2)
+		assert.Equal(t, "Hey", lp.Content[0].TopLogProbs[1].Token)
+	})
+
 	t.Run("response with tool calls", func(t *testing.T) {
 		oa := OpenAICompatResponse{
 			Choices: []OpenAICompatChoice{
@@ -496,6 +533,23 @@ func TestListModelsOpenAICompat(t *testing.T) {
 		assert.Equal(t, "gpt-4", models[0].ID)
 	})
 
+	t.Run("bare array", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"id":"meta-llama/Llama-3.3-70B-Instruct-Turbo","object":"model"}]`))
+		}))
+		defer srv.Close()
+
+		models, err := ListModelsOpenAICompat(
+			context.Background(), srv.Client(),
+			srv.URL, "test-key", "together", "/v1/models",
+			BearerTokenHeaders,
+		)
+		require.NoError(t, err)
+		require.Len(t, models, 1)
+		assert.Equal(t, "meta-llama/Llama-3.3-70B-Instruct-Turbo", models[0].ID)
+	})
+
 	t.Run("HTTP error", func(t *testing.T) {
 		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusUnauthorized)
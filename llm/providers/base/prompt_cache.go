@@ -0,0 +1,45 @@
+package providerbase
+
+import (
+	"strings"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+)
+
+// openAIExtendedRetentionThreshold 是 PromptCacheHint.TTL 升级为 24h 保留策略的阈值。
+const openAIExtendedRetentionThreshold = time.Hour
+
+// ResolvePromptCacheKey 返回 OpenAI 风格的 prompt_cache_key。
+// 显式的 PromptCacheKey 优先，否则回退到 PromptCacheHint.Key。
+func ResolvePromptCacheKey(req *llm.ChatRequest) string {
+	if req == nil {
+		return ""
+	}
+	if key := strings.TrimSpace(req.PromptCacheKey); key != "" {
+		return key
+	}
+	if req.PromptCache == nil {
+		return ""
+	}
+	return strings.TrimSpace(req.PromptCache.Key)
+}
+
+// ResolvePromptCacheRetention 返回 OpenAI 风格的 prompt_cache_retention。
+// 显式的 PromptCacheRetention 优先；否则 TTL 超过 1h 映射为 "24h"，
+// 其余非零 TTL 映射为 "in_memory"。
+func ResolvePromptCacheRetention(req *llm.ChatRequest) string {
+	if req == nil {
+		return ""
+	}
+	if retention := strings.TrimSpace(req.PromptCacheRetention); retention != "" {
+		return retention
+	}
+	if req.PromptCache == nil || req.PromptCache.TTL <= 0 {
+		return ""
+	}
+	if req.PromptCache.TTL > openAIExtendedRetentionThreshold {
+		return "24h"
+	}
+	return "in_memory"
+}
@@ -0,0 +1,40 @@
+package providerbase
+
+import (
+	"testing"
+	"time"
+
+	llm "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePromptCacheKey(t *testing.T) {
+	assert.Empty(t, ResolvePromptCacheKey(nil))
+	assert.Equal(t, "hint", ResolvePromptCacheKey(&llm.ChatRequest{PromptCache: &llm.PromptCacheHint{Key: " hint "}}))
+	assert.Equal(t, "explicit", ResolvePromptCacheKey(&llm.ChatRequest{
+		PromptCacheKey: "explicit",
+		PromptCache:    &llm.PromptCacheHint{Key: "hint"},
+	}))
+}
+
+func TestResolvePromptCacheRetention(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *llm.ChatRequest
+		want string
+	}{
+		{name: "nil request", req: nil, want: ""},
+		{name: "no hint", req: &llm.ChatRequest{}, want: ""},
+		{name: "short ttl", req: &llm.ChatRequest{PromptCache: &llm.PromptCacheHint{TTL: 5 * time.Minute}}, want: "in_memory"},
+		{name: "long ttl", req: &llm.ChatRequest{PromptCache: &llm.PromptCacheHint{TTL: 12 * time.Hour}}, want: "24h"},
+		{name: "explicit wins", req: &llm.ChatRequest{
+			PromptCacheRetention: "in_memory",
+			PromptCache:          &llm.PromptCacheHint{TTL: 12 * time.Hour},
+		}, want: "in_memory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResolvePromptCacheRetention(tt.req))
+		})
+	}
+}
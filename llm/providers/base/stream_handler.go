@@ -39,9 +39,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 					select {
 					case <-ctx.Done():
 						return
-					case ch <- llm.StreamChunk{Err: &types.Error{
-						Code: llm.ErrUpstreamError, Message: err.Error(), Cause: err, HTTPStatus: http.StatusBadGateway, Retryable: true, Provider: providerName,
-					}}:
+					case ch <- llm.StreamChunk{Err: llm.ClassifyStreamError(err, providerName)}:
 					}
 				}
 				return
@@ -68,11 +66,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 			}
 
 			if oaResp.Usage != nil {
-				streamUsage := &llm.ChatUsage{
-					PromptTokens:     oaResp.Usage.PromptTokens,
-					CompletionTokens: oaResp.Usage.CompletionTokens,
-					TotalTokens:      oaResp.Usage.TotalTokens,
-				}
+				streamUsage := ToLLMChatUsage(oaResp.Usage)
 				if len(oaResp.Choices) == 0 {
 					select {
 					case <-ctx.Done():
@@ -95,6 +89,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 					Model:        oaResp.Model,
 					Index:        choice.Index,
 					FinishReason: choice.FinishReason,
+					LogProbs:     choice.LogProbs,
 					Delta: types.Message{
 						Role: llm.RoleAssistant,
 					},
@@ -102,7 +97,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 				if choice.Delta != nil {
 					chunk.Delta.Content = choice.Delta.Content
 					chunk.Delta.Refusal = choice.Delta.Refusal
-					chunk.Delta.ReasoningContent = choice.Delta.ReasoningContent
+					chunk.Delta.ReasoningContent = choice.Delta.reasoningText()
 					if len(choice.Delta.ToolCalls) > 0 {
 						chunk.Delta.ToolCalls = make([]types.ToolCall, 0, len(choice.Delta.ToolCalls))
 						for _, tc := range choice.Delta.ToolCalls {
@@ -122,7 +117,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 									toolCallAccumulator.Append(itemID, string(arguments))
 								}
 							}
-							if choice.FinishReason == "tool_calls" || json.Valid(toolCallAccumulator.payloads[itemID]) {
+							if choice.FinishReason == "tool_calls" || toolCallAccumulator.Ready(itemID) {
 								if complete, ok := toolCallAccumulator.CompleteFunction(itemID); ok {
 									complete.Index = tc.Index
 									complete.Arguments = UnwrapStringifiedJSON(complete.Arguments)
@@ -133,11 +128,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser, providerName string) <-c
 					}
 				}
 				if oaResp.Usage != nil {
-					chunk.Usage = &llm.ChatUsage{
-						PromptTokens:     oaResp.Usage.PromptTokens,
-						CompletionTokens: oaResp.Usage.CompletionTokens,
-						TotalTokens:      oaResp.Usage.TotalTokens,
-					}
+					chunk.Usage = ToLLMChatUsage(oaResp.Usage)
 				}
 				select {
 				case <-ctx.Done():
@@ -173,8 +164,8 @@ func MapSDKError(err error, providerName string, extractAPIError func(err error)
 	if err == nil {
 		return nil
 	}
-	if statusCode, message, ok := extractAPIError(err); ok {
-		return MapHTTPError(statusCode, message, providerName)
+	if statusCode, body, ok := extractAPIError(err); ok {
+		return MapProviderError(statusCode, []byte(body), providerName)
 	}
 	return &types.Error{
 		Code:       llm.ErrUpstreamError,
@@ -195,3 +195,30 @@ func TestStreamSSEAccumulatesOpenAICompatToolCallDeltas(t *testing.T) {
 		t.Fatalf("tool call arguments mismatch: got=%s want=%s", got, want)
 	}
 }
+
+func TestStreamSSEPropagatesReasoningDeltasAndTokens(t *testing.T) {
+	body := strings.Join([]string{
+		`data: {"id":"s1","model":"m","choices":[{"index":0,"delta":{"reasoning":"thinking"}}]}`,
+		`data: {"id":"s1","model":"m","choices":[{"index":0,"delta":{"content":"answer"},"finish_reason":"stop"}]}`,
+		`data: {"id":"s1","model":"m","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":9,"total_tokens":12,"completion_tokens_details":{"reasoning_tokens":6}}}`,
+		`data: [DONE]`,
+		``,
+	}, "\n\n")
+
+	var reasoning strings.Builder
+	reasoningTokens := 0
+	for chunk := range StreamSSE(context.Background(), io.NopCloser(strings.NewReader(body)), "compat") {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Err)
+		}
+		reasoning.WriteString(chunk.ReasoningContent())
+		reasoningTokens += chunk.ReasoningTokens()
+	}
+
+	if got := reasoning.String(); got != "thinking" {
+		t.Fatalf("reasoning content mismatch: got=%q", got)
+	}
+	if reasoningTokens != 6 {
+		t.Fatalf("reasoning tokens mismatch: got=%d want=6", reasoningTokens)
+	}
+}
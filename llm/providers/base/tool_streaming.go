@@ -1,23 +1,29 @@
 package providerbase
 
 import (
-	"encoding/json"
 	"strings"
 
+	llm "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/types"
 )
 
+// ToolCallDeltaAccumulator 按上游 item ID 累积工具调用增量，基于 llm.ToolCallAssembler 实现；
+// 上游未给出 call ID 时以 item ID 作为调用 ID。
 type ToolCallDeltaAccumulator struct {
-	names    map[string]string
-	callIDs  map[string]string
-	payloads map[string]json.RawMessage
+	assembler *llm.ToolCallAssembler
 }
 
 func NewToolCallDeltaAccumulator() *ToolCallDeltaAccumulator {
+	return NewToolCallDeltaAccumulatorWithProgress(nil)
+}
+
+// NewToolCallDeltaAccumulatorWithProgress 创建累积器，onProgress 在工具调用出现、收到参数片段或完成时回调。
+func NewToolCallDeltaAccumulatorWithProgress(onProgress func(llm.ToolCallProgress)) *ToolCallDeltaAccumulator {
 	return &ToolCallDeltaAccumulator{
-		names:    make(map[string]string),
-		callIDs:  make(map[string]string),
-		payloads: make(map[string]json.RawMessage),
+		assembler: llm.NewToolCallAssembler(llm.ToolCallAssemblerOptions{
+			OnProgress:  onProgress,
+			IDGenerator: func(itemID string, _ int) string { return itemID },
+		}),
 	}
 }
 
@@ -25,59 +31,42 @@ func (a *ToolCallDeltaAccumulator) Register(itemID, toolType, name, callID strin
 	if a == nil || strings.TrimSpace(itemID) == "" {
 		return
 	}
-	if strings.TrimSpace(name) != "" {
-		a.names[itemID] = strings.TrimSpace(name)
-	}
-	if strings.TrimSpace(callID) != "" {
-		a.callIDs[itemID] = strings.TrimSpace(callID)
-	}
+	a.assembler.Start(itemID, 0, callID, name, toolType)
 }
 
 func (a *ToolCallDeltaAccumulator) Append(itemID, delta string) {
 	if a == nil || strings.TrimSpace(itemID) == "" {
 		return
 	}
-	a.payloads[itemID] = AppendToolJSONDelta(a.payloads[itemID], delta)
+	a.assembler.AppendArguments(itemID, delta)
+}
+
+// Ready 报告 itemID 累积的参数是否已构成合法 JSON。
+func (a *ToolCallDeltaAccumulator) Ready(itemID string) bool {
+	return a != nil && a.assembler.Ready(itemID)
 }
 
 func (a *ToolCallDeltaAccumulator) CompleteFunction(itemID string) (types.ToolCall, bool) {
-	if a == nil || strings.TrimSpace(itemID) == "" {
-		return types.ToolCall{}, false
-	}
-	callID := a.callIDs[itemID]
-	if callID == "" {
-		callID = itemID
-	}
-	name := a.names[itemID]
-	payload := a.payloads[itemID]
-	a.delete(itemID)
-	if strings.TrimSpace(name) == "" {
-		return types.ToolCall{}, false
-	}
-	return NewFunctionToolCall(callID, name, payload), true
+	return a.complete(itemID, types.ToolTypeFunction)
 }
 
 func (a *ToolCallDeltaAccumulator) CompleteCustom(itemID string) (types.ToolCall, bool) {
+	return a.complete(itemID, types.ToolTypeCustom)
+}
+
+// complete 取出完整调用；参数无法修复时保留原文，交由下游工具执行层校验。
+func (a *ToolCallDeltaAccumulator) complete(itemID, toolType string) (types.ToolCall, bool) {
 	if a == nil || strings.TrimSpace(itemID) == "" {
 		return types.ToolCall{}, false
 	}
-	callID := a.callIDs[itemID]
-	if callID == "" {
-		callID = itemID
-	}
-	name := a.names[itemID]
-	input := string(a.payloads[itemID])
-	a.delete(itemID)
-	if strings.TrimSpace(name) == "" {
+	a.assembler.Start(itemID, 0, "", "", toolType)
+	call, ok, _ := a.assembler.Complete(itemID)
+	a.assembler.Discard(itemID)
+	if !ok {
 		return types.ToolCall{}, false
 	}
-	return NewCustomToolCall(callID, name, input), true
-}
-
-func (a *ToolCallDeltaAccumulator) delete(itemID string) {
-	delete(a.names, itemID)
-	delete(a.callIDs, itemID)
-	delete(a.payloads, itemID)
+	call.Index = 0
+	return call, true
 }
 
 type ToolOutputWriteback struct {
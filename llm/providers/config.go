@@ -26,6 +26,8 @@ type OpenAIConfig struct {
 	BaseProviderConfig `yaml:",inline"`
 	Organization       string `json:"organization,omitempty" yaml:"organization,omitempty"`
 	UseResponsesAPI    bool   `json:"use_responses_api,omitempty" yaml:"use_responses_api,omitempty"` // 启用新的 Responses API (2025)
+	// BackgroundStreams 以后台模式（background + store）创建 Responses API 流，中途断开后可按 sequence 服务端续传
+	BackgroundStreams bool `json:"background_streams,omitempty" yaml:"background_streams,omitempty"`
 }
 
 // ClaudeConfig Claude Provider 配置
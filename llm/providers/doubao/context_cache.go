@@ -63,8 +63,7 @@ func (p *DoubaoProvider) CreateContextCache(ctx context.Context, model string, m
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	var result ContextCacheResponse
@@ -130,8 +129,7 @@ func (p *DoubaoProvider) CompletionWithContext(ctx context.Context, contextID st
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	var oaResp providerbase.OpenAICompatResponse
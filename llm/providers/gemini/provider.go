@@ -20,7 +20,7 @@ import (
 	llm "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/llm/middleware"
 	"github.com/BaSui01/agentflow/llm/providers"
-	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
 	"go.uber.org/zap"
 )
 
@@ -77,7 +77,7 @@ func NewGeminiProvider(cfg providers.GeminiConfig, logger *zap.Logger) *GeminiPr
 
 	return &GeminiProvider{
 		cfg:    cfg,
-		client: tlsutil.SecureHTTPClient(timeout),
+		client: providertransport.Client("gemini", timeout),
 		logger: logger,
 		rewriterChain: middleware.NewRewriterChain(
 			middleware.NewXMLToolRewriter(),
@@ -104,7 +104,17 @@ func (p *GeminiProvider) mapSDKError(err error) error {
 	return providerbase.MapSDKError(err, p.Name(), func(e error) (int, string, bool) {
 		var apiErr genai.APIError
 		if errors.As(e, &apiErr) {
-			return apiErr.Code, strings.TrimSpace(apiErr.Message), true
+			// 还原 Gemini 错误体，使 status 与 details.reason 参与错误分类
+			body, err := json.Marshal(map[string]any{"error": map[string]any{
+				"code":    apiErr.Code,
+				"message": strings.TrimSpace(apiErr.Message),
+				"status":  apiErr.Status,
+				"details": apiErr.Details,
+			}})
+			if err != nil {
+				return apiErr.Code, strings.TrimSpace(apiErr.Message), true
+			}
+			return apiErr.Code, string(body), true
 		}
 		return 0, "", false
 	})
@@ -132,6 +142,18 @@ func (p *GeminiProvider) SupportsNativeFunctionCalling() bool { return true }
 // structured output via responseMimeType + responseSchema.
 func (p *GeminiProvider) SupportsStructuredOutput() bool { return true }
 
+// Capabilities 返回 Gemini 对话能力：原生工具调用与 responseSchema JSON 模式；
+// 当前消息转换只携带文本，图片/视频输入会在能力协商阶段被明确拒绝而不是被静默丢弃。
+func (p *GeminiProvider) Capabilities() llm.CapabilityDescriptor {
+	return llm.CapabilityDescriptor{
+		SupportsVision:             false,
+		SupportsTools:              true,
+		SupportsJSONMode:           true,
+		SupportsStreamingToolCalls: true,
+		Modalities:                 []string{llm.ModalityText},
+	}
+}
+
 // ListModels 获取 Gemini 支持的模型列表
 func (p *GeminiProvider) ListModels(ctx context.Context) ([]llm.Model, error) {
 	client, err := p.sdkClient(ctx)
@@ -539,7 +561,7 @@ func buildGenAIGenerationConfig(req *llm.ChatRequest, safetySettings []providers
 
 	cfg.Tools = convertToGenAITools(req.Tools, req.WebSearchOptions)
 	cfg.ToolConfig = convertToolChoiceToGenAI(req.ToolChoice, req.IncludeServerSideToolInvocations)
-	cfg.CachedContent = strings.TrimSpace(req.CachedContent)
+	cfg.CachedContent = resolveGeminiCachedContent(req)
 
 	if len(req.Modalities) > 0 {
 		cfg.ResponseModalities = make([]string, 0, len(req.Modalities))
@@ -562,6 +584,22 @@ func buildGenAIGenerationConfig(req *llm.ChatRequest, safetySettings []providers
 	return cfg
 }
 
+// resolveGeminiCachedContent 返回要引用的 cachedContents 资源名。
+// 显式的 CachedContent 优先；PromptCacheHint.Key 仅在形如 "cachedContents/..." 时被采用。
+func resolveGeminiCachedContent(req *llm.ChatRequest) string {
+	if cached := strings.TrimSpace(req.CachedContent); cached != "" {
+		return cached
+	}
+	if req.PromptCache == nil {
+		return ""
+	}
+	key := strings.TrimSpace(req.PromptCache.Key)
+	if strings.HasPrefix(key, "cachedContents/") {
+		return key
+	}
+	return ""
+}
+
 func applyGeminiSafetySettings(cfg *genai.GenerateContentConfig, req *llm.ChatRequest, configured []providers.GeminiSafetySetting) {
 	if cfg == nil {
 		return
@@ -897,6 +935,7 @@ func toChatResponseFromGenAI(gr *genai.GenerateContentResponse, provider, model
 			Index:        int(candidate.Index),
 			FinishReason: normalizeFinishReason(string(candidate.FinishReason)),
 			Message:      msg,
+			LogProbs:     logProbsFromGenAI(candidate.LogprobsResult),
 		})
 	}
 
@@ -929,6 +968,7 @@ func streamChunksFromGenAI(gr *genai.GenerateContentResponse, provider, model st
 			Index:        int(candidate.Index),
 			FinishReason: normalizeFinishReason(string(candidate.FinishReason)),
 			Delta:        messageFromGenAICandidate(gr.ResponseID, candidate, provider),
+			LogProbs:     logProbsFromGenAI(candidate.LogprobsResult),
 		})
 	}
 	if gr.UsageMetadata != nil {
@@ -941,6 +981,36 @@ func streamChunksFromGenAI(gr *genai.GenerateContentResponse, provider, model st
 	return chunks
 }
 
+// logProbsFromGenAI 将 Gemini 的 chosen/top candidates 转换为统一的 token 对数概率。
+func logProbsFromGenAI(result *genai.LogprobsResult) *types.ChoiceLogProbs {
+	if result == nil || len(result.ChosenCandidates) == 0 {
+		return nil
+	}
+	content := make([]types.TokenLogProb, 0, len(result.ChosenCandidates))
+	for i, chosen := range result.ChosenCandidates {
+		if chosen == nil {
+			continue
+		}
+		token := types.TokenLogProb{
+			Token:   chosen.Token,
+			LogProb: float64(chosen.LogProbability),
+		}
+		if i < len(result.TopCandidates) && result.TopCandidates[i] != nil {
+			for _, alt := range result.TopCandidates[i].Candidates {
+				if alt == nil {
+					continue
+				}
+				token.TopLogProbs = append(token.TopLogProbs, types.TopLogProb{
+					Token:   alt.Token,
+					LogProb: float64(alt.LogProbability),
+				})
+			}
+		}
+		content = append(content, token)
+	}
+	return &types.ChoiceLogProbs{Content: content}
+}
+
 func messageFromGenAICandidate(responseID string, candidate *genai.Candidate, provider string) types.Message {
 	msg := types.Message{Role: llm.RoleAssistant}
 	if candidate == nil || candidate.Content == nil {
@@ -1036,6 +1106,8 @@ func convertUsageMetadataFromGenAI(m *genai.GenerateContentResponseUsageMetadata
 		TotalTokens:      int(m.TotalTokenCount),
 	}
 	if m.ThoughtsTokenCount > 0 {
+		// Gemini 的 candidatesTokenCount 不含思考 token，归一化为 OpenAI 语义（推理 token 计入 completion）。
+		usage.CompletionTokens += int(m.ThoughtsTokenCount)
 		usage.CompletionTokensDetails = &llm.CompletionTokensDetails{
 			ReasoningTokens: int(m.ThoughtsTokenCount),
 		}
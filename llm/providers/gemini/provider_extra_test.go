@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"google.golang.org/genai"
 )
 
 // --- resolveAPIKey ---
@@ -419,6 +420,7 @@ func TestGeminiProvider_Completion_WithThinking(t *testing.T) {
 	assert.Equal(t, "c2lnX3N5bmM=", resp.Choices[0].Message.OpaqueReasoning[0].State)
 	require.NotNil(t, resp.Usage.CompletionTokensDetails)
 	assert.Equal(t, 5, resp.Usage.CompletionTokensDetails.ReasoningTokens)
+	assert.Equal(t, 13, resp.Usage.CompletionTokens, "thought tokens are normalized into completion tokens")
 }
 
 // --- Completion with promptFeedback block ---
@@ -759,6 +761,33 @@ func TestExtractGroundingAnnotations_Nil(t *testing.T) {
 	assert.Nil(t, extractGroundingAnnotations(&geminiGroundingMetadata{}))
 }
 
+func TestToChatResponseFromGenAI_LogProbs(t *testing.T) {
+	gr := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []*genai.Part{{Text: "Yes"}}},
+			LogprobsResult: &genai.LogprobsResult{
+				ChosenCandidates: []*genai.LogprobsResultCandidate{{Token: "Yes", LogProbability: -0.25}},
+				TopCandidates: []*genai.LogprobsResultTopCandidates{{Candidates: []*genai.LogprobsResultCandidate{
+					{Token: "Yes", LogProbability: -0.25},
+					{Token: "No", LogProbability: -1.5},
+				}}},
+			},
+		}},
+	}
+
+	resp := toChatResponseFromGenAI(gr, "gemini", "gemini-2.5-flash")
+	require.Len(t, resp.Choices, 1)
+	lp := resp.Choices[0].LogProbs
+	require.NotNil(t, lp)
+	require.Len(t, lp.Content, 1)
+	assert.Equal(t, "Yes", lp.Content[0].Token)
+	assert.InDelta(t, -0.25, lp.Content[0].LogProb, 1e-6)
+	require.Len(t, lp.Content[0].TopLogProbs, 2)
+	assert.Equal(t, "No", lp.Content[0].TopLogProbs[1].Token)
+
+	assert.Nil(t, logProbsFromGenAI(nil))
+}
+
 func TestGeminiProvider_Completion_WithGrounding(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 验证请求中包含 google_search 工具
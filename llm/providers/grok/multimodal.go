@@ -55,8 +55,7 @@ func (p *GrokProvider) GenerateVideo(ctx context.Context, req *llm.VideoGenerati
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	var submitResp struct {
@@ -91,8 +90,7 @@ func (p *GrokProvider) GenerateVideo(ctx context.Context, req *llm.VideoGenerati
 		defer pollResp.Body.Close()
 
 		if pollResp.StatusCode >= 400 {
-			msg := providerbase.ReadErrorMessage(pollResp.Body)
-			return providers.PollResult[llm.VideoGenerationResponse]{Done: true, Err: providerbase.MapHTTPError(pollResp.StatusCode, msg, p.Name())}
+			return providers.PollResult[llm.VideoGenerationResponse]{Done: true, Err: providerbase.ReadProviderError(pollResp.StatusCode, pollResp.Body, p.Name())}
 		}
 
 		var statusResp struct {
@@ -74,8 +74,7 @@ func (p *MistralProvider) TranscribeAudio(ctx context.Context, req *llm.AudioTra
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	var transcriptionResp llm.AudioTranscriptionResponse
@@ -107,8 +106,7 @@ func (p *MistralProvider) CreateEmbedding(ctx context.Context, req *llm.Embeddin
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	var embeddingResp llm.EmbeddingResponse
@@ -247,7 +247,7 @@ type responsesReasoningPart struct {
 
 // completionWithResponsesAPI 使用新的 Responses API (/v1/responses).
 func (p *OpenAIProvider) completionWithResponsesAPI(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
-	promptCacheRetention, cacheErr := providerbase.NormalizeOpenAIPromptCacheRetention(req.PromptCacheRetention, p.Name())
+	promptCacheRetention, cacheErr := providerbase.NormalizeOpenAIPromptCacheRetention(providerbase.ResolvePromptCacheRetention(req), p.Name())
 	if cacheErr != nil {
 		return nil, cacheErr
 	}
@@ -521,8 +521,8 @@ func (p *OpenAIProvider) buildResponsesRequest(req *llm.ChatRequest) openAIRespo
 		ToolChoice:           req.ToolChoice,
 		Store:                req.Store,
 		Metadata:             req.Metadata,
-		PromptCacheKey:       strings.TrimSpace(req.PromptCacheKey),
-		PromptCacheRetention: strings.TrimSpace(req.PromptCacheRetention),
+		PromptCacheKey:       providerbase.ResolvePromptCacheKey(req),
+		PromptCacheRetention: providerbase.ResolvePromptCacheRetention(req),
 		Include:              append([]string(nil), req.Include...),
 		Truncation:           strings.TrimSpace(req.Truncation),
 		User:                 req.User,
@@ -575,6 +575,11 @@ func (p *OpenAIProvider) buildResponsesRequest(req *llm.ChatRequest) openAIRespo
 		body.Include = ensureString(body.Include, "reasoning.encrypted_content")
 	}
 
+	// Responses API 仅在 include 中声明时才返回 output_text 的 logprobs
+	if (req.LogProbs != nil && *req.LogProbs) || req.TopLogProbs != nil {
+		body.Include = ensureString(body.Include, string(responses.ResponseIncludableMessageOutputTextLogprobs))
+	}
+
 	// ResponseFormat / verbosity → text config
 	if req.ResponseFormat != nil || strings.TrimSpace(req.Verbosity) != "" {
 		body.Text = &responsesTextParam{
@@ -1044,7 +1049,9 @@ func toResponsesAPIChatResponse(resp *responses.Response, provider string) *llm.
 	for _, output := range resp.Output {
 		switch output.Type {
 		case "message":
-			msg := buildResponsesMessage(output.AsMessage())
+			message := output.AsMessage()
+			msg := buildResponsesMessage(message)
+			logProbs := buildResponsesLogProbs(message)
 			if len(choices) > 0 && choices[len(choices)-1].Message.Role == llm.RoleAssistant &&
 				choices[len(choices)-1].Message.Content == "" && len(choices[len(choices)-1].Message.ToolCalls) == 0 {
 				last := &choices[len(choices)-1]
@@ -1053,10 +1060,11 @@ func toResponsesAPIChatResponse(resp *responses.Response, provider string) *llm.
 				if msg.Refusal != nil {
 					last.Message.Refusal = msg.Refusal
 				}
+				last.LogProbs = mergeChoiceLogProbs(last.LogProbs, logProbs)
 				last.FinishReason = mapResponsesStatus(string(resp.Status))
 			} else {
 				choices = append(choices, llm.ChatChoice{
-					Index: choiceIdx, FinishReason: mapResponsesStatus(string(resp.Status)), Message: msg,
+					Index: choiceIdx, FinishReason: mapResponsesStatus(string(resp.Status)), Message: msg, LogProbs: logProbs,
 				})
 				choiceIdx++
 			}
@@ -1143,6 +1151,58 @@ func buildResponsesMessage(output responses.ResponseOutputMessage) types.Message
 	return msg
 }
 
+// buildResponsesLogProbs 汇总 output_text 内容块上的 token 对数概率。
+func buildResponsesLogProbs(output responses.ResponseOutputMessage) *types.ChoiceLogProbs {
+	var content []types.TokenLogProb
+	for _, part := range output.Content {
+		if part.Type != "output_text" {
+			continue
+		}
+		for _, lp := range part.Logprobs {
+			token := types.TokenLogProb{
+				Token:   lp.Token,
+				LogProb: lp.Logprob,
+				Bytes:   int64sToInts(lp.Bytes),
+			}
+			for _, top := range lp.TopLogprobs {
+				token.TopLogProbs = append(token.TopLogProbs, types.TopLogProb{
+					Token:   top.Token,
+					LogProb: top.Logprob,
+					Bytes:   int64sToInts(top.Bytes),
+				})
+			}
+			content = append(content, token)
+		}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return &types.ChoiceLogProbs{Content: content}
+}
+
+func mergeChoiceLogProbs(base, extra *types.ChoiceLogProbs) *types.ChoiceLogProbs {
+	if extra == nil {
+		return base
+	}
+	if base == nil {
+		return extra
+	}
+	base.Content = append(base.Content, extra.Content...)
+	base.Refusal = append(base.Refusal, extra.Refusal...)
+	return base
+}
+
+func int64sToInts(values []int64) []int {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]int, len(values))
+	for i, v := range values {
+		out[i] = int(v)
+	}
+	return out
+}
+
 func ensureResponsesAssistantChoice(choices *[]llm.ChatChoice, choiceIdx *int) *llm.ChatChoice {
 	if len(*choices) == 0 || (*choices)[len(*choices)-1].Message.Role != llm.RoleAssistant {
 		*choices = append(*choices, llm.ChatChoice{
@@ -1306,7 +1366,7 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 	}
 	req = rewrittenReq
 
-	promptCacheRetention, cacheErr := providerbase.NormalizeOpenAIPromptCacheRetention(req.PromptCacheRetention, p.Name())
+	promptCacheRetention, cacheErr := providerbase.NormalizeOpenAIPromptCacheRetention(providerbase.ResolvePromptCacheRetention(req), p.Name())
 	if cacheErr != nil {
 		return nil, cacheErr
 	}
@@ -1335,6 +1395,12 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 		PromptTokens: countResponsesPromptTokens(body),
 	})
 
+	if p.openaiCfg.BackgroundStreams {
+		// 后台模式的响应在服务端持续生成，断流后可由 ResumeStream 按 sequence 续传。
+		params.Background = param.NewOpt(true)
+		params.Store = param.NewOpt(true)
+	}
+
 	client := p.sdkClient(ctx)
 	stream := client.Responses.NewStreaming(ctx, params, responseRequestOptions(body)...)
 	if err := stream.Err(); err != nil {
@@ -1344,6 +1410,25 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req *llm.ChatRequest) (<-ch
 	return streamResponsesSDK(ctx, stream, p.Name()), nil
 }
 
+// ResumeStream 实现 llm.StreamResumer：从 token.Sequence 之后继续读取后台模式的 Responses API 流。
+// 未启用 BackgroundStreams 或请求未走 Responses API 时返回错误，由调用方回放续写。
+func (p *OpenAIProvider) ResumeStream(ctx context.Context, req *llm.ChatRequest, token llm.StreamResumeToken) (<-chan llm.StreamChunk, error) {
+	if !p.openaiCfg.BackgroundStreams || !p.useResponsesAPIForRequest(req) {
+		return nil, types.NewServiceUnavailableError("stream resume requires responses API background streams")
+	}
+	if strings.TrimSpace(token.ResponseID) == "" {
+		return nil, types.NewInvalidRequestError("stream resume requires a response id")
+	}
+	client := p.sdkClient(ctx)
+	stream := client.Responses.GetStreaming(ctx, token.ResponseID, responses.ResponseGetParams{
+		StartingAfter: param.NewOpt(int64(token.Sequence)),
+	}, openaisdkoption.WithQuery("stream", "true"))
+	if err := stream.Err(); err != nil {
+		return nil, p.mapSDKError(err)
+	}
+	return streamResponsesSDK(ctx, stream, p.Name()), nil
+}
+
 // streamResponsesSDK parses typed streaming events from the Responses API.
 func streamResponsesSDK(ctx context.Context, stream interface {
 	Next() bool
@@ -1390,7 +1475,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, Content: event.Delta}}:
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, Content: event.Delta}}:
 				}
 
 			case "response.refusal.delta":
@@ -1398,7 +1483,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, Refusal: &delta}}:
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, Refusal: &delta}}:
 				}
 
 			case "response.reasoning_text.delta", "response.reasoning_summary_text.delta":
@@ -1409,7 +1494,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ReasoningContent: stringPtr(delta)}}:
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ReasoningContent: stringPtr(delta)}}:
 				}
 
 			case "response.function_call_arguments.delta", "response.custom_tool_call_input.delta":
@@ -1432,7 +1517,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 					select {
 					case <-ctx.Done():
 						return
-					case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(providerbase.NewCustomToolCall(callID, item.Name, item.Input))}, FinishReason: "tool_calls"}:
+					case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(providerbase.NewCustomToolCall(callID, item.Name, item.Input))}, FinishReason: "tool_calls"}:
 					}
 				}
 
@@ -1458,7 +1543,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, FinishReason: func() string {
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, FinishReason: func() string {
 					if finishSent {
 						return ""
 					}
@@ -1475,7 +1560,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, FinishReason: "stop"}:
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, FinishReason: "stop"}:
 				}
 
 			case "response.function_call_arguments.done":
@@ -1491,7 +1576,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(toolCall)}, FinishReason: func() string {
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(toolCall)}, FinishReason: func() string {
 					if finishSent {
 						return ""
 					}
@@ -1513,7 +1598,7 @@ func streamResponsesSDK(ctx context.Context, stream interface {
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- llm.StreamChunk{ID: currentID, Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(toolCall)}, FinishReason: func() string {
+				case ch <- llm.StreamChunk{ID: currentID, Sequence: int(event.SequenceNumber), Provider: providerName, Model: currentModel, Delta: types.Message{Role: llm.RoleAssistant, ToolCalls: providerbase.ToolCallChunk(toolCall)}, FinishReason: func() string {
 					if finishSent {
 						return ""
 					}
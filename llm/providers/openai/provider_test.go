@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	providerbase "github.com/BaSui01/agentflow/llm/providers/base"
 
@@ -343,3 +344,55 @@ func TestOpenAIProvider_BuildResponsesRequestMapsVerbosityAndPhase(t *testing.T)
 	assert.Equal(t, "high", body.Text.Verbosity)
 	assert.Equal(t, "commentary", body.Phase)
 }
+
+func TestOpenAIProvider_ResumeStream_BackgroundResponses(t *testing.T) {
+	var createBody map[string]any
+	var resumeQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch r.Method {
+		case http.MethodPost:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&createBody))
+			_, _ = fmt.Fprintf(w, "data: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"model\":\"gpt-5.2\"}}\n\n")
+			_, _ = fmt.Fprintf(w, "data: {\"type\":\"response.output_text.delta\",\"sequence_number\":1,\"delta\":\"Hello \"}\n\n")
+			w.(http.Flusher).Flush()
+			// 模拟连接中途断开
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+		case http.MethodGet:
+			assert.Equal(t, "/v1/responses/resp_1", r.URL.Path)
+			resumeQuery = r.URL.RawQuery
+			_, _ = fmt.Fprintf(w, "data: {\"type\":\"response.output_text.delta\",\"sequence_number\":2,\"delta\":\"world\"}\n\n")
+			_, _ = fmt.Fprintf(w, "data: {\"type\":\"response.completed\",\"sequence_number\":3,\"response\":{\"id\":\"resp_1\",\"model\":\"gpt-5.2\",\"status\":\"completed\",\"output\":[]}}\n\n")
+		}
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(providers.OpenAIConfig{
+		BaseProviderConfig: providers.BaseProviderConfig{APIKey: "k", BaseURL: server.URL, Timeout: 5 * time.Second},
+		UseResponsesAPI:    true,
+		BackgroundStreams:  true,
+	}, zap.NewNop())
+	cfg := llm.DefaultResilientStreamConfig()
+	cfg.RetryPolicy.InitialBackoff = time.Millisecond
+	ch, err := llm.ResilientStream(context.Background(), p, &llm.ChatRequest{Messages: []types.Message{{Role: llm.RoleUser, Content: "Hi"}}}, cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	var content string
+	for c := range ch {
+		require.Nil(t, c.Err)
+		content += c.Delta.Content
+	}
+	assert.Equal(t, "Hello world", content)
+	assert.Equal(t, true, createBody["background"])
+	assert.Equal(t, true, createBody["store"])
+	assert.Contains(t, resumeQuery, "starting_after=1")
+	assert.Contains(t, resumeQuery, "stream=true")
+}
+
+func TestOpenAIProvider_ResumeStream_RequiresBackgroundStreams(t *testing.T) {
+	p := NewOpenAIProvider(providers.OpenAIConfig{UseResponsesAPI: true}, zap.NewNop())
+	_, err := p.ResumeStream(context.Background(), &llm.ChatRequest{}, llm.StreamResumeToken{ResponseID: "resp_1", Sequence: 1})
+	require.Error(t, err)
+}
@@ -26,7 +26,7 @@ import (
 	llm "github.com/BaSui01/agentflow/llm/core"
 	"github.com/BaSui01/agentflow/llm/middleware"
 	"github.com/BaSui01/agentflow/llm/providers"
-	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	providertransport "github.com/BaSui01/agentflow/llm/providers/transport"
 	"go.uber.org/zap"
 )
 
@@ -72,6 +72,10 @@ type Config struct {
 	// Defaults to true if not set.
 	SupportsTools *bool
 
+	// Capabilities overrides the chat capability descriptor reported by Capabilities().
+	// If nil, a descriptor is derived from the OpenAI-compatible wire format.
+	Capabilities *llm.CapabilityDescriptor
+
 	// AuthHeaderName 自定义认证头名称。为空时使用默认的 "Authorization: Bearer <key>"。
 	// 设置后使用 "<AuthHeaderName>: <key>"（不加 Bearer 前缀）。
 	AuthHeaderName string
@@ -107,7 +111,7 @@ func New(cfg Config, logger *zap.Logger) *Provider {
 	}
 	return &Provider{
 		Cfg:    cfg,
-		Client: tlsutil.SecureHTTPClient(timeout),
+		Client: providertransport.Client(cfg.ProviderName, timeout),
 		Logger: logger,
 		RewriterChain: middleware.NewRewriterChain(
 			middleware.NewXMLToolRewriter(),
@@ -131,6 +135,22 @@ func (p *Provider) SupportsNativeFunctionCalling() bool {
 	return true
 }
 
+// Capabilities reports the chat capabilities carried by the OpenAI-compatible wire format:
+// image/video content parts, JSON response_format and (unless disabled) streamed tool calls.
+func (p *Provider) Capabilities() llm.CapabilityDescriptor {
+	if p.Cfg.Capabilities != nil {
+		return *p.Cfg.Capabilities
+	}
+	tools := p.SupportsNativeFunctionCalling()
+	return llm.CapabilityDescriptor{
+		SupportsVision:             true,
+		SupportsTools:              tools,
+		SupportsJSONMode:           true,
+		SupportsStreamingToolCalls: tools,
+		Modalities:                 []string{llm.ModalityText, llm.ModalityImage, llm.ModalityVideo},
+	}
+}
+
 // SetBuildHeaders sets custom header builder for the provider.
 func (p *Provider) SetBuildHeaders(fn func(req *http.Request, apiKey string)) {
 	p.Cfg.BuildHeaders = fn
@@ -241,8 +261,7 @@ func (p *Provider) DoJSON(ctx context.Context, method, path string, payload any,
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	if out == nil {
@@ -305,7 +324,7 @@ func (p *Provider) Endpoints() llm.ProviderEndpoints {
 // buildRequestBody constructs the common OpenAI-compatible request body.
 func (p *Provider) buildRequestBody(req *llm.ChatRequest, isStream bool) (providerbase.OpenAICompatRequest, error) {
 	model := providerbase.ChooseModel(req, p.Cfg.DefaultModel, p.Cfg.FallbackModel)
-	promptCacheRetention, err := providerbase.NormalizeOpenAIPromptCacheRetention(req.PromptCacheRetention, p.Name())
+	promptCacheRetention, err := providerbase.NormalizeOpenAIPromptCacheRetention(providerbase.ResolvePromptCacheRetention(req), p.Name())
 	if err != nil {
 		return providerbase.OpenAICompatRequest{}, err
 	}
@@ -331,7 +350,7 @@ func (p *Provider) buildRequestBody(req *llm.ChatRequest, isStream bool) (provid
 		MaxCompletionTokens:  req.MaxCompletionTokens,
 		Store:                req.Store,
 		Modalities:           req.Modalities,
-		PromptCacheKey:       req.PromptCacheKey,
+		PromptCacheKey:       providerbase.ResolvePromptCacheKey(req),
 		PromptCacheRetention: promptCacheRetention,
 		PreviousResponseID:   req.PreviousResponseID,
 		Include:              req.Include,
@@ -436,8 +455,7 @@ func (p *Provider) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan llm
 	}
 	if resp.StatusCode >= 400 {
 		defer resp.Body.Close()
-		msg := providerbase.ReadErrorMessage(resp.Body)
-		return nil, providerbase.MapHTTPError(resp.StatusCode, msg, p.Name())
+		return nil, providerbase.ReadProviderError(resp.StatusCode, resp.Body, p.Name())
 	}
 
 	return providerbase.StreamSSE(ctx, resp.Body, p.Name()), nil
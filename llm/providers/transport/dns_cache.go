@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type lookupFunc func(ctx context.Context, host string) ([]string, error)
+
+type dnsEntry struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// dnsCache 缓存主机名解析结果，避免高并发下每个新连接都触发一次 DNS 查询。
+type dnsCache struct {
+	ttl    time.Duration
+	lookup lookupFunc
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]dnsEntry
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newDNSCache(ttl time.Duration, lookup lookupFunc) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		lookup:  lookup,
+		now:     time.Now,
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[host]
+	c.mu.RUnlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		c.hits.Add(1)
+		return entry.addrs, nil
+	}
+	c.misses.Add(1)
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expiresAt: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+func (c *dnsCache) invalidate(host string) {
+	c.mu.Lock()
+	delete(c.entries, host)
+	c.mu.Unlock()
+}
+
+func (c *dnsCache) stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// dialContext 先查缓存再逐个尝试解析出的地址；全部失败时清除缓存，下次重新解析。
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if c.ttl <= 0 {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		addrs, err := c.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var dialErrs []error
+		for _, ip := range addrs {
+			conn, dialErr := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if dialErr == nil {
+				return conn, nil
+			}
+			dialErrs = append(dialErrs, dialErr)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		c.invalidate(host)
+		return nil, errors.Join(dialErrs...)
+	}
+}
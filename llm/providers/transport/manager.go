@@ -0,0 +1,357 @@
+// Package transport 为所有 LLM Provider 提供共享的 HTTP 传输层：
+// 连接池容量、HTTP/2 keep-alive 探活、DNS 缓存、按 Provider 的代理配置与 TLS 会话复用，
+// 并统计连接复用率和 TLS 握手延迟。
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+)
+
+// ProxyDirect 显式关闭某个 Provider 的代理（不读取环境变量）。
+const ProxyDirect = "direct"
+
+// Config 共享传输层配置。零值字段使用 DefaultConfig 中的默认值。
+type Config struct {
+	MaxIdleConns        int           `json:"max_idle_conns" yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `json:"max_conns_per_host" yaml:"max_conns_per_host"` // 0 表示不限制
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	DialTimeout         time.Duration `json:"dial_timeout" yaml:"dial_timeout"`
+	KeepAlive           time.Duration `json:"keep_alive" yaml:"keep_alive"`
+	TLSHandshakeTimeout time.Duration `json:"tls_handshake_timeout" yaml:"tls_handshake_timeout"`
+	// HTTP2PingInterval 连接空闲多久后发送 HTTP/2 PING 探活，负数关闭探活
+	HTTP2PingInterval time.Duration `json:"http2_ping_interval" yaml:"http2_ping_interval"`
+	// HTTP2PingTimeout PING 未响应多久后关闭连接
+	HTTP2PingTimeout time.Duration `json:"http2_ping_timeout" yaml:"http2_ping_timeout"`
+	// DNSCacheTTL DNS 解析结果缓存时间，负数关闭缓存
+	DNSCacheTTL time.Duration `json:"dns_cache_ttl" yaml:"dns_cache_ttl"`
+	// TLSSessionCacheSize TLS 会话票据缓存容量，用于会话恢复（跳过完整握手）
+	TLSSessionCacheSize int `json:"tls_session_cache_size" yaml:"tls_session_cache_size"`
+	// Proxies 按 Provider 名称配置代理 URL；ProxyDirect 表示直连，未配置时读取环境变量
+	Proxies map[string]string `json:"proxies,omitempty" yaml:"proxies,omitempty"`
+}
+
+// DefaultConfig 返回面向高并发 LLM 调用的默认配置。
+func DefaultConfig() Config {
+	return Config{
+		MaxIdleConns:        256,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         30 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		HTTP2PingInterval:   30 * time.Second,
+		HTTP2PingTimeout:    15 * time.Second,
+		DNSCacheTTL:         time.Minute,
+		TLSSessionCacheSize: 256,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = defaults.IdleConnTimeout
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = defaults.DialTimeout
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = defaults.KeepAlive
+	}
+	if c.TLSHandshakeTimeout <= 0 {
+		c.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	if c.HTTP2PingInterval == 0 {
+		c.HTTP2PingInterval = defaults.HTTP2PingInterval
+	}
+	if c.HTTP2PingTimeout <= 0 {
+		c.HTTP2PingTimeout = defaults.HTTP2PingTimeout
+	}
+	if c.DNSCacheTTL == 0 {
+		c.DNSCacheTTL = defaults.DNSCacheTTL
+	}
+	if c.TLSSessionCacheSize <= 0 {
+		c.TLSSessionCacheSize = defaults.TLSSessionCacheSize
+	}
+	return c
+}
+
+// Validate 校验代理 URL。
+func (c Config) Validate() error {
+	for provider, raw := range c.Proxies {
+		raw = strings.TrimSpace(raw)
+		if raw == "" || strings.EqualFold(raw, ProxyDirect) {
+			continue
+		}
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy url for provider %q: %q", provider, raw)
+		}
+	}
+	return nil
+}
+
+// ProviderStats 单个 Provider 的连接统计。
+type ProviderStats struct {
+	Provider              string        `json:"provider"`
+	Requests              int64         `json:"requests"`
+	ReusedConns           int64         `json:"reused_conns"`
+	NewConns              int64         `json:"new_conns"`
+	TLSHandshakes         int64         `json:"tls_handshakes"`
+	TLSResumed            int64         `json:"tls_resumed"`
+	TLSHandshakeErrors    int64         `json:"tls_handshake_errors"`
+	TotalHandshakeLatency time.Duration `json:"total_handshake_latency"`
+	AvgHandshakeLatency   time.Duration `json:"avg_handshake_latency"`
+	ConnectionReuseRatio  float64       `json:"connection_reuse_ratio"`
+}
+
+// Stats 传输层统计快照。
+type Stats struct {
+	Providers      []ProviderStats `json:"providers"`
+	DNSCacheHits   int64           `json:"dns_cache_hits"`
+	DNSCacheMisses int64           `json:"dns_cache_misses"`
+}
+
+type providerCounters struct {
+	requests           atomic.Int64
+	reused             atomic.Int64
+	created            atomic.Int64
+	handshakes         atomic.Int64
+	resumed            atomic.Int64
+	handshakeErrors    atomic.Int64
+	handshakeLatencyNs atomic.Int64
+}
+
+type providerTransport struct {
+	transport *http.Transport
+	counters  *providerCounters
+}
+
+// Manager 按 Provider 管理共享的 http.Transport。同一 Provider 的所有客户端复用同一个连接池，
+// 所有 Provider 共享 DNS 缓存与 TLS 会话缓存。
+type Manager struct {
+	cfg          Config
+	dns          *dnsCache
+	sessionCache tls.ClientSessionCache
+
+	mu         sync.Mutex
+	transports map[string]*providerTransport
+}
+
+// NewManager 创建传输层管理器。
+func NewManager(cfg Config) (*Manager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults()
+	return &Manager{
+		cfg:          cfg,
+		dns:          newDNSCache(cfg.DNSCacheTTL, net.DefaultResolver.LookupHost),
+		sessionCache: tls.NewLRUClientSessionCache(cfg.TLSSessionCacheSize),
+		transports:   make(map[string]*providerTransport),
+	}, nil
+}
+
+// Client 返回使用 Provider 共享连接池的 http.Client。客户端本身很轻量，可按需创建。
+func (m *Manager) Client(provider string, timeout time.Duration) *http.Client {
+	pt := m.providerTransport(provider)
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &instrumentedRoundTripper{next: pt.transport, counters: pt.counters},
+	}
+}
+
+// Stats 返回各 Provider 的连接复用与握手延迟统计，按 Provider 名称排序。
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.transports))
+	for name := range m.transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	providers := make([]ProviderStats, 0, len(names))
+	for _, name := range names {
+		c := m.transports[name].counters
+		stats := ProviderStats{
+			Provider:              name,
+			Requests:              c.requests.Load(),
+			ReusedConns:           c.reused.Load(),
+			NewConns:              c.created.Load(),
+			TLSHandshakes:         c.handshakes.Load(),
+			TLSResumed:            c.resumed.Load(),
+			TLSHandshakeErrors:    c.handshakeErrors.Load(),
+			TotalHandshakeLatency: time.Duration(c.handshakeLatencyNs.Load()),
+		}
+		if stats.TLSHandshakes > 0 {
+			stats.AvgHandshakeLatency = stats.TotalHandshakeLatency / time.Duration(stats.TLSHandshakes)
+		}
+		if total := stats.ReusedConns + stats.NewConns; total > 0 {
+			stats.ConnectionReuseRatio = float64(stats.ReusedConns) / float64(total)
+		}
+		providers = append(providers, stats)
+	}
+	m.mu.Unlock()
+	hits, misses := m.dns.stats()
+	return Stats{Providers: providers, DNSCacheHits: hits, DNSCacheMisses: misses}
+}
+
+// CloseIdleConnections 关闭所有 Provider 连接池中的空闲连接。
+func (m *Manager) CloseIdleConnections() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, pt := range m.transports {
+		pt.transport.CloseIdleConnections()
+	}
+}
+
+func (m *Manager) providerTransport(provider string) *providerTransport {
+	name := normalizeProvider(provider)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if pt, ok := m.transports[name]; ok {
+		return pt
+	}
+	pt := &providerTransport{transport: m.newTransport(name), counters: &providerCounters{}}
+	m.transports[name] = pt
+	return pt
+}
+
+func (m *Manager) newTransport(provider string) *http.Transport {
+	tlsConfig := tlsutil.DefaultTLSConfig()
+	tlsConfig.ClientSessionCache = m.sessionCache
+	dialer := &net.Dialer{Timeout: m.cfg.DialTimeout, KeepAlive: m.cfg.KeepAlive}
+	transport := &http.Transport{
+		Proxy:                 m.proxyFor(provider),
+		DialContext:           m.dns.dialContext(dialer),
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          m.cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   m.cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       m.cfg.MaxConnsPerHost,
+		IdleConnTimeout:       m.cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   m.cfg.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if m.cfg.HTTP2PingInterval > 0 {
+		transport.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: m.cfg.HTTP2PingInterval,
+			PingTimeout:     m.cfg.HTTP2PingTimeout,
+		}
+	}
+	return transport
+}
+
+func (m *Manager) proxyFor(provider string) func(*http.Request) (*url.URL, error) {
+	raw := ""
+	for name, value := range m.cfg.Proxies {
+		if normalizeProvider(name) == provider {
+			raw = strings.TrimSpace(value)
+			break
+		}
+	}
+	switch {
+	case raw == "":
+		return http.ProxyFromEnvironment
+	case strings.EqualFold(raw, ProxyDirect):
+		return nil
+	}
+	proxyURL, _ := url.Parse(raw) // 已在 Validate 中校验
+	return http.ProxyURL(proxyURL)
+}
+
+func normalizeProvider(provider string) string {
+	name := strings.ToLower(strings.TrimSpace(provider))
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// instrumentedRoundTripper 通过 httptrace 统计连接复用与 TLS 握手，并把上游状态码与响应头
+// 上报给调用方的 UpstreamResponseRecorder，使所有 Provider（含 SDK 客户端）的成功与错误响应
+// 都能参与 API Key 配额耗尽检测。
+type instrumentedRoundTripper struct {
+	next     http.RoundTripper
+	counters *providerCounters
+}
+
+func (rt *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := rt.counters
+	c.requests.Add(1)
+	var handshakeStart atomic.Int64
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.reused.Add(1)
+			} else {
+				c.created.Add(1)
+			}
+		},
+		TLSHandshakeStart: func() {
+			handshakeStart.Store(time.Now().UnixNano())
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				c.handshakeErrors.Add(1)
+				return
+			}
+			c.handshakes.Add(1)
+			if state.DidResume {
+				c.resumed.Add(1)
+			}
+			if start := handshakeStart.Load(); start > 0 {
+				c.handshakeLatencyNs.Add(time.Now().UnixNano() - start)
+			}
+		},
+	}
+	resp, err := rt.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err == nil && resp != nil {
+		llmcore.RecordUpstreamResponse(req.Context(), resp.StatusCode, resp.Header)
+	}
+	return resp, err
+}
+
+var defaultManager atomic.Pointer[Manager]
+
+// Default 返回进程级共享的传输层管理器，首次调用时按 DefaultConfig 创建。
+func Default() *Manager {
+	if m := defaultManager.Load(); m != nil {
+		return m
+	}
+	m, _ := NewManager(DefaultConfig())
+	if defaultManager.CompareAndSwap(nil, m) {
+		return m
+	}
+	return defaultManager.Load()
+}
+
+// SetDefault 替换进程级共享管理器，应在构建 Provider 之前调用；已创建的客户端保持原传输层。
+func SetDefault(m *Manager) {
+	if m != nil {
+		defaultManager.Store(m)
+	}
+}
+
+// Client 使用进程级共享管理器为 Provider 创建 http.Client。
+func Client(provider string, timeout time.Duration) *http.Client {
+	return Default().Client(provider, timeout)
+}
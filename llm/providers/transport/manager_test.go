@@ -0,0 +1,172 @@
+package transport
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, resp.Body.Close())
+}
+
+func TestManager_ProviderClientsShareConnectionPool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	m, err := NewManager(Config{})
+	require.NoError(t, err)
+	get(t, m.Client("OpenAI", time.Second), server.URL)
+	get(t, m.Client("openai", time.Second), server.URL)
+	get(t, m.Client("claude", time.Second), server.URL)
+
+	stats := m.Stats()
+	require.Len(t, stats.Providers, 2)
+	assert.Equal(t, "claude", stats.Providers[0].Provider)
+	openai := stats.Providers[1]
+	assert.Equal(t, int64(2), openai.Requests)
+	assert.Equal(t, int64(1), openai.NewConns)
+	assert.Equal(t, int64(1), openai.ReusedConns)
+	assert.InDelta(t, 0.5, openai.ConnectionReuseRatio, 0.001)
+}
+
+func TestManager_ClientReportsUpstreamResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	m, err := NewManager(Config{})
+	require.NoError(t, err)
+	ctx, recorder := llmcore.WithUpstreamResponseRecorder(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := m.Client("anthropic", time.Second).Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	upstream, ok := recorder.Load()
+	require.True(t, ok, "error responses are reported for quota detection")
+	assert.Equal(t, http.StatusTooManyRequests, upstream.StatusCode)
+	assert.Equal(t, "0", upstream.Header.Get("X-Ratelimit-Remaining-Requests"))
+}
+
+func TestManager_RecordsTLSHandshakes(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	m, err := NewManager(Config{})
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	m.providerTransport("gemini").transport.TLSClientConfig.RootCAs = pool
+
+	client := m.Client("gemini", 5*time.Second)
+	get(t, client, server.URL)
+	get(t, client, server.URL)
+
+	stats := m.Stats().Providers[0]
+	assert.Equal(t, int64(1), stats.TLSHandshakes)
+	assert.Equal(t, int64(1), stats.ReusedConns)
+	assert.Positive(t, stats.AvgHandshakeLatency)
+}
+
+func TestManager_ProxyPerProvider(t *testing.T) {
+	_, err := NewManager(Config{Proxies: map[string]string{"openai": "://bad"}})
+	require.Error(t, err)
+
+	m, err := NewManager(Config{Proxies: map[string]string{
+		"OpenAI": "http://proxy.internal:3128",
+		"qwen":   ProxyDirect,
+	}})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "https://api.openai.com/v1/models", nil)
+
+	proxyURL, err := m.providerTransport("openai").transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxyURL.Host)
+	assert.Nil(t, m.providerTransport("qwen").transport.Proxy)
+	assert.NotNil(t, m.providerTransport("claude").transport.Proxy, "falls back to environment proxy")
+}
+
+func TestManager_HTTP2Tuning(t *testing.T) {
+	m, err := NewManager(Config{HTTP2PingInterval: 10 * time.Second})
+	require.NoError(t, err)
+	tr := m.providerTransport("openai").transport
+	require.NotNil(t, tr.HTTP2)
+	assert.Equal(t, 10*time.Second, tr.HTTP2.SendPingTimeout)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	assert.NotNil(t, tr.TLSClientConfig.ClientSessionCache)
+
+	disabled, err := NewManager(Config{HTTP2PingInterval: -1})
+	require.NoError(t, err)
+	assert.Nil(t, disabled.providerTransport("openai").transport.HTTP2)
+}
+
+func TestDNSCache_CachesUntilExpiry(t *testing.T) {
+	lookups := 0
+	cache := newDNSCache(time.Minute, func(context.Context, string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		addrs, err := cache.resolve(context.Background(), "api.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	}
+	assert.Equal(t, 1, lookups)
+	hits, misses := cache.stats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(1), misses)
+
+	now = now.Add(2 * time.Minute)
+	_, err := cache.resolve(context.Background(), "api.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups)
+}
+
+func TestDNSCache_DialFailureInvalidatesEntry(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, listener.Close())
+
+	cache := newDNSCache(time.Minute, func(context.Context, string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	})
+	dial := cache.dialContext(&net.Dialer{Timeout: time.Second})
+	_, err = dial(context.Background(), "tcp", net.JoinHostPort("closed.example.com", port))
+	require.Error(t, err)
+	cache.mu.RLock()
+	_, cached := cache.entries["closed.example.com"]
+	cache.mu.RUnlock()
+	assert.False(t, cached)
+
+	failing := newDNSCache(time.Minute, func(context.Context, string) ([]string, error) {
+		return nil, errors.New("nxdomain")
+	})
+	_, err = failing.dialContext(&net.Dialer{})(context.Background(), "tcp", "missing.example.com:443")
+	assert.ErrorContains(t, err, "nxdomain")
+}
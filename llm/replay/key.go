@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+)
+
+// KeyFunc 计算请求的回放键
+type KeyFunc func(req *llmcore.ChatRequest, stream bool) string
+
+// RequestKey 默认回放键：对请求做规范化后取 SHA-256。
+// 规范化会清除每次调用都会变化、且不影响模型输出的字段（trace/租户/用户标识、metadata、tags、超时、
+// 消息时间戳与消息 metadata），使同一段对话在不同运行之间得到相同的键。
+func RequestKey(req *llmcore.ChatRequest, stream bool) string {
+	normalized := normalizeRequest(req)
+	payload, err := json.Marshal(struct {
+		Stream  bool                 `json:"stream"`
+		Request *llmcore.ChatRequest `json:"request"`
+	}{Stream: stream, Request: normalized})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeRequest(req *llmcore.ChatRequest) *llmcore.ChatRequest {
+	if req == nil {
+		return nil
+	}
+	cp := *req
+	cp.TraceID = ""
+	cp.TenantID = ""
+	cp.UserID = ""
+	cp.User = ""
+	cp.Metadata = nil
+	cp.Tags = nil
+	cp.Timeout = 0
+	if len(req.Messages) > 0 {
+		cp.Messages = make([]llmcore.Message, len(req.Messages))
+		for i, msg := range req.Messages {
+			msg.Timestamp = time.Time{}
+			msg.Metadata = nil
+			cp.Messages[i] = msg
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,316 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// RecordingOptions 配置 RecordingProvider
+type RecordingOptions struct {
+	// KeyFunc 计算回放键，默认 RequestKey
+	KeyFunc KeyFunc
+	// Redact 在写入存储前处理记录（如脱敏请求中的敏感内容），可为 nil
+	Redact func(record *Record)
+	Logger *zap.Logger
+}
+
+// RecordingProvider 包装真实 provider，将每次请求/响应（含流式分片与错误）写入 Store。
+// 写入失败只记录日志，不影响调用结果。
+type RecordingProvider struct {
+	inner  llmcore.Provider
+	store  Store
+	key    KeyFunc
+	redact func(record *Record)
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewRecordingProvider 创建录制 provider
+func NewRecordingProvider(inner llmcore.Provider, store Store, opts RecordingOptions) *RecordingProvider {
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = RequestKey
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	return &RecordingProvider{
+		inner:  inner,
+		store:  store,
+		key:    opts.KeyFunc,
+		redact: opts.Redact,
+		logger: opts.Logger.With(zap.String("component", "replay_recorder")),
+		now:    time.Now,
+	}
+}
+
+func (p *RecordingProvider) Completion(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
+	start := p.now()
+	resp, err := p.inner.Completion(ctx, req)
+	record := p.newRecord(req, false, start)
+	record.Response = resp
+	record.Error = newRecordedError(err)
+	p.save(ctx, record)
+	return resp, err
+}
+
+func (p *RecordingProvider) Stream(ctx context.Context, req *llmcore.ChatRequest) (<-chan llmcore.StreamChunk, error) {
+	start := p.now()
+	source, err := p.inner.Stream(ctx, req)
+	if err != nil {
+		record := p.newRecord(req, true, start)
+		record.Error = newRecordedError(err)
+		p.save(ctx, record)
+		return nil, err
+	}
+
+	out := make(chan llmcore.StreamChunk)
+	go func() {
+		defer close(out)
+		record := p.newRecord(req, true, start)
+		defer func() {
+			record.Duration = p.now().Sub(start)
+			// 调用方 ctx 可能已结束，录制写入不应因此丢失
+			p.save(context.WithoutCancel(ctx), record)
+		}()
+		for chunk := range source {
+			record.Chunks = append(record.Chunks, chunk)
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// 继续消费上游，避免上游 goroutine 阻塞，同时保留完整录制
+				for rest := range source {
+					record.Chunks = append(record.Chunks, rest)
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *RecordingProvider) newRecord(req *llmcore.ChatRequest, stream bool, start time.Time) *Record {
+	record := &Record{
+		Key:        p.key(req, stream),
+		Provider:   p.inner.Name(),
+		Stream:     stream,
+		Duration:   p.now().Sub(start),
+		RecordedAt: start,
+	}
+	if req != nil {
+		record.Model = req.Model
+		// 拷贝请求，避免 Redact 或调用方后续修改互相影响
+		if cp, err := cloneJSON(req); err == nil {
+			record.Request = cp
+		} else {
+			p.logger.Warn("failed to copy request for replay record", zap.Error(err))
+		}
+	}
+	return record
+}
+
+func (p *RecordingProvider) save(ctx context.Context, record *Record) {
+	if p.redact != nil {
+		p.redact(record)
+	}
+	if err := p.store.Save(ctx, record); err != nil {
+		p.logger.Warn("failed to save replay record",
+			zap.String("key", record.Key),
+			zap.String("model", record.Model),
+			zap.Error(err))
+	}
+}
+
+func (p *RecordingProvider) HealthCheck(ctx context.Context) (*llmcore.HealthStatus, error) {
+	return p.inner.HealthCheck(ctx)
+}
+
+func (p *RecordingProvider) Name() string {
+	return p.inner.Name()
+}
+
+func (p *RecordingProvider) SupportsNativeFunctionCalling() bool {
+	return p.inner.SupportsNativeFunctionCalling()
+}
+
+func (p *RecordingProvider) Capabilities() llmcore.CapabilityDescriptor {
+	return llmcore.DescribeProvider(p.inner)
+}
+
+func (p *RecordingProvider) ListModels(ctx context.Context) ([]llmcore.Model, error) {
+	return p.inner.ListModels(ctx)
+}
+
+func (p *RecordingProvider) Endpoints() llmcore.ProviderEndpoints {
+	return p.inner.Endpoints()
+}
+
+func (p *RecordingProvider) CountTokens(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.TokenCountResponse, error) {
+	tokenCounter, ok := p.inner.(llmcore.TokenCountProvider)
+	if !ok {
+		return nil, types.NewServiceUnavailableError("wrapped provider does not implement native token counting")
+	}
+	return tokenCounter.CountTokens(ctx, req)
+}
+
+// ReplayOptions 配置 ReplayProvider
+type ReplayOptions struct {
+	// Name provider 名称，默认 "replay"
+	Name string
+	// KeyFunc 计算回放键，须与录制时一致，默认 RequestKey
+	KeyFunc KeyFunc
+	// Fallback 未命中录制记录时转发的 provider；为 nil 时返回 ErrRecordNotFound。
+	// 传入 RecordingProvider 可实现"缺失即录制"。
+	Fallback llmcore.Provider
+}
+
+// ReplayProvider 按请求哈希回放录制的响应，不访问任何真实 API。
+// 同一键有多条记录时按录制顺序依次返回，用尽后重复最后一条。
+type ReplayProvider struct {
+	store    Store
+	name     string
+	key      KeyFunc
+	fallback llmcore.Provider
+
+	mu      sync.Mutex
+	cursors map[string]int
+}
+
+// NewReplayProvider 创建回放 provider
+func NewReplayProvider(store Store, opts ReplayOptions) *ReplayProvider {
+	if strings.TrimSpace(opts.Name) == "" {
+		opts.Name = "replay"
+	}
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = RequestKey
+	}
+	return &ReplayProvider{
+		store:    store,
+		name:     opts.Name,
+		key:      opts.KeyFunc,
+		fallback: opts.Fallback,
+		cursors:  make(map[string]int),
+	}
+}
+
+func (p *ReplayProvider) Completion(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
+	record, err := p.next(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return p.fallback.Completion(ctx, req)
+	}
+	if record.Error != nil {
+		return nil, record.Error.Err()
+	}
+	return cloneResponse(record.Response)
+}
+
+func (p *ReplayProvider) Stream(ctx context.Context, req *llmcore.ChatRequest) (<-chan llmcore.StreamChunk, error) {
+	record, err := p.next(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return p.fallback.Stream(ctx, req)
+	}
+	if record.Error != nil && len(record.Chunks) == 0 {
+		return nil, record.Error.Err()
+	}
+
+	chunks := append([]llmcore.StreamChunk(nil), record.Chunks...)
+	out := make(chan llmcore.StreamChunk)
+	go func() {
+		defer close(out)
+		for _, chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// next 取出请求对应的下一条记录；未命中且配置了 Fallback 时返回 (nil, nil)
+func (p *ReplayProvider) next(ctx context.Context, req *llmcore.ChatRequest, stream bool) (*Record, error) {
+	key := p.key(req, stream)
+	records, err := p.store.Load(ctx, key)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrInternalError, "load replay records").WithProvider(p.name)
+	}
+	if len(records) == 0 {
+		if p.fallback != nil {
+			return nil, nil
+		}
+		model := ""
+		if req != nil {
+			model = req.Model
+		}
+		return nil, types.WrapErrorf(ErrRecordNotFound, types.ErrServiceUnavailable,
+			"no recorded response for model %q (key %s)", model, key).
+			WithProvider(p.name).
+			WithRetryable(false)
+	}
+
+	p.mu.Lock()
+	idx := p.cursors[key]
+	if idx < len(records)-1 {
+		p.cursors[key] = idx + 1
+	} else {
+		idx = len(records) - 1
+	}
+	p.mu.Unlock()
+	return records[idx], nil
+}
+
+// Reset 重置所有键的回放游标，使下一轮从第一条记录开始
+func (p *ReplayProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cursors = make(map[string]int)
+}
+
+func (p *ReplayProvider) HealthCheck(ctx context.Context) (*llmcore.HealthStatus, error) {
+	return &llmcore.HealthStatus{Healthy: true}, nil
+}
+
+func (p *ReplayProvider) Name() string { return p.name }
+
+func (p *ReplayProvider) SupportsNativeFunctionCalling() bool { return true }
+
+func (p *ReplayProvider) ListModels(ctx context.Context) ([]llmcore.Model, error) { return nil, nil }
+
+func (p *ReplayProvider) Endpoints() llmcore.ProviderEndpoints { return llmcore.ProviderEndpoints{} }
+
+// cloneResponse 深拷贝录制的响应，避免调用方修改影响后续回放
+func cloneResponse(resp *llmcore.ChatResponse) (*llmcore.ChatResponse, error) {
+	out, err := cloneJSON(resp)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrInternalError, "clone replay response")
+	}
+	return out, nil
+}
+
+func cloneJSON[T any](v *T) (*T, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	calls     int32
+	err       error
+	streamErr error
+}
+
+func (p *fakeProvider) Completion(ctx context.Context, req *llmcore.ChatRequest) (*llmcore.ChatResponse, error) {
+	n := atomic.AddInt32(&p.calls, 1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	content := "answer"
+	if n > 1 {
+		content = "answer again"
+	}
+	return &llmcore.ChatResponse{
+		Provider: "fake",
+		Model:    req.Model,
+		Choices:  []llmcore.ChatChoice{{Message: llmcore.Message{Role: llmcore.RoleAssistant, Content: content}}},
+	}, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, req *llmcore.ChatRequest) (<-chan llmcore.StreamChunk, error) {
+	atomic.AddInt32(&p.calls, 1)
+	if p.streamErr != nil {
+		return nil, p.streamErr
+	}
+	ch := make(chan llmcore.StreamChunk, 2)
+	ch <- llmcore.StreamChunk{Provider: "fake", Model: req.Model, Delta: llmcore.Message{Role: llmcore.RoleAssistant, Content: "hel"}}
+	ch <- llmcore.StreamChunk{Provider: "fake", Model: req.Model, Delta: llmcore.Message{Content: "lo"}, FinishReason: "stop"}
+	close(ch)
+	return ch, nil
+}
+
+func (p *fakeProvider) HealthCheck(ctx context.Context) (*llmcore.HealthStatus, error) {
+	return &llmcore.HealthStatus{Healthy: true}, nil
+}
+func (p *fakeProvider) Name() string                        { return "fake" }
+func (p *fakeProvider) SupportsNativeFunctionCalling() bool { return true }
+func (p *fakeProvider) ListModels(ctx context.Context) ([]llmcore.Model, error) {
+	return nil, nil
+}
+func (p *fakeProvider) Endpoints() llmcore.ProviderEndpoints { return llmcore.ProviderEndpoints{} }
+
+func testRequest(traceID string) *llmcore.ChatRequest {
+	return &llmcore.ChatRequest{
+		TraceID:  traceID,
+		Model:    "gpt-4o",
+		Messages: []llmcore.Message{types.NewUserMessage("hi")},
+	}
+}
+
+func collect(t *testing.T, ch <-chan llmcore.StreamChunk) string {
+	t.Helper()
+	var out string
+	for chunk := range ch {
+		out += chunk.Delta.Content
+	}
+	return out
+}
+
+func TestRecordAndReplay_Completion(t *testing.T) {
+	store := NewMemoryStore()
+	live := &fakeProvider{}
+	recorder := NewRecordingProvider(live, store, RecordingOptions{})
+
+	for i := 0; i < 2; i++ {
+		_, err := recorder.Completion(context.Background(), testRequest("trace-record"))
+		require.NoError(t, err)
+	}
+	require.Len(t, store.Records(), 2)
+
+	replayer := NewReplayProvider(store, ReplayOptions{})
+	first, err := replayer.Completion(context.Background(), testRequest("trace-replay"))
+	require.NoError(t, err)
+	assert.Equal(t, "answer", first.Choices[0].Message.Content)
+
+	second, err := replayer.Completion(context.Background(), testRequest("trace-replay"))
+	require.NoError(t, err)
+	assert.Equal(t, "answer again", second.Choices[0].Message.Content)
+
+	// 记录用尽后重复最后一条
+	third, err := replayer.Completion(context.Background(), testRequest("trace-replay"))
+	require.NoError(t, err)
+	assert.Equal(t, "answer again", third.Choices[0].Message.Content)
+
+	replayer.Reset()
+	again, err := replayer.Completion(context.Background(), testRequest("trace-replay"))
+	require.NoError(t, err)
+	assert.Equal(t, "answer", again.Choices[0].Message.Content)
+
+	// 回放返回拷贝，修改不影响后续回放
+	again.Choices[0].Message.Content = "mutated"
+	replayer.Reset()
+	fresh, err := replayer.Completion(context.Background(), testRequest("trace-replay"))
+	require.NoError(t, err)
+	assert.Equal(t, "answer", fresh.Choices[0].Message.Content)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&live.calls))
+}
+
+func TestRecordAndReplay_Stream(t *testing.T) {
+	store := NewMemoryStore()
+	recorder := NewRecordingProvider(&fakeProvider{}, store, RecordingOptions{})
+
+	ch, err := recorder.Stream(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", collect(t, ch))
+
+	replayer := NewReplayProvider(store, ReplayOptions{})
+	_, err = replayer.Completion(context.Background(), testRequest(""))
+	assert.ErrorIs(t, err, ErrRecordNotFound, "stream and completion use different keys")
+
+	replayed, err := replayer.Stream(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", collect(t, replayed))
+}
+
+func TestRecordAndReplay_Errors(t *testing.T) {
+	store := NewMemoryStore()
+	upstream := types.NewError(types.ErrRateLimit, "slow down").WithHTTPStatus(429).WithRetryable(true)
+	recorder := NewRecordingProvider(&fakeProvider{err: upstream, streamErr: errors.New("dial failed")}, store, RecordingOptions{})
+
+	_, err := recorder.Completion(context.Background(), testRequest(""))
+	require.Error(t, err)
+	_, err = recorder.Stream(context.Background(), testRequest(""))
+	require.Error(t, err)
+
+	replayer := NewReplayProvider(store, ReplayOptions{})
+	_, err = replayer.Completion(context.Background(), testRequest(""))
+	typed, ok := types.AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+	assert.Equal(t, 429, typed.HTTPStatus)
+	assert.True(t, typed.Retryable)
+
+	_, err = replayer.Stream(context.Background(), testRequest(""))
+	assert.True(t, types.IsErrorCode(err, types.ErrUpstreamError))
+}
+
+func TestReplayProvider_MissAndFallback(t *testing.T) {
+	store := NewMemoryStore()
+	replayer := NewReplayProvider(store, ReplayOptions{})
+	_, err := replayer.Completion(context.Background(), testRequest(""))
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	assert.True(t, types.IsErrorCode(err, types.ErrServiceUnavailable))
+
+	// 缺失即录制：未命中时转发给录制 provider，下一次即可离线回放
+	live := &fakeProvider{}
+	replayer = NewReplayProvider(store, ReplayOptions{Fallback: NewRecordingProvider(live, store, RecordingOptions{})})
+	_, err = replayer.Completion(context.Background(), testRequest(""))
+	require.NoError(t, err)
+
+	offline := NewReplayProvider(store, ReplayOptions{})
+	resp, err := offline.Completion(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "answer", resp.Choices[0].Message.Content)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&live.calls))
+}
+
+func TestRecordingProvider_RedactDoesNotTouchCallerRequest(t *testing.T) {
+	store := NewMemoryStore()
+	recorder := NewRecordingProvider(&fakeProvider{}, store, RecordingOptions{
+		Redact: func(record *Record) {
+			for i := range record.Request.Messages {
+				record.Request.Messages[i].Content = "[redacted]"
+			}
+		},
+	})
+
+	req := testRequest("")
+	_, err := recorder.Completion(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hi", req.Messages[0].Content)
+	records := store.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "[redacted]", records[0].Request.Messages[0].Content)
+	assert.Equal(t, "fake", records[0].Provider)
+	assert.Equal(t, "gpt-4o", records[0].Model)
+}
@@ -0,0 +1,203 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
+)
+
+// ErrRecordNotFound 回放时找不到与请求匹配的录制记录
+var ErrRecordNotFound = errors.New("replay: no recorded response for request")
+
+// Record 一次 provider 调用的录制结果
+type Record struct {
+	Key        string                `json:"key"`
+	Provider   string                `json:"provider,omitempty"`
+	Model      string                `json:"model,omitempty"`
+	Stream     bool                  `json:"stream,omitempty"`
+	Request    *llmcore.ChatRequest  `json:"request,omitempty"`
+	Response   *llmcore.ChatResponse `json:"response,omitempty"`
+	Chunks     []llmcore.StreamChunk `json:"chunks,omitempty"`
+	Error      *RecordedError        `json:"error,omitempty"`
+	Duration   time.Duration         `json:"duration,omitempty"`
+	RecordedAt time.Time             `json:"recorded_at"`
+}
+
+// RecordedError 录制的调用错误，回放时还原为 *types.Error
+type RecordedError struct {
+	Code       types.ErrorCode `json:"code"`
+	Message    string          `json:"message"`
+	HTTPStatus int             `json:"http_status,omitempty"`
+	Retryable  bool            `json:"retryable,omitempty"`
+	Provider   string          `json:"provider,omitempty"`
+}
+
+func newRecordedError(err error) *RecordedError {
+	if err == nil {
+		return nil
+	}
+	if typed, ok := types.AsError(err); ok {
+		return &RecordedError{
+			Code:       typed.Code,
+			Message:    typed.Message,
+			HTTPStatus: typed.HTTPStatus,
+			Retryable:  typed.Retryable,
+			Provider:   typed.Provider,
+		}
+	}
+	return &RecordedError{Code: types.ErrUpstreamError, Message: err.Error()}
+}
+
+// Err 还原为 *types.Error
+func (e *RecordedError) Err() *types.Error {
+	if e == nil {
+		return nil
+	}
+	return types.NewError(e.Code, e.Message).
+		WithHTTPStatus(e.HTTPStatus).
+		WithRetryable(e.Retryable).
+		WithProvider(e.Provider)
+}
+
+// Store 录制记录的存储后端
+// 同一 key 可对应多条记录（同一请求被多次调用），Load 按录制顺序返回
+type Store interface {
+	Save(ctx context.Context, record *Record) error
+	Load(ctx context.Context, key string) ([]*Record, error)
+}
+
+// MemoryStore 进程内存储，适合单元测试
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string][]*Record
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]*Record)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, record *Record) error {
+	if record == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Key] = append(s.records[record.Key], record)
+	return nil
+}
+
+func (s *MemoryStore) Load(_ context.Context, key string) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Record(nil), s.records[key]...), nil
+}
+
+// Records 返回全部录制记录，便于测试断言或导出
+func (s *MemoryStore) Records() []*Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Record
+	for _, records := range s.records {
+		out = append(out, records...)
+	}
+	return out
+}
+
+// FileStore 以 JSON Lines 追加写入单个文件，可直接提交到仓库作为回归测试夹具，
+// 也可从生产环境导出后离线回放
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+	// index 首次 Load 时从文件构建，Save 时同步更新
+	index map[string][]*Record
+}
+
+// NewFileStore 创建文件存储；文件不存在时在首次 Save 时创建
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Save(_ context.Context, record *Record) error {
+	if record == nil {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal replay record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create replay store dir: %w", err)
+		}
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open replay store: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write replay record: %w", err)
+	}
+	if s.index != nil {
+		s.index[record.Key] = append(s.index[record.Key], record)
+	}
+	return nil
+}
+
+func (s *FileStore) Load(_ context.Context, key string) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil {
+		index, err := s.readAll()
+		if err != nil {
+			return nil, err
+		}
+		s.index = index
+	}
+	return append([]*Record(nil), s.index[key]...), nil
+}
+
+func (s *FileStore) readAll() (map[string][]*Record, error) {
+	index := make(map[string][]*Record)
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open replay store: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("decode replay record at line %d: %w", line, err)
+		}
+		index[record.Key] = append(index[record.Key], &record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read replay store: %w", err)
+	}
+	return index, nil
+}
@@ -0,0 +1,79 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestKey_IgnoresVolatileFields(t *testing.T) {
+	a := testRequest("trace-a")
+	b := testRequest("trace-b")
+	b.TenantID = "tenant"
+	b.Metadata = map[string]string{"request_id": "r-1"}
+	b.Messages[0].Timestamp = time.Now().Add(time.Hour)
+
+	assert.Equal(t, RequestKey(a, false), RequestKey(b, false))
+	assert.NotEqual(t, RequestKey(a, false), RequestKey(a, true))
+
+	b.Temperature = 0.5
+	assert.NotEqual(t, RequestKey(a, false), RequestKey(b, false))
+
+	// 规范化不修改原请求
+	assert.Equal(t, "trace-b", b.TraceID)
+	assert.False(t, b.Messages[0].Timestamp.IsZero())
+}
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "session.jsonl")
+	store := NewFileStore(path)
+
+	recorder := NewRecordingProvider(&fakeProvider{}, store, RecordingOptions{})
+	_, err := recorder.Completion(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	ch, err := recorder.Stream(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	collect(t, ch)
+
+	// 新实例从文件加载，模拟离线回放
+	replayer := NewReplayProvider(NewFileStore(path), ReplayOptions{})
+	resp, err := replayer.Completion(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "answer", resp.Choices[0].Message.Content)
+
+	replayed, err := replayer.Stream(context.Background(), testRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", collect(t, replayed))
+}
+
+func TestFileStore_MissingFileAndCorruptLine(t *testing.T) {
+	dir := t.TempDir()
+	records, err := NewFileStore(filepath.Join(dir, "missing.jsonl")).Load(context.Background(), "k")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	corrupt := filepath.Join(dir, "corrupt.jsonl")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{\"key\":\"k\"}\nnot-json\n"), 0o644))
+	_, err = NewFileStore(corrupt).Load(context.Background(), "k")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestFileStore_SaveUpdatesLoadedIndex(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "s.jsonl"))
+	ctx := context.Background()
+	_, err := store.Load(ctx, "k")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save(ctx, &Record{Key: "k", Response: &llmcore.ChatResponse{Model: "m"}}))
+	records, err := store.Load(ctx, "k")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "m", records[0].Response.Model)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/BaSui01/agentflow/llm/observability"
 	llmpolicy "github.com/BaSui01/agentflow/llm/runtime/policy"
 	llmrouter "github.com/BaSui01/agentflow/llm/runtime/router"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -24,11 +25,28 @@ type Runtime struct {
 	Provider      llmcore.Provider
 	ToolProvider  llmcore.Provider
 	BudgetManager *llmpolicy.TokenBudgetManager
-	CostTracker   *observability.CostTracker
-	Ledger        observability.Ledger
-	Cache         *cache.MultiLevelCache
-	Metrics       *observability.Metrics
+	// CostCalculator 是 CostTracker 与 UsageLedger 共用的价格表，可在运行期用 UpdatePrices 导入定价。
+	CostCalculator *observability.CostCalculator
+	CostTracker    *observability.CostTracker
+	Ledger         observability.Ledger
+	// UsageLedger 仅在 Config.UsageStore 非空时创建，已并入 Ledger；
+	// 日报汇总由调用方基于 UsageLedger.Store() 调度。
+	UsageLedger *observability.UsageLedger
+	Cache       *cache.MultiLevelCache
+	Metrics     *observability.Metrics
+	// StreamLatency aggregates TTFT, inter-token latency and tokens/sec per
+	// provider+model for streaming calls made through Provider.
+	StreamLatency *observability.StreamLatencyTracker
+	// PromptTraffic/CacheWarmer 仅在 Cache.Warmup.Enabled 时创建；
+	// 预热调度由调用方通过 go CacheWarmer.Start(ctx) 启动并随 ctx 结束。
+	PromptTraffic *observability.PromptTrafficStore
+	CacheWarmer   *cache.CacheWarmer
 	PolicyManager *llmpolicy.Manager
+	// RateLimiter 仅在 Config.RateLimit.Client 非空时创建，Completion 与 Stream 共用。
+	RateLimiter *llmmw.RedisRateLimiter
+	// PriorityScheduler 仅在 Config.Priority.Enabled 时创建，位于 Gateway 之前；
+	// 独立的工具 gateway 使用同配置的另一个调度器。
+	PriorityScheduler *llmgateway.PriorityScheduler
 }
 
 // Config controls runtime composition around an already-constructed main
@@ -39,6 +57,36 @@ type Config struct {
 	Budget     BudgetConfig
 	Cache      CacheConfig
 	Tool       ToolProviderConfig
+
+	// Capabilities 可选：gateway 调用 chat provider 前按能力描述协商请求。
+	Capabilities *llmcore.CapabilityRegistry
+
+	// UsageStore 可选：gateway 落账同时写入按租户/模型/日统计的用量账本。
+	UsageStore observability.UsageStore
+
+	// ExtraLedgers 可选：与默认落账链路并行写入的附加账本（如按运行归集用量的 RunRecorder）。
+	ExtraLedgers []observability.Ledger
+
+	// RateLimit 可选：基于 Redis 的跨副本限流。
+	RateLimit RateLimitConfig
+
+	// Priority 可选：在 gateway 之前按请求优先级排队与准入。
+	Priority PriorityConfig
+}
+
+// PriorityConfig controls the gateway priority scheduler. Requests are
+// classified by types.WithRequestPriority on the context.
+type PriorityConfig struct {
+	Enabled   bool
+	Scheduler llmgateway.PrioritySchedulerConfig
+}
+
+// RateLimitConfig controls distributed (Redis-backed) rate limiting. It is
+// disabled while Client is nil.
+type RateLimitConfig struct {
+	Client *redis.Client
+	// Limiter 的 Provider、Logger 为空时分别默认为主 provider 名与 Build 的 logger。
+	Limiter llmmw.DistributedRateLimitConfig
 }
 
 // BudgetConfig controls token and cost policy assembly.
@@ -50,6 +98,11 @@ type BudgetConfig struct {
 	MaxTokensPerDay     int
 	MaxCostPerRequest   float64
 	MaxCostPerDay       float64
+	MaxTokensPerMonth   int
+	MaxCostPerMonth     float64
+	MaxTokensPerQuarter int
+	MaxCostPerQuarter   float64
+	Timezone            string
 	AlertThreshold      float64
 	AutoThrottle        bool
 	ThrottleDelay       time.Duration
@@ -63,6 +116,25 @@ type CacheConfig struct {
 	EnableRedis  bool
 	RedisTTL     time.Duration
 	KeyStrategy  string
+
+	IsolateByUser  bool
+	TenantPolicies map[string]cache.TenantCachePolicy
+
+	Warmup CacheWarmupConfig
+}
+
+// CacheWarmupConfig controls replaying frequent historical prompts into the
+// cache during off-peak hours.
+type CacheWarmupConfig struct {
+	Enabled          bool
+	TrafficCapacity  int // 采样的请求指纹上限，默认 10000
+	TopN             int
+	Concurrency      int
+	ModelVersion     string
+	Regenerate       bool
+	OffPeakStartHour int
+	OffPeakEndHour   int
+	CheckInterval    time.Duration
 }
 
 // ToolProviderConfig describes an optional dedicated tool-calling provider. If
@@ -107,8 +179,17 @@ func Build(cfg Config, mainProvider llmcore.Provider, logger *zap.Logger) (*Runt
 		llmMetrics = metrics
 	}
 
-	costTracker := observability.NewCostTracker(observability.NewCostCalculator())
-	ledger := observability.NewCostTrackerLedger(costTracker)
+	costCalculator := observability.NewCostCalculator()
+	costTracker := observability.NewCostTracker(costCalculator)
+	var ledger observability.Ledger = observability.NewCostTrackerLedger(costTracker)
+	var usageLedger *observability.UsageLedger
+	if cfg.UsageStore != nil {
+		usageLedger = observability.NewUsageLedger(cfg.UsageStore, costCalculator, logger)
+		ledger = observability.NewFanoutLedger(ledger, usageLedger)
+	}
+	if len(cfg.ExtraLedgers) > 0 {
+		ledger = observability.NewFanoutLedger(append([]observability.Ledger{ledger}, cfg.ExtraLedgers...)...)
+	}
 
 	var budgetManager *llmpolicy.TokenBudgetManager
 	if cfg.Budget.Enabled {
@@ -119,6 +200,11 @@ func Build(cfg Config, mainProvider llmcore.Provider, logger *zap.Logger) (*Runt
 			MaxTokensPerDay:     cfg.Budget.MaxTokensPerDay,
 			MaxCostPerRequest:   cfg.Budget.MaxCostPerRequest,
 			MaxCostPerDay:       cfg.Budget.MaxCostPerDay,
+			MaxTokensPerMonth:   cfg.Budget.MaxTokensPerMonth,
+			MaxCostPerMonth:     cfg.Budget.MaxCostPerMonth,
+			MaxTokensPerQuarter: cfg.Budget.MaxTokensPerQuarter,
+			MaxCostPerQuarter:   cfg.Budget.MaxCostPerQuarter,
+			Timezone:            cfg.Budget.Timezone,
 			AlertThreshold:      cfg.Budget.AlertThreshold,
 			AutoThrottle:        cfg.Budget.AutoThrottle,
 			ThrottleDelay:       cfg.Budget.ThrottleDelay,
@@ -140,6 +226,8 @@ func Build(cfg Config, mainProvider llmcore.Provider, logger *zap.Logger) (*Runt
 			EnableRedis:     cfg.Cache.EnableRedis,
 			RedisTTL:        cfg.Cache.RedisTTL,
 			KeyStrategyType: cfg.Cache.KeyStrategy,
+			IsolateByUser:   cfg.Cache.IsolateByUser,
+			TenantPolicies:  cfg.Cache.TenantPolicies,
 		}, logger)
 		logger.Info("LLM cache initialized")
 	}
@@ -154,8 +242,40 @@ func Build(cfg Config, mainProvider llmcore.Provider, logger *zap.Logger) (*Runt
 	if llmMetrics != nil {
 		chain.Use(llmmw.MetricsMiddleware(&llmmw.OtelMetricsAdapter{Metrics: llmMetrics}))
 	}
+	var promptTraffic *observability.PromptTrafficStore
+	var cacheWarmer *cache.CacheWarmer
 	if llmCache != nil {
 		chain.Use(llmmw.CacheMiddleware(&llmmw.PromptCacheAdapter{Cache: llmCache}))
+		if cfg.Cache.Warmup.Enabled {
+			// 预热直接调用缓存中间件之下的 provider，避免回放请求读到旧条目。
+			uncached := provider
+			promptTraffic = observability.NewPromptTrafficStore(cfg.Cache.Warmup.TrafficCapacity)
+			cacheWarmer = cache.NewCacheWarmer(llmCache, cache.NewTrafficWarmupSource(promptTraffic), uncached.Completion, cache.CacheWarmerConfig{
+				TopN:             cfg.Cache.Warmup.TopN,
+				Concurrency:      cfg.Cache.Warmup.Concurrency,
+				ModelVersion:     cfg.Cache.Warmup.ModelVersion,
+				Regenerate:       cfg.Cache.Warmup.Regenerate,
+				OffPeakStartHour: cfg.Cache.Warmup.OffPeakStartHour,
+				OffPeakEndHour:   cfg.Cache.Warmup.OffPeakEndHour,
+				CheckInterval:    cfg.Cache.Warmup.CheckInterval,
+			}, logger)
+		}
+	}
+	var rateLimiter *llmmw.RedisRateLimiter
+	if cfg.RateLimit.Client != nil {
+		limiterCfg := cfg.RateLimit.Limiter
+		if limiterCfg.Provider == "" {
+			limiterCfg.Provider = mainProvider.Name()
+		}
+		if limiterCfg.Logger == nil {
+			limiterCfg.Logger = logger
+		}
+		rateLimiter = llmmw.NewRedisRateLimiter(cfg.RateLimit.Client, limiterCfg)
+		// 位于缓存之后：命中缓存的请求不占用上游配额。
+		chain.Use(llmmw.DistributedRateLimitMiddleware(rateLimiter))
+		logger.Info("Distributed rate limiter initialized",
+			zap.String("provider", limiterCfg.Provider),
+			zap.Bool("dry_run", limiterCfg.DryRun))
 	}
 	cleaner := llmmw.NewEmptyToolsCleaner()
 	chain.UseFront(llmmw.TransformMiddleware(func(req *llmcore.ChatRequest) {
@@ -166,38 +286,77 @@ func Build(cfg Config, mainProvider llmcore.Provider, logger *zap.Logger) (*Runt
 		}
 	}, nil))
 
-	provider = llmmw.NewMiddlewareProvider(provider, chain)
-	gateway := llmgateway.New(llmgateway.Config{
-		ChatProvider:  provider,
-		Ledger:        ledger,
-		PolicyManager: policyManager,
-		Logger:        logger,
+	streamLatency := observability.NewStreamLatencyTracker(0)
+	metricsAdapter := &llmmw.OtelMetricsAdapter{Metrics: llmMetrics}
+	providerName := provider.Name()
+	provider = llmmw.NewMiddlewareProvider(provider, chain).WithRateLimiter(rateLimiter).WithStreamObserver(
+		func(ctx context.Context, req *llmcore.ChatRequest, timing observability.StreamTiming) {
+			if timing.Provider == "" {
+				timing.Provider = providerName
+			}
+			streamLatency.Record(timing.Provider, timing.Model, timing)
+			if llmMetrics != nil {
+				metricsAdapter.RecordStreamTiming(ctx, req, timing)
+			}
+		})
+	// 流式中途断开时按重试策略续流，续流次数与请求重试次数一致。
+	streamResume := llmcore.DefaultResilientStreamConfig()
+	streamResume.RetryPolicy = retryPolicy
+	streamResume.MaxResumes = retryPolicy.MaxRetries
+	var gateway llmcore.Gateway = llmgateway.New(llmgateway.Config{
+		ChatProvider:       provider,
+		Ledger:             ledger,
+		PolicyManager:      policyManager,
+		Logger:             logger,
+		PromptTraffic:      promptTraffic,
+		CapabilityRegistry: cfg.Capabilities,
+		StreamResume:       streamResume,
 	})
+	var priorityScheduler *llmgateway.PriorityScheduler
+	if cfg.Priority.Enabled {
+		priorityScheduler = llmgateway.NewPriorityScheduler(gateway, cfg.Priority.Scheduler, logger)
+		gateway = priorityScheduler
+		logger.Info("Gateway priority scheduler initialized",
+			zap.Int("max_concurrent", cfg.Priority.Scheduler.MaxConcurrent))
+	}
 	providerAdapter := llmgateway.NewChatProviderAdapter(gateway, provider)
 	toolProvider := buildToolProviderOrFallback(cfg, logger, provider)
 	toolProviderAdapter := providerAdapter
 	toolGateway := gateway
 	if toolProvider != nil && toolProvider != provider {
 		toolGateway = llmgateway.New(llmgateway.Config{
-			ChatProvider:  toolProvider,
-			Ledger:        ledger,
-			PolicyManager: policyManager,
-			Logger:        logger,
+			ChatProvider:       toolProvider,
+			Ledger:             ledger,
+			PolicyManager:      policyManager,
+			Logger:             logger,
+			CapabilityRegistry: cfg.Capabilities,
+			StreamResume:       streamResume,
 		})
+		if cfg.Priority.Enabled {
+			toolGateway = llmgateway.NewPriorityScheduler(toolGateway, cfg.Priority.Scheduler, logger)
+		}
 		toolProviderAdapter = llmgateway.NewChatProviderAdapter(toolGateway, toolProvider)
 	}
 
 	return &Runtime{
-		Gateway:       gateway,
-		ToolGateway:   toolGateway,
-		Provider:      providerAdapter,
-		ToolProvider:  toolProviderAdapter,
-		BudgetManager: budgetManager,
-		CostTracker:   costTracker,
-		Ledger:        ledger,
-		Cache:         llmCache,
-		Metrics:       llmMetrics,
-		PolicyManager: policyManager,
+		Gateway:        gateway,
+		ToolGateway:    toolGateway,
+		Provider:       providerAdapter,
+		ToolProvider:   toolProviderAdapter,
+		BudgetManager:  budgetManager,
+		CostCalculator: costCalculator,
+		CostTracker:    costTracker,
+		Ledger:         ledger,
+		UsageLedger:    usageLedger,
+		Cache:          llmCache,
+		Metrics:        llmMetrics,
+		StreamLatency:  streamLatency,
+		PolicyManager:  policyManager,
+		PromptTraffic:  promptTraffic,
+		CacheWarmer:    cacheWarmer,
+		RateLimiter:    rateLimiter,
+
+		PriorityScheduler: priorityScheduler,
 	}, nil
 }
 
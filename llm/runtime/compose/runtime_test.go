@@ -7,8 +7,12 @@ import (
 	"time"
 
 	llm "github.com/BaSui01/agentflow/llm/core"
+	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	llmmw "github.com/BaSui01/agentflow/llm/middleware"
 	llmpolicy "github.com/BaSui01/agentflow/llm/runtime/policy"
 	"github.com/BaSui01/agentflow/types"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -140,6 +144,94 @@ func TestBuild_ReusesMainProviderAndSharedAssembly(t *testing.T) {
 	require.Nil(t, provider.lastRequest)
 }
 
+func TestBuild_CacheWarmupRecordsTrafficAndBuildsWarmer(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{content: "hello"}
+	runtime, err := Build(Config{
+		Timeout: 2 * time.Second,
+		Cache: CacheConfig{
+			Enabled:      true,
+			LocalMaxSize: 32,
+			LocalTTL:     time.Minute,
+			Warmup:       CacheWarmupConfig{Enabled: true, ModelVersion: "v2", Regenerate: true},
+		},
+	}, provider, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, runtime.CacheWarmer)
+	require.NotNil(t, runtime.PromptTraffic)
+
+	req := &llm.ChatRequest{Model: "gpt-4o-mini", Messages: []types.Message{{Role: types.RoleUser, Content: "hello"}}}
+	_, err = runtime.Provider.Completion(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, 1, runtime.PromptTraffic.Len())
+
+	report, err := runtime.CacheWarmer.WarmOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Regenerated, "live entries without a model version are refreshed")
+	entry, err := runtime.Cache.Get(context.Background(), runtime.Cache.GenerateKey(req))
+	require.NoError(t, err)
+	require.Equal(t, "v2", entry.ModelVersion)
+}
+
+func TestBuild_DistributedRateLimitCoversCompletionAndStream(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	provider := &countingProvider{content: "hello"}
+	runtime, err := Build(Config{
+		Timeout: 2 * time.Second,
+		RateLimit: RateLimitConfig{
+			Client: client,
+			Limiter: llmmw.DistributedRateLimitConfig{
+				DefaultQuota: llmmw.RateLimitQuota{RequestsPerSecond: 1},
+			},
+		},
+	}, provider, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, runtime.RateLimiter)
+
+	ctx := types.WithTenantID(context.Background(), "t1")
+	req := &llm.ChatRequest{Model: "gpt-4o-mini", Messages: []types.Message{{Role: types.RoleUser, Content: "hello"}}}
+	_, err = runtime.Provider.Completion(ctx, req)
+	require.NoError(t, err)
+
+	_, err = runtime.Provider.Stream(ctx, req)
+	var typed *types.Error
+	require.ErrorAs(t, err, &typed)
+	require.Equal(t, types.ErrRateLimit, typed.Code)
+	require.Equal(t, 1, provider.completionCalls)
+	require.Greater(t, len(mr.Keys()), 0, "counters are kept in redis")
+}
+
+func TestBuild_PrioritySchedulerFrontsGateway(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{content: "hello"}
+	runtime, err := Build(Config{
+		Timeout:  2 * time.Second,
+		Priority: PriorityConfig{Enabled: true},
+	}, provider, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, runtime.PriorityScheduler)
+	require.Same(t, runtime.PriorityScheduler, runtime.Gateway)
+
+	req := &llm.ChatRequest{Model: "gpt-4o-mini", Messages: []types.Message{{Role: types.RoleUser, Content: "hello"}}}
+	_, err = runtime.Provider.Completion(context.Background(), req)
+	require.NoError(t, err)
+	stream, err := runtime.Provider.Stream(types.WithRequestPriority(context.Background(), "batch"), req)
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	stats := runtime.PriorityScheduler.Stats()
+	require.EqualValues(t, 1, stats[llmgateway.PriorityInteractive].Admitted)
+	require.EqualValues(t, 1, stats[llmgateway.PriorityBatch].Admitted)
+}
+
 func TestBuild_RequiresMainProvider(t *testing.T) {
 	t.Parallel()
 
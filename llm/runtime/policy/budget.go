@@ -11,15 +11,22 @@ import (
 
 // 预算Config 配置符号预算管理 。
 type BudgetConfig struct {
-	MaxTokensPerRequest int           `json:"max_tokens_per_request"`
-	MaxTokensPerMinute  int           `json:"max_tokens_per_minute"`
-	MaxTokensPerHour    int           `json:"max_tokens_per_hour"`
-	MaxTokensPerDay     int           `json:"max_tokens_per_day"`
-	MaxCostPerRequest   float64       `json:"max_cost_per_request"`
-	MaxCostPerDay       float64       `json:"max_cost_per_day"`
-	AlertThreshold      float64       `json:"alert_threshold"` // 0.0-1.0, alert when usage exceeds this
-	AutoThrottle        bool          `json:"auto_throttle"`
-	ThrottleDelay       time.Duration `json:"throttle_delay"`
+	MaxTokensPerRequest int     `json:"max_tokens_per_request"`
+	MaxTokensPerMinute  int     `json:"max_tokens_per_minute"`
+	MaxTokensPerHour    int     `json:"max_tokens_per_hour"`
+	MaxTokensPerDay     int     `json:"max_tokens_per_day"`
+	MaxCostPerRequest   float64 `json:"max_cost_per_request"`
+	MaxCostPerDay       float64 `json:"max_cost_per_day"`
+	// 日历月/季度上限，<=0 表示不限制；按 Timezone 在自然月/季度起点重置
+	MaxTokensPerMonth   int     `json:"max_tokens_per_month,omitempty"`
+	MaxCostPerMonth     float64 `json:"max_cost_per_month,omitempty"`
+	MaxTokensPerQuarter int     `json:"max_tokens_per_quarter,omitempty"`
+	MaxCostPerQuarter   float64 `json:"max_cost_per_quarter,omitempty"`
+	// Timezone 日/月/季度窗口重置所用的 IANA 时区，默认 UTC
+	Timezone       string        `json:"timezone,omitempty"`
+	AlertThreshold float64       `json:"alert_threshold"` // 0.0-1.0, alert when usage exceeds this
+	AutoThrottle   bool          `json:"auto_throttle"`
+	ThrottleDelay  time.Duration `json:"throttle_delay"`
 }
 
 // 默认预览返回合理的默认值 。
@@ -60,6 +67,8 @@ type BudgetStatus struct {
 	CostUtilization   float64    `json:"cost_utilization"`
 	IsThrottled       bool       `json:"is_throttled"`
 	ThrottleUntil     *time.Time `json:"throttle_until,omitempty"`
+	// Periods 已配置上限的月/季度窗口状态，含周期末预测用量
+	Periods map[BudgetPeriod]PeriodStatus `json:"periods,omitempty"`
 }
 
 // 提醒Type代表预算提醒的类型.
@@ -71,6 +80,12 @@ const (
 	AlertTokenDay    AlertType = "token_day_threshold"
 	AlertCostDay     AlertType = "cost_day_threshold"
 	AlertLimitHit    AlertType = "limit_hit"
+
+	AlertTokenMonth     AlertType = "token_month_threshold"
+	AlertCostMonth      AlertType = "cost_month_threshold"
+	AlertTokenQuarter   AlertType = "token_quarter_threshold"
+	AlertCostQuarter    AlertType = "cost_quarter_threshold"
+	AlertCostProjection AlertType = "cost_projected_overrun"
 )
 
 const defaultAlertHandlerTimeout = 5 * time.Second
@@ -82,6 +97,14 @@ type Alert struct {
 	Threshold float64   `json:"threshold"`
 	Current   float64   `json:"current"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// 以下字段仅月/季度告警填充：Used/Limit/Projected 与告警维度同单位（token 或成本），
+	// Projected 为按当前消耗速率外推的周期末用量。
+	Period    BudgetPeriod `json:"period,omitempty"`
+	Used      float64      `json:"used,omitempty"`
+	Limit     float64      `json:"limit,omitempty"`
+	Projected float64      `json:"projected,omitempty"`
+	PeriodEnd *time.Time   `json:"period_end,omitempty"`
 }
 
 // 警报汉德勒处理预算警报.
@@ -92,6 +115,8 @@ type TokenBudgetManager struct {
 	config        BudgetConfig
 	logger        *zap.Logger
 	alertHandlers []AlertHandler
+	location      *time.Location
+	now           func() time.Time
 
 	// 计数器 — 所有访问必须持有 mu 锁，不再使用裸 atomic 操作
 	tokensMinute int64
@@ -104,6 +129,10 @@ type TokenBudgetManager struct {
 	hourStart   time.Time
 	dayStart    time.Time
 
+	// 日历月/季度窗口
+	month   calendarWindow
+	quarter calendarWindow
+
 	// 调弦
 	throttleUntil time.Time
 	mu            sync.Mutex // 统一使用 Mutex（非 RWMutex），所有计数器访问均需持锁
@@ -124,15 +153,47 @@ func NewTokenBudgetManager(config BudgetConfig, logger *zap.Logger) *TokenBudget
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	loc := loadBudgetLocation(config.Timezone, logger)
 	return &TokenBudgetManager{
 		config:      config,
 		logger:      logger,
+		location:    loc,
+		now:         time.Now,
 		minuteStart: now,
 		hourStart:   now,
-		dayStart:    now.Truncate(24 * time.Hour),
+		dayStart:    dayStartIn(now, loc),
+		month:       newCalendarWindow(BudgetPeriodMonth, now, loc, config),
+		quarter:     newCalendarWindow(BudgetPeriodQuarter, now, loc, config),
 	}
 }
 
+// UpdateConfig 运行期调整预算配置。
+// 月/季度上限在当前周期内按比例折算：已过去部分沿用旧上限，剩余部分采用新上限，
+// 下一周期起完全采用新上限；时区变更同样从下一周期起生效。
+func (m *TokenBudgetManager) UpdateConfig(config BudgetConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resetWindowsLocked()
+
+	now := m.now()
+	for _, w := range m.periodWindowsLocked() {
+		w.prorate(now, config)
+	}
+	if config.Timezone != m.config.Timezone {
+		m.location = loadBudgetLocation(config.Timezone, m.logger)
+	}
+	m.config = config
+	m.logger.Info("budget config updated",
+		zap.Float64("month_cost_limit", m.month.costLimit),
+		zap.Float64("quarter_cost_limit", m.quarter.costLimit))
+}
+
+// periodWindowsLocked 返回月/季度窗口。调用者必须持有 mu 锁。
+func (m *TokenBudgetManager) periodWindowsLocked() []*calendarWindow {
+	return []*calendarWindow{&m.month, &m.quarter}
+}
+
 // OnAlert登记了一个警报处理器。
 func (m *TokenBudgetManager) OnAlert(handler AlertHandler) {
 	m.mu.Lock()
@@ -149,7 +210,7 @@ func (m *TokenBudgetManager) CheckBudget(ctx context.Context, estimatedTokens in
 	m.resetWindowsLocked()
 
 	// 检查节奏
-	if time.Now().Before(m.throttleUntil) {
+	if m.now().Before(m.throttleUntil) {
 		return fmt.Errorf("throttled until %s", m.throttleUntil.Format(time.RFC3339))
 	}
 
@@ -182,6 +243,16 @@ func (m *TokenBudgetManager) CheckBudget(ctx context.Context, estimatedTokens in
 		return fmt.Errorf("would exceed daily cost limit")
 	}
 
+	// 检查月/季度限制（上限 <=0 不限制）
+	for _, w := range m.periodWindowsLocked() {
+		if w.tokenLimit > 0 && float64(w.tokens)+float64(estimatedTokens) > w.tokenLimit {
+			return fmt.Errorf("would exceed %s token limit", w.period)
+		}
+		if w.costLimit > 0 && w.costUsed()+estimatedCost > w.costLimit {
+			return fmt.Errorf("would exceed %s cost limit", w.period)
+		}
+	}
+
 	return nil
 }
 
@@ -198,6 +269,10 @@ func (m *TokenBudgetManager) RecordUsage(record UsageRecord) {
 	m.tokensHour += int64(record.Tokens)
 	m.tokensDay += int64(record.Tokens)
 	m.costDay += int64(record.Cost * 1000000)
+	for _, w := range m.periodWindowsLocked() {
+		w.tokens += int64(record.Tokens)
+		w.cost += int64(record.Cost * 1000000)
+	}
 
 	// 检查提示
 	m.checkAlertsLocked()
@@ -231,18 +306,64 @@ func (m *TokenBudgetManager) GetStatus() BudgetStatus {
 		CostUtilization:   costDay / m.config.MaxCostPerDay,
 	}
 
-	if time.Now().Before(m.throttleUntil) {
+	now := m.now()
+	if now.Before(m.throttleUntil) {
 		status.IsThrottled = true
 		status.ThrottleUntil = &m.throttleUntil
 	}
 
+	for _, w := range m.periodWindowsLocked() {
+		if !w.enabled() {
+			continue
+		}
+		if status.Periods == nil {
+			status.Periods = make(map[BudgetPeriod]PeriodStatus, 2)
+		}
+		status.Periods[w.period] = w.status(now)
+	}
+
 	return status
 }
 
+// RemainingRatio 返回最紧张窗口（分钟/小时/日 token 与日成本）的剩余预算比例，范围 0-1。
+// 处于节流期时返回 0；上限未配置（<=0）的窗口不参与计算。
+func (m *TokenBudgetManager) RemainingRatio() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resetWindowsLocked()
+
+	if m.now().Before(m.throttleUntil) {
+		return 0
+	}
+
+	remaining := 1.0
+	consider := func(used, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		if r := 1 - used/limit; r < remaining {
+			remaining = r
+		}
+	}
+	consider(float64(m.tokensMinute), float64(m.config.MaxTokensPerMinute))
+	consider(float64(m.tokensHour), float64(m.config.MaxTokensPerHour))
+	consider(float64(m.tokensDay), float64(m.config.MaxTokensPerDay))
+	consider(float64(m.costDay)/1000000, m.config.MaxCostPerDay)
+	for _, w := range m.periodWindowsLocked() {
+		consider(float64(w.tokens), w.tokenLimit)
+		consider(w.costUsed(), w.costLimit)
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 // resetWindowsLocked 重置过期的时间窗口计数器。
 // 调用者必须持有 mu 锁。
 func (m *TokenBudgetManager) resetWindowsLocked() {
-	now := time.Now()
+	now := m.now()
 
 	// 重置分钟窗口
 	if now.Sub(m.minuteStart) >= time.Minute {
@@ -259,7 +380,7 @@ func (m *TokenBudgetManager) resetWindowsLocked() {
 	}
 
 	// 重设日窗口
-	dayStart := now.Truncate(24 * time.Hour)
+	dayStart := dayStartIn(now, m.location)
 	if dayStart.After(m.dayStart) {
 		m.tokensDay = 0
 		m.costDay = 0
@@ -267,6 +388,13 @@ func (m *TokenBudgetManager) resetWindowsLocked() {
 		m.alertedDay = false
 		m.alertedCost = false
 	}
+
+	// 重设月/季度窗口（自然周期边界，上限恢复为配置值）
+	for _, w := range m.periodWindowsLocked() {
+		if !now.Before(w.end) {
+			*w = newCalendarWindow(w.period, now, m.location, m.config)
+		}
+	}
 }
 
 // applyThrottleLocked 应用节流。调用者必须持有 mu 锁。
@@ -275,7 +403,7 @@ func (m *TokenBudgetManager) applyThrottleLocked() {
 		return
 	}
 
-	m.throttleUntil = time.Now().Add(m.config.ThrottleDelay)
+	m.throttleUntil = m.now().Add(m.config.ThrottleDelay)
 	m.logger.Warn("throttling applied", zap.Time("until", m.throttleUntil))
 }
 
@@ -292,7 +420,7 @@ func (m *TokenBudgetManager) checkAlertsLocked() {
 			Message:   "Minute token usage threshold exceeded",
 			Threshold: threshold,
 			Current:   minuteUtil,
-			Timestamp: time.Now(),
+			Timestamp: m.now(),
 		})
 	}
 
@@ -305,7 +433,7 @@ func (m *TokenBudgetManager) checkAlertsLocked() {
 			Message:   "Hour token usage threshold exceeded",
 			Threshold: threshold,
 			Current:   hourUtil,
-			Timestamp: time.Now(),
+			Timestamp: m.now(),
 		})
 	}
 
@@ -318,7 +446,7 @@ func (m *TokenBudgetManager) checkAlertsLocked() {
 			Message:   "Day token usage threshold exceeded",
 			Threshold: threshold,
 			Current:   dayUtil,
-			Timestamp: time.Now(),
+			Timestamp: m.now(),
 		})
 	}
 
@@ -331,7 +459,76 @@ func (m *TokenBudgetManager) checkAlertsLocked() {
 			Message:   "Daily cost threshold exceeded",
 			Threshold: threshold,
 			Current:   costUtil,
-			Timestamp: time.Now(),
+			Timestamp: m.now(),
+		})
+	}
+
+	for _, w := range m.periodWindowsLocked() {
+		m.checkPeriodAlertsLocked(w)
+	}
+}
+
+// checkPeriodAlertsLocked 检查月/季度窗口告警，告警附带周期末预测用量。
+// 调用者必须持有 mu 锁。
+func (m *TokenBudgetManager) checkPeriodAlertsLocked(w *calendarWindow) {
+	threshold := m.config.AlertThreshold
+	now := m.now()
+	periodEnd := w.end
+	tokenAlert, costAlert := periodAlertTypes(w.period)
+	label := periodLabel(w.period)
+
+	if w.tokenLimit > 0 && !w.alertedTokens {
+		used := float64(w.tokens)
+		if util := used / w.tokenLimit; util >= threshold {
+			w.alertedTokens = true
+			m.fireAlert(Alert{
+				Type:      tokenAlert,
+				Message:   label + " token usage threshold exceeded",
+				Threshold: threshold,
+				Current:   util,
+				Timestamp: now,
+				Period:    w.period,
+				Used:      used,
+				Limit:     w.tokenLimit,
+				Projected: w.project(used, now),
+				PeriodEnd: &periodEnd,
+			})
+		}
+	}
+
+	if w.costLimit <= 0 {
+		return
+	}
+	used := w.costUsed()
+	projected := w.project(used, now)
+	if util := used / w.costLimit; util >= threshold && !w.alertedCost {
+		w.alertedCost = true
+		m.fireAlert(Alert{
+			Type:      costAlert,
+			Message:   label + " cost threshold exceeded",
+			Threshold: threshold,
+			Current:   util,
+			Timestamp: now,
+			Period:    w.period,
+			Used:      used,
+			Limit:     w.costLimit,
+			Projected: projected,
+			PeriodEnd: &periodEnd,
+		})
+	}
+	if projected > w.costLimit && !w.alertedProjected && w.elapsed(now) >= minProjectionElapsed {
+		w.alertedProjected = true
+		m.fireAlert(Alert{
+			Type:      AlertCostProjection,
+			Message:   label + " cost projected to exceed limit",
+			Threshold: 1,
+			Current:   projected / w.costLimit,
+			Timestamp: now,
+			Period:    w.period,
+			Used:      used,
+			Limit:     w.costLimit,
+			Projected: projected,
+			PeriodEnd: &periodEnd,
 		})
 	}
 }
@@ -397,10 +594,12 @@ func (m *TokenBudgetManager) Reset() {
 	m.tokensDay = 0
 	m.costDay = 0
 
-	now := time.Now()
+	now := m.now()
 	m.minuteStart = now
 	m.hourStart = now
-	m.dayStart = now.Truncate(24 * time.Hour)
+	m.dayStart = dayStartIn(now, m.location)
+	m.month = newCalendarWindow(BudgetPeriodMonth, now, m.location, m.config)
+	m.quarter = newCalendarWindow(BudgetPeriodQuarter, now, m.location, m.config)
 	m.throttleUntil = time.Time{}
 
 	m.alertedMinute = false
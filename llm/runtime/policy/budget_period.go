@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BudgetPeriod 日历对齐的预算周期（月/季度）。
+type BudgetPeriod string
+
+const (
+	BudgetPeriodMonth   BudgetPeriod = "month"
+	BudgetPeriodQuarter BudgetPeriod = "quarter"
+)
+
+// minProjectionElapsed 周期已过去的比例低于该值时不触发预测超支告警，
+// 避免周期刚开始时线性外推把单次大额请求放大成误报。
+const minProjectionElapsed = 0.1
+
+// PeriodStatus 月/季度预算窗口的当前状态。
+type PeriodStatus struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	TokensUsed      int64     `json:"tokens_used"`
+	CostUsed        float64   `json:"cost_used"`
+	TokenLimit      float64   `json:"token_limit,omitempty"`
+	CostLimit       float64   `json:"cost_limit,omitempty"`
+	ProjectedTokens float64   `json:"projected_tokens"`
+	ProjectedCost   float64   `json:"projected_cost"`
+}
+
+// periodBounds 返回 now 在 loc 时区下所属日历周期的起止时间（左闭右开）。
+func periodBounds(period BudgetPeriod, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	year, month, _ := now.In(loc).Date()
+	if period == BudgetPeriodQuarter {
+		start := time.Date(year, time.Month((int(month)-1)/3*3+1), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0)
+	}
+	start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// dayStartIn 返回 now 在 loc 时区下当天零点。
+func dayStartIn(now time.Time, loc *time.Location) time.Time {
+	year, month, day := now.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// loadBudgetLocation 解析预算时区，空值或无效值回退到 UTC。
+func loadBudgetLocation(name string, logger *zap.Logger) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Warn("invalid budget timezone, falling back to UTC", zap.String("timezone", name), zap.Error(err))
+		return time.UTC
+	}
+	return loc
+}
+
+// periodLimits 返回配置中某个周期的 token 与成本上限。
+func periodLimits(config BudgetConfig, period BudgetPeriod) (int, float64) {
+	if period == BudgetPeriodQuarter {
+		return config.MaxTokensPerQuarter, config.MaxCostPerQuarter
+	}
+	return config.MaxTokensPerMonth, config.MaxCostPerMonth
+}
+
+// calendarWindow 月/季度窗口的用量与本周期生效上限。
+// 所有访问必须持有 TokenBudgetManager.mu 锁。
+type calendarWindow struct {
+	period BudgetPeriod
+	start  time.Time
+	end    time.Time
+	tokens int64
+	cost   int64 // stored as cost * 1000000
+
+	// 本周期生效上限；周期中途调整配置时按已过去/剩余时间比例折算，
+	// 进入下一周期后恢复为配置值。
+	tokenLimit float64
+	costLimit  float64
+
+	alertedTokens    bool
+	alertedCost      bool
+	alertedProjected bool
+}
+
+func newCalendarWindow(period BudgetPeriod, now time.Time, loc *time.Location, config BudgetConfig) calendarWindow {
+	start, end := periodBounds(period, now, loc)
+	tokenLimit, costLimit := periodLimits(config, period)
+	return calendarWindow{
+		period:     period,
+		start:      start,
+		end:        end,
+		tokenLimit: float64(tokenLimit),
+		costLimit:  costLimit,
+	}
+}
+
+// enabled 报告该窗口是否配置了任一上限。
+func (w *calendarWindow) enabled() bool {
+	return w.tokenLimit > 0 || w.costLimit > 0
+}
+
+// costUsed 返回本周期已用成本。
+func (w *calendarWindow) costUsed() float64 {
+	return float64(w.cost) / 1000000
+}
+
+// elapsed 返回本周期已过去的时间比例，范围 0-1。
+func (w *calendarWindow) elapsed(now time.Time) float64 {
+	total := w.end.Sub(w.start)
+	if total <= 0 {
+		return 1
+	}
+	ratio := float64(now.Sub(w.start)) / float64(total)
+	switch {
+	case ratio < 0:
+		return 0
+	case ratio > 1:
+		return 1
+	}
+	return ratio
+}
+
+// project 按当前消耗速率线性外推到周期末的用量。
+func (w *calendarWindow) project(used float64, now time.Time) float64 {
+	elapsed := w.elapsed(now)
+	if elapsed <= 0 {
+		return used
+	}
+	return used / elapsed
+}
+
+// prorate 周期中途调整上限：已过去部分按旧上限、剩余部分按新上限折算。
+// 新上限 <=0 表示关闭；旧上限未配置时新上限直接生效。
+func (w *calendarWindow) prorate(now time.Time, config BudgetConfig) {
+	tokenLimit, costLimit := periodLimits(config, w.period)
+	elapsed := w.elapsed(now)
+	w.tokenLimit = prorateLimit(w.tokenLimit, float64(tokenLimit), elapsed)
+	w.costLimit = prorateLimit(w.costLimit, costLimit, elapsed)
+}
+
+func prorateLimit(current, next, elapsed float64) float64 {
+	if next <= 0 {
+		return 0
+	}
+	if current <= 0 {
+		return next
+	}
+	return current*elapsed + next*(1-elapsed)
+}
+
+func (w *calendarWindow) status(now time.Time) PeriodStatus {
+	cost := w.costUsed()
+	return PeriodStatus{
+		Start:           w.start,
+		End:             w.end,
+		TokensUsed:      w.tokens,
+		CostUsed:        cost,
+		TokenLimit:      w.tokenLimit,
+		CostLimit:       w.costLimit,
+		ProjectedTokens: w.project(float64(w.tokens), now),
+		ProjectedCost:   w.project(cost, now),
+	}
+}
+
+func periodLabel(period BudgetPeriod) string {
+	if period == BudgetPeriodQuarter {
+		return "Quarterly"
+	}
+	return "Monthly"
+}
+
+func periodAlertTypes(period BudgetPeriod) (tokens AlertType, cost AlertType) {
+	if period == BudgetPeriodQuarter {
+		return AlertTokenQuarter, AlertCostQuarter
+	}
+	return AlertTokenMonth, AlertCostMonth
+}
@@ -266,3 +266,113 @@ func TestTokenBudgetManager_AlertFiredOnlyOnce(t *testing.T) {
 	defer mu.Unlock()
 	assert.Equal(t, 1, count, "alert should fire only once per window")
 }
+
+func TestTokenBudgetManager_RemainingRatio(t *testing.T) {
+	cfg := DefaultBudgetConfig()
+	cfg.MaxTokensPerMinute = 1000
+	cfg.MaxCostPerDay = 10
+	cfg.AlertThreshold = 1
+	mgr := NewTokenBudgetManager(cfg, testLogger())
+	assert.InDelta(t, 1.0, mgr.RemainingRatio(), 1e-9)
+
+	// 取最紧张的窗口：分钟 token 用掉 60%，日成本用掉 90%
+	mgr.RecordUsage(UsageRecord{Tokens: 600, Cost: 9})
+	assert.InDelta(t, 0.1, mgr.RemainingRatio(), 1e-6)
+
+	mgr.RecordUsage(UsageRecord{Tokens: 600, Cost: 0})
+	assert.Equal(t, 0.0, mgr.RemainingRatio())
+}
+
+func newClockedBudgetManager(t *testing.T, cfg BudgetConfig, now *time.Time) *TokenBudgetManager {
+	t.Helper()
+	mgr := NewTokenBudgetManager(cfg, testLogger())
+	mgr.now = func() time.Time { return *now }
+	mgr.Reset()
+	return mgr
+}
+
+func TestPeriodBounds(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	// UTC 3 月 31 日 20:00 在东八区已是 4 月 1 日，应落入第二季度
+	now := time.Date(2026, 3, 31, 20, 0, 0, 0, time.UTC)
+
+	start, end := periodBounds(BudgetPeriodQuarter, now, shanghai)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, shanghai), start)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, shanghai), end)
+
+	start, end = periodBounds(BudgetPeriodMonth, now, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestTokenBudgetManager_MonthlyCostLimitResetsOnCalendarBoundary(t *testing.T) {
+	cfg := DefaultBudgetConfig()
+	cfg.MaxCostPerMonth = 100
+	cfg.Timezone = "Asia/Shanghai"
+	now := time.Date(2026, 6, 30, 15, 0, 0, 0, time.UTC) // 东八区 6 月 30 日 23:00
+	mgr := newClockedBudgetManager(t, cfg, &now)
+
+	mgr.RecordUsage(UsageRecord{Tokens: 10, Cost: 95})
+	err := mgr.CheckBudget(context.Background(), 10, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "month cost limit")
+
+	now = now.Add(2 * time.Hour) // 东八区 7 月 1 日 01:00
+	require.NoError(t, mgr.CheckBudget(context.Background(), 10, 10))
+	status := mgr.GetStatus()
+	require.Contains(t, status.Periods, BudgetPeriodMonth)
+	assert.Zero(t, status.Periods[BudgetPeriodMonth].CostUsed)
+	assert.NotContains(t, status.Periods, BudgetPeriodQuarter, "quarter has no limit configured")
+}
+
+func TestTokenBudgetManager_UpdateConfigProratesPeriodLimit(t *testing.T) {
+	cfg := DefaultBudgetConfig()
+	cfg.MaxCostPerMonth = 100
+	now := time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC) // 6 月已过去一半
+	mgr := newClockedBudgetManager(t, cfg, &now)
+
+	cfg.MaxCostPerMonth = 200
+	mgr.UpdateConfig(cfg)
+	assert.InDelta(t, 150, mgr.GetStatus().Periods[BudgetPeriodMonth].CostLimit, 0.001)
+
+	now = time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 200, mgr.GetStatus().Periods[BudgetPeriodMonth].CostLimit, 0.001, "next cycle uses the new limit")
+
+	cfg.MaxCostPerMonth = 0
+	mgr.UpdateConfig(cfg)
+	assert.NotContains(t, mgr.GetStatus().Periods, BudgetPeriodMonth)
+}
+
+func TestTokenBudgetManager_PeriodAlertsIncludeProjection(t *testing.T) {
+	cfg := DefaultBudgetConfig()
+	cfg.MaxCostPerMonth = 100
+	cfg.AlertThreshold = 0.8
+	now := time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC)
+	mgr := newClockedBudgetManager(t, cfg, &now)
+
+	var mu sync.Mutex
+	received := map[AlertType]Alert{}
+	mgr.OnAlert(func(a Alert) {
+		mu.Lock()
+		received[a.Type] = a
+		mu.Unlock()
+	})
+
+	mgr.RecordUsage(UsageRecord{Tokens: 100, Cost: 90})
+	require.NoError(t, mgr.WaitAlerts(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	costAlert, ok := received[AlertCostMonth]
+	require.True(t, ok)
+	assert.Equal(t, BudgetPeriodMonth, costAlert.Period)
+	assert.InDelta(t, 0.9, costAlert.Current, 0.001)
+	assert.InDelta(t, 180, costAlert.Projected, 0.001)
+	require.NotNil(t, costAlert.PeriodEnd)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), *costAlert.PeriodEnd)
+
+	projection, ok := received[AlertCostProjection]
+	require.True(t, ok)
+	assert.InDelta(t, 1.8, projection.Current, 0.001)
+	assert.NotContains(t, received, AlertTokenMonth)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"sort"
 	"sync"
 	"time"
@@ -26,6 +27,7 @@ const (
 	StrategyWeightedRandom APIKeySelectionStrategy = "weighted_random" // 加权随机
 	StrategyPriority       APIKeySelectionStrategy = "priority"        // 优先级
 	StrategyLeastUsed      APIKeySelectionStrategy = "least_used"      // 最少使用
+	StrategyLeastLoaded    APIKeySelectionStrategy = "least_loaded"    // 剩余配额最多
 )
 
 // APIKeyPool API Key 池管理器
@@ -40,6 +42,19 @@ type APIKeyPool struct {
 	// rng is not thread-safe on its own, but all callers (SelectKey, etc.)
 	// hold p.mu before calling methods that use rng. Do not use rng without p.mu.
 	rng *rand.Rand
+
+	// 运行时配额与冷却状态（仅内存，不落库）
+	keyStates         map[uint]*apiKeyState
+	rateLimitCooldown time.Duration
+	authCooldown      time.Duration
+}
+
+// apiKeyState API Key 运行时状态
+type apiKeyState struct {
+	quota        APIKeyQuota
+	hasQuota     bool
+	benchedUntil time.Time
+	benchReason  string
 }
 
 // NewAPIKeyPool 创建 API Key 池
@@ -57,6 +72,10 @@ func NewAPIKeyPool(db *gorm.DB, providerID uint, strategy APIKeySelectionStrateg
 		strategy:   strategy,
 		logger:     logger,
 		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		keyStates:         make(map[uint]*apiKeyState),
+		rateLimitCooldown: defaultRateLimitCooldown,
+		authCooldown:      defaultAuthCooldown,
 	}
 
 	return pool, nil
@@ -94,10 +113,11 @@ func (p *APIKeyPool) SelectKey(ctx context.Context) (*LLMProviderAPIKey, error)
 		return nil, ErrNoAvailableAPIKey
 	}
 
-	// 过滤健康的 Keys
+	// 过滤健康且未处于冷却 / 配额耗尽状态的 Keys
+	now := time.Now()
 	healthyKeys := make([]*LLMProviderAPIKey, 0, len(p.keys))
 	for _, key := range p.keys {
-		if key.IsHealthy() {
+		if p.isAvailableLocked(key, now) {
 			healthyKeys = append(healthyKeys, key)
 		}
 	}
@@ -117,6 +137,8 @@ func (p *APIKeyPool) SelectKey(ctx context.Context) (*LLMProviderAPIKey, error)
 		selected = p.selectPriority(healthyKeys)
 	case StrategyLeastUsed:
 		selected = p.selectLeastUsed(healthyKeys)
+	case StrategyLeastLoaded:
+		selected = p.selectLeastLoaded(healthyKeys, now)
 	default:
 		return nil, fmt.Errorf("unsupported API key selection strategy: %s", p.strategy)
 	}
@@ -197,6 +219,108 @@ func (p *APIKeyPool) selectLeastUsed(keys []*LLMProviderAPIKey) *LLMProviderAPIK
 	return keysCopy[0]
 }
 
+// selectLeastLoaded 选择剩余配额比例最高的 Key，配额相同时选择使用次数最少的
+func (p *APIKeyPool) selectLeastLoaded(keys []*LLMProviderAPIKey, now time.Time) *LLMProviderAPIKey {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var (
+		selected     *LLMProviderAPIKey
+		bestHeadroom float64
+	)
+	for _, key := range keys {
+		headroom := 1.0
+		if state := p.keyStates[key.ID]; state != nil && state.hasQuota {
+			headroom = state.quota.Headroom(now)
+		}
+		if selected == nil || headroom > bestHeadroom ||
+			(headroom == bestHeadroom && key.TotalRequests < selected.TotalRequests) {
+			selected = key
+			bestHeadroom = headroom
+		}
+	}
+	return selected
+}
+
+// isAvailableLocked 判断 Key 是否可被选择。调用方需持有 p.mu
+func (p *APIKeyPool) isAvailableLocked(key *LLMProviderAPIKey, now time.Time) bool {
+	if !key.IsHealthy() {
+		return false
+	}
+	state := p.keyStates[key.ID]
+	if state == nil {
+		return true
+	}
+	if now.Before(state.benchedUntil) {
+		return false
+	}
+	return !state.hasQuota || !state.quota.Exhausted(now)
+}
+
+// SetCooldowns 设置 Key 被自动下线的冷却时间：rateLimit 用于 429（无 Retry-After 时），auth 用于 401/403
+func (p *APIKeyPool) SetCooldowns(rateLimit, auth time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rateLimit > 0 {
+		p.rateLimitCooldown = rateLimit
+	}
+	if auth > 0 {
+		p.authCooldown = auth
+	}
+}
+
+// RecordResponse 根据上游响应的状态码与限流头更新 Key 的配额，并在 429 / 401 / 403 时自动下线该 Key
+func (p *APIKeyPool) RecordResponse(ctx context.Context, keyID uint, statusCode int, header http.Header) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.hasKeyLocked(keyID) {
+		return errors.New("API key not found")
+	}
+
+	now := time.Now()
+	state := p.keyStates[keyID]
+	if state == nil {
+		state = &apiKeyState{}
+		p.keyStates[keyID] = state
+	}
+
+	quota, ok := ParseRateLimitHeaders(header, now)
+	if ok {
+		state.quota = quota
+		state.hasQuota = true
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		state.benchedUntil = quota.benchUntil(now, p.rateLimitCooldown)
+		state.benchReason = "rate_limited"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		state.benchedUntil = now.Add(p.authCooldown)
+		state.benchReason = "unauthorized"
+	default:
+		return nil
+	}
+
+	p.logger.Warn("API key benched",
+		zap.Uint("provider_id", p.providerID),
+		zap.Uint("key_id", keyID),
+		zap.Int("status_code", statusCode),
+		zap.String("reason", state.benchReason),
+		zap.Time("until", state.benchedUntil))
+	return nil
+}
+
+func (p *APIKeyPool) hasKeyLocked(keyID uint) bool {
+	for _, key := range p.keys {
+		if key.ID == keyID {
+			return true
+		}
+	}
+	return false
+}
+
 // RecordSuccess 记录成功使用
 func (p *APIKeyPool) RecordSuccess(ctx context.Context, keyID uint) error {
 	p.mu.Lock()
@@ -374,14 +498,15 @@ func (p *APIKeyPool) GetStats() map[uint]*APIKeyStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	now := time.Now()
 	stats := make(map[uint]*APIKeyStats)
 	for _, key := range p.keys {
-		stats[key.ID] = &APIKeyStats{
+		stat := &APIKeyStats{
 			KeyID:          key.ID,
 			Label:          key.Label,
 			BaseURL:        key.BaseURL,
 			Enabled:        key.Enabled,
-			IsHealthy:      key.IsHealthy(),
+			IsHealthy:      p.isAvailableLocked(key, now),
 			TotalRequests:  key.TotalRequests,
 			FailedRequests: key.FailedRequests,
 			SuccessRate:    p.calculateSuccessRate(key),
@@ -391,6 +516,18 @@ func (p *APIKeyPool) GetStats() map[uint]*APIKeyStats {
 			LastErrorAt:    key.LastErrorAt,
 			LastError:      key.LastError,
 		}
+		if state := p.keyStates[key.ID]; state != nil {
+			if state.hasQuota {
+				quota := state.quota
+				stat.Quota = &quota
+			}
+			if now.Before(state.benchedUntil) {
+				until := state.benchedUntil
+				stat.BenchedUntil = &until
+				stat.BenchReason = state.benchReason
+			}
+		}
+		stats[key.ID] = stat
 	}
 
 	return stats
@@ -419,4 +556,8 @@ type APIKeyStats struct {
 	LastUsedAt     *time.Time `json:"last_used_at"`
 	LastErrorAt    *time.Time `json:"last_error_at"`
 	LastError      string     `json:"last_error"`
+
+	Quota        *APIKeyQuota `json:"quota,omitempty"`
+	BenchedUntil *time.Time   `json:"benched_until,omitempty"`
+	BenchReason  string       `json:"bench_reason,omitempty"`
 }
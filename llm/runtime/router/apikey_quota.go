@@ -0,0 +1,192 @@
+package router
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认冷却时间
+const (
+	defaultRateLimitCooldown = 30 * time.Second // 429 且无 Retry-After / reset 信息时的冷却时间
+	defaultAuthCooldown      = 10 * time.Minute // 401/403 时的冷却时间
+)
+
+// APIKeyQuota 从上游响应头解析出的 API Key 配额快照
+// 数值为 -1 表示上游未返回该项
+type APIKeyQuota struct {
+	LimitRequests     int           `json:"limit_requests"`
+	RemainingRequests int           `json:"remaining_requests"`
+	ResetRequestsAt   time.Time     `json:"reset_requests_at,omitempty"`
+	LimitTokens       int           `json:"limit_tokens"`
+	RemainingTokens   int           `json:"remaining_tokens"`
+	ResetTokensAt     time.Time     `json:"reset_tokens_at,omitempty"`
+	RetryAfter        time.Duration `json:"retry_after,omitempty"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// rateLimitHeaderSet 一组厂商限流响应头名称
+type rateLimitHeaderSet struct {
+	limitRequests, remainingRequests, resetRequests string
+	limitTokens, remainingTokens, resetTokens       string
+}
+
+// rateLimitHeaderSets 按优先级排列的限流响应头：OpenAI 风格、Anthropic 风格、通用风格
+var rateLimitHeaderSets = []rateLimitHeaderSet{
+	{
+		limitRequests: "X-Ratelimit-Limit-Requests", remainingRequests: "X-Ratelimit-Remaining-Requests", resetRequests: "X-Ratelimit-Reset-Requests",
+		limitTokens: "X-Ratelimit-Limit-Tokens", remainingTokens: "X-Ratelimit-Remaining-Tokens", resetTokens: "X-Ratelimit-Reset-Tokens",
+	},
+	{
+		limitRequests: "Anthropic-Ratelimit-Requests-Limit", remainingRequests: "Anthropic-Ratelimit-Requests-Remaining", resetRequests: "Anthropic-Ratelimit-Requests-Reset",
+		limitTokens: "Anthropic-Ratelimit-Tokens-Limit", remainingTokens: "Anthropic-Ratelimit-Tokens-Remaining", resetTokens: "Anthropic-Ratelimit-Tokens-Reset",
+	},
+	{
+		limitRequests: "X-Ratelimit-Limit", remainingRequests: "X-Ratelimit-Remaining", resetRequests: "X-Ratelimit-Reset",
+	},
+}
+
+// ParseRateLimitHeaders 解析上游响应中的限流头（x-ratelimit-*、anthropic-ratelimit-*、Retry-After）
+// 未找到任何限流信息时返回 false
+func ParseRateLimitHeaders(header http.Header, now time.Time) (APIKeyQuota, bool) {
+	quota := APIKeyQuota{
+		LimitRequests:     -1,
+		RemainingRequests: -1,
+		LimitTokens:       -1,
+		RemainingTokens:   -1,
+		UpdatedAt:         now,
+	}
+	if len(header) == 0 {
+		return quota, false
+	}
+
+	for _, set := range rateLimitHeaderSets {
+		if quota.LimitRequests < 0 {
+			quota.LimitRequests = parseHeaderInt(header, set.limitRequests)
+		}
+		if quota.RemainingRequests < 0 {
+			quota.RemainingRequests = parseHeaderInt(header, set.remainingRequests)
+		}
+		if quota.ResetRequestsAt.IsZero() {
+			quota.ResetRequestsAt = parseResetHeader(header.Get(set.resetRequests), now)
+		}
+		if set.limitTokens != "" {
+			if quota.LimitTokens < 0 {
+				quota.LimitTokens = parseHeaderInt(header, set.limitTokens)
+			}
+			if quota.RemainingTokens < 0 {
+				quota.RemainingTokens = parseHeaderInt(header, set.remainingTokens)
+			}
+			if quota.ResetTokensAt.IsZero() {
+				quota.ResetTokensAt = parseResetHeader(header.Get(set.resetTokens), now)
+			}
+		}
+	}
+	found := quota.LimitRequests >= 0 || quota.RemainingRequests >= 0 || quota.LimitTokens >= 0 || quota.RemainingTokens >= 0
+
+	if raw := strings.TrimSpace(header.Get("Retry-After")); raw != "" {
+		if at := parseResetHeader(raw, now); !at.IsZero() && at.After(now) {
+			quota.RetryAfter = at.Sub(now)
+			found = true
+		}
+	}
+
+	return quota, found
+}
+
+// Exhausted 报告配额在 now 时刻是否已耗尽（剩余为 0 且尚未到重置时间）
+func (q APIKeyQuota) Exhausted(now time.Time) bool {
+	if q.RemainingRequests == 0 && now.Before(q.ResetRequestsAt) {
+		return true
+	}
+	if q.RemainingTokens == 0 && now.Before(q.ResetTokensAt) {
+		return true
+	}
+	return false
+}
+
+// Headroom 返回剩余配额比例（0~1），请求数与 token 取较小者；无法判断时返回 1
+func (q APIKeyQuota) Headroom(now time.Time) float64 {
+	headroom := 1.0
+	if r, ok := quotaRatio(q.RemainingRequests, q.LimitRequests, q.ResetRequestsAt, now); ok {
+		headroom = math.Min(headroom, r)
+	}
+	if r, ok := quotaRatio(q.RemainingTokens, q.LimitTokens, q.ResetTokensAt, now); ok {
+		headroom = math.Min(headroom, r)
+	}
+	return headroom
+}
+
+// benchUntil 根据 429 响应计算冷却截止时间：Retry-After 优先，其次为已耗尽维度的重置时间
+func (q APIKeyQuota) benchUntil(now time.Time, fallback time.Duration) time.Time {
+	if q.RetryAfter > 0 {
+		return now.Add(q.RetryAfter)
+	}
+	var until time.Time
+	if q.RemainingRequests == 0 && q.ResetRequestsAt.After(now) {
+		until = q.ResetRequestsAt
+	}
+	if q.RemainingTokens == 0 && q.ResetTokensAt.After(until) {
+		until = q.ResetTokensAt
+	}
+	if until.IsZero() {
+		until = now.Add(fallback)
+	}
+	return until
+}
+
+// quotaRatio 计算单一维度的剩余比例；重置时间已过视为配额已恢复
+func quotaRatio(remaining, limit int, resetAt, now time.Time) (float64, bool) {
+	if remaining < 0 {
+		return 0, false
+	}
+	if !resetAt.IsZero() && !now.Before(resetAt) {
+		return 1, true
+	}
+	if limit > 0 {
+		return math.Min(float64(remaining)/float64(limit), 1), true
+	}
+	if remaining == 0 {
+		return 0, true
+	}
+	return 0, false
+}
+
+func parseHeaderInt(header http.Header, name string) int {
+	raw := strings.TrimSpace(header.Get(name))
+	if raw == "" {
+		return -1
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return -1
+	}
+	return int(v)
+}
+
+// parseResetHeader 解析重置时间，兼容 Go duration（"6m0s"、"20ms"）、秒数、Unix 时间戳、RFC3339 与 HTTP 日期
+func parseResetHeader(raw string, now time.Time) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return now.Add(d)
+	}
+	if secs, err := strconv.ParseFloat(raw, 64); err == nil {
+		// 大于 10 年秒数时视为 Unix 时间戳
+		if secs > 10*365*24*3600 {
+			return time.Unix(int64(secs), 0)
+		}
+		return now.Add(time.Duration(secs * float64(time.Second)))
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t
+	}
+	if t, err := http.ParseTime(raw); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("openai", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("x-ratelimit-limit-requests", "100")
+		h.Set("x-ratelimit-remaining-requests", "25")
+		h.Set("x-ratelimit-reset-requests", "6m0s")
+		h.Set("x-ratelimit-limit-tokens", "10000")
+		h.Set("x-ratelimit-remaining-tokens", "5000")
+		h.Set("x-ratelimit-reset-tokens", "20ms")
+
+		q, ok := ParseRateLimitHeaders(h, now)
+		require.True(t, ok)
+		assert.Equal(t, 25, q.RemainingRequests)
+		assert.Equal(t, now.Add(6*time.Minute), q.ResetRequestsAt)
+		assert.Equal(t, 5000, q.RemainingTokens)
+		assert.InDelta(t, 0.25, q.Headroom(now), 1e-9)
+	})
+
+	t.Run("anthropic", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("anthropic-ratelimit-requests-limit", "50")
+		h.Set("anthropic-ratelimit-requests-remaining", "0")
+		h.Set("anthropic-ratelimit-requests-reset", now.Add(30*time.Second).Format(time.RFC3339))
+
+		q, ok := ParseRateLimitHeaders(h, now)
+		require.True(t, ok)
+		assert.True(t, q.Exhausted(now))
+		assert.False(t, q.Exhausted(now.Add(31*time.Second)))
+		assert.Equal(t, -1, q.RemainingTokens)
+	})
+
+	t.Run("retry after only", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Retry-After", "12")
+		q, ok := ParseRateLimitHeaders(h, now)
+		require.True(t, ok)
+		assert.Equal(t, 12*time.Second, q.RetryAfter)
+		assert.Equal(t, now.Add(12*time.Second), q.benchUntil(now, time.Minute))
+	})
+
+	t.Run("none", func(t *testing.T) {
+		_, ok := ParseRateLimitHeaders(http.Header{"Content-Type": {"application/json"}}, now)
+		assert.False(t, ok)
+	})
+}
+
+func newQuotaTestPool(t *testing.T, strategy APIKeySelectionStrategy) (*APIKeyPool, []*LLMProviderAPIKey) {
+	t.Helper()
+	db := setupTestDB(t)
+	keys := []*LLMProviderAPIKey{
+		{ProviderID: 1, APIKey: "key1", Priority: 10, Weight: 100, Enabled: true},
+		{ProviderID: 1, APIKey: "key2", Priority: 20, Weight: 100, Enabled: true},
+	}
+	for _, key := range keys {
+		require.NoError(t, db.Create(key).Error)
+	}
+	pool, err := NewAPIKeyPool(db, 1, strategy, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, pool.LoadKeys(context.Background()))
+	return pool, keys
+}
+
+func TestAPIKeyPool_LeastLoaded(t *testing.T) {
+	ctx := context.Background()
+	pool, keys := newQuotaTestPool(t, StrategyLeastLoaded)
+
+	low := http.Header{}
+	low.Set("x-ratelimit-limit-requests", "100")
+	low.Set("x-ratelimit-remaining-requests", "10")
+	require.NoError(t, pool.RecordResponse(ctx, keys[0].ID, http.StatusOK, low))
+
+	high := http.Header{}
+	high.Set("x-ratelimit-limit-requests", "100")
+	high.Set("x-ratelimit-remaining-requests", "90")
+	require.NoError(t, pool.RecordResponse(ctx, keys[1].ID, http.StatusOK, high))
+
+	key, err := pool.SelectKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", key.APIKey)
+
+	stats := pool.GetStats()
+	require.NotNil(t, stats[keys[0].ID].Quota)
+	assert.Equal(t, 10, stats[keys[0].ID].Quota.RemainingRequests)
+}
+
+func TestAPIKeyPool_BenchesOnRateLimitAndAuthErrors(t *testing.T) {
+	ctx := context.Background()
+	pool, keys := newQuotaTestPool(t, StrategyPriority)
+
+	h := http.Header{}
+	h.Set("Retry-After", "60")
+	require.NoError(t, pool.RecordResponse(ctx, keys[0].ID, http.StatusTooManyRequests, h))
+
+	key, err := pool.SelectKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", key.APIKey, "rate limited key should be benched")
+
+	stats := pool.GetStats()
+	assert.False(t, stats[keys[0].ID].IsHealthy)
+	assert.Equal(t, "rate_limited", stats[keys[0].ID].BenchReason)
+	require.NotNil(t, stats[keys[0].ID].BenchedUntil)
+
+	require.NoError(t, pool.RecordResponse(ctx, keys[1].ID, http.StatusUnauthorized, nil))
+	_, err = pool.SelectKey(ctx)
+	assert.ErrorIs(t, err, ErrAllKeysRateLimited)
+
+	assert.Error(t, pool.RecordResponse(ctx, 999, http.StatusOK, nil))
+}
+
+func TestAPIKeyPool_RateLimitCooldownExpires(t *testing.T) {
+	ctx := context.Background()
+	pool, keys := newQuotaTestPool(t, StrategyPriority)
+	pool.SetCooldowns(20*time.Millisecond, 0)
+
+	require.NoError(t, pool.RecordResponse(ctx, keys[0].ID, http.StatusTooManyRequests, nil))
+	key, err := pool.SelectKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", key.APIKey)
+
+	time.Sleep(30 * time.Millisecond)
+	key, err = pool.SelectKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key1", key.APIKey)
+}
@@ -27,7 +27,9 @@ type ChannelRoutedProviderOptions struct {
 	Factory              ChatProviderFactory
 	RetryPolicy          ChannelRouteRetryPolicy
 	Callbacks            ChannelRouteCallbacks
-	Logger               *zap.Logger
+	// Capabilities 可选：调用上游前按选中 provider/模型的能力描述协商请求
+	Capabilities *llmroot.CapabilityRegistry
+	Logger       *zap.Logger
 }
 
 // ChannelRoutedProvider is a generic routed chat provider that delegates route semantics to injected interfaces.
@@ -45,6 +47,7 @@ type ChannelRoutedProvider struct {
 	factory              ChatProviderFactory
 	retryPolicy          ChannelRouteRetryPolicy
 	callbacks            ChannelRouteCallbacks
+	capabilities         *llmroot.CapabilityRegistry
 	logger               *zap.Logger
 }
 
@@ -110,6 +113,7 @@ func NewChannelRoutedProvider(opts ChannelRoutedProviderOptions) *ChannelRoutedP
 		factory:              opts.Factory,
 		retryPolicy:          retryPolicy,
 		callbacks:            opts.Callbacks,
+		capabilities:         opts.Capabilities,
 		logger:               logger,
 	}
 }
@@ -138,8 +142,17 @@ func (p *ChannelRoutedProvider) Completion(ctx context.Context, req *ChatRequest
 			continue
 		}
 
+		routedReq, err := p.negotiateRequest(req, invocation, false)
+		if err != nil {
+			lastErr = err
+			if !p.shouldRetry(ctx, err, invocation.selection, attempt) {
+				return nil, err
+			}
+			state = state.exclude(invocation.selection, p.retryPolicy.ExcludeFailedChannel)
+			continue
+		}
+
 		callStart := time.Now()
-		routedReq := cloneChatRequest(req, invocation.remoteModelName())
 		resp, callErr := invocation.provider.Completion(ctx, routedReq)
 		if callErr == nil {
 			if resp != nil {
@@ -189,8 +202,17 @@ func (p *ChannelRoutedProvider) Stream(ctx context.Context, req *ChatRequest) (<
 			continue
 		}
 
+		routedReq, err := p.negotiateRequest(req, invocation, true)
+		if err != nil {
+			lastErr = err
+			if !p.shouldRetry(ctx, err, invocation.selection, attempt) {
+				return nil, err
+			}
+			state = state.exclude(invocation.selection, p.retryPolicy.ExcludeFailedChannel)
+			continue
+		}
+
 		callStart := time.Now()
-		routedReq := cloneChatRequest(req, invocation.remoteModelName())
 		source, streamErr := invocation.provider.Stream(ctx, routedReq)
 		if streamErr == nil {
 			out := make(chan StreamChunk)
@@ -379,8 +401,17 @@ func (p *ChannelRoutedProvider) recordUsage(
 	if invocation == nil {
 		return
 	}
+	p.publishUsage(ctx, p.usageRecord(invocation, success, errMsg, usage, latency))
+}
 
-	record := &ChannelUsageRecord{
+func (p *ChannelRoutedProvider) usageRecord(
+	invocation *resolvedChannelInvocation,
+	success bool,
+	errMsg string,
+	usage *ChatUsage,
+	latency time.Duration,
+) *ChannelUsageRecord {
+	return &ChannelUsageRecord{
 		Capability:     invocation.capability(),
 		Mode:           invocation.mode(),
 		Attempt:        invocation.attempt(),
@@ -397,7 +428,9 @@ func (p *ChannelRoutedProvider) recordUsage(
 		Usage:          cloneChatUsage(usage),
 		Metadata:       invocation.metadata(),
 	}
+}
 
+func (p *ChannelRoutedProvider) publishUsage(ctx context.Context, record *ChannelUsageRecord) {
 	var errs []error
 	if err := p.usageRecorder.RecordUsage(ctx, record); err != nil {
 		errs = append(errs, fmt.Errorf("usage recorder: %w", err))
@@ -420,6 +453,10 @@ func (p *ChannelRoutedProvider) shouldRetry(ctx context.Context, err error, sele
 	if attempt >= p.maxAttempts || err == nil {
 		return false
 	}
+	// A spent deadline budget leaves no time for another channel.
+	if ctx.Err() != nil {
+		return false
+	}
 	return p.retryPolicy.ShouldRetry(ctx, err, selection)
 }
 
@@ -438,9 +475,9 @@ func (p *ChannelRoutedProvider) relayStreamWithRetry(
 	currentSource := source
 
 	for {
-		start := time.Now()
-		success, retryableFailure, errMsg, usage := p.relayStreamAttempt(ctx, out, req, currentInvocation, currentSource)
-		p.recordUsage(ctx, currentInvocation, success, errMsg, usage, time.Since(start))
+		timing := &streamAttemptTiming{start: time.Now()}
+		success, retryableFailure, errMsg, usage := p.relayStreamAttempt(ctx, out, req, currentInvocation, currentSource, timing)
+		p.publishUsage(ctx, timing.apply(p.usageRecord(currentInvocation, success, errMsg, usage, time.Since(timing.start)), usage))
 		if !retryableFailure {
 			return
 		}
@@ -463,9 +500,12 @@ func (p *ChannelRoutedProvider) relayStreamAttempt(
 	req *ChatRequest,
 	invocation *resolvedChannelInvocation,
 	source <-chan StreamChunk,
+	timing *streamAttemptTiming,
 ) (success bool, retryableFailure bool, errMsg string, usage *ChatUsage) {
 	emitted := false
+	lastID := ""
 	for chunk := range source {
+		timing.observe(chunk, time.Now())
 		if chunk.Usage != nil {
 			usageCopy := *chunk.Usage
 			usage = &usageCopy
@@ -475,6 +515,15 @@ func (p *ChannelRoutedProvider) relayStreamAttempt(
 			if !emitted && p.shouldRetry(ctx, chunk.Err, invocation.selection, invocation.attempt()) {
 				return false, true, errMsg, usage
 			}
+			if emitted && types.DeadlineExceeded(ctx, chunk.Err) {
+				// Keep what was already streamed: close with a truncated final chunk instead of an error.
+				chunk = StreamChunk{
+					ID:           lastID,
+					FinishReason: types.FinishReasonDeadline,
+					Truncated:    types.TruncatedDeadline,
+					Usage:        usage,
+				}
+			}
 			if strings.TrimSpace(chunk.Provider) == "" {
 				chunk.Provider = invocation.providerName()
 			}
@@ -492,6 +541,9 @@ func (p *ChannelRoutedProvider) relayStreamAttempt(
 		}
 		out <- chunk
 		emitted = true
+		if chunk.ID != "" {
+			lastID = chunk.ID
+		}
 	}
 	return true, false, "", usage
 }
@@ -521,8 +573,17 @@ func (p *ChannelRoutedProvider) openStreamRetry(
 			continue
 		}
 
+		routedReq, err := p.negotiateRequest(req, invocation, true)
+		if err != nil {
+			lastErr = err
+			if !p.shouldRetry(ctx, err, invocation.selection, attempt) {
+				return nil, nil, currentState, err
+			}
+			currentState = currentState.exclude(invocation.selection, p.retryPolicy.ExcludeFailedChannel)
+			continue
+		}
+
 		callStart := time.Now()
-		routedReq := cloneChatRequest(req, invocation.remoteModelName())
 		source, streamErr := invocation.provider.Stream(ctx, routedReq)
 		if streamErr == nil {
 			return invocation, source, currentState, nil
@@ -539,12 +600,23 @@ func (p *ChannelRoutedProvider) openStreamRetry(
 	return nil, nil, currentState, firstNonNilError(lastErr, types.NewServiceUnavailableError("channel routed provider exhausted retry attempts"))
 }
 
+// negotiateRequest 生成发往选中渠道的请求，并在配置了能力注册表时按该渠道 provider/模型的能力协商
+func (p *ChannelRoutedProvider) negotiateRequest(req *ChatRequest, invocation *resolvedChannelInvocation, stream bool) (*ChatRequest, error) {
+	routedReq := cloneChatRequest(req, invocation.remoteModelName())
+	if p.capabilities == nil {
+		return routedReq, nil
+	}
+	return p.capabilities.Negotiate(invocation.providerName(), routedReq.Model, invocation.provider, routedReq, stream)
+}
+
 func buildChannelRouteRequest(req *ChatRequest, mode RouteMode, attempt int, state retryExclusionState) *ChannelRouteRequest {
 	return &ChannelRouteRequest{
 		Capability:         RouteCapabilityChat,
 		Mode:               mode,
 		Attempt:            attempt,
 		TraceID:            strings.TrimSpace(req.TraceID),
+		TenantID:           strings.TrimSpace(req.TenantID),
+		SessionID:          extractSessionID(req),
 		RequestedModel:     strings.TrimSpace(req.Model),
 		ProviderHint:       extractProviderHint(req),
 		RoutePolicy:        normalizeChannelRoutePolicy(req),
@@ -578,6 +650,18 @@ func normalizeChannelRoutePolicy(req *ChatRequest) string {
 	}
 }
 
+// extractSessionID returns the conversation identity used for session affinity.
+func extractSessionID(req *ChatRequest) string {
+	if req == nil {
+		return ""
+	}
+	return firstNonEmpty(
+		req.ConversationID,
+		req.Metadata["conversation_id"],
+		req.Metadata["session_id"],
+	)
+}
+
 func extractRegionHint(req *ChatRequest) string {
 	if req == nil || len(req.Metadata) == 0 {
 		return ""
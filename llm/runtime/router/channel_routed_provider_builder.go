@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	llmcore "github.com/BaSui01/agentflow/llm/core"
 	"go.uber.org/zap"
 )
 
@@ -31,7 +32,16 @@ type ChannelRoutedProviderConfig struct {
 	ProviderTimeout       time.Duration
 
 	Callbacks ChannelRouteCallbacks
-	Logger    *zap.Logger
+	// SessionAffinity optionally wraps ChannelSelector so requests of the
+	// same conversation/run stay on the same channel and key.
+	SessionAffinity *SessionAffinityOptions
+	// DataResidency optionally restricts each tenant's routes to compliant
+	// regions/providers. It wraps session affinity so pins cannot bypass it.
+	DataResidency *DataResidencyOptions
+	// Capabilities optionally negotiates each request against the selected
+	// provider/model capabilities before it is sent upstream.
+	Capabilities *llmcore.CapabilityRegistry
+	Logger       *zap.Logger
 }
 
 // ChannelRoutedProviderBuilder wraps ChannelRoutedProviderConfig so callers can
@@ -80,6 +90,18 @@ func (b *ChannelRoutedProviderBuilder) WithRetryPolicy(policy ChannelRouteRetryP
 	return b
 }
 
+// WithSessionAffinity enables sticky conversation routing during Build.
+func (b *ChannelRoutedProviderBuilder) WithSessionAffinity(opts SessionAffinityOptions) *ChannelRoutedProviderBuilder {
+	b.config.SessionAffinity = &opts
+	return b
+}
+
+// WithDataResidency enables per-tenant data residency enforcement during Build.
+func (b *ChannelRoutedProviderBuilder) WithDataResidency(opts DataResidencyOptions) *ChannelRoutedProviderBuilder {
+	b.config.DataResidency = &opts
+	return b
+}
+
 // WithLogger overrides the logger used during Build.
 func (b *ChannelRoutedProviderBuilder) WithLogger(logger *zap.Logger) *ChannelRoutedProviderBuilder {
 	b.config.Logger = logger
@@ -101,11 +123,27 @@ func (b *ChannelRoutedProviderBuilder) Build() (*ChannelRoutedProvider, error) {
 		logger = zap.NewNop()
 	}
 
+	selector := b.config.ChannelSelector
+	if b.config.SessionAffinity != nil {
+		opts := *b.config.SessionAffinity
+		if opts.Logger == nil {
+			opts.Logger = logger
+		}
+		selector = NewSessionAffinitySelector(selector, opts)
+	}
+	if b.config.DataResidency != nil {
+		opts := *b.config.DataResidency
+		if opts.Logger == nil {
+			opts.Logger = logger
+		}
+		selector = NewDataResidencySelector(selector, opts)
+	}
+
 	return NewChannelRoutedProvider(ChannelRoutedProviderOptions{
 		Name:                 b.config.Name,
 		ModelResolver:        b.config.ModelResolver,
 		ModelMappingResolver: b.config.ModelMappingResolver,
-		ChannelSelector:      b.config.ChannelSelector,
+		ChannelSelector:      selector,
 		SecretResolver:       b.config.SecretResolver,
 		UsageRecorder:        b.config.UsageRecorder,
 		CooldownController:   b.config.CooldownController,
@@ -114,6 +152,7 @@ func (b *ChannelRoutedProviderBuilder) Build() (*ChannelRoutedProvider, error) {
 		Factory:              resolveChannelChatProviderFactory(b.config, logger),
 		RetryPolicy:          b.config.RetryPolicy,
 		Callbacks:            b.config.Callbacks,
+		Capabilities:         b.config.Capabilities,
 		Logger:               logger,
 	}), nil
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	llmcore "github.com/BaSui01/agentflow/llm/core"
 	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
 
@@ -913,3 +915,91 @@ func TestRoutedChatProvider_GatewayCompletionIncludesResolvedBaseURL(t *testing.
 		t.Fatalf("expected baseURL http://example-a, got %s", got)
 	}
 }
+
+type deadlineStreamProvider struct {
+	scriptedStreamProvider
+}
+
+func (p *deadlineStreamProvider) Stream(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+	ch := make(chan StreamChunk, 2)
+	ch <- StreamChunk{ID: "resp-1", Delta: Message{Role: RoleAssistant, Content: "partial"}}
+	ch <- StreamChunk{Err: toTypesError(context.DeadlineExceeded)}
+	close(ch)
+	return ch, nil
+}
+
+func TestChannelRoutedProvider_StreamTruncatesOnDeadlineAfterContent(t *testing.T) {
+	t.Parallel()
+
+	selector := &captureChannelSelector{
+		selections: []ChannelSelection{
+			{ChannelID: "channel-a", KeyID: "key-a", Provider: "mock-provider", RemoteModel: "upstream-a"},
+			{ChannelID: "channel-b", KeyID: "key-b", Provider: "mock-provider", RemoteModel: "upstream-b"},
+		},
+	}
+	routed := NewChannelRoutedProvider(ChannelRoutedProviderOptions{
+		RetryPolicy: ChannelRouteRetryPolicy{
+			MaxAttempts: 2,
+			ShouldRetry: func(context.Context, error, *ChannelSelection) bool {
+				return true
+			},
+		},
+		ModelResolver:        PassthroughModelResolver{},
+		ModelMappingResolver: &captureModelMappingResolver{},
+		ChannelSelector:      selector,
+		SecretResolver:       &captureSecretResolver{secret: ChannelSecret{APIKey: "sk"}},
+		ProviderConfigSource: &captureProviderConfigSource{config: ChannelProviderConfig{Provider: "mock-provider"}},
+		Factory:              &captureChannelFactory{provider: &deadlineStreamProvider{}},
+		Logger:               zap.NewNop(),
+	})
+
+	stream, err := routed.Stream(context.Background(), &ChatRequest{
+		Model:    "public-model",
+		Messages: []Message{{Role: RoleUser, Content: "slow stream"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	var chunks []StreamChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected content chunk and truncated final chunk, got %+v", chunks)
+	}
+	last := chunks[1]
+	if last.Err != nil {
+		t.Fatalf("expected no error on truncated chunk, got %v", last.Err)
+	}
+	if last.FinishReason != types.FinishReasonDeadline || last.Truncated != types.TruncatedDeadline {
+		t.Fatalf("expected deadline truncation, got %+v", last)
+	}
+	if last.ID != "resp-1" || last.Model != "upstream-a" {
+		t.Fatalf("expected truncated chunk to keep stream identity, got %+v", last)
+	}
+	if len(selector.requests) != 1 {
+		t.Fatalf("expected no retry after content was emitted, got %d selections", len(selector.requests))
+	}
+}
+
+func TestChannelRoutedProvider_NoRetryWhenBudgetSpent(t *testing.T) {
+	t.Parallel()
+
+	routed := NewChannelRoutedProvider(ChannelRoutedProviderOptions{
+		RetryPolicy: ChannelRouteRetryPolicy{
+			MaxAttempts: 3,
+			ShouldRetry: func(context.Context, error, *ChannelSelection) bool {
+				return true
+			},
+		},
+		Logger: zap.NewNop(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	if routed.shouldRetry(ctx, errors.New("upstream failed"), nil, 1) {
+		t.Fatal("expected no retry once the deadline budget is spent")
+	}
+	if !routed.shouldRetry(context.Background(), errors.New("upstream failed"), nil, 1) {
+		t.Fatal("expected retry while budget remains")
+	}
+}
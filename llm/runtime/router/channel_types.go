@@ -25,6 +25,8 @@ type ChannelRouteRequest struct {
 	Mode               RouteMode         `json:"mode"`
 	Attempt            int               `json:"attempt,omitempty"`
 	TraceID            string            `json:"trace_id,omitempty"`
+	TenantID           string            `json:"tenant_id,omitempty"`
+	SessionID          string            `json:"session_id,omitempty"`
 	RequestedModel     string            `json:"requested_model,omitempty"`
 	ProviderHint       string            `json:"provider_hint,omitempty"`
 	RoutePolicy        string            `json:"route_policy,omitempty"`
@@ -114,6 +116,11 @@ type ChannelUsageRecord struct {
 	LatencyMS      int64             `json:"latency_ms,omitempty"`
 	Usage          *ChatUsage        `json:"usage,omitempty"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
+	// Streaming responsiveness; populated only for stream attempts that
+	// produced at least one token.
+	TimeToFirstTokenMS  int64   `json:"ttft_ms,omitempty"`
+	InterTokenLatencyMS float64 `json:"inter_token_latency_ms,omitempty"`
+	TokensPerSecond     float64 `json:"tokens_per_second,omitempty"`
 }
 
 // ChannelSelector chooses the concrete channel/key route for a request.
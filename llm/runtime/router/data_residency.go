@@ -0,0 +1,328 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// DefaultResidencyPolicyKey selects the policy applied to tenants without
+// their own entry in StaticDataResidencyPolicies.
+const DefaultResidencyPolicyKey = "*"
+
+// DataResidencyPolicy restricts which regions and providers may receive a
+// tenant's prompts. Empty lists leave that dimension unrestricted. Regions
+// match case-insensitively; a trailing "*" matches by prefix (e.g. "eu-*").
+type DataResidencyPolicy struct {
+	TenantID         string   `json:"tenant_id,omitempty" yaml:"tenant_id"`
+	AllowedRegions   []string `json:"allowed_regions,omitempty" yaml:"allowed_regions"`
+	AllowedProviders []string `json:"allowed_providers,omitempty" yaml:"allowed_providers"`
+}
+
+// Check reports whether a route to provider in region is compliant and, if
+// not, why. A route without a known region never satisfies a region
+// restriction because its location cannot be guaranteed.
+func (p DataResidencyPolicy) Check(provider, region string) (bool, string) {
+	if len(p.AllowedProviders) > 0 && !residencyMatchesAny(p.AllowedProviders, provider, false) {
+		return false, fmt.Sprintf("provider %q is not allowed", provider)
+	}
+	if len(p.AllowedRegions) > 0 {
+		if strings.TrimSpace(region) == "" {
+			return false, "region is unknown"
+		}
+		if !residencyMatchesAny(p.AllowedRegions, region, true) {
+			return false, fmt.Sprintf("region %q is not allowed", region)
+		}
+	}
+	return true, ""
+}
+
+func (p DataResidencyPolicy) describe() string {
+	var parts []string
+	if len(p.AllowedRegions) > 0 {
+		parts = append(parts, "allowed regions: "+strings.Join(p.AllowedRegions, ", "))
+	}
+	if len(p.AllowedProviders) > 0 {
+		parts = append(parts, "allowed providers: "+strings.Join(p.AllowedProviders, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func residencyMatchesAny(patterns []string, value string, allowPrefix bool) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, raw := range patterns {
+		pattern := strings.ToLower(strings.TrimSpace(raw))
+		if allowPrefix && strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(value, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+			continue
+		}
+		if pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DataResidencyPolicySource resolves the policy of a tenant. A nil policy
+// means the tenant is unrestricted.
+type DataResidencyPolicySource interface {
+	ResolveResidencyPolicy(ctx context.Context, tenantID string) (*DataResidencyPolicy, error)
+}
+
+// StaticDataResidencyPolicies maps tenant IDs to policies. The
+// DefaultResidencyPolicyKey entry applies to all other tenants, including
+// requests without a tenant.
+type StaticDataResidencyPolicies map[string]DataResidencyPolicy
+
+func (s StaticDataResidencyPolicies) ResolveResidencyPolicy(_ context.Context, tenantID string) (*DataResidencyPolicy, error) {
+	if policy, ok := s[strings.TrimSpace(tenantID)]; ok && tenantID != "" {
+		return &policy, nil
+	}
+	if policy, ok := s[DefaultResidencyPolicyKey]; ok {
+		return &policy, nil
+	}
+	return nil, nil
+}
+
+// ResidencyRejection records one route candidate excluded by a policy.
+type ResidencyRejection struct {
+	ChannelID string `json:"channel_id,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// ResidencyDecision is the audit record of one residency-constrained route.
+type ResidencyDecision struct {
+	Timestamp         time.Time            `json:"timestamp"`
+	TenantID          string               `json:"tenant_id,omitempty"`
+	TraceID           string               `json:"trace_id,omitempty"`
+	Mode              RouteMode            `json:"mode,omitempty"`
+	Attempt           int                  `json:"attempt,omitempty"`
+	RequestedModel    string               `json:"requested_model,omitempty"`
+	Policy            DataResidencyPolicy  `json:"policy"`
+	Allowed           bool                 `json:"allowed"`
+	CandidateCount    int                  `json:"candidate_count"`
+	CompliantCount    int                  `json:"compliant_count"`
+	Rejected          []ResidencyRejection `json:"rejected,omitempty"`
+	SelectedChannelID string               `json:"selected_channel_id,omitempty"`
+	SelectedProvider  string               `json:"selected_provider,omitempty"`
+	SelectedRegion    string               `json:"selected_region,omitempty"`
+	Reason            string               `json:"reason,omitempty"`
+}
+
+// ResidencyAuditor persists residency decisions.
+type ResidencyAuditor interface {
+	RecordResidencyDecision(ctx context.Context, decision *ResidencyDecision) error
+}
+
+// ResidencyAuditorFunc adapts a function to ResidencyAuditor.
+type ResidencyAuditorFunc func(ctx context.Context, decision *ResidencyDecision) error
+
+func (f ResidencyAuditorFunc) RecordResidencyDecision(ctx context.Context, decision *ResidencyDecision) error {
+	return f(ctx, decision)
+}
+
+// DataResidencyOptions configures residency enforcement.
+type DataResidencyOptions struct {
+	// Policies is required; tenants without a policy are routed unrestricted.
+	Policies DataResidencyPolicySource
+	// Auditor optionally records every decision made under a policy.
+	Auditor ResidencyAuditor
+	Logger  *zap.Logger
+}
+
+// DataResidencySelector restricts the wrapped selector to routes that satisfy
+// the tenant's residency policy and rejects the request with ErrDataResidency
+// when no compliant route exists. Every selection is checked, so it must wrap
+// selectors that can return routes outside the supplied mappings (such as
+// SessionAffinitySelector pins).
+//
+// The tenant is taken from ChannelRouteRequest.TenantID, falling back to the
+// tenant in context.
+type DataResidencySelector struct {
+	next     ChannelSelector
+	policies DataResidencyPolicySource
+	auditor  ResidencyAuditor
+	logger   *zap.Logger
+}
+
+var _ ChannelSelector = (*DataResidencySelector)(nil)
+
+// NewDataResidencySelector wraps next with residency enforcement.
+func NewDataResidencySelector(next ChannelSelector, opts DataResidencyOptions) *DataResidencySelector {
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DataResidencySelector{
+		next:     next,
+		policies: opts.Policies,
+		auditor:  opts.Auditor,
+		logger:   logger.With(zap.String("component", "data_residency")),
+	}
+}
+
+func (s *DataResidencySelector) SelectChannel(ctx context.Context, request *ChannelRouteRequest, resolution *ModelResolution, mappings []ChannelModelMapping) (*ChannelSelection, error) {
+	if s.policies == nil {
+		return s.next.SelectChannel(ctx, request, resolution, mappings)
+	}
+	tenantID := residencyTenantID(ctx, request)
+	policy, err := s.policies.ResolveResidencyPolicy(ctx, tenantID)
+	if err != nil {
+		return nil, types.NewInternalError("resolve data residency policy").WithCause(err)
+	}
+	if policy == nil {
+		return s.next.SelectChannel(ctx, request, resolution, mappings)
+	}
+
+	decision := &ResidencyDecision{
+		Timestamp:      time.Now(),
+		TenantID:       tenantID,
+		Policy:         *policy,
+		CandidateCount: len(mappings),
+	}
+	if request != nil {
+		decision.TraceID = request.TraceID
+		decision.Mode = request.Mode
+		decision.Attempt = request.Attempt
+		decision.RequestedModel = request.RequestedModel
+	}
+
+	// Mappings whose provider or region is known to violate the policy are
+	// dropped up front. Mappings that leave either blank may inherit it from
+	// their channel, so they stay candidates and the concrete selection is
+	// checked instead; a non-compliant selection removes its mapping and the
+	// wrapped selector is asked again.
+	candidates := make([]ChannelModelMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		if ok, reason := policy.Check(mapping.Provider, mapping.Region); !ok && !residencyUndetermined(*policy, mapping) {
+			decision.Rejected = append(decision.Rejected, residencyRejection(mapping.ChannelID, mapping.Provider, mapping.Region, reason))
+			continue
+		}
+		candidates = append(candidates, mapping)
+	}
+
+	for len(candidates) > 0 {
+		selection, err := s.next.SelectChannel(ctx, request, resolution, candidates)
+		if err != nil {
+			decision.Reason = "selector error: " + err.Error()
+			s.audit(ctx, decision)
+			return nil, err
+		}
+		if selection == nil {
+			decision.CompliantCount = len(candidates)
+			decision.Allowed = true
+			s.audit(ctx, decision)
+			return nil, nil
+		}
+		ok, reason := policy.Check(selection.Provider, selection.Region)
+		if ok {
+			decision.CompliantCount = len(candidates)
+			decision.Allowed = true
+			decision.SelectedChannelID = selection.ChannelID
+			decision.SelectedProvider = selection.Provider
+			decision.SelectedRegion = selection.Region
+			s.audit(ctx, decision)
+			return selection, nil
+		}
+		decision.Rejected = append(decision.Rejected, residencyRejection(selection.ChannelID, selection.Provider, selection.Region, reason))
+		remaining := withoutSelectedMapping(candidates, selection)
+		if len(remaining) == len(candidates) {
+			// The selector returned a route outside the candidates; it cannot
+			// be steered away from it.
+			break
+		}
+		candidates = remaining
+	}
+
+	decision.Reason = "no compliant provider"
+	s.audit(ctx, decision)
+	return nil, residencyViolation(tenantID, decision.RequestedModel, *policy)
+}
+
+// residencyUndetermined reports whether a mapping fails the policy only
+// because it leaves the provider or region for its channel to supply.
+func residencyUndetermined(policy DataResidencyPolicy, mapping ChannelModelMapping) bool {
+	providerBlank := strings.TrimSpace(mapping.Provider) == ""
+	regionBlank := strings.TrimSpace(mapping.Region) == ""
+	if !providerBlank && !regionBlank {
+		return false
+	}
+	probe := policy
+	if providerBlank {
+		probe.AllowedProviders = nil
+	}
+	if regionBlank {
+		probe.AllowedRegions = nil
+	}
+	ok, _ := probe.Check(mapping.Provider, mapping.Region)
+	return ok
+}
+
+func withoutSelectedMapping(mappings []ChannelModelMapping, selection *ChannelSelection) []ChannelModelMapping {
+	out := make([]ChannelModelMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		if selection.MappingID != "" && mapping.MappingID == selection.MappingID {
+			continue
+		}
+		if selection.MappingID == "" && mapping.ChannelID == selection.ChannelID {
+			continue
+		}
+		out = append(out, mapping)
+	}
+	return out
+}
+
+func residencyRejection(channelID, provider, region, reason string) ResidencyRejection {
+	return ResidencyRejection{ChannelID: channelID, Provider: provider, Region: region, Reason: reason}
+}
+
+func (s *DataResidencySelector) audit(ctx context.Context, decision *ResidencyDecision) {
+	fields := []zap.Field{
+		zap.String("tenant_id", decision.TenantID),
+		zap.String("trace_id", decision.TraceID),
+		zap.String("model", decision.RequestedModel),
+		zap.Bool("allowed", decision.Allowed),
+		zap.Int("candidates", decision.CandidateCount),
+		zap.Int("compliant", decision.CompliantCount),
+		zap.String("selected_channel_id", decision.SelectedChannelID),
+		zap.String("selected_region", decision.SelectedRegion),
+	}
+	if decision.Allowed {
+		s.logger.Debug("data residency route allowed", fields...)
+	} else {
+		s.logger.Warn("data residency route rejected", append(fields, zap.String("reason", decision.Reason))...)
+	}
+	if s.auditor == nil {
+		return
+	}
+	if err := s.auditor.RecordResidencyDecision(ctx, decision); err != nil {
+		s.logger.Warn("failed to record data residency decision", zap.Error(err))
+	}
+}
+
+func residencyTenantID(ctx context.Context, request *ChannelRouteRequest) string {
+	if request != nil && strings.TrimSpace(request.TenantID) != "" {
+		return strings.TrimSpace(request.TenantID)
+	}
+	tenantID, _ := types.TenantID(ctx)
+	return tenantID
+}
+
+func residencyViolation(tenantID, model string, policy DataResidencyPolicy) *types.Error {
+	subject := "this request"
+	if tenantID != "" {
+		subject = fmt.Sprintf("tenant %q", tenantID)
+	}
+	return types.NewError(types.ErrDataResidency, fmt.Sprintf(
+		"no provider for model %q satisfies the data residency policy of %s (%s)",
+		model, subject, policy.describe(),
+	))
+}
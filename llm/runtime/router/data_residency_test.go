@@ -0,0 +1,204 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// firstMappingSelector picks the first candidate, filling in the region from
+// its channel the way store-backed selectors do.
+type firstMappingSelector struct {
+	channelRegions map[string]string
+	calls          int
+}
+
+func (s *firstMappingSelector) SelectChannel(_ context.Context, _ *ChannelRouteRequest, _ *ModelResolution, mappings []ChannelModelMapping) (*ChannelSelection, error) {
+	s.calls++
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+	mapping := mappings[0]
+	region := mapping.Region
+	if region == "" {
+		region = s.channelRegions[mapping.ChannelID]
+	}
+	return &ChannelSelection{MappingID: mapping.MappingID, ChannelID: mapping.ChannelID, Provider: mapping.Provider, Region: region}, nil
+}
+
+type residencyAuditLog struct {
+	decisions []*ResidencyDecision
+}
+
+func (l *residencyAuditLog) RecordResidencyDecision(_ context.Context, decision *ResidencyDecision) error {
+	l.decisions = append(l.decisions, decision)
+	return nil
+}
+
+func residencyMappings() []ChannelModelMapping {
+	return []ChannelModelMapping{
+		{MappingID: "m-us", ChannelID: "ch-us", Provider: "openai", Region: "us-east-1"},
+		{MappingID: "m-eu", ChannelID: "ch-eu", Provider: "openai", Region: "eu-west-1"},
+		{MappingID: "m-local", ChannelID: "ch-local", Provider: "qwen", Region: "eu-central-1"},
+	}
+}
+
+func TestDataResidencyPolicy_Check(t *testing.T) {
+	policy := DataResidencyPolicy{AllowedRegions: []string{"EU-*", "uk-south"}, AllowedProviders: []string{"openai"}}
+
+	ok, _ := policy.Check("OpenAI", "eu-west-1")
+	assert.True(t, ok)
+	ok, _ = policy.Check("openai", "uk-south")
+	assert.True(t, ok)
+	ok, reason := policy.Check("openai", "us-east-1")
+	assert.False(t, ok)
+	assert.Contains(t, reason, "us-east-1")
+	ok, reason = policy.Check("anthropic", "eu-west-1")
+	assert.False(t, ok)
+	assert.Contains(t, reason, "anthropic")
+	ok, reason = policy.Check("openai", "")
+	assert.False(t, ok)
+	assert.Equal(t, "region is unknown", reason)
+
+	ok, _ = DataResidencyPolicy{}.Check("any", "")
+	assert.True(t, ok, "an empty policy is unrestricted")
+}
+
+func TestStaticDataResidencyPolicies_FallsBackToDefault(t *testing.T) {
+	policies := StaticDataResidencyPolicies{
+		"tenant-eu":               {AllowedRegions: []string{"eu-*"}},
+		DefaultResidencyPolicyKey: {AllowedProviders: []string{"openai"}},
+	}
+	ctx := context.Background()
+
+	policy, err := policies.ResolveResidencyPolicy(ctx, "tenant-eu")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-*"}, policy.AllowedRegions)
+
+	policy, err = policies.ResolveResidencyPolicy(ctx, "tenant-other")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai"}, policy.AllowedProviders)
+
+	policy, err = StaticDataResidencyPolicies{}.ResolveResidencyPolicy(ctx, "tenant-other")
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+}
+
+func TestDataResidencySelector_FiltersNonCompliantMappings(t *testing.T) {
+	inner := &captureChannelSelector{selections: []ChannelSelection{{ChannelID: "ch-eu", Provider: "openai", Region: "eu-west-1"}}}
+	audit := &residencyAuditLog{}
+	selector := NewDataResidencySelector(inner, DataResidencyOptions{
+		Policies: StaticDataResidencyPolicies{"tenant-eu": {AllowedRegions: []string{"eu-*"}, AllowedProviders: []string{"openai"}}},
+		Auditor:  audit,
+	})
+
+	request := &ChannelRouteRequest{TenantID: "tenant-eu", RequestedModel: "gpt-4o", TraceID: "trace-1"}
+	selection, err := selector.SelectChannel(context.Background(), request, nil, residencyMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "ch-eu", selection.ChannelID)
+	require.Len(t, inner.lastMappings, 1)
+	assert.Equal(t, "m-eu", inner.lastMappings[0].MappingID)
+
+	require.Len(t, audit.decisions, 1)
+	decision := audit.decisions[0]
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "tenant-eu", decision.TenantID)
+	assert.Equal(t, "trace-1", decision.TraceID)
+	assert.Equal(t, 3, decision.CandidateCount)
+	assert.Equal(t, 1, decision.CompliantCount)
+	assert.Equal(t, "eu-west-1", decision.SelectedRegion)
+	assert.Len(t, decision.Rejected, 2)
+}
+
+func TestDataResidencySelector_RejectsWhenNoCompliantProvider(t *testing.T) {
+	inner := &captureChannelSelector{selections: []ChannelSelection{{ChannelID: "ch-us"}}}
+	audit := &residencyAuditLog{}
+	selector := NewDataResidencySelector(inner, DataResidencyOptions{
+		Policies: StaticDataResidencyPolicies{"tenant-cn": {AllowedRegions: []string{"cn-*"}}},
+		Auditor:  audit,
+	})
+
+	ctx := types.WithTenantID(context.Background(), "tenant-cn")
+	selection, err := selector.SelectChannel(ctx, &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, residencyMappings())
+	require.Error(t, err)
+	assert.Nil(t, selection)
+	assert.Equal(t, 0, inner.callCount)
+
+	var typedErr *types.Error
+	require.True(t, errors.As(err, &typedErr))
+	assert.Equal(t, types.ErrDataResidency, typedErr.Code)
+	assert.Contains(t, typedErr.Message, `"tenant-cn"`)
+	assert.Contains(t, typedErr.Message, "cn-*")
+
+	require.Len(t, audit.decisions, 1)
+	assert.False(t, audit.decisions[0].Allowed)
+	assert.Equal(t, "tenant-cn", audit.decisions[0].TenantID, "the tenant falls back to the context")
+	assert.Len(t, audit.decisions[0].Rejected, 3)
+}
+
+func TestDataResidencySelector_ChecksRegionsInheritedFromChannels(t *testing.T) {
+	inner := &firstMappingSelector{channelRegions: map[string]string{"ch-us": "us-east-1", "ch-eu": "eu-west-1"}}
+	audit := &residencyAuditLog{}
+	selector := NewDataResidencySelector(inner, DataResidencyOptions{
+		Policies: StaticDataResidencyPolicies{DefaultResidencyPolicyKey: {AllowedRegions: []string{"eu-*"}}},
+		Auditor:  audit,
+	})
+	mappings := []ChannelModelMapping{
+		{MappingID: "m-us", ChannelID: "ch-us", Provider: "openai"},
+		{MappingID: "m-eu", ChannelID: "ch-eu", Provider: "openai"},
+	}
+
+	selection, err := selector.SelectChannel(context.Background(), &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, mappings)
+	require.NoError(t, err)
+	assert.Equal(t, "ch-eu", selection.ChannelID)
+	assert.Equal(t, 2, inner.calls, "the non-compliant selection is excluded and the selector asked again")
+	require.Len(t, audit.decisions, 1)
+	require.Len(t, audit.decisions[0].Rejected, 1)
+	assert.Equal(t, "ch-us", audit.decisions[0].Rejected[0].ChannelID)
+
+	_, err = selector.SelectChannel(context.Background(), &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, mappings[:1])
+	var typedErr *types.Error
+	require.True(t, errors.As(err, &typedErr))
+	assert.Equal(t, types.ErrDataResidency, typedErr.Code)
+}
+
+func TestDataResidencySelector_UnrestrictedTenantPassesThrough(t *testing.T) {
+	inner := &captureChannelSelector{selections: []ChannelSelection{{ChannelID: "ch-us"}}}
+	audit := &residencyAuditLog{}
+	selector := NewDataResidencySelector(inner, DataResidencyOptions{
+		Policies: StaticDataResidencyPolicies{"tenant-eu": {AllowedRegions: []string{"eu-*"}}},
+		Auditor:  audit,
+	})
+
+	selection, err := selector.SelectChannel(context.Background(), &ChannelRouteRequest{TenantID: "tenant-free"}, nil, residencyMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "ch-us", selection.ChannelID)
+	assert.Len(t, inner.lastMappings, 3)
+	assert.Empty(t, audit.decisions, "tenants without a policy are not audited")
+}
+
+func TestBuildChannelRoutedProvider_DataResidency(t *testing.T) {
+	selector := &captureChannelSelector{selections: []ChannelSelection{{ChannelID: "ch-us", Provider: "openai", Region: "us-east-1"}}}
+	factory := &captureChannelFactory{provider: &channelCaptureProvider{name: "openai"}}
+	provider, err := NewChannelRoutedProviderBuilder(ChannelRoutedProviderConfig{
+		ModelMappingResolver: &captureModelMappingResolver{mappings: residencyMappings()},
+		ChannelSelector:      selector,
+		ChatProviderFactory:  factory,
+	}).WithDataResidency(DataResidencyOptions{
+		Policies: StaticDataResidencyPolicies{"tenant-eu": {AllowedRegions: []string{"eu-*"}}},
+	}).Build()
+	require.NoError(t, err)
+
+	req := &ChatRequest{Model: "gpt-4o", TenantID: "tenant-eu", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	_, err = provider.Completion(context.Background(), req)
+	var typedErr *types.Error
+	require.True(t, errors.As(err, &typedErr), "got %v", err)
+	assert.Equal(t, types.ErrDataResidency, typedErr.Code)
+	for _, mapping := range selector.lastMappings {
+		assert.Contains(t, mapping.Region, "eu-")
+	}
+}
@@ -19,6 +19,12 @@ type RuntimeMetrics struct {
 	RateLimitCount int64
 	FailureCount   int64
 	LastUpdated    time.Time
+
+	// Streaming responsiveness, averaged over recent stream calls.
+	StreamSamples        int64
+	AvgTimeToFirstToken  time.Duration
+	AvgInterTokenLatency time.Duration
+	TokensPerSecond      float64
 }
 
 // MetricsSource provides runtime metrics for adaptive weight computation.
@@ -58,6 +64,27 @@ type AdaptiveWeightConfig struct {
 	// Below this threshold the base weight is used as-is.
 	// Default: 5
 	MinCalls int64
+
+	// TTFTTarget is the acceptable average time-to-first-token. Channels
+	// slower than this are penalized proportionally, up to StreamLatencyPenalty
+	// at twice the target.
+	// Default: 2s
+	TTFTTarget time.Duration
+
+	// MinTokensPerSecond is the acceptable streaming generation rate. Slower
+	// channels are penalized proportionally, up to StreamLatencyPenalty.
+	// Default: 10
+	MinTokensPerSecond float64
+
+	// StreamLatencyPenalty is the maximum factor reduction applied for each
+	// of slow TTFT and slow generation rate.
+	// Default: 0.5
+	StreamLatencyPenalty float64
+
+	// MinStreamSamples is the minimum number of stream samples before
+	// streaming latency influences the weight.
+	// Default: 3
+	MinStreamSamples int64
 }
 
 func (c AdaptiveWeightConfig) withDefaults() AdaptiveWeightConfig {
@@ -82,6 +109,18 @@ func (c AdaptiveWeightConfig) withDefaults() AdaptiveWeightConfig {
 	if c.MinCalls == 0 {
 		c.MinCalls = 5
 	}
+	if c.TTFTTarget == 0 {
+		c.TTFTTarget = 2 * time.Second
+	}
+	if c.MinTokensPerSecond == 0 {
+		c.MinTokensPerSecond = 10
+	}
+	if c.StreamLatencyPenalty == 0 {
+		c.StreamLatencyPenalty = 0.5
+	}
+	if c.MinStreamSamples == 0 {
+		c.MinStreamSamples = 3
+	}
 	return c
 }
 
@@ -100,6 +139,7 @@ type AdaptiveWeightedSelector struct {
 }
 
 var _ router.ChannelSelector = (*AdaptiveWeightedSelector)(nil)
+var _ router.UsageRecorder = (*InMemoryMetricsSource)(nil)
 
 // AdaptiveSelectorOptions configures an AdaptiveWeightedSelector.
 type AdaptiveSelectorOptions struct {
@@ -278,6 +318,7 @@ func (s *AdaptiveWeightedSelector) drawIndex(totalWeight int) int {
 //	factor = BaseFactor + successRate * SuccessBoost
 //	factor -= rateLimitRate * RateLimitPenalty
 //	factor -= nonRateFailureRate * FailurePenalty
+//	factor -= streamLatencyPenalty   (once StreamSamples >= MinStreamSamples)
 //	factor = clamp(factor, MinFactor, MaxFactor)
 //	adaptedWeight = round(baseWeight * factor)
 func computeAdaptiveWeight(baseWeight int, metrics *RuntimeMetrics, cfg AdaptiveWeightConfig) int {
@@ -296,6 +337,9 @@ func computeAdaptiveWeight(baseWeight int, metrics *RuntimeMetrics, cfg Adaptive
 	factor := cfg.BaseFactor + successRate*cfg.SuccessBoost
 	factor -= rateLimitRate * cfg.RateLimitPenalty
 	factor -= nonRateFailure * cfg.FailurePenalty
+	if metrics.StreamSamples >= cfg.MinStreamSamples {
+		factor -= streamLatencyPenalty(metrics, cfg)
+	}
 
 	if factor < cfg.MinFactor {
 		factor = cfg.MinFactor
@@ -311,6 +355,25 @@ func computeAdaptiveWeight(baseWeight int, metrics *RuntimeMetrics, cfg Adaptive
 	return weight
 }
 
+// streamLatencyPenalty scales StreamLatencyPenalty by how far TTFT exceeds
+// TTFTTarget and how far tokens/sec falls short of MinTokensPerSecond; each
+// shortfall is capped at 1.
+func streamLatencyPenalty(metrics *RuntimeMetrics, cfg AdaptiveWeightConfig) float64 {
+	penalty := 0.0
+	if cfg.TTFTTarget > 0 && metrics.AvgTimeToFirstToken > cfg.TTFTTarget {
+		excess := float64(metrics.AvgTimeToFirstToken-cfg.TTFTTarget) / float64(cfg.TTFTTarget)
+		penalty += math.Min(excess, 1) * cfg.StreamLatencyPenalty
+	}
+	if cfg.MinTokensPerSecond > 0 && metrics.TokensPerSecond > 0 && metrics.TokensPerSecond < cfg.MinTokensPerSecond {
+		shortfall := (cfg.MinTokensPerSecond - metrics.TokensPerSecond) / cfg.MinTokensPerSecond
+		penalty += math.Min(shortfall, 1) * cfg.StreamLatencyPenalty
+	}
+	return penalty
+}
+
+// streamMetricsAlpha is the EWMA weight given to each new stream sample.
+const streamMetricsAlpha = 0.2
+
 // InMemoryMetricsSource is a thread-safe in-memory MetricsSource.
 type InMemoryMetricsSource struct {
 	mu      sync.RWMutex
@@ -353,6 +416,63 @@ func (m *InMemoryMetricsSource) Record(channelID string, success bool, isRateLim
 	rm.LastUpdated = time.Now()
 }
 
+// RecordStream folds one stream call's TTFT, mean inter-token latency and
+// tokens/sec into the channel's moving averages.
+func (m *InMemoryMetricsSource) RecordStream(channelID string, ttft, interToken time.Duration, tokensPerSecond float64) {
+	if ttft <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rm, ok := m.metrics[channelID]
+	if !ok {
+		rm = &RuntimeMetrics{}
+		m.metrics[channelID] = rm
+	}
+	rm.StreamSamples++
+	rm.AvgTimeToFirstToken = time.Duration(movingAverage(float64(rm.AvgTimeToFirstToken), float64(ttft)))
+	if interToken > 0 {
+		rm.AvgInterTokenLatency = time.Duration(movingAverage(float64(rm.AvgInterTokenLatency), float64(interToken)))
+	}
+	if tokensPerSecond > 0 {
+		rm.TokensPerSecond = movingAverage(rm.TokensPerSecond, tokensPerSecond)
+	}
+	rm.LastUpdated = time.Now()
+}
+
+// RecordUsage implements router.UsageRecorder so the source can be fed
+// directly by ChannelRoutedProvider. Rate limits are detected from the error
+// message.
+func (m *InMemoryMetricsSource) RecordUsage(_ context.Context, usage *router.ChannelUsageRecord) error {
+	if usage == nil {
+		return nil
+	}
+	channelID := strings.TrimSpace(usage.ChannelID)
+	if channelID == "" {
+		return nil
+	}
+	m.Record(channelID, usage.Success, isRateLimitMessage(usage.ErrorMessage))
+	m.RecordStream(
+		channelID,
+		time.Duration(usage.TimeToFirstTokenMS)*time.Millisecond,
+		time.Duration(usage.InterTokenLatencyMS*float64(time.Millisecond)),
+		usage.TokensPerSecond,
+	)
+	return nil
+}
+
+func movingAverage(prev, sample float64) float64 {
+	if prev == 0 {
+		return sample
+	}
+	return streamMetricsAlpha*sample + (1-streamMetricsAlpha)*prev
+}
+
+func isRateLimitMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "429") || strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests")
+}
+
 // Reset clears all metrics.
 func (m *InMemoryMetricsSource) Reset() {
 	m.mu.Lock()
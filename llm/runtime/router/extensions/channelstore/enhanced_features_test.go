@@ -2,6 +2,7 @@ package channelstore
 
 import (
 	"context"
+	"math"
 	"math/rand"
 	"sync"
 	"testing"
@@ -98,6 +99,64 @@ func TestComputeAdaptiveWeight(t *testing.T) {
 	}
 }
 
+func TestComputeAdaptiveWeight_PenalizesSlowStreams(t *testing.T) {
+	cfg := AdaptiveWeightConfig{}.withDefaults()
+	base := &RuntimeMetrics{TotalCalls: 100, SuccessCount: 100}
+
+	fast := *base
+	fast.StreamSamples = 10
+	fast.AvgTimeToFirstToken = 300 * time.Millisecond
+	fast.TokensPerSecond = 80
+	if w := computeAdaptiveWeight(10, &fast, cfg); w != computeAdaptiveWeight(10, base, cfg) {
+		t.Errorf("expected responsive stream to keep weight, got %d", w)
+	}
+
+	// TTFT at twice the target and 40% of the minimum rate:
+	// factor = 1.4 - 0.5 - 0.3 = 0.6
+	slow := *base
+	slow.StreamSamples = 10
+	slow.AvgTimeToFirstToken = 4 * time.Second
+	slow.TokensPerSecond = 4
+	if w := computeAdaptiveWeight(10, &slow, cfg); w != 6 {
+		t.Errorf("expected slow stream weight 6, got %d", w)
+	}
+
+	// Too few stream samples are ignored
+	slow.StreamSamples = 1
+	if w := computeAdaptiveWeight(10, &slow, cfg); w != 14 {
+		t.Errorf("expected stream penalty to wait for samples, got %d", w)
+	}
+}
+
+func TestInMemoryMetricsSource_RecordUsage(t *testing.T) {
+	ctx := context.Background()
+	src := NewInMemoryMetricsSource()
+
+	_ = src.RecordUsage(ctx, &router.ChannelUsageRecord{ChannelID: "ch1", Success: true, TimeToFirstTokenMS: 1000, InterTokenLatencyMS: 20, TokensPerSecond: 50})
+	_ = src.RecordUsage(ctx, &router.ChannelUsageRecord{ChannelID: "ch1", Success: true, TimeToFirstTokenMS: 2000, TokensPerSecond: 40})
+	_ = src.RecordUsage(ctx, &router.ChannelUsageRecord{ChannelID: "ch1", ErrorMessage: "HTTP 429 Too Many Requests"})
+
+	m, err := src.GetMetrics(ctx, "ch1")
+	if err != nil || m == nil {
+		t.Fatalf("expected metrics, got %v %v", m, err)
+	}
+	if m.TotalCalls != 3 || m.SuccessCount != 2 || m.RateLimitCount != 1 {
+		t.Errorf("unexpected call counts: %+v", m)
+	}
+	if m.StreamSamples != 2 {
+		t.Errorf("expected 2 stream samples, got %d", m.StreamSamples)
+	}
+	if m.AvgTimeToFirstToken != 1200*time.Millisecond {
+		t.Errorf("expected EWMA ttft 1.2s, got %v", m.AvgTimeToFirstToken)
+	}
+	if m.AvgInterTokenLatency != 20*time.Millisecond {
+		t.Errorf("expected inter-token latency 20ms, got %v", m.AvgInterTokenLatency)
+	}
+	if math.Abs(m.TokensPerSecond-48) > 1e-9 {
+		t.Errorf("expected EWMA tokens/sec 48, got %v", m.TokensPerSecond)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // InMemoryQuotaPolicy
 // ---------------------------------------------------------------------------
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"sort"
 	"time"
 
@@ -15,8 +16,9 @@ import (
 type MultiProviderRouter struct {
 	*Router // 继承原有路由器
 
-	apiKeyPools     map[uint]*APIKeyPool // providerID -> APIKeyPool
-	providerFactory ProviderFactory      // Provider 工厂
+	apiKeyPools     map[uint]*APIKeyPool    // providerID -> APIKeyPool
+	providerFactory ProviderFactory         // Provider 工厂
+	apiKeyStrategy  APIKeySelectionStrategy // API Key 选择策略
 }
 
 type multiProviderCandidate struct {
@@ -44,10 +46,16 @@ func NewMultiProviderRouter(db *gorm.DB, providerFactory ProviderFactory, opts R
 	// 注意：这里不传入 providers map，因为会动态创建
 	baseRouter := NewRouter(db, make(map[string]Provider), opts)
 
+	apiKeyStrategy := opts.APIKeyStrategy
+	if apiKeyStrategy == "" {
+		apiKeyStrategy = StrategyWeightedRandom
+	}
+
 	return &MultiProviderRouter{
 		Router:          baseRouter,
 		apiKeyPools:     make(map[uint]*APIKeyPool),
 		providerFactory: providerFactory,
+		apiKeyStrategy:  apiKeyStrategy,
 	}
 }
 
@@ -65,7 +73,7 @@ func (r *MultiProviderRouter) InitAPIKeyPools(ctx context.Context) error {
 
 	// 为每个提供商创建 API Key 池
 	for _, provider := range providers {
-		pool, err := NewAPIKeyPool(r.db, provider.ID, StrategyWeightedRandom, r.logger)
+		pool, err := NewAPIKeyPool(r.db, provider.ID, r.apiKeyStrategy, r.logger)
 		if err != nil {
 			r.logger.Error("failed to create API key pool",
 				zap.Uint("provider_id", provider.ID),
@@ -136,6 +144,7 @@ func (r *MultiProviderRouter) queryCandidates(ctx context.Context, modelName, pr
 }
 
 func (r *MultiProviderRouter) selectByStrategy(ctx context.Context, candidates []multiProviderCandidate, strategy RoutingStrategy) (*ProviderSelection, error) {
+	candidates = r.preferProvidersWithHeadroom(candidates)
 	switch strategy {
 	case StrategyCostBased:
 		return r.selectByCostMulti(ctx, candidates)
@@ -150,6 +159,46 @@ func (r *MultiProviderRouter) selectByStrategy(ctx context.Context, candidates [
 	}
 }
 
+// preferProvidersWithHeadroom 按剩余配额分层，仅保留最优的一层：
+// 余量充足 > 余量低于水位 > 已耗尽或冷却中，避免把流量继续打到即将 429 的 provider
+func (r *MultiProviderRouter) preferProvidersWithHeadroom(candidates []multiProviderCandidate) []multiProviderCandidate {
+	if r.rateLimiter == nil || len(candidates) < 2 {
+		return candidates
+	}
+	var ample, low, exhausted []multiProviderCandidate
+	for _, c := range candidates {
+		headroom := r.rateLimiter.Headroom(c.ProviderCode)
+		switch {
+		case headroom >= r.rateLimiter.lowWatermark:
+			ample = append(ample, c)
+		case headroom > 0:
+			low = append(low, c)
+		default:
+			exhausted = append(exhausted, c)
+		}
+	}
+	if len(ample) > 0 {
+		return ample
+	}
+	if len(low) > 0 {
+		return low
+	}
+	// 全部耗尽时只保留最早恢复的 provider，请求随后在配额限流器中排队
+	soonest := make([]multiProviderCandidate, 0, len(exhausted))
+	minDelay := time.Duration(math.MaxInt64)
+	for _, c := range exhausted {
+		delay := r.rateLimiter.Delay(c.ProviderCode, 0)
+		switch {
+		case delay < minDelay:
+			minDelay = delay
+			soonest = append(soonest[:0], c)
+		case delay == minDelay:
+			soonest = append(soonest, c)
+		}
+	}
+	return soonest
+}
+
 // selectByCostMulti 成本优先选择（多提供商）
 func (r *MultiProviderRouter) selectByCostMulti(ctx context.Context, candidates []multiProviderCandidate) (*ProviderSelection, error) {
 	// 过滤不健康的提供商
@@ -273,13 +322,18 @@ func (r *MultiProviderRouter) selectByQPSMulti(ctx context.Context, candidates [
 		return nil, &Error{Code: "BUSINESS_LLM_PROVIDER_UNAVAILABLE", Message: "All providers are unhealthy"}
 	}
 
-	// 选择当前 QPS 最低的提供商
-	minQPS := int(^uint(0) >> 1)
+	// 选择当前 QPS 最低的提供商；配置了配额时按剩余比例折算，余量越少负载视为越高
+	minQPS := math.MaxFloat64
 	var bestCandidate *multiProviderCandidate
 
 	for i := range healthyCandidates {
 		c := &healthyCandidates[i]
-		currentQPS := r.healthMonitor.GetCurrentQPS(c.ProviderCode)
+		currentQPS := float64(r.healthMonitor.GetCurrentQPS(c.ProviderCode))
+		if r.rateLimiter != nil {
+			if headroom := r.rateLimiter.Headroom(c.ProviderCode); headroom > 0 {
+				currentQPS = (currentQPS + 1) / headroom
+			}
+		}
 		if currentQPS < minQPS {
 			minQPS = currentQPS
 			bestCandidate = c
@@ -346,6 +400,27 @@ func (r *MultiProviderRouter) buildSelectionMulti(
 	}, nil
 }
 
+// ProviderRateLimiter 返回各提供商共享的配额限流器
+func (r *MultiProviderRouter) ProviderRateLimiter() *ProviderRateLimiter {
+	return r.rateLimiter
+}
+
+// AcquireProviderQuota 在调用上游前占用提供商配额，配额不足时排队等待
+func (r *MultiProviderRouter) AcquireProviderQuota(ctx context.Context, providerCode string, tokens int) (*ProviderRateReservation, error) {
+	if r.rateLimiter == nil {
+		return nil, nil
+	}
+	return r.rateLimiter.Acquire(ctx, providerCode, tokens)
+}
+
+// ObserveProviderResponse 记录上游响应的限流头与 429，供后续路由与排队参考
+func (r *MultiProviderRouter) ObserveProviderResponse(providerCode string, statusCode int, header http.Header) {
+	if r.rateLimiter == nil {
+		return
+	}
+	r.rateLimiter.Observe(providerCode, statusCode, header)
+}
+
 // SelectAPIKey 为指定提供商选择 API Key
 func (r *MultiProviderRouter) SelectAPIKey(ctx context.Context, providerID uint) (*LLMProviderAPIKey, error) {
 	pool, exists := r.apiKeyPools[providerID]
@@ -380,6 +455,15 @@ func (r *MultiProviderRouter) RecordAPIKeyUsage(ctx context.Context, providerID
 	return pool.RecordFailure(ctx, keyID, errMsg)
 }
 
+// RecordAPIKeyResponse 根据上游响应状态码与限流头更新 API Key 配额与冷却状态
+func (r *MultiProviderRouter) RecordAPIKeyResponse(ctx context.Context, providerID uint, keyID uint, statusCode int, header http.Header) error {
+	pool, exists := r.apiKeyPools[providerID]
+	if !exists {
+		return fmt.Errorf("API key pool not found for provider %d", providerID)
+	}
+	return pool.RecordResponse(ctx, keyID, statusCode, header)
+}
+
 // GetAPIKeyStats 获取所有 API Key 统计信息
 func (r *MultiProviderRouter) GetAPIKeyStats() map[uint]map[uint]*APIKeyStats {
 	stats := make(map[uint]map[uint]*APIKeyStats)
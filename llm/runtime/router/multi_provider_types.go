@@ -25,6 +25,7 @@ type Router struct {
 	providers     map[string]Provider
 	healthMonitor *HealthMonitor
 	canaryConfig  *CanaryConfig
+	rateLimiter   *ProviderRateLimiter
 	logger        *zap.Logger
 
 	healthCheckInterval time.Duration
@@ -36,6 +37,12 @@ type RouterOptions struct {
 	HealthCheckInterval time.Duration
 	HealthCheckTimeout  time.Duration
 	Logger              *zap.Logger
+	// APIKeyStrategy API Key 池选择策略，默认加权随机
+	APIKeyStrategy APIKeySelectionStrategy
+	// ProviderRateLimits 按 provider code 配置的公布 RPM/TPM
+	ProviderRateLimits map[string]ProviderRateLimits
+	// RateLimitMaxWait 配额不足时请求排队等待的最长时间，0 使用默认值，负数不等待
+	RateLimitMaxWait time.Duration
 }
 
 // 提供者选择代表选定的提供者
@@ -64,11 +71,17 @@ func NewRouter(db *gorm.DB, providers map[string]Provider, opts RouterOptions) *
 		opts.HealthCheckTimeout = 10 * time.Second
 	}
 
+	rateLimiter := NewProviderRateLimiter(ProviderRateLimiterOptions{
+		Limits:  opts.ProviderRateLimits,
+		MaxWait: opts.RateLimitMaxWait,
+	})
+
 	return &Router{
 		db:                  db,
 		providers:           providers,
 		healthMonitor:       NewHealthMonitor(db),
 		canaryConfig:        NewCanaryConfig(db, opts.Logger),
+		rateLimiter:         rateLimiter,
 		logger:              opts.Logger,
 		healthCheckInterval: opts.HealthCheckInterval,
 		healthCheckTimeout:  opts.HealthCheckTimeout,
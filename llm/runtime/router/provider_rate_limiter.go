@@ -0,0 +1,364 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+const (
+	providerRateWindow          = time.Minute
+	defaultProviderRateMaxWait  = 10 * time.Second
+	defaultHeadroomLowWatermark = 0.1
+)
+
+// ProviderRateLimits 提供商公布的每分钟限额，0 表示不限制
+type ProviderRateLimits struct {
+	RPM int `json:"rpm"`
+	TPM int `json:"tpm"`
+}
+
+// ProviderRateLimiterOptions 配置提供商配额限流器
+type ProviderRateLimiterOptions struct {
+	// Limits 按 provider code 配置的公布限额
+	Limits map[string]ProviderRateLimits
+	// MaxWait 请求排队等待配额的最长时间，超出时直接返回限流错误；0 使用默认 10s，负数不等待
+	MaxWait time.Duration
+	// HeadroomLowWatermark 剩余配额比例低于该值的 provider 在有其他选择时不参与路由，默认 0.1
+	HeadroomLowWatermark float64
+	Now                  func() time.Time
+}
+
+// ProviderRateSnapshot 单个 provider 的配额视图
+type ProviderRateSnapshot struct {
+	Limits           ProviderRateLimits `json:"limits"`
+	RequestsInWindow int                `json:"requests_in_window"`
+	TokensInWindow   int                `json:"tokens_in_window"`
+	Headroom         float64            `json:"headroom"`
+	Observed         *APIKeyQuota       `json:"observed,omitempty"`
+	BlockedUntil     time.Time          `json:"blocked_until,omitempty"`
+}
+
+// ProviderRateLimiter 在所有请求间共享的提供商配额视图。
+// 结合公布的 RPM/TPM 与上游返回的限流头、429 冷却，供路由器优先选择仍有余量的 provider，
+// 并在配额不足时让请求排队等待，而不是打到上游触发 429 后再重试。
+type ProviderRateLimiter struct {
+	mu           sync.Mutex
+	limits       map[string]ProviderRateLimits
+	states       map[string]*providerRateState
+	maxWait      time.Duration
+	lowWatermark float64
+	now          func() time.Time
+}
+
+type providerRateState struct {
+	events       []*providerRateEvent
+	observed     APIKeyQuota
+	hasObserved  bool
+	blockedUntil time.Time
+}
+
+type providerRateEvent struct {
+	at     time.Time
+	tokens int
+}
+
+// ProviderRateReservation 已占用的一次请求配额，可在拿到实际用量后修正 token 数
+type ProviderRateReservation struct {
+	limiter *ProviderRateLimiter
+	event   *providerRateEvent
+}
+
+// NewProviderRateLimiter 创建提供商配额限流器
+func NewProviderRateLimiter(opts ProviderRateLimiterOptions) *ProviderRateLimiter {
+	maxWait := opts.MaxWait
+	if maxWait == 0 {
+		maxWait = defaultProviderRateMaxWait
+	}
+	if maxWait < 0 {
+		maxWait = 0
+	}
+	lowWatermark := opts.HeadroomLowWatermark
+	if lowWatermark <= 0 {
+		lowWatermark = defaultHeadroomLowWatermark
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	limits := make(map[string]ProviderRateLimits, len(opts.Limits))
+	for provider, l := range opts.Limits {
+		limits[normalizeProviderKey(provider)] = l
+	}
+	return &ProviderRateLimiter{
+		limits:       limits,
+		states:       make(map[string]*providerRateState),
+		maxWait:      maxWait,
+		lowWatermark: lowWatermark,
+		now:          now,
+	}
+}
+
+// SetLimits 更新 provider 的公布限额
+func (l *ProviderRateLimiter) SetLimits(provider string, limits ProviderRateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[normalizeProviderKey(provider)] = limits
+}
+
+// Observe 记录上游响应的状态码与限流头；429 时 provider 进入冷却，
+// 冷却时长取 Retry-After，其次为已耗尽维度的重置时间。
+func (l *ProviderRateLimiter) Observe(provider string, statusCode int, header http.Header) {
+	now := l.now()
+	quota, found := ParseRateLimitHeaders(header, now)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(provider)
+	if found {
+		state.observed = quota
+		state.hasObserved = true
+	}
+	if statusCode == http.StatusTooManyRequests {
+		if until := quota.benchUntil(now, defaultRateLimitCooldown); until.After(state.blockedUntil) {
+			state.blockedUntil = until
+		}
+	}
+}
+
+// Headroom 返回 provider 当前剩余配额比例（0~1），未知时为 1
+func (l *ProviderRateLimiter) Headroom(provider string) float64 {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.headroomLocked(normalizeProviderKey(provider), now)
+}
+
+// Delay 返回估算 tokens 的请求需要等待多久才能发往 provider，0 表示可立即发送
+func (l *ProviderRateLimiter) Delay(provider string, tokens int) time.Duration {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delayLocked(normalizeProviderKey(provider), tokens, now)
+}
+
+// Acquire 为一次请求占用配额；配额不足时排队等待，等待超过 MaxWait 或 ctx 结束时返回错误
+func (l *ProviderRateLimiter) Acquire(ctx context.Context, provider string, tokens int) (*ProviderRateReservation, error) {
+	key := normalizeProviderKey(provider)
+	deadline := l.now().Add(l.maxWait)
+	for {
+		now := l.now()
+		l.mu.Lock()
+		delay := l.delayLocked(key, tokens, now)
+		if delay <= 0 {
+			event := &providerRateEvent{at: now, tokens: l.cappedTokensLocked(key, tokens)}
+			state := l.stateLocked(key)
+			state.events = append(state.events, event)
+			l.mu.Unlock()
+			return &ProviderRateReservation{limiter: l, event: event}, nil
+		}
+		l.mu.Unlock()
+
+		if now.Add(delay).After(deadline) {
+			return nil, types.NewRateLimitError(fmt.Sprintf("provider %s has no rate limit headroom, retry in %s", provider, delay.Round(time.Millisecond)))
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Settle 用实际 token 用量替换预估值，tokens <= 0 时保持预估
+func (r *ProviderRateReservation) Settle(tokens int) {
+	if r == nil || r.limiter == nil || tokens <= 0 {
+		return
+	}
+	r.limiter.mu.Lock()
+	r.event.tokens = tokens
+	r.limiter.mu.Unlock()
+}
+
+// Snapshot 返回所有已知 provider 的配额视图
+func (l *ProviderRateLimiter) Snapshot() map[string]ProviderRateSnapshot {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make(map[string]ProviderRateSnapshot, len(l.states))
+	keys := make(map[string]struct{}, len(l.states)+len(l.limits))
+	for key := range l.states {
+		keys[key] = struct{}{}
+	}
+	for key := range l.limits {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		state := l.stateLocked(key)
+		l.pruneLocked(state, now)
+		snapshot := ProviderRateSnapshot{
+			Limits:           l.limits[key],
+			RequestsInWindow: len(state.events),
+			TokensInWindow:   sumEventTokens(state.events),
+			Headroom:         l.headroomLocked(key, now),
+		}
+		if state.hasObserved {
+			observed := state.observed
+			snapshot.Observed = &observed
+		}
+		if state.blockedUntil.After(now) {
+			snapshot.BlockedUntil = state.blockedUntil
+		}
+		out[key] = snapshot
+	}
+	return out
+}
+
+func (l *ProviderRateLimiter) stateLocked(provider string) *providerRateState {
+	key := normalizeProviderKey(provider)
+	state, ok := l.states[key]
+	if !ok {
+		state = &providerRateState{}
+		l.states[key] = state
+	}
+	return state
+}
+
+func (l *ProviderRateLimiter) pruneLocked(state *providerRateState, now time.Time) {
+	cutoff := now.Add(-providerRateWindow)
+	i := 0
+	for i < len(state.events) && !state.events[i].at.After(cutoff) {
+		i++
+	}
+	if i > 0 {
+		state.events = append(state.events[:0], state.events[i:]...)
+	}
+}
+
+// cappedTokensLocked 单次请求预估超过 TPM 时按 TPM 计，避免大请求永远无法发出
+func (l *ProviderRateLimiter) cappedTokensLocked(key string, tokens int) int {
+	if tokens < 0 {
+		tokens = 0
+	}
+	if tpm := l.limits[key].TPM; tpm > 0 && tokens > tpm {
+		return tpm
+	}
+	return tokens
+}
+
+// effectiveObservedLocked 将上游最近一次上报的剩余额度扣除此后已发出的请求
+func (l *ProviderRateLimiter) effectiveObservedLocked(state *providerRateState) APIKeyQuota {
+	quota := state.observed
+	for _, event := range state.events {
+		if event.at.Before(quota.UpdatedAt) {
+			continue
+		}
+		if quota.RemainingRequests > 0 {
+			quota.RemainingRequests--
+		}
+		if quota.RemainingTokens > 0 {
+			quota.RemainingTokens = max(quota.RemainingTokens-event.tokens, 0)
+		}
+	}
+	return quota
+}
+
+func (l *ProviderRateLimiter) headroomLocked(key string, now time.Time) float64 {
+	state, ok := l.states[key]
+	if !ok {
+		state = &providerRateState{}
+	}
+	l.pruneLocked(state, now)
+	if state.blockedUntil.After(now) {
+		return 0
+	}
+	headroom := 1.0
+	limits := l.limits[key]
+	if limits.RPM > 0 {
+		headroom = math.Min(headroom, float64(limits.RPM-len(state.events))/float64(limits.RPM))
+	}
+	if limits.TPM > 0 {
+		headroom = math.Min(headroom, float64(limits.TPM-sumEventTokens(state.events))/float64(limits.TPM))
+	}
+	if state.hasObserved {
+		headroom = math.Min(headroom, l.effectiveObservedLocked(state).Headroom(now))
+	}
+	return math.Max(headroom, 0)
+}
+
+func (l *ProviderRateLimiter) delayLocked(key string, tokens int, now time.Time) time.Duration {
+	state, ok := l.states[key]
+	if !ok {
+		state = &providerRateState{}
+	}
+	l.pruneLocked(state, now)
+
+	var until time.Time
+	later := func(t time.Time) {
+		if t.After(until) {
+			until = t
+		}
+	}
+	later(state.blockedUntil)
+
+	limits := l.limits[key]
+	if limits.RPM > 0 && len(state.events) >= limits.RPM {
+		later(state.events[len(state.events)-limits.RPM].at.Add(providerRateWindow))
+	}
+	if limits.TPM > 0 {
+		need := sumEventTokens(state.events) + l.cappedTokensLocked(key, tokens) - limits.TPM
+		for _, event := range state.events {
+			if need <= 0 {
+				break
+			}
+			need -= event.tokens
+			later(event.at.Add(providerRateWindow))
+		}
+	}
+	if state.hasObserved {
+		quota := l.effectiveObservedLocked(state)
+		if quota.RemainingRequests == 0 {
+			later(quota.ResetRequestsAt)
+		}
+		if quota.RemainingTokens == 0 {
+			later(quota.ResetTokensAt)
+		}
+	}
+	if !until.After(now) {
+		return 0
+	}
+	return until.Sub(now)
+}
+
+func sumEventTokens(events []*providerRateEvent) int {
+	total := 0
+	for _, event := range events {
+		total += event.tokens
+	}
+	return total
+}
+
+func normalizeProviderKey(provider string) string {
+	return strings.ToLower(strings.TrimSpace(provider))
+}
+
+// estimateRequestTokens 粗略估算请求占用的 token：按 4 字符/token 估算输入并加上 MaxTokens
+func estimateRequestTokens(req *ChatRequest) int {
+	if req == nil {
+		return 0
+	}
+	chars := 0
+	for _, msg := range req.Messages {
+		chars += len(msg.Content)
+	}
+	return chars/4 + req.MaxTokens
+}
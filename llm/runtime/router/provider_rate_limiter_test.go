@@ -0,0 +1,136 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRateClock struct{ now time.Time }
+
+func (c *fakeRateClock) Now() time.Time { return c.now }
+
+func TestProviderRateLimiter_PublishedLimits(t *testing.T) {
+	clock := &fakeRateClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewProviderRateLimiter(ProviderRateLimiterOptions{
+		Limits:  map[string]ProviderRateLimits{"OpenAI": {RPM: 2, TPM: 1000}},
+		MaxWait: -1,
+		Now:     clock.Now,
+	})
+	ctx := context.Background()
+
+	assert.Equal(t, 1.0, limiter.Headroom("openai"))
+	first, err := limiter.Acquire(ctx, "openai", 300)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, limiter.Headroom("openai"), 1e-9)
+
+	clock.now = clock.now.Add(10 * time.Second)
+	_, err = limiter.Acquire(ctx, "openai", 300)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, limiter.Headroom("openai"))
+
+	// RPM 用尽：需等到第一条请求滑出窗口
+	assert.Equal(t, 50*time.Second, limiter.Delay("openai", 10))
+	_, err = limiter.Acquire(ctx, "openai", 10)
+	var typed *types.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, types.ErrRateLimit, typed.Code)
+
+	clock.now = clock.now.Add(50 * time.Second)
+	assert.Zero(t, limiter.Delay("openai", 10))
+
+	// TPM：实际用量修正后影响后续等待
+	first.Settle(900)
+	assert.Zero(t, limiter.Delay("openai", 100))
+	snapshot := limiter.Snapshot()["openai"]
+	assert.Equal(t, 1, snapshot.RequestsInWindow)
+	assert.Equal(t, 300, snapshot.TokensInWindow)
+	assert.Equal(t, 10*time.Second, limiter.Delay("openai", 800))
+
+	// 未配置限额的 provider 不受限制
+	assert.Zero(t, limiter.Delay("anthropic", 1_000_000))
+}
+
+func TestProviderRateLimiter_ObservedHeaders(t *testing.T) {
+	clock := &fakeRateClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewProviderRateLimiter(ProviderRateLimiterOptions{Now: clock.Now})
+
+	limiter.Observe("openai", http.StatusOK, http.Header{
+		"X-Ratelimit-Limit-Requests":     {"10"},
+		"X-Ratelimit-Remaining-Requests": {"2"},
+		"X-Ratelimit-Reset-Requests":     {"20s"},
+	})
+	assert.InDelta(t, 0.2, limiter.Headroom("openai"), 1e-9)
+
+	// 上报后发出的请求会继续消耗剩余额度，耗尽后等待重置而不是打到上游
+	for i := 0; i < 2; i++ {
+		_, err := limiter.Acquire(context.Background(), "openai", 0)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 0.0, limiter.Headroom("openai"))
+	assert.Equal(t, 20*time.Second, limiter.Delay("openai", 0))
+
+	clock.now = clock.now.Add(20 * time.Second)
+	assert.Equal(t, 1.0, limiter.Headroom("openai"))
+}
+
+func TestProviderRateLimiter_429Cooldown(t *testing.T) {
+	clock := &fakeRateClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewProviderRateLimiter(ProviderRateLimiterOptions{Now: clock.Now})
+
+	limiter.Observe("openai", http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}})
+	assert.Equal(t, 0.0, limiter.Headroom("openai"))
+	assert.Equal(t, 5*time.Second, limiter.Delay("openai", 0))
+	assert.Equal(t, clock.now.Add(5*time.Second), limiter.Snapshot()["openai"].BlockedUntil)
+
+	limiter.Observe("anthropic", http.StatusTooManyRequests, nil)
+	assert.Equal(t, defaultRateLimitCooldown, limiter.Delay("anthropic", 0))
+}
+
+func TestProviderRateLimiter_AcquireQueuesUntilHeadroom(t *testing.T) {
+	limiter := NewProviderRateLimiter(ProviderRateLimiterOptions{MaxWait: time.Second})
+	limiter.Observe("openai", http.StatusTooManyRequests, http.Header{"Retry-After": {"0.05"}})
+
+	start := time.Now()
+	_, err := limiter.Acquire(context.Background(), "openai", 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	limiter.Observe("openai", http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = limiter.Acquire(ctx, "openai", 0)
+	require.Error(t, err, "waits beyond MaxWait are rejected immediately")
+}
+
+func TestRoutedChatProvider_AvoidsRateLimitedProvider(t *testing.T) {
+	t.Parallel()
+
+	router, _ := setupRouterForRoutedProviderTest(t)
+	routed := NewRoutedChatProvider(router, RoutedChatProviderOptions{
+		DefaultStrategy: StrategyCostBased,
+		Logger:          zap.NewNop(),
+	})
+	req := &ChatRequest{Model: "gpt-4o"}
+
+	resp, err := routed.Completion(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "mockA", resp.Provider, "cost-first prefers the cheaper provider")
+
+	router.ObserveProviderResponse("mockA", http.StatusTooManyRequests, http.Header{"Retry-After": {"0.2"}})
+	resp, err = routed.Completion(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "mockB", resp.Provider, "traffic moves to the provider with headroom")
+
+	// 两者都无余量时选择最早恢复的 provider，并排队等待而不是触发 429
+	router.ProviderRateLimiter().SetLimits("mockB", ProviderRateLimits{RPM: 1})
+	resp, err = routed.Completion(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "mockA", resp.Provider)
+}
@@ -15,6 +15,11 @@ type RoutedChatProviderOptions struct {
 	Fallback        Provider
 	Logger          *zap.Logger
 	TierRouter      *TierRouter
+	// Capabilities 可选：调用上游前按选中 provider/模型的能力描述协商请求
+	Capabilities *llmcore.CapabilityRegistry
+	// Transcoder 可选：按选中 provider 的方言改写消息历史，使对话中途切换 provider 时
+	// 工具调用配对、角色顺序与推理状态仍被上游接受
+	Transcoder *llmcore.ConversationTranscoder
 }
 
 // RoutedChatProvider routes chat requests to providers selected by MultiProviderRouter.
@@ -24,6 +29,8 @@ type RoutedChatProvider struct {
 	fallback        Provider
 	logger          *zap.Logger
 	tierRouter      *TierRouter
+	capabilities    *llmcore.CapabilityRegistry
+	transcoder      *llmcore.ConversationTranscoder
 }
 
 // NewRoutedChatProvider creates a routed provider entrypoint.
@@ -42,6 +49,8 @@ func NewRoutedChatProvider(router *MultiProviderRouter, opts RoutedChatProviderO
 		fallback:        opts.Fallback,
 		logger:          logger,
 		tierRouter:      opts.TierRouter,
+		capabilities:    opts.Capabilities,
+		transcoder:      opts.Transcoder,
 	}
 }
 
@@ -64,13 +73,25 @@ func (p *RoutedChatProvider) Completion(ctx context.Context, req *ChatRequest) (
 		Model:    resolvedModel,
 		BaseURL:  selection.BaseURL,
 	})
-	routedReq := cloneChatRequest(req, resolvedModel)
-	resp, callErr := selection.Provider.Completion(ctx, routedReq)
+	routedReq, err := p.negotiateRequest(selection, req, resolvedModel, false)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := p.acquireProviderQuota(ctx, selection, routedReq)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, upstream := llmcore.WithUpstreamResponseRecorder(ctx)
+	resp, callErr := selection.Provider.Completion(callCtx, routedReq)
+	p.recordAPIKeyResponse(ctx, selection, upstream, callErr)
 	if callErr != nil {
 		p.recordAPIKeyUsage(ctx, selection, false, callErr.Error())
 		return nil, callErr
 	}
 	p.recordAPIKeyUsage(ctx, selection, true, "")
+	if resp != nil {
+		reservation.Settle(resp.Usage.TotalTokens)
+	}
 	if resp != nil {
 		if strings.TrimSpace(resp.Provider) == "" {
 			resp.Provider = selection.ProviderCode
@@ -101,8 +122,16 @@ func (p *RoutedChatProvider) Stream(ctx context.Context, req *ChatRequest) (<-ch
 		Model:    resolvedModel,
 		BaseURL:  selection.BaseURL,
 	})
-	routedReq := cloneChatRequest(req, resolvedModel)
-	source, streamErr := selection.Provider.Stream(ctx, routedReq)
+	routedReq, err := p.negotiateRequest(selection, req, resolvedModel, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.acquireProviderQuota(ctx, selection, routedReq); err != nil {
+		return nil, err
+	}
+	callCtx, upstream := llmcore.WithUpstreamResponseRecorder(ctx)
+	source, streamErr := selection.Provider.Stream(callCtx, routedReq)
+	p.recordAPIKeyResponse(ctx, selection, upstream, streamErr)
 	if streamErr != nil {
 		p.recordAPIKeyUsage(ctx, selection, false, streamErr.Error())
 		return nil, streamErr
@@ -193,6 +222,31 @@ func (p *RoutedChatProvider) CountTokens(ctx context.Context, req *ChatRequest)
 	return counter.CountTokens(ctx, routedReq)
 }
 
+// negotiateRequest 生成发往选中 provider 的请求：配置了转换器时先把历史改写为该 provider 的方言，
+// 再在配置了能力注册表时按其能力协商，让不支持的请求在发出前被拒绝或改写。
+func (p *RoutedChatProvider) negotiateRequest(selection *ProviderSelection, req *ChatRequest, model string, stream bool) (*ChatRequest, error) {
+	routedReq := cloneChatRequest(req, model)
+	if p.transcoder != nil {
+		desc := p.capabilities.Resolve(selection.ProviderCode, model, selection.Provider)
+		transcoded, report := p.transcoder.TranscodeRequest(routedReq, llmcore.TranscodeTarget{
+			Dialect:      llmcore.DialectForProvider(selection.ProviderCode),
+			Provider:     selection.ProviderCode,
+			Capabilities: &desc,
+		})
+		if report.Changed() {
+			p.logger.Debug("transcoded conversation history for routed provider",
+				zap.String("provider", selection.ProviderCode),
+				zap.String("model", model),
+				zap.Any("report", report))
+		}
+		routedReq = transcoded
+	}
+	if p.capabilities == nil {
+		return routedReq, nil
+	}
+	return p.capabilities.Negotiate(selection.ProviderCode, model, selection.Provider, routedReq, stream)
+}
+
 func (p *RoutedChatProvider) selectProvider(ctx context.Context, req *ChatRequest) (*ProviderSelection, error) {
 	if p.router == nil {
 		return nil, types.NewServiceUnavailableError("multi-provider router is not configured")
@@ -233,6 +287,48 @@ func (p *RoutedChatProvider) recordAPIKeyUsage(ctx context.Context, selection *P
 	}
 }
 
+// acquireProviderQuota 按预估 token 占用提供商配额，配额不足时在此排队而不是让上游返回 429
+func (p *RoutedChatProvider) acquireProviderQuota(ctx context.Context, selection *ProviderSelection, req *ChatRequest) (*ProviderRateReservation, error) {
+	if p.router == nil || selection == nil {
+		return nil, nil
+	}
+	reservation, err := p.router.AcquireProviderQuota(ctx, selection.ProviderCode, estimateRequestTokens(req))
+	if err != nil {
+		p.logger.Warn("provider rate limit headroom exhausted",
+			zap.String("provider", selection.ProviderCode),
+			zap.Error(err))
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// recordAPIKeyResponse 将上游响应的状态码与限流头反馈给提供商配额与 API Key 池；
+// provider 未上报响应头时回退到错误中的 HTTP 状态码。
+func (p *RoutedChatProvider) recordAPIKeyResponse(ctx context.Context, selection *ProviderSelection, upstream *llmcore.UpstreamResponseRecorder, callErr error) {
+	if p.router == nil || selection == nil {
+		return
+	}
+	resp, ok := upstream.Load()
+	if !ok {
+		typed, isTyped := types.AsError(callErr)
+		if !isTyped || typed.HTTPStatus == 0 {
+			return
+		}
+		resp = llmcore.UpstreamResponse{StatusCode: typed.HTTPStatus}
+	}
+	p.router.ObserveProviderResponse(selection.ProviderCode, resp.StatusCode, resp.Header)
+	if selection.ProviderID == 0 || selection.APIKeyID == 0 {
+		return
+	}
+	if err := p.router.RecordAPIKeyResponse(ctx, selection.ProviderID, selection.APIKeyID, resp.StatusCode, resp.Header); err != nil {
+		p.logger.Warn("failed to record api key response",
+			zap.Uint("provider_id", selection.ProviderID),
+			zap.Uint("api_key_id", selection.APIKeyID),
+			zap.Int("status_code", resp.StatusCode),
+			zap.Error(err))
+	}
+}
+
 func extractProviderHint(req *ChatRequest) string {
 	if req == nil || len(req.Metadata) == 0 {
 		return ""
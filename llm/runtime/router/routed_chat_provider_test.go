@@ -2,10 +2,12 @@ package router
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
 	llmcore "github.com/BaSui01/agentflow/llm/core"
+	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
 
@@ -13,10 +15,16 @@ type captureProvider struct {
 	name      string
 	lastModel string
 	lastCount string
+	lastMsgs  []Message
+	headers   http.Header
 }
 
 func (p *captureProvider) Completion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	p.lastModel = req.Model
+	p.lastMsgs = req.Messages
+	if p.headers != nil {
+		llmcore.RecordUpstreamResponse(ctx, http.StatusOK, p.headers)
+	}
 	return &ChatResponse{
 		Provider: p.name,
 		Model:    req.Model,
@@ -221,3 +229,104 @@ func TestRoutedChatProvider_CountTokensUsesResolvedProvider(t *testing.T) {
 		t.Fatalf("expected remote-b count model, got %s", providers["mockB"].lastCount)
 	}
 }
+
+func TestRoutedChatProvider_FeedsRateLimitHeadersToKeyPool(t *testing.T) {
+	t.Parallel()
+
+	router, providers := setupRouterForRoutedProviderTest(t)
+	providers["mockB"].headers = http.Header{
+		"X-Ratelimit-Limit-Requests":     {"100"},
+		"X-Ratelimit-Remaining-Requests": {"42"},
+	}
+	routed := NewRoutedChatProvider(router, RoutedChatProviderOptions{Logger: zap.NewNop()})
+
+	_, err := routed.Completion(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Metadata: map[string]string{llmcore.MetadataKeyChatProvider: "mockB"},
+	})
+	if err != nil {
+		t.Fatalf("Completion error: %v", err)
+	}
+
+	var found bool
+	for _, poolStats := range router.GetAPIKeyStats() {
+		for _, stat := range poolStats {
+			if stat.Quota != nil && stat.Quota.RemainingRequests == 42 {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected key pool to record remaining quota from upstream headers")
+	}
+}
+
+func TestRoutedChatProvider_NegotiatesCapabilities(t *testing.T) {
+	t.Parallel()
+
+	router, providers := setupRouterForRoutedProviderTest(t)
+	registry := llmcore.NewCapabilityRegistry()
+	registry.Register("mockB", llmcore.CapabilityDescriptor{SupportsTools: true, Modalities: []string{llmcore.ModalityText}})
+	routed := NewRoutedChatProvider(router, RoutedChatProviderOptions{Logger: zap.NewNop(), Capabilities: registry})
+
+	_, err := routed.Completion(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Metadata: map[string]string{llmcore.MetadataKeyChatProvider: "mockB"},
+		Messages: []Message{{Role: RoleUser, Images: []types.ImageContent{{URL: "https://example.com/a.png"}}}},
+	})
+	if !types.IsErrorCode(err, llmcore.ErrCapabilityUnsupported) {
+		t.Fatalf("expected CAPABILITY_UNSUPPORTED, got %v", err)
+	}
+	if providers["mockB"].lastModel != "" {
+		t.Fatalf("expected upstream not to be called, got model %q", providers["mockB"].lastModel)
+	}
+
+	// 未注册能力的 provider 按自身声明放行
+	_, err = routed.Completion(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Metadata: map[string]string{llmcore.MetadataKeyChatProvider: "mockA"},
+		Messages: []Message{{Role: RoleUser, Images: []types.ImageContent{{URL: "https://example.com/a.png"}}}},
+	})
+	if err != nil {
+		t.Fatalf("Completion error: %v", err)
+	}
+	if providers["mockA"].lastModel != "remote-a" {
+		t.Fatalf("expected mockA to receive remote-a, got %q", providers["mockA"].lastModel)
+	}
+}
+
+func TestRoutedChatProvider_TranscodesHistoryForSelectedProvider(t *testing.T) {
+	t.Parallel()
+
+	router, providers := setupRouterForRoutedProviderTest(t)
+	routed := NewRoutedChatProvider(router, RoutedChatProviderOptions{
+		Logger:     zap.NewNop(),
+		Transcoder: llmcore.NewConversationTranscoder(),
+	})
+
+	history := []Message{
+		{Role: RoleUser, Content: "look up the weather"},
+		{Role: RoleAssistant, ToolCalls: []types.ToolCall{{ID: "toolu:01/weather", Name: "weather", Arguments: []byte(`{}`)}}},
+		{Role: RoleUser, Content: "and hurry"},
+	}
+	_, err := routed.Completion(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Metadata: map[string]string{llmcore.MetadataKeyChatProvider: "mockA"},
+		Messages: history,
+	})
+	if err != nil {
+		t.Fatalf("Completion error: %v", err)
+	}
+
+	got := providers["mockA"].lastMsgs
+	if len(got) != 4 {
+		t.Fatalf("expected synthesized tool result to be inserted, got %d messages", len(got))
+	}
+	callID := got[1].ToolCalls[0].ID
+	if callID == "toolu:01/weather" || got[2].Role != types.RoleTool || got[2].ToolCallID != callID {
+		t.Fatalf("expected normalized call id paired with tool result, got call %q and %+v", callID, got[2])
+	}
+	if history[1].ToolCalls[0].ID != "toolu:01/weather" {
+		t.Fatalf("expected caller history to be left untouched")
+	}
+}
@@ -0,0 +1,250 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSessionAffinityTTL        = 30 * time.Minute
+	defaultSessionAffinityMaxEntries = 10000
+)
+
+// SessionAffinityStore persists the channel/key a session is pinned to.
+// Implementations may be in-memory or shared (e.g. Redis) across replicas.
+type SessionAffinityStore interface {
+	Get(ctx context.Context, key string) (*ChannelSelection, bool, error)
+	Set(ctx context.Context, key string, selection *ChannelSelection, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// SessionAffinityOptions configures sticky routing for conversations and runs.
+type SessionAffinityOptions struct {
+	// Store defaults to an in-memory store.
+	Store SessionAffinityStore
+	// TTL is refreshed on every routed request; expired pins fall back to the
+	// wrapped selector. Defaults to 30 minutes.
+	TTL    time.Duration
+	Logger *zap.Logger
+}
+
+// SessionAffinitySelector pins a session to the first selected channel/key so
+// follow-up requests land on the same upstream account. This keeps
+// Responses API previous_response_id chains and provider prompt caches valid.
+//
+// The session is identified by ChannelRouteRequest.SessionID, falling back to
+// the run ID in context. A pin is overridden (and replaced) when its channel or
+// key was excluded by a failed attempt, is no longer mapped for the model, or
+// conflicts with an explicit provider hint.
+type SessionAffinitySelector struct {
+	next   ChannelSelector
+	store  SessionAffinityStore
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+var _ ChannelSelector = (*SessionAffinitySelector)(nil)
+
+// NewSessionAffinitySelector wraps next with session affinity.
+func NewSessionAffinitySelector(next ChannelSelector, opts SessionAffinityOptions) *SessionAffinitySelector {
+	store := opts.Store
+	if store == nil {
+		store = NewMemorySessionAffinityStore(0)
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = defaultSessionAffinityTTL
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SessionAffinitySelector{
+		next:   next,
+		store:  store,
+		ttl:    ttl,
+		logger: logger.With(zap.String("component", "session_affinity")),
+	}
+}
+
+func (s *SessionAffinitySelector) SelectChannel(ctx context.Context, request *ChannelRouteRequest, resolution *ModelResolution, mappings []ChannelModelMapping) (*ChannelSelection, error) {
+	key := sessionAffinityKey(ctx, request, resolution)
+	if key == "" {
+		return s.next.SelectChannel(ctx, request, resolution, mappings)
+	}
+
+	pinned, ok, err := s.store.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn("session affinity lookup failed", zap.Error(err))
+	}
+	if ok && pinnedSelectionUsable(request, pinned, mappings) {
+		if err := s.store.Set(ctx, key, pinned, s.ttl); err != nil {
+			s.logger.Warn("session affinity refresh failed", zap.Error(err))
+		}
+		return cloneChannelSelection(pinned), nil
+	}
+
+	selection, err := s.next.SelectChannel(ctx, request, resolution, mappings)
+	if err != nil || selection == nil {
+		return selection, err
+	}
+	if ok {
+		s.logger.Info("session affinity failed over",
+			zap.String("from_channel", pinned.ChannelID),
+			zap.String("from_key", pinned.KeyID),
+			zap.String("to_channel", selection.ChannelID),
+			zap.String("to_key", selection.KeyID))
+	}
+	if err := s.store.Set(ctx, key, cloneChannelSelection(selection), s.ttl); err != nil {
+		s.logger.Warn("session affinity pin failed", zap.Error(err))
+	}
+	return selection, nil
+}
+
+// Forget drops the pin for a session so the next request is routed afresh.
+func (s *SessionAffinitySelector) Forget(ctx context.Context, sessionID, model string) error {
+	return s.store.Delete(ctx, joinSessionAffinityKey(sessionID, model))
+}
+
+func sessionAffinityKey(ctx context.Context, request *ChannelRouteRequest, resolution *ModelResolution) string {
+	if request == nil {
+		return ""
+	}
+	sessionID := strings.TrimSpace(request.SessionID)
+	if sessionID == "" {
+		sessionID, _ = types.RunID(ctx)
+	}
+	model := request.RequestedModel
+	if resolution != nil {
+		model = firstNonEmpty(resolution.ResolvedModel, model)
+	}
+	return joinSessionAffinityKey(sessionID, model)
+}
+
+func joinSessionAffinityKey(sessionID, model string) string {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return ""
+	}
+	return sessionID + "|" + strings.TrimSpace(model)
+}
+
+func pinnedSelectionUsable(request *ChannelRouteRequest, pinned *ChannelSelection, mappings []ChannelModelMapping) bool {
+	if pinned == nil {
+		return false
+	}
+	for _, id := range request.ExcludedKeyIDs {
+		if pinned.KeyID != "" && id == pinned.KeyID {
+			return false
+		}
+	}
+	for _, id := range request.ExcludedChannelIDs {
+		if pinned.ChannelID != "" && id == pinned.ChannelID {
+			return false
+		}
+	}
+	if hint := strings.TrimSpace(request.ProviderHint); hint != "" && !strings.EqualFold(hint, pinned.Provider) {
+		return false
+	}
+	if pinned.ChannelID == "" || len(mappings) == 0 {
+		return true
+	}
+	for _, mapping := range mappings {
+		if mapping.ChannelID == pinned.ChannelID {
+			return true
+		}
+	}
+	return false
+}
+
+// MemorySessionAffinityStore is a process-local SessionAffinityStore with
+// lazy expiry. Once maxEntries is reached, expired pins are swept and, if
+// still full, the pin closest to expiry is evicted.
+type MemorySessionAffinityStore struct {
+	mu         sync.Mutex
+	entries    map[string]sessionAffinityEntry
+	maxEntries int
+	now        func() time.Time
+}
+
+type sessionAffinityEntry struct {
+	selection *ChannelSelection
+	expiresAt time.Time
+}
+
+// NewMemorySessionAffinityStore creates an in-memory store; maxEntries <= 0
+// defaults to 10000.
+func NewMemorySessionAffinityStore(maxEntries int) *MemorySessionAffinityStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultSessionAffinityMaxEntries
+	}
+	return &MemorySessionAffinityStore{
+		entries:    make(map[string]sessionAffinityEntry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (m *MemorySessionAffinityStore) Get(_ context.Context, key string) (*ChannelSelection, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return cloneChannelSelection(entry.selection), true, nil
+}
+
+func (m *MemorySessionAffinityStore) Set(_ context.Context, key string, selection *ChannelSelection, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if _, exists := m.entries[key]; !exists && len(m.entries) >= m.maxEntries {
+		m.evictLocked(now)
+	}
+	m.entries[key] = sessionAffinityEntry{
+		selection: cloneChannelSelection(selection),
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+func (m *MemorySessionAffinityStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Len returns the number of stored pins, including not-yet-swept expired ones.
+func (m *MemorySessionAffinityStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+func (m *MemorySessionAffinityStore) evictLocked(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(m.entries) >= m.maxEntries && oldestKey != "" {
+		delete(m.entries, oldestKey)
+	}
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func affinityMappings() []ChannelModelMapping {
+	return []ChannelModelMapping{{ChannelID: "ch-a"}, {ChannelID: "ch-b"}}
+}
+
+func TestSessionAffinitySelector_PinsSession(t *testing.T) {
+	inner := &captureChannelSelector{selections: []ChannelSelection{
+		{ChannelID: "ch-a", KeyID: "key-1", Provider: "openai"},
+		{ChannelID: "ch-b", KeyID: "key-2", Provider: "openai"},
+	}}
+	selector := NewSessionAffinitySelector(inner, SessionAffinityOptions{})
+	ctx := context.Background()
+	request := &ChannelRouteRequest{SessionID: "conv-1", RequestedModel: "gpt-4o"}
+
+	first, err := selector.SelectChannel(ctx, request, nil, affinityMappings())
+	require.NoError(t, err)
+	second, err := selector.SelectChannel(ctx, request, nil, affinityMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", first.KeyID)
+	assert.Equal(t, "key-1", second.KeyID)
+	assert.Equal(t, 1, inner.callCount)
+
+	other, err := selector.SelectChannel(ctx, &ChannelRouteRequest{SessionID: "conv-2", RequestedModel: "gpt-4o"}, nil, affinityMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", other.KeyID)
+
+	_, err = selector.SelectChannel(ctx, &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, affinityMappings())
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.callCount, "requests without a session are not pinned")
+}
+
+func TestSessionAffinitySelector_FailoverOverridesPin(t *testing.T) {
+	inner := &captureChannelSelector{selections: []ChannelSelection{
+		{ChannelID: "ch-a", KeyID: "key-1", Provider: "openai"},
+		{ChannelID: "ch-b", KeyID: "key-2", Provider: "openai"},
+	}}
+	selector := NewSessionAffinitySelector(inner, SessionAffinityOptions{})
+	ctx := types.WithRunID(context.Background(), "run-1")
+
+	_, err := selector.SelectChannel(ctx, &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, affinityMappings())
+	require.NoError(t, err)
+
+	failover, err := selector.SelectChannel(ctx, &ChannelRouteRequest{RequestedModel: "gpt-4o", ExcludedKeyIDs: []string{"key-1"}}, nil, affinityMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", failover.KeyID)
+
+	repinned, err := selector.SelectChannel(ctx, &ChannelRouteRequest{RequestedModel: "gpt-4o"}, nil, affinityMappings())
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", repinned.KeyID, "the failover target becomes the new pin")
+	assert.Equal(t, 2, inner.callCount)
+}
+
+func TestPinnedSelectionUsable(t *testing.T) {
+	pinned := &ChannelSelection{ChannelID: "ch-a", KeyID: "key-1", Provider: "openai"}
+	assert.True(t, pinnedSelectionUsable(&ChannelRouteRequest{}, pinned, affinityMappings()))
+	assert.False(t, pinnedSelectionUsable(&ChannelRouteRequest{ExcludedChannelIDs: []string{"ch-a"}}, pinned, nil))
+	assert.False(t, pinnedSelectionUsable(&ChannelRouteRequest{ProviderHint: "anthropic"}, pinned, nil))
+	assert.False(t, pinnedSelectionUsable(&ChannelRouteRequest{}, pinned, []ChannelModelMapping{{ChannelID: "ch-b"}}))
+}
+
+func TestMemorySessionAffinityStore_ExpiryAndEviction(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemorySessionAffinityStore(2)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", &ChannelSelection{KeyID: "1"}, time.Minute))
+	require.NoError(t, store.Set(ctx, "b", &ChannelSelection{KeyID: "2"}, time.Hour))
+	require.NoError(t, store.Set(ctx, "c", &ChannelSelection{KeyID: "3"}, time.Hour))
+	assert.Equal(t, 2, store.Len())
+	_, ok, _ := store.Get(ctx, "a")
+	assert.False(t, ok, "entry closest to expiry is evicted when full")
+
+	now = now.Add(2 * time.Hour)
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+}
+
+func TestBuildChannelRoutedProvider_SessionAffinity(t *testing.T) {
+	selector := &captureChannelSelector{selections: []ChannelSelection{
+		{ChannelID: "ch-a", KeyID: "key-1", Provider: "openai"},
+		{ChannelID: "ch-b", KeyID: "key-2", Provider: "openai"},
+	}}
+	factory := &captureChannelFactory{provider: &channelCaptureProvider{name: "openai"}}
+	provider, err := NewChannelRoutedProviderBuilder(ChannelRoutedProviderConfig{
+		ModelMappingResolver: &captureModelMappingResolver{mappings: affinityMappings()},
+		ChannelSelector:      selector,
+		ChatProviderFactory:  factory,
+	}).WithSessionAffinity(SessionAffinityOptions{TTL: time.Minute}).Build()
+	require.NoError(t, err)
+
+	req := &ChatRequest{Model: "gpt-4o", ConversationID: "conv-1", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	for i := 0; i < 2; i++ {
+		_, err = provider.Completion(context.Background(), req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, selector.callCount)
+	assert.Equal(t, "conv-1", selector.lastRequest.SessionID)
+}
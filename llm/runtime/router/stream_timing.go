@@ -0,0 +1,52 @@
+package router
+
+import "time"
+
+// streamAttemptTiming measures streaming responsiveness for one routed
+// attempt. The clock starts when relaying begins, after the upstream stream
+// has been opened.
+type streamAttemptTiming struct {
+	start       time.Time
+	firstToken  time.Time
+	lastToken   time.Time
+	tokenEvents int
+}
+
+func (t *streamAttemptTiming) observe(chunk StreamChunk, at time.Time) {
+	if t == nil || chunk.Err != nil || !isTokenChunk(chunk) {
+		return
+	}
+	if t.firstToken.IsZero() {
+		t.firstToken = at
+	}
+	t.lastToken = at
+	t.tokenEvents++
+}
+
+// apply copies TTFT, mean inter-token latency and tokens/sec onto record.
+// Reported completion tokens take precedence over the chunk count.
+func (t *streamAttemptTiming) apply(record *ChannelUsageRecord, usage *ChatUsage) *ChannelUsageRecord {
+	if t == nil || record == nil || t.firstToken.IsZero() {
+		return record
+	}
+	record.TimeToFirstTokenMS = t.firstToken.Sub(t.start).Milliseconds()
+	window := t.lastToken.Sub(t.firstToken)
+	if t.tokenEvents > 1 && window > 0 {
+		record.InterTokenLatencyMS = float64(window) / float64(time.Millisecond) / float64(t.tokenEvents-1)
+	}
+	tokens := t.tokenEvents
+	if usage != nil && usage.CompletionTokens > 0 {
+		tokens = usage.CompletionTokens
+	}
+	if window > 0 {
+		record.TokensPerSecond = float64(tokens) / window.Seconds()
+	}
+	return record
+}
+
+func isTokenChunk(chunk StreamChunk) bool {
+	delta := chunk.Delta
+	return delta.Content != "" ||
+		(delta.ReasoningContent != nil && *delta.ReasoningContent != "") ||
+		len(delta.ToolCalls) > 0
+}
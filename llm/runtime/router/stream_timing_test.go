@@ -0,0 +1,39 @@
+package router
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStreamAttemptTimingApply(t *testing.T) {
+	start := time.Unix(1000, 0)
+	timing := &streamAttemptTiming{start: start}
+	timing.observe(StreamChunk{}, start.Add(100*time.Millisecond))
+	timing.observe(StreamChunk{Delta: Message{Content: "he"}}, start.Add(400*time.Millisecond))
+	timing.observe(StreamChunk{Delta: Message{Content: "ll"}}, start.Add(500*time.Millisecond))
+	timing.observe(StreamChunk{Delta: Message{Content: "o"}}, start.Add(600*time.Millisecond))
+	timing.observe(StreamChunk{Err: toTypesError(errors.New("late failure"))}, start.Add(700*time.Millisecond))
+
+	record := timing.apply(&ChannelUsageRecord{}, &ChatUsage{CompletionTokens: 6})
+	if record.TimeToFirstTokenMS != 400 {
+		t.Fatalf("expected ttft 400ms, got %d", record.TimeToFirstTokenMS)
+	}
+	if math.Abs(record.InterTokenLatencyMS-100) > 1e-9 {
+		t.Fatalf("expected inter-token latency 100ms, got %v", record.InterTokenLatencyMS)
+	}
+	if math.Abs(record.TokensPerSecond-30) > 1e-9 {
+		t.Fatalf("expected 30 tokens/sec from reported usage, got %v", record.TokensPerSecond)
+	}
+}
+
+func TestStreamAttemptTimingWithoutTokensLeavesRecordEmpty(t *testing.T) {
+	timing := &streamAttemptTiming{start: time.Now()}
+	timing.observe(StreamChunk{FinishReason: "stop"}, time.Now())
+
+	record := timing.apply(&ChannelUsageRecord{Success: true}, nil)
+	if record.TimeToFirstTokenMS != 0 || record.InterTokenLatencyMS != 0 || record.TokensPerSecond != 0 {
+		t.Fatalf("expected no streaming latency fields, got %+v", record)
+	}
+}
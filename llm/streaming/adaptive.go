@@ -0,0 +1,189 @@
+package streaming
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig 配置按消费者确认速度动态调整准入速率的 AIMD 限流.
+type AdaptiveConfig struct {
+	InitialRate float64 `json:"initial_rate"` // 初始速率 (tokens/秒)
+	MinRate     float64 `json:"min_rate"`
+	MaxRate     float64 `json:"max_rate"`
+	Burst       int     `json:"burst"`
+
+	// AdditiveIncrease 是每个窗口内未拥塞时增加的速率.
+	AdditiveIncrease float64 `json:"additive_increase"`
+	// MultiplicativeDecrease 是拥塞时速率的乘数 (0-1).
+	MultiplicativeDecrease float64 `json:"multiplicative_decrease"`
+	// TargetBufferLevel 是缓冲区利用率上限 (0-1)，超过即视为拥塞.
+	TargetBufferLevel float64 `json:"target_buffer_level"`
+	// Window 是测量消费速率并调整准入速率的周期.
+	Window time.Duration `json:"window"`
+}
+
+// DefaultAdaptiveConfig 返回默认的 AIMD 参数.
+func DefaultAdaptiveConfig() AdaptiveConfig {
+	return AdaptiveConfig{
+		InitialRate:            100,
+		MinRate:                10,
+		MaxRate:                10000,
+		Burst:                  32,
+		AdditiveIncrease:       50,
+		MultiplicativeDecrease: 0.5,
+		TargetBufferLevel:      0.5,
+		Window:                 100 * time.Millisecond,
+	}
+}
+
+func (c AdaptiveConfig) withDefaults() AdaptiveConfig {
+	d := DefaultAdaptiveConfig()
+	if c.MinRate <= 0 {
+		c.MinRate = d.MinRate
+	}
+	if c.MaxRate <= 0 {
+		c.MaxRate = d.MaxRate
+	}
+	if c.MaxRate < c.MinRate {
+		c.MaxRate = c.MinRate
+	}
+	if c.InitialRate <= 0 {
+		c.InitialRate = d.InitialRate
+	}
+	c.InitialRate = math.Min(c.MaxRate, math.Max(c.MinRate, c.InitialRate))
+	if c.Burst <= 0 {
+		c.Burst = d.Burst
+	}
+	if c.AdditiveIncrease <= 0 {
+		c.AdditiveIncrease = d.AdditiveIncrease
+	}
+	if c.MultiplicativeDecrease <= 0 || c.MultiplicativeDecrease >= 1 {
+		c.MultiplicativeDecrease = d.MultiplicativeDecrease
+	}
+	if c.TargetBufferLevel <= 0 || c.TargetBufferLevel > 1 {
+		c.TargetBufferLevel = d.TargetBufferLevel
+	}
+	if c.Window <= 0 {
+		c.Window = d.Window
+	}
+	return c
+}
+
+// AdaptiveRateLimiter 在 RateLimiter 之上按 AIMD 调整准入速率:
+// 消费者跟得上时每个窗口加性增加，缓冲区超过目标水位或未确认量积压时乘性减少.
+// 生产者调用 Wait 获取准入，消费者处理完后调用 Ack.
+type AdaptiveRateLimiter struct {
+	config  AdaptiveConfig
+	limiter *RateLimiter
+	level   func() float64
+
+	mu          sync.Mutex
+	windowStart time.Time
+	admitted    int64
+	acked       int64
+	outstanding int64
+	ackRate     float64
+	increases   int64
+	decreases   int64
+	now         func() time.Time
+}
+
+// AdaptiveStats 包含自适应限流的统计数据.
+type AdaptiveStats struct {
+	Rate        float64 `json:"rate"`
+	AckRate     float64 `json:"ack_rate"`
+	Outstanding int64   `json:"outstanding"`
+	Increases   int64   `json:"increases"`
+	Decreases   int64   `json:"decreases"`
+}
+
+// NewAdaptiveRateLimiter 创建自适应限流器. bufferLevel 返回下游缓冲区利用率 (0-1)，
+// 为 nil 时以未确认 token 数超过 Burst 作为拥塞信号.
+func NewAdaptiveRateLimiter(config AdaptiveConfig, bufferLevel func() float64) *AdaptiveRateLimiter {
+	config = config.withDefaults()
+	return &AdaptiveRateLimiter{
+		config:      config,
+		limiter:     NewRateLimiter(config.InitialRate, config.Burst),
+		level:       bufferLevel,
+		windowStart: time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Wait 阻塞直到准入，并在窗口到期时调整速率.
+func (a *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	a.maybeAdjust()
+	if err := a.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.admitted++
+	a.outstanding++
+	a.mu.Unlock()
+	return nil
+}
+
+// Ack 记录消费者已处理 n 个 token.
+func (a *AdaptiveRateLimiter) Ack(n int) {
+	if n <= 0 {
+		return
+	}
+	a.mu.Lock()
+	a.acked += int64(n)
+	a.outstanding = max(0, a.outstanding-int64(n))
+	a.mu.Unlock()
+}
+
+// Rate 返回当前准入速率 (tokens/秒).
+func (a *AdaptiveRateLimiter) Rate() float64 {
+	return a.limiter.Rate()
+}
+
+// Stats 返回自适应限流统计.
+func (a *AdaptiveRateLimiter) Stats() AdaptiveStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return AdaptiveStats{
+		Rate:        a.limiter.Rate(),
+		AckRate:     a.ackRate,
+		Outstanding: a.outstanding,
+		Increases:   a.increases,
+		Decreases:   a.decreases,
+	}
+}
+
+func (a *AdaptiveRateLimiter) maybeAdjust() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	elapsed := now.Sub(a.windowStart)
+	if elapsed < a.config.Window {
+		return
+	}
+	a.ackRate = float64(a.acked) / elapsed.Seconds()
+
+	rate := a.limiter.Rate()
+	if a.congestedLocked() {
+		rate = math.Max(a.config.MinRate, rate*a.config.MultiplicativeDecrease)
+		a.decreases++
+	} else if a.admitted > 0 || a.acked > 0 {
+		// 窗口内无流量时保持速率，避免空闲期无限增长
+		rate = math.Min(a.config.MaxRate, rate+a.config.AdditiveIncrease)
+		a.increases++
+	}
+	a.limiter.SetRate(rate)
+
+	a.windowStart = now
+	a.admitted = 0
+	a.acked = 0
+}
+
+func (a *AdaptiveRateLimiter) congestedLocked() bool {
+	if a.level != nil {
+		return a.level() > a.config.TargetBufferLevel
+	}
+	return a.outstanding > int64(a.config.Burst)
+}
@@ -0,0 +1,153 @@
+package streaming
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAdaptive(level *float64) (*AdaptiveRateLimiter, *time.Time) {
+	var levelFn func() float64
+	if level != nil {
+		levelFn = func() float64 { return *level }
+	}
+	a := NewAdaptiveRateLimiter(AdaptiveConfig{
+		InitialRate:      100,
+		MinRate:          10,
+		MaxRate:          300,
+		Burst:            4,
+		AdditiveIncrease: 50,
+		Window:           time.Second,
+	}, levelFn)
+	now := time.Now()
+	a.windowStart = now
+	a.now = func() time.Time { return now }
+	return a, &now
+}
+
+func TestAdaptiveRateLimiter_AIMD(t *testing.T) {
+	level := 0.1
+	a, now := newTestAdaptive(&level)
+
+	// 消费者跟得上：加性增加直到上限
+	for i := 0; i < 5; i++ {
+		a.Ack(1)
+		*now = now.Add(time.Second)
+		a.maybeAdjust()
+	}
+	assert.Equal(t, 300.0, a.Rate())
+
+	// 缓冲区超过目标水位：乘性减少直到下限
+	level = 0.9
+	*now = now.Add(time.Second)
+	a.maybeAdjust()
+	assert.Equal(t, 150.0, a.Rate())
+	for i := 0; i < 10; i++ {
+		*now = now.Add(time.Second)
+		a.maybeAdjust()
+	}
+	assert.Equal(t, 10.0, a.Rate())
+
+	stats := a.Stats()
+	assert.Equal(t, int64(5), stats.Increases)
+	assert.Equal(t, int64(11), stats.Decreases)
+}
+
+func TestAdaptiveRateLimiter_IdleWindowKeepsRate(t *testing.T) {
+	level := 0.0
+	a, now := newTestAdaptive(&level)
+
+	*now = now.Add(time.Second)
+	a.maybeAdjust()
+	assert.Equal(t, 100.0, a.Rate())
+
+	// 窗口未到期不调整
+	a.Ack(10)
+	*now = now.Add(500 * time.Millisecond)
+	a.maybeAdjust()
+	assert.Equal(t, 100.0, a.Rate())
+}
+
+func TestAdaptiveRateLimiter_OutstandingSignalsCongestion(t *testing.T) {
+	a, now := newTestAdaptive(nil)
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, a.Wait(ctx))
+	}
+	*now = now.Add(time.Second)
+	a.maybeAdjust()
+	assert.Equal(t, 150.0, a.Rate(), "outstanding at burst is not congested")
+
+	require.NoError(t, a.Wait(ctx))
+	*now = now.Add(time.Second)
+	a.maybeAdjust()
+	assert.Equal(t, 75.0, a.Rate())
+	assert.Equal(t, int64(5), a.Stats().Outstanding)
+
+	a.Ack(5)
+	*now = now.Add(time.Second)
+	a.maybeAdjust()
+	assert.Equal(t, 125.0, a.Rate())
+	assert.Equal(t, 5.0, a.Stats().AckRate)
+}
+
+func TestBackpressureStream_AdaptiveAdmission(t *testing.T) {
+	config := DefaultBackpressureConfig()
+	config.BufferSize = 8
+	config.Adaptive = &AdaptiveConfig{
+		InitialRate:      1000,
+		MinRate:          50,
+		MaxRate:          5000,
+		Burst:            4,
+		AdditiveIncrease: 500,
+		Window:           10 * time.Millisecond,
+	}
+	stream := NewBackpressureStream(config)
+	defer stream.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 无消费者：缓冲区积压后准入速率下降
+	for i := 0; i < 6; i++ {
+		require.NoError(t, stream.Write(ctx, Token{Index: i}))
+	}
+	time.Sleep(15 * time.Millisecond)
+	require.NoError(t, stream.Write(ctx, Token{Index: 6}))
+	assert.Less(t, stream.AdmissionRate(), 1000.0)
+
+	// 快速消费者：缓冲区排空后准入速率回升
+	lowered := stream.AdmissionRate()
+	for i := 0; i < 7; i++ {
+		_, err := stream.Read(ctx)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(15 * time.Millisecond)
+		require.NoError(t, stream.Write(ctx, Token{Index: 7 + i}))
+		_, err := stream.Read(ctx)
+		require.NoError(t, err)
+	}
+	assert.Greater(t, stream.AdmissionRate(), lowered)
+	assert.Equal(t, stream.AdmissionRate(), stream.Stats().AdmissionRate)
+}
+
+func TestBackpressureStream_AdaptiveDisabledByDefault(t *testing.T) {
+	stream := NewBackpressureStream(DefaultBackpressureConfig())
+	defer stream.Close()
+	assert.Zero(t, stream.AdmissionRate())
+	stream.Ack(3)
+	assert.Zero(t, stream.Stats().Consumed)
+}
+
+func TestRateLimiter_SetRate(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+
+	limiter.SetRate(1000)
+	assert.Equal(t, 1000.0, limiter.Rate())
+	require.NoError(t, limiter.Wait(context.Background()))
+}
@@ -30,6 +30,9 @@ type BackpressureConfig struct {
 	LowWaterMark    float64       `json:"low_water_mark"`  // 0.0-1.0
 	SlowConsumerTTL time.Duration `json:"slow_consumer_ttl"`
 	DropPolicy      DropPolicy    `json:"drop_policy"`
+
+	// Adaptive 非 nil 时启用自适应准入：按消费速度以 AIMD 调整写入速率，缓冲区水位为拥塞信号.
+	Adaptive *AdaptiveConfig `json:"adaptive,omitempty"`
 }
 
 // DropPolicy 定义缓冲区满后的处理策略.
@@ -90,22 +93,39 @@ type BackpressureStream struct {
 	paused   atomic.Bool
 	pauseCh  chan struct{}
 	resumeCh chan struct{}
+
+	// 自适应准入（可选）
+	adaptive *AdaptiveRateLimiter
 }
 
 // NewBackpressureStream 创建新的支持背压的流.
 func NewBackpressureStream(config BackpressureConfig) *BackpressureStream {
-	return &BackpressureStream{
+	s := &BackpressureStream{
 		config:   config,
 		buffer:   make(chan Token, config.BufferSize),
 		done:     make(chan struct{}),
 		pauseCh:  make(chan struct{}, 1),
 		resumeCh: make(chan struct{}, 1),
 	}
+	if config.Adaptive != nil {
+		s.adaptive = NewAdaptiveRateLimiter(*config.Adaptive, s.BufferLevel)
+	}
+	return s
 }
 
 // Write 向流发送一个带背压处理的 token.
 // 使用 RLock 防止与 Close() 并发执行时向已关闭 channel 发送导致 panic。
 func (s *BackpressureStream) Write(ctx context.Context, token Token) error {
+	// 自适应准入在持锁前等待，避免阻塞 Close
+	if s.adaptive != nil {
+		if s.closed.Load() {
+			return ErrStreamClosed
+		}
+		if err := s.adaptive.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -216,11 +236,15 @@ func (s *BackpressureStream) Read(ctx context.Context) (Token, error) {
 			return Token{}, ErrStreamClosed
 		}
 		s.consumed.Add(1)
+		if s.adaptive != nil {
+			s.adaptive.Ack(1)
+		}
 		return token, nil
 	}
 }
 
 // ReadChan 返回用于读取 token 的通道.
+// 启用自适应准入时，通过通道消费的调用方应在处理后调用 Ack.
 func (s *BackpressureStream) ReadChan() <-chan Token {
 	return s.buffer
 }
@@ -238,6 +262,22 @@ func (s *BackpressureStream) Close() error {
 	return nil
 }
 
+// Ack 向自适应准入确认已处理 n 个通过 ReadChan 读取的 token；未启用时为空操作.
+func (s *BackpressureStream) Ack(n int) {
+	if s.adaptive != nil {
+		s.consumed.Add(int64(n))
+		s.adaptive.Ack(n)
+	}
+}
+
+// AdmissionRate 返回自适应准入的当前速率 (tokens/秒)，未启用时为 0.
+func (s *BackpressureStream) AdmissionRate() float64 {
+	if s.adaptive == nil {
+		return 0
+	}
+	return s.adaptive.Rate()
+}
+
 // IsPaused 返回流是否因背压而暂停.
 func (s *BackpressureStream) IsPaused() bool {
 	return s.paused.Load()
@@ -260,6 +300,8 @@ func (s *BackpressureStream) Stats() StreamStats {
 		IsPaused:   s.paused.Load(),
 		LastWrite:  time.Unix(0, s.lastWrite.Load()),
 		LastRead:   time.Unix(0, s.lastRead.Load()),
+
+		AdmissionRate: s.AdmissionRate(),
 	}
 }
 
@@ -274,6 +316,8 @@ type StreamStats struct {
 	IsPaused   bool      `json:"is_paused"`
 	LastWrite  time.Time `json:"last_write"`
 	LastRead   time.Time `json:"last_read"`
+
+	AdmissionRate float64 `json:"admission_rate,omitempty"`
 }
 
 // StreamMultiplexer 将一个流扇出给多个消费者.
@@ -401,11 +445,26 @@ func (r *RateLimiter) Wait(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(time.Duration(1000/r.tokensPerSec) * time.Millisecond):
+		case <-time.After(time.Duration(1000/r.Rate()) * time.Millisecond):
 		}
 	}
 }
 
+// Rate 返回当前速率 (tokens/秒).
+func (r *RateLimiter) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tokensPerSec
+}
+
+// SetRate 调整速率，已累积的 token 按旧速率结算.
+func (r *RateLimiter) SetRate(tokensPerSec float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refill()
+	r.tokensPerSec = tokensPerSec
+}
+
 func (r *RateLimiter) refill() {
 	now := time.Now()
 	elapsed := now.Sub(r.lastRefill).Seconds()
@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Format SIEM 导出格式
+type Format string
+
+const (
+	// FormatJSONLines 每行一个 JSON 事件
+	FormatJSONLines Format = "jsonl"
+	// FormatCEF ArcSight Common Event Format，每行一条
+	FormatCEF Format = "cef"
+)
+
+const (
+	cefVendor  = "AgentFlow"
+	cefProduct = "agentflow"
+	cefVersion = "1.0"
+)
+
+// ParseFormat 解析导出格式名称（忽略大小写，"json"/"ndjson" 视为 jsonl）
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "jsonl", "json", "ndjson":
+		return FormatJSONLines, nil
+	case "cef":
+		return FormatCEF, nil
+	default:
+		return "", fmt.Errorf("unsupported audit export format %q", value)
+	}
+}
+
+// Export 将事件按指定格式逐行写入 w
+func Export(w io.Writer, format Format, events []*Event) error {
+	bw := bufio.NewWriter(w)
+	for _, event := range events {
+		if err := writeEvent(bw, format, event); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func writeEvent(w io.Writer, format Format, event *Event) error {
+	switch format {
+	case FormatJSONLines:
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode audit event: %w", err)
+		}
+		data = append(data, '\n')
+		_, err = w.Write(data)
+		return err
+	case FormatCEF:
+		_, err := io.WriteString(w, FormatCEFLine(event)+"\n")
+		return err
+	default:
+		return fmt.Errorf("unsupported audit export format %q", format)
+	}
+}
+
+// FormatCEFLine 将事件编码为单行 CEF 记录
+func FormatCEFLine(event *Event) string {
+	var b strings.Builder
+	b.WriteString("CEF:0|")
+	b.WriteString(cefHeader(cefVendor))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(cefProduct))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(cefVersion))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(string(event.Category) + ":" + event.Action))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(event.Action + " " + event.Resource))
+	b.WriteByte('|')
+	b.WriteString(strconv.Itoa(cefSeverity(event)))
+	b.WriteByte('|')
+
+	ext := []struct{ key, value string }{
+		{"rt", strconv.FormatInt(event.Timestamp.UnixMilli(), 10)},
+		{"externalId", event.ID},
+		{"cat", string(event.Category)},
+		{"act", event.Action},
+		{"suser", event.Actor},
+		{"outcome", string(event.Outcome)},
+		{"cs1Label", "resource"},
+		{"cs1", event.Resource},
+		{"cs2Label", "resource_id"},
+		{"cs2", event.ResourceID},
+		{"cs3Label", "tenant_id"},
+		{"cs3", event.TenantID},
+		{"cs4Label", "before"},
+		{"cs4", cefJSON(event.Before)},
+		{"cs5Label", "after"},
+		{"cs5", cefJSON(event.After)},
+		{"cs6Label", "trace_id"},
+		{"cs6", event.TraceID},
+		{"msg", event.Reason},
+	}
+	first := true
+	for i, kv := range ext {
+		if kv.value == "" {
+			continue
+		}
+		// 标签只有在对应值存在时才输出
+		if strings.HasSuffix(kv.key, "Label") && (i+1 >= len(ext) || ext[i+1].value == "") {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(kv.key)
+		b.WriteByte('=')
+		b.WriteString(cefExtension(kv.value))
+	}
+	return b.String()
+}
+
+// cefSeverity 按结果映射 CEF 严重级别（0-10）
+func cefSeverity(event *Event) int {
+	switch event.Outcome {
+	case OutcomeDenied:
+		return 7
+	case OutcomeFailure:
+		return 5
+	default:
+		return 3
+	}
+}
+
+func cefJSON(value any) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func cefHeader(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func cefExtension(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`).Replace(value)
+}
+
+// WriterSink 以 JSON Lines 或 CEF 格式持续写出审计事件，可用于 SIEM 采集
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	format Format
+}
+
+// NewWriterSink 创建写入任意 io.Writer 的输出目标
+func NewWriterSink(w io.Writer, format Format) *WriterSink {
+	return &WriterSink{w: w, format: format}
+}
+
+// NewFileSink 打开（必要时创建）追加写入的审计文件
+func NewFileSink(path string, format Format) (*WriterSink, error) {
+	format, err := ParseFormat(string(format))
+	if err != nil {
+		return nil, err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("create audit directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	return &WriterSink{w: file, closer: file, format: format}, nil
+}
+
+// Write 写入一行
+func (s *WriterSink) Write(_ context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return fmt.Errorf("audit sink is closed")
+	}
+	if err := writeEvent(s.w, s.format, event); err != nil {
+		return fmt.Errorf("write audit sink: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭底层文件（如有）
+func (s *WriterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	s.w = nil
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
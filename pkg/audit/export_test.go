@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleEvent() *Event {
+	return &Event{
+		ID:         "evt-1",
+		Timestamp:  time.UnixMilli(1_767_225_600_000).UTC(),
+		Category:   CategoryConfig,
+		Actor:      "admin",
+		Action:     "config.update",
+		Resource:   "config",
+		ResourceID: "Log.Level",
+		Outcome:    OutcomeSuccess,
+		Before:     "info",
+		After:      "debug",
+		Reason:     "a=b|c\nnext",
+	}
+}
+
+func TestFormatCEFLine(t *testing.T) {
+	line := FormatCEFLine(sampleEvent())
+	assert.True(t, strings.HasPrefix(line, "CEF:0|AgentFlow|agentflow|1.0|config:config.update|config.update config|3|"))
+	assert.Contains(t, line, "rt=1767225600000")
+	assert.Contains(t, line, "suser=admin")
+	assert.Contains(t, line, `cs4Label=before cs4="info"`)
+	assert.Contains(t, line, `msg=a\=b|c\nnext`)
+	assert.NotContains(t, line, "cs3Label", "labels are omitted when the value is empty")
+	assert.NotContains(t, line, "\n")
+
+	denied := sampleEvent()
+	denied.Outcome = OutcomeDenied
+	denied.Action = "guardrail|block"
+	assert.Contains(t, FormatCEFLine(denied), `|guardrail\|block config|7|`)
+}
+
+func TestExport_JSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, FormatJSONLines, []*Event{sampleEvent(), sampleEvent()}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var decoded Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, "debug", decoded.After)
+
+	assert.Error(t, Export(&buf, Format("xml"), []*Event{sampleEvent()}))
+}
+
+func TestParseFormat(t *testing.T) {
+	for raw, want := range map[string]Format{"jsonl": FormatJSONLines, "NDJSON": FormatJSONLines, " cef ": FormatCEF} {
+		got, err := ParseFormat(raw)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFormat("syslog")
+	assert.Error(t, err)
+}
+
+func TestFileSink_AppendsAndCloses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "events.cef")
+	sink, err := NewFileSink(path, FormatCEF)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), sampleEvent()))
+	require.NoError(t, sink.Close())
+	assert.Error(t, sink.Write(context.Background(), sampleEvent()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "CEF:0|"))
+
+	_, err = NewFileSink(path, Format("xml"))
+	assert.Error(t, err)
+}
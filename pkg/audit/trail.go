@@ -0,0 +1,291 @@
+// Package audit provides the unified audit trail that aggregates admin actions,
+// guardrail violations and human-in-the-loop decisions, with JSON Lines and CEF
+// export for SIEM ingestion.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const defaultTrailMaxEntries = 10000
+
+// Category 审计事件来源分类
+type Category string
+
+const (
+	// CategoryConfig 配置变更（API 更新、文件重载、回滚）
+	CategoryConfig Category = "config"
+	// CategoryAPIKey API Key 创建、轮换、删除
+	CategoryAPIKey Category = "api_key"
+	// CategoryGuardrail 护栏拦截、脱敏、升级等非放行决策
+	CategoryGuardrail Category = "guardrail"
+	// CategoryHITL 人工审批中断的处理结果
+	CategoryHITL Category = "hitl"
+	// CategoryBudget 预算限额覆盖
+	CategoryBudget Category = "budget"
+)
+
+// Outcome 审计动作结果
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Event 统一审计事件：谁（Actor）在何时对什么（Resource）做了什么（Action），
+// 以及变更前后的值。Before/After 由调用方负责脱敏。
+type Event struct {
+	ID         string         `json:"id"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Category   Category       `json:"category"`
+	Actor      string         `json:"actor,omitempty"`
+	Action     string         `json:"action"`
+	Resource   string         `json:"resource"`
+	ResourceID string         `json:"resource_id,omitempty"`
+	Outcome    Outcome        `json:"outcome,omitempty"`
+	Before     any            `json:"before,omitempty"`
+	After      any            `json:"after,omitempty"`
+	TenantID   string         `json:"tenant_id,omitempty"`
+	TraceID    string         `json:"trace_id,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// Filter 审计查询条件，零值字段不参与过滤
+type Filter struct {
+	Categories []Category
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	Outcome    Outcome
+	TenantID   string
+	Since      time.Time
+	Until      time.Time
+}
+
+// Matches 判断事件是否满足过滤条件
+func (f Filter) Matches(event *Event) bool {
+	if event == nil {
+		return false
+	}
+	if len(f.Categories) > 0 {
+		found := false
+		for _, c := range f.Categories {
+			if c == event.Category {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Actor != "" && f.Actor != event.Actor {
+		return false
+	}
+	// Action 支持前缀匹配，例如 "config." 匹配所有配置动作
+	if f.Action != "" && event.Action != f.Action && !(strings.HasSuffix(f.Action, ".") && strings.HasPrefix(event.Action, f.Action)) {
+		return false
+	}
+	if f.Resource != "" && f.Resource != event.Resource {
+		return false
+	}
+	if f.ResourceID != "" && f.ResourceID != event.ResourceID {
+		return false
+	}
+	if f.Outcome != "" && f.Outcome != event.Outcome {
+		return false
+	}
+	if f.TenantID != "" && f.TenantID != event.TenantID {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Recorder 审计事件写入端，供各业务模块上报
+type Recorder interface {
+	Record(ctx context.Context, event Event) (*Event, error)
+}
+
+// Sink 审计事件的附加输出目标（文件、SIEM 转发等）
+type Sink interface {
+	Write(ctx context.Context, event *Event) error
+}
+
+// SinkFunc 函数适配器
+type SinkFunc func(ctx context.Context, event *Event) error
+
+// Write 实现 Sink
+func (f SinkFunc) Write(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// TrailConfig 审计轨迹配置
+type TrailConfig struct {
+	// MaxEntries 内存保留的最大事件数，超出后丢弃最旧事件
+	MaxEntries int
+	Sinks      []Sink
+	Logger     *zap.Logger
+	Now        func() time.Time
+}
+
+// Trail 汇聚管理操作、护栏拦截与人工审批决策的统一审计轨迹。
+// 事件保存在有界内存环中供查询，并同步分发到各输出目标。
+type Trail struct {
+	maxEntries int
+	logger     *zap.Logger
+	now        func() time.Time
+
+	mu     sync.RWMutex
+	events []*Event
+	sinks  []Sink
+}
+
+// NewTrail 创建审计轨迹
+func NewTrail(cfg TrailConfig) *Trail {
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultTrailMaxEntries
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &Trail{
+		maxEntries: maxEntries,
+		logger:     logger.With(zap.String("component", "audit_trail")),
+		now:        now,
+		sinks:      append([]Sink(nil), cfg.Sinks...),
+	}
+}
+
+// AddSink 追加输出目标
+func (t *Trail) AddSink(sink Sink) {
+	if sink == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sinks = append(t.sinks, sink)
+}
+
+// Record 补全 ID、时间戳与上下文身份后保存事件，并分发到所有输出目标。
+// 输出目标失败不影响事件入库，错误合并返回。
+func (t *Trail) Record(ctx context.Context, event Event) (*Event, error) {
+	event.Category = Category(strings.TrimSpace(string(event.Category)))
+	event.Action = strings.TrimSpace(event.Action)
+	event.Resource = strings.TrimSpace(event.Resource)
+	if event.Category == "" {
+		return nil, fmt.Errorf("audit event category is required")
+	}
+	if event.Action == "" {
+		return nil, fmt.Errorf("audit event action is required")
+	}
+	if event.Resource == "" {
+		return nil, fmt.Errorf("audit event resource is required")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = t.now()
+	}
+	event.Timestamp = event.Timestamp.UTC()
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+	}
+	if ctx != nil {
+		if event.Actor == "" {
+			if userID, ok := types.UserID(ctx); ok {
+				event.Actor = userID
+			}
+		}
+		if event.TenantID == "" {
+			if tenantID, ok := types.TenantID(ctx); ok {
+				event.TenantID = tenantID
+			}
+		}
+		if event.TraceID == "" {
+			if traceID, ok := types.TraceID(ctx); ok {
+				event.TraceID = traceID
+			}
+		}
+	}
+	if event.Actor == "" {
+		event.Actor = "system"
+	}
+
+	stored := &event
+	t.mu.Lock()
+	if len(t.events) >= t.maxEntries {
+		t.events = t.events[len(t.events)-t.maxEntries+1:]
+	}
+	t.events = append(t.events, stored)
+	sinks := append([]Sink(nil), t.sinks...)
+	t.mu.Unlock()
+
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, stored); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		t.logger.Warn("failed to forward audit event",
+			zap.String("category", string(stored.Category)),
+			zap.String("action", stored.Action),
+			zap.Error(err))
+		return stored, err
+	}
+	return stored, nil
+}
+
+// Query 按时间顺序（从旧到新）返回匹配的事件
+func (t *Trail) Query(_ context.Context, filter Filter) ([]*Event, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]*Event, 0)
+	for _, event := range t.events {
+		if filter.Matches(event) {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
+
+// Close 关闭实现了 io.Closer 的输出目标
+func (t *Trail) Close() error {
+	t.mu.RLock()
+	sinks := append([]Sink(nil), t.sinks...)
+	t.mu.RUnlock()
+	var errs []error
+	for _, sink := range sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
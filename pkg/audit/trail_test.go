@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrail_RecordFillsIdentityFromContext(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	trail := NewTrail(TrailConfig{Now: func() time.Time { return now }})
+
+	ctx := types.WithUserID(types.WithTenantID(types.WithTraceID(context.Background(), "trace-1"), "tenant-a"), "alice")
+	event, err := trail.Record(ctx, Event{Category: CategoryConfig, Action: "config.update", Resource: "config"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, "alice", event.Actor)
+	assert.Equal(t, "tenant-a", event.TenantID)
+	assert.Equal(t, "trace-1", event.TraceID)
+	assert.Equal(t, OutcomeSuccess, event.Outcome)
+	assert.Equal(t, now.UTC(), event.Timestamp)
+
+	event, err = trail.Record(context.Background(), Event{Category: CategoryBudget, Action: "budget.override", Resource: "budget"})
+	require.NoError(t, err)
+	assert.Equal(t, "system", event.Actor)
+
+	_, err = trail.Record(context.Background(), Event{Action: "x", Resource: "y"})
+	assert.Error(t, err)
+	_, err = trail.Record(context.Background(), Event{Category: CategoryConfig, Resource: "y"})
+	assert.Error(t, err)
+}
+
+func TestTrail_QueryFiltersAndEvictsOldest(t *testing.T) {
+	trail := NewTrail(TrailConfig{MaxEntries: 3})
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, action := range []string{"config.update", "config.reload", "api_key.create", "hitl.approve"} {
+		category := CategoryConfig
+		switch action {
+		case "api_key.create":
+			category = CategoryAPIKey
+		case "hitl.approve":
+			category = CategoryHITL
+		}
+		_, err := trail.Record(context.Background(), Event{
+			Category:  category,
+			Action:    action,
+			Resource:  "r",
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	all, err := trail.Query(context.Background(), Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "config.reload", all[0].Action)
+
+	byPrefix, err := trail.Query(context.Background(), Filter{Action: "config."})
+	require.NoError(t, err)
+	require.Len(t, byPrefix, 1)
+
+	windowed, err := trail.Query(context.Background(), Filter{Since: base.Add(2 * time.Minute), Categories: []Category{CategoryHITL, CategoryAPIKey}})
+	require.NoError(t, err)
+	require.Len(t, windowed, 2)
+}
+
+func TestTrail_SinkFailureKeepsEvent(t *testing.T) {
+	var forwarded []*Event
+	trail := NewTrail(TrailConfig{Sinks: []Sink{SinkFunc(func(_ context.Context, event *Event) error {
+		forwarded = append(forwarded, event)
+		return nil
+	})}})
+	trail.AddSink(SinkFunc(func(context.Context, *Event) error { return errors.New("siem down") }))
+
+	_, err := trail.Record(context.Background(), Event{Category: CategoryGuardrail, Action: "guardrail.block", Resource: "pii"})
+	assert.ErrorContains(t, err, "siem down")
+	assert.Len(t, forwarded, 1)
+
+	stored, queryErr := trail.Query(context.Background(), Filter{})
+	require.NoError(t, queryErr)
+	assert.Len(t, stored, 1)
+}
@@ -0,0 +1,192 @@
+package cryptoutil
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	sealedPrefix = "ENC["
+	sealedSuffix = "]"
+
+	// LocalKeyProviderID identifies the built-in AES-256-GCM key provider.
+	LocalKeyProviderID = "local"
+)
+
+var (
+	// ErrNotSealed is returned when a value does not use the ENC[...] envelope.
+	ErrNotSealed = errors.New("cryptoutil: value is not sealed")
+	// ErrUnknownKeyProvider is returned when no provider matches a sealed value.
+	ErrUnknownKeyProvider = errors.New("cryptoutil: no key provider for sealed value")
+)
+
+// KeyProvider encrypts and decrypts sealed values. Implementations may keep
+// the key locally or delegate to an external KMS.
+type KeyProvider interface {
+	// ID is embedded in sealed values so they can be routed back to the
+	// provider that produced them. It must not contain ':' or ']'.
+	ID() string
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// IsSealed reports whether value uses the ENC[<provider>:<base64>] envelope.
+func IsSealed(value string) bool {
+	_, _, err := parseSealed(value)
+	return err == nil
+}
+
+// Seal encrypts plaintext with provider and wraps it as ENC[<id>:<base64>].
+func Seal(ctx context.Context, provider KeyProvider, plaintext string) (string, error) {
+	if provider == nil {
+		return "", ErrUnknownKeyProvider
+	}
+	id := provider.ID()
+	if id == "" || strings.ContainsAny(id, ":]") {
+		return "", fmt.Errorf("cryptoutil: invalid key provider id %q", id)
+	}
+	ciphertext, err := provider.Encrypt(ctx, []byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("cryptoutil: encrypt with %s: %w", id, err)
+	}
+	return sealedPrefix + id + ":" + base64.StdEncoding.EncodeToString(ciphertext) + sealedSuffix, nil
+}
+
+// KeyRing routes sealed values to the provider named in their envelope.
+type KeyRing struct {
+	providers map[string]KeyProvider
+}
+
+// NewKeyRing creates a key ring from providers. Later providers with the same
+// ID replace earlier ones.
+func NewKeyRing(providers ...KeyProvider) *KeyRing {
+	kr := &KeyRing{providers: make(map[string]KeyProvider, len(providers))}
+	for _, p := range providers {
+		if p != nil {
+			kr.providers[p.ID()] = p
+		}
+	}
+	return kr
+}
+
+// Len returns the number of registered providers.
+func (k *KeyRing) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.providers)
+}
+
+// Open decrypts a sealed value.
+func (k *KeyRing) Open(ctx context.Context, value string) (string, error) {
+	id, ciphertext, err := parseSealed(value)
+	if err != nil {
+		return "", err
+	}
+	var provider KeyProvider
+	if k != nil {
+		provider = k.providers[id]
+	}
+	if provider == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyProvider, id)
+	}
+	plaintext, err := provider.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("cryptoutil: decrypt with %s: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+func parseSealed(value string) (string, []byte, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, sealedPrefix) || !strings.HasSuffix(value, sealedSuffix) {
+		return "", nil, ErrNotSealed
+	}
+	body := value[len(sealedPrefix) : len(value)-len(sealedSuffix)]
+	id, payload, ok := strings.Cut(body, ":")
+	if !ok || id == "" || payload == "" {
+		return "", nil, ErrNotSealed
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, ErrNotSealed
+	}
+	return id, ciphertext, nil
+}
+
+// AESGCMKeyProvider is a KeyProvider backed by a local 256-bit AES-GCM key.
+// The nonce is prepended to each ciphertext.
+type AESGCMKeyProvider struct {
+	id   string
+	aead cipher.AEAD
+}
+
+var _ KeyProvider = (*AESGCMKeyProvider)(nil)
+
+// NewAESGCMKeyProvider creates a provider from a 32-byte key. An empty id
+// defaults to LocalKeyProviderID.
+func NewAESGCMKeyProvider(id string, key []byte) (*AESGCMKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("cryptoutil: AES-256-GCM key must be 32 bytes, got %d", len(key))
+	}
+	if id == "" {
+		id = LocalKeyProviderID
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMKeyProvider{id: id, aead: aead}, nil
+}
+
+// ID returns the provider ID.
+func (p *AESGCMKeyProvider) ID() string { return p.id }
+
+// Encrypt seals plaintext with a random nonce.
+func (p *AESGCMKeyProvider) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens a ciphertext produced by Encrypt.
+func (p *AESGCMKeyProvider) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	size := p.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+	return p.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
+
+// GenerateKey returns a new random 32-byte key, base64 encoded.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// DecodeKey parses a base64 encoded 32-byte key as produced by GenerateKey.
+func DecodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("cryptoutil: decode key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("cryptoutil: key must decode to 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
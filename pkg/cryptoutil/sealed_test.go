@@ -0,0 +1,79 @@
+package cryptoutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestProvider(t *testing.T, id string) *AESGCMKeyProvider {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecodeKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewAESGCMKeyProvider(id, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	local := newTestProvider(t, "")
+	sealed, err := Seal(ctx, local, "sk-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "ENC[local:") || !IsSealed(sealed) {
+		t.Fatalf("unexpected envelope %q", sealed)
+	}
+
+	again, _ := Seal(ctx, local, "sk-secret")
+	if again == sealed {
+		t.Error("sealing the same value twice must use distinct nonces")
+	}
+
+	got, err := NewKeyRing(local).Open(ctx, sealed)
+	if err != nil || got != "sk-secret" {
+		t.Fatalf("Open = %q, %v", got, err)
+	}
+}
+
+func TestKeyRingRejectsUnknownProviderAndWrongKey(t *testing.T) {
+	ctx := context.Background()
+	sealed, err := Seal(ctx, newTestProvider(t, "kms-prod"), "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKeyRing(newTestProvider(t, "local")).Open(ctx, sealed); !errors.Is(err, ErrUnknownKeyProvider) {
+		t.Errorf("expected ErrUnknownKeyProvider, got %v", err)
+	}
+	if _, err := NewKeyRing(newTestProvider(t, "kms-prod")).Open(ctx, sealed); err == nil {
+		t.Error("expected decryption with a different key to fail")
+	}
+	if _, err := NewKeyRing().Open(ctx, "plain"); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("expected ErrNotSealed, got %v", err)
+	}
+}
+
+func TestIsSealed(t *testing.T) {
+	for value, want := range map[string]bool{
+		"ENC[local:AAAA]": true,
+		"ENC[local:]":     false,
+		"ENC[:AAAA]":      false,
+		"ENC[local:!!]":   false,
+		"sk-plain":        false,
+	} {
+		if got := IsSealed(value); got != want {
+			t.Errorf("IsSealed(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
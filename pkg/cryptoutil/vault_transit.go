@@ -0,0 +1,147 @@
+package cryptoutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+)
+
+// VaultTransitKeyProviderID identifies the HashiCorp Vault Transit key provider.
+const VaultTransitKeyProviderID = "vault"
+
+// VaultTransitConfig configures a VaultTransitKeyProvider.
+type VaultTransitConfig struct {
+	// Address is the Vault server URL, e.g. https://vault.example.com:8200.
+	Address string
+	// Token authenticates against Vault; it needs encrypt/decrypt on the key.
+	Token string
+	// KeyName is the transit key name.
+	KeyName string
+	// Mount is the transit secrets engine mount path. Defaults to "transit".
+	Mount string
+	// Namespace is sent as X-Vault-Namespace for Vault Enterprise.
+	Namespace string
+	// ID overrides the provider ID embedded in sealed values. Defaults to
+	// VaultTransitKeyProviderID.
+	ID string
+	// HTTPClient overrides the HTTP client. Defaults to a TLS-hardened client
+	// with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// VaultTransitKeyProvider is a KeyProvider backed by the Vault Transit
+// secrets engine: the key never leaves Vault, and sealed values carry the
+// versioned Vault ciphertext (vault:v<N>:...), so key rotation in Vault keeps
+// older values readable.
+type VaultTransitKeyProvider struct {
+	cfg    VaultTransitConfig
+	client *http.Client
+}
+
+var _ KeyProvider = (*VaultTransitKeyProvider)(nil)
+
+// NewVaultTransitKeyProvider validates cfg and creates the provider.
+func NewVaultTransitKeyProvider(cfg VaultTransitConfig) (*VaultTransitKeyProvider, error) {
+	cfg.Address = strings.TrimRight(strings.TrimSpace(cfg.Address), "/")
+	cfg.KeyName = strings.TrimSpace(cfg.KeyName)
+	cfg.Mount = strings.Trim(strings.TrimSpace(cfg.Mount), "/")
+	if cfg.Address == "" || cfg.KeyName == "" {
+		return nil, errors.New("cryptoutil: vault transit requires address and key name")
+	}
+	if parsed, err := url.Parse(cfg.Address); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("cryptoutil: invalid vault address %q", cfg.Address)
+	}
+	if strings.TrimSpace(cfg.Token) == "" {
+		return nil, errors.New("cryptoutil: vault transit requires a token")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+	if cfg.ID == "" {
+		cfg.ID = VaultTransitKeyProviderID
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = tlsutil.SecureHTTPClient(10 * time.Second)
+	}
+	return &VaultTransitKeyProvider{cfg: cfg, client: client}, nil
+}
+
+// ID returns the provider ID.
+func (p *VaultTransitKeyProvider) ID() string { return p.cfg.ID }
+
+// Encrypt asks Vault to encrypt plaintext and returns the Vault ciphertext.
+func (p *VaultTransitKeyProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var out struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	if err := p.call(ctx, "encrypt", body, &out); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(out.Ciphertext, "vault:") {
+		return nil, errors.New("vault returned no ciphertext")
+	}
+	return []byte(out.Ciphertext), nil
+}
+
+// Decrypt asks Vault to decrypt a ciphertext produced by Encrypt.
+func (p *VaultTransitKeyProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := p.call(ctx, "decrypt", map[string]string{"ciphertext": string(ciphertext)}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
+
+func (p *VaultTransitKeyProvider) call(ctx context.Context, op string, body map[string]string, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/v1/%s/%s/%s", p.cfg.Address, p.cfg.Mount, op, url.PathEscape(p.cfg.KeyName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &vaultErr)
+		return fmt.Errorf("vault %s failed with status %d: %s", op, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("decode vault %s response: %w", op, err)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,81 @@
+package cryptoutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newFakeVaultTransit 模拟 Vault Transit 的 encrypt/decrypt 接口。
+func newFakeVaultTransit(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/agentflow":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}})
+		case "/v1/transit/decrypt/agentflow":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultTransitKeyProvider_SealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeVaultTransit(t, "s.token")
+	vault, err := NewVaultTransitKeyProvider(VaultTransitConfig{Address: server.URL, Token: "s.token", KeyName: "agentflow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := Seal(ctx, vault, "sk-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "ENC[vault:") {
+		t.Fatalf("unexpected envelope %q", sealed)
+	}
+	got, err := NewKeyRing(vault).Open(ctx, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "sk-secret" {
+		t.Errorf("got %q", got)
+	}
+
+	denied, err := NewVaultTransitKeyProvider(VaultTransitConfig{Address: server.URL, Token: "wrong", KeyName: "agentflow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyRing(denied).Open(ctx, sealed); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected vault error, got %v", err)
+	}
+}
+
+func TestNewVaultTransitKeyProvider_Validates(t *testing.T) {
+	cases := []VaultTransitConfig{
+		{Token: "t", KeyName: "k"},
+		{Address: "vault:8200", Token: "t", KeyName: "k"},
+		{Address: "https://vault:8200", KeyName: "k"},
+		{Address: "https://vault:8200", Token: "t"},
+	}
+	for _, cfg := range cases {
+		if _, err := NewVaultTransitKeyProvider(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
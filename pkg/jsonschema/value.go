@@ -0,0 +1,419 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonNumberPattern matches the JSON number grammar; strconv.ParseFloat alone
+// would also accept hex floats, "Inf" and "NaN".
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Coercion records one automatic type conversion applied by ValidateValue.
+type Coercion struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ValueOptions controls ValidateValue.
+type ValueOptions struct {
+	// Coerce converts common mismatches (stringified numbers/booleans,
+	// scalars where a string is expected, JSON encoded as a string) instead
+	// of reporting them as violations.
+	Coerce bool
+}
+
+// ValueResult is the outcome of ValidateValue.
+type ValueResult struct {
+	// Value is the validated value, rewritten when coercions were applied.
+	Value     json.RawMessage
+	Coercions []Coercion
+	Errors    []ValidationError
+}
+
+// Valid reports whether the value satisfied the schema (after coercion).
+func (r ValueResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// valueSchema is the subset of JSON Schema understood by ValidateValue.
+type valueSchema struct {
+	Type                 json.RawMessage         `json:"type,omitempty"`
+	Properties           map[string]*valueSchema `json:"properties,omitempty"`
+	Required             []string                `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage         `json:"additionalProperties,omitempty"`
+	Items                *valueSchema            `json:"items,omitempty"`
+	Enum                 []json.RawMessage       `json:"enum,omitempty"`
+	Pattern              string                  `json:"pattern,omitempty"`
+	MinLength            *int                    `json:"minLength,omitempty"`
+	MaxLength            *int                    `json:"maxLength,omitempty"`
+	Minimum              *float64                `json:"minimum,omitempty"`
+	Maximum              *float64                `json:"maximum,omitempty"`
+	MinItems             *int                    `json:"minItems,omitempty"`
+	MaxItems             *int                    `json:"maxItems,omitempty"`
+}
+
+func (s *valueSchema) types() []string {
+	if s == nil || len(s.Type) == 0 {
+		return nil
+	}
+	var single string
+	if json.Unmarshal(s.Type, &single) == nil {
+		if single == "" {
+			return nil
+		}
+		return []string{single}
+	}
+	var many []string
+	if json.Unmarshal(s.Type, &many) == nil {
+		return many
+	}
+	return nil
+}
+
+// ValidateValue validates an arbitrary JSON value against a JSON Schema,
+// descending into nested properties and array items. Unlike ValidateArgs it
+// is meant for tool outputs and other values whose top level need not be an
+// object. Unsupported keywords are ignored; an unparsable schema accepts
+// any value.
+func ValidateValue(value json.RawMessage, schema json.RawMessage, opts ValueOptions) ValueResult {
+	out := ValueResult{Value: value}
+	if len(bytes.TrimSpace(schema)) == 0 {
+		return out
+	}
+	var root valueSchema
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return out
+	}
+
+	v := &valueWalker{coerce: opts.Coerce}
+	var decoded any
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil || dec.More() {
+		// 纯文本输出：期望字符串时包装为 JSON 字符串
+		if !opts.Coerce || !allowsType(root.types(), "string") {
+			out.Errors = []ValidationError{{Message: "value is not valid JSON"}}
+			return out
+		}
+		decoded = string(value)
+		v.coercions = append(v.coercions, Coercion{From: "text", To: "string"})
+	}
+
+	decoded = v.walk("", decoded, &root)
+	out.Coercions = v.coercions
+	out.Errors = v.errs
+	if len(v.coercions) > 0 && len(v.errs) == 0 {
+		if encoded, err := encodeValue(decoded); err == nil {
+			out.Value = encoded
+		}
+	}
+	return out
+}
+
+type valueWalker struct {
+	coerce    bool
+	coercions []Coercion
+	errs      []ValidationError
+}
+
+func (v *valueWalker) fail(field, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *valueWalker) walk(field string, val any, s *valueSchema) any {
+	if s == nil {
+		return val
+	}
+	if allowed := s.types(); len(allowed) > 0 && !matchesAnyType(val, allowed) {
+		converted, to, ok := v.tryCoerce(val, allowed)
+		if !ok {
+			v.fail(field, "expected %s, got %s", strings.Join(allowed, " or "), jsonTypeOf(val))
+			return val
+		}
+		v.coercions = append(v.coercions, Coercion{Field: field, From: jsonTypeOf(val), To: to})
+		val = converted
+	}
+
+	if len(s.Enum) > 0 && !inEnum(val, s.Enum) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = string(e)
+		}
+		v.fail(field, "value not in enum [%s]", strings.Join(allowed, ", "))
+	}
+
+	switch typed := val.(type) {
+	case map[string]any:
+		v.walkObject(field, typed, s)
+	case []any:
+		v.walkArray(field, typed, s)
+	case string:
+		v.checkString(field, typed, s)
+	case json.Number:
+		v.checkNumber(field, typed, s)
+	}
+	return val
+}
+
+func (v *valueWalker) walkObject(field string, obj map[string]any, s *valueSchema) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			v.fail(joinField(field, name), "required field missing")
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var extra *valueSchema
+	forbidExtra := false
+	if len(s.AdditionalProperties) > 0 {
+		var flag bool
+		if json.Unmarshal(s.AdditionalProperties, &flag) == nil {
+			forbidExtra = !flag
+		} else {
+			extra = &valueSchema{}
+			if json.Unmarshal(s.AdditionalProperties, extra) != nil {
+				extra = nil
+			}
+		}
+	}
+	for _, k := range keys {
+		child := joinField(field, k)
+		prop, declared := s.Properties[k]
+		switch {
+		case declared:
+			obj[k] = v.walk(child, obj[k], prop)
+		case forbidExtra:
+			v.fail(child, "additional property not allowed")
+		case extra != nil:
+			obj[k] = v.walk(child, obj[k], extra)
+		}
+	}
+}
+
+func (v *valueWalker) walkArray(field string, arr []any, s *valueSchema) {
+	if s.MinItems != nil && len(arr) < *s.MinItems {
+		v.fail(field, "array length %d is less than minItems %d", len(arr), *s.MinItems)
+	}
+	if s.MaxItems != nil && len(arr) > *s.MaxItems {
+		v.fail(field, "array length %d exceeds maxItems %d", len(arr), *s.MaxItems)
+	}
+	if s.Items == nil {
+		return
+	}
+	for i := range arr {
+		arr[i] = v.walk(fmt.Sprintf("%s[%d]", field, i), arr[i], s.Items)
+	}
+}
+
+func (v *valueWalker) checkString(field, str string, s *valueSchema) {
+	if s.Pattern != "" {
+		if err := checkPattern(field, str, s.Pattern); err != nil {
+			v.errs = append(v.errs, *err)
+		}
+	}
+	n := len([]rune(str))
+	if s.MinLength != nil && n < *s.MinLength {
+		v.fail(field, "string length %d is less than minLength %d", n, *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		v.fail(field, "string length %d exceeds maxLength %d", n, *s.MaxLength)
+	}
+}
+
+func (v *valueWalker) checkNumber(field string, num json.Number, s *valueSchema) {
+	f, err := num.Float64()
+	if err != nil {
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		v.fail(field, "value %g is less than minimum %g", f, *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		v.fail(field, "value %g exceeds maximum %g", f, *s.Maximum)
+	}
+}
+
+// tryCoerce converts val to the first allowed type it can represent.
+func (v *valueWalker) tryCoerce(val any, allowed []string) (any, string, bool) {
+	if !v.coerce {
+		return nil, "", false
+	}
+	for _, t := range allowed {
+		if converted, ok := coerceTo(val, t); ok {
+			return converted, t, true
+		}
+	}
+	return nil, "", false
+}
+
+func coerceTo(val any, target string) (any, bool) {
+	switch target {
+	case "number", "integer":
+		s, ok := val.(string)
+		if !ok {
+			return nil, false
+		}
+		s = strings.TrimSpace(s)
+		if !jsonNumberPattern.MatchString(s) {
+			return nil, false
+		}
+		num := json.Number(s)
+		if target == "integer" {
+			f, err := num.Float64()
+			if err != nil || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+				return nil, false
+			}
+			num = json.Number(strconv.FormatInt(int64(f), 10))
+		}
+		return num, true
+	case "boolean":
+		s, ok := val.(string)
+		if !ok {
+			return nil, false
+		}
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	case "string":
+		switch typed := val.(type) {
+		case json.Number:
+			return typed.String(), true
+		case bool:
+			return strconv.FormatBool(typed), true
+		}
+	case "object", "array":
+		// 双重序列化：对象/数组被编码成了 JSON 字符串
+		s, ok := val.(string)
+		if !ok {
+			return nil, false
+		}
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.UseNumber()
+		var inner any
+		if dec.Decode(&inner) != nil || dec.More() {
+			return nil, false
+		}
+		if matchesType(inner, target) {
+			return inner, true
+		}
+	}
+	return nil, false
+}
+
+func allowsType(allowed []string, t string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == t {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyType(val any, allowed []string) bool {
+	for _, t := range allowed {
+		if matchesType(val, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(val any, t string) bool {
+	switch t {
+	case "string":
+		_, ok := val.(string)
+		return ok
+	case "number":
+		_, ok := val.(json.Number)
+		return ok
+	case "integer":
+		num, ok := val.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := num.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "boolean":
+		_, ok := val.(bool)
+		return ok
+	case "object":
+		_, ok := val.(map[string]any)
+		return ok
+	case "array":
+		_, ok := val.([]any)
+		return ok
+	case "null":
+		return val == nil
+	}
+	// 未知类型关键字不做约束
+	return true
+}
+
+func jsonTypeOf(val any) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func inEnum(val any, enum []json.RawMessage) bool {
+	encoded, err := encodeValue(val)
+	if err != nil {
+		return false
+	}
+	for _, e := range enum {
+		var buf bytes.Buffer
+		if json.Compact(&buf, e) == nil && bytes.Equal(buf.Bytes(), encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// encodeValue marshals without HTML escaping so coerced values keep their
+// original text.
+func encodeValue(val any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,86 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"properties": {
+		"id":     {"type": "integer"},
+		"total":  {"type": "number", "minimum": 0},
+		"paid":   {"type": "boolean"},
+		"status": {"type": "string", "enum": ["open", "closed"]},
+		"items":  {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}, "qty": {"type": "integer"}}, "required": ["sku"]}}
+	},
+	"required": ["id", "status"]
+}`
+
+func TestValidateValue_CoercesStringifiedScalars(t *testing.T) {
+	value := json.RawMessage(`{"id":"42","total":" 19.5","paid":"TRUE","status":"open","items":[{"sku":1001,"qty":"3"}],"note":"a<b"}`)
+
+	res := ValidateValue(value, json.RawMessage(orderSchema), ValueOptions{Coerce: true})
+	if !res.Valid() {
+		t.Fatalf("expected valid after coercion, got %v", res.Errors)
+	}
+	if len(res.Coercions) != 5 {
+		t.Fatalf("expected 5 coercions, got %v", res.Coercions)
+	}
+	want := `{"id":42,"items":[{"qty":3,"sku":"1001"}],"note":"a<b","paid":true,"status":"open","total":19.5}`
+	if string(res.Value) != want {
+		t.Fatalf("unexpected coerced value:\n got %s\nwant %s", res.Value, want)
+	}
+}
+
+func TestValidateValue_ReportsNestedViolations(t *testing.T) {
+	value := json.RawMessage(`{"id":"4.5","total":-1,"status":"lost","items":[{"qty":1}]}`)
+
+	res := ValidateValue(value, json.RawMessage(orderSchema), ValueOptions{Coerce: true})
+	fields := map[string]bool{}
+	for _, e := range res.Errors {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"id", "total", "status", "items[0].sku"} {
+		if !fields[f] {
+			t.Errorf("expected violation for %q, got %v", f, res.Errors)
+		}
+	}
+	if string(res.Value) != string(value) {
+		t.Fatalf("invalid value must be returned unchanged, got %s", res.Value)
+	}
+}
+
+func TestValidateValue_WithoutCoercion(t *testing.T) {
+	res := ValidateValue(json.RawMessage(`{"id":"42","status":"open"}`), json.RawMessage(orderSchema), ValueOptions{})
+	if len(res.Errors) != 1 || res.Errors[0].Field != "id" {
+		t.Fatalf("expected id type violation, got %v", res.Errors)
+	}
+}
+
+func TestValidateValue_NonObjectRoots(t *testing.T) {
+	res := ValidateValue(json.RawMessage(`plain text answer`), json.RawMessage(`{"type":"string"}`), ValueOptions{Coerce: true})
+	if !res.Valid() || string(res.Value) != `"plain text answer"` {
+		t.Fatalf("expected text wrapped as string, got %s %v", res.Value, res.Errors)
+	}
+
+	res = ValidateValue(json.RawMessage(`"[1,2,\"3\"]"`), json.RawMessage(`{"type":"array","items":{"type":"integer"},"maxItems":3}`), ValueOptions{Coerce: true})
+	if !res.Valid() || string(res.Value) != `[1,2,3]` {
+		t.Fatalf("expected double-encoded array decoded, got %s %v", res.Value, res.Errors)
+	}
+
+	res = ValidateValue(json.RawMessage(`not json`), json.RawMessage(`{"type":"object"}`), ValueOptions{Coerce: true})
+	if res.Valid() {
+		t.Fatal("expected invalid JSON to be rejected for object schema")
+	}
+
+	res = ValidateValue(json.RawMessage(`{"a":1,"b":2}`), json.RawMessage(`{"type":"object","properties":{"a":{"type":"integer"}},"additionalProperties":false}`), ValueOptions{})
+	if len(res.Errors) != 1 || res.Errors[0].Field != "b" {
+		t.Fatalf("expected additional property violation, got %v", res.Errors)
+	}
+
+	res = ValidateValue(json.RawMessage(`null`), json.RawMessage(`{"type":["string","null"]}`), ValueOptions{})
+	if !res.Valid() {
+		t.Fatalf("expected null allowed by type union, got %v", res.Errors)
+	}
+}
@@ -23,6 +23,7 @@ const (
 	labelToolName  = "tool_name"
 	labelDatabase  = "database"
 	labelOperation = "operation"
+	labelAction    = "action"
 )
 
 // =============================================================================
@@ -42,6 +43,7 @@ type Collector struct {
 	llmRequestDuration *prometheus.HistogramVec
 	llmTokensUsed      *prometheus.CounterVec
 	llmCost            *prometheus.CounterVec
+	llmRetrySuppressed *prometheus.CounterVec
 
 	// Agent 指标
 	// K3 FIX: agent_id 改为 agent_type，避免动态 ID 导致时间序列基数爆炸
@@ -152,6 +154,15 @@ func NewCollector(namespace string, logger *zap.Logger) *Collector {
 		[]string{labelProvider, labelModel},
 	)
 
+	c.llmRetrySuppressed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_retry_suppressed_budget_total",
+			Help:      "Total number of LLM retries skipped or downgraded due to low remaining budget",
+		},
+		[]string{labelModel, labelAction}, // action: skipped, downgraded
+	)
+
 	// Agent 指标
 	// K3 FIX: 使用 agent_type（有限枚举）替代 agent_id（动态 UUID），防止时间序列基数爆炸
 	c.agentExecutionsTotal = promauto.NewCounterVec(
@@ -308,6 +319,11 @@ func (c *Collector) RecordLLMRequest(provider, model, status string, duration ti
 	c.llmCost.WithLabelValues(provider, model).Add(cost)
 }
 
+// RecordRetrySuppressedBudget 记录因预算不足被跳过或降级的 LLM 重试
+func (c *Collector) RecordRetrySuppressedBudget(model, action string) {
+	c.llmRetrySuppressed.WithLabelValues(model, action).Inc()
+}
+
 // =============================================================================
 // 🎭 Agent 指标记录
 // =============================================================================
@@ -44,6 +44,7 @@ func TestCollector_LabelSchema_IsFrozen(t *testing.T) {
 	assert.Equal(t, "tool_name", labelToolName)
 	assert.Equal(t, "database", labelDatabase)
 	assert.Equal(t, "operation", labelOperation)
+	assert.Equal(t, "action", labelAction)
 }
 
 func TestCollector_RecordHTTPRequest(t *testing.T) {
@@ -91,6 +92,17 @@ func TestCollector_RecordLLMRequest(t *testing.T) {
 	assert.Greater(t, costCount, 0)
 }
 
+func TestCollector_RecordRetrySuppressedBudget(t *testing.T) {
+	collector := NewCollector(nextTestNamespace(), zap.NewNop())
+
+	collector.RecordRetrySuppressedBudget("gpt-4", "skipped")
+	collector.RecordRetrySuppressedBudget("gpt-4", "skipped")
+	collector.RecordRetrySuppressedBudget("gpt-4", "downgraded")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.llmRetrySuppressed.WithLabelValues("gpt-4", "skipped")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.llmRetrySuppressed.WithLabelValues("gpt-4", "downgraded")))
+}
+
 func TestCollector_RecordAgentExecution(t *testing.T) {
 	logger := zap.NewNop()
 	collector := NewCollector(nextTestNamespace(), logger)
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/BaSui01/agentflow/pkg/cryptoutil"
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// staticAPIKeyID 静态配置密钥在上下文中的标识
+const staticAPIKeyID = "static"
+
+// APIKeyPrincipal 经 X-API-Key 认证的调用方
+type APIKeyPrincipal struct {
+	KeyID        string
+	TenantID     string
+	Scopes       []string
+	RateLimitRPM int
+}
+
+// APIKeyResolver 解析托管 API Key，由密钥服务实现
+type APIKeyResolver interface {
+	// ResolveAPIKey 返回 key 对应的调用方；无效、吊销或过期的 key 返回 ErrAuthentication 错误
+	ResolveAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+	// ConsumeAPIKeyBudget 为一次请求计入预算；预算耗尽返回 ErrQuotaExceeded 错误
+	ConsumeAPIKeyBudget(ctx context.Context, principal *APIKeyPrincipal) error
+}
+
+// ScopeRule 将请求映射到所需 scope。Method 为空时匹配任意方法，PathPrefix 按前缀匹配
+type ScopeRule struct {
+	Method     string
+	PathPrefix string
+	Scope      string
+}
+
+// TenantAPIKeyConfig 租户 API Key 认证配置
+type TenantAPIKeyConfig struct {
+	// Resolver 解析数据库中的托管密钥；为 nil 时仅接受静态密钥
+	Resolver APIKeyResolver
+	// StaticKeys 为配置文件中的密钥，视为不绑定租户的 admin 引导密钥
+	StaticKeys []string
+	// Rules 按顺序匹配，第一条命中的规则决定所需 scope
+	Rules []ScopeRule
+	// DefaultScope 为未命中任何规则的请求所需 scope，为空时使用 admin
+	DefaultScope string
+	SkipPaths    []string
+}
+
+type apiKeyIDKey struct{}
+
+// APIKeyIDFromContext 返回认证所用密钥的 ID；非 API Key 认证的请求返回空字符串
+func APIKeyIDFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(apiKeyIDKey{}).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// TenantAPIKeyAuth 按 X-API-Key 解析租户与 scope，并执行 scope 校验、每密钥限流与预算。
+// 认证成功后将 tenant_id、scopes 与密钥 ID 注入请求上下文。
+func TenantAPIKeyAuth(cfg TenantAPIKeyConfig, logger *zap.Logger) Middleware {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	skipSet := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skipSet[p] = struct{}{}
+	}
+	defaultScope := cfg.DefaultScope
+	if defaultScope == "" {
+		defaultScope = tenantkey.ScopeAdmin
+	}
+	limiters := &apiKeyLimiters{limiters: make(map[string]*apiKeyLimiter)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, skip := skipSet[r.URL.Path]; skip {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := strings.TrimSpace(r.Header.Get("X-API-Key"))
+			if key == "" {
+				writeMiddlewareError(w, http.StatusUnauthorized, string(types.ErrAuthentication), "invalid or missing API key")
+				return
+			}
+
+			principal := matchStaticAPIKey(cfg.StaticKeys, key)
+			if principal == nil {
+				if cfg.Resolver == nil {
+					logger.Debug("API key auth failed", zap.String("path", r.URL.Path))
+					writeMiddlewareError(w, http.StatusUnauthorized, string(types.ErrAuthentication), "invalid or missing API key")
+					return
+				}
+				resolved, err := cfg.Resolver.ResolveAPIKey(r.Context(), key)
+				if err != nil {
+					writeAPIKeyError(w, logger, r, err)
+					return
+				}
+				principal = resolved
+			}
+
+			required := requiredScope(cfg.Rules, defaultScope, r)
+			if !tenantkey.HasScope(principal.Scopes, required) {
+				logger.Debug("API key scope denied",
+					zap.String("key_id", principal.KeyID),
+					zap.String("path", r.URL.Path),
+					zap.String("required_scope", required))
+				writeMiddlewareError(w, http.StatusForbidden, string(types.ErrForbidden), fmt.Sprintf("API key lacks required scope %q", required))
+				return
+			}
+			if principal.RateLimitRPM > 0 && !limiters.allow(principal.KeyID, principal.RateLimitRPM) {
+				writeMiddlewareError(w, http.StatusTooManyRequests, string(types.ErrRateLimit), "API key rate limit exceeded")
+				return
+			}
+			if principal.KeyID != staticAPIKeyID && cfg.Resolver != nil {
+				if err := cfg.Resolver.ConsumeAPIKeyBudget(r.Context(), principal); err != nil {
+					writeAPIKeyError(w, logger, r, err)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyIDKey{}, principal.KeyID)
+			if principal.TenantID != "" {
+				ctx = types.WithTenantID(ctx, principal.TenantID)
+			}
+			ctx = types.WithScopes(ctx, principal.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func matchStaticAPIKey(staticKeys []string, key string) *APIKeyPrincipal {
+	for _, candidate := range staticKeys {
+		if candidate != "" && cryptoutil.SecureTokenEqual(key, candidate) {
+			return &APIKeyPrincipal{KeyID: staticAPIKeyID, Scopes: []string{tenantkey.ScopeAdmin}}
+		}
+	}
+	return nil
+}
+
+func requiredScope(rules []ScopeRule, defaultScope string, r *http.Request) string {
+	for _, rule := range rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+			continue
+		}
+		if strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+			return rule.Scope
+		}
+	}
+	return defaultScope
+}
+
+// writeAPIKeyError 将密钥解析错误映射为 HTTP 响应；非业务错误视为存储不可用
+func writeAPIKeyError(w http.ResponseWriter, logger *zap.Logger, r *http.Request, err error) {
+	var typed *types.Error
+	if errors.As(err, &typed) {
+		switch typed.Code {
+		case types.ErrAuthentication, types.ErrUnauthorized:
+			logger.Debug("API key auth failed", zap.String("path", r.URL.Path))
+			writeMiddlewareError(w, http.StatusUnauthorized, string(typed.Code), typed.Message)
+			return
+		case types.ErrForbidden:
+			writeMiddlewareError(w, http.StatusForbidden, string(typed.Code), typed.Message)
+			return
+		case types.ErrQuotaExceeded, types.ErrRateLimit:
+			writeMiddlewareError(w, http.StatusTooManyRequests, string(typed.Code), typed.Message)
+			return
+		}
+	}
+	logger.Error("API key resolution failed", zap.String("path", r.URL.Path), zap.Error(err))
+	writeMiddlewareError(w, http.StatusServiceUnavailable, string(types.ErrServiceUnavailable), "API key store unavailable")
+}
+
+type apiKeyLimiter struct {
+	limiter *rate.Limiter
+	rpm     int
+}
+
+// apiKeyLimiters 每个密钥一个令牌桶，容量为一分钟的配额
+type apiKeyLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*apiKeyLimiter
+}
+
+func (l *apiKeyLimiters) allow(keyID string, rpm int) bool {
+	l.mu.Lock()
+	entry, ok := l.limiters[keyID]
+	if !ok || entry.rpm != rpm {
+		entry = &apiKeyLimiter{limiter: rate.NewLimiter(rate.Limit(float64(rpm)/60), rpm), rpm: rpm}
+		l.limiters[keyID] = entry
+	}
+	l.mu.Unlock()
+	return entry.limiter.Allow()
+}
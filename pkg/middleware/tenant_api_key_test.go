@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BaSui01/agentflow/pkg/tenantkey"
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeAPIKeyResolver struct {
+	principals map[string]*APIKeyPrincipal
+	budgetErr  error
+	consumed   int
+	resolveErr error
+}
+
+func (f *fakeAPIKeyResolver) ResolveAPIKey(_ context.Context, key string) (*APIKeyPrincipal, error) {
+	if f.resolveErr != nil {
+		return nil, f.resolveErr
+	}
+	principal, ok := f.principals[key]
+	if !ok {
+		return nil, types.NewError(types.ErrAuthentication, "invalid or missing API key")
+	}
+	return principal, nil
+}
+
+func (f *fakeAPIKeyResolver) ConsumeAPIKeyBudget(context.Context, *APIKeyPrincipal) error {
+	f.consumed++
+	return f.budgetErr
+}
+
+func newTenantKeyTestHandler(resolver APIKeyResolver, captured *context.Context) http.Handler {
+	return TenantAPIKeyAuth(TenantAPIKeyConfig{
+		Resolver:   resolver,
+		StaticKeys: []string{"static-admin"},
+		Rules: []ScopeRule{
+			{Method: http.MethodGet, PathPrefix: "/v1/models", Scope: tenantkey.ScopeChatRead},
+			{PathPrefix: "/v1/chat", Scope: tenantkey.ScopeChatWrite},
+		},
+		SkipPaths: []string{"/health"},
+	}, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if captured != nil {
+			*captured = r.Context()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serveWithKey(h http.Handler, method, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp middlewareErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	return resp.Error.Code
+}
+
+func TestTenantAPIKeyAuth_InjectsTenantAndScopes(t *testing.T) {
+	resolver := &fakeAPIKeyResolver{principals: map[string]*APIKeyPrincipal{
+		"afk_chat": {KeyID: "7", TenantID: "tenant-a", Scopes: []string{tenantkey.ScopeChatWrite}},
+	}}
+	var ctx context.Context
+	h := newTenantKeyTestHandler(resolver, &ctx)
+
+	rec := serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_chat")
+	require.Equal(t, http.StatusOK, rec.Code)
+	tenantID, _ := types.TenantID(ctx)
+	assert.Equal(t, "tenant-a", tenantID)
+	scopes, _ := types.Scopes(ctx)
+	assert.Equal(t, []string{tenantkey.ScopeChatWrite}, scopes)
+	assert.Equal(t, "7", APIKeyIDFromContext(ctx))
+	assert.Equal(t, 1, resolver.consumed)
+
+	rec = serveWithKey(h, http.MethodGet, "/v1/models", "afk_chat")
+	assert.Equal(t, http.StatusOK, rec.Code, "chat:write implies chat:read")
+}
+
+func TestTenantAPIKeyAuth_RejectsMissingScope(t *testing.T) {
+	resolver := &fakeAPIKeyResolver{principals: map[string]*APIKeyPrincipal{
+		"afk_read": {KeyID: "1", TenantID: "tenant-a", Scopes: []string{tenantkey.ScopeChatRead}},
+	}}
+	h := newTenantKeyTestHandler(resolver, nil)
+
+	rec := serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_read")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, string(types.ErrForbidden), errorCode(t, rec))
+
+	rec = serveWithKey(h, http.MethodGet, "/api/v1/config", "afk_read")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "unlisted routes require admin")
+	assert.Equal(t, 0, resolver.consumed, "denied requests do not consume budget")
+}
+
+func TestTenantAPIKeyAuth_StaticKeysAreAdmin(t *testing.T) {
+	resolver := &fakeAPIKeyResolver{}
+	var ctx context.Context
+	h := newTenantKeyTestHandler(resolver, &ctx)
+
+	rec := serveWithKey(h, http.MethodGet, "/api/v1/config", "static-admin")
+	require.Equal(t, http.StatusOK, rec.Code)
+	_, hasTenant := types.TenantID(ctx)
+	assert.False(t, hasTenant)
+	assert.Equal(t, 0, resolver.consumed)
+
+	onlyStatic := newTenantKeyTestHandler(nil, nil)
+	assert.Equal(t, http.StatusOK, serveWithKey(onlyStatic, http.MethodGet, "/api/v1/config", "static-admin").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(onlyStatic, http.MethodGet, "/api/v1/config", "afk_other").Code)
+}
+
+func TestTenantAPIKeyAuth_Failures(t *testing.T) {
+	resolver := &fakeAPIKeyResolver{principals: map[string]*APIKeyPrincipal{
+		"afk_chat": {KeyID: "1", Scopes: []string{tenantkey.ScopeChatWrite}},
+	}}
+	h := newTenantKeyTestHandler(resolver, nil)
+
+	assert.Equal(t, http.StatusOK, serveWithKey(h, http.MethodGet, "/health", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(h, http.MethodPost, "/v1/chat/completions", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_unknown").Code)
+
+	resolver.budgetErr = types.NewError(types.ErrQuotaExceeded, "API key monthly request budget exhausted")
+	rec := serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_chat")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, string(types.ErrQuotaExceeded), errorCode(t, rec))
+
+	resolver.resolveErr = errors.New("database is down")
+	rec = serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_chat")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestTenantAPIKeyAuth_PerKeyRateLimit(t *testing.T) {
+	resolver := &fakeAPIKeyResolver{principals: map[string]*APIKeyPrincipal{
+		"afk_slow": {KeyID: "1", Scopes: []string{tenantkey.ScopeChatWrite}, RateLimitRPM: 2},
+		"afk_fast": {KeyID: "2", Scopes: []string{tenantkey.ScopeChatWrite}},
+	}}
+	h := newTenantKeyTestHandler(resolver, nil)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_slow").Code)
+	}
+	rec := serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_slow")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, string(types.ErrRateLimit), errorCode(t, rec))
+	assert.Equal(t, http.StatusOK, serveWithKey(h, http.MethodPost, "/v1/chat/completions", "afk_fast").Code, "limits are per key")
+}
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: HTTP-backed Tool Registrations
+-- Database: MySQL
+-- Version: 000004
+-- Description: Drop registration kind and HTTP call template columns
+-- =============================================================================
+
+ALTER TABLE sc_tool_registrations DROP COLUMN http_config;
+ALTER TABLE sc_tool_registrations DROP COLUMN kind;
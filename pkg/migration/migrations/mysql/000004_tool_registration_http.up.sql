@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration: HTTP-backed Tool Registrations
+-- Database: MySQL
+-- Version: 000004
+-- Description: Add registration kind and HTTP call template for runtime tools
+-- =============================================================================
+
+ALTER TABLE sc_tool_registrations ADD COLUMN kind VARCHAR(16) NOT NULL DEFAULT 'alias';
+ALTER TABLE sc_tool_registrations ADD COLUMN http_config JSON;
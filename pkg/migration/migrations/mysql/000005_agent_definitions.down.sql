@@ -0,0 +1,8 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Agent Definitions
+-- Database: MySQL
+-- Version: 000005
+-- Description: Drop versioned declarative agent definition table
+-- =============================================================================
+
+DROP TABLE IF EXISTS sc_agent_definitions;
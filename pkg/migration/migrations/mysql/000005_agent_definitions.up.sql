@@ -0,0 +1,22 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Agent Definitions
+-- Database: MySQL
+-- Version: 000005
+-- Description: Create versioned declarative agent definition table
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_agent_definitions (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    agent_id VARCHAR(120) NOT NULL,
+    version INT NOT NULL,
+    format VARCHAR(8) NOT NULL,
+    source MEDIUMTEXT NOT NULL,
+    definition JSON,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_agent_definition_version (agent_id, version),
+    INDEX idx_sc_agent_definitions_active (active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Versioned declarative agent definitions managed via API';
@@ -0,0 +1,8 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Tenant API Keys
+-- Database: MySQL
+-- Version: 000006
+-- Description: Drop tenant API key table
+-- =============================================================================
+
+DROP TABLE IF EXISTS sc_tenant_api_keys;
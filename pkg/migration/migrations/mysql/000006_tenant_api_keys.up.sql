@@ -0,0 +1,29 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Tenant API Keys
+-- Database: MySQL
+-- Version: 000006
+-- Description: Create hashed tenant API key table with scopes, rate limits and budgets
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_tenant_api_keys (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id VARCHAR(120) NOT NULL,
+    name VARCHAR(120),
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(1024),
+    rate_limit_rpm INT DEFAULT 0,
+    monthly_request_budget BIGINT DEFAULT 0,
+    period_requests BIGINT DEFAULT 0,
+    usage_period VARCHAR(7),
+    rotated_from_id INT UNSIGNED,
+    expires_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_sc_tenant_api_keys_key_hash (key_hash),
+    INDEX idx_sc_tenant_api_keys_tenant_id (tenant_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Tenant API keys authenticating X-API-Key requests';
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Usage Ledger
+-- Database: MySQL
+-- Version: 000007
+-- Description: Drop usage ledger tables
+-- =============================================================================
+
+DROP TABLE IF EXISTS sc_usage_daily;
+DROP TABLE IF EXISTS sc_usage_records;
@@ -0,0 +1,48 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Usage Ledger
+-- Database: MySQL
+-- Version: 000007
+-- Description: Create per-request usage records and per-day usage rollups for billing reports
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_usage_records (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    timestamp TIMESTAMP(6) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    tenant_id VARCHAR(120),
+    provider VARCHAR(100),
+    model VARCHAR(200),
+    capability VARCHAR(50),
+    trace_id VARCHAR(100),
+    prompt_tokens BIGINT DEFAULT 0,
+    completion_tokens BIGINT DEFAULT 0,
+    total_tokens BIGINT DEFAULT 0,
+    cached_tokens BIGINT DEFAULT 0,
+    cost_usd DOUBLE DEFAULT 0,
+    cache_savings_usd DOUBLE DEFAULT 0,
+
+    INDEX idx_sc_usage_records_timestamp (timestamp),
+    INDEX idx_sc_usage_records_day (day),
+    INDEX idx_sc_usage_records_tenant_id (tenant_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Per-request LLM usage written by the gateway ledger';
+
+CREATE TABLE IF NOT EXISTS sc_usage_daily (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    day VARCHAR(10) NOT NULL,
+    tenant_id VARCHAR(120) NOT NULL DEFAULT '',
+    provider VARCHAR(100) NOT NULL DEFAULT '',
+    model VARCHAR(200) NOT NULL DEFAULT '',
+    requests BIGINT DEFAULT 0,
+    cache_hits BIGINT DEFAULT 0,
+    prompt_tokens BIGINT DEFAULT 0,
+    completion_tokens BIGINT DEFAULT 0,
+    total_tokens BIGINT DEFAULT 0,
+    cached_tokens BIGINT DEFAULT 0,
+    cost_usd DOUBLE DEFAULT 0,
+    cache_savings_usd DOUBLE DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_sc_usage_daily_key (day, tenant_id, provider, model)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Daily usage rollup per tenant, provider and model';
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Agent Definitions
+-- Database: PostgreSQL
+-- Version: 000005
+-- Description: Drop versioned declarative agent definition table
+-- =============================================================================
+
+DROP TRIGGER IF EXISTS update_sc_agent_definitions_updated_at ON sc_agent_definitions;
+DROP TABLE IF EXISTS sc_agent_definitions CASCADE;
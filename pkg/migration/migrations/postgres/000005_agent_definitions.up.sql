@@ -0,0 +1,29 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Agent Definitions
+-- Database: PostgreSQL
+-- Version: 000005
+-- Description: Create versioned declarative agent definition table
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_agent_definitions (
+    id SERIAL PRIMARY KEY,
+    agent_id VARCHAR(120) NOT NULL,
+    version INTEGER NOT NULL,
+    format VARCHAR(8) NOT NULL,
+    source TEXT NOT NULL,
+    definition JSONB,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_agent_definition_version ON sc_agent_definitions(agent_id, version);
+CREATE INDEX IF NOT EXISTS idx_sc_agent_definitions_active ON sc_agent_definitions(active);
+
+COMMENT ON TABLE sc_agent_definitions IS 'Versioned declarative agent definitions managed via API';
+COMMENT ON COLUMN sc_agent_definitions.source IS 'Original YAML/JSON definition body';
+COMMENT ON COLUMN sc_agent_definitions.active IS 'Whether this version is the deployed one';
+
+CREATE TRIGGER update_sc_agent_definitions_updated_at
+    BEFORE UPDATE ON sc_agent_definitions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
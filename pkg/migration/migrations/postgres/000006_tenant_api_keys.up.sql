@@ -0,0 +1,36 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Tenant API Keys
+-- Database: PostgreSQL
+-- Version: 000006
+-- Description: Create hashed tenant API key table with scopes, rate limits and budgets
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_tenant_api_keys (
+    id SERIAL PRIMARY KEY,
+    tenant_id VARCHAR(120) NOT NULL,
+    name VARCHAR(120),
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(1024),
+    rate_limit_rpm INTEGER DEFAULT 0,
+    monthly_request_budget BIGINT DEFAULT 0,
+    period_requests BIGINT DEFAULT 0,
+    usage_period VARCHAR(7),
+    rotated_from_id INTEGER,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sc_tenant_api_keys_key_hash ON sc_tenant_api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_sc_tenant_api_keys_tenant_id ON sc_tenant_api_keys(tenant_id);
+
+COMMENT ON TABLE sc_tenant_api_keys IS 'Tenant API keys authenticating X-API-Key requests';
+COMMENT ON COLUMN sc_tenant_api_keys.key_hash IS 'SHA-256 hex digest of the key; plaintext is never stored';
+COMMENT ON COLUMN sc_tenant_api_keys.scopes IS 'Space-separated granted scopes';
+
+CREATE TRIGGER update_sc_tenant_api_keys_updated_at
+    BEFORE UPDATE ON sc_tenant_api_keys
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Usage Ledger
+-- Database: PostgreSQL
+-- Version: 000007
+-- Description: Drop usage ledger tables
+-- =============================================================================
+
+DROP TRIGGER IF EXISTS update_sc_usage_daily_updated_at ON sc_usage_daily;
+DROP TABLE IF EXISTS sc_usage_daily CASCADE;
+DROP TABLE IF EXISTS sc_usage_records CASCADE;
@@ -0,0 +1,56 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Usage Ledger
+-- Database: PostgreSQL
+-- Version: 000007
+-- Description: Create per-request usage records and per-day usage rollups for billing reports
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_usage_records (
+    id BIGSERIAL PRIMARY KEY,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    tenant_id VARCHAR(120),
+    provider VARCHAR(100),
+    model VARCHAR(200),
+    capability VARCHAR(50),
+    trace_id VARCHAR(100),
+    prompt_tokens BIGINT DEFAULT 0,
+    completion_tokens BIGINT DEFAULT 0,
+    total_tokens BIGINT DEFAULT 0,
+    cached_tokens BIGINT DEFAULT 0,
+    cost_usd DOUBLE PRECISION DEFAULT 0,
+    cache_savings_usd DOUBLE PRECISION DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_timestamp ON sc_usage_records(timestamp);
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_day ON sc_usage_records(day);
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_tenant_id ON sc_usage_records(tenant_id);
+
+COMMENT ON TABLE sc_usage_records IS 'Per-request LLM usage written by the gateway ledger; folded into sc_usage_daily by the rollup job';
+
+CREATE TABLE IF NOT EXISTS sc_usage_daily (
+    id BIGSERIAL PRIMARY KEY,
+    day VARCHAR(10) NOT NULL,
+    tenant_id VARCHAR(120) NOT NULL DEFAULT '',
+    provider VARCHAR(100) NOT NULL DEFAULT '',
+    model VARCHAR(200) NOT NULL DEFAULT '',
+    requests BIGINT DEFAULT 0,
+    cache_hits BIGINT DEFAULT 0,
+    prompt_tokens BIGINT DEFAULT 0,
+    completion_tokens BIGINT DEFAULT 0,
+    total_tokens BIGINT DEFAULT 0,
+    cached_tokens BIGINT DEFAULT 0,
+    cost_usd DOUBLE PRECISION DEFAULT 0,
+    cache_savings_usd DOUBLE PRECISION DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sc_usage_daily_key ON sc_usage_daily(day, tenant_id, provider, model);
+
+COMMENT ON TABLE sc_usage_daily IS 'Daily usage rollup per tenant, provider and model';
+COMMENT ON COLUMN sc_usage_daily.day IS 'UTC day in YYYY-MM-DD format';
+COMMENT ON COLUMN sc_usage_daily.cache_savings_usd IS 'Cost avoided by prompt cache hits relative to the uncached input price';
+
+CREATE TRIGGER update_sc_usage_daily_updated_at
+    BEFORE UPDATE ON sc_usage_daily
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
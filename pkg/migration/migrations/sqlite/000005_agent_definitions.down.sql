@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Agent Definitions
+-- Database: SQLite
+-- Version: 000005
+-- Description: Drop versioned declarative agent definition table
+-- =============================================================================
+
+DROP TRIGGER IF EXISTS update_sc_agent_definitions_updated_at;
+DROP TABLE IF EXISTS sc_agent_definitions;
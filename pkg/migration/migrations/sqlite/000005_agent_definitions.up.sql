@@ -0,0 +1,28 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Agent Definitions
+-- Database: SQLite
+-- Version: 000005
+-- Description: Create versioned declarative agent definition table
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_agent_definitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    agent_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    format TEXT NOT NULL,
+    source TEXT NOT NULL,
+    definition TEXT,
+    active INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_agent_definition_version ON sc_agent_definitions(agent_id, version);
+CREATE INDEX IF NOT EXISTS idx_sc_agent_definitions_active ON sc_agent_definitions(active);
+
+CREATE TRIGGER IF NOT EXISTS update_sc_agent_definitions_updated_at
+    AFTER UPDATE ON sc_agent_definitions
+    FOR EACH ROW
+BEGIN
+    UPDATE sc_agent_definitions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- AgentFlow Database Migration Rollback: Tenant API Keys
+-- Database: SQLite
+-- Version: 000006
+-- Description: Drop tenant API key table
+-- =============================================================================
+
+DROP TRIGGER IF EXISTS update_sc_tenant_api_keys_updated_at;
+DROP TABLE IF EXISTS sc_tenant_api_keys;
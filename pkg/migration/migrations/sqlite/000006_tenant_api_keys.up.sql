@@ -0,0 +1,35 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Tenant API Keys
+-- Database: SQLite
+-- Version: 000006
+-- Description: Create hashed tenant API key table with scopes, rate limits and budgets
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_tenant_api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id TEXT NOT NULL,
+    name TEXT,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL,
+    scopes TEXT,
+    rate_limit_rpm INTEGER DEFAULT 0,
+    monthly_request_budget INTEGER DEFAULT 0,
+    period_requests INTEGER DEFAULT 0,
+    usage_period TEXT,
+    rotated_from_id INTEGER,
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sc_tenant_api_keys_key_hash ON sc_tenant_api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_sc_tenant_api_keys_tenant_id ON sc_tenant_api_keys(tenant_id);
+
+CREATE TRIGGER IF NOT EXISTS update_sc_tenant_api_keys_updated_at
+    AFTER UPDATE ON sc_tenant_api_keys
+    FOR EACH ROW
+BEGIN
+    UPDATE sc_tenant_api_keys SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
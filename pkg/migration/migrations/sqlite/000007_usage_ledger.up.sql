@@ -0,0 +1,46 @@
+-- =============================================================================
+-- AgentFlow Database Migration: Usage Ledger
+-- Database: SQLite
+-- Version: 000007
+-- Description: Create per-request usage records and per-day usage rollups for billing reports
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS sc_usage_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME NOT NULL,
+    day TEXT NOT NULL,
+    tenant_id TEXT,
+    provider TEXT,
+    model TEXT,
+    capability TEXT,
+    trace_id TEXT,
+    prompt_tokens INTEGER DEFAULT 0,
+    completion_tokens INTEGER DEFAULT 0,
+    total_tokens INTEGER DEFAULT 0,
+    cached_tokens INTEGER DEFAULT 0,
+    cost_usd REAL DEFAULT 0,
+    cache_savings_usd REAL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_timestamp ON sc_usage_records(timestamp);
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_day ON sc_usage_records(day);
+CREATE INDEX IF NOT EXISTS idx_sc_usage_records_tenant_id ON sc_usage_records(tenant_id);
+
+CREATE TABLE IF NOT EXISTS sc_usage_daily (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    day TEXT NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    requests INTEGER DEFAULT 0,
+    cache_hits INTEGER DEFAULT 0,
+    prompt_tokens INTEGER DEFAULT 0,
+    completion_tokens INTEGER DEFAULT 0,
+    total_tokens INTEGER DEFAULT 0,
+    cached_tokens INTEGER DEFAULT 0,
+    cost_usd REAL DEFAULT 0,
+    cache_savings_usd REAL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sc_usage_daily_key ON sc_usage_daily(day, tenant_id, provider, model);
@@ -0,0 +1,148 @@
+// Package tenantkey 提供租户级 API Key：带 scope、限流与预算的密钥记录，
+// 数据库只保存密钥的 SHA-256 摘要，明文仅在签发时返回一次。
+package tenantkey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 内置 scope。资源级 "<resource>:admin" 隐含该资源下的全部 scope，
+// ":write" 隐含同资源的 ":read"，ScopeAdmin 与 ScopeAll 隐含一切。
+const (
+	ScopeAll              = "*"
+	ScopeAdmin            = "admin"
+	ScopeChatRead         = "chat:read"
+	ScopeChatWrite        = "chat:write"
+	ScopeAgentsExecute    = "agents:execute"
+	ScopeAgentsAdmin      = "agents:admin"
+	ScopeWorkflowsExecute = "workflows:execute"
+	ScopeRAGRead          = "rag:read"
+	ScopeRAGWrite         = "rag:write"
+	ScopeToolsAdmin       = "tools:admin"
+	ScopeKeysAdmin        = "keys:admin"
+	ScopeUsageRead        = "usage:read"
+)
+
+// KnownScopes 列出可分配给密钥的 scope
+var KnownScopes = []string{
+	ScopeAll, ScopeAdmin,
+	ScopeChatRead, ScopeChatWrite,
+	ScopeAgentsExecute, ScopeAgentsAdmin,
+	ScopeWorkflowsExecute,
+	ScopeRAGRead, ScopeRAGWrite,
+	ScopeToolsAdmin, ScopeKeysAdmin,
+	ScopeUsageRead,
+}
+
+// secretPrefix 标识 AgentFlow 签发的密钥，便于密钥扫描工具识别
+const secretPrefix = "afk_"
+
+// displayPrefixLen 为列表展示保留的明文前缀长度
+const displayPrefixLen = 12
+
+// Key 租户 API Key 记录
+type Key struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID string `gorm:"size:120;not null;index" json:"tenant_id"`
+	Name     string `gorm:"size:120" json:"name"`
+	// Prefix 为明文前若干字符，仅用于辨认，不可用于认证
+	Prefix  string `gorm:"size:16;not null" json:"prefix"`
+	KeyHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// Scopes 以空格分隔存储
+	Scopes       string `gorm:"size:1024" json:"-"`
+	RateLimitRPM int    `gorm:"default:0" json:"rate_limit_rpm"`
+	// MonthlyRequestBudget 为每自然月（UTC）允许的请求数，0 表示不限
+	MonthlyRequestBudget int64 `gorm:"default:0" json:"monthly_request_budget"`
+	PeriodRequests       int64 `gorm:"default:0" json:"period_requests"`
+	// UsagePeriod 为 PeriodRequests 所属月份，格式 2006-01
+	UsagePeriod   string     `gorm:"size:7" json:"usage_period,omitempty"`
+	RotatedFromID *uint      `json:"rotated_from_id,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Key) TableName() string {
+	return "sc_tenant_api_keys"
+}
+
+// ScopeList 返回密钥的 scope 列表
+func (k Key) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Active 判断密钥在 now 时刻是否可用（未吊销且未过期）
+func (k Key) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Generate 生成新密钥，返回明文、展示前缀与摘要
+func Generate() (secret, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret = secretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return secret, secret[:displayPrefixLen], Hash(secret), nil
+}
+
+// Hash 返回密钥明文的 SHA-256 十六进制摘要。密钥为高熵随机串，
+// 无需慢哈希；摘要唯一索引支持按密钥直接查找。
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeScopes 去重、排序并校验 scope，未知 scope 返回错误
+func NormalizeScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	for _, raw := range scopes {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(KnownScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", raw)
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// HasScope 判断 granted 是否满足 required
+func HasScope(granted []string, required string) bool {
+	if required == "" {
+		return true
+	}
+	resource, action, _ := strings.Cut(required, ":")
+	for _, scope := range granted {
+		switch scope {
+		case required, ScopeAll, ScopeAdmin, resource + ":admin":
+			return true
+		}
+		if action == "read" && scope == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+// UsagePeriodOf 返回 t 所属的预算周期
+func UsagePeriodOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
@@ -0,0 +1,124 @@
+package tenantkey
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) *GormStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Key{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return NewGormStore(db)
+}
+
+func TestGenerate(t *testing.T) {
+	secret, prefix, hash, err := Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "afk_"))
+	assert.True(t, strings.HasPrefix(secret, prefix))
+	assert.Len(t, prefix, displayPrefixLen)
+	assert.Equal(t, Hash(secret), hash)
+	assert.NotContains(t, hash, secret)
+
+	other, _, _, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, HasScope([]string{ScopeChatWrite}, ScopeChatWrite))
+	assert.True(t, HasScope([]string{ScopeChatWrite}, ScopeChatRead), "write implies read")
+	assert.False(t, HasScope([]string{ScopeChatRead}, ScopeChatWrite))
+	assert.True(t, HasScope([]string{ScopeAgentsAdmin}, ScopeAgentsExecute), "resource admin implies its scopes")
+	assert.False(t, HasScope([]string{ScopeAgentsAdmin}, ScopeChatWrite))
+	assert.True(t, HasScope([]string{ScopeAdmin}, ScopeKeysAdmin))
+	assert.True(t, HasScope([]string{ScopeAll}, ScopeAdmin))
+	assert.False(t, HasScope([]string{ScopeKeysAdmin}, ScopeAdmin))
+	assert.False(t, HasScope(nil, ScopeChatRead))
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := NormalizeScopes([]string{" Chat:Write", "agents:execute", "chat:write", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeAgentsExecute, ScopeChatWrite}, scopes)
+
+	_, err = NormalizeScopes([]string{"chat:delete"})
+	assert.ErrorContains(t, err, "unknown scope")
+}
+
+func TestKeyActive(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	assert.True(t, Key{}.Active(now))
+	assert.True(t, Key{ExpiresAt: &future}.Active(now))
+	assert.False(t, Key{ExpiresAt: &past}.Active(now))
+	assert.False(t, Key{RevokedAt: &past}.Active(now))
+}
+
+func TestGormStore_LookupByHash(t *testing.T) {
+	store := newTestStore(t)
+	secret, prefix, hash, err := Generate()
+	require.NoError(t, err)
+	key := &Key{TenantID: "tenant-a", Prefix: prefix, KeyHash: hash, Scopes: "chat:write rag:read"}
+	require.NoError(t, store.Create(key))
+
+	found, err := store.GetByHash(Hash(secret))
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, []string{ScopeChatWrite, ScopeRAGRead}, found.ScopeList())
+
+	_, err = store.GetByHash(Hash("afk_unknown"))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = store.Get(key.ID + 1)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGormStore_ConsumeRequestEnforcesMonthlyBudget(t *testing.T) {
+	store := newTestStore(t)
+	key := &Key{TenantID: "tenant-a", Prefix: "afk_x", KeyHash: Hash("afk_x"), MonthlyRequestBudget: 2}
+	require.NoError(t, store.Create(key))
+
+	october := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		ok, err := store.ConsumeRequest(key.ID, october)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err := store.ConsumeRequest(key.ID, october)
+	require.NoError(t, err)
+	assert.False(t, ok, "budget exhausted for the month")
+
+	november := october.AddDate(0, 1, 0)
+	ok, err = store.ConsumeRequest(key.ID, november)
+	require.NoError(t, err)
+	assert.True(t, ok, "budget resets in a new period")
+
+	stored, err := store.Get(key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.PeriodRequests)
+	assert.Equal(t, "2026-11", stored.UsagePeriod)
+	require.NotNil(t, stored.LastUsedAt)
+}
+
+func TestGormStore_ConsumeRequestUnlimited(t *testing.T) {
+	store := newTestStore(t)
+	key := &Key{TenantID: "tenant-a", Prefix: "afk_y", KeyHash: Hash("afk_y")}
+	require.NoError(t, store.Create(key))
+	for i := 0; i < 5; i++ {
+		ok, err := store.ConsumeRequest(key.ID, time.Now())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+}
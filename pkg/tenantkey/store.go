@@ -0,0 +1,94 @@
+package tenantkey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrKeyNotFound 未找到匹配的密钥
+var ErrKeyNotFound = errors.New("tenant api key not found")
+
+// Store 租户 API Key 的持久化接口
+type Store interface {
+	// List 列出密钥；tenantID 为空时列出全部租户
+	List(tenantID string) ([]Key, error)
+	Get(id uint) (Key, error)
+	GetByHash(hash string) (Key, error)
+	Create(key *Key) error
+	Update(key *Key, updates map[string]any) error
+	// ConsumeRequest 原子地为密钥计一次请求；预算耗尽时返回 false
+	ConsumeRequest(id uint, at time.Time) (bool, error)
+	WithTransaction(ctx context.Context, fn func(Store) error) error
+}
+
+// GormStore 基于 gorm 的 Store 实现
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore 创建基于 gorm 的密钥存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) List(tenantID string) ([]Key, error) {
+	var rows []Key
+	query := s.db.Order("tenant_id ASC, id ASC").Limit(1000)
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	err := query.Find(&rows).Error
+	return rows, err
+}
+
+func (s *GormStore) Get(id uint) (Key, error) {
+	var row Key
+	err := s.db.First(&row, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, ErrKeyNotFound
+	}
+	return row, err
+}
+
+func (s *GormStore) GetByHash(hash string) (Key, error) {
+	var row Key
+	err := s.db.Where("key_hash = ?", hash).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, ErrKeyNotFound
+	}
+	return row, err
+}
+
+func (s *GormStore) Create(key *Key) error {
+	return s.db.Create(key).Error
+}
+
+func (s *GormStore) Update(key *Key, updates map[string]any) error {
+	return s.db.Model(key).Updates(updates).Error
+}
+
+func (s *GormStore) ConsumeRequest(id uint, at time.Time) (bool, error) {
+	period := UsagePeriodOf(at)
+	// gorm 按列名排序生成 SET 子句，MySQL 又按顺序求值：period_requests
+	// 必须排在 usage_period 之前，CASE 才能读到旧周期。
+	result := s.db.Model(&Key{}).
+		Where("id = ? AND (monthly_request_budget = 0 OR usage_period IS NULL OR usage_period <> ? OR period_requests < monthly_request_budget)", id, period).
+		UpdateColumns(map[string]any{
+			"last_used_at":    at,
+			"period_requests": gorm.Expr("CASE WHEN usage_period = ? THEN period_requests + 1 ELSE 1 END", period),
+			"usage_period":    period,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (s *GormStore) WithTransaction(ctx context.Context, fn func(Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormStore(tx))
+	})
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 时间相关的文档元数据键。
+const (
+	// MetadataEffectiveAt 文档生效时间，早于该时间的查询看不到此文档
+	MetadataEffectiveAt = "effective_at"
+	// MetadataExpiresAt 文档失效时间，自该时间起文档不再有效
+	MetadataExpiresAt = "expires_at"
+	// MetadataPublishedAt 文档发布时间，用于时效性加权；缺省时使用生效时间
+	MetadataPublishedAt = "published_at"
+)
+
+// ParseDocumentTime 解析元数据中的时间值。
+// 支持 time.Time、RFC 3339 字符串、YYYY-MM-DD 日期以及 Unix 秒（数字或数字字符串）。
+func ParseDocumentTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return time.Time{}, false
+		}
+		return *v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return time.Time{}, false
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+		if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC(), true
+		}
+	case int:
+		return time.Unix(int64(v), 0).UTC(), true
+	case int64:
+		return time.Unix(v, 0).UTC(), true
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func (d Document) metadataTime(key string) (time.Time, bool) {
+	if d.Metadata == nil {
+		return time.Time{}, false
+	}
+	return ParseDocumentTime(d.Metadata[key])
+}
+
+// Validity 返回文档的生效与失效时间，未声明的一端为零值。
+func (d Document) Validity() (effective, expires time.Time) {
+	effective, _ = d.metadataTime(MetadataEffectiveAt)
+	expires, _ = d.metadataTime(MetadataExpiresAt)
+	return effective, expires
+}
+
+// ValidAt 判断文档在 t 时是否有效，有效期为 [effective_at, expires_at)。
+// 未声明有效期的文档始终有效。
+func (d Document) ValidAt(t time.Time) bool {
+	effective, expires := d.Validity()
+	if !effective.IsZero() && t.Before(effective) {
+		return false
+	}
+	if !expires.IsZero() && !t.Before(expires) {
+		return false
+	}
+	return true
+}
+
+// Timestamp 返回用于时效性加权的文档时间：优先发布时间，其次生效时间。
+func (d Document) Timestamp() (time.Time, bool) {
+	if t, ok := d.metadataTime(MetadataPublishedAt); ok {
+		return t, true
+	}
+	return d.metadataTime(MetadataEffectiveAt)
+}
+
+// SetValidity 写入文档有效期，零值表示不限制该端。
+func (d *Document) SetValidity(effective, expires time.Time) {
+	if d.Metadata == nil {
+		d.Metadata = make(map[string]any, 2)
+	}
+	if !effective.IsZero() {
+		d.Metadata[MetadataEffectiveAt] = effective.UTC().Format(time.RFC3339)
+	}
+	if !expires.IsZero() {
+		d.Metadata[MetadataExpiresAt] = expires.UTC().Format(time.RFC3339)
+	}
+}
+
+type asOfKey struct{}
+
+// WithAsOf 为检索指定“截至某时”的视角：只返回在该时间有效的文档，时效性也以该时间计算。
+func WithAsOf(ctx context.Context, asOf time.Time) context.Context {
+	if asOf.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, asOfKey{}, asOf)
+}
+
+// AsOfFromContext 读取检索的截至时间。
+func AsOfFromContext(ctx context.Context) (time.Time, bool) {
+	if ctx == nil {
+		return time.Time{}, false
+	}
+	asOf, ok := ctx.Value(asOfKey{}).(time.Time)
+	return asOf, ok
+}
+
+// ReferenceTime 返回检索的参考时间：指定了截至时间时使用它，否则使用 now。
+func ReferenceTime(ctx context.Context, now time.Time) time.Time {
+	if asOf, ok := AsOfFromContext(ctx); ok {
+		return asOf
+	}
+	return now
+}
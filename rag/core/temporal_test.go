@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseDocumentTime(t *testing.T) {
+	want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for name, value := range map[string]any{
+		"time":    want,
+		"rfc3339": "2025-03-01T00:00:00Z",
+		"date":    "2025-03-01",
+		"unix":    want.Unix(),
+		"float":   float64(want.Unix()),
+		"numeric": "1740787200",
+	} {
+		got, ok := ParseDocumentTime(value)
+		if !ok || !got.Equal(want) {
+			t.Errorf("%s: got %v, %v", name, got, ok)
+		}
+	}
+	for _, value := range []any{nil, "", "next tuesday", time.Time{}} {
+		if _, ok := ParseDocumentTime(value); ok {
+			t.Errorf("expected %v to be rejected", value)
+		}
+	}
+}
+
+func TestDocumentValidAt(t *testing.T) {
+	doc := Document{ID: "pricing-2024"}
+	doc.SetValidity(
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range cases {
+		if got := doc.ValidAt(tc.at); got != tc.want {
+			t.Errorf("ValidAt(%v) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+	if !(Document{}).ValidAt(time.Now()) {
+		t.Error("documents without validity metadata should always be valid")
+	}
+}
+
+func TestDocumentTimestampPrefersPublishedAt(t *testing.T) {
+	doc := Document{Metadata: map[string]any{
+		MetadataEffectiveAt: "2024-01-01",
+		MetadataPublishedAt: "2024-02-01",
+	}}
+	ts, ok := doc.Timestamp()
+	if !ok || ts.Month() != time.February {
+		t.Fatalf("Timestamp() = %v, %v", ts, ok)
+	}
+	delete(doc.Metadata, MetadataPublishedAt)
+	ts, ok = doc.Timestamp()
+	if !ok || ts.Month() != time.January {
+		t.Fatalf("Timestamp() fallback = %v, %v", ts, ok)
+	}
+}
+
+func TestReferenceTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := ReferenceTime(context.Background(), now); !got.Equal(now) {
+		t.Errorf("ReferenceTime without as-of = %v", got)
+	}
+	asOf := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if got := ReferenceTime(WithAsOf(context.Background(), asOf), now); !got.Equal(asOf) {
+		t.Errorf("ReferenceTime with as-of = %v", got)
+	}
+	if _, ok := AsOfFromContext(WithAsOf(context.Background(), time.Time{})); ok {
+		t.Error("zero as-of should not be stored")
+	}
+}
@@ -0,0 +1,254 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	speech "github.com/BaSui01/agentflow/llm/capabilities/audio"
+	rag "github.com/BaSui01/agentflow/rag/runtime"
+)
+
+// TranscriptLoaderConfig configures the audio/video transcription loader.
+type TranscriptLoaderConfig struct {
+	// Model is the STT model passed to the provider. Empty uses the provider default.
+	Model string
+	// Language is an optional ISO-639-1 hint.
+	Language string
+	// Prompt is an optional vocabulary/context hint for the STT model.
+	Prompt string
+	// DisableDiarization turns off speaker identification.
+	DisableDiarization bool
+	// MaxChunkDuration caps the media time covered by one Document. Defaults to 2 minutes.
+	MaxChunkDuration time.Duration
+	// MaxChunkChars caps the transcript length of one Document. Defaults to 2000.
+	MaxChunkChars int
+	// TopicGap is the pause between segments treated as a topic boundary. Defaults to 3 seconds.
+	TopicGap time.Duration
+	// Extensions overrides the handled file extensions (with leading dot).
+	// Defaults to the provider's SupportedFormats.
+	Extensions []string
+}
+
+// TranscriptLoader transcribes audio/video files with an STT provider and
+// emits one Document per speaker turn or topic span, with timecode metadata
+// linking back to the source media.
+type TranscriptLoader struct {
+	provider speech.STTProvider
+	config   TranscriptLoaderConfig
+}
+
+// NewTranscriptLoader creates a TranscriptLoader backed by the given STT provider.
+// It is not part of the built-in registry because it needs a provider; register it
+// explicitly with LoaderRegistry.Register for each extension in SupportedTypes.
+func NewTranscriptLoader(provider speech.STTProvider, config TranscriptLoaderConfig) *TranscriptLoader {
+	if config.MaxChunkDuration <= 0 {
+		config.MaxChunkDuration = 2 * time.Minute
+	}
+	if config.MaxChunkChars <= 0 {
+		config.MaxChunkChars = 2000
+	}
+	if config.TopicGap <= 0 {
+		config.TopicGap = 3 * time.Second
+	}
+	return &TranscriptLoader{provider: provider, config: config}
+}
+
+// Load transcribes the media file and returns timecoded transcript Documents.
+func (l *TranscriptLoader) Load(ctx context.Context, source string) ([]rag.Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if l.provider == nil {
+		return nil, fmt.Errorf("transcript loader: STT provider is required")
+	}
+	clean := filepath.Clean(source)
+	if strings.Contains(clean, "..") {
+		return nil, fmt.Errorf("transcript loader: source path must not contain ..")
+	}
+
+	resp, err := l.provider.TranscribeFile(ctx, clean, &speech.STTRequest{
+		Model:                  l.config.Model,
+		Language:               l.config.Language,
+		Prompt:                 l.config.Prompt,
+		ResponseFormat:         "verbose_json",
+		TimestampGranularities: []string{"segment", "word"},
+		Diarization:            !l.config.DisableDiarization,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transcript loader: transcribing %s: %w", source, err)
+	}
+
+	segments := transcriptSegments(resp)
+	if len(segments) == 0 {
+		return []rag.Document{}, nil
+	}
+
+	chunks := l.chunkSegments(segments)
+	docs := make([]rag.Document, 0, len(chunks))
+	for i, chunk := range chunks {
+		docs = append(docs, l.buildDocument(clean, resp, chunk, i, len(chunks)))
+	}
+	return docs, nil
+}
+
+// SupportedTypes returns the media extensions handled by TranscriptLoader.
+func (l *TranscriptLoader) SupportedTypes() []string {
+	if len(l.config.Extensions) > 0 {
+		return l.config.Extensions
+	}
+	if l.provider == nil {
+		return nil
+	}
+	formats := l.provider.SupportedFormats()
+	exts := make([]string, 0, len(formats))
+	for _, f := range formats {
+		exts = append(exts, "."+strings.TrimPrefix(strings.ToLower(f), "."))
+	}
+	return exts
+}
+
+// transcriptSegments returns the provider segments, falling back to a single
+// untimed segment when the provider only returned plain text.
+func transcriptSegments(resp *speech.STTResponse) []speech.Segment {
+	if resp == nil {
+		return nil
+	}
+	var out []speech.Segment
+	for _, seg := range resp.Segments {
+		seg.Text = strings.TrimSpace(seg.Text)
+		if seg.Text == "" {
+			continue
+		}
+		if seg.Speaker == "" {
+			seg.Speaker = dominantSpeaker(resp.Words, seg.Start, seg.End)
+		}
+		out = append(out, seg)
+	}
+	if len(out) == 0 && strings.TrimSpace(resp.Text) != "" {
+		out = append(out, speech.Segment{Text: strings.TrimSpace(resp.Text), End: resp.Duration})
+	}
+	return out
+}
+
+// dominantSpeaker picks the speaker who spoke most words inside [start, end].
+// Some providers only attach diarization labels to words.
+func dominantSpeaker(words []speech.Word, start, end time.Duration) string {
+	counts := map[string]int{}
+	best, bestCount := "", 0
+	for _, w := range words {
+		if w.Speaker == "" || w.Start < start || w.End > end {
+			continue
+		}
+		counts[w.Speaker]++
+		if counts[w.Speaker] > bestCount {
+			best, bestCount = w.Speaker, counts[w.Speaker]
+		}
+	}
+	return best
+}
+
+type transcriptChunk struct {
+	segments []speech.Segment
+	chars    int
+}
+
+func (c *transcriptChunk) start() time.Duration { return c.segments[0].Start }
+func (c *transcriptChunk) end() time.Duration   { return c.segments[len(c.segments)-1].End }
+
+// chunkSegments groups consecutive segments, starting a new chunk on a speaker
+// change, a pause longer than TopicGap, or when duration/length limits are hit.
+func (l *TranscriptLoader) chunkSegments(segments []speech.Segment) []*transcriptChunk {
+	var chunks []*transcriptChunk
+	var cur *transcriptChunk
+	for _, seg := range segments {
+		if cur != nil {
+			last := cur.segments[len(cur.segments)-1]
+			split := seg.Speaker != last.Speaker ||
+				seg.Start-last.End >= l.config.TopicGap ||
+				seg.End-cur.start() > l.config.MaxChunkDuration ||
+				cur.chars+len(seg.Text) > l.config.MaxChunkChars
+			if split {
+				chunks = append(chunks, cur)
+				cur = nil
+			}
+		}
+		if cur == nil {
+			cur = &transcriptChunk{}
+		}
+		cur.segments = append(cur.segments, seg)
+		cur.chars += len(seg.Text) + 1
+	}
+	if cur != nil {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+func (l *TranscriptLoader) buildDocument(source string, resp *speech.STTResponse, chunk *transcriptChunk, index, total int) rag.Document {
+	var b strings.Builder
+	var speakers []string
+	seen := map[string]bool{}
+	for i, seg := range chunk.segments {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("[" + formatTimecode(seg.Start) + "] ")
+		if seg.Speaker != "" {
+			b.WriteString(seg.Speaker + ": ")
+			if !seen[seg.Speaker] {
+				seen[seg.Speaker] = true
+				speakers = append(speakers, seg.Speaker)
+			}
+		}
+		b.WriteString(seg.Text)
+	}
+
+	start, end := chunk.start(), chunk.end()
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(source)))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	metadata := map[string]any{
+		"source_file":   filepath.Base(source),
+		"source_path":   source,
+		"content_type":  "text/plain",
+		"loader":        "transcript",
+		"media_uri":     fmt.Sprintf("%s#t=%.3f,%.3f", source, start.Seconds(), end.Seconds()),
+		"media_type":    contentType,
+		"start_seconds": start.Seconds(),
+		"end_seconds":   end.Seconds(),
+		"timecode":      formatTimecode(start) + "-" + formatTimecode(end),
+		"chunk_index":   index,
+		"chunk_total":   total,
+		"stt_provider":  resp.Provider,
+	}
+	if resp.Model != "" {
+		metadata["stt_model"] = resp.Model
+	}
+	if resp.Language != "" {
+		metadata["language"] = resp.Language
+	}
+	if len(speakers) == 1 {
+		metadata["speaker"] = speakers[0]
+	}
+	if len(speakers) > 0 {
+		metadata["speakers"] = speakers
+	}
+
+	return rag.Document{
+		ID:       fmt.Sprintf("%s#chunk_%d", source, index),
+		Content:  b.String(),
+		Metadata: metadata,
+	}
+}
+
+// formatTimecode renders d as HH:MM:SS.
+func formatTimecode(d time.Duration) string {
+	total := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total/60)%60, total%60)
+}
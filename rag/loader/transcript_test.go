@@ -0,0 +1,112 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	speech "github.com/BaSui01/agentflow/llm/capabilities/audio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSTTProvider struct {
+	resp    *speech.STTResponse
+	err     error
+	lastReq *speech.STTRequest
+}
+
+func (p *stubSTTProvider) Transcribe(ctx context.Context, req *speech.STTRequest) (*speech.STTResponse, error) {
+	return p.TranscribeFile(ctx, "", req)
+}
+
+func (p *stubSTTProvider) TranscribeFile(_ context.Context, _ string, opts *speech.STTRequest) (*speech.STTResponse, error) {
+	p.lastReq = opts
+	return p.resp, p.err
+}
+
+func (p *stubSTTProvider) Name() string { return "stub" }
+
+func (p *stubSTTProvider) SupportedFormats() []string { return []string{"mp3", "wav"} }
+
+func TestTranscriptLoader_ChunksBySpeakerAndGap(t *testing.T) {
+	t.Parallel()
+
+	stt := &stubSTTProvider{resp: &speech.STTResponse{
+		Provider: "stub",
+		Model:    "whisper-1",
+		Language: "en",
+		Segments: []speech.Segment{
+			{Start: 0, End: 4 * time.Second, Text: "Welcome to the show.", Speaker: "A"},
+			{Start: 4 * time.Second, End: 8 * time.Second, Text: "Today we talk about RAG.", Speaker: "A"},
+			{Start: 8 * time.Second, End: 12 * time.Second, Text: "Thanks for having me.", Speaker: "B"},
+			{Start: 20 * time.Second, End: 25 * time.Second, Text: "Next topic.", Speaker: "B"},
+		},
+	}}
+	l := NewTranscriptLoader(stt, TranscriptLoaderConfig{})
+
+	docs, err := l.Load(context.Background(), "meeting.mp3")
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, "[00:00:00] A: Welcome to the show.\n[00:00:04] A: Today we talk about RAG.", docs[0].Content)
+	assert.Equal(t, "A", docs[0].Metadata["speaker"])
+	assert.Equal(t, "00:00:00-00:00:08", docs[0].Metadata["timecode"])
+	assert.Equal(t, "meeting.mp3#t=0.000,8.000", docs[0].Metadata["media_uri"])
+	assert.Equal(t, "transcript", docs[0].Metadata["loader"])
+	assert.Equal(t, "en", docs[0].Metadata["language"])
+
+	assert.Equal(t, "B", docs[1].Metadata["speaker"])
+	assert.Equal(t, 20.0, docs[2].Metadata["start_seconds"], "pause beyond TopicGap starts a new chunk")
+
+	require.NotNil(t, stt.lastReq)
+	assert.True(t, stt.lastReq.Diarization)
+	assert.Equal(t, []string{"segment", "word"}, stt.lastReq.TimestampGranularities)
+}
+
+func TestTranscriptLoader_SpeakerFromWordsAndPlainTextFallback(t *testing.T) {
+	t.Parallel()
+
+	stt := &stubSTTProvider{resp: &speech.STTResponse{
+		Segments: []speech.Segment{{Start: 0, End: 2 * time.Second, Text: "hello there"}},
+		Words: []speech.Word{
+			{Word: "hello", Start: 0, End: time.Second, Speaker: "spk_1"},
+			{Word: "there", Start: time.Second, End: 2 * time.Second, Speaker: "spk_1"},
+		},
+	}}
+	docs, err := NewTranscriptLoader(stt, TranscriptLoaderConfig{}).Load(context.Background(), "clip.wav")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "spk_1", docs[0].Metadata["speaker"])
+
+	stt.resp = &speech.STTResponse{Text: "plain transcript", Duration: 5 * time.Second}
+	docs, err = NewTranscriptLoader(stt, TranscriptLoaderConfig{}).Load(context.Background(), "clip.wav")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "[00:00:00] plain transcript", docs[0].Content)
+}
+
+func TestTranscriptLoader_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewTranscriptLoader(nil, TranscriptLoaderConfig{}).Load(context.Background(), "a.mp3")
+	assert.Error(t, err)
+
+	stt := &stubSTTProvider{err: errors.New("boom")}
+	_, err = NewTranscriptLoader(stt, TranscriptLoaderConfig{}).Load(context.Background(), "a.mp3")
+	assert.ErrorContains(t, err, "boom")
+
+	_, err = NewTranscriptLoader(stt, TranscriptLoaderConfig{}).Load(context.Background(), "../a.mp3")
+	assert.ErrorContains(t, err, "..")
+}
+
+func TestTranscriptLoader_SupportedTypes(t *testing.T) {
+	t.Parallel()
+
+	l := NewTranscriptLoader(&stubSTTProvider{}, TranscriptLoaderConfig{})
+	assert.Equal(t, []string{".mp3", ".wav"}, l.SupportedTypes())
+
+	l = NewTranscriptLoader(&stubSTTProvider{}, TranscriptLoaderConfig{Extensions: []string{".mov"}})
+	assert.Equal(t, []string{".mov"}, l.SupportedTypes())
+}
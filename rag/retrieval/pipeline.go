@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	ragcore "github.com/BaSui01/agentflow/rag/core"
 	rag "github.com/BaSui01/agentflow/rag/runtime"
 )
 
@@ -32,6 +34,8 @@ type Composer interface {
 type PipelineInput struct {
 	Query          string
 	QueryEmbedding []float64
+	// AsOf restricts retrieval to documents valid at this time. Zero means now.
+	AsOf time.Time
 }
 
 // PipelineOutput is the normalized output of the retrieval pipeline.
@@ -97,6 +101,7 @@ func (p *Pipeline) Execute(ctx context.Context, in PipelineInput) (*PipelineOutp
 		return nil, fmt.Errorf("query is empty")
 	}
 
+	ctx = ragcore.WithAsOf(ctx, in.AsOf)
 	query := in.Query
 	if p.transformer != nil {
 		transformed, err := p.transformer.Transform(ctx, in.Query)
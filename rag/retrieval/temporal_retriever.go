@@ -0,0 +1,98 @@
+package retrieval
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	ragcore "github.com/BaSui01/agentflow/rag/core"
+	rag "github.com/BaSui01/agentflow/rag/runtime"
+)
+
+// TemporalConfig controls validity filtering and recency boosting.
+type TemporalConfig struct {
+	// RecencyWeight is the share of the final score driven by recency (0~1).
+	// Zero disables boosting; validity filtering still applies.
+	RecencyWeight float64 `json:"recency_weight" yaml:"recency_weight"`
+	// HalfLife is the document age at which the recency factor halves.
+	HalfLife time.Duration `json:"half_life" yaml:"half_life"`
+	// UndatedRecency is the recency factor for documents without a timestamp.
+	UndatedRecency float64 `json:"undated_recency" yaml:"undated_recency"`
+}
+
+// DefaultTemporalConfig returns defaults suited to slowly changing corpora
+// such as policies and price lists.
+func DefaultTemporalConfig() TemporalConfig {
+	return TemporalConfig{
+		RecencyWeight:  0.2,
+		HalfLife:       90 * 24 * time.Hour,
+		UndatedRecency: 0.5,
+	}
+}
+
+// TemporalRetriever wraps a Retriever with time awareness. Results whose
+// document is not valid at the reference time (the as-of date from
+// ragcore.WithAsOf, else now) are dropped, and scores are blended with an
+// exponential recency decay measured from the same reference time.
+//
+// Place it outside a CachingRetriever: the cache key ignores the as-of date,
+// so filtering must happen after cached results are returned.
+type TemporalRetriever struct {
+	inner  Retriever
+	config TemporalConfig
+	now    func() time.Time
+}
+
+// NewTemporalRetriever creates a time-aware retriever wrapper.
+func NewTemporalRetriever(inner Retriever, config TemporalConfig) *TemporalRetriever {
+	if config.RecencyWeight < 0 {
+		config.RecencyWeight = 0
+	}
+	if config.RecencyWeight > 1 {
+		config.RecencyWeight = 1
+	}
+	if config.HalfLife <= 0 {
+		config.HalfLife = DefaultTemporalConfig().HalfLife
+	}
+	return &TemporalRetriever{inner: inner, config: config, now: time.Now}
+}
+
+// Retrieve delegates to the wrapped retriever, then filters and re-scores.
+func (r *TemporalRetriever) Retrieve(ctx context.Context, query string, queryEmbedding []float64) ([]rag.RetrievalResult, error) {
+	results, err := r.inner.Retrieve(ctx, query, queryEmbedding)
+	if err != nil {
+		return nil, err
+	}
+	reference := ragcore.ReferenceTime(ctx, r.now())
+
+	out := make([]rag.RetrievalResult, 0, len(results))
+	for _, result := range results {
+		if !result.Document.ValidAt(reference) {
+			continue
+		}
+		if r.config.RecencyWeight > 0 {
+			factor := r.recencyFactor(result.Document, reference)
+			result.FinalScore *= (1 - r.config.RecencyWeight) + r.config.RecencyWeight*factor
+		}
+		out = append(out, result)
+	}
+	if r.config.RecencyWeight > 0 {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].FinalScore > out[j].FinalScore })
+	}
+	return out, nil
+}
+
+// recencyFactor returns 1 for documents dated at the reference time, halving
+// every HalfLife of age. Documents dated after the reference time count as fresh.
+func (r *TemporalRetriever) recencyFactor(doc rag.Document, reference time.Time) float64 {
+	ts, ok := doc.Timestamp()
+	if !ok {
+		return r.config.UndatedRecency
+	}
+	age := reference.Sub(ts)
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp(-math.Ln2 * float64(age) / float64(r.config.HalfLife))
+}
@@ -0,0 +1,109 @@
+package retrieval
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	ragcore "github.com/BaSui01/agentflow/rag/core"
+	rag "github.com/BaSui01/agentflow/rag/runtime"
+)
+
+func temporalResult(id string, score float64, metadata map[string]any) rag.RetrievalResult {
+	return rag.RetrievalResult{
+		Document:   rag.Document{ID: id, Content: id, Metadata: metadata},
+		FinalScore: score,
+	}
+}
+
+func resultIDs(results []rag.RetrievalResult) []string {
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.Document.ID)
+	}
+	return ids
+}
+
+func TestTemporalRetrieverFiltersByAsOfDate(t *testing.T) {
+	inner := &stubRetriever{results: []rag.RetrievalResult{
+		temporalResult("price-2023", 0.9, map[string]any{
+			ragcore.MetadataEffectiveAt: "2023-01-01",
+			ragcore.MetadataExpiresAt:   "2024-01-01",
+		}),
+		temporalResult("price-2024", 0.8, map[string]any{
+			ragcore.MetadataEffectiveAt: "2024-01-01",
+		}),
+		temporalResult("faq", 0.7, nil),
+	}}
+	retriever := NewTemporalRetriever(inner, TemporalConfig{})
+	retriever.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	got, err := retriever.Retrieve(context.Background(), "price", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(got); len(ids) != 2 || ids[0] != "price-2024" || ids[1] != "faq" {
+		t.Fatalf("current results = %v", ids)
+	}
+
+	ctx := ragcore.WithAsOf(context.Background(), time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	got, err = retriever.Retrieve(ctx, "price", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(got); len(ids) != 2 || ids[0] != "price-2023" || ids[1] != "faq" {
+		t.Fatalf("as-of results = %v", ids)
+	}
+}
+
+func TestTemporalRetrieverBoostsRecentDocuments(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	inner := &stubRetriever{results: []rag.RetrievalResult{
+		temporalResult("old", 0.80, map[string]any{ragcore.MetadataPublishedAt: now.AddDate(-2, 0, 0)}),
+		temporalResult("new", 0.75, map[string]any{ragcore.MetadataPublishedAt: now.AddDate(0, 0, -1)}),
+		temporalResult("undated", 0.70, nil),
+	}}
+	retriever := NewTemporalRetriever(inner, TemporalConfig{
+		RecencyWeight:  0.5,
+		HalfLife:       180 * 24 * time.Hour,
+		UndatedRecency: 0.5,
+	})
+	retriever.now = func() time.Time { return now }
+
+	got, err := retriever.Retrieve(context.Background(), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := resultIDs(got); ids[0] != "new" || ids[2] != "old" {
+		t.Fatalf("order = %v", ids)
+	}
+	if got[0].FinalScore > 0.75 || got[0].FinalScore < 0.74 {
+		t.Errorf("fresh document score = %v, want ~0.75", got[0].FinalScore)
+	}
+	if want := 0.70 * 0.75; math.Abs(got[1].FinalScore-want) > 1e-9 {
+		t.Errorf("undated score = %v, want %v", got[1].FinalScore, want)
+	}
+}
+
+func TestPipelineAsOfReachesRetriever(t *testing.T) {
+	asOf := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var seen time.Time
+	retriever := retrieverFunc(func(ctx context.Context, _ string, _ []float64) ([]rag.RetrievalResult, error) {
+		seen, _ = ragcore.AsOfFromContext(ctx)
+		return nil, nil
+	})
+	p := NewPipeline(PipelineConfig{}, nil, retriever, nil, nil)
+	if _, err := p.Execute(context.Background(), PipelineInput{Query: "q", AsOf: asOf}); err != nil {
+		t.Fatal(err)
+	}
+	if !seen.Equal(asOf) {
+		t.Errorf("retriever saw as-of %v, want %v", seen, asOf)
+	}
+}
+
+type retrieverFunc func(ctx context.Context, query string, queryEmbedding []float64) ([]rag.RetrievalResult, error)
+
+func (f retrieverFunc) Retrieve(ctx context.Context, query string, queryEmbedding []float64) ([]rag.RetrievalResult, error) {
+	return f(ctx, query, queryEmbedding)
+}
@@ -0,0 +1,194 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Score normalization constants for weighted/learned fusion.
+const (
+	ScoreNormalizationMinMax = "minmax"
+	ScoreNormalizationZScore = "zscore"
+)
+
+// FusionFeedback 一条融合评测反馈：某文档对某查询是否相关，以及检索时的归一化分数。
+type FusionFeedback struct {
+	Collection  string  `json:"collection"`
+	BM25Score   float64 `json:"bm25_score"`
+	VectorScore float64 `json:"vector_score"`
+	Relevant    bool    `json:"relevant"`
+}
+
+// FusionWeights 按集合拟合出的融合权重（逻辑回归系数）。
+type FusionWeights struct {
+	BM25    float64 `json:"bm25"`
+	Vector  float64 `json:"vector"`
+	Bias    float64 `json:"bias"`
+	Samples int     `json:"samples"`
+}
+
+// Score 返回文档相关的概率估计，范围 0-1。
+func (w FusionWeights) Score(bm25, vector float64) float64 {
+	return 1 / (1 + math.Exp(-(w.Bias + w.BM25*bm25 + w.Vector*vector)))
+}
+
+// FusionLearnerConfig 学习型融合配置
+type FusionLearnerConfig struct {
+	MinSamples   int     `json:"min_samples"`   // 拟合所需最少样本数，且正负样本都需存在，默认 20
+	MaxSamples   int     `json:"max_samples"`   // 每个集合保留的最近样本数，默认 5000
+	LearningRate float64 `json:"learning_rate"` // 梯度下降步长，默认 0.5
+	Iterations   int     `json:"iterations"`    // 梯度下降轮数，默认 300
+	L2           float64 `json:"l2"`            // L2 正则系数，默认 0.01
+}
+
+// DefaultFusionLearnerConfig 返回默认学习型融合配置
+func DefaultFusionLearnerConfig() FusionLearnerConfig {
+	return FusionLearnerConfig{
+		MinSamples:   20,
+		MaxSamples:   5000,
+		LearningRate: 0.5,
+		Iterations:   300,
+		L2:           0.01,
+	}
+}
+
+// FusionWeightLearner 按集合从评测反馈拟合 BM25/向量融合权重。
+// 可在多个 HybridRetriever 之间共享，集合由 HybridRetrievalConfig.Collection 区分。
+type FusionWeightLearner struct {
+	mu      sync.RWMutex
+	config  FusionLearnerConfig
+	samples map[string][]FusionFeedback
+	weights map[string]FusionWeights
+}
+
+// NewFusionWeightLearner 创建学习型融合权重拟合器
+func NewFusionWeightLearner(config FusionLearnerConfig) *FusionWeightLearner {
+	defaults := DefaultFusionLearnerConfig()
+	if config.MinSamples <= 0 {
+		config.MinSamples = defaults.MinSamples
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = defaults.MaxSamples
+	}
+	if config.LearningRate <= 0 {
+		config.LearningRate = defaults.LearningRate
+	}
+	if config.Iterations <= 0 {
+		config.Iterations = defaults.Iterations
+	}
+	if config.L2 < 0 {
+		config.L2 = defaults.L2
+	}
+	return &FusionWeightLearner{
+		config:  config,
+		samples: make(map[string][]FusionFeedback),
+		weights: make(map[string]FusionWeights),
+	}
+}
+
+// Record 记录反馈并在样本足够时重新拟合对应集合的权重。
+func (l *FusionWeightLearner) Record(feedback ...FusionFeedback) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	touched := make(map[string]struct{})
+	for _, fb := range feedback {
+		samples := append(l.samples[fb.Collection], fb)
+		if overflow := len(samples) - l.config.MaxSamples; overflow > 0 {
+			samples = append([]FusionFeedback(nil), samples[overflow:]...)
+		}
+		l.samples[fb.Collection] = samples
+		touched[fb.Collection] = struct{}{}
+	}
+	for collection := range touched {
+		if weights, err := l.fitLocked(collection); err == nil {
+			l.weights[collection] = weights
+		}
+	}
+}
+
+// Fit 立即重新拟合指定集合的权重。
+func (l *FusionWeightLearner) Fit(collection string) (FusionWeights, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	weights, err := l.fitLocked(collection)
+	if err != nil {
+		return FusionWeights{}, err
+	}
+	l.weights[collection] = weights
+	return weights, nil
+}
+
+// Weights 返回指定集合已拟合的权重。
+func (l *FusionWeightLearner) Weights(collection string) (FusionWeights, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	weights, ok := l.weights[collection]
+	return weights, ok
+}
+
+// fitLocked 使用带 L2 正则的批量梯度下降拟合逻辑回归。调用者必须持有写锁。
+func (l *FusionWeightLearner) fitLocked(collection string) (FusionWeights, error) {
+	samples := l.samples[collection]
+	positives := 0
+	for _, s := range samples {
+		if s.Relevant {
+			positives++
+		}
+	}
+	if len(samples) < l.config.MinSamples || positives == 0 || positives == len(samples) {
+		return FusionWeights{}, fmt.Errorf("collection %q needs at least %d samples with both relevant and irrelevant feedback, got %d (%d relevant)",
+			collection, l.config.MinSamples, len(samples), positives)
+	}
+
+	var w FusionWeights
+	n := float64(len(samples))
+	for iter := 0; iter < l.config.Iterations; iter++ {
+		var gradBias, gradBM25, gradVector float64
+		for _, s := range samples {
+			label := 0.0
+			if s.Relevant {
+				label = 1
+			}
+			diff := w.Score(s.BM25Score, s.VectorScore) - label
+			gradBias += diff
+			gradBM25 += diff * s.BM25Score
+			gradVector += diff * s.VectorScore
+		}
+		w.Bias -= l.config.LearningRate * gradBias / n
+		w.BM25 -= l.config.LearningRate * (gradBM25/n + l.config.L2*w.BM25)
+		w.Vector -= l.config.LearningRate * (gradVector/n + l.config.L2*w.Vector)
+	}
+	w.Samples = len(samples)
+	return w, nil
+}
+
+// normalizeScoresZ 归一化分数（Z-Score）。输出无上下界，所有分数相同时均为 0。
+func normalizeScoresZ(scores map[string]float64) map[string]float64 {
+	if len(scores) == 0 {
+		return scores
+	}
+	var mean float64
+	for _, score := range scores {
+		mean += score
+	}
+	mean /= float64(len(scores))
+
+	var variance float64
+	for _, score := range scores {
+		variance += (score - mean) * (score - mean)
+	}
+	std := math.Sqrt(variance / float64(len(scores)))
+
+	normalized := make(map[string]float64, len(scores))
+	for id, score := range scores {
+		if std == 0 {
+			normalized[id] = 0
+			continue
+		}
+		normalized[id] = (score - mean) / std
+	}
+	return normalized
+}
@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNormalizeScoresZ(t *testing.T) {
+	normalized := normalizeScoresZ(map[string]float64{"a": 1, "b": 2, "c": 3})
+	assert.InDelta(t, -1.2247, normalized["a"], 1e-3)
+	assert.InDelta(t, 0, normalized["b"], 1e-9)
+	assert.InDelta(t, 1.2247, normalized["c"], 1e-3)
+
+	flat := normalizeScoresZ(map[string]float64{"a": 5, "b": 5})
+	assert.Equal(t, map[string]float64{"a": 0, "b": 0}, flat)
+}
+
+func TestFusionWeightLearner_FitsPerCollection(t *testing.T) {
+	learner := NewFusionWeightLearner(FusionLearnerConfig{MinSamples: 10})
+
+	learner.Record(FusionFeedback{Collection: "docs", VectorScore: 0.9, Relevant: true})
+	_, err := learner.Fit("docs")
+	require.Error(t, err, "too few samples")
+
+	// vector 分数决定相关性，bm25 分数与相关性无关
+	var feedback []FusionFeedback
+	for i := 0; i < 40; i++ {
+		relevant := i%2 == 0
+		vector := 0.1
+		if relevant {
+			vector = 0.9
+		}
+		feedback = append(feedback, FusionFeedback{Collection: "docs", BM25Score: float64(i%4) / 3, VectorScore: vector, Relevant: relevant})
+	}
+	learner.Record(feedback...)
+
+	weights, ok := learner.Weights("docs")
+	require.True(t, ok)
+	assert.Greater(t, weights.Vector, weights.BM25)
+	assert.Greater(t, weights.Score(0, 0.9), weights.Score(1, 0.1))
+	assert.Equal(t, 41, weights.Samples)
+
+	_, ok = learner.Weights("other")
+	assert.False(t, ok, "collections are fitted independently")
+}
+
+func TestHybridRetriever_LearnedFusion(t *testing.T) {
+	cfg := DefaultHybridRetrievalConfig()
+	cfg.FusionAlgorithm = FusionLearned
+	cfg.Collection = "kb"
+	cfg.UseReranking = false
+	cfg.MinScore = -1
+	cfg.TopK = 10
+	retriever := NewHybridRetriever(cfg, zap.NewNop())
+	require.NotNil(t, retriever.FusionLearner())
+
+	require.NoError(t, retriever.IndexDocuments([]Document{
+		{ID: "lexical", Content: "golang channel golang channel", Embedding: []float64{0, 1}},
+		{ID: "semantic", Content: "concurrency primitives", Embedding: []float64{1, 0}},
+	}))
+	query := []float64{1, 0.1}
+
+	results, err := retriever.Retrieve(context.Background(), "golang channel", query)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	// 未拟合前回退到 alpha=0.5 的 weighted 融合：两者各得 0.5 分
+	assert.InDelta(t, results[0].HybridScore, results[1].HybridScore, 1e-9)
+
+	for i := 0; i < 20; i++ {
+		retriever.RecordFeedback(results, []string{"semantic"})
+	}
+	weights, ok := retriever.FusionLearner().Weights("kb")
+	require.True(t, ok)
+	assert.Greater(t, weights.Vector, weights.BM25)
+
+	results, err = retriever.Retrieve(context.Background(), "golang channel", query)
+	require.NoError(t, err)
+	assert.Equal(t, "semantic", results[0].Document.ID)
+	assert.Greater(t, results[0].HybridScore, 0.5)
+}
+
+func TestNormalizeHybridRetrievalConfig_Fusion(t *testing.T) {
+	cfg := normalizeHybridRetrievalConfig(HybridRetrievalConfig{FusionAlgorithm: "unknown", ScoreNormalization: "bogus"})
+	assert.Equal(t, FusionRRF, cfg.FusionAlgorithm)
+	assert.Equal(t, ScoreNormalizationMinMax, cfg.ScoreNormalization)
+
+	cfg = normalizeHybridRetrievalConfig(HybridRetrievalConfig{FusionAlgorithm: FusionLearned, ScoreNormalization: ScoreNormalizationZScore})
+	assert.Equal(t, FusionLearned, cfg.FusionAlgorithm)
+	assert.Equal(t, ScoreNormalizationZScore, cfg.ScoreNormalization)
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/BaSui01/agentflow/rag/core"
 	"github.com/BaSui01/agentflow/types"
 	"go.uber.org/zap"
 )
@@ -17,6 +18,7 @@ import (
 const (
 	FusionRRF      = "rrf"
 	FusionWeighted = "weighted"
+	FusionLearned  = "learned"
 )
 
 // HybridRetrievalConfig 混合检索配置（基于 2025 年最佳实践）
@@ -43,9 +45,17 @@ type HybridRetrievalConfig struct {
 	// 融合算法
 	// - "rrf": Reciprocal Rank Fusion（默认）
 	// - "weighted": 归一化加权融合
+	// - "learned": 按集合从评测反馈拟合的权重融合，权重未拟合前回退到 weighted
 	FusionAlgorithm string  `json:"fusion_algorithm"`
 	FusionAlpha     float64 `json:"fusion_alpha"` // weighted 模式下 vector 权重（0~1）
 	RRFK            int     `json:"rrf_k"`        // rrf 模式分母平滑参数，默认 60
+
+	// 分数归一化（weighted/learned 模式使用）
+	// - "minmax": 归一化到 0~1（默认）
+	// - "zscore": 标准分，输出无上下界，MinScore 需相应调整
+	ScoreNormalization string `json:"score_normalization"`
+	// Collection learned 模式下查找融合权重所用的集合名
+	Collection string `json:"collection,omitempty"`
 }
 
 // DefaultHybridRetrievalConfig 返回默认混合检索配置
@@ -64,6 +74,8 @@ func DefaultHybridRetrievalConfig() HybridRetrievalConfig {
 		FusionAlgorithm: FusionRRF,
 		FusionAlpha:     0.5,
 		RRFK:            60,
+
+		ScoreNormalization: ScoreNormalizationMinMax,
 	}
 }
 
@@ -83,6 +95,12 @@ type HybridRetriever struct {
 	// 向量存储（可选）
 	vectorStore VectorStore
 
+	// 学习型融合权重（learned 模式）
+	fusionLearner *FusionWeightLearner
+
+	// 假设问题索引（可选），问题命中折算为源 chunk 的向量分数
+	questionIndex *HypotheticalQuestionIndex
+
 	logger *zap.Logger
 }
 
@@ -93,9 +111,10 @@ func NewHybridRetriever(config HybridRetrievalConfig, logger *zap.Logger) *Hybri
 		logger = zap.NewNop()
 	}
 	return &HybridRetriever{
-		config: config,
-		idf:    make(map[string]float64),
-		logger: logger,
+		config:        config,
+		idf:           make(map[string]float64),
+		fusionLearner: defaultFusionLearner(config),
+		logger:        logger,
 	}
 }
 
@@ -110,13 +129,70 @@ func NewHybridRetrieverWithVectorStore(
 		logger = zap.NewNop()
 	}
 	return &HybridRetriever{
-		config:      config,
-		idf:         make(map[string]float64),
-		vectorStore: vectorStore,
-		logger:      logger,
+		config:        config,
+		idf:           make(map[string]float64),
+		vectorStore:   vectorStore,
+		fusionLearner: defaultFusionLearner(config),
+		logger:        logger,
 	}
 }
 
+func defaultFusionLearner(config HybridRetrievalConfig) *FusionWeightLearner {
+	if config.FusionAlgorithm != FusionLearned {
+		return nil
+	}
+	return NewFusionWeightLearner(DefaultFusionLearnerConfig())
+}
+
+// SetFusionLearner 注入（可跨检索器共享的）融合权重拟合器
+func (r *HybridRetriever) SetFusionLearner(learner *FusionWeightLearner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fusionLearner = learner
+}
+
+// SetQuestionIndex 注入假设问题索引，向量检索时 chunk 分数取直接相似度与问题命中分数的较大值
+func (r *HybridRetriever) SetQuestionIndex(index *HypotheticalQuestionIndex) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.questionIndex = index
+}
+
+// FusionLearner 返回当前融合权重拟合器，非 learned 模式且未注入时为 nil
+func (r *HybridRetriever) FusionLearner() *FusionWeightLearner {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.fusionLearner
+}
+
+// RecordFeedback 记录一次检索的评测反馈：relevantIDs 中的文档视为相关，其余结果视为不相关。
+// 反馈写入当前集合并触发权重重新拟合；未配置拟合器时为空操作。
+func (r *HybridRetriever) RecordFeedback(results []RetrievalResult, relevantIDs []string) {
+	r.mu.RLock()
+	learner := r.fusionLearner
+	collection := r.config.Collection
+	r.mu.RUnlock()
+	if learner == nil || len(results) == 0 {
+		return
+	}
+
+	relevant := make(map[string]struct{}, len(relevantIDs))
+	for _, id := range relevantIDs {
+		relevant[id] = struct{}{}
+	}
+	feedback := make([]FusionFeedback, 0, len(results))
+	for _, res := range results {
+		_, ok := relevant[res.Document.ID]
+		feedback = append(feedback, FusionFeedback{
+			Collection:  collection,
+			BM25Score:   res.BM25Score,
+			VectorScore: res.VectorScore,
+			Relevant:    ok,
+		})
+	}
+	learner.Record(feedback...)
+}
+
 // IndexDocuments 索引文档
 func (r *HybridRetriever) IndexDocuments(docs []Document) error {
 	return r.indexDocuments(context.Background(), docs)
@@ -213,10 +289,11 @@ func (r *HybridRetriever) Retrieve(ctx context.Context, query string, queryEmbed
 	// 3. 合并结果
 	merged := r.mergeResults(bm25Results, vectorResults)
 
-	// 4. 转换为 RetrievalResult
+	// 4. 转换为 RetrievalResult，剔除在参考时间（截至时间或当前时间）无效的文档
+	reference := core.ReferenceTime(ctx, time.Now())
 	for docID, scores := range merged {
 		doc := r.getDocumentByID(docID)
-		if doc == nil {
+		if doc == nil || !doc.ValidAt(reference) {
 			continue
 		}
 
@@ -352,6 +429,25 @@ func (r *HybridRetriever) bm25Retrieve(query string) map[string]float64 {
 
 // vectorRetrieve 向量检索（余弦相似度）
 func (r *HybridRetriever) vectorRetrieve(ctx context.Context, queryEmbedding []float64) map[string]float64 {
+	scores := r.directVectorRetrieve(ctx, queryEmbedding)
+	if r.questionIndex == nil {
+		return scores
+	}
+
+	questionScores, err := r.questionIndex.SearchChunks(ctx, queryEmbedding, r.config.RerankTopK)
+	if err != nil {
+		r.logger.Warn("hypothetical question search failed", zap.Error(err))
+		return scores
+	}
+	for chunkID, score := range questionScores {
+		if score > scores[chunkID] {
+			scores[chunkID] = score
+		}
+	}
+	return scores
+}
+
+func (r *HybridRetriever) directVectorRetrieve(ctx context.Context, queryEmbedding []float64) map[string]float64 {
 	scores := make(map[string]float64)
 
 	// 优先使用向量存储
@@ -412,9 +508,20 @@ func (r *HybridRetriever) cosineSimilarity(a, b []float64) float64 {
 func (r *HybridRetriever) mergeResults(bm25Results, vectorResults map[string]float64) map[string]map[string]float64 {
 	merged := make(map[string]map[string]float64)
 
-	// 归一化分数（weighted 模式使用）
-	bm25Normalized := r.normalizeScores(bm25Results)
-	vectorNormalized := r.normalizeScores(vectorResults)
+	// 归一化分数（weighted/learned 模式使用）
+	normalize := r.normalizeScores
+	if r.config.ScoreNormalization == ScoreNormalizationZScore {
+		normalize = normalizeScoresZ
+	}
+	bm25Normalized := normalize(bm25Results)
+	vectorNormalized := normalize(vectorResults)
+
+	var learned *FusionWeights
+	if r.config.FusionAlgorithm == FusionLearned && r.fusionLearner != nil {
+		if weights, ok := r.fusionLearner.Weights(r.config.Collection); ok {
+			learned = &weights
+		}
+	}
 	bm25Ranks := rankScoresDescending(bm25Results)
 	vectorRanks := rankScoresDescending(vectorResults)
 
@@ -433,8 +540,10 @@ func (r *HybridRetriever) mergeResults(bm25Results, vectorResults map[string]flo
 		vectorScore := vectorNormalized[id]
 
 		hybridScore := 0.0
-		switch r.config.FusionAlgorithm {
-		case FusionWeighted:
+		switch {
+		case learned != nil:
+			hybridScore = learned.Score(bm25Score, vectorScore)
+		case r.config.FusionAlgorithm == FusionWeighted || r.config.FusionAlgorithm == FusionLearned:
 			alpha := r.config.FusionAlpha
 			hybridScore = (1-alpha)*bm25Score + alpha*vectorScore
 		default:
@@ -458,9 +567,14 @@ func (r *HybridRetriever) mergeResults(bm25Results, vectorResults map[string]flo
 }
 
 func normalizeHybridRetrievalConfig(cfg HybridRetrievalConfig) HybridRetrievalConfig {
-	if cfg.FusionAlgorithm != FusionWeighted {
+	switch cfg.FusionAlgorithm {
+	case FusionWeighted, FusionLearned:
+	default:
 		cfg.FusionAlgorithm = FusionRRF
 	}
+	if cfg.ScoreNormalization != ScoreNormalizationZScore {
+		cfg.ScoreNormalization = ScoreNormalizationMinMax
+	}
 	if cfg.FusionAlpha < 0 || cfg.FusionAlpha > 1 {
 		cfg.FusionAlpha = 0.5
 	}
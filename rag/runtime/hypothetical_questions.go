@@ -0,0 +1,287 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// 假设问题文档的元数据键
+const (
+	MetadataSourceChunkID        = "source_chunk_id"
+	MetadataHypotheticalQuestion = "hypothetical_question"
+)
+
+// HypotheticalQuestionGenerator 为 chunk 生成它能回答的假设问题
+type HypotheticalQuestionGenerator interface {
+	GenerateQuestions(ctx context.Context, chunk Document, n int) ([]string, error)
+}
+
+// HypotheticalQuestionConfig 假设问题索引配置
+type HypotheticalQuestionConfig struct {
+	QuestionsPerChunk int     `json:"questions_per_chunk"` // 每个 chunk 生成的问题数，默认 3
+	MaxConcurrency    int     `json:"max_concurrency"`     // 并发生成的 chunk 数，默认 4
+	QuestionWeight    float64 `json:"question_weight"`     // 问题命中分数折算到 chunk 的系数，默认 1.0
+}
+
+// DefaultHypotheticalQuestionConfig 返回默认假设问题索引配置
+func DefaultHypotheticalQuestionConfig() HypotheticalQuestionConfig {
+	return HypotheticalQuestionConfig{
+		QuestionsPerChunk: 3,
+		MaxConcurrency:    4,
+		QuestionWeight:    1.0,
+	}
+}
+
+// LLMQuestionGenerator 基于 LLM 的假设问题生成器
+type LLMQuestionGenerator struct {
+	llmProvider func(context.Context, string) (string, error)
+	logger      *zap.Logger
+}
+
+// NewLLMQuestionGenerator 创建 LLM 假设问题生成器
+func NewLLMQuestionGenerator(
+	llmProvider func(context.Context, string) (string, error),
+	logger *zap.Logger,
+) *LLMQuestionGenerator {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LLMQuestionGenerator{
+		llmProvider: llmProvider,
+		logger:      logger,
+	}
+}
+
+// GenerateQuestions 生成 n 个假设问题
+func (g *LLMQuestionGenerator) GenerateQuestions(ctx context.Context, chunk Document, n int) ([]string, error) {
+	prompt := fmt.Sprintf(`Generate %d distinct questions that the following passage answers.
+Write questions the way a user would ask them. Output one question per line without numbering.
+
+Passage: %s
+
+Questions:`, n, chunk.Content)
+
+	response, err := g.llmProvider(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate questions: %w", err)
+	}
+	return parseGeneratedQuestions(response, n), nil
+}
+
+var questionListPrefix = regexp.MustCompile(`^(?:[-*•]|\d+[.)]|(?i:q\d*[:.]))\s*`)
+
+// parseGeneratedQuestions 按行解析问题，去掉编号与重复项，最多保留 n 个
+func parseGeneratedQuestions(response string, n int) []string {
+	seen := make(map[string]struct{})
+	questions := make([]string, 0, n)
+	for _, line := range strings.Split(response, "\n") {
+		q := strings.TrimSpace(questionListPrefix.ReplaceAllString(strings.TrimSpace(line), ""))
+		if q == "" {
+			continue
+		}
+		key := strings.ToLower(q)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		questions = append(questions, q)
+		if len(questions) == n {
+			break
+		}
+	}
+	return questions
+}
+
+// HypotheticalQuestionIndex 假设问题索引（与 HyDE 互补的入库侧方案）
+// 入库时为每个 chunk 生成 N 个假设问题并向量化，问题向量指回源 chunk；
+// 查询时问题与问题相匹配，命中后折算为源 chunk 的向量分数
+type HypotheticalQuestionIndex struct {
+	generator HypotheticalQuestionGenerator
+	embedder  EmbeddingProvider
+	store     VectorStore
+	config    HypotheticalQuestionConfig
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	byChunk map[string][]string // chunkID -> 问题文档 ID
+}
+
+// NewHypotheticalQuestionIndex 创建假设问题索引，store 为 nil 时使用内存向量存储。
+// 问题向量应与 chunk 向量分开存放，避免检索时混入问题文档。
+func NewHypotheticalQuestionIndex(
+	generator HypotheticalQuestionGenerator,
+	embedder EmbeddingProvider,
+	store VectorStore,
+	config HypotheticalQuestionConfig,
+	logger *zap.Logger,
+) *HypotheticalQuestionIndex {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	defaults := DefaultHypotheticalQuestionConfig()
+	if config.QuestionsPerChunk <= 0 {
+		config.QuestionsPerChunk = defaults.QuestionsPerChunk
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if config.QuestionWeight <= 0 {
+		config.QuestionWeight = defaults.QuestionWeight
+	}
+	if store == nil {
+		store = NewInMemoryVectorStore(logger)
+	}
+	return &HypotheticalQuestionIndex{
+		generator: generator,
+		embedder:  embedder,
+		store:     store,
+		config:    config,
+		logger:    logger,
+		byChunk:   make(map[string][]string),
+	}
+}
+
+// IndexChunks 为 chunks 生成、向量化并索引假设问题，替换这些 chunk 之前的问题。
+// 单个 chunk 生成失败只记录日志并跳过
+func (x *HypotheticalQuestionIndex) IndexChunks(ctx context.Context, chunks []Document) error {
+	generated := make([][]string, len(chunks))
+	sem := make(chan struct{}, x.config.MaxConcurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		if strings.TrimSpace(chunk.Content) == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, chunk Document) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			questions, err := x.generator.GenerateQuestions(ctx, chunk, x.config.QuestionsPerChunk)
+			if err != nil {
+				x.logger.Warn("failed to generate hypothetical questions, skipping chunk",
+					zap.String("chunk_id", chunk.ID),
+					zap.Error(err))
+				return
+			}
+			if len(questions) > x.config.QuestionsPerChunk {
+				questions = questions[:x.config.QuestionsPerChunk]
+			}
+			generated[i] = questions
+		}(i, chunk)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var texts []string
+	var questionDocs []Document
+	for i, questions := range generated {
+		for j, q := range questions {
+			texts = append(texts, q)
+			questionDocs = append(questionDocs, Document{
+				ID:      fmt.Sprintf("%s#q%d", chunks[i].ID, j),
+				Content: q,
+				Metadata: map[string]any{
+					MetadataSourceChunkID:        chunks[i].ID,
+					MetadataHypotheticalQuestion: true,
+				},
+			})
+		}
+	}
+
+	chunkIDs := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		chunkIDs[i] = chunk.ID
+	}
+	if err := x.RemoveChunks(ctx, chunkIDs); err != nil {
+		return err
+	}
+	if len(questionDocs) == 0 {
+		return nil
+	}
+
+	embeddings, err := x.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed hypothetical questions: %w", err)
+	}
+	if len(embeddings) != len(questionDocs) {
+		return fmt.Errorf("failed to embed hypothetical questions: expected %d embeddings, got %d", len(questionDocs), len(embeddings))
+	}
+	for i := range questionDocs {
+		questionDocs[i].Embedding = embeddings[i]
+	}
+	if err := x.store.AddDocuments(ctx, questionDocs); err != nil {
+		return fmt.Errorf("failed to index hypothetical questions: %w", err)
+	}
+
+	x.mu.Lock()
+	for _, doc := range questionDocs {
+		chunkID := doc.Metadata[MetadataSourceChunkID].(string)
+		x.byChunk[chunkID] = append(x.byChunk[chunkID], doc.ID)
+	}
+	x.mu.Unlock()
+
+	x.logger.Info("indexed hypothetical questions",
+		zap.Int("chunks", len(chunks)),
+		zap.Int("questions", len(questionDocs)))
+	return nil
+}
+
+// RemoveChunks 删除这些 chunk 的假设问题
+func (x *HypotheticalQuestionIndex) RemoveChunks(ctx context.Context, chunkIDs []string) error {
+	x.mu.Lock()
+	var ids []string
+	for _, chunkID := range chunkIDs {
+		ids = append(ids, x.byChunk[chunkID]...)
+		delete(x.byChunk, chunkID)
+	}
+	x.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := x.store.DeleteDocuments(ctx, ids); err != nil {
+		return fmt.Errorf("failed to delete hypothetical questions: %w", err)
+	}
+	return nil
+}
+
+// Questions 返回 chunk 已索引的问题文档 ID
+func (x *HypotheticalQuestionIndex) Questions(chunkID string) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return append([]string(nil), x.byChunk[chunkID]...)
+}
+
+// SearchChunks 用查询向量检索假设问题，返回源 chunk ID 到分数的映射（同一 chunk 取最高分）
+func (x *HypotheticalQuestionIndex) SearchChunks(ctx context.Context, queryEmbedding []float64, topK int) (map[string]float64, error) {
+	if topK <= 0 {
+		topK = 10
+	}
+	results, err := x.store.Search(ctx, queryEmbedding, topK*x.config.QuestionsPerChunk)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64)
+	for _, result := range results {
+		chunkID := getMetadataString(result.Document.Metadata, MetadataSourceChunkID)
+		if chunkID == "" {
+			continue
+		}
+		score := result.Score * x.config.QuestionWeight
+		if score > scores[chunkID] {
+			scores[chunkID] = score
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,157 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// conceptEmbeddingProvider 按概念词计数生成向量
+type conceptEmbeddingProvider struct{}
+
+var questionTestConcepts = [][]string{
+	{"password", "credentials", "login"},
+	{"settings", "account", "page", "banner"},
+	{"invoice", "billing"},
+}
+
+func (conceptEmbeddingProvider) vector(text string) []float64 {
+	text = strings.ToLower(text)
+	v := make([]float64, len(questionTestConcepts))
+	for i, words := range questionTestConcepts {
+		for _, w := range words {
+			v[i] += float64(strings.Count(text, w))
+		}
+	}
+	return v
+}
+
+func (p conceptEmbeddingProvider) EmbedQuery(_ context.Context, query string) ([]float64, error) {
+	return p.vector(query), nil
+}
+
+func (p conceptEmbeddingProvider) EmbedDocuments(_ context.Context, documents []string) ([][]float64, error) {
+	out := make([][]float64, len(documents))
+	for i, d := range documents {
+		out[i] = p.vector(d)
+	}
+	return out, nil
+}
+
+func (conceptEmbeddingProvider) Name() string { return "concept" }
+
+// stubQuestionGenerator 按 chunk ID 返回预设问题
+type stubQuestionGenerator struct {
+	mu        sync.Mutex
+	questions map[string][]string
+	calls     int
+}
+
+func (g *stubQuestionGenerator) GenerateQuestions(_ context.Context, chunk Document, n int) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	questions, ok := g.questions[chunk.ID]
+	if !ok {
+		return nil, errors.New("generation failed")
+	}
+	return questions, nil
+}
+
+func newQuestionTestRetriever(generator HypotheticalQuestionGenerator) *EnhancedRetriever {
+	config := DefaultHybridRetrievalConfig()
+	config.UseBM25 = false
+	config.UseReranking = false
+	config.MinScore = 0
+	return NewEnhancedRetriever(EnhancedRetrieverConfig{
+		HybridConfig:      config,
+		EmbeddingProvider: conceptEmbeddingProvider{},
+		QuestionGenerator: generator,
+	}, zap.NewNop())
+}
+
+func questionTestChunks() []Document {
+	return []Document{
+		{ID: "reset", Content: "Reset your password from the account settings page"},
+		{ID: "banner", Content: "Login page credentials banner styling"},
+		{ID: "billing", Content: "Invoices are sent by the billing team"},
+	}
+}
+
+func TestHypotheticalQuestions_ImproveQuestionMatching(t *testing.T) {
+	ctx := context.Background()
+	query := "How do I change my login credentials?"
+
+	plain := newQuestionTestRetriever(nil)
+	require.NoError(t, plain.IndexDocumentsWithEmbedding(ctx, questionTestChunks()))
+	results, err := plain.ExecuteRetrievalPipeline(ctx, query)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "banner", results[0].Document.ID)
+
+	generator := &stubQuestionGenerator{questions: map[string][]string{
+		"reset":  {"How do I change my login credentials?", "Where can I reset my password?"},
+		"banner": {"How is the banner styled?"},
+	}}
+	withQuestions := newQuestionTestRetriever(generator)
+	require.NoError(t, withQuestions.IndexDocumentsWithEmbedding(ctx, questionTestChunks()))
+	results, err = withQuestions.ExecuteRetrievalPipeline(ctx, query)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "reset", results[0].Document.ID)
+
+	// billing 生成失败被跳过，不影响其他 chunk
+	index := withQuestions.QuestionIndex()
+	require.NotNil(t, index)
+	assert.Equal(t, []string{"reset#q0", "reset#q1"}, index.Questions("reset"))
+	assert.Empty(t, index.Questions("billing"))
+}
+
+func TestHypotheticalQuestionIndex_ReindexReplacesQuestions(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryVectorStore(zap.NewNop())
+	generator := &stubQuestionGenerator{questions: map[string][]string{
+		"reset": {"q1 password", "q2 password", "q3 password", "q4 password"},
+	}}
+	index := NewHypotheticalQuestionIndex(generator, conceptEmbeddingProvider{}, store, HypotheticalQuestionConfig{QuestionsPerChunk: 2}, zap.NewNop())
+
+	chunk := Document{ID: "reset", Content: "Reset your password"}
+	require.NoError(t, index.IndexChunks(ctx, []Document{chunk}))
+	require.NoError(t, index.IndexChunks(ctx, []Document{chunk}))
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	scores, err := index.SearchChunks(ctx, []float64{1, 0, 0}, 5)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, scores["reset"], 1e-9)
+
+	require.NoError(t, index.RemoveChunks(ctx, []string{"reset"}))
+	count, err = store.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestParseGeneratedQuestions(t *testing.T) {
+	response := "1. What is RAG?\n- What is RAG?\n\nQ2: How does HyDE work?\n* Why index questions?\nextra"
+	assert.Equal(t, []string{"What is RAG?", "How does HyDE work?", "Why index questions?"}, parseGeneratedQuestions(response, 3))
+}
+
+func TestLLMQuestionGenerator(t *testing.T) {
+	var prompt string
+	generator := NewLLMQuestionGenerator(func(_ context.Context, p string) (string, error) {
+		prompt = p
+		return "What is a chunk?\nWhy split documents?", nil
+	}, nil)
+	questions, err := generator.GenerateQuestions(context.Background(), Document{Content: "Documents are split into chunks."}, 2)
+	require.NoError(t, err)
+	assert.Len(t, questions, 2)
+	assert.Contains(t, prompt, "Generate 2 distinct questions")
+	assert.Contains(t, prompt, "Documents are split into chunks.")
+}
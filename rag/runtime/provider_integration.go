@@ -11,6 +11,7 @@ type EnhancedRetriever struct {
 	*HybridRetriever
 	embeddingProvider EmbeddingProvider
 	rerankProvider    RerankProvider
+	questionIndex     *HypotheticalQuestionIndex
 	logger            *zap.Logger
 }
 
@@ -19,6 +20,12 @@ type EnhancedRetrieverConfig struct {
 	HybridConfig      HybridRetrievalConfig
 	EmbeddingProvider EmbeddingProvider
 	RerankProvider    RerankProvider
+
+	// QuestionGenerator 启用假设问题索引：入库时为每个 chunk 生成问题并向量化（需要 EmbeddingProvider）
+	QuestionGenerator     HypotheticalQuestionGenerator
+	HypotheticalQuestions HypotheticalQuestionConfig
+	// QuestionStore 存放问题向量，为空时使用内存向量存储
+	QuestionStore VectorStore
 }
 
 // NewEnhancedRetriever 创建了外部提供者的检索器 。
@@ -26,12 +33,22 @@ func NewEnhancedRetriever(cfg EnhancedRetrieverConfig, logger *zap.Logger) *Enha
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &EnhancedRetriever{
+	r := &EnhancedRetriever{
 		HybridRetriever:   NewHybridRetriever(cfg.HybridConfig, logger),
 		embeddingProvider: cfg.EmbeddingProvider,
 		rerankProvider:    cfg.RerankProvider,
 		logger:            logger,
 	}
+	if cfg.QuestionGenerator != nil && cfg.EmbeddingProvider != nil {
+		r.questionIndex = NewHypotheticalQuestionIndex(cfg.QuestionGenerator, cfg.EmbeddingProvider, cfg.QuestionStore, cfg.HypotheticalQuestions, logger)
+		r.SetQuestionIndex(r.questionIndex)
+	}
+	return r
+}
+
+// QuestionIndex 返回假设问题索引，未启用时为 nil
+func (r *EnhancedRetriever) QuestionIndex() *HypotheticalQuestionIndex {
+	return r.questionIndex
 }
 
 // 索引文件 带有Embedding索引文档并生成嵌入.
@@ -63,7 +80,17 @@ func (r *EnhancedRetriever) IndexDocumentsWithEmbedding(ctx context.Context, doc
 		zap.Int("count", len(docs)),
 		zap.String("provider", r.embeddingProvider.Name()))
 
-	return r.IndexDocuments(docs)
+	if err := r.IndexDocuments(docs); err != nil {
+		return err
+	}
+
+	// 生成并索引假设问题
+	if r.questionIndex != nil {
+		if err := r.questionIndex.IndexChunks(ctx, docs); err != nil {
+			return fmt.Errorf("failed to index hypothetical questions: %w", err)
+		}
+	}
+	return nil
 }
 
 // ExecuteRetrievalPipeline runs the unified retrieval path for enhanced retriever:
@@ -0,0 +1,425 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/adapters/declarative"
+	agent "github.com/BaSui01/agentflow/agent/runtime"
+	"github.com/BaSui01/agentflow/config"
+	"github.com/BaSui01/agentflow/llm/cache"
+	llmcore "github.com/BaSui01/agentflow/llm/core"
+	llmgateway "github.com/BaSui01/agentflow/llm/gateway"
+	llmmw "github.com/BaSui01/agentflow/llm/middleware"
+	"github.com/BaSui01/agentflow/llm/providers/vendor"
+	llmcompose "github.com/BaSui01/agentflow/llm/runtime/compose"
+	"github.com/BaSui01/agentflow/rag/core"
+	ragruntime "github.com/BaSui01/agentflow/rag/runtime"
+	"github.com/BaSui01/agentflow/types"
+	"go.uber.org/zap"
+)
+
+// BootstrapOptions customizes config-driven assembly. Every field is optional;
+// the zero value builds everything from config.Config alone.
+type BootstrapOptions struct {
+	Logger *zap.Logger
+
+	// Provider replaces the vendor provider built from cfg.LLM (for example a
+	// channel-routed provider or a test double). Compose middleware derived from
+	// cfg (retry, cache, budget) is still applied.
+	Provider llmcore.Provider
+
+	// Agent overrides the agent build options derived from cfg.Agent.
+	Agent *AgentOptions
+
+	// AgentDefinitionPaths are appended to cfg.Agent.DefinitionPaths.
+	AgentDefinitionPaths []string
+
+	// SkipDefaultAgent disables registering the agent described by cfg.Agent.
+	SkipDefaultAgent bool
+}
+
+// Bootstrap assembles a ready Runtime entirely from config: the main provider
+// and compose middleware from cfg.LLM/Budget/Cache, the RAG pipeline from
+// cfg.RAG and the vector store sections, the workflow facade, and every agent
+// described by cfg.Agent and the declarative definition files. Guardrail and
+// memory settings are carried on each agent's runtime config.
+func Bootstrap(ctx context.Context, cfg *config.Config, opts BootstrapOptions) (*Runtime, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("sdk bootstrap requires config")
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	mainProvider := opts.Provider
+	if mainProvider == nil {
+		provider, err := vendor.NewChatProviderFromConfig(cfg.LLM.DefaultProvider, vendor.ChatProviderConfig{
+			APIKey:  cfg.LLM.APIKey,
+			BaseURL: cfg.LLM.BaseURL,
+			Model:   cfg.Agent.Model,
+			Timeout: cfg.LLM.Timeout,
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("build main provider %q: %w", cfg.LLM.DefaultProvider, err)
+		}
+		mainProvider = provider
+	}
+
+	definitions, err := loadAgentDefinitions(append(append([]string(nil), cfg.Agent.DefinitionPaths...), opts.AgentDefinitionPaths...), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	composeCfg := ComposeConfigFromApp(cfg)
+	sdkOpts := Options{
+		Logger: logger,
+		LLM: &LLMOptions{
+			Provider: mainProvider,
+			Compose:  &composeCfg,
+		},
+		Agent:    opts.Agent,
+		Workflow: &WorkflowOptions{Enable: true, EnableDSL: true},
+	}
+	if sdkOpts.Agent == nil {
+		sdkOpts.Agent = &AgentOptions{BuildOptions: agentBuildOptionsFromApp(cfg)}
+	}
+	if cfg.RAG.Enabled {
+		sdkOpts.RAG = &RAGOptions{
+			Enable:             true,
+			StoreConfig:        StoreConfigFromApp(cfg),
+			DefaultLLMProvider: cfg.LLM.DefaultProvider,
+			VectorStoreType:    core.VectorStoreType(strings.ToLower(strings.TrimSpace(cfg.RAG.VectorStore))),
+			EmbeddingType:      core.EmbeddingProviderType(strings.TrimSpace(cfg.RAG.EmbeddingProvider)),
+			RerankType:         core.RerankProviderType(strings.TrimSpace(cfg.RAG.RerankProvider)),
+			APIKey:             cfg.LLM.APIKey,
+		}
+	}
+
+	rt, err := New(sdkOpts).Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	agentConfigs := make([]types.AgentConfig, 0, len(definitions)+1)
+	if !opts.SkipDefaultAgent {
+		agentConfigs = append(agentConfigs, cfg.Agent.ToRuntimeConfig())
+	}
+	agentConfigs = append(agentConfigs, definitions...)
+	for _, agentCfg := range agentConfigs {
+		if _, err := rt.RegisterAgent(ctx, agentCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("sdk runtime bootstrapped from config",
+		zap.String("provider", mainProvider.Name()),
+		zap.Strings("agents", rt.AgentIDs()),
+		zap.Bool("rag", rt.RAG != nil))
+	return rt, nil
+}
+
+// RegisterAgent builds an agent and registers it under its config ID.
+func (r *Runtime) RegisterAgent(ctx context.Context, cfg types.AgentConfig) (*agent.BaseAgent, error) {
+	id := strings.TrimSpace(cfg.Core.ID)
+	if id == "" {
+		return nil, fmt.Errorf("register agent %q: id is required", cfg.Core.Name)
+	}
+
+	r.agentsMu.Lock()
+	defer r.agentsMu.Unlock()
+	if _, exists := r.agents[id]; exists {
+		return nil, fmt.Errorf("register agent: duplicate agent id %q", id)
+	}
+	ag, err := r.NewAgent(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("register agent %q: %w", id, err)
+	}
+	if r.agents == nil {
+		r.agents = make(map[string]*agent.BaseAgent)
+	}
+	r.agents[id] = ag
+	return ag, nil
+}
+
+// Agent returns a registered agent by ID.
+func (r *Runtime) Agent(id string) (*agent.BaseAgent, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.agentsMu.RLock()
+	defer r.agentsMu.RUnlock()
+	ag, ok := r.agents[id]
+	return ag, ok
+}
+
+// AgentIDs returns the sorted IDs of registered agents.
+func (r *Runtime) AgentIDs() []string {
+	if r == nil {
+		return nil
+	}
+	r.agentsMu.RLock()
+	defer r.agentsMu.RUnlock()
+	ids := make([]string, 0, len(r.agents))
+	for id := range r.agents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// agentBuildOptionsFromApp enables only the agent subsystems the config asks
+// for, instead of runtime.DefaultBuildOptions' "everything on".
+func agentBuildOptionsFromApp(cfg *config.Config) agent.BuildOptions {
+	return agent.BuildOptions{
+		EnableEnhancedMemory: cfg.Agent.Memory.Enabled,
+		MaxReActIterations:   cfg.Agent.MaxIterations,
+	}
+}
+
+// loadAgentDefinitions loads declarative agent files. Directory entries are
+// scanned (non-recursively) for .yaml/.yml/.json files.
+func loadAgentDefinitions(paths []string, logger *zap.Logger) ([]types.AgentConfig, error) {
+	loader := declarative.NewYAMLLoader()
+	factory := declarative.NewAgentFactory(logger)
+
+	var files []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("agent definition path %q: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read agent definition dir %q: %w", path, err)
+		}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	configs := make([]types.AgentConfig, 0, len(files))
+	for _, file := range files {
+		def, err := loader.LoadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("load agent definition %q: %w", file, err)
+		}
+		if err := factory.Validate(def); err != nil {
+			return nil, fmt.Errorf("agent definition %q: %w", file, err)
+		}
+		if strings.TrimSpace(def.ID) == "" {
+			def.ID = def.Name
+		}
+		configs = append(configs, factory.ToAgentConfig(def))
+	}
+	return configs, nil
+}
+
+// ComposeConfigFromApp maps config.Config onto the llm/runtime/compose
+// middleware configuration (retry, budget, cache, tool provider, capabilities).
+func ComposeConfigFromApp(cfg *config.Config) llmcompose.Config {
+	return llmcompose.Config{
+		Timeout:    cfg.LLM.Timeout,
+		MaxRetries: cfg.LLM.MaxRetries,
+		Budget: llmcompose.BudgetConfig{
+			Enabled:             cfg.Budget.Enabled,
+			MaxTokensPerRequest: cfg.Budget.MaxTokensPerRequest,
+			MaxTokensPerMinute:  cfg.Budget.MaxTokensPerMinute,
+			MaxTokensPerHour:    cfg.Budget.MaxTokensPerHour,
+			MaxTokensPerDay:     cfg.Budget.MaxTokensPerDay,
+			MaxCostPerRequest:   cfg.Budget.MaxCostPerRequest,
+			MaxCostPerDay:       cfg.Budget.MaxCostPerDay,
+			MaxTokensPerMonth:   cfg.Budget.MaxTokensPerMonth,
+			MaxCostPerMonth:     cfg.Budget.MaxCostPerMonth,
+			MaxTokensPerQuarter: cfg.Budget.MaxTokensPerQuarter,
+			MaxCostPerQuarter:   cfg.Budget.MaxCostPerQuarter,
+			Timezone:            cfg.Budget.Timezone,
+			AlertThreshold:      cfg.Budget.AlertThreshold,
+			AutoThrottle:        cfg.Budget.AutoThrottle,
+			ThrottleDelay:       cfg.Budget.ThrottleDelay,
+		},
+		Cache: llmcompose.CacheConfig{
+			Enabled:      cfg.Cache.Enabled,
+			LocalMaxSize: cfg.Cache.LocalMaxSize,
+			LocalTTL:     cfg.Cache.LocalTTL,
+			EnableRedis:  cfg.Cache.EnableRedis,
+			RedisTTL:     cfg.Cache.RedisTTL,
+			KeyStrategy:  cfg.Cache.KeyStrategy,
+
+			IsolateByUser:  cfg.Cache.IsolateByUser,
+			TenantPolicies: tenantCachePoliciesFromApp(cfg.Cache.TenantPolicies),
+		},
+		Tool: llmcompose.ToolProviderConfig{
+			Provider:        cfg.LLM.ToolProvider,
+			DefaultProvider: cfg.LLM.DefaultProvider,
+			APIKey:          cfg.LLM.ToolAPIKey,
+			DefaultAPIKey:   cfg.LLM.APIKey,
+			BaseURL:         cfg.LLM.ToolBaseURL,
+			DefaultBaseURL:  cfg.LLM.BaseURL,
+			Timeout:         cfg.LLM.ToolTimeout,
+			MaxRetries:      cfg.LLM.ToolMaxRetries,
+		},
+		Capabilities: CapabilityRegistryFromApp(cfg.LLM.CapabilityOverrides),
+		// Redis 客户端由调用方按 cfg.LLM.DistributedRateLimit.Enabled 创建并填入 RateLimit.Client。
+		RateLimit: llmcompose.RateLimitConfig{
+			Limiter: DistributedRateLimitFromApp(cfg.LLM.DistributedRateLimit),
+		},
+		Priority: llmcompose.PriorityConfig{
+			Enabled:   cfg.LLM.PriorityScheduler.Enabled,
+			Scheduler: PrioritySchedulerFromApp(cfg.LLM.PriorityScheduler),
+		},
+	}
+}
+
+// PrioritySchedulerFromApp maps llm.priority_scheduler onto the gateway
+// priority scheduler configuration. Unknown class names are ignored (config
+// validation rejects them).
+func PrioritySchedulerFromApp(cfg config.PrioritySchedulerConfig) llmgateway.PrioritySchedulerConfig {
+	schedulerCfg := llmgateway.PrioritySchedulerConfig{MaxConcurrent: cfg.MaxConcurrent}
+	if class, ok := llmgateway.ParsePriorityClass(cfg.DefaultClass); ok {
+		schedulerCfg.DefaultClass = class
+	}
+	if len(cfg.Classes) > 0 {
+		schedulerCfg.Classes = make(map[llmgateway.PriorityClass]llmgateway.PriorityClassConfig, len(cfg.Classes))
+		for name, classCfg := range cfg.Classes {
+			class, ok := llmgateway.ParsePriorityClass(name)
+			if !ok {
+				continue
+			}
+			schedulerCfg.Classes[class] = llmgateway.PriorityClassConfig{
+				MaxConcurrent:      classCfg.MaxConcurrent,
+				MaxQueue:           classCfg.MaxQueue,
+				MaxWait:            classCfg.MaxWait,
+				DelayAbovePressure: classCfg.DelayAbovePressure,
+				ShedAbovePressure:  classCfg.ShedAbovePressure,
+			}
+		}
+	}
+	return schedulerCfg
+}
+
+// DistributedRateLimitFromApp maps llm.distributed_rate_limit onto the
+// Redis rate limiter configuration.
+func DistributedRateLimitFromApp(cfg config.DistributedRateLimitConfig) llmmw.DistributedRateLimitConfig {
+	limiterCfg := llmmw.DistributedRateLimitConfig{
+		Prefix: cfg.Prefix,
+		DryRun: cfg.DryRun,
+		DefaultQuota: llmmw.RateLimitQuota{
+			RequestsPerSecond: cfg.RequestsPerSecond,
+			TokensPerMinute:   cfg.TokensPerMinute,
+		},
+	}
+	if len(cfg.Quotas) > 0 {
+		limiterCfg.Quotas = make(map[string]llmmw.RateLimitQuota, len(cfg.Quotas))
+		for key, quota := range cfg.Quotas {
+			limiterCfg.Quotas[key] = llmmw.RateLimitQuota{
+				RequestsPerSecond: quota.RequestsPerSecond,
+				TokensPerMinute:   quota.TokensPerMinute,
+			}
+		}
+	}
+	return limiterCfg
+}
+
+// CapabilityRegistryFromApp builds a capability registry from config overrides
+// keyed by provider or provider/model.
+func CapabilityRegistryFromApp(overrides map[string]config.CapabilityOverrideConfig) *llmcore.CapabilityRegistry {
+	registry := llmcore.NewCapabilityRegistry()
+	for key, override := range overrides {
+		desc := llmcore.CapabilityDescriptor{
+			MaxContextTokens:           override.MaxContextTokens,
+			MaxOutputTokens:            override.MaxOutputTokens,
+			SupportsVision:             override.SupportsVision,
+			SupportsTools:              override.SupportsTools,
+			SupportsJSONMode:           override.SupportsJSONMode,
+			SupportsStreamingToolCalls: override.SupportsStreamingToolCalls,
+			Modalities:                 append([]string(nil), override.Modalities...),
+		}
+		if provider, model, ok := strings.Cut(key, "/"); ok {
+			registry.RegisterModel(provider, model, desc)
+			continue
+		}
+		registry.Register(key, desc)
+	}
+	return registry
+}
+
+func tenantCachePoliciesFromApp(in map[string]config.TenantCachePolicyConfig) map[string]cache.TenantCachePolicy {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]cache.TenantCachePolicy, len(in))
+	for tenantID, policy := range in {
+		out[tenantID] = cache.TenantCachePolicy{Disabled: policy.Disabled, TTL: policy.TTL}
+	}
+	return out
+}
+
+// StoreConfigFromApp maps the vector store sections of config.Config onto the
+// self-contained rag/runtime StoreConfig, so the RAG layer never imports config.
+func StoreConfigFromApp(cfg *config.Config) *ragruntime.StoreConfig {
+	if cfg == nil {
+		return nil
+	}
+	return &ragruntime.StoreConfig{
+		Qdrant: ragruntime.QdrantStoreConfig{
+			Host:                 cfg.Qdrant.Host,
+			Port:                 cfg.Qdrant.Port,
+			APIKey:               cfg.Qdrant.APIKey,
+			Collection:           cfg.Qdrant.Collection,
+			AutoCreateCollection: true,
+		},
+		Weaviate: ragruntime.WeaviateStoreConfig{
+			Host:             cfg.Weaviate.Host,
+			Port:             cfg.Weaviate.Port,
+			Scheme:           cfg.Weaviate.Scheme,
+			APIKey:           cfg.Weaviate.APIKey,
+			ClassName:        cfg.Weaviate.ClassName,
+			AutoCreateSchema: cfg.Weaviate.AutoCreateSchema,
+			Distance:         cfg.Weaviate.Distance,
+			HybridAlpha:      cfg.Weaviate.HybridAlpha,
+			Timeout:          cfg.Weaviate.Timeout,
+		},
+		Milvus: ragruntime.MilvusStoreConfig{
+			Host:                 cfg.Milvus.Host,
+			Port:                 cfg.Milvus.Port,
+			Username:             cfg.Milvus.Username,
+			Password:             cfg.Milvus.Password,
+			Token:                cfg.Milvus.Token,
+			Database:             cfg.Milvus.Database,
+			Collection:           cfg.Milvus.Collection,
+			VectorDimension:      cfg.Milvus.VectorDimension,
+			IndexType:            ragruntime.MilvusIndexType(cfg.Milvus.IndexType),
+			MetricType:           ragruntime.MilvusMetricType(cfg.Milvus.MetricType),
+			AutoCreateCollection: cfg.Milvus.AutoCreateCollection,
+			Timeout:              cfg.Milvus.Timeout,
+			BatchSize:            cfg.Milvus.BatchSize,
+			ConsistencyLevel:     cfg.Milvus.ConsistencyLevel,
+		},
+		Pinecone: ragruntime.PineconeStoreConfig{
+			APIKey:    cfg.Pinecone.APIKey,
+			Index:     cfg.Pinecone.Index,
+			BaseURL:   cfg.Pinecone.BaseURL,
+			Namespace: cfg.Pinecone.Namespace,
+			Timeout:   cfg.Pinecone.Timeout,
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package sdk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BaSui01/agentflow/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAgentDefinition(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func TestBootstrap_FromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeAgentDefinition(t, dir, "researcher.yaml", `
+id: researcher
+name: Researcher
+model: gpt-4o-mini
+system_prompt: You research things.
+guardrails:
+  on_input_failure: reject
+`)
+	writeAgentDefinition(t, dir, "notes.txt", "ignored")
+
+	cfg := config.DefaultConfig()
+	cfg.Agent.Guardrails = config.AgentGuardrailsConfig{
+		Enabled:         true,
+		BlockedKeywords: []string{"forbidden"},
+		OnInputFailure:  "reject",
+	}
+	cfg.Agent.DefinitionPaths = []string{dir}
+	cfg.RAG.Enabled = true
+	cfg.RAG.VectorStore = "memory"
+
+	rt, err := Bootstrap(context.Background(), cfg, BootstrapOptions{Provider: mockProvider{name: "mock"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"default-agent", "researcher"}, rt.AgentIDs())
+	assert.NotNil(t, rt.Gateway)
+	assert.NotNil(t, rt.Workflow)
+	require.NotNil(t, rt.RAG)
+	assert.NotNil(t, rt.RAG.Store)
+
+	defaultAgent, ok := rt.Agent("default-agent")
+	require.True(t, ok)
+	agentCfg := defaultAgent.Config()
+	assert.True(t, agentCfg.IsGuardrailsEnabled())
+	assert.Equal(t, []string{"forbidden"}, agentCfg.ExecutionOptions().Control.Guardrails.BlockedKeywords)
+
+	researcher, ok := rt.Agent("researcher")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o-mini", researcher.Config().ExecutionOptions().Model.Model)
+}
+
+func TestBootstrap_Errors(t *testing.T) {
+	_, err := Bootstrap(context.Background(), nil, BootstrapOptions{})
+	require.Error(t, err)
+
+	cfg := config.DefaultConfig()
+	cfg.Agent.DefinitionPaths = []string{filepath.Join(t.TempDir(), "missing.yaml")}
+	_, err = Bootstrap(context.Background(), cfg, BootstrapOptions{Provider: mockProvider{name: "mock"}})
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	writeAgentDefinition(t, dir, "dup.yaml", "id: default-agent\nname: Dup\nmodel: gpt-4\n")
+	cfg.Agent.DefinitionPaths = []string{dir}
+	_, err = Bootstrap(context.Background(), cfg, BootstrapOptions{Provider: mockProvider{name: "mock"}})
+	require.ErrorContains(t, err, "duplicate agent id")
+
+	rt, err := Bootstrap(context.Background(), cfg, BootstrapOptions{Provider: mockProvider{name: "mock"}, SkipDefaultAgent: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default-agent"}, rt.AgentIDs())
+}
@@ -64,6 +64,10 @@ type LLMRouterOptions struct {
 	// RetryPolicy is optional and overrides the router's default retry behavior.
 	RetryPolicy llmrouter.ChannelRouteRetryPolicy
 
+	// DataResidency optionally restricts each tenant's prompts to compliant
+	// regions/providers; requests with no compliant route are rejected.
+	DataResidency *llmrouter.DataResidencyOptions
+
 	// Logger overrides the build logger (optional).
 	Logger *zap.Logger
 }
@@ -104,7 +108,7 @@ type RAGOptions struct {
 
 	// StoreConfig optionally provides vector store backend configuration.
 	// May be nil; in that case the runtime defaults to in-memory store unless overridden.
-	// Use ragruntime.StoreConfig to construct, or bootstrap.StoreConfigFromApp to map from config.Config.
+	// Use ragruntime.StoreConfig to construct, or StoreConfigFromApp to map from config.Config.
 	StoreConfig *rag.StoreConfig
 
 	// DefaultLLMProvider optionally specifies the default LLM provider name
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/BaSui01/agentflow/agent/runtime"
 	agent "github.com/BaSui01/agentflow/agent/runtime"
@@ -37,6 +38,9 @@ type Runtime struct {
 
 	agentBuilder *runtime.Builder
 
+	agentsMu sync.RWMutex
+	agents   map[string]*agent.BaseAgent
+
 	Workflow *WorkflowRuntime
 	RAG      *RAGRuntime
 }
@@ -381,6 +385,7 @@ func buildSDKProviders(ctx context.Context, opts Options, logger *zap.Logger) (l
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("compose channel routed provider config: %w", err)
 	}
+	routedCfg.DataResidency = rOpts.DataResidency
 
 	provider, err := llmrouter.BuildChannelRoutedProvider(routedCfg)
 	if err != nil {
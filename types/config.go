@@ -224,13 +224,40 @@ func DefaultPromptEnhancerConfig() *PromptEnhancerConfig {
 
 func (c *PromptEnhancerConfig) IsEnabled() bool { return c != nil && c.Enabled }
 
+// Budget exceed actions.
+const (
+	BudgetActionAbort     = "abort"
+	BudgetActionDowngrade = "downgrade"
+)
+
+// BudgetConfig caps token and cost consumption per run and per session.
+// Zero limits are unlimited. Session usage is keyed by the input channel ID.
+type BudgetConfig struct {
+	MaxRunTokens     int     `json:"max_run_tokens,omitempty"`
+	MaxRunCost       float64 `json:"max_run_cost,omitempty"`
+	MaxSessionTokens int     `json:"max_session_tokens,omitempty"`
+	MaxSessionCost   float64 `json:"max_session_cost,omitempty"`
+	// OnExceeded is "abort" (default) or "downgrade". Downgrade switches the
+	// remaining LLM calls to DowngradeModel and falls back to abort when unset.
+	OnExceeded     string `json:"on_exceeded,omitempty"`
+	DowngradeModel string `json:"downgrade_model,omitempty"`
+}
+
+// IsEnabled reports whether any budget limit is configured.
+func (c *BudgetConfig) IsEnabled() bool {
+	return c != nil && (c.MaxRunTokens > 0 || c.MaxRunCost > 0 || c.MaxSessionTokens > 0 || c.MaxSessionCost > 0)
+}
+
 // GuardrailsConfig configures input/output validation.
 type GuardrailsConfig struct {
 	Enabled            bool     `json:"enabled"`
 	MaxInputLength     int      `json:"max_input_length,omitempty"`
 	BlockedKeywords    []string `json:"blocked_keywords,omitempty"`
 	PIIDetection       bool     `json:"pii_detection,omitempty"`
+	PIILocales         []string `json:"pii_locales,omitempty"`
 	InjectionDetection bool     `json:"injection_detection,omitempty"`
+	SecretsDetection   bool     `json:"secrets_detection,omitempty"`
+	PolicyFile         string   `json:"policy_file,omitempty"`
 	MaxRetries         int      `json:"max_retries,omitempty"`
 	OnInputFailure     string   `json:"on_input_failure,omitempty"`
 	OnOutputFailure    string   `json:"on_output_failure,omitempty"`
@@ -243,6 +270,7 @@ func DefaultGuardrailsConfig() *GuardrailsConfig {
 		MaxInputLength:     10000,
 		PIIDetection:       true,
 		InjectionDetection: true,
+		SecretsDetection:   true,
 		MaxRetries:         2,
 	}
 }
@@ -18,10 +18,13 @@ const (
 	keyLLMRoutePolicy      contextKey = "llm_route_policy"
 	keyPromptBundleVersion contextKey = "prompt_bundle_version"
 	keyRoles               contextKey = "roles"
+	keyScopes              contextKey = "scopes"
 	keyApprovalPolicy      contextKey = "approval_policy"
 	keySandboxMode         contextKey = "sandbox_mode"
 	keyMemoryExternalMode  contextKey = "memory_external_context_policy"
 	keySubagentDepth       contextKey = "subagent_depth"
+	keyRequestPriority     contextKey = "request_priority"
+	keyToolName            contextKey = "tool_name"
 )
 
 // WithTraceID adds trace ID to context.
@@ -161,6 +164,32 @@ func Roles(ctx context.Context) ([]string, bool) {
 	return append([]string(nil), v...), true
 }
 
+// WithScopes adds the scopes granted to the caller's credential to context.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	copied := append([]string(nil), scopes...)
+	return context.WithValue(ctx, keyScopes, copied)
+}
+
+// Scopes extracts the caller's granted scopes from context.
+func Scopes(ctx context.Context) ([]string, bool) {
+	v, ok := ctx.Value(keyScopes).([]string)
+	if !ok || len(v) == 0 {
+		return nil, false
+	}
+	return append([]string(nil), v...), true
+}
+
+// WithToolName adds the name of the tool being executed to context.
+func WithToolName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, keyToolName, name)
+}
+
+// ToolName extracts the name of the tool being executed from context.
+func ToolName(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(keyToolName).(string)
+	return v, ok && v != ""
+}
+
 // WithApprovalPolicy adds the normalized approval policy to context.
 func WithApprovalPolicy(ctx context.Context, policy string) context.Context {
 	return context.WithValue(ctx, keyApprovalPolicy, policy)
@@ -204,3 +233,14 @@ func SubagentDepth(ctx context.Context) (int, bool) {
 	v, ok := ctx.Value(keySubagentDepth).(int)
 	return v, ok
 }
+
+// WithRequestPriority adds the request priority class (e.g. interactive, background, batch) to context.
+func WithRequestPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, keyRequestPriority, priority)
+}
+
+// RequestPriority extracts the request priority class from context.
+func RequestPriority(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(keyRequestPriority).(string)
+	return v, ok && v != ""
+}
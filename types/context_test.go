@@ -54,6 +54,16 @@ func TestContextHelpers(t *testing.T) {
 	if got, ok := SubagentDepth(ctx); !ok || got != 2 {
 		t.Fatalf("SubagentDepth mismatch: %v %v", got, ok)
 	}
+
+	ctx = WithRequestPriority(ctx, "background")
+	if got, ok := RequestPriority(ctx); !ok || got != "background" {
+		t.Fatalf("RequestPriority mismatch: %v %v", got, ok)
+	}
+
+	ctx = WithToolName(ctx, "shell_exec")
+	if got, ok := ToolName(ctx); !ok || got != "shell_exec" {
+		t.Fatalf("ToolName mismatch: %v %v", got, ok)
+	}
 }
 
 func TestWithRolesCopiesInputSlice(t *testing.T) {
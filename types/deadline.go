@@ -0,0 +1,74 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TruncationReason explains why a result is partial rather than complete.
+type TruncationReason string
+
+const (
+	// TruncatedDeadline marks a result that was cut short because the
+	// deadline budget ran out before the producer finished.
+	TruncatedDeadline TruncationReason = "deadline"
+)
+
+// FinishReasonDeadline is the finish reason of the final chunk of a stream
+// that was truncated by the deadline budget.
+const FinishReasonDeadline = "deadline"
+
+// minReservedShare is the fraction of the remaining budget a callee always
+// receives, even when the caller's reservation would leave nothing.
+const minReservedShare = 0.5
+
+// WithDeadlineBudget starts a deadline budget of total for a request. An
+// earlier deadline already on ctx is kept. Each layer below derives its own
+// budget with ReserveBudget so that no single step can consume all of it.
+func WithDeadlineBudget(ctx context.Context, total time.Duration) (context.Context, context.CancelFunc) {
+	if total <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, total)
+}
+
+// RemainingBudget returns the time left before ctx's deadline. It reports
+// false when ctx has no deadline.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// ReserveBudget returns a child context whose deadline leaves reserve of the
+// remaining budget to the caller, e.g. time for the router to fall back or
+// for the model to answer after a slow tool. The child always receives at
+// least half of the remaining time. Without a deadline on ctx the child is
+// only cancellable.
+func ReserveBudget(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	remaining, ok := RemainingBudget(ctx)
+	if !ok || reserve <= 0 {
+		return context.WithCancel(ctx)
+	}
+	share := remaining - reserve
+	if floor := time.Duration(float64(remaining) * minReservedShare); share < floor {
+		share = floor
+	}
+	return context.WithTimeout(ctx, share)
+}
+
+// DeadlineExceeded reports whether err, or ctx itself, indicates that the
+// deadline budget ran out. Plain cancellation is not a deadline.
+func DeadlineExceeded(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var typed *Error
+	if errors.As(err, &typed) && typed.Code == ErrTimeout {
+		return true
+	}
+	return ctx != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveBudget(t *testing.T) {
+	ctx, cancel := WithDeadlineBudget(context.Background(), time.Second)
+	defer cancel()
+
+	child, childCancel := ReserveBudget(ctx, 200*time.Millisecond)
+	defer childCancel()
+	remaining, ok := RemainingBudget(child)
+	require.True(t, ok)
+	assert.InDelta(t, float64(800*time.Millisecond), float64(remaining), float64(50*time.Millisecond))
+
+	// 预留超过剩余时间时，下游至少获得一半
+	greedy, greedyCancel := ReserveBudget(ctx, 5*time.Second)
+	defer greedyCancel()
+	remaining, ok = RemainingBudget(greedy)
+	require.True(t, ok)
+	assert.InDelta(t, float64(500*time.Millisecond), float64(remaining), float64(50*time.Millisecond))
+
+	unbounded, unboundedCancel := ReserveBudget(context.Background(), time.Second)
+	defer unboundedCancel()
+	_, ok = RemainingBudget(unbounded)
+	assert.False(t, ok)
+}
+
+func TestDeadlineExceeded(t *testing.T) {
+	assert.True(t, DeadlineExceeded(nil, context.DeadlineExceeded))
+	assert.True(t, DeadlineExceeded(context.Background(), NewError(ErrTimeout, "slow")))
+	assert.False(t, DeadlineExceeded(context.Background(), errors.New("boom")))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, DeadlineExceeded(canceled, context.Canceled))
+
+	expired, expiredCancel := context.WithTimeout(context.Background(), -time.Second)
+	defer expiredCancel()
+	assert.True(t, DeadlineExceeded(expired, nil))
+}
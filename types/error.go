@@ -31,6 +31,10 @@ const (
 	ErrInternalError       ErrorCode = "INTERNAL_ERROR"
 	ErrServiceUnavailable  ErrorCode = "SERVICE_UNAVAILABLE"
 	ErrProviderUnavailable ErrorCode = "PROVIDER_UNAVAILABLE"
+	// ErrCapabilityUnsupported 请求使用了目标 provider/模型不支持的能力（视觉、工具、流式工具调用等）
+	ErrCapabilityUnsupported ErrorCode = "CAPABILITY_UNSUPPORTED"
+	// ErrDataResidency 租户的数据驻留策略下没有合规的 provider/区域可用
+	ErrDataResidency ErrorCode = "DATA_RESIDENCY_VIOLATION"
 )
 
 // Agent error codes
@@ -105,6 +109,20 @@ type ErrorContext struct {
 	RunID     string `json:"run_id,omitempty"`
 }
 
+// Remediation tells callers what to do about a failed provider call.
+type Remediation string
+
+const (
+	RemediationCheckCredentials Remediation = "check_credentials"
+	RemediationAddCredits       Remediation = "add_credits"
+	RemediationBackoff          Remediation = "backoff"
+	RemediationRetry            Remediation = "retry"
+	RemediationReduceInput      Remediation = "reduce_input"
+	RemediationReviseContent    Remediation = "revise_content"
+	RemediationSwitchModel      Remediation = "switch_model"
+	RemediationFixRequest       Remediation = "fix_request"
+)
+
 // Error represents a structured error with code, message, and metadata.
 type Error struct {
 	Code       ErrorCode    `json:"code"`
@@ -114,6 +132,11 @@ type Error struct {
 	Provider   string       `json:"provider,omitempty"`
 	Cause      error        `json:"-"`
 	Context    ErrorContext `json:"context,omitempty"`
+	// ProviderCode is the provider's own error type/code/status, e.g.
+	// "insufficient_quota", "overloaded_error" or "RESOURCE_EXHAUSTED".
+	ProviderCode string `json:"provider_code,omitempty"`
+	// Remediation is the suggested reaction to this error.
+	Remediation Remediation `json:"remediation,omitempty"`
 }
 
 // Error implements the error interface.
@@ -158,6 +181,12 @@ func (e *Error) WithProvider(provider string) *Error {
 	return e
 }
 
+// WithRemediation sets the suggested remediation.
+func (e *Error) WithRemediation(remediation Remediation) *Error {
+	e.Remediation = remediation
+	return e
+}
+
 // WithContext sets the error context for cross-layer tracing.
 func (e *Error) WithContext(ec ErrorContext) *Error {
 	e.Context = ec
@@ -124,6 +124,9 @@ type AgentControlOptions struct {
 	ApprovalPolicy        string                       `json:"approval_policy,omitempty"`
 	SandboxMode           string                       `json:"sandbox_mode,omitempty"`
 	DisablePlanner        bool                         `json:"disable_planner,omitempty"`
+	Autonomy              string                       `json:"autonomy,omitempty"`
+	MaxTotalTokens        int                          `json:"max_total_tokens,omitempty"`
+	MaxWallClock          int                          `json:"max_wall_clock,omitempty"` // seconds
 	Context               *ContextConfig               `json:"context,omitempty"`
 	Reflection            *ReflectionConfig            `json:"reflection,omitempty"`
 	Guardrails            *GuardrailsConfig            `json:"guardrails,omitempty"`
@@ -131,6 +134,7 @@ type AgentControlOptions struct {
 	MemoryExternalContext *MemoryExternalContextPolicy `json:"memory_external_context,omitempty"`
 	ToolSelection         *ToolSelectionConfig         `json:"tool_selection,omitempty"`
 	PromptEnhancer        *PromptEnhancerConfig        `json:"prompt_enhancer,omitempty"`
+	Budget                *BudgetConfig                `json:"budget,omitempty"`
 }
 
 // ToolProtocolOptions contains tool exposure and invocation controls.
@@ -310,6 +314,9 @@ func (o AgentControlOptions) clone() AgentControlOptions {
 		ApprovalPolicy:        o.ApprovalPolicy,
 		SandboxMode:           o.SandboxMode,
 		DisablePlanner:        o.DisablePlanner,
+		Autonomy:              o.Autonomy,
+		MaxTotalTokens:        o.MaxTotalTokens,
+		MaxWallClock:          o.MaxWallClock,
 		Context:               cloneContextConfig(o.Context),
 		Reflection:            cloneReflectionConfig(o.Reflection),
 		Guardrails:            cloneGuardrailsConfig(o.Guardrails),
@@ -317,6 +324,7 @@ func (o AgentControlOptions) clone() AgentControlOptions {
 		MemoryExternalContext: cloneMemoryExternalContextPolicy(o.MemoryExternalContext),
 		ToolSelection:         cloneToolSelectionConfig(o.ToolSelection),
 		PromptEnhancer:        clonePromptEnhancerConfig(o.PromptEnhancer),
+		Budget:                cloneBudgetConfig(o.Budget),
 	}
 }
 
@@ -547,6 +555,15 @@ func mergeAgentControlOptions(base AgentControlOptions, override AgentControlOpt
 	if override.DisablePlanner {
 		out.DisablePlanner = true
 	}
+	if strings.TrimSpace(override.Autonomy) != "" {
+		out.Autonomy = strings.TrimSpace(override.Autonomy)
+	}
+	if override.MaxTotalTokens > 0 {
+		out.MaxTotalTokens = override.MaxTotalTokens
+	}
+	if override.MaxWallClock > 0 {
+		out.MaxWallClock = override.MaxWallClock
+	}
 	if override.Context != nil {
 		out.Context = cloneContextConfig(override.Context)
 	}
@@ -568,6 +585,9 @@ func mergeAgentControlOptions(base AgentControlOptions, override AgentControlOpt
 	if override.PromptEnhancer != nil {
 		out.PromptEnhancer = clonePromptEnhancerConfig(override.PromptEnhancer)
 	}
+	if override.Budget != nil {
+		out.Budget = cloneBudgetConfig(override.Budget)
+	}
 	return out
 }
 
@@ -642,6 +662,14 @@ func clonePromptEnhancerConfig(value *PromptEnhancerConfig) *PromptEnhancerConfi
 	return &cloned
 }
 
+func cloneBudgetConfig(value *BudgetConfig) *BudgetConfig {
+	if value == nil {
+		return nil
+	}
+	cloned := *value
+	return &cloned
+}
+
 func cloneSafetySettings(values []SafetySetting) []SafetySetting {
 	if len(values) == 0 {
 		return nil
@@ -2,6 +2,8 @@ package types
 
 import (
 	"context"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -74,10 +76,11 @@ type ChatRequest struct {
 	Modalities          []string `json:"modalities,omitempty"`
 
 	// 缓存控制
-	PromptCacheKey       string        `json:"prompt_cache_key,omitempty"`
-	PromptCacheRetention string        `json:"prompt_cache_retention,omitempty"`
-	CacheControl         *CacheControl `json:"cache_control,omitempty"`
-	CachedContent        string        `json:"cached_content,omitempty"`
+	PromptCacheKey       string           `json:"prompt_cache_key,omitempty"`
+	PromptCacheRetention string           `json:"prompt_cache_retention,omitempty"`
+	CacheControl         *CacheControl    `json:"cache_control,omitempty"`
+	CachedContent        string           `json:"cached_content,omitempty"`
+	PromptCache          *PromptCacheHint `json:"prompt_cache,omitempty"`
 
 	// Gemini 扩展
 	IncludeServerSideToolInvocations *bool `json:"include_server_side_tool_invocations,omitempty"`
@@ -123,9 +126,66 @@ type ChatResponse struct {
 
 // ChatChoice 表示响应中的单个选项。
 type ChatChoice struct {
-	Index        int     `json:"index"`
-	FinishReason string  `json:"finish_reason,omitempty"`
-	Message      Message `json:"message"`
+	Index        int             `json:"index"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+	Message      Message         `json:"message"`
+	LogProbs     *ChoiceLogProbs `json:"logprobs,omitempty"`
+}
+
+// ChoiceLogProbs 表示单个选项的 token 级对数概率。
+type ChoiceLogProbs struct {
+	Content []TokenLogProb `json:"content,omitempty"`
+	Refusal []TokenLogProb `json:"refusal,omitempty"`
+}
+
+// TokenLogProb 表示一个输出 token 的对数概率及其 top-k 候选。
+type TokenLogProb struct {
+	Token       string       `json:"token"`
+	LogProb     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogProbs []TopLogProb `json:"top_logprobs,omitempty"`
+}
+
+// TopLogProb 表示某个位置上的一个候选 token。
+type TopLogProb struct {
+	Token   string  `json:"token"`
+	LogProb float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// MeanLogProb 返回内容 token 的平均对数概率；无数据时 ok 为 false。
+func (l *ChoiceLogProbs) MeanLogProb() (float64, bool) {
+	if l == nil || len(l.Content) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, t := range l.Content {
+		sum += t.LogProb
+	}
+	return sum / float64(len(l.Content)), true
+}
+
+// Confidence 返回内容 token 的几何平均概率（0-1），可作为整体置信度信号。
+func (l *ChoiceLogProbs) Confidence() (float64, bool) {
+	mean, ok := l.MeanLogProb()
+	if !ok {
+		return 0, false
+	}
+	return math.Exp(mean), true
+}
+
+// MinLogProb 返回置信度最低的内容 token；无数据时 ok 为 false。
+func (l *ChoiceLogProbs) MinLogProb() (TokenLogProb, bool) {
+	if l == nil || len(l.Content) == 0 {
+		return TokenLogProb{}, false
+	}
+	lowest := l.Content[0]
+	for _, t := range l.Content[1:] {
+		if t.LogProb < lowest.LogProb {
+			lowest = t
+		}
+	}
+	return lowest, true
 }
 
 // ChatUsage 表示响应中的 token 用量。
@@ -145,6 +205,7 @@ type PromptTokensDetails struct {
 }
 
 // CompletionTokensDetails 补全 token 详细统计。
+// ReasoningTokens 统一按 OpenAI 语义计入 CompletionTokens（provider 适配层负责归一化）。
 type CompletionTokensDetails struct {
 	ReasoningTokens          int `json:"reasoning_tokens"`
 	AudioTokens              int `json:"audio_tokens,omitempty"`
@@ -154,14 +215,21 @@ type CompletionTokensDetails struct {
 
 // StreamChunk 表示流式响应块。
 type StreamChunk struct {
-	ID           string     `json:"id,omitempty"`
-	Provider     string     `json:"provider,omitempty"`
-	Model        string     `json:"model,omitempty"`
-	Index        int        `json:"index,omitempty"`
-	Delta        Message    `json:"delta"`
-	FinishReason string     `json:"finish_reason,omitempty"`
-	Usage        *ChatUsage `json:"usage,omitempty"`
-	Err          *Error     `json:"error,omitempty"`
+	ID           string          `json:"id,omitempty"`
+	Provider     string          `json:"provider,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	Index        int             `json:"index,omitempty"`
+	Delta        Message         `json:"delta"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+	Usage        *ChatUsage      `json:"usage,omitempty"`
+	LogProbs     *ChoiceLogProbs `json:"logprobs,omitempty"`
+	Err          *Error          `json:"error,omitempty"`
+	// Sequence is the provider's stream event sequence number, set by providers
+	// that can resume a stream server-side (see llm/core.StreamResumer).
+	Sequence int `json:"sequence,omitempty"`
+	// Truncated is set on the final chunk when the stream ended early and the
+	// content received so far is a partial result.
+	Truncated TruncationReason `json:"truncated,omitempty"`
 }
 
 // -----------------------------------------------------------------------------
@@ -203,6 +271,22 @@ type CacheControl struct {
 	TTL  string `json:"ttl,omitempty"`  // provider-specific duration
 }
 
+// PromptCacheHint 描述与提供商无关的 prompt 缓存意图。
+// 各 provider 将其翻译为原生机制：Anthropic 的 cache_control 断点、
+// OpenAI 的 prompt_cache_key/prompt_cache_retention、Gemini 的 cachedContents。
+type PromptCacheHint struct {
+	// Key 是缓存路由键；Gemini 下若以 "cachedContents/" 开头则作为已创建的上下文缓存名。
+	Key string `json:"key,omitempty"`
+	// TTL 是期望的缓存保留时长，provider 会就近取整到其支持的档位。
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Breakpoints 是 Messages 中的下标，缓存前缀在这些消息之后结束。
+	Breakpoints []int `json:"breakpoints,omitempty"`
+	// System 为 true 时缓存 system prompt。
+	System bool `json:"system,omitempty"`
+	// Tools 为 true 时缓存工具定义。
+	Tools bool `json:"tools,omitempty"`
+}
+
 // WebSearchOptions 配置内置 web 搜索工具。
 type WebSearchOptions struct {
 	SearchContextSize string             `json:"search_context_size,omitempty"` // low/medium/high
@@ -255,3 +339,76 @@ func (r *ChatResponse) Content() string {
 func (c *StreamChunk) IsError() bool {
 	return c != nil && c.Err != nil
 }
+
+// ReasoningContent 返回第一个选项的推理/思考内容，依次回退到 thinking blocks 与 reasoning summaries。
+func (r *ChatResponse) ReasoningContent() string {
+	return messageReasoningText(r.FirstChoice().Message)
+}
+
+// ReasoningTokens 返回响应中的推理 token 数（已包含在 CompletionTokens 内）。
+func (r *ChatResponse) ReasoningTokens() int {
+	if r == nil {
+		return 0
+	}
+	return r.Usage.ReasoningTokens()
+}
+
+// ReasoningContent 返回流式块中的推理/思考增量。
+func (c *StreamChunk) ReasoningContent() string {
+	if c == nil {
+		return ""
+	}
+	return messageReasoningText(c.Delta)
+}
+
+// ReasoningTokens 返回流式块携带的推理 token 数，通常只在最后一个带 usage 的块中出现。
+func (c *StreamChunk) ReasoningTokens() int {
+	if c == nil || c.Usage == nil {
+		return 0
+	}
+	return c.Usage.ReasoningTokens()
+}
+
+func messageReasoningText(m Message) string {
+	if m.ReasoningContent != nil && *m.ReasoningContent != "" {
+		return *m.ReasoningContent
+	}
+	parts := make([]string, 0, len(m.ThinkingBlocks)+len(m.ReasoningSummaries))
+	for _, block := range m.ThinkingBlocks {
+		if block.Thinking != "" {
+			parts = append(parts, block.Thinking)
+		}
+	}
+	if len(parts) == 0 {
+		for _, summary := range m.ReasoningSummaries {
+			if summary.Text != "" {
+				parts = append(parts, summary.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ReasoningTokens 返回推理 token 数。
+func (u ChatUsage) ReasoningTokens() int {
+	if u.CompletionTokensDetails == nil {
+		return 0
+	}
+	return u.CompletionTokensDetails.ReasoningTokens
+}
+
+// CachedPromptTokens 返回命中 prompt 缓存的输入 token 数。
+func (u ChatUsage) CachedPromptTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
+}
+
+// CacheCreationPromptTokens 返回写入 prompt 缓存的输入 token 数。
+func (u ChatUsage) CacheCreationPromptTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CacheCreationTokens
+}
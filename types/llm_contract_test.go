@@ -0,0 +1,63 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChoiceLogProbs_ConfidenceSignals(t *testing.T) {
+	var empty *ChoiceLogProbs
+	_, ok := empty.Confidence()
+	assert.False(t, ok)
+	_, ok = (&ChoiceLogProbs{}).MinLogProb()
+	assert.False(t, ok)
+
+	lp := &ChoiceLogProbs{Content: []TokenLogProb{
+		{Token: "The", LogProb: -0.1},
+		{Token: " answer", LogProb: -2.3},
+		{Token: " is", LogProb: -0.2},
+	}}
+
+	mean, ok := lp.MeanLogProb()
+	require.True(t, ok)
+	assert.InDelta(t, -0.8666, mean, 1e-3)
+
+	conf, ok := lp.Confidence()
+	require.True(t, ok)
+	assert.InDelta(t, math.Exp(mean), conf, 1e-9)
+
+	lowest, ok := lp.MinLogProb()
+	require.True(t, ok)
+	assert.Equal(t, " answer", lowest.Token)
+}
+
+func TestChatResponse_ReasoningAccessors(t *testing.T) {
+	var nilResp *ChatResponse
+	assert.Empty(t, nilResp.ReasoningContent())
+	assert.Zero(t, nilResp.ReasoningTokens())
+
+	reasoning := "step by step"
+	resp := &ChatResponse{
+		Choices: []ChatChoice{{Message: Message{Content: "42", ReasoningContent: &reasoning}}},
+		Usage: ChatUsage{
+			CompletionTokens:        30,
+			CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 20},
+		},
+	}
+	assert.Equal(t, "step by step", resp.ReasoningContent())
+	assert.Equal(t, 20, resp.ReasoningTokens())
+
+	thinking := &ChatResponse{Choices: []ChatChoice{{Message: Message{ThinkingBlocks: []ThinkingBlock{{Thinking: "a"}, {Thinking: "b"}}}}}}
+	assert.Equal(t, "a\n\nb", thinking.ReasoningContent())
+
+	summary := &ChatResponse{Choices: []ChatChoice{{Message: Message{ReasoningSummaries: []ReasoningSummary{{Text: "summary"}}}}}}
+	assert.Equal(t, "summary", summary.ReasoningContent())
+
+	chunk := &StreamChunk{Delta: Message{ReasoningContent: &reasoning}, Usage: &ChatUsage{CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 7}}}
+	assert.Equal(t, "step by step", chunk.ReasoningContent())
+	assert.Equal(t, 7, chunk.ReasoningTokens())
+	assert.Zero(t, (&StreamChunk{}).ReasoningTokens())
+}
@@ -124,6 +124,29 @@ func (c *ModelCatalog) All() []ModelDescriptor {
 	return cloneModelDescriptors(c.models)
 }
 
+// Merge returns a new catalog with models added; entries sharing provider and
+// id with an existing descriptor replace it in place. The receiver is unchanged.
+func (c *ModelCatalog) Merge(models []ModelDescriptor) *ModelCatalog {
+	merged := c.All()
+	positions := make(map[string]int, len(merged))
+	for i, model := range merged {
+		positions[modelCatalogKey(model.Provider, model.ID)] = i
+	}
+	for _, model := range models {
+		key := modelCatalogKey(model.Provider, model.ID)
+		if key == "" {
+			continue
+		}
+		if idx, ok := positions[key]; ok {
+			merged[idx] = model
+			continue
+		}
+		positions[key] = len(merged)
+		merged = append(merged, model)
+	}
+	return NewModelCatalog(merged)
+}
+
 // Supports reports whether the descriptor declares a capability.
 func (d ModelDescriptor) Supports(capability ModelCapability) bool {
 	for _, value := range d.Capabilities {
@@ -213,3 +213,27 @@ func TestValidateModelCapabilitiesAllowsSupportedOrUnknownModels(t *testing.T) {
 	options.Model.Model = "not-in-catalog"
 	require.NoError(t, ValidateModelCapabilities(catalog, options))
 }
+
+func TestModelCatalogMerge(t *testing.T) {
+	base := NewModelCatalog([]ModelDescriptor{
+		{Provider: "openrouter", ID: "openai/gpt-4o", DisplayName: "old"},
+		{Provider: "openai", ID: "gpt-4o"},
+	})
+
+	merged := base.Merge([]ModelDescriptor{
+		{Provider: "OpenRouter", ID: "openai/gpt-4o", DisplayName: "new"},
+		{Provider: "together", ID: "meta-llama/Llama-3.3-70B-Instruct-Turbo"},
+		{Provider: "", ID: "ignored"},
+	})
+
+	require.Len(t, merged.All(), 3)
+	model, ok := merged.Lookup("openrouter", "openai/gpt-4o")
+	require.True(t, ok)
+	assert.Equal(t, "new", model.DisplayName)
+	_, ok = merged.Lookup("together", "meta-llama/llama-3.3-70b-instruct-turbo")
+	assert.True(t, ok)
+
+	original, _ := base.Lookup("openrouter", "openai/gpt-4o")
+	assert.Equal(t, "old", original.DisplayName)
+	assert.Len(t, base.All(), 2)
+}
@@ -12,6 +12,7 @@ const (
 	RunEventToolCall             RunEventType = "tool_call"
 	RunEventToolResult           RunEventType = "tool_result"
 	RunEventToolProgress         RunEventType = "tool_progress"
+	RunEventToolCallDelta        RunEventType = "tool_call_delta"
 
 	// Deprecated: Use RunEventHandoffRequested / RunEventHandoffCompleted instead.
 	RunEventHandoff RunEventType = "handoff"
@@ -39,6 +40,9 @@ const (
 	RunEventApprovalResolved  RunEventType = "approval_resolved"
 	RunEventCheckpointSaved   RunEventType = "checkpoint_saved"
 	RunEventStateChanged      RunEventType = "state_changed"
+	RunEventStarted           RunEventType = "started"
+	RunEventPlanGenerated     RunEventType = "plan_generated"
+	RunEventGuardrail         RunEventType = "guardrail_triggered"
 	RunEventCompleted         RunEventType = "completed"
 	RunEventFailed            RunEventType = "failed"
 )
@@ -52,6 +56,7 @@ type RunEvent struct {
 	RunID        string            `json:"run_id,omitempty"`
 	ParentRunID  string            `json:"parent_run_id,omitempty"`
 	TraceID      string            `json:"trace_id,omitempty"`
+	SpanID       string            `json:"span_id,omitempty"`
 	SessionID    string            `json:"session_id,omitempty"`
 	AgentID      string            `json:"agent_id,omitempty"`
 	TeamID       string            `json:"team_id,omitempty"`
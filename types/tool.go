@@ -16,6 +16,9 @@ type ToolSchema struct {
 	Format       *ToolFormat     `json:"format,omitempty"`
 	Strict       *bool           `json:"strict,omitempty"`
 	Version      string          `json:"version,omitempty"`
+	// Traits 声明工具的执行特性，供护栏、缓存、并行执行与 HITL 策略使用；
+	// nil 表示未声明，调用方回退到基于名称的启发式判断。provider 不会将其发往上游。
+	Traits *ToolTraits `json:"traits,omitempty"`
 }
 
 type ToolRetryPolicy struct {
@@ -38,6 +41,85 @@ const (
 	ToolTypeCustom   = "custom"
 )
 
+// ToolSideEffect 描述工具调用对外部状态的影响.
+type ToolSideEffect string
+
+const (
+	// ToolSideEffectNone 只读，不修改任何状态
+	ToolSideEffectNone ToolSideEffect = "none"
+	// ToolSideEffectLocal 修改本地/沙箱内状态（文件、进程内存储等）
+	ToolSideEffectLocal ToolSideEffect = "local"
+	// ToolSideEffectExternal 修改外部系统状态（发消息、下单、调用第三方写接口等）
+	ToolSideEffectExternal ToolSideEffect = "external"
+)
+
+// ToolLatencyClass 描述工具的预期延迟等级.
+type ToolLatencyClass string
+
+const (
+	ToolLatencyFast   ToolLatencyClass = "fast"   // 亚秒级
+	ToolLatencyMedium ToolLatencyClass = "medium" // 秒级
+	ToolLatencySlow   ToolLatencyClass = "slow"   // 十秒级以上或长任务
+)
+
+// ToolCostClass 描述单次调用的成本等级.
+type ToolCostClass string
+
+const (
+	ToolCostFree   ToolCostClass = "free"
+	ToolCostLow    ToolCostClass = "low"
+	ToolCostMedium ToolCostClass = "medium"
+	ToolCostHigh   ToolCostClass = "high"
+)
+
+// ToolTraits 工具的结构化能力声明.
+type ToolTraits struct {
+	SideEffect       ToolSideEffect   `json:"side_effect,omitempty"`  // 为空表示未声明
+	Idempotent       bool             `json:"idempotent,omitempty"`   // 相同参数重复调用结果一致且无额外副作用
+	Irreversible     bool             `json:"irreversible,omitempty"` // 副作用无法撤销（删除数据、转账、对外发送等）
+	LatencyClass     ToolLatencyClass `json:"latency_class,omitempty"`
+	AuthScopes       []string         `json:"auth_scopes,omitempty"` // 调用方需具备的授权范围
+	CostClass        ToolCostClass    `json:"cost_class,omitempty"`
+	EstimatedCostUSD float64          `json:"estimated_cost_usd,omitempty"` // 单次调用预估成本
+}
+
+// Declared 报告是否声明了副作用特性；nil 安全.
+func (t *ToolTraits) Declared() bool {
+	return t != nil && t.SideEffect != ""
+}
+
+// ReadOnly 报告工具是否声明为只读.
+func (t *ToolTraits) ReadOnly() bool {
+	return t != nil && t.SideEffect == ToolSideEffectNone
+}
+
+// HasSideEffects 报告工具是否声明了副作用；未声明时返回 false.
+func (t *ToolTraits) HasSideEffects() bool {
+	return t.Declared() && t.SideEffect != ToolSideEffectNone
+}
+
+// RetrySafe 报告失败后能否安全重试：未声明时保持原有行为返回 true，
+// 声明后仅只读或幂等工具可重试.
+func (t *ToolTraits) RetrySafe() bool {
+	if !t.Declared() {
+		return true
+	}
+	return t.ReadOnly() || t.Idempotent
+}
+
+// Cacheable 报告结果能否缓存复用：未声明时返回 true，声明后仅只读工具可缓存.
+func (t *ToolTraits) Cacheable() bool {
+	if !t.Declared() {
+		return true
+	}
+	return t.ReadOnly()
+}
+
+// ParallelSafe 报告能否与其他调用并发执行：声明了副作用的工具应串行执行.
+func (t *ToolTraits) ParallelSafe() bool {
+	return !t.HasSideEffects()
+}
+
 // ToolResult represents the result of a tool execution.
 type ToolResult struct {
 	ToolCallID string          `json:"tool_call_id"`
@@ -46,6 +128,8 @@ type ToolResult struct {
 	Error      string          `json:"error,omitempty"`
 	Duration   time.Duration   `json:"duration"`
 	FromCache  bool            `json:"from_cache,omitempty"`
+	// Truncated 非空表示工具因截止时间预算耗尽而未完成.
+	Truncated TruncationReason `json:"truncated,omitempty"`
 }
 
 // ToMessage converts ToolResult to a Message.
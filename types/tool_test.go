@@ -0,0 +1,58 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolTraits_Policies(t *testing.T) {
+	var undeclared *ToolTraits
+	assert.False(t, undeclared.Declared())
+	assert.False(t, undeclared.ReadOnly())
+	assert.True(t, undeclared.RetrySafe())
+	assert.True(t, undeclared.Cacheable())
+	assert.True(t, undeclared.ParallelSafe())
+
+	readOnly := &ToolTraits{SideEffect: ToolSideEffectNone}
+	assert.True(t, readOnly.ReadOnly())
+	assert.True(t, readOnly.RetrySafe())
+	assert.True(t, readOnly.Cacheable())
+	assert.True(t, readOnly.ParallelSafe())
+
+	idempotentWrite := &ToolTraits{SideEffect: ToolSideEffectLocal, Idempotent: true}
+	assert.True(t, idempotentWrite.HasSideEffects())
+	assert.True(t, idempotentWrite.RetrySafe())
+	assert.False(t, idempotentWrite.Cacheable())
+	assert.False(t, idempotentWrite.ParallelSafe())
+
+	external := &ToolTraits{SideEffect: ToolSideEffectExternal}
+	assert.False(t, external.RetrySafe())
+}
+
+func TestToolSchema_TraitsJSON(t *testing.T) {
+	schema := ToolSchema{
+		Name:       "send_email",
+		Parameters: json.RawMessage(`{"type":"object"}`),
+		Traits: &ToolTraits{
+			SideEffect:       ToolSideEffectExternal,
+			LatencyClass:     ToolLatencyMedium,
+			AuthScopes:       []string{"mail:send"},
+			CostClass:        ToolCostLow,
+			EstimatedCostUSD: 0.001,
+		},
+	}
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"traits":{"side_effect":"external","latency_class":"medium","auth_scopes":["mail:send"],"cost_class":"low","estimated_cost_usd":0.001}`)
+
+	var decoded ToolSchema
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, schema.Traits, decoded.Traits)
+
+	data, err = json.Marshal(ToolSchema{Name: "legacy"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "traits")
+}
@@ -75,6 +75,62 @@ type HumanInputHandler interface {
 	RequestInput(ctx context.Context, prompt string, inputType string, options []string) (*HumanInputResult, error)
 }
 
+// ExternalTaskStatus 外部活动任务状态。
+type ExternalTaskStatus string
+
+const (
+	ExternalTaskPending   ExternalTaskStatus = "pending"
+	ExternalTaskRunning   ExternalTaskStatus = "running"
+	ExternalTaskCompleted ExternalTaskStatus = "completed"
+	ExternalTaskFailed    ExternalTaskStatus = "failed"
+	ExternalTaskCancelled ExternalTaskStatus = "cancelled"
+	ExternalTaskTimeout   ExternalTaskStatus = "timeout"
+)
+
+// IsTerminal 状态是否为终态。
+func (s ExternalTaskStatus) IsTerminal() bool {
+	switch s {
+	case ExternalTaskCompleted, ExternalTaskFailed, ExternalTaskCancelled, ExternalTaskTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExternalTask 交由进程外 worker 执行的活动任务。
+type ExternalTask struct {
+	ID       string             `json:"id"`
+	TaskType string             `json:"task_type"`
+	StepID   string             `json:"step_id,omitempty"`
+	Input    map[string]any     `json:"input,omitempty"`
+	Status   ExternalTaskStatus `json:"status"`
+	Result   any                `json:"result,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	WorkerID string             `json:"worker_id,omitempty"`
+	// Attempt 从 0 开始的尝试序号
+	Attempt     int `json:"attempt"`
+	MaxAttempts int `json:"max_attempts"`
+	// HeartbeatTimeout worker 两次心跳的最大间隔，0 表示不检查心跳
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout,omitempty"`
+	// StartToCloseTimeout 单次尝试从领取到完成的最长时间，0 表示不限制
+	StartToCloseTimeout time.Duration `json:"start_to_close_timeout,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	StartedAt           *time.Time    `json:"started_at,omitempty"`
+	// LastHeartbeatAt 最近一次心跳时间（领取时视为首次心跳）
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at,omitempty"`
+}
+
+// ExternalTaskQueue 外部活动任务队列抽象。
+// workflow 层只负责发布任务与观察状态，领取、心跳与结果回传由 worker 通过 API 完成。
+type ExternalTaskQueue interface {
+	// Publish 发布待领取的任务，返回任务 ID
+	Publish(ctx context.Context, task *ExternalTask) (string, error)
+	// Get 查询任务当前状态
+	Get(ctx context.Context, taskID string) (*ExternalTask, error)
+	// Abort 将未结束的任务置为 cancelled 或 timeout，之后 worker 的心跳与结果会被拒绝
+	Abort(ctx context.Context, taskID string, status ExternalTaskStatus, reason string) error
+}
+
 // AgentExecutionOutput carries structured execution results from an agent step,
 // eliminating type assertions for metadata extraction.
 type AgentExecutionOutput struct {
@@ -0,0 +1,606 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+)
+
+// AnalysisSeverity ranks static analysis findings.
+type AnalysisSeverity string
+
+const (
+	// AnalysisSeverityError marks a graph that cannot run as intended
+	AnalysisSeverityError AnalysisSeverity = "error"
+	// AnalysisSeverityWarning marks a likely mistake that still runs
+	AnalysisSeverityWarning AnalysisSeverity = "warning"
+	// AnalysisSeverityInfo marks an observation worth surfacing
+	AnalysisSeverityInfo AnalysisSeverity = "info"
+)
+
+// Finding codes reported by AnalyzeDAG.
+const (
+	FindingUnreachableNode     = "unreachable_node"
+	FindingDanglingReference   = "dangling_reference"
+	FindingUnusedOutput        = "unused_output"
+	FindingMissingErrorPolicy  = "missing_error_policy"
+	FindingUnboundedLoop       = "unbounded_loop"
+	FindingNestedLoop          = "nested_loop"
+	FindingLoopSharedNode      = "loop_shared_node"
+	FindingUnpricedModel       = "unpriced_model"
+	FindingUnknownStepCategory = "unknown_step_type"
+)
+
+// AnalysisFinding is a single static analysis result.
+type AnalysisFinding struct {
+	Code     string           `json:"code"`
+	Severity AnalysisSeverity `json:"severity"`
+	NodeID   string           `json:"node_id,omitempty"`
+	Message  string           `json:"message"`
+}
+
+// StepProfile describes a step to the analyzer without executing it.
+type StepProfile struct {
+	Type      StepType
+	Model     string
+	Prompt    string
+	MaxTokens int
+}
+
+// ProfiledStep is implemented by steps that can describe themselves for
+// static analysis. Built-in step types are recognized without it.
+type ProfiledStep interface {
+	Profile() StepProfile
+}
+
+// ModelPriceLookup returns USD prices per 1K input and output tokens for a model.
+type ModelPriceLookup func(model string) (inputPer1K, outputPer1K float64, ok bool)
+
+// NodeStats summarizes historical executions of a node.
+type NodeStats struct {
+	Samples     int           `json:"samples"`
+	AvgDuration time.Duration `json:"avg_duration"`
+	FailureRate float64       `json:"failure_rate"`
+}
+
+// DAGAnalysisConfig configures AnalyzeDAG.
+type DAGAnalysisConfig struct {
+	// ModelCatalog supplies max output tokens for LLM steps without MaxTokens.
+	ModelCatalog *types.ModelCatalog
+	// Provider is used for catalog lookups.
+	Provider string
+	// Prices resolves model pricing; nil skips cost estimation.
+	Prices ModelPriceLookup
+	// NodeStats holds historical latency per node ID, see NodeStatsFromHistory.
+	NodeStats map[string]NodeStats
+	// EstimatedInputTokens is added to the prompt size of every LLM call (default 1000).
+	EstimatedInputTokens int
+	// DefaultOutputTokens is used when neither the step nor the catalog bounds output (default 1000).
+	DefaultOutputTokens int
+	// DefaultForEachItems is the assumed collection size of unbounded foreach loops (default 10).
+	DefaultForEachItems int
+	// DefaultLLMLatency is the assumed latency of an LLM step without history (default 2s).
+	DefaultLLMLatency time.Duration
+	// DefaultStepLatency is the assumed latency of other nodes without history (default 50ms).
+	DefaultStepLatency time.Duration
+}
+
+func (c DAGAnalysisConfig) withDefaults() DAGAnalysisConfig {
+	if c.EstimatedInputTokens <= 0 {
+		c.EstimatedInputTokens = 1000
+	}
+	if c.DefaultOutputTokens <= 0 {
+		c.DefaultOutputTokens = 1000
+	}
+	if c.DefaultForEachItems <= 0 {
+		c.DefaultForEachItems = 10
+	}
+	if c.DefaultLLMLatency <= 0 {
+		c.DefaultLLMLatency = 2 * time.Second
+	}
+	if c.DefaultStepLatency <= 0 {
+		c.DefaultStepLatency = 50 * time.Millisecond
+	}
+	return c
+}
+
+// NodeEstimate is the pre-run estimate for one node.
+type NodeEstimate struct {
+	NodeID        string        `json:"node_id"`
+	StepType      StepType      `json:"step_type,omitempty"`
+	Model         string        `json:"model,omitempty"`
+	Executions    int           `json:"executions"`
+	InputTokens   int           `json:"input_tokens,omitempty"`
+	OutputTokens  int           `json:"output_tokens,omitempty"`
+	CostUSD       float64       `json:"cost_usd"`
+	Latency       time.Duration `json:"latency"`
+	LatencySource string        `json:"latency_source"`
+}
+
+// RunEstimate is the pre-run cost and latency estimate for a graph.
+type RunEstimate struct {
+	TotalCostUSD        float64        `json:"total_cost_usd"`
+	CriticalPathLatency time.Duration  `json:"critical_path_latency"`
+	CriticalPath        []string       `json:"critical_path"`
+	Nodes               []NodeEstimate `json:"nodes"`
+	UnpricedModels      []string       `json:"unpriced_models,omitempty"`
+}
+
+// DAGAnalysisReport is the result of AnalyzeDAG.
+type DAGAnalysisReport struct {
+	Findings []AnalysisFinding `json:"findings"`
+	Estimate RunEstimate       `json:"estimate"`
+}
+
+// HasErrors reports whether any finding has error severity.
+func (r *DAGAnalysisReport) HasErrors() bool {
+	return len(r.BySeverity(AnalysisSeverityError)) > 0
+}
+
+// BySeverity returns the findings with the given severity.
+func (r *DAGAnalysisReport) BySeverity(severity AnalysisSeverity) []AnalysisFinding {
+	var out []AnalysisFinding
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Err joins error-severity findings into one error, or returns nil.
+func (r *DAGAnalysisReport) Err() error {
+	var errs []error
+	for _, f := range r.BySeverity(AnalysisSeverityError) {
+		errs = append(errs, fmt.Errorf("%s: %s", f.Code, f.Message))
+	}
+	return errors.Join(errs...)
+}
+
+// NodeStatsFromHistory aggregates per-node latency and failure rate from
+// recorded executions.
+func NodeStatsFromHistory(histories []*ExecutionHistory) map[string]NodeStats {
+	type acc struct {
+		samples  int
+		failures int
+		total    time.Duration
+	}
+	accs := make(map[string]*acc)
+	for _, h := range histories {
+		if h == nil {
+			continue
+		}
+		for _, n := range h.GetNodes() {
+			if n.Status == ExecutionStatusRunning {
+				continue
+			}
+			a := accs[n.NodeID]
+			if a == nil {
+				a = &acc{}
+				accs[n.NodeID] = a
+			}
+			a.samples++
+			a.total += n.Duration
+			if n.Status == ExecutionStatusFailed {
+				a.failures++
+			}
+		}
+	}
+	stats := make(map[string]NodeStats, len(accs))
+	for id, a := range accs {
+		stats[id] = NodeStats{
+			Samples:     a.samples,
+			AvgDuration: a.total / time.Duration(a.samples),
+			FailureRate: float64(a.failures) / float64(a.samples),
+		}
+	}
+	return stats
+}
+
+// AnalyzeDAG runs a static analysis pass over graph: reachability, dangling
+// references, unconsumed outputs, missing error policies on side-effecting
+// nodes, loop risks and a pre-run cost/latency estimate. Structural errors
+// that DAGBuilder.Build also rejects are reported as error findings here so
+// the report can be produced for graphs that do not build.
+func AnalyzeDAG(graph *DAGGraph, cfg DAGAnalysisConfig) *DAGAnalysisReport {
+	a := &dagAnalyzer{graph: graph, cfg: cfg.withDefaults(), report: &DAGAnalysisReport{}}
+	if graph == nil || len(graph.nodes) == 0 {
+		a.add(FindingUnreachableNode, AnalysisSeverityError, "", "graph has no nodes")
+		return a.report
+	}
+	a.checkReferences()
+	a.checkReachability()
+	a.checkNodes()
+	a.checkLoops()
+	a.checkUnusedOutputs()
+	a.estimate()
+	sort.SliceStable(a.report.Findings, func(i, j int) bool {
+		return severityRank(a.report.Findings[i].Severity) < severityRank(a.report.Findings[j].Severity)
+	})
+	return a.report
+}
+
+type dagAnalyzer struct {
+	graph     *DAGGraph
+	cfg       DAGAnalysisConfig
+	report    *DAGAnalysisReport
+	reachable map[string]bool
+}
+
+func (a *dagAnalyzer) add(code string, severity AnalysisSeverity, nodeID, format string, args ...any) {
+	a.report.Findings = append(a.report.Findings, AnalysisFinding{
+		Code:     code,
+		Severity: severity,
+		NodeID:   nodeID,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// successors returns edge targets plus condition branch targets.
+func (a *dagAnalyzer) successors(nodeID string) []string {
+	next := append([]string(nil), a.graph.GetEdges(nodeID)...)
+	if node, ok := a.graph.GetNode(nodeID); ok && node.Type == NodeTypeCondition {
+		next = append(next, conditionBranch(node, "on_true")...)
+		next = append(next, conditionBranch(node, "on_false")...)
+	}
+	return next
+}
+
+func conditionBranch(node *DAGNode, key string) []string {
+	ids, _ := node.Metadata[key].([]string)
+	return ids
+}
+
+func (a *dagAnalyzer) checkReferences() {
+	if a.graph.entry == "" {
+		a.add(FindingDanglingReference, AnalysisSeverityError, "", "entry node not set")
+	} else if _, ok := a.graph.GetNode(a.graph.entry); !ok {
+		a.add(FindingDanglingReference, AnalysisSeverityError, a.graph.entry, "entry node %s does not exist", a.graph.entry)
+	}
+	for _, fromID := range sortedNodeIDs(a.graph.edges) {
+		if _, ok := a.graph.GetNode(fromID); !ok {
+			a.add(FindingDanglingReference, AnalysisSeverityError, fromID, "edge source %s does not exist", fromID)
+		}
+	}
+	for _, nodeID := range sortedNodeIDs(a.graph.nodes) {
+		for _, toID := range a.successors(nodeID) {
+			if _, ok := a.graph.GetNode(toID); !ok {
+				a.add(FindingDanglingReference, AnalysisSeverityError, nodeID, "node %s routes to missing node %s", nodeID, toID)
+			}
+		}
+	}
+}
+
+func (a *dagAnalyzer) checkReachability() {
+	a.reachable = make(map[string]bool)
+	var walk func(string)
+	walk = func(nodeID string) {
+		if a.reachable[nodeID] {
+			return
+		}
+		if _, ok := a.graph.GetNode(nodeID); !ok {
+			return
+		}
+		a.reachable[nodeID] = true
+		for _, next := range a.successors(nodeID) {
+			walk(next)
+		}
+	}
+	walk(a.graph.entry)
+	for _, nodeID := range sortedNodeIDs(a.graph.nodes) {
+		if !a.reachable[nodeID] {
+			a.add(FindingUnreachableNode, AnalysisSeverityError, nodeID, "node %s is not reachable from entry %s and will never run", nodeID, a.graph.entry)
+		}
+	}
+}
+
+func (a *dagAnalyzer) checkNodes() {
+	for _, nodeID := range sortedNodeIDs(a.graph.nodes) {
+		node := a.graph.nodes[nodeID]
+		if node.Type != NodeTypeAction || node.Step == nil {
+			continue
+		}
+		profile := stepProfile(node)
+		if profile.Type == "" {
+			a.add(FindingUnknownStepCategory, AnalysisSeverityInfo, nodeID, "step type of node %s is unknown; side effects and cost cannot be analyzed", nodeID)
+			continue
+		}
+		if isSideEffecting(profile.Type) && node.ErrorConfig == nil {
+			a.add(FindingMissingErrorPolicy, AnalysisSeverityWarning, nodeID, "%s node %s has side effects but no error policy; a failure aborts the run without retry or fallback", profile.Type, nodeID)
+		}
+	}
+}
+
+// loopBody returns the nodes re-executed by each iteration of a loop node.
+func (a *dagAnalyzer) loopBody(loopID string) map[string]bool {
+	body := make(map[string]bool)
+	var walk func(string)
+	walk = func(nodeID string) {
+		if body[nodeID] {
+			return
+		}
+		if _, ok := a.graph.GetNode(nodeID); !ok {
+			return
+		}
+		body[nodeID] = true
+		for _, next := range a.successors(nodeID) {
+			walk(next)
+		}
+	}
+	for _, next := range a.graph.GetEdges(loopID) {
+		walk(next)
+	}
+	return body
+}
+
+func (a *dagAnalyzer) checkLoops() {
+	for _, loopID := range sortedNodeIDs(a.graph.nodes) {
+		node := a.graph.nodes[loopID]
+		if node.Type != NodeTypeLoop || node.LoopConfig == nil {
+			continue
+		}
+		switch cfg := node.LoopConfig; {
+		case cfg.Type == LoopTypeWhile && cfg.MaxIterations <= 0:
+			a.add(FindingUnboundedLoop, AnalysisSeverityWarning, loopID,
+				"while loop %s has no max_iterations and relies on its condition; it stops only at the %d-iteration safety cap", loopID, defaultWhileMaxIterations)
+		case cfg.Type == LoopTypeForEach && cfg.MaxIterations <= 0:
+			a.add(FindingUnboundedLoop, AnalysisSeverityInfo, loopID,
+				"foreach loop %s is bounded only by its collection size; the estimate assumes %d items", loopID, a.cfg.DefaultForEachItems)
+		}
+
+		body := a.loopBody(loopID)
+		for _, bodyID := range sortedNodeIDs(body) {
+			if bodyNode := a.graph.nodes[bodyID]; bodyNode.Type == NodeTypeLoop {
+				a.add(FindingNestedLoop, AnalysisSeverityWarning, bodyID,
+					"loop %s is nested in loop %s; iterations multiply", bodyID, loopID)
+			}
+			for _, parent := range a.parents(bodyID) {
+				if parent != loopID && !body[parent] {
+					a.add(FindingLoopSharedNode, AnalysisSeverityWarning, bodyID,
+						"node %s is in the body of loop %s but is also reached from %s; it is re-executed on every iteration", bodyID, loopID, parent)
+				}
+			}
+		}
+	}
+}
+
+func (a *dagAnalyzer) parents(nodeID string) []string {
+	var out []string
+	for _, fromID := range sortedNodeIDs(a.graph.nodes) {
+		for _, toID := range a.successors(fromID) {
+			if toID == nodeID {
+				out = append(out, fromID)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// checkUnusedOutputs flags data-producing sink nodes when a graph has several
+// sinks. The executor returns only one result, so the other sink outputs are
+// discarded. Graphs with control nodes are skipped because their sinks sit on
+// mutually exclusive branches.
+func (a *dagAnalyzer) checkUnusedOutputs() {
+	if a.graph.entry == "" || !supportsDependencyDrivenScheduling(a.graph, a.graph.entry) {
+		return
+	}
+	var sinks []string
+	for _, nodeID := range sortedNodeIDs(a.reachable) {
+		if len(a.graph.GetEdges(nodeID)) == 0 {
+			sinks = append(sinks, nodeID)
+		}
+	}
+	if len(sinks) < 2 {
+		return
+	}
+	for _, nodeID := range sinks {
+		node := a.graph.nodes[nodeID]
+		if node.Type != NodeTypeAction {
+			continue
+		}
+		if profile := stepProfile(node); isSideEffecting(profile.Type) {
+			continue
+		}
+		a.add(FindingUnusedOutput, AnalysisSeverityWarning, nodeID,
+			"output of node %s is never consumed; the run returns only one of the terminal nodes %s", nodeID, strings.Join(sinks, ", "))
+	}
+}
+
+// multipliers returns how many times each reachable node runs, accounting for
+// enclosing loop bounds.
+func (a *dagAnalyzer) multipliers() map[string]int {
+	mult := make(map[string]int, len(a.reachable))
+	for nodeID := range a.reachable {
+		mult[nodeID] = 1
+	}
+	for _, loopID := range sortedNodeIDs(a.reachable) {
+		node := a.graph.nodes[loopID]
+		if node.Type != NodeTypeLoop || node.LoopConfig == nil {
+			continue
+		}
+		bound := a.loopBound(node.LoopConfig)
+		for bodyID := range a.loopBody(loopID) {
+			mult[bodyID] *= bound
+		}
+	}
+	return mult
+}
+
+func (a *dagAnalyzer) loopBound(cfg *LoopConfig) int {
+	switch cfg.Type {
+	case LoopTypeWhile:
+		if cfg.MaxIterations > 0 {
+			return cfg.MaxIterations
+		}
+		return defaultWhileMaxIterations
+	case LoopTypeForEach:
+		if cfg.MaxIterations > 0 {
+			return min(cfg.MaxIterations, a.cfg.DefaultForEachItems)
+		}
+		return a.cfg.DefaultForEachItems
+	default:
+		return max(cfg.MaxIterations, 1)
+	}
+}
+
+func (a *dagAnalyzer) estimate() {
+	if len(a.reachable) == 0 {
+		return
+	}
+	mult := a.multipliers()
+	unpriced := make(map[string]bool)
+	nodeLatency := make(map[string]time.Duration, len(a.reachable))
+	est := &a.report.Estimate
+
+	for _, nodeID := range sortedNodeIDs(a.reachable) {
+		node := a.graph.nodes[nodeID]
+		ne := NodeEstimate{NodeID: nodeID, Executions: mult[nodeID]}
+		profile := stepProfile(node)
+		ne.StepType = profile.Type
+		ne.Model = profile.Model
+
+		if profile.Type == StepTypeLLM {
+			ne.InputTokens = a.cfg.EstimatedInputTokens + len(profile.Prompt)/4
+			ne.OutputTokens = a.outputTokens(profile)
+			if a.cfg.Prices != nil && profile.Model != "" {
+				if in, out, ok := a.cfg.Prices(profile.Model); ok {
+					perCall := float64(ne.InputTokens)/1000*in + float64(ne.OutputTokens)/1000*out
+					ne.CostUSD = perCall * float64(ne.Executions)
+				} else {
+					unpriced[profile.Model] = true
+				}
+			}
+		}
+
+		latency := a.cfg.DefaultStepLatency
+		ne.LatencySource = "default"
+		if stats, ok := a.cfg.NodeStats[nodeID]; ok && stats.Samples > 0 {
+			latency = stats.AvgDuration
+			ne.LatencySource = "history"
+		} else if profile.Type == StepTypeLLM {
+			latency = a.cfg.DefaultLLMLatency
+		}
+		ne.Latency = latency * time.Duration(ne.Executions)
+		nodeLatency[nodeID] = ne.Latency
+
+		est.TotalCostUSD += ne.CostUSD
+		est.Nodes = append(est.Nodes, ne)
+	}
+
+	for _, model := range sortedNodeIDs(unpriced) {
+		est.UnpricedModels = append(est.UnpricedModels, model)
+		a.add(FindingUnpricedModel, AnalysisSeverityInfo, "", "no price known for model %s; its cost is excluded from the estimate", model)
+	}
+	est.CriticalPathLatency, est.CriticalPath = a.criticalPath(nodeLatency)
+}
+
+func (a *dagAnalyzer) outputTokens(profile StepProfile) int {
+	if profile.MaxTokens > 0 {
+		return profile.MaxTokens
+	}
+	if a.cfg.ModelCatalog != nil && profile.Model != "" {
+		if desc, ok := a.cfg.ModelCatalog.Lookup(a.cfg.Provider, profile.Model); ok && desc.MaxOutputTokens > 0 {
+			return min(desc.MaxOutputTokens, a.cfg.DefaultOutputTokens)
+		}
+	}
+	return a.cfg.DefaultOutputTokens
+}
+
+// criticalPath returns the longest latency path from entry. Parallel branches
+// overlap, so only the slowest chain contributes to wall-clock latency.
+func (a *dagAnalyzer) criticalPath(latency map[string]time.Duration) (time.Duration, []string) {
+	memo := make(map[string]time.Duration)
+	next := make(map[string]string)
+	visiting := make(map[string]bool)
+	var longest func(string) time.Duration
+	longest = func(nodeID string) time.Duration {
+		if d, ok := memo[nodeID]; ok {
+			return d
+		}
+		if visiting[nodeID] {
+			return 0
+		}
+		visiting[nodeID] = true
+		var best time.Duration
+		for _, child := range a.successors(nodeID) {
+			if !a.reachable[child] {
+				continue
+			}
+			if d := longest(child); d > best || next[nodeID] == "" {
+				best = d
+				next[nodeID] = child
+			}
+		}
+		visiting[nodeID] = false
+		memo[nodeID] = latency[nodeID] + best
+		return memo[nodeID]
+	}
+	total := longest(a.graph.entry)
+	var path []string
+	for id := a.graph.entry; id != ""; id = next[id] {
+		path = append(path, id)
+	}
+	return total, path
+}
+
+// stepProfile describes the step of an action node. Metadata "step_type" and
+// "model" override what the step reports.
+func stepProfile(node *DAGNode) StepProfile {
+	var profile StepProfile
+	switch s := node.Step.(type) {
+	case ProfiledStep:
+		profile = s.Profile()
+	case *LLMStep:
+		profile = StepProfile{Type: StepTypeLLM, Model: s.Model, Prompt: s.Prompt, MaxTokens: s.MaxTokens}
+	case *ToolStep:
+		profile.Type = StepTypeTool
+	case *CodeStep:
+		profile.Type = StepTypeCode
+	case *HumanInputStep:
+		profile.Type = StepTypeHumanInput
+	case *PassthroughStep:
+		profile.Type = StepTypePassthrough
+	}
+	if v, ok := node.Metadata["step_type"].(string); ok && v != "" {
+		profile.Type = StepType(v)
+	}
+	if v, ok := node.Metadata["model"].(string); ok && v != "" {
+		profile.Model = v
+	}
+	return profile
+}
+
+// isSideEffecting reports whether a step type may change external state.
+func isSideEffecting(stepType StepType) bool {
+	switch stepType {
+	case StepTypeTool, StepTypeCode, StepTypeAgent, StepTypeExternalActivity, StepTypeOrchestration, StepTypeChain:
+		return true
+	default:
+		return false
+	}
+}
+
+func severityRank(s AnalysisSeverity) int {
+	switch s {
+	case AnalysisSeverityError:
+		return 0
+	case AnalysisSeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func sortedNodeIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func findingCodes(report *DAGAnalysisReport, nodeID string) []string {
+	var codes []string
+	for _, f := range report.Findings {
+		if f.NodeID == nodeID {
+			codes = append(codes, f.Code)
+		}
+	}
+	return codes
+}
+
+func TestAnalyzeDAG_ReportsUnreachableAndDanglingNodes(t *testing.T) {
+	graph := NewDAGGraph()
+	graph.AddNode(&DAGNode{ID: "start", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddNode(&DAGNode{ID: "check", Type: NodeTypeCondition, Metadata: map[string]any{
+		"on_true":  []string{"ghost"},
+		"on_false": []string{"end"},
+	}})
+	graph.AddNode(&DAGNode{ID: "end", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddNode(&DAGNode{ID: "island", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddEdge("start", "check")
+	graph.SetEntry("start")
+
+	report := AnalyzeDAG(graph, DAGAnalysisConfig{})
+
+	assert.True(t, report.HasErrors())
+	assert.Contains(t, findingCodes(report, "island"), FindingUnreachableNode)
+	assert.Contains(t, findingCodes(report, "check"), FindingDanglingReference)
+	assert.NotContains(t, findingCodes(report, "end"), FindingUnreachableNode, "condition branches count as reachable")
+	assert.Error(t, report.Err())
+	assert.Equal(t, AnalysisSeverityError, report.Findings[0].Severity, "errors sort first")
+}
+
+func TestAnalyzeDAG_ReportsUnusedOutputsAndMissingErrorPolicies(t *testing.T) {
+	graph := NewDAGGraph()
+	graph.AddNode(&DAGNode{ID: "start", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddNode(&DAGNode{ID: "summary", Type: NodeTypeAction, Step: &LLMStep{Model: "gpt-4o"}})
+	graph.AddNode(&DAGNode{ID: "notify", Type: NodeTypeAction, Step: &ToolStep{ToolName: "slack"}})
+	graph.AddNode(&DAGNode{ID: "draft", Type: NodeTypeAction, Step: &LLMStep{Model: "gpt-4o"}})
+	graph.AddNode(&DAGNode{ID: "safe", Type: NodeTypeAction, Step: &CodeStep{}, ErrorConfig: &ErrorConfig{Strategy: ErrorStrategySkip}})
+	graph.AddEdge("start", "summary")
+	graph.AddEdge("start", "notify")
+	graph.AddEdge("start", "draft")
+	graph.AddEdge("start", "safe")
+	graph.SetEntry("start")
+
+	report := AnalyzeDAG(graph, DAGAnalysisConfig{})
+
+	assert.False(t, report.HasErrors())
+	assert.Contains(t, findingCodes(report, "summary"), FindingUnusedOutput)
+	assert.Contains(t, findingCodes(report, "draft"), FindingUnusedOutput)
+	assert.NotContains(t, findingCodes(report, "notify"), FindingUnusedOutput, "side-effecting sinks are not unused")
+	assert.Contains(t, findingCodes(report, "notify"), FindingMissingErrorPolicy)
+	assert.NotContains(t, findingCodes(report, "safe"), FindingMissingErrorPolicy)
+	assert.NotContains(t, findingCodes(report, "summary"), FindingMissingErrorPolicy)
+}
+
+func TestAnalyzeDAG_ReportsLoopRisks(t *testing.T) {
+	graph := NewDAGGraph()
+	graph.AddNode(&DAGNode{ID: "outer", Type: NodeTypeLoop, LoopConfig: &LoopConfig{Type: LoopTypeWhile, Condition: func(context.Context, any) (bool, error) { return false, nil }}})
+	graph.AddNode(&DAGNode{ID: "inner", Type: NodeTypeLoop, LoopConfig: &LoopConfig{Type: LoopTypeForEach, Iterator: func(context.Context, any) ([]any, error) { return nil, nil }}})
+	graph.AddNode(&DAGNode{ID: "body", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddNode(&DAGNode{ID: "side", Type: NodeTypeAction, Step: &PassthroughStep{}})
+	graph.AddEdge("outer", "inner")
+	graph.AddEdge("inner", "body")
+	graph.AddEdge("outer", "side")
+	graph.AddEdge("side", "body")
+	graph.SetEntry("outer")
+
+	report := AnalyzeDAG(graph, DAGAnalysisConfig{})
+
+	assert.Contains(t, findingCodes(report, "outer"), FindingUnboundedLoop)
+	assert.Contains(t, findingCodes(report, "inner"), FindingUnboundedLoop)
+	assert.Contains(t, findingCodes(report, "inner"), FindingNestedLoop)
+	assert.Contains(t, findingCodes(report, "body"), FindingLoopSharedNode)
+	assert.NotContains(t, findingCodes(report, "side"), FindingLoopSharedNode)
+}
+
+func TestAnalyzeDAG_EstimatesCostAndLatency(t *testing.T) {
+	graph := NewDAGGraph()
+	graph.AddNode(&DAGNode{ID: "plan", Type: NodeTypeAction, Step: &LLMStep{Model: "gpt-4o", MaxTokens: 500}})
+	graph.AddNode(&DAGNode{ID: "loop", Type: NodeTypeLoop, LoopConfig: &LoopConfig{Type: LoopTypeFor, MaxIterations: 3}})
+	graph.AddNode(&DAGNode{ID: "write", Type: NodeTypeAction, Step: &LLMStep{Model: "mini"}})
+	graph.AddNode(&DAGNode{ID: "fetch", Type: NodeTypeAction, Step: &ToolStep{ToolName: "http"}, ErrorConfig: &ErrorConfig{Strategy: ErrorStrategyFailFast}})
+	graph.AddNode(&DAGNode{ID: "custom", Type: NodeTypeAction, Step: &CodeStep{}, Metadata: map[string]any{"step_type": "llm", "model": "unknown-model"}})
+	graph.AddEdge("plan", "loop")
+	graph.AddEdge("loop", "write")
+	graph.AddEdge("plan", "fetch")
+	graph.AddEdge("write", "custom")
+	graph.SetEntry("plan")
+
+	prices := map[string][2]float64{"gpt-4o": {0.005, 0.015}, "mini": {0.001, 0.002}}
+	report := AnalyzeDAG(graph, DAGAnalysisConfig{
+		ModelCatalog: types.NewModelCatalog([]types.ModelDescriptor{{Provider: "openai", ID: "mini", MaxOutputTokens: 200}}),
+		Provider:     "openai",
+		Prices: func(model string) (float64, float64, bool) {
+			p, ok := prices[model]
+			return p[0], p[1], ok
+		},
+		NodeStats:         map[string]NodeStats{"fetch": {Samples: 4, AvgDuration: 10 * time.Second}},
+		DefaultLLMLatency: time.Second,
+	})
+
+	byID := make(map[string]NodeEstimate)
+	for _, ne := range report.Estimate.Nodes {
+		byID[ne.NodeID] = ne
+	}
+	assert.InDelta(t, 1.0*0.005+0.5*0.015, byID["plan"].CostUSD, 1e-9)
+	assert.Equal(t, 3, byID["write"].Executions)
+	assert.Equal(t, 200, byID["write"].OutputTokens, "catalog bounds output tokens")
+	assert.InDelta(t, 3*(1.0*0.001+0.2*0.002), byID["write"].CostUSD, 1e-9)
+	assert.Equal(t, "history", byID["fetch"].LatencySource)
+	assert.Equal(t, 3, byID["custom"].Executions)
+	assert.Equal(t, []string{"unknown-model"}, report.Estimate.UnpricedModels)
+	assert.InDelta(t, byID["plan"].CostUSD+byID["write"].CostUSD, report.Estimate.TotalCostUSD, 1e-9)
+
+	// fetch (10s from history) outweighs loop -> write -> custom (3s + 3s + overhead).
+	assert.Equal(t, []string{"plan", "fetch"}, report.Estimate.CriticalPath)
+	assert.Equal(t, byID["plan"].Latency+10*time.Second, report.Estimate.CriticalPathLatency)
+}
+
+func TestNodeStatsFromHistory(t *testing.T) {
+	history := NewExecutionHistory("exec-1", "wf")
+	history.RecordNodeEnd(history.RecordNodeStart("a", NodeTypeAction, nil), nil, nil)
+	failed := history.RecordNodeStart("a", NodeTypeAction, nil)
+	history.RecordNodeEnd(failed, nil, assert.AnError)
+	history.RecordNodeStart("b", NodeTypeAction, nil)
+
+	stats := NodeStatsFromHistory([]*ExecutionHistory{history, nil})
+
+	require.Contains(t, stats, "a")
+	assert.Equal(t, 2, stats["a"].Samples)
+	assert.InDelta(t, 0.5, stats["a"].FailureRate, 1e-9)
+	assert.NotContains(t, stats, "b", "running nodes are ignored")
+}
+
+func TestDAGBuilder_WithAnalysisStoresReportAndFailsOnErrors(t *testing.T) {
+	build := func() *DAGBuilder {
+		b := NewDAGBuilder("analyzed").WithLogger(zap.NewNop())
+		b.AddNode("start", NodeTypeAction).WithStep(&PassthroughStep{}).Done()
+		b.AddNode("tool", NodeTypeAction).WithStep(&ToolStep{ToolName: "http"}).Done()
+		b.AddEdge("start", "tool").SetEntry("start")
+		return b
+	}
+
+	wf, err := build().WithAnalysis(DAGAnalysisConfig{}).Build()
+	require.NoError(t, err)
+	report, ok := wf.GetMetadata("analysis")
+	require.True(t, ok)
+	assert.Contains(t, findingCodes(report.(*DAGAnalysisReport), "tool"), FindingMissingErrorPolicy)
+
+	b := build()
+	b.AddNode("check", NodeTypeCondition).
+		WithCondition(func(context.Context, any) (bool, error) { return true, nil }).
+		WithOnTrue("missing").
+		Done()
+	b.AddEdge("tool", "check")
+	_, err = b.WithAnalysis(DAGAnalysisConfig{}).Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), FindingDanglingReference)
+}
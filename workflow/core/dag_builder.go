@@ -12,6 +12,8 @@ type DAGBuilder struct {
 	name   string
 	desc   string
 	logger *zap.Logger
+
+	analysis *DAGAnalysisConfig
 }
 
 // NewDAGBuilder creates a new DAG builder with the given name
@@ -36,6 +38,19 @@ func (b *DAGBuilder) WithLogger(logger *zap.Logger) *DAGBuilder {
 	return b
 }
 
+// WithAnalysis enables the static analysis pass in Build. Error findings fail
+// the build, warnings are logged, and the report is stored in the workflow
+// metadata under "analysis".
+func (b *DAGBuilder) WithAnalysis(cfg DAGAnalysisConfig) *DAGBuilder {
+	b.analysis = &cfg
+	return b
+}
+
+// Analyze runs the static analysis pass over the graph built so far.
+func (b *DAGBuilder) Analyze(cfg DAGAnalysisConfig) *DAGAnalysisReport {
+	return AnalyzeDAG(b.graph, cfg)
+}
+
 // AddNode adds a node to the graph and returns a NodeBuilder for configuration
 func (b *DAGBuilder) AddNode(id string, nodeType NodeType) *NodeBuilder {
 	node := &DAGNode{
@@ -70,8 +85,26 @@ func (b *DAGBuilder) Build() (*DAGWorkflow, error) {
 		return nil, fmt.Errorf("DAG validation failed: %w", err)
 	}
 
+	var report *DAGAnalysisReport
+	if b.analysis != nil {
+		report = AnalyzeDAG(b.graph, *b.analysis)
+		if err := report.Err(); err != nil {
+			return nil, fmt.Errorf("DAG analysis failed: %w", err)
+		}
+		for _, finding := range report.BySeverity(AnalysisSeverityWarning) {
+			b.logger.Warn("DAG analysis warning",
+				zap.String("code", finding.Code),
+				zap.String("node_id", finding.NodeID),
+				zap.String("message", finding.Message),
+			)
+		}
+	}
+
 	// Create the workflow
 	workflow := NewDAGWorkflow(b.name, b.desc, b.graph)
+	if report != nil {
+		workflow.SetMetadata("analysis", report)
+	}
 
 	b.logger.Info("DAG workflow built successfully",
 		zap.String("name", b.name),
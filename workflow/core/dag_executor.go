@@ -21,6 +21,10 @@ type DAGExecutor struct {
 	logger          *zap.Logger
 	circuitBreakers *CircuitBreakerRegistry
 
+	// stateStore backs StateChannel reads/writes; nil disables state binding.
+	stateStore      StateStore
+	stateWorkflowID string
+
 	// executeMu serializes concurrent Execute() calls on the same executor instance.
 	// Bug fix: without this, concurrent Execute() calls would reset shared state
 	// (visitedNodes, nodeResults, etc.) causing data races. (P0 — non-reentrant safety)
@@ -83,6 +87,14 @@ func (e *DAGExecutor) SetCircuitBreakerConfig(config CircuitBreakerConfig, handl
 	e.circuitBreakers = NewCircuitBreakerRegistry(config, handler, e.logger)
 }
 
+// SetStateStore binds state channels to store for every execution.
+// workflowID keys StateScopeWorkflow channels so state accumulates across runs;
+// each execution's ID is used as the run ID for StateScopeRun channels.
+func (e *DAGExecutor) SetStateStore(store StateStore, workflowID string) {
+	e.stateStore = store
+	e.stateWorkflowID = workflowID
+}
+
 // GetCircuitBreakerStates 获取所有熔断器状态
 func (e *DAGExecutor) GetCircuitBreakerStates() map[string]CircuitState {
 	return e.circuitBreakers.GetAllStates()
@@ -111,6 +123,15 @@ func (e *DAGExecutor) Execute(ctx context.Context, graph *DAGGraph, input any) (
 	e.history = NewExecutionHistory(e.executionID, "")
 	e.mu.Unlock()
 
+	// Subgraphs inherit the parent's binding so run-scoped state stays shared.
+	if _, bound := StateBindingFromContext(ctx); !bound && e.stateStore != nil {
+		ctx = WithStateBinding(ctx, StateBinding{
+			Store:      e.stateStore,
+			WorkflowID: e.stateWorkflowID,
+			RunID:      e.executionID,
+		})
+	}
+
 	traceID, _ := types.TraceID(ctx)
 	e.logger.Info("starting DAG execution",
 		zap.String("trace_id", traceID),
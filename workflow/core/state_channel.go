@@ -0,0 +1,273 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// 状态通道错误。
+var (
+	ErrStateUnbound  = errors.New("state channel: no state store bound to context")
+	ErrStateConflict = errors.New("state channel: too many concurrent write conflicts")
+)
+
+// defaultStateConflictRetries bounds the optimistic read-reduce-write loop.
+const defaultStateConflictRetries = 16
+
+// StateScope controls how long a state channel's value lives.
+type StateScope string
+
+const (
+	// StateScopeRun keeps state private to a single workflow execution.
+	StateScopeRun StateScope = "run"
+	// StateScopeWorkflow shares state across all runs of the same workflow,
+	// e.g. for accumulating results over scheduled runs.
+	StateScopeWorkflow StateScope = "workflow"
+)
+
+// StateStore persists state channel values with optimistic concurrency.
+// Version 0 means the key does not exist; every successful CompareAndSwap
+// increments the version by one.
+type StateStore interface {
+	Load(ctx context.Context, key string) (data []byte, version int64, err error)
+	CompareAndSwap(ctx context.Context, key string, expected int64, data []byte) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// StateBinding ties state channels to a store and to the executing workflow/run.
+type StateBinding struct {
+	Store      StateStore
+	WorkflowID string
+	RunID      string
+}
+
+type stateBindingKey struct{}
+
+// WithStateBinding attaches a state binding to ctx. DAGExecutor does this
+// automatically when a state store is configured.
+func WithStateBinding(ctx context.Context, binding StateBinding) context.Context {
+	return context.WithValue(ctx, stateBindingKey{}, binding)
+}
+
+// StateBindingFromContext returns the state binding attached to ctx.
+func StateBindingFromContext(ctx context.Context) (StateBinding, bool) {
+	binding, ok := ctx.Value(stateBindingKey{}).(StateBinding)
+	return binding, ok && binding.Store != nil
+}
+
+// Reducer merges an update into the current value. It must be deterministic
+// because it may be re-applied when a concurrent write wins the race.
+type Reducer[T any] func(current, update T) (T, error)
+
+// ReplaceReducer keeps the latest written value.
+func ReplaceReducer[T any]() Reducer[T] {
+	return func(_, update T) (T, error) { return update, nil }
+}
+
+// AppendReducer appends updates to a slice.
+func AppendReducer[E any]() Reducer[[]E] {
+	return func(current, update []E) ([]E, error) {
+		return append(current, update...), nil
+	}
+}
+
+// MergeMapReducer merges update keys into the current map, overwriting existing keys.
+func MergeMapReducer[K comparable, V any]() Reducer[map[K]V] {
+	return func(current, update map[K]V) (map[K]V, error) {
+		if current == nil {
+			current = make(map[K]V, len(update))
+		}
+		maps.Copy(current, update)
+		return current, nil
+	}
+}
+
+// SumReducer adds numeric updates to the current value.
+func SumReducer[N ~int | ~int32 | ~int64 | ~float32 | ~float64]() Reducer[N] {
+	return func(current, update N) (N, error) { return current + update, nil }
+}
+
+// StateChannel is a named, typed, persistent state slot shared by workflow
+// steps. Writes go through the reducer with optimistic concurrency, so
+// concurrent steps (or concurrent runs for StateScopeWorkflow) never lose updates.
+type StateChannel[T any] struct {
+	name       string
+	reducer    Reducer[T]
+	scope      StateScope
+	maxRetries int
+}
+
+// NewStateChannel creates a state channel. A nil reducer means ReplaceReducer;
+// an empty scope means StateScopeRun.
+func NewStateChannel[T any](name string, reducer Reducer[T], scope StateScope) *StateChannel[T] {
+	if reducer == nil {
+		reducer = ReplaceReducer[T]()
+	}
+	if scope == "" {
+		scope = StateScopeRun
+	}
+	return &StateChannel[T]{
+		name:       strings.TrimSpace(name),
+		reducer:    reducer,
+		scope:      scope,
+		maxRetries: defaultStateConflictRetries,
+	}
+}
+
+// Name returns the channel name.
+func (c *StateChannel[T]) Name() string { return c.name }
+
+// Scope returns the channel scope.
+func (c *StateChannel[T]) Scope() StateScope { return c.scope }
+
+// Get returns the current value, or the zero value when nothing has been written.
+func (c *StateChannel[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	binding, key, err := c.resolve(ctx)
+	if err != nil {
+		return zero, err
+	}
+	data, _, err := binding.Store.Load(ctx, key)
+	if err != nil {
+		return zero, fmt.Errorf("load state channel %q: %w", c.name, err)
+	}
+	return c.decode(data)
+}
+
+// Update merges update into the stored value via the reducer and returns the merged value.
+func (c *StateChannel[T]) Update(ctx context.Context, update T) (T, error) {
+	var zero T
+	binding, key, err := c.resolve(ctx)
+	if err != nil {
+		return zero, err
+	}
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		data, version, err := binding.Store.Load(ctx, key)
+		if err != nil {
+			return zero, fmt.Errorf("load state channel %q: %w", c.name, err)
+		}
+		current, err := c.decode(data)
+		if err != nil {
+			return zero, err
+		}
+		merged, err := c.reducer(current, update)
+		if err != nil {
+			return zero, fmt.Errorf("reduce state channel %q: %w", c.name, err)
+		}
+		encoded, err := json.Marshal(merged)
+		if err != nil {
+			return zero, fmt.Errorf("encode state channel %q: %w", c.name, err)
+		}
+		ok, err := binding.Store.CompareAndSwap(ctx, key, version, encoded)
+		if err != nil {
+			return zero, fmt.Errorf("write state channel %q: %w", c.name, err)
+		}
+		if ok {
+			return merged, nil
+		}
+	}
+	return zero, fmt.Errorf("%w: channel %q", ErrStateConflict, c.name)
+}
+
+// Reset deletes the stored value.
+func (c *StateChannel[T]) Reset(ctx context.Context) error {
+	binding, key, err := c.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return binding.Store.Delete(ctx, key)
+}
+
+func (c *StateChannel[T]) resolve(ctx context.Context) (StateBinding, string, error) {
+	binding, ok := StateBindingFromContext(ctx)
+	if !ok {
+		return StateBinding{}, "", ErrStateUnbound
+	}
+	key, err := StateKey(binding, c.name, c.scope)
+	return binding, key, err
+}
+
+func (c *StateChannel[T]) decode(data []byte) (T, error) {
+	var value T
+	if len(data) == 0 {
+		return value, nil
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("decode state channel %q: %w", c.name, err)
+	}
+	return value, nil
+}
+
+// StateKey builds the storage key for a channel under the given binding.
+func StateKey(binding StateBinding, name string, scope StateScope) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("state channel name is required")
+	}
+	workflowID := strings.TrimSpace(binding.WorkflowID)
+	switch scope {
+	case StateScopeWorkflow:
+		if workflowID == "" {
+			return "", fmt.Errorf("state channel %q: workflow scope requires a workflow ID", name)
+		}
+		return "wf:" + workflowID + ":state:" + name, nil
+	case StateScopeRun, "":
+		runID := strings.TrimSpace(binding.RunID)
+		if runID == "" {
+			return "", fmt.Errorf("state channel %q: run scope requires a run ID", name)
+		}
+		return "wf:" + workflowID + ":run:" + runID + ":state:" + name, nil
+	default:
+		return "", fmt.Errorf("state channel %q: unknown scope %q", name, scope)
+	}
+}
+
+// InMemoryStateStore is a process-local StateStore for tests and single-node use.
+type InMemoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]inMemoryStateEntry
+}
+
+type inMemoryStateEntry struct {
+	data    []byte
+	version int64
+}
+
+// NewInMemoryStateStore creates an in-memory state store.
+func NewInMemoryStateStore() *InMemoryStateStore {
+	return &InMemoryStateStore{entries: make(map[string]inMemoryStateEntry)}
+}
+
+func (s *InMemoryStateStore) Load(_ context.Context, key string) ([]byte, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, 0, nil
+	}
+	return append([]byte(nil), entry.data...), entry.version, nil
+}
+
+func (s *InMemoryStateStore) CompareAndSwap(_ context.Context, key string, expected int64, data []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries[key].version != expected {
+		return false, nil
+	}
+	s.entries[key] = inMemoryStateEntry{data: append([]byte(nil), data...), version: expected + 1}
+	return true, nil
+}
+
+func (s *InMemoryStateStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateChannel_Unbound(t *testing.T) {
+	ch := NewStateChannel[int]("count", SumReducer[int](), StateScopeRun)
+	_, err := ch.Get(context.Background())
+	assert.ErrorIs(t, err, ErrStateUnbound)
+}
+
+func TestStateChannel_ConcurrentUpdatesMerge(t *testing.T) {
+	ctx := WithStateBinding(context.Background(), StateBinding{
+		Store: NewInMemoryStateStore(), WorkflowID: "wf", RunID: "run-1",
+	})
+	counter := NewStateChannel[int]("count", SumReducer[int](), StateScopeRun)
+	items := NewStateChannel[[]string]("items", AppendReducer[string](), StateScopeRun)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := counter.Update(ctx, 1)
+			assert.NoError(t, err)
+			_, err = items.Update(ctx, []string{"x"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	total, err := counter.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, total)
+	list, err := items.Get(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 8)
+}
+
+func TestStateChannel_MergeMapAndReset(t *testing.T) {
+	ctx := WithStateBinding(context.Background(), StateBinding{
+		Store: NewInMemoryStateStore(), WorkflowID: "wf", RunID: "run-1",
+	})
+	ch := NewStateChannel[map[string]int]("stats", MergeMapReducer[string, int](), StateScopeRun)
+	_, err := ch.Update(ctx, map[string]int{"a": 1})
+	require.NoError(t, err)
+	merged, err := ch.Update(ctx, map[string]int{"b": 2, "a": 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 3, "b": 2}, merged)
+
+	require.NoError(t, ch.Reset(ctx))
+	got, err := ch.Get(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestStateKey_Scopes(t *testing.T) {
+	binding := StateBinding{WorkflowID: "daily", RunID: "r1"}
+	runKey, err := StateKey(binding, "total", StateScopeRun)
+	require.NoError(t, err)
+	wfKey, err := StateKey(binding, "total", StateScopeWorkflow)
+	require.NoError(t, err)
+	assert.NotEqual(t, runKey, wfKey)
+
+	_, err = StateKey(StateBinding{RunID: "r1"}, "total", StateScopeWorkflow)
+	assert.Error(t, err)
+}
+
+func TestDAGExecutor_StateChannelAcrossRuns(t *testing.T) {
+	store := NewInMemoryStateStore()
+	total := NewStateChannel[int]("total", SumReducer[int](), StateScopeWorkflow)
+	perRun := NewStateChannel[int]("per_run", SumReducer[int](), StateScopeRun)
+
+	graph := NewDAGGraph()
+	graph.AddNode(&DAGNode{ID: "agg", Type: NodeTypeAction, Step: NewFuncStep("agg", func(ctx context.Context, input any) (any, error) {
+		if _, err := perRun.Update(ctx, 1); err != nil {
+			return nil, err
+		}
+		return total.Update(ctx, input.(int))
+	})})
+	graph.SetEntry("agg")
+
+	executor := NewDAGExecutor(nil, nil)
+	executor.SetStateStore(store, "daily-aggregation")
+
+	for _, n := range []int{3, 4, 5} {
+		_, err := executor.Execute(context.Background(), graph, n)
+		require.NoError(t, err)
+	}
+
+	ctx := WithStateBinding(context.Background(), StateBinding{Store: store, WorkflowID: "daily-aggregation"})
+	got, err := total.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 12, got)
+
+	// 每次运行的 run 作用域状态互相隔离
+	runCtx := WithStateBinding(ctx, StateBinding{Store: store, WorkflowID: "daily-aggregation", RunID: executor.executionID})
+	last, err := perRun.Get(runCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, last)
+}
+
+func TestRedisStateStore_CompareAndSwap(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisStateStore(client, RedisStateStoreConfig{})
+	ctx := context.Background()
+
+	data, version, err := store.Load(ctx, "k")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+	assert.Zero(t, version)
+
+	ok, err := store.CompareAndSwap(ctx, "k", 0, []byte(`1`))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.CompareAndSwap(ctx, "k", 0, []byte(`2`))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	data, version, err = store.Load(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, `1`, string(data))
+	assert.Equal(t, int64(1), version)
+
+	bound := WithStateBinding(ctx, StateBinding{Store: store, WorkflowID: "wf"})
+	ch := NewStateChannel[int]("sum", SumReducer[int](), StateScopeWorkflow)
+	for i := 0; i < 3; i++ {
+		_, err := ch.Update(bound, 2)
+		require.NoError(t, err)
+	}
+	sum, err := ch.Get(bound)
+	require.NoError(t, err)
+	assert.Equal(t, 6, sum)
+
+	require.NoError(t, store.Delete(ctx, "k"))
+	assert.False(t, server.Exists(defaultRedisStateKeyPrefix+"k"))
+}
+
+func TestPostgreSQLStateStore_CompareAndSwap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS workflow_state_channels").WillReturnResult(sqlmock.NewResult(0, 0))
+	store, err := NewPostgreSQLStateStore(context.Background(), db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT data, version FROM workflow_state_channels").
+		WithArgs("k").WillReturnError(sql.ErrNoRows)
+	data, version, err := store.Load(ctx, "k")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+	assert.Zero(t, version)
+
+	mock.ExpectExec("INSERT INTO workflow_state_channels").
+		WithArgs("k", []byte(`1`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	ok, err := store.CompareAndSwap(ctx, "k", 0, []byte(`1`))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	mock.ExpectExec("UPDATE workflow_state_channels").
+		WithArgs("k", int64(3), []byte(`2`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	ok, err = store.CompareAndSwap(ctx, "k", 3, []byte(`2`))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
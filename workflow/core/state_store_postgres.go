@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const createStateChannelsTable = `
+CREATE TABLE IF NOT EXISTS workflow_state_channels (
+	key        TEXT PRIMARY KEY,
+	version    BIGINT NOT NULL,
+	data       JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+)`
+
+// PostgreSQLStateStore 基于 PostgreSQL 的状态通道存储，使用 version 列实现乐观并发控制
+type PostgreSQLStateStore struct {
+	db DBClient
+}
+
+// NewPostgreSQLStateStore 创建 PostgreSQL 状态存储并确保表存在
+func NewPostgreSQLStateStore(ctx context.Context, db DBClient) (*PostgreSQLStateStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db must not be nil")
+	}
+	if _, err := db.ExecContext(ctx, createStateChannelsTable); err != nil {
+		return nil, fmt.Errorf("failed to create workflow_state_channels table: %w", err)
+	}
+	return &PostgreSQLStateStore{db: db}, nil
+}
+
+func (s *PostgreSQLStateStore) Load(ctx context.Context, key string) ([]byte, int64, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT data, version FROM workflow_state_channels WHERE key = $1`, key)
+	var (
+		data    []byte
+		version int64
+	)
+	if err := row.Scan(&data, &version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	return data, version, nil
+}
+
+func (s *PostgreSQLStateStore) CompareAndSwap(ctx context.Context, key string, expected int64, data []byte) (bool, error) {
+	now := time.Now().UTC()
+	var (
+		result sql.Result
+		err    error
+	)
+	if expected == 0 {
+		result, err = s.db.ExecContext(ctx, `
+			INSERT INTO workflow_state_channels (key, version, data, updated_at)
+			VALUES ($1, 1, $2, $3)
+			ON CONFLICT (key) DO NOTHING`, key, data, now)
+	} else {
+		result, err = s.db.ExecContext(ctx, `
+			UPDATE workflow_state_channels
+			SET version = version + 1, data = $3, updated_at = $4
+			WHERE key = $1 AND version = $2`, key, expected, data, now)
+	}
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (s *PostgreSQLStateStore) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM workflow_state_channels WHERE key = $1`, key)
+	return err
+}
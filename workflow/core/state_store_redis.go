@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultRedisStateKeyPrefix = "agentflow:workflow:"
+
+// redisStateCASScript 仅当当前版本等于期望版本时写入，并将版本加一
+var redisStateCASScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], 'version') or '0')
+if current ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'version', current + 1, 'data', ARGV[2])
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// RedisStateStoreConfig 配置 Redis 状态存储
+type RedisStateStoreConfig struct {
+	// KeyPrefix 所有键的命名空间前缀，默认 "agentflow:workflow:"
+	KeyPrefix string
+	// TTL 每次写入后刷新的过期时间，0 表示永不过期
+	TTL time.Duration
+}
+
+// RedisStateStore 基于 Redis 哈希的状态通道存储，通过 Lua 脚本实现原子 CAS
+type RedisStateStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStateStore 创建 Redis 状态存储
+func NewRedisStateStore(client redis.UniversalClient, cfg RedisStateStoreConfig) *RedisStateStore {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = defaultRedisStateKeyPrefix
+	}
+	return &RedisStateStore{client: client, prefix: cfg.KeyPrefix, ttl: cfg.TTL}
+}
+
+func (s *RedisStateStore) Load(ctx context.Context, key string) ([]byte, int64, error) {
+	values, err := s.client.HMGet(ctx, s.prefix+key, "version", "data").Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	rawVersion, _ := values[0].(string)
+	rawData, _ := values[1].(string)
+	if rawVersion == "" {
+		return nil, 0, nil
+	}
+	version, err := strconv.ParseInt(rawVersion, 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []byte(rawData), version, nil
+}
+
+func (s *RedisStateStore) CompareAndSwap(ctx context.Context, key string, expected int64, data []byte) (bool, error) {
+	swapped, err := redisStateCASScript.Run(ctx, s.client, []string{s.prefix + key},
+		expected, string(data), s.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
+func (s *RedisStateStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
@@ -22,6 +22,7 @@ const (
 	StepTypeRerank           StepType = "rerank"
 	StepTypeOrchestration    StepType = "orchestration"
 	StepTypeChain            StepType = "chain"
+	StepTypeExternalActivity StepType = "external_activity"
 	StepTypePassthrough      StepType = "passthrough"
 )
 
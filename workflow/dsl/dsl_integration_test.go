@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	workflow "github.com/BaSui01/agentflow/workflow/core"
 	"github.com/BaSui01/agentflow/workflow/steps"
@@ -261,6 +262,78 @@ workflow:
 	assert.NotNil(t, wf)
 }
 
+func TestParse_ExternalActivityStepType(t *testing.T) {
+	yamlData := `
+version: "1.0"
+name: "external-activity-test"
+description: "test"
+variables:
+  queue:
+    type: string
+    default: "image"
+workflow:
+  entry: a
+  nodes:
+    - id: a
+      type: action
+      step_def:
+        type: external_activity
+        activity:
+          task_type: "${queue}.resize"
+          timeout_ms: 60000
+          heartbeat_timeout_ms: 5000
+          start_to_close_timeout_ms: 30000
+          max_retries: 2
+          retry_backoff_ms: 100
+`
+	p := NewParser()
+	wf, err := p.Parse([]byte(yamlData))
+	require.NoError(t, err)
+
+	node, ok := wf.Graph().GetNode("a")
+	require.True(t, ok)
+	adapter, ok := node.Step.(*protocolStepAdapter)
+	require.True(t, ok)
+	step, ok := adapter.step.(*steps.ExternalActivityStep)
+	require.True(t, ok)
+	assert.Equal(t, "image.resize", step.TaskType)
+	assert.Equal(t, time.Minute, step.Timeout)
+	assert.Equal(t, 5*time.Second, step.HeartbeatTimeout)
+	assert.Equal(t, 30*time.Second, step.StartToCloseTimeout)
+	assert.Equal(t, 2, step.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, step.RetryBackoff)
+
+	// 未注入队列时执行失败而非挂起
+	_, err = adapter.Execute(context.Background(), map[string]any{"url": "s3://img.png"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step dependency not configured")
+}
+
+func TestValidator_ExternalActivityStep(t *testing.T) {
+	v := NewValidator()
+	for name, tc := range map[string]struct {
+		def  StepDef
+		want string
+	}{
+		"missing activity":  {StepDef{Type: "external_activity"}, "step ext: external_activity step requires activity definition"},
+		"missing task type": {StepDef{Type: "external_activity", Activity: &ActivityStepDef{}}, "step ext: external_activity step requires task_type"},
+		"negative retries":  {StepDef{Type: "external_activity", Activity: &ActivityStepDef{TaskType: "t", MaxRetries: -1}}, "step ext: external_activity max_retries must not be negative"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dslDef := &WorkflowDSL{
+				Version: "1.0",
+				Name:    "test",
+				Steps:   map[string]StepDef{"ext": tc.def},
+				Workflow: WorkflowNodesDef{
+					Entry: "a",
+					Nodes: []NodeDef{{ID: "a", Type: "action", Step: "ext"}},
+				},
+			}
+			assert.Contains(t, errStrings(v.Validate(dslDef)), tc.want)
+		})
+	}
+}
+
 func TestParse_CustomRegisteredStep(t *testing.T) {
 	// The default branch in resolveStep looks up the stepRegistry for types
 	// not handled by the switch. "hybrid_retrieve" passes the validator but
@@ -0,0 +1,766 @@
+package dsl
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BaSui01/agentflow/agent/adapters/structured"
+	"github.com/BaSui01/agentflow/workflow/core"
+	"github.com/BaSui01/agentflow/workflow/engine"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// 原生 YAML DSL 支持的节点类型
+const (
+	NodeKindLLMCall   = "llm-call"
+	NodeKindToolCall  = "tool-call"
+	NodeKindAgent     = "agent"
+	NodeKindHTTP      = "http"
+	NodeKindCode      = "code"
+	NodeKindCondition = "condition"
+	NodeKindLoop      = "loop"
+	NodeKindParallel  = "parallel"
+)
+
+//go:embed workflow.schema.json
+var nativeSchemaJSON []byte
+
+//go:embed examples/native/*.yaml
+var nativeExamples embed.FS
+
+// Schema 返回原生 YAML DSL 的 JSON Schema，可供编辑器补全与外部校验使用
+func Schema() []byte {
+	return append([]byte(nil), nativeSchemaJSON...)
+}
+
+// Diagnostic 描述一条带源码位置的校验问题
+type Diagnostic struct {
+	// Path 字段路径，如 nodes[2].prompt
+	Path string `json:"path"`
+	// Line / Column 为 YAML 源码中的 1-based 位置，未知时为 0
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// String 以 line:col: path: message 形式渲染
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d: ", d.Line, d.Column)
+	}
+	if d.Path != "" {
+		b.WriteString(d.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// LoadError 汇总一次加载中的全部诊断信息
+type LoadError struct {
+	// Source 文件名或示例名，字节加载时为空
+	Source      string
+	Diagnostics []Diagnostic
+}
+
+// Error 实现 error 接口，每条诊断占一行
+func (e *LoadError) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		if e.Source != "" {
+			lines = append(lines, e.Source+":"+d.String())
+		} else {
+			lines = append(lines, d.String())
+		}
+	}
+	return "invalid workflow: " + strings.Join(lines, "\n")
+}
+
+// Loader 将原生 YAML DSL 校验并编译为 DAGWorkflow，无需编写 Go 处理函数
+type Loader struct {
+	parser     *Parser
+	httpClient *http.Client
+	logger     *zap.Logger
+	schema     *structured.JSONSchema
+	validator  *structured.DefaultValidator
+}
+
+// NewLoader 创建原生 DSL 加载器
+func NewLoader() *Loader {
+	var schema structured.JSONSchema
+	if err := json.Unmarshal(nativeSchemaJSON, &schema); err != nil {
+		panic(fmt.Sprintf("dsl: invalid embedded workflow schema: %v", err))
+	}
+	return &Loader{
+		parser:    NewParser(),
+		logger:    zap.NewNop(),
+		schema:    &schema,
+		validator: structured.NewValidator(),
+	}
+}
+
+// WithStepDependencies 为 llm-call / tool-call / agent 节点注入运行依赖
+func (l *Loader) WithStepDependencies(deps engine.StepDependencies) *Loader {
+	l.parser.WithStepDependencies(deps)
+	return l
+}
+
+// WithHTTPClient 指定 http 节点使用的客户端，默认使用安全 TLS 客户端
+func (l *Loader) WithHTTPClient(client *http.Client) *Loader {
+	l.httpClient = client
+	return l
+}
+
+// WithLogger 设置日志记录器
+func (l *Loader) WithLogger(logger *zap.Logger) *Loader {
+	if logger != nil {
+		l.logger = logger
+	}
+	return l
+}
+
+// Load 从 YAML 字节加载工作流
+func (l *Loader) Load(data []byte) (*core.DAGWorkflow, error) {
+	return l.load("", data)
+}
+
+// LoadFile 从文件加载工作流，诊断信息带文件名
+func (l *Loader) LoadFile(filename string) (*core.DAGWorkflow, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read workflow file: %w", err)
+	}
+	return l.load(filename, data)
+}
+
+// LoadDir 加载目录下全部 *.yaml / *.yml 工作流，按工作流名称索引
+func (l *Loader) LoadDir(dir string) (map[string]*core.DAGWorkflow, error) {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("glob workflows: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	workflows := make(map[string]*core.DAGWorkflow, len(files))
+	for _, file := range files {
+		wf, err := l.LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := workflows[wf.Name()]; exists {
+			return nil, fmt.Errorf("%s: duplicate workflow name %q", file, wf.Name())
+		}
+		workflows[wf.Name()] = wf
+	}
+	return workflows, nil
+}
+
+// LoadFS 加载文件系统中匹配 pattern 的工作流，按工作流名称索引
+func (l *Loader) LoadFS(fsys fs.FS, pattern string) (map[string]*core.DAGWorkflow, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("glob workflows: %w", err)
+	}
+	sort.Strings(matches)
+
+	workflows := make(map[string]*core.DAGWorkflow, len(matches))
+	for _, name := range matches {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("read workflow file: %w", err)
+		}
+		wf, err := l.load(name, data)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := workflows[wf.Name()]; exists {
+			return nil, fmt.Errorf("%s: duplicate workflow name %q", name, wf.Name())
+		}
+		workflows[wf.Name()] = wf
+	}
+	return workflows, nil
+}
+
+// ExampleNames 返回内置示例工作流名称（不含扩展名）
+func ExampleNames() []string {
+	entries, _ := fs.Glob(nativeExamples, "examples/native/*.yaml")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(path.Base(entry), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExampleSource 返回内置示例的 YAML 源码
+func ExampleSource(name string) ([]byte, error) {
+	data, err := nativeExamples.ReadFile("examples/native/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("example workflow %q not found", name)
+	}
+	return data, nil
+}
+
+// LoadExample 加载内置示例工作流
+func (l *Loader) LoadExample(name string) (*core.DAGWorkflow, error) {
+	data, err := ExampleSource(name)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(name+".yaml", data)
+}
+
+func (l *Loader) load(source string, data []byte) (*core.DAGWorkflow, error) {
+	doc, diags := l.validate(data)
+	if len(diags) > 0 {
+		return nil, &LoadError{Source: source, Diagnostics: diags}
+	}
+	wf, err := l.compile(doc)
+	if err != nil {
+		return nil, &LoadError{Source: source, Diagnostics: []Diagnostic{{Message: err.Error()}}}
+	}
+	return wf, nil
+}
+
+// Validate 仅校验 YAML 工作流而不编译，返回按源码位置排序的诊断信息
+func (l *Loader) Validate(data []byte) []Diagnostic {
+	_, diags := l.validate(data)
+	return diags
+}
+
+// nativeWorkflow 原生 DSL 顶层结构（通过 Schema 校验后解码）
+type nativeWorkflow struct {
+	Version     string         `yaml:"version"`
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Inputs      map[string]any `yaml:"inputs"`
+	Start       string         `yaml:"start"`
+	Metadata    map[string]any `yaml:"metadata"`
+	Nodes       []nativeNode   `yaml:"nodes"`
+}
+
+// nativeNode 原生 DSL 节点，字段按 kind 取用
+type nativeNode struct {
+	ID          string            `yaml:"id"`
+	Kind        string            `yaml:"kind"`
+	Description string            `yaml:"description"`
+	Next        []string          `yaml:"next"`
+	OnError     *nativeErrorDef   `yaml:"on_error"`
+	Metadata    map[string]any    `yaml:"metadata"`
+	Model       string            `yaml:"model"`
+	Prompt      string            `yaml:"prompt"`
+	Temperature float64           `yaml:"temperature"`
+	MaxTokens   int               `yaml:"max_tokens"`
+	Tool        string            `yaml:"tool"`
+	Args        map[string]any    `yaml:"args"`
+	Agent       string            `yaml:"agent"`
+	Input       string            `yaml:"input"`
+	Method      string            `yaml:"method"`
+	URL         string            `yaml:"url"`
+	Headers     map[string]string `yaml:"headers"`
+	Query       map[string]string `yaml:"query"`
+	Body        any               `yaml:"body"`
+	TimeoutMs   int               `yaml:"timeout_ms"`
+	Set         map[string]any    `yaml:"set"`
+	Test        map[string]string `yaml:"test"`
+	If          string            `yaml:"if"`
+	Then        []string          `yaml:"then"`
+	Else        []string          `yaml:"else"`
+	While       string            `yaml:"while"`
+	Times       int               `yaml:"times"`
+	MaxIter     int               `yaml:"max_iterations"`
+	Do          []string          `yaml:"do"`
+	Branches    []string          `yaml:"branches"`
+}
+
+type nativeErrorDef struct {
+	Strategy     string `yaml:"strategy"`
+	MaxRetries   int    `yaml:"max_retries"`
+	RetryDelayMs int    `yaml:"retry_delay_ms"`
+	Fallback     any    `yaml:"fallback"`
+}
+
+// isControl 判断节点是否为控制节点（其子节点由自身调度）
+func (n *nativeNode) isControl() bool {
+	switch n.Kind {
+	case NodeKindCondition, NodeKindLoop, NodeKindParallel:
+		return true
+	}
+	return false
+}
+
+// isBlock 判断控制节点是否需要折叠为独立子图（其后还有节点或声明了错误策略）
+func (n *nativeNode) isBlock() bool {
+	return n.isControl() && (len(n.Next) > 0 || n.OnError != nil)
+}
+
+// children 返回控制节点直接调度的子节点
+func (n *nativeNode) children() []string {
+	switch n.Kind {
+	case NodeKindCondition:
+		return append(append([]string(nil), n.Then...), n.Else...)
+	case NodeKindLoop:
+		return n.Do
+	case NodeKindParallel:
+		return n.Branches
+	}
+	return nil
+}
+
+// refs 返回节点引用的全部节点及其字段路径
+func (n *nativeNode) refs(index int) []nodeRef {
+	var refs []nodeRef
+	add := func(field string, ids []string) {
+		for j, id := range ids {
+			refs = append(refs, nodeRef{id: id, path: fmt.Sprintf("nodes[%d].%s[%d]", index, field, j)})
+		}
+	}
+	add("then", n.Then)
+	add("else", n.Else)
+	add("do", n.Do)
+	add("branches", n.Branches)
+	add("next", n.Next)
+	return refs
+}
+
+type nodeRef struct {
+	id   string
+	path string
+}
+
+var reservedNodeIDs = map[string]bool{"input": true, "output": true}
+
+// validate 依次执行 YAML 解析、Schema 校验与语义校验
+func (l *Loader) validate(data []byte) (*nativeWorkflow, []Diagnostic) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []Diagnostic{yamlErrorDiagnostic(err)}
+	}
+	if len(root.Content) == 0 {
+		return nil, []Diagnostic{{Message: "workflow document is empty"}}
+	}
+	locator := yamlLocator{root: root.Content[0]}
+
+	var raw any
+	if err := root.Decode(&raw); err != nil {
+		return nil, []Diagnostic{yamlErrorDiagnostic(err)}
+	}
+	diags := l.validateSchema(raw, locator)
+	if len(diags) > 0 {
+		return nil, sortDiagnostics(diags)
+	}
+
+	var doc nativeWorkflow
+	if err := root.Decode(&doc); err != nil {
+		return nil, []Diagnostic{yamlErrorDiagnostic(err)}
+	}
+	diags = checkSemantics(&doc, locator)
+	if len(diags) > 0 {
+		return nil, sortDiagnostics(diags)
+	}
+	return &doc, nil
+}
+
+// validateSchema 校验顶层结构，再按 kind 对每个节点套用 $defs 中的节点 Schema
+func (l *Loader) validateSchema(raw any, locator yamlLocator) []Diagnostic {
+	var diags []Diagnostic
+	check := func(value any, schema *structured.JSONSchema, prefix string) bool {
+		payload, err := json.Marshal(value)
+		if err != nil {
+			diags = append(diags, locator.diagnostic(prefix, fmt.Sprintf("unsupported value: %v", err)))
+			return false
+		}
+		err = l.validator.Validate(payload, schema)
+		if err == nil {
+			return true
+		}
+		verrs, ok := err.(*structured.ValidationErrors)
+		if !ok {
+			diags = append(diags, locator.diagnostic(prefix, err.Error()))
+			return false
+		}
+		for _, e := range verrs.Errors {
+			diags = append(diags, locator.diagnostic(joinSchemaPath(prefix, e.Path), e.Message))
+		}
+		return false
+	}
+
+	if !check(raw, l.schema, "") {
+		return diags
+	}
+	nodes, _ := raw.(map[string]any)["nodes"].([]any)
+	for i, node := range nodes {
+		prefix := fmt.Sprintf("nodes[%d]", i)
+		if !check(node, l.schema.Defs["node"], prefix) {
+			continue
+		}
+		kind, _ := node.(map[string]any)["kind"].(string)
+		check(node, l.schema.Defs[kind], prefix)
+	}
+	return diags
+}
+
+func joinSchemaPath(prefix, p string) string {
+	switch {
+	case prefix == "":
+		return p
+	case p == "":
+		return prefix
+	case strings.HasPrefix(p, "["):
+		return prefix + p
+	default:
+		return prefix + "." + p
+	}
+}
+
+var templatePattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// checkSemantics 校验 Schema 无法表达的约束：引用、表达式、模板与图结构
+func checkSemantics(doc *nativeWorkflow, locator yamlLocator) []Diagnostic {
+	var diags []Diagnostic
+	report := func(p, format string, args ...any) {
+		diags = append(diags, locator.diagnostic(p, fmt.Sprintf(format, args...)))
+	}
+
+	index := make(map[string]int, len(doc.Nodes))
+	for i := range doc.Nodes {
+		n := &doc.Nodes[i]
+		idPath := fmt.Sprintf("nodes[%d].id", i)
+		if reservedNodeIDs[n.ID] {
+			report(idPath, "node id %q is reserved", n.ID)
+		}
+		if first, ok := index[n.ID]; ok {
+			report(idPath, "duplicate node id %q (first defined at nodes[%d])", n.ID, first)
+			continue
+		}
+		index[n.ID] = i
+	}
+
+	if doc.Start != "" {
+		if _, ok := index[doc.Start]; !ok {
+			report("start", "unknown node %q", doc.Start)
+		}
+	}
+
+	for i := range doc.Nodes {
+		n := &doc.Nodes[i]
+		for _, ref := range n.refs(i) {
+			if _, ok := index[ref.id]; !ok {
+				report(ref.path, "unknown node %q", ref.id)
+			} else if ref.id == n.ID {
+				report(ref.path, "node %q cannot reference itself", ref.id)
+			}
+		}
+
+		prefix := fmt.Sprintf("nodes[%d]", i)
+		switch n.Kind {
+		case NodeKindLoop:
+			if (n.While == "") == (n.Times == 0) {
+				report(prefix, "loop requires exactly one of while or times")
+			}
+			if n.While != "" {
+				checkExpression(n.While, prefix+".while", report)
+			}
+		case NodeKindCondition:
+			checkExpression(n.If, prefix+".if", report)
+		case NodeKindCode:
+			if len(n.Set) == 0 && len(n.Test) == 0 {
+				report(prefix, "code node requires set or test")
+			}
+			for _, key := range sortedKeys(n.Test) {
+				checkExpression(n.Test[key], prefix+".test."+key, report)
+			}
+		}
+		checkTemplates(nodeTemplates(n), prefix, index, report)
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+
+	diags = append(diags, checkGraph(doc, index, locator)...)
+	return diags
+}
+
+func checkExpression(expr, p string, report func(p, format string, args ...any)) {
+	if strings.Contains(expr, "${") {
+		report(p, "expressions reference values directly (e.g. fetch.status), not via ${...}")
+		return
+	}
+	eval := &exprEvaluator{}
+	if _, err := eval.Evaluate(expr, map[string]any{}); err != nil {
+		report(p, "invalid expression %q: %v", expr, err)
+	}
+}
+
+// nodeTemplates 返回节点中可包含 ${...} 模板的字段（相对路径 -> 值）
+func nodeTemplates(n *nativeNode) map[string]any {
+	fields := map[string]any{
+		"model":  n.Model,
+		"prompt": n.Prompt,
+		"input":  n.Input,
+		"url":    n.URL,
+		"body":   n.Body,
+	}
+	for k, v := range n.Args {
+		fields["args."+k] = v
+	}
+	for k, v := range n.Headers {
+		fields["headers."+k] = v
+	}
+	for k, v := range n.Query {
+		fields["query."+k] = v
+	}
+	for k, v := range n.Set {
+		fields["set."+k] = v
+	}
+	return fields
+}
+
+func checkTemplates(fields map[string]any, prefix string, index map[string]int, report func(p, format string, args ...any)) {
+	var walk func(p string, v any)
+	walk = func(p string, v any) {
+		switch val := v.(type) {
+		case string:
+			if strings.Count(val, "${") != len(templatePattern.FindAllString(val, -1)) {
+				report(p, "unterminated ${ in template")
+				return
+			}
+			for _, m := range templatePattern.FindAllStringSubmatch(val, -1) {
+				ref := strings.TrimSpace(m[1])
+				if ref == "" {
+					report(p, "empty template reference")
+					continue
+				}
+				root := strings.SplitN(ref, ".", 2)[0]
+				if _, ok := index[root]; !ok && !reservedNodeIDs[root] {
+					report(p, "template ${%s} must start with input, output or a node id", ref)
+				}
+			}
+		case map[string]any:
+			for _, k := range sortedKeys(val) {
+				walk(p+"."+k, val[k])
+			}
+		case []any:
+			for i, item := range val {
+				walk(fmt.Sprintf("%s[%d]", p, i), item)
+			}
+		}
+	}
+	for _, field := range sortedKeys(fields) {
+		walk(prefix+"."+field, fields[field])
+	}
+}
+
+// checkGraph 检查可达性、环以及控制块的封闭性
+func checkGraph(doc *nativeWorkflow, index map[string]int, locator yamlLocator) []Diagnostic {
+	var diags []Diagnostic
+	entry := doc.entry()
+
+	edges := func(n *nativeNode) []string {
+		return append(n.children(), n.Next...)
+	}
+
+	// 环检测
+	state := make(map[string]int, len(doc.Nodes))
+	var stack []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case 1:
+			cycle := append(stack[indexOf(stack, id):], id)
+			diags = append(diags, locator.diagnostic(
+				fmt.Sprintf("nodes[%d]", index[id]),
+				fmt.Sprintf("cycle detected: %s (use a loop node to repeat steps)", strings.Join(cycle, " -> ")),
+			))
+			return true
+		case 2:
+			return false
+		}
+		state[id] = 1
+		stack = append(stack, id)
+		for _, next := range edges(&doc.Nodes[index[id]]) {
+			if visit(next) {
+				return true
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = 2
+		return false
+	}
+	if visit(entry) {
+		return diags
+	}
+
+	for i := range doc.Nodes {
+		if state[doc.Nodes[i].ID] == 0 {
+			diags = append(diags, locator.diagnostic(fmt.Sprintf("nodes[%d].id", i),
+				fmt.Sprintf("node %q is not reachable from start node %q", doc.Nodes[i].ID, entry)))
+		}
+	}
+
+	// 带 next 或 on_error 的控制块会被编译为独立子图，其内部节点不能被块外引用
+	for i := range doc.Nodes {
+		ctrl := &doc.Nodes[i]
+		if !ctrl.isBlock() {
+			continue
+		}
+		inner := doc.reachable(ctrl.children(), edges)
+		for j := range doc.Nodes {
+			n := &doc.Nodes[j]
+			if n.ID == ctrl.ID || inner[n.ID] {
+				continue
+			}
+			for _, ref := range n.refs(j) {
+				if inner[ref.id] {
+					diags = append(diags, locator.diagnostic(ref.path,
+						fmt.Sprintf("node %q belongs to the %s block %q and cannot be referenced from outside it", ref.id, ctrl.Kind, ctrl.ID)))
+				}
+			}
+		}
+		if inner[entry] {
+			diags = append(diags, locator.diagnostic("start",
+				fmt.Sprintf("start node %q is inside the %s block %q", entry, ctrl.Kind, ctrl.ID)))
+		}
+	}
+	return diags
+}
+
+func indexOf(items []string, target string) int {
+	for i, item := range items {
+		if item == target {
+			return i
+		}
+	}
+	return 0
+}
+
+// entry 返回入口节点，未指定 start 时取第一个节点
+func (w *nativeWorkflow) entry() string {
+	if w.Start != "" {
+		return w.Start
+	}
+	return w.Nodes[0].ID
+}
+
+func (w *nativeWorkflow) node(id string) *nativeNode {
+	for i := range w.Nodes {
+		if w.Nodes[i].ID == id {
+			return &w.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// reachable 返回从 roots 出发沿 edges 可达的节点集合
+func (w *nativeWorkflow) reachable(roots []string, edges func(n *nativeNode) []string) map[string]bool {
+	seen := make(map[string]bool)
+	var walk func(id string)
+	walk = func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		if n := w.node(id); n != nil {
+			for _, next := range edges(n) {
+				walk(next)
+			}
+		}
+	}
+	for _, id := range roots {
+		walk(id)
+	}
+	return seen
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortDiagnostics(diags []Diagnostic) []Diagnostic {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		if diags[i].Column != diags[j].Column {
+			return diags[i].Column < diags[j].Column
+		}
+		return diags[i].Path < diags[j].Path
+	})
+	return diags
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func yamlErrorDiagnostic(err error) Diagnostic {
+	d := Diagnostic{Message: fmt.Sprintf("parse YAML: %v", err)}
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		d.Line, _ = strconv.Atoi(m[1])
+	}
+	return d
+}
+
+// yamlLocator 将 Schema 路径映射回 YAML 源码位置
+type yamlLocator struct {
+	root *yaml.Node
+}
+
+var pathSegmentPattern = regexp.MustCompile(`([^.\[\]]+)|\[(\d+)\]`)
+
+// locate 返回路径对应的位置（映射字段取键的位置）；路径缺失时回退到最近的已存在祖先（如缺失的必填字段）
+func (y yamlLocator) locate(p string) (int, int) {
+	current := y.root
+	if current == nil {
+		return 0, 0
+	}
+	line, col := current.Line, current.Column
+	for _, m := range pathSegmentPattern.FindAllStringSubmatch(p, -1) {
+		var next *yaml.Node
+		if m[2] != "" {
+			idx, _ := strconv.Atoi(m[2])
+			if current.Kind == yaml.SequenceNode && idx < len(current.Content) {
+				next = current.Content[idx]
+				line, col = next.Line, next.Column
+			}
+		} else if current.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(current.Content); i += 2 {
+				if current.Content[i].Value == m[1] {
+					key := current.Content[i]
+					line, col = key.Line, key.Column
+					next = current.Content[i+1]
+					break
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		current = next
+	}
+	return line, col
+}
+
+func (y yamlLocator) diagnostic(p, message string) Diagnostic {
+	line, col := y.locate(p)
+	return Diagnostic{Path: p, Line: line, Column: col, Message: message}
+}
@@ -0,0 +1,287 @@
+package dsl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	workflow "github.com/BaSui01/agentflow/workflow/core"
+	"github.com/BaSui01/agentflow/workflow/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scriptedGateway struct {
+	mu      sync.Mutex
+	reply   string
+	prompts []string
+}
+
+func (g *scriptedGateway) Invoke(ctx context.Context, req *workflow.LLMRequest) (*workflow.LLMResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prompts = append(g.prompts, req.Prompt)
+	return &workflow.LLMResponse{Content: g.reply, Model: req.Model}, nil
+}
+
+func (g *scriptedGateway) Stream(ctx context.Context, req *workflow.LLMRequest) (<-chan workflow.LLMStreamChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+type recordingToolRegistry struct {
+	mu    sync.Mutex
+	calls map[string]map[string]any
+	fail  map[string]bool
+}
+
+func (r *recordingToolRegistry) ExecuteTool(ctx context.Context, name string, params map[string]any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = make(map[string]map[string]any)
+	}
+	r.calls[name] = params
+	if r.fail[name] {
+		return nil, errors.New("tool unavailable")
+	}
+	return "result-of-" + name, nil
+}
+
+type echoAgentExecutor struct{}
+
+func (echoAgentExecutor) Execute(ctx context.Context, input map[string]any) (*workflow.AgentExecutionOutput, error) {
+	return &workflow.AgentExecutionOutput{Content: input["agent_id"].(string) + ": " + input["content"].(string)}, nil
+}
+
+func TestLoaderLoadsBuiltinExamples(t *testing.T) {
+	names := ExampleNames()
+	assert.Equal(t, []string{"parallel_research", "poll_until_ready", "support_triage"}, names)
+
+	loader := NewLoader()
+	for _, name := range names {
+		wf, err := loader.LoadExample(name)
+		require.NoError(t, err, name)
+		meta, _ := wf.GetMetadata("dsl")
+		assert.Equal(t, "native", meta)
+	}
+
+	_, err := loader.LoadExample("missing")
+	assert.Error(t, err)
+	assert.Contains(t, string(Schema()), `"llm-call"`)
+}
+
+func TestLoaderReportsSchemaErrorsWithLocations(t *testing.T) {
+	src := `version: v1
+name: broken
+nodes:
+  - id: ask
+    kind: llm-call
+    temperature: 0.2
+    next: [fetch]
+  - id: fetch
+    kind: http
+    url: ftp://example.com
+    retries: 3
+  - id: other
+    kind: shell
+`
+	_, err := NewLoader().Load([]byte(src))
+	var loadErr *LoadError
+	require.True(t, errors.As(err, &loadErr))
+
+	byPath := make(map[string]Diagnostic)
+	for _, d := range loadErr.Diagnostics {
+		byPath[d.Path] = d
+	}
+	require.Contains(t, byPath, "nodes[0].prompt")
+	assert.Equal(t, 4, byPath["nodes[0].prompt"].Line, "missing field points at its node")
+	assert.Contains(t, byPath["nodes[0].prompt"].Message, "required")
+
+	require.Contains(t, byPath, "nodes[1].url")
+	assert.Equal(t, 10, byPath["nodes[1].url"].Line)
+	require.Contains(t, byPath, "nodes[1].retries")
+	assert.Equal(t, 11, byPath["nodes[1].retries"].Line)
+	assert.Equal(t, 5, byPath["nodes[1].retries"].Column)
+
+	require.Contains(t, byPath, "nodes[2].kind")
+	assert.Equal(t, 13, byPath["nodes[2].kind"].Line)
+	assert.Contains(t, err.Error(), "11:5: nodes[1].retries: additional property not allowed")
+}
+
+func TestLoaderReportsSemanticErrors(t *testing.T) {
+	src := `version: v1
+name: semantic
+nodes:
+  - id: start
+    kind: code
+    set:
+      greeting: "hi ${missing.name}"
+    next: [check, nowhere]
+  - id: check
+    kind: condition
+    if: start.greeting ==
+    then: [start]
+  - id: stray
+    kind: code
+    test:
+      ok: "true"
+`
+	diags := NewLoader().Validate([]byte(src))
+	require.NotEmpty(t, diags)
+
+	messages := make(map[string]string)
+	for _, d := range diags {
+		messages[d.Path] = d.Message
+	}
+	assert.Contains(t, messages["nodes[0].set.greeting"], "must start with input, output or a node id")
+	assert.Contains(t, messages["nodes[0].next[1]"], `unknown node "nowhere"`)
+	assert.Contains(t, messages["nodes[1].if"], "invalid expression")
+
+	// 结构正确后才检查环与可达性
+	cyclic := `version: v1
+name: cyclic
+nodes:
+  - id: a
+    kind: code
+    set: {x: 1}
+    next: [b]
+  - id: b
+    kind: code
+    set: {y: 2}
+    next: [a]
+  - id: orphan
+    kind: code
+    set: {z: 3}
+`
+	diags = NewLoader().Validate([]byte(cyclic))
+	require.Len(t, diags, 1)
+	assert.Contains(t, diags[0].Message, "cycle detected: a -> b -> a")
+
+	acyclic := strings.Replace(cyclic, "    next: [a]\n", "", 1)
+	diags = NewLoader().Validate([]byte(acyclic))
+	require.Len(t, diags, 1)
+	assert.Equal(t, "nodes[2].id", diags[0].Path)
+	assert.Equal(t, 11, diags[0].Line)
+	assert.Contains(t, diags[0].Message, "not reachable")
+}
+
+func TestSupportTriageExampleRoutesByClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		category  string
+		escalated bool
+	}{
+		{name: "urgent", category: "urgent", escalated: true},
+		{name: "normal", category: "normal", escalated: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &scriptedGateway{reply: tt.category}
+			tools := &recordingToolRegistry{}
+			loader := NewLoader().WithStepDependencies(engine.StepDependencies{
+				Gateway:       gateway,
+				ToolRegistry:  tools,
+				AgentExecutor: echoAgentExecutor{},
+			})
+			wf, err := loader.LoadExample("support_triage")
+			require.NoError(t, err)
+
+			result, err := wf.Execute(context.Background(), map[string]any{"ticket": "site is down", "customer": "acme"})
+			require.NoError(t, err)
+
+			require.Len(t, gateway.prompts, 1)
+			assert.Contains(t, gateway.prompts[0], "Ticket from acme: site is down")
+
+			out, ok := Output(result).(map[string]any)
+			require.True(t, ok)
+			assert.Equal(t, tt.category, out["category"])
+			assert.Equal(t, tt.escalated, out["escalated"])
+			if tt.escalated {
+				assert.Equal(t, "result-of-create_ticket", out["ticket"])
+				assert.Equal(t, map[string]any{"customer": "acme", "summary": "site is down", "priority": "high"}, tools.calls["create_ticket"])
+				assert.Nil(t, out["reply"])
+			} else {
+				assert.Empty(t, tools.calls)
+				assert.Equal(t, "support-agent: Draft a friendly reply to acme: site is down", out["reply"])
+			}
+
+			state, ok := StateOf(result)
+			require.True(t, ok)
+			assert.Contains(t, state.Nodes, "route")
+		})
+	}
+}
+
+func TestPollUntilReadyExampleLoopsOverHTTP(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if n < 3 {
+			_, _ = w.Write([]byte(`{"state":"pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"state":"ready","result":{"rows":42}}`))
+	}))
+	defer server.Close()
+
+	wf, err := NewLoader().WithHTTPClient(server.Client()).LoadExample("poll_until_ready")
+	require.NoError(t, err)
+
+	result, err := wf.Execute(context.Background(), map[string]any{"status_url": server.URL, "token": "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, map[string]any{
+		"state":  "ready",
+		"result": map[string]any{"rows": float64(42)},
+		"ready":  true,
+	}, Output(result))
+}
+
+func TestParallelResearchExampleMergesBranchesAndFallsBack(t *testing.T) {
+	gateway := &scriptedGateway{reply: "final answer"}
+	tools := &recordingToolRegistry{fail: map[string]bool{"docs_search": true}}
+	wf, err := NewLoader().WithStepDependencies(engine.StepDependencies{
+		Gateway:      gateway,
+		ToolRegistry: tools,
+	}).LoadExample("parallel_research")
+	require.NoError(t, err)
+
+	result, err := wf.Execute(context.Background(), map[string]any{"question": "what is agentflow?"})
+	require.NoError(t, err)
+	assert.Equal(t, "final answer", Output(result))
+
+	require.Len(t, gateway.prompts, 1)
+	assert.Contains(t, gateway.prompts[0], "Web results: result-of-web_search")
+	assert.Contains(t, gateway.prompts[0], "Internal docs: []")
+	assert.Equal(t, "what is agentflow?", tools.calls["web_search"]["query"])
+}
+
+func TestLoaderLoadDirReportsFileInDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	src, err := ExampleSource("support_triage")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "triage.yaml"), src, 0o600))
+
+	workflows, err := NewLoader().LoadDir(dir)
+	require.NoError(t, err)
+	assert.Contains(t, workflows, "support-triage")
+
+	bad := filepath.Join(dir, "bad.yml")
+	require.NoError(t, os.WriteFile(bad, []byte("version: v2\nname: bad\nnodes: []\n"), 0o600))
+	_, err = NewLoader().LoadDir(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), bad+":1:1: version")
+	assert.Contains(t, err.Error(), bad+":3:1: nodes")
+}
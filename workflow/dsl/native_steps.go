@@ -0,0 +1,516 @@
+package dsl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BaSui01/agentflow/pkg/tlsutil"
+	"github.com/BaSui01/agentflow/workflow/core"
+	"github.com/BaSui01/agentflow/workflow/engine"
+)
+
+// defaultHTTPNodeTimeout http 节点默认超时
+const defaultHTTPNodeTimeout = 30 * time.Second
+
+// maxHTTPNodeResponseBytes http 节点读取响应体的上限
+const maxHTTPNodeResponseBytes = 10 << 20
+
+// RunState 原生 DSL 工作流在节点之间传递的运行状态
+type RunState struct {
+	// Input 工作流输入（已合并 inputs 默认值）
+	Input any `json:"input"`
+	// Output 最近一个节点的输出
+	Output any `json:"output"`
+	// Nodes 已执行节点的输出（node id -> output）
+	Nodes map[string]any `json:"nodes"`
+}
+
+// vars 构造表达式与模板可见的变量：input、output 以及各节点 id
+func (s RunState) vars() map[string]any {
+	vars := make(map[string]any, len(s.Nodes)+2)
+	for id, out := range s.Nodes {
+		vars[id] = out
+	}
+	vars["input"] = s.Input
+	vars["output"] = s.Output
+	return vars
+}
+
+// with 返回记录了节点输出的新状态，不修改原状态（并行分支共享输入）
+func (s RunState) with(nodeID string, output any) RunState {
+	nodes := make(map[string]any, len(s.Nodes)+1)
+	for id, out := range s.Nodes {
+		nodes[id] = out
+	}
+	nodes[nodeID] = output
+	return RunState{Input: s.Input, Output: output, Nodes: nodes}
+}
+
+// StateOf 从工作流结果中提取运行状态；并行分支或汇聚节点的结果会被合并
+func StateOf(result any) (RunState, bool) {
+	switch v := result.(type) {
+	case RunState:
+		return v, true
+	case map[string]any:
+		if len(v) == 0 {
+			return RunState{}, false
+		}
+		merged := RunState{Nodes: make(map[string]any)}
+		outputs := make(map[string]any, len(v))
+		for _, key := range sortedKeys(v) {
+			branch, ok := StateOf(v[key])
+			if !ok {
+				return RunState{}, false
+			}
+			if merged.Input == nil {
+				merged.Input = branch.Input
+			}
+			for id, out := range branch.Nodes {
+				merged.Nodes[id] = out
+			}
+			outputs[key] = branch.Output
+		}
+		merged.Output = outputs
+		return merged, true
+	}
+	return RunState{}, false
+}
+
+// Output 返回原生 DSL 工作流结果中的最终输出
+func Output(result any) any {
+	if state, ok := StateOf(result); ok {
+		return state.Output
+	}
+	return result
+}
+
+// nativeProgram 编译期共享的工作流级信息
+type nativeProgram struct {
+	doc    *nativeWorkflow
+	loader *Loader
+}
+
+// stateOf 将节点输入规范化为运行状态；入口节点收到的原始输入与 inputs 默认值合并
+func (p *nativeProgram) stateOf(input any) RunState {
+	if state, ok := StateOf(input); ok {
+		return state
+	}
+	return RunState{Input: p.initialInput(input), Nodes: map[string]any{}}
+}
+
+func (p *nativeProgram) initialInput(input any) any {
+	if input != nil {
+		if _, ok := input.(map[string]any); !ok {
+			return input
+		}
+	}
+	merged := make(map[string]any, len(p.doc.Inputs))
+	for k, v := range p.doc.Inputs {
+		merged[k] = v
+	}
+	if m, ok := input.(map[string]any); ok {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// compile 将校验后的原生工作流编译为 DAGWorkflow
+func (l *Loader) compile(doc *nativeWorkflow) (*core.DAGWorkflow, error) {
+	prog := &nativeProgram{doc: doc, loader: l}
+	wf, err := prog.build(doc.Name, doc.Description, doc.entry(), "")
+	if err != nil {
+		return nil, err
+	}
+	wf.SetMetadata("dsl", "native")
+	wf.SetMetadata("version", doc.Version)
+	for k, v := range doc.Metadata {
+		wf.SetMetadata(k, v)
+	}
+	return wf, nil
+}
+
+// build 构建从 entry 可达的图。带 next 或 on_error 的控制块被折叠为一个执行子图的
+// action 节点，因为 DAG 执行器只调度控制节点自身的分支/循环体，不会在其后继续执行；
+// unwrapped 指定子图中以原生控制节点出现的那个块。
+func (p *nativeProgram) build(name, description, entry, unwrapped string) (*core.DAGWorkflow, error) {
+	builder := core.NewDAGBuilder(name).
+		WithDescription(description).
+		WithLogger(p.loader.logger)
+
+	collapsed := func(n *nativeNode) bool {
+		return n.isBlock() && n.ID != unwrapped
+	}
+	members := p.doc.reachable([]string{entry}, func(n *nativeNode) []string {
+		if collapsed(n) {
+			return n.Next
+		}
+		if n.ID == unwrapped {
+			return n.children()
+		}
+		return append(n.children(), n.Next...)
+	})
+
+	for i := range p.doc.Nodes {
+		n := &p.doc.Nodes[i]
+		if !members[n.ID] {
+			continue
+		}
+
+		var nb *core.NodeBuilder
+		switch {
+		case collapsed(n):
+			sub, err := p.build(name+"/"+n.ID, n.Description, n.ID, n.ID)
+			if err != nil {
+				return nil, fmt.Errorf("build %s block %s: %w", n.Kind, n.ID, err)
+			}
+			nb = builder.AddNode(n.ID, core.NodeTypeAction).WithStep(&blockStep{prog: p, node: n, workflow: sub})
+		case n.Kind == NodeKindCondition:
+			nb = builder.AddNode(n.ID, core.NodeTypeCondition).
+				WithCondition(p.condition(n.If)).
+				WithOnTrue(n.Then...)
+			if len(n.Else) > 0 {
+				nb.WithOnFalse(n.Else...)
+			}
+		case n.Kind == NodeKindLoop:
+			nb = builder.AddNode(n.ID, core.NodeTypeLoop).WithLoop(p.loopConfig(n))
+			for _, child := range n.Do {
+				builder.AddEdge(n.ID, child)
+			}
+		case n.Kind == NodeKindParallel:
+			nb = builder.AddNode(n.ID, core.NodeTypeParallel)
+			for _, child := range n.Branches {
+				builder.AddEdge(n.ID, child)
+			}
+		default:
+			nb = builder.AddNode(n.ID, core.NodeTypeAction).WithStep(&nativeStep{prog: p, node: n})
+		}
+
+		nb.WithMetadata("kind", n.Kind)
+		if n.Description != "" {
+			nb.WithMetadata("description", n.Description)
+		}
+		for k, v := range n.Metadata {
+			nb.WithMetadata(k, v)
+		}
+		nb.Done()
+
+		if !n.isControl() || collapsed(n) {
+			for _, next := range n.Next {
+				builder.AddEdge(n.ID, next)
+			}
+		}
+	}
+
+	builder.SetEntry(entry)
+	return builder.Build()
+}
+
+func (p *nativeProgram) condition(expr string) core.ConditionFunc {
+	return func(ctx context.Context, input any) (bool, error) {
+		eval := &exprEvaluator{}
+		result, err := eval.Evaluate(expr, p.stateOf(input).vars())
+		if err != nil {
+			return false, fmt.Errorf("evaluate expression %q: %w", expr, err)
+		}
+		return result, nil
+	}
+}
+
+func (p *nativeProgram) loopConfig(n *nativeNode) core.LoopConfig {
+	if n.Times > 0 {
+		return core.LoopConfig{Type: core.LoopTypeFor, MaxIterations: n.Times}
+	}
+	return core.LoopConfig{
+		Type:          core.LoopTypeWhile,
+		MaxIterations: n.MaxIter,
+		Condition:     p.condition(n.While),
+	}
+}
+
+// blockStep 执行折叠后的控制块子图，使块结束后可以沿 next 继续
+type blockStep struct {
+	prog     *nativeProgram
+	node     *nativeNode
+	workflow *core.DAGWorkflow
+}
+
+func (s *blockStep) Name() string { return s.node.ID }
+
+func (s *blockStep) Execute(ctx context.Context, input any) (any, error) {
+	state := s.prog.stateOf(input)
+	return applyErrorPolicy(ctx, s.node, state, func() (any, error) {
+		result, err := core.NewDAGExecutor(nil, nil).Execute(ctx, s.workflow.Graph(), state)
+		if err != nil {
+			return nil, err
+		}
+		final, ok := StateOf(result)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %T result from block", result)
+		}
+		return final.with(s.node.ID, final.Output), nil
+	})
+}
+
+// nativeStep 执行 llm-call / tool-call / agent / http / code 节点
+type nativeStep struct {
+	prog *nativeProgram
+	node *nativeNode
+}
+
+func (s *nativeStep) Name() string { return s.node.ID }
+
+func (s *nativeStep) Execute(ctx context.Context, input any) (any, error) {
+	state := s.prog.stateOf(input)
+	return applyErrorPolicy(ctx, s.node, state, func() (any, error) {
+		out, err := s.run(ctx, state.vars())
+		if err != nil {
+			return nil, err
+		}
+		return state.with(s.node.ID, out), nil
+	})
+}
+
+func (s *nativeStep) run(ctx context.Context, vars map[string]any) (any, error) {
+	switch s.node.Kind {
+	case NodeKindLLMCall:
+		return s.runEngineStep(ctx, engine.StepSpec{
+			ID:          s.node.ID,
+			Type:        core.StepTypeLLM,
+			Model:       renderText(s.node.Model, vars),
+			Prompt:      renderText(s.node.Prompt, vars),
+			Temperature: s.node.Temperature,
+			MaxTokens:   s.node.MaxTokens,
+		}, nil)
+	case NodeKindToolCall:
+		params, _ := renderValue(s.node.Args, vars).(map[string]any)
+		return s.runEngineStep(ctx, engine.StepSpec{
+			ID:         s.node.ID,
+			Type:       core.StepTypeTool,
+			ToolName:   s.node.Tool,
+			ToolParams: params,
+		}, nil)
+	case NodeKindAgent:
+		content := "${output}"
+		if s.node.Input != "" {
+			content = s.node.Input
+		}
+		return s.runEngineStep(ctx, engine.StepSpec{
+			ID:      s.node.ID,
+			Type:    core.StepTypeAgent,
+			AgentID: s.node.Agent,
+		}, map[string]any{"content": renderText(content, vars)})
+	case NodeKindHTTP:
+		return s.runHTTP(ctx, vars)
+	case NodeKindCode:
+		return s.runCode(vars)
+	}
+	return nil, fmt.Errorf("unsupported node kind %q", s.node.Kind)
+}
+
+// applyErrorPolicy 在步骤内部执行 on_error 策略。DAG 执行器的 ErrorConfig 在重试或跳过后
+// 不会继续调度后继节点，且回退值会丢失运行状态，因此这里自行处理并把回退值记为节点输出。
+func applyErrorPolicy(ctx context.Context, n *nativeNode, state RunState, run func() (any, error)) (any, error) {
+	result, err := run()
+	if err == nil {
+		return result, nil
+	}
+	policy := n.OnError
+	if policy == nil || policy.Strategy == string(core.ErrorStrategyFailFast) {
+		return nil, fmt.Errorf("node %s (%s): %w", n.ID, n.Kind, err)
+	}
+
+	if policy.Strategy == string(core.ErrorStrategyRetry) {
+		retries := policy.MaxRetries
+		if retries <= 0 {
+			retries = 3
+		}
+		delay := time.Duration(policy.RetryDelayMs) * time.Millisecond
+		for attempt := 1; attempt <= retries; attempt++ {
+			if delay > 0 {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(delay):
+				}
+			}
+			if result, err = run(); err == nil {
+				return result, nil
+			}
+		}
+		if policy.Fallback == nil {
+			return nil, fmt.Errorf("node %s (%s) failed after %d retries: %w", n.ID, n.Kind, retries, err)
+		}
+	}
+	return state.with(n.ID, policy.Fallback), nil
+}
+
+// runEngineStep 以渲染后的配置构建一次性引擎步骤并执行，复用 legacy DSL 的步骤适配
+func (s *nativeStep) runEngineStep(ctx context.Context, spec engine.StepSpec, data map[string]any) (any, error) {
+	step, err := s.prog.loader.parser.newEngineBackedStep(spec, s.node.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	return step.Execute(ctx, data)
+}
+
+func (s *nativeStep) runHTTP(ctx context.Context, vars map[string]any) (any, error) {
+	timeout := defaultHTTPNodeTimeout
+	if s.node.TimeoutMs > 0 {
+		timeout = time.Duration(s.node.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	target, err := url.Parse(renderText(s.node.URL, vars))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", target.Scheme)
+	}
+	if len(s.node.Query) > 0 {
+		query := target.Query()
+		for _, k := range sortedKeys(s.node.Query) {
+			query.Set(k, renderText(s.node.Query[k], vars))
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	method := s.node.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	jsonBody := false
+	if s.node.Body != nil {
+		switch v := renderValue(s.node.Body, vars).(type) {
+		case string:
+			body = strings.NewReader(v)
+		default:
+			payload, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("encode body: %w", err)
+			}
+			body = bytes.NewReader(payload)
+			jsonBody = true
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if jsonBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, k := range sortedKeys(s.node.Headers) {
+		req.Header.Set(k, renderText(s.node.Headers[k], vars))
+	}
+
+	client := s.prog.loader.httpClient
+	if client == nil {
+		client = tlsutil.SecureHTTPClient(timeout)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPNodeResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var decoded any = string(raw)
+	var parsed any
+	if len(raw) > 0 && json.Unmarshal(raw, &parsed) == nil {
+		decoded = parsed
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s %s returned status %d", method, target.Redacted(), resp.StatusCode)
+	}
+
+	headers := make(map[string]any, len(resp.Header))
+	for k := range resp.Header {
+		headers[strings.ToLower(k)] = resp.Header.Get(k)
+	}
+	return map[string]any{
+		"status":  resp.StatusCode,
+		"headers": headers,
+		"body":    decoded,
+	}, nil
+}
+
+func (s *nativeStep) runCode(vars map[string]any) (any, error) {
+	out := make(map[string]any, len(s.node.Set)+len(s.node.Test))
+	for _, k := range sortedKeys(s.node.Set) {
+		out[k] = renderValue(s.node.Set[k], vars)
+	}
+	eval := &exprEvaluator{}
+	for _, k := range sortedKeys(s.node.Test) {
+		result, err := eval.Evaluate(s.node.Test[k], vars)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate test %s: %w", k, err)
+		}
+		out[k] = result
+	}
+	return out, nil
+}
+
+// renderValue 递归渲染模板；整串为单个 ${...} 时保留引用值的原始类型
+func renderValue(v any, vars map[string]any) any {
+	switch val := v.(type) {
+	case string:
+		if m := templatePattern.FindStringSubmatchIndex(val); m != nil && m[0] == 0 && m[1] == len(val) {
+			return resolveVar(strings.TrimSpace(val[m[2]:m[3]]), vars)
+		}
+		return renderText(val, vars)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = renderValue(item, vars)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = renderValue(item, vars)
+		}
+		return out
+	}
+	return v
+}
+
+// renderText 将 ${...} 替换为引用值的文本形式，非字符串值按 JSON 输出
+func renderText(s string, vars map[string]any) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return templatePattern.ReplaceAllStringFunc(s, func(match string) string {
+		value := resolveVar(strings.TrimSpace(match[2:len(match)-1]), vars)
+		switch v := value.(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Sprint(v)
+			}
+			return string(data)
+		}
+	})
+}
@@ -414,6 +414,24 @@ func (p *Parser) resolveStep(def *NodeDef, dsl *WorkflowDSL, vars map[string]any
 		}
 		return p.newEngineBackedStep(spec, "chain")
 
+	case string(core.StepTypeExternalActivity):
+		if stepDef.Activity == nil {
+			return nil, fmt.Errorf("external_activity step requires activity definition")
+		}
+		activity := stepDef.Activity
+		spec := engine.StepSpec{
+			ID:                  def.ID,
+			Type:                core.StepTypeExternalActivity,
+			TaskType:            p.interpolate(activity.TaskType, vars),
+			Timeout:             time.Duration(activity.TimeoutMs) * time.Millisecond,
+			HeartbeatTimeout:    time.Duration(activity.HeartbeatTimeoutMs) * time.Millisecond,
+			StartToCloseTimeout: time.Duration(activity.StartToCloseTimeoutMs) * time.Millisecond,
+			MaxRetries:          activity.MaxRetries,
+			RetryBackoff:        time.Duration(activity.RetryBackoffMs) * time.Millisecond,
+			PollInterval:        time.Duration(activity.PollIntervalMs) * time.Millisecond,
+		}
+		return p.newEngineBackedStep(spec, string(core.StepTypeExternalActivity))
+
 	case string(core.StepTypePassthrough):
 		return &core.PassthroughStep{}, nil
 
@@ -478,6 +496,12 @@ func (p *Parser) newEngineBackedStep(spec engine.StepSpec, name string) (core.St
 		name:     name,
 		stepType: spec.Type,
 		step:     node.Step,
+		profile: core.StepProfile{
+			Type:      spec.Type,
+			Model:     spec.Model,
+			Prompt:    spec.Prompt,
+			MaxTokens: spec.MaxTokens,
+		},
 	}, nil
 }
 
@@ -498,6 +522,9 @@ func (p *Parser) effectiveStepDeps() engine.StepDependencies {
 	if deps.AgentResolver == nil {
 		deps.AgentResolver = noopAgentResolver{}
 	}
+	if deps.ExternalTasks == nil {
+		deps.ExternalTasks = noopExternalTaskQueue{}
+	}
 	if deps.CodeHandler == nil {
 		deps.CodeHandler = func(ctx context.Context, input core.StepInput) (map[string]any, error) {
 			return nil, fmt.Errorf("step dependency not configured")
@@ -556,6 +583,7 @@ type protocolStepAdapter struct {
 	name     string
 	stepType core.StepType
 	step     core.StepProtocol
+	profile  core.StepProfile
 }
 
 type noopGateway struct{}
@@ -580,6 +608,20 @@ func (p *noopHumanHandler) RequestInput(ctx context.Context, prompt, inputType s
 	return nil, fmt.Errorf("step dependency not configured")
 }
 
+type noopExternalTaskQueue struct{}
+
+func (noopExternalTaskQueue) Publish(ctx context.Context, task *core.ExternalTask) (string, error) {
+	return "", fmt.Errorf("step dependency not configured")
+}
+
+func (noopExternalTaskQueue) Get(ctx context.Context, taskID string) (*core.ExternalTask, error) {
+	return nil, fmt.Errorf("step dependency not configured")
+}
+
+func (noopExternalTaskQueue) Abort(ctx context.Context, taskID string, status core.ExternalTaskStatus, reason string) error {
+	return fmt.Errorf("step dependency not configured")
+}
+
 type noopAgentExecutor struct{}
 
 func (noopAgentExecutor) Execute(ctx context.Context, input map[string]any) (*core.AgentExecutionOutput, error) {
@@ -599,6 +641,11 @@ func (s *protocolStepAdapter) Name() string {
 	return string(s.stepType)
 }
 
+// Profile describes the wrapped step for core.AnalyzeDAG.
+func (s *protocolStepAdapter) Profile() core.StepProfile {
+	return s.profile
+}
+
 func (s *protocolStepAdapter) Execute(ctx context.Context, input any) (any, error) {
 	stepInput := core.StepInput{Data: make(map[string]any)}
 	if inputMap, ok := input.(map[string]any); ok {
@@ -646,6 +693,10 @@ func (s *protocolStepAdapter) Execute(ctx context.Context, input any) (any, erro
 		if v, ok := out.Data["result"]; ok {
 			return v, nil
 		}
+	case core.StepTypeExternalActivity:
+		if v, ok := out.Data["result"]; ok {
+			return v, nil
+		}
 	}
 	if len(out.Data) == 1 {
 		for _, v := range out.Data {
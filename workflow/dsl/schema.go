@@ -57,7 +57,7 @@ type ToolDef struct {
 
 // StepDef 步骤定义
 type StepDef struct {
-	Type          string                `yaml:"type" json:"type"`                                     // llm, tool, human_input, code, passthrough, orchestration, agent
+	Type          string                `yaml:"type" json:"type"`                                     // llm, tool, human_input, code, passthrough, orchestration, agent, external_activity
 	Agent         string                `yaml:"agent,omitempty" json:"agent,omitempty"`               // Agent ID reference for agent steps.
 	InlineAgent   *AgentDef             `yaml:"inline_agent,omitempty" json:"inline_agent,omitempty"` // Legacy reject-only field. Presence is invalid and must be rejected during validation; agent steps only support `agent`.
 	Tool          string                `yaml:"tool,omitempty" json:"tool,omitempty"`
@@ -65,6 +65,7 @@ type StepDef struct {
 	Config        map[string]any        `yaml:"config,omitempty" json:"config,omitempty"`
 	Orchestration *OrchestrationStepDef `yaml:"orchestration,omitempty" json:"orchestration,omitempty"`
 	Chain         *ChainStepDef         `yaml:"chain,omitempty" json:"chain,omitempty"`
+	Activity      *ActivityStepDef      `yaml:"activity,omitempty" json:"activity,omitempty"`
 	SubGraph      *WorkflowNodesDef     `yaml:"subgraph,omitempty" json:"subgraph,omitempty"`
 }
 
@@ -80,6 +81,17 @@ type ChainStepEntry struct {
 	MaxRetries int               `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
 }
 
+// ActivityStepDef 外部活动步骤定义：任务由进程外 worker 通过 API 领取执行
+type ActivityStepDef struct {
+	TaskType              string `yaml:"task_type" json:"task_type"`
+	TimeoutMs             int    `yaml:"timeout_ms,omitempty" json:"timeout_ms,omitempty"`
+	HeartbeatTimeoutMs    int    `yaml:"heartbeat_timeout_ms,omitempty" json:"heartbeat_timeout_ms,omitempty"`
+	StartToCloseTimeoutMs int    `yaml:"start_to_close_timeout_ms,omitempty" json:"start_to_close_timeout_ms,omitempty"`
+	MaxRetries            int    `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	RetryBackoffMs        int    `yaml:"retry_backoff_ms,omitempty" json:"retry_backoff_ms,omitempty"`
+	PollIntervalMs        int    `yaml:"poll_interval_ms,omitempty" json:"poll_interval_ms,omitempty"`
+}
+
 // OrchestrationStepDef 多 Agent 编排步骤定义
 type OrchestrationStepDef struct {
 	Mode      string   `yaml:"mode" json:"mode"`
@@ -198,6 +198,7 @@ func (v *Validator) validateStepDefinition(stepName string, step StepDef) []erro
 		string(core.StepTypeHybridRetrieve):   true,
 		string(core.StepTypeMultiHopRetrieve): true,
 		string(core.StepTypeRerank):           true,
+		string(core.StepTypeExternalActivity): true,
 	}
 	if !validStepTypes[step.Type] {
 		errs = append(errs, fmt.Errorf("step %s: invalid type %q", stepName, step.Type))
@@ -229,6 +230,14 @@ func (v *Validator) validateStepDefinition(stepName string, step StepDef) []erro
 		} else if len(step.Orchestration.AgentIDs) == 0 {
 			errs = append(errs, fmt.Errorf("step %s: orchestration step requires agent_ids", stepName))
 		}
+	case string(core.StepTypeExternalActivity):
+		if step.Activity == nil {
+			errs = append(errs, fmt.Errorf("step %s: external_activity step requires activity definition", stepName))
+		} else if strings.TrimSpace(step.Activity.TaskType) == "" {
+			errs = append(errs, fmt.Errorf("step %s: external_activity step requires task_type", stepName))
+		} else if step.Activity.MaxRetries < 0 {
+			errs = append(errs, fmt.Errorf("step %s: external_activity max_retries must not be negative", stepName))
+		}
 	case string(core.StepTypeChain):
 		if step.Chain == nil {
 			errs = append(errs, fmt.Errorf("step %s: chain step requires chain definition", stepName))
@@ -25,6 +25,8 @@ type StepDependencies struct {
 	HybridRetriever   workflowsteps.HybridRetriever
 	MultiHopReasoner  workflowsteps.MultiHopReasoner
 	RetrievalReranker workflowsteps.RetrievalReranker
+
+	ExternalTasks core.ExternalTaskQueue
 }
 
 // StepSpec describes one workflow step in a transport-friendly shape.
@@ -53,6 +55,13 @@ type StepSpec struct {
 	OrchestrationTimeout   time.Duration
 
 	ChainSteps []tools.ChainStep
+
+	TaskType            string
+	HeartbeatTimeout    time.Duration
+	StartToCloseTimeout time.Duration
+	MaxRetries          int
+	RetryBackoff        time.Duration
+	PollInterval        time.Duration
 }
 
 // Validate checks that the StepSpec has the minimum required fields.
@@ -141,6 +150,18 @@ func buildStep(spec StepSpec, deps StepDependencies) (core.StepProtocol, error)
 		exec := tools.NewChainExecutor(deps.ChainRegistry, tools.DefaultParallelConfig())
 		step := workflowsteps.NewChainStep(spec.ID, chain, exec)
 		return step, step.Validate()
+	case core.StepTypeExternalActivity:
+		step := workflowsteps.NewExternalActivityStep(spec.ID, deps.ExternalTasks)
+		step.TaskType = spec.TaskType
+		step.Timeout = spec.Timeout
+		step.HeartbeatTimeout = spec.HeartbeatTimeout
+		step.StartToCloseTimeout = spec.StartToCloseTimeout
+		step.MaxRetries = spec.MaxRetries
+		step.RetryBackoff = spec.RetryBackoff
+		if spec.PollInterval > 0 {
+			step.PollInterval = spec.PollInterval
+		}
+		return step, step.Validate()
 	default:
 		return nil, fmt.Errorf("unsupported step type: %s", spec.Type)
 	}
@@ -235,6 +256,13 @@ func DefaultStepRunner(ctx context.Context, step core.StepProtocol, input core.S
 			return core.StepOutput{}, err
 		}
 		return s.Execute(ctx, input)
+	case *workflowsteps.ExternalActivityStep:
+		_ = s.ID()
+		_ = s.Type()
+		if err := s.Validate(); err != nil {
+			return core.StepOutput{}, err
+		}
+		return s.Execute(ctx, input)
 	default:
 		if err := step.Validate(); err != nil {
 			return core.StepOutput{}, err
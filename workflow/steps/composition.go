@@ -0,0 +1,313 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	tools "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	toolexecution "github.com/BaSui01/agentflow/agent/capabilities/tools/execution"
+	"github.com/BaSui01/agentflow/workflow/core"
+	"go.uber.org/zap"
+)
+
+// Step names and node IDs emitted by CompositionPlanner.
+const (
+	CompositionInputStepName      = "composition_input"
+	CompositionCapabilityStepName = "composition_capability"
+	CompositionOutputStepName     = "composition_output"
+
+	CompositionInputNodeID  = "composition_input"
+	CompositionOutputNodeID = "composition_output"
+
+	compositionNodePrefix = "capability_"
+)
+
+// Node metadata keys describing how data flows between composition steps.
+const (
+	CompositionMetaCapability = "capability"
+	CompositionMetaAgentID    = "agent_id"
+	CompositionMetaInputFrom  = "input_from"
+	CompositionMetaOutputKey  = "output_key"
+)
+
+// CompositionPlannerConfig configures the DAG emitted for a composition.
+type CompositionPlannerConfig struct {
+	// Name is the workflow name. Defaults to "capability_composition".
+	Name        string
+	Description string
+	// Error is applied to every capability node when set.
+	Error *core.ErrorDefinition
+}
+
+// CompositionPlanner turns a CompositionResult into an executable DAG:
+// one action node per capability bound to its chosen agent, wired in
+// dependency order. The entry node fans the original input out to every
+// capability and the output node collects all capability results.
+type CompositionPlanner struct {
+	config CompositionPlannerConfig
+	logger *zap.Logger
+}
+
+// NewCompositionPlanner creates a composition planner.
+func NewCompositionPlanner(config CompositionPlannerConfig, logger *zap.Logger) *CompositionPlanner {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if config.Name == "" {
+		config.Name = "capability_composition"
+	}
+	return &CompositionPlanner{
+		config: config,
+		logger: logger.With(zap.String("component", "composition_planner")),
+	}
+}
+
+// CompositionNodeID returns the DAG node ID used for a capability.
+func CompositionNodeID(capability string) string {
+	return compositionNodePrefix + capability
+}
+
+// Plan emits a serializable DAG definition for the composition result.
+func (p *CompositionPlanner) Plan(result *tools.CompositionResult) (*core.DAGDefinition, error) {
+	if result == nil {
+		return nil, fmt.Errorf("composition result is nil")
+	}
+	if !result.Complete {
+		return nil, fmt.Errorf("composition is incomplete: missing capabilities: %v", result.MissingCapabilities)
+	}
+
+	order := result.ExecutionOrder
+	if len(order) == 0 {
+		for capability := range result.CapabilityMap {
+			order = append(order, capability)
+		}
+		sort.Strings(order)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("composition has no capabilities")
+	}
+
+	position := make(map[string]int, len(order))
+	for i, capability := range order {
+		if _, ok := result.CapabilityMap[capability]; !ok {
+			return nil, fmt.Errorf("no agent mapped for capability %s", capability)
+		}
+		if _, dup := position[capability]; dup {
+			return nil, fmt.Errorf("duplicate capability in execution order: %s", capability)
+		}
+		position[capability] = i
+	}
+
+	// Downstream edges per capability, derived from prerequisites.
+	dependents := make(map[string][]string, len(order))
+	for _, capability := range order {
+		for _, dep := range result.Dependencies[capability] {
+			depPos, ok := position[dep]
+			if !ok {
+				return nil, fmt.Errorf("capability %s depends on %s which is not part of the composition", capability, dep)
+			}
+			if depPos >= position[capability] {
+				return nil, fmt.Errorf("capability %s depends on %s which is not ordered before it", capability, dep)
+			}
+			dependents[dep] = append(dependents[dep], CompositionNodeID(capability))
+		}
+	}
+
+	entry := core.NodeDefinition{
+		ID:   CompositionInputNodeID,
+		Type: string(core.NodeTypeAction),
+		Step: CompositionInputStepName,
+	}
+	nodes := make([]core.NodeDefinition, 0, len(order)+2)
+	for _, capability := range order {
+		entry.Next = append(entry.Next, CompositionNodeID(capability))
+
+		inputFrom := append([]string(nil), result.Dependencies[capability]...)
+		nodes = append(nodes, core.NodeDefinition{
+			ID:    CompositionNodeID(capability),
+			Type:  string(core.NodeTypeAction),
+			Step:  CompositionCapabilityStepName,
+			Next:  append(dependents[capability], CompositionOutputNodeID),
+			Error: p.config.Error,
+			Metadata: map[string]any{
+				CompositionMetaCapability: capability,
+				CompositionMetaAgentID:    result.CapabilityMap[capability],
+				CompositionMetaInputFrom:  inputFrom,
+				CompositionMetaOutputKey:  capability,
+			},
+		})
+	}
+	nodes = append([]core.NodeDefinition{entry}, nodes...)
+	nodes = append(nodes, core.NodeDefinition{
+		ID:   CompositionOutputNodeID,
+		Type: string(core.NodeTypeAction),
+		Step: CompositionOutputStepName,
+	})
+
+	capabilityMap := make(map[string]string, len(result.CapabilityMap))
+	for capability, agentID := range result.CapabilityMap {
+		capabilityMap[capability] = agentID
+	}
+	def := &core.DAGDefinition{
+		Name:        p.config.Name,
+		Description: p.config.Description,
+		Entry:       CompositionInputNodeID,
+		Nodes:       nodes,
+		Metadata: map[string]any{
+			"execution_order": append([]string(nil), order...),
+			"capability_map":  capabilityMap,
+		},
+	}
+	if err := core.ValidateDAGDefinition(def); err != nil {
+		return nil, fmt.Errorf("validate composition DAG: %w", err)
+	}
+
+	p.logger.Debug("planned composition DAG",
+		zap.String("workflow", def.Name),
+		zap.Int("capabilities", len(order)),
+	)
+	return def, nil
+}
+
+// Build plans the composition and binds each capability node to a step that
+// invokes its agent through executor, so the workflow runs on the DAG engine.
+func (p *CompositionPlanner) Build(result *tools.CompositionResult, executor tools.AgentExecutor) (*core.DAGWorkflow, error) {
+	if executor == nil {
+		return nil, fmt.Errorf("agent executor is nil")
+	}
+	def, err := p.Plan(result)
+	if err != nil {
+		return nil, err
+	}
+	return BindCompositionDAG(def, executor)
+}
+
+// BindCompositionDAG converts a definition produced by CompositionPlanner
+// (possibly after a serialization round-trip) into an executable workflow.
+func BindCompositionDAG(def *core.DAGDefinition, executor tools.AgentExecutor) (*core.DAGWorkflow, error) {
+	if executor == nil {
+		return nil, fmt.Errorf("agent executor is nil")
+	}
+	wf, err := def.ToDAGWorkflow()
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string][]string)
+	outputKeys := make(map[string]string)
+	for _, nodeDef := range def.Nodes {
+		if nodeDef.Step != CompositionCapabilityStepName {
+			continue
+		}
+		capability, _ := nodeDef.Metadata[CompositionMetaCapability].(string)
+		if capability == "" {
+			return nil, fmt.Errorf("node %s: missing %s metadata", nodeDef.ID, CompositionMetaCapability)
+		}
+		deps[capability] = metadataStrings(nodeDef.Metadata[CompositionMetaInputFrom])
+		outputKey, _ := nodeDef.Metadata[CompositionMetaOutputKey].(string)
+		if outputKey == "" {
+			outputKey = capability
+		}
+		outputKeys[nodeDef.ID] = outputKey
+	}
+
+	for _, nodeDef := range def.Nodes {
+		node, ok := wf.Graph().GetNode(nodeDef.ID)
+		if !ok {
+			continue
+		}
+		switch nodeDef.Step {
+		case CompositionCapabilityStepName:
+			capability, _ := nodeDef.Metadata[CompositionMetaCapability].(string)
+			agentID, _ := nodeDef.Metadata[CompositionMetaAgentID].(string)
+			if agentID == "" {
+				return nil, fmt.Errorf("node %s: missing %s metadata", nodeDef.ID, CompositionMetaAgentID)
+			}
+			node.Step = &CompositionCapabilityStep{
+				Capability: capability,
+				AgentID:    agentID,
+				deps:       deps,
+				executor:   executor,
+			}
+		case CompositionOutputStepName:
+			node.Step = &CompositionOutputStep{outputKeys: outputKeys}
+		}
+	}
+	return wf, nil
+}
+
+// CompositionCapabilityStep invokes one capability on its chosen agent.
+// Its input is {"input": original, "upstream": {dep: output}}, the same shape
+// CompositionExecutor passes to AgentExecutor.
+type CompositionCapabilityStep struct {
+	Capability string
+	AgentID    string
+	deps       map[string][]string
+	executor   tools.AgentExecutor
+}
+
+func (s *CompositionCapabilityStep) Name() string { return CompositionCapabilityStepName }
+
+func (s *CompositionCapabilityStep) Execute(ctx context.Context, input any) (any, error) {
+	original := input
+	var upstream map[string]any
+	// With prerequisites the DAG engine delivers parent outputs keyed by node ID.
+	if len(s.deps[s.Capability]) > 0 {
+		parents, _ := input.(map[string]any)
+		original = parents[CompositionInputNodeID]
+		upstream = parents
+	}
+	capInput := toolexecution.BuildCapabilityInput(s.Capability, original, s.deps, func(dep string) (any, bool) {
+		result, ok := upstream[CompositionNodeID(dep)]
+		return result, ok
+	})
+
+	out, err := s.executor.ExecuteCapability(ctx, s.AgentID, s.Capability, capInput)
+	if err != nil {
+		return nil, fmt.Errorf("capability %s on agent %s: %w", s.Capability, s.AgentID, err)
+	}
+	return out, nil
+}
+
+// CompositionOutputStep collects capability outputs keyed by output key.
+type CompositionOutputStep struct {
+	outputKeys map[string]string
+}
+
+func (s *CompositionOutputStep) Name() string { return CompositionOutputStepName }
+
+func (s *CompositionOutputStep) Execute(_ context.Context, input any) (any, error) {
+	results := make(map[string]any, len(s.outputKeys))
+	if len(s.outputKeys) == 1 {
+		// A single parent's output is delivered as-is.
+		for _, key := range s.outputKeys {
+			results[key] = input
+		}
+		return results, nil
+	}
+	parents, _ := input.(map[string]any)
+	for nodeID, key := range s.outputKeys {
+		if out, ok := parents[nodeID]; ok {
+			results[key] = out
+		}
+	}
+	return results, nil
+}
+
+// metadataStrings accepts both []string and the []any produced by JSON decoding.
+func metadataStrings(v any) []string {
+	switch values := v.(type) {
+	case []string:
+		return values
+	case []any:
+		out := make([]string, 0, len(values))
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
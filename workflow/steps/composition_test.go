@@ -0,0 +1,151 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	tools "github.com/BaSui01/agentflow/agent/capabilities/tools"
+	"github.com/BaSui01/agentflow/workflow/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAgentExecutor struct {
+	mu     sync.Mutex
+	calls  []string
+	inputs map[string]map[string]any
+	fail   string
+}
+
+func (e *recordingAgentExecutor) ExecuteCapability(_ context.Context, agentID, capability string, input any) (any, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.inputs == nil {
+		e.inputs = make(map[string]map[string]any)
+	}
+	e.calls = append(e.calls, capability)
+	e.inputs[capability] = input.(map[string]any)
+	if capability == e.fail {
+		return nil, errors.New("agent failed")
+	}
+	return fmt.Sprintf("%s@%s", capability, agentID), nil
+}
+
+func testCompositionResult() *tools.CompositionResult {
+	return &tools.CompositionResult{
+		CapabilityMap: map[string]string{
+			"fetch":     "crawler",
+			"translate": "translator",
+			"summarize": "writer",
+		},
+		Dependencies: map[string][]string{
+			"translate": {"fetch"},
+			"summarize": {"fetch", "translate"},
+		},
+		ExecutionOrder: []string{"fetch", "translate", "summarize"},
+		Complete:       true,
+	}
+}
+
+func TestCompositionPlanner_PlanWiresDependencies(t *testing.T) {
+	def, err := NewCompositionPlanner(CompositionPlannerConfig{}, nil).Plan(testCompositionResult())
+	require.NoError(t, err)
+	assert.Equal(t, "capability_composition", def.Name)
+	assert.Equal(t, CompositionInputNodeID, def.Entry)
+	require.Len(t, def.Nodes, 5)
+
+	nodes := make(map[string]core.NodeDefinition)
+	for _, n := range def.Nodes {
+		nodes[n.ID] = n
+	}
+	assert.ElementsMatch(t, []string{"capability_fetch", "capability_translate", "capability_summarize"}, nodes[CompositionInputNodeID].Next)
+	assert.ElementsMatch(t, []string{"capability_translate", "capability_summarize", CompositionOutputNodeID}, nodes["capability_fetch"].Next)
+
+	summarize := nodes["capability_summarize"]
+	assert.Equal(t, CompositionCapabilityStepName, summarize.Step)
+	assert.Equal(t, "writer", summarize.Metadata[CompositionMetaAgentID])
+	assert.Equal(t, []string{"fetch", "translate"}, summarize.Metadata[CompositionMetaInputFrom])
+	assert.Equal(t, "summarize", summarize.Metadata[CompositionMetaOutputKey])
+}
+
+func TestCompositionPlanner_BuildExecutesOnDAGEngine(t *testing.T) {
+	executor := &recordingAgentExecutor{}
+	wf, err := NewCompositionPlanner(CompositionPlannerConfig{}, nil).Build(testCompositionResult(), executor)
+	require.NoError(t, err)
+
+	out, err := wf.Execute(context.Background(), "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"fetch":     "fetch@crawler",
+		"translate": "translate@translator",
+		"summarize": "summarize@writer",
+	}, out)
+
+	assert.Equal(t, []string{"fetch", "translate", "summarize"}, executor.calls)
+	assert.Equal(t, map[string]any{"input": "https://example.com"}, executor.inputs["fetch"])
+	assert.Equal(t, map[string]any{
+		"input": "https://example.com",
+		"upstream": map[string]any{
+			"fetch":     "fetch@crawler",
+			"translate": "translate@translator",
+		},
+	}, executor.inputs["summarize"])
+}
+
+func TestCompositionPlanner_RoundTripThroughJSON(t *testing.T) {
+	def, err := NewCompositionPlanner(CompositionPlannerConfig{Name: "pipeline"}, nil).Plan(testCompositionResult())
+	require.NoError(t, err)
+	data, err := json.Marshal(def)
+	require.NoError(t, err)
+
+	var decoded core.DAGDefinition
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	executor := &recordingAgentExecutor{}
+	wf, err := BindCompositionDAG(&decoded, executor)
+	require.NoError(t, err)
+
+	out, err := wf.Execute(context.Background(), "doc")
+	require.NoError(t, err)
+	assert.Len(t, out, 3)
+	assert.Contains(t, executor.inputs["translate"], "upstream")
+}
+
+func TestCompositionPlanner_FailureStopsDownstream(t *testing.T) {
+	executor := &recordingAgentExecutor{fail: "translate"}
+	wf, err := NewCompositionPlanner(CompositionPlannerConfig{}, nil).Build(testCompositionResult(), executor)
+	require.NoError(t, err)
+
+	_, err = wf.Execute(context.Background(), "doc")
+	require.Error(t, err)
+	assert.NotContains(t, executor.calls, "summarize")
+}
+
+func TestCompositionPlanner_RejectsInvalidComposition(t *testing.T) {
+	planner := NewCompositionPlanner(CompositionPlannerConfig{}, nil)
+
+	_, err := planner.Plan(nil)
+	assert.Error(t, err)
+
+	incomplete := testCompositionResult()
+	incomplete.Complete = false
+	incomplete.MissingCapabilities = []string{"ocr"}
+	_, err = planner.Plan(incomplete)
+	assert.ErrorContains(t, err, "ocr")
+
+	unmapped := testCompositionResult()
+	delete(unmapped.CapabilityMap, "translate")
+	_, err = planner.Plan(unmapped)
+	assert.ErrorContains(t, err, "no agent mapped")
+
+	misordered := testCompositionResult()
+	misordered.ExecutionOrder = []string{"summarize", "fetch", "translate"}
+	_, err = planner.Plan(misordered)
+	assert.ErrorContains(t, err, "not ordered before")
+
+	_, err = planner.Build(testCompositionResult(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,200 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BaSui01/agentflow/workflow/core"
+)
+
+const defaultExternalPollInterval = 500 * time.Millisecond
+
+// errExternalAttempt 标记单次尝试失败（worker 失败、心跳超时、执行超时），可重试。
+var errExternalAttempt = errors.New("external activity attempt failed")
+
+// ExternalActivityStep 将任务发布到 ExternalTaskQueue，由进程外 worker 领取执行。
+// 步骤轮询任务状态直到完成；worker 上报失败、心跳超时或单次执行超时时按 MaxRetries 重新发布。
+type ExternalActivityStep struct {
+	id       string
+	TaskType string
+	// Timeout 整体超时（含全部重试），0 表示仅受上游 context 约束
+	Timeout             time.Duration
+	HeartbeatTimeout    time.Duration
+	StartToCloseTimeout time.Duration
+	MaxRetries          int
+	RetryBackoff        time.Duration
+	PollInterval        time.Duration
+	Queue               core.ExternalTaskQueue
+}
+
+// NewExternalActivityStep 创建外部活动步骤。
+func NewExternalActivityStep(id string, queue core.ExternalTaskQueue) *ExternalActivityStep {
+	return &ExternalActivityStep{
+		id:           id,
+		PollInterval: defaultExternalPollInterval,
+		Queue:        queue,
+	}
+}
+
+func (s *ExternalActivityStep) ID() string          { return s.id }
+func (s *ExternalActivityStep) Type() core.StepType { return core.StepTypeExternalActivity }
+
+func (s *ExternalActivityStep) Validate() error {
+	if s.Queue == nil {
+		return core.NewStepError(s.id, core.StepTypeExternalActivity, core.ErrStepNotConfigured)
+	}
+	if s.TaskType == "" {
+		return core.NewStepError(s.id, core.StepTypeExternalActivity, fmt.Errorf("%w: external activity task type is empty", core.ErrStepValidation))
+	}
+	if s.MaxRetries < 0 || s.Timeout < 0 || s.HeartbeatTimeout < 0 || s.StartToCloseTimeout < 0 {
+		return core.NewStepError(s.id, core.StepTypeExternalActivity, fmt.Errorf("%w: external activity timeouts and retries must not be negative", core.ErrStepValidation))
+	}
+	return nil
+}
+
+func (s *ExternalActivityStep) Execute(ctx context.Context, input core.StepInput) (core.StepOutput, error) {
+	if s.Queue == nil {
+		return core.StepOutput{}, core.NewStepError(s.id, core.StepTypeExternalActivity, core.ErrStepNotConfigured)
+	}
+
+	runCtx := ctx
+	var cancel context.CancelFunc
+	if s.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var lastErr error
+	for attempt := 0; attempt <= s.MaxRetries; attempt++ {
+		if attempt > 0 && s.RetryBackoff > 0 {
+			timer := time.NewTimer(s.RetryBackoff)
+			select {
+			case <-runCtx.Done():
+				timer.Stop()
+				return core.StepOutput{}, core.NewStepError(s.id, core.StepTypeExternalActivity, core.ErrStepTimeout)
+			case <-timer.C:
+			}
+		}
+
+		task, err := s.runAttempt(runCtx, input, attempt)
+		if err == nil {
+			return core.StepOutput{
+				Data: map[string]any{
+					"result":    task.Result,
+					"task_id":   task.ID,
+					"worker_id": task.WorkerID,
+					"attempts":  attempt + 1,
+				},
+				Latency: time.Since(start),
+			}, nil
+		}
+		if runCtx.Err() != nil {
+			return core.StepOutput{}, core.NewStepError(s.id, core.StepTypeExternalActivity, core.ErrStepTimeout)
+		}
+		if !errors.Is(err, errExternalAttempt) {
+			return core.StepOutput{}, core.NewStepError(s.id, core.StepTypeExternalActivity, fmt.Errorf("%w: %w", core.ErrStepExecution, err))
+		}
+		lastErr = err
+	}
+
+	return core.StepOutput{}, core.NewStepError(s.id, core.StepTypeExternalActivity,
+		fmt.Errorf("%w: %d attempts exhausted: %w", core.ErrStepExecution, s.MaxRetries+1, lastErr))
+}
+
+// runAttempt 发布一次任务并等待其结束。
+func (s *ExternalActivityStep) runAttempt(ctx context.Context, input core.StepInput, attempt int) (*core.ExternalTask, error) {
+	taskID, err := s.Queue.Publish(ctx, &core.ExternalTask{
+		TaskType:            s.TaskType,
+		StepID:              s.id,
+		Input:               externalTaskInput(input),
+		Attempt:             attempt,
+		MaxAttempts:         s.MaxRetries + 1,
+		HeartbeatTimeout:    s.HeartbeatTimeout,
+		StartToCloseTimeout: s.StartToCloseTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("publish external task: %w", err)
+	}
+
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = defaultExternalPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		task, err := s.Queue.Get(ctx, taskID)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.abort(ctx, taskID)
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("get external task %s: %w", taskID, err)
+		}
+
+		switch task.Status {
+		case core.ExternalTaskCompleted:
+			return task, nil
+		case core.ExternalTaskFailed, core.ExternalTaskCancelled, core.ExternalTaskTimeout:
+			return nil, fmt.Errorf("%w: task %s %s: %s", errExternalAttempt, taskID, task.Status, task.Error)
+		case core.ExternalTaskRunning:
+			if reason := s.expired(task, time.Now()); reason != "" {
+				if err := s.Queue.Abort(context.WithoutCancel(ctx), taskID, core.ExternalTaskTimeout, reason); err != nil {
+					return nil, fmt.Errorf("abort external task %s: %w", taskID, err)
+				}
+				return nil, fmt.Errorf("%w: task %s %s", errExternalAttempt, taskID, reason)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			s.abort(ctx, taskID)
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// expired 检查运行中任务是否心跳超时或单次执行超时。
+func (s *ExternalActivityStep) expired(task *core.ExternalTask, now time.Time) string {
+	if task.StartedAt == nil {
+		return ""
+	}
+	if s.StartToCloseTimeout > 0 && now.Sub(*task.StartedAt) > s.StartToCloseTimeout {
+		return fmt.Sprintf("start-to-close timeout after %s", s.StartToCloseTimeout)
+	}
+	if s.HeartbeatTimeout > 0 {
+		last := *task.StartedAt
+		if task.LastHeartbeatAt != nil && task.LastHeartbeatAt.After(last) {
+			last = *task.LastHeartbeatAt
+		}
+		if now.Sub(last) > s.HeartbeatTimeout {
+			return fmt.Sprintf("heartbeat timeout after %s", s.HeartbeatTimeout)
+		}
+	}
+	return ""
+}
+
+// abort 在步骤被取消或整体超时时终止未结束的任务，使迟到的 worker 结果被拒绝。
+func (s *ExternalActivityStep) abort(ctx context.Context, taskID string) {
+	status := core.ExternalTaskCancelled
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		status = core.ExternalTaskTimeout
+	}
+	_ = s.Queue.Abort(context.WithoutCancel(ctx), taskID, status, ctx.Err().Error())
+}
+
+func externalTaskInput(input core.StepInput) map[string]any {
+	data := make(map[string]any, len(input.Data)+1)
+	for k, v := range input.Data {
+		data[k] = v
+	}
+	if len(input.Metadata) > 0 {
+		data["metadata"] = input.Metadata
+	}
+	return data
+}
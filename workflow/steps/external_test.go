@@ -0,0 +1,203 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaSui01/agentflow/workflow/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExternalQueue 在内存中模拟外部任务队列，worker 回调在发布后异步运行。
+type fakeExternalQueue struct {
+	mu     sync.Mutex
+	tasks  map[string]*core.ExternalTask
+	worker func(q *fakeExternalQueue, task *core.ExternalTask)
+}
+
+func newFakeExternalQueue(worker func(q *fakeExternalQueue, task *core.ExternalTask)) *fakeExternalQueue {
+	return &fakeExternalQueue{tasks: make(map[string]*core.ExternalTask), worker: worker}
+}
+
+func (q *fakeExternalQueue) Publish(_ context.Context, task *core.ExternalTask) (string, error) {
+	q.mu.Lock()
+	stored := *task
+	stored.ID = fmt.Sprintf("task-%d", len(q.tasks))
+	stored.Status = core.ExternalTaskPending
+	q.tasks[stored.ID] = &stored
+	q.mu.Unlock()
+	if q.worker != nil {
+		go q.worker(q, &stored)
+	}
+	return stored.ID, nil
+}
+
+func (q *fakeExternalQueue) Get(_ context.Context, taskID string) (*core.ExternalTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	task, ok := q.tasks[taskID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	copied := *task
+	return &copied, nil
+}
+
+func (q *fakeExternalQueue) Abort(_ context.Context, taskID string, status core.ExternalTaskStatus, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if task := q.tasks[taskID]; task != nil && !task.Status.IsTerminal() {
+		task.Status = status
+		task.Error = reason
+	}
+	return nil
+}
+
+func (q *fakeExternalQueue) update(taskID string, fn func(task *core.ExternalTask)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fn(q.tasks[taskID])
+}
+
+func (q *fakeExternalQueue) status(taskID string) core.ExternalTaskStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.tasks[taskID].Status
+}
+
+func claim(task *core.ExternalTask) {
+	now := time.Now()
+	task.Status = core.ExternalTaskRunning
+	task.WorkerID = "py-worker"
+	task.StartedAt = &now
+	task.LastHeartbeatAt = &now
+}
+
+func newTestExternalStep(queue core.ExternalTaskQueue) *ExternalActivityStep {
+	step := NewExternalActivityStep("resize", queue)
+	step.TaskType = "image.resize"
+	step.PollInterval = 5 * time.Millisecond
+	return step
+}
+
+func TestExternalActivityStep_Validate(t *testing.T) {
+	require.ErrorIs(t, NewExternalActivityStep("s", nil).Validate(), core.ErrStepNotConfigured)
+	require.ErrorIs(t, NewExternalActivityStep("s", newFakeExternalQueue(nil)).Validate(), core.ErrStepValidation)
+
+	step := newTestExternalStep(newFakeExternalQueue(nil))
+	require.NoError(t, step.Validate())
+	step.MaxRetries = -1
+	require.ErrorIs(t, step.Validate(), core.ErrStepValidation)
+}
+
+func TestExternalActivityStep_Completes(t *testing.T) {
+	queue := newFakeExternalQueue(func(q *fakeExternalQueue, task *core.ExternalTask) {
+		q.update(task.ID, func(task *core.ExternalTask) {
+			claim(task)
+			task.Status = core.ExternalTaskCompleted
+			task.Result = map[string]any{"width": 128, "source": task.Input["url"]}
+		})
+	})
+	step := newTestExternalStep(queue)
+
+	out, err := step.Execute(context.Background(), core.StepInput{Data: map[string]any{"url": "s3://img.png"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"width": 128, "source": "s3://img.png"}, out.Data["result"])
+	assert.Equal(t, "py-worker", out.Data["worker_id"])
+	assert.Equal(t, 1, out.Data["attempts"])
+
+	task, err := queue.Get(context.Background(), "task-0")
+	require.NoError(t, err)
+	assert.Equal(t, "image.resize", task.TaskType)
+	assert.Equal(t, "resize", task.StepID)
+}
+
+func TestExternalActivityStep_RetriesFailedAttempts(t *testing.T) {
+	queue := newFakeExternalQueue(func(q *fakeExternalQueue, task *core.ExternalTask) {
+		q.update(task.ID, func(task *core.ExternalTask) {
+			claim(task)
+			if task.Attempt < 2 {
+				task.Status = core.ExternalTaskFailed
+				task.Error = "transient"
+				return
+			}
+			task.Status = core.ExternalTaskCompleted
+			task.Result = "ok"
+		})
+	})
+	step := newTestExternalStep(queue)
+	step.MaxRetries = 2
+
+	out, err := step.Execute(context.Background(), core.StepInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out.Data["result"])
+	assert.Equal(t, 3, out.Data["attempts"])
+
+	step.MaxRetries = 1
+	_, err = step.Execute(context.Background(), core.StepInput{})
+	require.ErrorIs(t, err, core.ErrStepExecution)
+	assert.Contains(t, err.Error(), "2 attempts exhausted")
+	assert.Contains(t, err.Error(), "transient")
+}
+
+func TestExternalActivityStep_HeartbeatTimeout(t *testing.T) {
+	queue := newFakeExternalQueue(func(q *fakeExternalQueue, task *core.ExternalTask) {
+		q.update(task.ID, func(task *core.ExternalTask) {
+			claim(task)
+			// 第二次尝试的 worker 正常完成；第一次尝试的 worker 领取后失联
+			if task.Attempt == 1 {
+				task.Status = core.ExternalTaskCompleted
+				task.Result = "recovered"
+			}
+		})
+	})
+	step := newTestExternalStep(queue)
+	step.HeartbeatTimeout = 30 * time.Millisecond
+	step.MaxRetries = 1
+
+	out, err := step.Execute(context.Background(), core.StepInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "recovered", out.Data["result"])
+	assert.Equal(t, core.ExternalTaskTimeout, queue.status("task-0"), "the lost attempt is timed out so late results are rejected")
+
+	task, err := queue.Get(context.Background(), "task-0")
+	require.NoError(t, err)
+	assert.Contains(t, task.Error, "heartbeat timeout")
+}
+
+func TestExternalActivityStep_StartToCloseTimeout(t *testing.T) {
+	queue := newFakeExternalQueue(func(q *fakeExternalQueue, task *core.ExternalTask) {
+		q.update(task.ID, claim)
+		// 持续心跳但始终不完成
+		for q.status(task.ID) == core.ExternalTaskRunning {
+			q.update(task.ID, func(task *core.ExternalTask) {
+				now := time.Now()
+				task.LastHeartbeatAt = &now
+			})
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+	step := newTestExternalStep(queue)
+	step.HeartbeatTimeout = time.Second
+	step.StartToCloseTimeout = 40 * time.Millisecond
+
+	_, err := step.Execute(context.Background(), core.StepInput{})
+	require.ErrorIs(t, err, core.ErrStepExecution)
+	assert.Contains(t, err.Error(), "start-to-close timeout")
+	assert.Equal(t, core.ExternalTaskTimeout, queue.status("task-0"))
+}
+
+func TestExternalActivityStep_OverallTimeout(t *testing.T) {
+	queue := newFakeExternalQueue(nil)
+	step := newTestExternalStep(queue)
+	step.Timeout = 30 * time.Millisecond
+
+	_, err := step.Execute(context.Background(), core.StepInput{})
+	require.ErrorIs(t, err, core.ErrStepTimeout)
+	assert.Equal(t, core.ExternalTaskTimeout, queue.status("task-0"), "the pending task is withdrawn")
+}